@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ResurgenceIT/kit/v6/requestid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+EchoErrorHandlerConfig configures NewEchoErrorHandler. Mappers are
+passed to FromError. Logger, when set, logs problems with a status of
+500 or more along with their cause. Localize, when set, can translate a
+problem for the request before it is written
+*/
+type EchoErrorHandlerConfig struct {
+	Localize func(ctx echo.Context, problem *Problem) *Problem
+	Logger   logrus.FieldLogger
+	Mappers  []Mapper
+}
+
+/*
+NewEchoErrorHandler returns an Echo error handler that renders errors
+as application/problem+json. Instance is set to the request path, and
+RequestID to the request's ID, which is also logged.
+
+	e.HTTPErrorHandler = apierror.NewEchoErrorHandler(apierror.EchoErrorHandlerConfig{
+		Logger: logger,
+	})
+*/
+func NewEchoErrorHandler(config EchoErrorHandlerConfig) echo.HTTPErrorHandler {
+	return func(err error, ctx echo.Context) {
+		if ctx.Response().Committed {
+			return
+		}
+
+		problem := FromError(err, config.Mappers...)
+
+		if problem.Instance == "" {
+			problem = problem.WithInstance(ctx.Request().URL.Path)
+		}
+
+		if problem.Status >= http.StatusInternalServerError && config.Logger != nil {
+			requestid.Logger(ctx.Request().Context(), config.Logger).WithError(err).WithField("path", ctx.Request().URL.Path).Error("request failed")
+		}
+
+		if config.Localize != nil {
+			problem = config.Localize(ctx, problem)
+		}
+
+		_ = Write(ctx.Response(), ctx.Request(), problem)
+	}
+}
+
+/*
+Write writes a problem as application/problem+json. HEAD requests get
+the status without a body. RequestID is filled in from the request's
+context when it isn't set
+*/
+func Write(w http.ResponseWriter, r *http.Request, problem *Problem) error {
+	if id := requestid.FromContext(r.Context()); id != "" && problem.RequestID == "" {
+		result := *problem
+		result.RequestID = id
+		problem = &result
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(problem.Status)
+
+	if r.Method == http.MethodHead {
+		return nil
+	}
+
+	return json.NewEncoder(w).Encode(problem)
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ResurgenceIT/kit/v6/errs"
+	"github.com/ResurgenceIT/kit/v6/identity"
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+	"github.com/ResurgenceIT/kit/v6/validate"
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+)
+
+/*
+Mapper turns an error into a problem. It returns nil for errors it
+doesn't know
+*/
+type Mapper func(err error) *Problem
+
+/*
+KindStatuses maps each errs.Kind to the status of the problem it
+becomes
+*/
+var KindStatuses = map[errs.Kind]int{
+	errs.KindUnknown:      http.StatusInternalServerError,
+	errs.KindInvalid:      http.StatusBadRequest,
+	errs.KindUnauthorized: http.StatusUnauthorized,
+	errs.KindForbidden:    http.StatusForbidden,
+	errs.KindNotFound:     http.StatusNotFound,
+	errs.KindConflict:     http.StatusConflict,
+	errs.KindRateLimited:  http.StatusTooManyRequests,
+	errs.KindInternal:     http.StatusInternalServerError,
+	errs.KindUnavailable:  http.StatusServiceUnavailable,
+	errs.KindTimeout:      http.StatusGatewayTimeout,
+}
+
+/*
+FromError turns any error into a problem. Mappers are tried first, in
+order, then the built in mappings:
+
+  - a *Problem anywhere in the chain is used as is
+  - validate.ValidationErrors become a 422 listing the fields
+  - an *errs.Error gets the status for its kind from KindStatuses, its
+    code, and its message as the detail
+  - identity token errors and expired JWTs become a 401
+  - sqldatabase.ErrConflict, from optimistic locking, becomes a 409
+  - *echo.HTTPError keeps its status and message
+
+Anything else is a 500 that doesn't reveal the error
+*/
+func FromError(err error, mappers ...Mapper) *Problem {
+	var (
+		problem          *Problem
+		validationErrors validate.ValidationErrors
+		classified       *errs.Error
+		jwtError         *jwt.ValidationError
+		httpError        *echo.HTTPError
+	)
+
+	for _, mapper := range mappers {
+		if problem = mapper(err); problem != nil {
+			return problem
+		}
+	}
+
+	switch {
+	case errors.As(err, &problem):
+		return problem
+
+	case errors.As(err, &validationErrors):
+		return Validation(validationErrors).Wrap(err)
+
+	case errors.As(err, &classified):
+		return fromClassified(classified.Kind, classified.Code, classified.Message, err)
+
+	case errors.As(err, &jwtError) && jwtError.Errors&jwt.ValidationErrorExpired != 0:
+		return New(http.StatusUnauthorized, "token-expired", "").WithDetail("The token has expired").Wrap(err)
+
+	case errors.Is(err, identity.ErrInvalidToken), errors.Is(err, identity.ErrTokenMissingClaims),
+		errors.Is(err, identity.ErrInvalidIssuer), errors.As(err, &jwtError):
+		return New(http.StatusUnauthorized, "invalid-token", "").WithDetail("The token is invalid").Wrap(err)
+
+	case errors.Is(err, sqldatabase.ErrConflict):
+		return New(http.StatusConflict, "version-conflict", "").WithDetail("The record was changed by someone else. Reload it and try again").Wrap(err)
+
+	case errors.As(err, &httpError):
+		return fromHTTPError(httpError)
+	}
+
+	return Internal(err)
+}
+
+/*
+TableMapper returns a mapper that renders errors matched by an errs.Table
+as problems, so sentinel errors from other packages get a proper status
+and code
+
+	Mappers: []apierror.Mapper{apierror.TableMapper(errs.DefaultTable)},
+*/
+func TableMapper(table errs.Table) Mapper {
+	return func(err error) *Problem {
+		if mapping, ok := table.Lookup(err); ok {
+			return fromClassified(mapping.Kind, mapping.Code, mapping.Message, err)
+		}
+
+		return nil
+	}
+}
+
+/*
+fromClassified creates a problem for an error of a given kind. Problems
+with a status of 500 or more don't include the message, as with Internal
+*/
+func fromClassified(kind errs.Kind, code, message string, err error) *Problem {
+	status, ok := KindStatuses[kind]
+
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+
+	if status == http.StatusInternalServerError {
+		return Internal(err)
+	}
+
+	if status > http.StatusInternalServerError {
+		return New(status, codeForStatus(status), "").Wrap(err)
+	}
+
+	if code == "" {
+		code = kind.String()
+	}
+
+	return New(status, code, "").WithDetail(message).Wrap(err)
+}
+
+func fromHTTPError(httpError *echo.HTTPError) *Problem {
+	result := New(httpError.Code, codeForStatus(httpError.Code), "")
+
+	if message := fmt.Sprint(httpError.Message); message != "" && message != http.StatusText(httpError.Code) {
+		result.Detail = message
+	}
+
+	if httpError.Internal != nil {
+		result.cause = httpError.Internal
+	}
+
+	return result
+}
+
+/*
+codeForStatus turns a status into a code, such as "method-not-allowed"
+for 405
+*/
+func codeForStatus(status int) string {
+	text := http.StatusText(status)
+
+	if text == "" {
+		return "error"
+	}
+
+	return strings.ReplaceAll(strings.ToLower(text), " ", "-")
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ResurgenceIT/kit/v6/validate"
+)
+
+/*
+ContentType is the media type of problem responses
+*/
+const ContentType = "application/problem+json"
+
+/*
+Problem is an API error in the RFC 7807 problem details format. Status
+is the HTTP status code, Code a short machine readable code such as
+"order-not-found", Title a summary that is the same for every
+occurrence of the problem, and Detail an explanation of this
+occurrence. Errors lists the fields that failed validation. RequestID
+is the ID the requestid middleware gave the request, so a user reporting
+a problem can quote it.
+
+Problem is an error, so handlers can return one and have the error
+handler render it
+*/
+type Problem struct {
+	Code      string                `json:"code"`
+	Detail    string                `json:"detail,omitempty"`
+	Errors    []validate.FieldError `json:"errors,omitempty"`
+	Instance  string                `json:"instance,omitempty"`
+	RequestID string                `json:"requestID,omitempty"`
+	Status    int                   `json:"status"`
+	Title     string                `json:"title"`
+	Type      string                `json:"type"`
+
+	cause error
+}
+
+/*
+New creates a problem. Title defaults to the status text
+*/
+func New(status int, code, title string) *Problem {
+	if title == "" {
+		title = http.StatusText(status)
+	}
+
+	return &Problem{
+		Code:   code,
+		Status: status,
+		Title:  title,
+		Type:   "about:blank",
+	}
+}
+
+/*
+BadRequest is a 400 problem with the provided detail
+*/
+func BadRequest(detail string) *Problem {
+	return New(http.StatusBadRequest, "bad-request", "").WithDetail(detail)
+}
+
+/*
+Unauthorized is a 401 problem
+*/
+func Unauthorized(detail string) *Problem {
+	return New(http.StatusUnauthorized, "unauthorized", "").WithDetail(detail)
+}
+
+/*
+Forbidden is a 403 problem
+*/
+func Forbidden(detail string) *Problem {
+	return New(http.StatusForbidden, "forbidden", "").WithDetail(detail)
+}
+
+/*
+NotFound is a 404 problem
+*/
+func NotFound(detail string) *Problem {
+	return New(http.StatusNotFound, "not-found", "").WithDetail(detail)
+}
+
+/*
+Conflict is a 409 problem
+*/
+func Conflict(detail string) *Problem {
+	return New(http.StatusConflict, "conflict", "").WithDetail(detail)
+}
+
+/*
+Validation is a 422 problem listing the fields that failed validation
+*/
+func Validation(fieldErrors []validate.FieldError) *Problem {
+	result := New(http.StatusUnprocessableEntity, "validation-failed", "Validation Failed")
+	result.Detail = "One or more fields are invalid"
+	result.Errors = fieldErrors
+
+	return result
+}
+
+/*
+Internal is a 500 problem. The cause is kept for logging but never sent
+to the client
+*/
+func Internal(cause error) *Problem {
+	result := New(http.StatusInternalServerError, "internal-error", "")
+	result.cause = cause
+
+	return result
+}
+
+/*
+WithDetail returns a copy of the problem with Detail set
+*/
+func (p *Problem) WithDetail(detail string) *Problem {
+	result := *p
+	result.Detail = detail
+
+	return &result
+}
+
+/*
+WithInstance returns a copy of the problem with Instance set to a URI
+for this occurrence, such as the request path
+*/
+func (p *Problem) WithInstance(instance string) *Problem {
+	result := *p
+	result.Instance = instance
+
+	return &result
+}
+
+/*
+WithType returns a copy of the problem with Type set to a URI that
+documents it
+*/
+func (p *Problem) WithType(uri string) *Problem {
+	result := *p
+	result.Type = uri
+
+	return &result
+}
+
+/*
+Wrap returns a copy of the problem that keeps err as its cause, for
+logging and errors.Is
+*/
+func (p *Problem) Wrap(err error) *Problem {
+	result := *p
+	result.cause = err
+
+	return &result
+}
+
+func (p *Problem) Error() string {
+	message := fmt.Sprintf("%d %s", p.Status, p.Code)
+
+	if p.Detail != "" {
+		message += ": " + p.Detail
+	}
+
+	if p.cause != nil {
+		message += ": " + p.cause.Error()
+	}
+
+	return message
+}
+
+/*
+Unwrap returns the problem's cause
+*/
+func (p *Problem) Unwrap() error {
+	return p.cause
+}
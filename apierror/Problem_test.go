@@ -0,0 +1,125 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package apierror_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/apierror"
+	"github.com/ResurgenceIT/kit/v6/errs"
+	"github.com/ResurgenceIT/kit/v6/identity"
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+	"github.com/ResurgenceIT/kit/v6/validate"
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+)
+
+var errOrderNotFound = errors.New("order not found")
+
+func TestFromError(t *testing.T) {
+	mapper := func(err error) *apierror.Problem {
+		if errors.Is(err, errOrderNotFound) {
+			return apierror.New(http.StatusNotFound, "order-not-found", "Order Not Found")
+		}
+
+		return nil
+	}
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{name: "Problem", err: fmt.Errorf("wrapped: %w", apierror.Conflict("already exists")), status: http.StatusConflict, code: "conflict"},
+		{name: "Mapper", err: fmt.Errorf("error loading: %w", errOrderNotFound), status: http.StatusNotFound, code: "order-not-found"},
+		{name: "Validation", err: validate.ValidationErrors{{Field: "email", Rule: "email"}}, status: http.StatusUnprocessableEntity, code: "validation-failed"},
+		{name: "Invalid token", err: fmt.Errorf("parse: %w", identity.ErrInvalidIssuer), status: http.StatusUnauthorized, code: "invalid-token"},
+		{name: "Expired token", err: fmt.Errorf("parse: %w", &jwt.ValidationError{Errors: jwt.ValidationErrorExpired}), status: http.StatusUnauthorized, code: "token-expired"},
+		{name: "Version conflict", err: fmt.Errorf("error saving: %w", &sqldatabase.ConflictError{Table: "orders"}), status: http.StatusConflict, code: "version-conflict"},
+		{name: "Echo error", err: echo.NewHTTPError(http.StatusMethodNotAllowed), status: http.StatusMethodNotAllowed, code: "method-not-allowed"},
+		{name: "Classified", err: fmt.Errorf("loading: %w", errs.NotFound("order-not-found", "No such order")), status: http.StatusNotFound, code: "order-not-found"},
+		{name: "Classified internal", err: errs.Internal(errors.New("disk full")), status: http.StatusInternalServerError, code: "internal-error"},
+		{name: "Classified unavailable", err: errs.Unavailable("", "Payments are down"), status: http.StatusServiceUnavailable, code: "service-unavailable"},
+		{name: "Table", err: fmt.Errorf("scan: %w", sql.ErrNoRows), status: http.StatusNotFound, code: "not-found"},
+		{name: "Unknown", err: errors.New("connection refused"), status: http.StatusInternalServerError, code: "internal-error"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problem := apierror.FromError(test.err, mapper, apierror.TableMapper(errs.DefaultTable))
+
+			if problem.Status != test.status || problem.Code != test.code {
+				t.Errorf("Expected %d %s but got %d %s", test.status, test.code, problem.Status, problem.Code)
+			}
+
+			if problem.Status >= http.StatusInternalServerError && problem.Detail != "" {
+				t.Errorf("Expected no detail for a %d but got '%s'", problem.Status, problem.Detail)
+			}
+		})
+	}
+}
+
+func TestEchoErrorHandler(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = apierror.NewEchoErrorHandler(apierror.EchoErrorHandlerConfig{})
+
+	e.POST("/users", func(ctx echo.Context) error {
+		return validate.ValidationErrors{{Field: "email", Message: "must be a valid email address", Rule: "email"}}
+	})
+
+	e.GET("/fail", func(ctx echo.Context) error {
+		return errors.New("password=hunter2 rejected by database")
+	})
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		check  func(t *testing.T, body map[string]interface{})
+	}{
+		{method: http.MethodPost, path: "/users", status: http.StatusUnprocessableEntity, check: func(t *testing.T, body map[string]interface{}) {
+			fieldErrors, _ := body["errors"].([]interface{})
+
+			if body["instance"] != "/users" || len(fieldErrors) != 1 || fieldErrors[0].(map[string]interface{})["field"] != "email" {
+				t.Errorf("Unexpected problem %v", body)
+			}
+		}},
+		{method: http.MethodGet, path: "/fail", status: http.StatusInternalServerError, check: func(t *testing.T, body map[string]interface{}) {
+			if body["detail"] != nil || body["title"] != "Internal Server Error" {
+				t.Errorf("Expected the cause to be hidden but got %v", body)
+			}
+		}},
+		{method: http.MethodGet, path: "/missing", status: http.StatusNotFound, check: func(t *testing.T, body map[string]interface{}) {
+			if body["code"] != "not-found" || body["type"] != "about:blank" {
+				t.Errorf("Unexpected problem %v", body)
+			}
+		}},
+	}
+
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		e.ServeHTTP(recorder, httptest.NewRequest(test.method, test.path, nil))
+
+		if recorder.Code != test.status || recorder.Header().Get("Content-Type") != apierror.ContentType {
+			t.Errorf("Expected %d problem+json for %s but got %d %s", test.status, test.path, recorder.Code, recorder.Header().Get("Content-Type"))
+			continue
+		}
+
+		body := map[string]interface{}{}
+
+		if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Expected JSON but got '%s'", recorder.Body.String())
+		}
+
+		test.check(t, body)
+	}
+}
@@ -0,0 +1,297 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/retry"
+)
+
+var (
+	ErrClosed  = errors.New("batcher is closed")
+	ErrNoFlush = errors.New("a flush function is required")
+)
+
+/*
+Stats counts what a Batcher has done. Dropped counts items in batches
+that failed every retry
+*/
+type Stats struct {
+	Added   uint64 `json:"added"`
+	Batches uint64 `json:"batches"`
+	Dropped uint64 `json:"dropped"`
+	Failed  uint64 `json:"failed"`
+	Pending int    `json:"pending"`
+	Retries uint64 `json:"retries"`
+}
+
+/*
+Batcher collects items and flushes them in batches, trading a little
+latency for far fewer round trips, such as for bulk database writes or
+shipping metrics
+*/
+type Batcher struct {
+	sync.RWMutex
+
+	cancel     context.CancelFunc
+	closed     bool
+	config     BatcherConfig
+	ctx        context.Context
+	done       chan struct{}
+	items      chan interface{}
+	retrier    *retry.Retrier
+	slots      chan struct{}
+	stats      Stats
+	statsMutex sync.Mutex
+	wg         sync.WaitGroup
+}
+
+/*
+NewBatcher creates a batcher and starts collecting. Call Close to flush
+what is left when shutting down
+*/
+func NewBatcher(config BatcherConfig) (*Batcher, error) {
+	if config.Flush == nil {
+		return nil, ErrNoFlush
+	}
+
+	if config.Size <= 0 {
+		config.Size = 100
+	}
+
+	if config.BufferSize <= 0 {
+		config.BufferSize = config.Size * 10
+	}
+
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+
+	if config.MaxLatency <= 0 {
+		config.MaxLatency = time.Second
+	}
+
+	if config.Name == "" {
+		config.Name = "batch"
+	}
+
+	onRetry := config.Retry.OnRetry
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	result := &Batcher{
+		cancel: cancel,
+		config: config,
+		ctx:    ctx,
+		done:   make(chan struct{}),
+		items:  make(chan interface{}, config.BufferSize),
+		slots:  make(chan struct{}, config.Concurrency),
+	}
+
+	config.Retry.OnRetry = func(attempt int, err error, wait time.Duration) {
+		result.count(func(stats *Stats) { stats.Retries++ })
+
+		if onRetry != nil {
+			onRetry(attempt, err, wait)
+		}
+	}
+
+	result.retrier = retry.NewRetrier(config.Retry)
+
+	go result.collect()
+	return result, nil
+}
+
+/*
+Add queues an item for the next batch. It waits while the buffer is
+full, returning ctx's error if ctx is done first, and returns ErrClosed
+once Close has been called
+*/
+func (b *Batcher) Add(ctx context.Context, item interface{}) error {
+	b.RLock()
+	defer b.RUnlock()
+
+	if b.closed {
+		return ErrClosed
+	}
+
+	select {
+	case b.items <- item:
+		b.count(func(stats *Stats) { stats.Added++ })
+		return nil
+
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/*
+Close stops accepting items, flushes what has been collected, and waits
+for every flush to finish. If ctx is done first, flushes still retrying
+are canceled and ctx's error is returned
+*/
+func (b *Batcher) Close(ctx context.Context) error {
+	b.Lock()
+
+	if !b.closed {
+		b.closed = true
+		close(b.items)
+	}
+
+	b.Unlock()
+
+	select {
+	case <-b.done:
+		return nil
+
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+}
+
+/*
+Stats returns the batcher's counts
+*/
+func (b *Batcher) Stats() Stats {
+	b.statsMutex.Lock()
+	defer b.statsMutex.Unlock()
+
+	result := b.stats
+	result.Pending = len(b.items)
+
+	return result
+}
+
+/*
+Name returns the name the batcher's stats are reported under
+*/
+func (b *Batcher) Name() string {
+	return b.config.Name
+}
+
+/*
+Collect returns the batcher's stats in the form serverstats collectors
+report, so a Batcher can be passed to RegisterCollector
+*/
+func (b *Batcher) Collect(ctx context.Context) map[string]interface{} {
+	stats := b.Stats()
+
+	return map[string]interface{}{
+		"added":   stats.Added,
+		"batches": stats.Batches,
+		"dropped": stats.Dropped,
+		"failed":  stats.Failed,
+		"pending": stats.Pending,
+		"retries": stats.Retries,
+	}
+}
+
+/*
+collect gathers items into batches until the items channel is closed,
+then flushes the last batch and waits for running flushes
+*/
+func (b *Batcher) collect() {
+	var (
+		timeout <-chan time.Time
+		timer   *time.Timer
+	)
+
+	batch := make([]interface{}, 0, b.config.Size)
+
+	flush := func() {
+		if timer != nil {
+			timer.Stop()
+			timer, timeout = nil, nil
+		}
+
+		if len(batch) > 0 {
+			b.flush(batch)
+			batch = make([]interface{}, 0, b.config.Size)
+		}
+	}
+
+	defer func() {
+		flush()
+		b.wg.Wait()
+		b.cancel()
+		close(b.done)
+	}()
+
+	for {
+		select {
+		case item, ok := <-b.items:
+			if !ok {
+				return
+			}
+
+			batch = append(batch, item)
+
+			if len(batch) == 1 {
+				timer = time.NewTimer(b.config.MaxLatency)
+				timeout = timer.C
+			}
+
+			if len(batch) >= b.config.Size {
+				flush()
+			}
+
+		case <-timeout:
+			timer, timeout = nil, nil
+			flush()
+		}
+	}
+}
+
+/*
+flush waits for a free slot, then writes items on its own goroutine.
+Waiting here holds up collecting, so when flushes fall behind the
+buffer fills and Add waits
+*/
+func (b *Batcher) flush(items []interface{}) {
+	b.slots <- struct{}{}
+	b.wg.Add(1)
+
+	go func() {
+		defer func() {
+			<-b.slots
+			b.wg.Done()
+		}()
+
+		err := b.retrier.Do(b.ctx, func(ctx context.Context) (err error) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					err = retry.Permanent(fmt.Errorf("batch flush panicked: %v", recovered))
+				}
+			}()
+
+			return b.config.Flush(ctx, items)
+		})
+
+		b.count(func(stats *Stats) {
+			stats.Batches++
+
+			if err != nil {
+				stats.Dropped += uint64(len(items))
+				stats.Failed++
+			}
+		})
+
+		if err != nil && b.config.OnError != nil {
+			b.config.OnError(items, err)
+		}
+	}()
+}
+
+func (b *Batcher) count(fn func(stats *Stats)) {
+	b.statsMutex.Lock()
+	fn(&b.stats)
+	b.statsMutex.Unlock()
+}
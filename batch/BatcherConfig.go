@@ -0,0 +1,42 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package batch
+
+import (
+	"context"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/retry"
+)
+
+/*
+FlushFunc writes out a batch, such as with one bulk insert
+*/
+type FlushFunc func(ctx context.Context, items []interface{}) error
+
+/*
+BatcherConfig configures a Batcher.
+
+Flush is called with a batch once it holds Size items (default 100), or
+once its oldest item has waited MaxLatency (default 1 second). At most
+Concurrency flushes (default 1) run at once. BufferSize is how many
+items can wait while flushes are running, and defaults to 10 times
+Size; when it is full Add waits.
+
+Failed flushes are retried with Retry's backoff, which defaults to the
+retry package's defaults. When a batch still fails it is dropped and
+OnError is called with its items. Name identifies the batcher's stats
+in serverstats, and defaults to "batch"
+*/
+type BatcherConfig struct {
+	BufferSize  int
+	Concurrency int
+	Flush       FlushFunc
+	MaxLatency  time.Duration
+	Name        string
+	OnError     func(items []interface{}, err error)
+	Retry       retry.RetrierConfig
+	Size        int
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package batch_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/batch"
+	"github.com/ResurgenceIT/kit/v6/retry"
+)
+
+type recorder struct {
+	sync.Mutex
+
+	batches  [][]interface{}
+	failures int
+}
+
+func (r *recorder) flush(ctx context.Context, items []interface{}) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.failures > 0 {
+		r.failures--
+		return errors.New("database unavailable")
+	}
+
+	r.batches = append(r.batches, items)
+	return nil
+}
+
+func (r *recorder) sizes() string {
+	r.Lock()
+	defer r.Unlock()
+
+	sizes := make([]int, 0, len(r.batches))
+
+	for _, items := range r.batches {
+		sizes = append(sizes, len(items))
+	}
+
+	return fmt.Sprint(sizes)
+}
+
+func TestBatcher(t *testing.T) {
+	r := &recorder{failures: 1}
+	ctx := context.Background()
+
+	batcher, err := batch.NewBatcher(batch.BatcherConfig{
+		Flush:      r.flush,
+		MaxLatency: time.Millisecond * 30,
+		Retry:      retry.RetrierConfig{InitialInterval: time.Millisecond, Jitter: -1},
+		Size:       3,
+	})
+
+	if err != nil {
+		t.Fatalf("Expected a batcher but got %s", err.Error())
+	}
+
+	for index := 0; index < 7; index++ {
+		_ = batcher.Add(ctx, index)
+	}
+
+	time.Sleep(time.Millisecond * 80)
+
+	if sizes := r.sizes(); sizes != "[3 3 1]" {
+		t.Errorf("Expected batches of 3, 3, and 1 but got %s", sizes)
+	}
+
+	_ = batcher.Add(ctx, 7)
+	_ = batcher.Add(ctx, 8)
+
+	if err = batcher.Close(ctx); err != nil {
+		t.Errorf("Expected Close to drain but got %s", err.Error())
+	}
+
+	if sizes := r.sizes(); sizes != "[3 3 1 2]" {
+		t.Errorf("Expected Close to flush the last 2 but got %s", sizes)
+	}
+
+	if err = batcher.Add(ctx, 9); !errors.Is(err, batch.ErrClosed) {
+		t.Errorf("Expected ErrClosed but got %v", err)
+	}
+
+	if stats := batcher.Stats(); stats.Added != 9 || stats.Batches != 4 || stats.Retries != 1 || stats.Failed != 0 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestBatcherDropsFailedBatches(t *testing.T) {
+	r := &recorder{failures: 100}
+	dropped := make([]interface{}, 0)
+
+	batcher, _ := batch.NewBatcher(batch.BatcherConfig{
+		Flush:   r.flush,
+		OnError: func(items []interface{}, err error) { dropped = append(dropped, items...) },
+		Retry:   retry.RetrierConfig{InitialInterval: time.Millisecond, Jitter: -1, MaxAttempts: 2},
+		Size:    2,
+	})
+
+	_ = batcher.Add(context.Background(), "a")
+	_ = batcher.Add(context.Background(), "b")
+	_ = batcher.Close(context.Background())
+
+	if fmt.Sprint(dropped) != "[a b]" {
+		t.Errorf("Expected OnError with [a b] but got %v", dropped)
+	}
+
+	if stats := batcher.Stats(); stats.Failed != 1 || stats.Dropped != 2 {
+		t.Errorf("Expected 1 failed batch and 2 dropped items but got %+v", stats)
+	}
+}
+
+func TestBatcherCloseTimeout(t *testing.T) {
+	batcher, _ := batch.NewBatcher(batch.BatcherConfig{
+		Flush: func(ctx context.Context, items []interface{}) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		Size: 1,
+	})
+
+	_ = batcher.Add(context.Background(), "a")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+
+	if err := batcher.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Close to give up when its context is done but got %v", err)
+	}
+}
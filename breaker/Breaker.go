@@ -0,0 +1,363 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrOpen is returned instead of making a call while the breaker is open
+	ErrOpen = errors.New("circuit breaker is open")
+
+	// ErrTooManyRequests is returned when the breaker is half-open and already has as many probe calls as it allows
+	ErrTooManyRequests = errors.New("circuit breaker is half-open and has too many requests")
+
+	errPanic = errors.New("panic during circuit breaker call")
+)
+
+/*
+State is the state of a circuit breaker
+*/
+type State int
+
+const (
+	// Closed lets every call through
+	Closed State = 0
+
+	// Open fails every call without making it
+	Open State = 1
+
+	// HalfOpen lets a few probe calls through to see if the dependency has recovered
+	HalfOpen State = 2
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+/*
+Stats describes a breaker's current state and what it has done. Requests,
+Successes, and Failures are counted since the breaker last changed state
+or its interval reset. Rejections and StateChanges are totals
+*/
+type Stats struct {
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	Failures            int       `json:"failures"`
+	Rejections          uint64    `json:"rejections"`
+	Requests            int       `json:"requests"`
+	State               string    `json:"state"`
+	StateChangedAt      time.Time `json:"stateChangedAt"`
+	StateChanges        uint64    `json:"stateChanges"`
+	Successes           int       `json:"successes"`
+}
+
+/*
+Breaker is a circuit breaker. It stops calling a failing dependency, such
+as a remote API, so failures don't pile up waiting on timeouts, then
+probes it to see when it has recovered
+*/
+type Breaker struct {
+	sync.Mutex
+
+	config     BreakerConfig
+	counts     counts
+	expiresAt  time.Time
+	generation uint64
+	now        func() time.Time
+	state      State
+	stats      Stats
+}
+
+type counts struct {
+	consecutiveFailures int
+	failures            int
+	requests            int
+	successes           int
+}
+
+/*
+NewBreaker creates a closed circuit breaker
+*/
+func NewBreaker(config BreakerConfig) *Breaker {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 5
+	}
+
+	if config.HalfOpenMaxRequests <= 0 {
+		config.HalfOpenMaxRequests = 1
+	}
+
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = time.Second * 30
+	}
+
+	if config.IsFailure == nil {
+		config.IsFailure = func(err error) bool {
+			return err != nil && !errors.Is(err, context.Canceled)
+		}
+	}
+
+	result := &Breaker{
+		config: config,
+		now:    time.Now,
+	}
+
+	result.stats.StateChangedAt = result.now()
+	result.resetCounts(result.now())
+
+	return result
+}
+
+/*
+Execute calls fn if the breaker allows it and records the result. When
+the breaker is open, fn isn't called and ErrOpen is returned. Panics in
+fn count as failures and are passed on
+*/
+func (b *Breaker) Execute(fn func() error) error {
+	done, err := b.Allow()
+
+	if err != nil {
+		return err
+	}
+
+	completed := false
+
+	defer func() {
+		if !completed {
+			done(errPanic)
+		}
+	}()
+
+	err = fn()
+	completed = true
+	done(err)
+
+	return err
+}
+
+/*
+Allow asks the breaker for permission to make a call, for when the call
+can't be wrapped in Execute. If the error is nil, the call must be made
+and its result passed to done
+*/
+func (b *Breaker) Allow() (func(err error), error) {
+	b.Lock()
+
+	now := b.now()
+	changed := b.updateState(now)
+	generation := b.generation
+
+	var err error
+
+	switch {
+	case b.state == Open:
+		err = fmt.Errorf("%w: %s", ErrOpen, b.config.Name)
+	case b.state == HalfOpen && b.counts.requests >= b.config.HalfOpenMaxRequests:
+		err = fmt.Errorf("%w: %s", ErrTooManyRequests, b.config.Name)
+	}
+
+	if err != nil {
+		b.stats.Rejections++
+	} else {
+		b.counts.requests++
+	}
+
+	b.Unlock()
+	b.notify(changed)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return func(err error) {
+		b.record(generation, err)
+	}, nil
+}
+
+/*
+State returns the breaker's current state
+*/
+func (b *Breaker) State() State {
+	b.Lock()
+	changed := b.updateState(b.now())
+	state := b.state
+	b.Unlock()
+
+	b.notify(changed)
+	return state
+}
+
+/*
+Stats returns the breaker's current state and counts
+*/
+func (b *Breaker) Stats() Stats {
+	b.Lock()
+	changed := b.updateState(b.now())
+
+	result := b.stats
+	result.ConsecutiveFailures = b.counts.consecutiveFailures
+	result.Failures = b.counts.failures
+	result.Requests = b.counts.requests
+	result.State = b.state.String()
+	result.Successes = b.counts.successes
+
+	b.Unlock()
+
+	b.notify(changed)
+	return result
+}
+
+/*
+Name returns the breaker's name. With Collect, this lets a Breaker be
+registered as a serverstats Collector
+*/
+func (b *Breaker) Name() string {
+	return b.config.Name
+}
+
+/*
+Collect returns the breaker's stats for serverstats
+*/
+func (b *Breaker) Collect(ctx context.Context) map[string]interface{} {
+	return statsMap(b.Stats())
+}
+
+func (b *Breaker) record(generation uint64, err error) {
+	b.Lock()
+
+	now := b.now()
+	changed := b.updateState(now)
+
+	if generation != b.generation {
+		b.Unlock()
+		b.notify(changed)
+		return
+	}
+
+	if b.config.IsFailure(err) {
+		b.counts.failures++
+		b.counts.consecutiveFailures++
+
+		if b.state == HalfOpen || b.shouldOpen() {
+			changed = append(changed, b.setState(Open, now))
+		}
+	} else {
+		b.counts.successes++
+		b.counts.consecutiveFailures = 0
+
+		if b.state == HalfOpen && b.counts.successes >= b.config.HalfOpenMaxRequests {
+			changed = append(changed, b.setState(Closed, now))
+		}
+	}
+
+	b.Unlock()
+	b.notify(changed)
+}
+
+func (b *Breaker) shouldOpen() bool {
+	if b.counts.consecutiveFailures >= b.config.FailureThreshold {
+		return true
+	}
+
+	if b.config.FailureRatio <= 0 || b.counts.requests < b.config.MinRequests || b.counts.requests == 0 {
+		return false
+	}
+
+	return float64(b.counts.failures)/float64(b.counts.requests) >= b.config.FailureRatio
+}
+
+/*
+updateState moves an open breaker to half-open once its timeout passes,
+and resets a closed breaker's counts each interval. The caller must
+hold a lock
+*/
+func (b *Breaker) updateState(now time.Time) []stateChange {
+	switch b.state {
+	case Closed:
+		if !b.expiresAt.IsZero() && !now.Before(b.expiresAt) {
+			b.resetCounts(now)
+		}
+
+	case Open:
+		if !now.Before(b.expiresAt) {
+			return []stateChange{b.setState(HalfOpen, now)}
+		}
+	}
+
+	return nil
+}
+
+type stateChange struct {
+	from State
+	to   State
+}
+
+/*
+setState changes state and starts a new generation, so results from
+calls made in the old state are ignored. The caller must hold a lock
+*/
+func (b *Breaker) setState(state State, now time.Time) stateChange {
+	change := stateChange{from: b.state, to: state}
+
+	b.state = state
+	b.stats.StateChangedAt = now
+	b.stats.StateChanges++
+	b.resetCounts(now)
+
+	return change
+}
+
+func (b *Breaker) resetCounts(now time.Time) {
+	b.generation++
+	b.counts = counts{}
+	b.expiresAt = time.Time{}
+
+	switch b.state {
+	case Closed:
+		if b.config.Interval > 0 {
+			b.expiresAt = now.Add(b.config.Interval)
+		}
+
+	case Open:
+		b.expiresAt = now.Add(b.config.OpenTimeout)
+	}
+}
+
+func (b *Breaker) notify(changes []stateChange) {
+	if b.config.OnStateChange == nil {
+		return
+	}
+
+	for _, change := range changes {
+		b.config.OnStateChange(b.config.Name, change.from, change.to)
+	}
+}
+
+func statsMap(stats Stats) map[string]interface{} {
+	return map[string]interface{}{
+		"consecutiveFailures": stats.ConsecutiveFailures,
+		"failures":            stats.Failures,
+		"rejections":          stats.Rejections,
+		"requests":            stats.Requests,
+		"state":               stats.State,
+		"stateChangedAt":      stats.StateChangedAt,
+		"stateChanges":        stats.StateChanges,
+		"successes":           stats.Successes,
+	}
+}
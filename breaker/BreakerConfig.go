@@ -0,0 +1,36 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package breaker
+
+import "time"
+
+/*
+BreakerConfig configures a Breaker.
+
+The breaker opens after FailureThreshold consecutive failures (default
+5). Set FailureRatio and MinRequests to also open it when that share of
+requests fail, once at least MinRequests have been made. Counts for the
+ratio are reset every Interval while closed; zero never resets them.
+
+Once open, calls fail fast with ErrOpen for OpenTimeout (default 30
+seconds). Then the breaker is half-open and lets HalfOpenMaxRequests
+probe calls through (default 1). If they all succeed the breaker
+closes; any failure opens it again.
+
+IsFailure decides which errors count against the breaker, and defaults
+to every error except context.Canceled. OnStateChange is called, without
+any locks held, whenever the state changes
+*/
+type BreakerConfig struct {
+	FailureRatio        float64
+	FailureThreshold    int
+	HalfOpenMaxRequests int
+	Interval            time.Duration
+	IsFailure           func(err error) bool
+	MinRequests         int
+	Name                string
+	OnStateChange       func(name string, from, to State)
+	OpenTimeout         time.Duration
+}
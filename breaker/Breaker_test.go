@@ -0,0 +1,315 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package breaker_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/breaker"
+)
+
+var errFailed = errors.New("failed")
+
+func TestBreaker(t *testing.T) {
+	changes := make([]string, 0)
+	b := breaker.NewBreaker(breaker.BreakerConfig{
+		FailureThreshold: 2,
+		Name:             "api",
+		OnStateChange: func(name string, from, to breaker.State) {
+			changes = append(changes, from.String()+"->"+to.String())
+		},
+		OpenTimeout: time.Millisecond * 20,
+	})
+
+	fail := func() error { return errFailed }
+	succeed := func() error { return nil }
+
+	steps := []struct {
+		name     string
+		fn       func() error
+		wait     time.Duration
+		expected error
+		state    breaker.State
+	}{
+		{name: "First failure", fn: fail, expected: errFailed, state: breaker.Closed},
+		{name: "Success resets consecutive failures", fn: succeed, state: breaker.Closed},
+		{name: "Failure", fn: fail, expected: errFailed, state: breaker.Closed},
+		{name: "Threshold reached", fn: fail, expected: errFailed, state: breaker.Open},
+		{name: "Open fails fast", fn: succeed, expected: breaker.ErrOpen, state: breaker.Open},
+		{name: "Half-open probe fails", fn: fail, wait: time.Millisecond * 30, expected: errFailed, state: breaker.Open},
+		{name: "Half-open probe succeeds", fn: succeed, wait: time.Millisecond * 30, state: breaker.Closed},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			time.Sleep(step.wait)
+
+			if err := b.Execute(step.fn); !errors.Is(err, step.expected) {
+				t.Errorf("Expected error '%v' but got '%v'", step.expected, err)
+			}
+
+			if b.State() != step.state {
+				t.Errorf("Expected state %s but got %s", step.state, b.State())
+			}
+		})
+	}
+
+	expected := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected state changes %v but got %v", expected, changes)
+	}
+
+	for index := range expected {
+		if changes[index] != expected[index] {
+			t.Errorf("Expected state changes %v but got %v", expected, changes)
+		}
+	}
+
+	if stats := b.Stats(); stats.Rejections != 1 || stats.StateChanges != 5 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestHalfOpenProbes(t *testing.T) {
+	tests := []struct {
+		name            string
+		results         []error
+		expectedState   breaker.State
+		expectedChanges uint64
+	}{
+		{name: "All probes succeed", results: []error{nil, nil}, expectedState: breaker.Closed, expectedChanges: 3},
+		{name: "Last probe fails", results: []error{nil, errFailed}, expectedState: breaker.Open, expectedChanges: 3},
+		{name: "First probe fails and the late success is ignored", results: []error{errFailed, nil}, expectedState: breaker.Open, expectedChanges: 3},
+		{name: "Canceled probe isn't a failure", results: []error{context.Canceled, nil}, expectedState: breaker.Closed, expectedChanges: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := breaker.NewBreaker(breaker.BreakerConfig{
+				FailureThreshold:    1,
+				HalfOpenMaxRequests: 2,
+				OpenTimeout:         time.Millisecond * 50,
+			})
+
+			_ = b.Execute(func() error { return errFailed })
+			time.Sleep(time.Millisecond * 60)
+
+			if b.State() != breaker.HalfOpen {
+				t.Fatalf("Expected state %s but got %s", breaker.HalfOpen, b.State())
+			}
+
+			probes := make([]func(err error), 0, len(test.results))
+
+			for range test.results {
+				done, err := b.Allow()
+
+				if err != nil {
+					t.Fatalf("Expected a probe to be allowed but got %s", err.Error())
+				}
+
+				probes = append(probes, done)
+			}
+
+			if _, err := b.Allow(); !errors.Is(err, breaker.ErrTooManyRequests) {
+				t.Errorf("Expected error '%v' past the probe limit but got '%v'", breaker.ErrTooManyRequests, err)
+			}
+
+			for index, err := range test.results {
+				probes[index](err)
+
+				if index < len(test.results)-1 && errors.Is(err, errFailed) && b.State() != breaker.Open {
+					t.Errorf("Expected a failed probe to open the breaker but it is %s", b.State())
+				}
+			}
+
+			stats := b.Stats()
+
+			if b.State() != test.expectedState {
+				t.Errorf("Expected state %s but got %s", test.expectedState, b.State())
+			}
+
+			if stats.StateChanges != test.expectedChanges {
+				t.Errorf("Expected %d state changes but got %d", test.expectedChanges, stats.StateChanges)
+			}
+
+			if stats.Rejections != 1 {
+				t.Errorf("Expected 1 rejection but got %d", stats.Rejections)
+			}
+		})
+	}
+}
+
+func TestHalfOpenConcurrentProbes(t *testing.T) {
+	b := breaker.NewBreaker(breaker.BreakerConfig{
+		FailureThreshold:    1,
+		HalfOpenMaxRequests: 3,
+		OpenTimeout:         time.Millisecond * 10,
+	})
+
+	_ = b.Execute(func() error { return errFailed })
+	time.Sleep(time.Millisecond * 20)
+
+	var allowed, rejected int64
+
+	start := make(chan struct{})
+	release := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	probes := &sync.WaitGroup{}
+
+	for index := 0; index < 20; index++ {
+		wg.Add(1)
+		probes.Add(1)
+
+		go func() {
+			defer wg.Done()
+			<-start
+
+			err := b.Execute(func() error {
+				atomic.AddInt64(&allowed, 1)
+				probes.Done()
+				<-release
+				return nil
+			})
+
+			if errors.Is(err, breaker.ErrTooManyRequests) {
+				atomic.AddInt64(&rejected, 1)
+				probes.Done()
+			}
+		}()
+	}
+
+	close(start)
+	probes.Wait()
+	close(release)
+	wg.Wait()
+
+	if allowed != 3 || rejected != 17 {
+		t.Errorf("Expected 3 probes allowed and 17 rejected but got %d and %d", allowed, rejected)
+	}
+
+	if b.State() != breaker.Closed {
+		t.Errorf("Expected state %s but got %s", breaker.Closed, b.State())
+	}
+}
+
+func TestBreakerConcurrentCallers(t *testing.T) {
+	validChanges := map[string]bool{
+		"closed->open":      true,
+		"open->half-open":   true,
+		"half-open->open":   true,
+		"half-open->closed": true,
+	}
+
+	var changes uint64
+
+	invalid := make(chan string, 100)
+	b := breaker.NewBreaker(breaker.BreakerConfig{
+		FailureThreshold:    3,
+		HalfOpenMaxRequests: 2,
+		Interval:            time.Millisecond * 5,
+		OnStateChange: func(name string, from, to breaker.State) {
+			atomic.AddUint64(&changes, 1)
+
+			if change := from.String() + "->" + to.String(); !validChanges[change] {
+				select {
+				case invalid <- change:
+				default:
+				}
+			}
+		},
+		OpenTimeout: time.Millisecond,
+	})
+
+	var calls, rejections int64
+
+	wg := &sync.WaitGroup{}
+
+	for worker := 0; worker < 16; worker++ {
+		wg.Add(1)
+
+		go func(worker int) {
+			defer wg.Done()
+
+			for call := 0; call < 200; call++ {
+				result := errFailed
+
+				if (worker+call)%3 == 0 {
+					result = nil
+				}
+
+				err := b.Execute(func() error {
+					atomic.AddInt64(&calls, 1)
+					return result
+				})
+
+				if errors.Is(err, breaker.ErrOpen) || errors.Is(err, breaker.ErrTooManyRequests) {
+					atomic.AddInt64(&rejections, 1)
+				}
+
+				if call%50 == 0 {
+					_ = b.Stats()
+					time.Sleep(time.Millisecond)
+				}
+			}
+		}(worker)
+	}
+
+	wg.Wait()
+	close(invalid)
+
+	for change := range invalid {
+		t.Errorf("Unexpected state change %s", change)
+	}
+
+	stats := b.Stats()
+
+	if calls+rejections != 16*200 {
+		t.Errorf("Expected %d calls and rejections but got %d and %d", 16*200, calls, rejections)
+	}
+
+	if stats.Rejections != uint64(rejections) {
+		t.Errorf("Expected %d rejections but got %d", rejections, stats.Rejections)
+	}
+
+	if stats.StateChanges != atomic.LoadUint64(&changes) {
+		t.Errorf("Expected %d state changes but got %d", changes, stats.StateChanges)
+	}
+
+	if stats.StateChanges == 0 {
+		t.Errorf("Expected the breaker to change state")
+	}
+}
+
+func TestBreakerFailureRatio(t *testing.T) {
+	b := breaker.NewBreaker(breaker.BreakerConfig{FailureRatio: 0.5, FailureThreshold: 100, MinRequests: 4})
+
+	for _, err := range []error{nil, errFailed, nil, errFailed} {
+		_ = b.Execute(func() error { return err })
+	}
+
+	if b.State() != breaker.Open {
+		t.Errorf("Expected the breaker to open at a 50%% failure ratio but it is %s", b.State())
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := breaker.NewRegistry("breakers", breaker.BreakerConfig{FailureThreshold: 1})
+	registry.Configure("lenient", breaker.BreakerConfig{FailureThreshold: 10})
+
+	_ = registry.Execute("strict", func() error { return errFailed })
+	_ = registry.Execute("lenient", func() error { return errFailed })
+
+	collected := registry.Collect(context.Background())
+
+	if collected["strict"].(map[string]interface{})["state"] != "open" || collected["lenient"].(map[string]interface{})["state"] != "closed" {
+		t.Errorf("Unexpected collected stats %v", collected)
+	}
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package breaker
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+/*
+Registry hands out a breaker per name, such as one per downstream host,
+creating them on first use. It is a serverstats Collector, reporting
+every breaker it has created
+*/
+type Registry struct {
+	sync.Mutex
+
+	breakers  map[string]*Breaker
+	config    BreakerConfig
+	name      string
+	overrides map[string]BreakerConfig
+}
+
+/*
+NewRegistry creates a registry reported to serverstats under name.
+Breakers are created from config, with their Name set
+*/
+func NewRegistry(name string, config BreakerConfig) *Registry {
+	return &Registry{
+		breakers:  make(map[string]*Breaker),
+		config:    config,
+		name:      name,
+		overrides: make(map[string]BreakerConfig),
+	}
+}
+
+/*
+Configure sets the config used for one breaker name instead of the
+registry's default. It only affects breakers not created yet
+*/
+func (r *Registry) Configure(name string, config BreakerConfig) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.overrides[name] = config
+}
+
+/*
+Get returns the breaker for name, creating it if needed
+*/
+func (r *Registry) Get(name string) *Breaker {
+	r.Lock()
+	defer r.Unlock()
+
+	if b, ok := r.breakers[name]; ok {
+		return b
+	}
+
+	config, ok := r.overrides[name]
+
+	if !ok {
+		config = r.config
+	}
+
+	config.Name = name
+	b := NewBreaker(config)
+	r.breakers[name] = b
+
+	return b
+}
+
+/*
+Execute runs fn through the breaker for name
+*/
+func (r *Registry) Execute(name string, fn func() error) error {
+	return r.Get(name).Execute(fn)
+}
+
+/*
+Names returns the names of every breaker created so far, sorted
+*/
+func (r *Registry) Names() []string {
+	r.Lock()
+	defer r.Unlock()
+
+	result := make([]string, 0, len(r.breakers))
+
+	for name := range r.breakers {
+		result = append(result, name)
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+/*
+Name returns the name the registry is reported under
+*/
+func (r *Registry) Name() string {
+	return r.name
+}
+
+/*
+Collect returns the stats of every breaker, keyed by breaker name
+*/
+func (r *Registry) Collect(ctx context.Context) map[string]interface{} {
+	result := make(map[string]interface{})
+
+	for _, name := range r.Names() {
+		result[name] = statsMap(r.Get(name).Stats())
+	}
+
+	return result
+}
@@ -0,0 +1,294 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+/*
+ICache describes a key/value cache
+*/
+type ICache interface {
+	Clear()
+	Delete(key string)
+	Get(key string) (interface{}, bool)
+	GetOrLoad(ctx context.Context, key string, loader LoaderFunc) (interface{}, error)
+	Len() int
+	Set(key string, value interface{})
+	SetWithTTL(key string, value interface{}, ttl time.Duration)
+}
+
+/*
+Cache is a thread-safe, in-memory cache with per-entry TTLs and a least
+recently used size bound. Expired entries are removed when they are
+next read, or by the background cleanup started with StartCleanup
+*/
+type Cache struct {
+	sync.Mutex
+
+	calls           map[string]*loadCall
+	cleanupShutdown chan bool
+	config          CacheConfig
+	entries         map[string]*list.Element
+	lru             *list.List
+	now             func() time.Time
+	stats           Stats
+}
+
+type entry struct {
+	expiresAt time.Time
+	key       string
+	value     interface{}
+}
+
+type eviction struct {
+	entry  *entry
+	reason EvictionReason
+}
+
+/*
+NewCache creates a new cache
+*/
+func NewCache(config CacheConfig) *Cache {
+	return &Cache{
+		calls:   make(map[string]*loadCall),
+		config:  config,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		now:     time.Now,
+	}
+}
+
+/*
+Get returns the value stored under key. The second return value is false
+when there is no entry or it has expired
+*/
+func (c *Cache) Get(key string) (interface{}, bool) {
+	c.Lock()
+	value, ok, evicted := c.get(key)
+	c.Unlock()
+
+	c.notify(evicted)
+	return value, ok
+}
+
+/*
+Set stores a value using the cache's DefaultTTL
+*/
+func (c *Cache) Set(key string, value interface{}) {
+	c.SetWithTTL(key, value, c.config.DefaultTTL)
+}
+
+/*
+SetWithTTL stores a value that expires after ttl. A ttl of zero or less
+means the entry doesn't expire
+*/
+func (c *Cache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	c.Lock()
+	evicted := c.set(key, value, ttl)
+	c.Unlock()
+
+	c.notify(evicted)
+}
+
+/*
+Delete removes an entry
+*/
+func (c *Cache) Delete(key string) {
+	c.Lock()
+	defer c.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+}
+
+/*
+Clear removes every entry. Stats are kept
+*/
+func (c *Cache) Clear() {
+	c.Lock()
+	defer c.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+/*
+Len returns the number of entries, including any that have expired but
+haven't been removed yet
+*/
+func (c *Cache) Len() int {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.lru.Len()
+}
+
+/*
+RemoveExpired removes every expired entry and returns how many were
+removed
+*/
+func (c *Cache) RemoveExpired() int {
+	now := c.now()
+	evicted := make([]eviction, 0)
+
+	c.Lock()
+
+	for element := c.lru.Back(); element != nil; {
+		previous := element.Prev()
+		e := element.Value.(*entry)
+
+		if e.expired(now) {
+			c.remove(element)
+			c.stats.Expirations++
+			evicted = append(evicted, eviction{entry: e, reason: EvictedExpired})
+		}
+
+		element = previous
+	}
+
+	c.Unlock()
+
+	c.notify(evicted)
+	return len(evicted)
+}
+
+/*
+StartCleanup starts a goroutine that calls RemoveExpired on an interval.
+Calling it again while cleanup is running does nothing
+*/
+func (c *Cache) StartCleanup(interval time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.cleanupShutdown != nil {
+		return
+	}
+
+	shutdown := make(chan bool)
+	c.cleanupShutdown = shutdown
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-shutdown:
+				return
+
+			case <-ticker.C:
+				c.RemoveExpired()
+			}
+		}
+	}()
+}
+
+/*
+StopCleanup stops the background cleanup started by StartCleanup
+*/
+func (c *Cache) StopCleanup() {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.cleanupShutdown == nil {
+		return
+	}
+
+	close(c.cleanupShutdown)
+	c.cleanupShutdown = nil
+}
+
+/*
+get looks up an entry, removing it if it has expired. The caller must
+hold a lock
+*/
+func (c *Cache) get(key string) (interface{}, bool, []eviction) {
+	element, ok := c.entries[key]
+
+	if !ok {
+		c.stats.Misses++
+		return nil, false, nil
+	}
+
+	e := element.Value.(*entry)
+
+	if e.expired(c.now()) {
+		c.remove(element)
+		c.stats.Expirations++
+		c.stats.Misses++
+		return nil, false, []eviction{{entry: e, reason: EvictedExpired}}
+	}
+
+	c.lru.MoveToFront(element)
+	c.stats.Hits++
+
+	return e.value, true, nil
+}
+
+/*
+set stores an entry, evicting the least recently used entries if the
+cache is over its size limit. The caller must hold a lock
+*/
+func (c *Cache) set(key string, value interface{}, ttl time.Duration) []eviction {
+	var expiresAt time.Time
+	var evicted []eviction
+
+	if ttl > 0 {
+		expiresAt = c.now().Add(ttl)
+	}
+
+	if element, ok := c.entries[key]; ok {
+		e := element.Value.(*entry)
+		e.value = value
+		e.expiresAt = expiresAt
+
+		c.lru.MoveToFront(element)
+		return nil
+	}
+
+	c.entries[key] = c.lru.PushFront(&entry{
+		expiresAt: expiresAt,
+		key:       key,
+		value:     value,
+	})
+
+	for c.config.MaxEntries > 0 && c.lru.Len() > c.config.MaxEntries {
+		oldest := c.lru.Back()
+		c.remove(oldest)
+		c.stats.Evictions++
+		evicted = append(evicted, eviction{entry: oldest.Value.(*entry), reason: EvictedCapacity})
+	}
+
+	return evicted
+}
+
+func (c *Cache) remove(element *list.Element) {
+	c.lru.Remove(element)
+	delete(c.entries, element.Value.(*entry).key)
+}
+
+/*
+notify calls OnEvict for evicted entries. It must be called without
+holding a lock
+*/
+func (c *Cache) notify(evicted []eviction) {
+	if c.config.OnEvict == nil {
+		return
+	}
+
+	for _, e := range evicted {
+		c.config.OnEvict(e.entry.key, e.entry.value, e.reason)
+	}
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
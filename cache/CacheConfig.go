@@ -0,0 +1,27 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package cache
+
+import "time"
+
+/*
+CacheConfig configures a Cache.
+
+DefaultTTL is how long entries added with Set or GetOrLoad live. Zero
+means entries don't expire. MaxEntries bounds the size of the cache;
+once it's full, the least recently used entry is evicted. Zero means no
+limit.
+
+Name is reported by the cache's Name method, which, along with Collect,
+lets a Cache be registered directly as a serverstats Collector.
+OnEvict is called, without any locks held, whenever an entry is removed
+because it expired or the cache was full
+*/
+type CacheConfig struct {
+	DefaultTTL time.Duration
+	MaxEntries int
+	Name       string
+	OnEvict    func(key string, value interface{}, reason EvictionReason)
+}
@@ -0,0 +1,206 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/cache"
+	"github.com/ResurgenceIT/kit/v6/clock"
+)
+
+func TestCache(t *testing.T) {
+	evicted := make(map[string]cache.EvictionReason)
+	c := cache.NewCache(cache.CacheConfig{
+		MaxEntries: 2,
+		OnEvict: func(key string, value interface{}, reason cache.EvictionReason) {
+			evicted[key] = reason
+		},
+	})
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a")
+	c.Set("c", 3)
+	c.SetWithTTL("d", 4, time.Millisecond*10)
+
+	time.Sleep(time.Millisecond * 20)
+
+	tests := []struct {
+		key      string
+		expected interface{}
+		found    bool
+	}{
+		{key: "a", found: false},
+		{key: "b", found: false},
+		{key: "c", expected: 3, found: true},
+		{key: "d", found: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			value, found := c.Get(test.key)
+
+			if found != test.found || value != test.expected {
+				t.Errorf("Expected %v, %v but got %v, %v", test.expected, test.found, value, found)
+			}
+		})
+	}
+
+	if evicted["b"] != cache.EvictedCapacity || evicted["a"] != cache.EvictedCapacity || evicted["d"] != cache.EvictedExpired {
+		t.Errorf("Expected a and b evicted for capacity and d expired, got %v", evicted)
+	}
+
+	stats := c.Stats()
+
+	if stats.Hits != 2 || stats.Misses != 3 || stats.Evictions != 2 || stats.Expirations != 1 || stats.Entries != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestGetOrLoad(t *testing.T) {
+	var calls int32
+
+	c := cache.NewCache(cache.CacheConfig{DefaultTTL: time.Minute})
+	release := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	results := make([]interface{}, 10)
+
+	loader := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "loaded", nil
+	}
+
+	for index := range results {
+		wg.Add(1)
+
+		go func(index int) {
+			defer wg.Done()
+			results[index], _ = c.GetOrLoad(context.Background(), "key", loader)
+		}(index)
+	}
+
+	time.Sleep(time.Millisecond * 20)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("Expected the loader to be called once but it was called %d times", calls)
+	}
+
+	for _, result := range results {
+		if result != "loaded" {
+			t.Errorf("Expected 'loaded' but got '%v'", result)
+		}
+	}
+
+	loadErr := errors.New("database is down")
+
+	if _, err := c.GetOrLoad(context.Background(), "other", func(ctx context.Context) (interface{}, error) {
+		return nil, loadErr
+	}); !errors.Is(err, loadErr) {
+		t.Errorf("Expected error '%v' but got '%v'", loadErr, err)
+	}
+
+	if _, found := c.Get("other"); found {
+		t.Errorf("Expected errors not to be cached")
+	}
+
+	if stats := c.Stats(); stats.Loads != 2 || stats.LoadErrors != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestExpiringMap(t *testing.T) {
+	fake := clock.NewFake(time.Date(2021, 6, 1, 9, 0, 0, 0, time.UTC))
+	expired := make([]interface{}, 0)
+	m := cache.NewExpiringMap(cache.ExpiringMapConfig{
+		Clock:      fake,
+		DefaultTTL: time.Minute,
+		OnExpire:   func(key, value interface{}) { expired = append(expired, key) },
+	})
+
+	type counterKey struct {
+		ip   string
+		path string
+	}
+
+	m.Set(counterKey{ip: "10.0.0.1", path: "/login"}, 1, 0)
+	m.Set("short", "a", time.Second*10)
+	m.Set("long", "b", time.Hour)
+
+	if value, ok := m.Get(counterKey{ip: "10.0.0.1", path: "/login"}); !ok || value != 1 {
+		t.Errorf("Expected 1 under a struct key but got %v", value)
+	}
+
+	if m.SetIfAbsent("short", "c", 0) {
+		t.Errorf("Expected SetIfAbsent to keep a live entry")
+	}
+
+	fake.Advance(time.Second * 30)
+
+	if _, ok := m.Get("short"); ok {
+		t.Errorf("Expected short to have expired")
+	}
+
+	if !m.SetIfAbsent("short", "c", time.Second*10) || !m.Touch("long", time.Second) {
+		t.Errorf("Expected SetIfAbsent to replace an expired entry and Touch a live one")
+	}
+
+	fake.Advance(time.Minute)
+
+	if removed := m.RemoveExpired(); removed != 3 || m.Len() != 0 {
+		t.Errorf("Expected 3 removed and none left but got %d removed, %d left", removed, m.Len())
+	}
+
+	if len(expired) != 3 || expired[0] != "long" {
+		t.Errorf("Expected OnExpire for each entry, soonest first, but got %v", expired)
+	}
+}
+
+func TestExpiringSet(t *testing.T) {
+	fake := clock.NewFake(time.Date(2021, 6, 1, 9, 0, 0, 0, time.UTC))
+	nonces := cache.NewExpiringSet(cache.ExpiringMapConfig{Clock: fake})
+	added := int32(0)
+	wg := sync.WaitGroup{}
+
+	for index := 0; index < 10; index++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if nonces.Add("nonce-1", time.Minute) {
+				atomic.AddInt32(&added, 1)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if added != 1 || !nonces.Contains("nonce-1") {
+		t.Errorf("Expected exactly one Add to win but got %d", added)
+	}
+
+	nonces.StartCleanup(time.Second * 30)
+	defer nonces.StopCleanup()
+
+	fake.WaitForTimers(1)
+	fake.Advance(time.Minute)
+
+	for nonces.Len() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if nonces.Contains("nonce-1") {
+		t.Errorf("Expected the nonce to expire")
+	}
+}
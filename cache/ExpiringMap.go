@@ -0,0 +1,336 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package cache
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/clock"
+)
+
+/*
+ExpiringMap is a thread-safe map whose entries each live for their own
+TTL. Unlike Cache, keys can be any comparable value and there is no size
+bound, so it suits state that must be kept until it expires, such as
+nonces or rate limit counters. Expired entries are never returned, and
+are removed by RemoveExpired or the cleanup started with StartCleanup
+*/
+type ExpiringMap struct {
+	sync.Mutex
+
+	cleanupShutdown chan bool
+	config          ExpiringMapConfig
+	entries         map[interface{}]*expiringEntry
+	expiries        expiryHeap
+}
+
+type expiringEntry struct {
+	expiresAt time.Time
+	index     int
+	key       interface{}
+	value     interface{}
+}
+
+/*
+NewExpiringMap creates an empty map
+*/
+func NewExpiringMap(config ExpiringMapConfig) *ExpiringMap {
+	if config.Clock == nil {
+		config.Clock = clock.Real{}
+	}
+
+	return &ExpiringMap{
+		config:  config,
+		entries: make(map[interface{}]*expiringEntry),
+	}
+}
+
+/*
+Get returns the value stored under key. The second return value is
+false when there is no entry or it has expired
+*/
+func (m *ExpiringMap) Get(key interface{}) (interface{}, bool) {
+	m.Lock()
+	defer m.Unlock()
+
+	e, ok := m.live(key)
+
+	if !ok {
+		return nil, false
+	}
+
+	return e.value, true
+}
+
+/*
+ExpiresAt returns when the entry under key expires. The time is zero
+for entries that don't expire
+*/
+func (m *ExpiringMap) ExpiresAt(key interface{}) (time.Time, bool) {
+	m.Lock()
+	defer m.Unlock()
+
+	e, ok := m.live(key)
+
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return e.expiresAt, true
+}
+
+/*
+Set stores value under key for ttl, replacing any entry already there.
+A ttl of zero uses DefaultTTL
+*/
+func (m *ExpiringMap) Set(key, value interface{}, ttl time.Duration) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.set(key, value, ttl)
+}
+
+/*
+SetIfAbsent stores value under key for ttl only when there is no live
+entry, and reports whether it did. Checking and setting happen under
+one lock, so of many callers racing with the same key exactly one wins
+*/
+func (m *ExpiringMap) SetIfAbsent(key, value interface{}, ttl time.Duration) bool {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.live(key); ok {
+		return false
+	}
+
+	m.set(key, value, ttl)
+	return true
+}
+
+/*
+Touch gives the entry under key a new ttl, and reports whether there
+was a live entry to touch
+*/
+func (m *ExpiringMap) Touch(key interface{}, ttl time.Duration) bool {
+	m.Lock()
+	defer m.Unlock()
+
+	e, ok := m.live(key)
+
+	if !ok {
+		return false
+	}
+
+	m.set(key, e.value, ttl)
+	return true
+}
+
+/*
+Delete removes the entry under key. OnExpire isn't called
+*/
+func (m *ExpiringMap) Delete(key interface{}) {
+	m.Lock()
+	defer m.Unlock()
+
+	if e, ok := m.entries[key]; ok {
+		m.remove(e)
+	}
+}
+
+/*
+Len returns how many entries the map holds, including expired entries
+that haven't been removed yet
+*/
+func (m *ExpiringMap) Len() int {
+	m.Lock()
+	defer m.Unlock()
+
+	return len(m.entries)
+}
+
+/*
+Range calls fn with each live entry until fn returns false. fn is called
+with the map locked, so it must not use the map
+*/
+func (m *ExpiringMap) Range(fn func(key, value interface{}) bool) {
+	m.Lock()
+	defer m.Unlock()
+
+	now := m.config.Clock.Now()
+
+	for key, e := range m.entries {
+		if e.expired(now) {
+			continue
+		}
+
+		if !fn(key, e.value) {
+			return
+		}
+	}
+}
+
+/*
+RemoveExpired removes every expired entry, calling OnExpire for each,
+and returns how many were removed. Entries are kept in order of expiry,
+so this only looks at the entries it removes
+*/
+func (m *ExpiringMap) RemoveExpired() int {
+	now := m.config.Clock.Now()
+	expired := make([]*expiringEntry, 0)
+
+	m.Lock()
+
+	for len(m.expiries) > 0 && m.expiries[0].expired(now) {
+		e := m.expiries[0]
+		m.remove(e)
+		expired = append(expired, e)
+	}
+
+	m.Unlock()
+
+	if m.config.OnExpire != nil {
+		for _, e := range expired {
+			m.config.OnExpire(e.key, e.value)
+		}
+	}
+
+	return len(expired)
+}
+
+/*
+StartCleanup starts a goroutine that calls RemoveExpired on an interval.
+Calling it again while cleanup is running does nothing
+*/
+func (m *ExpiringMap) StartCleanup(interval time.Duration) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.cleanupShutdown != nil {
+		return
+	}
+
+	shutdown := make(chan bool)
+	m.cleanupShutdown = shutdown
+
+	go func() {
+		ticker := m.config.Clock.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-shutdown:
+				return
+
+			case <-ticker.C():
+				m.RemoveExpired()
+			}
+		}
+	}()
+}
+
+/*
+StopCleanup stops the background cleanup started by StartCleanup
+*/
+func (m *ExpiringMap) StopCleanup() {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.cleanupShutdown == nil {
+		return
+	}
+
+	close(m.cleanupShutdown)
+	m.cleanupShutdown = nil
+}
+
+/*
+live returns the entry under key if it hasn't expired. The caller must
+hold the lock
+*/
+func (m *ExpiringMap) live(key interface{}) (*expiringEntry, bool) {
+	e, ok := m.entries[key]
+
+	if !ok || e.expired(m.config.Clock.Now()) {
+		return nil, false
+	}
+
+	return e, true
+}
+
+/*
+set stores an entry, keeping entries that expire in the heap. The
+caller must hold the lock
+*/
+func (m *ExpiringMap) set(key, value interface{}, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = m.config.DefaultTTL
+	}
+
+	var expiresAt time.Time
+
+	if ttl > 0 {
+		expiresAt = m.config.Clock.Now().Add(ttl)
+	}
+
+	if e, ok := m.entries[key]; ok {
+		m.remove(e)
+	}
+
+	e := &expiringEntry{expiresAt: expiresAt, index: -1, key: key, value: value}
+	m.entries[key] = e
+
+	if !expiresAt.IsZero() {
+		heap.Push(&m.expiries, e)
+	}
+}
+
+func (m *ExpiringMap) remove(e *expiringEntry) {
+	delete(m.entries, e.key)
+
+	if e.index >= 0 {
+		heap.Remove(&m.expiries, e.index)
+	}
+}
+
+func (e *expiringEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+/*
+expiryHeap orders entries by when they expire, soonest first
+*/
+type expiryHeap []*expiringEntry
+
+func (h expiryHeap) Len() int {
+	return len(h)
+}
+
+func (h expiryHeap) Less(i, j int) bool {
+	return h[i].expiresAt.Before(h[j].expiresAt)
+}
+
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expiryHeap) Push(x interface{}) {
+	e := x.(*expiringEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *expiryHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	e.index = -1
+	*h = old[:len(old)-1]
+
+	return e
+}
@@ -0,0 +1,25 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package cache
+
+import (
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/clock"
+)
+
+/*
+ExpiringMapConfig configures an ExpiringMap or ExpiringSet.
+
+DefaultTTL is how long entries set without their own TTL live. Zero
+means they don't expire. OnExpire is called, without any locks held,
+for each entry removed because its TTL passed. Clock defaults to the
+system clock; a clock.Fake lets tests expire entries without waiting
+*/
+type ExpiringMapConfig struct {
+	Clock      clock.IClock
+	DefaultTTL time.Duration
+	OnExpire   func(key, value interface{})
+}
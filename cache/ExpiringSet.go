@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package cache
+
+import (
+	"time"
+)
+
+/*
+ExpiringSet is a thread-safe set whose members each live for their own
+TTL, such as nonces or request IDs seen recently
+*/
+type ExpiringSet struct {
+	members *ExpiringMap
+}
+
+/*
+NewExpiringSet creates an empty set. OnExpire is called with each
+expired member as the key and a nil value
+*/
+func NewExpiringSet(config ExpiringMapConfig) *ExpiringSet {
+	return &ExpiringSet{
+		members: NewExpiringMap(config),
+	}
+}
+
+/*
+Add adds member for ttl, and reports whether it is new. Adding a member
+already in the set returns false and leaves its TTL alone, so a replay
+check is one call.
+
+	if !nonces.Add(request.Nonce, time.Minute*5) {
+		return ErrReplayed
+	}
+*/
+func (s *ExpiringSet) Add(member interface{}, ttl time.Duration) bool {
+	return s.members.SetIfAbsent(member, nil, ttl)
+}
+
+/*
+Contains reports whether member is in the set and hasn't expired
+*/
+func (s *ExpiringSet) Contains(member interface{}) bool {
+	_, ok := s.members.Get(member)
+	return ok
+}
+
+/*
+Touch gives member a new ttl, and reports whether it was in the set
+*/
+func (s *ExpiringSet) Touch(member interface{}, ttl time.Duration) bool {
+	return s.members.Touch(member, ttl)
+}
+
+/*
+Remove takes member out of the set
+*/
+func (s *ExpiringSet) Remove(member interface{}) {
+	s.members.Delete(member)
+}
+
+/*
+Len returns how many members the set holds, including expired members
+that haven't been removed yet
+*/
+func (s *ExpiringSet) Len() int {
+	return s.members.Len()
+}
+
+/*
+RemoveExpired removes every expired member and returns how many were
+removed
+*/
+func (s *ExpiringSet) RemoveExpired() int {
+	return s.members.RemoveExpired()
+}
+
+/*
+StartCleanup starts a goroutine that calls RemoveExpired on an interval
+*/
+func (s *ExpiringSet) StartCleanup(interval time.Duration) {
+	s.members.StartCleanup(interval)
+}
+
+/*
+StopCleanup stops the background cleanup started by StartCleanup
+*/
+func (s *ExpiringSet) StopCleanup() {
+	s.members.StopCleanup()
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+/*
+LoaderFunc loads a value that isn't in the cache, such as by querying a
+database
+*/
+type LoaderFunc func(ctx context.Context) (interface{}, error)
+
+/*
+loadCall is a load in progress. Other callers asking for the same key
+wait on done instead of calling the loader again
+*/
+type loadCall struct {
+	done  chan struct{}
+	err   error
+	value interface{}
+}
+
+/*
+GetOrLoad returns the value stored under key, calling loader and storing
+its result with the cache's DefaultTTL when there is no entry. Concurrent
+calls for the same key share a single call to loader, so a popular key
+expiring doesn't send a stampede of queries to the database. Errors are
+returned to every waiting caller and are not cached.
+
+The loader runs with the context of the caller that started the load.
+Other callers stop waiting, and get ctx.Err(), when their own context is
+canceled. If the loader panics, the panic is passed on to the caller
+that started the load and the other callers get an error
+*/
+func (c *Cache) GetOrLoad(ctx context.Context, key string, loader LoaderFunc) (interface{}, error) {
+	return c.GetOrLoadWithTTL(ctx, key, c.config.DefaultTTL, loader)
+}
+
+/*
+GetOrLoadWithTTL is GetOrLoad with a TTL for the loaded value
+*/
+func (c *Cache) GetOrLoadWithTTL(ctx context.Context, key string, ttl time.Duration, loader LoaderFunc) (interface{}, error) {
+	c.Lock()
+	value, ok, evicted := c.get(key)
+
+	if ok {
+		c.Unlock()
+		c.notify(evicted)
+		return value, nil
+	}
+
+	call, inFlight := c.calls[key]
+
+	if !inFlight {
+		call = &loadCall{done: make(chan struct{})}
+		c.calls[key] = call
+		c.stats.Loads++
+	}
+
+	c.Unlock()
+	c.notify(evicted)
+
+	if !inFlight {
+		c.load(ctx, key, ttl, call, loader)
+		return call.value, call.err
+	}
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *Cache) load(ctx context.Context, key string, ttl time.Duration, call *loadCall, loader LoaderFunc) {
+	var evicted []eviction
+
+	completed := false
+
+	defer func() {
+		if !completed {
+			call.err = fmt.Errorf("cache loader for '%s' panicked", key)
+		}
+
+		c.Lock()
+
+		delete(c.calls, key)
+
+		if call.err != nil {
+			c.stats.LoadErrors++
+		} else {
+			evicted = c.set(key, call.value, ttl)
+		}
+
+		c.Unlock()
+
+		close(call.done)
+		c.notify(evicted)
+	}()
+
+	call.value, call.err = loader(ctx)
+	completed = true
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package cache
+
+import (
+	"context"
+)
+
+/*
+EvictionReason describes why an entry was removed from the cache
+*/
+type EvictionReason int
+
+const (
+	// EvictedCapacity means the entry was the least recently used when the cache was full
+	EvictedCapacity EvictionReason = 1
+
+	// EvictedExpired means the entry's TTL passed
+	EvictedExpired EvictionReason = 2
+)
+
+func (r EvictionReason) String() string {
+	switch r {
+	case EvictedCapacity:
+		return "capacity"
+	case EvictedExpired:
+		return "expired"
+	default:
+		return "unknown"
+	}
+}
+
+/*
+Stats are counters describing how a cache has been used
+*/
+type Stats struct {
+	Entries     int     `json:"entries"`
+	Evictions   uint64  `json:"evictions"`
+	Expirations uint64  `json:"expirations"`
+	HitRatio    float64 `json:"hitRatio"`
+	Hits        uint64  `json:"hits"`
+	LoadErrors  uint64  `json:"loadErrors"`
+	Loads       uint64  `json:"loads"`
+	Misses      uint64  `json:"misses"`
+}
+
+/*
+Stats returns the cache's counters
+*/
+func (c *Cache) Stats() Stats {
+	c.Lock()
+	defer c.Unlock()
+
+	result := c.stats
+	result.Entries = c.lru.Len()
+
+	if result.Hits+result.Misses > 0 {
+		result.HitRatio = float64(result.Hits) / float64(result.Hits+result.Misses)
+	}
+
+	return result
+}
+
+/*
+Hits returns how many lookups found an entry. Together with Misses, this
+satisfies serverstats.CacheStatsProvider
+*/
+func (c *Cache) Hits() uint64 {
+	return c.Stats().Hits
+}
+
+/*
+Misses returns how many lookups didn't find an entry
+*/
+func (c *Cache) Misses() uint64 {
+	return c.Stats().Misses
+}
+
+/*
+Name returns the name from the cache's config
+*/
+func (c *Cache) Name() string {
+	return c.config.Name
+}
+
+/*
+Collect returns the cache's stats in the form serverstats collectors
+report, so a named Cache can be passed to RegisterCollector
+*/
+func (c *Cache) Collect(ctx context.Context) map[string]interface{} {
+	stats := c.Stats()
+
+	return map[string]interface{}{
+		"entries":     stats.Entries,
+		"evictions":   stats.Evictions,
+		"expirations": stats.Expirations,
+		"hitRatio":    stats.HitRatio,
+		"hits":        stats.Hits,
+		"loadErrors":  stats.LoadErrors,
+		"loads":       stats.Loads,
+		"misses":      stats.Misses,
+	}
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+/*
+IClock describes a source of time. Code that reads the time, or waits,
+through an IClock can be tested with a Fake instead of sleeping
+*/
+type IClock interface {
+	After(d time.Duration) <-chan time.Time
+	NewTicker(d time.Duration) ITicker
+	NewTimer(d time.Duration) ITimer
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+/*
+ITimer describes a timer, like time.Timer, created by an IClock
+*/
+type ITimer interface {
+	C() <-chan time.Time
+	Reset(d time.Duration) bool
+	Stop() bool
+}
+
+/*
+ITicker describes a ticker, like time.Ticker, created by an IClock
+*/
+type ITicker interface {
+	C() <-chan time.Time
+	Reset(d time.Duration)
+	Stop()
+}
+
+/*
+Real is the system clock. Its methods call the time package
+*/
+type Real struct{}
+
+/*
+After waits for d to pass, then sends the current time on the returned
+channel
+*/
+func (Real) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+/*
+NewTicker returns a time.Ticker
+*/
+func (Real) NewTicker(d time.Duration) ITicker {
+	return &realTicker{ticker: time.NewTicker(d)}
+}
+
+/*
+NewTimer returns a time.Timer
+*/
+func (Real) NewTimer(d time.Duration) ITimer {
+	return &realTimer{timer: time.NewTimer(d)}
+}
+
+/*
+Now returns the current time
+*/
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+/*
+Since returns the time elapsed since t
+*/
+func (Real) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+/*
+Sleep waits for d to pass. It returns ctx's error if ctx is done first
+*/
+func (r Real) Sleep(ctx context.Context, d time.Duration) error {
+	return sleep(ctx, r, d)
+}
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (t *realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t *realTimer) Reset(d time.Duration) bool {
+	return t.timer.Reset(d)
+}
+
+func (t *realTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (t *realTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t *realTicker) Reset(d time.Duration) {
+	t.ticker.Reset(d)
+}
+
+func (t *realTicker) Stop() {
+	t.ticker.Stop()
+}
+
+func sleep(ctx context.Context, clock IClock, d time.Duration) error {
+	timer := clock.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C():
+		return nil
+
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package clock_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/clock"
+)
+
+var start = time.Date(2021, 6, 1, 9, 0, 0, 0, time.UTC)
+
+func TestFakeTimers(t *testing.T) {
+	fake := clock.NewFake(start)
+	early := fake.NewTimer(time.Minute)
+	late := fake.NewTimer(time.Hour)
+	stopped := fake.NewTimer(time.Minute)
+
+	if !stopped.Stop() || stopped.Stop() {
+		t.Errorf("Expected Stop to report only the first stop")
+	}
+
+	fake.Advance(time.Minute * 30)
+
+	select {
+	case fired := <-early.C():
+		if !fired.Equal(start.Add(time.Minute)) {
+			t.Errorf("Expected the timer to fire at its deadline but got %s", fired)
+		}
+
+	default:
+		t.Errorf("Expected the early timer to fire")
+	}
+
+	select {
+	case <-late.C():
+		t.Errorf("Expected the late timer not to fire yet")
+	case <-stopped.C():
+		t.Errorf("Expected the stopped timer not to fire")
+	default:
+	}
+
+	if fake.Since(start) != time.Minute*30 || fake.Timers() != 1 {
+		t.Errorf("Expected 30 minutes to pass with one timer left but got %s and %d", fake.Since(start), fake.Timers())
+	}
+
+	fake.Set(start.Add(time.Hour * 2))
+
+	if _, ok := <-late.C(); !ok || fake.Timers() != 0 {
+		t.Errorf("Expected the late timer to fire")
+	}
+}
+
+func TestFakeTicker(t *testing.T) {
+	fake := clock.NewFake(start)
+	ticker := fake.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	fake.Advance(time.Second)
+
+	if tick := <-ticker.C(); !tick.Equal(start.Add(time.Second)) {
+		t.Errorf("Expected a tick after a second but got %s", tick)
+	}
+
+	fake.Advance(time.Hour)
+
+	if tick := <-ticker.C(); !tick.Equal(start.Add(time.Second * 2)) {
+		t.Errorf("Expected the next tick but got %s", tick)
+	}
+
+	select {
+	case <-ticker.C():
+		t.Errorf("Expected ticks to be dropped for a slow receiver")
+	default:
+	}
+
+	fake.Advance(time.Second)
+
+	if tick := <-ticker.C(); !tick.Equal(start.Add(time.Hour + time.Second*2)) {
+		t.Errorf("Expected ticking to carry on after the skip but got %s", tick)
+	}
+}
+
+func TestFakeSleep(t *testing.T) {
+	fake := clock.NewFake(start)
+	done := make(chan error)
+
+	go func() {
+		done <- fake.Sleep(context.Background(), time.Minute)
+	}()
+
+	fake.WaitForTimers(1)
+	fake.Advance(time.Minute)
+
+	if err := <-done; err != nil {
+		t.Errorf("Expected no error but got '%s'", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := fake.Sleep(ctx, time.Minute); err != context.Canceled {
+		t.Errorf("Expected context.Canceled but got %v", err)
+	}
+}
+
+func TestReal(t *testing.T) {
+	var c clock.IClock = clock.Real{}
+
+	before := time.Now()
+
+	if err := c.Sleep(context.Background(), time.Millisecond); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	if c.Since(before) < time.Millisecond || c.Now().Before(before) {
+		t.Errorf("Expected the real clock to move forward")
+	}
+}
@@ -0,0 +1,264 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package clock
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+/*
+Fake is a clock for tests. Time only moves when Advance or Set is
+called, and timers, tickers, and sleeps waiting on it fire as time
+passes their deadlines. Use WaitForTimers to wait until code running in
+another goroutine has started waiting before moving time forward
+
+	fake := clock.NewFake(time.Date(2021, 6, 1, 9, 0, 0, 0, time.UTC))
+	scheduler := schedule.NewScheduler(schedule.SchedulerConfig{Clock: fake})
+	...
+	fake.WaitForTimers(1)
+	fake.Advance(time.Hour)
+*/
+type Fake struct {
+	changed *sync.Cond
+	mutex   sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+type fakeWaiter struct {
+	at     time.Time
+	c      chan time.Time
+	fake   *Fake
+	period time.Duration
+}
+
+/*
+NewFake creates a fake clock set to now
+*/
+func NewFake(now time.Time) *Fake {
+	f := &Fake{
+		now:     now,
+		waiters: make([]*fakeWaiter, 0),
+	}
+
+	f.changed = sync.NewCond(&f.mutex)
+	return f
+}
+
+/*
+Advance moves the clock forward by d, firing every timer and ticker
+whose deadline has been reached, earliest first
+*/
+func (f *Fake) Advance(d time.Duration) {
+	f.mutex.Lock()
+	target := f.now.Add(d)
+	f.mutex.Unlock()
+
+	f.Set(target)
+}
+
+/*
+Set moves the clock to t, firing every timer and ticker whose deadline
+has been reached, earliest first. Moving the clock backwards fires
+nothing
+*/
+func (f *Fake) Set(t time.Time) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for {
+		sort.Slice(f.waiters, func(i, j int) bool {
+			return f.waiters[i].at.Before(f.waiters[j].at)
+		})
+
+		if len(f.waiters) == 0 || f.waiters[0].at.After(t) {
+			break
+		}
+
+		waiter := f.waiters[0]
+		f.now = waiter.at
+
+		select {
+		case waiter.c <- waiter.at:
+		default:
+		}
+
+		// Tickers whose receivers haven't kept up drop ticks, so a
+		// ticker skips straight to its first deadline after t
+		if waiter.period > 0 {
+			waiter.at = waiter.at.Add(waiter.period)
+
+			if !waiter.at.After(t) {
+				waiter.at = waiter.at.Add((t.Sub(waiter.at)/waiter.period + 1) * waiter.period)
+			}
+
+			continue
+		}
+
+		f.waiters = f.waiters[1:]
+	}
+
+	f.now = t
+	f.changed.Broadcast()
+}
+
+/*
+WaitForTimers blocks until at least n timers, tickers, and sleeps are
+waiting on the clock
+*/
+func (f *Fake) WaitForTimers(n int) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for len(f.waiters) < n {
+		f.changed.Wait()
+	}
+}
+
+/*
+Timers returns how many timers, tickers, and sleeps are waiting on the
+clock
+*/
+func (f *Fake) Timers() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return len(f.waiters)
+}
+
+/*
+After sends the clock's time on the returned channel once d has passed
+*/
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+/*
+NewTicker creates a ticker that fires every d as the clock advances. As
+with time.Ticker, ticks are dropped for slow receivers
+*/
+func (f *Fake) NewTicker(d time.Duration) ITicker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+
+	return &fakeTicker{waiter: f.addWaiter(d, d)}
+}
+
+/*
+NewTimer creates a timer that fires once d has passed on the clock
+*/
+func (f *Fake) NewTimer(d time.Duration) ITimer {
+	return f.addWaiter(d, 0)
+}
+
+/*
+Now returns the clock's time
+*/
+func (f *Fake) Now() time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.now
+}
+
+/*
+Since returns the time elapsed on the clock since t
+*/
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+/*
+Sleep waits for the clock to advance by d. It returns ctx's error if
+ctx is done first
+*/
+func (f *Fake) Sleep(ctx context.Context, d time.Duration) error {
+	return sleep(ctx, f, d)
+}
+
+func (f *Fake) addWaiter(d, period time.Duration) *fakeWaiter {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	waiter := &fakeWaiter{
+		at:     f.now.Add(d),
+		c:      make(chan time.Time, 1),
+		fake:   f,
+		period: period,
+	}
+
+	if d <= 0 {
+		waiter.c <- f.now
+		return waiter
+	}
+
+	f.waiters = append(f.waiters, waiter)
+	f.changed.Broadcast()
+
+	return waiter
+}
+
+/*
+remove stops a waiter. It returns false when the waiter had already
+fired or been stopped. The caller must hold the lock
+*/
+func (f *Fake) remove(waiter *fakeWaiter) bool {
+	for index, w := range f.waiters {
+		if w == waiter {
+			f.waiters = append(f.waiters[:index], f.waiters[index+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *fakeWaiter) C() <-chan time.Time {
+	return w.c
+}
+
+func (w *fakeWaiter) Reset(d time.Duration) bool {
+	w.fake.mutex.Lock()
+	defer w.fake.mutex.Unlock()
+
+	active := w.fake.remove(w)
+
+	if w.period > 0 {
+		w.period = d
+	}
+
+	w.at = w.fake.now.Add(d)
+	w.fake.waiters = append(w.fake.waiters, w)
+	w.fake.changed.Broadcast()
+
+	return active
+}
+
+func (w *fakeWaiter) Stop() bool {
+	w.fake.mutex.Lock()
+	defer w.fake.mutex.Unlock()
+
+	return w.fake.remove(w)
+}
+
+type fakeTicker struct {
+	waiter *fakeWaiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.waiter.c
+}
+
+func (t *fakeTicker) Reset(d time.Duration) {
+	t.waiter.Reset(d)
+}
+
+func (t *fakeTicker) Stop() {
+	t.waiter.Stop()
+}
@@ -0,0 +1,205 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package collections_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/ResurgenceIT/kit/v6/collections"
+)
+
+func TestChunkProperties(t *testing.T) {
+	property := func(values []int, size uint8) bool {
+		chunkSize := int(size%10) + 1
+		chunks := collections.Chunk(values, chunkSize).([][]int)
+		joined := make([]int, 0, len(values))
+
+		for index, chunk := range chunks {
+			if len(chunk) == 0 || len(chunk) > chunkSize || (index < len(chunks)-1 && len(chunk) != chunkSize) {
+				return false
+			}
+
+			joined = append(joined, chunk...)
+		}
+
+		return len(joined) == len(values) && (len(values) == 0 || reflect.DeepEqual(joined, values))
+	}
+
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUniqueProperties(t *testing.T) {
+	property := func(values []uint8) bool {
+		unique := collections.Unique(values).([]uint8)
+		seen := collections.NewSet()
+
+		for _, value := range unique {
+			if seen.Contains(value) {
+				return false
+			}
+
+			seen.Add(value)
+		}
+
+		for _, value := range values {
+			if !seen.Contains(value) {
+				return false
+			}
+		}
+
+		/*
+		 * The first of each value is kept, so unique is a subsequence
+		 * of values
+		 */
+		position := 0
+
+		for _, value := range values {
+			if position < len(unique) && unique[position] == value {
+				position++
+			}
+		}
+
+		return position == len(unique)
+	}
+
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFilterAndDifferenceProperties(t *testing.T) {
+	property := func(a, b []int8) bool {
+		difference := collections.Difference(a, b).([]int8)
+		kept := collections.Filter(a, func(index int) bool { return !collections.Contains(b, a[index]) }).([]int8)
+
+		if len(difference) != len(kept) {
+			return false
+		}
+
+		for index, value := range difference {
+			if value != kept[index] || collections.Contains(b, value) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGroupByProperties(t *testing.T) {
+	property := func(values []int) bool {
+		groups := collections.GroupBy(values, func(index int) interface{} { return values[index] % 3 })
+		total := 0
+
+		for key, group := range groups {
+			for _, value := range group.([]int) {
+				if value%3 != key {
+					return false
+				}
+			}
+
+			total += len(group.([]int))
+		}
+
+		return total == len(values)
+	}
+
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSetProperties(t *testing.T) {
+	toSet := func(values []uint8) collections.Set {
+		result := collections.NewSet()
+
+		for _, value := range values {
+			result.Add(value)
+		}
+
+		return result
+	}
+
+	property := func(a, b []uint8) bool {
+		setA, setB := toSet(a), toSet(b)
+		union, intersection := setA.Union(setB), setA.Intersect(setB)
+
+		return union.Len() == setA.Len()+setB.Len()-intersection.Len() &&
+			setA.Difference(setB).Union(intersection).Equal(setA) &&
+			union.Equal(setB.Union(setA))
+	}
+
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMap(t *testing.T) {
+	type user struct {
+		ID   string
+		Name string
+	}
+
+	users := []user{{ID: "1", Name: "Bob"}, {ID: "2", Name: "Alice"}}
+	var ids []string
+
+	collections.Map(users, &ids, func(index int) interface{} { return users[index].ID })
+
+	if fmt.Sprint(ids) != "[1 2]" {
+		t.Errorf("Expected [1 2] but got %v", ids)
+	}
+
+	users = append(users, user{ID: "3", Name: "Bob"})
+	byName := collections.UniqueBy(users, func(index int) interface{} { return users[index].Name }).([]user)
+
+	if len(byName) != 2 || byName[0].ID != "1" {
+		t.Errorf("Expected 2 users with unique names but got %v", byName)
+	}
+}
+
+func TestOrderedMapJSON(t *testing.T) {
+	m := collections.NewOrderedMap()
+	m.Set("zebra", 1)
+	m.Set("apple", "two")
+	m.Set("mango", []int{3})
+	m.Set("zebra", 4)
+	m.Delete("mango")
+
+	b, err := json.Marshal(m)
+
+	if err != nil || string(b) != `{"zebra":4,"apple":"two"}` {
+		t.Fatalf("Expected keys in insertion order but got %s, %v", b, err)
+	}
+
+	decoded := collections.NewOrderedMap()
+
+	if err = json.Unmarshal([]byte(`{"c": 1, "a": {"x": true}, "b": null}`), decoded); err != nil {
+		t.Fatalf("Expected the object to be read but got %s", err.Error())
+	}
+
+	if fmt.Sprint(decoded.Keys()) != "[c a b]" {
+		t.Errorf("Expected [c a b] but got %v", decoded.Keys())
+	}
+
+	set := collections.NewSet()
+
+	if err = json.Unmarshal([]byte(`["a", "b", "a"]`), &set); err != nil || fmt.Sprint(set.Strings()) != "[a b]" {
+		t.Errorf("Expected a set of [a b] but got %v, %v", set.Strings(), err)
+	}
+
+	if err = json.Unmarshal([]byte(`[{"a": 1}]`), &set); err == nil {
+		t.Errorf("Expected an error for objects in a set")
+	}
+}
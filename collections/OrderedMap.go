@@ -0,0 +1,177 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package collections
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"fmt"
+)
+
+/*
+OrderedMap is a map that remembers the order keys were added in, and
+keeps it when written to and read from JSON, such as for settings shown
+in a fixed order. It isn't safe for concurrent use
+*/
+type OrderedMap struct {
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type orderedEntry struct {
+	key   string
+	value interface{}
+}
+
+/*
+NewOrderedMap creates an empty map
+*/
+func NewOrderedMap() *OrderedMap {
+	return &OrderedMap{
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+/*
+Get returns the value under key
+*/
+func (m *OrderedMap) Get(key string) (interface{}, bool) {
+	element, ok := m.entries[key]
+
+	if !ok {
+		return nil, false
+	}
+
+	return element.Value.(*orderedEntry).value, true
+}
+
+/*
+Set stores value under key. New keys go at the end, and existing keys
+keep their place
+*/
+func (m *OrderedMap) Set(key string, value interface{}) {
+	if element, ok := m.entries[key]; ok {
+		element.Value.(*orderedEntry).value = value
+		return
+	}
+
+	m.entries[key] = m.order.PushBack(&orderedEntry{key: key, value: value})
+}
+
+/*
+Delete removes key
+*/
+func (m *OrderedMap) Delete(key string) {
+	if element, ok := m.entries[key]; ok {
+		m.order.Remove(element)
+		delete(m.entries, key)
+	}
+}
+
+/*
+Len returns how many keys the map has
+*/
+func (m *OrderedMap) Len() int {
+	return len(m.entries)
+}
+
+/*
+Keys returns the keys in order
+*/
+func (m *OrderedMap) Keys() []string {
+	result := make([]string, 0, len(m.entries))
+
+	for element := m.order.Front(); element != nil; element = element.Next() {
+		result = append(result, element.Value.(*orderedEntry).key)
+	}
+
+	return result
+}
+
+/*
+Range calls fn with each key and value in order until fn returns false.
+fn may delete the key it is given
+*/
+func (m *OrderedMap) Range(fn func(key string, value interface{}) bool) {
+	for element := m.order.Front(); element != nil; {
+		next := element.Next()
+		e := element.Value.(*orderedEntry)
+
+		if !fn(e.key, e.value) {
+			return
+		}
+
+		element = next
+	}
+}
+
+/*
+MarshalJSON writes the map as an object with its keys in order
+*/
+func (m *OrderedMap) MarshalJSON() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	buffer.WriteByte('{')
+
+	for element := m.order.Front(); element != nil; element = element.Next() {
+		e := element.Value.(*orderedEntry)
+
+		if element != m.order.Front() {
+			buffer.WriteByte(',')
+		}
+
+		key, _ := json.Marshal(e.key)
+		value, err := json.Marshal(e.value)
+
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling %s: %w", e.key, err)
+		}
+
+		buffer.Write(key)
+		buffer.WriteByte(':')
+		buffer.Write(value)
+	}
+
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+/*
+UnmarshalJSON reads an object into the map, keeping the order of its
+keys. Values have the types encoding/json gives interface{} values.
+Nested objects are read as plain maps
+*/
+func (m *OrderedMap) UnmarshalJSON(b []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	token, err := decoder.Token()
+
+	if err != nil {
+		return err
+	}
+
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("expected a JSON object but got %v", token)
+	}
+
+	*m = *NewOrderedMap()
+
+	for decoder.More() {
+		if token, err = decoder.Token(); err != nil {
+			return err
+		}
+
+		var value interface{}
+
+		if err = decoder.Decode(&value); err != nil {
+			return err
+		}
+
+		m.Set(token.(string), value)
+	}
+
+	_, err = decoder.Token()
+	return err
+}
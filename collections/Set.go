@@ -0,0 +1,187 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package collections
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+/*
+Set is a set of comparable values. It is a map, so the zero value
+can't be added to; create sets with NewSet. Sets aren't safe for
+concurrent use
+*/
+type Set map[interface{}]struct{}
+
+/*
+NewSet creates a set holding members
+*/
+func NewSet(members ...interface{}) Set {
+	result := make(Set, len(members))
+
+	for _, member := range members {
+		result[member] = struct{}{}
+	}
+
+	return result
+}
+
+/*
+Add adds members to the set
+*/
+func (s Set) Add(members ...interface{}) {
+	for _, member := range members {
+		s[member] = struct{}{}
+	}
+}
+
+/*
+Remove takes members out of the set
+*/
+func (s Set) Remove(members ...interface{}) {
+	for _, member := range members {
+		delete(s, member)
+	}
+}
+
+/*
+Contains reports whether member is in the set
+*/
+func (s Set) Contains(member interface{}) bool {
+	_, ok := s[member]
+	return ok
+}
+
+/*
+Len returns how many members the set has
+*/
+func (s Set) Len() int {
+	return len(s)
+}
+
+/*
+Union returns a new set with the members of both sets
+*/
+func (s Set) Union(other Set) Set {
+	result := make(Set, len(s)+len(other))
+
+	for member := range s {
+		result[member] = struct{}{}
+	}
+
+	for member := range other {
+		result[member] = struct{}{}
+	}
+
+	return result
+}
+
+/*
+Intersect returns a new set with the members in both sets
+*/
+func (s Set) Intersect(other Set) Set {
+	result := make(Set)
+
+	for member := range s {
+		if other.Contains(member) {
+			result[member] = struct{}{}
+		}
+	}
+
+	return result
+}
+
+/*
+Difference returns a new set with the members of s that aren't in other
+*/
+func (s Set) Difference(other Set) Set {
+	result := make(Set)
+
+	for member := range s {
+		if !other.Contains(member) {
+			result[member] = struct{}{}
+		}
+	}
+
+	return result
+}
+
+/*
+Equal reports whether both sets have the same members
+*/
+func (s Set) Equal(other Set) bool {
+	if len(s) != len(other) {
+		return false
+	}
+
+	for member := range s {
+		if !other.Contains(member) {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+Members returns the set's members in no particular order
+*/
+func (s Set) Members() []interface{} {
+	result := make([]interface{}, 0, len(s))
+
+	for member := range s {
+		result = append(result, member)
+	}
+
+	return result
+}
+
+/*
+Strings returns the set's members formatted with fmt and sorted, which
+is handy for sets of strings and for stable output
+*/
+func (s Set) Strings() []string {
+	result := make([]string, 0, len(s))
+
+	for member := range s {
+		result = append(result, fmt.Sprint(member))
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+/*
+MarshalJSON writes the set as an array of its members
+*/
+func (s Set) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Members())
+}
+
+/*
+UnmarshalJSON reads an array into the set. Members have the types
+encoding/json gives interface{} values, such as float64 for numbers.
+Arrays holding objects or arrays can't be read, as those aren't
+comparable
+*/
+func (s *Set) UnmarshalJSON(b []byte) error {
+	members := make([]interface{}, 0)
+
+	if err := json.Unmarshal(b, &members); err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		if member != nil && !reflect.TypeOf(member).Comparable() {
+			return fmt.Errorf("set members must be comparable, not %T", member)
+		}
+	}
+
+	*s = NewSet(members...)
+	return nil
+}
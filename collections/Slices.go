@@ -0,0 +1,188 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package collections
+
+import (
+	"reflect"
+)
+
+/*
+Map calls fn with each index of slice and appends the results to the
+slice dest points to, which must have the type fn's results are.
+
+	var ids []string
+	collections.Map(users, &ids, func(index int) interface{} { return users[index].ID })
+*/
+func Map(slice interface{}, dest interface{}, fn func(index int) interface{}) {
+	source := sliceValue(slice)
+	target := reflect.ValueOf(dest).Elem()
+
+	for index := 0; index < source.Len(); index++ {
+		value := reflect.ValueOf(fn(index))
+
+		if !value.IsValid() {
+			value = reflect.Zero(target.Type().Elem())
+		}
+
+		target = reflect.Append(target, value)
+	}
+
+	reflect.ValueOf(dest).Elem().Set(target)
+}
+
+/*
+Filter returns the elements of slice for which keep returns true.
+
+	active := collections.Filter(users, func(index int) bool { return users[index].Active }).([]User)
+*/
+func Filter(slice interface{}, keep func(index int) bool) interface{} {
+	source := sliceValue(slice)
+	result := reflect.MakeSlice(source.Type(), 0, source.Len())
+
+	for index := 0; index < source.Len(); index++ {
+		if keep(index) {
+			result = reflect.Append(result, source.Index(index))
+		}
+	}
+
+	return result.Interface()
+}
+
+/*
+Chunk splits slice into slices of size elements, the last holding what
+is left. The chunks share slice's backing array.
+
+	for _, chunk := range collections.Chunk(ids, 500).([][]string) {
+		...
+	}
+*/
+func Chunk(slice interface{}, size int) interface{} {
+	if size <= 0 {
+		panic("collections: chunk size must be greater than zero")
+	}
+
+	source := sliceValue(slice)
+	result := reflect.MakeSlice(reflect.SliceOf(source.Type()), 0, (source.Len()+size-1)/size)
+
+	for start := 0; start < source.Len(); start += size {
+		end := start + size
+
+		if end > source.Len() {
+			end = source.Len()
+		}
+
+		result = reflect.Append(result, source.Slice3(start, end, end))
+	}
+
+	return result.Interface()
+}
+
+/*
+Unique returns the elements of slice without duplicates, keeping the
+first of each. Elements must be comparable
+*/
+func Unique(slice interface{}) interface{} {
+	return UniqueBy(slice, func(index int) interface{} {
+		return sliceValue(slice).Index(index).Interface()
+	})
+}
+
+/*
+UniqueBy returns the elements of slice without those whose key was seen
+earlier in the slice. Keys must be comparable.
+
+	customers := collections.UniqueBy(orders, func(index int) interface{} { return orders[index].CustomerID }).([]Order)
+*/
+func UniqueBy(slice interface{}, key func(index int) interface{}) interface{} {
+	seen := make(map[interface{}]struct{})
+
+	return Filter(slice, func(index int) bool {
+		k := key(index)
+
+		if _, ok := seen[k]; ok {
+			return false
+		}
+
+		seen[k] = struct{}{}
+		return true
+	})
+}
+
+/*
+GroupBy returns the elements of slice grouped by key, each group a slice
+of slice's type in the original order.
+
+	byStatus := collections.GroupBy(orders, func(index int) interface{} { return orders[index].Status })
+	shipped := byStatus["shipped"].([]Order)
+*/
+func GroupBy(slice interface{}, key func(index int) interface{}) map[interface{}]interface{} {
+	source := sliceValue(slice)
+	groups := make(map[interface{}]reflect.Value)
+
+	for index := 0; index < source.Len(); index++ {
+		k := key(index)
+		group, ok := groups[k]
+
+		if !ok {
+			group = reflect.MakeSlice(source.Type(), 0, 1)
+		}
+
+		groups[k] = reflect.Append(group, source.Index(index))
+	}
+
+	result := make(map[interface{}]interface{}, len(groups))
+
+	for k, group := range groups {
+		result[k] = group.Interface()
+	}
+
+	return result
+}
+
+/*
+Difference returns the elements of a that aren't in b. Both must be
+slices of the same comparable type.
+
+	removed := collections.Difference(oldTags, newTags).([]string)
+*/
+func Difference(a, b interface{}) interface{} {
+	exclude := NewSet()
+	other := sliceValue(b)
+
+	for index := 0; index < other.Len(); index++ {
+		exclude.Add(other.Index(index).Interface())
+	}
+
+	source := sliceValue(a)
+
+	return Filter(a, func(index int) bool {
+		return !exclude.Contains(source.Index(index).Interface())
+	})
+}
+
+/*
+Contains reports whether slice holds value. Elements must be comparable
+*/
+func Contains(slice interface{}, value interface{}) bool {
+	source := sliceValue(slice)
+
+	for index := 0; index < source.Len(); index++ {
+		if source.Index(index).Interface() == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sliceValue(slice interface{}) reflect.Value {
+	value := reflect.ValueOf(slice)
+
+	if value.Kind() != reflect.Slice {
+		panic("collections: expected a slice but got " + value.Kind().String())
+	}
+
+	return value
+}
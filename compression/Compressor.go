@@ -0,0 +1,249 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package compression
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// Brotli is the br content encoding
+	Brotli = "br"
+
+	// Gzip is the gzip content encoding
+	Gzip = "gzip"
+)
+
+var (
+	ErrInvalidLevel    = errors.New("invalid compression level")
+	ErrUnknownEncoding = errors.New("unknown encoding")
+)
+
+/*
+Compressor compresses responses with brotli or gzip, whichever the
+client accepts and the config prefers. Responses that are too small,
+have a content type that isn't allowed, or are already compressed are
+sent as they are. It keeps stats on how many bytes compression saved,
+and can be registered with serverstats as a collector
+*/
+type Compressor struct {
+	config CompressorConfig
+	pools  map[string]*sync.Pool
+	stats  *statsTracker
+}
+
+/*
+NewCompressor creates a Compressor, filling in defaults for anything not
+set in config. An error is returned for unknown encodings and levels out
+of range
+*/
+func NewCompressor(config CompressorConfig) (*Compressor, error) {
+	if len(config.ContentTypes) == 0 {
+		config.ContentTypes = DefaultContentTypes
+	}
+
+	if len(config.Encodings) == 0 {
+		config.Encodings = []string{Brotli, Gzip}
+	}
+
+	if config.BrotliLevel == 0 {
+		config.BrotliLevel = 4
+	}
+
+	if config.GzipLevel == 0 {
+		config.GzipLevel = 5
+	}
+
+	if config.MinSize <= 0 {
+		config.MinSize = 1024
+	}
+
+	if config.Name == "" {
+		config.Name = "compression"
+	}
+
+	if config.BrotliLevel < brotli.BestSpeed || config.BrotliLevel > brotli.BestCompression {
+		return nil, fmt.Errorf("%w: brotli level %d", ErrInvalidLevel, config.BrotliLevel)
+	}
+
+	if config.GzipLevel < gzip.HuffmanOnly || config.GzipLevel > gzip.BestCompression {
+		return nil, fmt.Errorf("%w: gzip level %d", ErrInvalidLevel, config.GzipLevel)
+	}
+
+	result := &Compressor{
+		config: config,
+		pools:  make(map[string]*sync.Pool, len(config.Encodings)),
+		stats:  newStatsTracker(),
+	}
+
+	for _, encoding := range config.Encodings {
+		switch encoding {
+		case Brotli:
+			result.pools[Brotli] = &sync.Pool{New: func() interface{} {
+				return brotli.NewWriterLevel(ioutil.Discard, config.BrotliLevel)
+			}}
+
+		case Gzip:
+			result.pools[Gzip] = &sync.Pool{New: func() interface{} {
+				w, _ := gzip.NewWriterLevel(ioutil.Discard, config.GzipLevel)
+				return w
+			}}
+
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrUnknownEncoding, encoding)
+		}
+	}
+
+	return result, nil
+}
+
+/*
+Middleware compresses responses for the Echo framework
+*/
+func (c *Compressor) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		if c.config.Skipper != nil && c.config.Skipper(ctx.Request()) {
+			return next(ctx)
+		}
+
+		response := ctx.Response()
+		original := response.Writer
+		writer := c.newResponseWriter(original, ctx.Request())
+		response.Writer = writer
+
+		// Echo writes errors after the middleware returns, so they go to the original writer uncompressed
+		defer func() {
+			writer.close()
+			response.Writer = original
+		}()
+
+		return next(ctx)
+	}
+}
+
+/*
+HTTPMiddleware compresses responses for a plain net/http handler
+*/
+func (c *Compressor) HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.config.Skipper != nil && c.config.Skipper(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		writer := c.newResponseWriter(w, r)
+		defer writer.close()
+
+		next.ServeHTTP(writer, r)
+	})
+}
+
+/*
+negotiate picks the encoding from an Accept-Encoding header. The
+highest quality wins, and ties go to the encoding listed first in the
+config. An empty string means the response shouldn't be compressed
+*/
+func (c *Compressor) negotiate(acceptEncoding string) string {
+	qualities := map[string]float64{}
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		quality := 1.0
+
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		if name != "" {
+			qualities[name] = quality
+		}
+	}
+
+	best := ""
+	bestQuality := 0.0
+
+	for _, encoding := range c.config.Encodings {
+		quality, ok := qualities[encoding]
+
+		if !ok {
+			quality = qualities["*"]
+		}
+
+		if quality > bestQuality {
+			best = encoding
+			bestQuality = quality
+		}
+	}
+
+	return best
+}
+
+/*
+allowed reports whether a content type is in the config's ContentTypes
+*/
+func (c *Compressor) allowed(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+
+	if err != nil {
+		return false
+	}
+
+	for _, pattern := range c.config.ContentTypes {
+		switch {
+		case pattern == mediaType:
+			return true
+
+		case strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(pattern, "*")):
+			return true
+
+		case strings.Contains(pattern, "/*+"):
+			prefix := pattern[:strings.Index(pattern, "*")]
+			suffix := pattern[strings.Index(pattern, "*")+1:]
+
+			if strings.HasPrefix(mediaType, prefix) && strings.HasSuffix(mediaType, suffix) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+func (c *Compressor) getEncoder(encoding string, w io.Writer) encoder {
+	result := c.pools[encoding].Get().(encoder)
+	result.Reset(w)
+
+	return result
+}
+
+func (c *Compressor) putEncoder(encoding string, e encoder) {
+	e.Reset(ioutil.Discard)
+	c.pools[encoding].Put(e)
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package compression
+
+import (
+	"net/http"
+)
+
+/*
+DefaultContentTypes are the content types compressed when none are
+configured. Images, video, archives, and event streams are left out, as
+they are either compressed already or need to reach the client as they
+are written
+*/
+var DefaultContentTypes = []string{
+	"application/*+json",
+	"application/*+xml",
+	"application/javascript",
+	"application/json",
+	"application/wasm",
+	"application/xml",
+	"font/otf",
+	"font/ttf",
+	"image/svg+xml",
+	"image/x-icon",
+	"text/calendar",
+	"text/css",
+	"text/csv",
+	"text/html",
+	"text/javascript",
+	"text/markdown",
+	"text/plain",
+	"text/xml",
+}
+
+/*
+CompressorConfig configures a Compressor.
+
+ContentTypes are the content types to compress, and default to
+DefaultContentTypes. Entries can be exact, such as "application/json",
+cover a whole type, such as "text/*", or cover a suffix, such as
+"application/*+json". Responses without a Content-Type are sniffed.
+
+Encodings are the encodings offered, most preferred first, and default
+to "br" then "gzip". BrotliLevel defaults to 4 and GzipLevel to 5,
+which compress well without slowing responses much.
+
+MinSize is how many bytes a response needs before it's compressed, and
+defaults to 1024. Smaller responses can grow when compressed. Requests
+that Skipper returns true for aren't compressed.
+
+Name identifies the compressor's stats in serverstats, and defaults to
+"compression"
+*/
+type CompressorConfig struct {
+	BrotliLevel  int
+	ContentTypes []string
+	Encodings    []string
+	GzipLevel    int
+	MinSize      int
+	Name         string
+	Skipper      func(r *http.Request) bool
+}
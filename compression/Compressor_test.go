@@ -0,0 +1,252 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package compression_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/compression"
+	"github.com/andybalholm/brotli"
+	"github.com/labstack/echo/v4"
+)
+
+var body = strings.Repeat(`{"name":"widget","price":"12.34"},`, 100)
+
+func decode(t *testing.T, encoding string, b []byte) string {
+	var reader io.Reader = bytes.NewReader(b)
+
+	switch encoding {
+	case "br":
+		reader = brotli.NewReader(reader)
+
+	case "gzip":
+		gz, err := gzip.NewReader(reader)
+
+		if err != nil {
+			t.Fatalf("Expected gzip but got %v", err)
+		}
+
+		reader = gz
+	}
+
+	result, err := ioutil.ReadAll(reader)
+
+	if err != nil {
+		t.Fatalf("Expected to decode %s but got %v", encoding, err)
+	}
+
+	return string(result)
+}
+
+func TestCompressor(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		acceptEncoding string
+		handler        http.HandlerFunc
+		encoding       string
+		vary           bool
+	}{
+		{
+			name:           "Brotli preferred",
+			acceptEncoding: "gzip, deflate, br",
+			handler:        write("application/json", http.StatusOK, body),
+			encoding:       "br",
+			vary:           true,
+		},
+		{
+			name:           "Gzip only",
+			acceptEncoding: "gzip",
+			handler:        write("application/json; charset=utf-8", http.StatusCreated, body),
+			encoding:       "gzip",
+			vary:           true,
+		},
+		{
+			name:           "Quality",
+			acceptEncoding: "br;q=0.5, gzip",
+			handler:        write("application/problem+json", http.StatusOK, body),
+			encoding:       "gzip",
+			vary:           true,
+		},
+		{
+			name:           "Refused",
+			acceptEncoding: "*;q=0, identity",
+			handler:        write("text/css", http.StatusOK, body),
+			vary:           true,
+		},
+		{
+			name:           "Too small",
+			acceptEncoding: "gzip",
+			handler:        write("application/json", http.StatusOK, `{"ok":true}`),
+			vary:           true,
+		},
+		{
+			name:           "Content type not allowed",
+			acceptEncoding: "gzip",
+			handler:        write("image/png", http.StatusOK, body),
+		},
+		{
+			name:           "Sniffed",
+			acceptEncoding: "gzip",
+			handler:        write("", http.StatusOK, "<html><body>"+body+"</body></html>"),
+			encoding:       "gzip",
+			vary:           true,
+		},
+		{
+			name:           "Already compressed",
+			acceptEncoding: "gzip",
+			handler:        write("text/plain", http.StatusOK, "\x1f\x8b"+body),
+			vary:           true,
+		},
+		{
+			name:           "Encoded by handler",
+			acceptEncoding: "gzip",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Encoding", "deflate")
+				write("text/plain", http.StatusOK, body)(w, r)
+			},
+			encoding: "deflate",
+		},
+		{
+			name:           "Head",
+			method:         http.MethodHead,
+			acceptEncoding: "gzip",
+			handler:        write("text/html", http.StatusOK, ""),
+		},
+	}
+
+	compressor, _ := compression.NewCompressor(compression.CompressorConfig{})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			method := test.method
+
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			request := httptest.NewRequest(method, "/", nil)
+			request.Header.Set("Accept-Encoding", test.acceptEncoding)
+			recorder := httptest.NewRecorder()
+			compressor.HTTPMiddleware(test.handler).ServeHTTP(recorder, request)
+
+			if got := recorder.Header().Get("Content-Encoding"); got != test.encoding {
+				t.Fatalf("Expected encoding %q but got %q", test.encoding, got)
+			}
+
+			if vary := recorder.Header().Get("Vary") == "Accept-Encoding"; vary != test.vary {
+				t.Errorf("Expected Vary %v but got %q", test.vary, recorder.Header().Get("Vary"))
+			}
+
+			if test.encoding == "br" || test.encoding == "gzip" {
+				if recorder.Header().Get("Content-Length") != "" {
+					t.Errorf("Expected Content-Length to be removed")
+				}
+
+				if decoded := decode(t, test.encoding, recorder.Body.Bytes()); !strings.Contains(decoded, body) {
+					t.Errorf("Expected the original body but got %q", decoded)
+				}
+			}
+		})
+	}
+
+	stats := compressor.Stats()
+
+	if stats.Compressed != 4 || stats.Encodings["br"] != 1 || stats.Encodings["gzip"] != 3 || stats.BytesSaved <= 0 || stats.Ratio >= 0.5 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	if collected := compressor.Collect(context.Background()); collected["compressed"] != uint64(4) || compressor.Name() != "compression" {
+		t.Errorf("Unexpected collected stats %v", collected)
+	}
+}
+
+func TestCompressorStreaming(t *testing.T) {
+	compressor, _ := compression.NewCompressor(compression.CompressorConfig{Encodings: []string{compression.Gzip}})
+
+	handler := compressor.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("first "))
+		w.(http.Flusher).Flush()
+		_, _ = w.Write([]byte("second"))
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("Accept-Encoding", "gzip")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	if !recorder.Flushed || recorder.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected a flushed gzip stream")
+	}
+
+	if decoded := decode(t, "gzip", recorder.Body.Bytes()); decoded != "first second" {
+		t.Errorf("Expected 'first second' but got %q", decoded)
+	}
+}
+
+func TestCompressorEcho(t *testing.T) {
+	compressor, _ := compression.NewCompressor(compression.CompressorConfig{})
+
+	e := echo.New()
+	e.Use(compressor.Middleware)
+
+	e.GET("/widgets", func(ctx echo.Context) error {
+		return ctx.String(http.StatusOK, body)
+	})
+
+	e.GET("/fail", func(ctx echo.Context) error {
+		return echo.NewHTTPError(http.StatusTeapot, "short and stout")
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/widgets", nil)
+	request.Header.Set("Accept-Encoding", "br")
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, request)
+
+	if decoded := decode(t, recorder.Header().Get("Content-Encoding"), recorder.Body.Bytes()); decoded != body {
+		t.Errorf("Expected the body to round trip but got %q", decoded)
+	}
+
+	request = httptest.NewRequest(http.MethodGet, "/fail", nil)
+	request.Header.Set("Accept-Encoding", "br")
+	recorder = httptest.NewRecorder()
+	e.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusTeapot || !strings.Contains(recorder.Body.String(), "short and stout") {
+		t.Errorf("Expected the error to be written but got %d %q", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestNewCompressorErrors(t *testing.T) {
+	if _, err := compression.NewCompressor(compression.CompressorConfig{Encodings: []string{"zstd"}}); !errors.Is(err, compression.ErrUnknownEncoding) {
+		t.Errorf("Expected ErrUnknownEncoding but got %v", err)
+	}
+
+	if _, err := compression.NewCompressor(compression.CompressorConfig{GzipLevel: 12}); !errors.Is(err, compression.ErrInvalidLevel) {
+		t.Errorf("Expected ErrInvalidLevel but got %v", err)
+	}
+}
+
+func write(contentType string, status int, s string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+
+		w.Header().Set("Content-Length", "123")
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, s)
+	}
+}
@@ -0,0 +1,252 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package compression
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var compressedMagic = [][]byte{
+	{0x1f, 0x8b},               // gzip
+	{0x28, 0xb5, 0x2f, 0xfd},   // zstd
+	[]byte("PK\x03\x04"),       // zip
+	[]byte("BZh"),              // bzip2
+	{0xfd, '7', 'z', 'X', 'Z'}, // xz
+}
+
+/*
+responseWriter holds back the start of a response until it knows enough
+to decide whether to compress it: MinSize bytes, the end of the
+response, or a flush
+*/
+type responseWriter struct {
+	http.ResponseWriter
+
+	buffer     []byte
+	compressor *Compressor
+	counter    *countingWriter
+	decided    bool
+	encoder    encoder
+	encoding   string
+	hijacked   bool
+	request    *http.Request
+	status     int
+	written    int64
+}
+
+type countingWriter struct {
+	n int64
+	w io.Writer
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	w.n += int64(n)
+
+	return n, err
+}
+
+func (c *Compressor) newResponseWriter(w http.ResponseWriter, r *http.Request) *responseWriter {
+	return &responseWriter{
+		ResponseWriter: w,
+		compressor:     c,
+		encoding:       c.negotiate(r.Header.Get("Accept-Encoding")),
+		request:        r,
+	}
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.decided || w.status != 0 {
+		return
+	}
+
+	// Informational responses, such as 103 Early Hints, go straight through
+	if status < http.StatusOK {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	w.status = status
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buffer = append(w.buffer, b...)
+
+		if len(w.buffer) < w.compressor.config.MinSize {
+			return len(b), nil
+		}
+
+		if err := w.decide(false); err != nil {
+			return 0, err
+		}
+
+		return len(b), nil
+	}
+
+	if w.encoder != nil {
+		w.written += int64(len(b))
+		return w.encoder.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+/*
+Flush sends what has been written so far, compressed if the response is
+being compressed. A response flushed before MinSize bytes is treated as
+a stream and compressed if its content type allows
+*/
+func (w *responseWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(true)
+	}
+
+	if w.encoder != nil {
+		_ = w.encoder.Flush()
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+/*
+Hijack hands the connection to the handler, such as for a WebSocket.
+Nothing is compressed
+*/
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+
+	if !ok {
+		return nil, nil, errors.New("the response writer doesn't support hijacking")
+	}
+
+	w.decided = true
+	w.hijacked = true
+
+	return hijacker.Hijack()
+}
+
+/*
+Unwrap returns the original response writer
+*/
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+/*
+decide chooses whether to compress, writes the headers, and sends what
+has been held back
+*/
+func (w *responseWriter) decide(streaming bool) error {
+	w.decided = true
+	compress := w.shouldCompress(streaming)
+	header := w.Header()
+
+	if compress {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", w.encoding)
+
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+
+		w.counter = &countingWriter{w: w.ResponseWriter}
+		w.encoder = w.compressor.getEncoder(w.encoding, w.counter)
+	}
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buffer := w.buffer
+	w.buffer = nil
+
+	if len(buffer) == 0 {
+		return nil
+	}
+
+	_, err := w.Write(buffer)
+	return err
+}
+
+func (w *responseWriter) shouldCompress(streaming bool) bool {
+	header := w.Header()
+
+	switch {
+	case w.request.Method == http.MethodHead,
+		w.status == http.StatusNoContent,
+		w.status == http.StatusNotModified,
+		w.status == http.StatusPartialContent,
+		header.Get("Content-Range") != "",
+		strings.Contains(header.Get("Cache-Control"), "no-transform"):
+		return false
+
+	case header.Get("Content-Encoding") != "" && header.Get("Content-Encoding") != "identity":
+		return false
+	}
+
+	contentType := header.Get("Content-Type")
+
+	if contentType == "" && len(w.buffer) > 0 {
+		contentType = http.DetectContentType(w.buffer)
+		header.Set("Content-Type", contentType)
+	}
+
+	if !w.compressor.allowed(contentType) {
+		return false
+	}
+
+	header.Add("Vary", "Accept-Encoding")
+
+	if w.encoding == "" || (!streaming && len(w.buffer) < w.compressor.config.MinSize) {
+		return false
+	}
+
+	for _, magic := range compressedMagic {
+		if bytes.HasPrefix(w.buffer, magic) {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+close finishes the response. A response nothing was written to is left
+uncommitted, so an error handler running later can still write it
+*/
+func (w *responseWriter) close() {
+	if w.hijacked {
+		return
+	}
+
+	if !w.decided {
+		if w.status == 0 && len(w.buffer) == 0 {
+			return
+		}
+
+		_ = w.decide(false)
+	}
+
+	if w.encoder == nil {
+		w.compressor.stats.skip()
+		return
+	}
+
+	_ = w.encoder.Close()
+	w.compressor.putEncoder(w.encoding, w.encoder)
+	w.compressor.stats.compress(w.encoding, w.written, w.counter.n)
+	w.encoder = nil
+}
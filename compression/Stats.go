@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package compression
+
+import (
+	"context"
+	"sync"
+)
+
+/*
+Stats counts what a Compressor has done. BytesIn is the size of
+compressed responses before compression and BytesOut their size after,
+so BytesSaved is the difference and Ratio is BytesOut over BytesIn.
+Skipped counts responses sent as they were. Encodings counts compressed
+responses by encoding
+*/
+type Stats struct {
+	BytesIn    int64             `json:"bytesIn"`
+	BytesOut   int64             `json:"bytesOut"`
+	BytesSaved int64             `json:"bytesSaved"`
+	Compressed uint64            `json:"compressed"`
+	Encodings  map[string]uint64 `json:"encodings"`
+	Ratio      float64           `json:"ratio"`
+	Skipped    uint64            `json:"skipped"`
+}
+
+type statsTracker struct {
+	sync.Mutex
+
+	stats Stats
+}
+
+func newStatsTracker() *statsTracker {
+	return &statsTracker{
+		stats: Stats{Encodings: make(map[string]uint64)},
+	}
+}
+
+func (t *statsTracker) compress(encoding string, bytesIn, bytesOut int64) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.stats.BytesIn += bytesIn
+	t.stats.BytesOut += bytesOut
+	t.stats.Compressed++
+	t.stats.Encodings[encoding]++
+}
+
+func (t *statsTracker) skip() {
+	t.Lock()
+	defer t.Unlock()
+
+	t.stats.Skipped++
+}
+
+/*
+Stats returns what the compressor has done since it was created
+*/
+func (c *Compressor) Stats() Stats {
+	c.stats.Lock()
+	defer c.stats.Unlock()
+
+	result := c.stats.stats
+	result.BytesSaved = result.BytesIn - result.BytesOut
+	result.Encodings = make(map[string]uint64, len(c.stats.stats.Encodings))
+
+	for encoding, count := range c.stats.stats.Encodings {
+		result.Encodings[encoding] = count
+	}
+
+	if result.BytesIn > 0 {
+		result.Ratio = float64(result.BytesOut) / float64(result.BytesIn)
+	}
+
+	return result
+}
+
+/*
+Name returns the name the compressor's stats are reported under
+*/
+func (c *Compressor) Name() string {
+	return c.config.Name
+}
+
+/*
+Collect returns the compressor's stats in the form serverstats
+collectors report, so a Compressor can be passed to RegisterCollector
+*/
+func (c *Compressor) Collect(ctx context.Context) map[string]interface{} {
+	stats := c.Stats()
+
+	return map[string]interface{}{
+		"bytesIn":    stats.BytesIn,
+		"bytesOut":   stats.BytesOut,
+		"bytesSaved": stats.BytesSaved,
+		"compressed": stats.Compressed,
+		"encodings":  stats.Encodings,
+		"ratio":      stats.Ratio,
+		"skipped":    stats.Skipped,
+	}
+}
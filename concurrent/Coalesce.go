@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package concurrent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+/*
+Coalescer shares one call between concurrent callers asking for the
+same key, so a burst of requests for the same thing, such as a cache
+miss on a popular key, runs the work once
+*/
+type Coalescer struct {
+	sync.Mutex
+
+	calls map[string]*coalescedCall
+}
+
+type coalescedCall struct {
+	done    chan struct{}
+	err     error
+	waiters int
+	value   interface{}
+}
+
+/*
+NewCoalescer creates a coalescer
+*/
+func NewCoalescer() *Coalescer {
+	return &Coalescer{
+		calls: make(map[string]*coalescedCall),
+	}
+}
+
+/*
+Do calls fn for key, unless a call for key is already running, in which
+case it waits for that call and returns its result. Shared reports
+whether the result went to more than one caller. Results aren't kept
+once the call returns; use a cache for that.
+
+fn runs with the context of the caller that started it. Other callers
+stop waiting, and get ctx.Err(), when their own context is done. If fn
+panics, the panic is passed on to the caller that started it and the
+others get an error wrapping ErrPanic
+*/
+func (c *Coalescer) Do(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (value interface{}, shared bool, err error) {
+	c.Lock()
+
+	if call, ok := c.calls[key]; ok {
+		call.waiters++
+		c.Unlock()
+
+		select {
+		case <-call.done:
+			return call.value, true, call.err
+
+		case <-ctx.Done():
+			return nil, true, ctx.Err()
+		}
+	}
+
+	call := &coalescedCall{done: make(chan struct{})}
+	c.calls[key] = call
+	c.Unlock()
+
+	completed := false
+
+	defer func() {
+		if !completed {
+			call.err = fmt.Errorf("%w: call for '%s'", ErrPanic, key)
+		}
+
+		c.Lock()
+
+		if c.calls[key] == call {
+			delete(c.calls, key)
+		}
+
+		shared = call.waiters > 0
+		c.Unlock()
+
+		close(call.done)
+	}()
+
+	call.value, call.err = fn(ctx)
+	completed = true
+
+	return call.value, shared, call.err
+}
+
+/*
+Forget makes the next Do for key start a new call instead of waiting on
+the one running. Callers already waiting still get its result
+*/
+func (c *Coalescer) Forget(key string) {
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.calls, key)
+}
@@ -0,0 +1,364 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package concurrent_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/concurrent"
+)
+
+var errFailed = errors.New("failed")
+
+func TestMap(t *testing.T) {
+	var running, most int32
+
+	results, err := concurrent.Map(context.Background(), 20, 3, func(ctx context.Context, index int) (interface{}, error) {
+		now := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+
+		for {
+			previous := atomic.LoadInt32(&most)
+
+			if now <= previous || atomic.CompareAndSwapInt32(&most, previous, now) {
+				break
+			}
+		}
+
+		time.Sleep(time.Millisecond)
+
+		if index%7 == 3 {
+			return nil, fmt.Errorf("item %d: %w", index, errFailed)
+		}
+
+		if index == 5 {
+			panic("boom")
+		}
+
+		return index * 2, nil
+	})
+
+	if most > 3 {
+		t.Errorf("Expected at most 3 running but got %d", most)
+	}
+
+	var errs concurrent.Errors
+
+	if !errors.As(err, &errs) || len(errs) != 4 || errs[0].Index != 3 || errs[1].Index != 5 || errs[2].Index != 10 {
+		t.Fatalf("Expected errors for items 3, 5, 10, and 17 but got %v", err)
+	}
+
+	if !errors.Is(err, errFailed) || !errors.Is(err, concurrent.ErrPanic) {
+		t.Errorf("Expected the errors to match errFailed and ErrPanic but got %v", err)
+	}
+
+	if results[4] != 8 || results[3] != nil || results[19] != 38 {
+		t.Errorf("Expected results in index order but got %v", results)
+	}
+}
+
+func TestForEachCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	started := int32(0)
+
+	err := concurrent.ForEach(ctx, 10, 1, func(ctx context.Context, index int) error {
+		if atomic.AddInt32(&started, 1) == 2 {
+			cancel()
+		}
+
+		return nil
+	})
+
+	if !errors.Is(err, context.Canceled) || started != 2 {
+		t.Errorf("Expected the rest to be canceled after 2 started but got %d, %v", started, err)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	group, _ := concurrent.NewGroup(context.Background(), 2)
+	canceled := int32(0)
+
+	for index := 0; index < 4; index++ {
+		index := index
+
+		group.Go(func(ctx context.Context) error {
+			if index == 0 {
+				return errFailed
+			}
+
+			select {
+			case <-ctx.Done():
+				atomic.AddInt32(&canceled, 1)
+			case <-time.After(time.Second):
+			}
+
+			return nil
+		})
+	}
+
+	if err := group.Wait(); !errors.Is(err, errFailed) {
+		t.Errorf("Expected the first error but got %v", err)
+	}
+
+	if canceled == 0 {
+		t.Errorf("Expected the failure to cancel the others")
+	}
+}
+
+func TestFirstSuccess(t *testing.T) {
+	slowCanceled := make(chan bool, 1)
+
+	value, err := concurrent.FirstSuccess(context.Background(),
+		func(ctx context.Context) (interface{}, error) { return nil, errFailed },
+		func(ctx context.Context) (interface{}, error) {
+			time.Sleep(time.Millisecond * 10)
+			return "fast", nil
+		},
+		func(ctx context.Context) (interface{}, error) {
+			select {
+			case <-ctx.Done():
+				slowCanceled <- true
+				return nil, ctx.Err()
+			case <-time.After(time.Second):
+				slowCanceled <- false
+				return "slow", nil
+			}
+		},
+	)
+
+	if err != nil || value != "fast" {
+		t.Errorf("Expected fast but got %v, %v", value, err)
+	}
+
+	if !<-slowCanceled {
+		t.Errorf("Expected the slow function to be canceled")
+	}
+
+	_, err = concurrent.FirstSuccess(context.Background(),
+		func(ctx context.Context) (interface{}, error) { return nil, errFailed },
+		func(ctx context.Context) (interface{}, error) { return nil, errors.New("down") },
+	)
+
+	if errs, ok := err.(concurrent.Errors); !ok || len(errs) != 2 {
+		t.Errorf("Expected both errors but got %v", err)
+	}
+}
+
+func TestSemaphore(t *testing.T) {
+	semaphore := concurrent.NewSemaphore(10)
+	ctx := context.Background()
+
+	if err := semaphore.Acquire(ctx, 11); !errors.Is(err, concurrent.ErrTooLarge) {
+		t.Errorf("Expected ErrTooLarge but got %v", err)
+	}
+
+	_ = semaphore.Acquire(ctx, 7)
+
+	if semaphore.TryAcquire(4) {
+		t.Errorf("Expected no room for 4 with 7 of 10 in use")
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, time.Millisecond*10)
+	defer cancel()
+
+	if err := semaphore.Acquire(timeout, 5); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the wait to time out but got %v", err)
+	}
+
+	acquired := make(chan struct{})
+
+	go func() {
+		_ = semaphore.Acquire(ctx, 8)
+		close(acquired)
+	}()
+
+	/*
+	 * Once the waiter for 8 is queued, even a weight that fits has to
+	 * wait behind it
+	 */
+	for semaphore.TryAcquire(1) {
+		semaphore.Release(1)
+		time.Sleep(time.Millisecond)
+	}
+
+	semaphore.Release(7)
+	<-acquired
+
+	if semaphore.InUse() != 8 || !semaphore.TryAcquire(2) || semaphore.TryAcquire(1) {
+		t.Errorf("Expected the waiter to get 8 and leave room for 2 but got %d in use", semaphore.InUse())
+	}
+
+	semaphore.Release(10)
+
+	err := semaphore.Do(ctx, 10, func(ctx context.Context) error { return errFailed })
+
+	if !errors.Is(err, errFailed) || semaphore.InUse() != 0 {
+		t.Errorf("Expected Do to return its error and release but got %v, %d in use", err, semaphore.InUse())
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	double := func(ctx context.Context, value interface{}) (interface{}, error) { return value.(int) * 2, nil }
+	results := make([]int, 0)
+
+	err := concurrent.NewPipeline().
+		Then(double, 3).
+		Then(func(ctx context.Context, value interface{}) (interface{}, error) { return value.(int) + 1, nil }, 1).
+		Run(context.Background(), concurrent.Values(1, 2, 3, 4), func(ctx context.Context, value interface{}) error {
+			results = append(results, value.(int))
+			return nil
+		})
+
+	sort.Ints(results)
+
+	if err != nil || fmt.Sprint(results) != "[3 5 7 9]" {
+		t.Errorf("Expected [3 5 7 9] but got %v, %v", results, err)
+	}
+
+	source := make(chan interface{})
+
+	go func() {
+		defer close(source)
+
+		for index := 0; index < 1000; index++ {
+			source <- index
+		}
+	}()
+
+	err = concurrent.NewPipeline().
+		Then(func(ctx context.Context, value interface{}) (interface{}, error) {
+			if value.(int) == 5 {
+				return nil, errFailed
+			}
+
+			return value, nil
+		}, 2).
+		Run(context.Background(), source, func(ctx context.Context, value interface{}) error { return nil })
+
+	if !errors.Is(err, errFailed) {
+		t.Errorf("Expected the stage error but got %v", err)
+	}
+
+	for range source {
+	}
+}
+
+func TestDebouncer(t *testing.T) {
+	calls := int32(0)
+	debouncer := concurrent.NewDebouncer(time.Millisecond*30, 0, func() { atomic.AddInt32(&calls, 1) })
+
+	for index := 0; index < 5; index++ {
+		debouncer.Call()
+		time.Sleep(time.Millisecond * 5)
+	}
+
+	time.Sleep(time.Millisecond * 80)
+
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("Expected a burst to run once but got %d", calls)
+	}
+
+	debouncer.Call()
+	debouncer.Flush()
+	debouncer.Flush()
+
+	if atomic.LoadInt32(&calls) != 2 {
+		t.Errorf("Expected Flush to run the pending call once but got %d", calls)
+	}
+
+	debouncer.Call()
+	debouncer.Stop()
+	time.Sleep(time.Millisecond * 50)
+
+	if atomic.LoadInt32(&calls) != 2 {
+		t.Errorf("Expected Stop to cancel the pending call but got %d", calls)
+	}
+}
+
+func TestDebouncerMaxWait(t *testing.T) {
+	calls := int32(0)
+	debouncer := concurrent.NewDebouncer(time.Millisecond*30, time.Millisecond*60, func() { atomic.AddInt32(&calls, 1) })
+	defer debouncer.Stop()
+
+	for index := 0; index < 20; index++ {
+		debouncer.Call()
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if count := atomic.LoadInt32(&calls); count < 2 {
+		t.Errorf("Expected MaxWait to run a long burst at least twice but got %d", count)
+	}
+}
+
+func TestThrottler(t *testing.T) {
+	calls := int32(0)
+	throttler := concurrent.NewThrottler(time.Millisecond*40, func() { atomic.AddInt32(&calls, 1) })
+	defer throttler.Stop()
+
+	for index := 0; index < 5; index++ {
+		throttler.Call()
+	}
+
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("Expected the first call to run right away but got %d", calls)
+	}
+
+	time.Sleep(time.Millisecond * 80)
+
+	if atomic.LoadInt32(&calls) != 2 {
+		t.Errorf("Expected one trailing call but got %d", calls)
+	}
+}
+
+func TestCoalescer(t *testing.T) {
+	coalescer := concurrent.NewCoalescer()
+	release := make(chan struct{})
+	calls := int32(0)
+	sharedCount := int32(0)
+	wg := sync.WaitGroup{}
+
+	for index := 0; index < 5; index++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			value, shared, err := coalescer.Do(context.Background(), "user:1", func(ctx context.Context) (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return "bob", nil
+			})
+
+			if value != "bob" || err != nil {
+				t.Errorf("Expected bob but got %v, %v", value, err)
+			}
+
+			if shared {
+				atomic.AddInt32(&sharedCount, 1)
+			}
+		}()
+	}
+
+	time.Sleep(time.Millisecond * 50)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 || sharedCount != 5 {
+		t.Errorf("Expected one shared call but got %d calls and %d shared", calls, sharedCount)
+	}
+
+	_, shared, _ := coalescer.Do(context.Background(), "user:1", func(ctx context.Context) (interface{}, error) { return "alice", nil })
+
+	if shared {
+		t.Errorf("Expected a call with no other callers not to be shared")
+	}
+}
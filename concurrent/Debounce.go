@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package concurrent
+
+import (
+	"sync"
+	"time"
+)
+
+/*
+Debouncer calls a function once calls to it stop for a while, so a
+burst of triggers, such as file change notifications during a deploy,
+runs it once. MaxWait, when set, runs it anyway once a burst has gone
+on that long
+*/
+type Debouncer struct {
+	sync.Mutex
+
+	first   time.Time
+	fn      func()
+	gen     int
+	maxWait time.Duration
+	stopped bool
+	timer   *time.Timer
+	wait    time.Duration
+}
+
+/*
+NewDebouncer creates a debouncer calling fn once Call hasn't been called
+for wait, or once maxWait has passed since the first call of a burst. A
+maxWait of zero waits for the burst to end however long it lasts.
+
+	reload := concurrent.NewDebouncer(time.Second, 10*time.Second, func() {
+		_ = loader.Load(&config)
+	})
+
+	for range changes {
+		reload.Call()
+	}
+*/
+func NewDebouncer(wait, maxWait time.Duration, fn func()) *Debouncer {
+	return &Debouncer{
+		fn:      fn,
+		maxWait: maxWait,
+		wait:    wait,
+	}
+}
+
+/*
+Call schedules the function, pushing back a call already scheduled
+*/
+func (d *Debouncer) Call() {
+	d.Lock()
+	defer d.Unlock()
+
+	if d.stopped {
+		return
+	}
+
+	now := time.Now()
+	delay := d.wait
+
+	if d.timer == nil {
+		d.first = now
+	} else {
+		d.timer.Stop()
+	}
+
+	if d.maxWait > 0 {
+		if remaining := d.first.Add(d.maxWait).Sub(now); remaining < delay {
+			delay = remaining
+		}
+	}
+
+	d.gen++
+	gen := d.gen
+	d.timer = time.AfterFunc(delay, func() { d.fire(gen) })
+}
+
+/*
+Flush calls the function now if a call is scheduled
+*/
+func (d *Debouncer) Flush() {
+	d.Lock()
+
+	if d.timer == nil || !d.timer.Stop() {
+		d.Unlock()
+		return
+	}
+
+	d.timer = nil
+	d.Unlock()
+
+	d.fn()
+}
+
+/*
+Stop cancels a scheduled call, and ignores later calls
+*/
+func (d *Debouncer) Stop() {
+	d.Lock()
+	defer d.Unlock()
+
+	d.stopped = true
+
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+}
+
+/*
+fire runs the function for the call numbered gen, unless a later call
+or Stop replaced its timer while it was waiting for the lock
+*/
+func (d *Debouncer) fire(gen int) {
+	d.Lock()
+
+	if d.gen != gen || d.timer == nil {
+		d.Unlock()
+		return
+	}
+
+	d.timer = nil
+	d.Unlock()
+
+	d.fn()
+}
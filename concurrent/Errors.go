@@ -0,0 +1,95 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package concurrent
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrNoFunctions = errors.New("no functions to run")
+	ErrPanic       = errors.New("panic")
+	ErrTooLarge    = errors.New("weight is larger than the semaphore")
+)
+
+/*
+IndexError is the error from one item of a Map or ForEach, or one
+function given to FirstSuccess
+*/
+type IndexError struct {
+	Err   error
+	Index int
+}
+
+func (e IndexError) Error() string {
+	return fmt.Sprintf("item %d: %s", e.Index, e.Err.Error())
+}
+
+func (e IndexError) Unwrap() error {
+	return e.Err
+}
+
+/*
+Errors collects every failure from a Map, ForEach, or FirstSuccess,
+ordered by index
+*/
+type Errors []IndexError
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+
+	for _, indexError := range e {
+		messages = append(messages, indexError.Error())
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+/*
+Is makes errors.Is true when it is true for any of the errors
+*/
+func (e Errors) Is(target error) bool {
+	for _, indexError := range e {
+		if errors.Is(indexError.Err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+collect returns the non-nil errors as Errors, or nil when there are none
+*/
+func collect(errs []error) error {
+	var result Errors
+
+	for index, err := range errs {
+		if err != nil {
+			result = append(result, IndexError{Err: err, Index: index})
+		}
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+
+	return result
+}
+
+/*
+safely calls fn, turning a panic into an error wrapping ErrPanic
+*/
+func safely(fn func() error) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("%w: %v", ErrPanic, recovered)
+		}
+	}()
+
+	return fn()
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package concurrent
+
+import (
+	"context"
+	"sync"
+)
+
+/*
+Group runs functions on their own goroutines, at most limit at a time,
+and stops at the first failure. The context given to each function is
+canceled when one fails, or when the group's parent is done
+*/
+type Group struct {
+	cancel  context.CancelFunc
+	ctx     context.Context
+	err     error
+	errOnce sync.Once
+	slots   chan struct{}
+	wg      sync.WaitGroup
+}
+
+/*
+NewGroup creates a group running at most limit functions at a time. A
+limit of zero or less doesn't limit them. The returned context is the
+one passed to the functions.
+
+	group, ctx := concurrent.NewGroup(ctx, 4)
+
+	for _, file := range files {
+		file := file
+		group.Go(func(ctx context.Context) error {
+			return upload(ctx, file)
+		})
+	}
+
+	err := group.Wait()
+*/
+func NewGroup(ctx context.Context, limit int) (*Group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	result := &Group{
+		cancel: cancel,
+		ctx:    ctx,
+	}
+
+	if limit > 0 {
+		result.slots = make(chan struct{}, limit)
+	}
+
+	return result, ctx
+}
+
+/*
+Go runs fn on a goroutine, waiting for a free slot when the group is at
+its limit. When the group's context is done before a slot frees up, fn
+isn't run
+*/
+func (g *Group) Go(fn func(ctx context.Context) error) {
+	if g.slots != nil {
+		select {
+		case <-g.ctx.Done():
+			g.fail(g.ctx.Err())
+			return
+
+		case g.slots <- struct{}{}:
+		}
+	}
+
+	g.wg.Add(1)
+
+	go func() {
+		defer func() {
+			if g.slots != nil {
+				<-g.slots
+			}
+
+			g.wg.Done()
+		}()
+
+		if err := safely(func() error { return fn(g.ctx) }); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+/*
+Wait waits for every function to return, and returns the first error
+*/
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+
+	return g.err
+}
+
+func (g *Group) fail(err error) {
+	g.errOnce.Do(func() {
+		g.err = err
+		g.cancel()
+	})
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package concurrent
+
+import (
+	"context"
+	"sync"
+)
+
+/*
+ForEach calls fn with every index from 0 to count, running at most
+limit at a time. A limit of zero or less runs them all at once. Every
+item runs even when others fail, and the failures are returned as
+Errors. Once ctx is done no more items start, and those left fail with
+ctx's error. Panics are returned as errors wrapping ErrPanic.
+
+	err := concurrent.ForEach(ctx, len(users), 5, func(ctx context.Context, index int) error {
+		return sendWelcome(ctx, users[index])
+	})
+*/
+func ForEach(ctx context.Context, count, limit int, fn func(ctx context.Context, index int) error) error {
+	if limit <= 0 || limit > count {
+		limit = count
+	}
+
+	errs := make([]error, count)
+	slots := make(chan struct{}, limit)
+	wg := sync.WaitGroup{}
+
+	for index := 0; index < count; index++ {
+		if errs[index] = ctx.Err(); errs[index] != nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			errs[index] = ctx.Err()
+			continue
+
+		case slots <- struct{}{}:
+		}
+
+		wg.Add(1)
+
+		go func(index int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			errs[index] = safely(func() error {
+				return fn(ctx, index)
+			})
+		}(index)
+	}
+
+	wg.Wait()
+	return collect(errs)
+}
+
+/*
+Map is ForEach returning a value for each index. Results are in index
+order, with nil for items that failed.
+
+	results, err := concurrent.Map(ctx, len(ids), 10, func(ctx context.Context, index int) (interface{}, error) {
+		return fetchProduct(ctx, ids[index])
+	})
+*/
+func Map(ctx context.Context, count, limit int, fn func(ctx context.Context, index int) (interface{}, error)) ([]interface{}, error) {
+	results := make([]interface{}, count)
+
+	err := ForEach(ctx, count, limit, func(ctx context.Context, index int) error {
+		value, err := fn(ctx, index)
+
+		if err == nil {
+			results[index] = value
+		}
+
+		return err
+	})
+
+	return results, err
+}
@@ -0,0 +1,189 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package concurrent
+
+import (
+	"context"
+	"sync"
+)
+
+/*
+Stage turns one value into the next stage's input
+*/
+type Stage func(ctx context.Context, value interface{}) (interface{}, error)
+
+/*
+Sink receives each value coming out of the last stage
+*/
+type Sink func(ctx context.Context, value interface{}) error
+
+/*
+Pipeline passes values through stages, each running on its own
+goroutines, so a slow stage such as a network call overlaps with the
+others. The first error stops the whole pipeline
+*/
+type Pipeline struct {
+	stages []pipelineStage
+}
+
+type pipelineStage struct {
+	stage   Stage
+	workers int
+}
+
+/*
+NewPipeline creates an empty pipeline. Add stages with Then
+*/
+func NewPipeline() *Pipeline {
+	return &Pipeline{
+		stages: make([]pipelineStage, 0, 5),
+	}
+}
+
+/*
+Then adds a stage run by workers goroutines. With more than one worker
+values may leave the stage in a different order than they arrived
+*/
+func (p *Pipeline) Then(stage Stage, workers int) *Pipeline {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	p.stages = append(p.stages, pipelineStage{stage: stage, workers: workers})
+	return p
+}
+
+/*
+Run reads values from source until it is closed, passes them through
+the stages, and gives the results to sink, which is called from one
+goroutine. It returns once everything has drained. The first error from
+a stage or the sink cancels the rest of the pipeline and is returned,
+as is ctx's error when it is done first. Whatever writes to source
+should stop when ctx is done, as the pipeline stops reading.
+
+	err := concurrent.NewPipeline().
+		Then(download, 4).
+		Then(resize, runtime.NumCPU()).
+		Run(ctx, concurrent.Values(urls...), save)
+*/
+func (p *Pipeline) Run(ctx context.Context, source <-chan interface{}, sink Sink) error {
+	var (
+		err     error
+		errOnce sync.Once
+		wg      sync.WaitGroup
+	)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	fail := func(e error) {
+		errOnce.Do(func() {
+			err = e
+			cancel()
+		})
+	}
+
+	in := source
+
+	for _, s := range p.stages {
+		out := make(chan interface{})
+		stageWG := &sync.WaitGroup{}
+
+		for worker := 0; worker < s.workers; worker++ {
+			stageWG.Add(1)
+			wg.Add(1)
+
+			go func(stage Stage, in <-chan interface{}, out chan<- interface{}) {
+				defer func() {
+					stageWG.Done()
+					wg.Done()
+				}()
+
+				for {
+					value, ok := receive(ctx, in)
+
+					if !ok {
+						return
+					}
+
+					stageErr := safely(func() error {
+						var e error
+						value, e = stage(ctx, value)
+						return e
+					})
+
+					if stageErr != nil {
+						fail(stageErr)
+						return
+					}
+
+					select {
+					case <-ctx.Done():
+						return
+
+					case out <- value:
+					}
+				}
+			}(s.stage, in, out)
+		}
+
+		go func(out chan interface{}) {
+			stageWG.Wait()
+			close(out)
+		}(out)
+
+		in = out
+	}
+
+	for {
+		value, ok := receive(ctx, in)
+
+		if !ok {
+			break
+		}
+
+		if sinkErr := safely(func() error { return sink(ctx, value) }); sinkErr != nil {
+			fail(sinkErr)
+			break
+		}
+	}
+
+	wg.Wait()
+
+	/*
+	 * Nothing failed, so cancel hasn't run, and a done ctx here means the
+	 * caller's context ended the run
+	 */
+	if err == nil {
+		err = ctx.Err()
+	}
+
+	return err
+}
+
+/*
+Values returns a closed channel holding values, to use as a pipeline's
+source
+*/
+func Values(values ...interface{}) <-chan interface{} {
+	result := make(chan interface{}, len(values))
+
+	for _, value := range values {
+		result <- value
+	}
+
+	close(result)
+	return result
+}
+
+func receive(ctx context.Context, in <-chan interface{}) (interface{}, bool) {
+	select {
+	case <-ctx.Done():
+		return nil, false
+
+	case value, ok := <-in:
+		return value, ok
+	}
+}
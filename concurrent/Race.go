@@ -0,0 +1,65 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package concurrent
+
+import (
+	"context"
+)
+
+/*
+FirstSuccess runs every function at once and returns the value of the
+first to succeed, canceling the context given to the rest. When they
+all fail the failures are returned as Errors. Functions should return
+promptly once their context is canceled.
+
+	rate, err := concurrent.FirstSuccess(ctx,
+		func(ctx context.Context) (interface{}, error) { return primary.Rate(ctx, "USD") },
+		func(ctx context.Context) (interface{}, error) { return backup.Rate(ctx, "USD") },
+	)
+*/
+func FirstSuccess(ctx context.Context, fns ...func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	type result struct {
+		err   error
+		index int
+		value interface{}
+	}
+
+	if len(fns) == 0 {
+		return nil, ErrNoFunctions
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan result, len(fns))
+
+	for index, fn := range fns {
+		go func(index int, fn func(ctx context.Context) (interface{}, error)) {
+			var value interface{}
+
+			err := safely(func() error {
+				var err error
+				value, err = fn(ctx)
+				return err
+			})
+
+			results <- result{err: err, index: index, value: value}
+		}(index, fn)
+	}
+
+	errs := make([]error, len(fns))
+
+	for range fns {
+		r := <-results
+
+		if r.err == nil {
+			return r.value, nil
+		}
+
+		errs[r.index] = r.err
+	}
+
+	return nil, collect(errs)
+}
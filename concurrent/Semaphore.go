@@ -0,0 +1,165 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package concurrent
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+)
+
+/*
+Semaphore limits how much of something, such as memory or connections,
+is in use at once. Each caller acquires a weight, and waits while the
+total would go over the size. Waiters are served in order, so a large
+weight isn't starved by a stream of small ones
+*/
+type Semaphore struct {
+	sync.Mutex
+
+	current int64
+	size    int64
+	waiters list.List
+}
+
+type waiter struct {
+	ready  chan struct{}
+	weight int64
+}
+
+/*
+NewSemaphore creates a semaphore holding up to size
+*/
+func NewSemaphore(size int64) *Semaphore {
+	return &Semaphore{
+		size: size,
+	}
+}
+
+/*
+Acquire waits until weight is available, or until ctx is done, when it
+returns ctx's error. Weights larger than the semaphore return
+ErrTooLarge, as they could never be acquired
+*/
+func (s *Semaphore) Acquire(ctx context.Context, weight int64) error {
+	if weight > s.size {
+		return fmt.Errorf("%w: %d of %d", ErrTooLarge, weight, s.size)
+	}
+
+	s.Lock()
+
+	if s.size-s.current >= weight && s.waiters.Len() == 0 {
+		s.current += weight
+		s.Unlock()
+		return nil
+	}
+
+	w := &waiter{ready: make(chan struct{}), weight: weight}
+	element := s.waiters.PushBack(w)
+	s.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+
+	case <-ctx.Done():
+		s.Lock()
+		defer s.Unlock()
+
+		select {
+		case <-w.ready:
+			/*
+			 * Acquired just as ctx was done. Give it back, since the
+			 * caller will see an error
+			 */
+			s.current -= weight
+
+		default:
+			s.waiters.Remove(element)
+		}
+
+		s.notify()
+		return ctx.Err()
+	}
+}
+
+/*
+TryAcquire acquires weight if it is available right now, without
+waiting, and reports whether it did
+*/
+func (s *Semaphore) TryAcquire(weight int64) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.size-s.current >= weight && s.waiters.Len() == 0 {
+		s.current += weight
+		return true
+	}
+
+	return false
+}
+
+/*
+Release gives back weight acquired earlier. Releasing more than is held
+panics
+*/
+func (s *Semaphore) Release(weight int64) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.current -= weight; s.current < 0 {
+		panic("concurrent: semaphore released more than it held")
+	}
+
+	s.notify()
+}
+
+/*
+Do acquires weight, calls fn, and releases weight, returning fn's error
+or the error from acquiring
+*/
+func (s *Semaphore) Do(ctx context.Context, weight int64, fn func(ctx context.Context) error) error {
+	if err := s.Acquire(ctx, weight); err != nil {
+		return err
+	}
+
+	defer s.Release(weight)
+	return fn(ctx)
+}
+
+/*
+InUse returns the weight currently acquired
+*/
+func (s *Semaphore) InUse() int64 {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.current
+}
+
+/*
+notify hands weight to waiters in order while there is room for the
+first. It must be called with the lock held
+*/
+func (s *Semaphore) notify() {
+	for {
+		front := s.waiters.Front()
+
+		if front == nil {
+			return
+		}
+
+		w := front.Value.(*waiter)
+
+		if s.size-s.current < w.weight {
+			return
+		}
+
+		s.current += w.weight
+		s.waiters.Remove(front)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package concurrent
+
+import (
+	"sync"
+	"time"
+)
+
+/*
+Throttler calls a function at most once per interval. The first call
+runs right away. Calls during the interval are collapsed into one more
+run at its end, so the last trigger is never lost
+*/
+type Throttler struct {
+	sync.Mutex
+
+	fn       func()
+	interval time.Duration
+	last     time.Time
+	stopped  bool
+	timer    *time.Timer
+}
+
+/*
+NewThrottler creates a throttler calling fn at most once per interval.
+
+	save := concurrent.NewThrottler(time.Second*5, func() {
+		_ = store.Save(state)
+	})
+*/
+func NewThrottler(interval time.Duration, fn func()) *Throttler {
+	return &Throttler{
+		fn:       fn,
+		interval: interval,
+	}
+}
+
+/*
+Call runs the function now if it hasn't run in the last interval, and
+otherwise schedules it for the end of the interval
+*/
+func (t *Throttler) Call() {
+	t.Lock()
+
+	if t.stopped || t.timer != nil {
+		t.Unlock()
+		return
+	}
+
+	now := time.Now()
+
+	if elapsed := now.Sub(t.last); elapsed < t.interval {
+		t.timer = time.AfterFunc(t.interval-elapsed, t.fire)
+		t.Unlock()
+		return
+	}
+
+	t.last = now
+	t.Unlock()
+
+	t.fn()
+}
+
+/*
+Stop cancels a scheduled call, and ignores later calls
+*/
+func (t *Throttler) Stop() {
+	t.Lock()
+	defer t.Unlock()
+
+	t.stopped = true
+
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+}
+
+func (t *Throttler) fire() {
+	t.Lock()
+
+	if t.stopped {
+		t.Unlock()
+		return
+	}
+
+	t.last = time.Now()
+	t.timer = nil
+	t.Unlock()
+
+	t.fn()
+}
@@ -0,0 +1,252 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+/*
+field is a single configurable value in a configuration struct
+*/
+type field struct {
+	defaultValue string
+	envName      string
+	flagName     string
+	hasDefault   bool
+	key          []string
+	name         string
+	required     bool
+	secret       bool
+	secretRef    string
+	usage        string
+	value        reflect.Value
+}
+
+/*
+collectFields walks a struct, and any nested structs, returning every
+exported field that can be configured
+*/
+func collectFields(value reflect.Value, parent []string) []*field {
+	result := make([]*field, 0, value.NumField())
+	valueType := value.Type()
+
+	for index := 0; index < valueType.NumField(); index++ {
+		structField := valueType.Field(index)
+
+		if structField.PkgPath != "" || structField.Tag.Get("config") == "-" {
+			continue
+		}
+
+		key := structField.Tag.Get("config")
+
+		if key == "" {
+			key = structField.Name
+		}
+
+		path := append(append([]string{}, parent...), key)
+		fieldValue := value.Field(index)
+
+		if fieldValue.Kind() == reflect.Struct {
+			result = append(result, collectFields(fieldValue, path)...)
+			continue
+		}
+
+		defaultValue, hasDefault := structField.Tag.Lookup("default")
+
+		result = append(result, &field{
+			defaultValue: defaultValue,
+			envName:      tagOr(structField.Tag.Get("env"), envName(path)),
+			flagName:     tagOr(structField.Tag.Get("flag"), flagName(path)),
+			hasDefault:   hasDefault,
+			key:          path,
+			name:         strings.Join(fieldNames(parent, structField.Name), "."),
+			required:     structField.Tag.Get("required") == "true",
+			secret:       structField.Tag.Get("secret") == "true" || structField.Tag.Get("secretRef") != "" || structField.Type == secretType,
+			secretRef:    structField.Tag.Get("secretRef"),
+			usage:        structField.Tag.Get("usage"),
+			value:        fieldValue,
+		})
+	}
+
+	return result
+}
+
+func fieldNames(parent []string, name string) []string {
+	return append(append([]string{}, parent...), name)
+}
+
+/*
+tagOr returns the tag value, fallback when the tag is empty, or an
+empty string when the tag is "-"
+*/
+func tagOr(tag, fallback string) string {
+	switch tag {
+	case "-":
+		return ""
+
+	case "":
+		return fallback
+	}
+
+	return tag
+}
+
+func envName(path []string) string {
+	return strings.ToUpper(strings.Join(splitWords(path), "_"))
+}
+
+func flagName(path []string) string {
+	return strings.ToLower(strings.Join(splitWords(path), "-"))
+}
+
+/*
+splitWords splits each CamelCase part of a path into words, keeping
+acronyms together, so "DBMaxIdleConns" becomes DB, Max, Idle, Conns
+*/
+func splitWords(path []string) []string {
+	result := make([]string, 0, len(path))
+
+	for _, part := range path {
+		runes := []rune(part)
+		start := 0
+
+		for index := 1; index < len(runes); index++ {
+			previous, current := runes[index-1], runes[index]
+			nextIsLower := index+1 < len(runes) && unicode.IsLower(runes[index+1])
+
+			if unicode.IsUpper(current) && (unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextIsLower)) {
+				result = append(result, string(runes[start:index]))
+				start = index
+			}
+		}
+
+		result = append(result, string(runes[start:]))
+	}
+
+	return result
+}
+
+/*
+setFromString parses raw into the field's type and sets it
+*/
+func setFromString(value reflect.Value, raw string) error {
+	if value.Type() == durationType {
+		duration, err := time.ParseDuration(raw)
+
+		if err != nil {
+			return err
+		}
+
+		value.SetInt(int64(duration))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+
+		if err != nil {
+			return err
+		}
+
+		value.SetBool(parsed)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, value.Type().Bits())
+
+		if err != nil {
+			return err
+		}
+
+		value.SetInt(parsed)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(raw, 10, value.Type().Bits())
+
+		if err != nil {
+			return err
+		}
+
+		value.SetUint(parsed)
+
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, value.Type().Bits())
+
+		if err != nil {
+			return err
+		}
+
+		value.SetFloat(parsed)
+
+	case reflect.Slice:
+		parts := make([]interface{}, 0)
+
+		for _, part := range strings.Split(raw, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				parts = append(parts, part)
+			}
+		}
+
+		return setSlice(value, parts)
+
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+
+	return nil
+}
+
+/*
+setFromFileValue sets a field from a value decoded from a config file
+*/
+func setFromFileValue(value reflect.Value, raw interface{}) error {
+	switch typed := raw.(type) {
+	case string:
+		return setFromString(value, typed)
+
+	case []interface{}:
+		if value.Kind() != reflect.Slice {
+			return fmt.Errorf("expected a single value for %s, got a list", value.Type())
+		}
+
+		return setSlice(value, typed)
+
+	case float64:
+		return setFromString(value, strconv.FormatFloat(typed, 'f', -1, 64))
+
+	case map[string]interface{}:
+		return fmt.Errorf("expected a value for %s, got a table", value.Type())
+
+	case nil:
+		value.Set(reflect.Zero(value.Type()))
+		return nil
+	}
+
+	return setFromString(value, fmt.Sprint(raw))
+}
+
+func setSlice(value reflect.Value, items []interface{}) error {
+	slice := reflect.MakeSlice(value.Type(), len(items), len(items))
+
+	for index, item := range items {
+		if err := setFromFileValue(slice.Index(index), item); err != nil {
+			return err
+		}
+	}
+
+	value.Set(slice)
+	return nil
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+readFile reads a JSON, YAML, or TOML config file into a map
+*/
+func readFile(fileName string) (map[string]interface{}, error) {
+	var err error
+	var contents []byte
+
+	result := make(map[string]interface{})
+
+	if contents, err = os.ReadFile(fileName); err != nil {
+		return nil, fmt.Errorf("error reading config file %s: %w", fileName, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".json":
+		err = json.Unmarshal(contents, &result)
+
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(contents, &result)
+
+	case ".toml":
+		err = toml.Unmarshal(contents, &result)
+
+	default:
+		return nil, fmt.Errorf("error reading config file %s: unsupported file type", fileName)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %w", fileName, err)
+	}
+
+	return result, nil
+}
+
+/*
+lookupKey finds a value by its key path, matching keys without regard
+to case
+*/
+func lookupKey(data map[string]interface{}, path []string) (interface{}, bool) {
+	var current interface{} = data
+
+	for _, key := range path {
+		table, ok := current.(map[string]interface{})
+
+		if !ok {
+			return nil, false
+		}
+
+		if current, ok = findKey(table, key); !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+func findKey(table map[string]interface{}, key string) (interface{}, bool) {
+	if value, ok := table[key]; ok {
+		return value, true
+	}
+
+	for candidate, value := range table {
+		if strings.EqualFold(candidate, key) {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
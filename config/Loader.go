@@ -0,0 +1,306 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package config
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+/*
+ErrMissingRequired is returned, wrapped with the names of the missing
+fields, when required fields are still empty after loading
+*/
+var ErrMissingRequired = errors.New("missing required configuration")
+
+/*
+ILoader describes an interface for loading configuration into a struct
+*/
+type ILoader interface {
+	Load(dest interface{}) error
+}
+
+/*
+Loader loads configuration into a tagged struct. Values are applied in
+this order, each overriding the one before: default tags, config files
+in the order they are listed, secrets, environment variables, and then
+command line flags.
+
+Fields are configured with struct tags:
+
+  - config: the key in config files. Defaults to the field name, and
+    keys are matched without regard to case
+  - env: the environment variable. Defaults to the path of the field in
+    upper snake case, such as DATABASE_HOST. Use "-" to skip the field
+  - flag: the command line flag. Defaults to the path of the field in
+    kebab case, such as database-host. Use "-" to skip the field
+  - default: the value used when nothing else sets the field
+  - required: when "true", loading fails if the field is still empty
+  - secret: when "true", the value is redacted by String and Fields.
+    Fields of type Secret are always redacted
+  - secretRef: the name of a secret to read from the loader's Secrets
+    provider, such as "secret/data/myapp#dbPassword". These fields are
+    redacted too
+  - usage: help text for the flag
+
+For example:
+
+	type Config struct {
+		Port     int           `default:"8080" usage:"port to listen on"`
+		Timeout  time.Duration `default:"30s"`
+		Database struct {
+			Host     string        `required:"true"`
+			Password config.Secret `env:"DB_PASSWORD" required:"true"`
+		}
+	}
+
+Supported field types are strings, bools, numbers, time.Duration,
+Secret, slices of those (comma separated in environment variables and
+flags), and nested structs
+*/
+type Loader struct {
+	config LoaderConfig
+}
+
+/*
+NewLoader creates a new configuration Loader
+*/
+func NewLoader(config LoaderConfig) *Loader {
+	if config.LookupEnv == nil {
+		config.LookupEnv = os.LookupEnv
+	}
+
+	if config.FlagSetName == "" && len(os.Args) > 0 {
+		config.FlagSetName = os.Args[0]
+	}
+
+	return &Loader{
+		config: config,
+	}
+}
+
+/*
+Load populates dest, which must be a pointer to a struct
+*/
+func (l *Loader) Load(dest interface{}) error {
+	var err error
+	var fields []*field
+
+	if fields, err = structFields(dest); err != nil {
+		return err
+	}
+
+	if err = applyDefaults(fields); err != nil {
+		return err
+	}
+
+	for _, fileName := range l.config.Files {
+		if err = l.applyFile(fields, fileName); err != nil {
+			return err
+		}
+	}
+
+	if err = l.applySecrets(fields); err != nil {
+		return err
+	}
+
+	if err = l.applyEnv(fields); err != nil {
+		return err
+	}
+
+	if err = l.applyFlags(fields); err != nil {
+		return err
+	}
+
+	return checkRequired(fields)
+}
+
+func structFields(dest interface{}) ([]*field, error) {
+	value := reflect.ValueOf(dest)
+
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("error loading configuration: destination must be a pointer to a struct, got %T", dest)
+	}
+
+	return collectFields(value.Elem(), nil), nil
+}
+
+func applyDefaults(fields []*field) error {
+	for _, f := range fields {
+		if !f.hasDefault {
+			continue
+		}
+
+		if err := setFromString(f.value, f.defaultValue); err != nil {
+			return fmt.Errorf("error setting default for %s: %w", f.name, err)
+		}
+	}
+
+	return nil
+}
+
+func (l *Loader) applyFile(fields []*field, fileName string) error {
+	data, err := readFile(fileName)
+
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && l.config.IgnoreMissingFiles {
+			return nil
+		}
+
+		return err
+	}
+
+	for _, f := range fields {
+		raw, ok := lookupKey(data, f.key)
+
+		if !ok {
+			continue
+		}
+
+		if err = setFromFileValue(f.value, raw); err != nil {
+			return fmt.Errorf("error setting %s from %s: %w", f.name, fileName, err)
+		}
+	}
+
+	return nil
+}
+
+func (l *Loader) applySecrets(fields []*field) error {
+	for _, f := range fields {
+		if f.secretRef == "" {
+			continue
+		}
+
+		if l.config.Secrets == nil {
+			return fmt.Errorf("error setting %s: field references secret '%s' but no secrets provider is configured", f.name, f.secretRef)
+		}
+
+		secret, err := l.config.Secrets.GetSecret(context.Background(), f.secretRef)
+
+		if err != nil {
+			return fmt.Errorf("error setting %s from secret '%s': %w", f.name, f.secretRef, err)
+		}
+
+		if err = setFromString(f.value, secret.Value); err != nil {
+			return fmt.Errorf("error setting %s from secret '%s': %w", f.name, f.secretRef, err)
+		}
+	}
+
+	return nil
+}
+
+func (l *Loader) applyEnv(fields []*field) error {
+	for _, f := range fields {
+		if f.envName == "" {
+			continue
+		}
+
+		raw, ok := l.config.LookupEnv(l.config.EnvPrefix + f.envName)
+
+		if !ok {
+			continue
+		}
+
+		if err := setFromString(f.value, raw); err != nil {
+			return fmt.Errorf("error setting %s from environment variable %s: %w", f.name, l.config.EnvPrefix+f.envName, err)
+		}
+	}
+
+	return nil
+}
+
+func (l *Loader) applyFlags(fields []*field) error {
+	if l.config.Args == nil {
+		return nil
+	}
+
+	flagSet := flag.NewFlagSet(l.config.FlagSetName, flag.ContinueOnError)
+	values := make([]*flagValue, 0, len(fields))
+
+	for _, f := range fields {
+		if f.flagName == "" {
+			continue
+		}
+
+		value := &flagValue{
+			field:  f,
+			isBool: f.value.Kind() == reflect.Bool,
+		}
+
+		if f.hasDefault && !f.secret {
+			value.defaultValue = f.defaultValue
+		}
+
+		flagSet.Var(value, f.flagName, f.usage)
+		values = append(values, value)
+	}
+
+	if err := flagSet.Parse(l.config.Args); err != nil {
+		return fmt.Errorf("error parsing flags: %w", err)
+	}
+
+	for _, value := range values {
+		if !value.set {
+			continue
+		}
+
+		if err := setFromString(value.field.value, value.raw); err != nil {
+			return fmt.Errorf("error setting %s from flag -%s: %w", value.field.name, value.field.flagName, err)
+		}
+	}
+
+	return nil
+}
+
+func checkRequired(fields []*field) error {
+	missing := make([]string, 0)
+
+	for _, f := range fields {
+		if f.required && f.value.IsZero() {
+			missing = append(missing, f.name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingRequired, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+/*
+flagValue collects a flag's raw value so it can be applied after the
+flags are parsed
+*/
+type flagValue struct {
+	defaultValue string
+	field        *field
+	isBool       bool
+	raw          string
+	set          bool
+}
+
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+
+	return v.defaultValue
+}
+
+func (v *flagValue) Set(raw string) error {
+	v.raw = raw
+	v.set = true
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.isBool
+}
@@ -0,0 +1,35 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package config
+
+import "github.com/ResurgenceIT/kit/v6/secrets"
+
+/*
+LoaderConfig configures where a Loader reads configuration from.
+
+Files are loaded in order, with later files overriding earlier ones.
+The format is picked by extension: .json, .yaml, .yml, or .toml. A
+missing file is an error unless IgnoreMissingFiles is true.
+
+EnvPrefix is prepended to every environment variable name, so a prefix
+of "MYAPP_" reads the Port field from MYAPP_PORT. LookupEnv defaults to
+os.LookupEnv.
+
+Secrets is the provider used for fields with a secretRef tag. Wrap it
+in a secrets.Cache so reloads don't read every secret again.
+
+Args are command line arguments to parse as flags, usually os.Args[1:].
+Flags are skipped when Args is nil. FlagSetName names the flag set in
+usage output
+*/
+type LoaderConfig struct {
+	Args               []string
+	EnvPrefix          string
+	Files              []string
+	FlagSetName        string
+	IgnoreMissingFiles bool
+	LookupEnv          func(key string) (string, bool)
+	Secrets            secrets.IProvider
+}
@@ -0,0 +1,155 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/config"
+	"github.com/ResurgenceIT/kit/v6/secrets"
+)
+
+type testConfig struct {
+	Debug    bool
+	Hosts    []string
+	Name     string `required:"true"`
+	Port     int    `default:"8080"`
+	Timeout  time.Duration
+	Database struct {
+		MaxIdleConns int
+		Password     config.Secret
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"app.json": `{"name": "json", "port": 9000, "database": {"maxIdleConns": 4}}`,
+		"app.yaml": "name: yaml\nhosts:\n  - a\n  - b\ntimeout: 5s\n",
+		"app.toml": "name = \"toml\"\n[database]\npassword = \"hunter2\"\n",
+	}
+
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0600); err != nil {
+			t.Fatalf("error writing test file: %s", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		config   config.LoaderConfig
+		env      map[string]string
+		check    func(c testConfig) string
+		expected string
+		err      error
+	}{
+		{
+			name:     "Defaults and required values",
+			config:   config.LoaderConfig{Args: []string{"-name", "flags"}},
+			check:    func(c testConfig) string { return c.Name + " " + strconv.Itoa(c.Port) },
+			expected: "flags 8080",
+		},
+		{
+			name:   "Missing required values",
+			config: config.LoaderConfig{},
+			err:    config.ErrMissingRequired,
+		},
+		{
+			name:   "Later files override earlier ones",
+			config: config.LoaderConfig{Files: []string{filepath.Join(dir, "app.json"), filepath.Join(dir, "app.yaml"), filepath.Join(dir, "app.toml")}},
+			check: func(c testConfig) string {
+				return c.Name + " " + strconv.Itoa(c.Port) + " " + strings.Join(c.Hosts, ",") + " " + c.Timeout.String() + " " + string(c.Database.Password) + " " + strconv.Itoa(c.Database.MaxIdleConns)
+			},
+			expected: "toml 9000 a,b 5s hunter2 4",
+		},
+		{
+			name:   "Environment overrides files, flags override environment",
+			config: config.LoaderConfig{EnvPrefix: "APP_", Files: []string{filepath.Join(dir, "app.json")}, Args: []string{"-debug", "-database-max-idle-conns", "8"}},
+			env:    map[string]string{"APP_NAME": "env", "APP_DATABASE_MAX_IDLE_CONNS": "6", "APP_HOSTS": "x, y"},
+			check: func(c testConfig) string {
+				return c.Name + " " + strconv.Itoa(c.Database.MaxIdleConns) + " " + strings.Join(c.Hosts, ",") + " " + strconv.FormatBool(c.Debug)
+			},
+			expected: "env 8 x,y true",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := testConfig{}
+			test.config.LookupEnv = func(key string) (string, bool) {
+				value, ok := test.env[key]
+				return value, ok
+			}
+
+			err := config.NewLoader(test.config).Load(&c)
+
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Errorf("Expected error '%v' but got '%v'", test.err, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error but got '%s'", err)
+			}
+
+			if actual := test.check(c); actual != test.expected {
+				t.Errorf("Expected '%s' but got '%s'", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestLoadSecrets(t *testing.T) {
+	type secretConfig struct {
+		Password string `secretRef:"DB_PASSWORD"`
+		User     string `secretRef:"DB_USER"`
+	}
+
+	noEnv := func(key string) (string, bool) { return "", false }
+	values := map[string]string{"DB_PASSWORD": "hunter2"}
+	provider := secrets.NewEnvProvider(secrets.EnvProviderConfig{
+		LookupEnv: func(key string) (string, bool) {
+			value, ok := values[key]
+			return value, ok
+		},
+	})
+
+	c := secretConfig{}
+	loader := config.NewLoader(config.LoaderConfig{LookupEnv: noEnv, Secrets: provider})
+
+	if err := loader.Load(&c); !errors.Is(err, secrets.ErrNotFound) {
+		t.Fatalf("Expected error '%v' but got '%v'", secrets.ErrNotFound, err)
+	}
+
+	values["DB_USER"] = "bob"
+
+	if err := loader.Load(&c); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	if c.Password != "hunter2" || c.User != "bob" || strings.Contains(config.String(c), "hunter2") {
+		t.Errorf("Expected secrets to be loaded and redacted but got %s", config.String(c))
+	}
+}
+
+func TestString(t *testing.T) {
+	c := testConfig{Name: "app"}
+	c.Database.Password = "hunter2"
+
+	actual := config.String(c)
+
+	if strings.Contains(actual, "hunter2") || !strings.Contains(actual, "Database.Password=********") {
+		t.Errorf("Expected the password to be redacted but got '%s'", actual)
+	}
+}
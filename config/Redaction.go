@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var secretType = reflect.TypeOf(Secret(""))
+
+/*
+String returns a configuration struct as one "Name=value" line per
+field, with secret values redacted. This is handy for logging the
+configuration an application started with
+*/
+func String(config interface{}) string {
+	fields := describeFields(config)
+	lines := make([]string, 0, len(fields))
+
+	for _, f := range fields {
+		lines = append(lines, f.name+"="+fmt.Sprint(f.display))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+/*
+Fields returns a configuration struct as a map of field names to values,
+with secret values redacted. The result can be passed to
+logrus.WithFields
+*/
+func Fields(config interface{}) map[string]interface{} {
+	fields := describeFields(config)
+	result := make(map[string]interface{}, len(fields))
+
+	for _, f := range fields {
+		result[f.name] = f.display
+	}
+
+	return result
+}
+
+type describedField struct {
+	display interface{}
+	name    string
+}
+
+func describeFields(config interface{}) []describedField {
+	value := reflect.Indirect(reflect.ValueOf(config))
+
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := collectFields(value, nil)
+	result := make([]describedField, 0, len(fields))
+
+	for _, f := range fields {
+		var display interface{} = f.value.Interface()
+
+		if f.secret {
+			display = ""
+
+			if !f.value.IsZero() {
+				display = redacted
+			}
+		}
+
+		result = append(result, describedField{
+			display: display,
+			name:    f.name,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,31 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package config
+
+// redacted replaces secret values in output
+const redacted = "********"
+
+/*
+Secret is a string that hides its value when printed, logged, or
+marshaled to JSON, so configuration structs can be logged safely. Use
+string(secret) to get the value
+*/
+type Secret string
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+
+	return redacted
+}
+
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + s.String() + `"`), nil
+}
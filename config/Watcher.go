@@ -0,0 +1,291 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+/*
+ChangeHandler is called with the previous and current configuration
+when a reload changes it. Both are pointers to the configuration struct
+and must not be modified
+*/
+type ChangeHandler func(previous, current interface{})
+
+/*
+Watcher keeps a configuration up to date. It polls the loader's config
+files and reloads the configuration when any of them change. A reload
+that fails keeps the current configuration and is reported to error
+handlers, so a half-edited file doesn't take down a running server.
+
+Each reload loads into a new struct, so the configuration returned by
+Current is never modified once it has been handed out
+*/
+type Watcher struct {
+	changeHandlers map[uint64]ChangeHandler
+	current        interface{}
+	errorHandlers  []func(err error)
+	fileStates     map[string]fileState
+	handlerID      uint64
+	interval       time.Duration
+	loader         *Loader
+	shutdown       chan struct{}
+	stopOnce       sync.Once
+
+	sync.RWMutex
+}
+
+type fileState struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+/*
+Watch loads the configuration into dest, which must be a pointer to a
+struct, then checks the config files for changes every interval. If
+interval isn't positive it defaults to 2 seconds. Call Stop to stop
+watching.
+
+	watcher, err := loader.Watch(&appConfig, time.Second*5)
+
+	watcher.OnChange(func(previous, current interface{}) {
+		if config.Changed(previous, current, "LogLevel") {
+			logger.SetLevel(current.(*Config).LogLevel)
+		}
+	})
+*/
+func (l *Loader) Watch(dest interface{}, interval time.Duration) (*Watcher, error) {
+	if err := l.Load(dest); err != nil {
+		return nil, err
+	}
+
+	if interval <= 0 {
+		interval = time.Second * 2
+	}
+
+	w := &Watcher{
+		changeHandlers: make(map[uint64]ChangeHandler),
+		current:        dest,
+		errorHandlers:  make([]func(err error), 0),
+		fileStates:     l.fileStates(),
+		interval:       interval,
+		loader:         l,
+		shutdown:       make(chan struct{}),
+	}
+
+	go w.poll()
+	return w, nil
+}
+
+/*
+Current returns the latest configuration, a pointer to the
+configuration struct. The first call returns the dest passed to Watch
+*/
+func (w *Watcher) Current() interface{} {
+	w.RLock()
+	defer w.RUnlock()
+
+	return w.current
+}
+
+/*
+OnChange subscribes handler to configuration changes. Call the returned
+function to unsubscribe
+*/
+func (w *Watcher) OnChange(handler ChangeHandler) func() {
+	w.Lock()
+	defer w.Unlock()
+
+	w.handlerID++
+	id := w.handlerID
+	w.changeHandlers[id] = handler
+
+	return func() {
+		w.Lock()
+		defer w.Unlock()
+
+		delete(w.changeHandlers, id)
+	}
+}
+
+/*
+OnError subscribes handler to reload errors
+*/
+func (w *Watcher) OnError(handler func(err error)) {
+	w.Lock()
+	defer w.Unlock()
+
+	w.errorHandlers = append(w.errorHandlers, handler)
+}
+
+/*
+Reload loads the configuration again right away, whether or not the
+files changed. This is useful for reloading on SIGHUP, or after
+environment variables change. Change handlers are only called if the
+configuration is different
+*/
+func (w *Watcher) Reload() error {
+	var err error
+
+	next := reflect.New(reflect.TypeOf(w.Current()).Elem()).Interface()
+
+	if err = w.loader.Load(next); err != nil {
+		return fmt.Errorf("error reloading configuration: %w", err)
+	}
+
+	w.Lock()
+
+	previous := w.current
+
+	if reflect.DeepEqual(previous, next) {
+		w.Unlock()
+		return nil
+	}
+
+	w.current = next
+	handlers := make([]ChangeHandler, 0, len(w.changeHandlers))
+
+	for _, handler := range w.changeHandlers {
+		handlers = append(handlers, handler)
+	}
+
+	w.Unlock()
+
+	for _, handler := range handlers {
+		handler(previous, next)
+	}
+
+	return nil
+}
+
+/*
+Stop stops watching the config files
+*/
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.shutdown)
+	})
+}
+
+func (w *Watcher) poll() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.shutdown:
+			return
+
+		case <-ticker.C:
+			w.checkFiles()
+		}
+	}
+}
+
+func (w *Watcher) checkFiles() {
+	states := w.loader.fileStates()
+
+	if reflect.DeepEqual(states, w.fileStates) {
+		return
+	}
+
+	w.fileStates = states
+
+	if err := w.Reload(); err != nil {
+		w.RLock()
+		handlers := append([]func(err error){}, w.errorHandlers...)
+		w.RUnlock()
+
+		for _, handler := range handlers {
+			handler(err)
+		}
+	}
+}
+
+func (l *Loader) fileStates() map[string]fileState {
+	result := make(map[string]fileState, len(l.config.Files))
+
+	for _, fileName := range l.config.Files {
+		info, err := os.Stat(fileName)
+
+		if err != nil {
+			result[fileName] = fileState{}
+			continue
+		}
+
+		result[fileName] = fileState{
+			exists:  true,
+			modTime: info.ModTime(),
+			size:    info.Size(),
+		}
+	}
+
+	return result
+}
+
+/*
+Changed reports whether any of the named fields differ between two
+configurations, such as the previous and current configuration passed
+to a ChangeHandler. Nested fields are named by their path, such as
+"Database.Host". With no names it reports whether anything changed
+*/
+func Changed(previous, current interface{}, names ...string) bool {
+	changed := ChangedFields(previous, current)
+
+	if len(names) == 0 {
+		return len(changed) > 0
+	}
+
+	for _, name := range names {
+		for _, changedName := range changed {
+			if name == changedName {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+/*
+ChangedFields returns the names of the fields that differ between two
+configurations of the same type
+*/
+func ChangedFields(previous, current interface{}) []string {
+	previousFields := describeValues(previous)
+	currentFields := describeValues(current)
+	result := make([]string, 0)
+
+	for name, value := range currentFields {
+		if !reflect.DeepEqual(previousFields[name], value) {
+			result = append(result, name)
+		}
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+func describeValues(config interface{}) map[string]interface{} {
+	value := reflect.Indirect(reflect.ValueOf(config))
+	result := make(map[string]interface{})
+
+	if value.Kind() != reflect.Struct {
+		return result
+	}
+
+	for _, f := range collectFields(value, nil) {
+		result[f.name] = f.value.Interface()
+	}
+
+	return result
+}
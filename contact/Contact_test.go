@@ -0,0 +1,166 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package contact_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/contact"
+)
+
+func TestParsePhone(t *testing.T) {
+	tests := []struct {
+		input          string
+		defaultCountry string
+		e164           string
+		country        string
+		extension      string
+		err            error
+	}{
+		{input: "(415) 555-2671", defaultCountry: "US", e164: "+14155552671", country: "US"},
+		{input: "1-415-555-2671", defaultCountry: "US", e164: "+14155552671", country: "US"},
+		{input: "416.555.0199", defaultCountry: "US", e164: "+14165550199", country: "CA"},
+		{input: "+1 604 555 0199", defaultCountry: "GB", e164: "+16045550199", country: "CA"},
+		{input: "020 7946 0958", defaultCountry: "GB", e164: "+442079460958", country: "GB"},
+		{input: "+44 (0)20 7946 0958", defaultCountry: "US", err: contact.ErrInvalidPhone},
+		{input: "0044 20 7946 0958", defaultCountry: "DE", e164: "+442079460958", country: "GB"},
+		{input: "011 33 6 12 34 56 78", defaultCountry: "US", e164: "+33612345678", country: "FR"},
+		{input: "+353 1 234 5678", defaultCountry: "US", e164: "+35312345678", country: "IE"},
+		{input: "(415) 555-2671 ext. 42", defaultCountry: "US", e164: "+14155552671", country: "US", extension: "42"},
+		{input: "555-2671", defaultCountry: "US", err: contact.ErrInvalidPhone},
+		{input: "(115) 555-2671", defaultCountry: "US", err: contact.ErrInvalidPhone},
+		{input: "call me", defaultCountry: "US", err: contact.ErrInvalidPhone},
+		{input: "+999 1234 5678", defaultCountry: "US", err: contact.ErrUnknownCountry},
+		{input: "0612345678", defaultCountry: "XX", err: contact.ErrUnknownCountry},
+	}
+
+	for _, test := range tests {
+		phone, err := contact.ParsePhone(test.input, test.defaultCountry)
+
+		if test.err != nil {
+			if !errors.Is(err, test.err) {
+				t.Errorf("Expected %v for %s but got %v", test.err, test.input, err)
+			}
+
+			continue
+		}
+
+		if err != nil || phone.E164() != test.e164 || phone.Country != test.country || phone.Extension != test.extension {
+			t.Errorf("Expected %s in %s for %s but got %+v, %v", test.e164, test.country, test.input, phone, err)
+		}
+	}
+}
+
+func TestPhoneFormats(t *testing.T) {
+	phone, _ := contact.ParsePhone("415 555 2671 x7", "US")
+
+	if phone.International() != "+1 415-555-2671 x7" || phone.NationalFormat() != "(415) 555-2671 x7" {
+		t.Errorf("Unexpected formats %s and %s", phone.International(), phone.NationalFormat())
+	}
+
+	uk, _ := contact.ParsePhone("+442079460958", "")
+
+	if uk.NationalFormat() != "02079460958" {
+		t.Errorf("Expected the trunk prefix but got %s", uk.NationalFormat())
+	}
+
+	b, _ := json.Marshal(map[string]contact.Phone{"phone": phone})
+
+	if string(b) != `{"phone":"+14155552671"}` {
+		t.Errorf("Unexpected JSON %s", b)
+	}
+
+	var decoded contact.Phone
+
+	if err := json.Unmarshal([]byte(`"(415) 555-2671"`), &decoded); !errors.Is(err, contact.ErrInvalidPhone) {
+		t.Errorf("Expected JSON to need E.164 but got %v", err)
+	}
+
+	if err := decoded.Scan("+14155552671"); err != nil || decoded.Country != "US" {
+		t.Errorf("Expected E.164 to scan but got %+v, %v", decoded, err)
+	}
+}
+
+func TestEmail(t *testing.T) {
+	tests := []struct {
+		input     string
+		normal    string
+		canonical string
+	}{
+		{input: " Adam.Presley+News@Example.COM ", normal: "adam.presley+news@example.com", canonical: "adam.presley@example.com"},
+		{input: "A.d.a.m+x@GoogleMail.com", normal: "a.d.a.m+x@googlemail.com", canonical: "adam@gmail.com"},
+		{input: "info@bücher.example", normal: "info@xn--bcher-kva.example", canonical: "info@xn--bcher-kva.example"},
+	}
+
+	for _, test := range tests {
+		normal, err := contact.NormalizeEmail(test.input)
+
+		if err != nil || normal != test.normal {
+			t.Errorf("Expected %s but got %s, %v", test.normal, normal, err)
+		}
+
+		if canonical, _ := contact.CanonicalEmail(test.input); canonical != test.canonical {
+			t.Errorf("Expected %s but got %s", test.canonical, canonical)
+		}
+	}
+
+	for _, invalid := range []string{"Adam <adam@example.com>", "adam@localhost", "adam@-example.com", "adam@example..com", "adam", "@example.com", "adam@[127.0.0.1]"} {
+		if err := contact.ValidateEmail(invalid); !errors.Is(err, contact.ErrInvalidEmail) {
+			t.Errorf("Expected %s to be invalid", invalid)
+		}
+	}
+}
+
+type fakeResolver struct {
+	hosts map[string][]string
+	mx    map[string][]*net.MX
+}
+
+func (r fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if addresses, ok := r.hosts[host]; ok {
+		return addresses, nil
+	}
+
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func (r fakeResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	if records, ok := r.mx[name]; ok {
+		return records, nil
+	}
+
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func TestCheckMX(t *testing.T) {
+	resolver := fakeResolver{
+		hosts: map[string][]string{"small.example": {"192.0.2.1"}},
+		mx: map[string][]*net.MX{
+			"example.com":    {{Host: "mx1.example.com.", Pref: 10}},
+			"nomail.example": {{Host: ".", Pref: 0}},
+		},
+	}
+
+	tests := []struct {
+		email string
+		err   error
+	}{
+		{email: "adam@example.com"},
+		{email: "adam@small.example"},
+		{email: "adam@nomail.example", err: contact.ErrNoMailServer},
+		{email: "adam@gmial.example", err: contact.ErrNoMailServer},
+		{email: "not an email", err: contact.ErrInvalidEmail},
+	}
+
+	for _, test := range tests {
+		if err := contact.CheckMX(context.Background(), test.email, resolver); !errors.Is(err, test.err) {
+			t.Errorf("Expected %v for %s but got %v", test.err, test.email, err)
+		}
+	}
+}
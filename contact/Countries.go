@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package contact
+
+/*
+country is how a country's phone numbers work: its calling code, the
+trunk prefix dialed before national numbers, and how many digits its
+national numbers have
+*/
+type country struct {
+	callingCode string
+	code        string
+	maxLength   int
+	minLength   int
+	trunkPrefix string
+}
+
+var countries = map[string]country{
+	"AT": {callingCode: "43", code: "AT", minLength: 4, maxLength: 13, trunkPrefix: "0"},
+	"AU": {callingCode: "61", code: "AU", minLength: 9, maxLength: 9, trunkPrefix: "0"},
+	"BE": {callingCode: "32", code: "BE", minLength: 8, maxLength: 9, trunkPrefix: "0"},
+	"BR": {callingCode: "55", code: "BR", minLength: 10, maxLength: 11, trunkPrefix: "0"},
+	"CA": {callingCode: "1", code: "CA", minLength: 10, maxLength: 10, trunkPrefix: "1"},
+	"CH": {callingCode: "41", code: "CH", minLength: 9, maxLength: 9, trunkPrefix: "0"},
+	"CN": {callingCode: "86", code: "CN", minLength: 7, maxLength: 11, trunkPrefix: "0"},
+	"DE": {callingCode: "49", code: "DE", minLength: 6, maxLength: 13, trunkPrefix: "0"},
+	"DK": {callingCode: "45", code: "DK", minLength: 8, maxLength: 8},
+	"ES": {callingCode: "34", code: "ES", minLength: 9, maxLength: 9},
+	"FR": {callingCode: "33", code: "FR", minLength: 9, maxLength: 9, trunkPrefix: "0"},
+	"GB": {callingCode: "44", code: "GB", minLength: 9, maxLength: 10, trunkPrefix: "0"},
+	"IE": {callingCode: "353", code: "IE", minLength: 7, maxLength: 9, trunkPrefix: "0"},
+	"IN": {callingCode: "91", code: "IN", minLength: 10, maxLength: 10, trunkPrefix: "0"},
+	"IT": {callingCode: "39", code: "IT", minLength: 6, maxLength: 11},
+	"JP": {callingCode: "81", code: "JP", minLength: 9, maxLength: 10, trunkPrefix: "0"},
+	"KR": {callingCode: "82", code: "KR", minLength: 8, maxLength: 10, trunkPrefix: "0"},
+	"MX": {callingCode: "52", code: "MX", minLength: 10, maxLength: 10},
+	"NL": {callingCode: "31", code: "NL", minLength: 9, maxLength: 9, trunkPrefix: "0"},
+	"NO": {callingCode: "47", code: "NO", minLength: 8, maxLength: 8},
+	"NZ": {callingCode: "64", code: "NZ", minLength: 8, maxLength: 10, trunkPrefix: "0"},
+	"PL": {callingCode: "48", code: "PL", minLength: 9, maxLength: 9},
+	"PT": {callingCode: "351", code: "PT", minLength: 9, maxLength: 9},
+	"SE": {callingCode: "46", code: "SE", minLength: 7, maxLength: 10, trunkPrefix: "0"},
+	"SG": {callingCode: "65", code: "SG", minLength: 8, maxLength: 8},
+	"US": {callingCode: "1", code: "US", minLength: 10, maxLength: 10, trunkPrefix: "1"},
+	"ZA": {callingCode: "27", code: "ZA", minLength: 9, maxLength: 9, trunkPrefix: "0"},
+}
+
+// canadianAreaCodes tell Canadian numbers from other +1 numbers, which are taken to be in the US
+var canadianAreaCodes = map[string]bool{
+	"204": true, "226": true, "236": true, "249": true, "250": true, "263": true, "289": true, "306": true,
+	"343": true, "354": true, "365": true, "367": true, "368": true, "382": true, "403": true, "416": true,
+	"418": true, "428": true, "431": true, "437": true, "438": true, "450": true, "468": true, "474": true,
+	"506": true, "514": true, "519": true, "548": true, "579": true, "581": true, "584": true, "587": true,
+	"604": true, "613": true, "639": true, "647": true, "672": true, "683": true, "705": true, "709": true,
+	"742": true, "753": true, "778": true, "780": true, "782": true, "807": true, "819": true, "825": true,
+	"867": true, "873": true, "879": true, "902": true, "905": true,
+}
+
+/*
+countryForCallingCode finds the country of an international number,
+returning it and the national number
+*/
+func countryForCallingCode(digits string) (country, string, bool) {
+	// Calling codes are prefix free, so at most one of 1, 2, or 3 digits matches
+	for length := 1; length <= 3 && length < len(digits); length++ {
+		prefix := digits[:length]
+
+		if prefix == "1" {
+			national := digits[1:]
+
+			if len(national) >= 3 && canadianAreaCodes[national[:3]] {
+				return countries["CA"], national, true
+			}
+
+			return countries["US"], national, true
+		}
+
+		for _, c := range countries {
+			if c.callingCode == prefix {
+				return c, digits[length:], true
+			}
+		}
+	}
+
+	return country{}, "", false
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package contact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+var (
+	ErrInvalidEmail = errors.New("invalid email address")
+	ErrNoMailServer = errors.New("domain doesn't accept email")
+)
+
+// gmailDomains ignore dots in the local part
+var gmailDomains = map[string]bool{"gmail.com": true, "googlemail.com": true}
+
+/*
+IResolver looks up DNS records. *net.Resolver is one
+*/
+type IResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+}
+
+/*
+ValidateEmail checks an email address's syntax. Addresses must be bare,
+as in "adam@example.com" rather than "Adam <adam@example.com>", and
+their domain must have at least two labels. Internationalized domains,
+such as "bücher.example", are allowed
+*/
+func ValidateEmail(email string) error {
+	_, _, err := splitEmail(email)
+	return err
+}
+
+/*
+NormalizeEmail trims an email address and folds it to lower case, so
+"Adam@Example.COM " and "adam@example.com" are the same account.
+Internationalized domains are written in their ASCII form. Store and
+look up addresses in this form
+*/
+func NormalizeEmail(email string) (string, error) {
+	local, domain, err := splitEmail(strings.TrimSpace(email))
+
+	if err != nil {
+		return "", err
+	}
+
+	return strings.ToLower(local) + "@" + domain, nil
+}
+
+/*
+CanonicalEmail goes further than NormalizeEmail, removing plus address
+tags, as in "adam+news@example.com", and the dots Gmail ignores. Use it
+to spot one person signing up many times, not as the address to send
+to
+*/
+func CanonicalEmail(email string) (string, error) {
+	normalized, err := NormalizeEmail(email)
+
+	if err != nil {
+		return "", err
+	}
+
+	index := strings.LastIndex(normalized, "@")
+	local, domain := normalized[:index], normalized[index+1:]
+
+	if tag := strings.Index(local, "+"); tag > 0 {
+		local = local[:tag]
+	}
+
+	if gmailDomains[domain] {
+		local = strings.ReplaceAll(local, ".", "")
+		domain = "gmail.com"
+	}
+
+	return local + "@" + domain, nil
+}
+
+/*
+CheckMX makes sure an email address's domain accepts email, by looking
+up its MX records, or its address records when it has no MX records.
+Domains that publish a null MX, saying they take no email, fail with
+ErrNoMailServer. resolver can be nil to use the system's. Lookups need
+the network, so use it to warn about typos such as "gmial.com" at sign
+up rather than on every request
+*/
+func CheckMX(ctx context.Context, email string, resolver IResolver) error {
+	_, domain, err := splitEmail(email)
+
+	if err != nil {
+		return err
+	}
+
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	records, err := resolver.LookupMX(ctx, domain)
+
+	if err == nil && len(records) > 0 {
+		if len(records) == 1 && (records[0].Host == "." || records[0].Host == "") {
+			return fmt.Errorf("%w: %s", ErrNoMailServer, domain)
+		}
+
+		return nil
+	}
+
+	var dnsError *net.DNSError
+
+	if err != nil && (!errors.As(err, &dnsError) || !dnsError.IsNotFound) {
+		return fmt.Errorf("error looking up mail servers for %s: %w", domain, err)
+	}
+
+	// Without MX records, mail goes to the domain's own address
+	if addresses, err := resolver.LookupHost(ctx, domain); err == nil && len(addresses) > 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrNoMailServer, domain)
+}
+
+/*
+splitEmail checks an address, returning its local part and its domain in
+lower case ASCII
+*/
+func splitEmail(email string) (string, string, error) {
+	invalid := fmt.Errorf("%w: %q", ErrInvalidEmail, email)
+
+	if len(email) > 254 {
+		return "", "", invalid
+	}
+
+	address, err := mail.ParseAddress(email)
+
+	if err != nil || address.Name != "" || address.Address != email {
+		return "", "", invalid
+	}
+
+	index := strings.LastIndex(email, "@")
+	local, domain := email[:index], email[index+1:]
+
+	if len(local) > 64 || strings.HasPrefix(domain, "[") {
+		return "", "", invalid
+	}
+
+	ascii, err := idna.Lookup.ToASCII(strings.ToLower(domain))
+
+	if err != nil || !strings.Contains(ascii, ".") {
+		return "", "", invalid
+	}
+
+	for _, label := range strings.Split(ascii, ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return "", "", invalid
+		}
+	}
+
+	return local, ascii, nil
+}
@@ -0,0 +1,258 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package contact
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidPhone   = errors.New("invalid phone number")
+	ErrUnknownCountry = errors.New("unknown country")
+)
+
+var extensionPattern = regexp.MustCompile(`(?i)\s*(?:ext\.?|extension|x|#)\s*(\d{1,6})\s*$`)
+
+/*
+Phone is a phone number. Country is its ISO 3166 country code, such as
+"US", and National the national number without a trunk prefix. It
+marshals to JSON and text, and is stored, in E.164 form, such as
++14155552671. Extensions aren't part of E.164, so they are kept apart
+*/
+type Phone struct {
+	CallingCode string
+	Country     string
+	Extension   string
+	National    string
+}
+
+/*
+ParsePhone parses a phone number as people write it, such as
+"(415) 555-2671", "+44 20 7946 0958", or "0044 20 7946 0958". Numbers
+without an international prefix are in defaultCountry, such as "US",
+and can start with its trunk prefix, such as the 0 in UK numbers. An
+extension, such as "x123" or "ext. 123", is kept in Extension.
+
+Numbers are checked against the length of national numbers for around
+thirty countries. Numbers for other countries are rejected with
+ErrUnknownCountry
+*/
+func ParsePhone(input, defaultCountry string) (Phone, error) {
+	result := Phone{}
+	text := strings.TrimSpace(input)
+
+	if match := extensionPattern.FindStringSubmatchIndex(text); match != nil && match[0] > 0 {
+		result.Extension = text[match[2]:match[3]]
+		text = text[:match[0]]
+	}
+
+	international := strings.HasPrefix(text, "+")
+	digits := strings.Builder{}
+
+	for _, r := range text {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+
+		case strings.ContainsRune(" -.()/+", r):
+			// Punctuation people write numbers with
+
+		default:
+			return Phone{}, fmt.Errorf("%w: %q", ErrInvalidPhone, input)
+		}
+	}
+
+	number := digits.String()
+	var c country
+	var ok bool
+
+	switch {
+	case international:
+		if c, number, ok = countryForCallingCode(number); !ok {
+			return Phone{}, fmt.Errorf("%w: %q", ErrUnknownCountry, input)
+		}
+
+	case strings.HasPrefix(number, "00") || (strings.HasPrefix(number, "011") && isNANP(defaultCountry)):
+		// International prefixes, as dialed from Europe or North America
+		number = strings.TrimPrefix(strings.TrimPrefix(number, "011"), "00")
+
+		if c, number, ok = countryForCallingCode(number); !ok {
+			return Phone{}, fmt.Errorf("%w: %q", ErrUnknownCountry, input)
+		}
+
+	default:
+		if c, ok = countries[strings.ToUpper(defaultCountry)]; !ok {
+			return Phone{}, fmt.Errorf("%w: %q", ErrUnknownCountry, defaultCountry)
+		}
+
+		if c.trunkPrefix != "" && len(number) > c.maxLength && strings.HasPrefix(number, c.trunkPrefix) {
+			number = strings.TrimPrefix(number, c.trunkPrefix)
+		} else if c.trunkPrefix == "0" && strings.HasPrefix(number, "0") {
+			number = number[1:]
+		}
+
+		if c.callingCode == "1" {
+			c, _, _ = countryForCallingCode("1" + number)
+		}
+	}
+
+	if len(number) < c.minLength || len(number) > c.maxLength {
+		return Phone{}, fmt.Errorf("%w: %q", ErrInvalidPhone, input)
+	}
+
+	// North American area codes and exchanges can't start with 0 or 1
+	if c.callingCode == "1" && (number[0] < '2' || number[3] < '2') {
+		return Phone{}, fmt.Errorf("%w: %q", ErrInvalidPhone, input)
+	}
+
+	result.CallingCode = c.callingCode
+	result.Country = c.code
+	result.National = number
+
+	return result, nil
+}
+
+/*
+IsZero returns true for a Phone with no number
+*/
+func (p Phone) IsZero() bool {
+	return p.National == ""
+}
+
+/*
+E164 returns the number in E.164 form, such as +14155552671
+*/
+func (p Phone) E164() string {
+	if p.IsZero() {
+		return ""
+	}
+
+	return "+" + p.CallingCode + p.National
+}
+
+/*
+International returns the number for people in other countries, such as
+"+1 415-555-2671" or "+44 2079460958". Only North American numbers are
+grouped
+*/
+func (p Phone) International() string {
+	if p.IsZero() {
+		return ""
+	}
+
+	result := "+" + p.CallingCode + " "
+
+	if p.CallingCode == "1" {
+		result += p.National[:3] + "-" + p.National[3:6] + "-" + p.National[6:]
+	} else {
+		result += p.National
+	}
+
+	return result + p.extension()
+}
+
+/*
+NationalFormat returns the number for people in its own country. North
+American numbers are written (415) 555-2671, and others with their
+trunk prefix, such as 02079460958
+*/
+func (p Phone) NationalFormat() string {
+	if p.IsZero() {
+		return ""
+	}
+
+	if p.CallingCode == "1" {
+		return "(" + p.National[:3] + ") " + p.National[3:6] + "-" + p.National[6:] + p.extension()
+	}
+
+	return countries[p.Country].trunkPrefix + p.National + p.extension()
+}
+
+/*
+String returns the number in E.164 form
+*/
+func (p Phone) String() string {
+	return p.E164()
+}
+
+/*
+MarshalText satisfies encoding.TextMarshaler, which JSON uses too
+*/
+func (p Phone) MarshalText() ([]byte, error) {
+	return []byte(p.E164()), nil
+}
+
+/*
+UnmarshalText satisfies encoding.TextUnmarshaler, which JSON uses too.
+Numbers must be in E.164 form
+*/
+func (p *Phone) UnmarshalText(b []byte) error {
+	if len(b) == 0 {
+		*p = Phone{}
+		return nil
+	}
+
+	if b[0] != '+' {
+		return fmt.Errorf("%w: %q is not in E.164 form", ErrInvalidPhone, b)
+	}
+
+	result, err := ParsePhone(string(b), "")
+
+	if err != nil {
+		return err
+	}
+
+	*p = result
+	return nil
+}
+
+/*
+Scan satisfies sql.Scanner
+*/
+func (p *Phone) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*p = Phone{}
+		return nil
+
+	case string:
+		return p.UnmarshalText([]byte(v))
+
+	case []byte:
+		return p.UnmarshalText(v)
+
+	default:
+		return fmt.Errorf("cannot scan %T into a Phone", src)
+	}
+}
+
+/*
+Value satisfies driver.Valuer, storing the number in E.164 form, or
+NULL when there is no number
+*/
+func (p Phone) Value() (driver.Value, error) {
+	if p.IsZero() {
+		return nil, nil
+	}
+
+	return p.E164(), nil
+}
+
+func (p Phone) extension() string {
+	if p.Extension == "" {
+		return ""
+	}
+
+	return " x" + p.Extension
+}
+
+func isNANP(code string) bool {
+	c, ok := countries[strings.ToUpper(code)]
+	return ok && c.callingCode == "1"
+}
@@ -0,0 +1,281 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+)
+
+/*
+Ciphertext produced by Encrypt starts with a two byte header: the
+format version, then the mode. The header is authenticated along with
+the ciphertext, so it can't be changed without decryption failing
+*/
+const (
+	formatVersion byte = 1
+
+	modeKey        byte = 1
+	modePassphrase byte = 2
+	modeStream     byte = 3
+
+	headerLength = 2
+	saltLength   = 16
+
+	// maxIterations guards against ciphertext that asks for an absurd amount of key derivation work
+	maxIterations = 10000000
+)
+
+/*
+Encrypt encrypts plaintext with AES-GCM. The key must be 16, 24, or 32
+bytes long, for AES-128, AES-192, or AES-256. The result carries a
+versioned header and a random nonce
+*/
+func Encrypt(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	header := []byte{formatVersion, modeKey}
+	return seal(gcm, header, plaintext, header)
+}
+
+/*
+Decrypt decrypts ciphertext produced by Encrypt
+*/
+func Decrypt(key, ciphertext []byte) ([]byte, error) {
+	if err := checkHeader(ciphertext, modeKey); err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return open(gcm, ciphertext[headerLength:], ciphertext[:headerLength])
+}
+
+/*
+EncryptWithPassphrase encrypts plaintext with a key derived from a
+passphrase using DefaultKDFOptions. A random salt and the iteration
+count are stored in the result, so only the passphrase is needed to
+decrypt it. Key derivation is deliberately slow, so prefer Encrypt with
+a derived or generated key when encrypting many values
+*/
+func EncryptWithPassphrase(passphrase string, plaintext []byte) ([]byte, error) {
+	salt, err := randomBytes(saltLength)
+
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, headerLength+4, headerLength+4+saltLength)
+	header[0] = formatVersion
+	header[1] = modePassphrase
+	binary.BigEndian.PutUint32(header[headerLength:], uint32(DefaultKDFOptions.Iterations))
+	header = append(header, salt...)
+
+	gcm, err := newGCM(passphraseKey(passphrase, salt, DefaultKDFOptions.Iterations))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return seal(gcm, header, plaintext, header)
+}
+
+/*
+DecryptWithPassphrase decrypts ciphertext produced by
+EncryptWithPassphrase
+*/
+func DecryptWithPassphrase(passphrase string, ciphertext []byte) ([]byte, error) {
+	if err := checkHeader(ciphertext, modePassphrase); err != nil {
+		return nil, err
+	}
+
+	headerEnd := headerLength + 4 + saltLength
+
+	if len(ciphertext) < headerEnd {
+		return nil, ErrInvalidCiphertext
+	}
+
+	iterations := binary.BigEndian.Uint32(ciphertext[headerLength:])
+
+	if iterations == 0 || iterations > maxIterations {
+		return nil, ErrInvalidCiphertext
+	}
+
+	salt := ciphertext[headerLength+4 : headerEnd]
+	gcm, err := newGCM(passphraseKey(passphrase, salt, int(iterations)))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return open(gcm, ciphertext[headerEnd:], ciphertext[:headerEnd])
+}
+
+/*
+EncryptString encrypts a string with Encrypt and returns it URL-safe
+Base64 encoded, ready to be stored in a text column or sent in a URL
+*/
+func EncryptString(key []byte, plaintext string) (string, error) {
+	result, err := Encrypt(key, []byte(plaintext))
+
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(result), nil
+}
+
+/*
+DecryptString decrypts a string produced by EncryptString
+*/
+func DecryptString(key []byte, ciphertext string) (string, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(ciphertext)
+
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidCiphertext, err.Error())
+	}
+
+	result, err := Decrypt(key, decoded)
+	return string(result), err
+}
+
+/*
+Seal encrypts plaintext with AES-GCM and returns the nonce followed by
+the ciphertext, without a header. This is the format JWTService tokens
+have always used. Prefer Encrypt for new data
+*/
+func Seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return seal(gcm, nil, plaintext, nil)
+}
+
+/*
+Open decrypts data produced by Seal
+*/
+func Open(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return open(gcm, sealed, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, ErrInvalidKey
+	}
+
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, fmt.Errorf("error creating AES cipher: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+
+	if err != nil {
+		return nil, fmt.Errorf("error creating GCM: %w", err)
+	}
+
+	return gcm, nil
+}
+
+/*
+seal appends a random nonce and the sealed plaintext to prefix
+*/
+func seal(gcm cipher.AEAD, prefix, plaintext, additionalData []byte) ([]byte, error) {
+	nonce, err := randomBytes(gcm.NonceSize())
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, 0, len(prefix)+len(nonce)+len(plaintext)+gcm.Overhead())
+	result = append(result, prefix...)
+	result = append(result, nonce...)
+
+	return gcm.Seal(result, nonce, plaintext, additionalData), nil
+}
+
+/*
+open splits the nonce off sealed data and decrypts the rest
+*/
+func open(gcm cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, ErrInvalidCiphertext
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	result, err := gcm.Open(nil, nonce, ciphertext, additionalData)
+
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+
+	return result, nil
+}
+
+func checkHeader(ciphertext []byte, mode byte) error {
+	if len(ciphertext) < headerLength {
+		return ErrInvalidCiphertext
+	}
+
+	if ciphertext[0] != formatVersion {
+		return fmt.Errorf("%w: %d", ErrUnsupportedVersion, ciphertext[0])
+	}
+
+	if ciphertext[1] != mode {
+		return fmt.Errorf("%w: %s", ErrInvalidCiphertext, modeMismatch(ciphertext[1]))
+	}
+
+	return nil
+}
+
+func modeMismatch(mode byte) string {
+	switch mode {
+	case modeKey:
+		return "encrypted with a key, use Decrypt"
+
+	case modePassphrase:
+		return "encrypted with a passphrase, use DecryptWithPassphrase"
+
+	case modeStream:
+		return "encrypted as a stream, use NewDecryptReader"
+
+	case modeKeyring:
+		return "encrypted with a keyring, use Keyring.Decrypt"
+	}
+
+	return "unknown mode"
+}
+
+func passphraseKey(passphrase string, salt []byte, iterations int) []byte {
+	return DeriveKey([]byte(passphrase), salt, KDFOptions{
+		Hash:       sha256.New,
+		Iterations: iterations,
+		KeyLength:  32,
+	})
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package crypto_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/crypto"
+)
+
+func TestEncrypt(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	otherKey, _ := crypto.GenerateKey()
+	plaintext := []byte("some secret data")
+
+	encrypted, err := crypto.Encrypt(key, plaintext)
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	tampered := append([]byte{}, encrypted...)
+	tampered[len(tampered)-1] ^= 1
+
+	versioned := append([]byte{}, encrypted...)
+	versioned[0] = 99
+
+	tests := []struct {
+		name       string
+		key        []byte
+		ciphertext []byte
+		err        error
+	}{
+		{name: "Decrypts with the same key", key: key, ciphertext: encrypted},
+		{name: "Fails with a different key", key: otherKey, ciphertext: encrypted, err: crypto.ErrInvalidCiphertext},
+		{name: "Fails when tampered with", key: key, ciphertext: tampered, err: crypto.ErrInvalidCiphertext},
+		{name: "Fails on unknown versions", key: key, ciphertext: versioned, err: crypto.ErrUnsupportedVersion},
+		{name: "Fails on short keys", key: key[:10], ciphertext: encrypted, err: crypto.ErrInvalidKey},
+		{name: "Fails on short ciphertext", key: key, ciphertext: encrypted[:5], err: crypto.ErrInvalidCiphertext},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := crypto.Decrypt(test.key, test.ciphertext)
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Expected error '%v' but got '%v'", test.err, err)
+			}
+
+			if test.err == nil && string(actual) != string(plaintext) {
+				t.Errorf("Expected '%s' but got '%s'", plaintext, actual)
+			}
+		})
+	}
+}
+
+func TestEncryptWithPassphrase(t *testing.T) {
+	encrypted, _ := crypto.EncryptWithPassphrase("correct horse", []byte("hello"))
+
+	if actual, err := crypto.DecryptWithPassphrase("correct horse", encrypted); err != nil || string(actual) != "hello" {
+		t.Errorf("Expected 'hello' but got '%s' (%v)", actual, err)
+	}
+
+	if _, err := crypto.DecryptWithPassphrase("battery staple", encrypted); !errors.Is(err, crypto.ErrInvalidCiphertext) {
+		t.Errorf("Expected error '%v' but got '%v'", crypto.ErrInvalidCiphertext, err)
+	}
+}
+
+func TestStream(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+
+	for _, size := range []int{0, 1, 64 * 1024, 200000} {
+		plaintext := bytes.Repeat([]byte{'a'}, size)
+		encrypted := &bytes.Buffer{}
+
+		writer, _ := crypto.NewEncryptWriter(encrypted, key)
+		_, _ = writer.Write(plaintext)
+		_ = writer.Close()
+
+		reader, _ := crypto.NewDecryptReader(bytes.NewReader(encrypted.Bytes()), key)
+		actual, err := ioutil.ReadAll(reader)
+
+		if err != nil || !bytes.Equal(actual, plaintext) {
+			t.Errorf("Expected %d bytes back but got %d (%v)", size, len(actual), err)
+		}
+
+		if size == 0 {
+			continue
+		}
+
+		reader, _ = crypto.NewDecryptReader(bytes.NewReader(encrypted.Bytes()[:encrypted.Len()-20]), key)
+
+		if _, err = ioutil.ReadAll(reader); !errors.Is(err, crypto.ErrTruncatedStream) {
+			t.Errorf("Expected error '%v' for %d bytes but got '%v'", crypto.ErrTruncatedStream, size, err)
+		}
+	}
+}
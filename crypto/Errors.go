@@ -0,0 +1,24 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package crypto
+
+import "errors"
+
+var (
+	// ErrInvalidCiphertext is returned when ciphertext is too short, corrupt, or was encrypted with a different key
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+
+	// ErrInvalidKey is returned when a key isn't 16, 24, or 32 bytes long
+	ErrInvalidKey = errors.New("invalid key: keys must be 16, 24, or 32 bytes long")
+
+	// ErrTruncatedStream is returned when an encrypted stream ends before its final chunk
+	ErrTruncatedStream = errors.New("encrypted stream is truncated")
+
+	// ErrUnknownKey is returned when a Keyring doesn't have the key some ciphertext was encrypted with
+	ErrUnknownKey = errors.New("unknown key")
+
+	// ErrUnsupportedVersion is returned when ciphertext has a header this version of the package doesn't know
+	ErrUnsupportedVersion = errors.New("unsupported ciphertext version")
+)
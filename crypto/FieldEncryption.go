@@ -0,0 +1,257 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package crypto
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+/*
+EncryptedPrefix marks string fields that have been encrypted by
+EncryptFields. The rest of the value is the URL-safe Base64 encoded
+ciphertext
+*/
+const EncryptedPrefix = "enc:"
+
+var bytesType = reflect.TypeOf([]byte(nil))
+
+/*
+EncryptFields encrypts every field of a struct tagged `encrypt:"true"`
+with the keyring's current key. v must be a pointer to a struct. Nested
+structs, pointers to structs, and slices of structs are walked too.
+For example:
+
+	type Customer struct {
+		Email string `encrypt:"true"`
+		Name  string
+		Notes []byte `encrypt:"true"`
+		SSN   *string `encrypt:"true"`
+	}
+
+Tagged fields must be a string, *string, or []byte. Strings are replaced
+with EncryptedPrefix followed by Base64 ciphertext, and byte slices with
+the raw ciphertext. Fields that are already encrypted or empty are left
+alone, so calling EncryptFields twice is safe
+*/
+func (k *Keyring) EncryptFields(v interface{}) error {
+	return walkEncryptedFields(v, func(field reflect.Value, name string) error {
+		return transformField(field, name, true, func(value []byte, encrypted bool) ([]byte, bool, error) {
+			if encrypted {
+				return value, false, nil
+			}
+
+			result, err := k.Encrypt(value)
+			return result, err == nil, err
+		})
+	})
+}
+
+/*
+DecryptFields decrypts every field tagged `encrypt:"true"` in place.
+Fields that aren't encrypted are left alone, which lets records written
+before a field was marked for encryption still be read
+*/
+func (k *Keyring) DecryptFields(v interface{}) error {
+	return walkEncryptedFields(v, func(field reflect.Value, name string) error {
+		return transformField(field, name, false, func(value []byte, encrypted bool) ([]byte, bool, error) {
+			if !encrypted {
+				return value, false, nil
+			}
+
+			result, err := k.Decrypt(value)
+			return result, err == nil, err
+		})
+	})
+}
+
+/*
+RotateFields re-encrypts every encrypted field that wasn't encrypted
+with the current key, and returns how many fields changed. Use it to
+migrate records after calling SetCurrentKey
+*/
+func (k *Keyring) RotateFields(v interface{}) (int, error) {
+	count := 0
+
+	err := walkEncryptedFields(v, func(field reflect.Value, name string) error {
+		return transformField(field, name, true, func(value []byte, encrypted bool) ([]byte, bool, error) {
+			if !encrypted {
+				return value, false, nil
+			}
+
+			result, rotated, err := k.Rotate(value)
+
+			if rotated {
+				count++
+			}
+
+			return result, rotated, err
+		})
+	})
+
+	return count, err
+}
+
+/*
+transformField reads a tagged field, passes its bytes to fn along with
+whether they are encrypted, and writes the result back when fn reports
+a change. encryptedResult says whether fn returns ciphertext
+*/
+func transformField(field reflect.Value, name string, encryptedResult bool, fn func(value []byte, encrypted bool) ([]byte, bool, error)) error {
+	if field.Kind() == reflect.Ptr {
+		if field.Type().Elem().Kind() != reflect.String {
+			return unsupportedField(field, name)
+		}
+
+		if field.IsNil() {
+			return nil
+		}
+
+		field = field.Elem()
+	}
+
+	switch {
+	case field.Kind() == reflect.String:
+		value := field.String()
+
+		if value == "" {
+			return nil
+		}
+
+		encrypted := strings.HasPrefix(value, EncryptedPrefix)
+		b := []byte(value)
+
+		if encrypted {
+			decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(value, EncryptedPrefix))
+
+			if err != nil {
+				return fmt.Errorf("error decoding field %s: %w", name, ErrInvalidCiphertext)
+			}
+
+			b = decoded
+		}
+
+		result, changed, err := fn(b, encrypted)
+
+		if err != nil {
+			return fmt.Errorf("error processing field %s: %w", name, err)
+		}
+
+		if !changed {
+			return nil
+		}
+
+		if encryptedResult {
+			field.SetString(EncryptedPrefix + base64.RawURLEncoding.EncodeToString(result))
+		} else {
+			field.SetString(string(result))
+		}
+
+	case field.Type() == bytesType:
+		value := field.Bytes()
+
+		if len(value) == 0 {
+			return nil
+		}
+
+		result, changed, err := fn(value, isKeyringCiphertext(value))
+
+		if err != nil {
+			return fmt.Errorf("error processing field %s: %w", name, err)
+		}
+
+		if changed {
+			field.SetBytes(result)
+		}
+
+	default:
+		return unsupportedField(field, name)
+	}
+
+	return nil
+}
+
+/*
+walkEncryptedFields calls fn for every settable field tagged
+`encrypt:"true"` in the struct v points to, descending into nested
+structs, pointers, and slices
+*/
+func walkEncryptedFields(v interface{}, fn func(field reflect.Value, name string) error) error {
+	value := reflect.ValueOf(v)
+
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("expected a pointer to a struct, got %T", v)
+	}
+
+	return walkValue(value.Elem(), value.Elem().Type().Name(), fn)
+}
+
+func walkValue(value reflect.Value, path string, fn func(field reflect.Value, name string) error) error {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+
+		return walkValue(value.Elem(), path, fn)
+
+	case reflect.Slice, reflect.Array:
+		if value.Type() == bytesType {
+			return nil
+		}
+
+		for index := 0; index < value.Len(); index++ {
+			if err := walkValue(value.Index(index), fmt.Sprintf("%s[%d]", path, index), fn); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Struct:
+		t := value.Type()
+
+		for index := 0; index < t.NumField(); index++ {
+			structField := t.Field(index)
+			field := value.Field(index)
+			name := path + "." + structField.Name
+
+			if structField.PkgPath != "" {
+				continue
+			}
+
+			if structField.Tag.Get("encrypt") == "true" {
+				if !field.CanSet() {
+					continue
+				}
+
+				if err := fn(field, name); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if err := walkValue(field, name, fn); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+/*
+isKeyringCiphertext reports whether b looks like the output of
+Keyring.Encrypt
+*/
+func isKeyringCiphertext(b []byte) bool {
+	_, err := KeyID(b)
+	return err == nil
+}
+
+func unsupportedField(field reflect.Value, name string) error {
+	return fmt.Errorf("cannot encrypt field %s of type %s: only string, *string, and []byte are supported", name, field.Type())
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package crypto_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/crypto"
+)
+
+type address struct {
+	Street string `encrypt:"true"`
+	City   string
+}
+
+type customer struct {
+	Addresses []address
+	Email     string `encrypt:"true"`
+	Name      string
+	Notes     []byte  `encrypt:"true"`
+	SSN       *string `encrypt:"true"`
+}
+
+func newKeyring(t *testing.T, ids ...string) *crypto.Keyring {
+	keys := make(map[string][]byte)
+
+	for _, id := range ids {
+		keys[id], _ = crypto.GenerateKey()
+	}
+
+	keyring, err := crypto.NewKeyring(crypto.KeyringConfig{CurrentKeyID: ids[0], Keys: keys})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	return keyring
+}
+
+func TestEncryptFields(t *testing.T) {
+	ssn := "123-45-6789"
+	keyring := newKeyring(t, "2021-01", "2021-06")
+
+	c := &customer{
+		Addresses: []address{{Street: "1 Main St", City: "Springfield"}},
+		Email:     "bob@example.com",
+		Name:      "Bob",
+		Notes:     []byte("likes cake"),
+		SSN:       &ssn,
+	}
+
+	if err := keyring.EncryptFields(c); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	encryptedEmail := c.Email
+
+	if err := keyring.EncryptFields(c); err != nil {
+		t.Fatalf("Expected no error encrypting twice but got '%s'", err)
+	}
+
+	tests := []struct {
+		name      string
+		encrypted bool
+		value     string
+	}{
+		{name: "Email", encrypted: true, value: c.Email},
+		{name: "SSN", encrypted: true, value: *c.SSN},
+		{name: "Street", encrypted: true, value: c.Addresses[0].Street},
+		{name: "Name", encrypted: false, value: c.Name},
+		{name: "City", encrypted: false, value: c.Addresses[0].City},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if strings.HasPrefix(test.value, crypto.EncryptedPrefix) != test.encrypted {
+				t.Errorf("Expected encrypted to be %v but got '%s'", test.encrypted, test.value)
+			}
+		})
+	}
+
+	if c.Email != encryptedEmail {
+		t.Errorf("Expected encrypting twice to leave the field alone")
+	}
+
+	if string(c.Notes) == "likes cake" {
+		t.Errorf("Expected Notes to be encrypted")
+	}
+
+	_ = keyring.SetCurrentKey("2021-06")
+
+	if count, err := keyring.RotateFields(c); err != nil || count != 4 {
+		t.Fatalf("Expected 4 fields rotated but got %d (%v)", count, err)
+	}
+
+	if count, _ := keyring.RotateFields(c); count != 0 {
+		t.Errorf("Expected no fields rotated the second time but got %d", count)
+	}
+
+	if err := keyring.DecryptFields(c); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	if c.Email != "bob@example.com" || *c.SSN != ssn || string(c.Notes) != "likes cake" || c.Addresses[0].Street != "1 Main St" {
+		t.Errorf("Expected fields to be decrypted but got %+v", c)
+	}
+}
+
+func TestDecryptFieldsUnknownKey(t *testing.T) {
+	c := &customer{Email: "bob@example.com"}
+	_ = newKeyring(t, "old").EncryptFields(c)
+
+	if err := newKeyring(t, "new").DecryptFields(c); !errors.Is(err, crypto.ErrUnknownKey) {
+		t.Errorf("Expected error '%v' but got '%v'", crypto.ErrUnknownKey, err)
+	}
+}
+
+func TestEncryptFieldsUnsupportedType(t *testing.T) {
+	v := &struct {
+		Age int `encrypt:"true"`
+	}{Age: 42}
+
+	if err := newKeyring(t, "key").EncryptFields(v); err == nil {
+		t.Errorf("Expected an error for an int field")
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+/*
+KDFOptions configures PBKDF2 key derivation. KeyLength is in bytes, and
+must be 16, 24, or 32 for the key to be used with AES
+*/
+type KDFOptions struct {
+	Hash       func() hash.Hash
+	Iterations int
+	KeyLength  int
+}
+
+/*
+DefaultKDFOptions derives 32 byte (AES-256) keys with PBKDF2-SHA256 and
+210,000 iterations, and are used for passphrase based encryption
+*/
+var DefaultKDFOptions = KDFOptions{
+	Hash:       sha256.New,
+	Iterations: 210000,
+	KeyLength:  32,
+}
+
+/*
+LegacyKDFOptions match the key derivation JWTService has always used:
+PBKDF2-SHA1 with 4096 iterations. Use these only to read data that was
+encrypted with keys derived that way
+*/
+var LegacyKDFOptions = KDFOptions{
+	Hash:       sha1.New,
+	Iterations: 4096,
+	KeyLength:  32,
+}
+
+/*
+DeriveKey derives a key from a passphrase and salt. Zero options fall
+back to DefaultKDFOptions
+*/
+func DeriveKey(passphrase, salt []byte, options KDFOptions) []byte {
+	if options.Hash == nil {
+		options.Hash = DefaultKDFOptions.Hash
+	}
+
+	if options.Iterations <= 0 {
+		options.Iterations = DefaultKDFOptions.Iterations
+	}
+
+	if options.KeyLength <= 0 {
+		options.KeyLength = DefaultKDFOptions.KeyLength
+	}
+
+	return pbkdf2.Key(passphrase, salt, options.Iterations, options.KeyLength, options.Hash)
+}
+
+/*
+GenerateKey returns a random 32 byte (AES-256) key
+*/
+func GenerateKey() ([]byte, error) {
+	return randomBytes(32)
+}
+
+func randomBytes(length int) ([]byte, error) {
+	result := make([]byte, length)
+
+	if _, err := io.ReadFull(rand.Reader, result); err != nil {
+		return nil, fmt.Errorf("error reading random bytes: %w", err)
+	}
+
+	return result, nil
+}
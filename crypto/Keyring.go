@@ -0,0 +1,199 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package crypto
+
+import (
+	"fmt"
+	"sync"
+)
+
+/*
+Ciphertext produced by a Keyring uses the keyring mode, and its header
+is followed by the length of the key ID and the key ID itself
+*/
+const (
+	modeKeyring byte = 4
+
+	maxKeyIDLength = 255
+)
+
+/*
+Keyring encrypts with a current key while still being able to decrypt
+with older ones. The ID of the key used is stored in the ciphertext, so
+keys can be rotated without re-encrypting everything at once
+*/
+type Keyring struct {
+	sync.RWMutex
+
+	currentKeyID string
+	keys         map[string][]byte
+}
+
+/*
+NewKeyring creates a Keyring from a config. CurrentKeyID must be one of
+the configured keys
+*/
+func NewKeyring(config KeyringConfig) (*Keyring, error) {
+	result := &Keyring{
+		keys: make(map[string][]byte, len(config.Keys)),
+	}
+
+	for id, key := range config.Keys {
+		if err := result.AddKey(id, key); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := result.SetCurrentKey(config.CurrentKeyID); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+/*
+AddKey adds a key to the keyring, replacing any key with the same ID
+*/
+func (k *Keyring) AddKey(id string, key []byte) error {
+	if id == "" || len(id) > maxKeyIDLength {
+		return fmt.Errorf("invalid key ID '%s': key IDs must be between 1 and %d bytes long", id, maxKeyIDLength)
+	}
+
+	if _, err := newGCM(key); err != nil {
+		return fmt.Errorf("key '%s': %w", id, err)
+	}
+
+	k.Lock()
+	defer k.Unlock()
+
+	k.keys[id] = append([]byte{}, key...)
+	return nil
+}
+
+/*
+SetCurrentKey changes the key used for new encryption. This is how keys
+are rotated: add the new key, make it current, then use Rotate or
+RotateFields to re-encrypt existing data
+*/
+func (k *Keyring) SetCurrentKey(id string) error {
+	k.Lock()
+	defer k.Unlock()
+
+	if _, ok := k.keys[id]; !ok {
+		return fmt.Errorf("%w: '%s'", ErrUnknownKey, id)
+	}
+
+	k.currentKeyID = id
+	return nil
+}
+
+/*
+CurrentKeyID returns the ID of the key used for new encryption
+*/
+func (k *Keyring) CurrentKeyID() string {
+	k.RLock()
+	defer k.RUnlock()
+
+	return k.currentKeyID
+}
+
+/*
+Encrypt encrypts plaintext with the current key
+*/
+func (k *Keyring) Encrypt(plaintext []byte) ([]byte, error) {
+	k.RLock()
+	id, key := k.currentKeyID, k.keys[k.currentKeyID]
+	k.RUnlock()
+
+	gcm, err := newGCM(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, headerLength+1+len(id))
+	header = append(header, formatVersion, modeKeyring, byte(len(id)))
+	header = append(header, id...)
+
+	return seal(gcm, header, plaintext, header)
+}
+
+/*
+Decrypt decrypts ciphertext produced by Encrypt, using whichever key it
+was encrypted with. ErrUnknownKey is returned if that key is no longer
+in the keyring
+*/
+func (k *Keyring) Decrypt(ciphertext []byte) ([]byte, error) {
+	id, err := KeyID(ciphertext)
+
+	if err != nil {
+		return nil, err
+	}
+
+	k.RLock()
+	key, ok := k.keys[id]
+	k.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownKey, id)
+	}
+
+	gcm, err := newGCM(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	headerEnd := headerLength + 1 + len(id)
+	return open(gcm, ciphertext[headerEnd:], ciphertext[:headerEnd])
+}
+
+/*
+Rotate re-encrypts ciphertext with the current key. The second return
+value is false, and the ciphertext is returned unchanged, when it was
+already encrypted with the current key
+*/
+func (k *Keyring) Rotate(ciphertext []byte) ([]byte, bool, error) {
+	id, err := KeyID(ciphertext)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if id == k.CurrentKeyID() {
+		return ciphertext, false, nil
+	}
+
+	plaintext, err := k.Decrypt(ciphertext)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	result, err := k.Encrypt(plaintext)
+	return result, err == nil, err
+}
+
+/*
+KeyID returns the ID of the key that ciphertext produced by a Keyring
+was encrypted with
+*/
+func KeyID(ciphertext []byte) (string, error) {
+	if err := checkHeader(ciphertext, modeKeyring); err != nil {
+		return "", err
+	}
+
+	if len(ciphertext) < headerLength+1 {
+		return "", ErrInvalidCiphertext
+	}
+
+	idLength := int(ciphertext[headerLength])
+
+	if idLength == 0 || len(ciphertext) < headerLength+1+idLength {
+		return "", ErrInvalidCiphertext
+	}
+
+	return string(ciphertext[headerLength+1 : headerLength+1+idLength]), nil
+}
@@ -0,0 +1,15 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package crypto
+
+/*
+KeyringConfig configures a Keyring. Keys maps key IDs to keys, and
+CurrentKeyID names the key used for new encryption. Keep retired keys in
+Keys until everything encrypted with them has been rotated
+*/
+type KeyringConfig struct {
+	CurrentKeyID string
+	Keys         map[string][]byte
+}
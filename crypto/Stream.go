@@ -0,0 +1,266 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package crypto
+
+import (
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// streamChunkSize is how much plaintext is sealed in each chunk of a stream
+	streamChunkSize = 64 * 1024
+
+	// lastChunkFlag marks the final chunk's length, so truncated streams are detected
+	lastChunkFlag = uint32(1) << 31
+
+	streamSaltLength = 32
+)
+
+/*
+encryptWriter encrypts a stream in fixed size chunks. Each chunk is
+sealed with a nonce made from its position and whether it is the last
+chunk, under a key derived for this stream alone, so chunks can't be
+reordered, dropped, or cut off without decryption failing
+*/
+type encryptWriter struct {
+	aead    cipher.AEAD
+	buffer  []byte
+	closed  bool
+	counter uint64
+	header  []byte
+	writer  io.Writer
+}
+
+type decryptReader struct {
+	aead    cipher.AEAD
+	counter uint64
+	done    bool
+	header  []byte
+	pending []byte
+	reader  io.Reader
+}
+
+/*
+NewEncryptWriter returns a writer that encrypts everything written to
+it with AES-GCM and writes the result to w. Large payloads are
+encrypted in chunks, so they never have to be held in memory. Close
+must be called to write the final chunk; it doesn't close w.
+
+	writer, err := crypto.NewEncryptWriter(file, key)
+	_, err = io.Copy(writer, upload)
+	err = writer.Close()
+*/
+func NewEncryptWriter(w io.Writer, key []byte) (io.WriteCloser, error) {
+	salt, err := randomBytes(streamSaltLength)
+
+	if err != nil {
+		return nil, err
+	}
+
+	header := append([]byte{formatVersion, modeStream}, salt...)
+	aead, err := newStreamAEAD(key, salt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = w.Write(header); err != nil {
+		return nil, fmt.Errorf("error writing encrypted stream header: %w", err)
+	}
+
+	return &encryptWriter{
+		aead:   aead,
+		buffer: make([]byte, 0, streamChunkSize),
+		header: header,
+		writer: w,
+	}, nil
+}
+
+/*
+NewDecryptReader returns a reader that decrypts a stream written by
+NewEncryptWriter. Reads return ErrTruncatedStream if the stream ends
+before its final chunk, and ErrInvalidCiphertext if it was tampered with
+*/
+func NewDecryptReader(r io.Reader, key []byte) (io.Reader, error) {
+	header := make([]byte, headerLength+streamSaltLength)
+
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+
+	if err := checkHeader(header, modeStream); err != nil {
+		return nil, err
+	}
+
+	aead, err := newStreamAEAD(key, header[headerLength:])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &decryptReader{
+		aead:   aead,
+		header: header,
+		reader: r,
+	}, nil
+}
+
+func (w *encryptWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, fmt.Errorf("error writing to encrypted stream: writer is closed")
+	}
+
+	written := 0
+
+	for len(p) > 0 {
+		space := streamChunkSize - len(w.buffer)
+
+		if space == 0 {
+			if err := w.writeChunk(false); err != nil {
+				return written, err
+			}
+
+			continue
+		}
+
+		if space > len(p) {
+			space = len(p)
+		}
+
+		w.buffer = append(w.buffer, p[:space]...)
+		p = p[space:]
+		written += space
+	}
+
+	return written, nil
+}
+
+/*
+Close writes the final chunk. A full buffer is held back until Close so
+the last chunk is never empty unless the stream is
+*/
+func (w *encryptWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+
+	w.closed = true
+	return w.writeChunk(true)
+}
+
+func (w *encryptWriter) writeChunk(last bool) error {
+	sealed := w.aead.Seal(nil, streamNonce(w.counter, last, w.aead.NonceSize()), w.buffer, w.header)
+	length := uint32(len(sealed))
+
+	if last {
+		length |= lastChunkFlag
+	}
+
+	prefix := make([]byte, 4)
+	binary.BigEndian.PutUint32(prefix, length)
+
+	if _, err := w.writer.Write(append(prefix, sealed...)); err != nil {
+		return fmt.Errorf("error writing encrypted stream: %w", err)
+	}
+
+	w.counter++
+	w.buffer = w.buffer[:0]
+
+	return nil
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+
+		if err := r.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+
+	return n, nil
+}
+
+func (r *decryptReader) readChunk() error {
+	prefix := make([]byte, 4)
+
+	if _, err := io.ReadFull(r.reader, prefix); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrTruncatedStream
+		}
+
+		return fmt.Errorf("error reading encrypted stream: %w", err)
+	}
+
+	length := binary.BigEndian.Uint32(prefix)
+	last := length&lastChunkFlag != 0
+	length &^= lastChunkFlag
+
+	if length > streamChunkSize+uint32(r.aead.Overhead()) {
+		return ErrInvalidCiphertext
+	}
+
+	sealed := make([]byte, length)
+
+	if _, err := io.ReadFull(r.reader, sealed); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrTruncatedStream
+		}
+
+		return fmt.Errorf("error reading encrypted stream: %w", err)
+	}
+
+	plaintext, err := r.aead.Open(nil, streamNonce(r.counter, last, r.aead.NonceSize()), sealed, r.header)
+
+	if err != nil {
+		return ErrInvalidCiphertext
+	}
+
+	r.counter++
+	r.done = last
+	r.pending = plaintext
+
+	return nil
+}
+
+/*
+newStreamAEAD derives a key for a single stream from the caller's key
+and the stream's random salt, so nonces never repeat across streams
+*/
+func newStreamAEAD(key, salt []byte) (cipher.AEAD, error) {
+	if _, err := newGCM(key); err != nil {
+		return nil, err
+	}
+
+	streamKey := make([]byte, 32)
+
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, salt, []byte("kit crypto stream")), streamKey); err != nil {
+		return nil, fmt.Errorf("error deriving stream key: %w", err)
+	}
+
+	return newGCM(streamKey)
+}
+
+func streamNonce(counter uint64, last bool, size int) []byte {
+	nonce := make([]byte, size)
+	binary.BigEndian.PutUint64(nonce, counter)
+
+	if last {
+		nonce[size-1] = 1
+	}
+
+	return nonce
+}
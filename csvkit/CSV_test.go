@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package csvkit_test
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/csvkit"
+	"github.com/labstack/echo/v4"
+)
+
+type audit struct {
+	CreatedBy string `csv:"Created By"`
+}
+
+type contact struct {
+	audit
+	Email    string     `csv:"Email,required"`
+	Name     string     `json:"name"`
+	Age      int        `csv:"Age"`
+	Balance  float64    `csv:"Balance,format=%.2f"`
+	Active   bool       `csv:"Active"`
+	Joined   time.Time  `csv:"Joined,format=2006-01-02"`
+	LastSeen *time.Time `csv:"Last Seen"`
+	Notes    sql.NullString
+	internal string
+	Ignored  string `csv:"-"`
+}
+
+func TestWriter(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	writer, err := csvkit.NewWriter(buffer, contact{})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	contacts := []*contact{
+		{audit: audit{CreatedBy: "admin"}, Email: "a@b.c", Name: "Adam, Jr.", Age: 40, Balance: 12.5, Active: true, Joined: time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)},
+	}
+
+	if err = writer.WriteAll(contacts); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := "Created By,Email,name,Age,Balance,Active,Joined,Last Seen,Notes\n" +
+		"admin,a@b.c,\"Adam, Jr.\",40,12.50,true,2021-03-04,,\n"
+
+	if buffer.String() != expected {
+		t.Errorf("Expected\n%s\nbut got\n%s", expected, buffer.String())
+	}
+
+	if err = writer.Write(audit{}); !errors.Is(err, csvkit.ErrWrongType) {
+		t.Errorf("Expected ErrWrongType but got %v", err)
+	}
+}
+
+func TestReader(t *testing.T) {
+	input := "\ufeffE-mail, NAME ,Age,Active,Joined,Last Seen,Notes,Extra\n" +
+		"a@b.c,Adam,40,yes,2021-03-04,2021-05-06T07:08:09Z,vip,x\n" +
+		"bad@b.c,Bob,forty,maybe,2021-03-04,,,\n" +
+		",Carol,30,no,,,,\n" +
+		"d@b.c,Dana,,n,,,,\n"
+
+	reader, _ := csvkit.NewReader(strings.NewReader(input), contact{}, csvkit.ReaderConfig{Aliases: map[string]string{"e-mail": "Email"}})
+	contacts := []contact{}
+	err := reader.ReadAll(&contacts)
+
+	var rowErrors csvkit.Errors
+
+	if !errors.As(err, &rowErrors) || len(rowErrors) != 3 {
+		t.Fatalf("Expected 3 row errors but got %v", err)
+	}
+
+	if rowErrors[0].Row != 3 || rowErrors[0].Column != "Age" || rowErrors[0].Value != "forty" || rowErrors[2].Row != 4 || !errors.Is(rowErrors[2], csvkit.ErrRequired) {
+		t.Errorf("Unexpected errors %+v", rowErrors)
+	}
+
+	if len(contacts) != 2 {
+		t.Fatalf("Expected 2 good rows but got %d", len(contacts))
+	}
+
+	first := contacts[0]
+
+	if first.Email != "a@b.c" || first.Name != "Adam" || first.Age != 40 || !first.Active || first.Joined.Day() != 4 || first.LastSeen == nil || first.LastSeen.Hour() != 7 || first.Notes.String != "vip" {
+		t.Errorf("Unexpected contact %+v", first)
+	}
+
+	if contacts[1].Email != "d@b.c" || contacts[1].Age != 0 || contacts[1].LastSeen != nil || contacts[1].Notes.Valid {
+		t.Errorf("Unexpected contact %+v", contacts[1])
+	}
+}
+
+func TestReaderMissingColumn(t *testing.T) {
+	reader, _ := csvkit.NewReader(strings.NewReader("name,age\nAdam,40\n"), contact{}, csvkit.ReaderConfig{})
+
+	if err := reader.Read(&contact{}); !errors.Is(err, csvkit.ErrMissingColumn) {
+		t.Errorf("Expected ErrMissingColumn but got %v", err)
+	}
+}
+
+func TestEchoExport(t *testing.T) {
+	e := echo.New()
+	e.GET("/contacts.csv", func(ctx echo.Context) error {
+		return csvkit.EchoExport(ctx, "contacts 2021.csv", contact{}, func(writer *csvkit.Writer) error {
+			for index := 0; index < 250; index++ {
+				if err := writer.Write(contact{Email: "a@b.c"}); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	})
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/contacts.csv", nil))
+
+	if recorder.Header().Get("Content-Type") != "text/csv; charset=utf-8" || recorder.Header().Get("Content-Disposition") != `attachment; filename="contacts 2021.csv"` {
+		t.Errorf("Unexpected headers %v", recorder.Header())
+	}
+
+	if lines := strings.Count(recorder.Body.String(), "\n"); lines != 251 {
+		t.Errorf("Expected 251 lines but got %d", lines)
+	}
+}
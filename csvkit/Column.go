@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package csvkit
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrMissingColumn = errors.New("missing column")
+	ErrNotStruct     = errors.New("model must be a struct")
+	ErrRequired      = errors.New("value is required")
+	ErrWrongType     = errors.New("record is not the model's type")
+)
+
+var columnCache sync.Map
+
+/*
+Column maps a struct field to a column. Header is the column's name in
+the header row, Format how values are written and read, and Required
+whether an empty value is an error. Width is the column's width in
+characters, for formats like spreadsheets that have one
+*/
+type Column struct {
+	Format   string
+	Header   string
+	Index    []int
+	Required bool
+	Type     reflect.Type
+	Width    int
+}
+
+/*
+Columns returns the columns for a struct type, in field order. Columns
+come from csv tags, such as
+`csv:"Created At,required,format=2006-01-02,width=12"`.
+Fields without one are named by their json tag, or their field name.
+Fields tagged "-" and unexported fields are skipped, and embedded
+structs are flattened. Values are also read from pointers to structs
+*/
+func Columns(model interface{}) ([]Column, error) {
+	t := reflect.TypeOf(model)
+
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, ErrNotStruct
+	}
+
+	if cached, ok := columnCache.Load(t); ok {
+		return cached.([]Column), nil
+	}
+
+	result := structColumns(t, nil)
+	columnCache.Store(t, result)
+
+	return result, nil
+}
+
+func structColumns(t reflect.Type, parent []int) []Column {
+	result := []Column{}
+
+	for index := 0; index < t.NumField(); index++ {
+		field := t.Field(index)
+		tag, hasTag := field.Tag.Lookup("csv")
+		fieldIndex := append(append([]int{}, parent...), index)
+
+		if field.Anonymous && !hasTag && field.Type.Kind() == reflect.Struct {
+			result = append(result, structColumns(field.Type, fieldIndex)...)
+			continue
+		}
+
+		if field.PkgPath != "" || tag == "-" {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		column := Column{Header: parts[0], Index: fieldIndex, Type: field.Type}
+
+		for _, option := range parts[1:] {
+			switch {
+			case option == "required":
+				column.Required = true
+
+			case strings.HasPrefix(option, "format="):
+				column.Format = strings.TrimPrefix(option, "format=")
+
+			case strings.HasPrefix(option, "width="):
+				column.Width, _ = strconv.Atoi(strings.TrimPrefix(option, "width="))
+			}
+		}
+
+		if column.Header == "" {
+			column.Header = strings.Split(field.Tag.Get("json"), ",")[0]
+		}
+
+		if column.Header == "" || column.Header == "-" {
+			column.Header = field.Name
+		}
+
+		result = append(result, column)
+	}
+
+	return result
+}
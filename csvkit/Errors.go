@@ -0,0 +1,45 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package csvkit
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+RowError is a value that couldn't be read. Row is the record's number
+in the file, counting the header as row 1
+*/
+type RowError struct {
+	Column string `json:"column"`
+	Err    error  `json:"-"`
+	Row    int    `json:"row"`
+	Value  string `json:"value"`
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("row %d, column %s: %s", e.Row, e.Column, e.Err.Error())
+}
+
+func (e RowError) Unwrap() error {
+	return e.Err
+}
+
+/*
+Errors collects every RowError from a read, so an import can report
+all of its problems at once
+*/
+type Errors []RowError
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+
+	for _, rowError := range e {
+		messages = append(messages, rowError.Error())
+	}
+
+	return strings.Join(messages, "; ")
+}
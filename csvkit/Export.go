@@ -0,0 +1,54 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package csvkit
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+/*
+Export streams a CSV download. It sets the content type and an
+attachment Content-Disposition for filename, writes the header row, and
+calls fn to write the rows, so exports of any size never sit in memory.
+Once rows are sent the status can't change, so errors from fn are only
+returned, not written
+*/
+func Export(w http.ResponseWriter, filename string, model interface{}, fn func(writer *Writer) error) error {
+	writer, err := NewWriter(w, model)
+
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	if err = writer.WriteHeader(); err == nil {
+		err = fn(writer)
+	}
+
+	if flushErr := writer.Flush(); err == nil {
+		err = flushErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("error exporting %s: %w", filename, err)
+	}
+
+	return nil
+}
+
+/*
+EchoExport streams a CSV download from an Echo handler
+*/
+func EchoExport(ctx echo.Context, filename string, model interface{}, fn func(writer *Writer) error) error {
+	return Export(ctx.Response(), filename, model, fn)
+}
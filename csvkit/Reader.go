@@ -0,0 +1,234 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package csvkit
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+/*
+ReaderConfig configures a Reader. Aliases maps other names a column
+may have in the header to the model's header, such as "E-mail" to
+"Email". Headers are matched ignoring case and surrounding spaces.
+Comma defaults to a comma. With TrimSpace, values have surrounding
+spaces removed. ReadAll stops after MaxErrors row errors, 100 by
+default
+*/
+type ReaderConfig struct {
+	Aliases   map[string]string
+	Comma     rune
+	MaxErrors int
+	TrimSpace bool
+}
+
+/*
+Reader streams CSV rows into structs, matching columns by the header
+row. Columns the model doesn't have are ignored
+*/
+type Reader struct {
+	columns   []Column
+	config    ReaderConfig
+	csv       *csv.Reader
+	modelType reflect.Type
+	positions []int
+	row       int
+}
+
+/*
+NewReader creates a Reader for records of model's type
+*/
+func NewReader(r io.Reader, model interface{}, config ReaderConfig) (*Reader, error) {
+	columns, err := Columns(model)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if config.MaxErrors <= 0 {
+		config.MaxErrors = 100
+	}
+
+	result := &Reader{
+		columns:   columns,
+		config:    config,
+		csv:       csv.NewReader(r),
+		modelType: reflect.Indirect(reflect.ValueOf(model)).Type(),
+	}
+
+	if config.Comma != 0 {
+		result.csv.Comma = config.Comma
+	}
+
+	result.csv.FieldsPerRecord = -1
+	result.csv.ReuseRecord = true
+
+	return result, nil
+}
+
+/*
+Read reads the next row into dest, a pointer to the model's type. It
+returns io.EOF after the last row. A row with values that can't be
+parsed returns Errors, with every other value still set, and reading
+can go on
+*/
+func (r *Reader) Read(dest interface{}) error {
+	value := reflect.ValueOf(dest)
+
+	if value.Kind() != reflect.Ptr || value.Elem().Type() != r.modelType {
+		return fmt.Errorf("%w: %T", ErrWrongType, dest)
+	}
+
+	if r.positions == nil {
+		if err := r.readHeader(); err != nil {
+			return err
+		}
+	}
+
+	record, err := r.csv.Read()
+
+	if err != nil {
+		if err == io.EOF {
+			return err
+		}
+
+		return fmt.Errorf("error reading CSV: %w", err)
+	}
+
+	r.row++
+	rowErrors := Errors{}
+	element := value.Elem()
+
+	for index, column := range r.columns {
+		text := ""
+
+		if position := r.positions[index]; position >= 0 && position < len(record) {
+			text = record[position]
+		}
+
+		if r.config.TrimSpace {
+			text = strings.TrimSpace(text)
+		}
+
+		if err := ParseValue(text, element.FieldByIndex(column.Index), column); err != nil {
+			rowErrors = append(rowErrors, RowError{Column: column.Header, Err: err, Row: r.row, Value: text})
+		}
+	}
+
+	if len(rowErrors) > 0 {
+		return rowErrors
+	}
+
+	return nil
+}
+
+/*
+ReadAll reads every row into dest, a pointer to a slice of the model's
+type or pointers to it. Rows with errors are left out and their errors
+returned together as Errors, so good rows can still be imported
+*/
+func (r *Reader) ReadAll(dest interface{}) error {
+	slice := reflect.ValueOf(dest)
+
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("%w: %T", ErrWrongType, dest)
+	}
+
+	slice = slice.Elem()
+	pointers := slice.Type().Elem().Kind() == reflect.Ptr
+	allErrors := Errors{}
+
+	for {
+		element := reflect.New(r.modelType)
+		err := r.Read(element.Interface())
+
+		if err == io.EOF {
+			break
+		}
+
+		if rowErrors, ok := err.(Errors); ok {
+			if allErrors = append(allErrors, rowErrors...); len(allErrors) >= r.config.MaxErrors {
+				return allErrors
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if pointers {
+			slice.Set(reflect.Append(slice, element))
+		} else {
+			slice.Set(reflect.Append(slice, element.Elem()))
+		}
+	}
+
+	if len(allErrors) > 0 {
+		return allErrors
+	}
+
+	return nil
+}
+
+func (r *Reader) readHeader() error {
+	header, err := r.csv.Read()
+
+	if err != nil {
+		if err == io.EOF {
+			return err
+		}
+
+		return fmt.Errorf("error reading CSV header: %w", err)
+	}
+
+	r.row++
+
+	// Excel starts UTF-8 files with a byte order mark
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	positions := map[string]int{}
+
+	for position, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		for alias, target := range r.config.Aliases {
+			if strings.ToLower(alias) == name {
+				name = strings.ToLower(target)
+			}
+		}
+
+		positions[name] = position
+	}
+
+	r.positions = make([]int, len(r.columns))
+	missing := []string{}
+
+	for index, column := range r.columns {
+		position, ok := positions[strings.ToLower(column.Header)]
+
+		if !ok {
+			position = -1
+
+			if column.Required {
+				missing = append(missing, column.Header)
+			}
+		}
+
+		r.positions[index] = position
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingColumn, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,220 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package csvkit
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	scannerType         = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+)
+
+/*
+FormatValue turns a field's value into text. Times use the column's
+Format as a layout, defaulting to RFC 3339, and numbers use it as a fmt
+verb, such as "%.2f". Nil pointers are empty. Types that implement
+driver.Valuer, such as sql.NullString, are written as their value, and
+encoding.TextMarshaler and fmt.Stringer types format themselves
+*/
+func FormatValue(value reflect.Value, column Column) string {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return ""
+		}
+
+		value = value.Elem()
+	}
+
+	if value.Type() == timeType {
+		t := value.Interface().(time.Time)
+
+		if t.IsZero() {
+			return ""
+		}
+
+		if column.Format != "" {
+			return t.Format(column.Format)
+		}
+
+		return t.Format(time.RFC3339)
+	}
+
+	if value.CanInterface() {
+		switch v := value.Interface().(type) {
+		case driver.Valuer:
+			if inner, err := v.Value(); err == nil {
+				if inner == nil {
+					return ""
+				}
+
+				return FormatValue(reflect.ValueOf(inner), column)
+			}
+
+		case encoding.TextMarshaler:
+			b, _ := v.MarshalText()
+			return string(b)
+
+		case fmt.Stringer:
+			return v.String()
+		}
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return value.String()
+
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool())
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if column.Format != "" {
+			return fmt.Sprintf(column.Format, value.Int())
+		}
+
+		return strconv.FormatInt(value.Int(), 10)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if column.Format != "" {
+			return fmt.Sprintf(column.Format, value.Uint())
+		}
+
+		return strconv.FormatUint(value.Uint(), 10)
+
+	case reflect.Float32, reflect.Float64:
+		if column.Format != "" {
+			return fmt.Sprintf(column.Format, value.Float())
+		}
+
+		return strconv.FormatFloat(value.Float(), 'f', -1, value.Type().Bits())
+	}
+
+	return fmt.Sprint(value.Interface())
+}
+
+/*
+ParseValue parses text into dest, a settable field. Empty text leaves
+pointers nil and other values zero. Booleans also accept yes, no, y,
+and n. Times are parsed with the column's Format, or RFC 3339 and then
+2006-01-02. Types that implement encoding.TextUnmarshaler or
+sql.Scanner, such as sql.NullString, parse themselves
+*/
+func ParseValue(text string, dest reflect.Value, column Column) error {
+	if text == "" {
+		if column.Required {
+			return ErrRequired
+		}
+
+		dest.Set(reflect.Zero(dest.Type()))
+		return nil
+	}
+
+	if dest.Kind() == reflect.Ptr {
+		value := reflect.New(dest.Type().Elem())
+
+		if err := ParseValue(text, value.Elem(), column); err != nil {
+			return err
+		}
+
+		dest.Set(value)
+		return nil
+	}
+
+	if dest.Type() == timeType {
+		return parseTime(text, dest, column)
+	}
+
+	if dest.Addr().Type().Implements(textUnmarshalerType) {
+		return dest.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text))
+	}
+
+	if dest.Addr().Type().Implements(scannerType) {
+		return dest.Addr().Interface().(sql.Scanner).Scan(text)
+	}
+
+	switch dest.Kind() {
+	case reflect.String:
+		dest.SetString(text)
+
+	case reflect.Bool:
+		b, err := parseBool(text)
+
+		if err != nil {
+			return err
+		}
+
+		dest.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(strings.TrimSpace(text), 10, dest.Type().Bits())
+
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", text)
+		}
+
+		dest.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(strings.TrimSpace(text), 10, dest.Type().Bits())
+
+		if err != nil {
+			return fmt.Errorf("%q is not a positive whole number", text)
+		}
+
+		dest.SetUint(u)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(text), dest.Type().Bits())
+
+		if err != nil {
+			return fmt.Errorf("%q is not a number", text)
+		}
+
+		dest.SetFloat(f)
+
+	default:
+		return fmt.Errorf("can't parse into %s", dest.Type())
+	}
+
+	return nil
+}
+
+func parseTime(text string, dest reflect.Value, column Column) error {
+	layouts := []string{time.RFC3339, "2006-01-02"}
+
+	if column.Format != "" {
+		layouts = []string{column.Format}
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(text)); err == nil {
+			dest.Set(reflect.ValueOf(t))
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%q is not a date in the format %s", text, layouts[0])
+}
+
+func parseBool(text string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(text)) {
+	case "1", "t", "true", "y", "yes":
+		return true, nil
+
+	case "0", "f", "false", "n", "no":
+		return false, nil
+	}
+
+	return false, fmt.Errorf("%q is not true or false", text)
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package csvkit
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+const flushEvery = 100
+
+/*
+Writer streams structs out as CSV rows, writing the header before the
+first row. When the underlying writer is an http.Flusher, such as an
+http.ResponseWriter, rows are flushed to the client every hundred rows
+*/
+type Writer struct {
+	columns       []Column
+	csv           *csv.Writer
+	flusher       http.Flusher
+	headerWritten bool
+	modelType     reflect.Type
+	rows          int
+}
+
+/*
+NewWriter creates a Writer for records of model's type
+*/
+func NewWriter(w io.Writer, model interface{}) (*Writer, error) {
+	columns, err := Columns(model)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Writer{
+		columns:   columns,
+		csv:       csv.NewWriter(w),
+		modelType: reflect.Indirect(reflect.ValueOf(model)).Type(),
+	}
+
+	result.flusher, _ = w.(http.Flusher)
+	return result, nil
+}
+
+/*
+WriteHeader writes the header row, if it hasn't been written
+*/
+func (w *Writer) WriteHeader() error {
+	if w.headerWritten {
+		return nil
+	}
+
+	header := make([]string, len(w.columns))
+
+	for index, column := range w.columns {
+		header[index] = column.Header
+	}
+
+	w.headerWritten = true
+	return w.csv.Write(header)
+}
+
+/*
+Write writes one record, a struct of the model's type or a pointer to
+one
+*/
+func (w *Writer) Write(record interface{}) error {
+	value := reflect.ValueOf(record)
+
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	if value.Type() != w.modelType {
+		return fmt.Errorf("%w: %s", ErrWrongType, value.Type())
+	}
+
+	if err := w.WriteHeader(); err != nil {
+		return err
+	}
+
+	row := make([]string, len(w.columns))
+
+	for index, column := range w.columns {
+		row[index] = FormatValue(value.FieldByIndex(column.Index), column)
+	}
+
+	if err := w.csv.Write(row); err != nil {
+		return err
+	}
+
+	if w.rows++; w.rows%flushEvery == 0 {
+		return w.Flush()
+	}
+
+	return nil
+}
+
+/*
+WriteAll writes every record in a slice, then flushes
+*/
+func (w *Writer) WriteAll(records interface{}) error {
+	value := reflect.ValueOf(records)
+
+	if value.Kind() != reflect.Slice {
+		return fmt.Errorf("%w: %s", ErrWrongType, value.Type())
+	}
+
+	if err := w.WriteHeader(); err != nil {
+		return err
+	}
+
+	for index := 0; index < value.Len(); index++ {
+		if err := w.Write(value.Index(index).Interface()); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+/*
+Flush writes any buffered rows
+*/
+func (w *Writer) Flush() error {
+	w.csv.Flush()
+
+	if w.flusher != nil {
+		w.flusher.Flush()
+	}
+
+	return w.csv.Error()
+}
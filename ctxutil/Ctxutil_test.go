@@ -0,0 +1,122 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ctxutil_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/ctxutil"
+	"github.com/ResurgenceIT/kit/v6/httpserver"
+	"github.com/sirupsen/logrus"
+)
+
+func TestDetach(t *testing.T) {
+	parent, cancel := context.WithTimeout(ctxutil.WithTenant(context.Background(), "acme"), time.Minute)
+	cancel()
+
+	detached := ctxutil.Detach(parent)
+
+	if detached.Err() != nil || detached.Done() != nil {
+		t.Errorf("Expected the detached context not to be canceled")
+	}
+
+	if _, ok := detached.Deadline(); ok {
+		t.Errorf("Expected no deadline")
+	}
+
+	if tenant := ctxutil.Tenant(detached); tenant != "acme" {
+		t.Errorf("Expected the tenant to be kept but got '%s'", tenant)
+	}
+
+	timed, cancelTimed := ctxutil.DetachWithTimeout(parent, time.Millisecond*10)
+	defer cancelTimed()
+
+	select {
+	case <-timed.Done():
+		if timed.Err() != context.DeadlineExceeded {
+			t.Errorf("Expected DeadlineExceeded but got %v", timed.Err())
+		}
+
+	case <-time.After(time.Second * 2):
+		t.Errorf("Expected the timeout to end the context")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	request, cancelRequest := context.WithCancel(ctxutil.WithTenant(context.Background(), "acme"))
+	shutdown, cancelShutdown := context.WithTimeout(ctxutil.WithRequestID(context.Background(), "abc"), time.Hour)
+	defer cancelShutdown()
+
+	merged, cancel := ctxutil.Merge(request, shutdown)
+	defer cancel()
+
+	if deadline, ok := merged.Deadline(); !ok || time.Until(deadline) < time.Minute*59 {
+		t.Errorf("Expected the shutdown deadline but got %s", deadline)
+	}
+
+	if ctxutil.Tenant(merged) != "acme" || ctxutil.RequestID(merged) != "abc" {
+		t.Errorf("Expected values from both contexts")
+	}
+
+	if merged.Err() != nil {
+		t.Fatalf("Expected the merged context to be running")
+	}
+
+	cancelRequest()
+
+	select {
+	case <-merged.Done():
+		if merged.Err() != context.Canceled {
+			t.Errorf("Expected Canceled but got %v", merged.Err())
+		}
+
+	case <-time.After(time.Second * 2):
+		t.Fatalf("Expected canceling either context to end the merged context")
+	}
+
+	other, cancelOther := ctxutil.Merge(context.Background())
+	cancelOther()
+	<-other.Done()
+
+	if other.Err() != context.Canceled {
+		t.Errorf("Expected Canceled after cancel but got %v", other.Err())
+	}
+}
+
+func TestKeys(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.SetOutput(buffer)
+	logger.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+
+	ctx := ctxutil.WithLogger(context.Background(), logger.WithField("job", "receipts"))
+	ctx = ctxutil.WithTenant(ctx, "acme")
+	ctx = ctxutil.WithRequestID(ctx, "abc")
+	ctx = ctxutil.WithIdentity(ctx, httpserver.Identity{UserID: "1", UserName: "alice"})
+
+	if identity, ok := ctxutil.Identity(ctx); !ok || identity.UserName != "alice" {
+		t.Errorf("Expected alice but got %+v", identity)
+	}
+
+	if identity, ok := httpserver.IdentityFromContext(ctx); !ok || identity.UserID != "1" {
+		t.Errorf("Expected httpserver to see the identity but got %+v", identity)
+	}
+
+	ctxutil.Logger(ctx).Info("receipt sent")
+
+	for _, field := range []string{"job=receipts", "tenant=acme", "requestID=abc"} {
+		if !strings.Contains(buffer.String(), field) {
+			t.Errorf("Expected %s in '%s'", field, buffer.String())
+		}
+	}
+
+	if _, ok := ctxutil.Identity(context.Background()); ok || ctxutil.Tenant(context.Background()) != "" {
+		t.Errorf("Expected nothing in an empty context")
+	}
+}
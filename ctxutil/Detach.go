@@ -0,0 +1,50 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ctxutil
+
+import (
+	"context"
+	"time"
+)
+
+type detachedContext struct {
+	parent context.Context
+}
+
+func (c detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (c detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (c detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+/*
+Detach returns a context that carries ctx's values, such as the request
+ID, identity, and logger, but is never canceled and has no deadline.
+Use it for background work started by a request that must finish after
+the response has been sent
+
+	go sendReceipt(ctxutil.Detach(ctx.Request().Context()), order)
+*/
+func Detach(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx}
+}
+
+/*
+DetachWithTimeout detaches ctx as Detach does, then gives the result its
+own timeout, so background work can't run forever
+*/
+func DetachWithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(Detach(ctx), timeout)
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ctxutil
+
+import (
+	"context"
+
+	"github.com/ResurgenceIT/kit/v6/httpserver"
+	"github.com/ResurgenceIT/kit/v6/requestid"
+	"github.com/sirupsen/logrus"
+)
+
+// TenantLogField is the log field Logger writes the tenant to
+const TenantLogField = "tenant"
+
+type tenantKey struct{}
+
+type loggerKey struct{}
+
+/*
+WithIdentity returns a copy of ctx carrying the user a request was made
+by. It is the same value httpserver's identity middleware stores, so
+code reading either sees it
+*/
+func WithIdentity(ctx context.Context, identity httpserver.Identity) context.Context {
+	return httpserver.NewIdentityContext(ctx, identity)
+}
+
+/*
+Identity returns the user carried by ctx, and false when there is none
+*/
+func Identity(ctx context.Context) (httpserver.Identity, bool) {
+	return httpserver.IdentityFromContext(ctx)
+}
+
+/*
+WithRequestID returns a copy of ctx carrying a request ID, as the
+requestid middleware stores it
+*/
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return requestid.NewContext(ctx, id)
+}
+
+/*
+RequestID returns the request ID carried by ctx, or an empty string
+*/
+func RequestID(ctx context.Context) string {
+	return requestid.FromContext(ctx)
+}
+
+/*
+WithTenant returns a copy of ctx carrying the tenant, such as an
+organization or account ID, that work is being done for
+*/
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+/*
+Tenant returns the tenant carried by ctx, or an empty string
+*/
+func Tenant(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+/*
+WithLogger returns a copy of ctx carrying a logger, such as one with
+fields added for the current job
+*/
+func WithLogger(ctx context.Context, logger logrus.FieldLogger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+/*
+Logger returns the logger carried by ctx, or the logrus standard logger
+when there is none. The request ID and tenant in ctx, if any, are added
+as fields
+
+	ctxutil.Logger(ctx).Info("receipt sent")
+*/
+func Logger(ctx context.Context) logrus.FieldLogger {
+	logger, ok := ctx.Value(loggerKey{}).(logrus.FieldLogger)
+
+	if !ok {
+		logger = logrus.StandardLogger()
+	}
+
+	if tenant := Tenant(ctx); tenant != "" {
+		logger = logger.WithField(TenantLogField, tenant)
+	}
+
+	return requestid.Logger(ctx, logger)
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ctxutil
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+type mergedContext struct {
+	contexts []context.Context
+	done     chan struct{}
+	err      error
+	mutex    sync.Mutex
+}
+
+/*
+Merge returns a context that is done as soon as any of contexts is done,
+with that context's error. Its deadline is the earliest of theirs, and
+values are looked up in each context in order. Call cancel to release
+its resources once the work is finished.
+
+	ctx, cancel := ctxutil.Merge(requestCtx, shutdownCtx)
+	defer cancel()
+*/
+func Merge(contexts ...context.Context) (context.Context, context.CancelFunc) {
+	if len(contexts) == 0 {
+		contexts = []context.Context{context.Background()}
+	}
+
+	merged := &mergedContext{
+		contexts: contexts,
+		done:     make(chan struct{}),
+	}
+
+	canceled := make(chan struct{})
+	cases := make([]reflect.SelectCase, 0, len(contexts)+1)
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(canceled)})
+
+	for _, ctx := range contexts {
+		if done := ctx.Done(); done != nil {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(done)})
+		}
+	}
+
+	go func() {
+		chosen, _, _ := reflect.Select(cases)
+
+		if chosen == 0 {
+			merged.finish(context.Canceled)
+			return
+		}
+
+		for _, ctx := range contexts {
+			if err := ctx.Err(); err != nil {
+				merged.finish(err)
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+
+	return merged, func() {
+		once.Do(func() { close(canceled) })
+	}
+}
+
+func (c *mergedContext) finish(err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.err == nil {
+		c.err = err
+		close(c.done)
+	}
+}
+
+func (c *mergedContext) Deadline() (time.Time, bool) {
+	var (
+		result time.Time
+		ok     bool
+	)
+
+	for _, ctx := range c.contexts {
+		if deadline, has := ctx.Deadline(); has && (!ok || deadline.Before(result)) {
+			result, ok = deadline, true
+		}
+	}
+
+	return result, ok
+}
+
+func (c *mergedContext) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *mergedContext) Err() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.err
+}
+
+func (c *mergedContext) Value(key interface{}) interface{} {
+	for _, ctx := range c.contexts {
+		if value := ctx.Value(key); value != nil {
+			return value
+		}
+	}
+
+	return nil
+}
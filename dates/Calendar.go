@@ -0,0 +1,202 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package dates
+
+import (
+	"sync"
+	"time"
+)
+
+/*
+Holiday returns a holiday's date in a year
+*/
+type Holiday func(year int) Date
+
+/*
+Fixed is a holiday on the same date every year, such as July 4
+*/
+func Fixed(month time.Month, day int) Holiday {
+	return func(year int) Date {
+		return NewDate(year, month, day)
+	}
+}
+
+/*
+Observed moves a holiday that falls on a Saturday to the Friday before,
+and one on a Sunday to the Monday after, as US employers do
+*/
+func Observed(holiday Holiday) Holiday {
+	return func(year int) Date {
+		d := holiday(year)
+
+		switch d.Weekday() {
+		case time.Saturday:
+			return d.AddDays(-1)
+
+		case time.Sunday:
+			return d.AddDays(1)
+		}
+
+		return d
+	}
+}
+
+/*
+NthWeekday is a holiday on the nth weekday of a month, such as the 4th
+Thursday in November. Use -1 for the last one, such as the last Monday
+in May
+*/
+func NthWeekday(month time.Month, weekday time.Weekday, n int) Holiday {
+	return func(year int) Date {
+		if n < 0 {
+			last := NewDate(year, month+1, 0)
+			return last.AddDays(-((int(last.Weekday()) - int(weekday) + 7) % 7) + (n+1)*7)
+		}
+
+		first := NewDate(year, month, 1)
+		return first.AddDays((int(weekday)-int(first.Weekday())+7)%7 + (n-1)*7)
+	}
+}
+
+/*
+USFederalHolidays are the holidays of the US federal government, with
+fixed date holidays observed on the nearest weekday
+*/
+func USFederalHolidays() []Holiday {
+	return []Holiday{
+		Observed(Fixed(time.January, 1)),
+		NthWeekday(time.January, time.Monday, 3),
+		NthWeekday(time.February, time.Monday, 3),
+		NthWeekday(time.May, time.Monday, -1),
+		Observed(Fixed(time.June, 19)),
+		Observed(Fixed(time.July, 4)),
+		NthWeekday(time.September, time.Monday, 1),
+		NthWeekday(time.October, time.Monday, 2),
+		Observed(Fixed(time.November, 11)),
+		NthWeekday(time.November, time.Thursday, 4),
+		Observed(Fixed(time.December, 25)),
+	}
+}
+
+/*
+Calendar does business day arithmetic, skipping weekends and holidays.
+It is safe for concurrent use
+*/
+type Calendar struct {
+	config  CalendarConfig
+	dates   map[Date]bool
+	mutex   sync.Mutex
+	weekend map[time.Weekday]bool
+	years   map[int]bool
+}
+
+/*
+NewCalendar creates a Calendar
+*/
+func NewCalendar(config CalendarConfig) *Calendar {
+	if len(config.Weekend) == 0 {
+		config.Weekend = []time.Weekday{time.Saturday, time.Sunday}
+	}
+
+	result := &Calendar{
+		config:  config,
+		dates:   make(map[Date]bool),
+		weekend: make(map[time.Weekday]bool),
+		years:   make(map[int]bool),
+	}
+
+	for _, weekday := range config.Weekend {
+		result.weekend[weekday] = true
+	}
+
+	for _, d := range config.Dates {
+		result.dates[d] = true
+	}
+
+	return result
+}
+
+/*
+IsBusinessDay returns true when a date isn't a weekend or holiday
+*/
+func (c *Calendar) IsBusinessDay(d Date) bool {
+	return !c.weekend[d.Weekday()] && !c.IsHoliday(d)
+}
+
+/*
+IsHoliday returns true when a date is a holiday
+*/
+func (c *Calendar) IsHoliday(d Date) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// Holidays are worked out a year at a time. Observed holidays can move
+	// into the next or previous year, so neighboring years are included
+	for year := d.Year - 1; year <= d.Year+1; year++ {
+		if !c.years[year] {
+			for _, holiday := range c.config.Holidays {
+				c.dates[holiday(year)] = true
+			}
+
+			c.years[year] = true
+		}
+	}
+
+	return c.dates[d]
+}
+
+/*
+AddBusinessDays returns the business day n business days after d, or
+before it when n is negative. With n of zero, d is returned as is
+*/
+func (c *Calendar) AddBusinessDays(d Date, n int) Date {
+	step := 1
+
+	if n < 0 {
+		step, n = -1, -n
+	}
+
+	for n > 0 {
+		d = d.AddDays(step)
+
+		if c.IsBusinessDay(d) {
+			n--
+		}
+	}
+
+	return d
+}
+
+/*
+NextBusinessDay returns d when it is a business day, or the first
+business day after it
+*/
+func (c *Calendar) NextBusinessDay(d Date) Date {
+	for !c.IsBusinessDay(d) {
+		d = d.AddDays(1)
+	}
+
+	return d
+}
+
+/*
+BusinessDaysBetween returns the number of business days after start, up
+to and including end. It is negative when end is before start
+*/
+func (c *Calendar) BusinessDaysBetween(start, end Date) int {
+	if end.Before(start) {
+		return -c.BusinessDaysBetween(end, start)
+	}
+
+	result := 0
+
+	for d := start.AddDays(1); !d.After(end); d = d.AddDays(1) {
+		if c.IsBusinessDay(d) {
+			result++
+		}
+	}
+
+	return result
+}
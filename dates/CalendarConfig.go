@@ -0,0 +1,18 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package dates
+
+import "time"
+
+/*
+CalendarConfig configures a business day Calendar. Weekend defaults to
+Saturday and Sunday. Holidays are rules that return a year's holidays,
+such as USFederalHolidays, and Dates are one off days off
+*/
+type CalendarConfig struct {
+	Dates    []Date
+	Holidays []Holiday
+	Weekend  []time.Weekday
+}
@@ -0,0 +1,302 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package dates
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DateLayout is how dates are written in JSON, text, and SQL
+const DateLayout = "2006-01-02"
+
+var (
+	ErrInvalidDate  = errors.New("invalid date")
+	ErrInvalidRange = errors.New("invalid range")
+)
+
+/*
+Date is a calendar date with no time or time zone, such as a birthday or
+a due date. It marshals to JSON and text as YYYY-MM-DD, is stored in
+DATE columns, and scans from time.Time or text. The zero Date is
+0001-01-01
+*/
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+/*
+NewDate returns a date, normalizing it as time.Date does, so
+NewDate(2021, 1, 32) is 2021-02-01
+*/
+func NewDate(year int, month time.Month, day int) Date {
+	return DateOf(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+/*
+DateOf returns the date of t in t's time zone
+*/
+func DateOf(t time.Time) Date {
+	year, month, day := t.Date()
+	return Date{Year: year, Month: month, Day: day}
+}
+
+/*
+Today returns the current date in a time zone
+*/
+func Today(location *time.Location) Date {
+	return DateOf(time.Now().In(location))
+}
+
+/*
+ParseDate parses a date written YYYY-MM-DD
+*/
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(DateLayout, s)
+
+	if err != nil {
+		return Date{}, fmt.Errorf("%w: %q", ErrInvalidDate, s)
+	}
+
+	return DateOf(t), nil
+}
+
+/*
+AddDays returns the date days later, or earlier when days is negative
+*/
+func (d Date) AddDays(days int) Date {
+	return NewDate(d.Year, d.Month, d.Day+days)
+}
+
+/*
+AddMonths returns the date months later, keeping the day where it can.
+Days past the end of a shorter month become its last day, so a month
+after January 31 is February 28 or 29
+*/
+func (d Date) AddMonths(months int) Date {
+	result := NewDate(d.Year, d.Month+time.Month(months), 1)
+
+	if last := daysIn(result.Year, result.Month); d.Day > last {
+		result.Day = last
+	} else {
+		result.Day = d.Day
+	}
+
+	return result
+}
+
+/*
+After returns true when d is after other
+*/
+func (d Date) After(other Date) bool {
+	return d.Compare(other) > 0
+}
+
+/*
+Before returns true when d is before other
+*/
+func (d Date) Before(other Date) bool {
+	return d.Compare(other) < 0
+}
+
+/*
+Compare returns -1, 0, or 1 when d is before, the same as, or after other
+*/
+func (d Date) Compare(other Date) int {
+	switch {
+	case d.Year != other.Year:
+		return sign(d.Year - other.Year)
+
+	case d.Month != other.Month:
+		return sign(int(d.Month - other.Month))
+
+	default:
+		return sign(d.Day - other.Day)
+	}
+}
+
+/*
+DaysUntil returns the number of days from d to other, negative when
+other is earlier
+*/
+func (d Date) DaysUntil(other Date) int {
+	return int(other.utc().Sub(d.utc()).Hours() / 24)
+}
+
+/*
+In returns the start of the date in a time zone
+*/
+func (d Date) In(location *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, location)
+}
+
+/*
+IsZero returns true for the zero Date
+*/
+func (d Date) IsZero() bool {
+	return d == Date{}
+}
+
+/*
+String returns the date as YYYY-MM-DD
+*/
+func (d Date) String() string {
+	return d.utc().Format(DateLayout)
+}
+
+/*
+Weekday returns the day of the week
+*/
+func (d Date) Weekday() time.Weekday {
+	return d.utc().Weekday()
+}
+
+/*
+MarshalText satisfies encoding.TextMarshaler, which JSON uses too
+*/
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+/*
+UnmarshalText satisfies encoding.TextUnmarshaler, which JSON uses too
+*/
+func (d *Date) UnmarshalText(b []byte) error {
+	result, err := ParseDate(string(b))
+
+	if err != nil {
+		return err
+	}
+
+	*d = result
+	return nil
+}
+
+/*
+Scan satisfies sql.Scanner. Drivers return DATE columns as time.Time or
+text
+*/
+func (d *Date) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case time.Time:
+		*d = DateOf(v)
+		return nil
+
+	case []byte:
+		return d.Scan(string(v))
+
+	case string:
+		// Some drivers return dates with a midnight time
+		if len(v) > len(DateLayout) {
+			v = v[:len(DateLayout)]
+		}
+
+		return d.UnmarshalText([]byte(v))
+
+	default:
+		return fmt.Errorf("cannot scan %T into a Date", src)
+	}
+}
+
+/*
+Value satisfies driver.Valuer, storing the date as YYYY-MM-DD
+*/
+func (d Date) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+func (d Date) utc() time.Time {
+	return d.In(time.UTC)
+}
+
+/*
+NullDate is a Date that can be NULL in a database, or null in JSON
+*/
+type NullDate struct {
+	Date  Date
+	Valid bool
+}
+
+/*
+MarshalJSON writes null for an invalid NullDate
+*/
+func (n NullDate) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+
+	return []byte(`"` + n.Date.String() + `"`), nil
+}
+
+/*
+UnmarshalJSON reads null or a date
+*/
+func (n *NullDate) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		*n = NullDate{}
+		return nil
+	}
+
+	if len(b) < 2 || b[0] != '"' || b[len(b)-1] != '"' {
+		return fmt.Errorf("%w: %s", ErrInvalidDate, b)
+	}
+
+	if err := n.Date.UnmarshalText(b[1 : len(b)-1]); err != nil {
+		return err
+	}
+
+	n.Valid = true
+	return nil
+}
+
+/*
+Scan satisfies sql.Scanner
+*/
+func (n *NullDate) Scan(src interface{}) error {
+	if src == nil {
+		*n = NullDate{}
+		return nil
+	}
+
+	if err := n.Date.Scan(src); err != nil {
+		return err
+	}
+
+	n.Valid = true
+	return nil
+}
+
+/*
+Value satisfies driver.Valuer
+*/
+func (n NullDate) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+
+	return n.Date.Value()
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+
+	case n > 0:
+		return 1
+
+	default:
+		return 0
+	}
+}
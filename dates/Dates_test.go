@@ -0,0 +1,219 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package dates_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/dates"
+)
+
+func TestDate(t *testing.T) {
+	d := dates.NewDate(2021, time.January, 31)
+
+	tests := []struct {
+		name string
+		got  dates.Date
+		want string
+	}{
+		{name: "Normalized", got: dates.NewDate(2021, time.January, 32), want: "2021-02-01"},
+		{name: "Add days", got: d.AddDays(30), want: "2021-03-02"},
+		{name: "Subtract days", got: d.AddDays(-31), want: "2020-12-31"},
+		{name: "Add month to shorter month", got: d.AddMonths(1), want: "2021-02-28"},
+		{name: "Add month in leap year", got: dates.NewDate(2024, time.January, 31).AddMonths(1), want: "2024-02-29"},
+		{name: "Subtract months", got: d.AddMonths(-2), want: "2020-11-30"},
+	}
+
+	for _, test := range tests {
+		if test.got.String() != test.want {
+			t.Errorf("%s: expected %s but got %s", test.name, test.want, test.got)
+		}
+	}
+
+	if days := d.DaysUntil(dates.NewDate(2021, time.March, 31)); days != 59 {
+		t.Errorf("Expected 59 days but got %d", days)
+	}
+
+	if _, err := dates.ParseDate("2021-02-30"); !errors.Is(err, dates.ErrInvalidDate) {
+		t.Errorf("Expected ErrInvalidDate but got %v", err)
+	}
+}
+
+func TestDateMarshaling(t *testing.T) {
+	type record struct {
+		Due      dates.Date     `json:"due"`
+		Paid     dates.NullDate `json:"paid"`
+		Vacation dates.DateRange
+	}
+
+	original := record{
+		Due:      dates.NewDate(2021, time.March, 4),
+		Vacation: dates.DateRange{End: dates.NewDate(2021, time.July, 9), Start: dates.NewDate(2021, time.July, 5)},
+	}
+
+	b, _ := json.Marshal(original)
+	want := `{"due":"2021-03-04","paid":null,"Vacation":{"end":"2021-07-09","start":"2021-07-05"}}`
+
+	if string(b) != want {
+		t.Errorf("Expected %s but got %s", want, b)
+	}
+
+	decoded := record{}
+
+	if err := json.Unmarshal(b, &decoded); err != nil || decoded != original {
+		t.Errorf("Expected %s to round trip but got %v", b, err)
+	}
+
+	var d dates.Date
+	scans := []interface{}{time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), "2021-03-04", []byte("2021-03-04T00:00:00Z")}
+
+	for _, src := range scans {
+		if err := d.Scan(src); err != nil || d != original.Due {
+			t.Errorf("Expected %v to scan as 2021-03-04 but got %s, %v", src, d, err)
+		}
+	}
+
+	var vacation dates.DateRange
+
+	if err := vacation.Scan("[2021-07-05,2021-07-10)"); err != nil || vacation != original.Vacation {
+		t.Errorf("Expected a daterange to scan but got %v, %v", vacation, err)
+	}
+
+	if vacation.Days() != 5 || !vacation.Contains(dates.NewDate(2021, time.July, 9)) {
+		t.Errorf("Expected 5 days including July 9 but got %d", vacation.Days())
+	}
+}
+
+func TestRange(t *testing.T) {
+	at := func(hour int) time.Time { return time.Date(2021, 3, 4, hour, 0, 0, 0, time.UTC) }
+
+	morning, _ := dates.NewRange(at(9), at(12))
+	lunch, _ := dates.NewRange(at(12), at(13))
+	meeting, _ := dates.NewRange(at(11), at(14))
+
+	if morning.Overlaps(lunch) || !morning.Overlaps(meeting) || morning.Contains(at(12)) {
+		t.Errorf("Expected ranges to exclude their ends")
+	}
+
+	if overlap, ok := morning.Intersect(meeting); !ok || overlap.Duration() != time.Hour {
+		t.Errorf("Expected an hour of overlap but got %v", overlap)
+	}
+
+	if _, err := dates.NewRange(at(12), at(9)); !errors.Is(err, dates.ErrInvalidRange) {
+		t.Errorf("Expected ErrInvalidRange but got %v", err)
+	}
+
+	var scanned dates.Range
+
+	if err := scanned.Scan(`["2021-03-04 09:00:00+00","2021-03-04 12:00:00+00")`); err != nil || !scanned.Start.Equal(at(9)) || !scanned.End.Equal(at(12)) {
+		t.Errorf("Expected a tstzrange to scan but got %v, %v", scanned, err)
+	}
+
+	value, _ := morning.Value()
+
+	if err := scanned.Scan(value); err != nil || !scanned.Start.Equal(at(9)) {
+		t.Errorf("Expected %v to scan back but got %v", value, err)
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	calendar := dates.NewCalendar(dates.CalendarConfig{
+		Dates:    []dates.Date{dates.NewDate(2021, time.November, 26)},
+		Holidays: dates.USFederalHolidays(),
+	})
+
+	tests := []struct {
+		name string
+		got  dates.Date
+		want string
+	}{
+		{name: "Over Thanksgiving", got: calendar.AddBusinessDays(dates.NewDate(2021, time.November, 24), 1), want: "2021-11-29"},
+		{name: "Over a weekend", got: calendar.AddBusinessDays(dates.NewDate(2021, time.March, 5), 1), want: "2021-03-08"},
+		{name: "Backwards over Memorial Day", got: calendar.AddBusinessDays(dates.NewDate(2021, time.June, 1), -1), want: "2021-05-28"},
+		{name: "Observed New Year", got: calendar.NextBusinessDay(dates.NewDate(2021, time.December, 31)), want: "2022-01-03"},
+	}
+
+	for _, test := range tests {
+		if test.got.String() != test.want {
+			t.Errorf("%s: expected %s but got %s", test.name, test.want, test.got)
+		}
+	}
+
+	if days := calendar.BusinessDaysBetween(dates.NewDate(2021, time.December, 20), dates.NewDate(2021, time.December, 31)); days != 7 {
+		t.Errorf("Expected 7 business days but got %d", days)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+
+	if err != nil {
+		t.Skip("time zone data isn't available")
+	}
+
+	// 2am UTC on a Sunday is still Saturday evening in New York
+	t1 := time.Date(2021, 3, 14, 2, 0, 0, 0, time.UTC)
+
+	if got := dates.StartOfDay(t1, newYork); got.Format(time.RFC3339) != "2021-03-13T00:00:00-05:00" {
+		t.Errorf("Expected the 13th in New York but got %s", got.Format(time.RFC3339))
+	}
+
+	if got := dates.StartOfWeek(t1, newYork, time.Monday); got.Format(time.RFC3339) != "2021-03-08T00:00:00-05:00" {
+		t.Errorf("Expected Monday the 8th but got %s", got.Format(time.RFC3339))
+	}
+
+	if got := dates.Month(t1, newYork); got.Start.Day() != 1 || got.End.Format(time.RFC3339) != "2021-04-01T00:00:00-04:00" {
+		t.Errorf("Expected March but got %v", got)
+	}
+
+	// Daylight saving time starts on the 14th
+	if got := dates.Day(time.Date(2021, 3, 14, 12, 0, 0, 0, newYork), newYork); got.Duration() != 23*time.Hour {
+		t.Errorf("Expected a 23 hour day but got %s", got.Duration())
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		long     string
+		short    string
+	}{
+		{duration: 0, long: "0 seconds", short: "0s"},
+		{duration: time.Second, long: "1 second", short: "1s"},
+		{duration: 2*time.Hour + 5*time.Minute + 3*time.Second, long: "2 hours 5 minutes", short: "2h 5m"},
+		{duration: 2*time.Hour + 3*time.Second, long: "2 hours", short: "2h"},
+		{duration: 8 * 24 * time.Hour, long: "1 week 1 day", short: "1w 1d"},
+		{duration: -90 * time.Second, long: "-1 minute 30 seconds", short: "-1m 30s"},
+	}
+
+	for _, test := range tests {
+		if got := dates.FormatDuration(test.duration); got != test.long {
+			t.Errorf("Expected %s but got %s", test.long, got)
+		}
+
+		if got := dates.FormatDurationShort(test.duration); got != test.short {
+			t.Errorf("Expected %s but got %s", test.short, got)
+		}
+	}
+
+	now := time.Date(2021, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	for _, test := range []struct {
+		t    time.Time
+		want string
+	}{
+		{t: now.Add(-30 * time.Second), want: "just now"},
+		{t: now.Add(-5*time.Minute - 10*time.Second), want: "5 minutes ago"},
+		{t: now.Add(49 * time.Hour), want: "in 2 days"},
+		{t: now.Add(-400 * 24 * time.Hour), want: "1 year ago"},
+	} {
+		if got := dates.Relative(test.t, now); got != test.want {
+			t.Errorf("Expected %s but got %s", test.want, got)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package dates
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var units = []struct {
+	duration time.Duration
+	name     string
+	short    string
+}{
+	{duration: 365 * 24 * time.Hour, name: "year", short: "y"},
+	{duration: 30 * 24 * time.Hour, name: "month", short: "mo"},
+	{duration: 7 * 24 * time.Hour, name: "week", short: "w"},
+	{duration: 24 * time.Hour, name: "day", short: "d"},
+	{duration: time.Hour, name: "hour", short: "h"},
+	{duration: time.Minute, name: "minute", short: "m"},
+	{duration: time.Second, name: "second", short: "s"},
+}
+
+/*
+FormatDuration writes a duration for people, using its two largest
+units, such as "2 hours 5 minutes" or "3 days". Months are 30 days and
+years are 365. Durations under a second are "0 seconds"
+*/
+func FormatDuration(d time.Duration) string {
+	return formatDuration(d, func(count int64, name, short string) string {
+		if count == 1 {
+			return fmt.Sprintf("%d %s", count, name)
+		}
+
+		return fmt.Sprintf("%d %ss", count, name)
+	}, " ")
+}
+
+/*
+FormatDurationShort writes a duration compactly, such as "2h 5m" or
+"3d"
+*/
+func FormatDurationShort(d time.Duration) string {
+	return formatDuration(d, func(count int64, name, short string) string {
+		return fmt.Sprintf("%d%s", count, short)
+	}, " ")
+}
+
+/*
+Relative describes when t is compared to now, such as "5 minutes ago",
+"in 2 days", or "just now" within a minute
+*/
+func Relative(t, now time.Time) string {
+	d := now.Sub(t)
+
+	if d > -time.Minute && d < time.Minute {
+		return "just now"
+	}
+
+	if d < 0 {
+		return "in " + formatDuration(-d, largest, "")
+	}
+
+	return formatDuration(d, largest, "") + " ago"
+}
+
+func largest(count int64, name, short string) string {
+	if count == 1 {
+		return "1 " + name
+	}
+
+	return fmt.Sprintf("%d %ss", count, name)
+}
+
+/*
+formatDuration writes the two largest units of a duration, or only the
+largest when separator is empty
+*/
+func formatDuration(d time.Duration, format func(count int64, name, short string) string, separator string) string {
+	sign := ""
+
+	if d < 0 {
+		sign, d = "-", -d
+	}
+
+	parts := []string{}
+
+	for _, unit := range units {
+		if d < unit.duration && len(parts) == 0 {
+			continue
+		}
+
+		count := int64(d / unit.duration)
+		d -= time.Duration(count) * unit.duration
+
+		if count > 0 {
+			parts = append(parts, format(count, unit.name, unit.short))
+		}
+
+		if len(parts) == 2 || (separator == "" && len(parts) == 1) || len(parts) > 0 && count == 0 {
+			break
+		}
+	}
+
+	if len(parts) == 0 {
+		return format(0, "second", "s")
+	}
+
+	return sign + strings.Join(parts, separator)
+}
@@ -0,0 +1,253 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package dates
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+/*
+Range is a span of time from Start up to, but not including, End, such
+as a booking. Ranges that share only an end point, such as 9:00 to 10:00
+and 10:00 to 11:00, don't overlap. It marshals to JSON as
+{"start": ..., "end": ...}, and is stored as a PostgreSQL tstzrange
+*/
+type Range struct {
+	End   time.Time `json:"end"`
+	Start time.Time `json:"start"`
+}
+
+/*
+NewRange returns the range from start to end. End can't be before start
+*/
+func NewRange(start, end time.Time) (Range, error) {
+	if end.Before(start) {
+		return Range{}, fmt.Errorf("%w: %s is before %s", ErrInvalidRange, end.Format(time.RFC3339), start.Format(time.RFC3339))
+	}
+
+	return Range{End: end, Start: start}, nil
+}
+
+/*
+Contains returns true when t is in the range
+*/
+func (r Range) Contains(t time.Time) bool {
+	return !t.Before(r.Start) && t.Before(r.End)
+}
+
+/*
+Duration returns how long the range is
+*/
+func (r Range) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+/*
+Intersect returns the part of the range that is also in other, and
+false when they don't overlap
+*/
+func (r Range) Intersect(other Range) (Range, bool) {
+	if !r.Overlaps(other) {
+		return Range{}, false
+	}
+
+	result := r
+
+	if other.Start.After(result.Start) {
+		result.Start = other.Start
+	}
+
+	if other.End.Before(result.End) {
+		result.End = other.End
+	}
+
+	return result, true
+}
+
+/*
+Overlaps returns true when the ranges share any time
+*/
+func (r Range) Overlaps(other Range) bool {
+	return r.Start.Before(other.End) && other.Start.Before(r.End)
+}
+
+/*
+Scan satisfies sql.Scanner, reading a tstzrange such as
+["2021-01-01 09:00:00+00","2021-01-01 10:00:00+00")
+*/
+func (r *Range) Scan(src interface{}) error {
+	lower, upper, err := scanRange(src)
+
+	if err != nil {
+		return err
+	}
+
+	result := Range{}
+
+	if result.Start, err = parseTimestamp(lower); err != nil {
+		return err
+	}
+
+	if result.End, err = parseTimestamp(upper); err != nil {
+		return err
+	}
+
+	*r = result
+	return nil
+}
+
+/*
+Value satisfies driver.Valuer, writing a tstzrange
+*/
+func (r Range) Value() (driver.Value, error) {
+	return fmt.Sprintf("[%q,%q)", r.Start.Format(time.RFC3339Nano), r.End.Format(time.RFC3339Nano)), nil
+}
+
+/*
+DateRange is the dates from Start to End, both included, such as a
+vacation. It marshals to JSON as {"start": ..., "end": ...}, and is
+stored as a PostgreSQL daterange
+*/
+type DateRange struct {
+	End   Date `json:"end"`
+	Start Date `json:"start"`
+}
+
+/*
+NewDateRange returns the dates from start to end. End can't be before
+start
+*/
+func NewDateRange(start, end Date) (DateRange, error) {
+	if end.Before(start) {
+		return DateRange{}, fmt.Errorf("%w: %s is before %s", ErrInvalidRange, end, start)
+	}
+
+	return DateRange{End: end, Start: start}, nil
+}
+
+/*
+Contains returns true when the date is in the range
+*/
+func (r DateRange) Contains(d Date) bool {
+	return !d.Before(r.Start) && !d.After(r.End)
+}
+
+/*
+Dates returns every date in the range
+*/
+func (r DateRange) Dates() []Date {
+	result := make([]Date, 0, r.Days())
+
+	for d := r.Start; !d.After(r.End); d = d.AddDays(1) {
+		result = append(result, d)
+	}
+
+	return result
+}
+
+/*
+Days returns the number of dates in the range
+*/
+func (r DateRange) Days() int {
+	return r.Start.DaysUntil(r.End) + 1
+}
+
+/*
+Overlaps returns true when the ranges share any date
+*/
+func (r DateRange) Overlaps(other DateRange) bool {
+	return !r.Start.After(other.End) && !other.Start.After(r.End)
+}
+
+/*
+Scan satisfies sql.Scanner, reading a daterange. PostgreSQL writes them
+with the end excluded, such as [2021-01-01,2021-01-08)
+*/
+func (r *DateRange) Scan(src interface{}) error {
+	lower, upper, err := scanRange(src)
+
+	if err != nil {
+		return err
+	}
+
+	text := fmt.Sprintf("%s", src)
+	result := DateRange{}
+
+	if result.Start, err = ParseDate(lower); err != nil {
+		return err
+	}
+
+	if result.End, err = ParseDate(upper); err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(text, "(") {
+		result.Start = result.Start.AddDays(1)
+	}
+
+	if strings.HasSuffix(text, ")") {
+		result.End = result.End.AddDays(-1)
+	}
+
+	*r = result
+	return nil
+}
+
+/*
+Value satisfies driver.Valuer, writing a daterange
+*/
+func (r DateRange) Value() (driver.Value, error) {
+	return "[" + r.Start.String() + "," + r.End.String() + "]", nil
+}
+
+/*
+scanRange splits a PostgreSQL range into its bounds, without quotes.
+Unbounded and empty ranges aren't supported
+*/
+func scanRange(src interface{}) (string, string, error) {
+	var text string
+
+	switch v := src.(type) {
+	case string:
+		text = v
+
+	case []byte:
+		text = string(v)
+
+	default:
+		return "", "", fmt.Errorf("cannot scan %T into a range", src)
+	}
+
+	if len(text) < 2 || !strings.ContainsAny(text[:1], "[(") || !strings.ContainsAny(text[len(text)-1:], "])") {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidRange, text)
+	}
+
+	bounds := strings.SplitN(text[1:len(text)-1], ",", 2)
+
+	if len(bounds) != 2 || bounds[0] == "" || bounds[1] == "" {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidRange, text)
+	}
+
+	return strings.Trim(bounds[0], `"`), strings.Trim(bounds[1], `"`), nil
+}
+
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07",
+	"2006-01-02 15:04:05.999999999-07:00",
+}
+
+func parseTimestamp(s string) (time.Time, error) {
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidRange, s)
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package dates
+
+import "time"
+
+/*
+StartOfDay returns midnight at the start of t's day in a time zone.
+Reports that group by day should truncate in the user's time zone, as
+time.Truncate only works in UTC
+*/
+func StartOfDay(t time.Time, location *time.Location) time.Time {
+	t = t.In(location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
+}
+
+/*
+StartOfWeek returns midnight at the start of t's week in a time zone.
+Weeks start on firstDay, such as time.Monday
+*/
+func StartOfWeek(t time.Time, location *time.Location, firstDay time.Weekday) time.Time {
+	day := StartOfDay(t, location)
+	back := (int(day.Weekday()) - int(firstDay) + 7) % 7
+
+	return time.Date(day.Year(), day.Month(), day.Day()-back, 0, 0, 0, 0, location)
+}
+
+/*
+StartOfMonth returns midnight on the first of t's month in a time zone
+*/
+func StartOfMonth(t time.Time, location *time.Location) time.Time {
+	t = t.In(location)
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, location)
+}
+
+/*
+Day returns the range of t's day in a time zone. Days are 23 or 25 hours
+long when daylight saving time starts or ends
+*/
+func Day(t time.Time, location *time.Location) Range {
+	start := StartOfDay(t, location)
+	return Range{End: time.Date(start.Year(), start.Month(), start.Day()+1, 0, 0, 0, 0, location), Start: start}
+}
+
+/*
+Week returns the range of t's week in a time zone
+*/
+func Week(t time.Time, location *time.Location, firstDay time.Weekday) Range {
+	start := StartOfWeek(t, location, firstDay)
+	return Range{End: time.Date(start.Year(), start.Month(), start.Day()+7, 0, 0, 0, 0, location), Start: start}
+}
+
+/*
+Month returns the range of t's month in a time zone
+*/
+func Month(t time.Time, location *time.Location) Range {
+	start := StartOfMonth(t, location)
+	return Range{End: time.Date(start.Year(), start.Month()+1, 1, 0, 0, 0, 0, location), Start: start}
+}
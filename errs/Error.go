@@ -0,0 +1,167 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package errs
+
+/*
+Error is an error with a machine readable code, such as
+"order-not-found", and a Kind. Message is safe to show to users.
+An Error can wrap a cause, which is kept for logging and errors.Is and
+errors.As, and can carry the stack it was created on.
+
+errors.Is matches an Error against its Kind, and against another Error
+with the same kind and code, so sentinel errors keep matching after
+WithStack or Wrap copies them
+
+	var ErrOrderNotFound = errs.NotFound("order-not-found", "The order doesn't exist")
+
+	return ErrOrderNotFound.Wrap(err)
+*/
+type Error struct {
+	Code    string
+	Kind    Kind
+	Message string
+
+	cause error
+	stack []uintptr
+}
+
+/*
+New creates an error. Code defaults to the kind's name
+*/
+func New(kind Kind, code, message string) *Error {
+	if code == "" {
+		code = kind.String()
+	}
+
+	return &Error{
+		Code:    code,
+		Kind:    kind,
+		Message: message,
+	}
+}
+
+/*
+Invalid is a KindInvalid error
+*/
+func Invalid(code, message string) *Error {
+	return New(KindInvalid, code, message)
+}
+
+/*
+Unauthorized is a KindUnauthorized error
+*/
+func Unauthorized(code, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+/*
+Forbidden is a KindForbidden error
+*/
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+/*
+NotFound is a KindNotFound error
+*/
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+/*
+Conflict is a KindConflict error
+*/
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+/*
+Unavailable is a KindUnavailable error
+*/
+func Unavailable(code, message string) *Error {
+	return New(KindUnavailable, code, message)
+}
+
+/*
+Internal is a KindInternal error wrapping cause, with the stack captured
+*/
+func Internal(cause error) *Error {
+	return New(KindInternal, "", "").Wrap(cause).withStack(3)
+}
+
+/*
+Wrap classifies err, returning an Error of the given kind wrapping it.
+It returns nil when err is nil, so it can wrap a call's result directly
+
+	return errs.Wrap(row.Scan(&order), errs.KindNotFound, "order-not-found", "The order doesn't exist")
+*/
+func Wrap(err error, kind Kind, code, message string) error {
+	if err == nil {
+		return nil
+	}
+
+	return New(kind, code, message).Wrap(err)
+}
+
+/*
+Wrap returns a copy of the error with err as its cause
+*/
+func (e *Error) Wrap(err error) *Error {
+	result := *e
+	result.cause = err
+
+	return &result
+}
+
+/*
+WithStack returns a copy of the error carrying the stack of the caller
+*/
+func (e *Error) WithStack() *Error {
+	return e.withStack(3)
+}
+
+func (e *Error) withStack(skip int) *Error {
+	result := *e
+	result.stack = callers(skip + 1)
+
+	return &result
+}
+
+func (e *Error) Error() string {
+	message := e.Message
+
+	if message == "" {
+		message = e.Code
+	}
+
+	if e.cause != nil {
+		message += ": " + e.cause.Error()
+	}
+
+	return message
+}
+
+/*
+Unwrap returns the error's cause
+*/
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+/*
+Is reports whether the error matches target, which can be a Kind, or an
+Error with the same kind and code
+*/
+func (e *Error) Is(target error) bool {
+	switch t := target.(type) {
+	case Kind:
+		return e.Kind == t
+
+	case *Error:
+		return e.Kind == t.Kind && e.Code == t.Code
+	}
+
+	return false
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package errs_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/errs"
+)
+
+var errOrderNotFound = errs.NotFound("order-not-found", "The order doesn't exist")
+
+func TestClassification(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind errs.Kind
+		code string
+	}{
+		{name: "Error", err: errs.Conflict("duplicate-email", "That email is taken"), kind: errs.KindConflict, code: "duplicate-email"},
+		{name: "Wrapped", err: fmt.Errorf("saving: %w", errs.Forbidden("", "No")), kind: errs.KindForbidden, code: "forbidden"},
+		{name: "Kind", err: fmt.Errorf("charging: %w", errs.KindUnavailable), kind: errs.KindUnavailable, code: "unavailable"},
+		{name: "Default table", err: fmt.Errorf("scan: %w", sql.ErrNoRows), kind: errs.KindNotFound, code: "not-found"},
+		{name: "Deadline", err: context.DeadlineExceeded, kind: errs.KindTimeout, code: "timeout"},
+		{name: "Plain", err: errors.New("boom"), kind: errs.KindUnknown, code: "unknown"},
+		{name: "Nil", err: nil, kind: errs.KindUnknown, code: "unknown"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if kind := errs.KindOf(test.err); kind != test.kind {
+				t.Errorf("Expected kind %s but got %s", test.kind, kind)
+			}
+
+			if code := errs.CodeOf(test.err); code != test.code {
+				t.Errorf("Expected code %s but got %s", test.code, code)
+			}
+		})
+	}
+}
+
+func TestWrapping(t *testing.T) {
+	cause := errors.New("no rows")
+	err := fmt.Errorf("loading order 7: %w", errOrderNotFound.Wrap(cause).WithStack())
+
+	if !errors.Is(err, errOrderNotFound) || !errors.Is(err, errs.KindNotFound) || !errors.Is(err, cause) {
+		t.Errorf("Expected the error to match its sentinel, kind, and cause")
+	}
+
+	if errors.Is(err, errs.KindConflict) || errors.Is(err, errs.NotFound("user-not-found", "")) {
+		t.Errorf("Expected the error not to match another kind or code")
+	}
+
+	var e *errs.Error
+
+	if !errors.As(err, &e) || e.Message != "The order doesn't exist" {
+		t.Errorf("Expected errors.As to find the Error but got %+v", e)
+	}
+
+	if message := err.Error(); message != "loading order 7: The order doesn't exist: no rows" {
+		t.Errorf("Unexpected message '%s'", message)
+	}
+
+	if errs.Wrap(nil, errs.KindInternal, "", "") != nil {
+		t.Errorf("Expected wrapping nil to return nil")
+	}
+}
+
+func TestStack(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "Method", err: errs.NotFound("", "").WithStack()},
+		{name: "Internal", err: errs.Internal(errors.New("disk full"))},
+		{name: "Plain", err: errs.WithStack(errors.New("disk full"))},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			frames := errs.Stack(fmt.Errorf("wrapped: %w", test.err))
+
+			if len(frames) == 0 || !strings.HasSuffix(frames[0].Function, "TestStack") {
+				t.Fatalf("Expected the stack to start at the caller but got %+v", frames)
+			}
+
+			if trace := errs.StackTrace(test.err); !strings.Contains(trace, "Errs_test.go:") {
+				t.Errorf("Expected file and line in the trace but got '%s'", trace)
+			}
+		})
+	}
+
+	if errs.Stack(errors.New("plain")) != nil {
+		t.Errorf("Expected no stack for an error without one")
+	}
+
+	plain := errors.New("plain")
+
+	if withStack := errs.WithStack(plain); !errors.Is(withStack, plain) || withStack.Error() != "plain" {
+		t.Errorf("Expected WithStack to keep the error's message and identity")
+	}
+}
+
+func TestTable(t *testing.T) {
+	errCardDeclined := errors.New("card declined")
+	table := errs.Table{
+		{Err: errCardDeclined, Kind: errs.KindInvalid, Code: "card-declined", Message: "The card was declined"},
+	}
+
+	err := table.Classify(fmt.Errorf("charging: %w", errCardDeclined))
+
+	if errs.KindOf(err) != errs.KindInvalid || errs.CodeOf(err) != "card-declined" || !errors.Is(err, errCardDeclined) {
+		t.Errorf("Expected a classified error but got %v", err)
+	}
+
+	other := errors.New("other")
+
+	if table.Classify(other) != other || table.Classify(nil) != nil {
+		t.Errorf("Expected unmatched errors to be returned as is")
+	}
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package errs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+/*
+Kind classifies an error by what went wrong, independent of where, so
+callers and the API error renderer can react to it. Kinds are errors
+themselves, so errors.Is(err, errs.KindNotFound) reports whether err is
+classified as not found
+*/
+type Kind int
+
+const (
+	// KindUnknown is an error nobody has classified. It is treated as internal
+	KindUnknown Kind = iota
+
+	// KindInvalid is a request that can't be processed as sent
+	KindInvalid
+
+	// KindUnauthorized is a request without valid credentials
+	KindUnauthorized
+
+	// KindForbidden is a request for something the caller isn't allowed to do
+	KindForbidden
+
+	// KindNotFound is a request for something that doesn't exist
+	KindNotFound
+
+	// KindConflict is a request that conflicts with the current state, such as a duplicate
+	KindConflict
+
+	// KindRateLimited is a request rejected because the caller sent too many
+	KindRateLimited
+
+	// KindInternal is a failure that is the server's fault
+	KindInternal
+
+	// KindUnavailable is a dependency that is down or overloaded, which may work if retried
+	KindUnavailable
+
+	// KindTimeout is work that took too long
+	KindTimeout
+)
+
+var kindNames = map[Kind]string{
+	KindUnknown:      "unknown",
+	KindInvalid:      "invalid",
+	KindUnauthorized: "unauthorized",
+	KindForbidden:    "forbidden",
+	KindNotFound:     "not-found",
+	KindConflict:     "conflict",
+	KindRateLimited:  "rate-limited",
+	KindInternal:     "internal",
+	KindUnavailable:  "unavailable",
+	KindTimeout:      "timeout",
+}
+
+/*
+String returns the kind's name, such as "not-found". It is also the
+default code for errors of that kind
+*/
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+
+	return kindNames[KindUnknown]
+}
+
+func (k Kind) Error() string {
+	return k.String()
+}
+
+/*
+KindOf returns how err is classified. The first *Error or Kind in the
+chain decides. Errors matched by DefaultTable, such as sql.ErrNoRows,
+get the kind it gives them, and anything else is KindUnknown
+*/
+func KindOf(err error) Kind {
+	var (
+		e    *Error
+		kind Kind
+	)
+
+	switch {
+	case err == nil:
+		return KindUnknown
+
+	case errors.As(err, &e):
+		return e.Kind
+
+	case errors.As(err, &kind):
+		return kind
+	}
+
+	if mapping, ok := DefaultTable.Lookup(err); ok {
+		return mapping.Kind
+	}
+
+	return KindUnknown
+}
+
+/*
+CodeOf returns the code of the first *Error in err's chain. Errors
+without one get the name of their kind
+*/
+func CodeOf(err error) string {
+	var e *Error
+
+	if errors.As(err, &e) && e.Code != "" {
+		return e.Code
+	}
+
+	if mapping, ok := DefaultTable.Lookup(err); ok && mapping.Code != "" {
+		return mapping.Code
+	}
+
+	return KindOf(err).String()
+}
+
+/*
+DefaultTable classifies standard library errors
+*/
+var DefaultTable = Table{
+	{Err: sql.ErrNoRows, Kind: KindNotFound},
+	{Err: context.DeadlineExceeded, Kind: KindTimeout},
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+/*
+Frame is one function call in a captured stack
+*/
+type Frame struct {
+	File     string `json:"file"`
+	Function string `json:"function"`
+	Line     int    `json:"line"`
+}
+
+func (f Frame) String() string {
+	return fmt.Sprintf("%s\n\t%s:%d", f.Function, f.File, f.Line)
+}
+
+type stackError struct {
+	err   error
+	stack []uintptr
+}
+
+func (e *stackError) Error() string {
+	return e.err.Error()
+}
+
+func (e *stackError) Unwrap() error {
+	return e.err
+}
+
+/*
+WithStack returns err carrying the stack of the caller, unless something
+in its chain already carries one. The error's message and what it
+matches are unchanged. It returns nil when err is nil
+*/
+func WithStack(err error) error {
+	if err == nil || len(stackOf(err)) > 0 {
+		return err
+	}
+
+	if e, ok := err.(*Error); ok {
+		return e.withStack(3)
+	}
+
+	return &stackError{err: err, stack: callers(3)}
+}
+
+/*
+Stack returns the stack captured deepest in err's chain, the one closest
+to where the error happened, or nil when none was captured
+*/
+func Stack(err error) []Frame {
+	pcs := stackOf(err)
+
+	if len(pcs) == 0 {
+		return nil
+	}
+
+	result := make([]Frame, 0, len(pcs))
+	frames := runtime.CallersFrames(pcs)
+
+	for {
+		frame, more := frames.Next()
+		result = append(result, Frame{File: frame.File, Function: frame.Function, Line: frame.Line})
+
+		if !more {
+			break
+		}
+	}
+
+	return result
+}
+
+/*
+StackTrace returns the stack captured in err's chain formatted one frame
+per line, as panics print them, or an empty string
+*/
+func StackTrace(err error) string {
+	frames := Stack(err)
+	lines := make([]string, 0, len(frames))
+
+	for _, frame := range frames {
+		lines = append(lines, frame.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func stackOf(err error) []uintptr {
+	var result []uintptr
+
+	for err != nil {
+		switch e := err.(type) {
+		case *Error:
+			if len(e.stack) > 0 {
+				result = e.stack
+			}
+
+		case *stackError:
+			result = e.stack
+		}
+
+		err = errors.Unwrap(err)
+	}
+
+	return result
+}
+
+func callers(skip int) []uintptr {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(skip, pcs)
+
+	return pcs[:n]
+}
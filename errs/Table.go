@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package errs
+
+import (
+	"errors"
+)
+
+/*
+Mapping classifies errors matching Err, using errors.Is. Code defaults
+to the kind's name
+*/
+type Mapping struct {
+	Code    string
+	Err     error
+	Kind    Kind
+	Message string
+}
+
+/*
+Table classifies errors from packages that don't use Error, such as a
+database driver's or a client library's sentinel errors. The first
+matching mapping wins
+
+	var errorTable = errs.Table{
+		{Err: sql.ErrNoRows, Kind: errs.KindNotFound, Code: "record-not-found"},
+		{Err: stripe.ErrCardDeclined, Kind: errs.KindInvalid, Code: "card-declined", Message: "The card was declined"},
+	}
+
+Pass a table to apierror.TableMapper to render matching errors as
+problems
+*/
+type Table []Mapping
+
+/*
+Lookup returns the first mapping matching err
+*/
+func (t Table) Lookup(err error) (Mapping, bool) {
+	if err == nil {
+		return Mapping{}, false
+	}
+
+	for _, mapping := range t {
+		if errors.Is(err, mapping.Err) {
+			return mapping, true
+		}
+	}
+
+	return Mapping{}, false
+}
+
+/*
+Classify wraps err in an Error using the first matching mapping. Errors
+that match nothing, and nil, are returned as is
+*/
+func (t Table) Classify(err error) error {
+	mapping, ok := t.Lookup(err)
+
+	if !ok {
+		return err
+	}
+
+	return New(mapping.Kind, mapping.Code, mapping.Message).Wrap(err)
+}
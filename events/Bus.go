@@ -0,0 +1,380 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var ErrBusClosed = errors.New("event bus is closed")
+
+/*
+TopicStats counts events for one topic. Delivered and Failed count
+handler calls, so one event with three subscribers adds three. Dropped
+counts events skipped because an async subscriber's buffer was full
+*/
+type TopicStats struct {
+	Delivered uint64 `json:"delivered"`
+	Dropped   uint64 `json:"dropped"`
+	Failed    uint64 `json:"failed"`
+	Published uint64 `json:"published"`
+}
+
+/*
+Bus delivers events to subscribers in the same process. Synchronous
+subscribers run inside Publish, and their errors are returned to the
+publisher. Async subscribers each have a buffer and a goroutine, so
+slow handlers don't hold up publishers
+*/
+type Bus struct {
+	sync.RWMutex
+
+	closed        bool
+	config        BusConfig
+	stats         map[Topic]*TopicStats
+	statsMutex    sync.Mutex
+	subscriptions map[*Subscription]struct{}
+	wg            sync.WaitGroup
+}
+
+/*
+Subscription is a handler subscribed to a topic pattern
+*/
+type Subscription struct {
+	sync.RWMutex
+
+	async   bool
+	bus     *Bus
+	closed  bool
+	handler Handler
+	pattern string
+	queue   chan Event
+}
+
+/*
+NewBus creates an event bus
+*/
+func NewBus(config BusConfig) *Bus {
+	if config.BufferSize <= 0 {
+		config.BufferSize = 100
+	}
+
+	if config.Name == "" {
+		config.Name = "events"
+	}
+
+	return &Bus{
+		config:        config,
+		stats:         make(map[Topic]*TopicStats),
+		subscriptions: make(map[*Subscription]struct{}),
+	}
+}
+
+/*
+Subscribe calls handler inside Publish for every event matching
+pattern. See Topic.Matches for patterns
+*/
+func (b *Bus) Subscribe(pattern string, handler Handler) (*Subscription, error) {
+	return b.subscribe(pattern, handler, false)
+}
+
+/*
+SubscribeAsync calls handler on its own goroutine for every event
+matching pattern, in the order events were published. Handlers get a
+context that isn't canceled when the publisher's is, and their errors
+go to OnError
+*/
+func (b *Bus) SubscribeAsync(pattern string, handler Handler) (*Subscription, error) {
+	return b.subscribe(pattern, handler, true)
+}
+
+func (b *Bus) subscribe(pattern string, handler Handler, async bool) (*Subscription, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.closed {
+		return nil, ErrBusClosed
+	}
+
+	for index := len(b.config.Middleware) - 1; index >= 0; index-- {
+		handler = b.config.Middleware[index](handler)
+	}
+
+	subscription := &Subscription{
+		async:   async,
+		bus:     b,
+		handler: handler,
+		pattern: pattern,
+	}
+
+	if async {
+		subscription.queue = make(chan Event, b.config.BufferSize)
+		b.wg.Add(1)
+
+		go subscription.work()
+	}
+
+	b.subscriptions[subscription] = struct{}{}
+	return subscription, nil
+}
+
+/*
+Publish sends an event with payload to every subscriber of topic. It
+returns an error if any synchronous handler fails
+*/
+func (b *Bus) Publish(ctx context.Context, topic Topic, payload interface{}) error {
+	return b.PublishEvent(ctx, Event{Payload: payload, Topic: topic})
+}
+
+/*
+PublishEvent sends an event to every subscriber of its topic. ID and
+Time are filled in when empty
+*/
+func (b *Bus) PublishEvent(ctx context.Context, event Event) error {
+	if event.ID == "" {
+		event.ID = newID()
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	b.RLock()
+
+	if b.closed {
+		b.RUnlock()
+		return ErrBusClosed
+	}
+
+	matches := make([]*Subscription, 0)
+
+	for subscription := range b.subscriptions {
+		if event.Topic.Matches(subscription.pattern) {
+			matches = append(matches, subscription)
+		}
+	}
+
+	b.RUnlock()
+	b.count(event.Topic, func(stats *TopicStats) { stats.Published++ })
+
+	var firstErr error
+	failed := 0
+
+	for _, subscription := range matches {
+		if subscription.async {
+			if err := subscription.enqueue(ctx, event); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := b.deliver(ctx, subscription, event); err != nil {
+			failed++
+
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	if firstErr != nil {
+		return fmt.Errorf("%d of %d handlers for %s failed: %w", failed, len(matches), event.Topic, firstErr)
+	}
+
+	return nil
+}
+
+/*
+Close unsubscribes everyone and waits for async subscribers to finish
+the events in their buffers, or for ctx to be done
+*/
+func (b *Bus) Close(ctx context.Context) error {
+	b.Lock()
+
+	if b.closed {
+		b.Unlock()
+		return nil
+	}
+
+	b.closed = true
+	subscriptions := b.subscriptions
+	b.subscriptions = make(map[*Subscription]struct{})
+	b.Unlock()
+
+	for subscription := range subscriptions {
+		subscription.close()
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+
+	case <-ctx.Done():
+		return fmt.Errorf("error waiting for async subscribers: %w", ctx.Err())
+	}
+}
+
+/*
+Stats returns counts for every topic that has had events published
+*/
+func (b *Bus) Stats() map[Topic]TopicStats {
+	b.statsMutex.Lock()
+	defer b.statsMutex.Unlock()
+
+	result := make(map[Topic]TopicStats, len(b.stats))
+
+	for topic, stats := range b.stats {
+		result[topic] = *stats
+	}
+
+	return result
+}
+
+/*
+Name returns the name the bus's stats are reported under
+*/
+func (b *Bus) Name() string {
+	return b.config.Name
+}
+
+/*
+Collect returns per-topic stats in the form serverstats collectors
+report, so a Bus can be passed to RegisterCollector
+*/
+func (b *Bus) Collect(ctx context.Context) map[string]interface{} {
+	result := make(map[string]interface{})
+
+	for topic, stats := range b.Stats() {
+		result[string(topic)] = stats
+	}
+
+	return result
+}
+
+func (b *Bus) deliver(ctx context.Context, subscription *Subscription, event Event) error {
+	err := callHandler(ctx, subscription.handler, event)
+
+	b.count(event.Topic, func(stats *TopicStats) {
+		if err != nil {
+			stats.Failed++
+		} else {
+			stats.Delivered++
+		}
+	})
+
+	return err
+}
+
+func (b *Bus) count(topic Topic, update func(stats *TopicStats)) {
+	b.statsMutex.Lock()
+	defer b.statsMutex.Unlock()
+
+	stats, ok := b.stats[topic]
+
+	if !ok {
+		stats = &TopicStats{}
+		b.stats[topic] = stats
+	}
+
+	update(stats)
+}
+
+/*
+Unsubscribe stops delivering events to the subscription. Async
+subscribers finish the events already in their buffer
+*/
+func (s *Subscription) Unsubscribe() {
+	s.bus.Lock()
+	delete(s.bus.subscriptions, s)
+	s.bus.Unlock()
+
+	s.close()
+}
+
+func (s *Subscription) close() {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	s.closed = true
+
+	if s.async {
+		close(s.queue)
+	}
+}
+
+func (s *Subscription) enqueue(ctx context.Context, event Event) error {
+	s.RLock()
+	defer s.RUnlock()
+
+	if s.closed {
+		return nil
+	}
+
+	if s.bus.config.DropWhenFull {
+		select {
+		case s.queue <- event:
+		default:
+			s.bus.count(event.Topic, func(stats *TopicStats) { stats.Dropped++ })
+		}
+
+		return nil
+	}
+
+	select {
+	case s.queue <- event:
+		return nil
+
+	case <-ctx.Done():
+		return fmt.Errorf("error publishing %s: %w", event.Topic, ctx.Err())
+	}
+}
+
+func (s *Subscription) work() {
+	defer s.bus.wg.Done()
+
+	for event := range s.queue {
+		if err := s.bus.deliver(context.Background(), s, event); err != nil && s.bus.config.OnError != nil {
+			s.bus.config.OnError(event, err)
+		}
+	}
+}
+
+/*
+callHandler calls a handler, turning a panic into an error
+*/
+func callHandler(ctx context.Context, handler Handler, event Event) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("handler for %s panicked: %v", event.Topic, recovered)
+		}
+	}()
+
+	return handler(ctx, event)
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,22 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package events
+
+/*
+BusConfig configures a Bus. BufferSize is how many events each async
+subscriber can have waiting, and defaults to 100. When a buffer is
+full, Publish waits for room, or drops the event for that subscriber
+when DropWhenFull is set. Middleware wraps every handler, with the
+first middleware outermost. OnError is called when an async handler
+fails, since there is no publisher to return the error to. Name
+identifies the bus's stats in serverstats, and defaults to "events"
+*/
+type BusConfig struct {
+	BufferSize   int
+	DropWhenFull bool
+	Middleware   []Middleware
+	Name         string
+	OnError      func(event Event, err error)
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package events_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/events"
+)
+
+const (
+	userLoggedIn  events.Topic = "identity.login"
+	userLoggedOut events.Topic = "identity.logout"
+	orderPlaced   events.Topic = "orders.placed"
+)
+
+func TestTopicMatches(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		topic    events.Topic
+		expected bool
+	}{
+		{pattern: "*", topic: orderPlaced, expected: true},
+		{pattern: "identity.login", topic: userLoggedIn, expected: true},
+		{pattern: "identity.login", topic: userLoggedOut, expected: false},
+		{pattern: "identity.*", topic: userLoggedOut, expected: true},
+		{pattern: "identity.*", topic: orderPlaced, expected: false},
+		{pattern: "identity.*", topic: "identity", expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern+" "+string(test.topic), func(t *testing.T) {
+			if actual := test.topic.Matches(test.pattern); actual != test.expected {
+				t.Errorf("Expected %v but got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestBus(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		received []string
+		order    []string
+	)
+
+	record := func(name string) events.Handler {
+		return func(ctx context.Context, event events.Event) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			received = append(received, name+":"+string(event.Topic))
+			return nil
+		}
+	}
+
+	middleware := func(name string) events.Middleware {
+		return func(next events.Handler) events.Handler {
+			return func(ctx context.Context, event events.Event) error {
+				mutex.Lock()
+				order = append(order, name)
+				mutex.Unlock()
+
+				return next(ctx, event)
+			}
+		}
+	}
+
+	asyncErrors := make(chan error, 10)
+	bus := events.NewBus(events.BusConfig{
+		Middleware: []events.Middleware{middleware("outer"), middleware("inner")},
+		OnError:    func(event events.Event, err error) { asyncErrors <- err },
+	})
+
+	_, _ = bus.Subscribe("identity.login", record("audit"))
+	_, _ = bus.SubscribeAsync("identity.*", record("webhooks"))
+	_, _ = bus.SubscribeAsync("orders.placed", func(ctx context.Context, event events.Event) error {
+		panic("boom")
+	})
+
+	failing, _ := bus.Subscribe("orders.placed", func(ctx context.Context, event events.Event) error {
+		return errors.New("out of stock")
+	})
+
+	if err := bus.Publish(context.Background(), userLoggedIn, "user-1"); err != nil {
+		t.Fatalf("Expected no error but got '%v'", err)
+	}
+
+	if err := bus.Publish(context.Background(), orderPlaced, "order-1"); err == nil {
+		t.Errorf("Expected the synchronous handler's error to be returned")
+	}
+
+	failing.Unsubscribe()
+
+	if err := bus.Publish(context.Background(), orderPlaced, "order-2"); err != nil {
+		t.Errorf("Expected no error after unsubscribing but got '%v'", err)
+	}
+
+	if err := bus.Close(context.Background()); err != nil {
+		t.Fatalf("Expected no error but got '%v'", err)
+	}
+
+	if err := bus.Publish(context.Background(), userLoggedIn, "user-2"); !errors.Is(err, events.ErrBusClosed) {
+		t.Errorf("Expected ErrBusClosed but got '%v'", err)
+	}
+
+	if len(received) != 2 {
+		t.Errorf("Expected 2 deliveries but got %v", received)
+	}
+
+	if len(order) < 2 || order[0] != "outer" || order[1] != "inner" {
+		t.Errorf("Expected middleware to run outer first but got %v", order)
+	}
+
+	if len(asyncErrors) != 2 {
+		t.Errorf("Expected the async panic to reach OnError")
+	}
+
+	stats := bus.Stats()
+
+	if stats[orderPlaced].Published != 2 || stats[orderPlaced].Failed != 3 || stats[userLoggedIn].Delivered != 2 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestBusDropWhenFull(t *testing.T) {
+	release := make(chan bool)
+	bus := events.NewBus(events.BusConfig{BufferSize: 1, DropWhenFull: true})
+
+	_, _ = bus.SubscribeAsync("*", func(ctx context.Context, event events.Event) error {
+		<-release
+		return nil
+	})
+
+	for index := 0; index < 5; index++ {
+		_ = bus.Publish(context.Background(), orderPlaced, index)
+		time.Sleep(time.Millisecond)
+	}
+
+	close(release)
+	_ = bus.Close(context.Background())
+
+	if stats := bus.Stats()[orderPlaced]; stats.Dropped != 3 || stats.Delivered != 2 {
+		t.Errorf("Expected 3 dropped and 2 delivered but got %+v", stats)
+	}
+}
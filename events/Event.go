@@ -0,0 +1,57 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package events
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+/*
+Topic names a kind of event, such as "identity.login". Declare topics as
+constants in the package that publishes them, alongside the payload type
+subscribers should expect
+*/
+type Topic string
+
+/*
+Matches reports whether a topic matches a subscription pattern. "*"
+matches every topic, a pattern ending in ".*" matches every topic with
+that prefix, such as "identity.*", and anything else must match exactly
+*/
+func (t Topic) Matches(pattern string) bool {
+	if pattern == "*" || pattern == string(t) {
+		return true
+	}
+
+	if strings.HasSuffix(pattern, ".*") {
+		return strings.HasPrefix(string(t), strings.TrimSuffix(pattern, "*"))
+	}
+
+	return false
+}
+
+/*
+Event is something that happened, delivered to every subscriber of its
+topic. Metadata carries context such as a request or correlation ID
+*/
+type Event struct {
+	ID       string            `json:"id"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Payload  interface{}       `json:"payload"`
+	Time     time.Time         `json:"time"`
+	Topic    Topic             `json:"topic"`
+}
+
+/*
+Handler handles an event
+*/
+type Handler func(ctx context.Context, event Event) error
+
+/*
+Middleware wraps a handler, such as to log or time deliveries
+*/
+type Middleware func(next Handler) Handler
@@ -0,0 +1,69 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+/*
+Logging logs every delivery at debug level, and failed deliveries at
+error level, with the topic, event ID, and how long the handler took
+*/
+func Logging(logger logrus.FieldLogger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, event Event) error {
+			start := time.Now()
+			err := next(ctx, event)
+
+			entry := logger.WithFields(logrus.Fields{
+				"duration": time.Since(start).String(),
+				"eventID":  event.ID,
+				"topic":    event.Topic,
+			})
+
+			if err != nil {
+				entry.WithError(err).Error("event handler failed")
+			} else {
+				entry.Debug("event delivered")
+			}
+
+			return err
+		}
+	}
+}
+
+/*
+Metrics calls record with the topic, duration, and error of every
+delivery, for feeding a metrics system
+*/
+func Metrics(record func(topic Topic, duration time.Duration, err error)) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, event Event) error {
+			start := time.Now()
+			err := next(ctx, event)
+
+			record(event.Topic, time.Since(start), err)
+			return err
+		}
+	}
+}
+
+/*
+Timeout cancels the handler's context after timeout
+*/
+func Timeout(timeout time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, event Event) error {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			return next(ctx, event)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package flags
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+EnvProvider reads flags from environment variables. The flag name is
+the rest of the variable name in lower case, with underscores replaced
+by hyphens, so FLAG_NEW_CHECKOUT sets the "new-checkout" flag. Values
+can be:
+
+	FLAG_NEW_CHECKOUT=true        on or off for everyone, also on/off, yes/no, and 1/0
+	FLAG_NEW_CHECKOUT=25%         on for 25 percent of users
+	FLAG_NEW_CHECKOUT=alice,bob   on for those user IDs only
+*/
+type EnvProvider struct {
+	config EnvProviderConfig
+}
+
+/*
+NewEnvProvider creates a provider that reads flags from environment
+variables
+*/
+func NewEnvProvider(config EnvProviderConfig) *EnvProvider {
+	if config.Environ == nil {
+		config.Environ = os.Environ
+	}
+
+	if config.Prefix == "" {
+		config.Prefix = "FLAG_"
+	}
+
+	return &EnvProvider{
+		config: config,
+	}
+}
+
+/*
+Flags returns a flag for every environment variable with the provider's
+prefix, sorted by name
+*/
+func (p *EnvProvider) Flags(ctx context.Context) ([]Flag, error) {
+	result := make([]Flag, 0)
+
+	for _, variable := range p.config.Environ() {
+		key, value := variable, ""
+
+		if index := strings.Index(variable, "="); index > -1 {
+			key, value = variable[:index], variable[index+1:]
+		}
+
+		if !strings.HasPrefix(key, p.config.Prefix) || len(key) == len(p.config.Prefix) {
+			continue
+		}
+
+		name := strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(key, p.config.Prefix)), "_", "-")
+		flag, err := parseEnvFlag(name, strings.TrimSpace(value))
+
+		if err != nil {
+			return nil, fmt.Errorf("error reading environment variable %s: %w", key, err)
+		}
+
+		result = append(result, flag)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+func parseEnvFlag(name, value string) (Flag, error) {
+	switch strings.ToLower(value) {
+	case "on", "yes":
+		return Flag{Enabled: true, Name: name}, nil
+
+	case "off", "no":
+		return Flag{Enabled: false, Name: name}, nil
+	}
+
+	if enabled, err := strconv.ParseBool(value); err == nil {
+		return Flag{Enabled: enabled, Name: name}, nil
+	}
+
+	if strings.HasSuffix(value, "%") {
+		percentage, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+
+		if err != nil || percentage < 0 || percentage > 100 {
+			return Flag{}, fmt.Errorf("%w: %q is not a percentage", ErrInvalidFlag, value)
+		}
+
+		return Flag{Enabled: percentage > 0, Name: name, Percentage: percentage}, nil
+	}
+
+	users := make([]string, 0)
+
+	for _, user := range strings.Split(value, ",") {
+		if user = strings.TrimSpace(user); user != "" {
+			users = append(users, user)
+		}
+	}
+
+	if len(users) == 0 {
+		return Flag{}, fmt.Errorf("%w: %q", ErrInvalidFlag, value)
+	}
+
+	return Flag{Enabled: true, Name: name, Users: users}, nil
+}
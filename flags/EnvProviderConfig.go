@@ -0,0 +1,15 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package flags
+
+/*
+EnvProviderConfig configures an EnvProvider. Only variables starting
+with Prefix are read, and it defaults to "FLAG_". Environ defaults to
+os.Environ
+*/
+type EnvProviderConfig struct {
+	Environ func() []string
+	Prefix  string
+}
@@ -0,0 +1,200 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package flags
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/httpserver"
+)
+
+/*
+Evaluator answers whether flags are on, using the flags last read from
+its provider. Flags it doesn't know about are off
+*/
+type Evaluator struct {
+	sync.RWMutex
+
+	config   EvaluatorConfig
+	flags    map[string]Flag
+	now      func() time.Time
+	shutdown chan struct{}
+	stats    *statsTracker
+}
+
+/*
+NewEvaluator creates an Evaluator. Call Refresh to read the flags before
+evaluating them, and StartPolling to keep them up to date
+
+	evaluator := flags.NewEvaluator(flags.EvaluatorConfig{
+		Provider: flags.NewRemoteProvider(flags.RemoteProviderConfig{URL: flagsURL}),
+	})
+
+	if err := evaluator.Refresh(ctx); err != nil {
+		...
+	}
+
+	evaluator.StartPolling(time.Minute)
+*/
+func NewEvaluator(config EvaluatorConfig) *Evaluator {
+	if config.Name == "" {
+		config.Name = "flags"
+	}
+
+	if config.Provider == nil {
+		config.Provider = NewStaticProvider()
+	}
+
+	return &Evaluator{
+		config: config,
+		flags:  make(map[string]Flag),
+		now:    time.Now,
+		stats:  newStatsTracker(),
+	}
+}
+
+/*
+Enabled reports whether a flag is on for the user a request was made by,
+as read from the request's Identity. Requests without one are anonymous
+
+	if evaluator.Enabled(ctx.Request().Context(), "new-checkout") {
+		...
+	}
+*/
+func (e *Evaluator) Enabled(ctx context.Context, name string) bool {
+	identity, _ := httpserver.IdentityFromContext(ctx)
+	return e.EnabledFor(name, identity.UserID)
+}
+
+/*
+EnabledFor reports whether a flag is on for a user ID. Pass an empty
+user ID for anonymous requests
+*/
+func (e *Evaluator) EnabledFor(name, userID string) bool {
+	e.RLock()
+	flag, ok := e.flags[name]
+	e.RUnlock()
+
+	if !ok {
+		e.stats.unknown()
+		return false
+	}
+
+	result := flag.EnabledFor(userID)
+	e.stats.evaluate(name, result)
+
+	return result
+}
+
+/*
+Flag returns a flag by name, and false when there is no such flag
+*/
+func (e *Evaluator) Flag(name string) (Flag, bool) {
+	e.RLock()
+	defer e.RUnlock()
+
+	flag, ok := e.flags[name]
+	return flag, ok
+}
+
+/*
+Flags returns every flag, sorted by name
+*/
+func (e *Evaluator) Flags() []Flag {
+	e.RLock()
+	defer e.RUnlock()
+
+	result := make([]Flag, 0, len(e.flags))
+
+	for _, flag := range e.flags {
+		result = append(result, flag)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+/*
+Refresh reads the flags from the provider. When that fails, the error is
+passed to OnError and returned, and the current flags are kept
+*/
+func (e *Evaluator) Refresh(ctx context.Context) error {
+	flags, err := e.config.Provider.Flags(ctx)
+
+	if err != nil {
+		e.stats.refreshed(err, e.now())
+
+		if e.config.OnError != nil {
+			e.config.OnError(err)
+		}
+
+		return err
+	}
+
+	next := make(map[string]Flag, len(flags))
+
+	for _, flag := range flags {
+		next[flag.Name] = flag
+	}
+
+	e.Lock()
+	e.flags = next
+	e.Unlock()
+
+	e.stats.refreshed(nil, e.now())
+	return nil
+}
+
+/*
+StartPolling starts a goroutine that calls Refresh on an interval.
+Calling it again while polling does nothing
+*/
+func (e *Evaluator) StartPolling(interval time.Duration) {
+	e.Lock()
+	defer e.Unlock()
+
+	if e.shutdown != nil {
+		return
+	}
+
+	shutdown := make(chan struct{})
+	e.shutdown = shutdown
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-shutdown:
+				return
+
+			case <-ticker.C:
+				_ = e.Refresh(context.Background())
+			}
+		}
+	}()
+}
+
+/*
+StopPolling stops the polling started by StartPolling
+*/
+func (e *Evaluator) StopPolling() {
+	e.Lock()
+	defer e.Unlock()
+
+	if e.shutdown == nil {
+		return
+	}
+
+	close(e.shutdown)
+	e.shutdown = nil
+}
@@ -0,0 +1,19 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package flags
+
+/*
+EvaluatorConfig configures an Evaluator.
+
+Provider is where flags are read from. OnError is called when reading
+them fails, and the flags from the last successful read stay in use.
+Name identifies the evaluator's stats in serverstats, and defaults to
+"flags"
+*/
+type EvaluatorConfig struct {
+	Name     string
+	OnError  func(err error)
+	Provider IProvider
+}
@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package flags
+
+import (
+	"hash/fnv"
+)
+
+/*
+Flag is a feature flag. A flag that isn't Enabled is off for everyone.
+An enabled flag is on for the user IDs in Users, and for Percentage
+percent of every other signed in user. Users are placed by a hash of
+the flag name and user ID, so each user gets the same answer every time
+and raising the percentage only adds users.
+
+An enabled flag with neither Users nor a Percentage is a simple switch,
+on for everyone, including anonymous requests
+*/
+type Flag struct {
+	Enabled    bool     `json:"enabled"`
+	Name       string   `json:"name"`
+	Percentage float64  `json:"percentage,omitempty"`
+	Users      []string `json:"users,omitempty"`
+}
+
+/*
+EnabledFor reports whether the flag is on for a user. Pass an empty user
+ID for anonymous requests
+*/
+func (f Flag) EnabledFor(userID string) bool {
+	if !f.Enabled {
+		return false
+	}
+
+	if len(f.Users) == 0 && f.Percentage <= 0 {
+		return true
+	}
+
+	if userID == "" {
+		return false
+	}
+
+	for _, user := range f.Users {
+		if user == userID {
+			return true
+		}
+	}
+
+	return f.Percentage > 0 && bucket(f.Name, userID) < f.Percentage
+}
+
+/*
+bucket places a user between 0 and 100 for a flag
+*/
+func bucket(name, userID string) float64 {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(name + ":" + userID))
+
+	return float64(hash.Sum32()%10000) / 100
+}
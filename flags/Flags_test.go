@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package flags_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/flags"
+)
+
+func TestFlagEnabledFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		flag   flags.Flag
+		userID string
+		expect bool
+	}{
+		{name: "Disabled", flag: flags.Flag{Name: "a", Users: []string{"alice"}}, userID: "alice", expect: false},
+		{name: "Switch", flag: flags.Flag{Enabled: true, Name: "a"}, userID: "", expect: true},
+		{name: "Targeted user", flag: flags.Flag{Enabled: true, Name: "a", Users: []string{"alice"}}, userID: "alice", expect: true},
+		{name: "Other user", flag: flags.Flag{Enabled: true, Name: "a", Users: []string{"alice"}}, userID: "bob", expect: false},
+		{name: "Anonymous targeted", flag: flags.Flag{Enabled: true, Name: "a", Users: []string{"alice"}}, userID: "", expect: false},
+		{name: "Full rollout", flag: flags.Flag{Enabled: true, Name: "a", Percentage: 100}, userID: "bob", expect: true},
+		{name: "Anonymous rollout", flag: flags.Flag{Enabled: true, Name: "a", Percentage: 100}, userID: "", expect: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if result := test.flag.EnabledFor(test.userID); result != test.expect {
+				t.Errorf("Expected %v but got %v", test.expect, result)
+			}
+		})
+	}
+}
+
+func TestFlagPercentage(t *testing.T) {
+	quarter := flags.Flag{Enabled: true, Name: "new-checkout", Percentage: 25}
+	half := flags.Flag{Enabled: true, Name: "new-checkout", Percentage: 50}
+	enabled := 0
+
+	for i := 0; i < 10000; i++ {
+		userID := fmt.Sprintf("user-%d", i)
+
+		if quarter.EnabledFor(userID) {
+			enabled++
+
+			if !half.EnabledFor(userID) {
+				t.Fatalf("Expected raising the percentage to keep %s enabled", userID)
+			}
+		}
+
+		if quarter.EnabledFor(userID) != quarter.EnabledFor(userID) {
+			t.Fatalf("Expected the same answer for %s every time", userID)
+		}
+	}
+
+	if enabled < 2300 || enabled > 2700 {
+		t.Errorf("Expected about 2500 of 10000 users but got %d", enabled)
+	}
+}
+
+func TestEnvProvider(t *testing.T) {
+	provider := flags.NewEnvProvider(flags.EnvProviderConfig{
+		Environ: func() []string {
+			return []string{"HOME=/root", "FLAG_NEW_CHECKOUT=25%", "FLAG_DARK_MODE=true", "FLAG_BETA=alice, bob", "FLAG_OLD=off"}
+		},
+	})
+
+	result, err := provider.Flags(context.Background())
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	expect := []flags.Flag{
+		{Enabled: true, Name: "beta", Users: []string{"alice", "bob"}},
+		{Enabled: true, Name: "dark-mode"},
+		{Enabled: true, Name: "new-checkout", Percentage: 25},
+		{Enabled: false, Name: "old"},
+	}
+
+	if !reflect.DeepEqual(result, expect) {
+		t.Errorf("Expected %+v but got %+v", expect, result)
+	}
+
+	provider = flags.NewEnvProvider(flags.EnvProviderConfig{
+		Environ: func() []string { return []string{"FLAG_BROKEN=150%"} },
+	})
+
+	if _, err = provider.Flags(context.Background()); !errors.Is(err, flags.ErrInvalidFlag) {
+		t.Errorf("Expected ErrInvalidFlag but got %v", err)
+	}
+}
+
+func TestRemoteProviderAndPolling(t *testing.T) {
+	var requests, notModified int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		if r.Header.Get("X-API-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`[{"name": "new-checkout", "enabled": true, "users": ["alice"]}]`))
+	}))
+	defer server.Close()
+
+	errorCount := 0
+	evaluator := flags.NewEvaluator(flags.EvaluatorConfig{
+		OnError: func(err error) { errorCount++ },
+		Provider: flags.NewRemoteProvider(flags.RemoteProviderConfig{
+			Headers: http.Header{"X-API-Key": []string{"secret"}},
+			URL:     server.URL,
+		}),
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := evaluator.Refresh(context.Background()); err != nil {
+			t.Fatalf("Expected no error but got '%s'", err)
+		}
+	}
+
+	if atomic.LoadInt32(&notModified) != 1 {
+		t.Errorf("Expected the second request to be answered with 304")
+	}
+
+	if !evaluator.EnabledFor("new-checkout", "alice") || evaluator.EnabledFor("new-checkout", "bob") {
+		t.Errorf("Expected new-checkout on for alice only")
+	}
+
+	if evaluator.Enabled(context.Background(), "new-checkout") || evaluator.EnabledFor("missing", "alice") {
+		t.Errorf("Expected anonymous requests and unknown flags to be off")
+	}
+
+	failing := flags.NewEvaluator(flags.EvaluatorConfig{
+		OnError:  func(err error) { errorCount++ },
+		Provider: flags.NewRemoteProvider(flags.RemoteProviderConfig{URL: server.URL}),
+	})
+
+	if err := failing.Refresh(context.Background()); err == nil || errorCount != 1 {
+		t.Errorf("Expected an error reported to OnError but got %v", err)
+	}
+
+	stats := evaluator.Stats()
+	expect := map[string]flags.FlagStats{"new-checkout": {Disabled: 2, Enabled: 1}}
+
+	if !reflect.DeepEqual(stats.Evaluations, expect) || stats.Unknown != 1 || stats.Refreshes != 2 || stats.LastRefresh.IsZero() {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	if collected := evaluator.Collect(context.Background()); evaluator.Name() != "flags" || collected["unknown"] != uint64(1) {
+		t.Errorf("Unexpected collected stats %v", collected)
+	}
+}
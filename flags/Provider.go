@@ -0,0 +1,45 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package flags
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrInvalidFlag is returned when a provider reads a flag it can't parse
+var ErrInvalidFlag = errors.New("invalid flag")
+
+/*
+IProvider describes a source of feature flags. Flags returns every flag
+the provider knows about
+*/
+type IProvider interface {
+	Flags(ctx context.Context) ([]Flag, error)
+}
+
+/*
+StaticProvider serves a fixed set of flags, such as ones from a config
+file or a test
+*/
+type StaticProvider struct {
+	flags []Flag
+}
+
+/*
+NewStaticProvider creates a provider that always returns flags
+*/
+func NewStaticProvider(flags ...Flag) *StaticProvider {
+	return &StaticProvider{
+		flags: flags,
+	}
+}
+
+/*
+Flags returns the provider's flags
+*/
+func (p *StaticProvider) Flags(ctx context.Context) ([]Flag, error) {
+	return p.flags, nil
+}
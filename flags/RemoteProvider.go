@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/ResurgenceIT/kit/v6/httpclient"
+)
+
+/*
+RemoteProvider reads flags from a JSON endpoint, such as a flag service
+or a file in object storage. The endpoint returns an array of flags:
+
+	[
+		{ "name": "new-checkout", "enabled": true, "percentage": 25 },
+		{ "name": "beta-reports", "enabled": true, "users": ["alice", "bob"] }
+	]
+
+When the endpoint sends an ETag, later requests ask for changes only,
+and a 304 Not Modified keeps the flags from the last response
+*/
+type RemoteProvider struct {
+	sync.Mutex
+
+	config RemoteProviderConfig
+	etag   string
+	flags  []Flag
+}
+
+/*
+NewRemoteProvider creates a provider that reads flags from a URL
+*/
+func NewRemoteProvider(config RemoteProviderConfig) *RemoteProvider {
+	if config.Client == nil {
+		config.Client = httpclient.NewClient(httpclient.ClientConfig{Name: "flags"})
+	}
+
+	return &RemoteProvider{
+		config: config,
+	}
+}
+
+/*
+Flags requests the flags from the provider's URL
+*/
+func (p *RemoteProvider) Flags(ctx context.Context) ([]Flag, error) {
+	var (
+		err      error
+		request  *http.Request
+		response *http.Response
+		body     []byte
+	)
+
+	if request, err = http.NewRequestWithContext(ctx, http.MethodGet, p.config.URL, nil); err != nil {
+		return nil, fmt.Errorf("error creating flags request: %w", err)
+	}
+
+	for key, values := range p.config.Headers {
+		for _, value := range values {
+			request.Header.Add(key, value)
+		}
+	}
+
+	request.Header.Set("Accept", "application/json")
+
+	p.Lock()
+	etag := p.etag
+	p.Unlock()
+
+	if etag != "" {
+		request.Header.Set("If-None-Match", etag)
+	}
+
+	if response, err = p.config.Client.Do(request); err != nil {
+		return nil, fmt.Errorf("error requesting flags: %w", err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotModified {
+		p.Lock()
+		defer p.Unlock()
+
+		return p.flags, nil
+	}
+
+	if body, err = ioutil.ReadAll(response.Body); err != nil {
+		return nil, fmt.Errorf("error reading flags response: %w", err)
+	}
+
+	if response.StatusCode >= 300 {
+		return nil, fmt.Errorf("error requesting flags: %w", &httpclient.ResponseError{Body: body, StatusCode: response.StatusCode})
+	}
+
+	result := make([]Flag, 0)
+
+	if err = json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("%w: error decoding flags: %s", ErrInvalidFlag, err.Error())
+	}
+
+	p.Lock()
+	p.etag = response.Header.Get("ETag")
+	p.flags = result
+	p.Unlock()
+
+	return result, nil
+}
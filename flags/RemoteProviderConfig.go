@@ -0,0 +1,23 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package flags
+
+import (
+	"net/http"
+
+	"github.com/ResurgenceIT/kit/v6/httpclient"
+)
+
+/*
+RemoteProviderConfig configures a RemoteProvider. URL is the endpoint
+serving the flags as a JSON array. Headers are added to every request,
+such as an API key. Client defaults to an httpclient.Client named
+"flags", so requests are retried and tracked
+*/
+type RemoteProviderConfig struct {
+	Client  *httpclient.Client
+	Headers http.Header
+	URL     string
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package flags
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/*
+Stats contains evaluation counts for an Evaluator. Evaluations counts,
+per flag, how often it was on and off. Unknown counts evaluations of
+flags the provider didn't return, which usually means a typo or a flag
+removed too early
+*/
+type Stats struct {
+	Evaluations   map[string]FlagStats `json:"evaluations"`
+	LastRefresh   time.Time            `json:"lastRefresh"`
+	RefreshErrors uint64               `json:"refreshErrors"`
+	Refreshes     uint64               `json:"refreshes"`
+	Unknown       uint64               `json:"unknown"`
+}
+
+/*
+FlagStats counts how often a flag was on and off
+*/
+type FlagStats struct {
+	Disabled uint64 `json:"disabled"`
+	Enabled  uint64 `json:"enabled"`
+}
+
+type statsTracker struct {
+	sync.Mutex
+
+	stats Stats
+}
+
+func newStatsTracker() *statsTracker {
+	return &statsTracker{
+		stats: Stats{Evaluations: make(map[string]FlagStats)},
+	}
+}
+
+func (t *statsTracker) evaluate(name string, enabled bool) {
+	t.Lock()
+	defer t.Unlock()
+
+	counts := t.stats.Evaluations[name]
+
+	if enabled {
+		counts.Enabled++
+	} else {
+		counts.Disabled++
+	}
+
+	t.stats.Evaluations[name] = counts
+}
+
+func (t *statsTracker) unknown() {
+	t.Lock()
+	t.stats.Unknown++
+	t.Unlock()
+}
+
+func (t *statsTracker) refreshed(err error, now time.Time) {
+	t.Lock()
+	defer t.Unlock()
+
+	if err != nil {
+		t.stats.RefreshErrors++
+		return
+	}
+
+	t.stats.LastRefresh = now.UTC()
+	t.stats.Refreshes++
+}
+
+/*
+Stats returns the evaluator's evaluation and refresh counts
+*/
+func (e *Evaluator) Stats() Stats {
+	e.stats.Lock()
+	defer e.stats.Unlock()
+
+	result := e.stats.stats
+	result.Evaluations = make(map[string]FlagStats, len(e.stats.stats.Evaluations))
+
+	for name, counts := range e.stats.stats.Evaluations {
+		result.Evaluations[name] = counts
+	}
+
+	return result
+}
+
+/*
+Name returns the name the evaluator's stats are reported under
+*/
+func (e *Evaluator) Name() string {
+	return e.config.Name
+}
+
+/*
+Collect returns the evaluator's stats in the form serverstats collectors
+report, so an Evaluator can be passed to RegisterCollector
+*/
+func (e *Evaluator) Collect(ctx context.Context) map[string]interface{} {
+	stats := e.Stats()
+
+	return map[string]interface{}{
+		"evaluations":   stats.Evaluations,
+		"lastRefresh":   stats.LastRefresh,
+		"refreshErrors": stats.RefreshErrors,
+		"refreshes":     stats.Refreshes,
+		"unknown":       stats.Unknown,
+	}
+}
@@ -0,0 +1,186 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package fsm
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+)
+
+/*
+Record is a transition that happened. It is saved to the history and
+is the payload of the event published on the bus
+*/
+type Record struct {
+	At       time.Time         `json:"at"`
+	EntityID string            `json:"entityID"`
+	Event    Event             `json:"event"`
+	From     State             `json:"from"`
+	ID       string            `json:"id"`
+	Machine  string            `json:"machine"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	To       State             `json:"to"`
+}
+
+/*
+IHistoryStore saves the transitions entities go through. List returns
+an entity's records oldest first
+*/
+type IHistoryStore interface {
+	Append(ctx context.Context, record Record) error
+	List(ctx context.Context, machine, entityID string) ([]Record, error)
+}
+
+/*
+MemoryHistoryStore keeps history in memory, for tests and single
+instance tools
+*/
+type MemoryHistoryStore struct {
+	sync.RWMutex
+
+	records []Record
+}
+
+/*
+NewMemoryHistoryStore creates an empty in-memory history
+*/
+func NewMemoryHistoryStore() *MemoryHistoryStore {
+	return &MemoryHistoryStore{
+		records: make([]Record, 0, 50),
+	}
+}
+
+func (s *MemoryHistoryStore) Append(ctx context.Context, record Record) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *MemoryHistoryStore) List(ctx context.Context, machine, entityID string) ([]Record, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	result := make([]Record, 0, 10)
+
+	for _, record := range s.records {
+		if record.Machine == machine && record.EntityID == entityID {
+			result = append(result, record)
+		}
+	}
+
+	return result, nil
+}
+
+/*
+SQLHistoryStore keeps history in a table. Metadata is stored as JSON.
+
+	CREATE TABLE state_history (
+		id VARCHAR(36) PRIMARY KEY,
+		machine VARCHAR(50) NOT NULL,
+		entity_id VARCHAR(50) NOT NULL,
+		event VARCHAR(50) NOT NULL,
+		from_state VARCHAR(50) NOT NULL,
+		to_state VARCHAR(50) NOT NULL,
+		metadata TEXT,
+		created_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX idx_state_history_entity ON state_history (machine, entity_id, created_at);
+*/
+type SQLHistoryStore struct {
+	config SQLHistoryStoreConfig
+}
+
+/*
+NewSQLHistoryStore creates a history store on a table
+*/
+func NewSQLHistoryStore(config SQLHistoryStoreConfig) *SQLHistoryStore {
+	if config.Rebind == nil {
+		config.Rebind = func(query string) string { return query }
+	}
+
+	if config.Table == "" {
+		config.Table = "state_history"
+	}
+
+	return &SQLHistoryStore{
+		config: config,
+	}
+}
+
+func (s *SQLHistoryStore) Append(ctx context.Context, record Record) error {
+	var (
+		err      error
+		metadata []byte
+	)
+
+	if metadata, err = json.Marshal(record.Metadata); err != nil {
+		return fmt.Errorf("error marshaling state history metadata: %w", err)
+	}
+
+	query := s.config.Rebind("INSERT INTO " + s.config.Table + " (id, machine, entity_id, event, from_state, to_state, metadata, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+
+	if _, err = s.config.DB.ExecContext(ctx, query, record.ID, record.Machine, record.EntityID, string(record.Event), string(record.From), string(record.To), string(metadata), record.At); err != nil {
+		return fmt.Errorf("error writing state history: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLHistoryStore) List(ctx context.Context, machine, entityID string) ([]Record, error) {
+	var (
+		err  error
+		rows sqldatabase.Rows
+	)
+
+	query := s.config.Rebind("SELECT id, event, from_state, to_state, metadata, created_at FROM " + s.config.Table + " WHERE machine = ? AND entity_id = ? ORDER BY created_at, id")
+
+	if rows, err = s.config.DB.QueryContext(ctx, query, machine, entityID); err != nil {
+		return nil, fmt.Errorf("error reading state history: %w", err)
+	}
+
+	defer rows.Close()
+
+	result := make([]Record, 0, 10)
+
+	for rows.Next() {
+		var (
+			event    string
+			from     string
+			metadata sql.NullString
+			to       string
+		)
+
+		record := Record{EntityID: entityID, Machine: machine}
+
+		if err = rows.Scan(&record.ID, &event, &from, &to, &metadata, &record.At); err != nil {
+			return nil, fmt.Errorf("error scanning state history: %w", err)
+		}
+
+		record.Event, record.From, record.To = Event(event), State(from), State(to)
+
+		if metadata.String != "" && metadata.String != "null" {
+			if err = json.Unmarshal([]byte(metadata.String), &record.Metadata); err != nil {
+				return nil, fmt.Errorf("error unmarshaling metadata of state history %s: %w", record.ID, err)
+			}
+		}
+
+		result = append(result, record)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading state history: %w", err)
+	}
+
+	return result, nil
+}
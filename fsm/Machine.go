@@ -0,0 +1,258 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package fsm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ResurgenceIT/kit/v6/clock"
+	"github.com/ResurgenceIT/kit/v6/events"
+	"github.com/ResurgenceIT/kit/v6/ids"
+)
+
+/*
+Machine moves entities between states. It holds no entity state of its
+own, so one Machine serves every order or ticket. Firing events on the
+same entity from more than one goroutine must be synchronized by the
+caller, such as with a row lock or optimistic locking
+*/
+type Machine struct {
+	any         map[Event]*Transition
+	config      MachineConfig
+	transitions map[Event]map[State]*Transition
+}
+
+/*
+NewMachine creates a machine, checking that each event has at most one
+transition from any state and, when States is set, that transitions
+only use those states
+*/
+func NewMachine(config MachineConfig) (*Machine, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("%w: a name is required", ErrInvalidDefinition)
+	}
+
+	if config.Clock == nil {
+		config.Clock = clock.Real{}
+	}
+
+	states := make(map[State]struct{}, len(config.States))
+
+	for _, state := range config.States {
+		states[state] = struct{}{}
+	}
+
+	known := func(state State) bool {
+		_, ok := states[state]
+		return len(states) == 0 || ok
+	}
+
+	result := &Machine{
+		any:         make(map[Event]*Transition),
+		config:      config,
+		transitions: make(map[Event]map[State]*Transition),
+	}
+
+	for index := range config.Transitions {
+		transition := &config.Transitions[index]
+
+		if transition.Event == "" || transition.To == "" || len(transition.From) == 0 {
+			return nil, fmt.Errorf("%w: transition %d needs an event, from states, and a to state", ErrInvalidDefinition, index)
+		}
+
+		if !known(transition.To) {
+			return nil, fmt.Errorf("%w: %s goes to unknown state %s", ErrInvalidDefinition, transition.Event, transition.To)
+		}
+
+		if result.transitions[transition.Event] == nil {
+			result.transitions[transition.Event] = make(map[State]*Transition)
+		}
+
+		for _, from := range transition.From {
+			if from == Any {
+				if _, ok := result.any[transition.Event]; ok {
+					return nil, fmt.Errorf("%w: %s is defined from any state more than once", ErrInvalidDefinition, transition.Event)
+				}
+
+				result.any[transition.Event] = transition
+				continue
+			}
+
+			if !known(from) {
+				return nil, fmt.Errorf("%w: %s comes from unknown state %s", ErrInvalidDefinition, transition.Event, from)
+			}
+
+			if _, ok := result.transitions[transition.Event][from]; ok {
+				return nil, fmt.Errorf("%w: %s is defined from %s more than once", ErrInvalidDefinition, transition.Event, from)
+			}
+
+			result.transitions[transition.Event][from] = transition
+		}
+	}
+
+	return result, nil
+}
+
+/*
+Name returns the machine's name
+*/
+func (m *Machine) Name() string {
+	return m.config.Name
+}
+
+/*
+Topic returns the topic transitions for event are published on
+*/
+func (m *Machine) Topic(event Event) events.Topic {
+	return events.Topic(m.config.Name + "." + string(event))
+}
+
+/*
+Events returns the events that can fire from state, in the order their
+transitions were defined. Guards aren't checked
+*/
+func (m *Machine) Events(state State) []Event {
+	result := make([]Event, 0, len(m.transitions))
+	seen := make(map[Event]struct{}, len(m.transitions))
+
+	for _, transition := range m.config.Transitions {
+		if _, ok := seen[transition.Event]; ok {
+			continue
+		}
+
+		if m.find(transition.Event, state) != nil {
+			result = append(result, transition.Event)
+			seen[transition.Event] = struct{}{}
+		}
+	}
+
+	return result
+}
+
+/*
+Can reports whether event can fire for entity, running the guards but
+not the hooks. It returns nil, an *InvalidTransitionError, or a
+*GuardError
+*/
+func (m *Machine) Can(ctx context.Context, entity IEntity, event Event, metadata map[string]string) error {
+	_, _, err := m.check(ctx, entity, event, metadata)
+	return err
+}
+
+/*
+Fire moves entity along the transition for event from its current
+state. Guards run first, then before hooks. When they pass the entity's
+state is set, the transition is saved to the history, after hooks run,
+and the Record is published on the bus. If saving the history fails the
+entity is put back in its old state and the error returned. Metadata,
+such as who made the change and why, is saved with the record
+*/
+func (m *Machine) Fire(ctx context.Context, entity IEntity, event Event, metadata map[string]string) (Record, error) {
+	var (
+		change     Change
+		err        error
+		transition *Transition
+	)
+
+	if transition, change, err = m.check(ctx, entity, event, metadata); err != nil {
+		return Record{}, err
+	}
+
+	for _, hooks := range [][]Hook{m.config.Before, transition.Before} {
+		for _, hook := range hooks {
+			if err = hook(ctx, change); err != nil {
+				return Record{}, fmt.Errorf("error before %s %s %s: %w", m.config.Name, change.Entity.GetID(), event, err)
+			}
+		}
+	}
+
+	record := Record{
+		At:       m.config.Clock.Now().UTC(),
+		EntityID: entity.GetID(),
+		Event:    event,
+		From:     change.From,
+		ID:       ids.NewV7().String(),
+		Machine:  m.config.Name,
+		Metadata: metadata,
+		To:       change.To,
+	}
+
+	entity.SetState(change.To)
+
+	if m.config.History != nil {
+		if err = m.config.History.Append(ctx, record); err != nil {
+			entity.SetState(change.From)
+			return Record{}, err
+		}
+	}
+
+	for _, hooks := range [][]Hook{transition.After, m.config.After} {
+		for _, hook := range hooks {
+			if err = hook(ctx, change); err != nil {
+				m.onError(record, fmt.Errorf("error after %s %s %s: %w", m.config.Name, record.EntityID, event, err))
+			}
+		}
+	}
+
+	if m.config.Bus != nil {
+		if err = m.config.Bus.PublishEvent(ctx, events.Event{ID: record.ID, Payload: record, Time: record.At, Topic: m.Topic(event)}); err != nil {
+			m.onError(record, fmt.Errorf("error publishing %s %s %s: %w", m.config.Name, record.EntityID, event, err))
+		}
+	}
+
+	return record, nil
+}
+
+/*
+History returns the transitions an entity has been through, oldest
+first. It returns ErrNoHistory when no history store is configured
+*/
+func (m *Machine) History(ctx context.Context, entityID string) ([]Record, error) {
+	if m.config.History == nil {
+		return nil, ErrNoHistory
+	}
+
+	return m.config.History.List(ctx, m.config.Name, entityID)
+}
+
+func (m *Machine) check(ctx context.Context, entity IEntity, event Event, metadata map[string]string) (*Transition, Change, error) {
+	from := entity.GetState()
+	transition := m.find(event, from)
+
+	if transition == nil {
+		return nil, Change{}, &InvalidTransitionError{EntityID: entity.GetID(), Event: event, Machine: m.config.Name, State: from}
+	}
+
+	change := Change{
+		Entity:   entity,
+		Event:    event,
+		From:     from,
+		Metadata: metadata,
+		To:       transition.To,
+	}
+
+	for _, guard := range transition.Guards {
+		if err := guard(ctx, change); err != nil {
+			return nil, Change{}, &GuardError{EntityID: entity.GetID(), Err: err, Event: event, Machine: m.config.Name, State: from}
+		}
+	}
+
+	return transition, change, nil
+}
+
+func (m *Machine) find(event Event, from State) *Transition {
+	if transition, ok := m.transitions[event][from]; ok {
+		return transition
+	}
+
+	return m.any[event]
+}
+
+func (m *Machine) onError(record Record, err error) {
+	if m.config.OnError != nil {
+		m.config.OnError(record, err)
+	}
+}
@@ -0,0 +1,36 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package fsm
+
+import (
+	"github.com/ResurgenceIT/kit/v6/clock"
+	"github.com/ResurgenceIT/kit/v6/events"
+)
+
+/*
+MachineConfig configures a Machine.
+
+Name identifies the machine, such as "order", in history records and
+errors, and prefixes the topics transitions are published on. States,
+when set, lists every state, and transitions to or from anything else
+are rejected by NewMachine. Transitions are the moves entities can make.
+
+Before hooks run before every transition's own, and After hooks after
+them. History saves every transition, and Bus, when set, gets an event
+with the Record as its payload on the topic "<Name>.<event>", such as
+"order.ship". Errors from after hooks and publishing don't undo the
+transition, so they go to OnError. Clock defaults to the system clock
+*/
+type MachineConfig struct {
+	After       []Hook
+	Before      []Hook
+	Bus         *events.Bus
+	Clock       clock.IClock
+	History     IHistoryStore
+	Name        string
+	OnError     func(record Record, err error)
+	States      []State
+	Transitions []Transition
+}
@@ -0,0 +1,188 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package fsm_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/events"
+	"github.com/ResurgenceIT/kit/v6/fsm"
+)
+
+const (
+	pending   fsm.State = "pending"
+	paid      fsm.State = "paid"
+	shipped   fsm.State = "shipped"
+	cancelled fsm.State = "cancelled"
+)
+
+var errNotPaid = errors.New("the order has not been paid")
+
+type order struct {
+	id     string
+	paid   bool
+	status fsm.State
+}
+
+func (o *order) GetID() string            { return o.id }
+func (o *order) GetState() fsm.State      { return o.status }
+func (o *order) SetState(state fsm.State) { o.status = state }
+
+func newOrderMachine(t *testing.T, config fsm.MachineConfig) *fsm.Machine {
+	config.Name = "order"
+	config.States = []fsm.State{pending, paid, shipped, cancelled}
+	config.Transitions = append([]fsm.Transition{
+		{Event: "pay", From: []fsm.State{pending}, To: paid},
+		{Event: "ship", From: []fsm.State{paid}, To: shipped, Guards: []fsm.Guard{
+			func(ctx context.Context, change fsm.Change) error {
+				if !change.Entity.(*order).paid {
+					return errNotPaid
+				}
+
+				return nil
+			},
+		}},
+		{Event: "cancel", From: []fsm.State{pending, paid}, To: cancelled},
+	}, config.Transitions...)
+
+	machine, err := fsm.NewMachine(config)
+
+	if err != nil {
+		t.Fatalf("Expected a machine but got %s", err.Error())
+	}
+
+	return machine
+}
+
+func TestNewMachine(t *testing.T) {
+	tests := []struct {
+		name        string
+		transitions []fsm.Transition
+	}{
+		{name: "Unknown state", transitions: []fsm.Transition{{Event: "refund", From: []fsm.State{shipped}, To: "refunded"}}},
+		{name: "Duplicate", transitions: []fsm.Transition{{Event: "pay", From: []fsm.State{pending}, To: cancelled}}},
+		{name: "Missing from", transitions: []fsm.Transition{{Event: "hold", To: pending}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := fsm.NewMachine(fsm.MachineConfig{
+				Name:        "order",
+				States:      []fsm.State{pending, paid, shipped, cancelled},
+				Transitions: append([]fsm.Transition{{Event: "pay", From: []fsm.State{pending}, To: paid}}, test.transitions...),
+			})
+
+			if !errors.Is(err, fsm.ErrInvalidDefinition) {
+				t.Errorf("Expected ErrInvalidDefinition but got %v", err)
+			}
+		})
+	}
+}
+
+func TestFire(t *testing.T) {
+	ctx := context.Background()
+	calls := make([]string, 0)
+
+	hook := func(name string) fsm.Hook {
+		return func(ctx context.Context, change fsm.Change) error {
+			calls = append(calls, fmt.Sprintf("%s %s->%s", name, change.From, change.To))
+			return nil
+		}
+	}
+
+	history := fsm.NewMemoryHistoryStore()
+	machine := newOrderMachine(t, fsm.MachineConfig{After: []fsm.Hook{hook("after")}, Before: []fsm.Hook{hook("before")}, History: history})
+	o := &order{id: "o-1", status: pending}
+
+	if _, err := machine.Fire(ctx, o, "ship", nil); !errors.Is(err, fsm.ErrInvalidTransition) {
+		t.Fatalf("Expected ErrInvalidTransition but got %v", err)
+	}
+
+	if _, err := machine.Fire(ctx, o, "pay", map[string]string{"by": "bob"}); err != nil || o.status != paid {
+		t.Fatalf("Expected the order to be paid but got %s, %v", o.status, err)
+	}
+
+	_, err := machine.Fire(ctx, o, "ship", nil)
+	guardErr := &fsm.GuardError{}
+
+	if !errors.As(err, &guardErr) || !errors.Is(err, errNotPaid) || !errors.Is(err, fsm.ErrGuardRejected) || o.status != paid {
+		t.Fatalf("Expected a guard error and the order still paid but got %v, %s", err, o.status)
+	}
+
+	o.paid = true
+
+	if _, err = machine.Fire(ctx, o, "ship", nil); err != nil || o.status != shipped {
+		t.Fatalf("Expected the order to be shipped but got %s, %v", o.status, err)
+	}
+
+	expected := []string{"before pending->paid", "after pending->paid", "before paid->shipped", "after paid->shipped"}
+
+	if fmt.Sprint(calls) != fmt.Sprint(expected) {
+		t.Errorf("Expected hooks %v but got %v", expected, calls)
+	}
+
+	records, _ := machine.History(ctx, "o-1")
+
+	if len(records) != 2 || records[0].To != paid || records[0].Metadata["by"] != "bob" || records[1].From != paid || records[1].To != shipped {
+		t.Errorf("Expected pay then ship in the history but got %+v", records)
+	}
+}
+
+func TestFireBeforeHookAborts(t *testing.T) {
+	history := fsm.NewMemoryHistoryStore()
+	machine := newOrderMachine(t, fsm.MachineConfig{
+		Before:  []fsm.Hook{func(ctx context.Context, change fsm.Change) error { return errors.New("inventory unavailable") }},
+		History: history,
+	})
+
+	o := &order{id: "o-2", status: pending}
+
+	if _, err := machine.Fire(context.Background(), o, "pay", nil); err == nil || o.status != pending {
+		t.Errorf("Expected the before hook to stop the transition but got %s, %v", o.status, err)
+	}
+
+	if records, _ := history.List(context.Background(), "order", "o-2"); len(records) != 0 {
+		t.Errorf("Expected no history but got %+v", records)
+	}
+}
+
+func TestFirePublishes(t *testing.T) {
+	bus := events.NewBus(events.BusConfig{})
+	received := make([]fsm.Record, 0)
+
+	_, _ = bus.Subscribe("order.*", func(ctx context.Context, event events.Event) error {
+		received = append(received, event.Payload.(fsm.Record))
+		return errors.New("subscriber failed")
+	})
+
+	failures := 0
+	machine := newOrderMachine(t, fsm.MachineConfig{
+		Bus:         bus,
+		OnError:     func(record fsm.Record, err error) { failures++ },
+		Transitions: []fsm.Transition{{Event: "archive", From: []fsm.State{fsm.Any}, To: cancelled}},
+	})
+
+	o := &order{id: "o-3", status: shipped}
+	record, err := machine.Fire(context.Background(), o, "archive", nil)
+
+	if err != nil || o.status != cancelled {
+		t.Fatalf("Expected the order to be archived from any state but got %s, %v", o.status, err)
+	}
+
+	if len(received) != 1 || received[0].ID != record.ID || machine.Topic("archive") != "order.archive" {
+		t.Errorf("Expected the record on order.archive but got %+v", received)
+	}
+
+	if failures != 1 {
+		t.Errorf("Expected the subscriber error to go to OnError but got %d failures", failures)
+	}
+
+	if available := machine.Events(pending); fmt.Sprint(available) != "[pay cancel archive]" {
+		t.Errorf("Expected [pay cancel archive] from pending but got %v", available)
+	}
+}
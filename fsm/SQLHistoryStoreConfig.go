@@ -0,0 +1,21 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package fsm
+
+import (
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+)
+
+/*
+SQLHistoryStoreConfig configures a SQLHistoryStore. DB is a database or
+a transaction. Table defaults to "state_history".
+Queries are written with ? placeholders; set Rebind to rewrite them for
+databases that use another style, such as $1 for Postgres
+*/
+type SQLHistoryStoreConfig struct {
+	DB     sqldatabase.Querier
+	Rebind func(query string) string
+	Table  string
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package fsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrGuardRejected     = errors.New("transition rejected by guard")
+	ErrInvalidDefinition = errors.New("invalid state machine definition")
+	ErrInvalidTransition = errors.New("invalid transition")
+	ErrNoHistory         = errors.New("state machine has no history store")
+)
+
+/*
+Any in a transition's From states lets its event fire from every state
+*/
+const Any State = "*"
+
+/*
+State is a state an entity can be in, such as "pending" or "shipped"
+*/
+type State string
+
+/*
+Event is something that moves an entity from one state to another, such
+as "ship" or "cancel"
+*/
+type Event string
+
+/*
+IEntity is anything whose state a Machine manages, such as an order or a
+ticket. SetState is called with the new state after before hooks pass
+*/
+type IEntity interface {
+	GetID() string
+	GetState() State
+	SetState(state State)
+}
+
+/*
+Change describes a transition in progress. It is passed to guards and
+hooks. Metadata is what was passed to Fire, and is saved in the history
+*/
+type Change struct {
+	Entity   IEntity
+	Event    Event
+	From     State
+	Metadata map[string]string
+	To       State
+}
+
+/*
+Guard decides if a transition may happen. Returning an error rejects the
+transition with a *GuardError holding it, so the error should explain
+why, such as "the order has not been paid"
+*/
+type Guard func(ctx context.Context, change Change) error
+
+/*
+Hook runs before or after a transition. An error from a before hook
+stops the transition
+*/
+type Hook func(ctx context.Context, change Change) error
+
+/*
+Transition moves an entity from any of the From states to To when Event
+fires. Guards must all pass for it to happen. Before hooks run before
+the state is changed, and After hooks once it has been
+*/
+type Transition struct {
+	After  []Hook
+	Before []Hook
+	Event  Event
+	From   []State
+	Guards []Guard
+	To     State
+}
+
+/*
+InvalidTransitionError is returned when an event can't fire from an
+entity's current state. It matches ErrInvalidTransition with errors.Is
+*/
+type InvalidTransitionError struct {
+	EntityID string
+	Event    Event
+	Machine  string
+	State    State
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("%s %s can't %s from %s: %s", e.Machine, e.EntityID, e.Event, e.State, ErrInvalidTransition.Error())
+}
+
+/*
+Is makes errors.Is(err, ErrInvalidTransition) true
+*/
+func (e *InvalidTransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+/*
+GuardError is returned when a guard rejects a transition. It matches
+ErrGuardRejected with errors.Is, and unwraps to the guard's error
+*/
+type GuardError struct {
+	EntityID string
+	Err      error
+	Event    Event
+	Machine  string
+	State    State
+}
+
+func (e *GuardError) Error() string {
+	return fmt.Sprintf("%s %s can't %s from %s: %s", e.Machine, e.EntityID, e.Event, e.State, e.Err.Error())
+}
+
+/*
+Is makes errors.Is(err, ErrGuardRejected) true
+*/
+func (e *GuardError) Is(target error) bool {
+	return target == ErrGuardRejected
+}
+
+func (e *GuardError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package geo
+
+import (
+	"math"
+)
+
+/*
+BoundingBox is the area between two latitudes and two longitudes. When
+the box crosses the antimeridian, at 180 degrees, MinLon is greater
+than MaxLon
+*/
+type BoundingBox struct {
+	MaxLat float64 `json:"maxLat"`
+	MaxLon float64 `json:"maxLon"`
+	MinLat float64 `json:"minLat"`
+	MinLon float64 `json:"minLon"`
+}
+
+/*
+BoundingBoxAround returns the smallest box holding every point within
+radius meters of center. Searching the box first, which indexes on
+latitude and longitude can do, then checking the distance, is much
+faster than working out the distance to every row. Boxes reaching a
+pole cover every longitude
+*/
+func BoundingBoxAround(center Point, radius float64) BoundingBox {
+	angular := radius / EarthRadius
+	lat := radians(center.Lat)
+
+	minLat := lat - angular
+	maxLat := lat + angular
+
+	if minLat <= -math.Pi/2 || maxLat >= math.Pi/2 {
+		return BoundingBox{
+			MaxLat: math.Min(degrees(maxLat), 90),
+			MaxLon: 180,
+			MinLat: math.Max(degrees(minLat), -90),
+			MinLon: -180,
+		}
+	}
+
+	deltaLon := math.Asin(math.Sin(angular) / math.Cos(lat))
+
+	return BoundingBox{
+		MaxLat: degrees(maxLat),
+		MaxLon: normalizeLon(center.Lon + degrees(deltaLon)),
+		MinLat: degrees(minLat),
+		MinLon: normalizeLon(center.Lon - degrees(deltaLon)),
+	}
+}
+
+/*
+Contains reports whether a point is in the box
+*/
+func (b BoundingBox) Contains(p Point) bool {
+	if p.Lat < b.MinLat || p.Lat > b.MaxLat {
+		return false
+	}
+
+	if b.CrossesAntimeridian() {
+		return p.Lon >= b.MinLon || p.Lon <= b.MaxLon
+	}
+
+	return p.Lon >= b.MinLon && p.Lon <= b.MaxLon
+}
+
+/*
+CrossesAntimeridian reports whether the box wraps around 180 degrees of
+longitude
+*/
+func (b BoundingBox) CrossesAntimeridian() bool {
+	return b.MinLon > b.MaxLon
+}
+
+/*
+Center returns the point in the middle of the box
+*/
+func (b BoundingBox) Center() Point {
+	lon := (b.MinLon + b.MaxLon) / 2
+
+	if b.CrossesAntimeridian() {
+		lon = normalizeLon(lon + 180)
+	}
+
+	return Point{Lat: (b.MinLat + b.MaxLat) / 2, Lon: lon}
+}
+
+func normalizeLon(lon float64) float64 {
+	for lon > 180 {
+		lon -= 360
+	}
+
+	for lon < -180 {
+		lon += 360
+	}
+
+	return lon
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package geo_test
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/geo"
+)
+
+var (
+	london = geo.Point{Lat: 51.5007, Lon: -0.1246}
+	paris  = geo.Point{Lat: 48.8584, Lon: 2.2945}
+)
+
+func TestParsePoint(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected geo.Point
+		err      error
+	}{
+		{input: "51.5007,-0.1246", expected: london},
+		{input: " 48.8584 , 2.2945 ", expected: paris},
+		{input: "91,0", err: geo.ErrInvalidLatitude},
+		{input: "0,-181", err: geo.ErrInvalidLongitude},
+		{input: "51.5", err: geo.ErrInvalidPoint},
+		{input: "north,south", err: geo.ErrInvalidPoint},
+	}
+
+	for _, test := range tests {
+		actual, err := geo.ParsePoint(test.input)
+
+		if !errors.Is(err, test.err) || (err == nil && actual != test.expected) {
+			t.Errorf("Expected %v %v for %s but got %v %v", test.expected, test.err, test.input, actual, err)
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        geo.Point
+		b        geo.Point
+		expected float64
+	}{
+		{name: "London to Paris", a: london, b: paris, expected: 340.5 * geo.Kilometer},
+		{name: "Same point", a: london, b: london, expected: 0},
+		{name: "Across the antimeridian", a: geo.Point{Lat: 0, Lon: 179.5}, b: geo.Point{Lat: 0, Lon: -179.5}, expected: 111.2 * geo.Kilometer},
+		{name: "Pole to pole", a: geo.Point{Lat: 90}, b: geo.Point{Lat: -90}, expected: math.Pi * geo.EarthRadius},
+	}
+
+	for _, test := range tests {
+		if actual := geo.Distance(test.a, test.b); math.Abs(actual-test.expected) > 500 {
+			t.Errorf("Expected %s to be %.0fm but got %.0fm", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestBoundingBoxAround(t *testing.T) {
+	tests := []struct {
+		name    string
+		center  geo.Point
+		radius  float64
+		crosses bool
+	}{
+		{name: "London", center: london, radius: 10 * geo.Kilometer},
+		{name: "Fiji", center: geo.Point{Lat: -17.7, Lon: 179.9}, radius: 50 * geo.Kilometer, crosses: true},
+		{name: "North Pole", center: geo.Point{Lat: 89.99, Lon: 10}, radius: 10 * geo.Kilometer},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			box := geo.BoundingBoxAround(test.center, test.radius)
+
+			if box.CrossesAntimeridian() != test.crosses {
+				t.Errorf("Expected crossing the antimeridian %v but got %v", test.crosses, box)
+			}
+
+			/*
+			 * Points on the circle must be in the box
+			 */
+			for bearing := 0.0; bearing < 360; bearing += 15 {
+				point := destination(test.center, bearing, test.radius*0.999)
+
+				if !box.Contains(point) {
+					t.Errorf("Expected %v, %.0f degrees from the center, to be in %v", point, bearing, box)
+				}
+			}
+
+			if box.Contains(destination(test.center, 180, test.radius*1.1)) {
+				t.Errorf("Expected a point past the radius to be outside %v", box)
+			}
+		})
+	}
+}
+
+func TestGeohash(t *testing.T) {
+	point := geo.Point{Lat: 57.64911, Lon: 10.40744}
+
+	if actual := geo.EncodeGeohash(point, 11); actual != "u4pruydqqvj" {
+		t.Errorf("Expected u4pruydqqvj but got %s", actual)
+	}
+
+	box, err := geo.DecodeGeohash("u4pruydqqvj")
+
+	if err != nil || !box.Contains(point) || geo.Distance(box.Center(), point) > 1 {
+		t.Errorf("Expected the cell to hold the point but got %v %v", box, err)
+	}
+
+	if _, err = geo.DecodeGeohash("u4pa"); !errors.Is(err, geo.ErrInvalidGeohash) {
+		t.Errorf("Expected ErrInvalidGeohash but got %v", err)
+	}
+}
+
+func TestWithinSQL(t *testing.T) {
+	where, args := geo.WithinSQL("s.lat", "s.lon", geo.Point{Lat: -17.7, Lon: 179.9}, 50*geo.Kilometer)
+
+	if !strings.HasPrefix(where, "(s.lat BETWEEN ? AND ? AND (s.lon >= ? OR s.lon <= ?) AND 12742017.6 * ASIN(") {
+		t.Errorf("Unexpected condition %s", where)
+	}
+
+	if strings.Count(where, "?") != len(args) || args[len(args)-1] != 50*geo.Kilometer {
+		t.Errorf("Expected an argument for each placeholder but got %d for %s", len(args), where)
+	}
+}
+
+/*
+destination returns the point distance meters from start along bearing
+*/
+func destination(start geo.Point, bearing, distance float64) geo.Point {
+	angular := distance / geo.EarthRadius
+	lat := start.Lat * math.Pi / 180
+	lon := start.Lon * math.Pi / 180
+	theta := bearing * math.Pi / 180
+
+	lat2 := math.Asin(math.Sin(lat)*math.Cos(angular) + math.Cos(lat)*math.Sin(angular)*math.Cos(theta))
+	lon2 := lon + math.Atan2(math.Sin(theta)*math.Sin(angular)*math.Cos(lat), math.Cos(angular)-math.Sin(lat)*math.Sin(lat2))
+	lon2 = math.Mod(lon2+3*math.Pi, 2*math.Pi) - math.Pi
+
+	return geo.Point{Lat: lat2 * 180 / math.Pi, Lon: lon2 * 180 / math.Pi}
+}
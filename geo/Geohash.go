@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidGeohash = errors.New("invalid geohash")
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+/*
+EncodeGeohash returns the point's geohash with precision characters,
+from 1 to 12. Each character narrows the cell, from about 5,000km
+across at 1 character to 5km at 5, 150m at 7, and under 4cm at 12.
+Points whose geohashes share a prefix are close, so geohashes make
+proximity searchable with an ordinary index
+*/
+func EncodeGeohash(p Point, precision int) string {
+	if precision < 1 {
+		precision = 1
+	}
+
+	if precision > 12 {
+		precision = 12
+	}
+
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+
+	result := strings.Builder{}
+	bits := 0
+	character := 0
+	even := true
+
+	for result.Len() < precision {
+		if even {
+			character = character<<1 | bisect(&lonRange, p.Lon)
+		} else {
+			character = character<<1 | bisect(&latRange, p.Lat)
+		}
+
+		even = !even
+		bits++
+
+		if bits == 5 {
+			result.WriteByte(geohashAlphabet[character])
+			bits = 0
+			character = 0
+		}
+	}
+
+	return result.String()
+}
+
+/*
+DecodeGeohash returns the cell a geohash covers. Use its Center for a
+single point
+*/
+func DecodeGeohash(hash string) (BoundingBox, error) {
+	if hash == "" {
+		return BoundingBox{}, ErrInvalidGeohash
+	}
+
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+	even := true
+
+	for _, r := range strings.ToLower(hash) {
+		value := strings.IndexRune(geohashAlphabet, r)
+
+		if value < 0 {
+			return BoundingBox{}, fmt.Errorf("%w: %q has %q", ErrInvalidGeohash, hash, r)
+		}
+
+		for bit := 4; bit >= 0; bit-- {
+			target := &latRange
+
+			if even {
+				target = &lonRange
+			}
+
+			middle := (target[0] + target[1]) / 2
+
+			if value>>bit&1 == 1 {
+				target[0] = middle
+			} else {
+				target[1] = middle
+			}
+
+			even = !even
+		}
+	}
+
+	return BoundingBox{MaxLat: latRange[1], MaxLon: lonRange[1], MinLat: latRange[0], MinLon: lonRange[0]}, nil
+}
+
+/*
+bisect halves a range toward value, returning 1 when value is in the
+upper half
+*/
+func bisect(valueRange *[2]float64, value float64) int {
+	middle := (valueRange[0] + valueRange[1]) / 2
+
+	if value >= middle {
+		valueRange[0] = middle
+		return 1
+	}
+
+	valueRange[1] = middle
+	return 0
+}
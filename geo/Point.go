@@ -0,0 +1,121 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidLatitude  = errors.New("latitude must be between -90 and 90")
+	ErrInvalidLongitude = errors.New("longitude must be between -180 and 180")
+	ErrInvalidPoint     = errors.New("invalid point")
+)
+
+const (
+	/*
+		EarthRadius is the Earth's mean radius in meters
+	*/
+	EarthRadius = 6371008.8
+
+	Meter     = 1.0
+	Kilometer = 1000.0
+	Mile      = 1609.344
+)
+
+/*
+Point is a location in degrees of latitude and longitude, using WGS 84
+as GPS does
+*/
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+/*
+NewPoint creates a point, checking it is on the globe
+*/
+func NewPoint(lat, lon float64) (Point, error) {
+	result := Point{Lat: lat, Lon: lon}
+	return result, result.Validate()
+}
+
+/*
+ParsePoint parses a point written as "lat,lon", such as
+"51.5007,-0.1246"
+*/
+func ParsePoint(s string) (Point, error) {
+	parts := strings.Split(s, ",")
+
+	if len(parts) != 2 {
+		return Point{}, fmt.Errorf("%w: %q is not lat,lon", ErrInvalidPoint, s)
+	}
+
+	lat, latErr := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	lon, lonErr := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+
+	if latErr != nil || lonErr != nil {
+		return Point{}, fmt.Errorf("%w: %q is not lat,lon", ErrInvalidPoint, s)
+	}
+
+	return NewPoint(lat, lon)
+}
+
+/*
+Validate checks the latitude is between -90 and 90 and the longitude
+between -180 and 180
+*/
+func (p Point) Validate() error {
+	if math.IsNaN(p.Lat) || p.Lat < -90 || p.Lat > 90 {
+		return ErrInvalidLatitude
+	}
+
+	if math.IsNaN(p.Lon) || p.Lon < -180 || p.Lon > 180 {
+		return ErrInvalidLongitude
+	}
+
+	return nil
+}
+
+/*
+DistanceTo returns the distance to another point in meters
+*/
+func (p Point) DistanceTo(other Point) float64 {
+	return Distance(p, other)
+}
+
+/*
+String returns the point as "lat,lon"
+*/
+func (p Point) String() string {
+	return strconv.FormatFloat(p.Lat, 'f', -1, 64) + "," + strconv.FormatFloat(p.Lon, 'f', -1, 64)
+}
+
+/*
+Distance returns the great-circle distance between two points in
+meters, using the haversine formula. Treating the Earth as a sphere is
+accurate to about 0.5%, plenty for "find nearby"
+*/
+func Distance(a, b Point) float64 {
+	lat1 := radians(a.Lat)
+	lat2 := radians(b.Lat)
+	dLat := radians(b.Lat - a.Lat)
+	dLon := radians(b.Lon - a.Lon)
+
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * EarthRadius * math.Asin(math.Sqrt(math.Min(h, 1)))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func degrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
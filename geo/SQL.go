@@ -0,0 +1,60 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package geo
+
+import (
+	"strconv"
+)
+
+/*
+DistanceSQL returns a SQL expression for the distance in meters from
+the point in the latitude and longitude columns to center, using the
+haversine formula, and its arguments. Placeholders are ?. It uses
+functions PostgreSQL, MySQL, and SQL Server all have
+
+	distance, args := geo.DistanceSQL("s.lat", "s.lon", center)
+	query := "SELECT s.id, " + distance + " AS distance FROM stores s ORDER BY distance"
+*/
+func DistanceSQL(latColumn, lonColumn string, center Point) (string, []interface{}) {
+	expression := strconv.FormatFloat(2*EarthRadius, 'f', -1, 64) + " * ASIN(SQRT(" +
+		"POWER(SIN(RADIANS(" + latColumn + " - ?) / 2), 2) + " +
+		"COS(RADIANS(?)) * COS(RADIANS(" + latColumn + ")) * " +
+		"POWER(SIN(RADIANS(" + lonColumn + " - ?) / 2), 2)))"
+
+	return expression, []interface{}{center.Lat, center.Lat, center.Lon}
+}
+
+/*
+BoundingBoxSQL returns a SQL condition selecting points in the box, and
+its arguments. It can use indexes on the columns
+*/
+func BoundingBoxSQL(latColumn, lonColumn string, box BoundingBox) (string, []interface{}) {
+	lonCondition := lonColumn + " BETWEEN ? AND ?"
+
+	if box.CrossesAntimeridian() {
+		lonCondition = "(" + lonColumn + " >= ? OR " + lonColumn + " <= ?)"
+	}
+
+	return latColumn + " BETWEEN ? AND ? AND " + lonCondition, []interface{}{box.MinLat, box.MaxLat, box.MinLon, box.MaxLon}
+}
+
+/*
+WithinSQL returns a SQL condition selecting points within radius meters
+of center, and its arguments. The bounding box is checked first, so
+indexes narrow the rows before the distance is worked out
+
+	where, args := geo.WithinSQL("s.lat", "s.lon", center, 5*geo.Kilometer)
+	query := "SELECT s.id FROM stores s WHERE s.open = ? AND " + where
+	rows, err := db.Query(query, append([]interface{}{true}, args...)...)
+*/
+func WithinSQL(latColumn, lonColumn string, center Point, radius float64) (string, []interface{}) {
+	boxCondition, args := BoundingBoxSQL(latColumn, lonColumn, BoundingBoxAround(center, radius))
+	distance, distanceArgs := DistanceSQL(latColumn, lonColumn, center)
+
+	args = append(args, distanceArgs...)
+	args = append(args, radius)
+
+	return "(" + boxCondition + " AND " + distance + " <= ?)", args
+}
@@ -3,10 +3,15 @@ module github.com/ResurgenceIT/kit/v6
 go 1.16
 
 require (
+	github.com/BurntSushi/toml v1.3.2
+	github.com/alicebob/miniredis/v2 v2.30.0
+	github.com/andybalholm/brotli v1.0.4
 	github.com/app-nerds/fireplace/v2 v2.0.2
 	github.com/dustin/go-humanize v1.0.0
 	github.com/globalsign/mgo v0.0.0-20181015135952-eeefdecb41b8
+	github.com/go-redis/redis/v8 v8.11.4
 	github.com/golang-jwt/jwt v3.2.2+incompatible
+	github.com/gorilla/websocket v1.4.2
 	github.com/kr/pretty v0.2.0 // indirect
 	github.com/labstack/echo/v4 v4.6.3
 	github.com/mattn/go-colorable v0.1.12 // indirect
@@ -15,10 +20,13 @@ require (
 	github.com/oliamb/cutter v0.2.2
 	github.com/shirou/gopsutil v2.18.12+incompatible
 	github.com/sirupsen/logrus v1.8.1
+	github.com/vmihailenco/msgpack/v5 v5.3.5
 	golang.org/x/crypto v0.0.0-20220112180741-5e0467b6c7ce
-	golang.org/x/net v0.0.0-20220114011407-0dd24b26b47d // indirect
+	golang.org/x/net v0.0.0-20220114011407-0dd24b26b47d
 	golang.org/x/sys v0.0.0-20220114195835-da31bd327af9 // indirect
+	golang.org/x/text v0.3.7
 	gopkg.in/alexcesaro/quotedprintable.v3 v3.0.0-20150716171945-2caba252f4dc // indirect
 	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
 	gopkg.in/gomail.v2 v2.0.0-20160411212932-81ebce5c23df
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,223 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/requestid"
+	"github.com/ResurgenceIT/kit/v6/retry"
+)
+
+/*
+Client is an HTTP client with timeouts, retries, per-host circuit
+breakers, request signing, and per-host stats. Its Do method matches
+restclient.HTTPClientInterface, so it can be used anywhere an
+*http.Client is
+*/
+type Client struct {
+	client  *http.Client
+	config  ClientConfig
+	retrier *retry.Retrier
+	stats   *hostStatsTracker
+}
+
+/*
+NewClient creates a Client, filling in defaults for anything not set in
+config
+*/
+func NewClient(config ClientConfig) *Client {
+	if config.Name == "" {
+		config.Name = "httpclient"
+	}
+
+	if config.Timeout <= 0 {
+		config.Timeout = time.Second * 30
+	}
+
+	if config.Transport == nil {
+		config.Transport = &http.Transport{
+			DialContext: (&net.Dialer{
+				KeepAlive: time.Second * 30,
+				Timeout:   time.Second * 5,
+			}).DialContext,
+			ExpectContinueTimeout: time.Second,
+			ForceAttemptHTTP2:     true,
+			IdleConnTimeout:       time.Second * 90,
+			MaxIdleConnsPerHost:   10,
+			Proxy:                 http.ProxyFromEnvironment,
+			ResponseHeaderTimeout: config.Timeout,
+			TLSHandshakeTimeout:   time.Second * 5,
+		}
+	}
+
+	if config.Retry.RetryIf == nil {
+		config.Retry.RetryIf = retry.RetryOnStatus()
+	}
+
+	return &Client{
+		client: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: config.Transport,
+		},
+		config:  config,
+		retrier: retry.NewRetrier(config.Retry),
+		stats:   newHostStatsTracker(),
+	}
+}
+
+/*
+Do sends a request, retrying, signing, and tracking it as configured.
+Like http.Client, a response with an unsuccessful status is not an
+error. When retries run out on a retryable status, the last response is
+returned
+*/
+func (c *Client) Do(request *http.Request) (*http.Response, error) {
+	var (
+		body []byte
+		err  error
+		last *http.Response
+	)
+
+	if request.Body != nil && request.Body != http.NoBody {
+		body, err = ioutil.ReadAll(request.Body)
+		request.Body.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("error reading request body: %w", err)
+		}
+	}
+
+	host := request.URL.Host
+	attempts := 0
+
+	attempt := func(ctx context.Context) error {
+		attempts++
+
+		if attempts > 1 {
+			c.stats.retry(host)
+		}
+
+		if last != nil {
+			discard(last)
+			last = nil
+		}
+
+		response, err := c.send(ctx, request, body)
+
+		if err != nil {
+			return err
+		}
+
+		last = response
+		return retry.CheckResponse(response)
+	}
+
+	ctx := request.Context()
+
+	if c.config.DisableRetry || !isIdempotent(request) {
+		err = attempt(ctx)
+	} else {
+		err = c.retrier.Do(ctx, attempt)
+	}
+
+	var statusErr *retry.StatusError
+
+	if last != nil && (err == nil || errors.As(err, &statusErr)) {
+		return last, nil
+	}
+
+	if last != nil {
+		discard(last)
+	}
+
+	return nil, err
+}
+
+/*
+send makes a single attempt at a request
+*/
+func (c *Client) send(ctx context.Context, request *http.Request, body []byte) (*http.Response, error) {
+	var err error
+
+	host := request.URL.Host
+	attemptRequest := request.Clone(ctx)
+
+	if body != nil {
+		attemptRequest.Body = ioutil.NopCloser(bytes.NewReader(body))
+		attemptRequest.ContentLength = int64(len(body))
+	}
+
+	for key, values := range c.config.Headers {
+		if _, ok := attemptRequest.Header[key]; !ok {
+			attemptRequest.Header[key] = values
+		}
+	}
+
+	if id := requestid.FromContext(ctx); id != "" && attemptRequest.Header.Get(requestid.Header) == "" {
+		attemptRequest.Header.Set(requestid.Header, id)
+	}
+
+	if c.config.Signer != nil {
+		if err = c.config.Signer.Sign(attemptRequest, body); err != nil {
+			return nil, retry.Permanent(fmt.Errorf("error signing request: %w", err))
+		}
+	}
+
+	done := func(err error) {}
+
+	if c.config.Breakers != nil {
+		if done, err = c.config.Breakers.Get(host).Allow(); err != nil {
+			c.stats.reject(host)
+			return nil, retry.Permanent(fmt.Errorf("error calling %s: %w", host, err))
+		}
+	}
+
+	start := time.Now()
+	response, err := c.client.Do(attemptRequest)
+	c.stats.record(host, response, err, time.Since(start))
+
+	if err != nil {
+		done(err)
+		return nil, err
+	}
+
+	if response.StatusCode >= 500 {
+		done(retry.CheckResponse(response))
+	} else {
+		done(nil)
+	}
+
+	return response, nil
+}
+
+/*
+isIdempotent reports whether a request is safe to send more than once
+*/
+func isIdempotent(request *http.Request) bool {
+	switch request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return request.Header.Get("Idempotency-Key") != ""
+}
+
+/*
+discard reads a little of a response body before closing it, so the
+connection can be reused
+*/
+func discard(response *http.Response) {
+	_, _ = io.CopyN(ioutil.Discard, response.Body, 4096)
+	response.Body.Close()
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package httpclient
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/breaker"
+	"github.com/ResurgenceIT/kit/v6/retry"
+)
+
+/*
+ClientConfig configures a Client.
+
+BaseURL is prefixed to relative paths passed to the JSON methods.
+Headers are added to every request. Timeout bounds each attempt, and
+defaults to 30 seconds. Transport defaults to one with connect, TLS
+handshake, and response header timeouts, so a stalled server can't hold
+a request open forever.
+
+Retry configures retries. RetryIf defaults to retry.RetryOnStatus, so
+network errors and temporary statuses are retried. Only requests that
+are safe to repeat are retried: GET, HEAD, OPTIONS, PUT, DELETE, and
+anything with an Idempotency-Key header. Set DisableRetry to make a
+single attempt.
+
+When Breakers is set, each host gets its own circuit breaker from the
+registry, and network errors and 5xx responses count as failures.
+
+Signer signs each attempt, such as with an HMACSigner or BearerSigner.
+A request ID in the request's context, from the requestid middleware,
+is forwarded in the X-Request-ID header.
+Name identifies the client's stats in serverstats, and defaults to
+"httpclient"
+*/
+type ClientConfig struct {
+	BaseURL      string
+	Breakers     *breaker.Registry
+	DisableRetry bool
+	Headers      http.Header
+	Name         string
+	Retry        retry.RetrierConfig
+	Signer       ISigner
+	Timeout      time.Duration
+	Transport    http.RoundTripper
+}
@@ -0,0 +1,212 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package httpclient_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/breaker"
+	"github.com/ResurgenceIT/kit/v6/httpclient"
+	"github.com/ResurgenceIT/kit/v6/retry"
+)
+
+type widget struct {
+	Name string `json:"name"`
+}
+
+type staticTokens string
+
+func (s staticTokens) Token(ctx context.Context) (string, error) {
+	return string(s), nil
+}
+
+func TestClientRetries(t *testing.T) {
+	tests := []struct {
+		name             string
+		method           string
+		headers          map[string]string
+		statuses         []int
+		expectedStatus   int
+		expectedRequests int
+	}{
+		{name: "GET retried until success", method: http.MethodGet, statuses: []int{503, 502, 200}, expectedStatus: 200, expectedRequests: 3},
+		{name: "GET returns last response when retries run out", method: http.MethodGet, statuses: []int{503, 503, 503, 200}, expectedStatus: 503, expectedRequests: 3},
+		{name: "GET not retried on 404", method: http.MethodGet, statuses: []int{404, 200}, expectedStatus: 404, expectedRequests: 1},
+		{name: "POST not retried", method: http.MethodPost, statuses: []int{503, 200}, expectedStatus: 503, expectedRequests: 1},
+		{name: "POST with Idempotency-Key retried", method: http.MethodPost, headers: map[string]string{"Idempotency-Key": "abc"}, statuses: []int{503, 200}, expectedStatus: 200, expectedRequests: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+
+				if r.Method == http.MethodPost && string(body) != "payload" {
+					t.Errorf("Expected body 'payload' on attempt %d but got '%s'", requests+1, body)
+				}
+
+				w.WriteHeader(test.statuses[requests])
+				requests++
+			}))
+			defer server.Close()
+
+			client := httpclient.NewClient(httpclient.ClientConfig{
+				Retry: retry.RetrierConfig{InitialInterval: time.Millisecond, MaxAttempts: 3},
+			})
+
+			var body io.Reader
+
+			if test.method == http.MethodPost {
+				body = strings.NewReader("payload")
+			}
+
+			request, _ := http.NewRequest(test.method, server.URL, body)
+
+			for key, value := range test.headers {
+				request.Header.Set(key, value)
+			}
+
+			response, err := client.Do(request)
+
+			if err != nil {
+				t.Fatalf("Expected no error but got '%v'", err)
+			}
+
+			response.Body.Close()
+
+			if response.StatusCode != test.expectedStatus {
+				t.Errorf("Expected status %d but got %d", test.expectedStatus, response.StatusCode)
+			}
+
+			if requests != test.expectedRequests {
+				t.Errorf("Expected %d requests but got %d", test.expectedRequests, requests)
+			}
+
+			host := server.Listener.Addr().String()
+
+			if stats := client.Stats()[host]; stats.Requests != uint64(test.expectedRequests) || stats.Retries != uint64(test.expectedRequests-1) {
+				t.Errorf("Expected %d requests and %d retries in stats but got %+v", test.expectedRequests, test.expectedRequests-1, stats)
+			}
+		})
+	}
+}
+
+func TestClientJSON(t *testing.T) {
+	secret := []byte("secret")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		timestamp := r.Header.Get("X-Signature-Timestamp")
+
+		if r.Header.Get("X-Signature") != httpclient.Signature(secret, timestamp, r.Method, r.URL.EscapedPath(), body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"not found"}`))
+			return
+		}
+
+		in := widget{}
+		_ = json.Unmarshal(body, &in)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(widget{Name: in.Name + " saved"})
+	}))
+	defer server.Close()
+
+	client := httpclient.NewClient(httpclient.ClientConfig{
+		BaseURL: server.URL + "/api/",
+		Signer:  httpclient.HMACSigner{Secret: secret},
+	})
+
+	out := widget{}
+
+	if err := client.Post(context.Background(), "/widgets", widget{Name: "sprocket"}, &out); err != nil {
+		t.Fatalf("Expected no error but got '%v'", err)
+	}
+
+	if out.Name != "sprocket saved" {
+		t.Errorf("Expected 'sprocket saved' but got '%s'", out.Name)
+	}
+
+	err := client.Get(context.Background(), server.URL+"/missing", &out)
+
+	var responseErr *httpclient.ResponseError
+
+	if !errors.As(err, &responseErr) || responseErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected a 404 ResponseError but got '%v'", err)
+	}
+
+	message := map[string]string{}
+
+	if err = responseErr.Decode(&message); err != nil || message["message"] != "not found" {
+		t.Errorf("Expected error body to decode but got %v (%v)", message, err)
+	}
+}
+
+func TestClientBearerSigner(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	client := httpclient.NewClient(httpclient.ClientConfig{
+		Signer: httpclient.BearerSigner{Tokens: staticTokens("token")},
+	})
+
+	if err := client.Get(context.Background(), server.URL, nil); err != nil {
+		t.Errorf("Expected no error but got '%v'", err)
+	}
+}
+
+func TestClientBreaker(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := httpclient.NewClient(httpclient.ClientConfig{
+		Breakers:     breaker.NewRegistry("hosts", breaker.BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute}),
+		DisableRetry: true,
+	})
+
+	for index := 0; index < 3; index++ {
+		_ = client.Get(context.Background(), server.URL, nil)
+	}
+
+	err := client.Get(context.Background(), server.URL, nil)
+
+	if !errors.Is(err, breaker.ErrOpen) {
+		t.Errorf("Expected breaker.ErrOpen but got '%v'", err)
+	}
+
+	if requests != 2 {
+		t.Errorf("Expected 2 requests to reach the server but got %d", requests)
+	}
+
+	host, _ := url.Parse(server.URL)
+
+	if stats := client.Stats()[host.Host]; stats.Rejected != 2 || stats.Statuses["5xx"] != 2 {
+		t.Errorf("Expected 2 rejected and 2 5xx requests but got %+v", stats)
+	}
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+/*
+ResponseError is returned by the JSON methods for responses with a
+status of 300 or above. Body holds the response body, which can be
+decoded with Decode
+*/
+type ResponseError struct {
+	Body       []byte
+	StatusCode int
+}
+
+func (e *ResponseError) Error() string {
+	body := string(e.Body)
+
+	if len(body) > 200 {
+		body = body[:200] + "..."
+	}
+
+	return fmt.Sprintf("unexpected HTTP status %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), body)
+}
+
+/*
+Decode unmarshals the JSON response body into v
+*/
+func (e *ResponseError) Decode(v interface{}) error {
+	return json.Unmarshal(e.Body, v)
+}
+
+/*
+Get sends a GET request and decodes the JSON response into out
+*/
+func (c *Client) Get(ctx context.Context, path string, out interface{}) error {
+	return c.JSON(ctx, http.MethodGet, path, nil, out)
+}
+
+/*
+Post sends body as JSON in a POST request and decodes the JSON response
+into out
+*/
+func (c *Client) Post(ctx context.Context, path string, body, out interface{}) error {
+	return c.JSON(ctx, http.MethodPost, path, body, out)
+}
+
+/*
+Put sends body as JSON in a PUT request and decodes the JSON response
+into out
+*/
+func (c *Client) Put(ctx context.Context, path string, body, out interface{}) error {
+	return c.JSON(ctx, http.MethodPut, path, body, out)
+}
+
+/*
+Patch sends body as JSON in a PATCH request and decodes the JSON
+response into out
+*/
+func (c *Client) Patch(ctx context.Context, path string, body, out interface{}) error {
+	return c.JSON(ctx, http.MethodPatch, path, body, out)
+}
+
+/*
+Delete sends a DELETE request and decodes the JSON response into out
+*/
+func (c *Client) Delete(ctx context.Context, path string, out interface{}) error {
+	return c.JSON(ctx, http.MethodDelete, path, nil, out)
+}
+
+/*
+JSON sends a request with body encoded as JSON, and decodes the JSON
+response into out. A nil body sends no body, and a nil out ignores the
+response. path is joined to BaseURL unless it is an absolute URL.
+Responses with a status of 300 or above return a *ResponseError
+*/
+func (c *Client) JSON(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+
+	if body != nil {
+		b, err := json.Marshal(body)
+
+		if err != nil {
+			return fmt.Errorf("error encoding request body: %w", err)
+		}
+
+		reader = bytes.NewReader(b)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, c.url(path), reader)
+
+	if err != nil {
+		return fmt.Errorf("error creating HTTP request: %w", err)
+	}
+
+	request.Header.Set("Accept", "application/json")
+
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := c.Do(request)
+
+	if err != nil {
+		return fmt.Errorf("error executing request: %w", err)
+	}
+
+	defer response.Body.Close()
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+
+	if err != nil {
+		return fmt.Errorf("error reading response body: %w", err)
+	}
+
+	if response.StatusCode > 299 {
+		return &ResponseError{Body: responseBody, StatusCode: response.StatusCode}
+	}
+
+	if out == nil || len(bytes.TrimSpace(responseBody)) == 0 {
+		return nil
+	}
+
+	if err = json.Unmarshal(responseBody, out); err != nil {
+		return fmt.Errorf("error decoding response body: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) url(path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") || c.config.BaseURL == "" {
+		return path
+	}
+
+	return strings.TrimSuffix(c.config.BaseURL, "/") + "/" + strings.TrimPrefix(path, "/")
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package httpclient
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+/*
+ISigner adds authentication to an outgoing request. body is the
+request body, or nil when there isn't one. Sign is called before every
+attempt, so signatures and tokens are always fresh
+*/
+type ISigner interface {
+	Sign(request *http.Request, body []byte) error
+}
+
+/*
+ITokenSource provides access tokens, such as identity.ClientCredentials
+*/
+type ITokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+/*
+BearerSigner sets an Authorization header with a bearer token from
+Tokens
+*/
+type BearerSigner struct {
+	Tokens ITokenSource
+}
+
+/*
+Sign sets the request's Authorization header
+*/
+func (s BearerSigner) Sign(request *http.Request, body []byte) error {
+	token, err := s.Tokens.Token(request.Context())
+
+	if err != nil {
+		return fmt.Errorf("error getting bearer token: %w", err)
+	}
+
+	request.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+/*
+HMACSigner signs requests with an HMAC-SHA256 of the timestamp, method,
+path, and body, so the receiver can check the request came from someone
+holding Secret and wasn't replayed later. The hex encoded signature is
+sent in Header (default X-Signature) and the Unix timestamp in
+TimestampHeader (default X-Signature-Timestamp). The signed message is
+
+	timestamp + "\n" + method + "\n" + path + "\n" + body
+*/
+type HMACSigner struct {
+	Header          string
+	Secret          []byte
+	TimestampHeader string
+}
+
+/*
+Sign sets the signature and timestamp headers
+*/
+func (s HMACSigner) Sign(request *http.Request, body []byte) error {
+	header := s.Header
+
+	if header == "" {
+		header = "X-Signature"
+	}
+
+	timestampHeader := s.TimestampHeader
+
+	if timestampHeader == "" {
+		timestampHeader = "X-Signature-Timestamp"
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	request.Header.Set(timestampHeader, timestamp)
+	request.Header.Set(header, Signature(s.Secret, timestamp, request.Method, request.URL.EscapedPath(), body))
+	return nil
+}
+
+/*
+Signature returns the hex encoded HMAC-SHA256 an HMACSigner sends. The
+receiving side can use it to check a request's signature
+*/
+func Signature(secret []byte, timestamp, method, path string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+
+	mac.Write([]byte(timestamp + "\n" + method + "\n" + path + "\n"))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
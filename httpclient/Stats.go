@@ -0,0 +1,142 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+/*
+HostStats contains counts and latency for requests to a single host.
+Errors are requests that failed without a response, such as timeouts
+and refused connections. Rejected are requests an open circuit breaker
+stopped. Statuses counts responses by class, such as "2xx"
+*/
+type HostStats struct {
+	Errors             uint64            `json:"errors"`
+	MaxInMilliseconds  float64           `json:"maxInMilliseconds"`
+	MeanInMilliseconds float64           `json:"meanInMilliseconds"`
+	Rejected           uint64            `json:"rejected"`
+	Requests           uint64            `json:"requests"`
+	Retries            uint64            `json:"retries"`
+	Statuses           map[string]uint64 `json:"statuses"`
+}
+
+type hostStatsTracker struct {
+	sync.Mutex
+
+	hosts map[string]*hostStats
+}
+
+type hostStats struct {
+	stats HostStats
+	total time.Duration
+	max   time.Duration
+}
+
+func newHostStatsTracker() *hostStatsTracker {
+	return &hostStatsTracker{
+		hosts: make(map[string]*hostStats),
+	}
+}
+
+func (t *hostStatsTracker) get(host string) *hostStats {
+	entry, ok := t.hosts[host]
+
+	if !ok {
+		entry = &hostStats{stats: HostStats{Statuses: make(map[string]uint64)}}
+		t.hosts[host] = entry
+	}
+
+	return entry
+}
+
+func (t *hostStatsTracker) record(host string, response *http.Response, err error, duration time.Duration) {
+	t.Lock()
+	defer t.Unlock()
+
+	entry := t.get(host)
+	entry.stats.Requests++
+	entry.total += duration
+
+	if duration > entry.max {
+		entry.max = duration
+	}
+
+	if err != nil {
+		entry.stats.Errors++
+		return
+	}
+
+	entry.stats.Statuses[statusClass(response.StatusCode)]++
+}
+
+func (t *hostStatsTracker) retry(host string) {
+	t.Lock()
+	t.get(host).stats.Retries++
+	t.Unlock()
+}
+
+func (t *hostStatsTracker) reject(host string) {
+	t.Lock()
+	t.get(host).stats.Rejected++
+	t.Unlock()
+}
+
+/*
+Stats returns stats for every host the client has sent requests to
+*/
+func (c *Client) Stats() map[string]HostStats {
+	c.stats.Lock()
+	defer c.stats.Unlock()
+
+	result := make(map[string]HostStats, len(c.stats.hosts))
+
+	for host, entry := range c.stats.hosts {
+		stats := entry.stats
+		stats.Statuses = make(map[string]uint64, len(entry.stats.Statuses))
+
+		for class, count := range entry.stats.Statuses {
+			stats.Statuses[class] = count
+		}
+
+		if stats.Requests > 0 {
+			stats.MeanInMilliseconds = float64(entry.total) / float64(stats.Requests) / float64(time.Millisecond)
+		}
+
+		stats.MaxInMilliseconds = float64(entry.max) / float64(time.Millisecond)
+		result[host] = stats
+	}
+
+	return result
+}
+
+/*
+Name returns the name the client's stats are reported under
+*/
+func (c *Client) Name() string {
+	return c.config.Name
+}
+
+/*
+Collect returns per-host stats in the form serverstats collectors
+report, so a Client can be passed to RegisterCollector
+*/
+func (c *Client) Collect(ctx context.Context) map[string]interface{} {
+	result := make(map[string]interface{})
+
+	for host, stats := range c.Stats() {
+		result[host] = stats
+	}
+
+	return result
+}
+
+func statusClass(status int) string {
+	return string(rune('0'+status/100)) + "xx"
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package httpserver
+
+import (
+	"net/http"
+	"time"
+)
+
+/*
+Config holds the settings for an HTTP server. It is tagged for the kit
+config package, so it can be embedded in an application's config
+struct and loaded from files, environment variables, and flags:
+
+	type AppConfig struct {
+		Server httpserver.Config
+	}
+
+Zero values fall back to the same defaults as the tags, so a Config
+that wasn't loaded by the config package works too
+*/
+type Config struct {
+	Address           string `default:":8080" usage:"address to listen on"`
+	CORS              CORSConfig
+	IdleTimeout       time.Duration `default:"2m"`
+	MaxHeaderBytes    int           `default:"1048576"`
+	ReadHeaderTimeout time.Duration `default:"5s"`
+	ReadTimeout       time.Duration `default:"30s"`
+	ShutdownTimeout   time.Duration `default:"30s"`
+	TLS               TLSConfig
+	WriteTimeout      time.Duration `default:"60s"`
+}
+
+/*
+TLSConfig turns on HTTPS. Either provide a certificate and key file, or
+list the domains to get certificates for from Let's Encrypt with ACME.
+
+ACME certificates are cached in ACMECacheDir. When HTTPRedirectAddress
+is set (usually ":80") a second server listens there to answer ACME
+HTTP challenges and redirect everything else to HTTPS
+*/
+type TLSConfig struct {
+	ACMECacheDir        string `default:"acme-cache"`
+	ACMEDomains         []string
+	ACMEEmail           string
+	CertFile            string
+	HTTPRedirectAddress string
+	KeyFile             string
+}
+
+/*
+CORSConfig turns on CORS when AllowOrigins is set. Use "*" to allow
+every origin
+*/
+type CORSConfig struct {
+	AllowCredentials bool
+	AllowHeaders     []string
+	AllowMethods     []string
+	AllowOrigins     []string
+	ExposeHeaders    []string
+	MaxAge           int
+}
+
+/*
+Enabled reports whether HTTPS is configured
+*/
+func (c TLSConfig) Enabled() bool {
+	return len(c.ACMEDomains) > 0 || (c.CertFile != "" && c.KeyFile != "")
+}
+
+/*
+withDefaults fills in zero values with the defaults from the tags
+*/
+func (c Config) withDefaults() Config {
+	if c.Address == "" {
+		c.Address = ":8080"
+	}
+
+	if c.IdleTimeout <= 0 {
+		c.IdleTimeout = time.Minute * 2
+	}
+
+	if c.MaxHeaderBytes <= 0 {
+		c.MaxHeaderBytes = 1 << 20
+	}
+
+	if c.ReadHeaderTimeout <= 0 {
+		c.ReadHeaderTimeout = time.Second * 5
+	}
+
+	if c.ReadTimeout <= 0 {
+		c.ReadTimeout = time.Second * 30
+	}
+
+	if c.ShutdownTimeout <= 0 {
+		c.ShutdownTimeout = time.Second * 30
+	}
+
+	if c.WriteTimeout <= 0 {
+		c.WriteTimeout = time.Second * 60
+	}
+
+	if c.TLS.ACMECacheDir == "" {
+		c.TLS.ACMECacheDir = "acme-cache"
+	}
+
+	if len(c.CORS.AllowMethods) == 0 {
+		c.CORS.AllowMethods = []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodPost, http.MethodDelete}
+	}
+
+	return c
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/ResurgenceIT/kit/v6/identity"
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// ContextKeyToken is the Echo context key the parsed *jwt.Token is stored under
+	ContextKeyToken = "token"
+
+	// ContextKeyUserID is the Echo context key the token's user ID is stored under
+	ContextKeyUserID = "userID"
+
+	// ContextKeyUserName is the Echo context key the token's user name is stored under
+	ContextKeyUserName = "userName"
+)
+
+type contextKey string
+
+const identityKey contextKey = "httpserver.identity"
+
+/*
+Identity is the user a request was made by, as read from its bearer
+token
+*/
+type Identity struct {
+	Token    *jwt.Token
+	UserID   string
+	UserName string
+}
+
+/*
+NewIdentityContext returns a copy of ctx carrying identity. Use it to
+keep the user on work that outlives the request, or to act as a user in
+tests
+*/
+func NewIdentityContext(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey, identity)
+}
+
+/*
+IdentityFromContext returns the identity of the user a request was made
+by. It returns false when the request had no valid token
+*/
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	result, ok := ctx.Value(identityKey).(Identity)
+	return result, ok
+}
+
+/*
+parseIdentity reads the bearer token from a request. It returns false
+when there is no token or the token isn't valid
+*/
+func parseIdentity(jwtService identity.IJWTService, request *http.Request) (Identity, bool) {
+	authorization := request.Header.Get("Authorization")
+
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return Identity{}, false
+	}
+
+	token, err := jwtService.ParseToken(strings.TrimPrefix(authorization, "Bearer "))
+
+	if err != nil {
+		return Identity{}, false
+	}
+
+	userID, userName := jwtService.GetUserFromToken(token)
+
+	return Identity{
+		Token:    token,
+		UserID:   userID,
+		UserName: userName,
+	}, true
+}
+
+func echoIdentityMiddleware(options Options) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if options.IdentitySkipper != nil && options.IdentitySkipper(ctx) {
+				return next(ctx)
+			}
+
+			user, ok := parseIdentity(options.JWTService, ctx.Request())
+
+			if !ok {
+				if options.IdentityOptional {
+					return next(ctx)
+				}
+
+				return echo.NewHTTPError(http.StatusUnauthorized)
+			}
+
+			ctx.Set(ContextKeyToken, user.Token)
+			ctx.Set(ContextKeyUserID, user.UserID)
+			ctx.Set(ContextKeyUserName, user.UserName)
+			ctx.SetRequest(ctx.Request().WithContext(NewIdentityContext(ctx.Request().Context(), user)))
+
+			return next(ctx)
+		}
+	}
+}
+
+func httpIdentityMiddleware(options Options, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := parseIdentity(options.JWTService, r)
+
+		if !ok {
+			if options.IdentityOptional {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewIdentityContext(r.Context(), user)))
+	})
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package httpserver
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+/*
+corsMiddleware adds CORS headers to net/http responses and answers
+preflight requests
+*/
+func corsMiddleware(config CORSConfig, next http.Handler) http.Handler {
+	allowMethods := strings.Join(config.AllowMethods, ",")
+	allowHeaders := strings.Join(config.AllowHeaders, ",")
+	exposeHeaders := strings.Join(config.ExposeHeaders, ",")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		allowOrigin := corsAllowedOrigin(config.AllowOrigins, origin)
+
+		w.Header().Add("Vary", "Origin")
+
+		if origin == "" || allowOrigin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
+
+		if config.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+			if exposeHeaders != "" {
+				w.Header().Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		w.Header().Set("Access-Control-Allow-Methods", allowMethods)
+
+		if allowHeaders != "" {
+			w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+		} else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+			w.Header().Set("Access-Control-Allow-Headers", requested)
+		}
+
+		if config.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(config.MaxAge))
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func corsAllowedOrigin(allowOrigins []string, origin string) string {
+	for _, allowed := range allowOrigins {
+		if allowed == "*" {
+			return "*"
+		}
+
+		if strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+
+	return ""
+}
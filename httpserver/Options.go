@@ -0,0 +1,48 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package httpserver
+
+import (
+	"github.com/ResurgenceIT/kit/v6/identity"
+	"github.com/ResurgenceIT/kit/v6/lifecycle"
+	"github.com/ResurgenceIT/kit/v6/recovery"
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+Options wires the parts of a server together. Only Config is required.
+
+When ServerStats is set its middleware is added, the server's
+connections are instrumented, and the server is marked ready once it is
+listening. StatsPath, if set, serves the stats report. It is behind the
+identity check unless the ServerStats has a HandlerAuth, in which case
+that protects it instead.
+
+When JWTService is set, bearer tokens in the Authorization header are
+parsed and the token, user ID, and user name are stored in the request
+context. Requests without a valid token are rejected with a 401 unless
+IdentityOptional is true or IdentitySkipper returns true for them.
+
+When Lifecycle is set, draining the server is registered as a shutdown
+hook and Run waits on the lifecycle manager. Otherwise Run shuts the
+server down itself on SIGINT or SIGTERM.
+
+Panics in handlers are logged with their stack, counted by ServerStats,
+answered with a problem details 500, and sent to PanicReporter when it
+is set
+*/
+type Options struct {
+	Config           Config
+	IdentityOptional bool
+	IdentitySkipper  func(ctx echo.Context) bool
+	JWTService       identity.IJWTService
+	Lifecycle        *lifecycle.Manager
+	Logger           logrus.FieldLogger
+	PanicReporter    recovery.IReporter
+	ServerStats      *serverstats.ServerStats
+	StatsPath        string
+}
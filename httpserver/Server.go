@@ -0,0 +1,351 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package httpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ResurgenceIT/kit/v6/recovery"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+/*
+Server is an HTTP server with timeouts, TLS, stats, identity, recovery,
+CORS, and graceful shutdown wired together. Echo is nil for servers
+created with NewHTTP
+*/
+type Server struct {
+	Echo       *echo.Echo
+	HTTPServer *http.Server
+
+	config         Config
+	options        Options
+	redirectServer *http.Server
+}
+
+/*
+NewEcho creates a server backed by a new Echo instance. Register routes
+on Server.Echo, then call Run.
+
+	server, err := httpserver.NewEcho(httpserver.Options{
+		Config:      appConfig.Server,
+		Lifecycle:   shutdown,
+		ServerStats: serverStats,
+		StatsPath:   "/serverstats",
+	})
+
+	server.Echo.GET("/health", healthHandler)
+	err = server.Run()
+*/
+func NewEcho(options Options) (*Server, error) {
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+
+	config := options.Config.withDefaults()
+
+	/*
+	 * Outermost, so the stats middleware sees the panic and counts it
+	 */
+	e.Use(recovery.NewRecoverer(recovery.RecovererConfig{
+		Logger:   options.Logger,
+		Reporter: options.PanicReporter,
+	}).Middleware)
+
+	if len(config.CORS.AllowOrigins) > 0 {
+		e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+			AllowCredentials: config.CORS.AllowCredentials,
+			AllowHeaders:     config.CORS.AllowHeaders,
+			AllowMethods:     config.CORS.AllowMethods,
+			AllowOrigins:     config.CORS.AllowOrigins,
+			ExposeHeaders:    config.CORS.ExposeHeaders,
+			MaxAge:           config.CORS.MaxAge,
+		}))
+	}
+
+	if options.ServerStats != nil {
+		e.Use(options.ServerStats.Middleware)
+
+		if options.StatsPath != "" {
+			e.GET(options.StatsPath, options.ServerStats.Handler)
+		}
+	}
+
+	if options.JWTService != nil {
+		if statsHaveOwnAuth(options) {
+			statsPath := options.StatsPath
+			skipper := options.IdentitySkipper
+
+			options.IdentitySkipper = func(ctx echo.Context) bool {
+				return ctx.Path() == statsPath || (skipper != nil && skipper(ctx))
+			}
+		}
+
+		e.Use(echoIdentityMiddleware(options))
+	}
+
+	server, err := newServer(config, options, e)
+
+	if err != nil {
+		return nil, err
+	}
+
+	server.Echo = e
+	return server, nil
+}
+
+/*
+NewHTTP creates a server for a net/http handler. The handler is wrapped
+with the same recovery, CORS, identity, and stats middleware NewEcho
+adds. When StatsPath is set, requests to it are answered by the stats
+report, after the identity check unless the ServerStats has its own
+HandlerAuth
+*/
+func NewHTTP(handler http.Handler, options Options) (*Server, error) {
+	config := options.Config.withDefaults()
+	serveStats := options.ServerStats != nil && options.StatsPath != ""
+
+	if serveStats && !statsHaveOwnAuth(options) {
+		handler = withStatsRoute(options, handler)
+	}
+
+	if options.JWTService != nil {
+		handler = httpIdentityMiddleware(options, handler)
+	}
+
+	if serveStats && statsHaveOwnAuth(options) {
+		handler = withStatsRoute(options, handler)
+	}
+
+	if len(config.CORS.AllowOrigins) > 0 {
+		handler = corsMiddleware(config.CORS, handler)
+	}
+
+	/*
+	 * Inside the stats middleware, which only sees the 500, so the
+	 * recoverer counts the panic
+	 */
+	recoverer := recovery.RecovererConfig{
+		Logger:   options.Logger,
+		Reporter: options.PanicReporter,
+	}
+
+	if options.ServerStats != nil {
+		recoverer.Recorder = options.ServerStats
+	}
+
+	handler = recovery.NewRecoverer(recoverer).HTTPMiddleware(handler)
+
+	if options.ServerStats != nil {
+		handler = options.ServerStats.HTTPMiddleware(handler)
+	}
+
+	return newServer(config, options, handler)
+}
+
+/*
+statsHaveOwnAuth reports whether the stats report can skip the identity
+check. It can only when the ServerStats has a HandlerAuth, or anyone
+could read it
+*/
+func statsHaveOwnAuth(options Options) bool {
+	return options.StatsPath != "" && options.ServerStats != nil && options.ServerStats.HasHandlerAuth()
+}
+
+func withStatsRoute(options Options, handler http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(options.StatsPath, options.ServerStats)
+	mux.Handle("/", handler)
+
+	return mux
+}
+
+func newServer(config Config, options Options, handler http.Handler) (*Server, error) {
+	result := &Server{
+		HTTPServer: &http.Server{
+			Addr:              config.Address,
+			Handler:           handler,
+			IdleTimeout:       config.IdleTimeout,
+			MaxHeaderBytes:    config.MaxHeaderBytes,
+			ReadHeaderTimeout: config.ReadHeaderTimeout,
+			ReadTimeout:       config.ReadTimeout,
+			WriteTimeout:      config.WriteTimeout,
+		},
+		config:  config,
+		options: options,
+	}
+
+	if err := result.configureTLS(); err != nil {
+		return nil, err
+	}
+
+	if options.ServerStats != nil {
+		options.ServerStats.InstrumentServer(result.HTTPServer)
+	}
+
+	if options.Lifecycle != nil {
+		options.Lifecycle.RegisterWithTimeout("drain HTTP server", config.ShutdownTimeout, result.Shutdown)
+	}
+
+	return result, nil
+}
+
+func (s *Server) configureTLS() error {
+	tlsConfig := s.config.TLS
+
+	if !tlsConfig.Enabled() {
+		return nil
+	}
+
+	if len(tlsConfig.ACMEDomains) == 0 {
+		if _, err := tls.LoadX509KeyPair(tlsConfig.CertFile, tlsConfig.KeyFile); err != nil {
+			return fmt.Errorf("error loading TLS certificate: %w", err)
+		}
+
+		s.HTTPServer.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		return nil
+	}
+
+	manager := &autocert.Manager{
+		Cache:      autocert.DirCache(tlsConfig.ACMECacheDir),
+		Email:      tlsConfig.ACMEEmail,
+		HostPolicy: autocert.HostWhitelist(tlsConfig.ACMEDomains...),
+		Prompt:     autocert.AcceptTOS,
+	}
+
+	s.HTTPServer.TLSConfig = manager.TLSConfig()
+	s.HTTPServer.TLSConfig.MinVersion = tls.VersionTLS12
+
+	if tlsConfig.HTTPRedirectAddress != "" {
+		s.redirectServer = &http.Server{
+			Addr:              tlsConfig.HTTPRedirectAddress,
+			Handler:           manager.HTTPHandler(nil),
+			IdleTimeout:       s.config.IdleTimeout,
+			ReadHeaderTimeout: s.config.ReadHeaderTimeout,
+			ReadTimeout:       s.config.ReadTimeout,
+			WriteTimeout:      s.config.WriteTimeout,
+		}
+	}
+
+	return nil
+}
+
+/*
+Start listens and serves until the server is shut down. It returns
+nil after a graceful shutdown. When ServerStats is set, the server is
+marked ready once it is listening
+*/
+func (s *Server) Start() error {
+	var err error
+	var listener net.Listener
+
+	if listener, err = net.Listen("tcp", s.config.Address); err != nil {
+		return fmt.Errorf("error listening on %s: %w", s.config.Address, err)
+	}
+
+	if s.redirectServer != nil {
+		go func() {
+			if redirectErr := s.redirectServer.ListenAndServe(); redirectErr != nil && !errors.Is(redirectErr, http.ErrServerClosed) && s.options.Logger != nil {
+				s.options.Logger.WithError(redirectErr).Error("error running HTTP redirect server")
+			}
+		}()
+	}
+
+	if s.options.Logger != nil {
+		s.options.Logger.WithField("address", listener.Addr().String()).WithField("tls", s.config.TLS.Enabled()).Info("server listening")
+	}
+
+	if s.options.ServerStats != nil {
+		s.options.ServerStats.MarkReady()
+	}
+
+	if s.config.TLS.Enabled() {
+		err = s.HTTPServer.ServeTLS(listener, s.config.TLS.CertFile, s.config.TLS.KeyFile)
+	} else {
+		err = s.HTTPServer.Serve(listener)
+	}
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("error serving HTTP: %w", err)
+	}
+
+	return nil
+}
+
+/*
+Shutdown gracefully stops the server, waiting for active requests to
+finish until ctx is done
+*/
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.redirectServer != nil {
+		_ = s.redirectServer.Shutdown(ctx)
+	}
+
+	if err := s.HTTPServer.Shutdown(ctx); err != nil {
+		return fmt.Errorf("error shutting down HTTP server: %w", err)
+	}
+
+	return nil
+}
+
+/*
+Run starts the server and blocks until it has shut down. When a
+Lifecycle manager is set Run waits on it, so every registered shutdown
+hook runs. Otherwise the server is drained on SIGINT or SIGTERM,
+waiting up to ShutdownTimeout
+*/
+func (s *Server) Run() error {
+	started := make(chan error, 1)
+
+	go func() {
+		started <- s.Start()
+	}()
+
+	if s.options.Lifecycle != nil {
+		shutdown := make(chan error, 1)
+
+		go func() {
+			shutdown <- s.options.Lifecycle.Wait().Err()
+		}()
+
+		select {
+		case err := <-started:
+			if err != nil {
+				return err
+			}
+
+			return <-shutdown
+
+		case err := <-shutdown:
+			return err
+		}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case err := <-started:
+		return err
+
+	case <-signals:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+
+	return s.Shutdown(ctx)
+}
@@ -0,0 +1,363 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package httpserver_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/httpserver"
+	"github.com/ResurgenceIT/kit/v6/identity"
+	"github.com/ResurgenceIT/kit/v6/lifecycle"
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+	"github.com/labstack/echo/v4"
+)
+
+func newJWTService() (identity.JWTService, string) {
+	jwtService := identity.NewJWTService(identity.JWTServiceConfig{
+		AuthSalt:         "salt",
+		AuthSecret:       "secret",
+		Issuer:           "issuer",
+		TimeoutInMinutes: 5,
+	})
+
+	token, _ := jwtService.CreateToken(identity.CreateTokenRequest{UserID: "user", UserName: "User"})
+	return jwtService, token
+}
+
+/*
+freeAddress returns a local address nothing is listening on
+*/
+func freeAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func TestNewHTTP(t *testing.T) {
+	jwtService, token := newJWTService()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/me", func(w http.ResponseWriter, r *http.Request) {
+		user, _ := httpserver.IdentityFromContext(r.Context())
+		_, _ = w.Write([]byte(user.UserID))
+	})
+
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	server, err := httpserver.NewHTTP(mux, httpserver.Options{
+		Config: httpserver.Config{
+			CORS: httpserver.CORSConfig{AllowOrigins: []string{"https://example.com"}},
+		},
+		JWTService:  jwtService,
+		ServerStats: serverstats.NewServerStats(nil),
+		StatsPath:   "/serverstats",
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		headers        map[string]string
+		expectedStatus int
+		expectedBody   string
+		expectedHeader string
+	}{
+		{name: "Requests without a token are rejected", method: http.MethodGet, path: "/me", expectedStatus: http.StatusUnauthorized},
+		{name: "Identity is read from the token", method: http.MethodGet, path: "/me", headers: map[string]string{"Authorization": "Bearer " + token}, expectedStatus: http.StatusOK, expectedBody: "user"},
+		{name: "Stats need a token without HandlerAuth", method: http.MethodGet, path: "/serverstats", expectedStatus: http.StatusUnauthorized},
+		{name: "Stats are served with a token", method: http.MethodGet, path: "/serverstats", headers: map[string]string{"Authorization": "Bearer " + token}, expectedStatus: http.StatusOK},
+		{name: "Panics are recovered", method: http.MethodGet, path: "/panic", headers: map[string]string{"Authorization": "Bearer " + token}, expectedStatus: http.StatusInternalServerError},
+		{name: "CORS preflight requests are answered", method: http.MethodOptions, path: "/me", headers: map[string]string{"Origin": "https://example.com", "Access-Control-Request-Method": "POST"}, expectedStatus: http.StatusNoContent, expectedHeader: "https://example.com"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(test.method, test.path, nil)
+
+			for key, value := range test.headers {
+				request.Header.Set(key, value)
+			}
+
+			recorder := httptest.NewRecorder()
+			server.HTTPServer.Handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != test.expectedStatus {
+				t.Errorf("Expected status %d but got %d", test.expectedStatus, recorder.Code)
+			}
+
+			if test.expectedBody != "" && recorder.Body.String() != test.expectedBody {
+				t.Errorf("Expected '%s' but got '%s'", test.expectedBody, recorder.Body.String())
+			}
+
+			if actual := recorder.Header().Get("Access-Control-Allow-Origin"); actual != test.expectedHeader {
+				t.Errorf("Expected '%s' but got '%s'", test.expectedHeader, actual)
+			}
+		})
+	}
+}
+
+func TestNewEcho(t *testing.T) {
+	jwtService, token := newJWTService()
+
+	server, err := httpserver.NewEcho(httpserver.Options{
+		Config: httpserver.Config{
+			CORS: httpserver.CORSConfig{AllowOrigins: []string{"https://example.com"}},
+		},
+		IdentitySkipper: func(ctx echo.Context) bool {
+			return ctx.Path() == "/health"
+		},
+		JWTService:  jwtService,
+		ServerStats: serverstats.NewServerStats(nil),
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	server.Echo.GET("/me", func(ctx echo.Context) error {
+		return ctx.String(http.StatusOK, ctx.Get(httpserver.ContextKeyUserID).(string))
+	})
+
+	server.Echo.GET("/health", func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusOK)
+	})
+
+	server.Echo.GET("/panic", func(ctx echo.Context) error {
+		panic("boom")
+	})
+
+	tests := []struct {
+		name           string
+		path           string
+		headers        map[string]string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "Requests without a token are rejected", path: "/me", expectedStatus: http.StatusUnauthorized},
+		{name: "Identity is read from the token", path: "/me", headers: map[string]string{"Authorization": "Bearer " + token}, expectedStatus: http.StatusOK, expectedBody: "user"},
+		{name: "Skipped routes don't need a token", path: "/health", expectedStatus: http.StatusOK},
+		{name: "Panics are recovered", path: "/panic", headers: map[string]string{"Authorization": "Bearer " + token}, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, test.path, nil)
+
+			for key, value := range test.headers {
+				request.Header.Set(key, value)
+			}
+
+			recorder := httptest.NewRecorder()
+			server.HTTPServer.Handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != test.expectedStatus {
+				t.Errorf("Expected status %d but got %d", test.expectedStatus, recorder.Code)
+			}
+
+			if test.expectedBody != "" && recorder.Body.String() != test.expectedBody {
+				t.Errorf("Expected '%s' but got '%s'", test.expectedBody, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestStatsPathIdentity(t *testing.T) {
+	jwtService, token := newJWTService()
+
+	newServer := func(kind string, handlerAuth *serverstats.HandlerAuth) *httpserver.Server {
+		statsOptions := serverstats.DefaultServerStatsOptions()
+		statsOptions.HandlerAuth = handlerAuth
+
+		options := httpserver.Options{
+			JWTService:  jwtService,
+			ServerStats: serverstats.NewServerStatsWithOptions(statsOptions, nil),
+			StatsPath:   "/serverstats",
+		}
+
+		var (
+			server *httpserver.Server
+			err    error
+		)
+
+		if kind == "echo" {
+			server, err = httpserver.NewEcho(options)
+		} else {
+			server, err = httpserver.NewHTTP(http.NotFoundHandler(), options)
+		}
+
+		if err != nil {
+			t.Fatalf("Expected no error but got '%s'", err)
+		}
+
+		return server
+	}
+
+	apiKeyAuth := &serverstats.HandlerAuth{APIKeys: []string{"stats-key"}}
+
+	tests := []struct {
+		name           string
+		handlerAuth    *serverstats.HandlerAuth
+		headers        map[string]string
+		expectedStatus int
+	}{
+		{name: "Without HandlerAuth anonymous requests are rejected", expectedStatus: http.StatusUnauthorized},
+		{name: "Without HandlerAuth a token is required", headers: map[string]string{"Authorization": "Bearer " + token}, expectedStatus: http.StatusOK},
+		{name: "Without HandlerAuth an API key isn't enough", headers: map[string]string{"X-API-Key": "stats-key"}, expectedStatus: http.StatusUnauthorized},
+		{name: "With HandlerAuth anonymous requests are rejected", handlerAuth: apiKeyAuth, expectedStatus: http.StatusUnauthorized},
+		{name: "With HandlerAuth the API key is checked instead of a token", handlerAuth: apiKeyAuth, headers: map[string]string{"X-API-Key": "stats-key"}, expectedStatus: http.StatusOK},
+		{name: "With HandlerAuth a token alone isn't enough", handlerAuth: apiKeyAuth, headers: map[string]string{"Authorization": "Bearer " + token}, expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, kind := range []string{"echo", "net/http"} {
+		for _, test := range tests {
+			t.Run(kind+": "+test.name, func(t *testing.T) {
+				server := newServer(kind, test.handlerAuth)
+				request := httptest.NewRequest(http.MethodGet, "/serverstats", nil)
+
+				for key, value := range test.headers {
+					request.Header.Set(key, value)
+				}
+
+				recorder := httptest.NewRecorder()
+				server.HTTPServer.Handler.ServeHTTP(recorder, request)
+
+				if recorder.Code != test.expectedStatus {
+					t.Errorf("Expected status %d but got %d", test.expectedStatus, recorder.Code)
+				}
+			})
+		}
+	}
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	address := freeAddress(t)
+	requestStarted := make(chan struct{})
+	release := make(chan struct{})
+
+	server, err := httpserver.NewHTTP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requestStarted)
+		<-release
+		_, _ = w.Write([]byte("done"))
+	}), httpserver.Options{
+		Config: httpserver.Config{Address: address},
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	started := make(chan error, 1)
+	go func() { started <- server.Start() }()
+
+	responses := make(chan string, 1)
+
+	go func() {
+		var response *http.Response
+
+		for attempt := 0; attempt < 100; attempt++ {
+			if response, err = http.Get("http://" + address + "/"); err == nil {
+				break
+			}
+
+			time.Sleep(time.Millisecond * 10)
+		}
+
+		if response == nil {
+			responses <- "no response"
+			return
+		}
+
+		b, _ := ioutil.ReadAll(response.Body)
+		_ = response.Body.Close()
+		responses <- string(b)
+	}()
+
+	<-requestStarted
+
+	/*
+	 * A shutdown that runs out of time while the request is in flight
+	 * fails, and the server keeps draining
+	 */
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+
+	if err = server.Shutdown(ctx); err == nil {
+		t.Errorf("Expected an error when the in-flight request outlives the shutdown")
+	}
+
+	close(release)
+
+	if body := <-responses; body != "done" {
+		t.Errorf("Expected the in-flight request to finish but got '%s'", body)
+	}
+
+	if err = server.Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected no error but got '%s'", err)
+	}
+
+	if err = <-started; err != nil {
+		t.Errorf("Expected Start to return nil after a graceful shutdown but got '%s'", err)
+	}
+}
+
+func TestLifecycleDrainsServer(t *testing.T) {
+	address := freeAddress(t)
+	shutdown := lifecycle.NewManager(lifecycle.ManagerConfig{})
+
+	server, err := httpserver.NewEcho(httpserver.Options{
+		Config:    httpserver.Config{Address: address},
+		Lifecycle: shutdown,
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	started := make(chan error, 1)
+	go func() { started <- server.Start() }()
+
+	for attempt := 0; attempt < 100; attempt++ {
+		if conn, dialErr := net.Dial("tcp", address); dialErr == nil {
+			_ = conn.Close()
+			break
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	report := shutdown.Shutdown()
+
+	if len(report.Hooks) != 1 || report.Hooks[0].Name != "drain HTTP server" || report.Err() != nil {
+		t.Errorf("Expected the server to be drained by the lifecycle manager but got %+v", report)
+	}
+
+	select {
+	case err = <-started:
+		if err != nil {
+			t.Errorf("Expected no error but got '%s'", err)
+		}
+
+	case <-time.After(time.Second):
+		t.Errorf("Expected the server to stop")
+	}
+}
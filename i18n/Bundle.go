@@ -0,0 +1,249 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+var (
+	ErrInvalidCatalog = errors.New("invalid message catalog")
+	ErrInvalidLocale  = errors.New("invalid locale")
+	ErrUnknownFormat  = errors.New("unknown catalog format")
+)
+
+/*
+Bundle holds the message catalogs for every locale an app supports, and
+picks the best one for a request. It is safe for concurrent use
+*/
+type Bundle struct {
+	catalogs      map[language.Tag]*catalog
+	config        BundleConfig
+	defaultLocale language.Tag
+	matcher       language.Matcher
+	mutex         sync.RWMutex
+	tags          []language.Tag
+}
+
+/*
+NewBundle creates a Bundle, loading every catalog in config.FS
+*/
+func NewBundle(config BundleConfig) (*Bundle, error) {
+	var err error
+
+	if config.DefaultLocale == "" {
+		config.DefaultLocale = "en"
+	}
+
+	if config.CookieName == "" {
+		config.CookieName = "lang"
+	}
+
+	if config.QueryParam == "" {
+		config.QueryParam = "lang"
+	}
+
+	result := &Bundle{
+		catalogs: make(map[language.Tag]*catalog),
+		config:   config,
+	}
+
+	if result.defaultLocale, err = language.Parse(config.DefaultLocale); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidLocale, config.DefaultLocale)
+	}
+
+	result.catalogFor(result.defaultLocale)
+
+	if config.FS == nil {
+		return result, nil
+	}
+
+	entries, err := fs.ReadDir(config.FS, ".")
+
+	if err != nil {
+		return nil, fmt.Errorf("error reading catalogs: %w", err)
+	}
+
+	for _, entry := range entries {
+		extension := path.Ext(entry.Name())
+
+		if entry.IsDir() || (extension != ".json" && extension != ".po") {
+			continue
+		}
+
+		b, err := fs.ReadFile(config.FS, entry.Name())
+
+		if err != nil {
+			return nil, fmt.Errorf("error reading catalog %s: %w", entry.Name(), err)
+		}
+
+		locale := strings.TrimSuffix(entry.Name(), extension)
+
+		if extension == ".json" {
+			err = result.AddJSON(locale, b)
+		} else {
+			err = result.AddPO(locale, b)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("error loading %s: %w", entry.Name(), err)
+		}
+	}
+
+	return result, nil
+}
+
+/*
+AddJSON adds messages in JSON to a locale's catalog. Keys map to
+messages, and objects nest keys, so {"users": {"title": "Users"}} is the
+key "users.title". An object of plural forms, such as
+{"one": "{count} user", "other": "{count} users"}, is a message that
+changes with a count
+*/
+func (b *Bundle) AddJSON(locale string, data []byte) error {
+	messages, err := parseJSON(data)
+
+	if err != nil {
+		return err
+	}
+
+	return b.add(locale, func(c *catalog) {
+		for key, m := range messages {
+			c.messages[key] = m
+		}
+	})
+}
+
+/*
+AddPO adds messages in gettext PO format to a locale's catalog. msgid is
+the key. Plural translations, msgstr[0] and on, are the forms the locale
+uses in CLDR order: zero, one, two, few, many, other. That matches the
+usual gettext order for most languages. Fuzzy and untranslated entries
+are skipped
+*/
+func (b *Bundle) AddPO(locale string, data []byte) error {
+	tag, err := language.Parse(locale)
+
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidLocale, locale)
+	}
+
+	entries, err := parsePO(data)
+
+	if err != nil {
+		return err
+	}
+
+	forms := pluralForms(tag)
+
+	return b.add(locale, func(c *catalog) {
+		for _, e := range entries {
+			c.messages[e.id] = e.message(forms)
+		}
+	})
+}
+
+/*
+Locales lists the locales that have catalogs, starting with the default
+*/
+func (b *Bundle) Locales() []string {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	result := make([]string, 0, len(b.tags))
+
+	for _, tag := range b.tags {
+		result = append(result, tag.String())
+	}
+
+	return result
+}
+
+/*
+Localizer returns a Localizer for the best match among the preferred
+locales, in order of preference. Each can be a locale, such as "fr-CA",
+or an Accept-Language header. With no match, the default locale is used
+*/
+func (b *Bundle) Localizer(preferences ...string) *Localizer {
+	desired := []language.Tag{}
+
+	for _, preference := range preferences {
+		if tags, _, err := language.ParseAcceptLanguage(preference); err == nil {
+			desired = append(desired, tags...)
+		}
+	}
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	tag := b.defaultLocale
+
+	if len(desired) > 0 {
+		if _, index, confidence := b.matcher.Match(desired...); confidence != language.No {
+			tag = b.tags[index]
+		}
+	}
+
+	result := &Localizer{tag: tag}
+
+	// Look in the locale, then its parents, such as fr-CA then fr, then the default
+	for current := tag; ; current = current.Parent() {
+		if c, ok := b.catalogs[current]; ok {
+			result.catalogs = append(result.catalogs, c)
+		}
+
+		if current == language.Und || current.Parent() == current {
+			break
+		}
+	}
+
+	if tag != b.defaultLocale {
+		result.catalogs = append(result.catalogs, b.catalogs[b.defaultLocale])
+	}
+
+	return result
+}
+
+func (b *Bundle) add(locale string, fn func(c *catalog)) error {
+	tag, err := language.Parse(locale)
+
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidLocale, locale)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	fn(b.catalogFor(tag))
+	return nil
+}
+
+/*
+catalogFor returns the catalog for a tag, creating it and rebuilding the
+matcher if needed. The caller holds the lock, except in NewBundle
+*/
+func (b *Bundle) catalogFor(tag language.Tag) *catalog {
+	if c, ok := b.catalogs[tag]; ok {
+		return c
+	}
+
+	c := &catalog{
+		messages: make(map[string]message),
+		tag:      tag,
+	}
+
+	b.catalogs[tag] = c
+	b.tags = append(b.tags, tag)
+	b.matcher = language.NewMatcher(b.tags)
+
+	return c
+}
@@ -0,0 +1,26 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package i18n
+
+import "io/fs"
+
+/*
+BundleConfig configures a Bundle.
+
+FS holds the message catalogs, one or more per locale, named by the
+locale: en.json, fr.po, pt-BR.json. DefaultLocale is used when nothing
+better matches a request, and for messages other locales are missing.
+It defaults to "en".
+
+The middleware picks a locale from the QueryParam query parameter,
+then the CookieName cookie, then the Accept-Language header. Both
+default to "lang"
+*/
+type BundleConfig struct {
+	CookieName    string
+	DefaultLocale string
+	FS            fs.FS
+	QueryParam    string
+}
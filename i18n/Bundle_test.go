@@ -0,0 +1,248 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package i18n_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ResurgenceIT/kit/v6/apierror"
+	"github.com/ResurgenceIT/kit/v6/i18n"
+	"github.com/ResurgenceIT/kit/v6/render"
+	"github.com/ResurgenceIT/kit/v6/validate"
+	"github.com/labstack/echo/v4"
+)
+
+const polish = `# Polish
+msgid ""
+msgstr ""
+"Language: pl\n"
+"Plural-Forms: nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n"
+
+msgid "greeting"
+msgstr "Cześć, {name}"
+
+msgid "cart.items"
+msgid_plural "{count} items"
+msgstr[0] "{count} produkt"
+msgstr[1] "{count} produkty"
+msgstr[2] "{count} "
+"produktów"
+
+#, fuzzy
+msgid "farewell"
+msgstr "Do widzenia"
+`
+
+func catalogs() fstest.MapFS {
+	return fstest.MapFS{
+		"en.json": {Data: []byte(`{
+			"greeting": "Hello, {name}",
+			"farewell": "Goodbye",
+			"cart": {"items": {"one": "{count} item", "other": "{count} items"}},
+			"validate": {"required": "{field} is required"},
+			"problem": {"not-found": {"title": "Not Found"}}
+		}`)},
+		"fr.json": {Data: []byte(`{
+			"greeting": "Bonjour, {name}",
+			"cart": {"items": {"one": "{count} article", "other": "{count} articles"}},
+			"validate": {"required": "est obligatoire"},
+			"problem": {"not-found": {"title": "Introuvable"}, "validation-failed": {"detail": "Certains champs sont invalides"}}
+		}`)},
+		"fr-CA.json": {Data: []byte(`{"farewell": "Bonne journée"}`)},
+		"pl.po":      {Data: []byte(polish)},
+		"README.md":  {Data: []byte(`ignored`)},
+	}
+}
+
+func newBundle(t *testing.T) *i18n.Bundle {
+	bundle, err := i18n.NewBundle(i18n.BundleConfig{FS: catalogs()})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err)
+	}
+
+	return bundle
+}
+
+func TestTranslate(t *testing.T) {
+	bundle := newBundle(t)
+
+	tests := []struct {
+		name   string
+		locale string
+		got    func(l *i18n.Localizer) string
+		want   string
+	}{
+		{name: "Placeholder", locale: "fr", got: func(l *i18n.Localizer) string { return l.T("greeting", "name", "Adam") }, want: "Bonjour, Adam"},
+		{name: "Parent locale", locale: "fr-CA", got: func(l *i18n.Localizer) string { return l.T("greeting", "name", "Adam") }, want: "Bonjour, Adam"},
+		{name: "Regional message", locale: "fr-CA", got: func(l *i18n.Localizer) string { return l.T("farewell") }, want: "Bonne journée"},
+		{name: "Default locale", locale: "fr", got: func(l *i18n.Localizer) string { return l.T("farewell") }, want: "Goodbye"},
+		{name: "Missing", locale: "fr", got: func(l *i18n.Localizer) string { return l.T("missing.key") }, want: "missing.key"},
+		{name: "Unknown placeholder", locale: "en", got: func(l *i18n.Localizer) string { return l.T("greeting", "user", "Adam") }, want: "Hello, {name}"},
+		{name: "French singular zero", locale: "fr", got: func(l *i18n.Localizer) string { return l.N("cart.items", 0) }, want: "0 article"},
+		{name: "English plural zero", locale: "en", got: func(l *i18n.Localizer) string { return l.N("cart.items", 0) }, want: "0 items"},
+		{name: "PO one", locale: "pl", got: func(l *i18n.Localizer) string { return l.N("cart.items", 1) }, want: "1 produkt"},
+		{name: "PO few", locale: "pl", got: func(l *i18n.Localizer) string { return l.N("cart.items", 23) }, want: "23 produkty"},
+		{name: "PO many", locale: "pl", got: func(l *i18n.Localizer) string { return l.N("cart.items", 12) }, want: "12 produktów"},
+		{name: "PO fuzzy skipped", locale: "pl", got: func(l *i18n.Localizer) string { return l.T("farewell") }, want: "Goodbye"},
+		{name: "Unsupported locale", locale: "ja", got: func(l *i18n.Localizer) string { return l.T("greeting", "name", "Adam") }, want: "Hello, Adam"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.got(bundle.Localizer(test.locale)); got != test.want {
+				t.Errorf("Expected '%s' but got '%s'", test.want, got)
+			}
+		})
+	}
+
+	var nilLocalizer *i18n.Localizer
+
+	if got := nilLocalizer.T("greeting"); got != "greeting" {
+		t.Errorf("Expected a nil Localizer to return the key but got '%s'", got)
+	}
+}
+
+func TestCatalogErrors(t *testing.T) {
+	bundle := newBundle(t)
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "Bad JSON", err: bundle.AddJSON("de", []byte(`{"a": 1}`)), want: i18n.ErrInvalidCatalog},
+		{name: "Bad PO", err: bundle.AddPO("de", []byte(`msgid "a"`+"\n"+`msgstr unquoted`)), want: i18n.ErrInvalidCatalog},
+		{name: "msgctxt", err: bundle.AddPO("de", []byte(`msgctxt "menu"`)), want: i18n.ErrInvalidCatalog},
+		{name: "Bad locale", err: bundle.AddJSON("not a locale", []byte(`{}`)), want: i18n.ErrInvalidLocale},
+	}
+
+	for _, test := range tests {
+		if !errors.Is(test.err, test.want) {
+			t.Errorf("%s: expected %v but got %v", test.name, test.want, test.err)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	bundle := newBundle(t)
+
+	handler := bundle.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(i18n.FromContext(r.Context()).T("greeting", "name", "Adam")))
+	}))
+
+	tests := []struct {
+		name           string
+		query          string
+		cookie         string
+		acceptLanguage string
+		want           string
+		locale         string
+	}{
+		{name: "Accept-Language", acceptLanguage: "de-DE, fr;q=0.8, en;q=0.5", want: "Bonjour, Adam", locale: "fr"},
+		{name: "Cookie", cookie: "pl", acceptLanguage: "fr", want: "Cześć, Adam", locale: "pl"},
+		{name: "Query", query: "?lang=fr-CA", cookie: "pl", want: "Bonjour, Adam", locale: "fr-CA"},
+		{name: "Nothing", want: "Hello, Adam", locale: "en"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/"+test.query, nil)
+			request.Header.Set("Accept-Language", test.acceptLanguage)
+
+			if test.cookie != "" {
+				request.AddCookie(&http.Cookie{Name: "lang", Value: test.cookie})
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			if recorder.Body.String() != test.want || recorder.Header().Get("Content-Language") != test.locale {
+				t.Errorf("Expected '%s' in %s but got '%s' in %s", test.want, test.locale, recorder.Body.String(), recorder.Header().Get("Content-Language"))
+			}
+		})
+	}
+}
+
+func TestLocalizeErrors(t *testing.T) {
+	bundle := newBundle(t)
+
+	e := echo.New()
+	e.Use(bundle.Middleware)
+	e.HTTPErrorHandler = apierror.NewEchoErrorHandler(apierror.EchoErrorHandlerConfig{Localize: bundle.LocalizeProblem})
+
+	e.POST("/users", func(ctx echo.Context) error {
+		return validate.ValidationErrors{
+			{Field: "email", Message: "is required", Rule: "required"},
+			{Field: "name", Message: "must be at least 2 characters", Param: "2", Rule: "min"},
+		}
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/users", nil)
+	request.Header.Set("Accept-Language", "fr-FR")
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, request)
+
+	problem := apierror.Problem{}
+
+	if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("Expected a problem but got '%s'", recorder.Body.String())
+	}
+
+	if problem.Detail != "Certains champs sont invalides" || problem.Title != "Validation Failed" {
+		t.Errorf("Expected a translated detail only but got %s: %s", problem.Title, problem.Detail)
+	}
+
+	if len(problem.Errors) != 2 || problem.Errors[0].Message != "est obligatoire" || problem.Errors[1].Message != "must be at least 2 characters" {
+		t.Errorf("Unexpected field errors %v", problem.Errors)
+	}
+
+	english := bundle.Localizer("en").ValidationErrors(validate.ValidationErrors{{Field: "email", Rule: "required"}})
+
+	if english[0].Message != "email is required" {
+		t.Errorf("Expected the field placeholder to be filled but got '%s'", english[0].Message)
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	bundle := newBundle(t)
+
+	renderer, err := render.NewRenderer(render.RendererConfig{
+		ContextFuncs: bundle.TemplateFuncs,
+		FS: fstest.MapFS{
+			"home.html": {Data: []byte(`<p lang="{{locale}}">{{t "greeting" "name" .}} {{tn "cart.items" 3}}</p>`)},
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err)
+	}
+
+	e := echo.New()
+	ctx := e.NewContext(httptest.NewRequest(http.MethodGet, "/?lang=fr", nil), httptest.NewRecorder())
+
+	if err = bundle.Middleware(func(ctx echo.Context) error { return nil })(ctx); err != nil {
+		t.Fatalf("Expected no error but got %s", err)
+	}
+
+	buffer := &bytes.Buffer{}
+
+	if err = renderer.Render(buffer, "home", "Adam", ctx); err != nil {
+		t.Fatalf("Expected no error but got %s", err)
+	}
+
+	want := `<p lang="fr">Bonjour, Adam 3 articles</p>`
+
+	if buffer.String() != want {
+		t.Errorf("Expected '%s' but got '%s'", want, buffer.String())
+	}
+}
@@ -0,0 +1,272 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package i18n
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+var formNames = map[string]plural.Form{
+	"few":   plural.Few,
+	"many":  plural.Many,
+	"one":   plural.One,
+	"other": plural.Other,
+	"two":   plural.Two,
+	"zero":  plural.Zero,
+}
+
+// cldrOrder is the order of plural forms in PO files
+var cldrOrder = map[plural.Form]int{
+	plural.Zero:  0,
+	plural.One:   1,
+	plural.Two:   2,
+	plural.Few:   3,
+	plural.Many:  4,
+	plural.Other: 5,
+}
+
+type catalog struct {
+	messages map[string]message
+	tag      language.Tag
+}
+
+/*
+message is a translation. Messages without plurals only have the Other
+form
+*/
+type message map[plural.Form]string
+
+func (m message) form(form plural.Form) string {
+	if text, ok := m[form]; ok {
+		return text
+	}
+
+	return m[plural.Other]
+}
+
+func parseJSON(data []byte) (map[string]message, error) {
+	document := map[string]interface{}{}
+
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCatalog, err.Error())
+	}
+
+	result := make(map[string]message)
+
+	if err := flattenJSON("", document, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func flattenJSON(prefix string, document map[string]interface{}, result map[string]message) error {
+	for key, value := range document {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		switch v := value.(type) {
+		case string:
+			result[key] = message{plural.Other: v}
+
+		case map[string]interface{}:
+			if m, ok := pluralJSON(v); ok {
+				result[key] = m
+				continue
+			}
+
+			if err := flattenJSON(key, v, result); err != nil {
+				return err
+			}
+
+		default:
+			return fmt.Errorf("%w: %s must be a string or an object", ErrInvalidCatalog, key)
+		}
+	}
+
+	return nil
+}
+
+/*
+pluralJSON reads an object of plural forms. Every key must be a form,
+and "other" is required
+*/
+func pluralJSON(document map[string]interface{}) (message, bool) {
+	if _, ok := document["other"].(string); !ok {
+		return nil, false
+	}
+
+	result := message{}
+
+	for key, value := range document {
+		form, ok := formNames[key]
+		text, isString := value.(string)
+
+		if !ok || !isString {
+			return nil, false
+		}
+
+		result[form] = text
+	}
+
+	return result, true
+}
+
+type poEntry struct {
+	fuzzy        bool
+	id           string
+	plural       string
+	translations map[int]string
+}
+
+func (e poEntry) message(forms []plural.Form) message {
+	if e.plural == "" {
+		return message{plural.Other: e.translations[0]}
+	}
+
+	result := message{}
+
+	for index, form := range forms {
+		if text := e.translations[index]; text != "" {
+			result[form] = text
+		}
+	}
+
+	return result
+}
+
+/*
+parsePO reads the translated entries of a PO file, skipping the header,
+fuzzy entries, and untranslated entries
+*/
+func parsePO(data []byte) ([]poEntry, error) {
+	result := []poEntry{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var entry poEntry
+	var appendTo func(text string)
+	lineNumber := 0
+	fuzzy := false
+
+	finish := func() {
+		if entry.id != "" && !entry.fuzzy && entry.translations[0] != "" {
+			result = append(result, entry)
+		}
+
+		entry = poEntry{fuzzy: fuzzy, translations: map[int]string{}}
+		fuzzy = false
+		appendTo = nil
+	}
+
+	finish()
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			if strings.HasPrefix(line, "#,") && strings.Contains(line, "fuzzy") {
+				fuzzy = true
+			}
+
+			continue
+		}
+
+		keyword, rest := "", line
+
+		if !strings.HasPrefix(line, `"`) {
+			keyword, rest = line, ""
+
+			if index := strings.IndexAny(line, " \t"); index >= 0 {
+				keyword, rest = line[:index], strings.TrimSpace(line[index:])
+			}
+		}
+
+		text, err := strconv.Unquote(rest)
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %s", ErrInvalidCatalog, lineNumber, line)
+		}
+
+		switch {
+		case keyword == "":
+			if appendTo == nil {
+				return nil, fmt.Errorf("%w: line %d: string without a keyword", ErrInvalidCatalog, lineNumber)
+			}
+
+			appendTo(text)
+
+		case keyword == "msgid":
+			finish()
+			entry.id = text
+			appendTo = func(text string) { entry.id += text }
+
+		case keyword == "msgid_plural":
+			entry.plural = text
+			appendTo = func(text string) { entry.plural += text }
+
+		case keyword == "msgstr" || strings.HasPrefix(keyword, "msgstr["):
+			index := 0
+
+			if keyword != "msgstr" {
+				if index, err = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(keyword, "msgstr["), "]")); err != nil {
+					return nil, fmt.Errorf("%w: line %d: %s", ErrInvalidCatalog, lineNumber, keyword)
+				}
+			}
+
+			entry.translations[index] = text
+			appendTo = func(text string) { entry.translations[index] += text }
+
+		case keyword == "msgctxt":
+			return nil, fmt.Errorf("%w: line %d: msgctxt is not supported", ErrInvalidCatalog, lineNumber)
+
+		default:
+			return nil, fmt.Errorf("%w: line %d: unknown keyword %s", ErrInvalidCatalog, lineNumber, keyword)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading PO file: %w", err)
+	}
+
+	finish()
+	return result, nil
+}
+
+/*
+pluralForms returns the plural forms a language uses for whole numbers,
+in CLDR order
+*/
+func pluralForms(tag language.Tag) []plural.Form {
+	seen := map[plural.Form]bool{}
+
+	for n := 0; n <= 1000; n++ {
+		seen[plural.Cardinal.MatchPlural(tag, n, 0, 0, 0, 0)] = true
+	}
+
+	result := make([]plural.Form, 0, len(seen))
+
+	for form := range seen {
+		result = append(result, form)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return cldrOrder[result[i]] < cldrOrder[result[j]] })
+	return result
+}
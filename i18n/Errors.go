@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package i18n
+
+import (
+	"github.com/ResurgenceIT/kit/v6/apierror"
+	"github.com/ResurgenceIT/kit/v6/validate"
+	"github.com/labstack/echo/v4"
+)
+
+/*
+ValidationErrors translates the messages of validation errors. The key
+for a rule is "validate." and the rule, such as "validate.min", and the
+message can use {field} and {param}:
+
+	{"validate": {"required": "est obligatoire", "min": "doit contenir au moins {param} caractères"}}
+
+Errors for rules without a translation keep their message
+*/
+func (l *Localizer) ValidationErrors(errs validate.ValidationErrors) validate.ValidationErrors {
+	result := make(validate.ValidationErrors, 0, len(errs))
+
+	for _, fieldError := range errs {
+		key := "validate." + fieldError.Rule
+
+		if l.Has(key) {
+			fieldError.Message = l.T(key, "field", fieldError.Field, "param", fieldError.Param)
+		}
+
+		result = append(result, fieldError)
+	}
+
+	return result
+}
+
+/*
+Problem translates an API problem. The title's key is "problem." and the
+code, then ".title", such as "problem.not-found.title". The detail's
+key ends in ".detail" instead. Fields that failed validation are
+translated by ValidationErrors. Anything without a translation is left
+as it is
+*/
+func (l *Localizer) Problem(problem *apierror.Problem) *apierror.Problem {
+	result := *problem
+	key := "problem." + problem.Code
+
+	if l.Has(key + ".title") {
+		result.Title = l.T(key + ".title")
+	}
+
+	if l.Has(key + ".detail") {
+		result.Detail = l.T(key + ".detail")
+	}
+
+	if len(problem.Errors) > 0 {
+		result.Errors = l.ValidationErrors(problem.Errors)
+	}
+
+	return &result
+}
+
+/*
+LocalizeProblem translates a problem into the locale of an Echo request.
+Pass it to the apierror error handler so API errors are translated:
+
+	apierror.EchoErrorHandlerConfig{Localize: bundle.LocalizeProblem}
+*/
+func (b *Bundle) LocalizeProblem(ctx echo.Context, problem *apierror.Problem) *apierror.Problem {
+	return b.fromEcho(ctx).Problem(problem)
+}
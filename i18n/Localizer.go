@@ -0,0 +1,159 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package i18n
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+/*
+Localizer translates messages into one locale, falling back to its
+parent locales and then the bundle's default. Messages can have
+placeholders in braces, such as "Hello, {name}", filled from pairs of
+names and values:
+
+	localizer.T("greeting", "name", user.Name)
+	localizer.N("cart.items", len(items))
+
+A message missing from every catalog is returned as its key, so gaps
+show up on screen instead of failing. A nil Localizer does the same
+*/
+type Localizer struct {
+	catalogs []*catalog
+	tag      language.Tag
+}
+
+/*
+Locale returns the locale the Localizer translates into, such as "fr-CA"
+*/
+func (l *Localizer) Locale() string {
+	if l == nil {
+		return ""
+	}
+
+	return l.tag.String()
+}
+
+/*
+Has returns true when a catalog has the message
+*/
+func (l *Localizer) Has(key string) bool {
+	_, _, ok := l.lookup(key)
+	return ok
+}
+
+/*
+T translates a message. args are pairs of placeholder names and values
+*/
+func (l *Localizer) T(key string, args ...interface{}) string {
+	m, _, ok := l.lookup(key)
+
+	if !ok {
+		return fill(key, args)
+	}
+
+	return fill(m.form(plural.Other), args)
+}
+
+/*
+N translates a message that changes with count, picking the plural form
+by the rules of the locale the message was found in. The count
+placeholder, {count}, is filled in, along with any args
+*/
+func (l *Localizer) N(key string, count int, args ...interface{}) string {
+	args = append([]interface{}{"count", count}, args...)
+	m, c, ok := l.lookup(key)
+
+	if !ok {
+		return fill(key, args)
+	}
+
+	n := count
+
+	if n < 0 {
+		n = -n
+	}
+
+	return fill(m.form(plural.Cardinal.MatchPlural(c.tag, n, 0, 0, 0, 0)), args)
+}
+
+/*
+Funcs returns template helpers that translate with this Localizer:
+
+	{{t "greeting" "name" .User.Name}}
+	{{tn "cart.items" .Count}}
+	<html lang="{{locale}}">
+*/
+func (l *Localizer) Funcs() map[string]interface{} {
+	return map[string]interface{}{
+		"locale": l.Locale,
+		"t":      l.T,
+		"tn":     l.N,
+	}
+}
+
+func (l *Localizer) lookup(key string) (message, *catalog, bool) {
+	if l == nil {
+		return nil, nil, false
+	}
+
+	for _, c := range l.catalogs {
+		if m, ok := c.messages[key]; ok {
+			return m, c, true
+		}
+	}
+
+	return nil, nil, false
+}
+
+/*
+fill replaces placeholders, such as {name}, with values from pairs of
+names and values. Unknown placeholders are left alone
+*/
+func fill(text string, args []interface{}) string {
+	if len(args) < 2 || !strings.Contains(text, "{") {
+		return text
+	}
+
+	values := make(map[string]string, len(args)/2)
+
+	for index := 0; index+1 < len(args); index += 2 {
+		values[fmt.Sprint(args[index])] = fmt.Sprint(args[index+1])
+	}
+
+	result := strings.Builder{}
+
+	for {
+		start := strings.Index(text, "{")
+
+		if start < 0 {
+			break
+		}
+
+		end := strings.Index(text[start:], "}")
+
+		if end < 0 {
+			break
+		}
+
+		end += start
+
+		if value, ok := values[text[start+1:end]]; ok {
+			result.WriteString(text[:start])
+			result.WriteString(value)
+		} else {
+			result.WriteString(text[:end+1])
+		}
+
+		text = text[end+1:]
+	}
+
+	result.WriteString(text)
+	return result.String()
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package i18n
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type contextKey struct{}
+
+/*
+Middleware picks a locale for each request for the Echo framework.
+Handlers get the Localizer with FromContext
+*/
+func (b *Bundle) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		localizer := b.negotiate(ctx.Response(), ctx.Request())
+		ctx.SetRequest(ctx.Request().WithContext(context.WithValue(ctx.Request().Context(), contextKey{}, localizer)))
+
+		return next(ctx)
+	}
+}
+
+/*
+HTTPMiddleware picks a locale for each request for a plain net/http
+handler
+*/
+func (b *Bundle) HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		localizer := b.negotiate(w, r)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, localizer)))
+	})
+}
+
+/*
+FromContext returns the Localizer the middleware picked, or nil
+*/
+func FromContext(ctx context.Context) *Localizer {
+	localizer, _ := ctx.Value(contextKey{}).(*Localizer)
+	return localizer
+}
+
+/*
+TemplateFuncs returns the template helpers for the locale of an Echo
+request, or the default locale when ctx is nil. Pass it to the render
+package so pages are translated:
+
+	render.RendererConfig{ContextFuncs: bundle.TemplateFuncs}
+*/
+func (b *Bundle) TemplateFuncs(ctx echo.Context) map[string]interface{} {
+	return b.fromEcho(ctx).Funcs()
+}
+
+/*
+negotiate picks the locale from the query string, then the cookie, then
+Accept-Language
+*/
+func (b *Bundle) negotiate(w http.ResponseWriter, r *http.Request) *Localizer {
+	preferences := []string{}
+
+	if value := r.URL.Query().Get(b.config.QueryParam); value != "" {
+		preferences = append(preferences, value)
+	}
+
+	if cookie, err := r.Cookie(b.config.CookieName); err == nil && cookie.Value != "" {
+		preferences = append(preferences, cookie.Value)
+	}
+
+	if value := r.Header.Get("Accept-Language"); value != "" {
+		preferences = append(preferences, value)
+	}
+
+	result := b.Localizer(preferences...)
+
+	w.Header().Add("Vary", "Accept-Language")
+	w.Header().Set("Content-Language", result.Locale())
+
+	return result
+}
+
+func (b *Bundle) fromEcho(ctx echo.Context) *Localizer {
+	if ctx != nil {
+		if localizer := FromContext(ctx.Request().Context()); localizer != nil {
+			return localizer
+		}
+	}
+
+	return b.Localizer()
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+ClientCredentialsConfig configures fetching access tokens with the
+OAuth2 client credentials grant. HTTPClient defaults to a client with a
+10 second timeout. Tokens are refreshed ExpiryMargin (default 30
+seconds) before they expire
+*/
+type ClientCredentialsConfig struct {
+	ClientID     string
+	ClientSecret string
+	ExpiryMargin time.Duration
+	HTTPClient   *http.Client
+	Scopes       []string
+	TokenURL     string
+}
+
+/*
+ClientCredentials fetches and caches access tokens for service to
+service calls. It is safe for concurrent use
+*/
+type ClientCredentials struct {
+	sync.Mutex
+
+	config    ClientCredentialsConfig
+	expiresAt time.Time
+	token     string
+}
+
+type clientCredentialsResponse struct {
+	AccessToken string `json:"access_token"`
+	Error       string `json:"error"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+/*
+NewClientCredentials creates a token source for the client credentials
+grant
+*/
+func NewClientCredentials(config ClientCredentialsConfig) *ClientCredentials {
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: time.Second * 10}
+	}
+
+	if config.ExpiryMargin <= 0 {
+		config.ExpiryMargin = time.Second * 30
+	}
+
+	return &ClientCredentials{
+		config: config,
+	}
+}
+
+/*
+Token returns a cached access token, fetching a new one when there is
+none or it is about to expire
+*/
+func (c *ClientCredentials) Token(ctx context.Context) (string, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.token != "" && time.Now().Before(c.expiresAt) {
+		return c.token, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+
+	if len(c.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.config.Scopes, " "))
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.TokenURL, strings.NewReader(form.Encode()))
+
+	if err != nil {
+		return "", fmt.Errorf("error creating token request: %w", err)
+	}
+
+	request.SetBasicAuth(url.QueryEscape(c.config.ClientID), url.QueryEscape(c.config.ClientSecret))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := c.config.HTTPClient.Do(request)
+
+	if err != nil {
+		return "", fmt.Errorf("error requesting access token: %w", err)
+	}
+
+	defer response.Body.Close()
+
+	body := clientCredentialsResponse{}
+
+	if err = json.NewDecoder(response.Body).Decode(&body); err != nil && response.StatusCode < 300 {
+		return "", fmt.Errorf("error decoding access token response: %w", err)
+	}
+
+	if response.StatusCode > 299 || body.AccessToken == "" {
+		return "", fmt.Errorf("error requesting access token: status %d %s", response.StatusCode, body.Error)
+	}
+
+	c.token = body.AccessToken
+	c.expiresAt = time.Now().Add(time.Hour)
+
+	if body.ExpiresIn > 0 {
+		c.expiresAt = time.Now().Add(time.Duration(body.ExpiresIn)*time.Second - c.config.ExpiryMargin)
+	}
+
+	return c.token, nil
+}
+
+/*
+Invalidate drops the cached token so the next call to Token fetches a
+new one, such as after a call is rejected with 401
+*/
+func (c *ClientCredentials) Invalidate() {
+	c.Lock()
+	c.token = ""
+	c.Unlock()
+}
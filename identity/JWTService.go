@@ -5,17 +5,15 @@
 package identity
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
-	"crypto/sha1"
+	"context"
 	"encoding/base64"
 	"fmt"
-	"io"
 	"time"
 
+	"github.com/ResurgenceIT/kit/v6/clock"
+	"github.com/ResurgenceIT/kit/v6/crypto"
+	"github.com/ResurgenceIT/kit/v6/secrets"
 	"github.com/golang-jwt/jwt"
-	"golang.org/x/crypto/pbkdf2"
 )
 
 /*
@@ -35,6 +33,7 @@ JWTService provides methods for working with JWT tokens
 type JWTService struct {
 	authSalt         string
 	authSecret       string
+	clock            clock.IClock
 	issuer           string
 	timeoutInMinutes int
 }
@@ -50,7 +49,7 @@ func (s JWTService) CreateToken(createRequest CreateTokenRequest) (string, error
 
 	claims := &Claims{
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Minute * time.Duration(s.timeoutInMinutes)).Unix(),
+			ExpiresAt: s.clock.Now().Add(time.Minute * time.Duration(s.timeoutInMinutes)).Unix(),
 			Issuer:    s.issuer,
 		},
 		UserID:   createRequest.UserID,
@@ -81,34 +80,14 @@ token
 */
 func (s JWTService) decryptToken(token string) (string, error) {
 	var err error
-	var aesBlock cipher.Block
 	var unencodedToken []byte
-	var gcm cipher.AEAD
-	var nonce []byte
 	var resultBytes []byte
 
-	key := s.generateAESKey()
-
 	if unencodedToken, err = base64.RawStdEncoding.DecodeString(token); err != nil {
 		return "", fmt.Errorf("Unable to base64 decode JWT token: %w", err)
 	}
 
-	if aesBlock, err = aes.NewCipher(key); err != nil {
-		return "", fmt.Errorf("Unable to create AES cipher block: %w", err)
-	}
-
-	if gcm, err = cipher.NewGCM(aesBlock); err != nil {
-		return "", fmt.Errorf("Problem creating GCM: %w", err)
-	}
-
-	nonceSize := gcm.NonceSize()
-	if len(unencodedToken) < nonceSize {
-		return "", fmt.Errorf("Ciphertext too short: %w", err)
-	}
-
-	nonce, cipherText := unencodedToken[:nonceSize], unencodedToken[nonceSize:]
-
-	if resultBytes, err = gcm.Open(nil, nonce, cipherText, nil); err != nil {
+	if resultBytes, err = crypto.Open(s.generateAESKey(), unencodedToken); err != nil {
 		return "", fmt.Errorf("Problem decrypting token: %w", err)
 	}
 
@@ -121,28 +100,13 @@ then encodes it in Base64.
 */
 func (s JWTService) encryptToken(token string) (string, error) {
 	var err error
-	var aesBlock cipher.Block
-	var gcm cipher.AEAD
-	var nonce []byte
 	var encryptedResult []byte
 
-	key := s.generateAESKey()
-
-	if aesBlock, err = aes.NewCipher(key); err != nil {
-		return "", fmt.Errorf("Unable to create AES cipher block: %w", err)
-	}
-
-	if gcm, err = cipher.NewGCM(aesBlock); err != nil {
-		return "", fmt.Errorf("Problem creating GCM: %w", err)
+	if encryptedResult, err = crypto.Seal(s.generateAESKey(), []byte(token)); err != nil {
+		return "", fmt.Errorf("Problem encrypting token: %w", err)
 	}
 
-	nonce = make([]byte, gcm.NonceSize())
-	_, _ = io.ReadFull(rand.Reader, nonce)
-
-	encryptedResult = gcm.Seal(nonce, nonce, []byte(token), nil)
-	encodedResult := base64.RawStdEncoding.EncodeToString(encryptedResult)
-
-	return encodedResult, nil
+	return base64.RawStdEncoding.EncodeToString(encryptedResult), nil
 }
 
 /*
@@ -170,14 +134,47 @@ func (s JWTService) GetUserFromToken(token *jwt.Token) (string, string) {
 NewJWTService creates a new instance of the JWTService struct
 */
 func NewJWTService(config JWTServiceConfig) JWTService {
+	if config.Clock == nil {
+		config.Clock = clock.Real{}
+	}
+
 	return JWTService{
 		authSalt:         config.AuthSalt,
 		authSecret:       config.AuthSecret,
+		clock:            config.Clock,
 		issuer:           config.Issuer,
 		timeoutInMinutes: config.TimeoutInMinutes,
 	}
 }
 
+/*
+NewJWTServiceFromSecrets creates a JWTService with its auth secret and
+salt read from a secrets provider, replacing AuthSecret and AuthSalt in
+config. For example:
+
+	jwtService, err := identity.NewJWTServiceFromSecrets(ctx, provider, "secret/data/myapp#jwtSecret", "secret/data/myapp#jwtSalt", identity.JWTServiceConfig{
+		Issuer:           "issuer://com.some.domain",
+		TimeoutInMinutes: 60,
+	})
+*/
+func NewJWTServiceFromSecrets(ctx context.Context, provider secrets.IProvider, authSecretName, authSaltName string, config JWTServiceConfig) (JWTService, error) {
+	var err error
+	var authSecret, authSalt secrets.Secret
+
+	if authSecret, err = provider.GetSecret(ctx, authSecretName); err != nil {
+		return JWTService{}, fmt.Errorf("error reading JWT auth secret: %w", err)
+	}
+
+	if authSalt, err = provider.GetSecret(ctx, authSaltName); err != nil {
+		return JWTService{}, fmt.Errorf("error reading JWT auth salt: %w", err)
+	}
+
+	config.AuthSecret = authSecret.Value
+	config.AuthSalt = authSalt.Value
+
+	return NewJWTService(config), nil
+}
+
 /*
 ParseToken decrypts the provided token and returns a JWT token object
 */
@@ -193,7 +190,13 @@ func (s JWTService) ParseToken(tokenFromHeader string) (*jwt.Token, error) {
 		return result, fmt.Errorf("Problem decrypting JWT token in Parse: %w", err)
 	}
 
-	if result, err = jwt.ParseWithClaims(decryptedToken, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+	/*
+	 * Time based claims are checked against the service's clock rather
+	 * than jwt.TimeFunc
+	 */
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+
+	if result, err = parser.ParseWithClaims(decryptedToken, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		var ok bool
 
 		if _, ok = token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -205,6 +208,10 @@ func (s JWTService) ParseToken(tokenFromHeader string) (*jwt.Token, error) {
 		return result, fmt.Errorf("Problem parsing JWT token: %w", err)
 	}
 
+	if err = s.validateTime(result); err != nil {
+		return result, fmt.Errorf("Problem parsing JWT token: %w", err)
+	}
+
 	if err = s.IsTokenValid(result); err != nil {
 		return result, err
 	}
@@ -241,6 +248,44 @@ func (s JWTService) IsTokenValid(token *jwt.Token) error {
 	return nil
 }
 
+/*
+validateTime checks a token's expiry, issued at, and not before claims
+against the service's clock, returning the same errors as jwt.Parse
+*/
+func (s JWTService) validateTime(token *jwt.Token) error {
+	claims, ok := token.Claims.(*Claims)
+
+	if !ok {
+		return ErrTokenMissingClaims
+	}
+
+	now := s.clock.Now().Unix()
+	vErr := &jwt.ValidationError{}
+
+	if !claims.VerifyExpiresAt(now, false) {
+		delta := time.Unix(now, 0).Sub(time.Unix(claims.ExpiresAt, 0))
+		vErr.Inner = fmt.Errorf("token is expired by %v", delta)
+		vErr.Errors |= jwt.ValidationErrorExpired
+	}
+
+	if !claims.VerifyIssuedAt(now, false) {
+		vErr.Inner = fmt.Errorf("Token used before issued")
+		vErr.Errors |= jwt.ValidationErrorIssuedAt
+	}
+
+	if !claims.VerifyNotBefore(now, false) {
+		vErr.Inner = fmt.Errorf("token is not valid yet")
+		vErr.Errors |= jwt.ValidationErrorNotValidYet
+	}
+
+	if vErr.Errors == 0 {
+		return nil
+	}
+
+	token.Valid = false
+	return vErr
+}
+
 func (s JWTService) generateAESKey() []byte {
-	return pbkdf2.Key([]byte(s.authSecret), []byte(s.authSalt), 4096, 32, sha1.New)
+	return crypto.DeriveKey([]byte(s.authSecret), []byte(s.authSalt), crypto.LegacyKDFOptions)
 }
@@ -4,13 +4,19 @@
 
 package identity
 
+import (
+	"github.com/ResurgenceIT/kit/v6/clock"
+)
+
 /*
 JWTServiceConfig is a configuration object for initializing the
-JWTService struct
+JWTService struct. Clock is used to set and check token expiry, and
+defaults to the system clock
 */
 type JWTServiceConfig struct {
 	AuthSalt         string
 	AuthSecret       string
+	Clock            clock.IClock
 	Issuer           string
 	TimeoutInMinutes int
 }
@@ -0,0 +1,52 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package identity_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/clock"
+	"github.com/ResurgenceIT/kit/v6/identity"
+	"github.com/golang-jwt/jwt"
+)
+
+func TestJWTServiceExpiry(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	service := identity.NewJWTService(identity.JWTServiceConfig{
+		AuthSalt:         "salt",
+		AuthSecret:       "secret",
+		Clock:            fake,
+		Issuer:           "issuer://test",
+		TimeoutInMinutes: 60,
+	})
+
+	token, err := service.CreateToken(identity.CreateTokenRequest{UserID: "1", UserName: "alice"})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	fake.Advance(time.Minute * 59)
+
+	parsed, err := service.ParseToken(token)
+
+	if err != nil {
+		t.Fatalf("Expected the token to be valid but got '%s'", err)
+	}
+
+	if userID, userName := service.GetUserFromToken(parsed); userID != "1" || userName != "alice" {
+		t.Errorf("Expected user 1 alice but got %s %s", userID, userName)
+	}
+
+	fake.Advance(time.Minute * 2)
+
+	var validationErr *jwt.ValidationError
+
+	if _, err = service.ParseToken(token); !errors.As(err, &validationErr) || validationErr.Errors&jwt.ValidationErrorExpired == 0 {
+		t.Errorf("Expected an expired token error but got %v", err)
+	}
+}
@@ -0,0 +1,21 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ids
+
+import "errors"
+
+var (
+	// ErrInvalidNode is returned when a snowflake node is outside 0 to 1023
+	ErrInvalidNode = errors.New("invalid snowflake node: nodes must be 0 to 1023")
+
+	// ErrInvalidSnowflake is returned when parsing something that isn't a snowflake ID
+	ErrInvalidSnowflake = errors.New("invalid snowflake ID")
+
+	// ErrInvalidULID is returned when parsing something that isn't a ULID
+	ErrInvalidULID = errors.New("invalid ULID")
+
+	// ErrInvalidUUID is returned when parsing something that isn't a UUID
+	ErrInvalidUUID = errors.New("invalid UUID")
+)
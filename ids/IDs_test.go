@@ -0,0 +1,214 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ids_test
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/ids"
+)
+
+func TestUUID(t *testing.T) {
+	v4 := ids.NewV4()
+
+	if v4.Version() != 4 || v4[8]&0xc0 != 0x80 {
+		t.Errorf("Expected a version 4 RFC variant UUID but got %s", v4)
+	}
+
+	before := time.Now().Add(-time.Millisecond)
+	v7 := ids.NewV7()
+
+	if v7.Version() != 7 || v7.Time().Before(before) || v7.Time().After(time.Now()) {
+		t.Errorf("Expected a version 7 UUID made now but got %s at %s", v7, v7.Time())
+	}
+
+	previous := v7.String()
+
+	for index := 0; index < 10000; index++ {
+		next := ids.NewV7().String()
+
+		if next <= previous {
+			t.Fatalf("Expected %s to sort after %s", next, previous)
+		}
+
+		previous = next
+	}
+}
+
+func TestParseUUID(t *testing.T) {
+	want := ids.MustParseUUID("f81d4fae-7dec-11d0-a765-00a0c91e6bf6")
+
+	tests := []struct {
+		input string
+		valid bool
+	}{
+		{input: "f81d4fae-7dec-11d0-a765-00a0c91e6bf6", valid: true},
+		{input: "F81D4FAE-7DEC-11D0-A765-00A0C91E6BF6", valid: true},
+		{input: "f81d4fae7dec11d0a76500a0c91e6bf6", valid: true},
+		{input: "{f81d4fae-7dec-11d0-a765-00a0c91e6bf6}", valid: true},
+		{input: "urn:uuid:f81d4fae-7dec-11d0-a765-00a0c91e6bf6", valid: true},
+		{input: "f81d4fae-7dec-11d0-a765_00a0c91e6bf6"},
+		{input: "f81d4fae-7dec-11d0-a765-00a0c91e6bfg"},
+		{input: ""},
+	}
+
+	for _, test := range tests {
+		got, err := ids.ParseUUID(test.input)
+
+		switch {
+		case test.valid && (err != nil || got != want):
+			t.Errorf("Expected %s to parse but got %v", test.input, err)
+
+		case !test.valid && !errors.Is(err, ids.ErrInvalidUUID):
+			t.Errorf("Expected %s to be invalid", test.input)
+		}
+	}
+
+	if want.String() != "f81d4fae-7dec-11d0-a765-00a0c91e6bf6" {
+		t.Errorf("Expected the canonical form but got %s", want)
+	}
+}
+
+func TestULID(t *testing.T) {
+	parsed, err := ids.ParseULID("01ARZ3NDEKTSV4RRFFQ69G5FAV")
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err)
+	}
+
+	if parsed.String() != "01ARZ3NDEKTSV4RRFFQ69G5FAV" || parsed.Time().UnixNano()/int64(time.Millisecond) != 1469922850259 {
+		t.Errorf("Expected the ULID to round trip but got %s at %s", parsed, parsed.Time())
+	}
+
+	if lower, _ := ids.ParseULID("01arz3ndektsv4rrffq69g5fav"); lower != parsed {
+		t.Errorf("Expected lowercase to parse the same")
+	}
+
+	for _, invalid := range []string{"81ARZ3NDEKTSV4RRFFQ69G5FAV", "01ARZ3NDEKTSV4RRFFQ69G5FAU", "01ARZ3NDEK"} {
+		if _, err = ids.ParseULID(invalid); !errors.Is(err, ids.ErrInvalidULID) {
+			t.Errorf("Expected %s to be invalid", invalid)
+		}
+	}
+
+	previous := ids.NewULID()
+
+	for index := 0; index < 10000; index++ {
+		next := ids.NewULID()
+
+		if next.String() <= previous.String() {
+			t.Fatalf("Expected %s to sort after %s", next, previous)
+		}
+
+		if roundTrip := ids.MustParseULID(next.String()); roundTrip != next {
+			t.Fatalf("Expected %s to round trip", next)
+		}
+
+		previous = next
+	}
+}
+
+func TestSnowflake(t *testing.T) {
+	if _, err := ids.NewSnowflake(ids.SnowflakeConfig{Node: 1024}); !errors.Is(err, ids.ErrInvalidNode) {
+		t.Errorf("Expected ErrInvalidNode but got %v", err)
+	}
+
+	generator, _ := ids.NewSnowflake(ids.SnowflakeConfig{Node: 42})
+
+	seen := sync.Map{}
+	wg := sync.WaitGroup{}
+	results := make(chan ids.SnowflakeID, 20000)
+
+	for worker := 0; worker < 4; worker++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for index := 0; index < 5000; index++ {
+				results <- generator.Next()
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(results)
+
+	all := []ids.SnowflakeID{}
+
+	for id := range results {
+		if _, duplicate := seen.LoadOrStore(id, true); duplicate {
+			t.Fatalf("Expected unique IDs but got %s twice", id)
+		}
+
+		if id.Node() != 42 {
+			t.Fatalf("Expected node 42 but got %d", id.Node())
+		}
+
+		all = append(all, id)
+	}
+
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+
+	if since := time.Since(generator.Time(all[len(all)-1])); since < 0 || since > time.Second {
+		t.Errorf("Expected the last ID to be from now but it is %s old", since)
+	}
+}
+
+func TestMarshaling(t *testing.T) {
+	type record struct {
+		ID        ids.UUID        `json:"id"`
+		Order     ids.ULID        `json:"order"`
+		Parent    ids.NullUUID    `json:"parent"`
+		Snowflake ids.SnowflakeID `json:"snowflake"`
+	}
+
+	original := record{
+		ID:        ids.NewV7(),
+		Order:     ids.NewULID(),
+		Snowflake: 1234567890123456789,
+	}
+
+	b, err := json.Marshal(original)
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err)
+	}
+
+	decoded := record{}
+
+	if err = json.Unmarshal(b, &decoded); err != nil || decoded != original {
+		t.Errorf("Expected %s to round trip but got %v", b, err)
+	}
+
+	if err = json.Unmarshal([]byte(`{"snowflake": 42}`), &decoded); err != nil || decoded.Snowflake != 42 {
+		t.Errorf("Expected a snowflake to unmarshal from a number but got %v", err)
+	}
+
+	var id ids.UUID
+	raw := original.ID
+
+	if err = id.Scan(raw[:]); err != nil || id != original.ID {
+		t.Errorf("Expected 16 bytes to scan but got %v", err)
+	}
+
+	if err = id.Scan(original.ID.String()); err != nil || id != original.ID {
+		t.Errorf("Expected text to scan but got %v", err)
+	}
+
+	var parent ids.NullUUID
+
+	if err = parent.Scan(nil); err != nil || parent.Valid {
+		t.Errorf("Expected NULL to scan as invalid but got %v", err)
+	}
+
+	if value, _ := parent.Value(); value != nil {
+		t.Errorf("Expected an invalid NullUUID to be NULL but got %v", value)
+	}
+}
@@ -0,0 +1,39 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ids
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+/*
+scanBytes reads a database value into a 16 byte ID. Columns can hold
+the ID as text, or as 16 raw bytes, as in BINARY(16) and UUID columns
+*/
+func scanBytes(src interface{}, parse func(s string) ([16]byte, error)) ([16]byte, error) {
+	switch v := src.(type) {
+	case string:
+		return parse(v)
+
+	case []byte:
+		if len(v) == 16 {
+			var result [16]byte
+			copy(result[:], v)
+			return result, nil
+		}
+
+		return parse(string(v))
+
+	default:
+		return [16]byte{}, fmt.Errorf("cannot scan %T into an ID", src)
+	}
+}
+
+func randomBytes(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Errorf("error reading random bytes: %w", err))
+	}
+}
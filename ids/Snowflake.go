@@ -0,0 +1,193 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ids
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	nodeBits     = 10
+	sequenceBits = 12
+	maxNode      = 1<<nodeBits - 1
+	maxSequence  = 1<<sequenceBits - 1
+)
+
+/*
+SnowflakeID is a 64 bit ID: 41 bits of milliseconds since an epoch, 10
+bits of node, and 12 bits of sequence. IDs sort by when they were made
+and fit in a BIGINT column. They marshal to JSON as strings, as
+JavaScript numbers can't hold them, and unmarshal from strings or
+numbers
+*/
+type SnowflakeID int64
+
+/*
+Snowflake makes SnowflakeIDs. Each node makes up to 4096 IDs a
+millisecond. It is safe for concurrent use
+*/
+type Snowflake struct {
+	epoch    int64
+	lastMS   int64
+	mutex    sync.Mutex
+	node     int64
+	sequence int64
+}
+
+/*
+NewSnowflake creates a Snowflake generator
+*/
+func NewSnowflake(config SnowflakeConfig) (*Snowflake, error) {
+	if config.Node < 0 || config.Node > maxNode {
+		return nil, ErrInvalidNode
+	}
+
+	if config.Epoch.IsZero() {
+		config.Epoch = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return &Snowflake{
+		epoch: config.Epoch.UnixNano() / int64(time.Millisecond),
+		node:  config.Node,
+	}, nil
+}
+
+/*
+Next returns a new ID. When this node has made 4096 IDs in the current
+millisecond, or the clock has moved backwards, it waits for the clock
+to catch up rather than repeat an ID
+*/
+func (s *Snowflake) Next() SnowflakeID {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ms := s.milliseconds()
+
+	for ms < s.lastMS {
+		time.Sleep(time.Duration(s.lastMS-ms) * time.Millisecond)
+		ms = s.milliseconds()
+	}
+
+	if ms == s.lastMS {
+		s.sequence = (s.sequence + 1) & maxSequence
+
+		if s.sequence == 0 {
+			for ms <= s.lastMS {
+				ms = s.milliseconds()
+			}
+		}
+	} else {
+		s.sequence = 0
+	}
+
+	s.lastMS = ms
+	return SnowflakeID(ms<<(nodeBits+sequenceBits) | s.node<<sequenceBits | s.sequence)
+}
+
+/*
+Time returns when an ID from this generator was made
+*/
+func (s *Snowflake) Time(id SnowflakeID) time.Time {
+	ms := int64(id)>>(nodeBits+sequenceBits) + s.epoch
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC()
+}
+
+func (s *Snowflake) milliseconds() int64 {
+	return time.Now().UnixNano()/int64(time.Millisecond) - s.epoch
+}
+
+/*
+ParseSnowflakeID parses an ID's decimal form
+*/
+func ParseSnowflakeID(s string) (SnowflakeID, error) {
+	result, err := strconv.ParseInt(s, 10, 64)
+
+	if err != nil || result < 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSnowflake, s)
+	}
+
+	return SnowflakeID(result), nil
+}
+
+/*
+Node returns the node that made the ID
+*/
+func (id SnowflakeID) Node() int64 {
+	return int64(id) >> sequenceBits & maxNode
+}
+
+/*
+Sequence returns the ID's sequence within its millisecond
+*/
+func (id SnowflakeID) Sequence() int64 {
+	return int64(id) & maxSequence
+}
+
+/*
+String returns the ID in decimal
+*/
+func (id SnowflakeID) String() string {
+	return strconv.FormatInt(int64(id), 10)
+}
+
+/*
+MarshalJSON writes the ID as a string
+*/
+func (id SnowflakeID) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + id.String() + `"`), nil
+}
+
+/*
+UnmarshalJSON reads the ID from a string or a number
+*/
+func (id *SnowflakeID) UnmarshalJSON(b []byte) error {
+	result, err := ParseSnowflakeID(string(bytes.Trim(b, `"`)))
+
+	if err != nil {
+		return err
+	}
+
+	*id = result
+	return nil
+}
+
+/*
+Scan satisfies sql.Scanner
+*/
+func (id *SnowflakeID) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case int64:
+		*id = SnowflakeID(v)
+		return nil
+
+	case []byte:
+		return id.Scan(string(v))
+
+	case string:
+		result, err := ParseSnowflakeID(v)
+
+		if err != nil {
+			return err
+		}
+
+		*id = result
+		return nil
+
+	default:
+		return fmt.Errorf("cannot scan %T into a SnowflakeID", src)
+	}
+}
+
+/*
+Value satisfies driver.Valuer, storing the ID as a BIGINT
+*/
+func (id SnowflakeID) Value() (driver.Value, error) {
+	return int64(id), nil
+}
@@ -0,0 +1,19 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ids
+
+import "time"
+
+/*
+SnowflakeConfig configures a Snowflake generator. Node identifies this
+process, from 0 to 1023, and must be unique among the processes making
+IDs at the same time, such as from a pod's ordinal. Epoch is when IDs
+start counting, and defaults to 2021-01-01 UTC. It must never change
+once IDs have been made
+*/
+type SnowflakeConfig struct {
+	Epoch time.Time
+	Node  int64
+}
@@ -0,0 +1,238 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ids
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// crockford is Crockford's base 32 alphabet, which leaves out I, L, O, and U
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var crockfordValues = func() [256]byte {
+	var result [256]byte
+
+	for index := range result {
+		result[index] = 0xff
+	}
+
+	for index := 0; index < len(crockford); index++ {
+		result[crockford[index]] = byte(index)
+
+		if crockford[index] >= 'A' {
+			result[crockford[index]+'a'-'A'] = byte(index)
+		}
+	}
+
+	// Letters that are easily mistaken for digits read as those digits
+	for _, pair := range []string{"I1", "i1", "L1", "l1", "O0", "o0"} {
+		result[pair[0]] = result[pair[1]]
+	}
+
+	return result
+}()
+
+/*
+ULID is a Universally Unique Lexicographically Sortable Identifier: a
+48 bit millisecond timestamp followed by 80 random bits, written as 26
+characters of Crockford's base 32, such as 01ARZ3NDEKTSV4RRFFQ69G5FAV.
+ULIDs sort by when they were made both as bytes and as text. It
+marshals to JSON and text as its string, and is stored in databases the
+same way. It scans from text or 16 raw bytes
+*/
+type ULID [16]byte
+
+var (
+	ulidMutex sync.Mutex
+	ulidLast  ULID
+)
+
+/*
+NewULID returns a ULID for the current time. ULIDs made in the same
+millisecond by this process still sort in order, by counting up from
+the first one's random bits
+*/
+func NewULID() ULID {
+	var result ULID
+
+	ms := time.Now().UnixNano() / int64(time.Millisecond)
+
+	ulidMutex.Lock()
+	defer ulidMutex.Unlock()
+
+	if ms <= ulidLast.milliseconds() {
+		result = ulidLast
+
+		// Count up through the random bits, carrying into the time if they run out
+		for index := 15; index >= 0; index-- {
+			result[index]++
+
+			if result[index] != 0 {
+				break
+			}
+		}
+	} else {
+		result.setMilliseconds(ms)
+		randomBytes(result[6:])
+	}
+
+	ulidLast = result
+	return result
+}
+
+/*
+ParseULID parses a ULID's 26 characters. Letters can be either case,
+and I, L, and O are read as 1, 1, and 0
+*/
+func ParseULID(s string) (ULID, error) {
+	var result ULID
+
+	if len(s) != 26 || crockfordValues[s[0]] > 7 {
+		return result, fmt.Errorf("%w: %q", ErrInvalidULID, s)
+	}
+
+	// Each character is 5 bits. The first only holds 3, as 26 characters are 130 bits
+	var accumulator uint
+	bits := 0
+	out := 15
+
+	for index := len(s) - 1; index >= 0; index-- {
+		value := crockfordValues[s[index]]
+
+		if value == 0xff {
+			return ULID{}, fmt.Errorf("%w: %q", ErrInvalidULID, s)
+		}
+
+		accumulator |= uint(value) << bits
+		bits += 5
+
+		for bits >= 8 && out >= 0 {
+			result[out] = byte(accumulator)
+			accumulator >>= 8
+			bits -= 8
+			out--
+		}
+	}
+
+	return result, nil
+}
+
+/*
+MustParseULID parses a ULID, panicking if it is invalid
+*/
+func MustParseULID(s string) ULID {
+	result, err := ParseULID(s)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return result
+}
+
+/*
+IsULID returns true when s is a ULID ParseULID accepts
+*/
+func IsULID(s string) bool {
+	_, err := ParseULID(s)
+	return err == nil
+}
+
+/*
+IsZero returns true for the ULID with every bit zero
+*/
+func (u ULID) IsZero() bool {
+	return u == ULID{}
+}
+
+/*
+String returns the ULID's 26 characters
+*/
+func (u ULID) String() string {
+	b := make([]byte, 26)
+
+	var accumulator uint
+	bits := 0
+	in := 15
+
+	for index := 25; index >= 0; index-- {
+		if bits < 5 && in >= 0 {
+			accumulator |= uint(u[in]) << bits
+			bits += 8
+			in--
+		}
+
+		b[index] = crockford[accumulator&0x1f]
+		accumulator >>= 5
+		bits -= 5
+	}
+
+	return string(b)
+}
+
+/*
+Time returns when the ULID was made
+*/
+func (u ULID) Time() time.Time {
+	return time.Unix(0, u.milliseconds()*int64(time.Millisecond)).UTC()
+}
+
+/*
+MarshalText satisfies encoding.TextMarshaler, which JSON uses too
+*/
+func (u ULID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+/*
+UnmarshalText satisfies encoding.TextUnmarshaler, which JSON uses too
+*/
+func (u *ULID) UnmarshalText(b []byte) error {
+	result, err := ParseULID(string(b))
+
+	if err != nil {
+		return err
+	}
+
+	*u = result
+	return nil
+}
+
+/*
+Scan satisfies sql.Scanner
+*/
+func (u *ULID) Scan(src interface{}) error {
+	result, err := scanBytes(src, func(s string) ([16]byte, error) { return ParseULID(s) })
+
+	if err != nil {
+		return err
+	}
+
+	*u = result
+	return nil
+}
+
+/*
+Value satisfies driver.Valuer, storing the ULID as text
+*/
+func (u ULID) Value() (driver.Value, error) {
+	return u.String(), nil
+}
+
+func (u ULID) milliseconds() int64 {
+	return int64(u[0])<<40 | int64(u[1])<<32 | int64(u[2])<<24 | int64(u[3])<<16 | int64(u[4])<<8 | int64(u[5])
+}
+
+func (u *ULID) setMilliseconds(ms int64) {
+	u[0] = byte(ms >> 40)
+	u[1] = byte(ms >> 32)
+	u[2] = byte(ms >> 24)
+	u[3] = byte(ms >> 16)
+	u[4] = byte(ms >> 8)
+	u[5] = byte(ms)
+}
@@ -0,0 +1,308 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ids
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+UUID is an RFC 9562 UUID. It marshals to JSON and text as the usual
+36 character form, and is stored in databases the same way. It scans
+from text or 16 raw bytes
+*/
+type UUID [16]byte
+
+/*
+Nil is the UUID with every bit zero
+*/
+var Nil UUID
+
+var (
+	v7Mutex    sync.Mutex
+	v7LastMS   int64
+	v7Sequence uint16
+)
+
+/*
+NewV4 returns a random UUID
+*/
+func NewV4() UUID {
+	var result UUID
+
+	randomBytes(result[:])
+	result.setVersion(4)
+
+	return result
+}
+
+/*
+NewV7 returns a UUID that starts with the current time in milliseconds,
+so UUIDs sort in the order they were made, which keeps database indexes
+compact. UUIDs made in the same millisecond by this process still sort
+in order
+*/
+func NewV7() UUID {
+	var result UUID
+
+	randomBytes(result[6:])
+
+	v7Mutex.Lock()
+
+	ms := time.Now().UnixNano() / int64(time.Millisecond)
+
+	// A 12 bit counter follows the time. It starts at a random value with
+	// room to count up, and borrows the next millisecond when it runs out
+	if ms > v7LastMS {
+		v7LastMS = ms
+		v7Sequence = (uint16(result[6])<<8 | uint16(result[7])) & 0x7ff
+	} else {
+		v7Sequence++
+
+		if v7Sequence > 0xfff {
+			v7LastMS++
+			v7Sequence = 0
+		}
+	}
+
+	ms, sequence := v7LastMS, v7Sequence
+	v7Mutex.Unlock()
+
+	result[0] = byte(ms >> 40)
+	result[1] = byte(ms >> 32)
+	result[2] = byte(ms >> 24)
+	result[3] = byte(ms >> 16)
+	result[4] = byte(ms >> 8)
+	result[5] = byte(ms)
+	result[6] = byte(sequence >> 8)
+	result[7] = byte(sequence)
+	result.setVersion(7)
+
+	return result
+}
+
+/*
+ParseUUID parses a UUID in the usual 36 character form, as 32 hex
+digits, in braces, or as a urn:uuid: URN. Hex digits can be either case
+*/
+func ParseUUID(s string) (UUID, error) {
+	var result UUID
+	text := s
+
+	switch {
+	case len(text) == 45 && strings.EqualFold(text[:9], "urn:uuid:"):
+		text = text[9:]
+
+	case len(text) == 38 && text[0] == '{' && text[37] == '}':
+		text = text[1:37]
+	}
+
+	if len(text) == 36 {
+		if text[8] != '-' || text[13] != '-' || text[18] != '-' || text[23] != '-' {
+			return Nil, fmt.Errorf("%w: %q", ErrInvalidUUID, s)
+		}
+
+		text = text[:8] + text[9:13] + text[14:18] + text[19:23] + text[24:]
+	}
+
+	if len(text) != 32 {
+		return Nil, fmt.Errorf("%w: %q", ErrInvalidUUID, s)
+	}
+
+	if _, err := hex.Decode(result[:], []byte(text)); err != nil {
+		return Nil, fmt.Errorf("%w: %q", ErrInvalidUUID, s)
+	}
+
+	return result, nil
+}
+
+/*
+MustParseUUID parses a UUID, panicking if it is invalid. Use it for
+constants
+*/
+func MustParseUUID(s string) UUID {
+	result, err := ParseUUID(s)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return result
+}
+
+/*
+IsUUID returns true when s is a UUID ParseUUID accepts
+*/
+func IsUUID(s string) bool {
+	_, err := ParseUUID(s)
+	return err == nil
+}
+
+/*
+IsZero returns true for the Nil UUID
+*/
+func (u UUID) IsZero() bool {
+	return u == Nil
+}
+
+/*
+String returns the UUID in lowercase 36 character form
+*/
+func (u UUID) String() string {
+	b := make([]byte, 36)
+
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+
+	return string(b)
+}
+
+/*
+Time returns when a version 7 UUID was made, or the zero time for other
+versions
+*/
+func (u UUID) Time() time.Time {
+	if u.Version() != 7 {
+		return time.Time{}
+	}
+
+	ms := int64(u[0])<<40 | int64(u[1])<<32 | int64(u[2])<<24 | int64(u[3])<<16 | int64(u[4])<<8 | int64(u[5])
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC()
+}
+
+/*
+Version returns the UUID's version, such as 4 or 7
+*/
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+/*
+MarshalText satisfies encoding.TextMarshaler, which JSON uses too
+*/
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+/*
+UnmarshalText satisfies encoding.TextUnmarshaler, which JSON uses too
+*/
+func (u *UUID) UnmarshalText(b []byte) error {
+	result, err := ParseUUID(string(b))
+
+	if err != nil {
+		return err
+	}
+
+	*u = result
+	return nil
+}
+
+/*
+Scan satisfies sql.Scanner
+*/
+func (u *UUID) Scan(src interface{}) error {
+	result, err := scanBytes(src, func(s string) ([16]byte, error) { return ParseUUID(s) })
+
+	if err != nil {
+		return err
+	}
+
+	*u = result
+	return nil
+}
+
+/*
+Value satisfies driver.Valuer, storing the UUID as text
+*/
+func (u UUID) Value() (driver.Value, error) {
+	return u.String(), nil
+}
+
+func (u *UUID) setVersion(version byte) {
+	u[6] = u[6]&0x0f | version<<4
+	u[8] = u[8]&0x3f | 0x80
+}
+
+/*
+NullUUID is a UUID that can be NULL in a database, or null in JSON
+*/
+type NullUUID struct {
+	UUID  UUID
+	Valid bool
+}
+
+/*
+MarshalJSON writes null for an invalid NullUUID
+*/
+func (n NullUUID) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+
+	return []byte(`"` + n.UUID.String() + `"`), nil
+}
+
+/*
+UnmarshalJSON reads null or a UUID
+*/
+func (n *NullUUID) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		*n = NullUUID{}
+		return nil
+	}
+
+	if len(b) < 2 || b[0] != '"' || b[len(b)-1] != '"' {
+		return fmt.Errorf("%w: %s", ErrInvalidUUID, b)
+	}
+
+	if err := n.UUID.UnmarshalText(b[1 : len(b)-1]); err != nil {
+		return err
+	}
+
+	n.Valid = true
+	return nil
+}
+
+/*
+Scan satisfies sql.Scanner
+*/
+func (n *NullUUID) Scan(src interface{}) error {
+	if src == nil {
+		*n = NullUUID{}
+		return nil
+	}
+
+	if err := n.UUID.Scan(src); err != nil {
+		return err
+	}
+
+	n.Valid = true
+	return nil
+}
+
+/*
+Value satisfies driver.Valuer
+*/
+func (n NullUUID) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+
+	return n.UUID.Value()
+}
@@ -0,0 +1,205 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+/*
+Package awsauth signs requests to AWS, and services compatible with it,
+with Signature Version 4. It is shared by the secrets and storage
+packages so there is one copy of the signing code
+*/
+package awsauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	amzDateFormat = "20060102T150405Z"
+	dateFormat    = "20060102"
+
+	/*
+	 * UnsignedPayload is used in place of the payload hash by services
+	 * that allow it, such as S3, so bodies can be streamed
+	 */
+	UnsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+/*
+Credentials are the keys to sign with, and the region and service the
+signature is scoped to
+*/
+type Credentials struct {
+	AccessKeyID     string
+	Region          string
+	SecretAccessKey string
+	Service         string
+	SessionToken    string
+}
+
+/*
+Sign adds Signature Version 4 headers to a request. Every header
+already on the request is signed, along with the host. payloadHash is
+HashPayload of the body, or UnsignedPayload
+*/
+func Sign(request *http.Request, payloadHash string, credentials Credentials, now time.Time) {
+	now = now.UTC()
+
+	request.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+
+	if credentials.SessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": request.URL.Host}
+
+	for name, values := range request.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	canonicalHeaders := &strings.Builder{}
+
+	for _, name := range names {
+		fmt.Fprintf(canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+
+	signedHeaders := strings.Join(names, ";")
+	signature := signature(credentials, now, request.Method, request.URL, canonicalHeaders.String(), signedHeaders, payloadHash)
+
+	request.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		credentials.AccessKeyID,
+		credentialScope(credentials, now),
+		signedHeaders,
+		signature,
+	))
+}
+
+/*
+Presign adds query string authentication to u and returns it, so anyone
+holding the URL can make a method request until it expires. Only the
+host header is signed, and the payload is unsigned
+*/
+func Presign(u *url.URL, method string, credentials Credentials, now time.Time, expires time.Duration) string {
+	now = now.UTC()
+	query := u.Query()
+
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", credentials.AccessKeyID+"/"+credentialScope(credentials, now))
+	query.Set("X-Amz-Date", now.Format(amzDateFormat))
+	query.Set("X-Amz-Expires", fmt.Sprintf("%d", int64(expires/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	if credentials.SessionToken != "" {
+		query.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	u.RawQuery = CanonicalQuery(query)
+	signature := signature(credentials, now, method, u, "host:"+u.Host+"\n", "host", UnsignedPayload)
+
+	return u.String() + "&X-Amz-Signature=" + signature
+}
+
+/*
+HashPayload returns the hex encoded SHA-256 hash of a request body
+*/
+func HashPayload(body []byte) string {
+	hash := sha256.Sum256(body)
+	return hex.EncodeToString(hash[:])
+}
+
+/*
+CanonicalQuery encodes a query string the way SigV4 expects: sorted,
+with spaces as %20 rather than +
+*/
+func CanonicalQuery(values url.Values) string {
+	pairs := []string{}
+
+	for name, list := range values {
+		for _, value := range list {
+			pairs = append(pairs, Escape(name, true)+"="+Escape(value, true))
+		}
+	}
+
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+/*
+Escape percent encodes everything but unreserved characters, and
+slashes unless encodeSlash is set
+*/
+func Escape(s string, encodeSlash bool) string {
+	builder := strings.Builder{}
+
+	for _, b := range []byte(s) {
+		switch {
+		case b >= 'A' && b <= 'Z', b >= 'a' && b <= 'z', b >= '0' && b <= '9', b == '-', b == '.', b == '_', b == '~':
+			builder.WriteByte(b)
+
+		case b == '/' && !encodeSlash:
+			builder.WriteByte(b)
+
+		default:
+			fmt.Fprintf(&builder, "%%%02X", b)
+		}
+	}
+
+	return builder.String()
+}
+
+func signature(credentials Credentials, now time.Time, method string, u *url.URL, canonicalHeaders, signedHeaders, payloadHash string) string {
+	path := u.EscapedPath()
+
+	if path == "" {
+		path = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		path,
+		CanonicalQuery(u.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		now.Format(amzDateFormat),
+		credentialScope(credentials, now),
+		HashPayload([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+credentials.SecretAccessKey), now.Format(dateFormat))
+	key = hmacSHA256(key, credentials.Region)
+	key = hmacSHA256(key, credentials.Service)
+	key = hmacSHA256(key, "aws4_request")
+
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func credentialScope(credentials Credentials, now time.Time) string {
+	return strings.Join([]string{now.Format(dateFormat), credentials.Region, credentials.Service, "aws4_request"}, "/")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package jsonpatch
+
+import (
+	"encoding/json"
+)
+
+/*
+MergePatch applies an RFC 7386 JSON Merge Patch to a JSON document and
+returns the result. Members of the patch replace those in the document,
+objects are merged member by member, and null removes a member
+*/
+func MergePatch(document, patch []byte) ([]byte, error) {
+	var (
+		doc     interface{}
+		err     error
+		changes interface{}
+	)
+
+	if doc, err = decode(document); err != nil {
+		return nil, err
+	}
+
+	if changes, err = decode(patch); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mergePatch(doc, changes))
+}
+
+/*
+MergePatchPaths returns the paths a merge patch changes, as JSON
+pointers. Objects in the patch are followed down to the members they
+set or remove
+*/
+func MergePatchPaths(patch []byte) ([]string, error) {
+	changes, err := decode(patch)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, 10)
+	mergePatchPaths(changes, "", &result)
+
+	return result, nil
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	changes, ok := patch.(map[string]interface{})
+
+	if !ok {
+		return patch
+	}
+
+	result, ok := target.(map[string]interface{})
+
+	if !ok {
+		result = make(map[string]interface{}, len(changes))
+	}
+
+	for key, value := range changes {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+
+		result[key] = mergePatch(result[key], value)
+	}
+
+	return result
+}
+
+func mergePatchPaths(patch interface{}, prefix string, result *[]string) {
+	changes, ok := patch.(map[string]interface{})
+
+	if !ok || (len(changes) == 0 && prefix != "") {
+		*result = append(*result, prefix)
+		return
+	}
+
+	for key, value := range changes {
+		mergePatchPaths(value, prefix+"/"+escapeToken(key), result)
+	}
+}
@@ -0,0 +1,379 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrFieldNotAllowed = errors.New("field can't be patched")
+	ErrInvalidPatch    = errors.New("invalid patch")
+	ErrPathNotFound    = errors.New("path not found")
+	ErrTestFailed      = errors.New("patch test failed")
+	ErrTooManyOps      = errors.New("patch has too many operations")
+)
+
+const (
+	JSONPatchContentType  = "application/json-patch+json"
+	MergePatchContentType = "application/merge-patch+json"
+)
+
+/*
+Operation is one step of an RFC 6902 JSON Patch. Op is add, remove,
+replace, move, copy, or test. Path and From are JSON pointers, such as
+"/address/city" or "/tags/0", with "/tags/-" meaning the end of an array
+*/
+type Operation struct {
+	From  string          `json:"from,omitempty"`
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+/*
+Patch is an RFC 6902 JSON Patch: operations applied in order, all or
+nothing
+*/
+type Patch []Operation
+
+/*
+DecodePatch reads a JSON Patch, checking each operation has what its op
+needs
+*/
+func DecodePatch(b []byte) (Patch, error) {
+	var (
+		err    error
+		fields []map[string]json.RawMessage
+		result Patch
+	)
+
+	if err = json.Unmarshal(b, &fields); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, err.Error())
+	}
+
+	if err = json.Unmarshal(b, &result); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, err.Error())
+	}
+
+	for index, operation := range result {
+		_, hasValue := fields[index]["value"]
+		_, hasFrom := fields[index]["from"]
+		_, hasPath := fields[index]["path"]
+
+		switch {
+		case !hasPath:
+			return nil, fmt.Errorf("%w: operation %d has no path", ErrInvalidPatch, index)
+
+		case operation.Op == "add" || operation.Op == "replace" || operation.Op == "test":
+			if !hasValue {
+				return nil, fmt.Errorf("%w: %s operation %d has no value", ErrInvalidPatch, operation.Op, index)
+			}
+
+		case operation.Op == "move" || operation.Op == "copy":
+			if !hasFrom {
+				return nil, fmt.Errorf("%w: %s operation %d has no from", ErrInvalidPatch, operation.Op, index)
+			}
+
+		case operation.Op != "remove":
+			return nil, fmt.Errorf("%w: unknown op '%s'", ErrInvalidPatch, operation.Op)
+		}
+	}
+
+	return result, nil
+}
+
+/*
+Apply applies the patch to a JSON document and returns the result. When
+any operation fails the error says which, and nothing is applied
+*/
+func (p Patch) Apply(document []byte) ([]byte, error) {
+	var (
+		doc interface{}
+		err error
+	)
+
+	if doc, err = decode(document); err != nil {
+		return nil, err
+	}
+
+	if doc, err = p.apply(doc); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(doc)
+}
+
+/*
+Paths returns the paths the patch changes. A move changes both its from
+and its path. Tests and the source of copies are only read
+*/
+func (p Patch) Paths() []string {
+	result := make([]string, 0, len(p))
+
+	for _, operation := range p {
+		switch operation.Op {
+		case "test":
+			continue
+
+		case "move":
+			result = append(result, operation.From, operation.Path)
+
+		default:
+			result = append(result, operation.Path)
+		}
+	}
+
+	return result
+}
+
+func (p Patch) apply(doc interface{}) (interface{}, error) {
+	for index, operation := range p {
+		var err error
+
+		if doc, err = operation.apply(doc); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", index, operation.Op, operation.Path, err)
+		}
+	}
+
+	return doc, nil
+}
+
+func (o Operation) apply(doc interface{}) (interface{}, error) {
+	var (
+		err   error
+		from  []string
+		path  []string
+		value interface{}
+	)
+
+	if path, err = parsePointer(o.Path); err != nil {
+		return nil, err
+	}
+
+	if len(o.Value) > 0 {
+		if value, err = decode(o.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	if o.Op == "move" || o.Op == "copy" {
+		if from, err = parsePointer(o.From); err != nil {
+			return nil, err
+		}
+
+		if value, err = get(doc, from); err != nil {
+			return nil, err
+		}
+	}
+
+	switch o.Op {
+	case "add":
+		return add(doc, path, value)
+
+	case "remove":
+		return remove(doc, path)
+
+	case "replace":
+		if _, err = get(doc, path); err != nil {
+			return nil, err
+		}
+
+		if doc, err = remove(doc, path); err != nil {
+			return nil, err
+		}
+
+		return add(doc, path, value)
+
+	case "move":
+		if o.Path != o.From && strings.HasPrefix(o.Path, o.From+"/") {
+			return nil, fmt.Errorf("%w: can't move a value into itself", ErrInvalidPatch)
+		}
+
+		if doc, err = remove(doc, from); err != nil {
+			return nil, err
+		}
+
+		return add(doc, path, value)
+
+	case "copy":
+		return add(doc, path, deepCopy(value))
+
+	case "test":
+		current, err := get(doc, path)
+
+		if err != nil || !equal(current, value) {
+			return nil, ErrTestFailed
+		}
+
+		return doc, nil
+
+	default:
+		return nil, fmt.Errorf("%w: unknown op '%s'", ErrInvalidPatch, o.Op)
+	}
+}
+
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return modify(doc, path, func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			c[token] = value
+			return c, nil
+
+		case []interface{}:
+			if token == "-" {
+				return append(c, value), nil
+			}
+
+			index, err := arrayIndex(token, len(c))
+
+			if err != nil {
+				return nil, err
+			}
+
+			result := make([]interface{}, 0, len(c)+1)
+			result = append(result, c[:index]...)
+			result = append(result, value)
+			return append(result, c[index:]...), nil
+
+		default:
+			return nil, fmt.Errorf("%w: can't add '%s' to a value that isn't an object or array", ErrPathNotFound, token)
+		}
+	})
+}
+
+func remove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: can't remove the whole document", ErrInvalidPatch)
+	}
+
+	return modify(doc, path, func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			if _, ok := c[token]; !ok {
+				return nil, fmt.Errorf("%w: no member '%s'", ErrPathNotFound, token)
+			}
+
+			delete(c, token)
+			return c, nil
+
+		case []interface{}:
+			index, err := arrayIndex(token, len(c)-1)
+
+			if err != nil {
+				return nil, err
+			}
+
+			result := make([]interface{}, 0, len(c)-1)
+			result = append(result, c[:index]...)
+			return append(result, c[index+1:]...), nil
+
+		default:
+			return nil, fmt.Errorf("%w: no member '%s'", ErrPathNotFound, token)
+		}
+	})
+}
+
+/*
+decode reads JSON keeping numbers as json.Number, so large integers
+survive a patch unchanged
+*/
+func decode(b []byte) (interface{}, error) {
+	var result interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&result); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, err.Error())
+	}
+
+	return result, nil
+}
+
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+
+		for key, child := range v {
+			result[key] = deepCopy(child)
+		}
+
+		return result
+
+	case []interface{}:
+		result := make([]interface{}, len(v))
+
+		for index, child := range v {
+			result[index] = deepCopy(child)
+		}
+
+		return result
+
+	default:
+		return v
+	}
+}
+
+/*
+equal compares JSON values, treating numbers as equal when their values
+are, so 1 and 1.0 match
+*/
+func equal(a, b interface{}) bool {
+	numberA, okA := a.(json.Number)
+	numberB, okB := b.(json.Number)
+
+	if okA && okB {
+		floatA, errA := numberA.Float64()
+		floatB, errB := numberB.Float64()
+
+		return errA == nil && errB == nil && floatA == floatB
+	}
+
+	switch v := a.(type) {
+	case map[string]interface{}:
+		other, ok := b.(map[string]interface{})
+
+		if !ok || len(v) != len(other) {
+			return false
+		}
+
+		for key, child := range v {
+			if otherChild, ok := other[key]; !ok || !equal(child, otherChild) {
+				return false
+			}
+		}
+
+		return true
+
+	case []interface{}:
+		other, ok := b.([]interface{})
+
+		if !ok || len(v) != len(other) {
+			return false
+		}
+
+		for index := range v {
+			if !equal(v[index], other[index]) {
+				return false
+			}
+		}
+
+		return true
+
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
@@ -0,0 +1,219 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package jsonpatch_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/jsonpatch"
+	"github.com/ResurgenceIT/kit/v6/validate"
+	"github.com/labstack/echo/v4"
+)
+
+func jsonEqual(t *testing.T, expected string, actual []byte) bool {
+	var a, b interface{}
+
+	if err := json.Unmarshal([]byte(expected), &a); err != nil {
+		t.Fatalf("Bad expected JSON %s", expected)
+	}
+
+	if err := json.Unmarshal(actual, &b); err != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+func TestPatchApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		patch    string
+		expected string
+		err      error
+	}{
+		{name: "Add member", document: `{"foo":"bar"}`, patch: `[{"op":"add","path":"/baz","value":"qux"}]`, expected: `{"baz":"qux","foo":"bar"}`},
+		{name: "Add to array", document: `{"foo":["bar","baz"]}`, patch: `[{"op":"add","path":"/foo/1","value":"qux"}]`, expected: `{"foo":["bar","qux","baz"]}`},
+		{name: "Append", document: `{"foo":["bar"]}`, patch: `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, expected: `{"foo":["bar",["abc","def"]]}`},
+		{name: "Remove", document: `{"baz":"qux","foo":"bar"}`, patch: `[{"op":"remove","path":"/baz"}]`, expected: `{"foo":"bar"}`},
+		{name: "Remove element", document: `{"foo":["bar","qux","baz"]}`, patch: `[{"op":"remove","path":"/foo/1"}]`, expected: `{"foo":["bar","baz"]}`},
+		{name: "Replace", document: `{"baz":"qux","foo":"bar"}`, patch: `[{"op":"replace","path":"/baz","value":"boo"}]`, expected: `{"baz":"boo","foo":"bar"}`},
+		{name: "Move", document: `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, patch: `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, expected: `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{name: "Move element", document: `{"foo":["all","grass","cows","eat"]}`, patch: `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, expected: `{"foo":["all","cows","eat","grass"]}`},
+		{name: "Copy", document: `{"a":{"b":1}}`, patch: `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`, expected: `{"a":{"b":1},"c":{"b":2}}`},
+		{name: "Test passes", document: `{"baz":"qux","foo":["a",2,"c"]}`, patch: `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2.0}]`, expected: `{"baz":"qux","foo":["a",2,"c"]}`},
+		{name: "Test fails", document: `{"baz":"qux"}`, patch: `[{"op":"test","path":"/baz","value":"bar"}]`, err: jsonpatch.ErrTestFailed},
+		{name: "Escaped keys", document: `{"a/b":1,"m~n":2}`, patch: `[{"op":"remove","path":"/a~1b"},{"op":"replace","path":"/m~0n","value":3}]`, expected: `{"m~n":3}`},
+		{name: "Missing target", document: `{"foo":"bar"}`, patch: `[{"op":"add","path":"/baz/bat","value":"qux"}]`, err: jsonpatch.ErrPathNotFound},
+		{name: "Out of range", document: `{"foo":[1]}`, patch: `[{"op":"add","path":"/foo/3","value":2}]`, err: jsonpatch.ErrPathNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			patch, err := jsonpatch.DecodePatch([]byte(test.patch))
+
+			if err != nil {
+				t.Fatalf("Expected a patch but got %s", err.Error())
+			}
+
+			result, err := patch.Apply([]byte(test.document))
+
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Errorf("Expected %v but got %v", test.err, err)
+				}
+
+				return
+			}
+
+			if err != nil || !jsonEqual(t, test.expected, result) {
+				t.Errorf("Expected %s but got %s, %v", test.expected, result, err)
+			}
+		})
+	}
+}
+
+func TestPatchKeepsLargeNumbers(t *testing.T) {
+	patch, _ := jsonpatch.DecodePatch([]byte(`[{"op":"add","path":"/x","value":1}]`))
+	result, err := patch.Apply([]byte(`{"id":9007199254740993}`))
+
+	if err != nil || string(result) != `{"id":9007199254740993,"x":1}` {
+		t.Errorf("Expected the ID unchanged but got %s, %v", result, err)
+	}
+}
+
+func TestDecodePatch(t *testing.T) {
+	for _, patch := range []string{`{"op":"add"}`, `[{"op":"add","path":"/a"}]`, `[{"op":"move","path":"/a"}]`, `[{"op":"merge","path":"/a"}]`, `[{"op":"remove"}]`} {
+		if _, err := jsonpatch.DecodePatch([]byte(patch)); !errors.Is(err, jsonpatch.ErrInvalidPatch) {
+			t.Errorf("Expected ErrInvalidPatch for %s but got %v", patch, err)
+		}
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		document string
+		patch    string
+		expected string
+	}{
+		{document: `{"a":"b"}`, patch: `{"a":"c"}`, expected: `{"a":"c"}`},
+		{document: `{"a":"b"}`, patch: `{"b":"c"}`, expected: `{"a":"b","b":"c"}`},
+		{document: `{"a":"b","b":"c"}`, patch: `{"a":null}`, expected: `{"b":"c"}`},
+		{document: `{"a":[{"b":"c"}]}`, patch: `{"a":[1]}`, expected: `{"a":[1]}`},
+		{document: `{"e":null}`, patch: `{"a":1}`, expected: `{"e":null,"a":1}`},
+		{document: `[1,2]`, patch: `{"a":"b","c":null}`, expected: `{"a":"b"}`},
+		{document: `{}`, patch: `{"a":{"bb":{"ccc":null}}}`, expected: `{"a":{"bb":{}}}`},
+	}
+
+	for _, test := range tests {
+		result, err := jsonpatch.MergePatch([]byte(test.document), []byte(test.patch))
+
+		if err != nil || !jsonEqual(t, test.expected, result) {
+			t.Errorf("Expected %s patching %s with %s but got %s, %v", test.expected, test.document, test.patch, result, err)
+		}
+	}
+}
+
+type address struct {
+	City string `json:"city" validate:"required"`
+	Zip  string `json:"zip"`
+}
+
+type user struct {
+	Address address  `json:"address"`
+	Email   string   `json:"email" validate:"required,email"`
+	ID      string   `json:"id"`
+	Name    string   `json:"name" validate:"max=10"`
+	Role    string   `json:"role"`
+	Tags    []string `json:"tags"`
+}
+
+func TestPatcher(t *testing.T) {
+	patcher := jsonpatch.NewPatcher(jsonpatch.PatcherConfig{Allowed: []string{"/address", "/email", "/name", "/tags"}})
+	original := user{Address: address{City: "Paris"}, Email: "bob@example.com", ID: "1", Name: "Bob", Role: "member", Tags: []string{}}
+
+	tests := []struct {
+		name        string
+		contentType string
+		patch       string
+		status      int
+		check       func(u user) bool
+	}{
+		{name: "Merge", contentType: jsonpatch.MergePatchContentType, patch: `{"name":"Robert","address":{"zip":"75001"}}`, check: func(u user) bool {
+			return u.Name == "Robert" && u.Address.City == "Paris" && u.Address.Zip == "75001" && u.Role == "member"
+		}},
+		{name: "JSON Patch", contentType: jsonpatch.JSONPatchContentType, patch: `[{"op":"add","path":"/tags/-","value":"vip"},{"op":"test","path":"/name","value":"Bob"}]`, check: func(u user) bool {
+			return len(u.Tags) == 1 && u.Tags[0] == "vip" && u.Name == "Bob"
+		}},
+		{name: "Not allowed", contentType: jsonpatch.MergePatchContentType, patch: `{"role":"admin"}`, status: http.StatusForbidden},
+		{name: "Move not allowed", contentType: jsonpatch.JSONPatchContentType, patch: `[{"op":"move","from":"/id","path":"/name"}]`, status: http.StatusForbidden},
+		{name: "Invalid", contentType: jsonpatch.MergePatchContentType, patch: `{"email":"not an email"}`, status: http.StatusUnprocessableEntity},
+		{name: "Nested invalid", contentType: jsonpatch.MergePatchContentType, patch: `{"address":{"city":null,"zip":"75001"}}`, status: http.StatusUnprocessableEntity},
+		{name: "Wrong type", contentType: jsonpatch.MergePatchContentType, patch: `{"name":5}`, status: http.StatusBadRequest},
+		{name: "Test fails", contentType: jsonpatch.JSONPatchContentType, patch: `[{"op":"test","path":"/name","value":"Alice"}]`, status: http.StatusConflict},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			target := original
+			err := patcher.Apply(&target, test.contentType, []byte(test.patch))
+
+			if test.status != 0 {
+				if status := jsonpatch.HTTPStatus(err); status != test.status {
+					t.Errorf("Expected %d but got %d for %v", test.status, status, err)
+				}
+
+				if !reflect.DeepEqual(target, original) {
+					t.Errorf("Expected a failed patch to leave the target alone but got %+v", target)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected the patch to apply but got %s", err.Error())
+			}
+
+			if !test.check(target) {
+				t.Errorf("Unexpected result %+v", target)
+			}
+		})
+	}
+}
+
+func TestBind(t *testing.T) {
+	patcher := jsonpatch.NewPatcher(jsonpatch.PatcherConfig{Validator: validate.NewValidator(validate.ValidatorConfig{})})
+	e := echo.New()
+
+	tests := []struct {
+		contentType string
+		expected    error
+	}{
+		{contentType: "application/merge-patch+json; charset=utf-8"},
+		{contentType: "text/plain", expected: echo.ErrUnsupportedMediaType},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodPatch, "/users/1", strings.NewReader(`{"name":"Al"}`))
+		request.Header.Set(echo.HeaderContentType, test.contentType)
+
+		target := map[string]interface{}{"name": "Bob", "id": "1"}
+		err := patcher.Bind(e.NewContext(request, httptest.NewRecorder()), &target)
+
+		if err != test.expected {
+			t.Errorf("Expected %v for %s but got %v", test.expected, test.contentType, err)
+		}
+
+		if test.expected == nil && (target["name"] != "Al" || target["id"] != "1") {
+			t.Errorf("Expected the map to be patched but got %v", target)
+		}
+	}
+}
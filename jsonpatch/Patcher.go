@@ -0,0 +1,220 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/ResurgenceIT/kit/v6/validate"
+	"github.com/labstack/echo/v4"
+)
+
+/*
+Patcher applies patches from clients to structs or maps, such as in a
+PATCH handler. The target is written to JSON, patched, and read back
+into a new value, which is validated before it replaces the target, so
+a failed patch leaves the target unchanged
+*/
+type Patcher struct {
+	config   PatcherConfig
+	validate func(value interface{}) error
+}
+
+/*
+NewPatcher creates a patcher
+*/
+func NewPatcher(config PatcherConfig) *Patcher {
+	if config.MaxOperations <= 0 {
+		config.MaxOperations = 100
+	}
+
+	result := &Patcher{
+		config:   config,
+		validate: validate.Struct,
+	}
+
+	if config.Validator != nil {
+		result.validate = config.Validator.Struct
+	}
+
+	return result
+}
+
+/*
+ApplyMergePatch applies an RFC 7386 merge patch to target, a pointer to
+a struct or map
+*/
+func (p *Patcher) ApplyMergePatch(target interface{}, patch []byte) error {
+	paths, err := MergePatchPaths(patch)
+
+	if err != nil {
+		return err
+	}
+
+	if err = p.checkPaths(paths); err != nil {
+		return err
+	}
+
+	return p.apply(target, func(document []byte) ([]byte, error) {
+		return MergePatch(document, patch)
+	})
+}
+
+/*
+ApplyJSONPatch applies an RFC 6902 JSON Patch to target, a pointer to a
+struct or map
+*/
+func (p *Patcher) ApplyJSONPatch(target interface{}, patch []byte) error {
+	operations, err := DecodePatch(patch)
+
+	if err != nil {
+		return err
+	}
+
+	if len(operations) > p.config.MaxOperations {
+		return fmt.Errorf("%w: %d is more than %d", ErrTooManyOps, len(operations), p.config.MaxOperations)
+	}
+
+	if err = p.checkPaths(operations.Paths()); err != nil {
+		return err
+	}
+
+	return p.apply(target, operations.Apply)
+}
+
+/*
+Apply applies a patch to target, choosing the kind of patch by content
+type. application/merge-patch+json and application/json are merge
+patches, and application/json-patch+json is a JSON Patch. Other content
+types return echo.ErrUnsupportedMediaType
+*/
+func (p *Patcher) Apply(target interface{}, contentType string, patch []byte) error {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch mediaType {
+	case MergePatchContentType, echo.MIMEApplicationJSON:
+		return p.ApplyMergePatch(target, patch)
+
+	case JSONPatchContentType:
+		return p.ApplyJSONPatch(target, patch)
+
+	default:
+		return echo.ErrUnsupportedMediaType
+	}
+}
+
+/*
+Bind reads a patch from an Echo request body and applies it to target.
+
+	func updateUser(ctx echo.Context) error {
+		user, err := users.Get(ctx.Request().Context(), ctx.Param("id"))
+		...
+		if err = patcher.Bind(ctx, &user); err != nil {
+			return err
+		}
+
+		return users.Save(ctx.Request().Context(), user)
+	}
+*/
+func (p *Patcher) Bind(ctx echo.Context, target interface{}) error {
+	body, err := io.ReadAll(ctx.Request().Body)
+
+	if err != nil {
+		return fmt.Errorf("error reading patch: %w", err)
+	}
+
+	return p.Apply(target, ctx.Request().Header.Get(echo.HeaderContentType), body)
+}
+
+/*
+HTTPStatus returns the status to respond with for an error from the
+patcher: 400 for bad patches, 403 for fields that can't be changed, 409
+for a failed test operation, 413 for too many operations, and 422 for
+validation failures. Use it in an apierror mapper
+*/
+func HTTPStatus(err error) int {
+	var validationErrors validate.ValidationErrors
+
+	switch {
+	case errors.Is(err, ErrFieldNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, ErrTestFailed):
+		return http.StatusConflict
+	case errors.Is(err, ErrTooManyOps):
+		return http.StatusRequestEntityTooLarge
+	case errors.As(err, &validationErrors):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, ErrInvalidPatch), errors.Is(err, ErrPathNotFound):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (p *Patcher) apply(target interface{}, patch func(document []byte) ([]byte, error)) error {
+	var (
+		document []byte
+		err      error
+		patched  []byte
+	)
+
+	rv := reflect.ValueOf(target)
+
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("%w: target must be a pointer", ErrInvalidPatch)
+	}
+
+	if document, err = json.Marshal(target); err != nil {
+		return fmt.Errorf("error marshaling patch target: %w", err)
+	}
+
+	if patched, err = patch(document); err != nil {
+		return err
+	}
+
+	result := reflect.New(rv.Elem().Type())
+
+	if err = json.Unmarshal(patched, result.Interface()); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPatch, err.Error())
+	}
+
+	if err = p.validate(result.Interface()); err != nil && !errors.Is(err, validate.ErrNotStruct) {
+		return err
+	}
+
+	rv.Elem().Set(result.Elem())
+	return nil
+}
+
+func (p *Patcher) checkPaths(paths []string) error {
+	if len(p.config.Allowed) == 0 {
+		return nil
+	}
+
+	for _, path := range paths {
+		if !p.allowed(path) {
+			return fmt.Errorf("%w: %s", ErrFieldNotAllowed, path)
+		}
+	}
+
+	return nil
+}
+
+func (p *Patcher) allowed(path string) bool {
+	for _, allowed := range p.config.Allowed {
+		if path == allowed || strings.HasPrefix(path, allowed+"/") {
+			return true
+		}
+	}
+
+	return false
+}
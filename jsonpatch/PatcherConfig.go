@@ -0,0 +1,25 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package jsonpatch
+
+import (
+	"github.com/ResurgenceIT/kit/v6/validate"
+)
+
+/*
+PatcherConfig configures a Patcher.
+
+Allowed lists the JSON pointers clients may change, such as "/name" or
+"/address". Allowing a path allows everything beneath it. When Allowed
+is empty every path may be changed, so set it for anything a client
+shouldn't control, such as IDs, owners, or roles. MaxOperations limits
+the operations in a JSON Patch, and defaults to 100. Patched structs are
+checked with Validator, which defaults to validate's default validator
+*/
+type PatcherConfig struct {
+	Allowed       []string
+	MaxOperations int
+	Validator     *validate.Validator
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package jsonpatch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+parsePointer splits an RFC 6901 JSON pointer, such as "/address/city",
+into its unescaped tokens. The empty pointer is the whole document
+*/
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: path '%s' must start with /", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+
+	for index, token := range tokens {
+		tokens[index] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+/*
+escapeToken escapes a key for use in a JSON pointer
+*/
+func escapeToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+/*
+arrayIndex parses an array index token. Indexes may go up to max
+*/
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: '%s' is not an array index", ErrPathNotFound, token)
+	}
+
+	index, err := strconv.Atoi(token)
+
+	if err != nil || index < 0 || index > max {
+		return 0, fmt.Errorf("%w: array index '%s' is out of range", ErrPathNotFound, token)
+	}
+
+	return index, nil
+}
+
+/*
+get returns the value at tokens inside node
+*/
+func get(node interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch container := node.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+
+			if !ok {
+				return nil, fmt.Errorf("%w: no member '%s'", ErrPathNotFound, token)
+			}
+
+			node = value
+
+		case []interface{}:
+			index, err := arrayIndex(token, len(container)-1)
+
+			if err != nil {
+				return nil, err
+			}
+
+			node = container[index]
+
+		default:
+			return nil, fmt.Errorf("%w: '%s' is inside a value that isn't an object or array", ErrPathNotFound, token)
+		}
+	}
+
+	return node, nil
+}
+
+/*
+modify walks to the object or array holding the last token and calls fn
+with it, returning node with fn's result in place of that container.
+Arrays are returned rather than changed in place, as adding to or
+removing from them makes a new slice
+*/
+func modify(node interface{}, tokens []string, fn func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return fn(node, tokens[0])
+	}
+
+	switch container := node.(type) {
+	case map[string]interface{}:
+		child, ok := container[tokens[0]]
+
+		if !ok {
+			return nil, fmt.Errorf("%w: no member '%s'", ErrPathNotFound, tokens[0])
+		}
+
+		updated, err := modify(child, tokens[1:], fn)
+
+		if err != nil {
+			return nil, err
+		}
+
+		container[tokens[0]] = updated
+		return container, nil
+
+	case []interface{}:
+		index, err := arrayIndex(tokens[0], len(container)-1)
+
+		if err != nil {
+			return nil, err
+		}
+
+		updated, err := modify(container[index], tokens[1:], fn)
+
+		if err != nil {
+			return nil, err
+		}
+
+		container[index] = updated
+		return container, nil
+
+	default:
+		return nil, fmt.Errorf("%w: '%s' is inside a value that isn't an object or array", ErrPathNotFound, tokens[0])
+	}
+}
@@ -0,0 +1,294 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+/*
+ErrHookTimeout is returned, wrapped with the hook name, for a hook that
+doesn't finish within its timeout
+*/
+var ErrHookTimeout = errors.New("shutdown hook timed out")
+
+/*
+IManager describes an interface for starting components and running
+ordered shutdown hooks
+*/
+type IManager interface {
+	Add(name string, start StartFunc, stop StopFunc)
+	Register(name string, stop StopFunc)
+	RegisterWithTimeout(name string, timeout time.Duration, stop StopFunc)
+	Shutdown() ShutdownReport
+	Start(ctx context.Context) error
+	Wait() ShutdownReport
+}
+
+/*
+StopFunc stops a component, such as draining an HTTP server or closing
+a database connection. The context is cancelled when the hook's timeout
+is reached
+*/
+type StopFunc func(ctx context.Context) error
+
+/*
+Manager runs shutdown hooks in the order they were registered when the
+process is asked to stop. Each hook runs with a timeout, and a hook that
+fails or times out doesn't keep the rest from running.
+
+Components added with Add are started in order by Start, and stopped in
+reverse order after the registered hooks have run
+*/
+type Manager struct {
+	components []component
+	config     ManagerConfig
+	hooks      []hook
+	report     *ShutdownReport
+	shutdown   sync.Once
+	started    []hook
+	starting   bool
+
+	sync.Mutex
+}
+
+type hook struct {
+	name    string
+	stop    StopFunc
+	timeout time.Duration
+}
+
+/*
+ShutdownReport describes how shutdown went. Duration covers every hook
+*/
+type ShutdownReport struct {
+	Duration time.Duration
+	Hooks    []HookResult
+}
+
+/*
+HookResult describes how a single shutdown hook went
+*/
+type HookResult struct {
+	Duration time.Duration
+	Error    error
+	Name     string
+}
+
+/*
+Err returns the first hook error, or nil if every hook succeeded
+*/
+func (r ShutdownReport) Err() error {
+	for _, result := range r.Hooks {
+		if result.Error != nil {
+			return result.Error
+		}
+	}
+
+	return nil
+}
+
+/*
+NewManager creates a new shutdown Manager
+*/
+func NewManager(config ManagerConfig) *Manager {
+	if config.DefaultHookTimeout <= 0 {
+		config.DefaultHookTimeout = time.Second * 10
+	}
+
+	if len(config.Signals) == 0 {
+		config.Signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	return &Manager{
+		components: make([]component, 0),
+		config:     config,
+		hooks:      make([]hook, 0),
+		started:    make([]hook, 0),
+	}
+}
+
+/*
+Register adds a shutdown hook that runs with the default hook timeout
+*/
+func (m *Manager) Register(name string, stop StopFunc) {
+	m.RegisterWithTimeout(name, m.config.DefaultHookTimeout, stop)
+}
+
+/*
+RegisterWithTimeout adds a shutdown hook with its own timeout
+*/
+func (m *Manager) RegisterWithTimeout(name string, timeout time.Duration, stop StopFunc) {
+	if timeout <= 0 {
+		timeout = m.config.DefaultHookTimeout
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	m.hooks = append(m.hooks, hook{
+		name:    name,
+		stop:    stop,
+		timeout: timeout,
+	})
+}
+
+/*
+Wait blocks until one of the configured signals is received, then runs
+the shutdown hooks and returns the report
+*/
+func (m *Manager) Wait() ShutdownReport {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, m.config.Signals...)
+	defer signal.Stop(signals)
+
+	received := <-signals
+
+	if m.config.Logger != nil {
+		m.config.Logger.WithField("signal", received.String()).Info("shutdown signal received")
+	}
+
+	return m.Shutdown()
+}
+
+/*
+Shutdown runs the shutdown hooks in the order they were registered, then
+stops started components in reverse order. Hooks only ever run once;
+later calls return the first report
+*/
+func (m *Manager) Shutdown() ShutdownReport {
+	m.shutdown.Do(func() {
+		m.Lock()
+		hooks := append([]hook{}, m.hooks...)
+
+		for index := len(m.started) - 1; index >= 0; index-- {
+			hooks = append(hooks, m.started[index])
+		}
+
+		m.started = m.started[:0]
+		m.Unlock()
+
+		report := m.runHooks(hooks)
+
+		m.Lock()
+		m.report = &report
+		m.Unlock()
+	})
+
+	m.Lock()
+	defer m.Unlock()
+
+	return *m.report
+}
+
+func (m *Manager) runHooks(hooks []hook) ShutdownReport {
+	start := time.Now()
+	report := ShutdownReport{
+		Hooks: make([]HookResult, 0, len(hooks)),
+	}
+
+	if m.config.Logger != nil {
+		m.config.Logger.WithField("hooks", len(hooks)).Info("shutting down")
+	}
+
+	for _, h := range hooks {
+		result := m.runHook(h)
+		report.Hooks = append(report.Hooks, result)
+
+		if m.config.Logger != nil {
+			entry := m.config.Logger.WithField("hook", result.Name).WithField("duration", result.Duration.String())
+
+			if result.Error != nil {
+				entry.WithError(result.Error).Error("shutdown hook failed")
+			} else {
+				entry.Info("shutdown hook complete")
+			}
+		}
+	}
+
+	report.Duration = time.Since(start)
+
+	if m.config.PhaseRecorder != nil {
+		m.config.PhaseRecorder.EndShutdown()
+	}
+
+	if m.config.Logger != nil {
+		m.config.Logger.WithField("duration", report.Duration.String()).Info("shutdown complete")
+	}
+
+	return report
+}
+
+func (m *Manager) runHook(h hook) HookResult {
+	var endPhase func()
+
+	if m.config.PhaseRecorder != nil {
+		endPhase = m.config.PhaseRecorder.StartShutdownPhase(h.name)
+	}
+
+	start := time.Now()
+	err := stopWithTimeout(h)
+
+	/*
+	 * A timed out phase is left open so the stats show where shutdown got stuck
+	 */
+	if endPhase != nil && !errors.Is(err, ErrHookTimeout) {
+		endPhase()
+	}
+
+	return HookResult{
+		Duration: time.Since(start),
+		Error:    err,
+		Name:     h.name,
+	}
+}
+
+/*
+stopWithTimeout runs a hook, giving up with ErrHookTimeout once its
+timeout is reached
+*/
+func stopWithTimeout(h hook) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- callHook(ctx, h)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("error running shutdown hook %s: %w", h.name, err)
+		}
+
+		return nil
+
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %s after %s", ErrHookTimeout, h.name, h.timeout)
+	}
+}
+
+/*
+callHook runs a hook, turning a panic into an error so one bad hook
+can't stop the rest from running
+*/
+func callHook(ctx context.Context, h hook) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	return h.stop(ctx)
+}
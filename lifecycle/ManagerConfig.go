@@ -0,0 +1,41 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package lifecycle
+
+import (
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+/*
+ManagerConfig configures a shutdown Manager.
+
+DefaultHookTimeout is how long a hook gets to finish when it wasn't
+registered with a timeout of its own. It defaults to 10 seconds.
+
+Signals are the signals that start shutdown, defaulting to SIGINT and
+SIGTERM.
+
+When Logger is set, the start and end of shutdown and each hook's result
+are logged. When PhaseRecorder is set, such as a *serverstats.ServerStats,
+each hook is recorded as a shutdown phase
+*/
+type ManagerConfig struct {
+	DefaultHookTimeout time.Duration
+	Logger             logrus.FieldLogger
+	PhaseRecorder      PhaseRecorder
+	Signals            []os.Signal
+}
+
+/*
+PhaseRecorder records how long shutdown phases take.
+*serverstats.ServerStats implements this interface
+*/
+type PhaseRecorder interface {
+	EndShutdown()
+	StartShutdownPhase(name string) func()
+}
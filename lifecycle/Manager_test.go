@@ -0,0 +1,323 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/lifecycle"
+)
+
+type recorder struct {
+	ended  []string
+	ends   int
+	starts []string
+}
+
+func (r *recorder) StartShutdownPhase(name string) func() {
+	r.starts = append(r.starts, name)
+	return func() { r.ended = append(r.ended, name) }
+}
+
+func (r *recorder) EndShutdown() {
+	r.ends++
+}
+
+func TestShutdown(t *testing.T) {
+	phases := &recorder{}
+	order := make([]string, 0)
+	mutex := sync.Mutex{}
+	run := func(name string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		order = append(order, name)
+	}
+
+	manager := lifecycle.NewManager(lifecycle.ManagerConfig{
+		DefaultHookTimeout: time.Second,
+		PhaseRecorder:      phases,
+	})
+
+	manager.Register("http", func(ctx context.Context) error {
+		run("http")
+		return nil
+	})
+
+	manager.RegisterWithTimeout("workers", time.Millisecond*10, func(ctx context.Context) error {
+		run("workers")
+		<-ctx.Done()
+		time.Sleep(time.Millisecond * 20)
+		return nil
+	})
+
+	manager.Register("database", func(ctx context.Context) error {
+		run("database")
+		return errors.New("close failed")
+	})
+
+	manager.Register("cache", func(ctx context.Context) error {
+		panic("boom")
+	})
+
+	report := manager.Shutdown()
+	manager.Shutdown()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+	}{
+		{name: "Hooks run in order", expected: "http,workers,database", actual: strings.Join(order, ",")},
+		{name: "Every hook is recorded as a phase", expected: "http,workers,database,cache", actual: strings.Join(phases.starts, ",")},
+		{name: "Timed out hooks are left open", expected: "http,database,cache", actual: strings.Join(phases.ended, ",")},
+		{name: "Shutdown ends once", expected: "1", actual: strings.Repeat("1", phases.ends)},
+		{name: "Successful hooks have no error", expected: "<nil>", actual: errorString(report.Hooks[0].Error)},
+		{name: "Hook errors are reported", expected: "error running shutdown hook database: close failed", actual: errorString(report.Hooks[2].Error)},
+		{name: "Panics are reported", expected: "error running shutdown hook cache: panic: boom", actual: errorString(report.Hooks[3].Error)},
+		{name: "First error is returned", expected: "shutdown hook timed out: workers after 10ms", actual: errorString(report.Err())},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.actual != test.expected {
+				t.Errorf("Expected '%s' but got '%s'", test.expected, test.actual)
+			}
+		})
+	}
+
+	if !errors.Is(report.Hooks[1].Error, lifecycle.ErrHookTimeout) {
+		t.Errorf("Expected a timeout error but got '%v'", report.Hooks[1].Error)
+	}
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return "<nil>"
+	}
+
+	return err.Error()
+}
+
+type calls struct {
+	names []string
+
+	sync.Mutex
+}
+
+func (c *calls) add(name string) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.names = append(c.names, name)
+}
+
+func (c *calls) String() string {
+	c.Lock()
+	defer c.Unlock()
+
+	return strings.Join(c.names, ",")
+}
+
+func TestComponentsStopInReverseOrder(t *testing.T) {
+	started := &calls{}
+	stopped := &calls{}
+	manager := lifecycle.NewManager(lifecycle.ManagerConfig{DefaultHookTimeout: time.Second})
+
+	for _, name := range []string{"database", "cache", "workers"} {
+		name := name
+
+		manager.Add(name, func(ctx context.Context) error {
+			started.add(name)
+			return nil
+		}, func(ctx context.Context) error {
+			stopped.add(name)
+			return nil
+		})
+	}
+
+	manager.Add("metrics", func(ctx context.Context) error {
+		started.add("metrics")
+		return nil
+	}, nil)
+
+	manager.Register("http", func(ctx context.Context) error {
+		stopped.add("http")
+		return nil
+	})
+
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	report := manager.Shutdown()
+	names := make([]string, 0, len(report.Hooks))
+
+	for _, result := range report.Hooks {
+		names = append(names, result.Name)
+	}
+
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+	}{
+		{name: "Components start in order", expected: "database,cache,workers,metrics", actual: started.String()},
+		{name: "Hooks run first, then components stop in reverse", expected: "http,workers,cache,database", actual: stopped.String()},
+		{name: "Every stop is reported", expected: "http,workers,cache,database", actual: strings.Join(names, ",")},
+		{name: "Starting twice fails", expected: lifecycle.ErrAlreadyStarted.Error(), actual: errorString(manager.Start(context.Background()))},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.actual != test.expected {
+				t.Errorf("Expected '%s' but got '%s'", test.expected, test.actual)
+			}
+		})
+	}
+}
+
+func TestHookTimeout(t *testing.T) {
+	phases := &recorder{}
+	release := make(chan struct{})
+	defer close(release)
+
+	manager := lifecycle.NewManager(lifecycle.ManagerConfig{
+		DefaultHookTimeout: time.Millisecond * 20,
+		PhaseRecorder:      phases,
+	})
+
+	/*
+	 * The hook ignores its context, so the manager has to give up on it
+	 */
+	manager.Register("stuck", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+
+	manager.Register("after", func(ctx context.Context) error {
+		return nil
+	})
+
+	start := time.Now()
+	report := manager.Shutdown()
+	elapsed := time.Since(start)
+
+	if elapsed > time.Second {
+		t.Errorf("Expected shutdown to give up on the stuck hook but it took %s", elapsed)
+	}
+
+	if !errors.Is(report.Hooks[0].Error, lifecycle.ErrHookTimeout) {
+		t.Errorf("Expected a timeout error but got '%v'", report.Hooks[0].Error)
+	}
+
+	if report.Hooks[0].Duration < time.Millisecond*20 {
+		t.Errorf("Expected the stuck hook to run for its timeout but got %s", report.Hooks[0].Duration)
+	}
+
+	if report.Hooks[1].Error != nil {
+		t.Errorf("Expected the next hook to run but got '%s'", report.Hooks[1].Error)
+	}
+
+	if strings.Join(phases.ended, ",") != "after" {
+		t.Errorf("Expected the stuck phase to be left open but got '%s'", strings.Join(phases.ended, ","))
+	}
+}
+
+func TestStartFailureRollsBack(t *testing.T) {
+	tests := []struct {
+		name            string
+		fail            func(ctx context.Context) error
+		expectedError   string
+		expectedStarted string
+		expectedStopped string
+	}{
+		{
+			name:            "Start error",
+			fail:            func(ctx context.Context) error { return errors.New("connection refused") },
+			expectedError:   "error starting workers: connection refused",
+			expectedStarted: "database,cache",
+			expectedStopped: "cache,database",
+		},
+		{
+			name:            "Start panic",
+			fail:            func(ctx context.Context) error { panic("boom") },
+			expectedError:   "error starting workers: panic: boom",
+			expectedStarted: "database,cache",
+			expectedStopped: "cache,database",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			phases := &recorder{}
+			started := &calls{}
+			stopped := &calls{}
+
+			manager := lifecycle.NewManager(lifecycle.ManagerConfig{
+				DefaultHookTimeout: time.Second,
+				PhaseRecorder:      phases,
+			})
+
+			add := func(name string, start lifecycle.StartFunc) {
+				manager.Add(name, start, func(ctx context.Context) error {
+					stopped.add(name)
+					return nil
+				})
+			}
+
+			for _, name := range []string{"database", "cache"} {
+				name := name
+
+				add(name, func(ctx context.Context) error {
+					started.add(name)
+					return nil
+				})
+			}
+
+			add("workers", test.fail)
+
+			add("scheduler", func(ctx context.Context) error {
+				started.add("scheduler")
+				return nil
+			})
+
+			err := manager.Start(context.Background())
+
+			if errorString(err) != test.expectedError {
+				t.Errorf("Expected '%s' but got '%s'", test.expectedError, errorString(err))
+			}
+
+			if started.String() != test.expectedStarted {
+				t.Errorf("Expected started '%s' but got '%s'", test.expectedStarted, started.String())
+			}
+
+			if stopped.String() != test.expectedStopped {
+				t.Errorf("Expected rolled back '%s' but got '%s'", test.expectedStopped, stopped.String())
+			}
+
+			if len(phases.starts) != 0 {
+				t.Errorf("Expected a rollback not to be recorded as shutdown but got '%s'", strings.Join(phases.starts, ","))
+			}
+
+			/*
+			 * Rolled back components aren't stopped a second time
+			 */
+			report := manager.Shutdown()
+
+			if len(report.Hooks) != 0 || stopped.String() != test.expectedStopped {
+				t.Errorf("Expected nothing left to stop but got %d hooks and '%s'", len(report.Hooks), stopped.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+/*
+ErrAlreadyStarted is returned when Start is called more than once
+*/
+var ErrAlreadyStarted = errors.New("lifecycle manager already started")
+
+/*
+StartFunc starts a component, such as opening a database connection or
+starting a worker pool
+*/
+type StartFunc func(ctx context.Context) error
+
+type component struct {
+	name  string
+	start StartFunc
+	stop  StopFunc
+}
+
+/*
+Add adds a component that is started by Start and stopped, with the
+default hook timeout, during shutdown. Either function may be nil
+*/
+func (m *Manager) Add(name string, start StartFunc, stop StopFunc) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.components = append(m.components, component{
+		name:  name,
+		start: start,
+		stop:  stop,
+	})
+}
+
+/*
+Start starts each component in the order it was added. If a component
+fails to start, the components already started are stopped in reverse
+order and the start error is returned. Components that were rolled back
+are not stopped again during shutdown
+*/
+func (m *Manager) Start(ctx context.Context) error {
+	m.Lock()
+
+	if m.starting {
+		m.Unlock()
+		return ErrAlreadyStarted
+	}
+
+	m.starting = true
+	components := append([]component{}, m.components...)
+	m.Unlock()
+
+	started := make([]hook, 0, len(components))
+
+	for _, c := range components {
+		if c.start != nil {
+			if err := callStart(ctx, c); err != nil {
+				m.rollback(started)
+				return fmt.Errorf("error starting %s: %w", c.name, err)
+			}
+		}
+
+		if m.config.Logger != nil {
+			m.config.Logger.WithField("component", c.name).Info("component started")
+		}
+
+		if c.stop != nil {
+			started = append(started, hook{
+				name:    c.name,
+				stop:    c.stop,
+				timeout: m.config.DefaultHookTimeout,
+			})
+		}
+	}
+
+	m.Lock()
+	m.started = append(m.started, started...)
+	m.Unlock()
+
+	return nil
+}
+
+func (m *Manager) rollback(started []hook) {
+	for index := len(started) - 1; index >= 0; index-- {
+		err := stopWithTimeout(started[index])
+
+		if m.config.Logger != nil && err != nil {
+			m.config.Logger.WithField("component", started[index].name).WithError(err).Error("unable to roll back component")
+		}
+	}
+}
+
+/*
+callStart runs a start function, turning a panic into an error
+*/
+func callStart(ctx context.Context, c component) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	return c.start(ctx)
+}
@@ -0,0 +1,183 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package listing
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidCursor  = errors.New("invalid cursor")
+	ErrMissingSecret  = errors.New("cursor secret is required")
+	ErrWrongCursorLen = errors.New("cursor needs one value per sort")
+)
+
+/*
+CursorCodec encodes and decodes opaque keyset pagination cursors. A
+cursor holds the sort values of the last row on a page, and is signed
+with HMAC-SHA256 so clients can't forge or edit one. Cursors are
+base64url, so they are safe in query strings
+*/
+type CursorCodec struct {
+	secret []byte
+}
+
+type cursorPayload struct {
+	Sort   string        `json:"s"`
+	Values []interface{} `json:"v"`
+}
+
+/*
+NewCursorCodec creates a CursorCodec that signs with secret
+*/
+func NewCursorCodec(secret []byte) (*CursorCodec, error) {
+	if len(secret) == 0 {
+		return nil, ErrMissingSecret
+	}
+
+	return &CursorCodec{
+		secret: secret,
+	}, nil
+}
+
+/*
+Encode creates a cursor from the sort values of the last row on a page,
+one for each sort, in order
+*/
+func (c *CursorCodec) Encode(sorts []Sort, values ...interface{}) (string, error) {
+	if len(values) != len(sorts) {
+		return "", fmt.Errorf("%w: got %d values for %d sorts", ErrWrongCursorLen, len(values), len(sorts))
+	}
+
+	payload, err := json.Marshal(cursorPayload{Sort: sortKey(sorts), Values: values})
+
+	if err != nil {
+		return "", fmt.Errorf("error encoding cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(c.sign(payload)), nil
+}
+
+/*
+Decode verifies a cursor and returns its values. Cursors made for a
+different sort are rejected. Numbers come back as json.Number and times
+as RFC 3339 strings, which databases compare correctly as parameters
+*/
+func (c *CursorCodec) Decode(cursor string, sorts []Sort) ([]interface{}, error) {
+	var (
+		err       error
+		payload   []byte
+		signature []byte
+		decoded   cursorPayload
+	)
+
+	parts := strings.Split(cursor, ".")
+
+	if len(parts) != 2 {
+		return nil, ErrInvalidCursor
+	}
+
+	if payload, err = base64.RawURLEncoding.DecodeString(parts[0]); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if signature, err = base64.RawURLEncoding.DecodeString(parts[1]); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if !hmac.Equal(signature, c.sign(payload)) {
+		return nil, ErrInvalidCursor
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	if err = decoder.Decode(&decoded); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if decoded.Sort != sortKey(sorts) || len(decoded.Values) != len(sorts) {
+		return nil, fmt.Errorf("%w: the sort has changed", ErrInvalidCursor)
+	}
+
+	return decoded.Values, nil
+}
+
+func (c *CursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
+
+func sortKey(sorts []Sort) string {
+	fields := make([]string, 0, len(sorts))
+
+	for _, sort := range sorts {
+		direction := "asc"
+
+		if sort.Descending {
+			direction = "desc"
+		}
+
+		fields = append(fields, sort.Field+":"+direction)
+	}
+
+	return strings.Join(fields, ",")
+}
+
+/*
+Keyset returns the condition that selects rows after the given sort
+values, without the WHERE keyword, and its arguments. For sorts a ASC,
+b DESC it is
+
+	(a > ? OR (a = ? AND b < ?))
+
+The last sort should be a unique column, such as the ID, so no two rows
+tie. Sort columns should not be nullable
+*/
+func Keyset(sorts []Sort, values []interface{}) (string, []interface{}) {
+	if len(sorts) == 0 || len(values) != len(sorts) {
+		return "", nil
+	}
+
+	conditions := make([]string, 0, len(sorts))
+	args := []interface{}{}
+
+	for index, sort := range sorts {
+		terms := make([]string, 0, index+1)
+
+		for previous := 0; previous < index; previous++ {
+			terms = append(terms, sorts[previous].Column+" = ?")
+			args = append(args, values[previous])
+		}
+
+		comparison := " > ?"
+
+		if sort.Descending {
+			comparison = " < ?"
+		}
+
+		terms = append(terms, sort.Column+comparison)
+		args = append(args, values[index])
+
+		condition := strings.Join(terms, " AND ")
+
+		if len(terms) > 1 {
+			condition = "(" + condition + ")"
+		}
+
+		conditions = append(conditions, condition)
+	}
+
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package listing_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/listing"
+)
+
+func TestCursorCodec(t *testing.T) {
+	codec, _ := listing.NewCursorCodec([]byte("secret"))
+	other, _ := listing.NewCursorCodec([]byte("other secret"))
+	sorts := []listing.Sort{{Column: "created_at", Descending: true, Field: "createdAt"}, {Column: "id", Field: "id"}}
+
+	cursor, err := codec.Encode(sorts, "2021-06-01T12:00:00Z", 42)
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	if strings.ContainsAny(cursor, "+/=") {
+		t.Errorf("Expected a URL safe cursor but got '%s'", cursor)
+	}
+
+	values, err := codec.Decode(cursor, sorts)
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	if !reflect.DeepEqual(values, []interface{}{"2021-06-01T12:00:00Z", json.Number("42")}) {
+		t.Errorf("Unexpected values %#v", values)
+	}
+
+	tampered := []byte(cursor)
+	tampered[2] ^= 1
+
+	tests := []struct {
+		name   string
+		codec  *listing.CursorCodec
+		cursor string
+		sorts  []listing.Sort
+	}{
+		{name: "Tampered", codec: codec, cursor: string(tampered), sorts: sorts},
+		{name: "Signed with another secret", codec: other, cursor: cursor, sorts: sorts},
+		{name: "Different sort", codec: codec, cursor: cursor, sorts: []listing.Sort{{Column: "id", Field: "id"}}},
+		{name: "Garbage", codec: codec, cursor: "garbage", sorts: sorts},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.codec.Decode(test.cursor, test.sorts); !errors.Is(err, listing.ErrInvalidCursor) {
+				t.Errorf("Expected ErrInvalidCursor but got '%v'", err)
+			}
+		})
+	}
+
+	if _, err = codec.Encode(sorts, 42); !errors.Is(err, listing.ErrWrongCursorLen) {
+		t.Errorf("Expected ErrWrongCursorLen but got '%v'", err)
+	}
+}
+
+func TestKeysetPagination(t *testing.T) {
+	codec, _ := listing.NewCursorCodec([]byte("secret"))
+	parser := listing.NewParser(listing.ListingConfig{
+		Cursors:     codec,
+		DefaultSort: []listing.Sort{{Column: "created_at", Descending: true, Field: "createdAt"}, {Column: "id", Field: "id"}},
+		Filterable:  map[string]string{"status": "status"},
+		Sortable:    map[string]string{"createdAt": "created_at", "id": "id"},
+	})
+
+	first, _ := parser.Parse(url.Values{})
+	cursor, err := parser.NextCursor(first, "2021-06-01T12:00:00Z", 42)
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	next, err := parser.Parse(url.Values{"cursor": {cursor}, "filter[status]": {"active"}})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	query, args := next.Apply("SELECT * FROM orders")
+	expectedQuery := "SELECT * FROM orders WHERE status = ? AND (created_at < ? OR (created_at = ? AND id > ?)) ORDER BY created_at DESC, id ASC LIMIT 25"
+	expectedArgs := []interface{}{"active", "2021-06-01T12:00:00Z", "2021-06-01T12:00:00Z", json.Number("42")}
+
+	if query != expectedQuery {
+		t.Errorf("Expected query '%s' but got '%s'", expectedQuery, query)
+	}
+
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("Expected args %v but got %v", expectedArgs, args)
+	}
+
+	if _, err = parser.Parse(url.Values{"cursor": {cursor}, "sort": {"id"}}); !errors.Is(err, listing.ErrInvalidCursor) {
+		t.Errorf("Expected a cursor for another sort to be rejected but got '%v'", err)
+	}
+}
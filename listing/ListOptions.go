@@ -0,0 +1,206 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package listing
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ResurgenceIT/kit/v6/paging"
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+)
+
+/*
+Operator is how a filter compares a column to its value
+*/
+type Operator string
+
+const (
+	Contains           Operator = "contains"
+	Equal              Operator = "eq"
+	GreaterThan        Operator = "gt"
+	GreaterThanOrEqual Operator = "gte"
+	In                 Operator = "in"
+	IsNull             Operator = "null"
+	LessThan           Operator = "lt"
+	LessThanOrEqual    Operator = "lte"
+	NotEqual           Operator = "ne"
+)
+
+var comparisons = map[Operator]string{
+	Equal:              "=",
+	GreaterThan:        ">",
+	GreaterThanOrEqual: ">=",
+	LessThan:           "<",
+	LessThanOrEqual:    "<=",
+	NotEqual:           "<>",
+}
+
+/*
+Filter is one condition from a filter[field][op]=value parameter. For
+In the values are comma separated, and for IsNull the value is "true"
+or "false"
+*/
+type Filter struct {
+	Column   string
+	Field    string
+	Operator Operator
+	Values   []string
+}
+
+/*
+Sort is one field from the sort parameter
+*/
+type Sort struct {
+	Column     string
+	Descending bool
+	Field      string
+}
+
+/*
+ListOptions is a parsed list request. When Cursor is set, Page should be
+ignored in favor of keyset pagination, and After holds the sort values
+decoded from it
+*/
+type ListOptions struct {
+	After   []interface{}
+	Cursor  string
+	Filters []Filter
+	Limit   int
+	Page    int
+	Sorts   []Sort
+}
+
+/*
+Offset returns how many rows to skip for the current page
+*/
+func (o ListOptions) Offset() int {
+	return (o.Page - 1) * o.Limit
+}
+
+/*
+Paging calculates paging information for the current page, given the
+total number of items
+*/
+func (o ListOptions) Paging(totalItems int) paging.PagingInfo {
+	result := paging.PagingInfo{}
+	result.Calculate(o.Page, o.Limit, totalItems)
+
+	return result
+}
+
+/*
+Where returns the filters as SQL conditions joined with AND, without the
+WHERE keyword, and their arguments. Placeholders are ?. Contains uses
+LIKE, escaping % and _ in the value with a backslash. When After is set
+the Keyset condition is included
+*/
+func (o ListOptions) Where() (string, []interface{}) {
+	conditions := make([]string, 0, len(o.Filters))
+	args := make([]interface{}, 0, len(o.Filters))
+
+	for _, filter := range o.Filters {
+		switch filter.Operator {
+		case Contains:
+			conditions = append(conditions, filter.Column+" LIKE ?")
+			args = append(args, "%"+likeEscaper.Replace(filter.Values[0])+"%")
+
+		case In:
+			placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(filter.Values)), ", ")
+			conditions = append(conditions, fmt.Sprintf("%s IN (%s)", filter.Column, placeholders))
+
+			for _, value := range filter.Values {
+				args = append(args, value)
+			}
+
+		case IsNull:
+			if filter.Values[0] == "true" {
+				conditions = append(conditions, filter.Column+" IS NULL")
+			} else {
+				conditions = append(conditions, filter.Column+" IS NOT NULL")
+			}
+
+		default:
+			conditions = append(conditions, fmt.Sprintf("%s %s ?", filter.Column, comparisons[filter.Operator]))
+			args = append(args, filter.Values[0])
+		}
+	}
+
+	if keyset, keysetArgs := Keyset(o.Sorts, o.After); keyset != "" {
+		conditions = append(conditions, keyset)
+		args = append(args, keysetArgs...)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+/*
+OrderBy returns the sorts as an ORDER BY list, without the ORDER BY
+keywords
+*/
+func (o ListOptions) OrderBy() string {
+	columns := make([]string, 0, len(o.Sorts))
+
+	for _, sort := range o.Sorts {
+		direction := "ASC"
+
+		if sort.Descending {
+			direction = "DESC"
+		}
+
+		columns = append(columns, sort.Column+" "+direction)
+	}
+
+	return strings.Join(columns, ", ")
+}
+
+/*
+Apply adds the filters, sorts, limit, and offset to query, which should
+end after its FROM or WHERE clause. Conditions are joined to an
+existing WHERE clause with AND. The offset is left off when Cursor is
+set
+*/
+func (o ListOptions) Apply(query string, args ...interface{}) (string, []interface{}) {
+	return o.ApplyDialect("", query, args...)
+}
+
+/*
+ApplyDialect is Apply with the dialect's paging clause, such as
+OFFSET ... FETCH on SQL Server. Placeholders are still ?; rebind the
+result with the dialect
+*/
+func (o ListOptions) ApplyDialect(dialect sqldatabase.Dialect, query string, args ...interface{}) (string, []interface{}) {
+	builder := strings.Builder{}
+	builder.WriteString(query)
+
+	if where, whereArgs := o.Where(); where != "" {
+		if hasWhere(query) {
+			builder.WriteString(" AND ")
+		} else {
+			builder.WriteString(" WHERE ")
+		}
+
+		builder.WriteString(where)
+		args = append(args, whereArgs...)
+	}
+
+	if orderBy := o.OrderBy(); orderBy != "" {
+		builder.WriteString(" ORDER BY " + orderBy)
+	}
+
+	if o.Cursor == "" {
+		builder.WriteString(" " + dialect.LimitOffset(o.Limit, o.Offset()))
+	} else {
+		builder.WriteString(" " + dialect.Limit(o.Limit))
+	}
+
+	return builder.String(), args
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func hasWhere(query string) bool {
+	return strings.Contains(strings.ToUpper(strings.Join(strings.Fields(query), " ")), " WHERE ")
+}
@@ -0,0 +1,29 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package listing
+
+/*
+ListingConfig configures a Parser.
+
+Sortable and Filterable whitelist the fields clients may sort and
+filter on, mapping each field's name in the query string to the column
+it is applied to. Fields not in these maps are rejected, so client input
+never reaches SQL as anything but a bound value.
+
+DefaultLimit (default 25) is used when no limit is given, and limits are
+capped at MaxLimit (default 100). DefaultSort is used when no sort is
+given.
+
+Set Cursors to decode the cursor parameter for keyset pagination. The
+cursor's values are then used to select the rows after it
+*/
+type ListingConfig struct {
+	Cursors      *CursorCodec
+	DefaultLimit int
+	DefaultSort  []Sort
+	Filterable   map[string]string
+	MaxLimit     int
+	Sortable     map[string]string
+}
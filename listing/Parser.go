@@ -0,0 +1,233 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package listing
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidFilter      = errors.New("invalid filter")
+	ErrInvalidLimit       = errors.New("limit must be a positive number")
+	ErrInvalidPage        = errors.New("page must be a positive number")
+	ErrInvalidSort        = errors.New("invalid sort")
+	ErrUnknownFilterField = errors.New("field cannot be filtered on")
+	ErrUnknownOperator    = errors.New("unknown filter operator")
+	ErrUnknownSortField   = errors.New("field cannot be sorted on")
+)
+
+/*
+Parser turns list query parameters into ListOptions. The parameters are
+
+	page=2
+	limit=50
+	cursor=opaque-value
+	sort=lastName,createdAt:desc
+	filter[status]=active
+	filter[age][gte]=21
+	filter[role][in]=admin,owner
+*/
+type Parser struct {
+	config ListingConfig
+}
+
+/*
+NewParser creates a Parser
+*/
+func NewParser(config ListingConfig) *Parser {
+	if config.DefaultLimit <= 0 {
+		config.DefaultLimit = 25
+	}
+
+	if config.MaxLimit <= 0 {
+		config.MaxLimit = 100
+	}
+
+	if config.DefaultLimit > config.MaxLimit {
+		config.DefaultLimit = config.MaxLimit
+	}
+
+	return &Parser{
+		config: config,
+	}
+}
+
+/*
+ParseRequest parses the query string of r
+*/
+func (p *Parser) ParseRequest(r *http.Request) (ListOptions, error) {
+	return p.Parse(r.URL.Query())
+}
+
+/*
+Parse parses list query parameters. Errors wrap one of the Err values,
+and are meant to be reported to the client as a bad request
+*/
+func (p *Parser) Parse(values url.Values) (ListOptions, error) {
+	var err error
+
+	result := ListOptions{
+		Cursor: values.Get("cursor"),
+		Limit:  p.config.DefaultLimit,
+		Page:   1,
+		Sorts:  p.config.DefaultSort,
+	}
+
+	if page := values.Get("page"); page != "" {
+		if result.Page, err = strconv.Atoi(page); err != nil || result.Page < 1 {
+			return result, fmt.Errorf("%w: '%s'", ErrInvalidPage, page)
+		}
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		if result.Limit, err = strconv.Atoi(limit); err != nil || result.Limit < 1 {
+			return result, fmt.Errorf("%w: '%s'", ErrInvalidLimit, limit)
+		}
+
+		if result.Limit > p.config.MaxLimit {
+			result.Limit = p.config.MaxLimit
+		}
+	}
+
+	if sorts := values.Get("sort"); sorts != "" {
+		if result.Sorts, err = p.parseSorts(sorts); err != nil {
+			return result, err
+		}
+	}
+
+	if result.Filters, err = p.parseFilters(values); err != nil {
+		return result, err
+	}
+
+	if result.Cursor != "" && p.config.Cursors != nil {
+		if result.After, err = p.config.Cursors.Decode(result.Cursor, result.Sorts); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+func (p *Parser) parseSorts(sorts string) ([]Sort, error) {
+	result := []Sort{}
+
+	for _, field := range strings.Split(sorts, ",") {
+		s := Sort{}
+		direction := "asc"
+
+		if index := strings.Index(field, ":"); index > -1 {
+			field, direction = field[:index], strings.ToLower(field[index+1:])
+		}
+
+		s.Field = strings.TrimSpace(field)
+
+		switch direction {
+		case "asc":
+		case "desc":
+			s.Descending = true
+		default:
+			return nil, fmt.Errorf("%w: unknown direction '%s'", ErrInvalidSort, direction)
+		}
+
+		column, ok := p.config.Sortable[s.Field]
+
+		if !ok {
+			return nil, fmt.Errorf("%w: '%s'", ErrUnknownSortField, s.Field)
+		}
+
+		s.Column = column
+		result = append(result, s)
+	}
+
+	return result, nil
+}
+
+/*
+parseFilters reads filter parameters in name order, so the same query
+string always gives the same SQL
+*/
+func (p *Parser) parseFilters(values url.Values) ([]Filter, error) {
+	names := make([]string, 0, len(values))
+
+	for name := range values {
+		if strings.HasPrefix(name, "filter[") {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	result := make([]Filter, 0, len(names))
+
+	for _, name := range names {
+		filter, err := p.parseFilter(name, values.Get(name))
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, filter)
+	}
+
+	return result, nil
+}
+
+func (p *Parser) parseFilter(name, value string) (Filter, error) {
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(name, "filter["), "]"), "][")
+	result := Filter{Field: parts[0], Operator: Equal}
+
+	if len(parts) > 2 || !strings.HasSuffix(name, "]") || result.Field == "" {
+		return result, fmt.Errorf("%w: '%s'", ErrInvalidFilter, name)
+	}
+
+	if len(parts) == 2 {
+		result.Operator = Operator(strings.ToLower(parts[1]))
+	}
+
+	column, ok := p.config.Filterable[result.Field]
+
+	if !ok {
+		return result, fmt.Errorf("%w: '%s'", ErrUnknownFilterField, result.Field)
+	}
+
+	result.Column = column
+	result.Values = []string{value}
+
+	switch result.Operator {
+	case Contains:
+	case In:
+		result.Values = strings.Split(value, ",")
+
+	case IsNull:
+		if value != "true" && value != "false" {
+			return result, fmt.Errorf("%w: '%s' must be true or false", ErrInvalidFilter, name)
+		}
+
+	default:
+		if _, ok := comparisons[result.Operator]; !ok {
+			return result, fmt.Errorf("%w: '%s'", ErrUnknownOperator, result.Operator)
+		}
+	}
+
+	return result, nil
+}
+
+/*
+NextCursor creates the cursor for the page after options, from the sort
+values of the last row on the current page. It returns
+ErrMissingSecret when no Cursors are configured
+*/
+func (p *Parser) NextCursor(options ListOptions, values ...interface{}) (string, error) {
+	if p.config.Cursors == nil {
+		return "", ErrMissingSecret
+	}
+
+	return p.config.Cursors.Encode(options.Sorts, values...)
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package listing_test
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/listing"
+)
+
+func newParser() *listing.Parser {
+	return listing.NewParser(listing.ListingConfig{
+		DefaultSort: []listing.Sort{{Column: "u.created_at", Descending: true, Field: "createdAt"}},
+		Filterable:  map[string]string{"age": "u.age", "name": "u.name", "role": "u.role", "deletedAt": "u.deleted_at"},
+		MaxLimit:    50,
+		Sortable:    map[string]string{"createdAt": "u.created_at", "name": "u.name"},
+	})
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		expectedQuery string
+		expectedArgs  []interface{}
+		expectedErr   error
+	}{
+		{
+			name:          "Defaults",
+			query:         "",
+			expectedQuery: "SELECT * FROM users u ORDER BY u.created_at DESC LIMIT 25 OFFSET 0",
+		},
+		{
+			name:          "Page, limit, and sort",
+			query:         "page=3&limit=10&sort=name,createdAt:desc",
+			expectedQuery: "SELECT * FROM users u ORDER BY u.name ASC, u.created_at DESC LIMIT 10 OFFSET 20",
+		},
+		{
+			name:          "Limit is capped",
+			query:         "limit=500",
+			expectedQuery: "SELECT * FROM users u ORDER BY u.created_at DESC LIMIT 50 OFFSET 0",
+		},
+		{
+			name:          "Filters",
+			query:         "filter[role][in]=admin,owner&filter[age][gte]=21&filter[name][contains]=50%25_off&filter[deletedAt][null]=true",
+			expectedQuery: "SELECT * FROM users u WHERE u.age >= ? AND u.deleted_at IS NULL AND u.name LIKE ? AND u.role IN (?, ?) ORDER BY u.created_at DESC LIMIT 25 OFFSET 0",
+			expectedArgs:  []interface{}{"21", `%50\%\_off%`, "admin", "owner"},
+		},
+		{
+			name:          "Cursor leaves off the offset",
+			query:         "cursor=abc&filter[name]=Adam",
+			expectedQuery: "SELECT * FROM users u WHERE u.name = ? ORDER BY u.created_at DESC LIMIT 25",
+			expectedArgs:  []interface{}{"Adam"},
+		},
+		{name: "Bad page", query: "page=0", expectedErr: listing.ErrInvalidPage},
+		{name: "Bad limit", query: "limit=abc", expectedErr: listing.ErrInvalidLimit},
+		{name: "Bad direction", query: "sort=name:sideways", expectedErr: listing.ErrInvalidSort},
+		{name: "Unsortable field", query: "sort=password", expectedErr: listing.ErrUnknownSortField},
+		{name: "Unfilterable field", query: "filter[password]=x", expectedErr: listing.ErrUnknownFilterField},
+		{name: "Unknown operator", query: "filter[age][between]=1", expectedErr: listing.ErrUnknownOperator},
+		{name: "Malformed filter", query: "filter[age][gte][x]=1", expectedErr: listing.ErrInvalidFilter},
+		{name: "Bad null value", query: "filter[deletedAt][null]=maybe", expectedErr: listing.ErrInvalidFilter},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(test.query)
+			options, err := newParser().Parse(values)
+
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("Expected error '%v' but got '%v'", test.expectedErr, err)
+			}
+
+			if test.expectedErr != nil {
+				return
+			}
+
+			query, args := options.Apply("SELECT * FROM users u")
+
+			if query != test.expectedQuery {
+				t.Errorf("Expected query '%s' but got '%s'", test.expectedQuery, query)
+			}
+
+			if len(args) != len(test.expectedArgs) || (len(args) > 0 && !reflect.DeepEqual(args, test.expectedArgs)) {
+				t.Errorf("Expected args %v but got %v", test.expectedArgs, args)
+			}
+		})
+	}
+}
+
+func TestApplyWithWhere(t *testing.T) {
+	values, _ := url.ParseQuery("filter[name]=Adam&sort=name")
+	options, _ := newParser().Parse(values)
+
+	query, args := options.Apply("SELECT * FROM users u\nWHERE u.account_id = ?", 7)
+
+	if query != "SELECT * FROM users u\nWHERE u.account_id = ? AND u.name = ? ORDER BY u.name ASC LIMIT 25 OFFSET 0" {
+		t.Errorf("Unexpected query '%s'", query)
+	}
+
+	if !reflect.DeepEqual(args, []interface{}{7, "Adam"}) {
+		t.Errorf("Expected args [7 Adam] but got %v", args)
+	}
+
+	if pagination := options.Paging(60); pagination.TotalPages != 3 || !pagination.HasNextPage {
+		t.Errorf("Expected 3 pages with a next page but got %+v", pagination)
+	}
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package logging_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/logging"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRedactionHook(t *testing.T) {
+	type customer struct {
+		Email string `json:"email" mask:"email"`
+		Name  string `json:"name"`
+	}
+
+	output := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.SetOutput(output)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logging.AddRedaction(logger, nil)
+
+	logger.WithFields(logrus.Fields{
+		"customer": customer{Email: "bob@example.com", Name: "Bob"},
+		"error":    errors.New("login failed for alice@example.com"),
+		"password": "hunter2",
+		"retries":  3,
+	}).Info("charging card 4111 1111 1111 1111 with Authorization: Bearer abc.def.ghi")
+
+	logged := output.String()
+
+	for _, secret := range []string{"hunter2", "bob@example.com", "alice@example.com", "4111 1111", "abc.def.ghi"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("Expected %s to be redacted but got %s", secret, logged)
+		}
+	}
+
+	for _, kept := range []string{`"b**@example.com"`, "a****@example.com", "************1111", `"retries":3`, `"name":"Bob"`} {
+		if !strings.Contains(logged, kept) {
+			t.Errorf("Expected %s in %s", kept, logged)
+		}
+	}
+}
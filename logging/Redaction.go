@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package logging
+
+import (
+	"github.com/ResurgenceIT/kit/v6/redact"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+RedactionHook is a Logrus hook that hides personal data and secrets
+before entries reach any sink. Fields with sensitive names are hidden,
+structs and maps are redacted by their mask tags, and messages and
+string fields have emails, card numbers, and credentials masked. Errors
+are replaced with their redacted message
+*/
+type RedactionHook struct {
+	redactor *redact.Redactor
+}
+
+/*
+NewRedactionHook creates a hook using redactor. A nil redactor uses one
+with the default keys and masks
+*/
+func NewRedactionHook(redactor *redact.Redactor) *RedactionHook {
+	if redactor == nil {
+		redactor = redact.NewRedactor(redact.RedactorConfig{})
+	}
+
+	return &RedactionHook{
+		redactor: redactor,
+	}
+}
+
+/*
+AddRedaction adds a RedactionHook to logger. Add it before other hooks,
+as hooks run in the order they were added
+*/
+func AddRedaction(logger *logrus.Logger, redactor *redact.Redactor) {
+	logger.AddHook(NewRedactionHook(redactor))
+}
+
+func (h *RedactionHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *RedactionHook) Fire(entry *logrus.Entry) error {
+	entry.Message = h.redactor.String(entry.Message)
+
+	for key, value := range entry.Data {
+		if h.redactor.SensitiveKey(key) {
+			entry.Data[key] = redact.Redacted
+			continue
+		}
+
+		switch v := value.(type) {
+		case string:
+			entry.Data[key] = h.redactor.String(v)
+
+		case error:
+			entry.Data[key] = h.redactor.String(v.Error())
+
+		case bool, int, int64, uint, uint64, float64:
+			/*
+			 * Plain numbers and flags are left alone, as they are
+			 * usually counts and durations
+			 */
+
+		default:
+			entry.Data[key] = h.redactor.Value(v)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,223 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package mail_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ResurgenceIT/kit/v6/mail"
+)
+
+func testMessage() mail.Message {
+	return mail.Message{
+		Attachments: []mail.Attachment{
+			{ContentType: "text/csv", Data: []byte("a,b\n1,2\n"), Filename: "report.csv"},
+			{ContentID: "logo", ContentType: "image/png", Data: []byte("png"), Filename: "logo.png"},
+		},
+		Bcc:     []mail.Address{{Email: "audit@example.com"}},
+		From:    mail.Address{Email: "sender@example.com", Name: "Sender"},
+		HTML:    `<p>Hello</p><img src="cid:logo">`,
+		Subject: "Monthly report",
+		Text:    "Hello",
+		To:      []mail.Address{{Email: "bob@example.com", Name: "Bob Hope"}},
+	}
+}
+
+func TestMessageWriteTo(t *testing.T) {
+	buffer := &bytes.Buffer{}
+
+	if _, err := testMessage().WriteTo(buffer); err != nil {
+		t.Fatalf("Expected no error but got '%v'", err)
+	}
+
+	raw := buffer.String()
+
+	expected := []string{
+		"From: \"Sender\" <sender@example.com>",
+		"To: \"Bob Hope\" <bob@example.com>",
+		"Subject: Monthly report",
+		"Message-ID: <",
+		"multipart/mixed",
+		"multipart/related",
+		"multipart/alternative",
+		"Content-ID: <logo>",
+		"filename=\"report.csv\"",
+	}
+
+	for _, value := range expected {
+		if !strings.Contains(raw, value) {
+			t.Errorf("Expected message to contain '%s'", value)
+		}
+	}
+
+	if strings.Contains(raw, "audit@example.com") {
+		t.Errorf("Expected Bcc recipients to be left out of the headers")
+	}
+}
+
+func TestMessageValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(message *mail.Message)
+		expected error
+	}{
+		{name: "Valid", modify: func(message *mail.Message) {}},
+		{name: "No sender", modify: func(message *mail.Message) { message.From = mail.Address{} }, expected: mail.ErrNoSender},
+		{name: "No recipients", modify: func(message *mail.Message) { message.To, message.Bcc = nil, nil }, expected: mail.ErrNoRecipients},
+		{name: "No body", modify: func(message *mail.Message) { message.Text, message.HTML = "", "" }, expected: mail.ErrNoBody},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			message := testMessage()
+			test.modify(&message)
+
+			if err := message.Validate(); !errors.Is(err, test.expected) {
+				t.Errorf("Expected error '%v' but got '%v'", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestTemplateRenderer(t *testing.T) {
+	fsys := fstest.MapFS{
+		"welcome.subject.tmpl":         {Data: []byte("Welcome, {{.Name}}!\n")},
+		"welcome.txt.tmpl":             {Data: []byte("Hi {{.Name}}")},
+		"welcome.html.tmpl":            {Data: []byte("<p>Hi {{.Name}}</p>")},
+		"billing/invoice.subject.tmpl": {Data: []byte("Invoice {{upper .Number}}")},
+	}
+
+	renderer, err := mail.NewTemplateRenderer(fsys, map[string]interface{}{"upper": strings.ToUpper})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%v'", err)
+	}
+
+	message := mail.Message{}
+
+	if err = renderer.Render(&message, "welcome", map[string]string{"Name": "<Bob>"}); err != nil {
+		t.Fatalf("Expected no error but got '%v'", err)
+	}
+
+	if message.Subject != "Welcome, <Bob>!" || message.Text != "Hi <Bob>" || message.HTML != "<p>Hi &lt;Bob&gt;</p>" {
+		t.Errorf("Unexpected rendered message %+v", message)
+	}
+
+	if err = renderer.Render(&message, "billing/invoice", map[string]string{"Number": "inv-1"}); err != nil || message.Subject != "Invoice INV-1" {
+		t.Errorf("Expected subject 'Invoice INV-1' but got '%s' (%v)", message.Subject, err)
+	}
+
+	if err = renderer.Render(&message, "missing", nil); !errors.Is(err, mail.ErrTemplateNotFound) {
+		t.Errorf("Expected ErrTemplateNotFound but got '%v'", err)
+	}
+}
+
+func TestTestTransport(t *testing.T) {
+	transport := mail.NewTestTransport()
+
+	if err := transport.Send(context.Background(), testMessage()); err != nil {
+		t.Fatalf("Expected no error but got '%v'", err)
+	}
+
+	if messages := transport.Messages(); len(messages) != 1 || messages[0].Subject != "Monthly report" {
+		t.Errorf("Expected the sent message to be captured but got %+v", messages)
+	}
+
+	transport.Err = errors.New("down")
+
+	if err := transport.Send(context.Background(), testMessage()); err == nil {
+		t.Errorf("Expected Err to be returned")
+	}
+}
+
+func TestSMTPTransport(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%v'", err)
+	}
+
+	defer listener.Close()
+
+	commands := make(chan []string, 1)
+	go serveSMTP(listener, commands)
+
+	address := listener.Addr().(*net.TCPAddr)
+	transport := mail.NewSMTPTransport(mail.SMTPConfig{
+		Host:     "127.0.0.1",
+		Port:     address.Port,
+		Security: mail.SecurityNone,
+	})
+
+	if err = transport.Send(context.Background(), testMessage()); err != nil {
+		t.Fatalf("Expected no error but got '%v'", err)
+	}
+
+	received := strings.Join(<-commands, "\n")
+
+	for _, expected := range []string{"MAIL FROM:<sender@example.com>", "RCPT TO:<bob@example.com>", "RCPT TO:<audit@example.com>", "Subject: Monthly report", "QUIT"} {
+		if !strings.Contains(received, expected) {
+			t.Errorf("Expected the server to receive '%s'", expected)
+		}
+	}
+}
+
+/*
+serveSMTP accepts one connection and answers just enough SMTP to take
+a message
+*/
+func serveSMTP(listener net.Listener, commands chan []string) {
+	conn, err := listener.Accept()
+
+	if err != nil {
+		return
+	}
+
+	defer conn.Close()
+
+	received := make([]string, 0)
+	reader := bufio.NewReader(conn)
+	inData := false
+
+	_, _ = conn.Write([]byte("220 localhost ESMTP\r\n"))
+
+	for {
+		line, err := reader.ReadString('\n')
+
+		if err != nil {
+			break
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		received = append(received, line)
+
+		switch {
+		case inData && line == ".":
+			inData = false
+			_, _ = conn.Write([]byte("250 OK\r\n"))
+		case inData:
+		case strings.HasPrefix(line, "EHLO"):
+			_, _ = conn.Write([]byte("250 localhost\r\n"))
+		case strings.HasPrefix(line, "DATA"):
+			inData = true
+			_, _ = conn.Write([]byte("354 Go ahead\r\n"))
+		case strings.HasPrefix(line, "QUIT"):
+			_, _ = conn.Write([]byte("221 Bye\r\n"))
+			commands <- received
+			return
+		default:
+			_, _ = conn.Write([]byte("250 OK\r\n"))
+		}
+	}
+
+	commands <- received
+}
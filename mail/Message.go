@@ -0,0 +1,190 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+
+	"gopkg.in/gomail.v2"
+)
+
+var (
+	ErrNoBody       = errors.New("message has no text or HTML body")
+	ErrNoRecipients = errors.New("message has no recipients")
+	ErrNoSender     = errors.New("message has no sender")
+)
+
+/*
+Address is an email address with an optional display name
+*/
+type Address struct {
+	Email string
+	Name  string
+}
+
+/*
+String returns the address formatted for a header, such as
+"Bob Hope <bob@example.com>"
+*/
+func (a Address) String() string {
+	return (&mail.Address{Address: a.Email, Name: a.Name}).String()
+}
+
+/*
+Attachment is a file sent with a message. Attachments with a ContentID
+are inline, such as images referenced from the HTML body with
+"cid:<ContentID>". ContentType is guessed from Filename when empty
+*/
+type Attachment struct {
+	ContentID   string
+	ContentType string
+	Data        []byte
+	Filename    string
+}
+
+/*
+Message is an email to send. A message can have a Text body, an HTML
+body, or both, in which case mail clients pick the one they prefer
+*/
+type Message struct {
+	Attachments []Attachment
+	Bcc         []Address
+	Cc          []Address
+	From        Address
+	HTML        string
+	Headers     map[string]string
+	ReplyTo     []Address
+	Subject     string
+	Text        string
+	To          []Address
+}
+
+/*
+Validate checks the message has a sender, at least one recipient, and a
+body
+*/
+func (m Message) Validate() error {
+	if m.From.Email == "" {
+		return ErrNoSender
+	}
+
+	if len(m.Recipients()) == 0 {
+		return ErrNoRecipients
+	}
+
+	if m.Text == "" && m.HTML == "" {
+		return ErrNoBody
+	}
+
+	return nil
+}
+
+/*
+Recipients returns the email addresses of every To, Cc, and Bcc
+recipient
+*/
+func (m Message) Recipients() []string {
+	result := make([]string, 0, len(m.To)+len(m.Cc)+len(m.Bcc))
+
+	for _, list := range [][]Address{m.To, m.Cc, m.Bcc} {
+		for _, address := range list {
+			result = append(result, address.Email)
+		}
+	}
+
+	return result
+}
+
+/*
+WriteTo writes the message in MIME format, as it is sent over SMTP. Bcc
+recipients are left out of the headers. A Message-ID is generated when
+the message's Headers don't have one
+*/
+func (m Message) WriteTo(w io.Writer) (int64, error) {
+	message := gomail.NewMessage()
+	message.SetAddressHeader("From", m.From.Email, m.From.Name)
+	message.SetHeader("Subject", m.Subject)
+
+	setAddresses(message, "To", m.To)
+	setAddresses(message, "Cc", m.Cc)
+	setAddresses(message, "Reply-To", m.ReplyTo)
+
+	if _, ok := m.Headers["Message-ID"]; !ok {
+		message.SetHeader("Message-ID", newMessageID(m.From.Email))
+	}
+
+	for key, value := range m.Headers {
+		message.SetHeader(key, value)
+	}
+
+	switch {
+	case m.Text != "" && m.HTML != "":
+		message.SetBody("text/plain", m.Text)
+		message.AddAlternative("text/html", m.HTML)
+
+	case m.HTML != "":
+		message.SetBody("text/html", m.HTML)
+
+	default:
+		message.SetBody("text/plain", m.Text)
+	}
+
+	for _, attachment := range m.Attachments {
+		data := attachment.Data
+		settings := []gomail.FileSetting{
+			gomail.SetCopyFunc(func(w io.Writer) error {
+				_, err := w.Write(data)
+				return err
+			}),
+		}
+
+		if attachment.ContentType != "" {
+			settings = append(settings, gomail.SetHeader(map[string][]string{
+				"Content-Type": {fmt.Sprintf("%s; name=%q", attachment.ContentType, attachment.Filename)},
+			}))
+		}
+
+		if attachment.ContentID != "" {
+			settings = append(settings, gomail.SetHeader(map[string][]string{
+				"Content-ID": {"<" + attachment.ContentID + ">"},
+			}))
+
+			message.Embed(attachment.Filename, settings...)
+			continue
+		}
+
+		message.Attach(attachment.Filename, settings...)
+	}
+
+	return message.WriteTo(w)
+}
+
+func setAddresses(message *gomail.Message, header string, addresses []Address) {
+	if len(addresses) == 0 {
+		return
+	}
+
+	values := make([]string, 0, len(addresses))
+
+	for _, address := range addresses {
+		values = append(values, message.FormatAddress(address.Email, address.Name))
+	}
+
+	message.SetHeader(header, values...)
+}
+
+func newMessageID(from string) string {
+	domain := "localhost"
+
+	if index := strings.LastIndex(from, "@"); index > -1 {
+		domain = from[index+1:]
+	}
+
+	return "<" + newID() + "@" + domain + ">"
+}
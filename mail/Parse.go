@@ -0,0 +1,271 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+/*
+Mail is a received email, parsed from its raw RFC 5322 form. Header
+values in Headers are as they were received, while Subject, addresses,
+and attachment filenames are decoded. Text and HTML bodies are
+converted to UTF-8. InlineImages are parts with a Content-ID that the
+HTML body references with "cid:" URLs, and Attachments are everything
+else that isn't a body
+*/
+type Mail struct {
+	Attachments  []Attachment
+	Bcc          []Address
+	Cc           []Address
+	Date         time.Time
+	From         Address
+	HTML         string
+	Headers      textproto.MIMEHeader
+	InlineImages []Attachment
+	MessageID    string
+	ReplyTo      []Address
+	Subject      string
+	Text         string
+	To           []Address
+}
+
+var wordDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+/*
+Parse reads a raw email. Parsing is forgiving, as received mail often
+bends the rules: unknown charsets are left as is, addresses that can't
+be parsed are kept as written, and a missing or invalid date leaves
+Date zero
+*/
+func Parse(r io.Reader) (*Mail, error) {
+	message, err := mail.ReadMessage(r)
+
+	if err != nil {
+		return nil, fmt.Errorf("error reading message: %w", err)
+	}
+
+	header := textproto.MIMEHeader(message.Header)
+	result := &Mail{
+		Attachments:  make([]Attachment, 0),
+		Bcc:          parseAddresses(header.Get("Bcc")),
+		Cc:           parseAddresses(header.Get("Cc")),
+		Headers:      header,
+		InlineImages: make([]Attachment, 0),
+		MessageID:    strings.Trim(header.Get("Message-ID"), "<> "),
+		ReplyTo:      parseAddresses(header.Get("Reply-To")),
+		Subject:      decodeHeader(header.Get("Subject")),
+		To:           parseAddresses(header.Get("To")),
+	}
+
+	if from := parseAddresses(header.Get("From")); len(from) > 0 {
+		result.From = from[0]
+	}
+
+	if date, err := message.Header.Date(); err == nil {
+		result.Date = date
+	}
+
+	if err = result.parsePart(header, message.Body); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+/*
+parsePart adds a MIME part to the mail, descending into multipart parts
+*/
+func (m *Mail) parsePart(header textproto.MIMEHeader, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	body = decodeTransferEncoding(header.Get("Content-Transfer-Encoding"), body)
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+
+		for {
+			part, err := reader.NextRawPart()
+
+			if err == io.EOF {
+				return nil
+			}
+
+			if err != nil {
+				return fmt.Errorf("error reading %s part: %w", mediaType, err)
+			}
+
+			if err = m.parsePart(part.Header, part); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := ioutil.ReadAll(body)
+
+	if err != nil {
+		return fmt.Errorf("error reading %s part: %w", mediaType, err)
+	}
+
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dispositionParams["filename"]
+
+	if filename == "" {
+		filename = params["name"]
+	}
+
+	if disposition != "attachment" && filename == "" && (mediaType == "text/plain" || mediaType == "text/html") {
+		text := string(decodeCharset(data, params["charset"]))
+
+		if mediaType == "text/html" {
+			m.HTML += text
+		} else {
+			m.Text += text
+		}
+
+		return nil
+	}
+
+	attachment := Attachment{
+		ContentID:   strings.Trim(header.Get("Content-ID"), "<> "),
+		ContentType: mediaType,
+		Data:        data,
+		Filename:    decodeHeader(filename),
+	}
+
+	if attachment.ContentID != "" && disposition != "attachment" {
+		m.InlineImages = append(m.InlineImages, attachment)
+	} else {
+		m.Attachments = append(m.Attachments, attachment)
+	}
+
+	return nil
+}
+
+func decodeTransferEncoding(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &base64Cleaner{reader: body})
+
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	}
+
+	return body
+}
+
+/*
+decodeCharset converts text to UTF-8. Text in a charset that isn't
+recognized is returned as is
+*/
+func decodeCharset(data []byte, charset string) []byte {
+	charset = strings.ToLower(strings.TrimSpace(charset))
+
+	if charset == "" || charset == "utf-8" || charset == "us-ascii" {
+		return data
+	}
+
+	encoding, err := htmlindex.Get(charset)
+
+	if err != nil {
+		return data
+	}
+
+	result, err := encoding.NewDecoder().Bytes(data)
+
+	if err != nil {
+		return data
+	}
+
+	return result
+}
+
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	encoding, err := htmlindex.Get(charset)
+
+	if err != nil {
+		return nil, fmt.Errorf("unsupported charset %s: %w", charset, err)
+	}
+
+	return encoding.NewDecoder().Reader(input), nil
+}
+
+/*
+decodeHeader decodes RFC 2047 encoded words, such as
+"=?ISO-8859-1?Q?Caf=E9?=". Values that can't be decoded are returned as
+is
+*/
+func decodeHeader(value string) string {
+	decoded, err := wordDecoder.DecodeHeader(value)
+
+	if err != nil {
+		return value
+	}
+
+	return decoded
+}
+
+func parseAddresses(value string) []Address {
+	result := make([]Address, 0)
+
+	if strings.TrimSpace(value) == "" {
+		return result
+	}
+
+	parser := &mail.AddressParser{WordDecoder: wordDecoder}
+	addresses, err := parser.ParseList(value)
+
+	if err != nil {
+		for _, address := range strings.Split(value, ",") {
+			result = append(result, Address{Email: strings.TrimSpace(decodeHeader(address))})
+		}
+
+		return result
+	}
+
+	for _, address := range addresses {
+		result = append(result, Address{Email: address.Address, Name: address.Name})
+	}
+
+	return result
+}
+
+/*
+base64Cleaner drops the line breaks and spaces base64 bodies are
+wrapped with, which encoding/base64 only partly ignores
+*/
+type base64Cleaner struct {
+	reader io.Reader
+}
+
+func (c *base64Cleaner) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	cleaned := bytes.Map(func(r rune) rune {
+		if r == ' ' || r == '\t' || r == '\r' || r == '\n' {
+			return -1
+		}
+
+		return r
+	}, p[:n])
+
+	return copy(p, cleaned), err
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package mail_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/mail"
+)
+
+const rawMail = "From: =?ISO-8859-1?Q?Ren=E9?= <rene@example.com>\r\n" +
+	"To: Bob <bob@example.com>, carol@example.com\r\n" +
+	"Subject: =?UTF-8?B?Q2Fmw6kgbWVudQ==?=\r\n" +
+	"Date: Mon, 02 Jan 2006 15:04:05 -0700\r\n" +
+	"Message-ID: <abc123@example.com>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/related; boundary=related\r\n" +
+	"\r\n" +
+	"--related\r\n" +
+	"Content-Type: multipart/alternative; boundary=alt\r\n" +
+	"\r\n" +
+	"--alt\r\n" +
+	"Content-Type: text/plain; charset=ISO-8859-1\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Caf=E9 au lait\r\n" +
+	"--alt\r\n" +
+	"Content-Type: text/html; charset=UTF-8\r\n" +
+	"\r\n" +
+	"<p>Caf\xc3\xa9</p><img src=\"cid:logo\">\r\n" +
+	"--alt--\r\n" +
+	"--related\r\n" +
+	"Content-Type: image/png\r\n" +
+	"Content-ID: <logo>\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"cG5n\r\n" +
+	"--related--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/csv; name=\"=?UTF-8?Q?r=C3=A9sum=C3=A9.csv?=\"\r\n" +
+	"Content-Disposition: attachment; filename=\"=?UTF-8?Q?r=C3=A9sum=C3=A9.csv?=\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"YSxiCjEs\r\n" +
+	"Mgo=\r\n" +
+	"--outer--\r\n"
+
+func TestParse(t *testing.T) {
+	parsed, err := mail.Parse(strings.NewReader(rawMail))
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%v'", err)
+	}
+
+	tests := []struct {
+		name     string
+		actual   interface{}
+		expected interface{}
+	}{
+		{name: "From", actual: parsed.From, expected: mail.Address{Email: "rene@example.com", Name: "René"}},
+		{name: "To", actual: len(parsed.To), expected: 2},
+		{name: "Second To", actual: parsed.To[1].Email, expected: "carol@example.com"},
+		{name: "Subject", actual: parsed.Subject, expected: "Café menu"},
+		{name: "Date", actual: parsed.Date.UTC().Format("2006-01-02 15:04"), expected: "2006-01-02 22:04"},
+		{name: "Message ID", actual: parsed.MessageID, expected: "abc123@example.com"},
+		{name: "Text", actual: strings.TrimSpace(parsed.Text), expected: "Café au lait"},
+		{name: "HTML", actual: strings.TrimSpace(parsed.HTML), expected: `<p>Café</p><img src="cid:logo">`},
+		{name: "Inline images", actual: len(parsed.InlineImages), expected: 1},
+		{name: "Inline image", actual: parsed.InlineImages[0].ContentID + " " + string(parsed.InlineImages[0].Data), expected: "logo png"},
+		{name: "Attachments", actual: len(parsed.Attachments), expected: 1},
+		{name: "Attachment filename", actual: parsed.Attachments[0].Filename, expected: "résumé.csv"},
+		{name: "Attachment data", actual: string(parsed.Attachments[0].Data), expected: "a,b\n1,2\n"},
+		{name: "Raw header", actual: parsed.Headers.Get("Mime-Version"), expected: "1.0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.actual != test.expected {
+				t.Errorf("Expected '%v' but got '%v'", test.expected, test.actual)
+			}
+		})
+	}
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	message := testMessage()
+
+	if _, err := message.WriteTo(buffer); err != nil {
+		t.Fatalf("Expected no error but got '%v'", err)
+	}
+
+	parsed, err := mail.Parse(buffer)
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%v'", err)
+	}
+
+	if parsed.Subject != message.Subject || parsed.Text != message.Text || parsed.HTML != message.HTML {
+		t.Errorf("Expected bodies to survive a round trip but got %+v", parsed)
+	}
+
+	if len(parsed.Attachments) != 1 || string(parsed.Attachments[0].Data) != "a,b\n1,2\n" {
+		t.Errorf("Expected the attachment to survive a round trip but got %+v", parsed.Attachments)
+	}
+
+	if len(parsed.InlineImages) != 1 || parsed.InlineImages[0].ContentID != "logo" {
+		t.Errorf("Expected the inline image to survive a round trip but got %+v", parsed.InlineImages)
+	}
+}
@@ -0,0 +1,432 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package mail
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/ratelimit"
+	"github.com/ResurgenceIT/kit/v6/retry"
+)
+
+var ErrNoTransport = errors.New("mail queue requires a transport")
+
+/*
+QueueStats counts what a Queue has done since it was created. Pending
+is how many messages are waiting in the store
+*/
+type QueueStats struct {
+	Bounced    uint64 `json:"bounced"`
+	Deferred   uint64 `json:"deferred"`
+	Enqueued   uint64 `json:"enqueued"`
+	Pending    int    `json:"pending"`
+	Retried    uint64 `json:"retried"`
+	Sent       uint64 `json:"sent"`
+	Suppressed uint64 `json:"suppressed"`
+}
+
+/*
+Queue delivers mail in the background. Messages are saved to a store
+when enqueued, then delivered through a transport, with retries, per
+domain rate caps, and bounce handling. Queue implements ITransport, so
+code that sends mail doesn't need to know it is queued
+*/
+type Queue struct {
+	sync.Mutex
+
+	config   QueueConfig
+	limits   *ratelimit.MemoryStore
+	now      func() time.Time
+	retrier  *retry.Retrier
+	shutdown chan bool
+
+	bounced    uint64
+	deferred   uint64
+	enqueued   uint64
+	retried    uint64
+	sent       uint64
+	suppressed uint64
+}
+
+/*
+NewQueue creates a mail queue. Call Start to deliver in the background,
+or Process to deliver due messages once
+*/
+func NewQueue(config QueueConfig) (*Queue, error) {
+	if config.Transport == nil {
+		return nil, ErrNoTransport
+	}
+
+	if config.Store == nil {
+		config.Store = NewMemoryQueueStore()
+	}
+
+	if config.BatchSize <= 0 {
+		config.BatchSize = 10
+	}
+
+	if config.Lease <= 0 {
+		config.Lease = time.Minute * 5
+	}
+
+	if config.Name == "" {
+		config.Name = "mailqueue"
+	}
+
+	if config.IsPermanent == nil {
+		config.IsPermanent = IsPermanentError
+	}
+
+	if config.Retry.InitialInterval <= 0 {
+		config.Retry.InitialInterval = time.Minute
+	}
+
+	if config.Retry.MaxInterval <= 0 {
+		config.Retry.MaxInterval = time.Hour
+	}
+
+	if config.Retry.MaxAttempts == 0 {
+		config.Retry.MaxAttempts = 8
+	}
+
+	return &Queue{
+		config:  config,
+		limits:  ratelimit.NewMemoryStore(),
+		now:     time.Now,
+		retrier: retry.NewRetrier(config.Retry),
+	}, nil
+}
+
+/*
+Enqueue validates messages and saves them to be delivered
+*/
+func (q *Queue) Enqueue(ctx context.Context, messages ...Message) error {
+	now := q.now()
+	queued := make([]QueuedMessage, 0, len(messages))
+
+	for _, message := range messages {
+		if err := message.Validate(); err != nil {
+			return err
+		}
+
+		queued = append(queued, QueuedMessage{
+			CreatedAt:     now,
+			ID:            newID(),
+			Message:       message,
+			NextAttemptAt: now,
+		})
+	}
+
+	if err := q.config.Store.Enqueue(ctx, queued...); err != nil {
+		return fmt.Errorf("error enqueuing mail: %w", err)
+	}
+
+	atomic.AddUint64(&q.enqueued, uint64(len(queued)))
+	return nil
+}
+
+/*
+Send enqueues messages, so a Queue can be used as an ITransport
+*/
+func (q *Queue) Send(ctx context.Context, messages ...Message) error {
+	return q.Enqueue(ctx, messages...)
+}
+
+/*
+Process claims the messages that are due and tries to deliver them,
+returning how many were claimed. Delivery failures are handled by
+retrying or bouncing, so only store errors are returned
+*/
+func (q *Queue) Process(ctx context.Context) (int, error) {
+	now := q.now()
+	messages, err := q.config.Store.Claim(ctx, now, q.config.BatchSize, q.config.Lease)
+
+	if err != nil {
+		return 0, fmt.Errorf("error claiming queued mail: %w", err)
+	}
+
+	for _, message := range messages {
+		if err = q.deliver(ctx, message, now); err != nil {
+			return len(messages), err
+		}
+	}
+
+	return len(messages), nil
+}
+
+/*
+Start processes the queue every interval until Stop is called
+*/
+func (q *Queue) Start(interval time.Duration) {
+	q.Lock()
+	defer q.Unlock()
+
+	if q.shutdown != nil {
+		return
+	}
+
+	shutdown := make(chan bool)
+	q.shutdown = shutdown
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-shutdown:
+				return
+
+			case <-ticker.C:
+				if _, err := q.Process(context.Background()); err != nil && q.config.OnError != nil {
+					q.config.OnError(err)
+				}
+			}
+		}
+	}()
+}
+
+/*
+Stop stops the background processing started by Start
+*/
+func (q *Queue) Stop() {
+	q.Lock()
+	defer q.Unlock()
+
+	if q.shutdown == nil {
+		return
+	}
+
+	close(q.shutdown)
+	q.shutdown = nil
+}
+
+func (q *Queue) deliver(ctx context.Context, queued QueuedMessage, now time.Time) error {
+	var err error
+
+	if queued.Message, err = q.removeSuppressed(ctx, queued.Message); err != nil {
+		return err
+	}
+
+	if len(queued.Message.Recipients()) == 0 {
+		atomic.AddUint64(&q.suppressed, 1)
+		return q.remove(ctx, queued)
+	}
+
+	if wait := q.takeDomainLimits(ctx, queued.Message, now); wait > 0 {
+		atomic.AddUint64(&q.deferred, 1)
+		queued.NextAttemptAt = now.Add(wait)
+		return q.update(ctx, queued)
+	}
+
+	sendErr := q.config.Transport.Send(ctx, queued.Message)
+
+	if sendErr == nil {
+		atomic.AddUint64(&q.sent, 1)
+		return q.remove(ctx, queued)
+	}
+
+	queued.Attempts++
+	queued.LastError = sendErr.Error()
+
+	permanent := q.config.IsPermanent(sendErr)
+	outOfAttempts := q.config.Retry.MaxAttempts > 0 && queued.Attempts >= q.config.Retry.MaxAttempts
+
+	if !permanent && !outOfAttempts {
+		atomic.AddUint64(&q.retried, 1)
+		queued.NextAttemptAt = now.Add(q.retrier.Backoff(queued.Attempts, sendErr))
+		return q.update(ctx, queued)
+	}
+
+	var recipientErr *RecipientError
+
+	if permanent && q.config.SuppressionList != nil && errors.As(sendErr, &recipientErr) {
+		if err = q.config.SuppressionList.Suppress(ctx, recipientErr.Email, sendErr.Error()); err != nil {
+			return fmt.Errorf("error suppressing %s: %w", recipientErr.Email, err)
+		}
+	}
+
+	atomic.AddUint64(&q.bounced, 1)
+
+	if q.config.OnBounce != nil {
+		q.config.OnBounce(queued, sendErr)
+	}
+
+	return q.remove(ctx, queued)
+}
+
+/*
+removeSuppressed drops recipients on the suppression list
+*/
+func (q *Queue) removeSuppressed(ctx context.Context, message Message) (Message, error) {
+	if q.config.SuppressionList == nil {
+		return message, nil
+	}
+
+	filter := func(addresses []Address) ([]Address, error) {
+		result := make([]Address, 0, len(addresses))
+
+		for _, address := range addresses {
+			suppressed, err := q.config.SuppressionList.IsSuppressed(ctx, address.Email)
+
+			if err != nil {
+				return nil, fmt.Errorf("error checking suppression list: %w", err)
+			}
+
+			if !suppressed {
+				result = append(result, address)
+			}
+		}
+
+		return result, nil
+	}
+
+	var err error
+
+	if message.To, err = filter(message.To); err != nil {
+		return message, err
+	}
+
+	if message.Cc, err = filter(message.Cc); err != nil {
+		return message, err
+	}
+
+	message.Bcc, err = filter(message.Bcc)
+	return message, err
+}
+
+/*
+takeDomainLimits counts the message against the rate cap of every
+recipient domain, and returns how long to wait if any cap is reached
+*/
+func (q *Queue) takeDomainLimits(ctx context.Context, message Message, now time.Time) time.Duration {
+	var wait time.Duration
+
+	seen := make(map[string]struct{})
+
+	for _, recipient := range message.Recipients() {
+		domain := strings.ToLower(recipient[strings.LastIndex(recipient, "@")+1:])
+
+		if _, ok := seen[domain]; ok {
+			continue
+		}
+
+		seen[domain] = struct{}{}
+		rule, ok := q.config.DomainLimits[domain]
+
+		if !ok {
+			rule = q.config.DefaultDomainLimit
+		}
+
+		if rule.Limit <= 0 {
+			continue
+		}
+
+		if rule.Burst <= 0 {
+			rule.Burst = rule.Limit
+		}
+
+		result, _ := q.limits.Take(ctx, domain, rule, now)
+
+		if !result.Allowed && result.RetryAfter > wait {
+			wait = result.RetryAfter
+		}
+	}
+
+	return wait
+}
+
+func (q *Queue) remove(ctx context.Context, queued QueuedMessage) error {
+	if err := q.config.Store.Remove(ctx, queued.ID); err != nil {
+		return fmt.Errorf("error removing queued mail %s: %w", queued.ID, err)
+	}
+
+	return nil
+}
+
+func (q *Queue) update(ctx context.Context, queued QueuedMessage) error {
+	if err := q.config.Store.Update(ctx, queued); err != nil {
+		return fmt.Errorf("error updating queued mail %s: %w", queued.ID, err)
+	}
+
+	return nil
+}
+
+/*
+Stats returns the queue's counts and how many messages are pending
+*/
+func (q *Queue) Stats(ctx context.Context) (QueueStats, error) {
+	pending, err := q.config.Store.Len(ctx)
+
+	return QueueStats{
+		Bounced:    atomic.LoadUint64(&q.bounced),
+		Deferred:   atomic.LoadUint64(&q.deferred),
+		Enqueued:   atomic.LoadUint64(&q.enqueued),
+		Pending:    pending,
+		Retried:    atomic.LoadUint64(&q.retried),
+		Sent:       atomic.LoadUint64(&q.sent),
+		Suppressed: atomic.LoadUint64(&q.suppressed),
+	}, err
+}
+
+/*
+Name returns the name the queue's stats are reported under
+*/
+func (q *Queue) Name() string {
+	return q.config.Name
+}
+
+/*
+Collect returns the queue's stats in the form serverstats collectors
+report, so a Queue can be passed to RegisterCollector
+*/
+func (q *Queue) Collect(ctx context.Context) map[string]interface{} {
+	stats, err := q.Stats(ctx)
+
+	result := map[string]interface{}{
+		"bounced":    stats.Bounced,
+		"deferred":   stats.Deferred,
+		"enqueued":   stats.Enqueued,
+		"retried":    stats.Retried,
+		"sent":       stats.Sent,
+		"suppressed": stats.Suppressed,
+	}
+
+	if err == nil {
+		result["pending"] = stats.Pending
+	}
+
+	return result
+}
+
+/*
+IsPermanentError reports whether a delivery error isn't worth retrying:
+SMTP replies in the 5xx range, and messages that fail validation
+*/
+func IsPermanentError(err error) bool {
+	var smtpErr *textproto.Error
+
+	if errors.As(err, &smtpErr) {
+		return smtpErr.Code >= 500
+	}
+
+	return errors.Is(err, ErrNoBody) || errors.Is(err, ErrNoRecipients) || errors.Is(err, ErrNoSender)
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package mail
+
+import (
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/ratelimit"
+	"github.com/ResurgenceIT/kit/v6/retry"
+)
+
+/*
+QueueConfig configures a Queue. Transport is required, and Store
+defaults to a MemoryQueueStore.
+
+Each pass of Process claims up to BatchSize messages (default 10) for
+Lease (default 5 minutes). Failed deliveries are retried with backoff
+from Retry, which defaults to starting at a minute, growing to an hour,
+and giving up after 8 attempts. IsPermanent decides which errors are
+not worth retrying, and defaults to IsPermanentError.
+
+DomainLimits caps how fast mail is sent to each recipient domain, such
+as "gmail.com". DefaultDomainLimit applies to domains without their own
+limit, and a zero Limit means no cap. Burst defaults to Limit. Messages
+over a cap wait without using up an attempt.
+
+Recipients on SuppressionList are dropped before sending, and
+recipients rejected with a permanent error are added to it. OnBounce is
+called for messages that fail permanently or run out of attempts.
+OnError is called with store errors from the background processing
+started by Start. Name identifies the queue's stats in serverstats, and
+defaults to "mailqueue"
+*/
+type QueueConfig struct {
+	BatchSize          int
+	DefaultDomainLimit ratelimit.Rule
+	DomainLimits       map[string]ratelimit.Rule
+	IsPermanent        func(err error) bool
+	Lease              time.Duration
+	Name               string
+	OnBounce           func(message QueuedMessage, err error)
+	OnError            func(err error)
+	Retry              retry.RetrierConfig
+	Store              IQueueStore
+	SuppressionList    ISuppressionList
+	Transport          ITransport
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package mail
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+/*
+QueuedMessage is a message waiting in a Queue, with its delivery
+history
+*/
+type QueuedMessage struct {
+	Attempts      int
+	CreatedAt     time.Time
+	ID            string
+	LastError     string
+	Message       Message
+	NextAttemptAt time.Time
+}
+
+/*
+IQueueStore persists queued messages. Claim must hand each due message
+to only one caller, by pushing its NextAttemptAt out by lease, so
+several processes can share a store. If a process dies mid-delivery,
+the message is tried again once the lease runs out
+*/
+type IQueueStore interface {
+	Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]QueuedMessage, error)
+	Enqueue(ctx context.Context, messages ...QueuedMessage) error
+	Len(ctx context.Context) (int, error)
+	Remove(ctx context.Context, id string) error
+	Update(ctx context.Context, message QueuedMessage) error
+}
+
+/*
+MemoryQueueStore keeps queued messages in memory. Messages are lost when
+the process exits, so use it for tests and development, or where losing
+mail is acceptable
+*/
+type MemoryQueueStore struct {
+	sync.Mutex
+
+	messages map[string]QueuedMessage
+}
+
+/*
+NewMemoryQueueStore creates an empty in-memory queue store
+*/
+func NewMemoryQueueStore() *MemoryQueueStore {
+	return &MemoryQueueStore{
+		messages: make(map[string]QueuedMessage),
+	}
+}
+
+/*
+Claim returns up to limit messages due by now, oldest first
+*/
+func (s *MemoryQueueStore) Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]QueuedMessage, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	result := make([]QueuedMessage, 0, limit)
+
+	for _, message := range s.messages {
+		if !message.NextAttemptAt.After(now) {
+			result = append(result, message)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].NextAttemptAt.Before(result[j].NextAttemptAt)
+	})
+
+	if len(result) > limit {
+		result = result[:limit]
+	}
+
+	for _, message := range result {
+		message.NextAttemptAt = now.Add(lease)
+		s.messages[message.ID] = message
+	}
+
+	return result, nil
+}
+
+/*
+Enqueue adds messages to the store
+*/
+func (s *MemoryQueueStore) Enqueue(ctx context.Context, messages ...QueuedMessage) error {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, message := range messages {
+		s.messages[message.ID] = message
+	}
+
+	return nil
+}
+
+/*
+Len returns how many messages are waiting
+*/
+func (s *MemoryQueueStore) Len(ctx context.Context) (int, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	return len(s.messages), nil
+}
+
+/*
+Remove deletes a message from the store
+*/
+func (s *MemoryQueueStore) Remove(ctx context.Context, id string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.messages, id)
+	return nil
+}
+
+/*
+Update saves a message's delivery history and next attempt time
+*/
+func (s *MemoryQueueStore) Update(ctx context.Context, message QueuedMessage) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.messages[message.ID] = message
+	return nil
+}
@@ -0,0 +1,165 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package mail_test
+
+import (
+	"context"
+	"errors"
+	"net/textproto"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/mail"
+	"github.com/ResurgenceIT/kit/v6/ratelimit"
+	"github.com/ResurgenceIT/kit/v6/retry"
+)
+
+type scriptedTransport struct {
+	errs []error
+	sent []mail.Message
+}
+
+func (t *scriptedTransport) Send(ctx context.Context, messages ...mail.Message) error {
+	var err error
+
+	if len(t.errs) > 0 {
+		err, t.errs = t.errs[0], t.errs[1:]
+	}
+
+	if err == nil {
+		t.sent = append(t.sent, messages...)
+	}
+
+	return err
+}
+
+func TestQueue(t *testing.T) {
+	rejected := &mail.RecipientError{Email: "bob@example.com", Err: &textproto.Error{Code: 550, Msg: "no such user"}}
+
+	tests := []struct {
+		name          string
+		errs          []error
+		suppressed    []string
+		passes        int
+		expected      mail.QueueStats
+		bounced       bool
+		suppressesBob bool
+	}{
+		{
+			name:     "Sent first time",
+			errs:     []error{nil},
+			passes:   1,
+			expected: mail.QueueStats{Enqueued: 1, Sent: 1},
+		},
+		{
+			name:     "Retried after temporary failure",
+			errs:     []error{&textproto.Error{Code: 421, Msg: "try later"}, nil},
+			passes:   2,
+			expected: mail.QueueStats{Enqueued: 1, Retried: 1, Sent: 1},
+		},
+		{
+			name:     "Bounced after running out of attempts",
+			errs:     []error{errors.New("timeout"), errors.New("timeout"), errors.New("timeout")},
+			passes:   3,
+			expected: mail.QueueStats{Bounced: 1, Enqueued: 1, Retried: 2},
+			bounced:  true,
+		},
+		{
+			name:          "Permanent failure bounces and suppresses",
+			errs:          []error{rejected},
+			passes:        1,
+			expected:      mail.QueueStats{Bounced: 1, Enqueued: 1},
+			bounced:       true,
+			suppressesBob: true,
+		},
+		{
+			name:          "Suppressed recipients dropped",
+			suppressed:    []string{"BOB@example.com"},
+			passes:        1,
+			expected:      mail.QueueStats{Enqueued: 1, Suppressed: 1},
+			suppressesBob: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			transport := &scriptedTransport{errs: test.errs}
+			suppressionList := mail.NewMemorySuppressionList()
+			bounced := false
+
+			for _, address := range test.suppressed {
+				_ = suppressionList.Suppress(ctx, address, "complained")
+			}
+
+			queue, err := mail.NewQueue(mail.QueueConfig{
+				OnBounce:        func(message mail.QueuedMessage, err error) { bounced = true },
+				Retry:           retry.RetrierConfig{InitialInterval: time.Nanosecond, Jitter: -1, MaxAttempts: 3},
+				SuppressionList: suppressionList,
+				Transport:       transport,
+			})
+
+			if err != nil {
+				t.Fatalf("Expected no error but got '%v'", err)
+			}
+
+			message := testMessage()
+			message.Bcc = nil
+
+			if err = queue.Enqueue(ctx, message); err != nil {
+				t.Fatalf("Expected no error but got '%v'", err)
+			}
+
+			for pass := 0; pass < test.passes; pass++ {
+				time.Sleep(time.Millisecond)
+
+				if _, err = queue.Process(ctx); err != nil {
+					t.Fatalf("Expected no error but got '%v'", err)
+				}
+			}
+
+			stats, _ := queue.Stats(ctx)
+
+			if stats != test.expected {
+				t.Errorf("Expected stats %+v but got %+v", test.expected, stats)
+			}
+
+			if bounced != test.bounced {
+				t.Errorf("Expected bounced %v but got %v", test.bounced, bounced)
+			}
+
+			if isSuppressed, _ := suppressionList.IsSuppressed(ctx, "bob@example.com"); isSuppressed != test.suppressesBob {
+				t.Errorf("Expected bob@example.com suppressed to be %v", test.suppressesBob)
+			}
+		})
+	}
+}
+
+func TestQueueDomainLimits(t *testing.T) {
+	ctx := context.Background()
+	transport := &scriptedTransport{}
+
+	queue, _ := mail.NewQueue(mail.QueueConfig{
+		DomainLimits: map[string]ratelimit.Rule{
+			"example.com": {Limit: 1, Window: time.Hour},
+		},
+		Transport: transport,
+	})
+
+	message := testMessage()
+	message.Bcc = nil
+
+	_ = queue.Enqueue(ctx, message, message)
+
+	if _, err := queue.Process(ctx); err != nil {
+		t.Fatalf("Expected no error but got '%v'", err)
+	}
+
+	stats, _ := queue.Stats(ctx)
+
+	if stats.Sent != 1 || stats.Deferred != 1 || stats.Pending != 1 {
+		t.Errorf("Expected 1 sent, 1 deferred, and 1 pending but got %+v", stats)
+	}
+}
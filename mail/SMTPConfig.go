@@ -0,0 +1,45 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package mail
+
+import (
+	"crypto/tls"
+	"time"
+)
+
+/*
+Security is how an SMTP connection is encrypted
+*/
+type Security int
+
+const (
+	// SecuritySTARTTLS upgrades the connection with STARTTLS, failing if the server doesn't support it
+	SecuritySTARTTLS Security = iota
+
+	// SecurityTLS connects with TLS from the start, usually on port 465
+	SecurityTLS
+
+	// SecurityNone sends in plain text. Only use it for local development servers
+	SecurityNone
+)
+
+/*
+SMTPConfig configures an SMTPTransport. Port defaults to 465 with
+SecurityTLS and 587 otherwise. UserName and Password, when set, are
+sent with PLAIN authentication, which net/smtp only allows over TLS or
+to localhost. LocalName is the name sent with HELO, and defaults to
+"localhost". Timeout bounds the whole delivery, and defaults to 30
+seconds
+*/
+type SMTPConfig struct {
+	Host      string
+	LocalName string
+	Password  string
+	Port      int
+	Security  Security
+	TLSConfig *tls.Config
+	Timeout   time.Duration
+	UserName  string
+}
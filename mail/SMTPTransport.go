@@ -0,0 +1,203 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+var ErrSTARTTLSNotSupported = errors.New("SMTP server does not support STARTTLS")
+
+/*
+RecipientError is returned when the SMTP server rejects a recipient.
+Err is usually a *textproto.Error with the server's reply code
+*/
+type RecipientError struct {
+	Email string
+	Err   error
+}
+
+func (e *RecipientError) Error() string {
+	return fmt.Sprintf("error adding recipient %s: %s", e.Email, e.Err.Error())
+}
+
+func (e *RecipientError) Unwrap() error {
+	return e.Err
+}
+
+/*
+SMTPTransport delivers messages to an SMTP server. Each call to Send
+opens one connection and delivers every message over it
+*/
+type SMTPTransport struct {
+	config SMTPConfig
+}
+
+/*
+NewSMTPTransport creates a transport for an SMTP server
+*/
+func NewSMTPTransport(config SMTPConfig) *SMTPTransport {
+	if config.Port == 0 {
+		config.Port = 587
+
+		if config.Security == SecurityTLS {
+			config.Port = 465
+		}
+	}
+
+	if config.LocalName == "" {
+		config.LocalName = "localhost"
+	}
+
+	if config.Timeout <= 0 {
+		config.Timeout = time.Second * 30
+	}
+
+	if config.TLSConfig == nil {
+		config.TLSConfig = &tls.Config{ServerName: config.Host}
+	}
+
+	return &SMTPTransport{
+		config: config,
+	}
+}
+
+/*
+Send delivers messages. Delivery stops at the first message that fails
+*/
+func (t *SMTPTransport) Send(ctx context.Context, messages ...Message) error {
+	var (
+		client *smtp.Client
+		err    error
+	)
+
+	for _, message := range messages {
+		if err = message.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if client, err = t.connect(ctx); err != nil {
+		return err
+	}
+
+	defer client.Close()
+
+	for index, message := range messages {
+		if err = send(client, message); err != nil {
+			return fmt.Errorf("error sending message %d of %d: %w", index+1, len(messages), err)
+		}
+	}
+
+	return client.Quit()
+}
+
+func (t *SMTPTransport) connect(ctx context.Context) (*smtp.Client, error) {
+	var (
+		conn net.Conn
+		err  error
+	)
+
+	address := net.JoinHostPort(t.config.Host, strconv.Itoa(t.config.Port))
+	deadline := time.Now().Add(t.config.Timeout)
+
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	dialer := &net.Dialer{Deadline: deadline}
+
+	if conn, err = dialer.DialContext(ctx, "tcp", address); err != nil {
+		return nil, fmt.Errorf("error connecting to SMTP server %s: %w", address, err)
+	}
+
+	_ = conn.SetDeadline(deadline)
+
+	if t.config.Security == SecurityTLS {
+		conn = tls.Client(conn, t.config.TLSConfig)
+	}
+
+	client, err := smtp.NewClient(conn, t.config.Host)
+
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error starting SMTP session with %s: %w", address, err)
+	}
+
+	if err = t.start(client); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
+/*
+start says hello, upgrades the connection with STARTTLS when required,
+and authenticates
+*/
+func (t *SMTPTransport) start(client *smtp.Client) error {
+	var err error
+
+	if err = client.Hello(t.config.LocalName); err != nil {
+		return fmt.Errorf("error saying hello to SMTP server: %w", err)
+	}
+
+	if t.config.Security == SecuritySTARTTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return ErrSTARTTLSNotSupported
+		}
+
+		if err = client.StartTLS(t.config.TLSConfig); err != nil {
+			return fmt.Errorf("error starting TLS: %w", err)
+		}
+	}
+
+	if t.config.UserName != "" {
+		if err = client.Auth(smtp.PlainAuth("", t.config.UserName, t.config.Password, t.config.Host)); err != nil {
+			return fmt.Errorf("error authenticating with SMTP server: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func send(client *smtp.Client, message Message) error {
+	var err error
+
+	if err = client.Mail(message.From.Email); err != nil {
+		return fmt.Errorf("error setting sender: %w", err)
+	}
+
+	for _, recipient := range message.Recipients() {
+		if err = client.Rcpt(recipient); err != nil {
+			return &RecipientError{Email: recipient, Err: err}
+		}
+	}
+
+	writer, err := client.Data()
+
+	if err != nil {
+		return fmt.Errorf("error starting message data: %w", err)
+	}
+
+	if _, err = message.WriteTo(writer); err != nil {
+		writer.Close()
+		return fmt.Errorf("error writing message: %w", err)
+	}
+
+	if err = writer.Close(); err != nil {
+		return fmt.Errorf("error finishing message: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package mail
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+/*
+ISuppressionList tracks addresses mail should no longer be sent to, such
+as addresses that hard bounced or complained. Addresses are compared
+case-insensitively
+*/
+type ISuppressionList interface {
+	IsSuppressed(ctx context.Context, email string) (bool, error)
+	Suppress(ctx context.Context, email, reason string) error
+}
+
+/*
+MemorySuppressionList is an in-memory suppression list
+*/
+type MemorySuppressionList struct {
+	sync.RWMutex
+
+	addresses map[string]string
+}
+
+/*
+NewMemorySuppressionList creates an empty suppression list
+*/
+func NewMemorySuppressionList() *MemorySuppressionList {
+	return &MemorySuppressionList{
+		addresses: make(map[string]string),
+	}
+}
+
+/*
+IsSuppressed reports whether mail to email should be dropped
+*/
+func (l *MemorySuppressionList) IsSuppressed(ctx context.Context, email string) (bool, error) {
+	l.RLock()
+	defer l.RUnlock()
+
+	_, ok := l.addresses[strings.ToLower(email)]
+	return ok, nil
+}
+
+/*
+Suppress stops mail to email, recording why
+*/
+func (l *MemorySuppressionList) Suppress(ctx context.Context, email, reason string) error {
+	l.Lock()
+	defer l.Unlock()
+
+	l.addresses[strings.ToLower(email)] = reason
+	return nil
+}
+
+/*
+Reason returns why an address was suppressed, or an empty string if it
+isn't
+*/
+func (l *MemorySuppressionList) Reason(email string) string {
+	l.RLock()
+	defer l.RUnlock()
+
+	return l.addresses[strings.ToLower(email)]
+}
+
+/*
+Unsuppress allows mail to email again
+*/
+func (l *MemorySuppressionList) Unsuppress(email string) {
+	l.Lock()
+	defer l.Unlock()
+
+	delete(l.addresses, strings.ToLower(email))
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+)
+
+var ErrTemplateNotFound = errors.New("email template not found")
+
+const (
+	htmlSuffix    = ".html.tmpl"
+	subjectSuffix = ".subject.tmpl"
+	textSuffix    = ".txt.tmpl"
+)
+
+/*
+TemplateRenderer renders messages from Go templates. An email named
+"welcome" is made of up to three files: welcome.subject.tmpl,
+welcome.txt.tmpl, and welcome.html.tmpl. The HTML template is rendered
+with html/template, so data is escaped. Templates can be in
+subdirectories, in which case the name includes the path, such as
+"billing/invoice"
+*/
+type TemplateRenderer struct {
+	html    map[string]*htmltemplate.Template
+	subject map[string]*texttemplate.Template
+	text    map[string]*texttemplate.Template
+}
+
+/*
+NewTemplateRenderer parses every template in fsys, which is often an
+embed.FS. funcs, which may be nil, are available to every template
+*/
+func NewTemplateRenderer(fsys fs.FS, funcs map[string]interface{}) (*TemplateRenderer, error) {
+	result := &TemplateRenderer{
+		html:    make(map[string]*htmltemplate.Template),
+		subject: make(map[string]*texttemplate.Template),
+		text:    make(map[string]*texttemplate.Template),
+	}
+
+	err := fs.WalkDir(fsys, ".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		b, err := fs.ReadFile(fsys, path)
+
+		if err != nil {
+			return fmt.Errorf("error reading email template %s: %w", path, err)
+		}
+
+		switch {
+		case strings.HasSuffix(path, htmlSuffix):
+			result.html[strings.TrimSuffix(path, htmlSuffix)], err = htmltemplate.New(path).Funcs(funcs).Parse(string(b))
+
+		case strings.HasSuffix(path, subjectSuffix):
+			result.subject[strings.TrimSuffix(path, subjectSuffix)], err = texttemplate.New(path).Funcs(funcs).Parse(string(b))
+
+		case strings.HasSuffix(path, textSuffix):
+			result.text[strings.TrimSuffix(path, textSuffix)], err = texttemplate.New(path).Funcs(funcs).Parse(string(b))
+		}
+
+		if err != nil {
+			return fmt.Errorf("error parsing email template %s: %w", path, err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+/*
+Render renders the named email with data, setting the message's
+Subject, Text, and HTML. Parts without a template are left alone
+*/
+func (r *TemplateRenderer) Render(message *Message, name string, data interface{}) error {
+	var err error
+
+	subject, hasSubject := r.subject[name]
+	text, hasText := r.text[name]
+	html, hasHTML := r.html[name]
+
+	if !hasSubject && !hasText && !hasHTML {
+		return fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+
+	buffer := &bytes.Buffer{}
+
+	if hasSubject {
+		if err = subject.Execute(buffer, data); err != nil {
+			return fmt.Errorf("error rendering subject for email %s: %w", name, err)
+		}
+
+		message.Subject = strings.TrimSpace(buffer.String())
+		buffer.Reset()
+	}
+
+	if hasText {
+		if err = text.Execute(buffer, data); err != nil {
+			return fmt.Errorf("error rendering text for email %s: %w", name, err)
+		}
+
+		message.Text = buffer.String()
+		buffer.Reset()
+	}
+
+	if hasHTML {
+		if err = html.Execute(buffer, data); err != nil {
+			return fmt.Errorf("error rendering HTML for email %s: %w", name, err)
+		}
+
+		message.HTML = buffer.String()
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package mail
+
+import (
+	"context"
+	"sync"
+)
+
+/*
+ITransport delivers messages
+*/
+type ITransport interface {
+	Send(ctx context.Context, messages ...Message) error
+}
+
+/*
+TestTransport is a transport for tests. Instead of delivering messages
+it keeps them, so tests can check what was sent. Set Err to make Send
+fail
+*/
+type TestTransport struct {
+	sync.Mutex
+
+	Err error
+
+	messages []Message
+}
+
+/*
+NewTestTransport creates a transport that captures sent messages
+*/
+func NewTestTransport() *TestTransport {
+	return &TestTransport{
+		messages: make([]Message, 0),
+	}
+}
+
+/*
+Send validates and captures messages, or returns Err when it is set
+*/
+func (t *TestTransport) Send(ctx context.Context, messages ...Message) error {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.Err != nil {
+		return t.Err
+	}
+
+	for _, message := range messages {
+		if err := message.Validate(); err != nil {
+			return err
+		}
+	}
+
+	t.messages = append(t.messages, messages...)
+	return nil
+}
+
+/*
+Messages returns every message sent so far
+*/
+func (t *TestTransport) Messages() []Message {
+	t.Lock()
+	defer t.Unlock()
+
+	result := make([]Message, len(t.messages))
+	copy(result, t.messages)
+	return result
+}
+
+/*
+Reset forgets every sent message
+*/
+func (t *TestTransport) Reset() {
+	t.Lock()
+	t.messages = t.messages[:0]
+	t.Unlock()
+}
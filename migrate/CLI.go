@@ -0,0 +1,152 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+var ErrUsage = errors.New("usage: up | down [steps] | to <version> | status | version | force <version>")
+
+/*
+Run is a command line entry point for a Migrator, so a service can
+offer migrations as a subcommand or from a small main.
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrate.Run(ctx, migrator, os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
+The commands are up, down [steps] (default 1), to <version>, status,
+version, and force <version>
+*/
+func Run(ctx context.Context, m *Migrator, args []string, out io.Writer) error {
+	var (
+		err        error
+		migrations []Migration
+	)
+
+	if len(args) == 0 {
+		return ErrUsage
+	}
+
+	switch args[0] {
+	case "up":
+		migrations, err = m.Up(ctx)
+		printMigrations(out, "applied", migrations)
+
+	case "down":
+		steps := 1
+
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return ErrUsage
+			}
+		}
+
+		migrations, err = m.Down(ctx, steps)
+		printMigrations(out, "rolled back", migrations)
+
+	case "to":
+		version, parseErr := versionArg(args)
+
+		if parseErr != nil {
+			return parseErr
+		}
+
+		migrations, err = m.To(ctx, version)
+		printMigrations(out, "migrated", migrations)
+
+	case "status":
+		return printStatus(ctx, m, out)
+
+	case "version":
+		version, dirty, versionErr := m.Version(ctx)
+
+		if versionErr != nil {
+			return versionErr
+		}
+
+		if dirty {
+			fmt.Fprintf(out, "%d (dirty)\n", version)
+		} else {
+			fmt.Fprintf(out, "%d\n", version)
+		}
+
+	case "force":
+		version, parseErr := versionArg(args)
+
+		if parseErr != nil {
+			return parseErr
+		}
+
+		err = m.Force(ctx, version)
+
+	default:
+		return ErrUsage
+	}
+
+	return err
+}
+
+func versionArg(args []string) (int64, error) {
+	if len(args) < 2 {
+		return 0, ErrUsage
+	}
+
+	version, err := strconv.ParseInt(args[1], 10, 64)
+
+	if err != nil {
+		return 0, ErrUsage
+	}
+
+	return version, nil
+}
+
+func printMigrations(out io.Writer, action string, migrations []Migration) {
+	if len(migrations) == 0 {
+		fmt.Fprintln(out, "nothing to do")
+	}
+
+	for _, migration := range migrations {
+		fmt.Fprintf(out, "%s %d %s\n", action, migration.Version, migration.Name)
+	}
+}
+
+func printStatus(ctx context.Context, m *Migrator, out io.Writer) error {
+	statuses, err := m.Status(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+
+	for _, status := range statuses {
+		state, appliedAt := "pending", ""
+
+		if status.Applied {
+			state, appliedAt = "applied", status.AppliedAt.Format(time.RFC3339)
+		}
+
+		if status.Dirty {
+			state = "dirty"
+		}
+
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+
+	return writer.Flush()
+}
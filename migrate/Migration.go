@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package migrate
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+/*
+Migration is one version's up and down SQL
+*/
+type Migration struct {
+	Down    string
+	Name    string
+	Up      string
+	Version int64
+}
+
+/*
+MigrationStatus is a migration and whether it has been applied. Dirty
+migrations started but never finished
+*/
+type MigrationStatus struct {
+	AppliedAt time.Time
+	Applied   bool
+	Dirty     bool
+	Name      string
+	Version   int64
+}
+
+/*
+loadMigrations reads migration files named like
+0001_create_users.up.sql and 0001_create_users.down.sql, sorted by
+version. Down files are optional
+*/
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+
+	if err != nil {
+		return nil, fmt.Errorf("error reading migrations: %w", err)
+	}
+
+	byVersion := map[int64]*Migration{}
+
+	for _, entry := range entries {
+		matches := migrationFileName.FindStringSubmatch(entry.Name())
+
+		if entry.IsDir() || matches == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: '%s'", ErrInvalidMigration, entry.Name())
+		}
+
+		contents, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+
+		if err != nil {
+			return nil, fmt.Errorf("error reading migration '%s': %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+
+		if !ok {
+			migration = &Migration{Name: matches[2], Version: version}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != matches[2] {
+			return nil, fmt.Errorf("%w: version %d is used by '%s' and '%s'", ErrInvalidMigration, version, migration.Name, matches[2])
+		}
+
+		if matches[3] == "up" {
+			migration.Up = string(contents)
+		} else {
+			migration.Down = string(contents)
+		}
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("%w: version %d has no up migration", ErrInvalidMigration, migration.Version)
+		}
+
+		result = append(result, *migration)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+
+	return result, nil
+}
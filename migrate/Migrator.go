@@ -0,0 +1,389 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+)
+
+var (
+	ErrDirty            = errors.New("database is dirty; fix it by hand, then call Force")
+	ErrInvalidMigration = errors.New("invalid migration")
+	ErrMissingDB        = errors.New("migrator needs a DB and an FS")
+	ErrNoDownMigration  = errors.New("migration has no down migration")
+	ErrUnknownVersion   = errors.New("unknown migration version")
+)
+
+/*
+Migrator applies and rolls back SQL migrations, recording applied
+versions in a table. Each migration runs in a transaction. A migration
+is marked dirty before it starts and clean once it commits, so one that
+fails part way, such as DDL on MySQL which can't be rolled back, stops
+every later run with ErrDirty until it is fixed and Force is called.
+
+Run migrations from one instance at a time, such as from a deploy step
+or the CLI, as the Migrator doesn't lock the table
+*/
+type Migrator struct {
+	config     MigratorConfig
+	migrations []Migration
+	now        func() time.Time
+}
+
+type appliedVersion struct {
+	appliedAt time.Time
+	dirty     bool
+}
+
+/*
+NewMigrator creates a Migrator, reading migrations from the config's FS
+*/
+func NewMigrator(config MigratorConfig) (*Migrator, error) {
+	if config.DB == nil || config.FS == nil {
+		return nil, ErrMissingDB
+	}
+
+	if config.Dir == "" {
+		config.Dir = "."
+	}
+
+	if config.Logf == nil {
+		config.Logf = func(format string, args ...interface{}) {}
+	}
+
+	if config.Rebind == nil {
+		config.Rebind = func(query string) string { return query }
+	}
+
+	if config.Table == "" {
+		config.Table = "schema_migrations"
+	}
+
+	migrations, err := loadMigrations(config.FS, config.Dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		config:     config,
+		migrations: migrations,
+		now:        time.Now,
+	}, nil
+}
+
+/*
+Migrations returns the migrations, oldest first
+*/
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+/*
+Up applies every pending migration in order, and returns those applied
+*/
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.To(ctx, m.latest())
+}
+
+/*
+Down rolls back the most recently applied migrations, up to steps of
+them, and returns those rolled back
+*/
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	applied, err := m.checkApplied(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := []Migration{}
+
+	for index := len(m.migrations) - 1; index >= 0 && len(result) < steps; index-- {
+		migration := m.migrations[index]
+
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		if err = m.down(ctx, migration); err != nil {
+			return result, err
+		}
+
+		result = append(result, migration)
+	}
+
+	return result, nil
+}
+
+/*
+To migrates up or down until version is the latest applied migration.
+Version 0 rolls everything back
+*/
+func (m *Migrator) To(ctx context.Context, version int64) ([]Migration, error) {
+	if version != 0 && m.find(version) == nil {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	applied, err := m.checkApplied(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := []Migration{}
+
+	for index := len(m.migrations) - 1; index >= 0; index-- {
+		migration := m.migrations[index]
+
+		if _, ok := applied[migration.Version]; ok && migration.Version > version {
+			if err = m.down(ctx, migration); err != nil {
+				return result, err
+			}
+
+			result = append(result, migration)
+		}
+	}
+
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok && migration.Version <= version {
+			if err = m.up(ctx, migration); err != nil {
+				return result, err
+			}
+
+			result = append(result, migration)
+		}
+	}
+
+	return result, nil
+}
+
+/*
+Status reports every migration and whether it has been applied
+*/
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]MigrationStatus, 0, len(m.migrations))
+
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Name: migration.Name, Version: migration.Version}
+
+		if version, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = version.appliedAt
+			status.Dirty = version.dirty
+		}
+
+		result = append(result, status)
+	}
+
+	return result, nil
+}
+
+/*
+Version returns the latest applied version, 0 when none are, and
+whether any migration is dirty
+*/
+func (m *Migrator) Version(ctx context.Context) (int64, bool, error) {
+	applied, err := m.applied(ctx)
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	var (
+		result int64
+		dirty  bool
+	)
+
+	for version, status := range applied {
+		if version > result {
+			result = version
+		}
+
+		dirty = dirty || status.dirty
+	}
+
+	return result, dirty, nil
+}
+
+/*
+Force marks a dirty version clean, once whatever it left behind has
+been fixed by hand. Versions that were never applied are recorded as
+applied
+*/
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	migration := m.find(version)
+
+	if migration == nil {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	if err := m.ensureTable(ctx); err != nil {
+		return err
+	}
+
+	if _, err := m.exec(ctx, m.config.DB, "DELETE FROM "+m.config.Table+" WHERE version=?", version); err != nil {
+		return fmt.Errorf("error forcing version %d: %w", version, err)
+	}
+
+	if _, err := m.exec(ctx, m.config.DB, "INSERT INTO "+m.config.Table+" (version, name, dirty, applied_at) VALUES (?, ?, ?, ?)", version, migration.Name, false, m.now().UTC()); err != nil {
+		return fmt.Errorf("error forcing version %d: %w", version, err)
+	}
+
+	return nil
+}
+
+func (m *Migrator) up(ctx context.Context, migration Migration) error {
+	m.config.Logf("applying migration %d %s", migration.Version, migration.Name)
+
+	if _, err := m.exec(ctx, m.config.DB, "INSERT INTO "+m.config.Table+" (version, name, dirty, applied_at) VALUES (?, ?, ?, ?)", migration.Version, migration.Name, true, m.now().UTC()); err != nil {
+		return fmt.Errorf("error recording migration %d: %w", migration.Version, err)
+	}
+
+	err := m.inTransaction(ctx, migration.Up, "UPDATE "+m.config.Table+" SET dirty=? WHERE version=?", false, migration.Version)
+
+	if err != nil {
+		return fmt.Errorf("error applying migration %d %s: %w", migration.Version, migration.Name, err)
+	}
+
+	return nil
+}
+
+func (m *Migrator) down(ctx context.Context, migration Migration) error {
+	if migration.Down == "" {
+		return fmt.Errorf("%w: %d %s", ErrNoDownMigration, migration.Version, migration.Name)
+	}
+
+	m.config.Logf("rolling back migration %d %s", migration.Version, migration.Name)
+
+	if _, err := m.exec(ctx, m.config.DB, "UPDATE "+m.config.Table+" SET dirty=? WHERE version=?", true, migration.Version); err != nil {
+		return fmt.Errorf("error recording migration %d: %w", migration.Version, err)
+	}
+
+	err := m.inTransaction(ctx, migration.Down, "DELETE FROM "+m.config.Table+" WHERE version=?", migration.Version)
+
+	if err != nil {
+		return fmt.Errorf("error rolling back migration %d %s: %w", migration.Version, migration.Name, err)
+	}
+
+	return nil
+}
+
+/*
+inTransaction runs the migration's SQL and the statement recording it
+in one transaction
+*/
+func (m *Migrator) inTransaction(ctx context.Context, migrationSQL, record string, args ...interface{}) error {
+	tx, err := m.config.DB.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, migrationSQL); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if _, err = m.exec(ctx, tx, record, args...); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *Migrator) checkApplied(ctx context.Context) (map[int64]appliedVersion, error) {
+	applied, err := m.applied(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for version, status := range applied {
+		if status.dirty {
+			return nil, fmt.Errorf("%w: version %d", ErrDirty, version)
+		}
+	}
+
+	return applied, nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int64]appliedVersion, error) {
+	var (
+		err  error
+		rows sqldatabase.Rows
+	)
+
+	if err = m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	if rows, err = m.config.DB.QueryContext(ctx, "SELECT version, dirty, applied_at FROM "+m.config.Table); err != nil {
+		return nil, fmt.Errorf("error reading applied migrations: %w", err)
+	}
+
+	defer rows.Close()
+	result := map[int64]appliedVersion{}
+
+	for rows.Next() {
+		var (
+			version int64
+			status  appliedVersion
+		)
+
+		if err = rows.Scan(&version, &status.dirty, &status.appliedAt); err != nil {
+			return nil, fmt.Errorf("error reading applied migrations: %w", err)
+		}
+
+		result[version] = status
+	}
+
+	return result, rows.Err()
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	_, err := m.config.DB.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+m.config.Table+" (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, dirty BOOLEAN NOT NULL, applied_at TIMESTAMP NOT NULL)")
+
+	if err != nil {
+		return fmt.Errorf("error creating migrations table: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Migrator) exec(ctx context.Context, q sqldatabase.Querier, query string, args ...interface{}) (sql.Result, error) {
+	return q.ExecContext(ctx, m.config.Rebind(query), args...)
+}
+
+func (m *Migrator) find(version int64) *Migration {
+	for index := range m.migrations {
+		if m.migrations[index].Version == version {
+			return &m.migrations[index]
+		}
+	}
+
+	return nil
+}
+
+func (m *Migrator) latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
@@ -0,0 +1,30 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package migrate
+
+import (
+	"io/fs"
+
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+)
+
+/*
+MigratorConfig configures a Migrator.
+
+FS holds the migration files, usually an embed.FS, and Dir is the
+directory in it they are in (default "."). Table is where applied
+versions are recorded, and defaults to "schema_migrations". Queries are
+written with ? placeholders; set Rebind to rewrite them for databases
+that use another style, such as $1 for Postgres. Logf, when set, is
+told about each migration as it runs
+*/
+type MigratorConfig struct {
+	DB     sqldatabase.DB
+	Dir    string
+	FS     fs.FS
+	Logf   func(format string, args ...interface{})
+	Rebind func(query string) string
+	Table  string
+}
@@ -0,0 +1,252 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package migrate_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/migrate"
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+)
+
+type versionRow struct {
+	dirty bool
+	name  string
+}
+
+/*
+fakeDB keeps the migrations table in memory and records the migration
+SQL that ran. Migration SQL containing FAIL fails
+*/
+type fakeDB struct {
+	executed []string
+	versions map[int64]versionRow
+}
+
+func newFakeDB() (*fakeDB, *sqldatabase.MockDB) {
+	fake := &fakeDB{versions: map[int64]versionRow{}}
+
+	return fake, &sqldatabase.MockDB{
+		BeginFunc: func() (sqldatabase.Tx, error) {
+			pending := []func(){}
+
+			return &sqldatabase.MockTx{
+				CommitFunc: func() error {
+					for _, apply := range pending {
+						apply()
+					}
+
+					return nil
+				},
+				ExecContextFunc: func(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+					if strings.Contains(query, "FAIL") {
+						return nil, errors.New("syntax error")
+					}
+
+					if strings.Contains(query, "schema_migrations") {
+						pending = append(pending, func() { fake.record(query, args) })
+					} else {
+						pending = append(pending, func() { fake.executed = append(fake.executed, query) })
+					}
+
+					return nil, nil
+				},
+				RollbackFunc: func() error { return nil },
+			}, nil
+		},
+		ExecContextFunc: func(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+			fake.record(query, args)
+			return nil, nil
+		},
+		QueryContextFunc: func(ctx context.Context, query string, args ...interface{}) (sqldatabase.Rows, error) {
+			versions := make([]int64, 0, len(fake.versions))
+
+			for version := range fake.versions {
+				versions = append(versions, version)
+			}
+
+			sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+			index := -1
+
+			return &sqldatabase.MockRows{
+				CloseFunc: func() error { return nil },
+				ErrFunc:   func() error { return nil },
+				NextFunc: func() bool {
+					index++
+					return index < len(versions)
+				},
+				ScanFunc: func(dst ...interface{}) error {
+					*dst[0].(*int64) = versions[index]
+					*dst[1].(*bool) = fake.versions[versions[index]].dirty
+					*dst[2].(*time.Time) = time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+					return nil
+				},
+			}, nil
+		},
+	}
+}
+
+func (f *fakeDB) record(query string, args []interface{}) {
+	switch {
+	case strings.HasPrefix(query, "INSERT"):
+		f.versions[args[0].(int64)] = versionRow{dirty: args[2].(bool), name: args[1].(string)}
+
+	case strings.HasPrefix(query, "UPDATE"):
+		row := f.versions[args[1].(int64)]
+		row.dirty = args[0].(bool)
+		f.versions[args[1].(int64)] = row
+
+	case strings.HasPrefix(query, "DELETE"):
+		delete(f.versions, args[0].(int64))
+	}
+}
+
+func migrations(extra fstest.MapFS) fstest.MapFS {
+	result := fstest.MapFS{
+		"migrations/0001_create_users.up.sql":    {Data: []byte("CREATE TABLE users")},
+		"migrations/0001_create_users.down.sql":  {Data: []byte("DROP TABLE users")},
+		"migrations/0002_add_email.up.sql":       {Data: []byte("ALTER TABLE users ADD email")},
+		"migrations/0002_add_email.down.sql":     {Data: []byte("ALTER TABLE users DROP email")},
+		"migrations/0010_create_orders.up.sql":   {Data: []byte("CREATE TABLE orders")},
+		"migrations/0010_create_orders.down.sql": {Data: []byte("DROP TABLE orders")},
+		"migrations/README.md":                   {Data: []byte("not a migration")},
+	}
+
+	for name, file := range extra {
+		result[name] = file
+	}
+
+	return result
+}
+
+func TestMigrator(t *testing.T) {
+	fake, db := newFakeDB()
+	migrator, err := migrate.NewMigrator(migrate.MigratorConfig{DB: db, Dir: "migrations", FS: migrations(nil)})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	ctx := context.Background()
+
+	if applied, err := migrator.Up(ctx); err != nil || len(applied) != 3 {
+		t.Fatalf("Expected 3 migrations to be applied but got %d, %v", len(applied), err)
+	}
+
+	if version, dirty, _ := migrator.Version(ctx); version != 10 || dirty {
+		t.Errorf("Expected clean version 10 but got %d, dirty %v", version, dirty)
+	}
+
+	if applied, _ := migrator.Up(ctx); len(applied) != 0 {
+		t.Errorf("Expected nothing to apply but got %d", len(applied))
+	}
+
+	if rolledBack, err := migrator.Down(ctx, 2); err != nil || len(rolledBack) != 2 || rolledBack[0].Version != 10 {
+		t.Errorf("Expected 10 and 2 to be rolled back but got %+v, %v", rolledBack, err)
+	}
+
+	if _, err = migrator.To(ctx, 2); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	expected := []string{
+		"CREATE TABLE users", "ALTER TABLE users ADD email", "CREATE TABLE orders",
+		"DROP TABLE orders", "ALTER TABLE users DROP email", "ALTER TABLE users ADD email",
+	}
+
+	if strings.Join(fake.executed, ";") != strings.Join(expected, ";") {
+		t.Errorf("Expected %v but got %v", expected, fake.executed)
+	}
+
+	if _, err = migrator.To(ctx, 5); !errors.Is(err, migrate.ErrUnknownVersion) {
+		t.Errorf("Expected ErrUnknownVersion but got '%v'", err)
+	}
+}
+
+func TestDirtyMigration(t *testing.T) {
+	_, db := newFakeDB()
+	migrator, _ := migrate.NewMigrator(migrate.MigratorConfig{DB: db, Dir: "migrations", FS: migrations(fstest.MapFS{
+		"migrations/0003_broken.up.sql": {Data: []byte("FAIL")},
+	})})
+
+	ctx := context.Background()
+	applied, err := migrator.Up(ctx)
+
+	if err == nil || len(applied) != 2 {
+		t.Fatalf("Expected the third migration to fail but got %d applied, %v", len(applied), err)
+	}
+
+	if _, err = migrator.Up(ctx); !errors.Is(err, migrate.ErrDirty) {
+		t.Errorf("Expected ErrDirty but got '%v'", err)
+	}
+
+	if err = migrator.Force(ctx, 3); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	if applied, err = migrator.Up(ctx); err != nil || len(applied) != 1 || applied[0].Version != 10 {
+		t.Errorf("Expected version 10 to be applied after forcing but got %+v, %v", applied, err)
+	}
+}
+
+func TestInvalidMigrations(t *testing.T) {
+	_, db := newFakeDB()
+
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{name: "Duplicate version", files: fstest.MapFS{"migrations/0001_other.up.sql": {Data: []byte("SELECT 1")}}},
+		{name: "Down without up", files: fstest.MapFS{"migrations/0004_orphan.down.sql": {Data: []byte("SELECT 1")}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := migrate.NewMigrator(migrate.MigratorConfig{DB: db, Dir: "migrations", FS: migrations(test.files)}); !errors.Is(err, migrate.ErrInvalidMigration) {
+				t.Errorf("Expected ErrInvalidMigration but got '%v'", err)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	_, db := newFakeDB()
+	migrator, _ := migrate.NewMigrator(migrate.MigratorConfig{DB: db, Dir: "migrations", FS: migrations(nil)})
+
+	tests := []struct {
+		args     []string
+		expected string
+		err      error
+	}{
+		{args: []string{"up"}, expected: "applied 1 create_users\napplied 2 add_email\napplied 10 create_orders\n"},
+		{args: []string{"down"}, expected: "rolled back 10 create_orders\n"},
+		{args: []string{"version"}, expected: "2\n"},
+		{args: []string{"status"}, expected: "VERSION  NAME           STATUS   APPLIED AT\n1        create_users   applied  2021-06-01T12:00:00Z\n2        add_email      applied  2021-06-01T12:00:00Z\n10       create_orders  pending  \n"},
+		{args: []string{"down", "zero"}, err: migrate.ErrUsage},
+		{args: []string{"sideways"}, err: migrate.ErrUsage},
+	}
+
+	for _, test := range tests {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			out := &bytes.Buffer{}
+
+			if err := migrate.Run(context.Background(), migrator, test.args, out); !errors.Is(err, test.err) {
+				t.Fatalf("Expected error '%v' but got '%v'", test.err, err)
+			}
+
+			if out.String() != test.expected {
+				t.Errorf("Expected %q but got %q", test.expected, out.String())
+			}
+		})
+	}
+}
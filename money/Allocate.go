@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package money
+
+import (
+	"fmt"
+	"math/big"
+)
+
+/*
+Allocate splits the amount by ratios, such as 70 and 30 for a 70/30
+revenue share. The parts always add up to the amount: minor units left
+over from rounding down go one each to the parts with the largest
+remainders, earlier parts first on ties
+*/
+func (m Money) Allocate(ratios ...int64) ([]Money, error) {
+	if len(ratios) == 0 {
+		return nil, fmt.Errorf("%w: no ratios to allocate by", ErrInvalidAmount)
+	}
+
+	total := int64(0)
+
+	for _, ratio := range ratios {
+		if ratio < 0 {
+			return nil, fmt.Errorf("%w: ratios can't be negative", ErrInvalidAmount)
+		}
+
+		total += ratio
+	}
+
+	if total == 0 {
+		return nil, fmt.Errorf("%w: ratios add up to zero", ErrInvalidAmount)
+	}
+
+	magnitude := m.Abs().amount
+	parts := make([]int64, len(ratios))
+	remainders := make([]int64, len(ratios))
+	allocated := int64(0)
+
+	for index, ratio := range ratios {
+		product := new(big.Int).Mul(big.NewInt(magnitude), big.NewInt(ratio))
+		quotient, remainder := new(big.Int).QuoRem(product, big.NewInt(total), new(big.Int))
+
+		parts[index] = quotient.Int64()
+		remainders[index] = remainder.Int64()
+		allocated += parts[index]
+	}
+
+	for leftover := magnitude - allocated; leftover > 0; leftover-- {
+		largest := 0
+
+		for index := range remainders {
+			if remainders[index] > remainders[largest] {
+				largest = index
+			}
+		}
+
+		parts[largest]++
+		remainders[largest] = -1
+	}
+
+	result := make([]Money, len(parts))
+
+	for index, part := range parts {
+		result[index] = Money{amount: part, currency: m.currency}
+
+		if m.amount < 0 {
+			result[index] = result[index].Negate()
+		}
+	}
+
+	return result, nil
+}
+
+/*
+Split divides the amount into n parts that add up to it, such as $10
+into $3.34, $3.33, and $3.33
+*/
+func (m Money) Split(n int) ([]Money, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: can't split into %d parts", ErrInvalidAmount, n)
+	}
+
+	ratios := make([]int64, n)
+
+	for index := range ratios {
+		ratios[index] = 1
+	}
+
+	return m.Allocate(ratios...)
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package money
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+/*
+Currency is an ISO 4217 currency. Decimals is the number of digits in
+its minor unit, such as 2 for cents, and Symbol how it is written in
+amounts
+*/
+type Currency struct {
+	Code     string
+	Decimals int
+	Symbol   string
+}
+
+var (
+	currencies = map[string]Currency{
+		"AUD": {Code: "AUD", Decimals: 2, Symbol: "A$"},
+		"BHD": {Code: "BHD", Decimals: 3, Symbol: "BHD"},
+		"BRL": {Code: "BRL", Decimals: 2, Symbol: "R$"},
+		"CAD": {Code: "CAD", Decimals: 2, Symbol: "CA$"},
+		"CHF": {Code: "CHF", Decimals: 2, Symbol: "CHF"},
+		"CNY": {Code: "CNY", Decimals: 2, Symbol: "CN¥"},
+		"CZK": {Code: "CZK", Decimals: 2, Symbol: "Kč"},
+		"DKK": {Code: "DKK", Decimals: 2, Symbol: "kr."},
+		"EUR": {Code: "EUR", Decimals: 2, Symbol: "€"},
+		"GBP": {Code: "GBP", Decimals: 2, Symbol: "£"},
+		"HKD": {Code: "HKD", Decimals: 2, Symbol: "HK$"},
+		"HUF": {Code: "HUF", Decimals: 2, Symbol: "Ft"},
+		"INR": {Code: "INR", Decimals: 2, Symbol: "₹"},
+		"JPY": {Code: "JPY", Decimals: 0, Symbol: "¥"},
+		"KRW": {Code: "KRW", Decimals: 0, Symbol: "₩"},
+		"KWD": {Code: "KWD", Decimals: 3, Symbol: "KWD"},
+		"MXN": {Code: "MXN", Decimals: 2, Symbol: "MX$"},
+		"NOK": {Code: "NOK", Decimals: 2, Symbol: "kr"},
+		"NZD": {Code: "NZD", Decimals: 2, Symbol: "NZ$"},
+		"PLN": {Code: "PLN", Decimals: 2, Symbol: "zł"},
+		"SEK": {Code: "SEK", Decimals: 2, Symbol: "kr"},
+		"SGD": {Code: "SGD", Decimals: 2, Symbol: "S$"},
+		"USD": {Code: "USD", Decimals: 2, Symbol: "$"},
+		"ZAR": {Code: "ZAR", Decimals: 2, Symbol: "R"},
+	}
+
+	currencyMutex sync.RWMutex
+)
+
+/*
+LookupCurrency returns a currency by its code, such as "USD"
+*/
+func LookupCurrency(code string) (Currency, error) {
+	currencyMutex.RLock()
+	defer currencyMutex.RUnlock()
+
+	result, ok := currencies[strings.ToUpper(code)]
+
+	if !ok {
+		return Currency{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, code)
+	}
+
+	return result, nil
+}
+
+/*
+RegisterCurrency adds a currency, or replaces a built in one, such as to
+write CAD as "$" in a Canadian app
+*/
+func RegisterCurrency(currency Currency) {
+	currencyMutex.Lock()
+	defer currencyMutex.Unlock()
+
+	currency.Code = strings.ToUpper(currency.Code)
+	currencies[currency.Code] = currency
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package money
+
+import "strings"
+
+/*
+localeFormat is how a locale writes amounts: its decimal and group
+separators, whether the symbol follows the number, and what goes
+between them
+*/
+type localeFormat struct {
+	decimal     string
+	group       string
+	space       string
+	symbolAfter bool
+}
+
+var localeFormats = map[string]localeFormat{
+	"da":    {decimal: ",", group: ".", space: "\u00a0", symbolAfter: true},
+	"de":    {decimal: ",", group: ".", space: "\u00a0", symbolAfter: true},
+	"de-ch": {decimal: ".", group: "’", space: "\u00a0"},
+	"en":    {decimal: ".", group: ","},
+	"es":    {decimal: ",", group: ".", space: "\u00a0", symbolAfter: true},
+	"fr":    {decimal: ",", group: "\u202f", space: "\u00a0", symbolAfter: true},
+	"fr-ch": {decimal: ",", group: "\u202f", space: "\u00a0", symbolAfter: true},
+	"it":    {decimal: ",", group: ".", space: "\u00a0", symbolAfter: true},
+	"ja":    {decimal: ".", group: ","},
+	"nb":    {decimal: ",", group: "\u00a0", space: "\u00a0", symbolAfter: true},
+	"nl":    {decimal: ",", group: ".", space: "\u00a0"},
+	"pl":    {decimal: ",", group: "\u00a0", space: "\u00a0", symbolAfter: true},
+	"pt":    {decimal: ",", group: ".", space: "\u00a0"},
+	"pt-pt": {decimal: ",", group: "\u00a0", space: "\u00a0", symbolAfter: true},
+	"sv":    {decimal: ",", group: "\u00a0", space: "\u00a0", symbolAfter: true},
+	"zh":    {decimal: ".", group: ","},
+}
+
+/*
+Format writes the amount for people in a locale, such as "$1,234.50"
+in "en-US" or "1.234,50 €" in "de-DE". Spaces are non-breaking, so
+amounts don't wrap. Locales fall back to their language, then to
+English
+*/
+func (m Money) Format(locale string) string {
+	format := formatFor(locale)
+	number := m.Abs().Decimal()
+	whole, fraction := number, ""
+
+	if index := strings.Index(number, "."); index >= 0 {
+		whole, fraction = number[:index], number[index+1:]
+	}
+
+	grouped := []string{}
+
+	for len(whole) > 3 {
+		grouped = append([]string{whole[len(whole)-3:]}, grouped...)
+		whole = whole[:len(whole)-3]
+	}
+
+	grouped = append([]string{whole}, grouped...)
+	result := strings.Join(grouped, format.group)
+
+	if fraction != "" {
+		result += format.decimal + fraction
+	}
+
+	symbol := m.currency.Symbol
+
+	switch {
+	case symbol == "":
+		// The zero Money has no currency to show
+
+	case format.symbolAfter:
+		result += format.space + symbol
+
+	case len(symbol) > 1 && symbol == m.currency.Code:
+		// Codes, such as CHF, are set apart from the number
+		result = symbol + "\u00a0" + result
+
+	default:
+		result = symbol + format.space + result
+	}
+
+	if m.amount < 0 {
+		result = "-" + result
+	}
+
+	return result
+}
+
+func formatFor(locale string) localeFormat {
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+
+	for locale != "" {
+		if format, ok := localeFormats[locale]; ok {
+			return format
+		}
+
+		index := strings.LastIndex(locale, "-")
+
+		if index < 0 {
+			break
+		}
+
+		locale = locale[:index]
+	}
+
+	return localeFormats["en"]
+}
@@ -0,0 +1,421 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrCurrencyMismatch = errors.New("currencies don't match")
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrOverflow         = errors.New("amount is too large")
+	ErrPrecision        = errors.New("amount has more decimals than its currency")
+	ErrUnknownCurrency  = errors.New("unknown currency")
+)
+
+/*
+Money is an amount of a currency, kept as a whole number of minor units,
+such as cents, so arithmetic is exact. Operations on two amounts fail
+when their currencies differ. The zero Money has no currency, and adds
+to amounts of any currency, so totals can start from it.
+
+Money marshals to JSON as {"amount": "12.34", "currency": "USD"}, with
+the amount a string so no precision is lost
+*/
+type Money struct {
+	amount   int64
+	currency Currency
+}
+
+/*
+New returns an amount of minor units, such as New(1234, "USD") for
+$12.34
+*/
+func New(minor int64, currency string) (Money, error) {
+	c, err := LookupCurrency(currency)
+
+	if err != nil {
+		return Money{}, err
+	}
+
+	return Money{amount: minor, currency: c}, nil
+}
+
+/*
+MustNew returns an amount of minor units, panicking on an unknown
+currency
+*/
+func MustNew(minor int64, currency string) Money {
+	result, err := New(minor, currency)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return result
+}
+
+/*
+Zero returns no money in a currency
+*/
+func Zero(currency string) (Money, error) {
+	return New(0, currency)
+}
+
+/*
+Parse reads a decimal amount of a currency, such as "1234.50" or
+"-0.99". Thousands separators and symbols aren't allowed. Amounts with
+more decimals than the currency has fail with ErrPrecision rather than
+being rounded
+*/
+func Parse(amount, currency string) (Money, error) {
+	c, err := LookupCurrency(currency)
+
+	if err != nil {
+		return Money{}, err
+	}
+
+	minor, err := parseMinor(strings.TrimSpace(amount), c.Decimals)
+
+	if err != nil {
+		return Money{}, err
+	}
+
+	return Money{amount: minor, currency: c}, nil
+}
+
+/*
+Amount returns the amount in minor units, such as cents
+*/
+func (m Money) Amount() int64 {
+	return m.amount
+}
+
+/*
+Currency returns the amount's currency
+*/
+func (m Money) Currency() Currency {
+	return m.currency
+}
+
+/*
+Add returns the sum of two amounts of the same currency
+*/
+func (m Money) Add(other Money) (Money, error) {
+	currency, err := m.match(other)
+
+	if err != nil {
+		return Money{}, err
+	}
+
+	sum := m.amount + other.amount
+
+	if (other.amount > 0 && sum < m.amount) || (other.amount < 0 && sum > m.amount) {
+		return Money{}, ErrOverflow
+	}
+
+	return Money{amount: sum, currency: currency}, nil
+}
+
+/*
+Subtract returns the difference of two amounts of the same currency
+*/
+func (m Money) Subtract(other Money) (Money, error) {
+	if other.amount == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+
+	return m.Add(other.Negate())
+}
+
+/*
+Multiply returns the amount times a whole number, such as a quantity
+*/
+func (m Money) Multiply(n int64) (Money, error) {
+	return m.Scale(n, 1)
+}
+
+/*
+Scale returns the amount times a fraction, rounded half away from zero
+to a whole minor unit. Use it for rates, such as 8.25% tax with
+Scale(825, 10000)
+*/
+func (m Money) Scale(numerator, denominator int64) (Money, error) {
+	if denominator == 0 {
+		return Money{}, fmt.Errorf("%w: division by zero", ErrInvalidAmount)
+	}
+
+	product := new(big.Int).Mul(big.NewInt(m.amount), big.NewInt(numerator))
+	d := big.NewInt(denominator)
+
+	if d.Sign() < 0 {
+		product.Neg(product)
+		d.Neg(d)
+	}
+
+	// Round half away from zero: add half the denominator to the magnitude, then truncate
+	negative := product.Sign() < 0
+	product.Abs(product)
+	product.Add(product, new(big.Int).Rsh(d, 1))
+	product.Quo(product, d)
+
+	if negative {
+		product.Neg(product)
+	}
+
+	if !product.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+
+	return Money{amount: product.Int64(), currency: m.currency}, nil
+}
+
+/*
+Negate returns the amount with its sign flipped
+*/
+func (m Money) Negate() Money {
+	return Money{amount: -m.amount, currency: m.currency}
+}
+
+/*
+Abs returns the amount without its sign
+*/
+func (m Money) Abs() Money {
+	if m.amount < 0 {
+		return m.Negate()
+	}
+
+	return m
+}
+
+/*
+Compare returns -1, 0, or 1 when m is less than, equal to, or more than
+other
+*/
+func (m Money) Compare(other Money) (int, error) {
+	if _, err := m.match(other); err != nil {
+		return 0, err
+	}
+
+	switch {
+	case m.amount < other.amount:
+		return -1, nil
+
+	case m.amount > other.amount:
+		return 1, nil
+
+	default:
+		return 0, nil
+	}
+}
+
+/*
+IsNegative returns true for amounts below zero
+*/
+func (m Money) IsNegative() bool {
+	return m.amount < 0
+}
+
+/*
+IsZero returns true for zero of any currency
+*/
+func (m Money) IsZero() bool {
+	return m.amount == 0
+}
+
+/*
+Decimal returns the amount as a decimal, such as "1234.50"
+*/
+func (m Money) Decimal() string {
+	magnitude := strconv.FormatUint(absolute(m.amount), 10)
+	sign := ""
+
+	if m.amount < 0 {
+		sign = "-"
+	}
+
+	if m.currency.Decimals == 0 {
+		return sign + magnitude
+	}
+
+	if len(magnitude) <= m.currency.Decimals {
+		magnitude = strings.Repeat("0", m.currency.Decimals-len(magnitude)+1) + magnitude
+	}
+
+	split := len(magnitude) - m.currency.Decimals
+	return sign + magnitude[:split] + "." + magnitude[split:]
+}
+
+/*
+String returns the amount and currency code, such as "1234.50 USD", for
+logs. Use Format to show amounts to people
+*/
+func (m Money) String() string {
+	if m.currency.Code == "" {
+		return m.Decimal()
+	}
+
+	return m.Decimal() + " " + m.currency.Code
+}
+
+type jsonMoney struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+/*
+MarshalJSON writes {"amount": "12.34", "currency": "USD"}
+*/
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{Amount: m.Decimal(), Currency: m.currency.Code})
+}
+
+/*
+UnmarshalJSON reads an amount as a string or a number, and a currency
+*/
+func (m *Money) UnmarshalJSON(b []byte) error {
+	value := jsonMoney{}
+
+	if err := json.Unmarshal(b, &value); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidAmount, err.Error())
+	}
+
+	result, err := Parse(strings.Trim(string(value.Amount), `"`), value.Currency)
+
+	if err != nil {
+		return err
+	}
+
+	*m = result
+	return nil
+}
+
+/*
+Scan satisfies sql.Scanner, reading a DECIMAL or NUMERIC column into an
+amount whose currency is already set, such as from Zero("USD"). Text
+with a currency code, such as "12.34 USD", scans into any Money. To
+store the currency too, keep the minor units and currency code in two
+columns and call New
+*/
+func (m *Money) Scan(src interface{}) error {
+	var text string
+
+	switch v := src.(type) {
+	case []byte:
+		text = string(v)
+
+	case string:
+		text = v
+
+	case int64:
+		text = strconv.FormatInt(v, 10)
+
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+
+	currency := m.currency.Code
+
+	if fields := strings.Fields(text); len(fields) == 2 {
+		text, currency = fields[0], fields[1]
+	}
+
+	if currency == "" {
+		return fmt.Errorf("%w: scan into Money with a currency, such as from Zero", ErrUnknownCurrency)
+	}
+
+	result, err := Parse(text, currency)
+
+	if err != nil {
+		return err
+	}
+
+	*m = result
+	return nil
+}
+
+/*
+Value satisfies driver.Valuer, storing the amount as a decimal for
+DECIMAL and NUMERIC columns
+*/
+func (m Money) Value() (driver.Value, error) {
+	return m.Decimal(), nil
+}
+
+/*
+match returns the currency two amounts share. The zero Money matches
+any currency
+*/
+func (m Money) match(other Money) (Currency, error) {
+	switch {
+	case m.currency == other.currency:
+		return m.currency, nil
+
+	case m.currency.Code == "" && m.amount == 0:
+		return other.currency, nil
+
+	case other.currency.Code == "" && other.amount == 0:
+		return m.currency, nil
+
+	default:
+		return Currency{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency.Code, other.currency.Code)
+	}
+}
+
+func parseMinor(amount string, decimals int) (int64, error) {
+	negative := strings.HasPrefix(amount, "-")
+	amount = strings.TrimPrefix(strings.TrimPrefix(amount, "-"), "+")
+	whole, fraction := amount, ""
+
+	if index := strings.Index(amount, "."); index >= 0 {
+		whole, fraction = amount[:index], amount[index+1:]
+	}
+
+	if whole == "" && fraction == "" || strings.Trim(whole+fraction, "0123456789") != "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	}
+
+	// Zeros past the currency's decimals, as in "12.340" for USD, don't change the amount
+	trimmed := strings.TrimRight(fraction, "0")
+
+	if len(trimmed) > decimals {
+		return 0, fmt.Errorf("%w: %q", ErrPrecision, amount)
+	}
+
+	fraction = trimmed + strings.Repeat("0", decimals-len(trimmed))
+	result, err := strconv.ParseInt("0"+whole+fraction, 10, 64)
+
+	if err != nil {
+		return 0, ErrOverflow
+	}
+
+	if negative {
+		result = -result
+	}
+
+	return result, nil
+}
+
+func absolute(n int64) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+
+	return uint64(n)
+}
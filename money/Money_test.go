@@ -0,0 +1,191 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package money_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/money"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		minor    int64
+		err      error
+	}{
+		{amount: "1234.5", currency: "USD", minor: 123450},
+		{amount: "-0.99", currency: "usd", minor: -99},
+		{amount: "12.340", currency: "USD", minor: 1234},
+		{amount: ".5", currency: "EUR", minor: 50},
+		{amount: "1500", currency: "JPY", minor: 1500},
+		{amount: "1.234", currency: "BHD", minor: 1234},
+		{amount: "12.345", currency: "USD", err: money.ErrPrecision},
+		{amount: "1,234.50", currency: "USD", err: money.ErrInvalidAmount},
+		{amount: "", currency: "USD", err: money.ErrInvalidAmount},
+		{amount: "99999999999999999999", currency: "USD", err: money.ErrOverflow},
+		{amount: "1", currency: "XXX", err: money.ErrUnknownCurrency},
+	}
+
+	for _, test := range tests {
+		got, err := money.Parse(test.amount, test.currency)
+
+		if test.err != nil {
+			if !errors.Is(err, test.err) {
+				t.Errorf("Expected %v for %s but got %v", test.err, test.amount, err)
+			}
+
+			continue
+		}
+
+		if err != nil || got.Amount() != test.minor {
+			t.Errorf("Expected %d for %s but got %d, %v", test.minor, test.amount, got.Amount(), err)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	price := money.MustNew(1999, "USD")
+
+	total, err := price.Multiply(3)
+
+	if err != nil || total.Decimal() != "59.97" {
+		t.Errorf("Expected 59.97 but got %s, %v", total, err)
+	}
+
+	tax, _ := total.Scale(825, 10000)
+
+	if tax.Decimal() != "4.95" {
+		t.Errorf("Expected 8.25%% of 59.97 to round to 4.95 but got %s", tax)
+	}
+
+	refund, _ := money.MustNew(-1, "USD").Scale(1, 2)
+
+	if refund.Amount() != -1 {
+		t.Errorf("Expected half a cent to round away from zero but got %d", refund.Amount())
+	}
+
+	var sum money.Money
+
+	for _, amount := range []money.Money{total, tax} {
+		if sum, err = sum.Add(amount); err != nil {
+			t.Fatalf("Expected no error but got %s", err)
+		}
+	}
+
+	if sum.String() != "64.92 USD" {
+		t.Errorf("Expected 64.92 USD but got %s", sum)
+	}
+
+	if _, err = sum.Add(money.MustNew(100, "EUR")); !errors.Is(err, money.ErrCurrencyMismatch) {
+		t.Errorf("Expected ErrCurrencyMismatch but got %v", err)
+	}
+
+	if _, err = money.MustNew(1<<62, "USD").Multiply(4); !errors.Is(err, money.ErrOverflow) {
+		t.Errorf("Expected ErrOverflow but got %v", err)
+	}
+
+	if change, _ := money.MustNew(500, "USD").Subtract(sum); change.Decimal() != "-59.92" || !change.IsNegative() {
+		t.Errorf("Expected -59.92 but got %s", change)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		amount money.Money
+		ratios []int64
+		want   string
+	}{
+		{amount: money.MustNew(1000, "USD"), ratios: []int64{1, 1, 1}, want: "3.34 3.33 3.33"},
+		{amount: money.MustNew(5, "USD"), ratios: []int64{70, 30}, want: "0.04 0.01"},
+		{amount: money.MustNew(-1000, "USD"), ratios: []int64{1, 1, 1}, want: "-3.34 -3.33 -3.33"},
+		{amount: money.MustNew(100, "JPY"), ratios: []int64{1, 2, 3}, want: "17 33 50"},
+		{amount: money.MustNew(10, "USD"), ratios: []int64{0, 1}, want: "0.00 0.10"},
+	}
+
+	for _, test := range tests {
+		parts, err := test.amount.Allocate(test.ratios...)
+
+		if err != nil {
+			t.Fatalf("Expected no error but got %s", err)
+		}
+
+		got := []string{}
+		var sum money.Money
+
+		for _, part := range parts {
+			got = append(got, part.Decimal())
+			sum, _ = sum.Add(part)
+		}
+
+		if strings.Join(got, " ") != test.want || sum != test.amount {
+			t.Errorf("Expected %s adding up to %s but got %v", test.want, test.amount, got)
+		}
+	}
+
+	if _, err := money.MustNew(100, "USD").Split(0); !errors.Is(err, money.ErrInvalidAmount) {
+		t.Errorf("Expected ErrInvalidAmount but got %v", err)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		amount money.Money
+		locale string
+		want   string
+	}{
+		{amount: money.MustNew(123450, "USD"), locale: "en-US", want: "$1,234.50"},
+		{amount: money.MustNew(-123450, "USD"), locale: "en", want: "-$1,234.50"},
+		{amount: money.MustNew(123450, "EUR"), locale: "de-DE", want: "1.234,50\u00a0€"},
+		{amount: money.MustNew(123450, "EUR"), locale: "fr_FR", want: "1\u202f234,50\u00a0€"},
+		{amount: money.MustNew(1234567, "JPY"), locale: "ja-JP", want: "¥1,234,567"},
+		{amount: money.MustNew(123450, "CHF"), locale: "de-CH", want: "CHF\u00a01’234.50"},
+		{amount: money.MustNew(5, "USD"), locale: "xx", want: "$0.05"},
+	}
+
+	for _, test := range tests {
+		if got := test.amount.Format(test.locale); got != test.want {
+			t.Errorf("Expected %q but got %q", test.want, got)
+		}
+	}
+}
+
+func TestMarshaling(t *testing.T) {
+	type invoice struct {
+		Total money.Money `json:"total"`
+	}
+
+	b, _ := json.Marshal(invoice{Total: money.MustNew(123450, "EUR")})
+
+	if string(b) != `{"total":{"amount":"1234.50","currency":"EUR"}}` {
+		t.Errorf("Unexpected JSON %s", b)
+	}
+
+	decoded := invoice{}
+
+	if err := json.Unmarshal([]byte(`{"total":{"amount":1234.5,"currency":"EUR"}}`), &decoded); err != nil || decoded.Total.Amount() != 123450 {
+		t.Errorf("Expected a number amount to unmarshal but got %v, %v", decoded.Total, err)
+	}
+
+	amount, _ := money.Zero("USD")
+
+	if err := amount.Scan([]byte("19.99")); err != nil || amount.String() != "19.99 USD" {
+		t.Errorf("Expected a decimal to scan into USD but got %s, %v", amount, err)
+	}
+
+	var unknown money.Money
+
+	if err := unknown.Scan("19.99"); !errors.Is(err, money.ErrUnknownCurrency) {
+		t.Errorf("Expected ErrUnknownCurrency but got %v", err)
+	}
+
+	if err := unknown.Scan("19.99 GBP"); err != nil || unknown.Currency().Code != "GBP" {
+		t.Errorf("Expected text with a code to scan but got %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/httpclient"
+	"github.com/ResurgenceIT/kit/v6/mail"
+)
+
+/*
+IChannel delivers notifications one way, such as by email. Send returns
+the ID the provider gave the message, if any, and ErrNoAddress when the
+recipient can't be reached on the channel
+*/
+type IChannel interface {
+	Channel() Channel
+	Send(ctx context.Context, notification Notification, notificationType Type) (providerID string, err error)
+}
+
+/*
+IEmailRenderer fills in a message from a named template.
+mail.TemplateRenderer implements it
+*/
+type IEmailRenderer interface {
+	Render(message *mail.Message, name string, data interface{}) error
+}
+
+/*
+EmailChannel sends notifications with the mail package. Each type's
+EmailTemplate is rendered with the notification's Data
+*/
+type EmailChannel struct {
+	from      mail.Address
+	renderer  IEmailRenderer
+	transport mail.ITransport
+}
+
+/*
+NewEmailChannel creates an email channel. Pass a mail.Queue as the
+transport to send in the background
+*/
+func NewEmailChannel(from mail.Address, renderer IEmailRenderer, transport mail.ITransport) *EmailChannel {
+	return &EmailChannel{
+		from:      from,
+		renderer:  renderer,
+		transport: transport,
+	}
+}
+
+/*
+Channel returns ChannelEmail
+*/
+func (c *EmailChannel) Channel() Channel {
+	return ChannelEmail
+}
+
+/*
+Send renders and sends an email
+*/
+func (c *EmailChannel) Send(ctx context.Context, notification Notification, notificationType Type) (string, error) {
+	if notification.Recipient.Email == "" {
+		return "", ErrNoAddress
+	}
+
+	message := mail.Message{
+		From: c.from,
+		To:   []mail.Address{{Email: notification.Recipient.Email, Name: notification.Recipient.Name}},
+	}
+
+	if err := c.renderer.Render(&message, notificationType.EmailTemplate, notification.Data); err != nil {
+		return "", err
+	}
+
+	return "", c.transport.Send(ctx, message)
+}
+
+/*
+ISMSProvider sends text messages, returning the provider's ID for the
+message
+*/
+type ISMSProvider interface {
+	SendSMS(ctx context.Context, to, body string) (string, error)
+}
+
+/*
+SMSChannel sends notifications as text messages. Each type's
+SMSTemplate is rendered with the notification's Data
+*/
+type SMSChannel struct {
+	provider ISMSProvider
+}
+
+/*
+NewSMSChannel creates a channel that sends text messages through
+provider
+*/
+func NewSMSChannel(provider ISMSProvider) *SMSChannel {
+	return &SMSChannel{
+		provider: provider,
+	}
+}
+
+/*
+Channel returns ChannelSMS
+*/
+func (c *SMSChannel) Channel() Channel {
+	return ChannelSMS
+}
+
+/*
+Send renders and sends a text message
+*/
+func (c *SMSChannel) Send(ctx context.Context, notification Notification, notificationType Type) (string, error) {
+	if notification.Recipient.Phone == "" {
+		return "", ErrNoAddress
+	}
+
+	body, err := notificationType.RenderSMS(notification.Data)
+
+	if err != nil {
+		return "", err
+	}
+
+	return c.provider.SendSMS(ctx, notification.Recipient.Phone, body)
+}
+
+/*
+WebhookPayload is the JSON body posted by WebhookChannel
+*/
+type WebhookPayload struct {
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+}
+
+/*
+WebhookChannel posts notifications as JSON to the recipient's
+WebhookURL
+*/
+type WebhookChannel struct {
+	client *httpclient.Client
+}
+
+/*
+NewWebhookChannel creates a webhook channel. Give client an
+httpclient.HMACSigner so receivers can check posts came from you. The
+notification's ID is sent as the Idempotency-Key, so posts are retried
+and receivers can drop repeats
+*/
+func NewWebhookChannel(client *httpclient.Client) *WebhookChannel {
+	return &WebhookChannel{
+		client: client,
+	}
+}
+
+/*
+Channel returns ChannelWebhook
+*/
+func (c *WebhookChannel) Channel() Channel {
+	return ChannelWebhook
+}
+
+/*
+Send posts the notification
+*/
+func (c *WebhookChannel) Send(ctx context.Context, notification Notification, notificationType Type) (string, error) {
+	if notification.Recipient.WebhookURL == "" {
+		return "", ErrNoAddress
+	}
+
+	b, err := json.Marshal(WebhookPayload{
+		CreatedAt: time.Now().UTC(),
+		Data:      notification.Data,
+		ID:        notification.ID,
+		Type:      notification.Type,
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("error encoding webhook payload: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, notification.Recipient.WebhookURL, bytes.NewReader(b))
+
+	if err != nil {
+		return "", fmt.Errorf("error creating webhook request: %w", err)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Idempotency-Key", notification.ID)
+
+	response, err := c.client.Do(request)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer response.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, response.Body)
+
+	if response.StatusCode > 299 {
+		return "", fmt.Errorf("webhook returned %s", response.Status)
+	}
+
+	return "", nil
+}
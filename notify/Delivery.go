@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package notify
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrDeliveryNotFound = errors.New("notification delivery not found")
+
+/*
+Status is where a delivery has got to
+*/
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusSent      Status = "sent"
+	StatusDelivered Status = "delivered"
+	StatusFailed    Status = "failed"
+	StatusSkipped   Status = "skipped"
+)
+
+/*
+Delivery tracks sending one notification through one channel.
+ProviderID is the ID the channel's provider gave the message, used to
+match delivery receipts to it. Error is set for failed and skipped
+deliveries
+*/
+type Delivery struct {
+	Channel        Channel
+	CreatedAt      time.Time
+	Error          string
+	ID             string
+	NotificationID string
+	ProviderID     string
+	Status         Status
+	Type           string
+	UpdatedAt      time.Time
+	UserID         string
+}
+
+/*
+IDeliveryStore persists deliveries. Save inserts or replaces a delivery
+by ID. ByProviderID returns ErrDeliveryNotFound when no delivery matches
+*/
+type IDeliveryStore interface {
+	ByProviderID(ctx context.Context, channel Channel, providerID string) (Delivery, error)
+	ForNotification(ctx context.Context, notificationID string) ([]Delivery, error)
+	Save(ctx context.Context, delivery Delivery) error
+}
+
+/*
+MemoryDeliveryStore keeps deliveries in memory, for tests and
+development
+*/
+type MemoryDeliveryStore struct {
+	sync.Mutex
+
+	deliveries map[string]Delivery
+}
+
+/*
+NewMemoryDeliveryStore creates an empty in-memory delivery store
+*/
+func NewMemoryDeliveryStore() *MemoryDeliveryStore {
+	return &MemoryDeliveryStore{
+		deliveries: make(map[string]Delivery),
+	}
+}
+
+/*
+ByProviderID returns the delivery the channel's provider knows by
+providerID
+*/
+func (s *MemoryDeliveryStore) ByProviderID(ctx context.Context, channel Channel, providerID string) (Delivery, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, delivery := range s.deliveries {
+		if delivery.Channel == channel && delivery.ProviderID == providerID && providerID != "" {
+			return delivery, nil
+		}
+	}
+
+	return Delivery{}, ErrDeliveryNotFound
+}
+
+/*
+ForNotification returns a notification's deliveries, oldest first
+*/
+func (s *MemoryDeliveryStore) ForNotification(ctx context.Context, notificationID string) ([]Delivery, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	result := make([]Delivery, 0, 3)
+
+	for _, delivery := range s.deliveries {
+		if delivery.NotificationID == notificationID {
+			result = append(result, delivery)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	return result, nil
+}
+
+/*
+Save stores a delivery
+*/
+func (s *MemoryDeliveryStore) Save(ctx context.Context, delivery Delivery) error {
+	s.Lock()
+	s.deliveries[delivery.ID] = delivery
+	s.Unlock()
+
+	return nil
+}
@@ -0,0 +1,302 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/ids"
+)
+
+/*
+ChannelStats counts a channel's deliveries by status. Receipts from
+UpdateStatus are counted too, so a message that was sent and then
+failed is counted under both
+*/
+type ChannelStats struct {
+	Delivered uint64
+	Failed    uint64
+	Sent      uint64
+	Skipped   uint64
+}
+
+/*
+Dispatcher sends notifications to the channels their type and the
+recipient's preferences call for, recording each delivery
+*/
+type Dispatcher struct {
+	channels map[Channel]IChannel
+	config   DispatcherConfig
+	mutex    sync.Mutex
+	stats    map[Channel]*ChannelStats
+	types    map[string]Type
+}
+
+/*
+NewDispatcher creates a dispatcher, parsing every type's templates
+
+	dispatcher, err := notify.NewDispatcher(notify.DispatcherConfig{
+		Channels: []notify.IChannel{
+			notify.NewEmailChannel(from, renderer, mailQueue),
+			notify.NewSMSChannel(smsProvider),
+		},
+		Preferences: preferences,
+		Types: []notify.Type{
+			{
+				Channels:    []notify.Channel{notify.ChannelEmail, notify.ChannelSMS},
+				Name:        "order-shipped",
+				SMSTemplate: "Order {{.Number}} is on its way",
+			},
+		},
+	})
+*/
+func NewDispatcher(config DispatcherConfig) (*Dispatcher, error) {
+	if config.Deliveries == nil {
+		config.Deliveries = NewMemoryDeliveryStore()
+	}
+
+	if config.Name == "" {
+		config.Name = "notify"
+	}
+
+	result := &Dispatcher{
+		channels: make(map[Channel]IChannel),
+		config:   config,
+		stats:    make(map[Channel]*ChannelStats),
+		types:    make(map[string]Type),
+	}
+
+	for _, channel := range config.Channels {
+		result.channels[channel.Channel()] = channel
+		result.stats[channel.Channel()] = &ChannelStats{}
+	}
+
+	for _, notificationType := range config.Types {
+		if err := notificationType.parse(); err != nil {
+			return nil, err
+		}
+
+		result.types[notificationType.Name] = notificationType
+	}
+
+	return result, nil
+}
+
+/*
+Send delivers a notification on each of its channels, one after
+another, and returns the deliveries. Channels the recipient has no
+address for are skipped. When a channel fails the others are still
+tried, and ErrDeliveryFailed is returned with the first failure
+*/
+func (d *Dispatcher) Send(ctx context.Context, notification Notification) ([]Delivery, error) {
+	var (
+		err    error
+		failed error
+	)
+
+	notificationType, ok := d.types[notification.Type]
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, notification.Type)
+	}
+
+	if notification.ID == "" {
+		notification.ID = ids.NewV7().String()
+	}
+
+	channels, err := d.channelsFor(ctx, notification, notificationType)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Delivery, 0, len(channels))
+
+	for _, channel := range channels {
+		now := time.Now().UTC()
+
+		delivery := Delivery{
+			Channel:        channel,
+			CreatedAt:      now,
+			ID:             ids.NewV7().String(),
+			NotificationID: notification.ID,
+			Status:         StatusPending,
+			Type:           notification.Type,
+			UpdatedAt:      now,
+			UserID:         notification.Recipient.UserID,
+		}
+
+		if err = d.config.Deliveries.Save(ctx, delivery); err != nil {
+			return result, fmt.Errorf("error saving notification delivery: %w", err)
+		}
+
+		delivery.ProviderID, err = d.send(ctx, channel, notification, notificationType)
+		delivery.Status = StatusSent
+		delivery.UpdatedAt = time.Now().UTC()
+
+		switch {
+		case errors.Is(err, ErrNoAddress):
+			delivery.Status = StatusSkipped
+			delivery.Error = err.Error()
+
+		case err != nil:
+			delivery.Status = StatusFailed
+			delivery.Error = err.Error()
+
+			if failed == nil {
+				failed = fmt.Errorf("%w: %s: %s", ErrDeliveryFailed, channel, err.Error())
+			}
+		}
+
+		d.count(channel, delivery.Status)
+
+		if err = d.config.Deliveries.Save(ctx, delivery); err != nil {
+			return result, fmt.Errorf("error saving notification delivery: %w", err)
+		}
+
+		result = append(result, delivery)
+	}
+
+	return result, failed
+}
+
+/*
+UpdateStatus records a delivery receipt from a channel's provider, such
+as an SMS provider reporting a message was delivered or failed. reason
+is kept as the delivery's error when it isn't empty
+*/
+func (d *Dispatcher) UpdateStatus(ctx context.Context, channel Channel, providerID string, status Status, reason string) error {
+	delivery, err := d.config.Deliveries.ByProviderID(ctx, channel, providerID)
+
+	if err != nil {
+		return err
+	}
+
+	if delivery.Status == status {
+		return nil
+	}
+
+	d.count(channel, status)
+
+	delivery.Status = status
+	delivery.UpdatedAt = time.Now().UTC()
+
+	if reason != "" {
+		delivery.Error = reason
+	}
+
+	if err = d.config.Deliveries.Save(ctx, delivery); err != nil {
+		return fmt.Errorf("error saving notification delivery: %w", err)
+	}
+
+	return nil
+}
+
+/*
+Deliveries returns a notification's deliveries
+*/
+func (d *Dispatcher) Deliveries(ctx context.Context, notificationID string) ([]Delivery, error) {
+	return d.config.Deliveries.ForNotification(ctx, notificationID)
+}
+
+/*
+Stats returns delivery counts for each channel
+*/
+func (d *Dispatcher) Stats() map[Channel]ChannelStats {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	result := make(map[Channel]ChannelStats, len(d.stats))
+
+	for channel, stats := range d.stats {
+		result[channel] = *stats
+	}
+
+	return result
+}
+
+/*
+Name returns the name the dispatcher's stats are reported under
+*/
+func (d *Dispatcher) Name() string {
+	return d.config.Name
+}
+
+/*
+Collect returns the dispatcher's stats in the form serverstats
+collectors report, so a Dispatcher can be passed to RegisterCollector
+*/
+func (d *Dispatcher) Collect(ctx context.Context) map[string]interface{} {
+	result := make(map[string]interface{})
+
+	for channel, stats := range d.Stats() {
+		result[string(channel)] = map[string]interface{}{
+			"delivered": stats.Delivered,
+			"failed":    stats.Failed,
+			"sent":      stats.Sent,
+			"skipped":   stats.Skipped,
+		}
+	}
+
+	return result
+}
+
+func (d *Dispatcher) channelsFor(ctx context.Context, notification Notification, notificationType Type) ([]Channel, error) {
+	if d.config.Preferences == nil || notificationType.Mandatory || notification.Recipient.UserID == "" {
+		return notificationType.Channels, nil
+	}
+
+	channels, ok, err := d.config.Preferences.Channels(ctx, notification.Recipient.UserID, notification.Type)
+
+	if err != nil {
+		return nil, fmt.Errorf("error loading notification preferences: %w", err)
+	}
+
+	if !ok {
+		return notificationType.Channels, nil
+	}
+
+	return channels, nil
+}
+
+func (d *Dispatcher) send(ctx context.Context, channel Channel, notification Notification, notificationType Type) (string, error) {
+	sender, ok := d.channels[channel]
+
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrChannelNotConfigured, channel)
+	}
+
+	return sender.Send(ctx, notification, notificationType)
+}
+
+func (d *Dispatcher) count(channel Channel, status Status) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	stats, ok := d.stats[channel]
+
+	if !ok {
+		stats = &ChannelStats{}
+		d.stats[channel] = stats
+	}
+
+	switch status {
+	case StatusDelivered:
+		stats.Delivered++
+
+	case StatusFailed:
+		stats.Failed++
+
+	case StatusSent:
+		stats.Sent++
+
+	case StatusSkipped:
+		stats.Skipped++
+	}
+}
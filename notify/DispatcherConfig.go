@@ -0,0 +1,22 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package notify
+
+/*
+DispatcherConfig configures a Dispatcher.
+
+Types are the notifications that can be sent, and Channels deliver
+them. Preferences, when set, let users choose the channels each type is
+sent on. Deliveries stores each delivery's status, and defaults to a
+MemoryDeliveryStore. Name identifies the dispatcher's stats in
+serverstats, and defaults to "notify"
+*/
+type DispatcherConfig struct {
+	Channels    []IChannel
+	Deliveries  IDeliveryStore
+	Name        string
+	Preferences IPreferences
+	Types       []Type
+}
@@ -0,0 +1,271 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ResurgenceIT/kit/v6/httpclient"
+	"github.com/ResurgenceIT/kit/v6/mail"
+	"github.com/ResurgenceIT/kit/v6/notify"
+	"github.com/ResurgenceIT/kit/v6/privacy"
+)
+
+type fakeSMSProvider struct {
+	err      error
+	messages []string
+}
+
+func (p *fakeSMSProvider) SendSMS(ctx context.Context, to, body string) (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+
+	p.messages = append(p.messages, to+": "+body)
+	return "SM123", nil
+}
+
+type order struct {
+	Number string
+}
+
+func newDispatcher(t *testing.T, preferences notify.IPreferences) (*notify.Dispatcher, *mail.TestTransport, *fakeSMSProvider) {
+	renderer, err := mail.NewTemplateRenderer(fstest.MapFS{
+		"order-shipped.subject.tmpl": {Data: []byte("Order {{.Number}} shipped")},
+		"order-shipped.txt.tmpl":     {Data: []byte("Order {{.Number}} is on its way")},
+	}, nil)
+
+	if err != nil {
+		t.Fatalf("Expected templates to parse but got %s", err.Error())
+	}
+
+	transport := mail.NewTestTransport()
+	sms := &fakeSMSProvider{}
+
+	dispatcher, err := notify.NewDispatcher(notify.DispatcherConfig{
+		Channels: []notify.IChannel{
+			notify.NewEmailChannel(mail.Address{Email: "noreply@example.com"}, renderer, transport),
+			notify.NewSMSChannel(sms),
+			notify.NewWebhookChannel(httpclient.NewClient(httpclient.ClientConfig{DisableRetry: true})),
+		},
+		Preferences: preferences,
+		Types: []notify.Type{
+			{
+				Channels:    []notify.Channel{notify.ChannelEmail, notify.ChannelSMS, notify.ChannelWebhook},
+				Name:        "order-shipped",
+				SMSTemplate: "Order {{.Number}} is on its way",
+			},
+			{
+				Channels:  []notify.Channel{notify.ChannelSMS},
+				Mandatory: true,
+				Name:      "password-reset",
+			},
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("Expected a dispatcher but got %s", err.Error())
+	}
+
+	return dispatcher, transport, sms
+}
+
+func TestDispatcherSend(t *testing.T) {
+	received := []notify.WebhookPayload{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := notify.WebhookPayload{}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		received = append(received, payload)
+	}))
+
+	defer server.Close()
+
+	preferences := notify.NewMemoryPreferences()
+	preferences.Set("opted-out", "order-shipped")
+	preferences.Set("sms-only", "order-shipped", notify.ChannelSMS)
+
+	tests := []struct {
+		name      string
+		recipient notify.Recipient
+		expected  map[notify.Channel]notify.Status
+	}{
+		{
+			name:      "Type's channels",
+			recipient: notify.Recipient{Email: "bob@example.com", Phone: "+15555550100", UserID: "bob", WebhookURL: server.URL},
+			expected:  map[notify.Channel]notify.Status{notify.ChannelEmail: notify.StatusSent, notify.ChannelSMS: notify.StatusSent, notify.ChannelWebhook: notify.StatusSent},
+		},
+		{
+			name:      "Missing addresses are skipped",
+			recipient: notify.Recipient{Email: "bob@example.com", UserID: "bob"},
+			expected:  map[notify.Channel]notify.Status{notify.ChannelEmail: notify.StatusSent, notify.ChannelSMS: notify.StatusSkipped, notify.ChannelWebhook: notify.StatusSkipped},
+		},
+		{
+			name:      "Preferred channels",
+			recipient: notify.Recipient{Email: "bob@example.com", Phone: "+15555550100", UserID: "sms-only"},
+			expected:  map[notify.Channel]notify.Status{notify.ChannelSMS: notify.StatusSent},
+		},
+		{
+			name:      "Opted out",
+			recipient: notify.Recipient{Email: "bob@example.com", UserID: "opted-out"},
+			expected:  map[notify.Channel]notify.Status{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dispatcher, _, _ := newDispatcher(t, preferences)
+
+			deliveries, err := dispatcher.Send(context.Background(), notify.Notification{
+				Data:      order{Number: "1001"},
+				Recipient: test.recipient,
+				Type:      "order-shipped",
+			})
+
+			if err != nil {
+				t.Fatalf("Expected no error but got %s", err.Error())
+			}
+
+			if len(deliveries) != len(test.expected) {
+				t.Fatalf("Expected %d deliveries but got %v", len(test.expected), deliveries)
+			}
+
+			for _, delivery := range deliveries {
+				if delivery.Status != test.expected[delivery.Channel] {
+					t.Errorf("Expected %s to be %s but got %s", delivery.Channel, test.expected[delivery.Channel], delivery.Status)
+				}
+			}
+		})
+	}
+
+	if len(received) != 1 || received[0].Type != "order-shipped" || received[0].ID == "" {
+		t.Errorf("Expected one webhook but got %v", received)
+	}
+}
+
+func TestDispatcherRendersTemplates(t *testing.T) {
+	dispatcher, transport, sms := newDispatcher(t, nil)
+
+	_, err := dispatcher.Send(context.Background(), notify.Notification{
+		Data:      order{Number: "1001"},
+		Recipient: notify.Recipient{Email: "bob@example.com", Phone: "+15555550100"},
+		Type:      "order-shipped",
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+
+	if messages := transport.Messages(); len(messages) != 1 || messages[0].Subject != "Order 1001 shipped" {
+		t.Errorf("Expected a rendered email but got %v", messages)
+	}
+
+	if len(sms.messages) != 1 || sms.messages[0] != "+15555550100: Order 1001 is on its way" {
+		t.Errorf("Expected a rendered text message but got %v", sms.messages)
+	}
+}
+
+func TestDispatcherFailures(t *testing.T) {
+	preferences := notify.NewMemoryPreferences()
+	preferences.Set("bob", "password-reset")
+
+	dispatcher, _, sms := newDispatcher(t, preferences)
+	ctx := context.Background()
+
+	_, err := dispatcher.Send(ctx, notify.Notification{Type: "unknown"})
+
+	if !errors.Is(err, notify.ErrUnknownType) {
+		t.Errorf("Expected ErrUnknownType but got %v", err)
+	}
+
+	deliveries, err := dispatcher.Send(ctx, notify.Notification{
+		Recipient: notify.Recipient{Phone: "+15555550100", UserID: "bob"},
+		Type:      "password-reset",
+	})
+
+	if !errors.Is(err, notify.ErrDeliveryFailed) || !strings.Contains(err.Error(), "no SMS template") {
+		t.Errorf("Expected a mandatory type to be sent despite the opt out and fail but got %v", err)
+	}
+
+	if len(deliveries) != 1 || deliveries[0].Status != notify.StatusFailed {
+		t.Errorf("Expected a failed delivery but got %v", deliveries)
+	}
+
+	sms.err = errors.New("carrier unavailable")
+
+	deliveries, _ = dispatcher.Send(ctx, notify.Notification{
+		Data:      order{Number: "1001"},
+		Recipient: notify.Recipient{Email: "bob@example.com", Phone: "+15555550100"},
+		Type:      "order-shipped",
+	})
+
+	if len(deliveries) != 3 || deliveries[0].Status != notify.StatusSent || deliveries[1].Error != "carrier unavailable" {
+		t.Errorf("Expected other channels to be tried but got %v", deliveries)
+	}
+
+	stats := dispatcher.Stats()
+
+	if stats[notify.ChannelSMS].Failed != 2 || stats[notify.ChannelEmail].Sent != 1 || stats[notify.ChannelWebhook].Skipped != 1 {
+		t.Errorf("Unexpected stats %v", stats)
+	}
+}
+
+func TestDispatcherUpdateStatus(t *testing.T) {
+	dispatcher, _, _ := newDispatcher(t, nil)
+	ctx := context.Background()
+
+	deliveries, _ := dispatcher.Send(ctx, notify.Notification{
+		Data:      order{Number: "1001"},
+		Recipient: notify.Recipient{Phone: "+15555550100"},
+		Type:      "order-shipped",
+	})
+
+	if err := dispatcher.UpdateStatus(ctx, notify.ChannelSMS, "SM123", notify.StatusDelivered, ""); err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+
+	if err := dispatcher.UpdateStatus(ctx, notify.ChannelSMS, "SM999", notify.StatusDelivered, ""); !errors.Is(err, notify.ErrDeliveryNotFound) {
+		t.Errorf("Expected ErrDeliveryNotFound but got %v", err)
+	}
+
+	updated, _ := dispatcher.Deliveries(ctx, deliveries[0].NotificationID)
+
+	for _, delivery := range updated {
+		if delivery.Channel == notify.ChannelSMS && delivery.Status != notify.StatusDelivered {
+			t.Errorf("Expected the SMS to be delivered but got %s", delivery.Status)
+		}
+	}
+}
+
+func TestPreferencesPrivacyHandler(t *testing.T) {
+	preferences := notify.NewMemoryPreferences()
+	preferences.Set("bob", "order-shipped", notify.ChannelSMS)
+	preferences.Set("alice", "order-shipped", notify.ChannelEmail)
+
+	manager := privacy.NewManager(privacy.ManagerConfig{})
+
+	if err := manager.Register(preferences.PrivacyHandler()); err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+
+	if _, err := manager.Erase(context.Background(), "bob"); err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+
+	if _, ok, _ := preferences.Channels(context.Background(), "bob", "order-shipped"); ok {
+		t.Errorf("Expected bob's preferences to be erased")
+	}
+
+	if _, ok, _ := preferences.Channels(context.Background(), "alice", "order-shipped"); !ok {
+		t.Errorf("Expected alice's preferences to be kept")
+	}
+}
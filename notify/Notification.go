@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package notify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+var (
+	ErrChannelNotConfigured = errors.New("notification channel not configured")
+	ErrDeliveryFailed       = errors.New("notification delivery failed")
+	ErrNoAddress            = errors.New("recipient has no address for channel")
+	ErrNoTemplate           = errors.New("notification type has no template for channel")
+	ErrUnknownType          = errors.New("unknown notification type")
+)
+
+/*
+Channel names a way of delivering notifications
+*/
+type Channel string
+
+const (
+	ChannelEmail   Channel = "email"
+	ChannelSMS     Channel = "sms"
+	ChannelWebhook Channel = "webhook"
+)
+
+/*
+Recipient is who a notification is for, and where it can reach them.
+A channel skips recipients without an address for it
+*/
+type Recipient struct {
+	Email      string
+	Name       string
+	Phone      string
+	UserID     string
+	WebhookURL string
+}
+
+/*
+Notification is something to tell a recipient. Type names a registered
+Type, and Data is passed to its templates. ID is assigned when empty
+*/
+type Notification struct {
+	Data      interface{}
+	ID        string
+	Recipient Recipient
+	Type      string
+}
+
+/*
+Type defines a kind of notification, such as "order-shipped".
+
+Channels are where it is sent when the recipient hasn't chosen. Users'
+preferences are ignored for Mandatory types, such as password resets.
+EmailTemplate names the mail template, and defaults to Name.
+SMSTemplate is a text/template for the message body. Webhooks send Data
+as JSON, so they don't need a template
+*/
+type Type struct {
+	Channels      []Channel
+	EmailTemplate string
+	Mandatory     bool
+	Name          string
+	SMSTemplate   string
+
+	sms *template.Template
+}
+
+func (t *Type) parse() error {
+	if t.EmailTemplate == "" {
+		t.EmailTemplate = t.Name
+	}
+
+	if t.SMSTemplate == "" {
+		return nil
+	}
+
+	var err error
+
+	if t.sms, err = template.New(t.Name).Parse(t.SMSTemplate); err != nil {
+		return fmt.Errorf("error parsing SMS template for notification type %s: %w", t.Name, err)
+	}
+
+	return nil
+}
+
+/*
+RenderSMS renders the type's SMS template with data
+*/
+func (t Type) RenderSMS(data interface{}) (string, error) {
+	if t.sms == nil {
+		return "", fmt.Errorf("%w: %s has no SMS template", ErrNoTemplate, t.Name)
+	}
+
+	buffer := &bytes.Buffer{}
+
+	if err := t.sms.Execute(buffer, data); err != nil {
+		return "", fmt.Errorf("error rendering SMS for notification type %s: %w", t.Name, err)
+	}
+
+	return strings.TrimSpace(buffer.String()), nil
+}
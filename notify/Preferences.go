@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package notify
+
+import (
+	"context"
+	"sync"
+)
+
+/*
+IPreferences looks up which channels a user wants a type of
+notification on. ok is false when the user hasn't chosen, in which case
+the type's channels are used. An empty list with ok true means the user
+opted out
+*/
+type IPreferences interface {
+	Channels(ctx context.Context, userID, notificationType string) (channels []Channel, ok bool, err error)
+}
+
+/*
+MemoryPreferences keeps users' choices in memory
+*/
+type MemoryPreferences struct {
+	sync.RWMutex
+
+	preferences map[string]map[string][]Channel
+}
+
+/*
+NewMemoryPreferences creates an empty set of preferences
+*/
+func NewMemoryPreferences() *MemoryPreferences {
+	return &MemoryPreferences{
+		preferences: make(map[string]map[string][]Channel),
+	}
+}
+
+/*
+Channels returns the channels a user chose for a notification type
+*/
+func (p *MemoryPreferences) Channels(ctx context.Context, userID, notificationType string) ([]Channel, bool, error) {
+	p.RLock()
+	defer p.RUnlock()
+
+	channels, ok := p.preferences[userID][notificationType]
+	return channels, ok, nil
+}
+
+/*
+Set chooses the channels a user gets a notification type on. Pass no
+channels to opt out
+*/
+func (p *MemoryPreferences) Set(userID, notificationType string, channels ...Channel) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.preferences[userID] == nil {
+		p.preferences[userID] = make(map[string][]Channel)
+	}
+
+	p.preferences[userID][notificationType] = append([]Channel{}, channels...)
+}
+
+/*
+Reset forgets a user's choice, so the type's channels are used again
+*/
+func (p *MemoryPreferences) Reset(userID, notificationType string) {
+	p.Lock()
+	delete(p.preferences[userID], notificationType)
+	p.Unlock()
+}
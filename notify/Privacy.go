@@ -0,0 +1,42 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package notify
+
+import (
+	"context"
+
+	"github.com/ResurgenceIT/kit/v6/privacy"
+)
+
+/*
+PrivacyHandler exports and erases users' notification preferences for
+a privacy.Manager. Exports hold preferences.json, mapping each
+notification type to the channels the user chose
+*/
+func (p *MemoryPreferences) PrivacyHandler() privacy.Handler {
+	return privacy.Handler{
+		Erase: func(ctx context.Context, userID string) error {
+			p.Lock()
+			delete(p.preferences, userID)
+			p.Unlock()
+
+			return nil
+		},
+		Export: func(ctx context.Context, userID string, archive *privacy.Archive) error {
+			p.RLock()
+
+			preferences := make(map[string][]Channel, len(p.preferences[userID]))
+
+			for notificationType, channels := range p.preferences[userID] {
+				preferences[notificationType] = channels
+			}
+
+			p.RUnlock()
+
+			return archive.WriteJSON("preferences.json", preferences)
+		},
+		Name: "notification-preferences",
+	}
+}
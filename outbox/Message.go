@@ -0,0 +1,25 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+)
+
+/*
+Message is an event waiting in the outbox. Key deduplicates the
+message: it is unique in the outbox table, and is passed on as the
+event ID so consumers can skip messages they have already handled.
+When Key is empty a random one is generated. Payload is stored as JSON
+*/
+type Message struct {
+	CreatedAt time.Time         `json:"createdAt"`
+	ID        int64             `json:"id"`
+	Key       string            `json:"key"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Payload   json.RawMessage   `json:"payload"`
+	Topic     string            `json:"topic"`
+}
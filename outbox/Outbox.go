@@ -0,0 +1,323 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+)
+
+var (
+	ErrMissingTopic       = errors.New("outbox message needs a topic")
+	ErrRelayNotConfigured = errors.New("the relay needs a DB and a Publisher")
+)
+
+/*
+Outbox implements the transactional outbox pattern. Messages are written
+to an outbox table in the same transaction as the business data they
+describe, so they are only sent if that transaction commits. A relay
+then reads the table and publishes each message, marking it published
+once the publisher accepts it. If the relay stops between publishing and
+marking, the message is published again, so delivery is at least once.
+
+The outbox table needs these columns. The key column must be unique.
+
+	CREATE TABLE outbox (
+		id BIGSERIAL PRIMARY KEY,
+		message_key VARCHAR(100) NOT NULL UNIQUE,
+		topic VARCHAR(200) NOT NULL,
+		payload TEXT NOT NULL,
+		metadata TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		published_at TIMESTAMP NULL,
+		attempts INT NOT NULL DEFAULT 0,
+		last_error TEXT NULL
+	);
+*/
+type Outbox struct {
+	sync.Mutex
+
+	cancel  context.CancelFunc
+	config  OutboxConfig
+	now     func() time.Time
+	running bool
+	wg      sync.WaitGroup
+}
+
+/*
+NewOutbox creates an outbox
+*/
+func NewOutbox(config OutboxConfig) *Outbox {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+
+	if config.Interval <= 0 {
+		config.Interval = time.Second
+	}
+
+	if config.Rebind == nil {
+		config.Rebind = func(query string) string { return query }
+	}
+
+	if config.Table == "" {
+		config.Table = "outbox"
+	}
+
+	return &Outbox{
+		config: config,
+		now:    time.Now,
+	}
+}
+
+/*
+Write adds a message to the outbox inside tx. payload is marshaled to
+JSON. The message is only relayed if tx commits
+*/
+func (o *Outbox) Write(ctx context.Context, tx sqldatabase.Tx, topic, key string, payload interface{}) error {
+	b, err := json.Marshal(payload)
+
+	if err != nil {
+		return fmt.Errorf("error marshaling outbox payload: %w", err)
+	}
+
+	return o.WriteMessage(ctx, tx, Message{Key: key, Payload: b, Topic: topic})
+}
+
+/*
+WriteMessage adds a message to the outbox inside tx. A key is generated
+if the message doesn't have one, and CreatedAt defaults to now
+*/
+func (o *Outbox) WriteMessage(ctx context.Context, tx sqldatabase.Tx, message Message) error {
+	var (
+		err      error
+		metadata []byte
+	)
+
+	if message.Topic == "" {
+		return ErrMissingTopic
+	}
+
+	if message.Key == "" {
+		message.Key = newKey()
+	}
+
+	if message.CreatedAt.IsZero() {
+		message.CreatedAt = o.now().UTC()
+	}
+
+	if message.Payload == nil {
+		message.Payload = json.RawMessage("null")
+	}
+
+	if message.Metadata == nil {
+		message.Metadata = map[string]string{}
+	}
+
+	if metadata, err = json.Marshal(message.Metadata); err != nil {
+		return fmt.Errorf("error marshaling outbox metadata: %w", err)
+	}
+
+	query := o.config.Rebind("INSERT INTO " + o.config.Table + " (message_key, topic, payload, metadata, created_at) VALUES (?, ?, ?, ?, ?)")
+
+	if _, err = tx.ExecContext(ctx, query, message.Key, message.Topic, string(message.Payload), string(metadata), message.CreatedAt); err != nil {
+		return fmt.Errorf("error writing outbox message: %w", err)
+	}
+
+	return nil
+}
+
+/*
+Start runs the relay every Interval until Stop is called
+*/
+func (o *Outbox) Start() error {
+	if o.config.DB == nil || o.config.Publisher == nil {
+		return ErrRelayNotConfigured
+	}
+
+	o.Lock()
+	defer o.Unlock()
+
+	if o.running {
+		return nil
+	}
+
+	var ctx context.Context
+
+	ctx, o.cancel = context.WithCancel(context.Background())
+	o.running = true
+	o.wg.Add(1)
+
+	go o.relayLoop(ctx)
+	return nil
+}
+
+/*
+Stop stops the relay and waits for the current pass to finish, or for
+ctx to be done
+*/
+func (o *Outbox) Stop(ctx context.Context) error {
+	o.Lock()
+
+	if !o.running {
+		o.Unlock()
+		return nil
+	}
+
+	o.cancel()
+	o.running = false
+	o.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+		o.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (o *Outbox) relayLoop(ctx context.Context) {
+	defer o.wg.Done()
+
+	ticker := time.NewTicker(o.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := o.Relay(ctx); err != nil && ctx.Err() == nil && o.config.OnError != nil {
+			o.config.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+/*
+Relay publishes up to BatchSize unpublished messages, oldest first, and
+returns how many were published. It stops at the first message that
+fails to publish, which is retried on the next pass. Call it from your
+own scheduler instead of Start if you prefer
+*/
+func (o *Outbox) Relay(ctx context.Context) (int, error) {
+	var (
+		err      error
+		messages []Message
+	)
+
+	if o.config.DB == nil || o.config.Publisher == nil {
+		return 0, ErrRelayNotConfigured
+	}
+
+	if messages, err = o.pending(ctx); err != nil {
+		return 0, err
+	}
+
+	published := 0
+
+	for _, message := range messages {
+		if err = o.config.Publisher(ctx, message); err != nil {
+			o.recordFailure(ctx, message, err)
+			return published, fmt.Errorf("error publishing outbox message %s: %w", message.Key, err)
+		}
+
+		query := o.config.Rebind("UPDATE " + o.config.Table + " SET published_at = ?, attempts = attempts + 1 WHERE id = ?")
+
+		if _, err = o.config.DB.ExecContext(ctx, query, o.now().UTC(), message.ID); err != nil {
+			return published, fmt.Errorf("error marking outbox message %s published: %w", message.Key, err)
+		}
+
+		published++
+	}
+
+	if o.config.Retention > 0 {
+		query := o.config.Rebind("DELETE FROM " + o.config.Table + " WHERE published_at IS NOT NULL AND published_at < ?")
+
+		if _, err = o.config.DB.ExecContext(ctx, query, o.now().UTC().Add(-o.config.Retention)); err != nil {
+			return published, fmt.Errorf("error deleting published outbox messages: %w", err)
+		}
+	}
+
+	return published, nil
+}
+
+func (o *Outbox) pending(ctx context.Context) ([]Message, error) {
+	var (
+		err  error
+		rows sqldatabase.Rows
+	)
+
+	query := o.config.Rebind(fmt.Sprintf("SELECT id, message_key, topic, payload, metadata, created_at FROM %s WHERE published_at IS NULL ORDER BY id LIMIT %d", o.config.Table, o.config.BatchSize))
+
+	if rows, err = o.config.DB.QueryContext(ctx, query); err != nil {
+		return nil, fmt.Errorf("error reading outbox: %w", err)
+	}
+
+	defer rows.Close()
+
+	result := make([]Message, 0, o.config.BatchSize)
+
+	for rows.Next() {
+		var (
+			message  Message
+			payload  string
+			metadata sql.NullString
+		)
+
+		if err = rows.Scan(&message.ID, &message.Key, &message.Topic, &payload, &metadata, &message.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning outbox message: %w", err)
+		}
+
+		message.Payload = json.RawMessage(payload)
+
+		if metadata.String != "" {
+			if err = json.Unmarshal([]byte(metadata.String), &message.Metadata); err != nil {
+				return nil, fmt.Errorf("error unmarshaling metadata of outbox message %s: %w", message.Key, err)
+			}
+		}
+
+		result = append(result, message)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading outbox: %w", err)
+	}
+
+	return result, nil
+}
+
+/*
+recordFailure counts a failed attempt on the message. Errors here are
+ignored, as the publish error is the one worth reporting
+*/
+func (o *Outbox) recordFailure(ctx context.Context, message Message, publishErr error) {
+	query := o.config.Rebind("UPDATE " + o.config.Table + " SET attempts = attempts + 1, last_error = ? WHERE id = ?")
+	_, _ = o.config.DB.ExecContext(ctx, query, publishErr.Error(), message.ID)
+}
+
+func newKey() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,37 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package outbox
+
+import (
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+)
+
+/*
+OutboxConfig configures an Outbox.
+
+Table is the outbox table, and defaults to "outbox". Queries are written
+with ? placeholders; set Rebind to rewrite them for databases that use
+another style, such as $1 for Postgres.
+
+The rest is only used by the relay. DB is the database the relay reads
+from, and Publisher sends each message on. Every Interval (default 1
+second) the relay publishes up to BatchSize (default 100) messages,
+oldest first. When publishing a message fails the relay stops and
+retries it on the next pass, so messages are published in order.
+Published messages are deleted once they are older than Retention;
+zero keeps them. OnError is called when a pass fails
+*/
+type OutboxConfig struct {
+	BatchSize int
+	DB        sqldatabase.DB
+	Interval  time.Duration
+	OnError   func(err error)
+	Publisher Publisher
+	Rebind    func(query string) string
+	Retention time.Duration
+	Table     string
+}
@@ -0,0 +1,205 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package outbox_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/events"
+	"github.com/ResurgenceIT/kit/v6/outbox"
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+)
+
+type outboxRow struct {
+	attempts  int
+	createdAt time.Time
+	id        int64
+	key       string
+	lastError string
+	metadata  string
+	payload   string
+	published bool
+	topic     string
+}
+
+/*
+fakeOutboxDB keeps outbox rows in memory and answers the queries the
+relay makes
+*/
+func fakeOutboxDB(rows []*outboxRow) *sqldatabase.MockDB {
+	return &sqldatabase.MockDB{
+		QueryContextFunc: func(ctx context.Context, query string, args ...interface{}) (sqldatabase.Rows, error) {
+			pending := make([]*outboxRow, 0)
+
+			for _, row := range rows {
+				if !row.published {
+					pending = append(pending, row)
+				}
+			}
+
+			index := -1
+
+			return &sqldatabase.MockRows{
+				CloseFunc: func() error { return nil },
+				ErrFunc:   func() error { return nil },
+				NextFunc: func() bool {
+					index++
+					return index < len(pending)
+				},
+				ScanFunc: func(dst ...interface{}) error {
+					row := pending[index]
+					*dst[0].(*int64) = row.id
+					*dst[1].(*string) = row.key
+					*dst[2].(*string) = row.topic
+					*dst[3].(*string) = row.payload
+					*dst[4].(*sql.NullString) = sql.NullString{String: row.metadata, Valid: true}
+					*dst[5].(*time.Time) = row.createdAt
+					return nil
+				},
+			}, nil
+		},
+		ExecContextFunc: func(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+			for _, row := range rows {
+				if row.id != args[1].(int64) {
+					continue
+				}
+
+				row.attempts++
+
+				if strings.Contains(query, "published_at") {
+					row.published = true
+				} else {
+					row.lastError = args[0].(string)
+				}
+			}
+
+			return nil, nil
+		},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var (
+		query string
+		args  []interface{}
+	)
+
+	tx := &sqldatabase.MockTx{
+		ExecContextFunc: func(ctx context.Context, q string, a ...interface{}) (sql.Result, error) {
+			query = q
+			args = a
+			return nil, nil
+		},
+	}
+
+	box := outbox.NewOutbox(outbox.OutboxConfig{
+		Rebind: func(query string) string {
+			for index := 1; strings.Contains(query, "?"); index++ {
+				query = strings.Replace(query, "?", fmt.Sprintf("$%d", index), 1)
+			}
+
+			return query
+		},
+		Table: "events_outbox",
+	})
+
+	if err := box.Write(context.Background(), tx, "orders.created", "order-42", map[string]int{"total": 1200}); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	expectedQuery := "INSERT INTO events_outbox (message_key, topic, payload, metadata, created_at) VALUES ($1, $2, $3, $4, $5)"
+
+	if query != expectedQuery {
+		t.Errorf("Expected query '%s' but got '%s'", expectedQuery, query)
+	}
+
+	if len(args) != 5 || args[0] != "order-42" || args[1] != "orders.created" || args[2] != `{"total":1200}` || args[3] != "{}" {
+		t.Errorf("Unexpected arguments %v", args)
+	}
+
+	if err := box.Write(context.Background(), tx, "", "", nil); !errors.Is(err, outbox.ErrMissingTopic) {
+		t.Errorf("Expected ErrMissingTopic but got '%v'", err)
+	}
+
+	if err := box.Write(context.Background(), tx, "orders.created", "", nil); err != nil || args[0] == "" {
+		t.Errorf("Expected a generated key but got '%v' with error '%v'", args[0], err)
+	}
+}
+
+func TestRelay(t *testing.T) {
+	rows := []*outboxRow{
+		{id: 1, key: "order-1", topic: "orders.created", payload: `{"id":1}`, metadata: `{"requestID":"abc"}`},
+		{id: 2, key: "order-2", topic: "orders.created", payload: `{"id":2}`, metadata: "{}"},
+		{id: 3, key: "order-3", topic: "orders.created", payload: `{"id":3}`, metadata: "{}"},
+	}
+
+	bus := events.NewBus(events.BusConfig{
+		Middleware: []events.Middleware{outbox.Deduplicate(10)},
+	})
+
+	handled := make([]string, 0)
+
+	_, _ = bus.Subscribe("orders.*", func(ctx context.Context, event events.Event) error {
+		handled = append(handled, event.ID)
+		return nil
+	})
+
+	failNext := true
+	publish := outbox.BusPublisher(bus)
+
+	box := outbox.NewOutbox(outbox.OutboxConfig{
+		DB: fakeOutboxDB(rows),
+		Publisher: func(ctx context.Context, message outbox.Message) error {
+			if message.Key == "order-2" && failNext {
+				failNext = false
+				return errors.New("broker unavailable")
+			}
+
+			return publish(ctx, message)
+		},
+	})
+
+	published, err := box.Relay(context.Background())
+
+	if published != 1 || err == nil {
+		t.Fatalf("Expected the relay to stop after 1 message with an error but got %d and '%v'", published, err)
+	}
+
+	if rows[1].attempts != 1 || rows[1].lastError != "broker unavailable" || rows[2].attempts != 0 {
+		t.Errorf("Expected the failure to be recorded on the second message only but got %+v and %+v", rows[1], rows[2])
+	}
+
+	if published, err = box.Relay(context.Background()); published != 2 || err != nil {
+		t.Fatalf("Expected the remaining 2 messages to be published but got %d and '%v'", published, err)
+	}
+
+	rows[0].published = false
+
+	if published, err = box.Relay(context.Background()); published != 1 || err != nil {
+		t.Fatalf("Expected the redelivered message to be published but got %d and '%v'", published, err)
+	}
+
+	if strings.Join(handled, ",") != "order-1,order-2,order-3" {
+		t.Errorf("Expected each message to be handled once, in order, but got %v", handled)
+	}
+
+	if published, err = box.Relay(context.Background()); published != 0 || err != nil {
+		t.Errorf("Expected nothing left to publish but got %d and '%v'", published, err)
+	}
+}
+
+func TestRelayNotConfigured(t *testing.T) {
+	box := outbox.NewOutbox(outbox.OutboxConfig{})
+
+	if err := box.Start(); !errors.Is(err, outbox.ErrRelayNotConfigured) {
+		t.Errorf("Expected ErrRelayNotConfigured but got '%v'", err)
+	}
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package outbox
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ResurgenceIT/kit/v6/events"
+)
+
+/*
+Publisher sends a message from the outbox to a bus or broker. A message
+may be published more than once, so consumers should be idempotent or
+deduplicate by the message key
+*/
+type Publisher func(ctx context.Context, message Message) error
+
+/*
+BusPublisher publishes messages to an events Bus. The event ID is the
+message key, and the payload is the message's raw JSON
+*/
+func BusPublisher(bus *events.Bus) Publisher {
+	return func(ctx context.Context, message Message) error {
+		return bus.PublishEvent(ctx, events.Event{
+			ID:       message.Key,
+			Metadata: message.Metadata,
+			Payload:  message.Payload,
+			Time:     message.CreatedAt,
+			Topic:    events.Topic(message.Topic),
+		})
+	}
+}
+
+/*
+Deduplicate is events middleware that skips events whose ID the handler
+has already handled successfully. Each handler remembers the last size
+IDs, which makes the relay's at-least-once delivery look exactly-once
+to handlers in this process
+*/
+func Deduplicate(size int) events.Middleware {
+	if size <= 0 {
+		size = 1000
+	}
+
+	return func(next events.Handler) events.Handler {
+		seen := newSeenSet(size)
+
+		return func(ctx context.Context, event events.Event) error {
+			if seen.contains(event.ID) {
+				return nil
+			}
+
+			if err := next(ctx, event); err != nil {
+				return err
+			}
+
+			seen.add(event.ID)
+			return nil
+		}
+	}
+}
+
+/*
+seenSet remembers the last size keys added to it
+*/
+type seenSet struct {
+	sync.Mutex
+
+	keys  map[string]struct{}
+	next  int
+	order []string
+}
+
+func newSeenSet(size int) *seenSet {
+	return &seenSet{
+		keys:  make(map[string]struct{}, size),
+		order: make([]string, size),
+	}
+}
+
+func (s *seenSet) contains(key string) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	_, ok := s.keys[key]
+	return ok
+}
+
+func (s *seenSet) add(key string) {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.keys[key]; ok {
+		return
+	}
+
+	delete(s.keys, s.order[s.next])
+	s.order[s.next] = key
+	s.keys[key] = struct{}{}
+	s.next = (s.next + 1) % len(s.order)
+}
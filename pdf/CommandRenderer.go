@@ -0,0 +1,55 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+/*
+CommandRenderer renders HTML by running a program, such as wkhtmltopdf
+*/
+type CommandRenderer struct {
+	config CommandRendererConfig
+}
+
+/*
+NewCommandRenderer creates a renderer that runs config.Command
+*/
+func NewCommandRenderer(config CommandRendererConfig) *CommandRenderer {
+	return &CommandRenderer{
+		config: config,
+	}
+}
+
+/*
+RenderHTML pipes html through the command. The PDF is buffered until
+the command succeeds, so a failure leaves w untouched. Canceling ctx
+kills the command
+*/
+func (r *CommandRenderer) RenderHTML(ctx context.Context, html []byte, w io.Writer) error {
+	output := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	command := exec.CommandContext(ctx, r.config.Command, r.config.Args...)
+	command.Stdin = bytes.NewReader(html)
+	command.Stdout = output
+	command.Stderr = stderr
+
+	if err := command.Run(); err != nil {
+		return fmt.Errorf("%w: %s: %s: %s", ErrRenderFailed, r.config.Command, err.Error(), strings.TrimSpace(stderr.String()))
+	}
+
+	if _, err := output.WriteTo(w); err != nil {
+		return fmt.Errorf("error writing PDF: %w", err)
+	}
+
+	return nil
+}
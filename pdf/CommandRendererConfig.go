@@ -0,0 +1,16 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package pdf
+
+/*
+CommandRendererConfig configures a CommandRenderer. Command is the
+program to run and Args its arguments. It must read HTML from standard
+input and write the PDF to standard output, as
+wkhtmltopdf --quiet - - does
+*/
+type CommandRendererConfig struct {
+	Args    []string
+	Command string
+}
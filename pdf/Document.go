@@ -0,0 +1,106 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package pdf
+
+/*
+PageSize is a page's width and height in points, 72 to the inch
+*/
+type PageSize struct {
+	Height float64
+	Width  float64
+}
+
+var (
+	A4     = PageSize{Height: 841.89, Width: 595.28}
+	Letter = PageSize{Height: 792, Width: 612}
+)
+
+/*
+Document is a simple document model: blocks laid out top to bottom,
+flowing onto new pages as needed. Size defaults to A4 and Margin to 50
+points. Footer is drawn at the bottom of every page, with {page} and
+{pages} replaced by the page number and page count
+*/
+type Document struct {
+	Author string
+	Blocks []Block
+	Footer string
+	Margin float64
+	Size   PageSize
+	Title  string
+}
+
+/*
+Block is one piece of a document, such as a Heading or Table
+*/
+type Block interface {
+	layout(l *layout) error
+}
+
+/*
+Alignment is how a paragraph's lines are aligned
+*/
+type Alignment int
+
+const (
+	AlignLeft Alignment = iota
+	AlignCenter
+	AlignRight
+)
+
+/*
+Heading is bold text. Level 1 is the largest, down to level 3
+*/
+type Heading struct {
+	Level int
+	Text  string
+}
+
+/*
+Paragraph is text wrapped to the page width. Newlines start new lines.
+Size defaults to 11 points
+*/
+type Paragraph struct {
+	Align Alignment
+	Bold  bool
+	Size  float64
+	Text  string
+}
+
+/*
+Table is a grid of text with a bold, shaded header row that repeats on
+every page the table spans. Widths are relative column widths, such as
+{3, 1, 1}; columns are equal when Widths is empty. Align sets each
+column's alignment, so amounts can be right aligned
+*/
+type Table struct {
+	Align  []Alignment
+	Header []string
+	Rows   [][]string
+	Widths []float64
+}
+
+/*
+Image is a JPEG, PNG, or GIF image. Width is in points, and defaults to
+the image's size at 72 DPI, shrunk to fit the page. Height keeps the
+aspect ratio
+*/
+type Image struct {
+	Align Alignment
+	Data  []byte
+	Width float64
+}
+
+/*
+Spacer is empty vertical space, in points
+*/
+type Spacer struct {
+	Height float64
+}
+
+/*
+PageBreak starts a new page
+*/
+type PageBreak struct{}
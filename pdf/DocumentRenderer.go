@@ -0,0 +1,428 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	fontRegular = 1
+	fontBold    = 2
+
+	footerSize = 9
+	tableSize  = 10
+)
+
+var headingSizes = map[int]float64{1: 20, 2: 16, 3: 13}
+
+/*
+DocumentRenderer renders the document model to PDF itself, with no
+external tools. It uses the Helvetica fonts built into every PDF
+reader, so text is limited to the characters of Windows-1252; anything
+else prints as a question mark. Use an IHTMLRenderer for richer
+layouts or other scripts
+*/
+type DocumentRenderer struct{}
+
+/*
+NewDocumentRenderer creates a DocumentRenderer
+*/
+func NewDocumentRenderer() *DocumentRenderer {
+	return &DocumentRenderer{}
+}
+
+/*
+Render lays out document and writes it to w as a PDF
+*/
+func (r *DocumentRenderer) Render(ctx context.Context, document Document, w io.Writer) error {
+	if len(document.Blocks) == 0 {
+		return ErrEmptyDocument
+	}
+
+	if document.Size.Width <= 0 || document.Size.Height <= 0 {
+		document.Size = A4
+	}
+
+	if document.Margin <= 0 {
+		document.Margin = 50
+	}
+
+	l := &layout{document: document}
+	l.newPage()
+
+	for _, block := range document.Blocks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := block.layout(l); err != nil {
+			return err
+		}
+	}
+
+	l.drawFooters()
+	return l.write(w)
+}
+
+/*
+layout places blocks on pages, tracking y, the distance from the top of
+the page to where the next block goes
+*/
+type layout struct {
+	document Document
+	images   []*pdfImage
+	page     *bytes.Buffer
+	pages    []*bytes.Buffer
+	y        float64
+}
+
+func (l *layout) newPage() {
+	l.page = &bytes.Buffer{}
+	l.pages = append(l.pages, l.page)
+	l.y = l.document.Margin
+}
+
+func (l *layout) contentWidth() float64 {
+	return l.document.Size.Width - l.document.Margin*2
+}
+
+func (l *layout) bottom() float64 {
+	bottom := l.document.Size.Height - l.document.Margin
+
+	if l.document.Footer != "" {
+		bottom -= footerSize * 2
+	}
+
+	return bottom
+}
+
+/*
+ensure starts a new page when height won't fit on this one, unless the
+page is still empty
+*/
+func (l *layout) ensure(height float64) {
+	if l.y+height > l.bottom() && l.y > l.document.Margin {
+		l.newPage()
+	}
+}
+
+func (l *layout) atTop() bool {
+	return l.y <= l.document.Margin
+}
+
+/*
+text draws one line with its top at y. x is from the left edge
+*/
+func (l *layout) text(line string, bold bool, size, x, y float64) {
+	font := fontRegular
+
+	if bold {
+		font = fontBold
+	}
+
+	fmt.Fprintf(l.page, "BT /F%d %s Tf %s %s Td %s Tj ET\n", font, number(size), number(x), number(l.document.Size.Height-y-size*0.8), pdfString(line))
+}
+
+func (l *layout) alignedX(line string, bold bool, size, left, width float64, align Alignment) float64 {
+	switch align {
+	case AlignCenter:
+		return left + (width-textWidth(line, bold, size))/2
+
+	case AlignRight:
+		return left + width - textWidth(line, bold, size)
+	}
+
+	return left
+}
+
+func (l *layout) lines(lines []string, bold bool, size float64, align Alignment) {
+	leading := size * 1.4
+
+	for _, line := range lines {
+		l.ensure(leading)
+		l.text(line, bold, size, l.alignedX(line, bold, size, l.document.Margin, l.contentWidth(), align), l.y)
+		l.y += leading
+	}
+}
+
+func (h Heading) layout(l *layout) error {
+	size, ok := headingSizes[h.Level]
+
+	if !ok {
+		size = headingSizes[1]
+	}
+
+	if !l.atTop() {
+		l.y += size * 0.6
+	}
+
+	l.ensure(size * 2.8)
+	l.lines(wrapText(h.Text, true, size, l.contentWidth()), true, size, AlignLeft)
+	l.y += size * 0.3
+
+	return nil
+}
+
+func (p Paragraph) layout(l *layout) error {
+	size := p.Size
+
+	if size <= 0 {
+		size = 11
+	}
+
+	l.lines(wrapText(p.Text, p.Bold, size, l.contentWidth()), p.Bold, size, p.Align)
+	l.y += size * 0.6
+
+	return nil
+}
+
+func (t Table) layout(l *layout) error {
+	columns := len(t.Header)
+
+	for _, row := range t.Rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+
+	if columns == 0 {
+		return nil
+	}
+
+	widths := make([]float64, columns)
+	total := 0.0
+
+	for index := range widths {
+		widths[index] = 1
+
+		if index < len(t.Widths) && t.Widths[index] > 0 {
+			widths[index] = t.Widths[index]
+		}
+
+		total += widths[index]
+	}
+
+	for index := range widths {
+		widths[index] = widths[index] / total * l.contentWidth()
+	}
+
+	if len(t.Header) > 0 {
+		t.row(l, widths, t.Header, true)
+	}
+
+	for _, row := range t.Rows {
+		if !t.row(l, widths, row, false) {
+			continue
+		}
+
+		if len(t.Header) > 0 {
+			t.row(l, widths, t.Header, true)
+		}
+
+		t.row(l, widths, row, false)
+	}
+
+	l.y += tableSize
+
+	return nil
+}
+
+/*
+row draws one table row. When the row doesn't fit it starts a new page
+and returns true without drawing, so the header can be repeated first
+*/
+func (t Table) row(l *layout, widths []float64, cells []string, header bool) bool {
+	const padding = 4
+	leading := tableSize * 1.3
+	wrapped := make([][]string, len(widths))
+	height := 0.0
+
+	for index, width := range widths {
+		text := ""
+
+		if index < len(cells) {
+			text = cells[index]
+		}
+
+		wrapped[index] = wrapText(text, header, tableSize, width-padding*2)
+		height = math.Max(height, float64(len(wrapped[index]))*leading+padding*2)
+	}
+
+	if l.y+height > l.bottom() && !l.atTop() {
+		l.newPage()
+
+		if !header {
+			return true
+		}
+	}
+
+	x := l.document.Margin
+	pdfY := l.document.Size.Height - l.y - height
+
+	for index, width := range widths {
+		if header {
+			fmt.Fprintf(l.page, "0.85 g %s %s %s %s re f 0 g\n", number(x), number(pdfY), number(width), number(height))
+		}
+
+		fmt.Fprintf(l.page, "0.6 G 0.5 w %s %s %s %s re S 0 G\n", number(x), number(pdfY), number(width), number(height))
+
+		align := AlignLeft
+
+		if index < len(t.Align) && !header {
+			align = t.Align[index]
+		}
+
+		for line, text := range wrapped[index] {
+			l.text(text, header, tableSize, l.alignedX(text, header, tableSize, x+padding, width-padding*2, align), l.y+padding+float64(line)*leading+1)
+		}
+
+		x += width
+	}
+
+	l.y += height
+	return false
+}
+
+func (i Image) layout(l *layout) error {
+	embedded, err := newImage(i.Data)
+
+	if err != nil {
+		return err
+	}
+
+	l.images = append(l.images, embedded)
+
+	width := i.Width
+
+	if width <= 0 {
+		width = float64(embedded.width)
+	}
+
+	width = math.Min(width, l.contentWidth())
+	height := width * float64(embedded.height) / float64(embedded.width)
+
+	if maxHeight := l.bottom() - l.document.Margin; height > maxHeight {
+		height = maxHeight
+		width = height * float64(embedded.width) / float64(embedded.height)
+	}
+
+	l.ensure(height)
+
+	x := l.document.Margin
+
+	switch i.Align {
+	case AlignCenter:
+		x += (l.contentWidth() - width) / 2
+
+	case AlignRight:
+		x += l.contentWidth() - width
+	}
+
+	fmt.Fprintf(l.page, "q %s 0 0 %s %s %s cm /Im%d Do Q\n", number(width), number(height), number(x), number(l.document.Size.Height-l.y-height), len(l.images))
+	l.y += height + 8
+
+	return nil
+}
+
+func (s Spacer) layout(l *layout) error {
+	l.ensure(s.Height)
+	l.y += s.Height
+
+	return nil
+}
+
+func (PageBreak) layout(l *layout) error {
+	l.newPage()
+	return nil
+}
+
+func (l *layout) drawFooters() {
+	if l.document.Footer == "" {
+		return
+	}
+
+	pages := strconv.Itoa(len(l.pages))
+
+	for index, page := range l.pages {
+		l.page = page
+		footer := strings.NewReplacer("{page}", strconv.Itoa(index+1), "{pages}", pages).Replace(l.document.Footer)
+		x := l.alignedX(footer, false, footerSize, l.document.Margin, l.contentWidth(), AlignCenter)
+
+		l.text(footer, false, footerSize, x, l.document.Size.Height-l.document.Margin-footerSize)
+	}
+}
+
+/*
+write serializes the laid out pages. Objects are numbered: the catalog,
+the page tree, the two fonts, the document info, the images, then a
+page and its contents for each page
+*/
+func (l *layout) write(w io.Writer) error {
+	const (
+		catalog = 1
+		pages   = 2
+		info    = 5
+	)
+
+	firstImage := 6
+	firstPage := firstImage + len(l.images)
+	writer := newPDFWriter(w)
+
+	kids := make([]string, len(l.pages))
+
+	for index := range l.pages {
+		kids[index] = fmt.Sprintf("%d 0 R", firstPage+index*2)
+	}
+
+	writer.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+	writer.object(pages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(l.pages)))
+	writer.object(fontRegular+2, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	writer.object(fontBold+2, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	writer.object(info, fmt.Sprintf("<< /Title %s /Author %s /Producer (kit) >>", pdfString(l.document.Title), pdfString(l.document.Author)))
+
+	xObjects := &strings.Builder{}
+
+	for index, image := range l.images {
+		fmt.Fprintf(xObjects, " /Im%d %d 0 R", index+1, firstImage+index)
+
+		writer.stream(firstImage+index, fmt.Sprintf(
+			"/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter %s%s",
+			image.width, image.height, image.colorSpace, image.filter, image.decode,
+		), image.data)
+	}
+
+	resources := fmt.Sprintf("<< /Font << /F1 %d 0 R /F2 %d 0 R >> /XObject <<%s >> >>", fontRegular+2, fontBold+2, xObjects.String())
+
+	for index, page := range l.pages {
+		pageNumber := firstPage + index*2
+
+		writer.object(pageNumber, fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources %s /Contents %d 0 R >>",
+			pages, number(l.document.Size.Width), number(l.document.Size.Height), resources, pageNumber+1,
+		))
+
+		writer.compressedStream(pageNumber+1, page.Bytes())
+	}
+
+	if err := writer.finish(firstPage+len(l.pages)*2-1, catalog, info); err != nil {
+		return fmt.Errorf("error writing PDF: %w", err)
+	}
+
+	return nil
+}
+
+/*
+number formats a coordinate with at most two decimals
+*/
+func number(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
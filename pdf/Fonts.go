@@ -0,0 +1,148 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package pdf
+
+import (
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+/*
+Glyph widths, in thousandths of the font size, of the printable ASCII
+characters from space to tilde, from the Adobe font metrics of the
+standard Helvetica fonts every PDF reader has
+*/
+var (
+	helveticaWidths = []int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+
+	helveticaBoldWidths = []int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
+
+/*
+encodeText converts text to Windows-1252, the encoding the standard
+fonts use. Characters it can't represent become question marks
+*/
+func encodeText(text string) []byte {
+	result := make([]byte, 0, len(text))
+
+	for _, r := range text {
+		if b, ok := charmap.Windows1252.EncodeRune(r); ok {
+			result = append(result, b)
+		} else {
+			result = append(result, '?')
+		}
+	}
+
+	return result
+}
+
+/*
+textWidth measures text in points
+*/
+func textWidth(text string, bold bool, size float64) float64 {
+	widths := helveticaWidths
+
+	if bold {
+		widths = helveticaBoldWidths
+	}
+
+	total := 0
+
+	for _, b := range encodeText(text) {
+		if b >= 32 && int(b-32) < len(widths) {
+			total += widths[b-32]
+		} else {
+			total += 556
+		}
+	}
+
+	return float64(total) * size / 1000
+}
+
+/*
+pdfString writes text as a PDF string literal
+*/
+func pdfString(text string) string {
+	builder := strings.Builder{}
+	builder.WriteByte('(')
+
+	for _, b := range encodeText(text) {
+		switch b {
+		case '(', ')', '\\':
+			builder.WriteByte('\\')
+			builder.WriteByte(b)
+
+		case '\r':
+			builder.WriteString(`\r`)
+
+		default:
+			builder.WriteByte(b)
+		}
+	}
+
+	builder.WriteByte(')')
+	return builder.String()
+}
+
+/*
+wrapText breaks text into lines no wider than width. Newlines always
+break, and words too long for a line are split
+*/
+func wrapText(text string, bold bool, size, width float64) []string {
+	lines := []string{}
+
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line := ""
+
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+
+			if line != "" {
+				candidate = line + " " + word
+			}
+
+			if textWidth(candidate, bold, size) <= width {
+				line = candidate
+				continue
+			}
+
+			if line != "" {
+				lines = append(lines, line)
+			}
+
+			for textWidth(word, bold, size) > width {
+				cut := len([]rune(word)) - 1
+
+				for cut > 1 && textWidth(string([]rune(word)[:cut]), bold, size) > width {
+					cut--
+				}
+
+				lines = append(lines, string([]rune(word)[:cut]))
+				word = string([]rune(word)[cut:])
+			}
+
+			line = word
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
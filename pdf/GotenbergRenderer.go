@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+/*
+GotenbergRenderer renders HTML with a Gotenberg server, which prints it
+with headless Chromium, so anything Chrome can lay out can be a PDF
+*/
+type GotenbergRenderer struct {
+	config GotenbergRendererConfig
+}
+
+/*
+NewGotenbergRenderer creates a renderer for the Gotenberg server at
+config.URL
+*/
+func NewGotenbergRenderer(config GotenbergRendererConfig) (*GotenbergRenderer, error) {
+	if config.URL == "" {
+		return nil, ErrMissingURL
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: time.Minute}
+	}
+
+	config.URL = strings.TrimSuffix(config.URL, "/")
+
+	return &GotenbergRenderer{
+		config: config,
+	}, nil
+}
+
+/*
+RenderHTML converts an HTML page to PDF
+*/
+func (r *GotenbergRenderer) RenderHTML(ctx context.Context, html []byte, w io.Writer) error {
+	var (
+		err      error
+		part     io.Writer
+		request  *http.Request
+		response *http.Response
+	)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	for name, value := range r.config.Fields {
+		_ = writer.WriteField(name, value)
+	}
+
+	if part, err = writer.CreateFormFile("files", "index.html"); err != nil {
+		return fmt.Errorf("error building Gotenberg request: %w", err)
+	}
+
+	_, _ = part.Write(html)
+	_ = writer.Close()
+
+	if request, err = http.NewRequestWithContext(ctx, http.MethodPost, r.config.URL+"/forms/chromium/convert/html", body); err != nil {
+		return fmt.Errorf("error building Gotenberg request: %w", err)
+	}
+
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+
+	if response, err = r.config.HTTPClient.Do(request); err != nil {
+		return fmt.Errorf("error calling Gotenberg: %w", err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		message, _ := ioutil.ReadAll(io.LimitReader(response.Body, 1024))
+		return fmt.Errorf("%w: Gotenberg returned %d: %s", ErrRenderFailed, response.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	if _, err = io.Copy(w, response.Body); err != nil {
+		return fmt.Errorf("error reading PDF from Gotenberg: %w", err)
+	}
+
+	return nil
+}
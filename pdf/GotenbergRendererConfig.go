@@ -0,0 +1,20 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package pdf
+
+import "net/http"
+
+/*
+GotenbergRendererConfig configures a GotenbergRenderer. URL is the
+Gotenberg server, such as "http://gotenberg:3000". Fields are extra form
+fields sent with every conversion, such as "paperWidth" or
+"printBackground". HTTPClient defaults to a client with a one minute
+timeout
+*/
+type GotenbergRendererConfig struct {
+	Fields     map[string]string
+	HTTPClient *http.Client
+	URL        string
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+/*
+pdfImage is an image ready to embed. JPEGs are embedded as they are,
+since PDF understands them. Anything else is decoded to RGB, composited
+onto white, and compressed
+*/
+type pdfImage struct {
+	colorSpace string
+	data       []byte
+	decode     string
+	filter     string
+	height     int
+	width      int
+}
+
+func newImage(data []byte) (*pdfImage, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownImage, err.Error())
+	}
+
+	if format == "jpeg" {
+		result := &pdfImage{colorSpace: "/DeviceRGB", data: data, filter: "/DCTDecode", height: config.Height, width: config.Width}
+
+		switch config.ColorModel {
+		case color.GrayModel:
+			result.colorSpace = "/DeviceGray"
+
+		case color.CMYKModel:
+			// CMYK JPEGs are nearly always Adobe's, which store the channels inverted
+			result.colorSpace = "/DeviceCMYK"
+			result.decode = " /Decode [1 0 1 0 1 0 1 0]"
+		}
+
+		return result, nil
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownImage, err.Error())
+	}
+
+	bounds := decoded.Bounds()
+	pixels := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := decoded.At(x, y).RGBA()
+			white := 0xffff - a
+
+			pixels = append(pixels, byte((r+white)>>8), byte((g+white)>>8), byte((b+white)>>8))
+		}
+	}
+
+	compressed := &bytes.Buffer{}
+	writer := zlib.NewWriter(compressed)
+	_, _ = writer.Write(pixels)
+	_ = writer.Close()
+
+	return &pdfImage{
+		colorSpace: "/DeviceRGB",
+		data:       compressed.Bytes(),
+		filter:     "/FlateDecode",
+		height:     bounds.Dy(),
+		width:      bounds.Dx(),
+	}, nil
+}
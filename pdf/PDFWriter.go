@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+pdfWriter writes PDF objects, remembering where each starts for the
+cross reference table. Object numbers are assigned by the caller
+*/
+type pdfWriter struct {
+	err     error
+	offsets map[int]int64
+	w       io.Writer
+	written int64
+}
+
+func newPDFWriter(w io.Writer) *pdfWriter {
+	result := &pdfWriter{offsets: map[int]int64{}, w: w}
+	result.write("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	return result
+}
+
+func (p *pdfWriter) write(s string) {
+	if p.err != nil {
+		return
+	}
+
+	n, err := io.WriteString(p.w, s)
+	p.written += int64(n)
+	p.err = err
+}
+
+func (p *pdfWriter) object(number int, body string) {
+	p.offsets[number] = p.written
+	p.write(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", number, body))
+}
+
+func (p *pdfWriter) stream(number int, dictionary string, data []byte) {
+	p.offsets[number] = p.written
+	p.write(fmt.Sprintf("%d 0 obj\n<< %s /Length %d >>\nstream\n", number, dictionary, len(data)))
+	p.write(string(data))
+	p.write("\nendstream\nendobj\n")
+}
+
+/*
+compressedStream writes a stream compressed with Flate
+*/
+func (p *pdfWriter) compressedStream(number int, data []byte) {
+	buffer := &bytes.Buffer{}
+	writer := zlib.NewWriter(buffer)
+	_, _ = writer.Write(data)
+	_ = writer.Close()
+
+	p.stream(number, "/Filter /FlateDecode", buffer.Bytes())
+}
+
+/*
+finish writes the cross reference table and trailer. Every object from
+1 to count must have been written
+*/
+func (p *pdfWriter) finish(count int, root, info int) error {
+	start := p.written
+	builder := &strings.Builder{}
+
+	fmt.Fprintf(builder, "xref\n0 %d\n0000000000 65535 f \n", count+1)
+
+	for number := 1; number <= count; number++ {
+		fmt.Fprintf(builder, "%010d 00000 n \n", p.offsets[number])
+	}
+
+	fmt.Fprintf(builder, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", count+1, root, info, start)
+	p.write(builder.String())
+
+	return p.err
+}
@@ -0,0 +1,221 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package pdf_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/pdf"
+)
+
+var streamPattern = regexp.MustCompile(`(?s)/Filter /FlateDecode /Length (\d+) >>\nstream\n`)
+
+func testImages() (pngData, jpegData []byte) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+
+	for x := 0; x < 40; x++ {
+		img.Set(x, 10, color.RGBA{R: 255, A: 255})
+	}
+
+	pngBuffer, jpegBuffer := &bytes.Buffer{}, &bytes.Buffer{}
+	_ = png.Encode(pngBuffer, img)
+	_ = jpeg.Encode(jpegBuffer, img, nil)
+
+	return pngBuffer.Bytes(), jpegBuffer.Bytes()
+}
+
+/*
+pageText decompresses every Flate stream, which holds the page
+contents, and the PNG image
+*/
+func pageText(t *testing.T, b []byte) string {
+	result := &strings.Builder{}
+
+	for _, match := range streamPattern.FindAllSubmatchIndex(b, -1) {
+		length, _ := strconv.Atoi(string(b[match[2]:match[3]]))
+		reader, err := zlib.NewReader(bytes.NewReader(b[match[1] : match[1]+length]))
+
+		if err != nil {
+			t.Fatalf("Expected a valid Flate stream but got %v", err)
+		}
+
+		content, _ := ioutil.ReadAll(reader)
+		result.Write(content)
+	}
+
+	return result.String()
+}
+
+/*
+checkXref makes sure every cross reference entry points at its object
+*/
+func checkXref(t *testing.T, b []byte) {
+	start := bytes.LastIndex(b, []byte("startxref\n"))
+	offset, _ := strconv.Atoi(strings.Fields(string(b[start+10:]))[0])
+
+	if !bytes.HasPrefix(b[offset:], []byte("xref\n")) {
+		t.Fatalf("Expected startxref to point at the xref table")
+	}
+
+	lines := strings.Split(string(b[offset:]), "\n")
+
+	for number := 1; !strings.HasPrefix(lines[number+2], "trailer"); number++ {
+		objectOffset, _ := strconv.Atoi(lines[number+2][:10])
+
+		if !bytes.HasPrefix(b[objectOffset:], []byte(fmt.Sprintf("%d 0 obj", number))) {
+			t.Errorf("Expected object %d at offset %d", number, objectOffset)
+		}
+	}
+}
+
+func TestDocumentRenderer(t *testing.T) {
+	pngData, jpegData := testImages()
+	rows := [][]string{}
+
+	for index := 1; index <= 80; index++ {
+		rows = append(rows, []string{fmt.Sprintf("Widget (model %d)", index), "2", "€10.00"})
+	}
+
+	document := pdf.Document{
+		Author: "Billing",
+		Blocks: []pdf.Block{
+			pdf.Heading{Level: 1, Text: "Invoice INV-1"},
+			pdf.Image{Data: pngData, Width: 120},
+			pdf.Paragraph{Text: strings.Repeat("Thank you for your business. ", 40)},
+			pdf.Paragraph{Text: "Unsupported: 日本"},
+			pdf.Table{Align: []pdf.Alignment{pdf.AlignLeft, pdf.AlignRight, pdf.AlignRight}, Header: []string{"Item", "Qty", "Price"}, Rows: rows, Widths: []float64{3, 1, 1}},
+			pdf.PageBreak{},
+			pdf.Image{Data: jpegData, Align: pdf.AlignCenter},
+		},
+		Footer: "Page {page} of {pages}",
+		Title:  "Invoice (INV-1)",
+	}
+
+	buffer := &bytes.Buffer{}
+
+	if err := pdf.NewDocumentRenderer().Render(context.Background(), document, buffer); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	b := buffer.Bytes()
+
+	if !bytes.HasPrefix(b, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(b, []byte("%%EOF\n")) {
+		t.Fatalf("Expected a PDF but got %q", b[:20])
+	}
+
+	checkXref(t, b)
+
+	pages := regexp.MustCompile(`/Count (\d+)`).FindSubmatch(b)
+
+	if pages == nil || string(pages[1]) != "4" {
+		t.Errorf("Expected 4 pages but got %s", pages)
+	}
+
+	content := pageText(t, b)
+
+	expected := []string{
+		"(Invoice INV-1) Tj",
+		"(Page 1 of 4) Tj",
+		"(Page 4 of 4) Tj",
+		"(Widget \\(model 80\\)) Tj",
+		"(Unsupported: ??) Tj",
+		"(\x8010.00) Tj",
+		"/Im2 Do",
+	}
+
+	for _, text := range expected {
+		if !strings.Contains(content, text) {
+			t.Errorf("Expected the content to contain %q", text)
+		}
+	}
+
+	if strings.Count(content, "(Item) Tj") < 2 {
+		t.Errorf("Expected the table header to repeat on each page")
+	}
+
+	if !bytes.Contains(b, []byte("/Title (Invoice \\(INV-1\\))")) || !bytes.Contains(b, []byte("/Filter /DCTDecode")) {
+		t.Errorf("Expected the title and an embedded JPEG")
+	}
+}
+
+func TestDocumentRendererErrors(t *testing.T) {
+	renderer := pdf.NewDocumentRenderer()
+
+	if err := renderer.Render(context.Background(), pdf.Document{}, &bytes.Buffer{}); !errors.Is(err, pdf.ErrEmptyDocument) {
+		t.Errorf("Expected ErrEmptyDocument but got %v", err)
+	}
+
+	document := pdf.Document{Blocks: []pdf.Block{pdf.Image{Data: []byte("not an image")}}}
+
+	if err := renderer.Render(context.Background(), document, &bytes.Buffer{}); !errors.Is(err, pdf.ErrUnknownImage) {
+		t.Errorf("Expected ErrUnknownImage but got %v", err)
+	}
+}
+
+func TestGotenbergRenderer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("files")
+
+		if r.URL.Path != "/forms/chromium/convert/html" || err != nil || header.Filename != "index.html" || r.FormValue("printBackground") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("bad request"))
+			return
+		}
+
+		html, _ := ioutil.ReadAll(file)
+		_, _ = w.Write(append([]byte("%PDF "), html...))
+	}))
+
+	defer server.Close()
+
+	renderer, _ := pdf.NewGotenbergRenderer(pdf.GotenbergRendererConfig{
+		Fields: map[string]string{"printBackground": "true"},
+		URL:    server.URL + "/",
+	})
+
+	tmpl := template.Must(template.New("invoice").Parse(`<h1>{{.}}</h1>`))
+	buffer := &bytes.Buffer{}
+
+	if err := pdf.RenderTemplate(context.Background(), renderer, tmpl, "invoice", "<INV-1>", buffer); err != nil || buffer.String() != "%PDF <h1>&lt;INV-1&gt;</h1>" {
+		t.Errorf("Unexpected PDF '%s', %v", buffer.String(), err)
+	}
+
+	failing, _ := pdf.NewGotenbergRenderer(pdf.GotenbergRendererConfig{URL: server.URL})
+
+	if err := failing.RenderHTML(context.Background(), []byte("<p>"), &bytes.Buffer{}); !errors.Is(err, pdf.ErrRenderFailed) {
+		t.Errorf("Expected ErrRenderFailed but got %v", err)
+	}
+}
+
+func TestCommandRenderer(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	renderer := pdf.NewCommandRenderer(pdf.CommandRendererConfig{Command: "cat"})
+
+	if err := renderer.RenderHTML(context.Background(), []byte("<p>Hi</p>"), buffer); err != nil || buffer.String() != "<p>Hi</p>" {
+		t.Errorf("Unexpected output '%s', %v", buffer.String(), err)
+	}
+
+	failing := pdf.NewCommandRenderer(pdf.CommandRendererConfig{Command: "false"})
+
+	if err := failing.RenderHTML(context.Background(), []byte("<p>Hi</p>"), buffer); !errors.Is(err, pdf.ErrRenderFailed) {
+		t.Errorf("Expected ErrRenderFailed but got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+)
+
+var (
+	ErrEmptyDocument = errors.New("document has no content")
+	ErrMissingURL    = errors.New("a URL is required")
+	ErrRenderFailed  = errors.New("PDF rendering failed")
+	ErrUnknownImage  = errors.New("unsupported image format")
+)
+
+/*
+IRenderer describes an engine that renders the document model to PDF
+*/
+type IRenderer interface {
+	Render(ctx context.Context, document Document, w io.Writer) error
+}
+
+/*
+IHTMLRenderer describes an engine that renders HTML to PDF, such as a
+headless browser
+*/
+type IHTMLRenderer interface {
+	RenderHTML(ctx context.Context, html []byte, w io.Writer) error
+}
+
+/*
+RenderTemplate executes an HTML template with data and renders the
+result to PDF with renderer. The template runs before anything is
+written, so a template error leaves w untouched
+*/
+func RenderTemplate(ctx context.Context, renderer IHTMLRenderer, t *template.Template, name string, data interface{}, w io.Writer) error {
+	buffer := &bytes.Buffer{}
+
+	if err := t.ExecuteTemplate(buffer, name, data); err != nil {
+		return fmt.Errorf("error executing template %s: %w", name, err)
+	}
+
+	return renderer.RenderHTML(ctx, buffer.Bytes(), w)
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package privacy
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+/*
+Archive is the zip file an export is written to. Each handler's files
+go in a folder named after it
+*/
+type Archive struct {
+	files  []string
+	folder string
+	now    time.Time
+	zw     *zip.Writer
+}
+
+func newArchive(w io.Writer, now time.Time) *Archive {
+	return &Archive{
+		files: make([]string, 0, 20),
+		now:   now,
+		zw:    zip.NewWriter(w),
+	}
+}
+
+/*
+WriteFile copies r into the archive as name, a slash separated path
+inside the handler's folder
+*/
+func (a *Archive) WriteFile(name string, r io.Reader) error {
+	if name == "" || path.IsAbs(name) || path.Clean(name) != name || name == ".." || strings.HasPrefix(name, "../") {
+		return fmt.Errorf("%w: %q", ErrInvalidFileName, name)
+	}
+
+	name = path.Join(a.folder, name)
+
+	w, err := a.zw.CreateHeader(&zip.FileHeader{
+		Method:   zip.Deflate,
+		Modified: a.now,
+		Name:     name,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(w, r); err != nil {
+		return err
+	}
+
+	a.files = append(a.files, name)
+	return nil
+}
+
+/*
+WriteJSON writes v to the archive as indented JSON
+*/
+func (a *Archive) WriteJSON(name string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return a.WriteFile(name, bytes.NewReader(b))
+}
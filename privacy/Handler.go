@@ -0,0 +1,32 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package privacy
+
+import (
+	"context"
+)
+
+/*
+ExportFunc writes everything a module holds about a user to archive
+*/
+type ExportFunc func(ctx context.Context, userID string, archive *Archive) error
+
+/*
+EraseFunc deletes or anonymizes everything a module holds about a user.
+Erasures can be retried, so erasing a user that is already gone must
+succeed
+*/
+type EraseFunc func(ctx context.Context, userID string) error
+
+/*
+Handler exports and erases one module's data. Name is the folder the
+module's files are written to in an export, so it must be unique. A
+module that can only do one of the two leaves the other nil
+*/
+type Handler struct {
+	Erase  EraseFunc
+	Export ExportFunc
+	Name   string
+}
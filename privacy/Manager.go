@@ -0,0 +1,432 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package privacy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/clock"
+	"github.com/ResurgenceIT/kit/v6/ids"
+	"github.com/ResurgenceIT/kit/v6/storage"
+)
+
+var (
+	ErrDuplicateHandler = errors.New("privacy: a handler with this name is already registered")
+	ErrExportNotReady   = errors.New("privacy: export has not completed")
+	ErrInvalidFileName  = errors.New("privacy: invalid archive file name")
+	ErrInvalidHandler   = errors.New("privacy: a handler needs a name and an export or erase function")
+	ErrMissingUserID    = errors.New("privacy: a user ID is required")
+	ErrNoStore          = errors.New("privacy: exports need a store")
+	ErrRequestFailed    = errors.New("privacy: request failed")
+	ErrRequestNotFound  = errors.New("privacy: request not found")
+)
+
+/*
+Manager runs subject access requests. Each module that keeps personal
+data registers a Handler, and the manager runs every handler for a user
+when they ask for a copy of their data or for it to be erased, tracking
+each handler's progress. Exports are written to a zip archive in a
+storage.IStore and downloaded with a signed link
+*/
+type Manager struct {
+	sync.RWMutex
+
+	config   ManagerConfig
+	handlers map[string]Handler
+	running  sync.WaitGroup
+}
+
+type manifest struct {
+	CreatedAt time.Time `json:"createdAt"`
+	Files     []string  `json:"files"`
+	RequestID string    `json:"requestID"`
+	UserID    string    `json:"userID"`
+}
+
+/*
+NewManager creates a manager with no handlers
+*/
+func NewManager(config ManagerConfig) *Manager {
+	if config.Clock == nil {
+		config.Clock = clock.Real{}
+	}
+
+	if config.LinkExpiry <= 0 {
+		config.LinkExpiry = 24 * time.Hour
+	}
+
+	if config.Prefix == "" {
+		config.Prefix = "privacy-exports"
+	}
+
+	if config.Requests == nil {
+		config.Requests = NewMemoryRequestStore()
+	}
+
+	return &Manager{
+		config:   config,
+		handlers: make(map[string]Handler),
+	}
+}
+
+/*
+Register adds handlers. Names must be unique, as they name each
+handler's folder in an export
+*/
+func (m *Manager) Register(handlers ...Handler) error {
+	m.Lock()
+	defer m.Unlock()
+
+	for _, handler := range handlers {
+		if handler.Name == "" || (handler.Erase == nil && handler.Export == nil) {
+			return ErrInvalidHandler
+		}
+
+		if _, ok := m.handlers[handler.Name]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicateHandler, handler.Name)
+		}
+
+		m.handlers[handler.Name] = handler
+	}
+
+	return nil
+}
+
+/*
+Export writes everything the registered handlers hold about a user to
+an archive and waits for it to finish. When any handler fails the
+archive is deleted, and the failed request is returned with an error
+wrapping ErrRequestFailed
+*/
+func (m *Manager) Export(ctx context.Context, userID string) (Request, error) {
+	request, handlers, err := m.newRequest(ctx, KindExport, userID)
+
+	if err != nil {
+		return request, err
+	}
+
+	return m.export(ctx, request, handlers)
+}
+
+/*
+Erase runs every registered eraser for a user and waits for them to
+finish. Erasers that fail don't stop the others, and the failed request
+is returned with an error wrapping ErrRequestFailed, so the erasure can
+be run again. The user's export archives are deleted too
+*/
+func (m *Manager) Erase(ctx context.Context, userID string) (Request, error) {
+	request, handlers, err := m.newRequest(ctx, KindErasure, userID)
+
+	if err != nil {
+		return request, err
+	}
+
+	return m.erase(ctx, request, handlers)
+}
+
+/*
+StartExport saves a pending export and runs it in the background,
+returning straight away, such as from an HTTP handler. Check on it with
+Request, or with OnProgress
+*/
+func (m *Manager) StartExport(ctx context.Context, userID string) (Request, error) {
+	return m.start(ctx, KindExport, userID, m.export)
+}
+
+/*
+StartErase saves a pending erasure and runs it in the background. See
+StartExport
+*/
+func (m *Manager) StartErase(ctx context.Context, userID string) (Request, error) {
+	return m.start(ctx, KindErasure, userID, m.erase)
+}
+
+/*
+Wait blocks until every request started in the background has finished
+*/
+func (m *Manager) Wait() {
+	m.running.Wait()
+}
+
+/*
+Request returns a request, with its progress
+*/
+func (m *Manager) Request(ctx context.Context, id string) (Request, error) {
+	return m.config.Requests.Get(ctx, id)
+}
+
+/*
+Requests returns a user's requests, newest first
+*/
+func (m *Manager) Requests(ctx context.Context, userID string) ([]Request, error) {
+	return m.config.Requests.ForUser(ctx, userID)
+}
+
+/*
+DownloadURL returns a signed link to a completed export's archive,
+lasting LinkExpiry. It returns ErrExportNotReady for erasures and for
+exports that haven't completed
+*/
+func (m *Manager) DownloadURL(ctx context.Context, id string) (string, error) {
+	request, err := m.config.Requests.Get(ctx, id)
+
+	if err != nil {
+		return "", err
+	}
+
+	if request.Kind != KindExport || request.Status != StatusCompleted || request.ArchiveKey == "" {
+		return "", ErrExportNotReady
+	}
+
+	if m.config.Store == nil {
+		return "", ErrNoStore
+	}
+
+	return m.config.Store.SignedURL(ctx, request.ArchiveKey, m.config.LinkExpiry)
+}
+
+func (m *Manager) start(ctx context.Context, kind Kind, userID string, run func(context.Context, Request, []Handler) (Request, error)) (Request, error) {
+	request, handlers, err := m.newRequest(ctx, kind, userID)
+
+	if err != nil {
+		return request, err
+	}
+
+	m.running.Add(1)
+
+	go func() {
+		defer m.running.Done()
+
+		/*
+		 * The caller's context usually belongs to an HTTP request that
+		 * ends long before the handlers do
+		 */
+		_, _ = run(context.Background(), copyRequest(request), handlers)
+	}()
+
+	return request, nil
+}
+
+/*
+newRequest saves a pending request with a step for each handler that
+can do kind, and returns those handlers in name order
+*/
+func (m *Manager) newRequest(ctx context.Context, kind Kind, userID string) (Request, []Handler, error) {
+	if userID == "" {
+		return Request{}, nil, ErrMissingUserID
+	}
+
+	if kind == KindExport && m.config.Store == nil {
+		return Request{}, nil, ErrNoStore
+	}
+
+	m.RLock()
+
+	handlers := make([]Handler, 0, len(m.handlers))
+
+	for _, handler := range m.handlers {
+		if (kind == KindExport && handler.Export != nil) || (kind == KindErasure && handler.Erase != nil) {
+			handlers = append(handlers, handler)
+		}
+	}
+
+	m.RUnlock()
+
+	sort.Slice(handlers, func(i, j int) bool {
+		return handlers[i].Name < handlers[j].Name
+	})
+
+	request := Request{
+		CreatedAt: m.config.Clock.Now(),
+		ID:        ids.NewV7().String(),
+		Kind:      kind,
+		Status:    StatusPending,
+		Steps:     make([]Step, 0, len(handlers)),
+		UserID:    userID,
+	}
+
+	for _, handler := range handlers {
+		request.Steps = append(request.Steps, Step{Handler: handler.Name, Status: StatusPending})
+	}
+
+	return request, handlers, m.save(ctx, request)
+}
+
+func (m *Manager) export(ctx context.Context, request Request, handlers []Handler) (Request, error) {
+	request.ArchiveKey = path.Join(m.config.Prefix, url.PathEscape(request.UserID), request.ID+".zip")
+	request.Status = StatusRunning
+	m.saveProgress(ctx, request)
+
+	pr, pw := io.Pipe()
+	stored := make(chan error, 1)
+
+	go func() {
+		_, err := m.config.Store.Put(ctx, request.ArchiveKey, pr, storage.PutOptions{
+			ContentType: "application/zip",
+			Metadata:    map[string]string{"requestID": request.ID},
+		})
+
+		/*
+		 * If the store gives up early, fail the archive's writes
+		 * rather than leave them blocked
+		 */
+		_ = pr.CloseWithError(err)
+		stored <- err
+	}()
+
+	archive := newArchive(pw, request.CreatedAt)
+
+	for index, handler := range handlers {
+		archive.folder = handler.Name
+		m.runStep(ctx, &request, index, func() error {
+			return handler.Export(ctx, request.UserID, archive)
+		})
+	}
+
+	archive.folder = ""
+	err := archive.WriteJSON("manifest.json", manifest{
+		CreatedAt: request.CreatedAt,
+		Files:     append([]string{}, archive.files...),
+		RequestID: request.ID,
+		UserID:    request.UserID,
+	})
+
+	if err == nil {
+		err = archive.zw.Close()
+	}
+
+	if err == nil && request.Error != "" {
+		err = errors.New(request.Error)
+	}
+
+	_ = pw.CloseWithError(err)
+
+	if storeErr := <-stored; err == nil {
+		err = storeErr
+	}
+
+	if err != nil {
+		_ = m.config.Store.Delete(ctx, request.ArchiveKey)
+		request.ArchiveKey = ""
+	}
+
+	return m.finish(ctx, request, err)
+}
+
+func (m *Manager) erase(ctx context.Context, request Request, handlers []Handler) (Request, error) {
+	request.Status = StatusRunning
+	m.saveProgress(ctx, request)
+
+	for index, handler := range handlers {
+		m.runStep(ctx, &request, index, func() error {
+			return handler.Erase(ctx, request.UserID)
+		})
+	}
+
+	var err error
+
+	if request.Error != "" {
+		err = errors.New(request.Error)
+	}
+
+	if m.config.Store != nil {
+		if archiveErr := m.deleteArchives(ctx, request.UserID); archiveErr != nil && err == nil {
+			err = fmt.Errorf("exports: %w", archiveErr)
+		}
+	}
+
+	return m.finish(ctx, request, err)
+}
+
+/*
+deleteArchives deletes every export archive kept for a user
+*/
+func (m *Manager) deleteArchives(ctx context.Context, userID string) error {
+	objects, err := m.config.Store.List(ctx, path.Join(m.config.Prefix, url.PathEscape(userID))+"/")
+
+	if err != nil {
+		return err
+	}
+
+	for _, object := range objects {
+		if err = m.config.Store.Delete(ctx, object.Key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+runStep runs one handler's part of a request, saving its progress
+before and after. The first failure is kept as the request's error
+*/
+func (m *Manager) runStep(ctx context.Context, request *Request, index int, fn func() error) {
+	request.Steps[index].Status = StatusRunning
+	m.saveProgress(ctx, *request)
+
+	if err := fn(); err != nil {
+		request.Steps[index].Error = err.Error()
+		request.Steps[index].Status = StatusFailed
+
+		if request.Error == "" {
+			request.Error = request.Steps[index].Handler + ": " + err.Error()
+		}
+	} else {
+		request.Steps[index].Status = StatusCompleted
+	}
+
+	m.saveProgress(ctx, *request)
+}
+
+func (m *Manager) finish(ctx context.Context, request Request, err error) (Request, error) {
+	completedAt := m.config.Clock.Now()
+	request.CompletedAt = &completedAt
+	request.Status = StatusCompleted
+
+	if err != nil {
+		request.Error = err.Error()
+		request.Status = StatusFailed
+	}
+
+	if saveErr := m.save(ctx, request); saveErr != nil {
+		return request, saveErr
+	}
+
+	if err != nil {
+		return request, fmt.Errorf("%w: %s", ErrRequestFailed, err)
+	}
+
+	return request, nil
+}
+
+/*
+saveProgress saves a running request. A failure to record progress
+shouldn't stop an erasure half way, so errors are left for the final
+save to report
+*/
+func (m *Manager) saveProgress(ctx context.Context, request Request) {
+	_ = m.save(ctx, request)
+}
+
+func (m *Manager) save(ctx context.Context, request Request) error {
+	if err := m.config.Requests.Save(ctx, request); err != nil {
+		return err
+	}
+
+	if m.config.OnProgress != nil {
+		m.config.OnProgress(copyRequest(request))
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package privacy
+
+import (
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/clock"
+	"github.com/ResurgenceIT/kit/v6/storage"
+)
+
+/*
+ManagerConfig configures a Manager.
+
+Clock sets request times and defaults to the system clock. LinkExpiry
+is how long download links last, and defaults to 24 hours. OnProgress,
+when set, is called with a copy of the request each time it is saved,
+such as to email the user once their export is ready. Prefix is the
+storage folder archives are written to, and defaults to
+"privacy-exports". Requests keeps requests and defaults to a
+MemoryRequestStore. Store is where export archives are written, and is
+only needed for exports
+*/
+type ManagerConfig struct {
+	Clock      clock.IClock
+	LinkExpiry time.Duration
+	OnProgress func(request Request)
+	Prefix     string
+	Requests   IRequestStore
+	Store      storage.IStore
+}
@@ -0,0 +1,265 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package privacy_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/privacy"
+	"github.com/ResurgenceIT/kit/v6/storage"
+)
+
+func newManager(t *testing.T, onProgress func(privacy.Request)) (*privacy.Manager, *storage.LocalStore) {
+	store, err := storage.NewLocalStore(storage.LocalStoreConfig{
+		BaseURL:    "https://example.com/files",
+		Root:       t.TempDir(),
+		SigningKey: []byte("signing key"),
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	return privacy.NewManager(privacy.ManagerConfig{OnProgress: onProgress, Store: store}), store
+}
+
+func ordersHandler(orders map[string][]string) privacy.Handler {
+	return privacy.Handler{
+		Erase: func(ctx context.Context, userID string) error {
+			delete(orders, userID)
+			return nil
+		},
+		Export: func(ctx context.Context, userID string, archive *privacy.Archive) error {
+			if err := archive.WriteJSON("orders.json", orders[userID]); err != nil {
+				return err
+			}
+
+			return archive.WriteFile("invoices/1.txt", strings.NewReader("Invoice 1"))
+		},
+		Name: "orders",
+	}
+}
+
+func TestExport(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		progress []string
+	)
+
+	manager, store := newManager(t, func(request privacy.Request) {
+		done, total := request.Progress()
+
+		mutex.Lock()
+		progress = append(progress, fmt.Sprintf("%s:%d/%d", request.Status, done, total))
+		mutex.Unlock()
+	})
+
+	orders := map[string][]string{"user-1": {"order-1", "order-2"}}
+
+	err := manager.Register(
+		ordersHandler(orders),
+		privacy.Handler{
+			Export: func(ctx context.Context, userID string, archive *privacy.Archive) error {
+				return archive.WriteJSON("profile.json", map[string]string{"id": userID})
+			},
+			Name: "profile",
+		},
+		privacy.Handler{Erase: func(ctx context.Context, userID string) error { return nil }, Name: "sessions"},
+	)
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	request, err := manager.Export(context.Background(), "user-1")
+
+	if err != nil || request.Status != privacy.StatusCompleted || len(request.Steps) != 2 {
+		t.Fatalf("Expected a completed export with 2 steps but got %+v and '%v'", request, err)
+	}
+
+	expectedProgress := "pending:0/2,running:0/2,running:0/2,running:1/2,running:1/2,running:2/2,completed:2/2"
+
+	if strings.Join(progress, ",") != expectedProgress {
+		t.Errorf("Expected progress %s but got %s", expectedProgress, strings.Join(progress, ","))
+	}
+
+	reader, object, err := store.Get(context.Background(), request.ArchiveKey)
+
+	if err != nil {
+		t.Fatalf("Expected the archive to be stored but got '%s'", err)
+	}
+
+	b, _ := ioutil.ReadAll(reader)
+	_ = reader.Close()
+
+	if object.ContentType != "application/zip" {
+		t.Errorf("Expected application/zip but got %s", object.ContentType)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+
+	if err != nil {
+		t.Fatalf("Expected a zip archive but got '%s'", err)
+	}
+
+	files := make(map[string]string)
+	names := make([]string, 0, len(archive.File))
+
+	for _, file := range archive.File {
+		r, _ := file.Open()
+		contents, _ := ioutil.ReadAll(r)
+		_ = r.Close()
+
+		files[file.Name] = string(contents)
+		names = append(names, file.Name)
+	}
+
+	expectedNames := "orders/orders.json,orders/invoices/1.txt,profile/profile.json,manifest.json"
+
+	if strings.Join(names, ",") != expectedNames {
+		t.Errorf("Expected files %s but got %s", expectedNames, strings.Join(names, ","))
+	}
+
+	if !strings.Contains(files["orders/orders.json"], `"order-2"`) || !strings.Contains(files["manifest.json"], request.ID) {
+		t.Errorf("Unexpected archive contents %v", files)
+	}
+
+	link, err := manager.DownloadURL(context.Background(), request.ID)
+
+	if err != nil || !strings.HasPrefix(link, "https://example.com/files/privacy-exports/user-1/") {
+		t.Errorf("Expected a signed link but got '%s' and '%v'", link, err)
+	}
+}
+
+func TestExportFailure(t *testing.T) {
+	manager, store := newManager(t, nil)
+
+	_ = manager.Register(ordersHandler(map[string][]string{}), privacy.Handler{
+		Export: func(ctx context.Context, userID string, archive *privacy.Archive) error {
+			return errors.New("database unavailable")
+		},
+		Name: "billing",
+	})
+
+	request, err := manager.Export(context.Background(), "user-1")
+
+	if !errors.Is(err, privacy.ErrRequestFailed) || request.Status != privacy.StatusFailed || request.Error != "billing: database unavailable" {
+		t.Fatalf("Expected the export to fail on billing but got %+v and '%v'", request, err)
+	}
+
+	if request.Steps[0].Status != privacy.StatusFailed || request.Steps[1].Status != privacy.StatusCompleted {
+		t.Errorf("Expected billing to fail and orders to complete but got %+v", request.Steps)
+	}
+
+	if objects, _ := store.List(context.Background(), ""); len(objects) != 0 {
+		t.Errorf("Expected the incomplete archive to be deleted but got %v", objects)
+	}
+
+	if _, err = manager.DownloadURL(context.Background(), request.ID); !errors.Is(err, privacy.ErrExportNotReady) {
+		t.Errorf("Expected ErrExportNotReady but got '%v'", err)
+	}
+}
+
+func TestErase(t *testing.T) {
+	manager, store := newManager(t, nil)
+	orders := map[string][]string{"user-1": {"order-1"}, "user-2": {"order-3"}}
+	failures := 1
+
+	_ = manager.Register(ordersHandler(orders), privacy.Handler{
+		Erase: func(ctx context.Context, userID string) error {
+			if failures > 0 {
+				failures--
+				return errors.New("timed out")
+			}
+
+			return nil
+		},
+		Name: "search",
+	})
+
+	if _, err := manager.Export(context.Background(), "user-1"); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	request, err := manager.Erase(context.Background(), "user-1")
+
+	if !errors.Is(err, privacy.ErrRequestFailed) || request.Steps[1].Error != "timed out" {
+		t.Fatalf("Expected the search eraser to fail but got %+v and '%v'", request, err)
+	}
+
+	if _, ok := orders["user-1"]; ok || len(orders["user-2"]) != 1 {
+		t.Errorf("Expected only user-1's orders to be erased but got %v", orders)
+	}
+
+	if request, err = manager.Erase(context.Background(), "user-1"); err != nil || request.Status != privacy.StatusCompleted {
+		t.Errorf("Expected the retried erasure to complete but got %+v and '%v'", request, err)
+	}
+
+	if objects, _ := store.List(context.Background(), ""); len(objects) != 0 {
+		t.Errorf("Expected the user's exports to be deleted but got %v", objects)
+	}
+
+	requests, _ := manager.Requests(context.Background(), "user-1")
+
+	if len(requests) != 3 || requests[0].ID != request.ID {
+		t.Errorf("Expected 3 requests, newest first, but got %+v", requests)
+	}
+}
+
+func TestStartExport(t *testing.T) {
+	manager, _ := newManager(t, nil)
+	_ = manager.Register(ordersHandler(map[string][]string{"user-1": {"order-1"}}))
+
+	request, err := manager.StartExport(context.Background(), "user-1")
+
+	if err != nil || request.Status != privacy.StatusPending {
+		t.Fatalf("Expected a pending export but got %+v and '%v'", request, err)
+	}
+
+	manager.Wait()
+
+	if request, err = manager.Request(context.Background(), request.ID); err != nil || request.Status != privacy.StatusCompleted {
+		t.Errorf("Expected the export to complete but got %+v and '%v'", request, err)
+	}
+
+	if _, err = manager.Request(context.Background(), "missing"); !errors.Is(err, privacy.ErrRequestNotFound) {
+		t.Errorf("Expected ErrRequestNotFound but got '%v'", err)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	manager := privacy.NewManager(privacy.ManagerConfig{})
+
+	tests := []struct {
+		handler  privacy.Handler
+		expected error
+	}{
+		{handler: ordersHandler(nil), expected: nil},
+		{handler: ordersHandler(nil), expected: privacy.ErrDuplicateHandler},
+		{handler: privacy.Handler{Name: "empty"}, expected: privacy.ErrInvalidHandler},
+	}
+
+	for _, test := range tests {
+		if err := manager.Register(test.handler); !errors.Is(err, test.expected) {
+			t.Errorf("Expected '%v' but got '%v'", test.expected, err)
+		}
+	}
+
+	if _, err := manager.Export(context.Background(), "user-1"); !errors.Is(err, privacy.ErrNoStore) {
+		t.Errorf("Expected ErrNoStore but got '%v'", err)
+	}
+
+	if _, err := manager.Erase(context.Background(), ""); !errors.Is(err, privacy.ErrMissingUserID) {
+		t.Errorf("Expected ErrMissingUserID but got '%v'", err)
+	}
+}
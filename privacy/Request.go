@@ -0,0 +1,154 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package privacy
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+/*
+Kind is what a request asks for
+*/
+type Kind string
+
+const (
+	KindErasure Kind = "erasure"
+	KindExport  Kind = "export"
+)
+
+/*
+Status is where a request, or one handler's part of it, has got to
+*/
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+)
+
+/*
+Step is one handler's part of a request
+*/
+type Step struct {
+	Error   string `json:"error,omitempty"`
+	Handler string `json:"handler"`
+	Status  Status `json:"status"`
+}
+
+/*
+Request is a subject access request: an export of, or erasure of,
+everything held about a user. ArchiveKey is the storage key of a
+completed export's archive. Error is set when the request failed
+*/
+type Request struct {
+	ArchiveKey  string     `json:"archiveKey,omitempty"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	Error       string     `json:"error,omitempty"`
+	ID          string     `json:"id"`
+	Kind        Kind       `json:"kind"`
+	Status      Status     `json:"status"`
+	Steps       []Step     `json:"steps"`
+	UserID      string     `json:"userID"`
+}
+
+/*
+Progress returns how many of the request's steps have finished, whether
+they completed or failed, and how many there are
+*/
+func (r Request) Progress() (done, total int) {
+	for _, step := range r.Steps {
+		if step.Status == StatusCompleted || step.Status == StatusFailed {
+			done++
+		}
+	}
+
+	return done, len(r.Steps)
+}
+
+/*
+IRequestStore keeps requests so their progress can be checked, and so
+there is a record of when each was made and completed. Get returns
+ErrRequestNotFound when there is no request with the ID. ForUser
+returns a user's requests, newest first
+*/
+type IRequestStore interface {
+	ForUser(ctx context.Context, userID string) ([]Request, error)
+	Get(ctx context.Context, id string) (Request, error)
+	Save(ctx context.Context, request Request) error
+}
+
+/*
+MemoryRequestStore keeps requests in memory, for tests and single
+instance tools
+*/
+type MemoryRequestStore struct {
+	sync.RWMutex
+
+	requests map[string]Request
+}
+
+/*
+NewMemoryRequestStore creates an empty in-memory request store
+*/
+func NewMemoryRequestStore() *MemoryRequestStore {
+	return &MemoryRequestStore{
+		requests: make(map[string]Request),
+	}
+}
+
+func (s *MemoryRequestStore) ForUser(ctx context.Context, userID string) ([]Request, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	result := make([]Request, 0, 5)
+
+	for _, request := range s.requests {
+		if request.UserID == userID {
+			result = append(result, copyRequest(request))
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+
+	return result, nil
+}
+
+func (s *MemoryRequestStore) Get(ctx context.Context, id string) (Request, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	request, ok := s.requests[id]
+
+	if !ok {
+		return Request{}, ErrRequestNotFound
+	}
+
+	return copyRequest(request), nil
+}
+
+func (s *MemoryRequestStore) Save(ctx context.Context, request Request) error {
+	s.Lock()
+	s.requests[request.ID] = copyRequest(request)
+	s.Unlock()
+
+	return nil
+}
+
+/*
+copyRequest copies a request's steps, so a stored request isn't changed
+by a running one
+*/
+func copyRequest(request Request) Request {
+	request.Steps = append([]Step{}, request.Steps...)
+	return request
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package randutil
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"regexp"
+	"strings"
+)
+
+const (
+	apiKeySecretLength   = 32
+	apiKeyChecksumLength = 6
+)
+
+var (
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	ErrInvalidPrefix = errors.New("prefixes must be lowercase letters, digits, and underscores")
+)
+
+var prefixPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+/*
+APIKey is a new API key. Show Key to its owner once, and store only
+Hash, then look keys up by HashSecret(key). Hint, such as
+"sk_live_Ab3x", identifies the key in lists without revealing it
+*/
+type APIKey struct {
+	Hash   string
+	Hint   string
+	Key    string
+	Prefix string
+}
+
+/*
+NewAPIKey creates an API key with a prefix, such as "sk_live", that
+says what the key is for. Keys look like
+sk_live_<32 random characters><6 character checksum>. The checksum lets
+ParseAPIKey reject typos and garbage without a database lookup, and the
+prefix lets secret scanners find leaked keys
+*/
+func NewAPIKey(prefix string) (APIKey, error) {
+	if !prefixPattern.MatchString(prefix) {
+		return APIKey{}, ErrInvalidPrefix
+	}
+
+	secret, err := String(apiKeySecretLength, Alphanumeric)
+
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	key := prefix + "_" + secret + checksum(secret)
+
+	return APIKey{
+		Hash:   HashSecret(key),
+		Hint:   prefix + "_" + secret[:4],
+		Key:    key,
+		Prefix: prefix,
+	}, nil
+}
+
+/*
+ParseAPIKey checks an API key's format and checksum, returning its
+prefix
+*/
+func ParseAPIKey(key string) (string, error) {
+	index := strings.LastIndex(key, "_")
+
+	if index < 1 || len(key)-index-1 != apiKeySecretLength+apiKeyChecksumLength {
+		return "", fmt.Errorf("%w: %s", ErrInvalidAPIKey, redact(key))
+	}
+
+	prefix, body := key[:index], key[index+1:]
+	secret, sum := body[:apiKeySecretLength], body[apiKeySecretLength:]
+
+	if !prefixPattern.MatchString(prefix) || strings.Trim(secret, Alphanumeric) != "" || checksum(secret) != sum {
+		return "", fmt.Errorf("%w: %s", ErrInvalidAPIKey, redact(key))
+	}
+
+	return prefix, nil
+}
+
+/*
+HashSecret returns the SHA-256 hash of a secret, such as an API key or a
+password reset token, as hex. Store the hash and look secrets up by it,
+so a leaked database doesn't leak working secrets. Random secrets don't
+need a slow password hash
+*/
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+/*
+CompareSecret returns true when secret matches a hash from HashSecret.
+It takes the same time whatever the secret, so it doesn't reveal how
+close a guess was
+*/
+func CompareSecret(secret, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashSecret(secret)), []byte(strings.ToLower(hash))) == 1
+}
+
+/*
+checksum is the CRC-32 of a secret in base 62
+*/
+func checksum(secret string) string {
+	n := crc32.ChecksumIEEE([]byte(secret))
+	result := make([]byte, apiKeyChecksumLength)
+
+	for index := apiKeyChecksumLength - 1; index >= 0; index-- {
+		result[index] = Alphanumeric[n%62]
+		n /= 62
+	}
+
+	return string(result)
+}
+
+func redact(key string) string {
+	if len(key) <= 12 {
+		return "..."
+	}
+
+	return key[:12] + "..."
+}
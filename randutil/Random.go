@@ -0,0 +1,140 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package randutil
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+const (
+	// Alphanumeric is digits and upper and lower case letters
+	Alphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+	// Digits is 0 to 9
+	Digits = "0123456789"
+
+	// Lowercase is digits and lower case letters
+	Lowercase = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+	// Unambiguous leaves out characters that are easily mistaken for others, such as 0, O, 1, I, and l
+	Unambiguous = "23456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+)
+
+var (
+	ErrInvalidAlphabet = errors.New("alphabets need 2 to 256 distinct characters")
+	ErrInvalidLength   = errors.New("length must be more than zero")
+)
+
+/*
+Bytes returns n random bytes from the operating system's secure random
+source
+*/
+func Bytes(n int) ([]byte, error) {
+	if n <= 0 {
+		return nil, ErrInvalidLength
+	}
+
+	result := make([]byte, n)
+
+	if _, err := rand.Read(result); err != nil {
+		return nil, fmt.Errorf("error reading random bytes: %w", err)
+	}
+
+	return result, nil
+}
+
+/*
+Token returns a URL safe token of n random bytes, base64 encoded without
+padding. 32 bytes, 43 characters, suits magic links and password resets
+*/
+func Token(n int) (string, error) {
+	b, err := Bytes(n)
+
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+/*
+Hex returns n random bytes as 2n hex digits
+*/
+func Hex(n int) (string, error) {
+	b, err := Bytes(n)
+
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+/*
+String returns length characters picked at random from alphabet. Every
+character is equally likely
+*/
+func String(length int, alphabet string) (string, error) {
+	if length <= 0 {
+		return "", ErrInvalidLength
+	}
+
+	characters := []rune(alphabet)
+
+	if err := checkAlphabet(characters); err != nil {
+		return "", err
+	}
+
+	// Bytes past the largest multiple of the alphabet's size are thrown
+	// away, so characters at the start of the alphabet aren't favored
+	limit := 256 - 256%len(characters)
+	result := make([]rune, 0, length)
+	buffer := make([]byte, length+length/4+1)
+
+	for len(result) < length {
+		if _, err := rand.Read(buffer); err != nil {
+			return "", fmt.Errorf("error reading random bytes: %w", err)
+		}
+
+		for _, b := range buffer {
+			if int(b) < limit && len(result) < length {
+				result = append(result, characters[int(b)%len(characters)])
+			}
+		}
+	}
+
+	return string(result), nil
+}
+
+/*
+OTP returns a numeric one time code of digits digits, such as "042917".
+Codes can start with zeros, so keep them as strings
+*/
+func OTP(digits int) (string, error) {
+	return String(digits, Digits)
+}
+
+func checkAlphabet(characters []rune) error {
+	if len(characters) < 2 || len(characters) > 256 {
+		return ErrInvalidAlphabet
+	}
+
+	seen := make(map[rune]bool, len(characters))
+
+	for _, r := range characters {
+		if seen[r] || r == utf8.RuneError {
+			return ErrInvalidAlphabet
+		}
+
+		seen[r] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package randutil_test
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/randutil"
+)
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		name     string
+		length   int
+		alphabet string
+		pattern  string
+		err      error
+	}{
+		{name: "Alphanumeric", length: 24, alphabet: randutil.Alphanumeric, pattern: `^[0-9A-Za-z]{24}$`},
+		{name: "Unambiguous", length: 10, alphabet: randutil.Unambiguous, pattern: `^[2-9A-HJ-NP-Za-km-z]{10}$`},
+		{name: "Unicode", length: 5, alphabet: "αβγ", pattern: `^[αβγ]{5}$`},
+		{name: "No length", length: 0, alphabet: randutil.Digits, err: randutil.ErrInvalidLength},
+		{name: "Short alphabet", length: 5, alphabet: "a", err: randutil.ErrInvalidAlphabet},
+		{name: "Repeated characters", length: 5, alphabet: "abca", err: randutil.ErrInvalidAlphabet},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := randutil.String(test.length, test.alphabet)
+
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Errorf("Expected %v but got %v", test.err, err)
+				}
+
+				return
+			}
+
+			if err != nil || !regexp.MustCompile(test.pattern).MatchString(got) {
+				t.Errorf("Expected a match for %s but got %s, %v", test.pattern, got, err)
+			}
+		})
+	}
+}
+
+func TestDistribution(t *testing.T) {
+	counts := map[rune]int{}
+	s, _ := randutil.String(60000, "abc")
+
+	for _, r := range s {
+		counts[r]++
+	}
+
+	for _, r := range "abc" {
+		if counts[r] < 19000 || counts[r] > 21000 {
+			t.Errorf("Expected about 20000 of %c but got %d", r, counts[r])
+		}
+	}
+}
+
+func TestTokens(t *testing.T) {
+	token, _ := randutil.Token(32)
+	hex, _ := randutil.Hex(16)
+	otp, _ := randutil.OTP(6)
+
+	if !regexp.MustCompile(`^[A-Za-z0-9_-]{43}$`).MatchString(token) {
+		t.Errorf("Expected a 43 character URL safe token but got %s", token)
+	}
+
+	if !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(hex) {
+		t.Errorf("Expected 32 hex digits but got %s", hex)
+	}
+
+	if !regexp.MustCompile(`^[0-9]{6}$`).MatchString(otp) {
+		t.Errorf("Expected 6 digits but got %s", otp)
+	}
+
+	other, _ := randutil.Token(32)
+
+	if other == token {
+		t.Errorf("Expected different tokens")
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	key, err := randutil.NewAPIKey("sk_live")
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err)
+	}
+
+	if !regexp.MustCompile(`^sk_live_[0-9A-Za-z]{38}$`).MatchString(key.Key) || !strings.HasPrefix(key.Key, key.Hint) {
+		t.Errorf("Unexpected key %s with hint %s", key.Key, key.Hint)
+	}
+
+	if prefix, err := randutil.ParseAPIKey(key.Key); err != nil || prefix != "sk_live" {
+		t.Errorf("Expected sk_live but got %s, %v", prefix, err)
+	}
+
+	if !randutil.CompareSecret(key.Key, key.Hash) || randutil.CompareSecret(key.Key+"x", key.Hash) {
+		t.Errorf("Expected the key, and only the key, to match its hash")
+	}
+
+	// Change one character of the secret
+	last := key.Key[10]
+	replacement := "A"
+
+	if last == 'A' {
+		replacement = "B"
+	}
+
+	for _, invalid := range []string{key.Key[:10] + replacement + key.Key[11:], key.Key[:len(key.Key)-1], "sk_live", ""} {
+		if _, err = randutil.ParseAPIKey(invalid); !errors.Is(err, randutil.ErrInvalidAPIKey) {
+			t.Errorf("Expected %s to be invalid", invalid)
+		}
+	}
+
+	if _, err = randutil.NewAPIKey("Bad Prefix"); !errors.Is(err, randutil.ErrInvalidPrefix) {
+		t.Errorf("Expected ErrInvalidPrefix but got %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ratelimit
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/ResurgenceIT/kit/v6/httpserver"
+)
+
+/*
+KeyFunc picks the key a request is limited by. Returning an empty string
+lets the request through without limiting it. serverstats
+ClientIdentifier functions can be used as KeyFuncs
+*/
+type KeyFunc func(r *http.Request) string
+
+/*
+KeyByIP limits requests by the address of the connection the request
+came in on. Forwarded headers are ignored, as any client can set them.
+Behind a proxy, use KeyByTrustedProxyIP or set TrustedProxies instead
+*/
+func KeyByIP(r *http.Request) string {
+	return "ip:" + remoteIP(r)
+}
+
+/*
+KeyByTrustedProxyIP limits requests by client IP address for servers
+behind proxies. trustedProxies are the IP addresses or CIDR ranges of
+those proxies. Forwarded headers are only read when the request comes
+from a trusted proxy. X-Forwarded-For is then walked from the right, and
+the first address that isn't a trusted proxy is the client, since every
+address left of it could have been made up by the client. X-Real-IP is
+used when X-Forwarded-For isn't set
+*/
+func KeyByTrustedProxyIP(trustedProxies ...string) (KeyFunc, error) {
+	networks := make([]*net.IPNet, 0, len(trustedProxies))
+
+	for _, proxy := range trustedProxies {
+		network, err := parseNetwork(proxy)
+
+		if err != nil {
+			return nil, err
+		}
+
+		networks = append(networks, network)
+	}
+
+	isTrusted := func(address string) bool {
+		ip := net.ParseIP(address)
+
+		if ip == nil {
+			return false
+		}
+
+		for _, network := range networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return func(r *http.Request) string {
+		ip := remoteIP(r)
+
+		if !isTrusted(ip) {
+			return "ip:" + ip
+		}
+
+		hops := forwardedFor(r)
+
+		if len(hops) == 0 {
+			if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+				return "ip:" + realIP
+			}
+
+			return "ip:" + ip
+		}
+
+		for index := len(hops) - 1; index >= 0; index-- {
+			if net.ParseIP(hops[index]) == nil {
+				return "ip:" + ip
+			}
+
+			ip = hops[index]
+
+			if !isTrusted(ip) {
+				break
+			}
+		}
+
+		return "ip:" + ip
+	}, nil
+}
+
+/*
+KeyByHeader limits requests by the value of a header, such as X-API-Key.
+Requests without the header aren't limited, so pair this with a limiter
+keyed by IP if anonymous requests need limiting too
+*/
+func KeyByHeader(header string) KeyFunc {
+	return func(r *http.Request) string {
+		if value := r.Header.Get(header); value != "" {
+			return "header:" + value
+		}
+
+		return ""
+	}
+}
+
+/*
+KeyByUserID limits requests by the user ID from the bearer token, as
+parsed by the httpserver identity middleware. Requests without an
+identity are limited by KeyByIP instead
+*/
+func KeyByUserID(r *http.Request) string {
+	if user, ok := httpserver.IdentityFromContext(r.Context()); ok && user.UserID != "" {
+		return "user:" + user.UserID
+	}
+
+	return KeyByIP(r)
+}
+
+func remoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+
+	return r.RemoteAddr
+}
+
+/*
+forwardedFor returns every address in the X-Forwarded-For headers, in
+the order they were added
+*/
+func forwardedFor(r *http.Request) []string {
+	result := make([]string, 0)
+
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(header, ",") {
+			result = append(result, strings.TrimSpace(hop))
+		}
+	}
+
+	return result
+}
+
+func parseNetwork(proxy string) (*net.IPNet, error) {
+	if strings.Contains(proxy, "/") {
+		_, network, err := net.ParseCIDR(proxy)
+
+		if err != nil {
+			return nil, fmt.Errorf("error parsing trusted proxy %q: %w", proxy, err)
+		}
+
+		return network, nil
+	}
+
+	ip := net.ParseIP(proxy)
+
+	if ip == nil {
+		return nil, fmt.Errorf("error parsing trusted proxy %q: not an IP address or CIDR range", proxy)
+	}
+
+	bits := 128
+
+	if ip.To4() != nil {
+		ip = ip.To4()
+		bits = 32
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+/*
+Algorithm selects how a Limiter counts requests
+*/
+type Algorithm int
+
+const (
+	/*
+		TokenBucket refills a bucket of Burst tokens at Limit tokens per
+		Window, and each request takes a token. It allows short bursts
+		while holding the average rate to the limit
+	*/
+	TokenBucket Algorithm = 0
+
+	/*
+		SlidingWindow counts requests in the current and previous fixed
+		windows, weighting the previous window by how much of it still
+		overlaps the sliding window. It avoids the burst at the edge of
+		fixed windows without storing every request
+	*/
+	SlidingWindow Algorithm = 1
+)
+
+func (a Algorithm) String() string {
+	switch a {
+	case TokenBucket:
+		return "tokenBucket"
+	case SlidingWindow:
+		return "slidingWindow"
+	default:
+		return "unknown"
+	}
+}
+
+/*
+ILimiter describes a rate limiter
+*/
+type ILimiter interface {
+	Allow(ctx context.Context, key string) (Result, error)
+}
+
+/*
+Rule is the limit a store enforces for a key
+*/
+type Rule struct {
+	Algorithm Algorithm
+	Burst     int
+	Limit     int
+	Window    time.Duration
+}
+
+/*
+Limiter limits how often each key, such as an IP address, user, or API
+key, can make requests
+*/
+type Limiter struct {
+	config LimiterConfig
+	now    func() time.Time
+	rule   Rule
+}
+
+/*
+NewLimiter creates a new rate limiter. Limit and Window must be positive,
+and TrustedProxies must be IP addresses or CIDR ranges
+*/
+func NewLimiter(config LimiterConfig) (*Limiter, error) {
+	if config.Limit <= 0 || config.Window <= 0 {
+		return nil, fmt.Errorf("error creating rate limiter: Limit and Window must be positive")
+	}
+
+	if config.Burst <= 0 {
+		config.Burst = config.Limit
+	}
+
+	if config.KeyFunc == nil && len(config.TrustedProxies) > 0 {
+		keyFunc, err := KeyByTrustedProxyIP(config.TrustedProxies...)
+
+		if err != nil {
+			return nil, fmt.Errorf("error creating rate limiter: %w", err)
+		}
+
+		config.KeyFunc = keyFunc
+	}
+
+	if config.KeyFunc == nil {
+		config.KeyFunc = KeyByIP
+	}
+
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+
+	return &Limiter{
+		config: config,
+		now:    time.Now,
+		rule: Rule{
+			Algorithm: config.Algorithm,
+			Burst:     config.Burst,
+			Limit:     config.Limit,
+			Window:    config.Window,
+		},
+	}, nil
+}
+
+/*
+Allow records a request for key and reports whether it is allowed
+*/
+func (l *Limiter) Allow(ctx context.Context, key string) (Result, error) {
+	if l.config.Name != "" {
+		key = l.config.Name + ":" + key
+	}
+
+	result, err := l.config.Store.Take(ctx, key, l.rule, l.now())
+
+	if err != nil {
+		return Result{}, fmt.Errorf("error checking rate limit: %w", err)
+	}
+
+	return result, nil
+}
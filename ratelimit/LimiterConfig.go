@@ -0,0 +1,42 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ratelimit
+
+import (
+	"net/http"
+	"time"
+)
+
+/*
+LimiterConfig configures a Limiter. Limit requests are allowed per
+Window for each key. With the TokenBucket algorithm, Burst is how many
+requests can be made at once before the limit kicks in, and defaults to
+Limit.
+
+Name is prepended to keys in the store, so several limiters can share
+one store. Store defaults to a MemoryStore; use redisstore.RateLimitStore
+to share limits across instances.
+
+These are only used by the middleware. KeyFunc picks the key to limit
+by, and defaults to KeyByIP. When TrustedProxies is set it defaults to
+KeyByTrustedProxyIP with those proxies instead. Requests that Skipper returns true for, or
+whose key is empty, aren't limited. OnLimited writes the response when a
+request is limited, and defaults to a 429 with a Retry-After header.
+OnError is called when the store fails, and the request is allowed
+through
+*/
+type LimiterConfig struct {
+	Algorithm      Algorithm
+	Burst          int
+	KeyFunc        KeyFunc
+	Limit          int
+	Name           string
+	OnError        func(r *http.Request, err error)
+	OnLimited      func(w http.ResponseWriter, r *http.Request, result Result)
+	Skipper        func(r *http.Request) bool
+	Store          IStore
+	TrustedProxies []string
+	Window         time.Duration
+}
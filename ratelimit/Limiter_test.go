@@ -0,0 +1,198 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ratelimit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/ratelimit"
+)
+
+func TestMemoryStore(t *testing.T) {
+	start := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		rule     ratelimit.Rule
+		requests []time.Duration
+		expected []bool
+	}{
+		{
+			name:     "Token bucket allows a burst, then refills",
+			rule:     ratelimit.Rule{Algorithm: ratelimit.TokenBucket, Burst: 3, Limit: 1, Window: time.Second},
+			requests: []time.Duration{0, 0, 0, 0, time.Second, time.Second},
+			expected: []bool{true, true, true, false, true, false},
+		},
+		{
+			name:     "Sliding window limits within a window",
+			rule:     ratelimit.Rule{Algorithm: ratelimit.SlidingWindow, Limit: 2, Window: time.Minute},
+			requests: []time.Duration{0, time.Second, time.Second * 2},
+			expected: []bool{true, true, false},
+		},
+		{
+			name:     "Sliding window weights the previous window",
+			rule:     ratelimit.Rule{Algorithm: ratelimit.SlidingWindow, Limit: 2, Window: time.Minute},
+			requests: []time.Duration{time.Second * 50, time.Second * 55, time.Second * 65, time.Second * 95},
+			expected: []bool{true, true, false, true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := ratelimit.NewMemoryStore()
+
+			for index, offset := range test.requests {
+				result, _ := store.Take(context.Background(), "key", test.rule, start.Add(offset))
+
+				if result.Allowed != test.expected[index] {
+					t.Errorf("Request %d: expected allowed to be %v but got %+v", index, test.expected[index], result)
+				}
+
+				if !result.Allowed && result.RetryAfter <= 0 {
+					t.Errorf("Request %d: expected a positive RetryAfter but got %+v", index, result)
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	limiter, err := ratelimit.NewLimiter(ratelimit.LimiterConfig{
+		KeyFunc: ratelimit.KeyByHeader("X-API-Key"),
+		Limit:   2,
+		Window:  time.Minute,
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	handler := limiter.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name      string
+		apiKey    string
+		status    int
+		remaining string
+	}{
+		{name: "First request", apiKey: "abc", status: http.StatusOK, remaining: "1"},
+		{name: "Second request", apiKey: "abc", status: http.StatusOK, remaining: "0"},
+		{name: "Limited", apiKey: "abc", status: http.StatusTooManyRequests, remaining: "0"},
+		{name: "Other key", apiKey: "def", status: http.StatusOK, remaining: "1"},
+		{name: "No key isn't limited", apiKey: "", status: http.StatusOK, remaining: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.Header.Set("X-API-Key", test.apiKey)
+			recorder := httptest.NewRecorder()
+
+			handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != test.status || recorder.Header().Get("RateLimit-Remaining") != test.remaining {
+				t.Errorf("Expected %d with %s remaining but got %d with %s", test.status, test.remaining, recorder.Code, recorder.Header().Get("RateLimit-Remaining"))
+			}
+
+			if test.status == http.StatusTooManyRequests && recorder.Header().Get("Retry-After") == "" {
+				t.Errorf("Expected a Retry-After header")
+			}
+		})
+	}
+}
+
+func TestKeyByIP(t *testing.T) {
+	trusted, err := ratelimit.KeyByTrustedProxyIP("10.0.0.0/8", "192.168.1.5")
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		forwardedFor  []string
+		realIP        string
+		expected      string
+		expectedProxy string
+	}{
+		{name: "No headers", remoteAddr: "203.0.113.7:5000", expected: "ip:203.0.113.7", expectedProxy: "ip:203.0.113.7"},
+		{name: "Spoofed header from a client", remoteAddr: "203.0.113.7:5000", forwardedFor: []string{"1.2.3.4"}, expected: "ip:203.0.113.7", expectedProxy: "ip:203.0.113.7"},
+		{name: "Through a trusted proxy", remoteAddr: "10.0.0.2:5000", forwardedFor: []string{"203.0.113.7"}, expected: "ip:10.0.0.2", expectedProxy: "ip:203.0.113.7"},
+		{name: "Spoofed hop before the client", remoteAddr: "10.0.0.2:5000", forwardedFor: []string{"1.2.3.4, 203.0.113.7"}, expected: "ip:10.0.0.2", expectedProxy: "ip:203.0.113.7"},
+		{name: "Through two trusted proxies", remoteAddr: "10.0.0.2:5000", forwardedFor: []string{"1.2.3.4, 203.0.113.7", "192.168.1.5"}, expected: "ip:10.0.0.2", expectedProxy: "ip:203.0.113.7"},
+		{name: "Only trusted hops", remoteAddr: "10.0.0.2:5000", forwardedFor: []string{"10.0.0.9"}, expected: "ip:10.0.0.2", expectedProxy: "ip:10.0.0.9"},
+		{name: "Garbage hop", remoteAddr: "10.0.0.2:5000", forwardedFor: []string{"not-an-ip"}, expected: "ip:10.0.0.2", expectedProxy: "ip:10.0.0.2"},
+		{name: "X-Real-IP from a trusted proxy", remoteAddr: "192.168.1.5:5000", realIP: "203.0.113.7", expected: "ip:192.168.1.5", expectedProxy: "ip:203.0.113.7"},
+		{name: "X-Real-IP from a client", remoteAddr: "203.0.113.7:5000", realIP: "1.2.3.4", expected: "ip:203.0.113.7", expectedProxy: "ip:203.0.113.7"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.RemoteAddr = test.remoteAddr
+
+			for _, value := range test.forwardedFor {
+				request.Header.Add("X-Forwarded-For", value)
+			}
+
+			if test.realIP != "" {
+				request.Header.Set("X-Real-IP", test.realIP)
+			}
+
+			if actual := ratelimit.KeyByIP(request); actual != test.expected {
+				t.Errorf("Expected KeyByIP to return '%s' but got '%s'", test.expected, actual)
+			}
+
+			if actual := trusted(request); actual != test.expectedProxy {
+				t.Errorf("Expected KeyByTrustedProxyIP to return '%s' but got '%s'", test.expectedProxy, actual)
+			}
+		})
+	}
+}
+
+func TestTrustedProxiesConfig(t *testing.T) {
+	if _, err := ratelimit.NewLimiter(ratelimit.LimiterConfig{Limit: 1, TrustedProxies: []string{"10.0.0.0/33"}, Window: time.Minute}); err == nil {
+		t.Errorf("Expected an error for an invalid trusted proxy")
+	}
+
+	limiter, err := ratelimit.NewLimiter(ratelimit.LimiterConfig{Limit: 1, TrustedProxies: []string{"10.0.0.0/8"}, Window: time.Minute})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	handler := limiter.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		client   string
+		expected int
+	}{
+		{client: "203.0.113.7", expected: http.StatusOK},
+		{client: "203.0.113.7", expected: http.StatusTooManyRequests},
+		{client: "203.0.113.8", expected: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.RemoteAddr = "10.0.0.2:5000"
+		request.Header.Set("X-Forwarded-For", test.client)
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, request)
+
+		if recorder.Code != test.expected {
+			t.Errorf("Expected %d for %s but got %d", test.expected, test.client, recorder.Code)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ratelimit
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+/*
+Middleware limits requests for the Echo framework. Every response gets
+RateLimit-* headers, and limited requests get a 429 Too Many Requests
+*/
+func (l *Limiter) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		result, limited := l.check(ctx.Request())
+
+		if result != nil {
+			result.SetHeaders(ctx.Response().Header())
+		}
+
+		if !limited {
+			return next(ctx)
+		}
+
+		if l.config.OnLimited != nil {
+			l.config.OnLimited(ctx.Response(), ctx.Request(), *result)
+			return nil
+		}
+
+		return echo.NewHTTPError(http.StatusTooManyRequests)
+	}
+}
+
+/*
+HTTPMiddleware limits requests for a plain net/http handler
+*/
+func (l *Limiter) HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result, limited := l.check(r)
+
+		if result != nil {
+			result.SetHeaders(w.Header())
+		}
+
+		if !limited {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if l.config.OnLimited != nil {
+			l.config.OnLimited(w, r, *result)
+			return
+		}
+
+		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+	})
+}
+
+/*
+check runs the limiter for a request. The result is nil when the
+request was skipped or the store failed
+*/
+func (l *Limiter) check(r *http.Request) (*Result, bool) {
+	if l.config.Skipper != nil && l.config.Skipper(r) {
+		return nil, false
+	}
+
+	key := l.config.KeyFunc(r)
+
+	if key == "" {
+		return nil, false
+	}
+
+	result, err := l.Allow(r.Context(), key)
+
+	if err != nil {
+		if l.config.OnError != nil {
+			l.config.OnError(r, err)
+		}
+
+		return nil, false
+	}
+
+	return &result, !result.Allowed
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+/*
+Result is the outcome of a rate limit check. ResetAfter is how long
+until the key is back to its full allowance, and RetryAfter is how long
+a limited caller should wait before trying again
+*/
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration
+	RetryAfter time.Duration
+}
+
+/*
+SetHeaders writes the RateLimit-Limit, RateLimit-Remaining, and
+RateLimit-Reset headers, plus Retry-After when the request isn't
+allowed. Times are in whole seconds, rounded up
+*/
+func (r Result) SetHeaders(header http.Header) {
+	header.Set("RateLimit-Limit", strconv.Itoa(r.Limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(r.Remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(seconds(r.ResetAfter)))
+
+	if !r.Allowed {
+		header.Set("Retry-After", strconv.Itoa(seconds(r.RetryAfter)))
+	}
+}
+
+/*
+TokenBucketResult builds the Result for a token bucket holding tokens
+after a request was taken, or refused. Stores use it so every backend
+reports the same way
+*/
+func TokenBucketResult(rule Rule, tokens float64, allowed bool) Result {
+	perToken := float64(rule.Window) / float64(rule.Limit)
+	result := Result{
+		Allowed:    allowed,
+		Limit:      rule.Limit,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: time.Duration((float64(rule.Burst) - tokens) * perToken),
+	}
+
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) * perToken)
+	}
+
+	return result
+}
+
+/*
+SlidingWindowResult builds the Result for a sliding window from the
+previous and current window counts, after the request was counted if it
+was allowed. elapsed is how far into the current window the request was
+*/
+func SlidingWindowResult(rule Rule, previous, current int, elapsed time.Duration, allowed bool) Result {
+	window := float64(rule.Window)
+	weight := (window - float64(elapsed)) / window
+	estimated := float64(previous)*weight + float64(current)
+
+	result := Result{
+		Allowed:    allowed,
+		Limit:      rule.Limit,
+		Remaining:  maxInt(0, int(math.Floor(float64(rule.Limit)-estimated))),
+		ResetAfter: rule.Window - elapsed,
+	}
+
+	if allowed {
+		return result
+	}
+
+	if current+1 > rule.Limit || previous == 0 {
+		result.RetryAfter = rule.Window - elapsed
+		return result
+	}
+
+	/*
+	 * The previous window's share shrinks as time passes. Wait until it
+	 * has shrunk enough to leave room for one more request
+	 */
+	needed := window - float64(rule.Limit-current-1)*window/float64(previous)
+	result.RetryAfter = time.Duration(math.Max(0, needed-float64(elapsed)))
+
+	return result
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
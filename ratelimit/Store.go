@@ -0,0 +1,131 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+/*
+IStore records requests and applies a Rule to them. Take must be atomic
+for a key, even when several instances share the store
+*/
+type IStore interface {
+	Take(ctx context.Context, key string, rule Rule, now time.Time) (Result, error)
+}
+
+/*
+MemoryStore keeps rate limit state in memory. Limits only apply within a
+single instance; use redisstore.RateLimitStore when several instances
+share the load. Keys that haven't been seen for a while are removed as
+the store is used
+*/
+type MemoryStore struct {
+	sync.Mutex
+
+	buckets   map[string]*memoryState
+	lastSweep time.Time
+}
+
+type memoryState struct {
+	current     int
+	expiresAt   time.Time
+	previous    int
+	tokens      float64
+	updatedAt   time.Time
+	windowIndex int64
+}
+
+/*
+NewMemoryStore creates an in-memory rate limit store
+*/
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*memoryState),
+	}
+}
+
+/*
+Take records a request for key
+*/
+func (s *MemoryStore) Take(ctx context.Context, key string, rule Rule, now time.Time) (Result, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.sweep(now, rule.Window)
+
+	state, ok := s.buckets[key]
+
+	if !ok {
+		state = &memoryState{tokens: float64(rule.Burst), updatedAt: now}
+		s.buckets[key] = state
+	}
+
+	state.expiresAt = now.Add(rule.Window * 2)
+
+	if rule.Algorithm == SlidingWindow {
+		return s.slidingWindow(state, rule, now), nil
+	}
+
+	return s.tokenBucket(state, rule, now), nil
+}
+
+func (s *MemoryStore) tokenBucket(state *memoryState, rule Rule, now time.Time) Result {
+	elapsed := math.Max(0, float64(now.Sub(state.updatedAt)))
+	state.tokens = math.Min(float64(rule.Burst), state.tokens+elapsed*float64(rule.Limit)/float64(rule.Window))
+	state.updatedAt = now
+
+	allowed := state.tokens >= 1
+
+	if allowed {
+		state.tokens--
+	}
+
+	return TokenBucketResult(rule, state.tokens, allowed)
+}
+
+func (s *MemoryStore) slidingWindow(state *memoryState, rule Rule, now time.Time) Result {
+	index := now.UnixNano() / int64(rule.Window)
+	elapsed := time.Duration(now.UnixNano() - index*int64(rule.Window))
+
+	switch {
+	case index == state.windowIndex+1:
+		state.previous, state.current = state.current, 0
+	case index != state.windowIndex:
+		state.previous, state.current = 0, 0
+	}
+
+	state.windowIndex = index
+
+	estimated := float64(state.previous)*float64(rule.Window-elapsed)/float64(rule.Window) + float64(state.current)
+	allowed := estimated+1 <= float64(rule.Limit)
+
+	if allowed {
+		state.current++
+	}
+
+	return SlidingWindowResult(rule, state.previous, state.current, elapsed, allowed)
+}
+
+/*
+sweep removes expired keys at most once per window. The caller must hold
+a lock
+*/
+func (s *MemoryStore) sweep(now time.Time, window time.Duration) {
+	if now.Sub(s.lastSweep) < window {
+		return
+	}
+
+	s.lastSweep = now
+
+	for key, state := range s.buckets {
+		if now.After(state.expiresAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package recovery
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/apierror"
+	"github.com/ResurgenceIT/kit/v6/requestid"
+	"github.com/labstack/echo/v4"
+)
+
+/*
+IPanicRecorder records recovered panics. serverstats.ServerStats
+implements it
+*/
+type IPanicRecorder interface {
+	RecordPanic(request *http.Request, path string, recovered interface{})
+}
+
+/*
+IReporter sends panics somewhere they will be noticed, such as an error
+tracker
+*/
+type IReporter interface {
+	ReportPanic(report PanicReport)
+}
+
+/*
+ReporterFunc adapts a function to IReporter
+*/
+type ReporterFunc func(report PanicReport)
+
+/*
+ReportPanic calls f
+*/
+func (f ReporterFunc) ReportPanic(report PanicReport) {
+	f(report)
+}
+
+/*
+PanicReport describes a recovered panic. Path is the route the request
+matched, or its URL path for net/http. Value is what was passed to
+panic, and Stack the stack of the goroutine that panicked
+*/
+type PanicReport struct {
+	Path      string
+	Request   *http.Request
+	RequestID string
+	Stack     []byte
+	Time      time.Time
+	Value     interface{}
+}
+
+/*
+Recoverer is middleware that turns panics in handlers into 500
+responses in the problem details format, logging, counting, and
+reporting each one. Panics with http.ErrAbortHandler, which abort a
+response on purpose, are passed on
+*/
+type Recoverer struct {
+	config RecovererConfig
+}
+
+/*
+NewRecoverer creates panic recovery middleware
+
+	recoverer := recovery.NewRecoverer(recovery.RecovererConfig{
+		Logger:   logger,
+		Recorder: serverStats,
+		Reporter: sentryReporter,
+	})
+
+	e.Use(serverStats.Middleware, recoverer.Middleware)
+*/
+func NewRecoverer(config RecovererConfig) *Recoverer {
+	return &Recoverer{
+		config: config,
+	}
+}
+
+/*
+Middleware recovers panics in Echo handlers
+*/
+func (rc *Recoverer) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) (err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				rc.recovered(ctx.Response(), ctx.Request(), ctx.Path(), recovered, ctx.Response().Committed)
+				err = nil
+			}
+		}()
+
+		return next(ctx)
+	}
+}
+
+/*
+HTTPMiddleware recovers panics in net/http handlers
+*/
+func (rc *Recoverer) HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tracker := &headerTracker{ResponseWriter: w}
+
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				rc.recovered(w, r, r.URL.Path, recovered, tracker.wroteHeader)
+			}
+		}()
+
+		next.ServeHTTP(tracker, r)
+	})
+}
+
+/*
+recovered handles a panic. It must be called from the deferred function
+that recovered it, so the stack is the panicking goroutine's
+*/
+func (rc *Recoverer) recovered(w http.ResponseWriter, r *http.Request, path string, recovered interface{}, committed bool) {
+	if recovered == http.ErrAbortHandler {
+		panic(recovered)
+	}
+
+	report := PanicReport{
+		Path:      path,
+		Request:   r,
+		RequestID: requestid.FromContext(r.Context()),
+		Stack:     debug.Stack(),
+		Time:      time.Now().UTC(),
+		Value:     recovered,
+	}
+
+	if rc.config.Logger != nil {
+		requestid.Logger(r.Context(), rc.config.Logger).
+			WithField("path", path).
+			WithField("stack", string(report.Stack)).
+			Error(fmt.Sprintf("panic: %v", recovered))
+	}
+
+	if rc.config.Recorder != nil {
+		rc.config.Recorder.RecordPanic(r, path, recovered)
+	}
+
+	if !committed {
+		_ = apierror.Write(w, r, apierror.Internal(fmt.Errorf("panic: %v", recovered)).WithInstance(r.URL.Path))
+	}
+
+	if rc.config.Reporter != nil {
+		rc.config.Reporter.ReportPanic(report)
+	}
+}
+
+/*
+headerTracker notes whether a handler has written its response header,
+after which a 500 can no longer be sent
+*/
+type headerTracker struct {
+	http.ResponseWriter
+
+	wroteHeader bool
+}
+
+func (t *headerTracker) WriteHeader(status int) {
+	t.wroteHeader = true
+	t.ResponseWriter.WriteHeader(status)
+}
+
+func (t *headerTracker) Write(b []byte) (int, error) {
+	t.wroteHeader = true
+	return t.ResponseWriter.Write(b)
+}
+
+func (t *headerTracker) Flush() {
+	if flusher, ok := t.ResponseWriter.(http.Flusher); ok {
+		t.wroteHeader = true
+		flusher.Flush()
+	}
+}
+
+/*
+Hijack hands the connection to the handler, for websockets
+*/
+func (t *headerTracker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := t.ResponseWriter.(http.Hijacker)
+
+	if !ok {
+		return nil, nil, fmt.Errorf("%T does not support hijacking", t.ResponseWriter)
+	}
+
+	t.wroteHeader = true
+	return hijacker.Hijack()
+}
+
+/*
+Unwrap returns the wrapped ResponseWriter, for http.ResponseController
+*/
+func (t *headerTracker) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
@@ -0,0 +1,24 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package recovery
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+/*
+RecovererConfig configures a Recoverer.
+
+Logger, when set, logs each panic with its stack, such as a logger from
+logging.NewFireplaceLogger. Reporter, when set, is sent each panic, for
+error trackers like Sentry. Recorder, when set, counts each panic. Pass
+a ServerStats when the recoverer runs inside its middleware, which then
+only sees the 500 response
+*/
+type RecovererConfig struct {
+	Logger   logrus.FieldLogger
+	Recorder IPanicRecorder
+	Reporter IReporter
+}
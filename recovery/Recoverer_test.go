@@ -0,0 +1,116 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package recovery_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/apierror"
+	"github.com/ResurgenceIT/kit/v6/recovery"
+	"github.com/labstack/echo/v4"
+)
+
+type panicRecorder struct {
+	paths []string
+}
+
+func (r *panicRecorder) RecordPanic(request *http.Request, path string, recovered interface{}) {
+	r.paths = append(r.paths, path)
+}
+
+func TestRecoverer(t *testing.T) {
+	recorder := &panicRecorder{}
+	reports := []recovery.PanicReport{}
+
+	recoverer := recovery.NewRecoverer(recovery.RecovererConfig{
+		Recorder: recorder,
+		Reporter: recovery.ReporterFunc(func(report recovery.PanicReport) {
+			reports = append(reports, report)
+		}),
+	})
+
+	e := echo.New()
+	e.Use(recoverer.Middleware)
+	e.GET("/orders/:id", func(ctx echo.Context) error {
+		panic("secret=hunter2")
+	})
+
+	handler := recoverer.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/committed" {
+			w.WriteHeader(http.StatusAccepted)
+		}
+
+		panic("boom")
+	}))
+
+	tests := []struct {
+		name           string
+		handler        http.Handler
+		path           string
+		expectedPath   string
+		expectedStatus int
+	}{
+		{name: "Echo", handler: e, path: "/orders/1", expectedPath: "/orders/:id", expectedStatus: http.StatusInternalServerError},
+		{name: "net/http", handler: handler, path: "/reports", expectedPath: "/reports", expectedStatus: http.StatusInternalServerError},
+		{name: "Already committed", handler: handler, path: "/committed", expectedPath: "/committed", expectedStatus: http.StatusAccepted},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder.paths = nil
+			reports = nil
+
+			response := httptest.NewRecorder()
+			test.handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+			if response.Code != test.expectedStatus {
+				t.Errorf("Expected status %d but got %d", test.expectedStatus, response.Code)
+			}
+
+			if test.expectedStatus == http.StatusInternalServerError {
+				if response.Header().Get("Content-Type") != apierror.ContentType {
+					t.Errorf("Expected a problem but got '%s'", response.Header().Get("Content-Type"))
+				}
+
+				if strings.Contains(response.Body.String(), "hunter2") || strings.Contains(response.Body.String(), "boom") {
+					t.Errorf("Expected the panic value to be hidden but got '%s'", response.Body.String())
+				}
+			}
+
+			if len(recorder.paths) != 1 || recorder.paths[0] != test.expectedPath {
+				t.Errorf("Expected one panic recorded for %s but got %v", test.expectedPath, recorder.paths)
+			}
+
+			if len(reports) != 1 || reports[0].Path != test.expectedPath || len(reports[0].Stack) == 0 {
+				t.Fatalf("Expected one report with a stack but got %v", reports)
+			}
+		})
+	}
+}
+
+func TestRecovererPassesOnAbort(t *testing.T) {
+	reported := false
+
+	handler := recovery.NewRecoverer(recovery.RecovererConfig{
+		Reporter: recovery.ReporterFunc(func(report recovery.PanicReport) { reported = true }),
+	}).HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Errorf("Expected http.ErrAbortHandler to be passed on but got %v", recovered)
+		}
+
+		if reported {
+			t.Errorf("Expected an aborted handler not to be reported")
+		}
+	}()
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package redact
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+Redacted replaces values that are hidden completely
+*/
+const Redacted = "********"
+
+/*
+MaskFunc hides part or all of a value
+*/
+type MaskFunc func(value string) string
+
+/*
+Full hides the whole value. Empty values stay empty, so it is still
+clear whether a value was set
+*/
+func Full(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	return Redacted
+}
+
+/*
+Email keeps the first letter of the mailbox and the domain, so
+"bob@example.com" becomes "b**@example.com". Values that aren't email
+addresses are hidden completely
+*/
+func Email(value string) string {
+	at := strings.LastIndex(value, "@")
+
+	if at < 1 || at == len(value)-1 {
+		return Full(value)
+	}
+
+	first, size := utf8.DecodeRuneInString(value)
+	return string(first) + strings.Repeat("*", utf8.RuneCountInString(value[size:at])) + value[at:]
+}
+
+/*
+Last4 keeps the last four characters, so a card number becomes
+"************1111". Values of four characters or fewer are hidden
+completely
+*/
+func Last4(value string) string {
+	runes := []rune(value)
+
+	if len(runes) <= 4 {
+		return Full(value)
+	}
+
+	return strings.Repeat("*", len(runes)-4) + string(runes[len(runes)-4:])
+}
+
+/*
+Initial keeps the first letter of each word, so "Bob Smith" becomes
+"B** S****"
+*/
+func Initial(value string) string {
+	words := strings.Fields(value)
+
+	for index, word := range words {
+		first, size := utf8.DecodeRuneInString(word)
+		words[index] = string(first) + strings.Repeat("*", utf8.RuneCountInString(word[size:]))
+	}
+
+	return strings.Join(words, " ")
+}
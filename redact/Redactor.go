@@ -0,0 +1,338 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package redact
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/ResurgenceIT/kit/v6/collections"
+)
+
+var (
+	defaultRedactor = NewRedactor(RedactorConfig{})
+	jsonMarshaler   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	keySeparators   = strings.NewReplacer("-", "", "_", "")
+	textMarshaler   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+/*
+Redactor hides personal data and secrets in values before they are
+logged or stored, such as in audit events. Struct fields are masked
+according to their mask tag, and fields and map keys with sensitive
+names, such as password, are hidden whatever their tag
+*/
+type Redactor struct {
+	sync.RWMutex
+
+	config     RedactorConfig
+	keyPattern *regexp.Regexp
+	keys       map[string]struct{}
+	masks      map[string]MaskFunc
+}
+
+/*
+NewRedactor creates a redactor
+*/
+func NewRedactor(config RedactorConfig) *Redactor {
+	if config.Keys == nil {
+		config.Keys = DefaultKeys
+	}
+
+	if config.TagName == "" {
+		config.TagName = "mask"
+	}
+
+	result := &Redactor{
+		config: config,
+		keys:   make(map[string]struct{}, len(config.Keys)),
+		masks: map[string]MaskFunc{
+			"email":   Email,
+			"full":    Full,
+			"initial": Initial,
+			"last4":   Last4,
+		},
+	}
+
+	alternatives := make([]string, 0, len(config.Keys))
+
+	for _, key := range config.Keys {
+		key = normalizeKey(key)
+		result.keys[key] = struct{}{}
+		alternatives = append(alternatives, keyExpression(key))
+	}
+
+	for name, mask := range config.Masks {
+		result.masks[name] = mask
+	}
+
+	if len(alternatives) > 0 {
+		result.keyPattern = regexp.MustCompile(`(?i)\b(` + strings.Join(alternatives, "|") + `)("?\s*[:=]\s*"?)([^\s"&,;]+)`)
+	}
+
+	return result
+}
+
+/*
+Register adds a mask for the mask tag, or replaces a built in one
+*/
+func (r *Redactor) Register(name string, mask MaskFunc) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.masks[name] = mask
+}
+
+/*
+SensitiveKey reports whether values under key are always hidden
+*/
+func (r *Redactor) SensitiveKey(key string) bool {
+	_, ok := r.keys[normalizeKey(key)]
+	return ok
+}
+
+/*
+Marshal writes v to JSON with its personal data and secrets hidden.
+Struct fields keep their order and json tag names
+*/
+func (r *Redactor) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(r.Value(v))
+}
+
+/*
+Value returns a copy of v, ready to marshal to JSON, with its personal
+data and secrets hidden. Structs become ordered maps of their fields,
+using their json tags. Fields tagged mask:"omit" are left out, and
+fields with other masks become masked strings. Maps have the values of
+sensitive keys hidden. Values with their own JSON or text marshaling,
+such as times, are kept as they are
+*/
+func (r *Redactor) Value(v interface{}) interface{} {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.value(reflect.ValueOf(v))
+}
+
+func (r *Redactor) value(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	if v.Type().Implements(jsonMarshaler) || v.Type().Implements(textMarshaler) {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			return nil
+		}
+
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+
+		return r.value(v.Elem())
+
+	case reflect.Struct:
+		result := collections.NewOrderedMap()
+		r.fields(v, result)
+
+		return result
+
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+
+		result := make(map[string]interface{}, v.Len())
+		iterator := v.MapRange()
+
+		for iterator.Next() {
+			key := fmt.Sprint(iterator.Key().Interface())
+
+			if r.SensitiveKey(key) {
+				result[key] = Full(display(iterator.Value()))
+				continue
+			}
+
+			result[key] = r.value(iterator.Value())
+		}
+
+		return result
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && (v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8) {
+			return v.Interface()
+		}
+
+		result := make([]interface{}, v.Len())
+
+		for index := range result {
+			result[index] = r.value(v.Index(index))
+		}
+
+		return result
+
+	default:
+		return v.Interface()
+	}
+}
+
+/*
+fields adds the exported fields of a struct to result. Embedded structs
+without a json name have their fields added as if they were the outer
+struct's, as encoding/json does
+*/
+func (r *Redactor) fields(v reflect.Value, result *collections.OrderedMap) {
+	t := v.Type()
+
+	for index := 0; index < t.NumField(); index++ {
+		field := t.Field(index)
+		value := v.Field(index)
+		name, options := parseTag(field.Tag.Get("json"))
+
+		if name == "-" && options == "" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := value
+
+			for embedded.Kind() == reflect.Ptr && !embedded.IsNil() {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				r.fields(embedded, result)
+				continue
+			}
+
+			if embedded.Kind() == reflect.Ptr && embedded.Type().Elem().Kind() == reflect.Struct {
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if strings.Contains(","+options+",", ",omitempty,") && value.IsZero() {
+			continue
+		}
+
+		mask := field.Tag.Get(r.config.TagName)
+
+		switch {
+		case mask == "omit":
+			continue
+
+		case r.SensitiveKey(name) || r.SensitiveKey(field.Name):
+			result.Set(name, Full(display(value)))
+
+		case mask != "":
+			if isNil(value) {
+				result.Set(name, nil)
+				continue
+			}
+
+			fn, ok := r.masks[mask]
+
+			if !ok {
+				fn = Full
+			}
+
+			result.Set(name, fn(display(value)))
+
+		default:
+			result.Set(name, r.value(value))
+		}
+	}
+}
+
+/*
+display formats a value for masking, following pointers
+*/
+func display(v reflect.Value) string {
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	if !v.IsValid() || isNil(v) {
+		return ""
+	}
+
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+
+	return fmt.Sprint(v.Interface())
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+func parseTag(tag string) (string, string) {
+	if index := strings.Index(tag, ","); index >= 0 {
+		return tag[:index], tag[index+1:]
+	}
+
+	return tag, ""
+}
+
+func normalizeKey(key string) string {
+	return strings.ToLower(keySeparators.Replace(key))
+}
+
+/*
+keyExpression matches key with optional - or _ between its letters, so
+"apikey" matches "api_key" and "API-Key"
+*/
+func keyExpression(key string) string {
+	letters := make([]string, 0, len(key))
+
+	for _, letter := range key {
+		letters = append(letters, regexp.QuoteMeta(string(letter)))
+	}
+
+	return strings.Join(letters, "[-_]?")
+}
+
+/*
+Marshal writes v to JSON with the default redactor
+*/
+func Marshal(v interface{}) ([]byte, error) {
+	return defaultRedactor.Marshal(v)
+}
+
+/*
+Value returns a redacted copy of v with the default redactor
+*/
+func Value(v interface{}) interface{} {
+	return defaultRedactor.Value(v)
+}
+
+/*
+Register adds a mask to the default redactor
+*/
+func Register(name string, mask MaskFunc) {
+	defaultRedactor.Register(name, mask)
+}
@@ -0,0 +1,39 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package redact
+
+/*
+DefaultKeys are field and map key names whose values are always hidden.
+Keys are matched ignoring case, "-", and "_", so "api_key" and "API-Key"
+match "apikey"
+*/
+var DefaultKeys = []string{
+	"accesstoken",
+	"apikey",
+	"authorization",
+	"clientsecret",
+	"cookie",
+	"creditcard",
+	"cvv",
+	"password",
+	"refreshtoken",
+	"secret",
+	"ssn",
+	"token",
+}
+
+/*
+RedactorConfig configures a Redactor.
+
+Keys are field and map key names whose values are always hidden, and
+default to DefaultKeys. Masks adds or replaces masks for the mask tag,
+alongside the built in full, email, last4, and initial. TagName is the
+struct tag naming a field's mask, and defaults to "mask"
+*/
+type RedactorConfig struct {
+	Keys    []string
+	Masks   map[string]MaskFunc
+	TagName string
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package redact_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/redact"
+)
+
+func TestMasks(t *testing.T) {
+	tests := []struct {
+		mask     redact.MaskFunc
+		value    string
+		expected string
+	}{
+		{mask: redact.Email, value: "bob@example.com", expected: "b**@example.com"},
+		{mask: redact.Email, value: "not-an-email", expected: redact.Redacted},
+		{mask: redact.Last4, value: "4111111111111111", expected: "************1111"},
+		{mask: redact.Last4, value: "1234", expected: redact.Redacted},
+		{mask: redact.Initial, value: "Bob Smith", expected: "B** S****"},
+		{mask: redact.Full, value: "", expected: ""},
+	}
+
+	for _, test := range tests {
+		if actual := test.mask(test.value); actual != test.expected {
+			t.Errorf("Expected %s for %s but got %s", test.expected, test.value, actual)
+		}
+	}
+}
+
+type Audit struct {
+	At time.Time `json:"at"`
+}
+
+type payment struct {
+	Audit
+
+	APIKey   string            `json:"api_key"`
+	Card     string            `json:"card" mask:"last4"`
+	Email    *string           `json:"email" mask:"email"`
+	Headers  map[string]string `json:"headers"`
+	Internal string            `json:"-"`
+	Name     string            `json:"name" mask:"initial"`
+	Notes    string            `json:"notes,omitempty"`
+	Payer    *payer            `json:"payer"`
+	SSN      string            `mask:"omit"`
+	Zip      string            `json:"zip" mask:"zip"`
+}
+
+type payer struct {
+	Phone string `json:"phone" mask:"last4"`
+}
+
+func TestMarshal(t *testing.T) {
+	email := "bob@example.com"
+	redactor := redact.NewRedactor(redact.RedactorConfig{
+		Masks: map[string]redact.MaskFunc{"zip": func(value string) string { return value[:3] + "**" }},
+	})
+
+	b, err := redactor.Marshal(payment{
+		Audit:    Audit{At: time.Date(2021, 6, 1, 9, 0, 0, 0, time.UTC)},
+		APIKey:   "sk_live_123",
+		Card:     "4111111111111111",
+		Email:    &email,
+		Headers:  map[string]string{"Authorization": "Bearer abc", "Accept": "application/json"},
+		Internal: "x",
+		Name:     "Bob Smith",
+		Payer:    &payer{Phone: "5551234567"},
+		SSN:      "123-45-6789",
+		Zip:      "90210",
+	})
+
+	expected := `{"at":"2021-06-01T09:00:00Z","api_key":"********","card":"************1111","email":"b**@example.com",` +
+		`"headers":{"Accept":"application/json","Authorization":"********"},"name":"B** S****","payer":{"phone":"******4567"},"zip":"902**"}`
+
+	if err != nil || string(b) != expected {
+		t.Errorf("Expected %s but got %s, %v", expected, b, err)
+	}
+}
+
+func TestString(t *testing.T) {
+	text := redact.String(`login failed for bob@example.com: password=hunter2, {"api_key": "sk_123"} card 4242-4242-4242-4242 order 1234567890123 Authorization: Basic dXNlcjpwYXNz`)
+
+	for _, secret := range []string{"bob@", "hunter2", "sk_123", "4242-4242", "dXNlcjpwYXNz"} {
+		if strings.Contains(text, secret) {
+			t.Errorf("Expected %s to be redacted but got %s", secret, text)
+		}
+	}
+
+	if !strings.Contains(text, "order 1234567890123") || !strings.Contains(text, "************4242") {
+		t.Errorf("Expected only numbers passing the Luhn check to be masked but got %s", text)
+	}
+}
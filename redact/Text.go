@@ -0,0 +1,79 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package redact
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	authorizationRegex = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]+`)
+	cardRegex          = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	emailRegex         = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+)
+
+/*
+String hides personal data and secrets in free text, such as log
+messages and error strings. It masks email addresses, card numbers that
+pass the Luhn check, bearer and basic credentials, and the values in
+key=value or "key": "value" pairs whose key is sensitive
+*/
+func (r *Redactor) String(text string) string {
+	text = authorizationRegex.ReplaceAllStringFunc(text, func(match string) string {
+		return strings.Fields(match)[0] + " " + Redacted
+	})
+
+	if r.keyPattern != nil {
+		text = r.keyPattern.ReplaceAllString(text, "${1}${2}"+Redacted)
+	}
+
+	text = emailRegex.ReplaceAllStringFunc(text, Email)
+
+	return cardRegex.ReplaceAllStringFunc(text, func(match string) string {
+		digits := strings.Map(func(r rune) rune {
+			if unicode.IsDigit(r) {
+				return r
+			}
+
+			return -1
+		}, match)
+
+		if !luhn(digits) {
+			return match
+		}
+
+		return Last4(digits)
+	})
+}
+
+/*
+String hides personal data and secrets in free text with the default
+redactor
+*/
+func String(text string) string {
+	return defaultRedactor.String(text)
+}
+
+func luhn(digits string) bool {
+	sum := 0
+	double := false
+
+	for index := len(digits) - 1; index >= 0; index-- {
+		digit := int(digits[index] - '0')
+
+		if double {
+			if digit *= 2; digit > 9 {
+				digit -= 9
+			}
+		}
+
+		sum += digit
+		double = !double
+	}
+
+	return sum%10 == 0
+}
@@ -0,0 +1,415 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/cache"
+	"github.com/go-redis/redis/v8"
+)
+
+/*
+ErrNoNamespace is returned by Clear and Len when the cache has no
+namespace, as they would otherwise touch every key on the server
+*/
+var ErrNoNamespace = errors.New("a namespace is required to clear or count cache keys")
+
+/*
+Cache is a Redis-backed cache that can be shared by every instance of a
+service. It implements cache.ICache, so it can stand in for an
+in-memory cache.Cache.
+
+Values read through the cache.ICache methods are decoded without knowing
+their type, so structs come back as maps. Use GetValue and LoadValue to
+decode into a specific type
+*/
+type Cache struct {
+	sync.Mutex
+
+	calls  map[string]*loadCall
+	client redis.UniversalClient
+	config CacheConfig
+	stats  cache.Stats
+}
+
+type loadCall struct {
+	data []byte
+	done chan struct{}
+	err  error
+}
+
+/*
+NewCache creates a cache backed by a Redis client from NewClient
+*/
+func NewCache(client redis.UniversalClient, config CacheConfig) *Cache {
+	if config.Codec == nil {
+		config.Codec = JSONCodec
+	}
+
+	if config.Timeout <= 0 {
+		config.Timeout = time.Second
+	}
+
+	return &Cache{
+		calls:  make(map[string]*loadCall),
+		client: client,
+		config: config,
+	}
+}
+
+/*
+Key returns the Redis key for a cache key, with the namespace prepended
+*/
+func (c *Cache) Key(key string) string {
+	if c.config.Namespace == "" {
+		return key
+	}
+
+	return c.config.Namespace + ":" + key
+}
+
+/*
+GetValue decodes the value stored under key into dest. The first return
+value is false when there is no entry
+*/
+func (c *Cache) GetValue(ctx context.Context, key string, dest interface{}) (bool, error) {
+	data, err := c.client.Get(ctx, c.Key(key)).Bytes()
+
+	if errors.Is(err, redis.Nil) {
+		c.count(&c.stats.Misses)
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("error reading '%s' from Redis: %w", key, err)
+	}
+
+	c.count(&c.stats.Hits)
+
+	if err = c.config.Codec.Unmarshal(data, dest); err != nil {
+		return false, fmt.Errorf("error decoding '%s': %w", key, err)
+	}
+
+	return true, nil
+}
+
+/*
+SetValue encodes and stores a value that expires after ttl. A ttl of
+zero or less means the entry doesn't expire
+*/
+func (c *Cache) SetValue(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := c.config.Codec.Marshal(value)
+
+	if err != nil {
+		return fmt.Errorf("error encoding '%s': %w", key, err)
+	}
+
+	return c.setData(ctx, key, data, ttl)
+}
+
+/*
+DeleteValue removes an entry
+*/
+func (c *Cache) DeleteValue(ctx context.Context, key string) error {
+	if err := c.client.Del(ctx, c.Key(key)).Err(); err != nil {
+		return fmt.Errorf("error deleting '%s' from Redis: %w", key, err)
+	}
+
+	return nil
+}
+
+/*
+LoadValue decodes the value stored under key into dest, calling loader
+and storing its result with the DefaultTTL when there is no entry.
+Concurrent calls for the same key within this process share a single
+call to loader. Errors from loader are returned and not cached
+*/
+func (c *Cache) LoadValue(ctx context.Context, key string, dest interface{}, loader cache.LoaderFunc) error {
+	found, err := c.GetValue(ctx, key, dest)
+
+	if err != nil || found {
+		return err
+	}
+
+	c.Lock()
+	call, inFlight := c.calls[key]
+
+	if !inFlight {
+		call = &loadCall{done: make(chan struct{})}
+		c.calls[key] = call
+		c.stats.Loads++
+	}
+
+	c.Unlock()
+
+	if !inFlight {
+		c.load(ctx, key, call, loader)
+	} else {
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if call.err != nil {
+		return call.err
+	}
+
+	if err = c.config.Codec.Unmarshal(call.data, dest); err != nil {
+		return fmt.Errorf("error decoding '%s': %w", key, err)
+	}
+
+	return nil
+}
+
+/*
+Get returns the value stored under key, decoded without knowing its type
+*/
+func (c *Cache) Get(key string) (interface{}, bool) {
+	var value interface{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.Timeout)
+	defer cancel()
+
+	found, err := c.GetValue(ctx, key, &value)
+	c.reportError("get", key, err)
+
+	return value, found && err == nil
+}
+
+/*
+Set stores a value using the DefaultTTL
+*/
+func (c *Cache) Set(key string, value interface{}) {
+	c.SetWithTTL(key, value, c.config.DefaultTTL)
+}
+
+/*
+SetWithTTL stores a value that expires after ttl
+*/
+func (c *Cache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.Timeout)
+	defer cancel()
+
+	c.reportError("set", key, c.SetValue(ctx, key, value, ttl))
+}
+
+/*
+Delete removes an entry
+*/
+func (c *Cache) Delete(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.Timeout)
+	defer cancel()
+
+	c.reportError("delete", key, c.DeleteValue(ctx, key))
+}
+
+/*
+GetOrLoad returns the value stored under key, calling loader and storing
+its result when there is no entry. Cached values are decoded without
+knowing their type; use LoadValue to decode into a specific type
+*/
+func (c *Cache) GetOrLoad(ctx context.Context, key string, loader cache.LoaderFunc) (interface{}, error) {
+	var value interface{}
+
+	err := c.LoadValue(ctx, key, &value, loader)
+	return value, err
+}
+
+/*
+Clear removes every key in the cache's namespace
+*/
+func (c *Cache) Clear() {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.Timeout*10)
+	defer cancel()
+
+	_, err := c.scan(ctx, true)
+	c.reportError("clear", "*", err)
+}
+
+/*
+Len counts the keys in the cache's namespace. This scans the keyspace,
+so avoid calling it often on large servers
+*/
+func (c *Cache) Len() int {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.Timeout*10)
+	defer cancel()
+
+	count, err := c.scan(ctx, false)
+	c.reportError("len", "*", err)
+
+	return count
+}
+
+/*
+Stats returns hit, miss, and load counts. Entries, evictions, and
+expirations are managed by Redis and aren't reported
+*/
+func (c *Cache) Stats() cache.Stats {
+	c.Lock()
+	defer c.Unlock()
+
+	result := c.stats
+
+	if result.Hits+result.Misses > 0 {
+		result.HitRatio = float64(result.Hits) / float64(result.Hits+result.Misses)
+	}
+
+	return result
+}
+
+/*
+Hits returns how many lookups found an entry
+*/
+func (c *Cache) Hits() uint64 {
+	return c.Stats().Hits
+}
+
+/*
+Misses returns how many lookups didn't find an entry
+*/
+func (c *Cache) Misses() uint64 {
+	return c.Stats().Misses
+}
+
+/*
+Name returns the name from the cache's config
+*/
+func (c *Cache) Name() string {
+	return c.config.Name
+}
+
+/*
+Collect returns the cache's stats for serverstats
+*/
+func (c *Cache) Collect(ctx context.Context) map[string]interface{} {
+	stats := c.Stats()
+
+	return map[string]interface{}{
+		"hitRatio":   stats.HitRatio,
+		"hits":       stats.Hits,
+		"loadErrors": stats.LoadErrors,
+		"loads":      stats.Loads,
+		"misses":     stats.Misses,
+	}
+}
+
+func (c *Cache) load(ctx context.Context, key string, call *loadCall, loader cache.LoaderFunc) {
+	completed := false
+
+	defer func() {
+		if !completed {
+			call.err = fmt.Errorf("cache loader for '%s' panicked", key)
+		}
+
+		c.Lock()
+		delete(c.calls, key)
+
+		if call.err != nil {
+			c.stats.LoadErrors++
+		}
+
+		c.Unlock()
+		close(call.done)
+	}()
+
+	value, err := loader(ctx)
+	completed = true
+
+	if err != nil {
+		call.err = err
+		return
+	}
+
+	if call.data, call.err = c.config.Codec.Marshal(value); call.err != nil {
+		call.err = fmt.Errorf("error encoding '%s': %w", key, call.err)
+		return
+	}
+
+	call.err = c.setData(ctx, key, call.data, c.config.DefaultTTL)
+}
+
+func (c *Cache) setData(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	if ttl < 0 {
+		ttl = 0
+	}
+
+	if err := c.client.Set(ctx, c.Key(key), data, ttl).Err(); err != nil {
+		return fmt.Errorf("error writing '%s' to Redis: %w", key, err)
+	}
+
+	return nil
+}
+
+/*
+scan walks the keys in the namespace, deleting them when remove is true,
+and returns how many were found. On a cluster every master is scanned
+*/
+func (c *Cache) scan(ctx context.Context, remove bool) (int, error) {
+	if c.config.Namespace == "" {
+		return 0, ErrNoNamespace
+	}
+
+	cluster, ok := c.client.(*redis.ClusterClient)
+
+	if !ok {
+		return c.scanNode(ctx, c.client, remove)
+	}
+
+	mutex := &sync.Mutex{}
+	total := 0
+
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		count, err := c.scanNode(ctx, node, remove)
+
+		mutex.Lock()
+		total += count
+		mutex.Unlock()
+
+		return err
+	})
+
+	return total, err
+}
+
+func (c *Cache) scanNode(ctx context.Context, node redis.Cmdable, remove bool) (int, error) {
+	count := 0
+	iterator := node.Scan(ctx, 0, c.config.Namespace+":*", 500).Iterator()
+
+	for iterator.Next(ctx) {
+		count++
+
+		if remove {
+			if err := node.Del(ctx, iterator.Val()).Err(); err != nil {
+				return count, fmt.Errorf("error deleting '%s' from Redis: %w", iterator.Val(), err)
+			}
+		}
+	}
+
+	if err := iterator.Err(); err != nil {
+		return count, fmt.Errorf("error scanning Redis keys: %w", err)
+	}
+
+	return count, nil
+}
+
+func (c *Cache) count(counter *uint64) {
+	c.Lock()
+	defer c.Unlock()
+
+	*counter++
+}
+
+func (c *Cache) reportError(operation, key string, err error) {
+	if err != nil && c.config.OnError != nil {
+		c.config.OnError(operation, key, err)
+	}
+}
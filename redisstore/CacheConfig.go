@@ -0,0 +1,29 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package redisstore
+
+import "time"
+
+/*
+CacheConfig configures a Redis-backed Cache.
+
+Namespace is prepended to every key, separated by a colon, so several
+caches or applications can share a server. Clear and Len only work when
+a namespace is set. Codec defaults to JSONCodec. DefaultTTL is used by
+Set and the load methods, and zero means entries don't expire.
+
+The cache.ICache methods don't return errors, so Redis errors from them
+are passed to OnError instead. Timeout bounds each of those calls and
+defaults to one second. Name is reported by the cache's Name method for
+use as a serverstats Collector
+*/
+type CacheConfig struct {
+	Codec      ICodec
+	DefaultTTL time.Duration
+	Name       string
+	Namespace  string
+	OnError    func(operation, key string, err error)
+	Timeout    time.Duration
+}
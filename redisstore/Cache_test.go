@@ -0,0 +1,151 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package redisstore_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/cache"
+	"github.com/ResurgenceIT/kit/v6/redisstore"
+	"github.com/alicebob/miniredis/v2"
+)
+
+type user struct {
+	ID   int
+	Name string
+}
+
+func newTestCache(t *testing.T, codec redisstore.ICodec) (*miniredis.Miniredis, *redisstore.Cache) {
+	server, err := miniredis.Run()
+
+	if err != nil {
+		t.Fatalf("error starting test Redis server: %s", err)
+	}
+
+	t.Cleanup(server.Close)
+
+	client := redisstore.NewClient(redisstore.ClientConfig{Addresses: []string{server.Addr()}})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return server, redisstore.NewCache(client, redisstore.CacheConfig{
+		Codec:      codec,
+		DefaultTTL: time.Minute,
+		Namespace:  "users",
+	})
+}
+
+func TestCacheCodecs(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec redisstore.ICodec
+	}{
+		{name: "JSON", codec: redisstore.JSONCodec},
+		{name: "Msgpack", codec: redisstore.MsgpackCodec},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var actual user
+
+			server, c := newTestCache(t, test.codec)
+			ctx := context.Background()
+
+			if err := c.SetValue(ctx, "1", user{ID: 1, Name: "Bob"}, time.Second*10); err != nil {
+				t.Fatalf("Expected no error but got '%s'", err)
+			}
+
+			if !server.Exists("users:1") {
+				t.Errorf("Expected the key to be namespaced")
+			}
+
+			if found, err := c.GetValue(ctx, "1", &actual); !found || err != nil || actual.Name != "Bob" {
+				t.Errorf("Expected Bob but got %+v (%v, %v)", actual, found, err)
+			}
+
+			server.FastForward(time.Second * 11)
+
+			if _, found := c.Get("1"); found {
+				t.Errorf("Expected the entry to expire")
+			}
+		})
+	}
+}
+
+func TestCacheICache(t *testing.T) {
+	var c cache.ICache
+
+	_, redisCache := newTestCache(t, nil)
+	c = redisCache
+
+	c.Set("a", "apple")
+	c.Set("b", map[string]interface{}{"name": "banana"})
+
+	if value, found := c.Get("a"); !found || value != "apple" {
+		t.Errorf("Expected 'apple' but got '%v'", value)
+	}
+
+	if value, _ := c.Get("b"); value.(map[string]interface{})["name"] != "banana" {
+		t.Errorf("Expected a map with banana but got '%v'", value)
+	}
+
+	if c.Len() != 2 {
+		t.Errorf("Expected 2 entries but got %d", c.Len())
+	}
+
+	c.Clear()
+
+	if c.Len() != 0 {
+		t.Errorf("Expected no entries after clearing but got %d", c.Len())
+	}
+}
+
+func TestCacheLoadValue(t *testing.T) {
+	var calls int32
+
+	_, c := newTestCache(t, redisstore.MsgpackCodec)
+	wg := &sync.WaitGroup{}
+	release := make(chan struct{})
+
+	for index := 0; index < 5; index++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			var actual user
+
+			err := c.LoadValue(context.Background(), "2", &actual, func(ctx context.Context) (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return user{ID: 2, Name: "Alice"}, nil
+			})
+
+			if err != nil || actual.Name != "Alice" {
+				t.Errorf("Expected Alice but got %+v (%v)", actual, err)
+			}
+		}()
+	}
+
+	time.Sleep(time.Millisecond * 20)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("Expected the loader to be called once but it was called %d times", calls)
+	}
+
+	loadErr := errors.New("database is down")
+
+	if err := c.LoadValue(context.Background(), "3", &user{}, func(ctx context.Context) (interface{}, error) {
+		return nil, loadErr
+	}); !errors.Is(err, loadErr) {
+		t.Errorf("Expected error '%v' but got '%v'", loadErr, err)
+	}
+}
@@ -0,0 +1,45 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package redisstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+/*
+NewClient creates a pooled Redis client. The client is safe for
+concurrent use and should be shared by everything talking to the same
+server. Close it when the application shuts down
+*/
+func NewClient(config ClientConfig) redis.UniversalClient {
+	return redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs:        config.Addresses,
+		DB:           config.DB,
+		DialTimeout:  config.DialTimeout,
+		MasterName:   config.MasterName,
+		MinIdleConns: config.MinIdleConns,
+		Password:     config.Password,
+		PoolSize:     config.PoolSize,
+		PoolTimeout:  config.PoolTimeout,
+		ReadTimeout:  config.ReadTimeout,
+		TLSConfig:    config.TLSConfig,
+		Username:     config.Username,
+		WriteTimeout: config.WriteTimeout,
+	})
+}
+
+/*
+Ping checks that Redis can be reached
+*/
+func Ping(ctx context.Context, client redis.UniversalClient) error {
+	if err := client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("error connecting to Redis: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package redisstore
+
+import (
+	"crypto/tls"
+	"time"
+)
+
+/*
+ClientConfig configures a pooled Redis connection. Use a single address
+for a standalone server, several for a cluster, or set MasterName to
+connect through Sentinel. PoolSize defaults to ten connections per CPU.
+Zero timeouts use the go-redis defaults
+*/
+type ClientConfig struct {
+	Addresses    []string
+	DB           int
+	DialTimeout  time.Duration
+	MasterName   string
+	MinIdleConns int
+	Password     string
+	PoolSize     int
+	PoolTimeout  time.Duration
+	ReadTimeout  time.Duration
+	TLSConfig    *tls.Config
+	Username     string
+	WriteTimeout time.Duration
+}
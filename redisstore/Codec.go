@@ -0,0 +1,47 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package redisstore
+
+import (
+	"encoding/json"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+/*
+ICodec describes how values are encoded before they are stored in Redis
+*/
+type ICodec interface {
+	Marshal(value interface{}) ([]byte, error)
+	Unmarshal(data []byte, dest interface{}) error
+}
+
+var (
+	// JSONCodec encodes values as JSON. It is the default
+	JSONCodec ICodec = jsonCodec{}
+
+	// MsgpackCodec encodes values as MessagePack, which is smaller and faster than JSON
+	MsgpackCodec ICodec = msgpackCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (jsonCodec) Unmarshal(data []byte, dest interface{}) error {
+	return json.Unmarshal(data, dest)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(value interface{}) ([]byte, error) {
+	return msgpack.Marshal(value)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, dest interface{}) error {
+	return msgpack.Unmarshal(data, dest)
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package redisstore
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/ratelimit"
+	"github.com/go-redis/redis/v8"
+)
+
+/*
+tokenBucketScript refills and takes from a token bucket stored as a hash
+of tokens and the time it was last updated, in milliseconds
+*/
+var tokenBucketScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local perMillisecond = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "updatedAt")
+local tokens = tonumber(state[1]) or burst
+local updatedAt = tonumber(state[2]) or now
+
+tokens = math.min(burst, tokens + math.max(0, now - updatedAt) * perMillisecond)
+
+local allowed = 0
+
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "updatedAt", tostring(now))
+redis.call("PEXPIRE", KEYS[1], ttl)
+
+return {allowed, tostring(tokens)}
+`)
+
+/*
+slidingWindowScript counts a request in the current window if the
+weighted count of the previous and current windows leaves room for it
+*/
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local weight = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[3])
+
+local current = tonumber(redis.call("GET", KEYS[1]) or "0")
+local previous = tonumber(redis.call("GET", KEYS[2]) or "0")
+local allowed = 0
+
+if previous * weight + current + 1 <= limit then
+	current = redis.call("INCR", KEYS[1])
+	redis.call("PEXPIRE", KEYS[1], ttl)
+	allowed = 1
+end
+
+return {allowed, previous, current}
+`)
+
+/*
+RateLimitStore keeps rate limit state in Redis, so limits are shared by
+every instance of a service. Each check is a single Lua script, so it
+is atomic. Keys are wrapped in a hash tag so a key's state stays on one
+cluster node
+*/
+type RateLimitStore struct {
+	client    redis.UniversalClient
+	namespace string
+}
+
+/*
+NewRateLimitStore creates a rate limit store. Namespace is prepended to
+every key, and defaults to "ratelimit"
+*/
+func NewRateLimitStore(client redis.UniversalClient, namespace string) *RateLimitStore {
+	if namespace == "" {
+		namespace = "ratelimit"
+	}
+
+	return &RateLimitStore{
+		client:    client,
+		namespace: namespace,
+	}
+}
+
+/*
+Take records a request for key
+*/
+func (s *RateLimitStore) Take(ctx context.Context, key string, rule ratelimit.Rule, now time.Time) (ratelimit.Result, error) {
+	key = s.namespace + ":{" + key + "}"
+	windowMilliseconds := float64(rule.Window) / float64(time.Millisecond)
+	ttl := int64(math.Max(1, windowMilliseconds*2))
+
+	if rule.Algorithm == ratelimit.SlidingWindow {
+		index := now.UnixNano() / int64(rule.Window)
+		elapsed := time.Duration(now.UnixNano() - index*int64(rule.Window))
+		weight := float64(rule.Window-elapsed) / float64(rule.Window)
+
+		values, err := slidingWindowScript.Run(ctx, s.client, []string{
+			key + ":" + strconv.FormatInt(index, 10),
+			key + ":" + strconv.FormatInt(index-1, 10),
+		}, rule.Limit, weight, ttl).Int64Slice()
+
+		if err != nil {
+			return ratelimit.Result{}, fmt.Errorf("error running sliding window script: %w", err)
+		}
+
+		return ratelimit.SlidingWindowResult(rule, int(values[1]), int(values[2]), elapsed, values[0] == 1), nil
+	}
+
+	perMillisecond := float64(rule.Limit) / windowMilliseconds
+	values, err := tokenBucketScript.Run(ctx, s.client, []string{key}, rule.Burst, perMillisecond, now.UnixNano()/int64(time.Millisecond), ttl).Slice()
+
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("error running token bucket script: %w", err)
+	}
+
+	allowed, _ := values[0].(int64)
+	tokens, err := strconv.ParseFloat(fmt.Sprintf("%v", values[1]), 64)
+
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("error reading token count: %w", err)
+	}
+
+	return ratelimit.TokenBucketResult(rule, tokens, allowed == 1), nil
+}
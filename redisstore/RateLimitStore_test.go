@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package redisstore_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/ratelimit"
+	"github.com/ResurgenceIT/kit/v6/redisstore"
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestRateLimitStore(t *testing.T) {
+	server, err := miniredis.Run()
+
+	if err != nil {
+		t.Fatalf("error starting test Redis server: %s", err)
+	}
+
+	defer server.Close()
+
+	client := redisstore.NewClient(redisstore.ClientConfig{Addresses: []string{server.Addr()}})
+	defer client.Close()
+
+	store := redisstore.NewRateLimitStore(client, "")
+	start := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		rule     ratelimit.Rule
+		requests []time.Duration
+		expected []bool
+	}{
+		{
+			name:     "Token bucket",
+			rule:     ratelimit.Rule{Algorithm: ratelimit.TokenBucket, Burst: 2, Limit: 1, Window: time.Second},
+			requests: []time.Duration{0, 0, 0, time.Second},
+			expected: []bool{true, true, false, true},
+		},
+		{
+			name:     "Sliding window",
+			rule:     ratelimit.Rule{Algorithm: ratelimit.SlidingWindow, Limit: 2, Window: time.Minute},
+			requests: []time.Duration{time.Second * 50, time.Second * 55, time.Second * 65, time.Second * 95},
+			expected: []bool{true, true, false, true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for index, offset := range test.requests {
+				result, err := store.Take(context.Background(), test.name, test.rule, start.Add(offset))
+
+				if err != nil {
+					t.Fatalf("Expected no error but got '%s'", err)
+				}
+
+				if result.Allowed != test.expected[index] {
+					t.Errorf("Request %d: expected allowed to be %v but got %+v", index, test.expected[index], result)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package refcode
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrInvalidAlphabet = errors.New("alphabets need at least 2 distinct characters")
+	ErrInvalidCode     = errors.New("invalid reference code")
+)
+
+// DefaultAlphabet is Crockford's base 32
+const DefaultAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// confusables are characters people type by mistake, and what they meant
+var confusables = map[rune]rune{'I': '1', 'L': '1', 'O': '0', 'U': 'V'}
+
+/*
+Generator makes human friendly reference codes, such as order numbers,
+and checks codes people type back in. The check character catches any
+single mistyped character and most swapped neighbors, so typos are
+caught before a lookup. Codes are random, so they don't reveal how many
+orders there are; check new codes are unique where they are stored. It
+is safe for concurrent use
+*/
+type Generator struct {
+	config  GeneratorConfig
+	indexes map[rune]int
+}
+
+/*
+NewGenerator creates a Generator, filling in defaults for anything not
+set in config
+*/
+func NewGenerator(config GeneratorConfig) (*Generator, error) {
+	if config.Alphabet == "" {
+		config.Alphabet = DefaultAlphabet
+	}
+
+	if config.Length <= 0 {
+		config.Length = 8
+	}
+
+	if config.GroupSize <= 0 {
+		config.GroupSize = 4
+	}
+
+	if config.Separator == "" {
+		config.Separator = "-"
+	}
+
+	config.Alphabet = strings.ToUpper(config.Alphabet)
+	indexes := make(map[rune]int)
+
+	for index, r := range []rune(config.Alphabet) {
+		if _, duplicate := indexes[r]; duplicate {
+			return nil, ErrInvalidAlphabet
+		}
+
+		indexes[r] = index
+	}
+
+	if len(indexes) < 2 {
+		return nil, ErrInvalidAlphabet
+	}
+
+	return &Generator{
+		config:  config,
+		indexes: indexes,
+	}, nil
+}
+
+/*
+Generate returns a new random code
+*/
+func (g *Generator) Generate() string {
+	alphabet := []rune(g.config.Alphabet)
+	max := big.NewInt(int64(len(alphabet)))
+	code := make([]rune, g.config.Length)
+
+	for index := range code {
+		n, err := rand.Int(rand.Reader, max)
+
+		if err != nil {
+			panic(fmt.Errorf("error reading random bytes: %w", err))
+		}
+
+		code[index] = alphabet[n.Int64()]
+	}
+
+	if !g.config.NoChecksum {
+		code = append(code, g.checkCharacter(code))
+	}
+
+	return g.format(code)
+}
+
+/*
+Normalize checks a code someone typed and returns it as Generate writes
+it. Case, spaces, separators, and the prefix are optional, and letters
+people confuse with others, such as O for 0, are corrected when the
+alphabet doesn't have them
+*/
+func (g *Generator) Normalize(code string) (string, error) {
+	text := strings.ToUpper(strings.TrimSpace(code))
+
+	if g.config.Prefix != "" {
+		text = strings.TrimPrefix(text, strings.ToUpper(g.config.Prefix))
+	}
+
+	characters := []rune{}
+
+	for _, r := range text {
+		if unicode.IsSpace(r) || strings.ContainsRune(g.config.Separator, r) || r == '-' {
+			continue
+		}
+
+		if _, ok := g.indexes[r]; !ok {
+			if meant, isConfusable := confusables[r]; isConfusable {
+				if _, known := g.indexes[meant]; known {
+					r = meant
+				}
+			}
+		}
+
+		if _, ok := g.indexes[r]; !ok {
+			return "", fmt.Errorf("%w: %q", ErrInvalidCode, code)
+		}
+
+		characters = append(characters, r)
+	}
+
+	length := g.config.Length
+
+	if !g.config.NoChecksum {
+		length++
+	}
+
+	if len(characters) != length {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCode, code)
+	}
+
+	if !g.config.NoChecksum && g.checkCharacter(characters[:len(characters)-1]) != characters[len(characters)-1] {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCode, code)
+	}
+
+	return g.format(characters), nil
+}
+
+/*
+Valid returns true when Normalize accepts a code
+*/
+func (g *Generator) Valid(code string) bool {
+	_, err := g.Normalize(code)
+	return err == nil
+}
+
+/*
+checkCharacter is the Luhn mod N check character for a code
+*/
+func (g *Generator) checkCharacter(code []rune) rune {
+	alphabet := []rune(g.config.Alphabet)
+	n := len(alphabet)
+	factor := 2
+	sum := 0
+
+	for index := len(code) - 1; index >= 0; index-- {
+		addend := factor * g.indexes[code[index]]
+		sum += addend/n + addend%n
+
+		if factor == 2 {
+			factor = 1
+		} else {
+			factor = 2
+		}
+	}
+
+	return alphabet[(n-sum%n)%n]
+}
+
+func (g *Generator) format(code []rune) string {
+	groups := []string{}
+
+	if g.config.Prefix != "" {
+		groups = append(groups, g.config.Prefix)
+	}
+
+	for start := 0; start < len(code); start += g.config.GroupSize {
+		end := start + g.config.GroupSize
+
+		if end > len(code) {
+			end = len(code)
+		}
+
+		groups = append(groups, string(code[start:end]))
+	}
+
+	return strings.Join(groups, g.config.Separator)
+}
@@ -0,0 +1,26 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package refcode
+
+/*
+GeneratorConfig configures a Generator.
+
+Alphabet is the characters codes are made of. It defaults to Crockford's
+base 32, 0-9 and A-Z without I, L, O, and U, so codes are easy to read
+aloud and type. Length is the number of random characters, and defaults
+to 8. A check character is added unless NoChecksum is set.
+
+Codes are written in groups of GroupSize characters, defaulting to 4,
+joined by Separator, defaulting to "-". Prefix, such as "ORD", starts
+every code. With the defaults, codes look like ORD-7K3M-9QXT-B
+*/
+type GeneratorConfig struct {
+	Alphabet   string
+	GroupSize  int
+	Length     int
+	NoChecksum bool
+	Prefix     string
+	Separator  string
+}
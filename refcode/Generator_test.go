@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package refcode_test
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/refcode"
+)
+
+func TestGenerate(t *testing.T) {
+	generator, err := refcode.NewGenerator(refcode.GeneratorConfig{Prefix: "ORD"})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err)
+	}
+
+	pattern := regexp.MustCompile(`^ORD-[0-9A-HJKMNP-TV-Z]{4}-[0-9A-HJKMNP-TV-Z]{4}-[0-9A-HJKMNP-TV-Z]$`)
+	seen := map[string]bool{}
+
+	for index := 0; index < 1000; index++ {
+		code := generator.Generate()
+
+		if !pattern.MatchString(code) {
+			t.Fatalf("Expected a code like ORD-XXXX-XXXX-X but got %s", code)
+		}
+
+		if seen[code] {
+			t.Fatalf("Expected unique codes but got %s twice", code)
+		}
+
+		seen[code] = true
+
+		if normalized, err := generator.Normalize(code); err != nil || normalized != code {
+			t.Fatalf("Expected %s to be valid but got %s, %v", code, normalized, err)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	generator, _ := refcode.NewGenerator(refcode.GeneratorConfig{Prefix: "ORD"})
+	raw := ""
+
+	// Find the check character for a known code
+	for _, check := range refcode.DefaultAlphabet {
+		if generator.Valid("7K3M9QXT" + string(check)) {
+			raw = "7K3M9QXT" + string(check)
+		}
+	}
+
+	code := "ORD-" + raw[:4] + "-" + raw[4:8] + "-" + raw[8:]
+
+	tests := []struct {
+		name  string
+		input string
+		valid bool
+	}{
+		{name: "As written", input: code, valid: true},
+		{name: "Lowercase without prefix", input: strings.ToLower(raw), valid: true},
+		{name: "Spaces", input: " ord " + raw[:4] + " " + raw[4:] + " ", valid: true},
+		{name: "Typo", input: "7K3N9QXT" + raw[8:]},
+		{name: "Swapped neighbors", input: "K73M9QXT" + raw[8:]},
+		{name: "Too short", input: raw[1:]},
+		{name: "Not in alphabet", input: "#" + raw[1:]},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			normalized, err := generator.Normalize(test.input)
+
+			if test.valid && normalized != code {
+				t.Errorf("Expected %s to normalize to %s but got %s, %v", test.input, code, normalized, err)
+			}
+
+			if !test.valid && !errors.Is(err, refcode.ErrInvalidCode) {
+				t.Errorf("Expected %s to be invalid", test.input)
+			}
+		})
+	}
+}
+
+func TestConfusables(t *testing.T) {
+	generator, _ := refcode.NewGenerator(refcode.GeneratorConfig{Alphabet: "0123456789", Length: 6, NoChecksum: true, GroupSize: 3})
+
+	if normalized, err := generator.Normalize("1O1-l0I"); err != nil || normalized != "101-101" {
+		t.Errorf("Expected 101-101 but got %s, %v", normalized, err)
+	}
+
+	if _, err := refcode.NewGenerator(refcode.GeneratorConfig{Alphabet: "AA"}); !errors.Is(err, refcode.ErrInvalidAlphabet) {
+		t.Errorf("Expected ErrInvalidAlphabet but got %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package render
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var currencySymbols = map[string]string{
+	"AUD": "A$",
+	"CAD": "CA$",
+	"EUR": "€",
+	"GBP": "£",
+	"INR": "₹",
+	"JPY": "¥",
+	"USD": "$",
+}
+
+var zeroDecimalCurrencies = map[string]bool{"JPY": true, "KRW": true}
+
+/*
+funcs returns the helpers every template can use. The CSRF helpers are
+placeholders until contextFuncs binds them to a request
+*/
+func (r *Renderer) funcs() template.FuncMap {
+	result := template.FuncMap{
+		"csrfField": func() template.HTML { return "" },
+		"csrfToken": func() string { return "" },
+		"date":      Date,
+		"dict":      Dict,
+		"money":     Money,
+	}
+
+	if r.config.ContextFuncs != nil {
+		for name, fn := range r.config.ContextFuncs(nil) {
+			result[name] = fn
+		}
+	}
+
+	for name, fn := range r.config.Funcs {
+		result[name] = fn
+	}
+
+	return result
+}
+
+func (r *Renderer) contextFuncs(ctx echo.Context) template.FuncMap {
+	token := ""
+
+	if ctx != nil {
+		token, _ = ctx.Get(r.config.CSRFContextKey).(string)
+	}
+
+	result := template.FuncMap{
+		"csrfField": func() template.HTML {
+			return template.HTML(fmt.Sprintf(`<input type="hidden" name="%s" value="%s">`, template.HTMLEscapeString(r.config.CSRFField), template.HTMLEscapeString(token)))
+		},
+		"csrfToken": func() string { return token },
+	}
+
+	if r.config.ContextFuncs != nil {
+		for name, fn := range r.config.ContextFuncs(ctx) {
+			result[name] = fn
+		}
+	}
+
+	// Helpers replaced through config win over the request's
+	for name := range r.config.Funcs {
+		delete(result, name)
+	}
+
+	return result
+}
+
+/*
+Date formats a time.Time or *time.Time with a Go layout, such as
+{{date .CreatedAt "Jan 2, 2006"}}. Zero and nil times are empty
+*/
+func Date(value interface{}, layout string) string {
+	var t time.Time
+
+	switch v := value.(type) {
+	case time.Time:
+		t = v
+
+	case *time.Time:
+		if v != nil {
+			t = *v
+		}
+	}
+
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(layout)
+}
+
+/*
+Dict builds a map from pairs of keys and values, to pass several values
+to a partial: {{template "partials/user" dict "User" .User "Compact" true}}
+*/
+func Dict(pairs ...interface{}) (map[string]interface{}, error) {
+	if len(pairs)%2 != 0 {
+		return nil, errors.New("dict needs pairs of keys and values")
+	}
+
+	result := make(map[string]interface{}, len(pairs)/2)
+
+	for index := 0; index < len(pairs); index += 2 {
+		key, ok := pairs[index].(string)
+
+		if !ok {
+			return nil, fmt.Errorf("dict key %v is not a string", pairs[index])
+		}
+
+		result[key] = pairs[index+1]
+	}
+
+	return result, nil
+}
+
+/*
+Money formats an amount of a currency with its symbol and thousands
+separators, such as {{money .Total "USD"}} for $1,234.50. Amounts are
+in major units, such as dollars. Integer amounts, which are taken to be
+minor units such as cents, are divided by 100 first for currencies with
+cents
+*/
+func Money(amount interface{}, currency string) string {
+	currency = strings.ToUpper(currency)
+	decimals := 2
+
+	if zeroDecimalCurrencies[currency] {
+		decimals = 0
+	}
+
+	var value float64
+
+	switch v := amount.(type) {
+	case float64:
+		value = v
+
+	case float32:
+		value = float64(v)
+
+	case int:
+		value = float64(v) / math.Pow10(decimals)
+
+	case int64:
+		value = float64(v) / math.Pow10(decimals)
+
+	default:
+		return fmt.Sprint(amount)
+	}
+
+	sign := ""
+
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+
+	text := strconv.FormatFloat(value, 'f', decimals, 64)
+	whole, fraction := text, ""
+
+	if index := strings.Index(text, "."); index >= 0 {
+		whole, fraction = text[:index], text[index:]
+	}
+
+	grouped := []string{}
+
+	for len(whole) > 3 {
+		grouped = append([]string{whole[len(whole)-3:]}, grouped...)
+		whole = whole[:len(whole)-3]
+	}
+
+	grouped = append([]string{whole}, grouped...)
+	number := strings.Join(grouped, ",") + fraction
+
+	if symbol, ok := currencySymbols[currency]; ok {
+		return sign + symbol + number
+	}
+
+	return sign + number + " " + currency
+}
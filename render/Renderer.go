@@ -0,0 +1,241 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package render
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	layoutsDir  = "layouts"
+	partialsDir = "partials"
+)
+
+var (
+	ErrMissingTemplates = errors.New("templates need an FS or a Dir")
+	ErrUnknownLayout    = errors.New("unknown layout")
+	ErrUnknownTemplate  = errors.New("unknown template")
+)
+
+/*
+Renderer renders HTML pages inside layouts, for Echo or on its own.
+Templates are organized by directory:
+
+	layouts/base.html      layouts, which call {{template "content" .}}
+	partials/nav.html      partials, available to every page and layout
+	users/index.html       pages, which {{define "content"}} and blocks
+
+Templates are named by their path without the extension, such as
+"users/index" or "partials/nav"
+*/
+type Renderer struct {
+	cache  sync.Map
+	config RendererConfig
+}
+
+/*
+NewRenderer creates a Renderer and parses every page, so template errors
+are found at startup instead of on the first request
+*/
+func NewRenderer(config RendererConfig) (*Renderer, error) {
+	if config.FS == nil && config.Dir == "" {
+		return nil, ErrMissingTemplates
+	}
+
+	if config.FS == nil || config.Reload {
+		config.FS = os.DirFS(config.Dir)
+	}
+
+	if config.Extension == "" {
+		config.Extension = ".html"
+	}
+
+	if config.CSRFContextKey == "" {
+		config.CSRFContextKey = "csrf"
+	}
+
+	if config.CSRFField == "" {
+		config.CSRFField = "_csrf"
+	}
+
+	if config.DefaultLayout == "" {
+		if _, err := fs.Stat(config.FS, path.Join(layoutsDir, "base"+config.Extension)); err == nil {
+			config.DefaultLayout = "base"
+		}
+	}
+
+	result := &Renderer{
+		config: config,
+	}
+
+	names, err := result.Templates()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		if _, err = result.template(result.config.DefaultLayout, name); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+/*
+Render renders the named page to w, satisfying echo.Renderer. Pages
+render in the default layout. Name another layout before a colon, such
+as "admin:users/index", or none, as in ":users/index", to render just
+the page's content. Partials, such as "partials/row", always render on
+their own, which suits HTML fragments. ctx may be nil outside Echo
+*/
+func (r *Renderer) Render(w io.Writer, name string, data interface{}, ctx echo.Context) error {
+	layout := r.config.DefaultLayout
+
+	if index := strings.Index(name, ":"); index >= 0 {
+		layout, name = name[:index], name[index+1:]
+	}
+
+	if strings.HasPrefix(name, partialsDir+"/") {
+		layout = ""
+	}
+
+	master, err := r.template(layout, name)
+
+	if err != nil {
+		return err
+	}
+
+	t, err := master.Clone()
+
+	if err != nil {
+		return fmt.Errorf("error preparing template %s: %w", name, err)
+	}
+
+	t.Funcs(r.contextFuncs(ctx))
+
+	entry := name
+
+	switch {
+	case layout != "":
+		entry = path.Join(layoutsDir, layout)
+
+	case t.Lookup("content") != nil && !strings.HasPrefix(name, partialsDir+"/"):
+		entry = "content"
+	}
+
+	// Render to a buffer first, so a failing template doesn't send half a page
+	buffer := &bytes.Buffer{}
+
+	if err = t.ExecuteTemplate(buffer, entry, data); err != nil {
+		return fmt.Errorf("error rendering template %s: %w", name, err)
+	}
+
+	_, err = buffer.WriteTo(w)
+	return err
+}
+
+/*
+Templates lists the names of the pages and partials
+*/
+func (r *Renderer) Templates() ([]string, error) {
+	result := []string{}
+
+	err := fs.WalkDir(r.config.FS, ".", func(filename string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.HasSuffix(filename, r.config.Extension) {
+			return err
+		}
+
+		if !strings.HasPrefix(filename, layoutsDir+"/") {
+			result = append(result, strings.TrimSuffix(filename, r.config.Extension))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing templates: %w", err)
+	}
+
+	sort.Strings(result)
+	return result, nil
+}
+
+/*
+template returns the parsed set for a page in a layout: the helpers,
+every partial, the layout, and the page. Sets are cached unless
+reloading. The cached set is never executed, only cloned, so it can be
+cloned again for every render
+*/
+func (r *Renderer) template(layout, name string) (*template.Template, error) {
+	key := layout + ":" + name
+
+	if !r.config.Reload {
+		if cached, ok := r.cache.Load(key); ok {
+			return cached.(*template.Template), nil
+		}
+	}
+
+	t := template.New("root").Funcs(r.funcs())
+
+	partials, err := fs.Glob(r.config.FS, path.Join(partialsDir, "*"+r.config.Extension))
+
+	if err != nil {
+		return nil, fmt.Errorf("error finding partials: %w", err)
+	}
+
+	files := partials
+
+	if layout != "" {
+		layoutFile := path.Join(layoutsDir, layout+r.config.Extension)
+
+		if _, err = fs.Stat(r.config.FS, layoutFile); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownLayout, layout)
+		}
+
+		files = append(files, layoutFile)
+	}
+
+	pageFile := name + r.config.Extension
+
+	if _, err = fs.Stat(r.config.FS, pageFile); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+
+	if !strings.HasPrefix(name, partialsDir+"/") {
+		files = append(files, pageFile)
+	}
+
+	for _, file := range files {
+		b, err := fs.ReadFile(r.config.FS, file)
+
+		if err != nil {
+			return nil, fmt.Errorf("error reading template %s: %w", file, err)
+		}
+
+		if _, err = t.New(strings.TrimSuffix(file, r.config.Extension)).Parse(string(b)); err != nil {
+			return nil, fmt.Errorf("error parsing template %s: %w", file, err)
+		}
+	}
+
+	if !r.config.Reload {
+		r.cache.Store(key, t)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,39 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package render
+
+import (
+	"io/fs"
+
+	"github.com/labstack/echo/v4"
+)
+
+/*
+RendererConfig configures a Renderer.
+
+FS holds the templates, and is usually an embed.FS. Dir is the same
+templates on disk. With Reload set, templates are read from Dir and
+parsed again on every render, so edits show up without a restart; use
+it in development only. When FS is nil, templates are read from Dir.
+
+Extension defaults to ".html". DefaultLayout is the layout pages render
+in, defaulting to "base" when layouts/base.html exists. CSRFContextKey
+is where Echo's CSRF middleware keeps the token, defaulting to "csrf",
+and CSRFField the form field csrfField writes, defaulting to "_csrf".
+Funcs are added to every template, and can replace the helpers.
+ContextFuncs returns helpers for a request, such as translations in its
+locale. It is called with a nil context when templates are parsed
+*/
+type RendererConfig struct {
+	ContextFuncs   func(ctx echo.Context) map[string]interface{}
+	CSRFContextKey string
+	CSRFField      string
+	DefaultLayout  string
+	Dir            string
+	Extension      string
+	FS             fs.FS
+	Funcs          map[string]interface{}
+	Reload         bool
+}
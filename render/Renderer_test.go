@@ -0,0 +1,185 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package render_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/render"
+	"github.com/labstack/echo/v4"
+)
+
+func templates() fstest.MapFS {
+	return fstest.MapFS{
+		"layouts/base.html":  {Data: []byte(`<title>{{block "title" .}}Site{{end}}</title>{{template "partials/nav" .}}<main>{{template "content" .}}</main>`)},
+		"layouts/admin.html": {Data: []byte(`<div class="admin">{{template "content" .}}</div>`)},
+		"partials/nav.html":  {Data: []byte(`<nav>{{.User}}</nav>`)},
+		"partials/row.html":  {Data: []byte(`<tr><td>{{.}}</td></tr>`)},
+		"users/index.html":   {Data: []byte(`{{define "title"}}Users{{end}}{{define "content"}}<h1>Hi {{.User}}</h1>{{end}}`)},
+		"users/form.html":    {Data: []byte(`{{define "content"}}<form>{{csrfField}}</form>{{end}}`)},
+		"users/helpers.html": {Data: []byte(`{{define "content"}}{{date .Joined "2006-01-02"}} {{money .Total "USD"}} {{shout "hi"}}{{end}}`)},
+	}
+}
+
+func TestRender(t *testing.T) {
+	renderer, err := render.NewRenderer(render.RendererConfig{
+		FS:    templates(),
+		Funcs: map[string]interface{}{"shout": strings.ToUpper},
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err)
+	}
+
+	data := map[string]interface{}{
+		"Joined": time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC),
+		"Total":  1234567.5,
+		"User":   "Adam",
+	}
+
+	tests := []struct {
+		name string
+		page string
+		data interface{}
+		want string
+	}{
+		{name: "Default layout", page: "users/index", data: data, want: `<title>Users</title><nav>Adam</nav><main><h1>Hi Adam</h1></main>`},
+		{name: "Other layout", page: "admin:users/index", data: data, want: `<div class="admin"><h1>Hi Adam</h1></div>`},
+		{name: "No layout", page: ":users/index", data: data, want: `<h1>Hi Adam</h1>`},
+		{name: "Partial", page: "partials/row", data: "<b>", want: `<tr><td>&lt;b&gt;</td></tr>`},
+		{name: "Helpers", page: ":users/helpers", data: data, want: `2021-03-04 $1,234,567.50 HI`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+
+			if err := renderer.Render(buffer, test.page, test.data, nil); err != nil {
+				t.Fatalf("Expected no error but got %s", err)
+			}
+
+			if buffer.String() != test.want {
+				t.Errorf("Expected '%s' but got '%s'", test.want, buffer.String())
+			}
+		})
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	shout := map[string]interface{}{"shout": strings.ToUpper}
+	renderer, err := render.NewRenderer(render.RendererConfig{FS: templates(), Funcs: shout})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err)
+	}
+
+	if err := renderer.Render(&bytes.Buffer{}, "users/missing", nil, nil); !errors.Is(err, render.ErrUnknownTemplate) {
+		t.Errorf("Expected ErrUnknownTemplate but got %v", err)
+	}
+
+	if err := renderer.Render(&bytes.Buffer{}, "missing:users/index", nil, nil); !errors.Is(err, render.ErrUnknownLayout) {
+		t.Errorf("Expected ErrUnknownLayout but got %v", err)
+	}
+
+	broken := templates()
+	broken["users/broken.html"] = &fstest.MapFile{Data: []byte(`{{define "content"}}{{.Missing{{end}}`)}
+
+	if _, err := render.NewRenderer(render.RendererConfig{FS: broken, Funcs: shout}); err == nil {
+		t.Errorf("Expected a parse error at startup")
+	}
+
+	if _, err := render.NewRenderer(render.RendererConfig{}); !errors.Is(err, render.ErrMissingTemplates) {
+		t.Errorf("Expected ErrMissingTemplates but got %v", err)
+	}
+}
+
+func TestEchoCSRF(t *testing.T) {
+	renderer, err := render.NewRenderer(render.RendererConfig{
+		DefaultLayout: "admin",
+		FS:            templates(),
+		Funcs:         map[string]interface{}{"shout": strings.ToUpper},
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err)
+	}
+
+	e := echo.New()
+	e.Renderer = renderer
+
+	e.GET("/form", func(ctx echo.Context) error {
+		ctx.Set("csrf", "token\"123")
+		return ctx.Render(http.StatusOK, "users/form", nil)
+	})
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/form", nil))
+
+	want := `<div class="admin"><form><input type="hidden" name="_csrf" value="token&#34;123"></form></div>`
+
+	if recorder.Body.String() != want {
+		t.Errorf("Expected '%s' but got '%s'", want, recorder.Body.String())
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "home.html")
+
+	if err := os.WriteFile(page, []byte(`v1`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	renderer, err := render.NewRenderer(render.RendererConfig{Dir: dir, Reload: true})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err)
+	}
+
+	for _, version := range []string{"v1", "v2"} {
+		if err = os.WriteFile(page, []byte(version), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		buffer := &bytes.Buffer{}
+
+		if err = renderer.Render(buffer, "home", nil, nil); err != nil {
+			t.Fatalf("Expected no error but got %s", err)
+		}
+
+		if buffer.String() != version {
+			t.Errorf("Expected '%s' but got '%s'", version, buffer.String())
+		}
+	}
+}
+
+func TestMoney(t *testing.T) {
+	tests := []struct {
+		amount   interface{}
+		currency string
+		want     string
+	}{
+		{amount: 1234.5, currency: "USD", want: "$1,234.50"},
+		{amount: -99.999, currency: "eur", want: "-€100.00"},
+		{amount: 123456, currency: "USD", want: "$1,234.56"},
+		{amount: 1500000.0, currency: "JPY", want: "¥1,500,000"},
+		{amount: 12.0, currency: "CHF", want: "12.00 CHF"},
+	}
+
+	for _, test := range tests {
+		if got := render.Money(test.amount, test.currency); got != test.want {
+			t.Errorf("Expected %s but got %s", test.want, got)
+		}
+	}
+}
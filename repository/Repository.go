@@ -0,0 +1,447 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/listing"
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+)
+
+var (
+	ErrMissingConfig = errors.New("repository needs a DB, a Model struct, and a Table")
+	ErrNoPrimaryKey  = errors.New("model has no primary key; tag one db:\"...,pk\" or name it ID")
+	ErrNotFound      = errors.New("record not found")
+	ErrWrongModel    = errors.New("wrong model type for repository")
+)
+
+/*
+Repository provides CRUD for one struct type and table. Columns are
+mapped by the same db tags sqldatabase uses, with options after the
+name:
+
+	type User struct {
+		ID        int64      `db:"id,pk"`
+		Email     string     `db:"email"`
+		DeletedAt *time.Time `db:"deleted_at,softdelete"`
+	}
+
+The primary key is the pk column, or the id column. A zero primary key
+is left out of inserts so the database generates it, and read back with
+RETURNING on dialects that support it, or LastInsertId for integer
+keys. With a softdelete column, Delete sets
+it to the current time instead of removing the row, and deleted rows are
+left out of GetByID, List, and Count.
+
+A version column turns on optimistic locking. Insert starts it at 1,
+and Update only saves a row still at the model's version, incrementing
+it, so two people editing the same record can't overwrite each other.
+The loser gets a *sqldatabase.ConflictError.
+
+	Version int64 `db:"version,version"`
+
+Queries run in the transaction in the context, if there is one. See
+sqldatabase.WithTransaction.
+
+Go 1.16 has no generics, so the repository works with interface{}
+values checked against Model with reflection: pass pointers to Model
+for single records and pointers to slices of Model for lists
+*/
+type Repository struct {
+	columns    []sqldatabase.StructColumn
+	config     RepositoryConfig
+	modelType  reflect.Type
+	now        func() time.Time
+	pk         sqldatabase.StructColumn
+	softDelete *sqldatabase.StructColumn
+	version    *sqldatabase.StructColumn
+}
+
+/*
+NewRepository creates a Repository
+*/
+func NewRepository(config RepositoryConfig) (*Repository, error) {
+	if config.DB == nil || config.Model == nil || config.Table == "" {
+		return nil, ErrMissingConfig
+	}
+
+	modelType := reflect.TypeOf(config.Model)
+
+	if modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+
+	if modelType.Kind() != reflect.Struct {
+		return nil, ErrMissingConfig
+	}
+
+	if config.Rebind == nil {
+		config.Rebind = config.Dialect.Rebind
+	}
+
+	result := &Repository{
+		columns:   sqldatabase.StructColumns(modelType),
+		config:    config,
+		modelType: modelType,
+		now:       time.Now,
+	}
+
+	pkFound := false
+
+	for index, column := range result.columns {
+		if column.HasOption("pk") || (!pkFound && strings.EqualFold(column.Name, "id")) {
+			result.pk = column
+			pkFound = true
+		}
+
+		if column.HasOption("softdelete") {
+			result.softDelete = &result.columns[index]
+		}
+
+		if column.HasOption("version") {
+			result.version = &result.columns[index]
+		}
+	}
+
+	if !pkFound {
+		return nil, ErrNoPrimaryKey
+	}
+
+	return result, nil
+}
+
+/*
+Insert adds model, a pointer to Model, as a new row. When the primary
+key is zero it is left to the database, and set on model afterwards for
+integer keys
+*/
+func (r *Repository) Insert(ctx context.Context, model interface{}) error {
+	value, err := r.record(model)
+
+	if err != nil {
+		return err
+	}
+
+	pkValue := value.FieldByIndex(r.pk.Index)
+	generated := pkValue.IsZero()
+
+	if r.version != nil && value.FieldByIndex(r.version.Index).IsZero() {
+		setInteger(value.FieldByIndex(r.version.Index), 1)
+	}
+
+	names := []string{}
+	args := []interface{}{}
+
+	for _, column := range r.columns {
+		if generated && column.Name == r.pk.Name {
+			continue
+		}
+
+		names = append(names, r.q(column.Name))
+		args = append(args, value.FieldByIndex(column.Index).Interface())
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", r.q(r.config.Table), strings.Join(names, ", "), placeholders(len(names)))
+	if generated && r.config.Dialect.SupportsReturning() {
+		return r.insertReturning(ctx, query+r.config.Dialect.Returning(r.q(r.pk.Name)), pkValue, args)
+	}
+
+	result, err := r.querier(ctx).ExecContext(ctx, r.config.Rebind(query), args...)
+
+	if err != nil {
+		return fmt.Errorf("error inserting into %s: %w", r.config.Table, err)
+	}
+
+	if generated && isInteger(pkValue.Kind()) {
+		id, err := result.LastInsertId()
+
+		if err != nil {
+			return fmt.Errorf("error reading inserted ID from %s: %w", r.config.Table, err)
+		}
+
+		setInteger(pkValue, id)
+	}
+
+	return nil
+}
+
+/*
+Update saves every column of model, a pointer to Model, to the row with
+its primary key. It returns ErrNotFound when there is no such row. With
+a version column, it returns a *sqldatabase.ConflictError when the row
+is no longer at model's version, and increments model's version when
+it saves
+*/
+func (r *Repository) Update(ctx context.Context, model interface{}) error {
+	value, err := r.record(model)
+
+	if err != nil {
+		return err
+	}
+
+	assignments := []string{}
+	args := []interface{}{}
+	id := value.FieldByIndex(r.pk.Index).Interface()
+
+	for _, column := range r.columns {
+		if column.Name == r.pk.Name || (r.version != nil && column.Name == r.version.Name) {
+			continue
+		}
+
+		assignments = append(assignments, r.q(column.Name)+"=?")
+		args = append(args, value.FieldByIndex(column.Index).Interface())
+	}
+
+	if r.version == nil {
+		args = append(args, id)
+		query := fmt.Sprintf("UPDATE %s SET %s WHERE %s=?%s", r.q(r.config.Table), strings.Join(assignments, ", "), r.q(r.pk.Name), r.notDeleted(" AND "))
+
+		return r.execOne(ctx, query, args...)
+	}
+
+	versionValue := value.FieldByIndex(r.version.Index)
+	version := toInt64(versionValue)
+
+	assignments = append(assignments, fmt.Sprintf("%s=%s+1", r.q(r.version.Name), r.q(r.version.Name)))
+	args = append(args, id, version)
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s=? AND %s=?%s", r.q(r.config.Table), strings.Join(assignments, ", "), r.q(r.pk.Name), r.q(r.version.Name), r.notDeleted(" AND "))
+
+	if err = r.execOne(ctx, query, args...); errors.Is(err, ErrNotFound) {
+		err = sqldatabase.VersionConflict(ctx, r.querier(ctx), sqldatabase.VersionedUpdate{
+			ID:            id,
+			IDColumn:      r.q(r.pk.Name),
+			Rebind:        r.config.Rebind,
+			Table:         r.q(r.config.Table),
+			Version:       version,
+			VersionColumn: r.q(r.version.Name),
+		})
+
+		if errors.Is(err, sql.ErrNoRows) {
+			err = ErrNotFound
+		}
+	}
+
+	if err == nil {
+		setInteger(versionValue, version+1)
+	}
+
+	return err
+}
+
+/*
+Delete removes the row with primary key id, or marks it deleted when
+the model has a softdelete column. It returns ErrNotFound when there is
+no such row
+*/
+func (r *Repository) Delete(ctx context.Context, id interface{}) error {
+	if r.softDelete != nil {
+		query := fmt.Sprintf("UPDATE %s SET %s=? WHERE %s=?%s", r.q(r.config.Table), r.q(r.softDelete.Name), r.q(r.pk.Name), r.notDeleted(" AND "))
+		return r.execOne(ctx, query, r.now().UTC(), id)
+	}
+
+	return r.execOne(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s=?", r.q(r.config.Table), r.q(r.pk.Name)), id)
+}
+
+/*
+GetByID reads the row with primary key id into dest, a pointer to
+Model. It returns ErrNotFound when there is no such row
+*/
+func (r *Repository) GetByID(ctx context.Context, dest interface{}, id interface{}) error {
+	if _, err := r.record(dest); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("%s WHERE %s=?%s", r.selectAll(), r.q(r.pk.Name), r.notDeleted(" AND "))
+	err := sqldatabase.Get(ctx, r.querier(ctx), dest, r.config.Rebind(query), id)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	return err
+}
+
+/*
+List reads the rows matching options into dest, a pointer to a slice
+of Model
+*/
+func (r *Repository) List(ctx context.Context, dest interface{}, options listing.ListOptions) error {
+	destType := reflect.TypeOf(dest)
+
+	if destType.Kind() != reflect.Ptr || destType.Elem().Kind() != reflect.Slice || destType.Elem().Elem() != r.modelType {
+		return fmt.Errorf("%w: expected *[]%s but got %s", ErrWrongModel, r.modelType, destType)
+	}
+
+	query, args := options.ApplyDialect(r.config.Dialect, r.selectAll()+r.notDeleted(" WHERE "))
+	return sqldatabase.Select(ctx, r.querier(ctx), dest, r.config.Rebind(query), args...)
+}
+
+/*
+Count returns how many rows match the filters in options, for paging
+*/
+func (r *Repository) Count(ctx context.Context, options listing.ListOptions) (int, error) {
+	var count int
+
+	query := "SELECT COUNT(*) FROM " + r.q(r.config.Table) + r.notDeleted(" WHERE ")
+	where, args := listing.ListOptions{Filters: options.Filters}.Where()
+
+	if where != "" {
+		if r.softDelete != nil {
+			query += " AND " + where
+		} else {
+			query += " WHERE " + where
+		}
+	}
+
+	rows, err := r.querier(ctx).QueryContext(ctx, r.config.Rebind(query), args...)
+
+	if err != nil {
+		return 0, fmt.Errorf("error counting %s: %w", r.config.Table, err)
+	}
+
+	defer rows.Close()
+
+	if rows.Next() {
+		err = rows.Scan(&count)
+	}
+
+	if err == nil {
+		err = rows.Err()
+	}
+
+	return count, err
+}
+
+/*
+insertReturning inserts with a RETURNING clause, scanning the generated
+primary key into pkValue. This is how Postgres returns generated keys
+*/
+func (r *Repository) insertReturning(ctx context.Context, query string, pkValue reflect.Value, args []interface{}) error {
+	rows, err := r.querier(ctx).QueryContext(ctx, r.config.Rebind(query), args...)
+
+	if err != nil {
+		return fmt.Errorf("error inserting into %s: %w", r.config.Table, err)
+	}
+
+	defer rows.Close()
+
+	if rows.Next() {
+		err = rows.Scan(pkValue.Addr().Interface())
+	}
+
+	if err == nil {
+		err = rows.Err()
+	}
+
+	if err != nil {
+		return fmt.Errorf("error reading inserted ID from %s: %w", r.config.Table, err)
+	}
+
+	return nil
+}
+
+func (r *Repository) execOne(ctx context.Context, query string, args ...interface{}) error {
+	result, err := r.querier(ctx).ExecContext(ctx, r.config.Rebind(query), args...)
+
+	if err != nil {
+		return fmt.Errorf("error writing to %s: %w", r.config.Table, err)
+	}
+
+	affected, err := result.RowsAffected()
+
+	if err != nil {
+		return fmt.Errorf("error writing to %s: %w", r.config.Table, err)
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) record(model interface{}) (reflect.Value, error) {
+	value := reflect.ValueOf(model)
+
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Type() != r.modelType {
+		return value, fmt.Errorf("%w: expected *%s but got %T", ErrWrongModel, r.modelType, model)
+	}
+
+	return value.Elem(), nil
+}
+
+func (r *Repository) querier(ctx context.Context) sqldatabase.Querier {
+	return sqldatabase.QuerierFromContext(ctx, r.config.DB)
+}
+
+/*
+q quotes an identifier for the dialect
+*/
+func (r *Repository) q(identifier string) string {
+	return r.config.Dialect.Quote(identifier)
+}
+
+func (r *Repository) selectAll() string {
+	names := make([]string, 0, len(r.columns))
+
+	for _, column := range r.columns {
+		names = append(names, r.q(column.Name))
+	}
+
+	return fmt.Sprintf("SELECT %s FROM %s", strings.Join(names, ", "), r.q(r.config.Table))
+}
+
+/*
+notDeleted returns the soft delete condition joined with keyword, or
+nothing when the model has no softdelete column
+*/
+func (r *Repository) notDeleted(keyword string) string {
+	if r.softDelete == nil {
+		return ""
+	}
+
+	return keyword + r.q(r.softDelete.Name) + " IS NULL"
+}
+
+func placeholders(count int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", count), ", ")
+}
+
+func isInteger(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+
+	return false
+}
+
+func toInt64(value reflect.Value) int64 {
+	switch value.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(value.Uint())
+
+	default:
+		return value.Int()
+	}
+}
+
+func setInteger(value reflect.Value, id int64) {
+	switch value.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value.SetUint(uint64(id))
+
+	default:
+		value.SetInt(id)
+	}
+}
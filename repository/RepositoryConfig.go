@@ -0,0 +1,26 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package repository
+
+import (
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+)
+
+/*
+RepositoryConfig configures a Repository. Model is a zero value of the
+struct the repository stores, such as User{}, and Table is its table.
+
+Set Dialect to write SQL for a particular database. Identifiers are then
+quoted, placeholders rebound, generated keys returned with RETURNING
+where supported, and pages fetched with the dialect's paging clause.
+Rebind overrides the dialect's placeholders
+*/
+type RepositoryConfig struct {
+	DB      sqldatabase.DB
+	Dialect sqldatabase.Dialect
+	Model   interface{}
+	Rebind  func(query string) string
+	Table   string
+}
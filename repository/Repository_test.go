@@ -0,0 +1,326 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/listing"
+	"github.com/ResurgenceIT/kit/v6/repository"
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+)
+
+type user struct {
+	ID        int64 `db:"id,pk"`
+	Email     string
+	DeletedAt *time.Time `db:"deleted_at,softdelete"`
+}
+
+type tag struct {
+	Name  string `db:"name,pk"`
+	Color string
+}
+
+type call struct {
+	query string
+	args  []interface{}
+}
+
+/*
+recordingDB records every statement. Execs affect affected rows, and
+queries return rows with the given columns and values
+*/
+func recordingDB(calls *[]call, affected int64, columns []string, values ...[]interface{}) *sqldatabase.MockDB {
+	return &sqldatabase.MockDB{
+		ExecContextFunc: func(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+			*calls = append(*calls, call{query: query, args: args})
+
+			return &sqldatabase.MockResult{
+				LastInsertIdFunc: func() (int64, error) { return 42, nil },
+				RowsAffectedFunc: func() (int64, error) { return affected, nil },
+			}, nil
+		},
+		QueryContextFunc: func(ctx context.Context, query string, args ...interface{}) (sqldatabase.Rows, error) {
+			*calls = append(*calls, call{query: query, args: args})
+			index := -1
+
+			return &sqldatabase.MockRows{
+				CloseFunc:   func() error { return nil },
+				ColumnsFunc: func() ([]string, error) { return columns, nil },
+				ErrFunc:     func() error { return nil },
+				NextFunc: func() bool {
+					index++
+					return index < len(values)
+				},
+				ScanFunc: func(dst ...interface{}) error {
+					for column, destination := range dst {
+						if value := values[index][column]; value != nil {
+							reflect.ValueOf(destination).Elem().Set(reflect.ValueOf(value))
+						}
+					}
+
+					return nil
+				},
+			}, nil
+		},
+	}
+}
+
+func TestRepositoryWrites(t *testing.T) {
+	tests := []struct {
+		name          string
+		model         interface{}
+		affected      int64
+		run           func(ctx context.Context, repo *repository.Repository) error
+		expectedQuery string
+		expectedArgs  []interface{}
+		expectedErr   error
+	}{
+		{
+			name:     "Insert with a generated key",
+			model:    user{},
+			affected: 1,
+			run: func(ctx context.Context, repo *repository.Repository) error {
+				return repo.Insert(ctx, &user{Email: "a@b.c"})
+			},
+			expectedQuery: "INSERT INTO users (email, deleted_at) VALUES (?, ?)",
+			expectedArgs:  []interface{}{"a@b.c", (*time.Time)(nil)},
+		},
+		{
+			name:     "Insert with a given key",
+			model:    tag{},
+			affected: 1,
+			run: func(ctx context.Context, repo *repository.Repository) error {
+				return repo.Insert(ctx, &tag{Name: "urgent", Color: "red"})
+			},
+			expectedQuery: "INSERT INTO users (name, color) VALUES (?, ?)",
+			expectedArgs:  []interface{}{"urgent", "red"},
+		},
+		{
+			name:     "Update",
+			model:    user{},
+			affected: 1,
+			run: func(ctx context.Context, repo *repository.Repository) error {
+				return repo.Update(ctx, &user{ID: 7, Email: "a@b.c"})
+			},
+			expectedQuery: "UPDATE users SET email=?, deleted_at=? WHERE id=? AND deleted_at IS NULL",
+			expectedArgs:  []interface{}{"a@b.c", (*time.Time)(nil), int64(7)},
+		},
+		{
+			name:          "Update a missing row",
+			model:         user{},
+			run:           func(ctx context.Context, repo *repository.Repository) error { return repo.Update(ctx, &user{ID: 7}) },
+			expectedQuery: "UPDATE users SET email=?, deleted_at=? WHERE id=? AND deleted_at IS NULL",
+			expectedArgs:  []interface{}{"", (*time.Time)(nil), int64(7)},
+			expectedErr:   repository.ErrNotFound,
+		},
+		{
+			name:          "Soft delete",
+			model:         user{},
+			affected:      1,
+			run:           func(ctx context.Context, repo *repository.Repository) error { return repo.Delete(ctx, 7) },
+			expectedQuery: "UPDATE users SET deleted_at=? WHERE id=? AND deleted_at IS NULL",
+		},
+		{
+			name:          "Hard delete",
+			model:         tag{},
+			affected:      1,
+			run:           func(ctx context.Context, repo *repository.Repository) error { return repo.Delete(ctx, "urgent") },
+			expectedQuery: "DELETE FROM users WHERE name=?",
+			expectedArgs:  []interface{}{"urgent"},
+		},
+		{
+			name:        "Wrong model",
+			model:       user{},
+			run:         func(ctx context.Context, repo *repository.Repository) error { return repo.Insert(ctx, &tag{}) },
+			expectedErr: repository.ErrWrongModel,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls := []call{}
+			repo, err := repository.NewRepository(repository.RepositoryConfig{
+				DB:    recordingDB(&calls, test.affected, nil),
+				Model: test.model,
+				Table: "users",
+			})
+
+			if err != nil {
+				t.Fatalf("Expected no error but got '%s'", err)
+			}
+
+			if err = test.run(context.Background(), repo); !errors.Is(err, test.expectedErr) {
+				t.Fatalf("Expected error '%v' but got '%v'", test.expectedErr, err)
+			}
+
+			if test.expectedQuery == "" {
+				return
+			}
+
+			if len(calls) != 1 || calls[0].query != test.expectedQuery || (test.expectedArgs != nil && !reflect.DeepEqual(calls[0].args, test.expectedArgs)) {
+				t.Errorf("Expected '%s' %v but got %+v", test.expectedQuery, test.expectedArgs, calls)
+			}
+		})
+	}
+}
+
+func TestSoftDeleteTime(t *testing.T) {
+	calls := []call{}
+	repo, _ := repository.NewRepository(repository.RepositoryConfig{DB: recordingDB(&calls, 1, nil), Model: user{}, Table: "users"})
+	before := time.Now().UTC()
+
+	if err := repo.Delete(context.Background(), 7); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	if deletedAt, ok := calls[0].args[0].(time.Time); !ok || deletedAt.Before(before) || calls[0].args[1] != 7 {
+		t.Errorf("Expected the current time and ID 7 but got %v", calls[0].args)
+	}
+}
+
+func TestInsertSetsGeneratedID(t *testing.T) {
+	calls := []call{}
+	repo, _ := repository.NewRepository(repository.RepositoryConfig{DB: recordingDB(&calls, 1, nil), Model: user{}, Table: "users"})
+	u := &user{Email: "a@b.c"}
+
+	if err := repo.Insert(context.Background(), u); err != nil || u.ID != 42 {
+		t.Errorf("Expected ID 42 but got %d, %v", u.ID, err)
+	}
+}
+
+func TestInsertReturningWithDialect(t *testing.T) {
+	calls := []call{}
+	db := recordingDB(&calls, 1, []string{"id"}, []interface{}{int64(9)})
+	repo, _ := repository.NewRepository(repository.RepositoryConfig{DB: db, Dialect: sqldatabase.Postgres, Model: user{}, Table: "users"})
+	u := &user{Email: "a@b.c"}
+
+	if err := repo.Insert(context.Background(), u); err != nil || u.ID != 9 {
+		t.Fatalf("Expected ID 9 but got %d, %v", u.ID, err)
+	}
+
+	expected := `INSERT INTO "users" ("email", "deleted_at") VALUES ($1, $2) RETURNING "id"`
+
+	if calls[0].query != expected {
+		t.Errorf("Expected '%s' but got '%s'", expected, calls[0].query)
+	}
+}
+
+func TestListWithDialect(t *testing.T) {
+	calls := []call{}
+	db := recordingDB(&calls, 0, []string{"id", "email", "deleted_at"})
+	repo, _ := repository.NewRepository(repository.RepositoryConfig{DB: db, Dialect: sqldatabase.SQLServer, Model: user{}, Table: "users"})
+	options := listing.ListOptions{Limit: 10, Page: 2, Sorts: []listing.Sort{{Column: "email"}}}
+	users := []user{}
+
+	if err := repo.List(context.Background(), &users, options); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := "SELECT [id], [email], [deleted_at] FROM [users] WHERE [deleted_at] IS NULL ORDER BY email ASC OFFSET 10 ROWS FETCH NEXT 10 ROWS ONLY"
+
+	if calls[0].query != expected {
+		t.Errorf("Expected '%s' but got '%s'", expected, calls[0].query)
+	}
+}
+
+func TestRepositoryReads(t *testing.T) {
+	calls := []call{}
+	db := recordingDB(&calls, 0, []string{"id", "email", "deleted_at"}, []interface{}{int64(7), "a@b.c", nil})
+	repo, _ := repository.NewRepository(repository.RepositoryConfig{DB: db, Model: user{}, Table: "users"})
+	ctx := context.Background()
+
+	u := user{}
+
+	if err := repo.GetByID(ctx, &u, 7); err != nil || u.ID != 7 || u.Email != "a@b.c" {
+		t.Errorf("Expected user 7 but got %+v, %v", u, err)
+	}
+
+	parser := listing.NewParser(listing.ListingConfig{Filterable: map[string]string{"email": "email"}, Sortable: map[string]string{"id": "id"}})
+	options, _ := parser.Parse(map[string][]string{"filter[email][contains]": {"b.c"}, "sort": {"id"}})
+	users := []user{}
+
+	if err := repo.List(ctx, &users, options); err != nil || len(users) != 1 {
+		t.Errorf("Expected 1 user but got %+v, %v", users, err)
+	}
+
+	countRepo, _ := repository.NewRepository(repository.RepositoryConfig{DB: recordingDB(&calls, 0, []string{"count"}, []interface{}{5}), Model: user{}, Table: "users"})
+
+	if count, err := countRepo.Count(ctx, options); err != nil || count != 5 {
+		t.Errorf("Expected a count of 5 but got %d, %v", count, err)
+	}
+
+	expected := []string{
+		"SELECT id, email, deleted_at FROM users WHERE id=? AND deleted_at IS NULL",
+		"SELECT id, email, deleted_at FROM users WHERE deleted_at IS NULL AND email LIKE ? ORDER BY id ASC LIMIT 25 OFFSET 0",
+		"SELECT COUNT(*) FROM users WHERE deleted_at IS NULL AND email LIKE ?",
+	}
+
+	for index, query := range expected {
+		if calls[index].query != query {
+			t.Errorf("Expected '%s' but got '%s'", query, calls[index].query)
+		}
+	}
+
+	if err := repo.List(ctx, &[]tag{}, options); !errors.Is(err, repository.ErrWrongModel) {
+		t.Errorf("Expected ErrWrongModel but got '%v'", err)
+	}
+}
+
+func TestGetByIDNotFound(t *testing.T) {
+	calls := []call{}
+	repo, _ := repository.NewRepository(repository.RepositoryConfig{DB: recordingDB(&calls, 0, []string{"id"}), Model: user{}, Table: "users"})
+
+	if err := repo.GetByID(context.Background(), &user{}, 7); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but got '%v'", err)
+	}
+
+	if _, err := repository.NewRepository(repository.RepositoryConfig{DB: recordingDB(&calls, 0, nil), Model: struct{ Name string }{}, Table: "x"}); !errors.Is(err, repository.ErrNoPrimaryKey) {
+		t.Errorf("Expected ErrNoPrimaryKey but got '%v'", err)
+	}
+}
+
+type order struct {
+	ID      int64
+	Status  string
+	Version int `db:"version,version"`
+}
+
+func TestOptimisticLocking(t *testing.T) {
+	calls := []call{}
+	repo, _ := repository.NewRepository(repository.RepositoryConfig{DB: recordingDB(&calls, 1, nil), Model: order{}, Table: "orders"})
+	o := &order{Status: "new"}
+
+	if err := repo.Insert(context.Background(), o); err != nil || o.Version != 1 {
+		t.Fatalf("Expected version 1 after insert but got %d, %v", o.Version, err)
+	}
+
+	if err := repo.Update(context.Background(), o); err != nil || o.Version != 2 {
+		t.Fatalf("Expected version 2 after update but got %d, %v", o.Version, err)
+	}
+
+	expected := "UPDATE orders SET status=?, version=version+1 WHERE id=? AND version=?"
+
+	if calls[1].query != expected || !reflect.DeepEqual(calls[1].args, []interface{}{"new", int64(42), int64(1)}) {
+		t.Errorf("Expected '%s' but got %+v", expected, calls[1])
+	}
+
+	conflicted, _ := repository.NewRepository(repository.RepositoryConfig{DB: recordingDB(&calls, 0, []string{"version"}, []interface{}{int64(5)}), Model: order{}, Table: "orders"})
+
+	if err := conflicted.Update(context.Background(), o); !errors.Is(err, sqldatabase.ErrConflict) || o.Version != 2 {
+		t.Errorf("Expected ErrConflict with the version unchanged but got %d, '%v'", o.Version, err)
+	}
+
+	missing, _ := repository.NewRepository(repository.RepositoryConfig{DB: recordingDB(&calls, 0, []string{"version"}), Model: order{}, Table: "orders"})
+
+	if err := missing.Update(context.Background(), o); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but got '%v'", err)
+	}
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package requestid
+
+import (
+	"net/http"
+
+	"github.com/ResurgenceIT/kit/v6/ids"
+	"github.com/labstack/echo/v4"
+)
+
+/*
+Assigner gives each request an ID, accepting the one the caller sent or
+making a new one. The ID is stored in the request's context, where
+FromContext finds it, and echoed in the response header
+*/
+type Assigner struct {
+	config AssignerConfig
+}
+
+/*
+NewAssigner creates an Assigner, filling in defaults for anything not
+set in config
+*/
+func NewAssigner(config AssignerConfig) *Assigner {
+	if config.Generator == nil {
+		config.Generator = func() string { return ids.NewV7().String() }
+	}
+
+	if config.Header == "" {
+		config.Header = Header
+	}
+
+	return &Assigner{
+		config: config,
+	}
+}
+
+/*
+Middleware assigns request IDs for the Echo framework. Add it before
+other middleware so everything after it can log the ID
+*/
+func (a *Assigner) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		ctx.SetRequest(a.assign(ctx.Response(), ctx.Request()))
+		return next(ctx)
+	}
+}
+
+/*
+HTTPMiddleware assigns request IDs for a plain net/http handler
+*/
+func (a *Assigner) HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, a.assign(w, r))
+	})
+}
+
+/*
+assign picks the request's ID. One already in the context, such as from
+serverstats, is kept, then the incoming header is used if it's valid
+*/
+func (a *Assigner) assign(w http.ResponseWriter, r *http.Request) *http.Request {
+	id := FromContext(r.Context())
+
+	if id == "" && !a.config.IgnoreIncoming && Valid(r.Header.Get(a.config.Header)) {
+		id = r.Header.Get(a.config.Header)
+	}
+
+	if id == "" {
+		id = a.config.Generator()
+	}
+
+	w.Header().Set(a.config.Header, id)
+	return r.WithContext(NewContext(r.Context(), id))
+}
@@ -0,0 +1,22 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package requestid
+
+/*
+AssignerConfig configures an Assigner. Header is the header request IDs
+are read from and written to, and defaults to X-Request-ID. Generator
+makes new IDs, and defaults to time ordered UUIDs from the ids package.
+
+Incoming IDs are accepted so a request can be followed across services.
+Set IgnoreIncoming on services that face the internet and don't trust
+their callers. Incoming IDs longer than 128 characters, or with
+characters other than letters, digits, and . _ : / + = -, are always
+replaced, so they can't be used to forge log lines
+*/
+type AssignerConfig struct {
+	Generator      func() string
+	Header         string
+	IgnoreIncoming bool
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package requestid
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Header is the header request IDs are read from, written to, and forwarded in
+	Header = "X-Request-ID"
+
+	// LogField is the log field request IDs are written to
+	LogField = "requestID"
+)
+
+type contextKey struct{}
+
+/*
+NewContext returns a copy of ctx carrying a request ID. Use it to keep
+an ID on work that outlives the request, such as a queued job
+*/
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+/*
+FromContext returns the request ID carried by ctx, or an empty string
+*/
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+/*
+Valid reports whether an incoming request ID is safe to accept. IDs
+must be 1 to 128 characters of letters, digits, and . _ : / + = -
+*/
+func Valid(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '.', r == '_', r == ':', r == '/', r == '+', r == '=', r == '-':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+Logger returns logger with the request ID from ctx added as the
+requestID field. When ctx has no ID, logger is returned as is.
+
+	requestid.Logger(ctx.Request().Context(), logger).Info("order placed")
+*/
+func Logger(ctx context.Context, logger logrus.FieldLogger) logrus.FieldLogger {
+	if id := FromContext(ctx); id != "" {
+		return logger.WithField(LogField, id)
+	}
+
+	return logger
+}
+
+/*
+LogHook is a logrus hook that adds the request ID to entries logged with
+a context, so every line logged with WithContext carries it.
+
+	logger.AddHook(requestid.LogHook{})
+	logger.WithContext(ctx).Info("order placed")
+*/
+type LogHook struct{}
+
+/*
+Levels returns every level, so all entries get the request ID
+*/
+func (LogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+/*
+Fire adds the request ID to an entry that has a context
+*/
+func (LogHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+
+	if id := FromContext(entry.Context); id != "" {
+		if _, ok := entry.Data[LogField]; !ok {
+			entry.Data[LogField] = id
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package requestid_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/apierror"
+	"github.com/ResurgenceIT/kit/v6/httpclient"
+	"github.com/ResurgenceIT/kit/v6/requestid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAssigner(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   requestid.AssignerConfig
+		incoming string
+		expect   string
+	}{
+		{name: "Generated", expect: "generated"},
+		{name: "Incoming", incoming: "abc-123", expect: "abc-123"},
+		{name: "Forged", incoming: "abc\nlevel=error msg=forged", expect: "generated"},
+		{name: "Too long", incoming: strings.Repeat("a", 129), expect: "generated"},
+		{name: "Ignored", config: requestid.AssignerConfig{IgnoreIncoming: true}, incoming: "abc-123", expect: "generated"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.config.Generator = func() string { return "generated" }
+			assigner := requestid.NewAssigner(test.config)
+
+			var got string
+
+			handler := assigner.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = requestid.FromContext(r.Context())
+			}))
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.Header.Set(requestid.Header, test.incoming)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			if got != test.expect || recorder.Header().Get(requestid.Header) != test.expect {
+				t.Errorf("Expected %q but got %q and header %q", test.expect, got, recorder.Header().Get(requestid.Header))
+			}
+		})
+	}
+}
+
+func TestDefaultGenerator(t *testing.T) {
+	assigner := requestid.NewAssigner(requestid.AssignerConfig{})
+	seen := map[string]bool{}
+
+	for i := 0; i < 10; i++ {
+		recorder := httptest.NewRecorder()
+		assigner.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		id := recorder.Header().Get(requestid.Header)
+
+		if len(id) != 36 || seen[id] {
+			t.Fatalf("Expected a unique UUID but got %q", id)
+		}
+
+		seen[id] = true
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	var forwarded string
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Get(requestid.Header)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer upstream.Close()
+
+	client := httpclient.NewClient(httpclient.ClientConfig{DisableRetry: true})
+	output := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.SetOutput(output)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	e := echo.New()
+	e.HTTPErrorHandler = apierror.NewEchoErrorHandler(apierror.EchoErrorHandlerConfig{Logger: logger})
+	e.Use(requestid.NewAssigner(requestid.AssignerConfig{}).Middleware)
+
+	e.GET("/orders", func(ctx echo.Context) error {
+		err := client.Get(ctx.Request().Context(), upstream.URL, nil)
+		return apierror.Internal(err)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	request.Header.Set(requestid.Header, "req-42")
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, request)
+
+	if forwarded != "req-42" {
+		t.Errorf("Expected the ID to be forwarded but got %q", forwarded)
+	}
+
+	problem := apierror.Problem{}
+	_ = json.Unmarshal(recorder.Body.Bytes(), &problem)
+
+	if problem.RequestID != "req-42" {
+		t.Errorf("Expected the ID in the problem but got %s", recorder.Body.String())
+	}
+
+	if !strings.Contains(output.String(), `"requestID":"req-42"`) {
+		t.Errorf("Expected the ID in the log but got %s", output.String())
+	}
+}
+
+func TestLogHook(t *testing.T) {
+	output := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.SetOutput(output)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.AddHook(requestid.LogHook{})
+
+	ctx := requestid.NewContext(context.Background(), "job-7")
+	logger.WithContext(ctx).Info("working")
+	logger.Info("no context")
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+
+	if len(lines) != 2 || !strings.Contains(lines[0], `"requestID":"job-7"`) || strings.Contains(lines[1], "requestID") {
+		t.Errorf("Unexpected log output %s", output.String())
+	}
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package respond
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+/*
+ArrayWriterConfig configures an ArrayWriter. When Envelope is set the
+array is written as the "data" of an envelope. FlushEvery is how many
+items are written between flushes to the client, and defaults to 100
+*/
+type ArrayWriterConfig struct {
+	Envelope   bool
+	FlushEvery int
+}
+
+/*
+ArrayWriter streams a JSON array one item at a time, so large result
+sets never have to be held in memory. Call Close when done to finish
+the array.
+
+	w.Header().Set("Content-Type", "application/json")
+	writer := respond.NewArrayWriter(w, respond.ArrayWriterConfig{Envelope: true})
+	defer writer.Close()
+
+	for rows.Next() {
+		...
+		if err = writer.Write(row); err != nil {
+			return err
+		}
+	}
+*/
+type ArrayWriter struct {
+	config  ArrayWriterConfig
+	count   int
+	encoder *json.Encoder
+	started bool
+	w       io.Writer
+}
+
+/*
+NewArrayWriter creates an ArrayWriter that writes to w. When w is an
+http.Flusher the output is flushed every FlushEvery items
+*/
+func NewArrayWriter(w io.Writer, config ArrayWriterConfig) *ArrayWriter {
+	if config.FlushEvery <= 0 {
+		config.FlushEvery = 100
+	}
+
+	return &ArrayWriter{
+		config:  config,
+		encoder: json.NewEncoder(w),
+		w:       w,
+	}
+}
+
+/*
+Write adds an item to the array
+*/
+func (a *ArrayWriter) Write(item interface{}) error {
+	separator := ","
+
+	if !a.started {
+		separator = a.opening()
+		a.started = true
+	}
+
+	if _, err := io.WriteString(a.w, separator); err != nil {
+		return fmt.Errorf("error writing JSON array: %w", err)
+	}
+
+	if err := a.encoder.Encode(item); err != nil {
+		return fmt.Errorf("error writing JSON array item: %w", err)
+	}
+
+	a.count++
+
+	if a.count%a.config.FlushEvery == 0 {
+		a.flush()
+	}
+
+	return nil
+}
+
+/*
+Close finishes the array. An empty array is written if no items were
+*/
+func (a *ArrayWriter) Close() error {
+	closing := "]"
+
+	if !a.started {
+		closing = a.opening() + "]"
+		a.started = true
+	}
+
+	if a.config.Envelope {
+		closing += "}"
+	}
+
+	if _, err := io.WriteString(a.w, closing+"\n"); err != nil {
+		return fmt.Errorf("error writing JSON array: %w", err)
+	}
+
+	a.flush()
+	return nil
+}
+
+/*
+Count returns how many items have been written
+*/
+func (a *ArrayWriter) Count() int {
+	return a.count
+}
+
+func (a *ArrayWriter) opening() string {
+	if a.config.Envelope {
+		return `{"data":[`
+	}
+
+	return "["
+}
+
+func (a *ArrayWriter) flush() {
+	if flusher, ok := a.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package respond
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+/*
+csvRecords turns a slice of structs, or pointers to structs, into a
+header and rows. Columns are the exported fields, named by their json
+tags. It returns false for anything else
+*/
+func csvRecords(data interface{}) ([][]string, bool) {
+	value := reflect.ValueOf(data)
+
+	if value.Kind() != reflect.Slice {
+		return nil, false
+	}
+
+	elementType := value.Type().Elem()
+
+	if elementType.Kind() == reflect.Ptr {
+		elementType = elementType.Elem()
+	}
+
+	if elementType.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	header := make([]string, 0, elementType.NumField())
+	indexes := make([]int, 0, elementType.NumField())
+
+	for index := 0; index < elementType.NumField(); index++ {
+		field := elementType.Field(index)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+
+		if field.PkgPath != "" || name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		header = append(header, name)
+		indexes = append(indexes, index)
+	}
+
+	result := make([][]string, 0, value.Len()+1)
+	result = append(result, header)
+
+	for row := 0; row < value.Len(); row++ {
+		element := reflect.Indirect(value.Index(row))
+		record := make([]string, len(indexes))
+
+		if element.IsValid() {
+			for column, index := range indexes {
+				record[column] = csvValue(element.Field(index))
+			}
+		}
+
+		result = append(result, record)
+	}
+
+	return result, true
+}
+
+func csvValue(value reflect.Value) string {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return ""
+		}
+
+		value = value.Elem()
+	}
+
+	if t, ok := value.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+
+	return fmt.Sprint(value.Interface())
+}
+
+func writeCSV(w http.ResponseWriter, r *http.Request, status int, records [][]string) error {
+	w.Header().Set("Content-Type", MIMECSV+"; charset=utf-8")
+	w.WriteHeader(status)
+
+	if r.Method == http.MethodHead {
+		return nil
+	}
+
+	writer := csv.NewWriter(w)
+
+	if err := writer.WriteAll(records); err != nil {
+		return fmt.Errorf("error writing CSV response: %w", err)
+	}
+
+	return nil
+}
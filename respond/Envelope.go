@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package respond
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ResurgenceIT/kit/v6/apierror"
+	"github.com/ResurgenceIT/kit/v6/paging"
+)
+
+/*
+Envelope wraps every response body, so clients always find results under
+"data", errors under "error", and paging under "pagination"
+*/
+type Envelope struct {
+	Data       interface{}        `json:"data,omitempty"`
+	Error      *apierror.Problem  `json:"error,omitempty"`
+	Meta       interface{}        `json:"meta,omitempty"`
+	Pagination *paging.PagingInfo `json:"pagination,omitempty"`
+}
+
+/*
+Write sends an envelope with status. The format is negotiated from the
+request: CSV when the "format" query parameter is "csv" or the Accept
+header prefers text/csv and Data is a slice, otherwise JSON. HEAD
+requests get the headers without a body
+*/
+func Write(w http.ResponseWriter, r *http.Request, status int, envelope Envelope) error {
+	if envelope.Error == nil && Negotiate(r, MIMEJSON, MIMECSV) == MIMECSV {
+		if records, ok := csvRecords(envelope.Data); ok {
+			return writeCSV(w, r, status, records)
+		}
+	}
+
+	return JSON(w, r, status, envelope)
+}
+
+/*
+JSON sends value as JSON with status
+*/
+func JSON(w http.ResponseWriter, r *http.Request, status int, value interface{}) error {
+	w.Header().Set("Content-Type", MIMEJSON+"; charset=utf-8")
+	w.WriteHeader(status)
+
+	if r.Method == http.MethodHead {
+		return nil
+	}
+
+	return json.NewEncoder(w).Encode(value)
+}
+
+/*
+OK sends data in an envelope with a 200
+*/
+func OK(w http.ResponseWriter, r *http.Request, data interface{}) error {
+	return Write(w, r, http.StatusOK, Envelope{Data: data})
+}
+
+/*
+Created sends data in an envelope with a 201
+*/
+func Created(w http.ResponseWriter, r *http.Request, data interface{}) error {
+	return Write(w, r, http.StatusCreated, Envelope{Data: data})
+}
+
+/*
+Page sends one page of results with its paging information
+*/
+func Page(w http.ResponseWriter, r *http.Request, data interface{}, pagination paging.PagingInfo) error {
+	return Write(w, r, http.StatusOK, Envelope{Data: data, Pagination: &pagination})
+}
+
+/*
+Error sends err in an envelope, with the status and body apierror maps
+it to. Internal errors are reported without their details
+*/
+func Error(w http.ResponseWriter, r *http.Request, err error, mappers ...apierror.Mapper) error {
+	problem := apierror.FromError(err, mappers...)
+	return Write(w, r, problem.Status, Envelope{Error: problem})
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package respond
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	MIMECSV  = "text/csv"
+	MIMEJSON = "application/json"
+)
+
+/*
+Negotiate picks the offered media type the request prefers. The
+"format" query parameter wins when it names an offer's subtype, such as
+"csv". Otherwise the Accept header is used, honoring q values and
+wildcards. The first offer is returned when nothing matches
+*/
+func Negotiate(r *http.Request, offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+
+	if format := r.URL.Query().Get("format"); format != "" {
+		for _, offer := range offers {
+			if strings.HasSuffix(offer, "/"+format) {
+				return offer
+			}
+		}
+	}
+
+	best := offers[0]
+	bestQuality := -1.0
+
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaRange, quality := parseMediaRange(part)
+
+		if mediaRange == "" || quality <= bestQuality {
+			continue
+		}
+
+		for _, offer := range offers {
+			if matchesMediaRange(offer, mediaRange) {
+				best = offer
+				bestQuality = quality
+				break
+			}
+		}
+	}
+
+	return best
+}
+
+func parseMediaRange(part string) (string, float64) {
+	fields := strings.Split(part, ";")
+	mediaRange := strings.ToLower(strings.TrimSpace(fields[0]))
+	quality := 1.0
+
+	for _, parameter := range fields[1:] {
+		parameter = strings.TrimSpace(parameter)
+
+		if strings.HasPrefix(parameter, "q=") {
+			if q, err := strconv.ParseFloat(strings.TrimPrefix(parameter, "q="), 64); err == nil {
+				quality = q
+			}
+		}
+	}
+
+	if quality <= 0 {
+		return "", 0
+	}
+
+	return mediaRange, quality
+}
+
+func matchesMediaRange(offer, mediaRange string) bool {
+	if mediaRange == "*/*" || mediaRange == offer {
+		return true
+	}
+
+	if strings.HasSuffix(mediaRange, "/*") {
+		return strings.HasPrefix(offer, strings.TrimSuffix(mediaRange, "*"))
+	}
+
+	return false
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package respond_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/apierror"
+	"github.com/ResurgenceIT/kit/v6/paging"
+	"github.com/ResurgenceIT/kit/v6/respond"
+)
+
+type user struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Secret string `json:"-"`
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		accept   string
+		expected string
+	}{
+		{name: "No Accept header", target: "/", expected: respond.MIMEJSON},
+		{name: "Prefers CSV", target: "/", accept: "text/csv", expected: respond.MIMECSV},
+		{name: "Quality values", target: "/", accept: "text/csv;q=0.5, application/json", expected: respond.MIMEJSON},
+		{name: "Wildcard subtype", target: "/", accept: "text/*", expected: respond.MIMECSV},
+		{name: "Refused type", target: "/", accept: "application/json;q=0, */*;q=0.1", expected: respond.MIMEJSON},
+		{name: "Unknown type", target: "/", accept: "application/xml", expected: respond.MIMEJSON},
+		{name: "Format parameter wins", target: "/?format=csv", accept: "application/json", expected: respond.MIMECSV},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, test.target, nil)
+			request.Header.Set("Accept", test.accept)
+
+			if actual := respond.Negotiate(request, respond.MIMEJSON, respond.MIMECSV); actual != test.expected {
+				t.Errorf("Expected %s but got %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	users := []user{{ID: 1, Name: "Adam", Secret: "x"}, {ID: 2, Name: "Bea, Jr"}}
+	pagination := paging.PagingInfo{}
+	pagination.Calculate(1, 2, 3)
+
+	tests := []struct {
+		name        string
+		target      string
+		write       func(w http.ResponseWriter, r *http.Request) error
+		status      int
+		contentType string
+		expected    string
+	}{
+		{
+			name:        "Data",
+			target:      "/",
+			write:       func(w http.ResponseWriter, r *http.Request) error { return respond.Created(w, r, users[0]) },
+			status:      http.StatusCreated,
+			contentType: "application/json; charset=utf-8",
+			expected:    `{"data":{"id":1,"name":"Adam"}}`,
+		},
+		{
+			name:        "Page",
+			target:      "/",
+			write:       func(w http.ResponseWriter, r *http.Request) error { return respond.Page(w, r, users[:1], pagination) },
+			status:      http.StatusOK,
+			contentType: "application/json; charset=utf-8",
+			expected:    `{"data":[{"id":1,"name":"Adam"}],"pagination":{"currentPage":1,"end":2,"hasNextPage":true,"hasPreviousPage":false,"nextPage":2,"pageSize":2,"previousPage":1,"start":0,"totalItems":3,"totalPages":2}}`,
+		},
+		{
+			name:        "CSV",
+			target:      "/?format=csv",
+			write:       func(w http.ResponseWriter, r *http.Request) error { return respond.OK(w, r, users) },
+			status:      http.StatusOK,
+			contentType: "text/csv; charset=utf-8",
+			expected:    "id,name\n1,Adam\n2,\"Bea, Jr\"\n",
+		},
+		{
+			name:        "CSV falls back to JSON for a single item",
+			target:      "/?format=csv",
+			write:       func(w http.ResponseWriter, r *http.Request) error { return respond.OK(w, r, users[0]) },
+			status:      http.StatusOK,
+			contentType: "application/json; charset=utf-8",
+			expected:    `{"data":{"id":1,"name":"Adam"}}`,
+		},
+		{
+			name:        "Error",
+			target:      "/?format=csv",
+			write:       func(w http.ResponseWriter, r *http.Request) error { return respond.Error(w, r, apierror.NotFound("no such user")) },
+			status:      http.StatusNotFound,
+			contentType: "application/json; charset=utf-8",
+			expected:    `{"error":{"code":"not-found","detail":"no such user","status":404,"title":"Not Found","type":"about:blank"}}`,
+		},
+		{
+			name:        "Internal errors are hidden",
+			target:      "/",
+			write:       func(w http.ResponseWriter, r *http.Request) error { return respond.Error(w, r, errors.New("db down")) },
+			status:      http.StatusInternalServerError,
+			contentType: "application/json; charset=utf-8",
+			expected:    `{"error":{"code":"internal-error","status":500,"title":"Internal Server Error","type":"about:blank"}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+
+			if err := test.write(recorder, httptest.NewRequest(http.MethodGet, test.target, nil)); err != nil {
+				t.Fatalf("Expected no error but got '%s'", err)
+			}
+
+			if recorder.Code != test.status || recorder.Header().Get("Content-Type") != test.contentType {
+				t.Errorf("Expected %d %s but got %d %s", test.status, test.contentType, recorder.Code, recorder.Header().Get("Content-Type"))
+			}
+
+			if actual := recorder.Body.String(); actual != test.expected && actual != test.expected+"\n" {
+				t.Errorf("Expected body %s but got %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestArrayWriter(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   respond.ArrayWriterConfig
+		items    []interface{}
+		expected string
+	}{
+		{name: "Empty", expected: "[]"},
+		{name: "Items", items: []interface{}{1, "two", user{ID: 3}}, expected: `[1,"two",{"id":3,"name":""}]`},
+		{name: "Empty envelope", config: respond.ArrayWriterConfig{Envelope: true}, expected: `{"data":[]}`},
+		{name: "Envelope", config: respond.ArrayWriterConfig{Envelope: true, FlushEvery: 1}, items: []interface{}{1, 2}, expected: `{"data":[1,2]}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			writer := respond.NewArrayWriter(buffer, test.config)
+
+			for _, item := range test.items {
+				if err := writer.Write(item); err != nil {
+					t.Fatalf("Expected no error but got '%s'", err)
+				}
+			}
+
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Expected no error but got '%s'", err)
+			}
+
+			compacted := &bytes.Buffer{}
+
+			if err := json.Compact(compacted, buffer.Bytes()); err != nil {
+				t.Fatalf("Expected valid JSON but got '%s': %s", buffer.String(), err)
+			}
+
+			if compacted.String() != test.expected {
+				t.Errorf("Expected %s but got %s", test.expected, compacted.String())
+			}
+
+			if writer.Count() != len(test.items) {
+				t.Errorf("Expected a count of %d but got %d", len(test.items), writer.Count())
+			}
+		})
+	}
+}
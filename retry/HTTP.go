@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+/*
+RetryableStatuses are the HTTP statuses worth retrying: timeouts, rate
+limits, and server errors that are usually temporary
+*/
+var RetryableStatuses = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooEarly,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+/*
+StatusError is an HTTP response with an unsuccessful status. Its
+RetryAfter comes from the response's Retry-After header
+*/
+type StatusError struct {
+	Retry      time.Duration
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected HTTP status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+/*
+RetryAfter returns how long the server asked callers to wait
+*/
+func (e *StatusError) RetryAfter() time.Duration {
+	return e.Retry
+}
+
+/*
+CheckResponse returns a *StatusError for responses with a status of 400
+or above, and nil otherwise. Use it inside a retried function, with
+RetryOnStatus deciding which statuses are retried
+*/
+func CheckResponse(response *http.Response) error {
+	if response.StatusCode < 400 {
+		return nil
+	}
+
+	return &StatusError{
+		Retry:      parseRetryAfter(response.Header.Get("Retry-After"), time.Now()),
+		StatusCode: response.StatusCode,
+	}
+}
+
+/*
+RetryOnStatus returns a RetryIf predicate that retries StatusErrors with
+one of the given statuses, or RetryableStatuses when none are given.
+Other errors, such as network errors, are retried too
+*/
+func RetryOnStatus(statuses ...int) func(err error) bool {
+	if len(statuses) == 0 {
+		statuses = RetryableStatuses
+	}
+
+	return func(err error) bool {
+		var statusErr *StatusError
+
+		if !errors.As(err, &statusErr) {
+			return true
+		}
+
+		for _, status := range statuses {
+			if statusErr.StatusCode == status {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+/*
+RetryOnErrors returns a RetryIf predicate that only retries errors
+matching one of targets with errors.Is
+*/
+func RetryOnErrors(targets ...error) func(err error) bool {
+	return func(err error) bool {
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+/*
+parseRetryAfter reads a Retry-After header, which is either a number of
+seconds or an HTTP date
+*/
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+
+	return 0
+}
@@ -0,0 +1,201 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+/*
+IRetrier describes something that can retry a function
+*/
+type IRetrier interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+/*
+Retrier calls a function until it succeeds, backing off exponentially
+between attempts
+*/
+type Retrier struct {
+	config RetrierConfig
+	random *rand.Rand
+	mutex  sync.Mutex
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+/*
+NewRetrier creates a Retrier, filling in defaults for anything not set
+in config
+*/
+func NewRetrier(config RetrierConfig) *Retrier {
+	if config.InitialInterval <= 0 {
+		config.InitialInterval = time.Millisecond * 100
+	}
+
+	if config.MaxInterval <= 0 {
+		config.MaxInterval = time.Second * 30
+	}
+
+	if config.Multiplier < 1 {
+		config.Multiplier = 2
+	}
+
+	if config.Jitter == 0 {
+		config.Jitter = 0.5
+	}
+
+	if config.MaxAttempts == 0 {
+		config.MaxAttempts = 5
+	}
+
+	if config.RetryIf == nil {
+		config.RetryIf = func(err error) bool { return true }
+	}
+
+	return &Retrier{
+		config: config,
+		random: rand.New(rand.NewSource(time.Now().UnixNano())),
+		now:    time.Now,
+		sleep:  sleep,
+	}
+}
+
+/*
+Do calls fn until it returns nil, returns a Permanent error or one
+RetryIf rejects, or the attempt or time limit is reached. The error from
+the last attempt is returned, wrapped with how many attempts were made.
+Waiting stops early when ctx is canceled
+*/
+func (r *Retrier) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	start := r.now()
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+
+		if err == nil {
+			return nil
+		}
+
+		var permanent *permanentError
+
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+
+		if ctx.Err() != nil || errors.Is(err, context.Canceled) || !r.config.RetryIf(err) {
+			return err
+		}
+
+		if r.config.MaxAttempts > 0 && attempt >= r.config.MaxAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		wait := r.Backoff(attempt, err)
+
+		if r.config.MaxElapsedTime > 0 && r.now().Add(wait).Sub(start) > r.config.MaxElapsedTime {
+			return fmt.Errorf("giving up after %d attempts and %s: %w", attempt, r.now().Sub(start).Round(time.Millisecond), err)
+		}
+
+		if r.config.OnRetry != nil {
+			r.config.OnRetry(attempt, err, wait)
+		}
+
+		if sleepErr := r.sleep(ctx, wait); sleepErr != nil {
+			return fmt.Errorf("%w (last error: %s)", sleepErr, err.Error())
+		}
+	}
+}
+
+/*
+Backoff returns how long to wait after the given attempt failed with
+err. A server asking for a longer wait, through Retry-After, is honored.
+Use it to schedule retries that don't fit in a single Do call, such as
+queued jobs
+*/
+func (r *Retrier) Backoff(attempt int, err error) time.Duration {
+	wait := float64(r.config.InitialInterval) * math.Pow(r.config.Multiplier, float64(attempt-1))
+	wait = math.Min(wait, float64(r.config.MaxInterval))
+
+	if r.config.Jitter > 0 {
+		r.mutex.Lock()
+		wait += wait * r.config.Jitter * (r.random.Float64()*2 - 1)
+		r.mutex.Unlock()
+	}
+
+	result := time.Duration(wait)
+
+	if retryAfter := RetryAfter(err); retryAfter > result {
+		result = retryAfter
+	}
+
+	return result
+}
+
+/*
+Do retries fn with the default RetrierConfig
+*/
+func Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return NewRetrier(RetrierConfig{}).Do(ctx, fn)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+/*
+Permanent wraps an error so it isn't retried. Do returns the original
+error
+*/
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &permanentError{err: err}
+}
+
+/*
+RetryAfter returns how long an error asks callers to wait before trying
+again, or zero. Errors ask by having a RetryAfter() time.Duration
+method, as StatusError does
+*/
+func RetryAfter(err error) time.Duration {
+	var retryAfter interface{ RetryAfter() time.Duration }
+
+	if errors.As(err, &retryAfter) {
+		return retryAfter.RetryAfter()
+	}
+
+	return 0
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,36 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package retry
+
+import "time"
+
+/*
+RetrierConfig configures how a Retrier backs off between attempts.
+
+The first retry waits InitialInterval (default 100ms), and each one
+after that waits Multiplier (default 2) times longer, up to MaxInterval
+(default 30 seconds). Jitter (default 0.5) randomizes each wait by up to
+that fraction either way, so clients that failed together don't retry
+together. Set Jitter below zero to disable it.
+
+Retrying stops after MaxAttempts attempts (default 5), or once
+MaxElapsedTime has passed since the first attempt, whichever comes
+first. A negative MaxAttempts removes the attempt limit, in which case
+MaxElapsedTime or the context should bound the retries.
+
+RetryIf decides which errors are worth retrying, and defaults to every
+error except Permanent errors and context cancellation. OnRetry is
+called before each wait, for logging or metrics
+*/
+type RetrierConfig struct {
+	InitialInterval time.Duration
+	Jitter          float64
+	MaxAttempts     int
+	MaxElapsedTime  time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64
+	OnRetry         func(attempt int, err error, wait time.Duration)
+	RetryIf         func(err error) bool
+}
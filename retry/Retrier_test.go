@@ -0,0 +1,179 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/retry"
+)
+
+var (
+	errTemporary = errors.New("temporary")
+	errFatal     = errors.New("fatal")
+)
+
+func TestDo(t *testing.T) {
+	tests := []struct {
+		name             string
+		config           retry.RetrierConfig
+		results          []error
+		expectedErr      error
+		expectedAttempts int
+	}{
+		{
+			name:             "Succeeds first time",
+			results:          []error{nil},
+			expectedAttempts: 1,
+		},
+		{
+			name:             "Succeeds after retries",
+			results:          []error{errTemporary, errTemporary, nil},
+			expectedAttempts: 3,
+		},
+		{
+			name:             "Gives up after max attempts",
+			config:           retry.RetrierConfig{MaxAttempts: 3},
+			results:          []error{errTemporary, errTemporary, errTemporary, nil},
+			expectedErr:      errTemporary,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "Permanent errors stop retries",
+			results:          []error{errTemporary, retry.Permanent(errFatal), nil},
+			expectedErr:      errFatal,
+			expectedAttempts: 2,
+		},
+		{
+			name:             "RetryIf rejects error",
+			config:           retry.RetrierConfig{RetryIf: retry.RetryOnErrors(errTemporary)},
+			results:          []error{errTemporary, errFatal, nil},
+			expectedErr:      errFatal,
+			expectedAttempts: 2,
+		},
+		{
+			name:             "Retryable status",
+			config:           retry.RetrierConfig{RetryIf: retry.RetryOnStatus()},
+			results:          []error{&retry.StatusError{StatusCode: http.StatusServiceUnavailable}, nil},
+			expectedAttempts: 2,
+		},
+		{
+			name:             "Non-retryable status",
+			config:           retry.RetrierConfig{RetryIf: retry.RetryOnStatus()},
+			results:          []error{&retry.StatusError{StatusCode: http.StatusBadRequest}, nil},
+			expectedErr:      &retry.StatusError{},
+			expectedAttempts: 1,
+		},
+		{
+			name:             "Max elapsed time",
+			config:           retry.RetrierConfig{InitialInterval: time.Millisecond * 20, MaxAttempts: -1, MaxElapsedTime: time.Millisecond * 30},
+			results:          []error{errTemporary, errTemporary, errTemporary, errTemporary, nil},
+			expectedErr:      errTemporary,
+			expectedAttempts: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := test.config
+			config.Jitter = -1
+
+			if config.InitialInterval == 0 {
+				config.InitialInterval = time.Millisecond
+			}
+
+			retries := 0
+			config.OnRetry = func(attempt int, err error, wait time.Duration) {
+				retries++
+			}
+
+			attempts := 0
+			err := retry.NewRetrier(config).Do(context.Background(), func(ctx context.Context) error {
+				result := test.results[attempts]
+				attempts++
+				return result
+			})
+
+			if test.expectedErr == nil && err != nil {
+				t.Errorf("Expected no error but got '%v'", err)
+			}
+
+			var statusErr *retry.StatusError
+
+			if _, ok := test.expectedErr.(*retry.StatusError); ok && !errors.As(err, &statusErr) {
+				t.Errorf("Expected a StatusError but got '%v'", err)
+			} else if !ok && test.expectedErr != nil && !errors.Is(err, test.expectedErr) {
+				t.Errorf("Expected error '%v' but got '%v'", test.expectedErr, err)
+			}
+
+			if attempts != test.expectedAttempts {
+				t.Errorf("Expected %d attempts but got %d", test.expectedAttempts, attempts)
+			}
+
+			if retries != attempts-1 && err == nil {
+				t.Errorf("Expected OnRetry to be called %d times but got %d", attempts-1, retries)
+			}
+		})
+	}
+}
+
+func TestDoCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	retrier := retry.NewRetrier(retry.RetrierConfig{InitialInterval: time.Hour, Jitter: -1})
+
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		cancel()
+	}()
+
+	err := retrier.Do(ctx, func(ctx context.Context) error {
+		return errTemporary
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled but got '%v'", err)
+	}
+}
+
+func TestCheckResponse(t *testing.T) {
+	tests := []struct {
+		name               string
+		status             int
+		retryAfter         string
+		expectedErr        bool
+		expectedRetryAfter time.Duration
+	}{
+		{name: "OK", status: http.StatusOK},
+		{name: "Not found", status: http.StatusNotFound, expectedErr: true},
+		{name: "Retry-After seconds", status: http.StatusTooManyRequests, retryAfter: "3", expectedErr: true, expectedRetryAfter: time.Second * 3},
+		{name: "Invalid Retry-After", status: http.StatusServiceUnavailable, retryAfter: "soon", expectedErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+
+			if test.retryAfter != "" {
+				recorder.Header().Set("Retry-After", test.retryAfter)
+			}
+
+			recorder.WriteHeader(test.status)
+			err := retry.CheckResponse(recorder.Result())
+
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("Expected error %v but got '%v'", test.expectedErr, err)
+			}
+
+			if actual := retry.RetryAfter(err); actual != test.expectedRetryAfter {
+				t.Errorf("Expected Retry-After %s but got %s", test.expectedRetryAfter, actual)
+			}
+		})
+	}
+}
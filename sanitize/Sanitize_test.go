@@ -0,0 +1,157 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package sanitize_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/sanitize"
+)
+
+func TestSanitizerHTML(t *testing.T) {
+	ugc := sanitize.NewSanitizer(sanitize.SanitizerConfig{})
+	mail := sanitize.NewSanitizer(sanitize.SanitizerConfig{Images: true, LinksInNewTab: true, Mail: true})
+	remote := sanitize.NewSanitizer(sanitize.SanitizerConfig{RemoteImages: true})
+
+	tests := []struct {
+		name      string
+		sanitizer *sanitize.Sanitizer
+		input     string
+		contains  []string
+		excludes  []string
+	}{
+		{
+			name:      "Script",
+			sanitizer: ugc,
+			input:     `<p onclick="steal()">Hello <strong>there</strong></p><script>alert(1)</script>`,
+			contains:  []string{"<p>Hello <strong>there</strong></p>"},
+			excludes:  []string{"script", "alert", "onclick"},
+		},
+		{
+			name:      "Links",
+			sanitizer: ugc,
+			input:     `<a href="https://example.com">ok</a><a href="JaVaScRiPt:alert(1)">bad</a><a href="/docs">relative</a>`,
+			contains:  []string{`href="https://example.com"`, `rel="nofollow noreferrer"`, `href="/docs"`, "bad"},
+			excludes:  []string{"javascript", "JaVaScRiPt", "target"},
+		},
+		{
+			name:      "No images by default",
+			sanitizer: ugc,
+			input:     `<img src="https://example.com/a.png"><iframe src="https://example.com"></iframe><form><input name="x"></form>`,
+			excludes:  []string{"img", "iframe", "form", "input"},
+		},
+		{
+			name:      "Mail layout",
+			sanitizer: mail,
+			input:     `<table width="600" cellpadding="0" bgcolor="#ffffff"><tr><td align="center" valign="top" style="color: #333; padding: 10px; position: fixed; background-image: url(https://tracker.example/p.gif)"><font face="Arial" color="red">Hi</font></td></tr></table>`,
+			contains:  []string{`width="600"`, `cellpadding="0"`, `bgcolor="#ffffff"`, `align="center"`, `valign="top"`, "color: #333", "padding: 10px", `<font face="Arial" color="red">`},
+			excludes:  []string{"position", "tracker"},
+		},
+		{
+			name:      "Mail images",
+			sanitizer: mail,
+			input:     `<img src="cid:logo@example.com" alt="Logo"><img src="https://tracker.example/pixel.gif"><a href="https://example.com">Site</a>`,
+			contains:  []string{`src="cid:logo@example.com"`, `alt="Logo"`, `target="_blank"`, "noopener"},
+			excludes:  []string{"tracker"},
+		},
+		{
+			name:      "Remote images",
+			sanitizer: remote,
+			input:     `<img src="https://example.com/a.png"><img src="javascript:alert(1)">`,
+			contains:  []string{`src="https://example.com/a.png"`},
+			excludes:  []string{"javascript"},
+		},
+		{
+			name:      "No styles outside mail",
+			sanitizer: ugc,
+			input:     `<p style="color: red" class="x">Hi</p>`,
+			contains:  []string{"<p>Hi</p>"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := test.sanitizer.HTML(test.input)
+
+			for _, s := range test.contains {
+				if !strings.Contains(result, s) {
+					t.Errorf("Expected %s in %s", s, result)
+				}
+			}
+
+			for _, s := range test.excludes {
+				if strings.Contains(result, s) {
+					t.Errorf("Expected no %s in %s", s, result)
+				}
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+	}{
+		{input: `<html><head><title>Hi</title><style>p { color: red }</style></head><body><p>Hello   <b>World</b></p><p>Second&nbsp;line &amp; more</p></body></html>`, expect: "Hello World\n\nSecond line & more"},
+		{input: `<h1>Title</h1><ul><li>One</li><li>Two</li></ul>Done`, expect: "Title\n\n- One\n- Two\n\nDone"},
+		{input: `Line one<br>Line two<script>alert(1)</script>`, expect: "Line one\nLine two"},
+		{input: `<a href="https://example.com/reset">Reset your password</a> or <a href="https://example.com">https://example.com</a>`, expect: "Reset your password (https://example.com/reset) or https://example.com"},
+		{input: `<table><tr><td>Item</td><td>$5</td></tr><tr><td>Tax</td><td>$1</td></tr></table>`, expect: "Item $5\nTax $1"},
+		{input: "<pre>a\n  b</pre>", expect: "a\n  b"},
+	}
+
+	for _, test := range tests {
+		if result := sanitize.Text(test.input); result != test.expect {
+			t.Errorf("Expected %q but got %q", test.expect, result)
+		}
+	}
+}
+
+func TestTextToHTML(t *testing.T) {
+	expect := "Hi &lt;b&gt;Adam&lt;/b&gt; &amp; co<br>\nBye"
+
+	if result := string(sanitize.TextToHTML("Hi <b>Adam</b> & co\r\nBye")); result != expect {
+		t.Errorf("Expected %q but got %q", expect, result)
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		schemes []string
+		safe    bool
+	}{
+		{url: "https://example.com/a?b=c", safe: true},
+		{url: "/relative/path", safe: true},
+		{url: "mailto:adam@example.com", safe: true},
+		{url: "javascript:alert(1)"},
+		{url: " JavaScript:alert(1)"},
+		{url: "java\tscript:alert(1)"},
+		{url: "java\x00script:alert(1)"},
+		{url: "data:text/html;base64,PHNjcmlwdD4="},
+		{url: "vbscript:msgbox"},
+		{url: "ftp://example.com/file", schemes: []string{"ftp"}, safe: true},
+		{url: "https://example.com", schemes: []string{"ftp"}},
+	}
+
+	for _, test := range tests {
+		err := sanitize.ValidateURL(test.url, test.schemes...)
+
+		if test.safe && err != nil {
+			t.Errorf("Expected %q to be safe but got %v", test.url, err)
+		}
+
+		if !test.safe && !errors.Is(err, sanitize.ErrUnsafeURL) {
+			t.Errorf("Expected %q to be unsafe", test.url)
+		}
+	}
+
+	if sanitize.SafeURL(" https://example.com\n ") != "https://example.com" || sanitize.SafeURL("javascript:alert(1)") != "" {
+		t.Errorf("Unexpected SafeURL result")
+	}
+}
@@ -0,0 +1,157 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package sanitize
+
+import (
+	"html/template"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/microcosm-cc/bluemonday/css"
+)
+
+var (
+	embeddedImage = regexp.MustCompile(`^(?i)(cid:|data:image/(gif|jpeg|png|webp);base64,)`)
+	fontSize      = regexp.MustCompile(`^[+-]?[1-7]$`)
+	hexColor      = regexp.MustCompile(`^#([0-9a-f]{3,4}|[0-9a-f]{8})$`)
+	htmlColor     = regexp.MustCompile(`^#?[0-9a-zA-Z]{1,20}$`)
+)
+
+var textElements = []string{
+	"abbr", "article", "b", "bdi", "bdo", "blockquote", "br", "cite", "code", "dd", "del", "details", "dfn", "div", "dl",
+	"dt", "em", "figcaption", "figure", "footer", "h1", "h2", "h3", "h4", "h5", "h6", "header", "hr", "i", "ins", "kbd",
+	"li", "mark", "ol", "p", "pre", "q", "rp", "rt", "ruby", "s", "samp", "section", "small", "span", "strike", "strong",
+	"sub", "summary", "sup", "time", "u", "ul", "var", "wbr",
+}
+
+var mailStyles = []string{
+	"border", "border-bottom", "border-collapse", "border-color", "border-left", "border-radius",
+	"border-right", "border-spacing", "border-style", "border-top", "border-width", "display", "font",
+	"font-family", "font-size", "font-style", "font-variant", "font-weight", "height", "letter-spacing", "line-height",
+	"list-style-type", "margin", "margin-bottom", "margin-left", "margin-right", "margin-top", "max-height", "max-width",
+	"min-height", "min-width", "padding", "padding-bottom", "padding-left", "padding-right", "padding-top",
+	"table-layout", "text-align", "text-decoration", "text-indent", "text-transform", "vertical-align", "white-space",
+	"width", "word-break", "word-spacing",
+}
+
+/*
+Sanitizer cleans untrusted HTML, such as posts written by users or the
+body of an email, by keeping only the elements, attributes, and URLs its
+config allows. Scripts, event handlers, forms, frames, and javascript:
+links are always removed. A Sanitizer is safe to share between goroutines
+*/
+type Sanitizer struct {
+	policy *bluemonday.Policy
+}
+
+/*
+NewSanitizer creates a Sanitizer, filling in defaults for anything not
+set in config
+*/
+func NewSanitizer(config SanitizerConfig) *Sanitizer {
+	if len(config.Schemes) == 0 {
+		config.Schemes = DefaultSchemes
+	}
+
+	policy := bluemonday.NewPolicy()
+	policy.AllowStandardAttributes()
+	policy.AllowElements(textElements...)
+	policy.AllowLists()
+	policy.AllowTables()
+
+	policy.RequireParseableURLs(true)
+	policy.AllowRelativeURLs(true)
+	policy.AllowURLSchemes(config.Schemes...)
+	policy.RequireNoFollowOnLinks(true)
+	policy.RequireNoReferrerOnLinks(true)
+	policy.AllowAttrs("href").OnElements("a")
+	policy.AllowAttrs("datetime").Matching(bluemonday.ISO8601).OnElements("del", "ins", "time")
+	policy.AddTargetBlankToFullyQualifiedLinks(config.LinksInNewTab)
+
+	if config.Images || config.RemoteImages {
+		policy.AllowAttrs("alt", "title").Matching(bluemonday.Paragraph).OnElements("img")
+		policy.AllowAttrs("height", "width").Matching(bluemonday.NumberOrPercent).OnElements("img")
+		policy.AllowURLSchemes("cid")
+		policy.AllowDataURIImages()
+
+		if config.RemoteImages {
+			policy.AllowAttrs("src").OnElements("img")
+		} else {
+			policy.AllowAttrs("src").Matching(embeddedImage).OnElements("img")
+		}
+	}
+
+	if config.Mail {
+		layout := []string{"div", "img", "p", "table", "tbody", "td", "tfoot", "th", "thead", "tr"}
+
+		policy.AllowElements("center", "font")
+		policy.AllowAttrs("class").Matching(bluemonday.SpaceSeparatedTokens).Globally()
+		policy.AllowAttrs("align").Matching(bluemonday.CellAlign).OnElements(layout...)
+		policy.AllowAttrs("valign").Matching(bluemonday.CellVerticalAlign).OnElements("tbody", "td", "tfoot", "th", "thead", "tr")
+		policy.AllowAttrs("bgcolor").Matching(htmlColor).OnElements("table", "td", "th", "tr")
+		policy.AllowAttrs("height", "width").Matching(bluemonday.NumberOrPercent).OnElements(layout...)
+		policy.AllowAttrs("border", "cellpadding", "cellspacing").Matching(bluemonday.Integer).OnElements("table")
+		policy.AllowAttrs("color").Matching(htmlColor).OnElements("font")
+		policy.AllowAttrs("face").Matching(bluemonday.Paragraph).OnElements("font")
+		policy.AllowAttrs("size").Matching(fontSize).OnElements("font")
+		policy.AllowStyles(mailStyles...).Globally()
+		policy.AllowStyles("background-color", "color").MatchingHandler(cssColor).Globally()
+	}
+
+	if len(config.Elements) > 0 {
+		policy.AllowElements(config.Elements...)
+	}
+
+	for element, attributes := range config.Attributes {
+		if element == "*" {
+			policy.AllowAttrs(attributes...).Globally()
+			continue
+		}
+
+		policy.AllowAttrs(attributes...).OnElements(element)
+	}
+
+	return &Sanitizer{
+		policy: policy,
+	}
+}
+
+/*
+HTML returns the input with everything the Sanitizer doesn't allow
+removed. The content of removed elements is kept, except for elements
+such as script and style whose content isn't text
+*/
+func (s *Sanitizer) HTML(input string) string {
+	return s.policy.Sanitize(input)
+}
+
+/*
+SafeHTML sanitizes the input and marks the result as safe, so templates
+output it without escaping it again
+*/
+func (s *Sanitizer) SafeHTML(input string) template.HTML {
+	return template.HTML(s.policy.Sanitize(input))
+}
+
+/*
+Funcs returns template functions for showing untrusted content. Add them
+to the render package's Funcs.
+
+	{{ sanitize .Post.Body }}
+	{{ textToHTML .Message.TextBody }}
+	<p>{{ plainText .Message.HTMLBody }}</p>
+*/
+func (s *Sanitizer) Funcs() template.FuncMap {
+	return template.FuncMap{
+		"plainText":  Text,
+		"sanitize":   s.SafeHTML,
+		"textToHTML": TextToHTML,
+	}
+}
+
+// cssColor also accepts the short hex colors, such as #333, that mail is full of
+func cssColor(value string) bool {
+	return hexColor.MatchString(value) || css.ColorHandler(value)
+}
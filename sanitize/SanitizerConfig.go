@@ -0,0 +1,57 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package sanitize
+
+/*
+SanitizerConfig chooses what HTML a Sanitizer keeps. The zero value keeps
+text formatting, headings, links, lists, quotes, code, and tables, which
+suits comments and posts written by users
+*/
+type SanitizerConfig struct {
+	/*
+		Attributes allows more attributes, keyed by element name. Use "*" to
+		allow them on every element. Values aren't checked, so never allow
+		event handlers such as onclick
+	*/
+	Attributes map[string][]string
+
+	/*
+		Elements allows more elements
+	*/
+	Elements []string
+
+	/*
+		Images allows img elements that embed their image as a data: URI or
+		refer to a mail attachment with cid:
+	*/
+	Images bool
+
+	/*
+		LinksInNewTab opens absolute links in a new tab, adding
+		rel="noopener"
+	*/
+	LinksInNewTab bool
+
+	/*
+		Mail allows the layout tables, presentational attributes, and inline
+		styles that HTML email is built with. Styles that load URLs or move
+		content out of the message, such as background-image and position,
+		are removed
+	*/
+	Mail bool
+
+	/*
+		RemoteImages also allows images loaded over http and https. Leave it
+		off when showing mail, so opening a message doesn't fire tracking
+		pixels
+	*/
+	RemoteImages bool
+
+	/*
+		Schemes are the URL schemes links may use. Relative links are always
+		allowed. Defaults to DefaultSchemes
+	*/
+	Schemes []string
+}
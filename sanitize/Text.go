@@ -0,0 +1,248 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package sanitize
+
+import (
+	"html/template"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var skippedElements = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Noscript: true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Template: true,
+	atom.Title:    true,
+}
+
+var paragraphElements = map[atom.Atom]bool{
+	atom.Blockquote: true,
+	atom.H1:         true,
+	atom.H2:         true,
+	atom.H3:         true,
+	atom.H4:         true,
+	atom.H5:         true,
+	atom.H6:         true,
+	atom.Hr:         true,
+	atom.Ol:         true,
+	atom.P:          true,
+	atom.Pre:        true,
+	atom.Table:      true,
+	atom.Ul:         true,
+}
+
+var lineElements = map[atom.Atom]bool{
+	atom.Address:    true,
+	atom.Article:    true,
+	atom.Br:         true,
+	atom.Dd:         true,
+	atom.Details:    true,
+	atom.Div:        true,
+	atom.Dl:         true,
+	atom.Dt:         true,
+	atom.Figcaption: true,
+	atom.Figure:     true,
+	atom.Footer:     true,
+	atom.Header:     true,
+	atom.Li:         true,
+	atom.Section:    true,
+	atom.Summary:    true,
+	atom.Tr:         true,
+}
+
+/*
+Text extracts the readable text from HTML, for search indexes, previews,
+and plain text versions of HTML email. Scripts, styles, and the head are
+dropped. Paragraphs, headings, and tables are separated by blank lines,
+list items start with "- ", and links show their URL after their text
+when the two differ. Entities are decoded, so escape the result before
+putting it back in HTML
+*/
+func Text(input string) string {
+	tokenizer := html.NewTokenizer(strings.NewReader(input))
+	writer := &textWriter{}
+
+	var (
+		skip     int
+		pre      int
+		link     string
+		linkFrom int
+	)
+
+	for {
+		tokenType := tokenizer.Next()
+
+		if tokenType == html.ErrorToken {
+			break
+		}
+
+		token := tokenizer.Token()
+
+		switch tokenType {
+		case html.TextToken:
+			if skip == 0 {
+				writer.text(token.Data, pre > 0)
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if token.DataAtom == atom.Body {
+				skip = 0
+			}
+
+			if skippedElements[token.DataAtom] {
+				if tokenType == html.StartTagToken {
+					skip++
+				}
+
+				continue
+			}
+
+			if skip > 0 {
+				continue
+			}
+
+			writer.block(token.DataAtom)
+
+			switch token.DataAtom {
+			case atom.A:
+				link = ""
+				linkFrom = writer.len()
+
+				for _, attr := range token.Attr {
+					if attr.Key == "href" {
+						link = attr.Val
+					}
+				}
+
+			case atom.Li:
+				writer.text("- ", false)
+
+			case atom.Pre:
+				pre++
+
+			case atom.Td, atom.Th:
+				writer.text(" ", false)
+			}
+
+		case html.EndTagToken:
+			if skippedElements[token.DataAtom] {
+				if skip > 0 {
+					skip--
+				}
+
+				continue
+			}
+
+			if skip > 0 {
+				continue
+			}
+
+			writer.block(token.DataAtom)
+
+			switch token.DataAtom {
+			case atom.A:
+				linkText := writer.from(linkFrom)
+				absolute := strings.HasPrefix(strings.ToLower(link), "http://") || strings.HasPrefix(strings.ToLower(link), "https://")
+
+				if absolute && strings.TrimSpace(linkText) != "" && !strings.Contains(linkText, link) {
+					writer.text(" ("+link+")", false)
+				}
+
+				link = ""
+
+			case atom.Pre:
+				if pre > 0 {
+					pre--
+				}
+			}
+		}
+	}
+
+	return writer.String()
+}
+
+/*
+TextToHTML escapes plain text, such as the text body of an email, and
+keeps its line breaks, so it shows in a page as it was written
+*/
+func TextToHTML(text string) template.HTML {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(template.HTMLEscapeString(text), "\n")
+
+	return template.HTML(strings.Join(lines, "<br>\n"))
+}
+
+type textWriter struct {
+	builder  strings.Builder
+	newlines int
+	space    bool
+}
+
+func (w *textWriter) block(a atom.Atom) {
+	switch {
+	case paragraphElements[a]:
+		w.lineBreak(2)
+
+	case lineElements[a]:
+		w.lineBreak(1)
+	}
+}
+
+func (w *textWriter) lineBreak(n int) {
+	if n > w.newlines {
+		w.newlines = n
+	}
+
+	w.space = false
+}
+
+func (w *textWriter) text(s string, pre bool) {
+	if pre {
+		w.flush()
+		w.builder.WriteString(strings.ReplaceAll(s, "\r\n", "\n"))
+		return
+	}
+
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			w.space = w.newlines == 0
+			continue
+		}
+
+		w.flush()
+		w.builder.WriteRune(r)
+	}
+}
+
+// flush writes the line breaks or space waiting to go before the next text. Nothing is written before the first text
+func (w *textWriter) flush() {
+	if w.builder.Len() > 0 {
+		if w.newlines > 0 {
+			w.builder.WriteString(strings.Repeat("\n", w.newlines))
+		} else if w.space {
+			w.builder.WriteByte(' ')
+		}
+	}
+
+	w.newlines = 0
+	w.space = false
+}
+
+func (w *textWriter) len() int {
+	return w.builder.Len()
+}
+
+func (w *textWriter) from(i int) string {
+	return w.builder.String()[i:]
+}
+
+func (w *textWriter) String() string {
+	return strings.TrimSpace(w.builder.String())
+}
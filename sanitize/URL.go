@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package sanitize
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var (
+	ErrUnsafeURL = errors.New("unsafe URL")
+)
+
+/*
+DefaultSchemes are the URL schemes allowed when none are given
+*/
+var DefaultSchemes = []string{"http", "https", "mailto"}
+
+/*
+ValidateURL returns ErrUnsafeURL unless raw is a relative URL or uses one
+of the given schemes, DefaultSchemes when none are given. Check any URL
+from users before putting it in an href or src, as javascript: and data:
+URLs run code when clicked. Tricks that browsers see through, such as
+mixed case, tabs, newlines, and leading spaces, are caught
+*/
+func ValidateURL(raw string, schemes ...string) error {
+	if _, err := cleanURL(raw, schemes); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+/*
+SafeURL returns raw, without spaces around it or tabs and newlines in
+it, when ValidateURL accepts it, or an empty string when it doesn't
+*/
+func SafeURL(raw string, schemes ...string) string {
+	result, err := cleanURL(raw, schemes)
+
+	if err != nil {
+		return ""
+	}
+
+	return result
+}
+
+func cleanURL(raw string, schemes []string) (string, error) {
+	if len(schemes) == 0 {
+		schemes = DefaultSchemes
+	}
+
+	trimmed := strings.TrimFunc(raw, func(r rune) bool { return r <= ' ' })
+
+	// Browsers remove tabs and newlines anywhere in a URL, so "java\tscript:" is still a javascript: URL
+	cleaned := strings.NewReplacer("\t", "", "\n", "", "\r", "").Replace(trimmed)
+
+	for _, r := range cleaned {
+		if r < ' ' || r == 0x7f {
+			return "", fmt.Errorf("%w: %q", ErrUnsafeURL, raw)
+		}
+	}
+
+	u, err := url.Parse(cleaned)
+
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrUnsafeURL, raw)
+	}
+
+	if u.Scheme == "" {
+		return cleaned, nil
+	}
+
+	for _, scheme := range schemes {
+		if strings.EqualFold(u.Scheme, scheme) {
+			return cleaned, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %q", ErrUnsafeURL, raw)
+}
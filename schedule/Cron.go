@@ -0,0 +1,275 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCron = errors.New("invalid cron expression")
+
+/*
+ISchedule decides when a job runs next
+*/
+type ISchedule interface {
+	Next(after time.Time) time.Time
+}
+
+/*
+CronSchedule runs on times matching a cron expression
+*/
+type CronSchedule struct {
+	days     uint64
+	hours    uint64
+	minutes  uint64
+	months   uint64
+	weekdays uint64
+
+	daysRestricted     bool
+	weekdaysRestricted bool
+}
+
+type cronField struct {
+	max   int
+	min   int
+	names map[string]int
+}
+
+var (
+	minuteField  = cronField{min: 0, max: 59}
+	hourField    = cronField{min: 0, max: 23}
+	dayField     = cronField{min: 1, max: 31}
+	monthField   = cronField{min: 1, max: 12, names: map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}}
+	weekdayField = cronField{min: 0, max: 7, names: map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}}
+)
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+/*
+Cron parses a standard five field cron expression: minute, hour, day
+of month, month, and day of week. Fields can be *, a number, a range
+such as 1-5, a range or * followed by a step such as /15, or a comma
+separated list of those. Months and days of the week can be written as names, such as
+JAN or MON, and Sunday is 0 or 7. Like cron, when both day of month and
+day of week are restricted, a time matching either runs the job.
+
+The descriptors @yearly, @monthly, @weekly, @daily, and @hourly are
+accepted too, as is "@every <duration>", such as "@every 90s", which
+returns an interval schedule
+*/
+func Cron(expression string) (ISchedule, error) {
+	expression = strings.TrimSpace(expression)
+
+	if strings.HasPrefix(expression, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expression, "@every ")))
+
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCron, expression)
+		}
+
+		return Every(interval), nil
+	}
+
+	if descriptor, ok := cronDescriptors[strings.ToLower(expression)]; ok {
+		expression = descriptor
+	}
+
+	fields := strings.Fields(expression)
+
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: expected 5 fields but got %d in '%s'", ErrInvalidCron, len(fields), expression)
+	}
+
+	result := &CronSchedule{
+		daysRestricted:     fields[2] != "*",
+		weekdaysRestricted: fields[4] != "*",
+	}
+
+	var err error
+
+	parsers := []struct {
+		field  cronField
+		target *uint64
+	}{
+		{minuteField, &result.minutes},
+		{hourField, &result.hours},
+		{dayField, &result.days},
+		{monthField, &result.months},
+		{weekdayField, &result.weekdays},
+	}
+
+	for index, parser := range parsers {
+		if *parser.target, err = parser.field.parse(fields[index]); err != nil {
+			return nil, fmt.Errorf("%w: %s in '%s'", ErrInvalidCron, err.Error(), expression)
+		}
+	}
+
+	if result.weekdays&(1<<7) != 0 {
+		result.weekdays |= 1
+	}
+
+	return result, nil
+}
+
+/*
+MustCron is like Cron but panics if the expression is invalid. Use it
+for expressions written in code
+*/
+func MustCron(expression string) ISchedule {
+	schedule, err := Cron(expression)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return schedule
+}
+
+/*
+Next returns the first matching time after after, in after's location.
+A zero time is returned if nothing matches within five years, such as
+for February 30th
+*/
+func (c *CronSchedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !c.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if c.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if c.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (c *CronSchedule) matchesDay(t time.Time) bool {
+	day := c.days&(1<<uint(t.Day())) != 0
+	weekday := c.weekdays&(1<<uint(t.Weekday())) != 0
+
+	if c.daysRestricted && c.weekdaysRestricted {
+		return day || weekday
+	}
+
+	return day && weekday
+}
+
+/*
+parse turns a cron field into a bit set of the values it matches
+*/
+func (f cronField) parse(value string) (uint64, error) {
+	var result uint64
+
+	for _, part := range strings.Split(value, ",") {
+		step := 1
+		rangePart := part
+
+		if index := strings.Index(part, "/"); index > -1 {
+			var err error
+
+			if step, err = strconv.Atoi(part[index+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step '%s'", part)
+			}
+
+			rangePart = part[:index]
+		}
+
+		start, end := f.min, f.max
+
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+
+			if start, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+
+			end = start
+
+			if len(bounds) == 2 {
+				if end, err = f.value(bounds[1]); err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				end = f.max
+			}
+		}
+
+		if start > end {
+			return 0, fmt.Errorf("invalid range '%s'", part)
+		}
+
+		for v := start; v <= end; v += step {
+			result |= 1 << uint(v)
+		}
+	}
+
+	return result, nil
+}
+
+func (f cronField) value(value string) (int, error) {
+	if v, ok := f.names[strings.ToLower(value)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(value)
+
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("value '%s' out of range %d-%d", value, f.min, f.max)
+	}
+
+	return v, nil
+}
+
+/*
+IntervalSchedule runs at a fixed interval
+*/
+type IntervalSchedule struct {
+	Interval time.Duration
+}
+
+/*
+Every returns a schedule that runs every interval
+*/
+func Every(interval time.Duration) ISchedule {
+	return IntervalSchedule{Interval: interval}
+}
+
+/*
+Next returns after plus the interval
+*/
+func (s IntervalSchedule) Next(after time.Time) time.Time {
+	return after.Add(s.Interval)
+}
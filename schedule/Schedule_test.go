@@ -0,0 +1,221 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package schedule_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/clock"
+	"github.com/ResurgenceIT/kit/v6/schedule"
+)
+
+func TestCron(t *testing.T) {
+	base := time.Date(2021, time.March, 15, 10, 7, 30, 0, time.UTC) // A Monday
+
+	tests := []struct {
+		expression string
+		expected   time.Time
+		err        bool
+	}{
+		{expression: "* * * * *", expected: time.Date(2021, time.March, 15, 10, 8, 0, 0, time.UTC)},
+		{expression: "*/15 * * * *", expected: time.Date(2021, time.March, 15, 10, 15, 0, 0, time.UTC)},
+		{expression: "0 9-17 * * MON-FRI", expected: time.Date(2021, time.March, 15, 11, 0, 0, 0, time.UTC)},
+		{expression: "30 2 * * *", expected: time.Date(2021, time.March, 16, 2, 30, 0, 0, time.UTC)},
+		{expression: "0 0 1 */3 *", expected: time.Date(2021, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{expression: "0 12 * * sun", expected: time.Date(2021, time.March, 21, 12, 0, 0, 0, time.UTC)},
+		{expression: "0 12 * * 7", expected: time.Date(2021, time.March, 21, 12, 0, 0, 0, time.UTC)},
+		{expression: "0 0 13 * 5", expected: time.Date(2021, time.March, 19, 0, 0, 0, 0, time.UTC)},
+		{expression: "@monthly", expected: time.Date(2021, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{expression: "@every 90s", expected: base.Add(time.Second * 90)},
+		{expression: "0 0 30 2 *", expected: time.Time{}},
+		{expression: "* * * *", err: true},
+		{expression: "60 * * * *", err: true},
+		{expression: "5-1 * * * *", err: true},
+		{expression: "@every soon", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			s, err := schedule.Cron(test.expression)
+
+			if test.err {
+				if !errors.Is(err, schedule.ErrInvalidCron) {
+					t.Errorf("Expected ErrInvalidCron but got '%v'", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error but got '%v'", err)
+			}
+
+			if actual := s.Next(base); !actual.Equal(test.expected) {
+				t.Errorf("Expected %s but got %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+type recorder struct {
+	sync.Mutex
+	runs map[string]int
+}
+
+func (r *recorder) RecordJobRun(name string, duration time.Duration, err error) {
+	r.Lock()
+	r.runs[name]++
+	r.Unlock()
+}
+
+func TestScheduler(t *testing.T) {
+	stats := &recorder{runs: make(map[string]int)}
+	errs := make(chan error, 10)
+
+	scheduler := schedule.NewScheduler(schedule.SchedulerConfig{
+		OnError:  func(job string, err error) { errs <- err },
+		Recorder: stats,
+	})
+
+	var (
+		mutex sync.Mutex
+		ticks int
+	)
+
+	_ = scheduler.Add(schedule.Job{
+		Name:     "tick",
+		Schedule: schedule.Every(time.Millisecond * 10),
+		Run: func(ctx context.Context) error {
+			mutex.Lock()
+			ticks++
+			mutex.Unlock()
+			return nil
+		},
+	})
+
+	_ = scheduler.Add(schedule.Job{
+		Name:     "panics",
+		Schedule: schedule.Every(time.Hour),
+		Run: func(ctx context.Context) error {
+			panic("boom")
+		},
+	})
+
+	if err := scheduler.Add(schedule.Job{Name: "tick", Schedule: schedule.Every(time.Second), Run: func(ctx context.Context) error { return nil }}); !errors.Is(err, schedule.ErrDuplicateJob) {
+		t.Errorf("Expected ErrDuplicateJob but got '%v'", err)
+	}
+
+	scheduler.Start()
+	time.Sleep(time.Millisecond * 55)
+
+	if err := scheduler.RunNow(context.Background(), "panics"); err == nil {
+		t.Errorf("Expected the panic to be returned as an error")
+	}
+
+	if err := scheduler.Stop(context.Background()); err != nil {
+		t.Fatalf("Expected no error but got '%v'", err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if ticks < 3 {
+		t.Errorf("Expected at least 3 ticks but got %d", ticks)
+	}
+
+	if stats.runs["tick"] != ticks || stats.runs["panics"] != 1 {
+		t.Errorf("Expected every run to be recorded but got %v", stats.runs)
+	}
+
+	if len(errs) != 1 {
+		t.Errorf("Expected OnError to be called once but got %d", len(errs))
+	}
+
+	if history := scheduler.History("panics"); len(history) != 1 || history[0].Error == "" {
+		t.Errorf("Expected the failed run in history but got %+v", history)
+	}
+}
+
+func TestSchedulerOverlapAndTimeout(t *testing.T) {
+	scheduler := schedule.NewScheduler(schedule.SchedulerConfig{})
+	started := make(chan bool)
+
+	_ = scheduler.Add(schedule.Job{
+		Name:     "slow",
+		Schedule: schedule.Every(time.Hour),
+		Timeout:  time.Millisecond * 50,
+		Run: func(ctx context.Context) error {
+			started <- true
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+
+	result := make(chan error)
+
+	go func() {
+		result <- scheduler.RunNow(context.Background(), "slow")
+	}()
+
+	<-started
+
+	if err := scheduler.RunNow(context.Background(), "slow"); !errors.Is(err, schedule.ErrJobRunning) {
+		t.Errorf("Expected ErrJobRunning but got '%v'", err)
+	}
+
+	if err := <-result; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the timeout to cancel the run but got '%v'", err)
+	}
+
+	history := scheduler.History("slow")
+
+	if len(history) != 2 || !history[0].Skipped || history[1].Error == "" {
+		t.Errorf("Expected a skipped run then a timed out run but got %+v", history)
+	}
+}
+
+func TestSchedulerFakeClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2021, time.March, 15, 8, 30, 0, 0, time.UTC))
+	scheduler := schedule.NewScheduler(schedule.SchedulerConfig{Clock: fake, Location: time.UTC})
+	runs := make(chan time.Time, 10)
+
+	_ = scheduler.AddCron("morning report", "0 9 * * *", func(ctx context.Context) error {
+		runs <- fake.Now()
+		return nil
+	})
+
+	scheduler.Start()
+	defer func() { _ = scheduler.Stop(context.Background()) }()
+
+	fake.WaitForTimers(1)
+
+	if jobs := scheduler.Jobs(); !jobs[0].NextRunAt.Equal(time.Date(2021, time.March, 15, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the next run at 9:00 but got %s", jobs[0].NextRunAt)
+	}
+
+	fake.Advance(time.Minute * 29)
+
+	select {
+	case <-runs:
+		t.Fatalf("Expected no run before 9:00")
+	default:
+	}
+
+	fake.Advance(time.Minute)
+
+	select {
+	case ranAt := <-runs:
+		if ranAt.Hour() != 9 {
+			t.Errorf("Expected the job to run at 9:00 but got %s", ranAt)
+		}
+
+	case <-time.After(time.Second * 2):
+		t.Fatalf("Expected the job to run at 9:00")
+	}
+}
@@ -0,0 +1,386 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/clock"
+)
+
+var (
+	ErrDuplicateJob = errors.New("a job with this name is already scheduled")
+	ErrInvalidJob   = errors.New("job needs a name, schedule, and function")
+	ErrJobNotFound  = errors.New("job not found")
+	ErrJobRunning   = errors.New("job is already running")
+)
+
+/*
+Run is one run of a job. Skipped runs were due while the previous run
+was still going
+*/
+type Run struct {
+	Duration  time.Duration `json:"duration"`
+	Error     string        `json:"error,omitempty"`
+	Skipped   bool          `json:"skipped,omitempty"`
+	StartedAt time.Time     `json:"startedAt"`
+}
+
+/*
+JobStatus describes a scheduled job
+*/
+type JobStatus struct {
+	LastRun   *Run      `json:"lastRun,omitempty"`
+	Name      string    `json:"name"`
+	NextRunAt time.Time `json:"nextRunAt"`
+	Running   bool      `json:"running"`
+}
+
+/*
+Scheduler runs jobs on cron or interval schedules
+*/
+type Scheduler struct {
+	sync.Mutex
+
+	cancel  context.CancelFunc
+	config  SchedulerConfig
+	ctx     context.Context
+	jobs    map[string]*scheduledJob
+	random  *rand.Rand
+	running bool
+	wg      sync.WaitGroup
+}
+
+type scheduledJob struct {
+	history   []Run
+	job       Job
+	nextRunAt time.Time
+	running   int
+}
+
+/*
+NewScheduler creates a scheduler. Add jobs, then call Start
+*/
+func NewScheduler(config SchedulerConfig) *Scheduler {
+	if config.HistorySize <= 0 {
+		config.HistorySize = 20
+	}
+
+	if config.Clock == nil {
+		config.Clock = clock.Real{}
+	}
+
+	if config.Location == nil {
+		config.Location = time.Local
+	}
+
+	return &Scheduler{
+		config: config,
+		jobs:   make(map[string]*scheduledJob),
+		random: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+/*
+Add schedules a job. Jobs added after Start begin running right away
+*/
+func (s *Scheduler) Add(job Job) error {
+	if job.Name == "" || job.Schedule == nil || job.Run == nil {
+		return ErrInvalidJob
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.jobs[job.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateJob, job.Name)
+	}
+
+	scheduled := &scheduledJob{
+		history: make([]Run, 0, s.config.HistorySize),
+		job:     job,
+	}
+
+	s.jobs[job.Name] = scheduled
+
+	if s.running {
+		s.startJob(scheduled)
+	}
+
+	return nil
+}
+
+/*
+AddCron schedules fn to run on a cron expression. See Cron for the
+syntax
+*/
+func (s *Scheduler) AddCron(name, expression string, fn func(ctx context.Context) error) error {
+	schedule, err := Cron(expression)
+
+	if err != nil {
+		return err
+	}
+
+	return s.Add(Job{Name: name, Run: fn, Schedule: schedule})
+}
+
+/*
+Start begins running jobs on their schedules
+*/
+func (s *Scheduler) Start() {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.running {
+		return
+	}
+
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.running = true
+
+	for _, scheduled := range s.jobs {
+		s.startJob(scheduled)
+	}
+}
+
+/*
+Stop stops scheduling runs, cancels the context of running jobs, and
+waits for them to return, or for ctx to be done
+*/
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.Lock()
+
+	if !s.running {
+		s.Unlock()
+		return nil
+	}
+
+	s.running = false
+	s.cancel()
+	s.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+
+	case <-ctx.Done():
+		return fmt.Errorf("error waiting for running jobs: %w", ctx.Err())
+	}
+}
+
+/*
+RunNow runs a job immediately, outside its schedule, and waits for it
+to finish. Unless the job allows overlap, ErrJobRunning is returned when
+it is already running
+*/
+func (s *Scheduler) RunNow(ctx context.Context, name string) error {
+	s.Lock()
+	scheduled, ok := s.jobs[name]
+	s.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, name)
+	}
+
+	ran, err := s.run(ctx, scheduled)
+
+	if !ran {
+		return fmt.Errorf("%w: %s", ErrJobRunning, name)
+	}
+
+	return err
+}
+
+/*
+History returns a job's most recent runs, oldest first
+*/
+func (s *Scheduler) History(name string) []Run {
+	s.Lock()
+	defer s.Unlock()
+
+	scheduled, ok := s.jobs[name]
+
+	if !ok {
+		return nil
+	}
+
+	result := make([]Run, len(scheduled.history))
+	copy(result, scheduled.history)
+	return result
+}
+
+/*
+Jobs returns the status of every job, sorted by name
+*/
+func (s *Scheduler) Jobs() []JobStatus {
+	s.Lock()
+	defer s.Unlock()
+
+	result := make([]JobStatus, 0, len(s.jobs))
+
+	for name, scheduled := range s.jobs {
+		status := JobStatus{
+			Name:      name,
+			NextRunAt: scheduled.nextRunAt,
+			Running:   scheduled.running > 0,
+		}
+
+		if len(scheduled.history) > 0 {
+			lastRun := scheduled.history[len(scheduled.history)-1]
+			status.LastRun = &lastRun
+		}
+
+		result = append(result, status)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+/*
+startJob starts the goroutine that waits for a job's next run. The
+caller must hold a lock
+*/
+func (s *Scheduler) startJob(scheduled *scheduledJob) {
+	ctx := s.ctx
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+
+		for {
+			now := s.config.Clock.Now().In(s.config.Location)
+			next := scheduled.job.Schedule.Next(now)
+
+			if next.IsZero() {
+				return
+			}
+
+			wait := next.Sub(now) + s.jitter(scheduled.job.Jitter)
+
+			s.Lock()
+			scheduled.nextRunAt = now.Add(wait)
+			s.Unlock()
+
+			timer := s.config.Clock.NewTimer(wait)
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+
+			case <-timer.C():
+				s.wg.Add(1)
+
+				go func() {
+					defer s.wg.Done()
+					_, _ = s.run(ctx, scheduled)
+				}()
+			}
+		}
+	}()
+}
+
+/*
+run runs a job once, recording the run. ran is false when the run was
+skipped because the job was already running
+*/
+func (s *Scheduler) run(ctx context.Context, scheduled *scheduledJob) (ran bool, err error) {
+	job := scheduled.job
+	start := s.config.Clock.Now()
+
+	s.Lock()
+
+	if scheduled.running > 0 && !job.AllowOverlap {
+		s.record(scheduled, Run{Skipped: true, StartedAt: start})
+		s.Unlock()
+		return false, nil
+	}
+
+	scheduled.running++
+	s.Unlock()
+
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+
+	err = callJob(ctx, job)
+	duration := s.config.Clock.Since(start)
+
+	run := Run{Duration: duration, StartedAt: start}
+
+	if err != nil {
+		run.Error = err.Error()
+	}
+
+	s.Lock()
+	scheduled.running--
+	s.record(scheduled, run)
+	s.Unlock()
+
+	if s.config.Recorder != nil {
+		s.config.Recorder.RecordJobRun(job.Name, duration, err)
+	}
+
+	if err != nil && s.config.OnError != nil {
+		s.config.OnError(job.Name, err)
+	}
+
+	return true, err
+}
+
+/*
+record adds a run to a job's history. The caller must hold a lock
+*/
+func (s *Scheduler) record(scheduled *scheduledJob, run Run) {
+	if len(scheduled.history) >= s.config.HistorySize {
+		scheduled.history = append(scheduled.history[:0], scheduled.history[1:]...)
+	}
+
+	scheduled.history = append(scheduled.history, run)
+}
+
+func (s *Scheduler) jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	return time.Duration(s.random.Int63n(int64(max)))
+}
+
+/*
+callJob runs a job, turning a panic into an error
+*/
+func callJob(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job %s panicked: %v", job.Name, recovered)
+		}
+	}()
+
+	return job.Run(ctx)
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package schedule
+
+import (
+	"context"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/clock"
+)
+
+/*
+IJobRecorder records job runs. serverstats.ServerStats implements it,
+so scheduled jobs show up in the server's job stats
+*/
+type IJobRecorder interface {
+	RecordJobRun(name string, duration time.Duration, err error)
+}
+
+/*
+SchedulerConfig configures a Scheduler. Schedules are evaluated in
+Location, which defaults to time.Local. HistorySize is how many runs
+are kept per job, and defaults to 20. OnError is called when a run
+fails or panics, and Recorder, such as a ServerStats, receives every
+run. Clock defaults to the system clock, and a clock.Fake lets tests
+fire jobs without waiting
+*/
+type SchedulerConfig struct {
+	Clock       clock.IClock
+	HistorySize int
+	Location    *time.Location
+	OnError     func(job string, err error)
+	Recorder    IJobRecorder
+}
+
+/*
+Job is a function run on a schedule.
+
+Timeout, when set, cancels the context passed to Run after that long.
+Runs are skipped while the previous run is still going, unless
+AllowOverlap is set. Jitter delays each run by a random amount up to
+that long, so jobs on many servers don't all fire at once
+*/
+type Job struct {
+	AllowOverlap bool
+	Jitter       time.Duration
+	Name         string
+	Run          func(ctx context.Context) error
+	Schedule     ISchedule
+	Timeout      time.Duration
+}
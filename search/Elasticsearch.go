@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package search
+
+import (
+	"strings"
+)
+
+/*
+Elasticsearch turns a parsed search into an Elasticsearch query, ready
+to be encoded as the "query" of a search request. AND, OR, and NOT
+become bool queries. Words without a field use multi_match across the
+text paths. Keyword fields use term, or wildcard when the value has a
+*, text fields use match or match_phrase, and comparisons and ranges
+use range. A nil node returns match_all
+*/
+func (p *Parser) Elasticsearch(node Node) map[string]interface{} {
+	switch n := node.(type) {
+	case And:
+		return boolQuery("must", p.elasticsearchAll(n.Children))
+
+	case Or:
+		query := boolQuery("should", p.elasticsearchAll(n.Children))
+		query["bool"].(map[string]interface{})["minimum_should_match"] = 1
+		return query
+
+	case Not:
+		return boolQuery("must_not", []interface{}{p.Elasticsearch(n.Child)})
+
+	case Term:
+		return p.elasticsearchTerm(n)
+
+	case Range:
+		bounds := map[string]interface{}{}
+
+		if n.From != nil {
+			bounds[rangeKey("gt", n.IncludeFrom)] = n.From
+		}
+
+		if n.To != nil {
+			bounds[rangeKey("lt", n.IncludeTo)] = n.To
+		}
+
+		return map[string]interface{}{"range": map[string]interface{}{p.pathFor(n.Field): bounds}}
+	}
+
+	return map[string]interface{}{"match_all": map[string]interface{}{}}
+}
+
+func (p *Parser) elasticsearchAll(nodes []Node) []interface{} {
+	result := make([]interface{}, 0, len(nodes))
+
+	for _, node := range nodes {
+		result = append(result, p.Elasticsearch(node))
+	}
+
+	return result
+}
+
+func (p *Parser) elasticsearchTerm(term Term) map[string]interface{} {
+	if term.Field == "" {
+		query := map[string]interface{}{
+			"fields": p.config.TextPaths,
+			"query":  term.Value,
+		}
+
+		if term.Phrase {
+			query["type"] = "phrase"
+		}
+
+		return map[string]interface{}{"multi_match": query}
+	}
+
+	field := p.config.Fields[term.Field]
+	path := p.pathFor(term.Field)
+
+	switch {
+	case field.Type == FieldText && term.Phrase:
+		return map[string]interface{}{"match_phrase": map[string]interface{}{path: term.Value}}
+
+	case field.Type == FieldText:
+		return map[string]interface{}{"match": map[string]interface{}{path: term.Value}}
+
+	case field.Type == FieldKeyword && strings.Contains(term.Value, "*"):
+		return map[string]interface{}{"wildcard": map[string]interface{}{path: map[string]interface{}{"value": term.Value}}}
+
+	case term.Comparison == ">" || term.Comparison == ">=":
+		return map[string]interface{}{"range": map[string]interface{}{path: map[string]interface{}{rangeKey("gt", term.Comparison == ">="): term.Parsed}}}
+
+	case term.Comparison == "<" || term.Comparison == "<=":
+		return map[string]interface{}{"range": map[string]interface{}{path: map[string]interface{}{rangeKey("lt", term.Comparison == "<="): term.Parsed}}}
+	}
+
+	return map[string]interface{}{"term": map[string]interface{}{path: term.Parsed}}
+}
+
+func (p *Parser) pathFor(name string) string {
+	if path := p.config.Fields[name].Path; path != "" {
+		return path
+	}
+
+	return name
+}
+
+func boolQuery(occur string, clauses []interface{}) map[string]interface{} {
+	return map[string]interface{}{"bool": map[string]interface{}{occur: clauses}}
+}
+
+func rangeKey(key string, inclusive bool) string {
+	if inclusive {
+		return key + "e"
+	}
+
+	return key
+}
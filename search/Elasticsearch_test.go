@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package search_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/search"
+)
+
+func TestElasticsearchQueries(t *testing.T) {
+	pathParser := search.NewParser(search.ParserConfig{
+		Fields: map[string]search.Field{
+			"customer": {Path: "customer.name", Type: search.FieldText},
+			"status":   {},
+		},
+		TextPaths: []string{"notes"},
+	})
+
+	tests := []struct {
+		name     string
+		parser   *search.Parser
+		input    string
+		expected string
+	}{
+		{name: "Empty search", input: "", expected: `{"match_all":{}}`},
+		{name: "Word", input: "red", expected: `{"multi_match":{"fields":["notes","name"],"query":"red"}}`},
+		{name: "Implied AND", input: "red shoes", expected: `{"bool":{"must":[{"multi_match":{"fields":["notes","name"],"query":"red"}},{"multi_match":{"fields":["notes","name"],"query":"shoes"}}]}}`},
+		{name: "OR", input: "status:a OR status:b", expected: `{"bool":{"minimum_should_match":1,"should":[{"term":{"status":"a"}},{"term":{"status":"b"}}]}}`},
+		{name: "NOT", input: "-status:closed", expected: `{"bool":{"must_not":[{"term":{"status":"closed"}}]}}`},
+		{name: "Keyword", input: "sku:AB-1", expected: `{"term":{"sku":"AB-1"}}`},
+		{name: "Text field word", input: "name:bob", expected: `{"match":{"name":"bob"}}`},
+		{name: "Number", input: "total:25", expected: `{"term":{"total":25}}`},
+		{name: "Greater than", input: "total:>25", expected: `{"range":{"total":{"gt":25}}}`},
+		{name: "Greater than or equal", input: "total:>=25", expected: `{"range":{"total":{"gte":25}}}`},
+		{name: "Less than", input: "total:<25", expected: `{"range":{"total":{"lt":25}}}`},
+		{name: "Less than or equal", input: "placed:<=2021-06-30", expected: `{"range":{"placed":{"lte":"2021-06-30T00:00:00Z"}}}`},
+		{name: "Range open at the end", input: "total:{10 TO *]", expected: `{"range":{"total":{"gt":10}}}`},
+		{name: "Inclusive range", input: "placed:[2021-01-01 TO 2021-06-30]", expected: `{"range":{"placed":{"gte":"2021-01-01T00:00:00Z","lte":"2021-06-30T00:00:00Z"}}}`},
+		{name: "Path", parser: pathParser, input: `customer:"Bob Hope"`, expected: `{"match_phrase":{"customer.name":"Bob Hope"}}`},
+		{name: "Path defaults to the field name", parser: pathParser, input: "status:open", expected: `{"term":{"status":"open"}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := test.parser
+
+			if p == nil {
+				p = parser
+			}
+
+			node, err := p.Parse(test.input)
+
+			if err != nil {
+				t.Fatalf("Expected %s to parse but got %s", test.input, err.Error())
+			}
+
+			actual, _ := json.Marshal(p.Elasticsearch(node))
+
+			if string(actual) != test.expected {
+				t.Errorf("Expected %s but got %s", test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,616 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package search
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	ErrInvalidQuery = errors.New("invalid search")
+	ErrTooComplex   = errors.New("search is too complex")
+	ErrUnknownField = errors.New("field cannot be searched")
+)
+
+/*
+SyntaxError is a search that can't be parsed. Position is the offset,
+in characters, where the problem was found
+*/
+type SyntaxError struct {
+	Message  string
+	Position int
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s: %s at position %d", ErrInvalidQuery.Error(), e.Message, e.Position)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return ErrInvalidQuery
+}
+
+/*
+Parser parses search strings like users type them. The syntax is
+
+	red shoes              both words (AND is implied)
+	"red shoes"            the phrase
+	red OR blue            either word
+	NOT red, -red          without the word
+	(red OR blue) shoes    grouping
+	status:active          a field's value
+	name:"Bob Hope"        a phrase in a field
+	age:>21, age:<=65      comparisons
+	price:[10 TO 20]       an inclusive range; {10 TO 20} excludes the ends
+	date:[2021-01-01 TO *] an open range
+
+NOT binds tightest, then AND, then OR. Keywords must be uppercase, so
+"and" and "or" are searched for as words
+*/
+type Parser struct {
+	config ParserConfig
+}
+
+/*
+NewParser creates a search parser
+
+	var orderSearch = search.NewParser(search.ParserConfig{
+		Fields: map[string]search.Field{
+			"placed": {Column: "o.placed_at", Type: search.FieldDate},
+			"status": {Column: "o.status"},
+			"total":  {Column: "o.total", Type: search.FieldNumber},
+		},
+		TextColumns: []string{"o.customer_name", "o.notes"},
+	})
+*/
+func NewParser(config ParserConfig) *Parser {
+	if config.MaxDepth <= 0 {
+		config.MaxDepth = 5
+	}
+
+	if config.MaxLength <= 0 {
+		config.MaxLength = 512
+	}
+
+	if config.MaxTerms <= 0 {
+		config.MaxTerms = 20
+	}
+
+	if config.TSConfig == "" {
+		config.TSConfig = "simple"
+	}
+
+	return &Parser{
+		config: config,
+	}
+}
+
+/*
+Parse parses a search. An empty search returns a nil Node. Errors wrap
+ErrInvalidQuery, ErrUnknownField, or ErrTooComplex, and their messages
+are safe to show to users
+*/
+func (p *Parser) Parse(input string) (Node, error) {
+	if len([]rune(input)) > p.config.MaxLength {
+		return nil, fmt.Errorf("%w: longer than %d characters", ErrTooComplex, p.config.MaxLength)
+	}
+
+	tokens, err := tokenize(input)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	state := &parseState{parser: p, tokens: tokens}
+	node, err := state.or(0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !state.done() {
+		return nil, &SyntaxError{Message: "unexpected " + state.peek().describe(), Position: state.peek().position}
+	}
+
+	return node, nil
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenPhrase
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+	tokenRange
+)
+
+type token struct {
+	comparison string
+	field      string
+	kind       tokenKind
+	position   int
+	rangeText  [2]string
+	rangeEnds  [2]bool
+	value      string
+}
+
+func (t token) describe() string {
+	switch t.kind {
+	case tokenAnd:
+		return "AND"
+
+	case tokenOr:
+		return "OR"
+
+	case tokenNot:
+		return "NOT"
+
+	case tokenOpen:
+		return `"("`
+
+	case tokenClose:
+		return `")"`
+	}
+
+	return fmt.Sprintf("%q", t.value)
+}
+
+/*
+tokenize splits a search into tokens. A field and its value, such as
+status:active or price:[10 TO 20], are a single token
+*/
+func tokenize(input string) ([]token, error) {
+	runes := []rune(input)
+	result := make([]token, 0, 8)
+
+	for index := 0; index < len(runes); {
+		r := runes[index]
+
+		switch {
+		case unicode.IsSpace(r):
+			index++
+
+		case r == '(':
+			result = append(result, token{kind: tokenOpen, position: index})
+			index++
+
+		case r == ')':
+			result = append(result, token{kind: tokenClose, position: index})
+			index++
+
+		case r == '-' && index+1 < len(runes) && !unicode.IsSpace(runes[index+1]) && (index == 0 || isBoundary(runes[index-1])):
+			result = append(result, token{kind: tokenNot, position: index})
+			index++
+
+		case r == '"':
+			value, next, err := readPhrase(runes, index)
+
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, token{kind: tokenPhrase, position: index, value: value})
+			index = next
+
+		default:
+			start := index
+			word := readWord(runes, index)
+			index += len([]rune(word))
+
+			colon := strings.Index(word, ":")
+
+			if colon <= 0 || !isFieldName(word[:colon]) {
+				result = append(result, keyword(word, start))
+				continue
+			}
+
+			fieldToken, next, err := readFieldValue(runes, word[:colon], start, start+len([]rune(word[:colon]))+1)
+
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, fieldToken)
+			index = next
+		}
+	}
+
+	return result, nil
+}
+
+func keyword(word string, position int) token {
+	switch word {
+	case "AND", "&&":
+		return token{kind: tokenAnd, position: position, value: word}
+
+	case "OR", "||":
+		return token{kind: tokenOr, position: position, value: word}
+
+	case "NOT":
+		return token{kind: tokenNot, position: position, value: word}
+	}
+
+	return token{kind: tokenWord, position: position, value: word}
+}
+
+/*
+readFieldValue reads the value after "field:", starting at index
+*/
+func readFieldValue(runes []rune, field string, start, index int) (token, int, error) {
+	result := token{field: field, kind: tokenWord, position: start}
+
+	if index >= len(runes) || isBoundary(runes[index]) {
+		return result, index, &SyntaxError{Message: "missing value for " + field, Position: index}
+	}
+
+	switch runes[index] {
+	case '"':
+		value, next, err := readPhrase(runes, index)
+		result.kind = tokenPhrase
+		result.value = value
+		return result, next, err
+
+	case '[', '{':
+		return readRange(runes, result, index)
+	}
+
+	for _, comparison := range []string{">=", "<=", ">", "<"} {
+		if strings.HasPrefix(string(runes[index:]), comparison) {
+			result.comparison = comparison
+			index += len(comparison)
+			break
+		}
+	}
+
+	result.value = readWord(runes, index)
+
+	if result.value == "" {
+		return result, index, &SyntaxError{Message: "missing value for " + field, Position: index}
+	}
+
+	return result, index + len([]rune(result.value)), nil
+}
+
+/*
+readRange reads "[from TO to]", with either bracket being a brace to
+exclude that end
+*/
+func readRange(runes []rune, result token, index int) (token, int, error) {
+	end := index + 1
+
+	for end < len(runes) && runes[end] != ']' && runes[end] != '}' {
+		end++
+	}
+
+	if end == len(runes) {
+		return result, end, &SyntaxError{Message: "unclosed range", Position: index}
+	}
+
+	parts := strings.Fields(string(runes[index+1 : end]))
+
+	if len(parts) != 3 || parts[1] != "TO" {
+		return result, end, &SyntaxError{Message: `ranges are written like [10 TO 20]`, Position: index}
+	}
+
+	result.kind = tokenRange
+	result.rangeEnds = [2]bool{runes[index] == '[', runes[end] == ']'}
+	result.rangeText = [2]string{parts[0], parts[2]}
+	result.value = string(runes[index : end+1])
+
+	return result, end + 1, nil
+}
+
+func readPhrase(runes []rune, index int) (string, int, error) {
+	builder := strings.Builder{}
+
+	for position := index + 1; position < len(runes); position++ {
+		switch {
+		case runes[position] == '\\' && position+1 < len(runes):
+			position++
+			builder.WriteRune(runes[position])
+
+		case runes[position] == '"':
+			return builder.String(), position + 1, nil
+
+		default:
+			builder.WriteRune(runes[position])
+		}
+	}
+
+	return "", len(runes), &SyntaxError{Message: "unclosed quote", Position: index}
+}
+
+func readWord(runes []rune, index int) string {
+	end := index
+
+	for end < len(runes) && !isBoundary(runes[end]) && runes[end] != '"' {
+		end++
+	}
+
+	return string(runes[index:end])
+}
+
+func isBoundary(r rune) bool {
+	return unicode.IsSpace(r) || r == '(' || r == ')'
+}
+
+func isFieldName(s string) bool {
+	for index, r := range s {
+		if !(r == '_' || r == '.' || unicode.IsLetter(r) || (index > 0 && unicode.IsDigit(r))) {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+parseState is a recursive descent parser over the tokens of one search
+*/
+type parseState struct {
+	parser   *Parser
+	position int
+	terms    int
+	tokens   []token
+}
+
+func (s *parseState) done() bool {
+	return s.position >= len(s.tokens)
+}
+
+func (s *parseState) peek() token {
+	return s.tokens[s.position]
+}
+
+func (s *parseState) or(depth int) (Node, error) {
+	children := make([]Node, 0, 2)
+
+	for {
+		node, err := s.and(depth)
+
+		if err != nil {
+			return nil, err
+		}
+
+		children = append(children, node)
+
+		if s.done() || s.peek().kind != tokenOr {
+			break
+		}
+
+		s.position++
+	}
+
+	if len(children) == 1 {
+		return children[0], nil
+	}
+
+	return Or{Children: children}, nil
+}
+
+func (s *parseState) and(depth int) (Node, error) {
+	children := make([]Node, 0, 2)
+
+	for {
+		node, err := s.unary(depth)
+
+		if err != nil {
+			return nil, err
+		}
+
+		children = append(children, node)
+
+		if s.done() || s.peek().kind == tokenOr || s.peek().kind == tokenClose {
+			break
+		}
+
+		if s.peek().kind == tokenAnd {
+			s.position++
+		}
+	}
+
+	if len(children) == 1 {
+		return children[0], nil
+	}
+
+	return And{Children: children}, nil
+}
+
+func (s *parseState) unary(depth int) (Node, error) {
+	if s.done() {
+		return nil, &SyntaxError{Message: "unexpected end of search", Position: s.endPosition()}
+	}
+
+	if s.peek().kind == tokenNot {
+		s.position++
+		child, err := s.unary(depth)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return Not{Child: child}, nil
+	}
+
+	return s.primary(depth)
+}
+
+func (s *parseState) primary(depth int) (Node, error) {
+	current := s.peek()
+	s.position++
+
+	switch current.kind {
+	case tokenOpen:
+		if depth >= s.parser.config.MaxDepth {
+			return nil, fmt.Errorf("%w: more than %d levels of parentheses", ErrTooComplex, s.parser.config.MaxDepth)
+		}
+
+		node, err := s.or(depth + 1)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if s.done() || s.peek().kind != tokenClose {
+			return nil, &SyntaxError{Message: `missing ")"`, Position: s.endPosition()}
+		}
+
+		s.position++
+		return node, nil
+
+	case tokenWord, tokenPhrase, tokenRange:
+		s.terms++
+
+		if s.terms > s.parser.config.MaxTerms {
+			return nil, fmt.Errorf("%w: more than %d terms", ErrTooComplex, s.parser.config.MaxTerms)
+		}
+
+		return s.parser.term(current)
+	}
+
+	return nil, &SyntaxError{Message: "unexpected " + current.describe(), Position: current.position}
+}
+
+func (s *parseState) endPosition() int {
+	if s.done() {
+		last := s.tokens[len(s.tokens)-1]
+		return last.position + len([]rune(last.value))
+	}
+
+	return s.peek().position
+}
+
+/*
+term checks a term's field against the whitelist and parses its value
+*/
+func (p *Parser) term(t token) (Node, error) {
+	if t.field == "" {
+		if len(p.config.TextColumns) == 0 && len(p.config.TextPaths) == 0 && p.config.TSVector == "" {
+			return nil, &SyntaxError{Message: "search for a field, like " + p.exampleField() + ":value", Position: t.position}
+		}
+
+		return Term{Comparison: "=", Parsed: t.value, Phrase: t.kind == tokenPhrase, Value: t.value}, nil
+	}
+
+	field, ok := p.config.Fields[t.field]
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownField, t.field)
+	}
+
+	if t.kind == tokenRange {
+		return p.rangeNode(t, field)
+	}
+
+	if t.comparison != "" && field.Type != FieldNumber && field.Type != FieldDate {
+		return nil, &SyntaxError{Message: t.field + " can't be compared", Position: t.position}
+	}
+
+	parsed, err := parseValue(field.Type, t.value)
+
+	if err != nil {
+		return nil, &SyntaxError{Message: fmt.Sprintf("%s %s", t.field, err.Error()), Position: t.position}
+	}
+
+	comparison := t.comparison
+
+	if comparison == "" {
+		comparison = "="
+	}
+
+	return Term{Comparison: comparison, Field: t.field, Parsed: parsed, Phrase: t.kind == tokenPhrase, Value: t.value}, nil
+}
+
+func (p *Parser) rangeNode(t token, field Field) (Node, error) {
+	if field.Type != FieldNumber && field.Type != FieldDate {
+		return nil, &SyntaxError{Message: t.field + " can't be ranged", Position: t.position}
+	}
+
+	result := Range{
+		Field:       t.field,
+		FromText:    t.rangeText[0],
+		IncludeFrom: t.rangeEnds[0],
+		IncludeTo:   t.rangeEnds[1],
+		ToText:      t.rangeText[1],
+	}
+
+	bounds := []*interface{}{&result.From, &result.To}
+
+	for index, text := range t.rangeText {
+		if text == "*" {
+			continue
+		}
+
+		parsed, err := parseValue(field.Type, text)
+
+		if err != nil {
+			return nil, &SyntaxError{Message: fmt.Sprintf("%s %s", t.field, err.Error()), Position: t.position}
+		}
+
+		*bounds[index] = parsed
+	}
+
+	if result.From == nil && result.To == nil {
+		return nil, &SyntaxError{Message: "a range needs at least one end", Position: t.position}
+	}
+
+	return result, nil
+}
+
+func parseValue(fieldType FieldType, value string) (interface{}, error) {
+	switch fieldType {
+	case FieldNumber:
+		number, err := strconv.ParseFloat(value, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("must be a number")
+		}
+
+		return number, nil
+
+	case FieldDate:
+		if date, err := time.Parse("2006-01-02", value); err == nil {
+			return date, nil
+		}
+
+		date, err := time.Parse(time.RFC3339, value)
+
+		if err != nil {
+			return nil, fmt.Errorf("must be a date, like 2021-06-30")
+		}
+
+		return date, nil
+	}
+
+	return value, nil
+}
+
+func (p *Parser) exampleField() string {
+	names := make([]string, 0, len(p.config.Fields))
+
+	for name := range p.config.Fields {
+		names = append(names, name)
+	}
+
+	if len(names) == 0 {
+		return "field"
+	}
+
+	sort.Strings(names)
+	return names[0]
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package search
+
+/*
+FieldType decides how a field's values are checked and matched
+*/
+type FieldType int
+
+const (
+	/*
+		FieldKeyword values match exactly. A * in the value matches
+		anything, so "sku:AB*" finds SKUs starting with AB
+	*/
+	FieldKeyword FieldType = 0
+
+	/*
+		FieldText values match anywhere in the field
+	*/
+	FieldText FieldType = 1
+
+	/*
+		FieldNumber values must be numbers, and can be compared and
+		ranged
+	*/
+	FieldNumber FieldType = 2
+
+	/*
+		FieldDate values must be dates, as 2006-01-02 or RFC 3339, and
+		can be compared and ranged
+	*/
+	FieldDate FieldType = 3
+)
+
+/*
+Field is a field users may search on. Column is the SQL column and
+Path the Elasticsearch field, and both default to the field's name
+*/
+type Field struct {
+	Column string
+	Path   string
+	Type   FieldType
+}
+
+/*
+ParserConfig configures a Parser.
+
+Fields whitelists the fields users may search, such as "status" in
+"status:active". Queries naming any other field are rejected, so user
+input only ever reaches a query as a bound value.
+
+Words without a field are searched for in TextColumns with LIKE, or,
+when TSVector is set, matched against that tsvector column with
+plainto_tsquery in TSConfig (default "simple"). TextPaths are the
+Elasticsearch fields searched with multi_match.
+
+Queries are limited to MaxTerms terms (default 20) and MaxDepth levels
+of parentheses (default 5), and MaxLength characters (default 512), so
+a single search can't produce a huge query
+*/
+type ParserConfig struct {
+	Fields      map[string]Field
+	MaxDepth    int
+	MaxLength   int
+	MaxTerms    int
+	TSConfig    string
+	TSVector    string
+	TextColumns []string
+	TextPaths   []string
+}
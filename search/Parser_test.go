@@ -0,0 +1,209 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package search_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/search"
+)
+
+var parser = search.NewParser(search.ParserConfig{
+	Fields: map[string]search.Field{
+		"name":   {Column: "c.name", Path: "name", Type: search.FieldText},
+		"placed": {Column: "o.placed_at", Type: search.FieldDate},
+		"sku":    {Column: "o.sku"},
+		"status": {Column: "o.status"},
+		"total":  {Column: "o.total", Type: search.FieldNumber},
+	},
+	TextColumns: []string{"o.notes"},
+	TextPaths:   []string{"notes", "name"},
+})
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		err      error
+	}{
+		{input: "red shoes", expected: "red AND shoes"},
+		{input: `"red shoes" OR boots`, expected: `"red shoes" OR boots`},
+		{input: "a b OR c", expected: "(a AND b) OR c"},
+		{input: "a AND (b OR c)", expected: "a AND (b OR c)"},
+		{input: "-red NOT blue", expected: "NOT red AND NOT blue"},
+		{input: "NOT (a OR b)", expected: "NOT (a OR b)"},
+		{input: `status:active name:"Bob Hope"`, expected: `status:active AND name:"Bob Hope"`},
+		{input: "total:>=10 total:<20.5", expected: "total:>=10 AND total:<20.5"},
+		{input: "total:[10 TO 20} placed:[2021-01-01 TO *]", expected: "total:[10 TO 20} AND placed:[2021-01-01 TO *]"},
+		{input: "red-shoes and or", expected: "red-shoes AND and AND or"},
+		{input: "   ", expected: ""},
+		{input: "secret:x", err: search.ErrUnknownField},
+		{input: `"unclosed`, err: search.ErrInvalidQuery},
+		{input: "(a OR b", err: search.ErrInvalidQuery},
+		{input: "a OR", err: search.ErrInvalidQuery},
+		{input: "a )", err: search.ErrInvalidQuery},
+		{input: "status:", err: search.ErrInvalidQuery},
+		{input: "total:abc", err: search.ErrInvalidQuery},
+		{input: "status:>a", err: search.ErrInvalidQuery},
+		{input: "status:[a TO b]", err: search.ErrInvalidQuery},
+		{input: "total:[1 2]", err: search.ErrInvalidQuery},
+		{input: "((((((a))))))", err: search.ErrTooComplex},
+		{input: "a b c d e f g h i j k l m n o p q r s t u", err: search.ErrTooComplex},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			node, err := parser.Parse(test.input)
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Expected %v but got %v", test.err, err)
+			}
+
+			if test.err != nil {
+				return
+			}
+
+			actual := ""
+
+			if node != nil {
+				actual = node.String()
+			}
+
+			if actual != test.expected {
+				t.Errorf("Expected %s but got %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSQL(t *testing.T) {
+	placed, _ := time.Parse("2006-01-02", "2021-01-01")
+
+	tests := []struct {
+		input        string
+		expected     string
+		expectedArgs []interface{}
+	}{
+		{input: "red", expected: "(o.notes LIKE ?)", expectedArgs: []interface{}{"%red%"}},
+		{input: "status:active OR status:pending", expected: "(o.status = ? OR o.status = ?)", expectedArgs: []interface{}{"active", "pending"}},
+		{input: "sku:AB_1* -name:bob", expected: "(o.sku LIKE ? AND NOT (c.name LIKE ?))", expectedArgs: []interface{}{`AB\_1%`, "%bob%"}},
+		{input: "total:>10", expected: "o.total > ?", expectedArgs: []interface{}{10.0}},
+		{input: "placed:{2021-01-01 TO *]", expected: "(o.placed_at > ?)", expectedArgs: []interface{}{placed}},
+	}
+
+	for _, test := range tests {
+		node, err := parser.Parse(test.input)
+
+		if err != nil {
+			t.Fatalf("Expected %s to parse but got %s", test.input, err.Error())
+		}
+
+		actual, args := parser.SQL(node)
+
+		if actual != test.expected || !reflect.DeepEqual(args, test.expectedArgs) {
+			t.Errorf("Expected %s %v but got %s %v", test.expected, test.expectedArgs, actual, args)
+		}
+	}
+
+	tsParser := search.NewParser(search.ParserConfig{TSVector: "document", TSConfig: "english"})
+	node, _ := tsParser.Parse(`"red shoes" -boots`)
+
+	if actual, args := tsParser.SQL(node); actual != "(document @@ phraseto_tsquery(?, ?) AND NOT (document @@ plainto_tsquery(?, ?)))" || len(args) != 4 {
+		t.Errorf("Unexpected tsquery %s %v", actual, args)
+	}
+}
+
+func TestElasticsearch(t *testing.T) {
+	node, err := parser.Parse(`(name:"Bob Hope" OR "red shoes") total:[10 TO 20} -sku:AB*`)
+
+	if err != nil {
+		t.Fatalf("Expected to parse but got %s", err.Error())
+	}
+
+	actual, _ := json.Marshal(parser.Elasticsearch(node))
+	expected := `{"bool":{"must":[` +
+		`{"bool":{"minimum_should_match":1,"should":[{"match_phrase":{"name":"Bob Hope"}},{"multi_match":{"fields":["notes","name"],"query":"red shoes","type":"phrase"}}]}},` +
+		`{"range":{"total":{"gte":10,"lt":20}}},` +
+		`{"bool":{"must_not":[{"wildcard":{"sku":{"value":"AB*"}}}]}}]}}`
+
+	if string(actual) != expected {
+		t.Errorf("Expected %s but got %s", expected, actual)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	fieldsOnly := search.NewParser(search.ParserConfig{
+		Fields: map[string]search.Field{
+			"status": {},
+			"age":    {Type: search.FieldNumber},
+		},
+		MaxDepth:  1,
+		MaxLength: 20,
+		MaxTerms:  2,
+	})
+
+	tests := []struct {
+		name             string
+		parser           *search.Parser
+		input            string
+		err              error
+		expectedMessage  string
+		expectedPosition int
+	}{
+		{name: "Unclosed quote", input: `red "shoes`, err: search.ErrInvalidQuery, expectedMessage: "invalid search: unclosed quote at position 4", expectedPosition: 4},
+		{name: "Unclosed range", input: "total:[1 TO 5", err: search.ErrInvalidQuery, expectedMessage: "invalid search: unclosed range at position 6", expectedPosition: 6},
+		{name: "Range without TO", input: "total:[1 5]", err: search.ErrInvalidQuery, expectedMessage: "invalid search: ranges are written like [10 TO 20] at position 6", expectedPosition: 6},
+		{name: "Range without ends", input: "total:[* TO *]", err: search.ErrInvalidQuery, expectedMessage: "invalid search: a range needs at least one end at position 0"},
+		{name: "Range on a keyword", input: "a status:[a TO b]", err: search.ErrInvalidQuery, expectedMessage: "invalid search: status can't be ranged at position 2", expectedPosition: 2},
+		{name: "Comparison on a text field", input: "name:>bob", err: search.ErrInvalidQuery, expectedMessage: "invalid search: name can't be compared at position 0"},
+		{name: "Bad number", input: "total:1e", err: search.ErrInvalidQuery, expectedMessage: "invalid search: total must be a number at position 0"},
+		{name: "Bad date", input: "placed:>2021-13-01", err: search.ErrInvalidQuery, expectedMessage: "invalid search: placed must be a date, like 2021-06-30 at position 0"},
+		{name: "Bad range bound", input: "placed:[2021-01-01 TO soon]", err: search.ErrInvalidQuery, expectedMessage: "invalid search: placed must be a date, like 2021-06-30 at position 0"},
+		{name: "Missing value", input: "red status: shoes", err: search.ErrInvalidQuery, expectedMessage: "invalid search: missing value for status at position 11", expectedPosition: 11},
+		{name: "Leading operator", input: "AND red", err: search.ErrInvalidQuery, expectedMessage: "invalid search: unexpected AND at position 0"},
+		{name: "Trailing NOT", input: "red NOT", err: search.ErrInvalidQuery, expectedMessage: "invalid search: unexpected end of search at position 7", expectedPosition: 7},
+		{name: "Missing close", input: "(red OR blue", err: search.ErrInvalidQuery, expectedMessage: `invalid search: missing ")" at position 12`, expectedPosition: 12},
+		{name: "Unknown field", input: "password:x", err: search.ErrUnknownField, expectedMessage: "field cannot be searched: password"},
+		{name: "Words need text columns", parser: fieldsOnly, input: "red", err: search.ErrInvalidQuery, expectedMessage: "invalid search: search for a field, like age:value at position 0"},
+		{name: "Too long", parser: fieldsOnly, input: "status:abcdefghijklmn", err: search.ErrTooComplex, expectedMessage: "search is too complex: longer than 20 characters"},
+		{name: "Length counts characters", parser: fieldsOnly, input: "status:äöüäöüäöü", expectedMessage: ""},
+		{name: "Too many terms", parser: fieldsOnly, input: "age:1 age:2 age:3", err: search.ErrTooComplex, expectedMessage: "search is too complex: more than 2 terms"},
+		{name: "Too deep", parser: fieldsOnly, input: "((age:1))", err: search.ErrTooComplex, expectedMessage: "search is too complex: more than 1 levels of parentheses"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := test.parser
+
+			if p == nil {
+				p = parser
+			}
+
+			_, err := p.Parse(test.input)
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Expected %v but got %v", test.err, err)
+			}
+
+			if err == nil {
+				return
+			}
+
+			if err.Error() != test.expectedMessage {
+				t.Errorf("Expected '%s' but got '%s'", test.expectedMessage, err.Error())
+			}
+
+			syntaxError := &search.SyntaxError{}
+
+			if errors.As(err, &syntaxError) && syntaxError.Position != test.expectedPosition {
+				t.Errorf("Expected position %d but got %d", test.expectedPosition, syntaxError.Position)
+			}
+		})
+	}
+}
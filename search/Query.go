@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package search
+
+import (
+	"strconv"
+	"strings"
+)
+
+/*
+Node is a part of a parsed query: And, Or, Not, Term, or Range. String
+returns the node in query syntax, for logging and echoing back to users
+*/
+type Node interface {
+	String() string
+}
+
+/*
+And matches when every child matches
+*/
+type And struct {
+	Children []Node
+}
+
+/*
+Or matches when any child matches
+*/
+type Or struct {
+	Children []Node
+}
+
+/*
+Not matches when its child doesn't
+*/
+type Not struct {
+	Child Node
+}
+
+/*
+Term matches a word or phrase. Field is empty for text searched across
+the configured text columns. Value is the value as typed, and Parsed
+the value converted for the field's type: a string, float64, or
+time.Time. Comparison is "=" unless the term was written like
+"age:>21", in which case it is one of >, >=, <, or <=
+*/
+type Term struct {
+	Comparison string
+	Field      string
+	Parsed     interface{}
+	Phrase     bool
+	Value      string
+}
+
+/*
+Range matches values between From and To, written like
+"price:[10 TO 20]". Brackets include the end, braces exclude it, and
+"*" leaves that side open, in which case its value is nil
+*/
+type Range struct {
+	Field       string
+	From        interface{}
+	FromText    string
+	IncludeFrom bool
+	IncludeTo   bool
+	To          interface{}
+	ToText      string
+}
+
+func (n And) String() string {
+	return join(n.Children, " AND ")
+}
+
+func (n Or) String() string {
+	return join(n.Children, " OR ")
+}
+
+func (n Not) String() string {
+	return "NOT " + group(n.Child)
+}
+
+func (n Term) String() string {
+	value := n.Value
+
+	if n.Phrase || strings.ContainsAny(value, " \t():\"") {
+		value = strconv.Quote(value)
+	}
+
+	if n.Comparison != "" && n.Comparison != "=" {
+		value = n.Comparison + value
+	}
+
+	if n.Field == "" {
+		return value
+	}
+
+	return n.Field + ":" + value
+}
+
+func (n Range) String() string {
+	open, close := "{", "}"
+
+	if n.IncludeFrom {
+		open = "["
+	}
+
+	if n.IncludeTo {
+		close = "]"
+	}
+
+	return n.Field + ":" + open + n.FromText + " TO " + n.ToText + close
+}
+
+func join(nodes []Node, separator string) string {
+	parts := make([]string, 0, len(nodes))
+
+	for _, node := range nodes {
+		parts = append(parts, group(node))
+	}
+
+	return strings.Join(parts, separator)
+}
+
+func group(node Node) string {
+	switch node.(type) {
+	case And, Or:
+		return "(" + node.String() + ")"
+	}
+
+	return node.String()
+}
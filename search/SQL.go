@@ -0,0 +1,179 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package search
+
+import (
+	"strings"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+/*
+SQL turns a parsed search into a SQL condition, without the WHERE
+keyword, and its arguments. Placeholders are ?, so rebind them for
+databases that use another style. Every value is a bound argument.
+
+Words without a field are matched with LIKE against each of the text
+columns, or against the TSVector column with plainto_tsquery, and
+phraseto_tsquery for phrases. Keyword fields are compared with =, or
+LIKE when the value has a *. Text fields use LIKE. LIKE values escape %
+and _ with a backslash. A nil node returns an empty condition
+*/
+func (p *Parser) SQL(node Node) (string, []interface{}) {
+	if node == nil {
+		return "", nil
+	}
+
+	builder := &sqlBuilder{config: p.config}
+	builder.node(node)
+
+	return builder.sql.String(), builder.args
+}
+
+type sqlBuilder struct {
+	args   []interface{}
+	config ParserConfig
+	sql    strings.Builder
+}
+
+func (b *sqlBuilder) node(node Node) {
+	switch n := node.(type) {
+	case And:
+		b.join(n.Children, " AND ")
+
+	case Or:
+		b.join(n.Children, " OR ")
+
+	case Not:
+		b.sql.WriteString("NOT ")
+
+		/*
+		 * And, Or, and Range already have parentheses
+		 */
+		switch n.Child.(type) {
+		case And, Or, Range:
+			b.node(n.Child)
+
+		default:
+			b.sql.WriteString("(")
+			b.node(n.Child)
+			b.sql.WriteString(")")
+		}
+
+	case Term:
+		b.term(n)
+
+	case Range:
+		b.rangeCondition(n)
+	}
+}
+
+func (b *sqlBuilder) join(nodes []Node, separator string) {
+	b.sql.WriteString("(")
+
+	for index, node := range nodes {
+		if index > 0 {
+			b.sql.WriteString(separator)
+		}
+
+		b.node(node)
+	}
+
+	b.sql.WriteString(")")
+}
+
+func (b *sqlBuilder) term(term Term) {
+	if term.Field == "" {
+		b.text(term)
+		return
+	}
+
+	field := b.config.Fields[term.Field]
+	column := columnFor(term.Field, field)
+
+	switch {
+	case field.Type == FieldText:
+		b.sql.WriteString(column + " LIKE ?")
+		b.args = append(b.args, "%"+likeEscaper.Replace(term.Value)+"%")
+
+	case field.Type == FieldKeyword && strings.Contains(term.Value, "*"):
+		b.sql.WriteString(column + " LIKE ?")
+		b.args = append(b.args, strings.ReplaceAll(likeEscaper.Replace(term.Value), "*", "%"))
+
+	default:
+		b.sql.WriteString(column + " " + term.Comparison + " ?")
+		b.args = append(b.args, term.Parsed)
+	}
+}
+
+/*
+text matches a word or phrase without a field
+*/
+func (b *sqlBuilder) text(term Term) {
+	if b.config.TSVector != "" {
+		function := "plainto_tsquery"
+
+		if term.Phrase {
+			function = "phraseto_tsquery"
+		}
+
+		b.sql.WriteString(b.config.TSVector + " @@ " + function + "(?, ?)")
+		b.args = append(b.args, b.config.TSConfig, term.Value)
+		return
+	}
+
+	if len(b.config.TextColumns) == 0 {
+		b.sql.WriteString("1 = 0")
+		return
+	}
+
+	b.sql.WriteString("(")
+
+	for index, column := range b.config.TextColumns {
+		if index > 0 {
+			b.sql.WriteString(" OR ")
+		}
+
+		b.sql.WriteString(column + " LIKE ?")
+		b.args = append(b.args, "%"+likeEscaper.Replace(term.Value)+"%")
+	}
+
+	b.sql.WriteString(")")
+}
+
+func (b *sqlBuilder) rangeCondition(r Range) {
+	column := columnFor(r.Field, b.config.Fields[r.Field])
+	conditions := make([]string, 0, 2)
+
+	if r.From != nil {
+		if r.IncludeFrom {
+			conditions = append(conditions, column+" >= ?")
+		} else {
+			conditions = append(conditions, column+" > ?")
+		}
+
+		b.args = append(b.args, r.From)
+	}
+
+	if r.To != nil {
+		if r.IncludeTo {
+			conditions = append(conditions, column+" <= ?")
+		} else {
+			conditions = append(conditions, column+" < ?")
+		}
+
+		b.args = append(b.args, r.To)
+	}
+
+	b.sql.WriteString("(" + strings.Join(conditions, " AND ") + ")")
+}
+
+func columnFor(name string, field Field) string {
+	if field.Column == "" {
+		return name
+	}
+
+	return field.Column
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package search_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/listing"
+	"github.com/ResurgenceIT/kit/v6/search"
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+)
+
+func TestSQLBuilder(t *testing.T) {
+	placed := time.Date(2021, time.June, 30, 0, 0, 0, 0, time.UTC)
+	placedAt := time.Date(2021, time.June, 30, 14, 5, 0, 0, time.UTC)
+
+	multiColumn := search.NewParser(search.ParserConfig{
+		Fields: map[string]search.Field{
+			"status": {},
+		},
+		TextColumns: []string{"o.notes", "c.name"},
+	})
+
+	pathsOnly := search.NewParser(search.ParserConfig{
+		TextPaths: []string{"notes"},
+	})
+
+	tests := []struct {
+		name         string
+		parser       *search.Parser
+		input        string
+		expected     string
+		expectedArgs []interface{}
+	}{
+		{name: "Empty search", input: "", expected: "", expectedArgs: nil},
+		{name: "Implied AND", input: "red shoes", expected: "((o.notes LIKE ?) AND (o.notes LIKE ?))", expectedArgs: []interface{}{"%red%", "%shoes%"}},
+		{name: "Phrase", input: `"red shoes"`, expected: "(o.notes LIKE ?)", expectedArgs: []interface{}{"%red shoes%"}},
+		{name: "AND binds tighter than OR", input: "a b OR c", expected: "(((o.notes LIKE ?) AND (o.notes LIKE ?)) OR (o.notes LIKE ?))", expectedArgs: []interface{}{"%a%", "%b%", "%c%"}},
+		{name: "NOT of a term", input: "-status:closed", expected: "NOT (o.status = ?)", expectedArgs: []interface{}{"closed"}},
+		{name: "NOT of a group", input: "NOT (status:a OR status:b)", expected: "NOT (o.status = ? OR o.status = ?)", expectedArgs: []interface{}{"a", "b"}},
+		{name: "NOT of a range", input: "NOT total:[1 TO 5]", expected: "NOT (o.total >= ? AND o.total <= ?)", expectedArgs: []interface{}{1.0, 5.0}},
+		{name: "Keyword wildcard escapes LIKE characters", input: `sku:10%_\*`, expected: "o.sku LIKE ?", expectedArgs: []interface{}{`10\%\_\\%`}},
+		{name: "Text field escapes LIKE characters", input: `name:"50% off"`, expected: "c.name LIKE ?", expectedArgs: []interface{}{`%50\% off%`}},
+		{name: "Greater than or equal", input: "total:>=10", expected: "o.total >= ?", expectedArgs: []interface{}{10.0}},
+		{name: "Less than", input: "total:<-2.5", expected: "o.total < ?", expectedArgs: []interface{}{-2.5}},
+		{name: "Less than or equal", input: "total:<=0", expected: "o.total <= ?", expectedArgs: []interface{}{0.0}},
+		{name: "Date", input: "placed:2021-06-30", expected: "o.placed_at = ?", expectedArgs: []interface{}{placed}},
+		{name: "RFC 3339 date", input: "placed:>2021-06-30T14:05:00Z", expected: "o.placed_at > ?", expectedArgs: []interface{}{placedAt}},
+		{name: "Exclusive range", input: "total:{1 TO 5}", expected: "(o.total > ? AND o.total < ?)", expectedArgs: []interface{}{1.0, 5.0}},
+		{name: "Range open at the start", input: "total:[* TO 5]", expected: "(o.total <= ?)", expectedArgs: []interface{}{5.0}},
+		{name: "Every text column", parser: multiColumn, input: "red", expected: "(o.notes LIKE ? OR c.name LIKE ?)", expectedArgs: []interface{}{"%red%", "%red%"}},
+		{name: "Column defaults to the field name", parser: multiColumn, input: "status:open", expected: "status = ?", expectedArgs: []interface{}{"open"}},
+		{name: "No text columns matches nothing", parser: pathsOnly, input: "red", expected: "1 = 0", expectedArgs: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := test.parser
+
+			if p == nil {
+				p = parser
+			}
+
+			node, err := p.Parse(test.input)
+
+			if err != nil {
+				t.Fatalf("Expected %s to parse but got %s", test.input, err.Error())
+			}
+
+			actual, args := p.SQL(node)
+
+			if actual != test.expected || !reflect.DeepEqual(args, test.expectedArgs) {
+				t.Errorf("Expected %s %v but got %s %v", test.expected, test.expectedArgs, actual, args)
+			}
+		})
+	}
+}
+
+func TestSQLWithPaging(t *testing.T) {
+	node, err := parser.Parse("status:active total:>25")
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %s", err.Error())
+	}
+
+	where, args := parser.SQL(node)
+
+	options := listing.ListOptions{
+		Filters: []listing.Filter{{Column: "o.region", Operator: listing.Equal, Values: []string{"west"}}},
+		Limit:   10,
+		Page:    3,
+		Sorts:   []listing.Sort{{Column: "o.placed_at", Descending: true}},
+	}
+
+	tests := []struct {
+		name         string
+		dialect      sqldatabase.Dialect
+		expected     string
+		expectedArgs []interface{}
+	}{
+		{
+			name:         "Default",
+			expected:     "SELECT o.id FROM orders o WHERE (o.status = ? AND o.total > ?) AND o.region = ? ORDER BY o.placed_at DESC LIMIT 10 OFFSET 20",
+			expectedArgs: []interface{}{"active", 25.0, "west"},
+		},
+		{
+			name:         "PostgreSQL",
+			dialect:      sqldatabase.Postgres,
+			expected:     "SELECT o.id FROM orders o WHERE (o.status = $1 AND o.total > $2) AND o.region = $3 ORDER BY o.placed_at DESC LIMIT 10 OFFSET 20",
+			expectedArgs: []interface{}{"active", 25.0, "west"},
+		},
+		{
+			name:         "SQL Server",
+			dialect:      sqldatabase.SQLServer,
+			expected:     "SELECT o.id FROM orders o WHERE (o.status = @p1 AND o.total > @p2) AND o.region = @p3 ORDER BY o.placed_at DESC OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY",
+			expectedArgs: []interface{}{"active", 25.0, "west"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, queryArgs := options.ApplyDialect(test.dialect, "SELECT o.id FROM orders o WHERE "+where, args...)
+			query = test.dialect.Rebind(query)
+
+			if query != test.expected || !reflect.DeepEqual(queryArgs, test.expectedArgs) {
+				t.Errorf("Expected %s %v but got %s %v", test.expected, test.expectedArgs, query, queryArgs)
+			}
+		})
+	}
+
+	if paging := options.Paging(45); paging.TotalPages != 5 || paging.NextPage != 4 || paging.PreviousPage != 2 {
+		t.Errorf("Expected page 3 of 5 but got %+v", paging)
+	}
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/internal/awsauth"
+)
+
+/*
+AWSProvider reads secrets from AWS Secrets Manager. Secret names are
+secret names or ARNs, optionally followed by "#key" to select a key from
+a secret stored as JSON, such as "prod/database#password". Binary
+secrets are returned as their raw bytes
+*/
+type AWSProvider struct {
+	config AWSProviderConfig
+	now    func() time.Time
+}
+
+type awsGetSecretValueResponse struct {
+	Message      string `json:"message"`
+	SecretBinary []byte `json:"SecretBinary"`
+	SecretString string `json:"SecretString"`
+	Type         string `json:"__type"`
+	VersionID    string `json:"VersionId"`
+}
+
+/*
+NewAWSProvider creates a provider that reads secrets from AWS Secrets
+Manager
+*/
+func NewAWSProvider(config AWSProviderConfig) *AWSProvider {
+	if config.AccessKeyID == "" {
+		config.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		config.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		config.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+
+	if config.Region == "" {
+		config.Region = os.Getenv("AWS_REGION")
+	}
+
+	if config.Region == "" {
+		config.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+
+	if config.Endpoint == "" {
+		config.Endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", config.Region)
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: time.Second * 10}
+	}
+
+	return &AWSProvider{
+		config: config,
+		now:    time.Now,
+	}
+}
+
+/*
+GetSecret reads the current version of a secret from Secrets Manager
+*/
+func (p *AWSProvider) GetSecret(ctx context.Context, name string) (Secret, error) {
+	var (
+		err      error
+		b        []byte
+		request  *http.Request
+		response *http.Response
+		result   awsGetSecretValueResponse
+	)
+
+	secretID, key := splitName(name)
+	body, _ := json.Marshal(map[string]string{"SecretId": secretID})
+
+	if p.config.Region == "" || p.config.AccessKeyID == "" {
+		return Secret{}, fmt.Errorf("error reading secret '%s' from AWS: no region or credentials configured", name)
+	}
+
+	if request, err = http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(p.config.Endpoint, "/")+"/", bytes.NewReader(body)); err != nil {
+		return Secret{}, fmt.Errorf("error creating AWS request: %w", err)
+	}
+
+	request.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	request.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+
+	awsauth.Sign(request, awsauth.HashPayload(body), awsauth.Credentials{
+		AccessKeyID:     p.config.AccessKeyID,
+		Region:          p.config.Region,
+		SecretAccessKey: p.config.SecretAccessKey,
+		Service:         "secretsmanager",
+		SessionToken:    p.config.SessionToken,
+	}, p.now())
+
+	if response, err = p.config.HTTPClient.Do(request); err != nil {
+		return Secret{}, fmt.Errorf("error reading secret '%s' from AWS: %w", name, err)
+	}
+
+	defer response.Body.Close()
+
+	if b, err = ioutil.ReadAll(response.Body); err != nil {
+		return Secret{}, fmt.Errorf("error reading AWS response: %w", err)
+	}
+
+	_ = json.Unmarshal(b, &result)
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		if strings.HasSuffix(result.Type, "ResourceNotFoundException") {
+			return Secret{}, fmt.Errorf("%w: '%s'", ErrNotFound, name)
+		}
+
+		return Secret{}, fmt.Errorf("error reading secret '%s' from AWS: status %d: %s %s", name, response.StatusCode, result.Type, result.Message)
+	}
+
+	value := result.SecretString
+
+	if value == "" && len(result.SecretBinary) > 0 {
+		value = string(result.SecretBinary)
+	}
+
+	value, err = selectKey(name, value, key)
+
+	return Secret{
+		Name:    name,
+		Value:   value,
+		Version: result.VersionID,
+	}, err
+}
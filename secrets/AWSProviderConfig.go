@@ -0,0 +1,24 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package secrets
+
+import "net/http"
+
+/*
+AWSProviderConfig configures an AWSProvider. Credentials default to the
+AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN
+environment variables, and Region to AWS_REGION or AWS_DEFAULT_REGION.
+Endpoint defaults to the regional Secrets Manager endpoint and only
+needs setting for VPC endpoints or local testing. HTTPClient defaults to
+a client with a ten second timeout
+*/
+type AWSProviderConfig struct {
+	AccessKeyID     string
+	Endpoint        string
+	HTTPClient      *http.Client
+	Region          string
+	SecretAccessKey string
+	SessionToken    string
+}
@@ -0,0 +1,209 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package secrets
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/*
+Cache wraps a provider and keeps the secrets it returns, so every read
+doesn't go back to Vault or AWS. It is itself an IProvider, so it can be
+used anywhere a provider is expected.
+
+Leased secrets are kept until their lease expires. Call StartRenewal to
+renew leases in the background before they run out. Providers that
+implement IRenewer have their leases extended. Secrets that can't be
+renewed, or whose renewal fails, are read again
+*/
+type Cache struct {
+	sync.Mutex
+
+	config   CacheConfig
+	entries  map[string]*cacheEntry
+	now      func() time.Time
+	provider IProvider
+	shutdown chan bool
+}
+
+type cacheEntry struct {
+	expiresAt time.Time
+	renewAt   time.Time
+	secret    Secret
+}
+
+/*
+NewCache creates a caching provider in front of another provider
+*/
+func NewCache(provider IProvider, config CacheConfig) *Cache {
+	if config.TTL <= 0 {
+		config.TTL = time.Minute * 5
+	}
+
+	return &Cache{
+		config:   config,
+		entries:  make(map[string]*cacheEntry),
+		now:      time.Now,
+		provider: provider,
+	}
+}
+
+/*
+GetSecret returns a cached secret, reading it from the provider when it
+isn't cached or has expired
+*/
+func (c *Cache) GetSecret(ctx context.Context, name string) (Secret, error) {
+	c.Lock()
+	entry, ok := c.entries[name]
+	c.Unlock()
+
+	if ok && c.now().Before(entry.expiresAt) {
+		return entry.secret, nil
+	}
+
+	return c.fetch(ctx, name)
+}
+
+/*
+Invalidate removes a secret from the cache, so the next read goes to the
+provider
+*/
+func (c *Cache) Invalidate(name string) {
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.entries, name)
+}
+
+/*
+Renew renews every cached lease that is due, and reads again any secret
+whose lease can't be renewed. Errors are passed to OnRenewError, and
+the first one is returned
+*/
+func (c *Cache) Renew(ctx context.Context) error {
+	var firstErr error
+
+	now := c.now()
+	due := make([]Secret, 0)
+
+	c.Lock()
+
+	for _, entry := range c.entries {
+		if !entry.renewAt.IsZero() && !now.Before(entry.renewAt) {
+			due = append(due, entry.secret)
+		}
+	}
+
+	c.Unlock()
+
+	for _, secret := range due {
+		if err := c.renew(ctx, secret); err != nil {
+			if c.config.OnRenewError != nil {
+				c.config.OnRenewError(secret.Name, err)
+			}
+
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+/*
+StartRenewal starts a goroutine that calls Renew on an interval. Calling
+it again while renewal is running does nothing
+*/
+func (c *Cache) StartRenewal(interval time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.shutdown != nil {
+		return
+	}
+
+	shutdown := make(chan bool)
+	c.shutdown = shutdown
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-shutdown:
+				return
+
+			case <-ticker.C:
+				_ = c.Renew(context.Background())
+			}
+		}
+	}()
+}
+
+/*
+StopRenewal stops the background renewal started by StartRenewal
+*/
+func (c *Cache) StopRenewal() {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.shutdown == nil {
+		return
+	}
+
+	close(c.shutdown)
+	c.shutdown = nil
+}
+
+func (c *Cache) fetch(ctx context.Context, name string) (Secret, error) {
+	secret, err := c.provider.GetSecret(ctx, name)
+
+	if err != nil {
+		return secret, err
+	}
+
+	c.store(secret)
+	return secret, nil
+}
+
+func (c *Cache) renew(ctx context.Context, secret Secret) error {
+	if renewer, ok := c.provider.(IRenewer); ok && secret.Renewable {
+		if renewed, err := renewer.RenewSecret(ctx, secret); err == nil {
+			c.store(renewed)
+			return nil
+		}
+	}
+
+	_, err := c.fetch(ctx, secret.Name)
+	return err
+}
+
+func (c *Cache) store(secret Secret) {
+	now := c.now()
+	entry := &cacheEntry{
+		expiresAt: now.Add(c.config.TTL),
+		secret:    secret,
+	}
+
+	if secret.LeaseDuration > 0 {
+		renewBefore := c.config.RenewBefore
+
+		if renewBefore <= 0 || renewBefore >= secret.LeaseDuration {
+			renewBefore = secret.LeaseDuration / 3
+		}
+
+		entry.expiresAt = now.Add(secret.LeaseDuration)
+		entry.renewAt = entry.expiresAt.Add(-renewBefore)
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.entries[secret.Name] = entry
+}
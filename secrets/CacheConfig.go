@@ -0,0 +1,22 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package secrets
+
+import "time"
+
+/*
+CacheConfig configures a Cache. TTL is how long secrets without a lease
+are kept before being read again, and defaults to five minutes.
+
+Secrets with a lease are kept until the lease expires. Renewal starts
+RenewBefore the lease ends, which defaults to a third of the lease
+duration. OnRenewError is called when a lease can't be renewed or the
+secret can't be read again
+*/
+type CacheConfig struct {
+	OnRenewError func(name string, err error)
+	RenewBefore  time.Duration
+	TTL          time.Duration
+}
@@ -0,0 +1,52 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+/*
+EnvProvider reads secrets from environment variables
+*/
+type EnvProvider struct {
+	config EnvProviderConfig
+}
+
+/*
+NewEnvProvider creates a provider that reads secrets from environment
+variables
+*/
+func NewEnvProvider(config EnvProviderConfig) *EnvProvider {
+	if config.LookupEnv == nil {
+		config.LookupEnv = os.LookupEnv
+	}
+
+	return &EnvProvider{
+		config: config,
+	}
+}
+
+/*
+GetSecret returns the value of the environment variable named by the
+provider's prefix and name
+*/
+func (p *EnvProvider) GetSecret(ctx context.Context, name string) (Secret, error) {
+	variable, key := splitName(name)
+	value, ok := p.config.LookupEnv(p.config.Prefix + variable)
+
+	if !ok {
+		return Secret{}, fmt.Errorf("%w: environment variable %s", ErrNotFound, p.config.Prefix+variable)
+	}
+
+	value, err := selectKey(name, value, key)
+
+	return Secret{
+		Name:  name,
+		Value: value,
+	}, err
+}
@@ -0,0 +1,14 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package secrets
+
+/*
+EnvProviderConfig configures an EnvProvider. Prefix is prepended to
+every secret name. LookupEnv defaults to os.LookupEnv
+*/
+type EnvProviderConfig struct {
+	LookupEnv func(key string) (string, bool)
+	Prefix    string
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+FileProvider reads secrets from files in a directory, one secret per
+file. A single trailing newline is trimmed from the contents
+*/
+type FileProvider struct {
+	config FileProviderConfig
+}
+
+/*
+NewFileProvider creates a provider that reads secrets from files
+*/
+func NewFileProvider(config FileProviderConfig) *FileProvider {
+	return &FileProvider{
+		config: config,
+	}
+}
+
+/*
+GetSecret returns the contents of the file named by name in the
+provider's directory. Names can't leave the directory
+*/
+func (p *FileProvider) GetSecret(ctx context.Context, name string) (Secret, error) {
+	fileName, key := splitName(name)
+	cleaned := filepath.Clean(string(filepath.Separator) + fileName)
+
+	b, err := ioutil.ReadFile(filepath.Join(p.config.Directory, cleaned))
+
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Secret{}, fmt.Errorf("%w: file %s", ErrNotFound, fileName)
+		}
+
+		return Secret{}, fmt.Errorf("error reading secret file %s: %w", fileName, err)
+	}
+
+	value := strings.TrimSuffix(strings.TrimSuffix(string(b), "\n"), "\r")
+	value, err = selectKey(name, value, key)
+
+	return Secret{
+		Name:  name,
+		Value: value,
+	}, err
+}
@@ -0,0 +1,14 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package secrets
+
+/*
+FileProviderConfig configures a FileProvider. Directory is where secret
+files live, such as /run/secrets for Docker or a mounted Kubernetes
+secret volume
+*/
+type FileProviderConfig struct {
+	Directory string
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when a provider doesn't have the requested secret
+	ErrNotFound = errors.New("secret not found")
+
+	// ErrNotRenewable is returned when renewing a secret that has no renewable lease
+	ErrNotRenewable = errors.New("secret is not renewable")
+)
+
+/*
+IProvider describes a source of secrets. Names are provider specific,
+such as an environment variable, a file name, or a Vault path. A name
+may end with "#key" to select a single key from a secret stored as a
+JSON object, for example "prod/database#password"
+*/
+type IProvider interface {
+	GetSecret(ctx context.Context, name string) (Secret, error)
+}
+
+/*
+IRenewer is implemented by providers whose secrets have leases that can
+be extended, such as Vault dynamic secrets
+*/
+type IRenewer interface {
+	RenewSecret(ctx context.Context, secret Secret) (Secret, error)
+}
+
+/*
+Secret is a value read from a provider. LeaseDuration is zero for
+secrets that don't expire
+*/
+type Secret struct {
+	LeaseDuration time.Duration
+	LeaseID       string
+	Name          string
+	Renewable     bool
+	Value         string
+	Version       string
+}
+
+/*
+splitName separates a secret name from the optional "#key" suffix
+*/
+func splitName(name string) (string, string) {
+	if index := strings.LastIndex(name, "#"); index > -1 {
+		return name[:index], name[index+1:]
+	}
+
+	return name, ""
+}
+
+/*
+selectKey returns a single key from a secret value holding a JSON
+object. The value is returned unchanged when key is empty
+*/
+func selectKey(name, value, key string) (string, error) {
+	if key == "" {
+		return value, nil
+	}
+
+	data := make(map[string]interface{})
+
+	if err := json.Unmarshal([]byte(value), &data); err != nil {
+		return "", fmt.Errorf("error reading key '%s' from secret '%s': value is not a JSON object", key, name)
+	}
+
+	return keyFromMap(name, data, key)
+}
+
+func keyFromMap(name string, data map[string]interface{}, key string) (string, error) {
+	value, ok := data[key]
+
+	if !ok {
+		return "", fmt.Errorf("%w: key '%s' in secret '%s'", ErrNotFound, key, name)
+	}
+
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+
+	b, err := json.Marshal(value)
+
+	if err != nil {
+		return "", fmt.Errorf("error encoding key '%s' from secret '%s': %w", key, name, err)
+	}
+
+	return string(b), nil
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package secrets_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/secrets"
+)
+
+func TestProviders(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "db"), []byte(`{"user": "bob", "password": "hunter2"}`+"\n"), 0600)
+	_ = os.WriteFile(filepath.Join(dir, "token"), []byte("abc123\n"), 0600)
+
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/secret/data/myapp":
+			_, _ = w.Write([]byte(`{"data": {"data": {"password": "hunter2", "port": 5432}, "metadata": {"version": 3}}}`))
+		case "/v1/database/creds/readonly":
+			_, _ = w.Write([]byte(`{"lease_id": "database/creds/readonly/abc", "lease_duration": 3600, "renewable": true, "data": {"username": "v-user", "password": "pw"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	defer vault.Close()
+
+	aws := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") || r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		buffer := make([]byte, 1024)
+		n, _ := r.Body.Read(buffer)
+
+		if !strings.Contains(string(buffer[:n]), `"prod/db"`) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type": "ResourceNotFoundException", "message": "not found"}`))
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"SecretString": "{\"password\": \"hunter2\"}", "VersionId": "v1"}`))
+	}))
+
+	defer aws.Close()
+
+	env := secrets.NewEnvProvider(secrets.EnvProviderConfig{
+		LookupEnv: func(key string) (string, bool) { return "s3cret", key == "APP_API_KEY" },
+		Prefix:    "APP_",
+	})
+	files := secrets.NewFileProvider(secrets.FileProviderConfig{Directory: dir})
+	vaultProvider := secrets.NewVaultProvider(secrets.VaultProviderConfig{Address: vault.URL, Token: "root"})
+	awsProvider := secrets.NewAWSProvider(secrets.AWSProviderConfig{
+		AccessKeyID:     "AKID",
+		Endpoint:        aws.URL,
+		Region:          "us-east-1",
+		SecretAccessKey: "secret",
+	})
+
+	tests := []struct {
+		name       string
+		provider   secrets.IProvider
+		secretName string
+		expected   string
+		err        error
+	}{
+		{name: "Environment variable", provider: env, secretName: "API_KEY", expected: "s3cret"},
+		{name: "Missing environment variable", provider: env, secretName: "OTHER", err: secrets.ErrNotFound},
+		{name: "File", provider: files, secretName: "token", expected: "abc123"},
+		{name: "Key from a JSON file", provider: files, secretName: "db#password", expected: "hunter2"},
+		{name: "Files can't leave the directory", provider: files, secretName: "../" + filepath.Base(dir) + "/token", err: secrets.ErrNotFound},
+		{name: "Vault KV version 2", provider: vaultProvider, secretName: "secret/data/myapp#password", expected: "hunter2"},
+		{name: "Vault non-string value", provider: vaultProvider, secretName: "secret/data/myapp#port", expected: "5432"},
+		{name: "Vault missing key", provider: vaultProvider, secretName: "secret/data/myapp#user", err: secrets.ErrNotFound},
+		{name: "Vault dynamic secret", provider: vaultProvider, secretName: "database/creds/readonly#username", expected: "v-user"},
+		{name: "Vault missing secret", provider: vaultProvider, secretName: "secret/data/other", err: secrets.ErrNotFound},
+		{name: "AWS Secrets Manager", provider: awsProvider, secretName: "prod/db#password", expected: "hunter2"},
+		{name: "AWS missing secret", provider: awsProvider, secretName: "prod/other", err: secrets.ErrNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secret, err := test.provider.GetSecret(context.Background(), test.secretName)
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Expected error '%v' but got '%v'", test.err, err)
+			}
+
+			if secret.Value != test.expected {
+				t.Errorf("Expected '%s' but got '%s'", test.expected, secret.Value)
+			}
+		})
+	}
+}
+
+type countingProvider struct {
+	lease time.Duration
+	reads int32
+	renew int32
+}
+
+func (p *countingProvider) GetSecret(ctx context.Context, name string) (secrets.Secret, error) {
+	atomic.AddInt32(&p.reads, 1)
+	return secrets.Secret{LeaseDuration: p.lease, LeaseID: "lease", Name: name, Renewable: true, Value: "value"}, nil
+}
+
+func (p *countingProvider) RenewSecret(ctx context.Context, secret secrets.Secret) (secrets.Secret, error) {
+	atomic.AddInt32(&p.renew, 1)
+	return secret, nil
+}
+
+func TestCache(t *testing.T) {
+	provider := &countingProvider{lease: time.Millisecond * 60}
+	cache := secrets.NewCache(provider, secrets.CacheConfig{})
+
+	for index := 0; index < 3; index++ {
+		_, _ = cache.GetSecret(context.Background(), "name")
+	}
+
+	if reads := atomic.LoadInt32(&provider.reads); reads != 1 {
+		t.Errorf("Expected 1 read but got %d", reads)
+	}
+
+	cache.StartRenewal(time.Millisecond * 10)
+	time.Sleep(time.Millisecond * 100)
+	cache.StopRenewal()
+
+	if renewals := atomic.LoadInt32(&provider.renew); renewals == 0 {
+		t.Errorf("Expected the lease to be renewed")
+	}
+
+	if reads := atomic.LoadInt32(&provider.reads); reads != 1 {
+		t.Errorf("Expected renewal instead of reads but got %d reads", reads)
+	}
+
+	cache.Invalidate("name")
+	_, _ = cache.GetSecret(context.Background(), "name")
+
+	if reads := atomic.LoadInt32(&provider.reads); reads != 2 {
+		t.Errorf("Expected 2 reads after invalidating but got %d", reads)
+	}
+}
@@ -0,0 +1,200 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+/*
+VaultProvider reads secrets from HashiCorp Vault over its HTTP API.
+Secret names are API paths without the /v1/ prefix, optionally followed
+by "#key". For example, "secret/data/myapp#password" reads the password
+key from the KV version 2 secret myapp, and "database/creds/readonly"
+reads dynamic database credentials.
+
+KV version 2 responses are unwrapped, and the secret's Version is set
+from their metadata. When no key is given and the secret holds a single
+key, that key's value is returned. Otherwise the whole secret is
+returned as a JSON object
+*/
+type VaultProvider struct {
+	config VaultProviderConfig
+}
+
+type vaultResponse struct {
+	Data          map[string]interface{} `json:"data"`
+	Errors        []string               `json:"errors"`
+	LeaseDuration int                    `json:"lease_duration"`
+	LeaseID       string                 `json:"lease_id"`
+	Renewable     bool                   `json:"renewable"`
+}
+
+/*
+NewVaultProvider creates a provider that reads secrets from Vault
+*/
+func NewVaultProvider(config VaultProviderConfig) *VaultProvider {
+	if config.Address == "" {
+		config.Address = os.Getenv("VAULT_ADDR")
+	}
+
+	if config.Token == "" {
+		config.Token = os.Getenv("VAULT_TOKEN")
+	}
+
+	if config.Namespace == "" {
+		config.Namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: time.Second * 10}
+	}
+
+	config.Address = strings.TrimSuffix(config.Address, "/")
+
+	return &VaultProvider{
+		config: config,
+	}
+}
+
+/*
+GetSecret reads a secret from Vault
+*/
+func (p *VaultProvider) GetSecret(ctx context.Context, name string) (Secret, error) {
+	path, key := splitName(name)
+	response, err := p.do(ctx, http.MethodGet, "/v1/"+strings.TrimPrefix(path, "/"), nil)
+
+	if err != nil {
+		return Secret{}, fmt.Errorf("error reading secret '%s' from Vault: %w", name, err)
+	}
+
+	result := Secret{
+		LeaseDuration: time.Duration(response.LeaseDuration) * time.Second,
+		LeaseID:       response.LeaseID,
+		Name:          name,
+		Renewable:     response.Renewable,
+	}
+
+	data := response.Data
+	nested, isKV2 := data["data"].(map[string]interface{})
+	metadata, hasMetadata := data["metadata"].(map[string]interface{})
+
+	if isKV2 && hasMetadata {
+		data = nested
+
+		if version, ok := metadata["version"]; ok {
+			result.Version = fmt.Sprintf("%v", version)
+		}
+	}
+
+	if key == "" && len(data) == 1 {
+		for onlyKey := range data {
+			key = onlyKey
+		}
+	}
+
+	if key != "" {
+		result.Value, err = keyFromMap(name, data, key)
+		return result, err
+	}
+
+	b, err := json.Marshal(data)
+
+	if err != nil {
+		return Secret{}, fmt.Errorf("error encoding secret '%s': %w", name, err)
+	}
+
+	result.Value = string(b)
+	return result, nil
+}
+
+/*
+RenewSecret extends the lease on a secret. ErrNotRenewable is returned
+for secrets without a renewable lease
+*/
+func (p *VaultProvider) RenewSecret(ctx context.Context, secret Secret) (Secret, error) {
+	if !secret.Renewable || secret.LeaseID == "" {
+		return secret, fmt.Errorf("%w: '%s'", ErrNotRenewable, secret.Name)
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"lease_id":  secret.LeaseID,
+		"increment": int(secret.LeaseDuration / time.Second),
+	})
+
+	response, err := p.do(ctx, http.MethodPut, "/v1/sys/leases/renew", bytes.NewReader(body))
+
+	if err != nil {
+		return secret, fmt.Errorf("error renewing lease for secret '%s': %w", secret.Name, err)
+	}
+
+	secret.LeaseDuration = time.Duration(response.LeaseDuration) * time.Second
+	secret.Renewable = response.Renewable
+
+	if response.LeaseID != "" {
+		secret.LeaseID = response.LeaseID
+	}
+
+	return secret, nil
+}
+
+func (p *VaultProvider) do(ctx context.Context, method, path string, body io.Reader) (vaultResponse, error) {
+	var (
+		err      error
+		b        []byte
+		request  *http.Request
+		response *http.Response
+		result   vaultResponse
+	)
+
+	if p.config.Address == "" {
+		return result, fmt.Errorf("no Vault address configured")
+	}
+
+	if request, err = http.NewRequestWithContext(ctx, method, p.config.Address+path, body); err != nil {
+		return result, fmt.Errorf("error creating request: %w", err)
+	}
+
+	request.Header.Set("X-Vault-Token", p.config.Token)
+
+	if p.config.Namespace != "" {
+		request.Header.Set("X-Vault-Namespace", p.config.Namespace)
+	}
+
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	if response, err = p.config.HTTPClient.Do(request); err != nil {
+		return result, err
+	}
+
+	defer response.Body.Close()
+
+	if b, err = ioutil.ReadAll(response.Body); err != nil {
+		return result, fmt.Errorf("error reading response: %w", err)
+	}
+
+	if response.StatusCode == http.StatusNotFound {
+		return result, ErrNotFound
+	}
+
+	_ = json.Unmarshal(b, &result)
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return result, fmt.Errorf("vault returned status %d: %s", response.StatusCode, strings.Join(result.Errors, ", "))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,20 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package secrets
+
+import "net/http"
+
+/*
+VaultProviderConfig configures a VaultProvider. Address and Token
+default to the VAULT_ADDR and VAULT_TOKEN environment variables, and
+Namespace to VAULT_NAMESPACE. HTTPClient defaults to a client with a
+ten second timeout
+*/
+type VaultProviderConfig struct {
+	Address    string
+	HTTPClient *http.Client
+	Namespace  string
+	Token      string
+}
@@ -0,0 +1,224 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+)
+
+const refKey = "_ref"
+
+var (
+	ErrDuplicateRef = errors.New("seed reference is used twice")
+	ErrMissingDB    = errors.New("seeder needs a DB")
+	ErrUnknownRef   = errors.New("unknown seed reference")
+)
+
+/*
+SeederConfig configures a Seeder. Sets are only loaded if they apply to
+Environment. IDColumn (default "id") is the column a bare reference
+resolves to. Queries are written with ? placeholders; set Rebind to
+rewrite them for databases that use another style
+*/
+type SeederConfig struct {
+	DB          sqldatabase.DB
+	Environment string
+	IDColumn    string
+	Rebind      func(query string) string
+}
+
+/*
+Seeder inserts seed sets and fixtures.
+
+String values starting with @ refer to a row named with _ref earlier in
+the same Load. "@adam" is the row's ID and "@adam.email" is its email
+column. Rows without an ID get the one the database generates, through
+LastInsertId, so give rows explicit IDs on databases without it, such
+as Postgres. Start a string with @@ for a literal @
+*/
+type Seeder struct {
+	config SeederConfig
+}
+
+/*
+NewSeeder creates a Seeder
+*/
+func NewSeeder(config SeederConfig) (*Seeder, error) {
+	if config.DB == nil {
+		return nil, ErrMissingDB
+	}
+
+	if config.IDColumn == "" {
+		config.IDColumn = "id"
+	}
+
+	if config.Rebind == nil {
+		config.Rebind = func(query string) string { return query }
+	}
+
+	return &Seeder{
+		config: config,
+	}, nil
+}
+
+/*
+Load inserts the sets that apply to the environment, in order, in one
+transaction
+*/
+func (s *Seeder) Load(ctx context.Context, sets ...Set) error {
+	return sqldatabase.WithTransaction(ctx, s.config.DB, func(ctx context.Context, tx sqldatabase.Tx) error {
+		refs := map[string]Row{}
+
+		for _, set := range sets {
+			if !set.appliesTo(s.config.Environment) {
+				continue
+			}
+
+			for _, table := range set.Tables {
+				for index, row := range table.Rows {
+					if err := s.insert(ctx, tx, table.Name, row, refs); err != nil {
+						return fmt.Errorf("error seeding %s row %d in set '%s': %w", table.Name, index+1, set.Name, err)
+					}
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+/*
+Reset deletes every row from the tables in the sets that apply to the
+environment, last table first so rows that refer to others go first.
+Use it between integration tests
+*/
+func (s *Seeder) Reset(ctx context.Context, sets ...Set) error {
+	tables := []string{}
+	seen := map[string]bool{}
+
+	for _, set := range sets {
+		if !set.appliesTo(s.config.Environment) {
+			continue
+		}
+
+		for _, table := range set.Tables {
+			if !seen[table.Name] {
+				seen[table.Name] = true
+				tables = append(tables, table.Name)
+			}
+		}
+	}
+
+	for i, j := 0, len(tables)-1; i < j; i, j = i+1, j-1 {
+		tables[i], tables[j] = tables[j], tables[i]
+	}
+
+	return s.ResetTables(ctx, tables...)
+}
+
+/*
+ResetTables deletes every row from tables, in the order given
+*/
+func (s *Seeder) ResetTables(ctx context.Context, tables ...string) error {
+	return sqldatabase.WithTransaction(ctx, s.config.DB, func(ctx context.Context, tx sqldatabase.Tx) error {
+		for _, table := range tables {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+				return fmt.Errorf("error resetting %s: %w", table, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *Seeder) insert(ctx context.Context, tx sqldatabase.Tx, table string, row Row, refs map[string]Row) error {
+	ref, _ := row[refKey].(string)
+	resolved := Row{}
+	columns := make([]string, 0, len(row))
+
+	for column, value := range row {
+		if column == refKey {
+			continue
+		}
+
+		value, err := resolve(value, refs, s.config.IDColumn)
+
+		if err != nil {
+			return err
+		}
+
+		resolved[column] = value
+		columns = append(columns, column)
+	}
+
+	sort.Strings(columns)
+	args := make([]interface{}, 0, len(columns))
+
+	for _, column := range columns {
+		args = append(args, resolved[column])
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "))
+	result, err := tx.ExecContext(ctx, s.config.Rebind(query), args...)
+
+	if err != nil {
+		return err
+	}
+
+	if ref == "" {
+		return nil
+	}
+
+	if _, exists := refs[ref]; exists {
+		return fmt.Errorf("%w: '%s'", ErrDuplicateRef, ref)
+	}
+
+	if _, hasID := resolved[s.config.IDColumn]; !hasID {
+		if id, err := result.LastInsertId(); err == nil {
+			resolved[s.config.IDColumn] = id
+		}
+	}
+
+	refs[ref] = resolved
+	return nil
+}
+
+func resolve(value interface{}, refs map[string]Row, idColumn string) (interface{}, error) {
+	text, ok := value.(string)
+
+	if !ok || !strings.HasPrefix(text, "@") {
+		return value, nil
+	}
+
+	if strings.HasPrefix(text, "@@") {
+		return text[1:], nil
+	}
+
+	name, column := text[1:], idColumn
+
+	if index := strings.Index(name, "."); index > -1 {
+		name, column = name[:index], name[index+1:]
+	}
+
+	row, ok := refs[name]
+
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownRef, text)
+	}
+
+	result, ok := row[column]
+
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s' has no column '%s'", ErrUnknownRef, name, column)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package seed_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ResurgenceIT/kit/v6/seed"
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+)
+
+/*
+recordingDB logs each statement with its arguments. Inserts get
+generated IDs counting up from 100
+*/
+func recordingDB(log *[]string) *sqldatabase.MockDB {
+	nextID := int64(100)
+
+	return &sqldatabase.MockDB{
+		BeginFunc: func() (sqldatabase.Tx, error) {
+			return &sqldatabase.MockTx{
+				CommitFunc: func() error { return nil },
+				ExecContextFunc: func(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+					*log = append(*log, fmt.Sprintf("%s %v", query, args))
+					id := nextID
+					nextID++
+
+					return &sqldatabase.MockResult{LastInsertIdFunc: func() (int64, error) { return id, nil }}, nil
+				},
+				RollbackFunc: func() error {
+					*log = append(*log, "ROLLBACK")
+					return nil
+				},
+			}, nil
+		},
+	}
+}
+
+const usersYAML = `
+environments: [development, test]
+tables:
+  - name: users
+    rows:
+      - _ref: adam
+        id: 1
+        email: adam@example.com
+      - _ref: bea
+        email: "@@bea"
+  - name: orders
+    rows:
+      - user_id: "@adam"
+        email: "@adam.email"
+      - user_id: "@bea"
+`
+
+const demoYAML = `
+environments: [demo]
+tables:
+  - name: demo
+    rows:
+      - name: only in demos
+`
+
+func TestLoad(t *testing.T) {
+	sets, err := seed.ReadDir(fstest.MapFS{
+		"seeds/01_users.yaml": {Data: []byte(usersYAML)},
+		"seeds/02_demo.yml":   {Data: []byte(demoYAML)},
+		"seeds/README.md":     {Data: []byte("not a seed")},
+	}, "seeds")
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	if len(sets) != 2 || sets[0].Name != "01_users" {
+		t.Fatalf("Expected two sets named after their files but got %+v", sets)
+	}
+
+	log := []string{}
+	seeder, _ := seed.NewSeeder(seed.SeederConfig{DB: recordingDB(&log), Environment: "test"})
+
+	if err = seeder.Load(context.Background(), sets...); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	expected := []string{
+		"INSERT INTO users (email, id) VALUES (?, ?) [adam@example.com 1]",
+		"INSERT INTO users (email) VALUES (?) [@bea]",
+		"INSERT INTO orders (email, user_id) VALUES (?, ?) [adam@example.com 1]",
+		"INSERT INTO orders (user_id) VALUES (?) [101]",
+	}
+
+	if strings.Join(log, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected\n%s\nbut got\n%s", strings.Join(expected, "\n"), strings.Join(log, "\n"))
+	}
+
+	log = log[:0]
+
+	if err = seeder.Reset(context.Background(), sets...); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	if strings.Join(log, ";") != "DELETE FROM orders [];DELETE FROM users []" {
+		t.Errorf("Expected orders then users to be reset but got %v", log)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	type user struct {
+		ID    int
+		Email string
+	}
+
+	tests := []struct {
+		name     string
+		rows     []seed.Row
+		expected error
+	}{
+		{name: "Unknown reference", rows: []seed.Row{{"user_id": "@nobody"}}, expected: seed.ErrUnknownRef},
+		{name: "Unknown column", rows: []seed.Row{seed.FromStruct("adam", user{ID: 1}), {"user_id": "@adam.phone"}}, expected: seed.ErrUnknownRef},
+		{name: "Duplicate reference", rows: []seed.Row{seed.FromStruct("adam", user{ID: 1}), seed.FromStruct("adam", user{ID: 2})}, expected: seed.ErrDuplicateRef},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			log := []string{}
+			seeder, _ := seed.NewSeeder(seed.SeederConfig{DB: recordingDB(&log)})
+			err := seeder.Load(context.Background(), seed.Set{Name: "test", Tables: []seed.Table{{Name: "users", Rows: test.rows}}})
+
+			if !errors.Is(err, test.expected) {
+				t.Errorf("Expected error '%v' but got '%v'", test.expected, err)
+			}
+
+			if log[len(log)-1] != "ROLLBACK" {
+				t.Errorf("Expected the load to roll back but got %v", log)
+			}
+		})
+	}
+}
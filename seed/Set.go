@@ -0,0 +1,148 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package seed
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"reflect"
+	"sort"
+
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Row is one record, keyed by column. The "_ref" key names the row so
+later rows can refer to it; it isn't inserted
+*/
+type Row map[string]interface{}
+
+/*
+Table is the rows to insert into one table
+*/
+type Table struct {
+	Name string `yaml:"name"`
+	Rows []Row  `yaml:"rows"`
+}
+
+/*
+Set is a named group of tables, inserted in order. Environments limits
+where the set is loaded, such as "development" and "test"; an empty
+list loads it everywhere
+*/
+type Set struct {
+	Environments []string `yaml:"environments"`
+	Name         string   `yaml:"name"`
+	Tables       []Table  `yaml:"tables"`
+}
+
+/*
+ReadYAML reads a set from YAML.
+
+	name: users
+	environments: [development, test]
+	tables:
+	  - name: users
+	    rows:
+	      - _ref: adam
+	        id: 1
+	        email: adam@example.com
+	  - name: orders
+	    rows:
+	      - user_id: "@adam"
+	        total: 10
+*/
+func ReadYAML(r io.Reader) (Set, error) {
+	result := Set{}
+
+	if err := yaml.NewDecoder(r).Decode(&result); err != nil {
+		return result, fmt.Errorf("error reading seed set: %w", err)
+	}
+
+	return result, nil
+}
+
+/*
+ReadDir reads every .yaml and .yml file in dir, in name order. Sets
+without a name are named after their file
+*/
+func ReadDir(fsys fs.FS, dir string) ([]Set, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+
+	if err != nil {
+		return nil, fmt.Errorf("error reading seed sets: %w", err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	result := []Set{}
+
+	for _, entry := range entries {
+		extension := path.Ext(entry.Name())
+
+		if entry.IsDir() || (extension != ".yaml" && extension != ".yml") {
+			continue
+		}
+
+		file, err := fsys.Open(path.Join(dir, entry.Name()))
+
+		if err != nil {
+			return nil, fmt.Errorf("error reading seed set '%s': %w", entry.Name(), err)
+		}
+
+		set, err := ReadYAML(file)
+		_ = file.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+
+		if set.Name == "" {
+			set.Name = entry.Name()[:len(entry.Name())-len(extension)]
+		}
+
+		result = append(result, set)
+	}
+
+	return result, nil
+}
+
+/*
+FromStruct makes a row from a struct, with columns named by the rules
+sqldatabase.ScanStruct uses. ref names the row, and may be empty
+*/
+func FromStruct(ref string, model interface{}) Row {
+	value := reflect.Indirect(reflect.ValueOf(model))
+	result := Row{}
+
+	for _, column := range sqldatabase.StructColumns(value.Type()) {
+		result[column.Name] = value.FieldByIndex(column.Index).Interface()
+	}
+
+	if ref != "" {
+		result[refKey] = ref
+	}
+
+	return result
+}
+
+/*
+appliesTo reports whether the set loads in environment
+*/
+func (s Set) appliesTo(environment string) bool {
+	if len(s.Environments) == 0 {
+		return true
+	}
+
+	for _, e := range s.Environments {
+		if e == environment {
+			return true
+		}
+	}
+
+	return false
+}
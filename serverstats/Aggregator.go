@@ -0,0 +1,404 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/restclient"
+)
+
+/*
+AggregatorConfig configures an Aggregator. Peers is a list of URLs to
+peer stats handlers which are fetched every time the fleet view is
+requested. Local, when set, is this server's own ServerStats and is
+included without an HTTP round trip. Snapshots pushed to PushHandler
+are dropped once they are older than PushTTL (default 5 minutes), and
+at most MaxPushedInstances (default 100) instances are kept at once.
+
+Auth protects the aggregator's handlers. PeerHeaders are sent with every
+request to a peer, which is how credentials for protected peers are
+provided
+*/
+type AggregatorConfig struct {
+	Auth               *HandlerAuth
+	HTTPClient         restclient.HTTPClientInterface
+	Local              *ServerStats
+	LocalName          string
+	MaxPushedInstances int
+	PeerHeaders        http.Header
+	PeerTimeout        time.Duration
+	Peers              []string
+	PushTTL            time.Duration
+}
+
+/*
+Aggregator merges stats snapshots from multiple instances into a single
+fleet-wide view. This is useful for small clusters that don't have a
+metrics stack. Snapshots can be pulled from peers, pushed by peers, or
+both
+*/
+type Aggregator struct {
+	config AggregatorConfig
+	pushed map[string]pushedSnapshot
+
+	sync.RWMutex
+}
+
+type pushedSnapshot struct {
+	receivedAt time.Time
+	snapshot   Snapshot
+}
+
+/*
+FleetSnapshot is the merged view of every instance's stats
+*/
+type FleetSnapshot struct {
+	AverageResponseTimeInMilliseconds float64                   `json:"averageResponseTimeInMilliseconds"`
+	Errors                            ErrorStats                `json:"errors"`
+	GeneratedAt                       time.Time                 `json:"generatedAt"`
+	Instances                         []InstanceStatus          `json:"instances"`
+	RequestCount                      uint64                    `json:"requestCount"`
+	Routes                            []RouteStats              `json:"routes"`
+	Statuses                          map[string]int            `json:"statuses"`
+	StatusesByRoute                   map[string]map[string]int `json:"statusesByRoute"`
+}
+
+/*
+InstanceStatus reports whether an instance's snapshot was included in
+the fleet view
+*/
+type InstanceStatus struct {
+	Error        string    `json:"error,omitempty"`
+	Instance     string    `json:"instance"`
+	OK           bool      `json:"ok"`
+	RequestCount uint64    `json:"requestCount"`
+	SnapshotAt   time.Time `json:"snapshotAt"`
+	Source       string    `json:"source"`
+}
+
+/*
+NewAggregator creates a new Aggregator
+*/
+func NewAggregator(config AggregatorConfig) *Aggregator {
+	if config.PeerTimeout <= 0 {
+		config.PeerTimeout = time.Second * 5
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{
+			Timeout: config.PeerTimeout,
+		}
+	}
+
+	if config.PushTTL <= 0 {
+		config.PushTTL = time.Minute * 5
+	}
+
+	if config.MaxPushedInstances <= 0 {
+		config.MaxPushedInstances = 100
+	}
+
+	if config.LocalName == "" {
+		config.LocalName = "local"
+	}
+
+	return &Aggregator{
+		config: config,
+		pushed: make(map[string]pushedSnapshot),
+	}
+}
+
+/*
+ServeHTTP is a net/http handler that returns the fleet-wide view
+*/
+func (a *Aggregator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !authorize(a.config.Auth, w, r) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, a.Aggregate())
+}
+
+/*
+PushHTTPHandler is a net/http handler that accepts a snapshot pushed by
+a peer. The instance name is read from the "instance" query parameter.
+Peers can push using ServerStats.PushSnapshot. A new instance is turned
+away with 429 Too Many Requests when MaxPushedInstances instances have
+pushed within PushTTL
+*/
+func (a *Aggregator) PushHTTPHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err      error
+		snapshot Snapshot
+	)
+
+	if !authorize(a.config.Auth, w, r) {
+		return
+	}
+
+	instance := r.URL.Query().Get("instance")
+
+	if instance == "" {
+		http.Error(w, "instance is required", http.StatusBadRequest)
+		return
+	}
+
+	if err = json.NewDecoder(r.Body).Decode(&snapshot); err != nil {
+		http.Error(w, "invalid snapshot", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now().UTC()
+
+	a.Lock()
+	defer a.Unlock()
+
+	if _, ok := a.pushed[instance]; !ok && len(a.pushed) >= a.config.MaxPushedInstances {
+		a.removeExpired(now)
+
+		if len(a.pushed) >= a.config.MaxPushedInstances {
+			http.Error(w, "too many pushed instances", http.StatusTooManyRequests)
+			return
+		}
+	}
+
+	a.pushed[instance] = pushedSnapshot{
+		receivedAt: now,
+		snapshot:   snapshot,
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/*
+Aggregate fetches snapshots from every peer, combines them with pushed
+and local snapshots, and merges them into a fleet-wide view
+*/
+func (a *Aggregator) Aggregate() FleetSnapshot {
+	snapshots := make([]Snapshot, 0, len(a.config.Peers)+1)
+	instances := make([]InstanceStatus, 0, len(a.config.Peers)+1)
+	now := time.Now().UTC()
+
+	if a.config.Local != nil {
+		snapshot := a.config.Local.GetSnapshot()
+		snapshots = append(snapshots, snapshot)
+		instances = append(instances, InstanceStatus{
+			Instance:     a.config.LocalName,
+			OK:           true,
+			RequestCount: snapshot.RequestCount,
+			SnapshotAt:   now,
+			Source:       "local",
+		})
+	}
+
+	peerSnapshots, peerInstances := a.fetchPeers()
+	snapshots = append(snapshots, peerSnapshots...)
+	instances = append(instances, peerInstances...)
+
+	a.Lock()
+	a.removeExpired(now)
+
+	for instance, pushed := range a.pushed {
+		snapshots = append(snapshots, pushed.snapshot)
+		instances = append(instances, InstanceStatus{
+			Instance:     instance,
+			OK:           true,
+			RequestCount: pushed.snapshot.RequestCount,
+			SnapshotAt:   pushed.receivedAt,
+			Source:       "push",
+		})
+	}
+
+	a.Unlock()
+
+	result := MergeSnapshots(snapshots...)
+	result.GeneratedAt = now
+	result.Instances = instances
+
+	return result
+}
+
+/*
+removeExpired drops pushed snapshots older than PushTTL. The caller
+must hold the lock
+*/
+func (a *Aggregator) removeExpired(now time.Time) {
+	for instance, pushed := range a.pushed {
+		if now.Sub(pushed.receivedAt) > a.config.PushTTL {
+			delete(a.pushed, instance)
+		}
+	}
+}
+
+func (a *Aggregator) fetchPeers() ([]Snapshot, []InstanceStatus) {
+	wg := &sync.WaitGroup{}
+	snapshots := make([]*Snapshot, len(a.config.Peers))
+	instances := make([]InstanceStatus, len(a.config.Peers))
+
+	for index, peer := range a.config.Peers {
+		wg.Add(1)
+
+		go func(index int, peer string) {
+			defer wg.Done()
+
+			instances[index] = InstanceStatus{
+				Instance:   peer,
+				SnapshotAt: time.Now().UTC(),
+				Source:     "pull",
+			}
+
+			snapshot, err := a.fetchPeer(peer)
+
+			if err != nil {
+				instances[index].Error = err.Error()
+				return
+			}
+
+			snapshots[index] = &snapshot
+			instances[index].OK = true
+			instances[index].RequestCount = snapshot.RequestCount
+		}(index, peer)
+	}
+
+	wg.Wait()
+
+	result := make([]Snapshot, 0, len(snapshots))
+
+	for _, snapshot := range snapshots {
+		if snapshot != nil {
+			result = append(result, *snapshot)
+		}
+	}
+
+	return result, instances
+}
+
+func (a *Aggregator) fetchPeer(peer string) (Snapshot, error) {
+	var (
+		err      error
+		b        []byte
+		request  *http.Request
+		response *http.Response
+		result   Snapshot
+	)
+
+	if request, err = http.NewRequest(http.MethodGet, peer, nil); err != nil {
+		return result, fmt.Errorf("error creating peer request: %w", err)
+	}
+
+	for header, values := range a.config.PeerHeaders {
+		for _, value := range values {
+			request.Header.Add(header, value)
+		}
+	}
+
+	request.Header.Set("Accept", "application/json")
+
+	if response, err = a.config.HTTPClient.Do(request); err != nil {
+		return result, fmt.Errorf("error fetching peer stats: %w", err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return result, fmt.Errorf("peer returned status %d", response.StatusCode)
+	}
+
+	if b, err = io.ReadAll(response.Body); err != nil {
+		return result, fmt.Errorf("error reading peer stats: %w", err)
+	}
+
+	if err = json.Unmarshal(b, &result); err != nil {
+		return result, fmt.Errorf("error unmarshaling peer stats: %w", err)
+	}
+
+	return result, nil
+}
+
+/*
+MergeSnapshots merges snapshots from several instances. Counters are
+summed, and latency stats are combined as if they had been calculated
+over every instance's requests. The fleet average response time comes
+from the merged route latency stats, so it covers the same requests as
+the routes do
+*/
+func MergeSnapshots(snapshots ...Snapshot) FleetSnapshot {
+	result := FleetSnapshot{
+		Routes:          make([]RouteStats, 0),
+		Statuses:        make(map[string]int),
+		StatusesByRoute: make(map[string]map[string]int),
+	}
+
+	routes := make(map[string]*RouteStats)
+	overall := LatencyStats{}
+
+	for _, snapshot := range snapshots {
+		result.RequestCount += snapshot.RequestCount
+		result.Errors.ClientDisconnects += snapshot.Errors.ClientDisconnects
+		result.Errors.HandlerErrors += snapshot.Errors.HandlerErrors
+		result.Errors.Panics += snapshot.Errors.Panics
+		result.Errors.Timeouts += snapshot.Errors.Timeouts
+
+		for status, count := range snapshot.Statuses {
+			result.Statuses[status] += count
+		}
+
+		for route, statuses := range snapshot.StatusesByRoute {
+			merged, ok := result.StatusesByRoute[route]
+
+			if !ok {
+				merged = make(map[string]int, len(statuses))
+				result.StatusesByRoute[route] = merged
+			}
+
+			for status, count := range statuses {
+				merged[status] += count
+			}
+		}
+
+		for _, route := range snapshot.Routes {
+			merged, ok := routes[route.Route]
+
+			if !ok {
+				merged = &RouteStats{
+					Method: route.Method,
+					Path:   route.Path,
+					Route:  route.Route,
+				}
+
+				routes[route.Route] = merged
+			}
+
+			merged.ClientDisconnects += route.ClientDisconnects
+			merged.Requests += route.Requests
+			merged.Timeouts += route.Timeouts
+			merged.Latency = mergeLatencyStats(merged.Latency, route.Latency)
+			merged.LatencyBands = mergeLatencyBands(merged.LatencyBands, route.LatencyBands)
+		}
+	}
+
+	for _, route := range routes {
+		result.Routes = append(result.Routes, *route)
+	}
+
+	sort.Slice(result.Routes, func(i, j int) bool {
+		return result.Routes[i].Route < result.Routes[j].Route
+	})
+
+	for _, route := range result.Routes {
+		overall = mergeLatencyStats(overall, route.Latency)
+	}
+
+	result.AverageResponseTimeInMilliseconds = overall.MeanInMilliseconds
+
+	return result
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestMergeSnapshots(t *testing.T) {
+	first := serverstats.Snapshot{
+		RequestCount: 1000,
+		Routes: []serverstats.RouteStats{
+			{Route: "GET /users", Requests: 1, Latency: serverstats.LatencyStats{Count: 1, MeanInMilliseconds: 10, MinInMilliseconds: 10, MaxInMilliseconds: 10}},
+		},
+		Statuses: map[string]int{"200": 1},
+	}
+
+	second := serverstats.Snapshot{
+		RequestCount: 3,
+		Routes: []serverstats.RouteStats{
+			{Route: "GET /users", Requests: 2, Latency: serverstats.LatencyStats{Count: 2, MeanInMilliseconds: 30, MinInMilliseconds: 20, MaxInMilliseconds: 40}},
+			{Route: "POST /users", Requests: 1, Latency: serverstats.LatencyStats{Count: 1, MeanInMilliseconds: 50, MinInMilliseconds: 50, MaxInMilliseconds: 50}},
+		},
+		Statuses: map[string]int{"200": 2, "201": 1},
+	}
+
+	fleet := serverstats.MergeSnapshots(first, second)
+
+	if fleet.RequestCount != 1003 {
+		t.Errorf("Expected 1003 requests but got %d", fleet.RequestCount)
+	}
+
+	if fleet.Statuses["200"] != 3 || fleet.Statuses["201"] != 1 {
+		t.Errorf("Unexpected statuses %v", fleet.Statuses)
+	}
+
+	if len(fleet.Routes) != 2 || fleet.Routes[0].Route != "GET /users" || fleet.Routes[0].Latency.Count != 3 {
+		t.Fatalf("Unexpected routes %+v", fleet.Routes)
+	}
+
+	if math.Abs(fleet.Routes[0].Latency.MeanInMilliseconds-70.0/3.0) > 0.0001 {
+		t.Errorf("Expected a route mean of 23.33 but got %f", fleet.Routes[0].Latency.MeanInMilliseconds)
+	}
+
+	if math.Abs(fleet.AverageResponseTimeInMilliseconds-30) > 0.0001 {
+		t.Errorf("Expected an average response time of 30 but got %f", fleet.AverageResponseTimeInMilliseconds)
+	}
+}
+
+func TestAggregatorPushLimit(t *testing.T) {
+	aggregator := serverstats.NewAggregator(serverstats.AggregatorConfig{
+		MaxPushedInstances: 2,
+	})
+
+	push := func(instance string) int {
+		request := httptest.NewRequest(http.MethodPost, "/push?instance="+instance, strings.NewReader(`{"requestCount":1}`))
+		recorder := httptest.NewRecorder()
+
+		aggregator.PushHTTPHandler(recorder, request)
+		return recorder.Code
+	}
+
+	tests := []struct {
+		instance string
+		expected int
+	}{
+		{instance: "worker-1", expected: http.StatusNoContent},
+		{instance: "worker-2", expected: http.StatusNoContent},
+		{instance: "worker-3", expected: http.StatusTooManyRequests},
+		{instance: "worker-1", expected: http.StatusNoContent},
+	}
+
+	for _, test := range tests {
+		if code := push(test.instance); code != test.expected {
+			t.Errorf("Expected status %d pushing %s but got %d", test.expected, test.instance, code)
+		}
+	}
+
+	if fleet := aggregator.Aggregate(); len(fleet.Instances) != 2 || fleet.RequestCount != 2 {
+		t.Errorf("Expected 2 pushed instances but got %+v", fleet.Instances)
+	}
+}
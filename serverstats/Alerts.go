@@ -0,0 +1,272 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// AlertMetric identifies the metric an alert rule is evaluated against
+type AlertMetric int
+
+const (
+	// MetricAverageLatency is the average response time, in milliseconds, over the rule window
+	MetricAverageLatency AlertMetric = 1
+
+	// MetricLatencyP95 is the 95th percentile response time, in milliseconds, over the rule window
+	MetricLatencyP95 AlertMetric = 2
+
+	// MetricLatencyP99 is the 99th percentile response time, in milliseconds, over the rule window
+	MetricLatencyP99 AlertMetric = 3
+
+	// MetricErrorRate is the percentage (0-100) of 5xx responses over the rule window
+	MetricErrorRate AlertMetric = 4
+
+	// MetricFreeMemoryPercent is the percentage (0-100) of system memory available
+	MetricFreeMemoryPercent AlertMetric = 5
+
+	// MetricApdex is the Apdex score (0-1) over the rule window. Requires ApdexThreshold
+	MetricApdex AlertMetric = 6
+
+	// MetricBurnRate is the error budget burn rate over the rule window. Requires AvailabilitySLO
+	MetricBurnRate AlertMetric = 7
+)
+
+func (m AlertMetric) String() string {
+	switch m {
+	case MetricAverageLatency:
+		return "averageLatency"
+	case MetricLatencyP95:
+		return "latencyP95"
+	case MetricLatencyP99:
+		return "latencyP99"
+	case MetricErrorRate:
+		return "errorRate"
+	case MetricFreeMemoryPercent:
+		return "freeMemoryPercent"
+	case MetricApdex:
+		return "apdex"
+	case MetricBurnRate:
+		return "burnRate"
+	default:
+		return "unknown"
+	}
+}
+
+// AlertComparison describes how a metric value is compared to a threshold
+type AlertComparison int
+
+const (
+	// Above fires when the metric value is greater than the threshold
+	Above AlertComparison = 1
+
+	// Below fires when the metric value is less than the threshold
+	Below AlertComparison = 2
+)
+
+/*
+AlertRule describes a condition that is evaluated by the background
+sampler. For example, to alert when p95 latency is over 500ms for the
+last five minutes:
+
+	AlertRule{
+		Name:       "slow responses",
+		Metric:     MetricLatencyP95,
+		Comparison: Above,
+		Threshold:  500,
+		Window:     time.Minute * 5,
+		Cooldown:   time.Minute * 15,
+		WebhookURL: "https://hooks.example.com/alerts",
+	}
+
+When a rule fires, Callback is called and, if WebhookURL is set, the
+alert is POSTed to that URL as JSON. A rule will not fire again until
+Cooldown has passed. Latency and error rate rules do not fire when there
+are no requests in the window
+*/
+type AlertRule struct {
+	Callback       func(alert Alert)
+	Comparison     AlertComparison
+	Cooldown       time.Duration
+	Metric         AlertMetric
+	Name           string
+	OnWebhookError func(alert Alert, err error)
+	Threshold      float64
+	WebhookURL     string
+	Window         time.Duration
+}
+
+/*
+Alert is the notification sent when an AlertRule fires
+*/
+type Alert struct {
+	FiredAt   time.Time `json:"firedAt"`
+	Metric    string    `json:"metric"`
+	Rule      string    `json:"rule"`
+	Threshold float64   `json:"threshold"`
+	Value     float64   `json:"value"`
+	Window    string    `json:"window"`
+
+	rule *AlertRule
+}
+
+type alertRuleState struct {
+	lastFired time.Time
+	rule      AlertRule
+}
+
+/*
+AddAlertRule registers a new alert rule. Rules are evaluated by the
+background sampler, so StartSampler must be called for alerts to fire
+*/
+func (s *ServerStats) AddAlertRule(rule AlertRule) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.alertRules = append(s.alertRules, &alertRuleState{rule: rule})
+}
+
+/*
+evaluateAlertRules returns alerts for every rule that is breached and
+not cooling down. The caller must hold a lock
+*/
+func (s *ServerStats) evaluateAlertRules(now time.Time) []Alert {
+	result := make([]Alert, 0, len(s.alertRules))
+
+	for _, state := range s.alertRules {
+		var (
+			ok    bool
+			value float64
+		)
+
+		if !state.lastFired.IsZero() && now.Sub(state.lastFired) < state.rule.Cooldown {
+			continue
+		}
+
+		if value, ok = s.alertMetricValue(state.rule.Metric, state.rule.Window, now); !ok {
+			continue
+		}
+
+		if (state.rule.Comparison == Below && value < state.rule.Threshold) ||
+			(state.rule.Comparison != Below && value > state.rule.Threshold) {
+			state.lastFired = now
+
+			result = append(result, Alert{
+				FiredAt:   now,
+				Metric:    state.rule.Metric.String(),
+				Rule:      state.rule.Name,
+				Threshold: state.rule.Threshold,
+				Value:     value,
+				Window:    state.rule.Window.String(),
+
+				rule: &state.rule,
+			})
+		}
+	}
+
+	return result
+}
+
+/*
+alertMetricValue calculates the current value of a metric. The second
+return value is false when there is not enough data to evaluate
+*/
+func (s *ServerStats) alertMetricValue(metric AlertMetric, window time.Duration, now time.Time) (float64, bool) {
+	if metric == MetricFreeMemoryPercent {
+		return s.freeMemoryPercent, s.freeMemoryPercent > 0
+	}
+
+	if metric == MetricApdex || metric == MetricBurnRate {
+		report := s.slo.windowReport(window, now)
+
+		if metric == MetricApdex {
+			return report.Apdex, report.Requests > 0 && report.Apdex >= 0
+		}
+
+		return report.BurnRate, report.Requests > 0 && report.BurnRate >= 0
+	}
+
+	responseTimes := s.responseTimesSince(now.Add(-window))
+
+	if len(responseTimes) == 0 {
+		return 0, false
+	}
+
+	switch metric {
+	case MetricAverageLatency:
+		return toMilliseconds(averageResponseTime(responseTimes)), true
+
+	case MetricLatencyP95:
+		return toMilliseconds(percentile(responseTimes, 95)), true
+
+	case MetricLatencyP99:
+		return toMilliseconds(percentile(responseTimes, 99)), true
+
+	case MetricErrorRate:
+		return errorRate(responseTimes), true
+
+	default:
+		return 0, false
+	}
+}
+
+/*
+dispatchAlert calls the rule's callback and posts the alert to the
+rule's webhook URL. The webhook is called in a goroutine
+*/
+func (s *ServerStats) dispatchAlert(alert Alert) {
+	if alert.rule.Callback != nil {
+		alert.rule.Callback(alert)
+	}
+
+	if alert.rule.WebhookURL == "" {
+		return
+	}
+
+	go func() {
+		if err := s.postAlert(alert); err != nil && alert.rule.OnWebhookError != nil {
+			alert.rule.OnWebhookError(alert, err)
+		}
+	}()
+}
+
+func (s *ServerStats) postAlert(alert Alert) error {
+	var (
+		err      error
+		b        []byte
+		request  *http.Request
+		response *http.Response
+	)
+
+	if b, err = json.Marshal(alert); err != nil {
+		return fmt.Errorf("error marshaling alert: %w", err)
+	}
+
+	if request, err = http.NewRequest(http.MethodPost, alert.rule.WebhookURL, bytes.NewReader(b)); err != nil {
+		return fmt.Errorf("error creating alert webhook request: %w", err)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	if response, err = s.httpClient.Do(request); err != nil {
+		return fmt.Errorf("error posting alert to webhook: %w", err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("alert webhook returned status %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+func toMilliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
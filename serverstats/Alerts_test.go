@@ -0,0 +1,108 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestAlertRules(t *testing.T) {
+	var (
+		mutex   sync.Mutex
+		fired   []serverstats.Alert
+		posted  []serverstats.Alert
+		webhook = make(chan bool, 10)
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert serverstats.Alert
+
+		_ = json.NewDecoder(r.Body).Decode(&alert)
+
+		mutex.Lock()
+		posted = append(posted, alert)
+		mutex.Unlock()
+
+		w.WriteHeader(http.StatusNoContent)
+		webhook <- true
+	}))
+
+	defer server.Close()
+
+	callback := func(alert serverstats.Alert) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		fired = append(fired, alert)
+	}
+
+	stats := serverstats.NewServerStats(nil)
+
+	stats.AddAlertRule(serverstats.AlertRule{
+		Callback:   callback,
+		Comparison: serverstats.Above,
+		Cooldown:   time.Hour,
+		Metric:     serverstats.MetricErrorRate,
+		Name:       "errors",
+		Threshold:  20,
+		WebhookURL: server.URL,
+		Window:     time.Minute,
+	})
+
+	stats.AddAlertRule(serverstats.AlertRule{
+		Callback:   callback,
+		Comparison: serverstats.Above,
+		Metric:     serverstats.MetricAverageLatency,
+		Name:       "slow",
+		Threshold:  1000,
+		Window:     time.Minute,
+	})
+
+	stats.AddAlertRule(serverstats.AlertRule{
+		Callback:   callback,
+		Comparison: serverstats.Below,
+		Metric:     serverstats.MetricApdex,
+		Name:       "apdex without a threshold",
+		Threshold:  0.9,
+		Window:     time.Minute,
+	})
+
+	serve(stats, http.MethodGet, "/", status(http.StatusOK))
+	serve(stats, http.MethodGet, "/", status(http.StatusInternalServerError))
+
+	stats.StartSampler(time.Millisecond * 10)
+	defer stats.StopSampler()
+
+	select {
+	case <-webhook:
+	case <-time.After(time.Second * 2):
+		t.Fatalf("Expected the alert to be posted to the webhook")
+	}
+
+	// Give the sampler a few more runs to show the cooldown holds
+	time.Sleep(time.Millisecond * 50)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if len(fired) != 1 {
+		t.Fatalf("Expected 1 alert but got %+v", fired)
+	}
+
+	if fired[0].Rule != "errors" || fired[0].Metric != "errorRate" || fired[0].Value != 50 || fired[0].Window != "1m0s" {
+		t.Errorf("Unexpected alert %+v", fired[0])
+	}
+
+	if len(posted) != 1 || posted[0].Rule != "errors" || posted[0].Value != 50 {
+		t.Errorf("Expected the webhook to receive the alert once but got %+v", posted)
+	}
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+/*
+BuildInfo describes the build of the running server, so operators can
+see exactly what is deployed
+*/
+type BuildInfo struct {
+	BuildTime string `json:"buildTime,omitempty"`
+	Commit    string `json:"commit,omitempty"`
+	GoVersion string `json:"goVersion"`
+	Module    string `json:"module,omitempty"`
+	Version   string `json:"version,omitempty"`
+}
+
+/*
+SetBuildInfo sets the version, commit, and build time reported in the
+stats output. Empty values are ignored, so anything read from the binary
+is kept unless you provide something else. These are commonly set at
+build time with -ldflags:
+
+	go build -ldflags "-X main.version=1.4.2 -X main.commit=$(git rev-parse HEAD)"
+
+	serverStats.SetBuildInfo(version, commit, buildTime)
+*/
+func (s *ServerStats) SetBuildInfo(version, commit, buildTime string) {
+	s.Lock()
+	defer s.Unlock()
+
+	if buildTime != "" {
+		s.buildInfo.BuildTime = buildTime
+	}
+
+	if commit != "" {
+		s.buildInfo.Commit = commit
+	}
+
+	if version != "" {
+		s.buildInfo.Version = version
+	}
+}
+
+/*
+readBuildInfo returns build information embedded in the binary by the
+Go toolchain, including the VCS revision and commit time when the
+toolchain records them. Values set with SetBuildInfo take precedence
+*/
+func readBuildInfo() BuildInfo {
+	result := BuildInfo{
+		GoVersion: runtime.Version(),
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		result.Module = info.Main.Path
+
+		if info.Main.Version != "(devel)" {
+			result.Version = info.Main.Version
+		}
+
+		applyVCSSettings(info, &result)
+	}
+
+	return result
+}
@@ -0,0 +1,18 @@
+//go:build !go1.18
+// +build !go1.18
+
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"runtime/debug"
+)
+
+/*
+applyVCSSettings does nothing before Go 1.18, which does not record VCS
+information in the binary
+*/
+func applyVCSSettings(info *debug.BuildInfo, result *BuildInfo) {}
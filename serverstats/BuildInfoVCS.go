@@ -0,0 +1,28 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"runtime/debug"
+)
+
+/*
+applyVCSSettings copies the revision and commit time the Go toolchain
+stamps into binaries built from a repository
+*/
+func applyVCSSettings(info *debug.BuildInfo, result *BuildInfo) {
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			result.Commit = setting.Value
+
+		case "vcs.time":
+			result.BuildTime = setting.Value
+		}
+	}
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestSetBuildInfo(t *testing.T) {
+	stats := serverstats.NewServerStats(nil)
+
+	if build := stats.GetSnapshot().Build; build.GoVersion != runtime.Version() {
+		t.Errorf("Expected Go version '%s' but got '%s'", runtime.Version(), build.GoVersion)
+	}
+
+	stats.SetBuildInfo("1.4.2", "abc123", "2021-06-01T12:00:00Z")
+	stats.SetBuildInfo("", "def456", "")
+
+	build := stats.GetSnapshot().Build
+
+	if build.Version != "1.4.2" {
+		t.Errorf("Expected version '1.4.2' but got '%s'", build.Version)
+	}
+
+	if build.Commit != "def456" {
+		t.Errorf("Expected commit 'def456' but got '%s'", build.Commit)
+	}
+
+	if build.BuildTime != "2021-06-01T12:00:00Z" {
+		t.Errorf("Expected build time '2021-06-01T12:00:00Z' but got '%s'", build.BuildTime)
+	}
+}
+
+func TestBuildInfoMetric(t *testing.T) {
+	stats := serverstats.NewServerStats(nil)
+	stats.SetBuildInfo("1.4.2", `abc"123`, "")
+
+	recorder := httptest.NewRecorder()
+	stats.MetricsHTTPHandler(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	expected := fmt.Sprintf(`build_info{commit="abc\"123",go_version="%s",version="1.4.2"} 1`, runtime.Version())
+
+	if !strings.Contains(recorder.Body.String(), "# TYPE build_info gauge\n"+expected+"\n") {
+		t.Errorf("Expected the build info metric %s but got %s", expected, recorder.Body.String())
+	}
+
+	if !strings.Contains(recorder.Body.String(), "# TYPE http_requests_total counter\nhttp_requests_total 0\n") {
+		t.Errorf("Expected a request counter but got %s", recorder.Body.String())
+	}
+
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("Expected the Prometheus text format but got %s", contentType)
+	}
+}
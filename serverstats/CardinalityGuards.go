@@ -0,0 +1,145 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"sort"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// overflowLabel is the status and client type used once their limits are reached
+	overflowLabel = "other"
+
+	// maxClientTypes is how many client types are counted before grouping the rest
+	maxClientTypes = 20
+
+	// overflowMethod and overflowRoute name the route used once MaxRoutes is reached
+	overflowMethod = "*"
+	overflowRoute  = "(overflow)"
+)
+
+/*
+OverflowStats counts requests and values that went past the cardinality
+limits. Routes and Statuses are requests that were grouped under the
+"(overflow)" route or "other" status. ClientTypes are requests grouped
+under the "other" client type. CustomStats are custom stat values that
+were dropped
+*/
+type OverflowStats struct {
+	ClientTypes uint64 `json:"clientTypes"`
+	CustomStats uint64 `json:"customStats"`
+	Routes      uint64 `json:"routes"`
+	Statuses    uint64 `json:"statuses"`
+}
+
+/*
+SetCustomStat sets a custom stat. It returns false, and the stat is
+dropped, when MaxCustomStats different stats have already been set
+*/
+func (s *ServerStats) SetCustomStat(name string, value interface{}) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.setCustomStat(name, value)
+}
+
+/*
+setCustomStat is the single place custom stats are written, so
+MaxCustomStats applies however a stat is set. The caller must hold a
+write lock
+*/
+func (s *ServerStats) setCustomStat(name string, value interface{}) bool {
+	if _, ok := s.CustomStats[name]; !ok && len(s.CustomStats) >= s.maxCustomStats {
+		s.overflow.CustomStats++
+		return false
+	}
+
+	s.CustomStats[name] = value
+	return true
+}
+
+/*
+runCustomMiddleware runs the custom middleware against a staged copy of
+CustomStats, then removes the stats it deleted and sets the rest with
+setCustomStat. Stats are set in name order, so the same stats are
+dropped every time once MaxCustomStats is reached. The caller must hold
+a write lock
+*/
+func (s *ServerStats) runCustomMiddleware(ctx echo.Context) {
+	current := s.CustomStats
+	staged := copyCustomStats(current)
+
+	s.CustomStats = staged
+	s.customMiddleware(ctx, s)
+	s.CustomStats = current
+
+	for name := range current {
+		if _, ok := staged[name]; !ok {
+			delete(current, name)
+		}
+	}
+
+	names := make([]string, 0, len(staged))
+
+	for name := range staged {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		s.setCustomStat(name, staged[name])
+	}
+}
+
+/*
+guardRoute returns the method and path a request is tracked under. Once
+MaxRoutes routes are tracked, requests to new routes are grouped under
+a single overflow route. The caller must hold a write lock
+*/
+func (s *ServerStats) guardRoute(method, path string) (string, string) {
+	if _, ok := s.routes[routeName(method, path)]; ok || len(s.routes) < s.maxRoutes {
+		return method, path
+	}
+
+	s.overflow.Routes++
+	return overflowMethod, overflowRoute
+}
+
+/*
+guardLabel returns label, or "other" if label is new and labels already
+holds limit entries. overflowCount is incremented when that happens
+*/
+func guardLabel(labels map[string]int, label string, limit int, overflowCount *uint64) string {
+	if _, ok := labels[label]; ok || len(labels) < limit {
+		return label
+	}
+
+	*overflowCount++
+	return overflowLabel
+}
+
+/*
+getOverflowStats returns the overflow counters, or nil if no limit has
+been reached. The caller must hold a lock
+*/
+func (s *ServerStats) getOverflowStats() *OverflowStats {
+	if s.overflow == (OverflowStats{}) {
+		return nil
+	}
+
+	result := s.overflow
+	return &result
+}
+
+func positiveOr(value, fallback int) int {
+	if value <= 0 {
+		return fallback
+	}
+
+	return value
+}
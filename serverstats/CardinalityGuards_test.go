@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+	"github.com/labstack/echo/v4"
+)
+
+func TestSetCustomStat(t *testing.T) {
+	options := serverstats.DefaultServerStatsOptions()
+	options.MaxCustomStats = 2
+
+	stats := serverstats.NewServerStatsWithOptions(options, nil)
+
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{name: "queueDepth", expected: true},
+		{name: "cacheHits", expected: true},
+		{name: "workers", expected: false},
+		{name: "queueDepth", expected: true},
+	}
+
+	for _, test := range tests {
+		if actual := stats.SetCustomStat(test.name, 1); actual != test.expected {
+			t.Errorf("Expected %t setting %s but got %t", test.expected, test.name, actual)
+		}
+	}
+
+	snapshot := stats.GetSnapshot()
+
+	if len(snapshot.CustomStats) != 2 || snapshot.Overflow == nil || snapshot.Overflow.CustomStats != 1 {
+		t.Errorf("Expected 2 custom stats and 1 dropped but got %v and %+v", snapshot.CustomStats, snapshot.Overflow)
+	}
+}
+
+func TestCustomMiddlewareStatsLimit(t *testing.T) {
+	options := serverstats.DefaultServerStatsOptions()
+	options.MaxCustomStats = 2
+
+	stats := serverstats.NewServerStatsWithOptions(options, func(ctx echo.Context, serverStats *serverstats.ServerStats) {
+		count, _ := serverStats.CustomStats["requests"].(int)
+		serverStats.CustomStats["requests"] = count + 1
+		serverStats.CustomStats["path "+ctx.Request().URL.Path] = true
+	})
+
+	e := echo.New()
+	e.Use(stats.Middleware)
+	e.GET("/*", func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusOK)
+	})
+
+	for index := 0; index < 3; index++ {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, fmt.Sprintf("/page%d", index), nil))
+	}
+
+	snapshot := stats.GetSnapshot()
+
+	if len(snapshot.CustomStats) != 2 || snapshot.CustomStats["requests"] != 3 || snapshot.CustomStats["path /page0"] != true {
+		t.Errorf("Expected the first 2 custom stats to be kept but got %v", snapshot.CustomStats)
+	}
+
+	if snapshot.Overflow == nil || snapshot.Overflow.CustomStats != 2 {
+		t.Errorf("Expected 2 dropped custom stats but got %+v", snapshot.Overflow)
+	}
+}
+
+func TestRouteAndStatusLimits(t *testing.T) {
+	options := serverstats.DefaultServerStatsOptions()
+	options.MaxRoutes = 2
+	options.MaxStatuses = 2
+
+	stats := serverstats.NewServerStatsWithOptions(options, nil)
+
+	serve(stats, http.MethodGet, "/a", status(http.StatusOK))
+	serve(stats, http.MethodGet, "/b", status(http.StatusNotFound))
+	serve(stats, http.MethodGet, "/c", status(http.StatusOK))
+	serve(stats, http.MethodGet, "/d", status(http.StatusTeapot))
+
+	snapshot := stats.GetSnapshot()
+	routes := make(map[string]uint64)
+
+	for _, route := range snapshot.Routes {
+		routes[route.Route] = route.Requests
+	}
+
+	if len(routes) != 3 || routes["GET /a"] != 1 || routes["GET /b"] != 1 || routes["* (overflow)"] != 2 {
+		t.Errorf("Expected 2 routes plus the overflow route but got %v", routes)
+	}
+
+	if snapshot.Statuses["200"] != 2 || snapshot.Statuses["404"] != 1 || snapshot.Statuses["other"] != 1 {
+		t.Errorf("Expected the third status to be counted as other but got %v", snapshot.Statuses)
+	}
+
+	if snapshot.Overflow == nil || snapshot.Overflow.Routes != 2 || snapshot.Overflow.Statuses != 1 {
+		t.Errorf("Unexpected overflow counts %+v", snapshot.Overflow)
+	}
+}
+
+func TestLatencyReservoirWeighting(t *testing.T) {
+	options := serverstats.DefaultServerStatsOptions()
+	options.LatencyReservoirSize = 5
+
+	stats := serverstats.NewServerStatsWithOptions(options, nil)
+
+	for index := 0; index < 50; index++ {
+		serve(stats, http.MethodGet, "/users", status(http.StatusOK))
+	}
+
+	snapshot := stats.GetSnapshot()
+
+	if count := snapshot.LatencyByWindow["1m0s"].Count; count != 50 {
+		t.Errorf("Expected 5 samples to stand in for 50 requests but got %d", count)
+	}
+
+	if len(snapshot.Routes) != 1 || snapshot.Routes[0].LatencyByWindow["5m0s"].Count != 50 {
+		t.Errorf("Expected the route window to count 50 requests but got %+v", snapshot.Routes)
+	}
+}
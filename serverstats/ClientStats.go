@@ -0,0 +1,155 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"container/list"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+ClientIdentifier resolves the identifier of the client making a request,
+such as an IP address, API key, or user ID. Returning an empty string
+skips tracking the request
+*/
+type ClientIdentifier func(request *http.Request) string
+
+/*
+ClientIdentifierIP identifies clients by their IP address. The first
+address in X-Forwarded-For or X-Real-IP is used when present, so this
+should only be used behind a proxy that sets those headers
+*/
+func ClientIdentifierIP(request *http.Request) string {
+	if forwardedFor := request.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+		return strings.TrimSpace(strings.Split(forwardedFor, ",")[0])
+	}
+
+	if realIP := request.Header.Get("X-Real-IP"); realIP != "" {
+		return realIP
+	}
+
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+
+	if err != nil {
+		return request.RemoteAddr
+	}
+
+	return host
+}
+
+/*
+ClientIdentifierHeader identifies clients by the value of a request
+header, such as X-API-Key
+*/
+func ClientIdentifierHeader(header string) ClientIdentifier {
+	return func(request *http.Request) string {
+		return request.Header.Get(header)
+	}
+}
+
+/*
+ClientStats contains request and error counts for a single client.
+ClientErrors are 4xx responses, and ServerErrors are 5xx responses
+*/
+type ClientStats struct {
+	Client       string    `json:"client"`
+	ClientErrors uint64    `json:"clientErrors"`
+	ErrorRate    float64   `json:"errorRate"`
+	FirstSeen    time.Time `json:"firstSeen"`
+	LastSeen     time.Time `json:"lastSeen"`
+	Requests     uint64    `json:"requests"`
+	ServerErrors uint64    `json:"serverErrors"`
+}
+
+type clientEntry struct {
+	rate  rateCounter
+	stats ClientStats
+}
+
+/*
+clientTracker tracks stats per client, evicting the least recently
+seen client once maxClients is reached
+*/
+type clientTracker struct {
+	clients    map[string]*list.Element
+	lru        *list.List
+	maxClients int
+}
+
+func newClientTracker(maxClients int) *clientTracker {
+	if maxClients <= 0 {
+		maxClients = 1000
+	}
+
+	return &clientTracker{
+		clients:    make(map[string]*list.Element),
+		lru:        list.New(),
+		maxClients: maxClients,
+	}
+}
+
+func (t *clientTracker) record(client string, status int, now time.Time) {
+	var entry *clientEntry
+
+	if element, ok := t.clients[client]; ok {
+		t.lru.MoveToFront(element)
+		entry = element.Value.(*clientEntry)
+	} else {
+		if t.lru.Len() >= t.maxClients {
+			oldest := t.lru.Back()
+			t.lru.Remove(oldest)
+			delete(t.clients, oldest.Value.(*clientEntry).stats.Client)
+		}
+
+		entry = &clientEntry{
+			stats: ClientStats{
+				Client:    client,
+				FirstSeen: now,
+			},
+		}
+
+		t.clients[client] = t.lru.PushFront(entry)
+	}
+
+	stats := &entry.stats
+	entry.rate.add(now)
+
+	stats.Requests++
+	stats.LastSeen = now
+
+	if status >= 500 {
+		stats.ServerErrors++
+	} else if status >= 400 {
+		stats.ClientErrors++
+	}
+}
+
+/*
+top returns up to n clients with the most requests
+*/
+func (t *clientTracker) top(n int) []ClientStats {
+	result := make([]ClientStats, 0, t.lru.Len())
+
+	for element := t.lru.Front(); element != nil; element = element.Next() {
+		stats := element.Value.(*clientEntry).stats
+		stats.ErrorRate = float64(stats.ClientErrors+stats.ServerErrors) / float64(stats.Requests) * 100
+
+		result = append(result, stats)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Requests > result[j].Requests
+	})
+
+	if n > 0 && len(result) > n {
+		result = result[:n]
+	}
+
+	return result
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestClientStats(t *testing.T) {
+	options := serverstats.DefaultServerStatsOptions()
+	options.ClientIdentifier = serverstats.ClientIdentifierHeader("X-API-Key")
+	options.MaxClients = 2
+
+	stats := serverstats.NewServerStatsWithOptions(options, nil)
+
+	requests := []struct {
+		apiKey     string
+		statusCode int
+	}{
+		{apiKey: "a", statusCode: http.StatusOK},
+		{apiKey: "b", statusCode: http.StatusNotFound},
+		{apiKey: "a", statusCode: http.StatusInternalServerError},
+		{apiKey: "", statusCode: http.StatusOK},
+		{apiKey: "a", statusCode: http.StatusOK},
+		{apiKey: "c", statusCode: http.StatusBadRequest},
+	}
+
+	for _, request := range requests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-API-Key", request.apiKey)
+
+		stats.HTTPMiddleware(status(request.statusCode)).ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	clients := stats.GetSnapshot().Clients
+
+	// b was seen least recently when c arrived, so it was evicted
+	if len(clients) != 2 {
+		t.Fatalf("Expected 2 clients but got %+v", clients)
+	}
+
+	if clients[0].Client != "a" || clients[0].Requests != 3 || clients[0].ServerErrors != 1 || clients[0].ClientErrors != 0 {
+		t.Errorf("Unexpected stats for the busiest client %+v", clients[0])
+	}
+
+	if clients[0].ErrorRate < 33.3 || clients[0].ErrorRate > 33.4 {
+		t.Errorf("Expected an error rate of 33.3 but got %v", clients[0].ErrorRate)
+	}
+
+	if clients[1].Client != "c" || clients[1].Requests != 1 || clients[1].ClientErrors != 1 || clients[1].ErrorRate != 100 {
+		t.Errorf("Unexpected stats for the newest client %+v", clients[1])
+	}
+}
+
+func TestClientStatsTopClients(t *testing.T) {
+	options := serverstats.DefaultServerStatsOptions()
+	options.ClientIdentifier = serverstats.ClientIdentifierHeader("X-API-Key")
+	options.NumTopClients = 1
+
+	stats := serverstats.NewServerStatsWithOptions(options, nil)
+
+	for _, apiKey := range []string{"a", "b", "b"} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-API-Key", apiKey)
+
+		stats.HTTPMiddleware(status(http.StatusOK)).ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	if clients := stats.GetSnapshot().Clients; len(clients) != 1 || clients[0].Client != "b" {
+		t.Errorf("Expected only the busiest client but got %+v", clients)
+	}
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	ClientTypeAPI     = "api"
+	ClientTypeBot     = "bot"
+	ClientTypeBrowser = "browser"
+	ClientTypeMobile  = "mobile"
+	ClientTypeUnknown = "unknown"
+)
+
+var (
+	botUserAgentMarkers = []string{
+		"bot", "crawl", "spider", "slurp", "facebookexternalhit", "headlesschrome", "lighthouse",
+	}
+
+	apiUserAgentMarkers = []string{
+		"curl/", "wget/", "httpie/", "python-requests", "python-urllib", "go-http-client", "okhttp",
+		"axios", "node-fetch", "java/", "apache-httpclient", "postmanruntime", "insomnia", "libwww-perl",
+	}
+
+	mobileUserAgentMarkers = []string{
+		"mobile", "android", "iphone", "ipad", "ipod", "cfnetwork", "dalvik",
+	}
+)
+
+/*
+ClientTypeClassifier sorts a request into a client type, such as
+browser, mobile, bot, or API client. It should return one of a small,
+fixed set of values, as every value returned gets its own counter.
+Returning an empty string skips counting the request
+*/
+type ClientTypeClassifier func(request *http.Request) string
+
+/*
+ClientTypeFromUserAgent classifies requests by their User-Agent header
+using ClassifyUserAgent
+*/
+func ClientTypeFromUserAgent(request *http.Request) string {
+	return ClassifyUserAgent(request.UserAgent())
+}
+
+/*
+ClassifyUserAgent sorts a User-Agent string into ClientTypeBot,
+ClientTypeAPI, ClientTypeMobile, or ClientTypeBrowser. Non-browser
+agents that aren't recognized are considered API clients, and an empty
+User-Agent is ClientTypeUnknown
+*/
+func ClassifyUserAgent(userAgent string) string {
+	userAgent = strings.ToLower(strings.TrimSpace(userAgent))
+
+	if userAgent == "" {
+		return ClientTypeUnknown
+	}
+
+	if containsAny(userAgent, botUserAgentMarkers) {
+		return ClientTypeBot
+	}
+
+	if containsAny(userAgent, apiUserAgentMarkers) {
+		return ClientTypeAPI
+	}
+
+	if containsAny(userAgent, mobileUserAgentMarkers) {
+		return ClientTypeMobile
+	}
+
+	if strings.HasPrefix(userAgent, "mozilla/") || strings.HasPrefix(userAgent, "opera/") {
+		return ClientTypeBrowser
+	}
+
+	return ClientTypeAPI
+}
+
+func containsAny(value string, markers []string) bool {
+	for _, marker := range markers {
+		if strings.Contains(value, marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+recordClientType counts the request against its client type. The
+caller must hold a write lock
+*/
+func (s *ServerStats) recordClientType(request *http.Request) {
+	if s.clientTypeClassifier == nil {
+		return
+	}
+
+	clientType := s.clientTypeClassifier(request)
+
+	if clientType == "" {
+		return
+	}
+
+	if _, ok := s.clientTypes[clientType]; !ok && len(s.clientTypes) >= maxClientTypes {
+		s.overflow.ClientTypes++
+		clientType = overflowLabel
+	}
+
+	s.clientTypes[clientType]++
+}
+
+/*
+getClientTypes returns request counts per client type. The caller must
+hold a lock
+*/
+func (s *ServerStats) getClientTypes() map[string]uint64 {
+	if len(s.clientTypes) == 0 {
+		return nil
+	}
+
+	result := make(map[string]uint64, len(s.clientTypes))
+
+	for clientType, count := range s.clientTypes {
+		result[clientType] = count
+	}
+
+	return result
+}
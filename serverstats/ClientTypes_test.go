@@ -0,0 +1,35 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestClassifyUserAgent(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		expected  string
+	}{
+		{"", serverstats.ClientTypeUnknown},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/96.0.4664.110 Safari/537.36", serverstats.ClientTypeBrowser},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 15_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.2 Mobile/15E148 Safari/604.1", serverstats.ClientTypeMobile},
+		{"Mozilla/5.0 (Linux; Android 12; Pixel 6) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/96.0.4664.104 Mobile Safari/537.36", serverstats.ClientTypeMobile},
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", serverstats.ClientTypeBot},
+		{"curl/7.79.1", serverstats.ClientTypeAPI},
+		{"Go-http-client/1.1", serverstats.ClientTypeAPI},
+		{"MyInternalService/2.0", serverstats.ClientTypeAPI},
+	}
+
+	for _, test := range tests {
+		actual := serverstats.ClassifyUserAgent(test.userAgent)
+
+		if actual != test.expected {
+			t.Errorf("Expected '%s' for '%s' but got '%s'", test.expected, test.userAgent, actual)
+		}
+	}
+}
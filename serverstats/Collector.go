@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"context"
+	"time"
+)
+
+/*
+Collector is implemented by anything that wants to contribute its own
+stats, such as cache hit rates or queue depths. Collectors are polled by
+the background sampler, and the values returned from Collect are
+reported under the collector's name. Collectors are polled concurrently
+and share a deadline of one sampler interval. The context is canceled
+at the deadline, and collectors that haven't returned by then, or that
+panic, are left out of that sample
+*/
+type Collector interface {
+	Name() string
+	Collect(ctx context.Context) map[string]interface{}
+}
+
+/*
+MockCollector is a mock Collector
+*/
+type MockCollector struct {
+	NameFunc    func() string
+	CollectFunc func(ctx context.Context) map[string]interface{}
+}
+
+func (m *MockCollector) Name() string {
+	return m.NameFunc()
+}
+
+func (m *MockCollector) Collect(ctx context.Context) map[string]interface{} {
+	return m.CollectFunc(ctx)
+}
+
+/*
+RegisterCollector adds a collector to be polled by the background sampler.
+Registering a collector with the same name as an existing one replaces it
+*/
+func (s *ServerStats) RegisterCollector(collector Collector) {
+	s.Lock()
+	defer s.Unlock()
+
+	for index, existing := range s.collectors {
+		if existing.Name() == collector.Name() {
+			s.collectors[index] = collector
+			return
+		}
+	}
+
+	s.collectors = append(s.collectors, collector)
+}
+
+/*
+runCollectors polls every registered collector concurrently, waiting at
+most timeout for all of them. This must be called without holding a
+lock, as collectors may be slow
+*/
+func (s *ServerStats) runCollectors(timeout time.Duration) map[string]map[string]interface{} {
+	type collected struct {
+		name     string
+		panicked bool
+		values   map[string]interface{}
+	}
+
+	s.RLock()
+	collectors := make([]Collector, len(s.collectors))
+	copy(collectors, s.collectors)
+	s.RUnlock()
+
+	result := make(map[string]map[string]interface{}, len(collectors))
+
+	if len(collectors) == 0 {
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	results := make(chan collected, len(collectors))
+
+	for _, collector := range collectors {
+		go func(collector Collector) {
+			item := collected{name: collector.Name(), panicked: true}
+
+			/*
+			 * A panicking collector is left out rather than taking the
+			 * sampler, and the process, down with it
+			 */
+			defer func() {
+				_ = recover()
+				results <- item
+			}()
+
+			item.values = collector.Collect(ctx)
+			item.panicked = false
+		}(collector)
+	}
+
+	for range collectors {
+		select {
+		case item := <-results:
+			if !item.panicked {
+				result[item.name] = item.values
+			}
+
+		case <-ctx.Done():
+			return result
+		}
+	}
+
+	return result
+}
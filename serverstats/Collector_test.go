@@ -0,0 +1,76 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestCollectors(t *testing.T) {
+	collector := func(name string, collect func(ctx context.Context) map[string]interface{}) *serverstats.MockCollector {
+		return &serverstats.MockCollector{
+			NameFunc:    func() string { return name },
+			CollectFunc: collect,
+		}
+	}
+
+	slow := func(ctx context.Context) map[string]interface{} {
+		time.Sleep(time.Millisecond * 40)
+		return map[string]interface{}{"depth": 2}
+	}
+
+	release := make(chan bool)
+	defer close(release)
+
+	stats := serverstats.NewServerStats(nil)
+
+	stats.RegisterCollector(collector("cache", func(ctx context.Context) map[string]interface{} {
+		return map[string]interface{}{"hits": 1}
+	}))
+
+	stats.RegisterCollector(collector("cache", func(ctx context.Context) map[string]interface{} {
+		return map[string]interface{}{"hits": 10}
+	}))
+
+	stats.RegisterCollector(collector("queue1", slow))
+	stats.RegisterCollector(collector("queue2", slow))
+	stats.RegisterCollector(collector("queue3", slow))
+
+	stats.RegisterCollector(collector("stuck", func(ctx context.Context) map[string]interface{} {
+		<-release
+		return map[string]interface{}{"stuck": true}
+	}))
+
+	start := time.Now()
+	stats.StartSampler(time.Millisecond * 50)
+	defer stats.StopSampler()
+
+	if !waitFor(func() bool { return stats.GetSnapshot().Collectors != nil }) {
+		t.Fatalf("Expected the sampler to poll collectors")
+	}
+
+	// Polled one after another, the slow collectors alone would take 120ms on top of the interval
+	if elapsed := time.Since(start); elapsed > time.Millisecond*180 {
+		t.Errorf("Expected collectors to be polled concurrently within one interval but took %s", elapsed)
+	}
+
+	collectors := stats.GetSnapshot().Collectors
+
+	if len(collectors) != 4 {
+		t.Fatalf("Expected 4 collectors but got %v", collectors)
+	}
+
+	if collectors["cache"]["hits"] != 10 || collectors["queue3"]["depth"] != 2 {
+		t.Errorf("Unexpected collected stats %v", collectors)
+	}
+
+	if _, ok := collectors["stuck"]; ok {
+		t.Errorf("Expected the collector that missed the deadline to be left out")
+	}
+}
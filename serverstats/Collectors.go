@@ -0,0 +1,144 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"context"
+)
+
+/*
+CacheStatsProvider is implemented by caches that count hits and misses
+*/
+type CacheStatsProvider interface {
+	Hits() uint64
+	Misses() uint64
+}
+
+/*
+WorkerPoolStatsProvider is implemented by worker pools that report how
+many workers are busy and how many jobs are waiting. workerpool.Pool
+satisfies this interface
+*/
+type WorkerPoolStatsProvider interface {
+	AvailableWorkers() int
+	MaxWorkers() int
+	QueuedJobs() int
+}
+
+/*
+CacheCollector reports the hit/miss counts and hit ratio of a cache
+*/
+type CacheCollector struct {
+	cache CacheStatsProvider
+	name  string
+}
+
+/*
+NewCacheCollector creates a collector that reports cache hit and miss stats
+*/
+func NewCacheCollector(name string, cache CacheStatsProvider) *CacheCollector {
+	return &CacheCollector{
+		cache: cache,
+		name:  name,
+	}
+}
+
+func (c *CacheCollector) Name() string {
+	return c.name
+}
+
+func (c *CacheCollector) Collect(ctx context.Context) map[string]interface{} {
+	hits := c.cache.Hits()
+	misses := c.cache.Misses()
+	hitRatio := 0.0
+
+	if hits+misses > 0 {
+		hitRatio = float64(hits) / float64(hits+misses)
+	}
+
+	return map[string]interface{}{
+		"hits":     hits,
+		"misses":   misses,
+		"hitRatio": hitRatio,
+	}
+}
+
+/*
+QueueDepthCollector reports the depth of one or more in-process queues.
+Each gauge is a function returning the current depth, such as the length
+of a buffered channel
+*/
+type QueueDepthCollector struct {
+	gauges map[string]func() int
+	name   string
+}
+
+/*
+NewQueueDepthCollector creates a collector reporting queue depths. For example:
+
+	serverstats.NewQueueDepthCollector("queues", map[string]func() int{
+		"outboundMail": func() int { return len(mailChannel) },
+	})
+*/
+func NewQueueDepthCollector(name string, gauges map[string]func() int) *QueueDepthCollector {
+	return &QueueDepthCollector{
+		gauges: gauges,
+		name:   name,
+	}
+}
+
+func (c *QueueDepthCollector) Name() string {
+	return c.name
+}
+
+func (c *QueueDepthCollector) Collect(ctx context.Context) map[string]interface{} {
+	result := make(map[string]interface{}, len(c.gauges))
+
+	for queue, gauge := range c.gauges {
+		result[queue] = gauge()
+	}
+
+	return result
+}
+
+/*
+WorkerPoolCollector reports worker pool utilization and queue depth
+*/
+type WorkerPoolCollector struct {
+	name string
+	pool WorkerPoolStatsProvider
+}
+
+/*
+NewWorkerPoolCollector creates a collector that reports how busy a
+worker pool is
+*/
+func NewWorkerPoolCollector(name string, pool WorkerPoolStatsProvider) *WorkerPoolCollector {
+	return &WorkerPoolCollector{
+		name: name,
+		pool: pool,
+	}
+}
+
+func (c *WorkerPoolCollector) Name() string {
+	return c.name
+}
+
+func (c *WorkerPoolCollector) Collect(ctx context.Context) map[string]interface{} {
+	maxWorkers := c.pool.MaxWorkers()
+	busyWorkers := maxWorkers - c.pool.AvailableWorkers()
+	utilization := 0.0
+
+	if maxWorkers > 0 {
+		utilization = float64(busyWorkers) / float64(maxWorkers)
+	}
+
+	return map[string]interface{}{
+		"busyWorkers": busyWorkers,
+		"maxWorkers":  maxWorkers,
+		"queuedJobs":  c.pool.QueuedJobs(),
+		"utilization": utilization,
+	}
+}
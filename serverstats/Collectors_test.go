@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+	"github.com/ResurgenceIT/kit/v6/workerpool"
+)
+
+var _ serverstats.WorkerPoolStatsProvider = &workerpool.Pool{}
+
+type fakeCache struct {
+	hits   uint64
+	misses uint64
+}
+
+func (c fakeCache) Hits() uint64   { return c.hits }
+func (c fakeCache) Misses() uint64 { return c.misses }
+
+type fakePool struct {
+	available int
+	max       int
+	queued    int
+}
+
+func (p fakePool) AvailableWorkers() int { return p.available }
+func (p fakePool) MaxWorkers() int       { return p.max }
+func (p fakePool) QueuedJobs() int       { return p.queued }
+
+func TestBuiltInCollectors(t *testing.T) {
+	tests := []struct {
+		name      string
+		collector serverstats.Collector
+		expected  map[string]interface{}
+	}{
+		{
+			name:      "Cache hits and misses",
+			collector: serverstats.NewCacheCollector("cache", fakeCache{hits: 3, misses: 1}),
+			expected:  map[string]interface{}{"hits": uint64(3), "misses": uint64(1), "hitRatio": 0.75},
+		},
+		{
+			name:      "Unused cache",
+			collector: serverstats.NewCacheCollector("cache", fakeCache{}),
+			expected:  map[string]interface{}{"hits": uint64(0), "misses": uint64(0), "hitRatio": 0.0},
+		},
+		{
+			name: "Queue depths",
+			collector: serverstats.NewQueueDepthCollector("queues", map[string]func() int{
+				"mail":     func() int { return 4 },
+				"webhooks": func() int { return 0 },
+			}),
+			expected: map[string]interface{}{"mail": 4, "webhooks": 0},
+		},
+		{
+			name:      "Busy worker pool",
+			collector: serverstats.NewWorkerPoolCollector("workers", fakePool{available: 1, max: 4, queued: 7}),
+			expected:  map[string]interface{}{"busyWorkers": 3, "maxWorkers": 4, "queuedJobs": 7, "utilization": 0.75},
+		},
+		{
+			name:      "Worker pool without workers",
+			collector: serverstats.NewWorkerPoolCollector("workers", fakePool{}),
+			expected:  map[string]interface{}{"busyWorkers": 0, "maxWorkers": 0, "queuedJobs": 0, "utilization": 0.0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := test.collector.Collect(context.Background()); !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("Expected %v but got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCollectorRegistration(t *testing.T) {
+	stats := serverstats.NewServerStats(nil)
+
+	stats.RegisterCollector(serverstats.NewCacheCollector("cache", fakeCache{hits: 1}))
+	stats.RegisterCollector(serverstats.NewWorkerPoolCollector("workers", fakePool{available: 2, max: 2}))
+	stats.RegisterCollector(serverstats.NewCacheCollector("cache", fakeCache{hits: 5, misses: 5}))
+
+	stats.StartSampler(time.Millisecond * 20)
+	defer stats.StopSampler()
+
+	if !waitFor(func() bool { return stats.GetSnapshot().Collectors != nil }) {
+		t.Fatalf("Expected the sampler to poll collectors")
+	}
+
+	collectors := stats.GetSnapshot().Collectors
+
+	if len(collectors) != 2 {
+		t.Fatalf("Expected 2 collectors but got %v", collectors)
+	}
+
+	if collectors["cache"]["hits"] != uint64(5) || collectors["cache"]["hitRatio"] != 0.5 {
+		t.Errorf("Expected the second cache collector to replace the first but got %v", collectors["cache"])
+	}
+
+	if collectors["workers"]["busyWorkers"] != 0 {
+		t.Errorf("Expected an idle worker pool but got %v", collectors["workers"])
+	}
+}
+
+func TestCollectorPanicIsolation(t *testing.T) {
+	stats := serverstats.NewServerStats(nil)
+
+	stats.RegisterCollector(&serverstats.MockCollector{
+		NameFunc: func() string { return "broken" },
+		CollectFunc: func(ctx context.Context) map[string]interface{} {
+			panic("boom")
+		},
+	})
+
+	stats.RegisterCollector(&serverstats.MockCollector{
+		NameFunc: func() string { return "empty" },
+		CollectFunc: func(ctx context.Context) map[string]interface{} {
+			return nil
+		},
+	})
+
+	stats.RegisterCollector(serverstats.NewQueueDepthCollector("queues", map[string]func() int{
+		"mail": func() int { return 2 },
+	}))
+
+	stats.StartSampler(time.Millisecond * 20)
+	defer stats.StopSampler()
+
+	if !waitFor(func() bool { return stats.GetSnapshot().Collectors != nil }) {
+		t.Fatalf("Expected the sampler to poll collectors")
+	}
+
+	collectors := stats.GetSnapshot().Collectors
+
+	if _, ok := collectors["broken"]; ok {
+		t.Errorf("Expected the panicking collector to be left out but got %v", collectors)
+	}
+
+	if _, ok := collectors["empty"]; !ok {
+		t.Errorf("Expected a collector with nothing to report to be kept but got %v", collectors)
+	}
+
+	if collectors["queues"]["mail"] != 2 {
+		t.Errorf("Expected the other collectors to be reported but got %v", collectors)
+	}
+
+	/*
+	 * The sampler keeps running after a panic
+	 */
+	stats.RegisterCollector(serverstats.NewQueueDepthCollector("queues", map[string]func() int{
+		"mail": func() int { return 9 },
+	}))
+
+	if !waitFor(func() bool { return stats.GetSnapshot().Collectors["queues"]["mail"] == 9 }) {
+		t.Errorf("Expected the sampler to keep polling after a collector panicked")
+	}
+}
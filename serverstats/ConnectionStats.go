@@ -0,0 +1,209 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+var tlsVersionNames = map[uint16]string{
+	tls.VersionTLS10: "TLS 1.0",
+	tls.VersionTLS11: "TLS 1.1",
+	tls.VersionTLS12: "TLS 1.2",
+	tls.VersionTLS13: "TLS 1.3",
+}
+
+/*
+ConnectionStats reports connection-level metrics for an instrumented
+http.Server. KeepAliveReuseRate is the percentage of times a connection
+went active that were an idle keep-alive connection being reused.
+Protocols counts requests by protocol (e.g. HTTP/1.1 and HTTP/2.0).
+TLS handshake failures are connections that closed before completing a
+handshake
+*/
+type ConnectionStats struct {
+	ActiveConnections    int64             `json:"activeConnections"`
+	ClosedConnections    uint64            `json:"closedConnections"`
+	KeepAliveReuseRate   float64           `json:"keepAliveReuseRate"`
+	KeepAliveReuses      uint64            `json:"keepAliveReuses"`
+	NewConnections       uint64            `json:"newConnections"`
+	OpenConnections      int64             `json:"openConnections"`
+	Protocols            map[string]uint64 `json:"protocols"`
+	TLSHandshakeFailures uint64            `json:"tlsHandshakeFailures"`
+	TLSHandshakes        uint64            `json:"tlsHandshakes"`
+	TLSVersions          map[string]uint64 `json:"tlsVersions"`
+}
+
+type connectionTracker struct {
+	activations          uint64
+	closed               uint64
+	connections          map[net.Conn]*trackedConnection
+	newConnections       uint64
+	protocols            map[string]uint64
+	reuses               uint64
+	tlsHandshakeFailures uint64
+	tlsHandshakes        uint64
+	tlsVersions          map[string]uint64
+}
+
+type trackedConnection struct {
+	handshakeRecorded bool
+	state             http.ConnState
+}
+
+func newConnectionTracker() *connectionTracker {
+	return &connectionTracker{
+		connections: make(map[net.Conn]*trackedConnection),
+		protocols:   make(map[string]uint64),
+		tlsVersions: make(map[string]uint64),
+	}
+}
+
+/*
+InstrumentServer hooks into an http.Server's connection state changes
+to record connection counts, keep-alive reuse, and TLS handshakes and
+versions. Any ConnState hook already on the server is still called.
+This must be called before the server starts. With Echo, pass
+echo.Server (and echo.TLSServer when serving TLS)
+*/
+func (s *ServerStats) InstrumentServer(server *http.Server) {
+	s.Lock()
+
+	if s.connections == nil {
+		s.connections = newConnectionTracker()
+	}
+
+	s.Unlock()
+
+	existing := server.ConnState
+
+	server.ConnState = func(conn net.Conn, state http.ConnState) {
+		s.recordConnState(conn, state)
+
+		if existing != nil {
+			existing(conn, state)
+		}
+	}
+}
+
+func (s *ServerStats) recordConnState(conn net.Conn, state http.ConnState) {
+	var (
+		tlsState *tls.ConnectionState
+	)
+
+	/*
+	 * The TLS connection state is read before taking the lock, as it
+	 * takes the connection's own handshake lock
+	 */
+	if tlsConn, ok := conn.(*tls.Conn); ok && state != http.StateNew {
+		connectionState := tlsConn.ConnectionState()
+		tlsState = &connectionState
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	tracker := s.connections
+	tracked, ok := tracker.connections[conn]
+
+	if !ok {
+		tracked = &trackedConnection{}
+		tracker.connections[conn] = tracked
+	}
+
+	switch state {
+	case http.StateNew:
+		tracker.newConnections++
+
+	case http.StateActive:
+		if tracked.state == http.StateIdle {
+			tracker.reuses++
+		} else {
+			tracker.activations++
+		}
+
+		if tlsState != nil && !tracked.handshakeRecorded && tlsState.HandshakeComplete {
+			tracked.handshakeRecorded = true
+			tracker.tlsHandshakes++
+			tracker.tlsVersions[tlsVersionName(tlsState.Version)]++
+		}
+
+	case http.StateClosed, http.StateHijacked:
+		if tlsState != nil && !tracked.handshakeRecorded && !tlsState.HandshakeComplete {
+			tracker.tlsHandshakeFailures++
+		}
+
+		tracker.closed++
+		delete(tracker.connections, conn)
+		return
+	}
+
+	tracked.state = state
+}
+
+/*
+recordProtocol counts the request's protocol. The caller must hold a
+write lock
+*/
+func (s *ServerStats) recordProtocol(request *http.Request) {
+	if s.connections == nil {
+		return
+	}
+
+	s.connections.protocols[request.Proto]++
+}
+
+/*
+getConnectionStats returns connection stats, or nil if no server has
+been instrumented. The caller must hold a lock
+*/
+func (s *ServerStats) getConnectionStats() *ConnectionStats {
+	if s.connections == nil {
+		return nil
+	}
+
+	tracker := s.connections
+	result := &ConnectionStats{
+		ClosedConnections:    tracker.closed,
+		KeepAliveReuses:      tracker.reuses,
+		NewConnections:       tracker.newConnections,
+		OpenConnections:      int64(len(tracker.connections)),
+		Protocols:            make(map[string]uint64, len(tracker.protocols)),
+		TLSHandshakeFailures: tracker.tlsHandshakeFailures,
+		TLSHandshakes:        tracker.tlsHandshakes,
+		TLSVersions:          make(map[string]uint64, len(tracker.tlsVersions)),
+	}
+
+	for _, tracked := range tracker.connections {
+		if tracked.state == http.StateActive {
+			result.ActiveConnections++
+		}
+	}
+
+	if total := tracker.activations + tracker.reuses; total > 0 {
+		result.KeepAliveReuseRate = float64(tracker.reuses) / float64(total) * 100
+	}
+
+	for protocol, count := range tracker.protocols {
+		result.Protocols[protocol] = count
+	}
+
+	for version, count := range tracker.tlsVersions {
+		result.TLSVersions[version] = count
+	}
+
+	return result
+}
+
+func tlsVersionName(version uint16) string {
+	if name, ok := tlsVersionNames[version]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("0x%04x", version)
+}
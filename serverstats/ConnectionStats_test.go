@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"crypto/tls"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestConnectionStates(t *testing.T) {
+	stats := serverstats.NewServerStats(nil)
+	existingCalls := 0
+
+	server := &http.Server{
+		ConnState: func(conn net.Conn, state http.ConnState) {
+			existingCalls++
+		},
+	}
+
+	if stats.GetSnapshot().Connections != nil {
+		t.Fatalf("Expected no connection stats before a server is instrumented")
+	}
+
+	stats.InstrumentServer(server)
+
+	first, firstPeer := net.Pipe()
+	second, secondPeer := net.Pipe()
+	defer firstPeer.Close()
+	defer secondPeer.Close()
+
+	tests := []struct {
+		name           string
+		conn           net.Conn
+		state          http.ConnState
+		expectedNew    uint64
+		expectedOpen   int64
+		expectedActive int64
+		expectedReuses uint64
+		expectedClosed uint64
+		expectedRate   float64
+	}{
+		{name: "First connection opens", conn: first, state: http.StateNew, expectedNew: 1, expectedOpen: 1},
+		{name: "First connection serves a request", conn: first, state: http.StateActive, expectedNew: 1, expectedOpen: 1, expectedActive: 1},
+		{name: "Second connection opens", conn: second, state: http.StateNew, expectedNew: 2, expectedOpen: 2, expectedActive: 1},
+		{name: "Second connection serves a request", conn: second, state: http.StateActive, expectedNew: 2, expectedOpen: 2, expectedActive: 2},
+		{name: "First connection goes idle", conn: first, state: http.StateIdle, expectedNew: 2, expectedOpen: 2, expectedActive: 1},
+		{name: "First connection is reused", conn: first, state: http.StateActive, expectedNew: 2, expectedOpen: 2, expectedActive: 2, expectedReuses: 1, expectedRate: 100.0 / 3},
+		{name: "Second connection is hijacked", conn: second, state: http.StateHijacked, expectedNew: 2, expectedOpen: 1, expectedActive: 1, expectedReuses: 1, expectedClosed: 1, expectedRate: 100.0 / 3},
+		{name: "First connection goes idle again", conn: first, state: http.StateIdle, expectedNew: 2, expectedOpen: 1, expectedReuses: 1, expectedClosed: 1, expectedRate: 100.0 / 3},
+		{name: "First connection closes", conn: first, state: http.StateClosed, expectedNew: 2, expectedReuses: 1, expectedClosed: 2, expectedRate: 100.0 / 3},
+	}
+
+	for index, test := range tests {
+		server.ConnState(test.conn, test.state)
+		connections := stats.GetSnapshot().Connections
+
+		if connections.NewConnections != test.expectedNew || connections.OpenConnections != test.expectedOpen || connections.ActiveConnections != test.expectedActive {
+			t.Errorf("%s: expected %d new, %d open, and %d active but got %+v", test.name, test.expectedNew, test.expectedOpen, test.expectedActive, connections)
+		}
+
+		if connections.KeepAliveReuses != test.expectedReuses || connections.ClosedConnections != test.expectedClosed || math.Abs(connections.KeepAliveReuseRate-test.expectedRate) > 1e-9 {
+			t.Errorf("%s: expected %d reuses at %v%% and %d closed but got %+v", test.name, test.expectedReuses, test.expectedRate, test.expectedClosed, connections)
+		}
+
+		if existingCalls != index+1 {
+			t.Errorf("%s: expected the existing ConnState hook to be called %d times but got %d", test.name, index+1, existingCalls)
+		}
+	}
+}
+
+func TestTLSHandshakeFailures(t *testing.T) {
+	stats := serverstats.NewServerStats(nil)
+	server := &http.Server{}
+	stats.InstrumentServer(server)
+
+	conn, peer := net.Pipe()
+	defer peer.Close()
+
+	tlsConn := tls.Server(conn, &tls.Config{})
+
+	server.ConnState(tlsConn, http.StateNew)
+	server.ConnState(tlsConn, http.StateClosed)
+
+	connections := stats.GetSnapshot().Connections
+
+	if connections.TLSHandshakeFailures != 1 || connections.TLSHandshakes != 0 || connections.OpenConnections != 0 {
+		t.Errorf("Expected one failed handshake but got %+v", connections)
+	}
+}
+
+func TestInstrumentedTLSServer(t *testing.T) {
+	stats := serverstats.NewServerStats(nil)
+	server := httptest.NewUnstartedServer(stats.HTTPMiddleware(status(http.StatusOK)))
+	stats.InstrumentServer(server.Config)
+	server.StartTLS()
+
+	client := server.Client()
+
+	for i := 0; i < 3; i++ {
+		response, err := client.Get(server.URL)
+
+		if err != nil {
+			t.Fatalf("Expected no error but got '%s'", err)
+		}
+
+		_ = response.Body.Close()
+	}
+
+	server.Close()
+
+	var connections *serverstats.ConnectionStats
+
+	if !waitFor(func() bool {
+		connections = stats.GetSnapshot().Connections
+		return connections.ClosedConnections == 1
+	}) {
+		t.Fatalf("Expected the connection to be closed but got %+v", connections)
+	}
+
+	if connections.NewConnections != 1 || connections.KeepAliveReuses != 2 || connections.OpenConnections != 0 {
+		t.Errorf("Expected one connection reused twice but got %+v", connections)
+	}
+
+	if connections.TLSHandshakes != 1 || connections.TLSHandshakeFailures != 0 || connections.TLSVersions["TLS 1.3"] != 1 {
+		t.Errorf("Expected one TLS 1.3 handshake but got %+v", connections)
+	}
+
+	if connections.Protocols["HTTP/1.1"] != 3 {
+		t.Errorf("Expected 3 HTTP/1.1 requests but got %+v", connections.Protocols)
+	}
+}
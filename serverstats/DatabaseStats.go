@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"database/sql"
+	"time"
+)
+
+/*
+DBStatsProvider is anything that can report database/sql connection
+pool statistics. *sql.DB satisfies this interface
+*/
+type DBStatsProvider interface {
+	Stats() sql.DBStats
+}
+
+/*
+DBPoolStats reports connection pool statistics for a registered database.
+WaitCountSinceLastSample is how many connections had to be waited for
+since the previous sample, which is usually more telling than the total
+*/
+type DBPoolStats struct {
+	Idle                       int       `json:"idle"`
+	InUse                      int       `json:"inUse"`
+	MaxIdleClosed              int64     `json:"maxIdleClosed"`
+	MaxIdleTimeClosed          int64     `json:"maxIdleTimeClosed"`
+	MaxLifetimeClosed          int64     `json:"maxLifetimeClosed"`
+	MaxOpenConnections         int       `json:"maxOpenConnections"`
+	OpenConnections            int       `json:"openConnections"`
+	SampledAt                  time.Time `json:"sampledAt"`
+	WaitCount                  int64     `json:"waitCount"`
+	WaitCountSinceLastSample   int64     `json:"waitCountSinceLastSample"`
+	WaitDurationInMilliseconds int64     `json:"waitDurationInMilliseconds"`
+}
+
+/*
+RegisterDB registers a database handle whose connection pool stats are
+read by the background sampler and included in the stats output under
+the provided name
+*/
+func (s *ServerStats) RegisterDB(name string, db DBStatsProvider) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.databases[name] = db
+}
+
+/*
+sampleDatabases reads pool stats from every registered database. The
+caller must hold a write lock
+*/
+func (s *ServerStats) sampleDatabases(now time.Time) {
+	for name, db := range s.databases {
+		stats := db.Stats()
+		previous, hasPrevious := s.databaseStats[name]
+
+		poolStats := DBPoolStats{
+			Idle:                       stats.Idle,
+			InUse:                      stats.InUse,
+			MaxIdleClosed:              stats.MaxIdleClosed,
+			MaxIdleTimeClosed:          stats.MaxIdleTimeClosed,
+			MaxLifetimeClosed:          stats.MaxLifetimeClosed,
+			MaxOpenConnections:         stats.MaxOpenConnections,
+			OpenConnections:            stats.OpenConnections,
+			SampledAt:                  now,
+			WaitCount:                  stats.WaitCount,
+			WaitDurationInMilliseconds: stats.WaitDuration.Milliseconds(),
+		}
+
+		if hasPrevious {
+			poolStats.WaitCountSinceLastSample = stats.WaitCount - previous.WaitCount
+		}
+
+		s.databaseStats[name] = poolStats
+	}
+}
+
+/*
+getDatabaseStats returns a copy of the sampled pool stats. The caller
+must hold a lock
+*/
+func (s *ServerStats) getDatabaseStats() map[string]DBPoolStats {
+	if len(s.databaseStats) == 0 {
+		return nil
+	}
+
+	result := make(map[string]DBPoolStats, len(s.databaseStats))
+
+	for name, stats := range s.databaseStats {
+		result[name] = stats
+	}
+
+	return result
+}
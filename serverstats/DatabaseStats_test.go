@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"bytes"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+type fakeDB struct {
+	sync.Mutex
+	stats sql.DBStats
+}
+
+func (db *fakeDB) Stats() sql.DBStats {
+	db.Lock()
+	defer db.Unlock()
+
+	return db.stats
+}
+
+func (db *fakeDB) wait(count int64) {
+	db.Lock()
+	defer db.Unlock()
+
+	db.stats.WaitCount += count
+}
+
+func TestDatabaseStats(t *testing.T) {
+	db := &fakeDB{
+		stats: sql.DBStats{
+			Idle:               2,
+			InUse:              3,
+			MaxOpenConnections: 10,
+			OpenConnections:    5,
+			WaitCount:          4,
+			WaitDuration:       time.Millisecond * 250,
+		},
+	}
+
+	stats := serverstats.NewServerStats(nil)
+	stats.RegisterDB("primary", db)
+	stats.StartSampler(time.Millisecond * 10)
+
+	if !waitFor(func() bool { return stats.GetSnapshot().Databases["primary"].OpenConnections == 5 }) {
+		t.Fatalf("Expected the sampler to read pool stats")
+	}
+
+	stats.StopSampler()
+	db.wait(3)
+
+	// Sample slowly enough to catch the change before the next sample resets it
+	stats.StartSampler(time.Millisecond * 200)
+
+	if !waitFor(func() bool { return stats.GetSnapshot().Databases["primary"].WaitCount == 7 }) {
+		t.Fatalf("Expected the sampler to read the new wait count")
+	}
+
+	stats.StopSampler()
+	primary := stats.GetSnapshot().Databases["primary"]
+
+	if primary.InUse != 3 || primary.Idle != 2 || primary.WaitDurationInMilliseconds != 250 || primary.WaitCountSinceLastSample != 3 {
+		t.Errorf("Unexpected pool stats %+v", primary)
+	}
+
+	buffer := &bytes.Buffer{}
+
+	if err := stats.ExportDatabasesCSV(buffer); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "sampledAt,database,openConnections") || !strings.Contains(lines[1], ",primary,5,3,2,10,7,3,250,") {
+		t.Errorf("Unexpected CSV export %q", lines)
+	}
+
+	recorder := httptest.NewRecorder()
+	stats.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/?format=text", nil))
+
+	if !strings.Contains(recorder.Body.String(), "primary: 5 open, 3 in use, 2 idle, 7 waits (3 since last sample), waited 250ms") {
+		t.Errorf("Expected the text report to include the database but got:\n%s", recorder.Body.String())
+	}
+}
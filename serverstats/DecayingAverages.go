@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"math"
+	"time"
+)
+
+/*
+latencyTickInterval is how often average latency is folded into the
+moving averages as requests arrive. Averaging per tick keeps bursts of
+requests that finish at the same instant from being ignored. The
+background sampler also folds in the current tick on every sample
+*/
+const latencyTickInterval = time.Second * 5
+
+/*
+DecayingAverage holds exponentially-weighted moving averages over 1,
+5, and 15 minutes, in the same spirit as Unix load averages. Recent
+values carry more weight than old ones
+*/
+type DecayingAverage struct {
+	FifteenMinutes float64 `json:"fifteenMinutes"`
+	FiveMinutes    float64 `json:"fiveMinutes"`
+	OneMinute      float64 `json:"oneMinute"`
+}
+
+/*
+DecayingAverages reports moving averages for response time and memory
+*/
+type DecayingAverages struct {
+	FreeMemory                 DecayingAverage `json:"freeMemory"`
+	MemoryUsage                DecayingAverage `json:"memoryUsage"`
+	ResponseTimeInMilliseconds DecayingAverage `json:"responseTimeInMilliseconds"`
+}
+
+/*
+ewma is an exponentially-weighted moving average where the weight of a
+new value depends on how much time has passed since the last one
+*/
+type ewma struct {
+	initialized bool
+	lastUpdate  time.Time
+	value       float64
+	window      time.Duration
+}
+
+func (e *ewma) update(value float64, now time.Time) {
+	if !e.initialized {
+		e.initialized = true
+		e.lastUpdate = now
+		e.value = value
+		return
+	}
+
+	elapsed := now.Sub(e.lastUpdate)
+
+	if elapsed <= 0 {
+		return
+	}
+
+	alpha := 1 - math.Exp(-float64(elapsed)/float64(e.window))
+	e.value += alpha * (value - e.value)
+	e.lastUpdate = now
+}
+
+/*
+ewmaSet is a set of 1, 5, and 15 minute moving averages
+*/
+type ewmaSet struct {
+	oneMinute      ewma
+	fiveMinutes    ewma
+	fifteenMinutes ewma
+}
+
+func newEWMASet() *ewmaSet {
+	return &ewmaSet{
+		oneMinute:      ewma{window: time.Minute},
+		fiveMinutes:    ewma{window: time.Minute * 5},
+		fifteenMinutes: ewma{window: time.Minute * 15},
+	}
+}
+
+func (e *ewmaSet) update(value float64, now time.Time) {
+	e.oneMinute.update(value, now)
+	e.fiveMinutes.update(value, now)
+	e.fifteenMinutes.update(value, now)
+}
+
+func (e *ewmaSet) report() DecayingAverage {
+	return DecayingAverage{
+		FifteenMinutes: e.fifteenMinutes.value,
+		FiveMinutes:    e.fiveMinutes.value,
+		OneMinute:      e.oneMinute.value,
+	}
+}
+
+/*
+latencyEWMA accumulates response times for a tick, then folds the
+tick's average into the moving averages
+*/
+type latencyEWMA struct {
+	averages  *ewmaSet
+	count     int64
+	sum       time.Duration
+	tickStart time.Time
+}
+
+func newLatencyEWMA() *latencyEWMA {
+	return &latencyEWMA{
+		averages: newEWMASet(),
+	}
+}
+
+func (l *latencyEWMA) record(executionTime time.Duration, now time.Time) {
+	if l.tickStart.IsZero() {
+		l.tickStart = now
+	}
+
+	if now.Sub(l.tickStart) >= latencyTickInterval {
+		l.flush(now)
+	}
+
+	l.sum += executionTime
+	l.count++
+}
+
+/*
+flush folds the average response time since the last flush into the
+moving averages. When there were no requests the averages decay toward
+zero, so an idle server's averages don't stay stuck at its last burst
+*/
+func (l *latencyEWMA) flush(now time.Time) {
+	if l.count > 0 {
+		l.averages.update(toMilliseconds(l.sum/time.Duration(l.count)), now)
+	} else if l.averages.oneMinute.initialized {
+		l.averages.update(0, now)
+	}
+
+	l.count = 0
+	l.sum = 0
+	l.tickStart = now
+}
+
+/*
+getDecayingAverages returns the current moving averages. The caller must
+hold a lock
+*/
+func (s *ServerStats) getDecayingAverages() DecayingAverages {
+	return DecayingAverages{
+		FreeMemory:                 s.freeMemoryEWMA.report(),
+		MemoryUsage:                s.memoryUsageEWMA.report(),
+		ResponseTimeInMilliseconds: s.latencyEWMA.averages.report(),
+	}
+}
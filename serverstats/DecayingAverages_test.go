@@ -0,0 +1,52 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestDecayingAverages(t *testing.T) {
+	stats := serverstats.NewServerStats(nil)
+	serve(stats, http.MethodGet, "/", status(http.StatusOK, time.Millisecond*20))
+
+	responseTime := func() serverstats.DecayingAverage {
+		return stats.GetSnapshot().DecayingAverages.ResponseTimeInMilliseconds
+	}
+
+	stats.StartSampler(time.Millisecond * 10)
+	defer stats.StopSampler()
+
+	// The sampler folds in the first tick without waiting for another request
+	if !waitFor(func() bool { return responseTime().OneMinute > 0 }) {
+		t.Fatalf("Expected the sampler to fold in the response time")
+	}
+
+	first := responseTime()
+
+	if first.OneMinute < 20 || first.FiveMinutes < 20 || first.FifteenMinutes < 20 {
+		t.Errorf("Expected every average to start at the first tick's average but got %+v", first)
+	}
+
+	// With no requests, the averages decay, faster over shorter windows
+	time.Sleep(time.Millisecond * 100)
+	idle := responseTime()
+
+	if idle.OneMinute >= first.OneMinute || idle.FifteenMinutes >= first.FifteenMinutes {
+		t.Errorf("Expected the averages to decay while idle but went from %+v to %+v", first, idle)
+	}
+
+	if first.OneMinute-idle.OneMinute <= first.FifteenMinutes-idle.FifteenMinutes {
+		t.Errorf("Expected the one minute average to decay fastest but got %+v", idle)
+	}
+
+	if memory := stats.GetSnapshot().DecayingAverages.MemoryUsage; memory.OneMinute <= 0 {
+		t.Errorf("Expected the sampler to update memory averages but got %+v", memory)
+	}
+}
@@ -0,0 +1,225 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+/*
+Middleware is used to capture request and response stats. This is designed
+to be used with the Echo framework
+*/
+func (s *ServerStats) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		s.trackEcho(ctx, next)
+		return nil
+	}
+}
+
+/*
+NewMiddlewareWithTimeTracking returns a middleware that tracks stats by day and hour. You
+provide it a pointer to a StatsByDayCollection and this will update stats grouped by
+day (starting at midnight) and hour.
+*/
+// TODO: Perhaps keep the statsByDayCollection in ServerStats locally. cause otherwise this ain't working
+func (s *ServerStats) NewMiddlewareWithTimeTracking() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			tracked := s.trackEcho(ctx, next)
+			startTime := tracked.startTime
+
+			s.Lock()
+			defer s.Unlock()
+
+			/*
+			 * Get the date starting at midnight, and the hour integer
+			 */
+			day := time.Date(startTime.Year(), startTime.Month(), startTime.Day(), 0, 0, 0, 0, startTime.Location())
+			hour := startTime.Hour()
+
+			/*
+			 * Find the day and hour, then update the hour structure
+			 */
+			var byDay *StatsByDay
+			var byHour *StatsByHour
+			resetStats := false
+
+			for _, d := range s.StatsByDayCollection {
+				if d.Date.Equal(day) {
+					byDay = d
+					break
+				}
+			}
+
+			if byDay != nil {
+				for _, h := range byDay.HourlyStats {
+					if h.Hour == hour {
+						byHour = h
+						break
+					}
+				}
+
+				if byHour == nil {
+					byHour = NewStatsByHour(hour)
+					byDay.HourlyStats = append(byDay.HourlyStats, byHour)
+
+					resetStats = true
+				}
+			} else {
+				byDay = NewStatsByDay(day)
+				byHour = NewStatsByHour(hour)
+				resetStats = true
+
+				byDay.HourlyStats = append(byDay.HourlyStats, byHour)
+				s.StatsByDayCollection = append(s.StatsByDayCollection, byDay)
+			}
+
+			if byHour != nil {
+				if resetStats {
+					s.RequestCount = 0
+					s.Statuses = make(map[string]int)
+				}
+
+				byHour.Calculate(s)
+			}
+
+			return nil
+		}
+	}
+}
+
+/*
+trackEcho runs an Echo handler through a TrackedRequest. Errors are
+handed to Echo's error handler so the response status is known before
+the request is recorded
+*/
+func (s *ServerStats) trackEcho(ctx echo.Context, next echo.HandlerFunc) *TrackedRequest {
+	tracked := s.BeginRequest(ctx.Response(), ctx.Request(), ctx.Path())
+	defer tracked.EndOnPanic()
+
+	ctx.SetRequest(tracked.Request)
+
+	if requestID := RequestIDFromContext(tracked.Request.Context()); requestID != "" {
+		ctx.Set(RequestIDContextKey, requestID)
+	}
+
+	err := tracked.Run(func() error {
+		return next(ctx)
+	})
+
+	if err != nil {
+		ctx.Error(err)
+	}
+
+	tracked.Request = ctx.Request()
+	tracked.End(ctx.Response().Status, err)
+
+	if s.customMiddleware != nil {
+		s.Lock()
+		s.runCustomMiddleware(ctx)
+		s.Unlock()
+	}
+
+	return tracked
+}
+
+/*
+Handler is an endpoint handler you can plug into your application
+to return stat data. Stats are returned as JSON unless the Accept
+header asks for text/plain or text/html, or the "format" query
+parameter is set to text or html
+*/
+func (s *ServerStats) Handler(ctx echo.Context) error {
+	s.ServeHTTP(ctx.Response(), ctx.Request())
+	return nil
+}
+
+/*
+SlowTracesHandler is an endpoint handler that returns the most recent
+slow request traces, newest first
+*/
+func (s *ServerStats) SlowTracesHandler(ctx echo.Context) error {
+	s.SlowTracesHTTPHandler(ctx.Response(), ctx.Request())
+	return nil
+}
+
+/*
+EventStreamHandler is an endpoint handler that streams events to the
+client using Server-Sent Events as they are recorded
+*/
+func (s *ServerStats) EventStreamHandler(ctx echo.Context) error {
+	s.EventStreamHTTPHandler(ctx.Response(), ctx.Request())
+	return nil
+}
+
+/*
+ExportHandler is an endpoint handler that downloads stats as CSV
+*/
+func (s *ServerStats) ExportHandler(ctx echo.Context) error {
+	s.ExportHTTPHandler(ctx.Response(), ctx.Request())
+	return nil
+}
+
+/*
+MetricsHandler is an endpoint handler that returns the build info and
+request counters in the Prometheus text format
+*/
+func (s *ServerStats) MetricsHandler(ctx echo.Context) error {
+	s.MetricsHTTPHandler(ctx.Response(), ctx.Request())
+	return nil
+}
+
+/*
+LongRunningRequestsHandler is an endpoint handler that returns the
+requests that have been in flight longer than LongRequestThreshold
+*/
+func (s *ServerStats) LongRunningRequestsHandler(ctx echo.Context) error {
+	s.LongRunningRequestsHTTPHandler(ctx.Response(), ctx.Request())
+	return nil
+}
+
+/*
+Handler is an endpoint handler that returns the fleet-wide view
+*/
+func (a *Aggregator) Handler(ctx echo.Context) error {
+	a.ServeHTTP(ctx.Response(), ctx.Request())
+	return nil
+}
+
+/*
+PushHandler is an endpoint handler that accepts a snapshot pushed by a
+peer
+*/
+func (a *Aggregator) PushHandler(ctx echo.Context) error {
+	a.PushHTTPHandler(ctx.Response(), ctx.Request())
+	return nil
+}
+
+/*
+StartSpan marks the start of a named span in the current request's
+trace. Call the returned function when the span is done. If tracing is
+not enabled this does nothing.
+
+	func handler(ctx echo.Context) error {
+		endSpan := serverstats.StartSpan(ctx, "load customer")
+		customer, err := loadCustomer()
+		endSpan()
+		...
+	}
+*/
+func StartSpan(ctx echo.Context, name string) func() {
+	return StartSpanContext(ctx.Request().Context(), name)
+}
+
+/*
+GetRequestID returns the ID assigned to the current request, or an
+empty string if tracing is not enabled
+*/
+func GetRequestID(ctx echo.Context) string {
+	return RequestIDFromContext(ctx.Request().Context())
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+/*
+ErrorHotSpot is a route with server errors in the hot spot window.
+ErrorRate is the percentage (0-100) of the route's requests in the
+window that returned a 5xx status
+*/
+type ErrorHotSpot struct {
+	ErrorRate float64 `json:"errorRate"`
+	Errors    uint64  `json:"errors"`
+	Requests  uint64  `json:"requests"`
+	Route     string  `json:"route"`
+	Window    string  `json:"window"`
+}
+
+/*
+getStatusesByRoute returns request counts per status for each route.
+The caller must hold a lock
+*/
+func (s *ServerStats) getStatusesByRoute() map[string]map[string]int {
+	result := make(map[string]map[string]int, len(s.routes))
+
+	for route, tracker := range s.routes {
+		statuses := make(map[string]int, len(tracker.statuses))
+
+		for status, count := range tracker.statuses {
+			statuses[status] = count
+		}
+
+		result[route] = statuses
+	}
+
+	return result
+}
+
+/*
+getErrorHotSpots returns the routes with the highest 5xx rate over the
+hot spot window, highest first. Routes without server errors in the
+window are left out. The caller must hold a lock
+*/
+func (s *ServerStats) getErrorHotSpots(now time.Time) []ErrorHotSpot {
+	type routeCounts struct {
+		errors   float64
+		requests float64
+	}
+
+	counts := make(map[string]*routeCounts)
+
+	for _, responseTime := range s.responseTimesSince(now.Add(-s.errorHotSpotWindow)) {
+		count, ok := counts[responseTime.Route]
+
+		if !ok {
+			count = &routeCounts{}
+			counts[responseTime.Route] = count
+		}
+
+		count.requests += responseTime.sampleWeight()
+
+		if responseTime.Status >= 500 {
+			count.errors += responseTime.sampleWeight()
+		}
+	}
+
+	result := make([]ErrorHotSpot, 0)
+	window := s.errorHotSpotWindow.String()
+
+	for route, count := range counts {
+		if count.errors == 0 {
+			continue
+		}
+
+		result = append(result, ErrorHotSpot{
+			ErrorRate: count.errors / count.requests * 100,
+			Errors:    uint64(math.Round(count.errors)),
+			Requests:  uint64(math.Round(count.requests)),
+			Route:     route,
+			Window:    window,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ErrorRate != result[j].ErrorRate {
+			return result[i].ErrorRate > result[j].ErrorRate
+		}
+
+		if result[i].Errors != result[j].Errors {
+			return result[i].Errors > result[j].Errors
+		}
+
+		return result[i].Route < result[j].Route
+	})
+
+	if len(result) > s.numErrorHotSpots {
+		result = result[:s.numErrorHotSpots]
+	}
+
+	return result
+}
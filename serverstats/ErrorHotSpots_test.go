@@ -0,0 +1,164 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/clock"
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestErrorHotSpots(t *testing.T) {
+	type request struct {
+		method string
+		path   string
+		status int
+		times  int
+	}
+
+	tests := []struct {
+		name             string
+		numHotSpots      int
+		requests         []request
+		expectedRoutes   []string
+		expectedRates    []float64
+		expectedErrors   []uint64
+		expectedRequests []uint64
+	}{
+		{
+			name: "Routes are ranked by error rate",
+			requests: []request{
+				{http.MethodGet, "/users", http.StatusOK, 3},
+				{http.MethodGet, "/users", http.StatusInternalServerError, 1},
+				{http.MethodPost, "/orders", http.StatusBadGateway, 1},
+				{http.MethodPost, "/orders", http.StatusOK, 1},
+				{http.MethodGet, "/health", http.StatusOK, 5},
+				{http.MethodGet, "/missing", http.StatusNotFound, 5},
+			},
+			expectedRoutes:   []string{"POST /orders", "GET /users"},
+			expectedRates:    []float64{50, 25},
+			expectedErrors:   []uint64{1, 1},
+			expectedRequests: []uint64{2, 4},
+		},
+		{
+			name: "Equal rates are ranked by error count, then route",
+			requests: []request{
+				{http.MethodGet, "/b", http.StatusServiceUnavailable, 1},
+				{http.MethodGet, "/a", http.StatusServiceUnavailable, 1},
+				{http.MethodGet, "/c", http.StatusServiceUnavailable, 3},
+			},
+			expectedRoutes:   []string{"GET /c", "GET /a", "GET /b"},
+			expectedRates:    []float64{100, 100, 100},
+			expectedErrors:   []uint64{3, 1, 1},
+			expectedRequests: []uint64{3, 1, 1},
+		},
+		{
+			name:        "Only the top routes are listed",
+			numHotSpots: 2,
+			requests: []request{
+				{http.MethodGet, "/a", http.StatusInternalServerError, 1},
+				{http.MethodGet, "/a", http.StatusOK, 3},
+				{http.MethodGet, "/b", http.StatusInternalServerError, 1},
+				{http.MethodGet, "/c", http.StatusInternalServerError, 1},
+				{http.MethodGet, "/c", http.StatusOK, 1},
+			},
+			expectedRoutes:   []string{"GET /b", "GET /c"},
+			expectedRates:    []float64{100, 50},
+			expectedErrors:   []uint64{1, 1},
+			expectedRequests: []uint64{1, 2},
+		},
+		{
+			name: "No server errors",
+			requests: []request{
+				{http.MethodGet, "/a", http.StatusOK, 2},
+				{http.MethodGet, "/b", http.StatusBadRequest, 2},
+			},
+			expectedRoutes: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options := serverstats.DefaultServerStatsOptions()
+			options.NumErrorHotSpots = test.numHotSpots
+			stats := serverstats.NewServerStatsWithOptions(options, nil)
+
+			for _, r := range test.requests {
+				for i := 0; i < r.times; i++ {
+					serve(stats, r.method, r.path, status(r.status))
+				}
+			}
+
+			hotSpots := stats.GetSnapshot().ErrorHotSpots
+			routes := make([]string, 0, len(hotSpots))
+
+			for _, hotSpot := range hotSpots {
+				routes = append(routes, hotSpot.Route)
+			}
+
+			if !reflect.DeepEqual(routes, test.expectedRoutes) {
+				t.Fatalf("Expected hot spots %v but got %+v", test.expectedRoutes, hotSpots)
+			}
+
+			for index, hotSpot := range hotSpots {
+				if hotSpot.ErrorRate != test.expectedRates[index] || hotSpot.Errors != test.expectedErrors[index] || hotSpot.Requests != test.expectedRequests[index] || hotSpot.Window != "5m0s" {
+					t.Errorf("Expected %s to have %d of %d requests fail (%v%%) over 5m0s but got %+v", hotSpot.Route, test.expectedErrors[index], test.expectedRequests[index], test.expectedRates[index], hotSpot)
+				}
+			}
+		})
+	}
+}
+
+func TestErrorHotSpotWindow(t *testing.T) {
+	fake := clock.NewFake(time.Date(2021, time.June, 1, 9, 0, 0, 0, time.UTC))
+	options := serverstats.DefaultServerStatsOptions()
+	options.Clock = fake
+	options.ErrorHotSpotWindow = time.Minute
+	stats := serverstats.NewServerStatsWithOptions(options, nil)
+
+	serve(stats, http.MethodGet, "/old", status(http.StatusInternalServerError))
+	fake.Advance(time.Minute * 2)
+	serve(stats, http.MethodGet, "/new", status(http.StatusInternalServerError))
+
+	hotSpots := stats.GetSnapshot().ErrorHotSpots
+
+	if len(hotSpots) != 1 || hotSpots[0].Route != "GET /new" || hotSpots[0].Window != "1m0s" {
+		t.Errorf("Expected only the error inside the window but got %+v", hotSpots)
+	}
+}
+
+func TestStatusesByRoute(t *testing.T) {
+	stats := serverstats.NewServerStats(nil)
+
+	serve(stats, http.MethodGet, "/users", status(http.StatusOK))
+	serve(stats, http.MethodGet, "/users", status(http.StatusOK))
+	serve(stats, http.MethodGet, "/users", status(http.StatusInternalServerError))
+	serve(stats, http.MethodPost, "/users", status(http.StatusCreated))
+	serve(stats, http.MethodPost, "/users", status(http.StatusBadRequest))
+
+	expected := map[string]map[string]int{
+		"GET /users":  {"200": 2, "500": 1},
+		"POST /users": {"201": 1, "400": 1},
+	}
+
+	snapshot := stats.GetSnapshot()
+
+	if !reflect.DeepEqual(snapshot.StatusesByRoute, expected) {
+		t.Errorf("Expected %v but got %v", expected, snapshot.StatusesByRoute)
+	}
+
+	/*
+	 * The snapshot holds a copy, so changing it doesn't change the stats
+	 */
+	snapshot.StatusesByRoute["GET /users"]["200"] = 100
+
+	if stats.GetSnapshot().StatusesByRoute["GET /users"]["200"] != 2 {
+		t.Errorf("Expected the snapshot to hold a copy of the status counts")
+	}
+}
@@ -0,0 +1,198 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	redactEmailRegex  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	redactTokenRegex  = regexp.MustCompile(`[A-Za-z0-9_\-+=]{24,}`)
+	redactNumberRegex = regexp.MustCompile(`\d{4,}`)
+)
+
+const maxRecordedErrorLength = 500
+
+/*
+ErrorStats reports the number of panics and handler errors, and the
+most recent errors captured. Requests that timed out or were canceled
+by a client disconnecting are counted in Timeouts and ClientDisconnects
+instead of HandlerErrors
+*/
+type ErrorStats struct {
+	ClientDisconnects uint64          `json:"clientDisconnects"`
+	HandlerErrors     uint64          `json:"handlerErrors"`
+	Panics            uint64          `json:"panics"`
+	RecentErrors      []RecordedError `json:"recentErrors"`
+	Timeouts          uint64          `json:"timeouts"`
+}
+
+/*
+RecordedError is an error or panic captured by the middleware. Messages
+are redacted before they are stored. For panics, StackDigest is a short
+hash of the call stack, which lets you group recurring panics without
+exposing the stack itself
+*/
+type RecordedError struct {
+	Message     string    `json:"message"`
+	Method      string    `json:"method"`
+	Panic       bool      `json:"panic"`
+	Route       string    `json:"route"`
+	StackDigest string    `json:"stackDigest,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+/*
+DefaultErrorRedactor masks email addresses, long numbers, and anything
+that looks like a token or key in an error message. A token is a run of
+24 or more letters, digits, and _-+= that mixes letters and digits, so
+URL paths and long words are left readable
+*/
+func DefaultErrorRedactor(message string) string {
+	message = redactEmailRegex.ReplaceAllString(message, "[email]")
+	message = redactTokenRegex.ReplaceAllStringFunc(message, redactToken)
+	message = redactNumberRegex.ReplaceAllString(message, "[number]")
+
+	if len(message) > maxRecordedErrorLength {
+		/*
+		 * Back off to the start of a rune so multi-byte characters
+		 * aren't split into invalid UTF-8
+		 */
+		end := maxRecordedErrorLength
+
+		for end > 0 && !utf8.RuneStart(message[end]) {
+			end--
+		}
+
+		message = message[:end] + "..."
+	}
+
+	return message
+}
+
+/*
+redactToken masks value if it mixes letters and digits, as generated
+keys and tokens do
+*/
+func redactToken(value string) string {
+	if strings.IndexFunc(value, unicode.IsDigit) >= 0 && strings.IndexFunc(value, unicode.IsLetter) >= 0 {
+		return "[token]"
+	}
+
+	return value
+}
+
+/*
+callNext calls the next handler. When panic recovery is enabled a panic
+is recovered, recorded, and returned as an error so the adapter can
+respond with a 500
+*/
+func (s *ServerStats) callNext(request *http.Request, path string, next func() error) (err error) {
+	if s.recoverPanics {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+				s.recordError(request, path, err, true, stackDigest(3))
+			}
+		}()
+	}
+
+	if err = next(); err != nil && requestInterruption(request, err) == interruptionNone {
+		s.recordError(request, path, err, false, "")
+	}
+
+	return err
+}
+
+/*
+RecordPanic counts a panic recovered outside the stats middleware, such
+as by the recovery package's middleware, and adds it to the recent
+errors. Call it from the deferred function that recovered the panic, so
+the stack digest covers the panicking code
+*/
+func (s *ServerStats) RecordPanic(request *http.Request, path string, recovered interface{}) {
+	s.recordError(request, path, fmt.Errorf("panic: %v", recovered), true, stackDigest(4))
+}
+
+func (s *ServerStats) recordError(request *http.Request, path string, err error, isPanic bool, digest string) {
+	s.Lock()
+	defer s.Unlock()
+
+	if isPanic {
+		s.panics++
+	} else {
+		s.handlerErrors++
+	}
+
+	s.recentErrors = s.recentErrors.Next()
+	s.recentErrors.Value = RecordedError{
+		Message:     s.errorRedactor(err.Error()),
+		Method:      request.Method,
+		Panic:       isPanic,
+		Route:       path,
+		StackDigest: digest,
+		Time:        s.clock.Now().UTC(),
+	}
+}
+
+/*
+getErrorStats returns error counts and recent errors, newest first.
+The caller must hold a lock
+*/
+func (s *ServerStats) getErrorStats() ErrorStats {
+	result := ErrorStats{
+		ClientDisconnects: s.clientDisconnects,
+		HandlerErrors:     s.handlerErrors,
+		Panics:            s.panics,
+		RecentErrors:      make([]RecordedError, 0, s.recentErrors.Len()),
+		Timeouts:          s.timeouts,
+	}
+
+	r := s.recentErrors
+
+	for index := 0; index < r.Len(); index++ {
+		if recordedError, ok := r.Value.(RecordedError); ok {
+			result.RecentErrors = append(result.RecentErrors, recordedError)
+		}
+
+		r = r.Prev()
+	}
+
+	return result
+}
+
+/*
+stackDigest returns a short hash of the function names on the current
+call stack. Addresses and line numbers are left out so the digest is
+stable across builds with the same call path
+*/
+func stackDigest(skip int) string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(skip, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	functions := make([]string, 0, n)
+
+	for {
+		frame, more := frames.Next()
+		functions = append(functions, frame.Function)
+
+		if !more {
+			break
+		}
+	}
+
+	hash := sha1.Sum([]byte(strings.Join(functions, "\n")))
+	return hex.EncodeToString(hash[:])[:12]
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+/*
+Event is an operational event, such as a deploy, config reload, or
+migration. Events let dashboards line up changes in metrics with what
+happened to the server at the time
+*/
+type Event struct {
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	Name     string                 `json:"name"`
+	Time     time.Time              `json:"time"`
+}
+
+const eventChannel = "events"
+
+var eventNameReplacer = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+/*
+RecordEvent adds an event to the timeline. The most recent
+NumEventsToKeep events are included in the stats output, and every
+event is sent to clients connected to EventStreamHandler. Line breaks
+in the name are replaced with spaces, as they would break the event
+stream.
+
+	serverStats.RecordEvent("deploy", map[string]interface{}{
+		"version": "1.4.2",
+	})
+*/
+func (s *ServerStats) RecordEvent(name string, metadata map[string]interface{}) {
+	event := Event{
+		Metadata: metadata,
+		Name:     eventNameReplacer.Replace(name),
+		Time:     s.clock.Now().UTC(),
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.events = s.events.Next()
+	s.events.Value = event
+
+	_, _ = s.eventBroker.PublishJSON(eventChannel, event.Name, event)
+}
+
+/*
+GetEvents returns the event timeline, oldest first
+*/
+func (s *ServerStats) GetEvents() []Event {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.getEvents()
+}
+
+/*
+EventStreamHTTPHandler is a net/http handler that streams events to the
+client using Server-Sent Events as they are recorded. The stream stays
+open until the client disconnects. Clients reconnecting with a
+Last-Event-ID header are sent the events they missed
+*/
+func (s *ServerStats) EventStreamHTTPHandler(w http.ResponseWriter, r *http.Request) {
+	if !authorize(s.handlerAuth, w, r) {
+		return
+	}
+
+	s.eventBroker.Stream(w, r, eventChannel)
+}
+
+/*
+getEvents returns the event timeline, oldest first. The caller must
+hold a lock
+*/
+func (s *ServerStats) getEvents() []Event {
+	result := make([]Event, 0, s.events.Len())
+
+	s.events.Next().Do(func(e interface{}) {
+		if event, ok := e.(Event); ok {
+			result = append(result, event)
+		}
+	})
+
+	return result
+}
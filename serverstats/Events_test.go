@@ -0,0 +1,71 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestEventStream(t *testing.T) {
+	stats := serverstats.NewServerStats(nil)
+	server := httptest.NewServer(http.HandlerFunc(stats.EventStreamHTTPHandler))
+	defer server.Close()
+
+	response, err := http.Get(server.URL)
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	defer response.Body.Close()
+
+	if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("Expected an event stream but got '%s'", contentType)
+	}
+
+	stats.RecordEvent("deploy\r\nevent: injected\ndata: {}", map[string]interface{}{"version": "1.4.2"})
+
+	lines := make([]string, 0)
+	reader := bufio.NewReader(response.Body)
+
+	for {
+		line, err := reader.ReadString('\n')
+
+		if err != nil {
+			t.Fatalf("Expected no error but got '%s'", err)
+		}
+
+		if line == "\n" {
+			break
+		}
+
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+
+	if len(lines) != 3 || lines[0] != "id: 1" || lines[1] != "event: deploy event: injected data: {}" || !strings.HasPrefix(lines[2], "data: ") {
+		t.Fatalf("Expected a single event with line breaks removed from its name but got %q", lines)
+	}
+
+	var event serverstats.Event
+
+	if err = json.Unmarshal([]byte(strings.TrimPrefix(lines[2], "data: ")), &event); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	if event.Metadata["version"] != "1.4.2" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+
+	if events := stats.GetEvents(); len(events) != 1 || events[0].Name != event.Name {
+		t.Errorf("Expected the event in the timeline but got %+v", events)
+	}
+}
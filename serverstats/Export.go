@@ -0,0 +1,316 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var routeCSVHeader = []string{
+	"generatedAt", "route", "method", "path", "window", "requests",
+	"meanInMilliseconds", "minInMilliseconds", "maxInMilliseconds", "stdDevInMilliseconds",
+}
+
+var sloCSVHeader = []string{
+	"calculatedAt", "window", "requests", "apdex", "errorRate", "burnRate", "apdexThresholdInMilliseconds", "availabilitySLO",
+}
+
+var databaseCSVHeader = []string{
+	"sampledAt", "database", "openConnections", "inUse", "idle", "maxOpenConnections", "waitCount",
+	"waitCountSinceLastSample", "waitDurationInMilliseconds", "maxIdleClosed", "maxIdleTimeClosed", "maxLifetimeClosed",
+}
+
+/*
+ExportHTTPHandler is a net/http handler that downloads stats as CSV. By
+default it exports per-route stats. Set the "report" query parameter to
+"hourly" to export the hourly rows collected by
+NewMiddlewareWithTimeTracking instead, to "slo" to export Apdex scores
+and burn rates, or to "databases" to export database connection pool
+stats
+*/
+func (s *ServerStats) ExportHTTPHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		exportFunc func(w io.Writer) error
+		fileName   string
+	)
+
+	if !authorize(s.handlerAuth, w, r) {
+		return
+	}
+
+	switch r.URL.Query().Get("report") {
+	case "hourly":
+		exportFunc = s.ExportHourlyCSV
+		fileName = "serverstats-hourly.csv"
+
+	case "slo":
+		exportFunc = s.ExportSLOCSV
+		fileName = "serverstats-slo.csv"
+
+	case "databases":
+		exportFunc = s.ExportDatabasesCSV
+		fileName = "serverstats-databases.csv"
+
+	case "", "routes":
+		exportFunc = s.ExportCSV
+		fileName = "serverstats-routes.csv"
+
+	default:
+		http.Error(w, "report must be routes, hourly, slo, or databases", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	w.WriteHeader(http.StatusOK)
+
+	_ = exportFunc(w)
+}
+
+/*
+ExportCSV writes per-route stats as CSV. Each route gets one row for
+all requests since the server started (window "all"), and one row for
+each of the LatencyWindows
+*/
+func (s *ServerStats) ExportCSV(w io.Writer) error {
+	var err error
+
+	snapshot := s.GetSnapshot()
+	generatedAt := s.clock.Now().UTC().Format(time.RFC3339)
+	writer := csv.NewWriter(w)
+
+	if err = writer.Write(routeCSVHeader); err != nil {
+		return fmt.Errorf("error writing CSV header: %w", err)
+	}
+
+	for _, route := range snapshot.Routes {
+		if err = writer.Write(routeCSVRow(generatedAt, route, "all", route.Latency)); err != nil {
+			return fmt.Errorf("error writing CSV row: %w", err)
+		}
+
+		for _, window := range LatencyWindows {
+			latency, ok := route.LatencyByWindow[window.String()]
+
+			if !ok {
+				continue
+			}
+
+			if err = writer.Write(routeCSVRow(generatedAt, route, window.String(), latency)); err != nil {
+				return fmt.Errorf("error writing CSV row: %w", err)
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+/*
+ExportHourlyCSV writes one timestamped row per hour collected by
+NewMiddlewareWithTimeTracking. Each status code seen gets its own
+column. Only the header is written if time tracking isn't in use
+*/
+func (s *ServerStats) ExportHourlyCSV(w io.Writer) error {
+	var err error
+
+	type hourlyRow struct {
+		averageFreeMemory   uint64
+		averageMemoryUsage  uint64
+		averageResponseTime int64
+		requestCount        uint64
+		statuses            map[string]int
+		timestamp           time.Time
+	}
+
+	s.RLock()
+
+	rows := make([]hourlyRow, 0)
+	statusSet := make(map[string]struct{})
+
+	for _, day := range s.StatsByDayCollection {
+		for _, hour := range day.HourlyStats {
+			hour.RLock()
+
+			row := hourlyRow{
+				averageFreeMemory:   hour.AverageFreeMemory,
+				averageMemoryUsage:  hour.AverageMemoryUsage,
+				averageResponseTime: hour.AverageResponseTimeInMilliseconds,
+				requestCount:        hour.RequestCount,
+				statuses:            make(map[string]int, len(hour.Statuses)),
+				timestamp:           day.Date.Add(time.Hour * time.Duration(hour.Hour)),
+			}
+
+			for status, count := range hour.Statuses {
+				row.statuses[status] = count
+				statusSet[status] = struct{}{}
+			}
+
+			hour.RUnlock()
+			rows = append(rows, row)
+		}
+	}
+
+	s.RUnlock()
+
+	statuses := make([]string, 0, len(statusSet))
+
+	for status := range statusSet {
+		statuses = append(statuses, status)
+	}
+
+	sort.Strings(statuses)
+
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].timestamp.Before(rows[j].timestamp)
+	})
+
+	writer := csv.NewWriter(w)
+	header := []string{"timestamp", "requests", "averageResponseTimeInMilliseconds", "averageMemoryUsage", "averageFreeMemory"}
+
+	for _, status := range statuses {
+		header = append(header, "status"+status)
+	}
+
+	if err = writer.Write(header); err != nil {
+		return fmt.Errorf("error writing CSV header: %w", err)
+	}
+
+	for _, row := range rows {
+		record := []string{
+			row.timestamp.Format(time.RFC3339),
+			strconv.FormatUint(row.requestCount, 10),
+			strconv.FormatInt(row.averageResponseTime, 10),
+			strconv.FormatUint(row.averageMemoryUsage, 10),
+			strconv.FormatUint(row.averageFreeMemory, 10),
+		}
+
+		for _, status := range statuses {
+			record = append(record, strconv.Itoa(row.statuses[status]))
+		}
+
+		if err = writer.Write(record); err != nil {
+			return fmt.Errorf("error writing CSV row: %w", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+/*
+ExportSLOCSV writes the latest Apdex score and burn rate for each of
+the SLOWindows, one row per window. Apdex and burn rate are -1 when
+they aren't configured or the window had no requests. Only the header
+is written until the sampler has run
+*/
+func (s *ServerStats) ExportSLOCSV(w io.Writer) error {
+	var err error
+
+	report := s.GetSnapshot().SLO
+	writer := csv.NewWriter(w)
+
+	if err = writer.Write(sloCSVHeader); err != nil {
+		return fmt.Errorf("error writing CSV header: %w", err)
+	}
+
+	if report != nil {
+		for _, window := range report.Windows {
+			record := []string{
+				report.CalculatedAt.UTC().Format(time.RFC3339),
+				window.Window,
+				strconv.FormatUint(window.Requests, 10),
+				strconv.FormatFloat(window.Apdex, 'f', 3, 64),
+				strconv.FormatFloat(window.ErrorRate, 'f', 3, 64),
+				strconv.FormatFloat(window.BurnRate, 'f', 3, 64),
+				formatMilliseconds(report.ApdexThresholdInMilliseconds),
+				strconv.FormatFloat(report.AvailabilitySLO, 'f', -1, 64),
+			}
+
+			if err = writer.Write(record); err != nil {
+				return fmt.Errorf("error writing CSV row: %w", err)
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+/*
+ExportDatabasesCSV writes the latest connection pool stats sampled from
+each database registered with RegisterDB, one row per database. Only
+the header is written until the sampler has run
+*/
+func (s *ServerStats) ExportDatabasesCSV(w io.Writer) error {
+	var err error
+
+	s.RLock()
+	databases := s.getDatabaseStats()
+	s.RUnlock()
+
+	names := make([]string, 0, len(databases))
+
+	for name := range databases {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	writer := csv.NewWriter(w)
+
+	if err = writer.Write(databaseCSVHeader); err != nil {
+		return fmt.Errorf("error writing CSV header: %w", err)
+	}
+
+	for _, name := range names {
+		stats := databases[name]
+
+		record := []string{
+			stats.SampledAt.Format(time.RFC3339),
+			name,
+			strconv.Itoa(stats.OpenConnections),
+			strconv.Itoa(stats.InUse),
+			strconv.Itoa(stats.Idle),
+			strconv.Itoa(stats.MaxOpenConnections),
+			strconv.FormatInt(stats.WaitCount, 10),
+			strconv.FormatInt(stats.WaitCountSinceLastSample, 10),
+			strconv.FormatInt(stats.WaitDurationInMilliseconds, 10),
+			strconv.FormatInt(stats.MaxIdleClosed, 10),
+			strconv.FormatInt(stats.MaxIdleTimeClosed, 10),
+			strconv.FormatInt(stats.MaxLifetimeClosed, 10),
+		}
+
+		if err = writer.Write(record); err != nil {
+			return fmt.Errorf("error writing CSV row: %w", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func routeCSVRow(generatedAt string, route RouteStats, window string, latency LatencyStats) []string {
+	return []string{
+		generatedAt,
+		route.Route,
+		route.Method,
+		route.Path,
+		window,
+		strconv.FormatUint(latency.Count, 10),
+		formatMilliseconds(latency.MeanInMilliseconds),
+		formatMilliseconds(latency.MinInMilliseconds),
+		formatMilliseconds(latency.MaxInMilliseconds),
+		formatMilliseconds(latency.StdDevInMilliseconds),
+	}
+}
+
+func formatMilliseconds(value float64) string {
+	return strconv.FormatFloat(value, 'f', 3, 64)
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestExportCSV(t *testing.T) {
+	stats := serverstats.NewServerStats(nil)
+
+	serve(stats, http.MethodGet, "/users", status(http.StatusOK))
+	serve(stats, http.MethodGet, "/users", status(http.StatusOK))
+
+	buffer := &bytes.Buffer{}
+
+	if err := stats.ExportCSV(buffer); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	records, err := csv.NewReader(buffer).ReadAll()
+
+	if err != nil {
+		t.Fatalf("Expected valid CSV but got '%s'", err)
+	}
+
+	if len(records) != 5 || records[0][1] != "route" || records[0][5] != "requests" {
+		t.Fatalf("Expected a header and 4 rows but got %v", records)
+	}
+
+	expectedWindows := []string{"all", "1m0s", "5m0s", "15m0s"}
+
+	for index, window := range expectedWindows {
+		record := records[index+1]
+
+		if record[1] != "GET /users" || record[2] != http.MethodGet || record[3] != "/users" {
+			t.Errorf("Unexpected route columns %v", record)
+		}
+
+		if record[4] != window || record[5] != "2" {
+			t.Errorf("Expected window %s with 2 requests but got %v", window, record)
+		}
+	}
+}
+
+func TestExportHTTPHandler(t *testing.T) {
+	stats := serverstats.NewServerStats(nil)
+	serve(stats, http.MethodGet, "/users", status(http.StatusOK))
+
+	tests := []struct {
+		report       string
+		expected     int
+		fileName     string
+		headerPrefix string
+	}{
+		{report: "", expected: http.StatusOK, fileName: "serverstats-routes.csv", headerPrefix: "generatedAt,route"},
+		{report: "routes", expected: http.StatusOK, fileName: "serverstats-routes.csv", headerPrefix: "generatedAt,route"},
+		{report: "hourly", expected: http.StatusOK, fileName: "serverstats-hourly.csv", headerPrefix: "timestamp,requests"},
+		{report: "slo", expected: http.StatusOK, fileName: "serverstats-slo.csv", headerPrefix: "calculatedAt,window,requests,apdex"},
+		{report: "databases", expected: http.StatusOK, fileName: "serverstats-databases.csv", headerPrefix: "sampledAt,database"},
+		{report: "bogus", expected: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		stats.ExportHTTPHandler(recorder, httptest.NewRequest(http.MethodGet, "/serverstats/export?report="+test.report, nil))
+
+		if recorder.Code != test.expected {
+			t.Errorf("Expected status %d for report '%s' but got %d", test.expected, test.report, recorder.Code)
+			continue
+		}
+
+		if test.expected != http.StatusOK {
+			continue
+		}
+
+		if contentType := recorder.Header().Get("Content-Type"); contentType != "text/csv; charset=utf-8" {
+			t.Errorf("Expected a CSV content type for report '%s' but got '%s'", test.report, contentType)
+		}
+
+		if disposition := recorder.Header().Get("Content-Disposition"); !strings.Contains(disposition, test.fileName) {
+			t.Errorf("Expected file name %s but got '%s'", test.fileName, disposition)
+		}
+
+		if !strings.HasPrefix(recorder.Body.String(), test.headerPrefix) {
+			t.Errorf("Expected the %s report but got '%s'", test.report, recorder.Body.String())
+		}
+	}
+}
@@ -0,0 +1,173 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/ResurgenceIT/kit/v6/identity"
+	"github.com/golang-jwt/jwt"
+)
+
+/*
+HandlerAuth protects the stats endpoints. Any combination of methods can
+be configured, and a request is allowed if it passes any one of them.
+
+  - APIKeys: the key is read from APIKeyHeader (default X-API-Key)
+  - BasicAuthUsers: a map of user names to passwords
+  - JWTService: a kit JWT in the Authorization header as a bearer token.
+    When JWTRoles is set the token must carry one of those roles in
+    the RoleClaim additional data key (default "roles"), which can be
+    a single string or a list of strings
+
+If no methods are configured every request is rejected
+*/
+type HandlerAuth struct {
+	APIKeyHeader   string
+	APIKeys        []string
+	BasicAuthRealm string
+	BasicAuthUsers map[string]string
+	JWTRoles       []string
+	JWTService     identity.IJWTService
+	RoleClaim      string
+}
+
+/*
+HasHandlerAuth reports whether the stats endpoints are protected by a
+HandlerAuth. Without one they answer anyone who can reach them
+*/
+func (s *ServerStats) HasHandlerAuth() bool {
+	return s.handlerAuth != nil
+}
+
+/*
+authorize checks a request against auth. When the request doesn't pass
+it a 401 response is written and false is returned. A nil auth allows
+every request
+*/
+func authorize(auth *HandlerAuth, w http.ResponseWriter, r *http.Request) bool {
+	if auth == nil || auth.isAuthorized(r) {
+		return true
+	}
+
+	if len(auth.BasicAuthUsers) > 0 {
+		realm := auth.BasicAuthRealm
+
+		if realm == "" {
+			realm = "Server Stats"
+		}
+
+		w.Header().Set("WWW-Authenticate", `Basic realm="`+realm+`"`)
+	}
+
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	return false
+}
+
+func (a *HandlerAuth) isAuthorized(request *http.Request) bool {
+	return a.hasValidAPIKey(request) || a.hasValidBasicAuth(request) || a.hasValidJWT(request)
+}
+
+func (a *HandlerAuth) hasValidAPIKey(request *http.Request) bool {
+	header := a.APIKeyHeader
+
+	if header == "" {
+		header = "X-API-Key"
+	}
+
+	key := request.Header.Get(header)
+
+	if key == "" {
+		return false
+	}
+
+	for _, validKey := range a.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(validKey)) == 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a *HandlerAuth) hasValidBasicAuth(request *http.Request) bool {
+	userName, password, ok := request.BasicAuth()
+
+	if !ok || len(a.BasicAuthUsers) == 0 {
+		return false
+	}
+
+	validPassword, ok := a.BasicAuthUsers[userName]
+
+	if !ok {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(password), []byte(validPassword)) == 1
+}
+
+func (a *HandlerAuth) hasValidJWT(request *http.Request) bool {
+	var (
+		err   error
+		token *jwt.Token
+	)
+
+	if a.JWTService == nil {
+		return false
+	}
+
+	authorization := request.Header.Get("Authorization")
+
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return false
+	}
+
+	if token, err = a.JWTService.ParseToken(strings.TrimPrefix(authorization, "Bearer ")); err != nil {
+		return false
+	}
+
+	if len(a.JWTRoles) == 0 {
+		return true
+	}
+
+	return a.hasRole(a.JWTService.GetAdditionalDataFromToken(token))
+}
+
+func (a *HandlerAuth) hasRole(additionalData map[string]interface{}) bool {
+	roleClaim := a.RoleClaim
+
+	if roleClaim == "" {
+		roleClaim = "roles"
+	}
+
+	tokenRoles := make([]string, 0)
+
+	switch roles := additionalData[roleClaim].(type) {
+	case string:
+		tokenRoles = append(tokenRoles, roles)
+
+	case []string:
+		tokenRoles = append(tokenRoles, roles...)
+
+	case []interface{}:
+		for _, role := range roles {
+			if roleString, ok := role.(string); ok {
+				tokenRoles = append(tokenRoles, roleString)
+			}
+		}
+	}
+
+	for _, requiredRole := range a.JWTRoles {
+		for _, tokenRole := range tokenRoles {
+			if requiredRole == tokenRole {
+				return true
+			}
+		}
+	}
+
+	return false
+}
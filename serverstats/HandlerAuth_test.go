@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/identity"
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestHandlerAuth(t *testing.T) {
+	jwtService := identity.NewJWTService(identity.JWTServiceConfig{
+		AuthSalt:         "salt",
+		AuthSecret:       "secret",
+		Issuer:           "issuer",
+		TimeoutInMinutes: 5,
+	})
+
+	token := func(roles interface{}) string {
+		request := identity.CreateTokenRequest{UserID: "user", UserName: "User"}
+
+		if roles != nil {
+			request.AdditionalData = map[string]interface{}{"roles": roles}
+		}
+
+		result, err := jwtService.CreateToken(request)
+
+		if err != nil {
+			t.Fatalf("Expected no error but got '%s'", err)
+		}
+
+		return result
+	}
+
+	options := serverstats.DefaultServerStatsOptions()
+	options.HandlerAuth = &serverstats.HandlerAuth{
+		APIKeys:        []string{"stats-key"},
+		BasicAuthUsers: map[string]string{"ops": "password"},
+		JWTService:     jwtService,
+		JWTRoles:       []string{"admin"},
+	}
+
+	stats := serverstats.NewServerStatsWithOptions(options, nil)
+
+	tests := []struct {
+		name     string
+		prepare  func(request *http.Request)
+		expected int
+	}{
+		{name: "no credentials", prepare: func(request *http.Request) {}, expected: http.StatusUnauthorized},
+		{name: "valid API key", prepare: func(request *http.Request) { request.Header.Set("X-API-Key", "stats-key") }, expected: http.StatusOK},
+		{name: "invalid API key", prepare: func(request *http.Request) { request.Header.Set("X-API-Key", "wrong") }, expected: http.StatusUnauthorized},
+		{name: "valid basic auth", prepare: func(request *http.Request) { request.SetBasicAuth("ops", "password") }, expected: http.StatusOK},
+		{name: "wrong password", prepare: func(request *http.Request) { request.SetBasicAuth("ops", "wrong") }, expected: http.StatusUnauthorized},
+		{name: "unknown user", prepare: func(request *http.Request) { request.SetBasicAuth("dev", "password") }, expected: http.StatusUnauthorized},
+		{name: "JWT with role", prepare: func(request *http.Request) { request.Header.Set("Authorization", "Bearer "+token("admin")) }, expected: http.StatusOK},
+		{name: "JWT with role list", prepare: func(request *http.Request) {
+			request.Header.Set("Authorization", "Bearer "+token([]string{"viewer", "admin"}))
+		}, expected: http.StatusOK},
+		{name: "JWT without role", prepare: func(request *http.Request) { request.Header.Set("Authorization", "Bearer "+token("viewer")) }, expected: http.StatusUnauthorized},
+		{name: "JWT without roles claim", prepare: func(request *http.Request) { request.Header.Set("Authorization", "Bearer "+token(nil)) }, expected: http.StatusUnauthorized},
+		{name: "invalid JWT", prepare: func(request *http.Request) { request.Header.Set("Authorization", "Bearer garbage") }, expected: http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodGet, "/serverstats", nil)
+		recorder := httptest.NewRecorder()
+
+		test.prepare(request)
+		stats.ServeHTTP(recorder, request)
+
+		if recorder.Code != test.expected {
+			t.Errorf("%s: Expected status %d but got %d", test.name, test.expected, recorder.Code)
+		}
+
+		if recorder.Code == http.StatusUnauthorized && recorder.Header().Get("WWW-Authenticate") != `Basic realm="Server Stats"` {
+			t.Errorf("%s: Expected a basic auth challenge but got '%s'", test.name, recorder.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// otherHost is the host name used for requests to hosts not in the allowlist
+const otherHost = "(other)"
+
+/*
+HostStats reports request counts and latency for a single virtual host
+*/
+type HostStats struct {
+	Latency  LatencyStats   `json:"latency"`
+	Requests uint64         `json:"requests"`
+	Statuses map[string]int `json:"statuses"`
+}
+
+type hostTracker struct {
+	latency  runningStats
+	requests uint64
+	statuses map[string]int
+}
+
+func newHostAllowlist(hosts []string) map[string]struct{} {
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	result := make(map[string]struct{}, len(hosts))
+
+	for _, host := range hosts {
+		result[normalizeHost(host)] = struct{}{}
+	}
+
+	return result
+}
+
+/*
+normalizeHost lowercases a host and strips its port
+*/
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+/*
+recordHost updates stats for the request's Host header. Hosts not in
+the allowlist are grouped together so the number of tracked hosts stays
+bounded. The caller must hold a write lock
+*/
+func (s *ServerStats) recordHost(request *http.Request, status int, executionTime time.Duration) {
+	if s.hostAllowlist == nil {
+		return
+	}
+
+	host := normalizeHost(request.Host)
+
+	if _, ok := s.hostAllowlist[host]; !ok {
+		host = otherHost
+	}
+
+	tracker, ok := s.hosts[host]
+
+	if !ok {
+		tracker = &hostTracker{
+			statuses: make(map[string]int),
+		}
+
+		s.hosts[host] = tracker
+	}
+
+	tracker.requests++
+	tracker.statuses[guardLabel(tracker.statuses, strconv.Itoa(status), s.maxStatuses, &s.overflow.Statuses)]++
+	tracker.latency.add(executionTime)
+}
+
+/*
+getHostStats returns stats for every host that has received requests.
+The caller must hold a lock
+*/
+func (s *ServerStats) getHostStats() map[string]HostStats {
+	if len(s.hosts) == 0 {
+		return nil
+	}
+
+	result := make(map[string]HostStats, len(s.hosts))
+
+	for host, tracker := range s.hosts {
+		statuses := make(map[string]int, len(tracker.statuses))
+
+		for status, count := range tracker.statuses {
+			statuses[status] = count
+		}
+
+		result[host] = HostStats{
+			Latency:  tracker.latency.report(),
+			Requests: tracker.requests,
+			Statuses: statuses,
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/clock"
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestHostStats(t *testing.T) {
+	fake := clock.NewFake(time.Date(2021, time.June, 1, 9, 0, 0, 0, time.UTC))
+	options := serverstats.DefaultServerStatsOptions()
+	options.Clock = fake
+	options.HostAllowlist = []string{"API.example.com", "admin.example.com.", "::1"}
+	stats := serverstats.NewServerStatsWithOptions(options, nil)
+
+	requests := []struct {
+		host    string
+		status  int
+		latency time.Duration
+	}{
+		{host: "api.example.com", status: http.StatusOK, latency: time.Millisecond * 10},
+		{host: "API.EXAMPLE.COM:8443", status: http.StatusOK, latency: time.Millisecond * 30},
+		{host: "api.example.com.", status: http.StatusNotFound, latency: time.Millisecond * 20},
+		{host: "admin.example.com", status: http.StatusOK, latency: time.Millisecond * 5},
+		{host: "[::1]:8080", status: http.StatusOK, latency: time.Millisecond},
+		{host: "attacker.example.com", status: http.StatusOK, latency: time.Millisecond},
+		{host: "", status: http.StatusBadRequest, latency: time.Millisecond},
+		{host: "not a host", status: http.StatusBadRequest, latency: time.Millisecond},
+	}
+
+	for _, request := range requests {
+		request := request
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Host = request.host
+
+		stats.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fake.Advance(request.latency)
+			w.WriteHeader(request.status)
+		})).ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	hosts := stats.GetSnapshot().Hosts
+
+	if len(hosts) != 4 {
+		t.Fatalf("Expected 4 hosts but got %+v", hosts)
+	}
+
+	tests := []struct {
+		host             string
+		expectedRequests uint64
+		expectedStatuses map[string]int
+		expectedMean     float64
+	}{
+		{host: "api.example.com", expectedRequests: 3, expectedStatuses: map[string]int{"200": 2, "404": 1}, expectedMean: 20},
+		{host: "admin.example.com", expectedRequests: 1, expectedStatuses: map[string]int{"200": 1}, expectedMean: 5},
+		{host: "::1", expectedRequests: 1, expectedStatuses: map[string]int{"200": 1}, expectedMean: 1},
+		{host: "(other)", expectedRequests: 3, expectedStatuses: map[string]int{"200": 1, "400": 2}, expectedMean: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.host, func(t *testing.T) {
+			host, ok := hosts[test.host]
+
+			if !ok {
+				t.Fatalf("Expected stats for %s but got %+v", test.host, hosts)
+			}
+
+			if host.Requests != test.expectedRequests || host.Latency.Count != test.expectedRequests {
+				t.Errorf("Expected %d requests but got %+v", test.expectedRequests, host)
+			}
+
+			if host.Latency.MeanInMilliseconds != test.expectedMean {
+				t.Errorf("Expected a mean of %vms but got %vms", test.expectedMean, host.Latency.MeanInMilliseconds)
+			}
+
+			for status, count := range test.expectedStatuses {
+				if host.Statuses[status] != count {
+					t.Errorf("Expected %d %s responses but got %+v", count, status, host.Statuses)
+				}
+			}
+		})
+	}
+}
+
+func TestHostStatsDisabled(t *testing.T) {
+	stats := serverstats.NewServerStats(nil)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Host = "api.example.com"
+	stats.HTTPMiddleware(status(http.StatusOK)).ServeHTTP(httptest.NewRecorder(), r)
+
+	snapshot := stats.GetSnapshot()
+
+	if snapshot.Hosts != nil {
+		t.Errorf("Expected no host stats without an allowlist but got %+v", snapshot.Hosts)
+	}
+
+	b, _ := json.Marshal(snapshot)
+
+	if strings.Contains(string(b), `"hosts"`) {
+		t.Errorf("Expected hosts to be left out of the JSON")
+	}
+}
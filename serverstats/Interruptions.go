@@ -0,0 +1,57 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+/*
+interruption describes why a request ended early. Timeouts and client
+disconnects are counted separately from handler errors, so capacity
+problems can be told apart from application bugs
+*/
+type interruption int
+
+const (
+	interruptionNone interruption = iota
+	interruptionTimeout
+	interruptionClientDisconnect
+)
+
+/*
+requestInterruption reports whether a request timed out or was canceled
+by the client. Either the handler's error or the request's own context
+can say so
+*/
+func requestInterruption(request *http.Request, err error) interruption {
+	contextErr := request.Context().Err()
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, http.ErrHandlerTimeout) || contextErr == context.DeadlineExceeded {
+		return interruptionTimeout
+	}
+
+	if errors.Is(err, context.Canceled) || contextErr == context.Canceled {
+		return interruptionClientDisconnect
+	}
+
+	return interruptionNone
+}
+
+/*
+recordInterruption counts a timeout or client disconnect. The caller
+must hold a write lock
+*/
+func (s *ServerStats) recordInterruption(reason interruption) {
+	switch reason {
+	case interruptionTimeout:
+		s.timeouts++
+
+	case interruptionClientDisconnect:
+		s.clientDisconnects++
+	}
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestInterruptions(t *testing.T) {
+	canceled := func() context.Context {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		return ctx
+	}
+
+	expired := func() context.Context {
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+		return ctx
+	}
+
+	tests := []struct {
+		name                      string
+		ctx                       func() context.Context
+		handlerErr                error
+		status                    int
+		expectedTimeouts          uint64
+		expectedClientDisconnects uint64
+		expectedHandlerErrors     uint64
+	}{
+		{name: "Deadline exceeded error", ctx: context.Background, handlerErr: context.DeadlineExceeded, status: http.StatusServiceUnavailable, expectedTimeouts: 1},
+		{name: "Handler timeout error", ctx: context.Background, handlerErr: http.ErrHandlerTimeout, status: http.StatusServiceUnavailable, expectedTimeouts: 1},
+		{name: "Wrapped deadline exceeded error", ctx: context.Background, handlerErr: fmt.Errorf("query failed: %w", context.DeadlineExceeded), status: http.StatusGatewayTimeout, expectedTimeouts: 1},
+		{name: "Expired request context", ctx: expired, status: http.StatusServiceUnavailable, expectedTimeouts: 1},
+		{name: "Canceled error", ctx: canceled, handlerErr: context.Canceled, status: http.StatusInternalServerError, expectedClientDisconnects: 1},
+		{name: "Canceled request context without an error", ctx: canceled, status: http.StatusOK, expectedClientDisconnects: 1},
+		{name: "Wrapped canceled error", ctx: context.Background, handlerErr: fmt.Errorf("query failed: %w", context.Canceled), status: http.StatusInternalServerError, expectedClientDisconnects: 1},
+		{name: "Handler error", ctx: context.Background, handlerErr: errors.New("boom"), status: http.StatusInternalServerError, expectedHandlerErrors: 1},
+		{name: "Success", ctx: context.Background, status: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options := serverstats.DefaultServerStatsOptions()
+			options.AvailabilitySLO = 99
+			stats := serverstats.NewServerStatsWithOptions(options, nil)
+
+			request := httptest.NewRequest(http.MethodGet, "/reports/1", nil).WithContext(test.ctx())
+			tracked := stats.BeginRequest(httptest.NewRecorder(), request, "/reports/:id")
+			err := tracked.Run(func() error { return test.handlerErr })
+			tracked.End(test.status, err)
+
+			snapshot := stats.GetSnapshot()
+
+			if snapshot.Errors.Timeouts != test.expectedTimeouts {
+				t.Errorf("Expected %d timeouts but got %d", test.expectedTimeouts, snapshot.Errors.Timeouts)
+			}
+
+			if snapshot.Errors.ClientDisconnects != test.expectedClientDisconnects {
+				t.Errorf("Expected %d client disconnects but got %d", test.expectedClientDisconnects, snapshot.Errors.ClientDisconnects)
+			}
+
+			if snapshot.Errors.HandlerErrors != test.expectedHandlerErrors {
+				t.Errorf("Expected %d handler errors but got %d", test.expectedHandlerErrors, snapshot.Errors.HandlerErrors)
+			}
+
+			if len(snapshot.Errors.RecentErrors) != int(test.expectedHandlerErrors) {
+				t.Errorf("Expected %d recent errors but got %+v", test.expectedHandlerErrors, snapshot.Errors.RecentErrors)
+			}
+
+			if len(snapshot.Routes) != 1 {
+				t.Fatalf("Expected one route but got %+v", snapshot.Routes)
+			}
+
+			route := snapshot.Routes[0]
+
+			if route.Route != "GET /reports/:id" || route.Timeouts != test.expectedTimeouts || route.ClientDisconnects != test.expectedClientDisconnects {
+				t.Errorf("Expected the interruption on GET /reports/:id but got %+v", route)
+			}
+
+			stats.StartSampler(time.Millisecond * 10)
+			defer stats.StopSampler()
+
+			if !waitFor(func() bool { return stats.GetSnapshot().SLO != nil }) {
+				t.Fatalf("Expected the sampler to calculate an SLO report")
+			}
+
+			expectedErrorRate := 0.0
+
+			if test.expectedHandlerErrors > 0 {
+				expectedErrorRate = 100
+			}
+
+			if window := stats.GetSnapshot().SLO.Windows[0]; window.Requests != 1 || window.ErrorRate != expectedErrorRate {
+				t.Errorf("Expected an error rate of %v but got %+v", expectedErrorRate, window)
+			}
+		})
+	}
+}
+
+func TestInterruptionsPerRoute(t *testing.T) {
+	stats := serverstats.NewServerStats(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	serve(stats, http.MethodGet, "/search", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for i := 0; i < 2; i++ {
+		recorder := httptest.NewRecorder()
+		stats.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/search", nil).WithContext(ctx))
+	}
+
+	request := httptest.NewRequest(http.MethodPost, "/reports", nil)
+	tracked := stats.BeginRequest(httptest.NewRecorder(), request, "/reports")
+	tracked.End(http.StatusServiceUnavailable, tracked.Run(func() error { return context.DeadlineExceeded }))
+
+	snapshot := stats.GetSnapshot()
+	routes := make(map[string]serverstats.RouteStats)
+
+	for _, route := range snapshot.Routes {
+		routes[route.Route] = route
+	}
+
+	if route := routes["GET /search"]; route.Requests != 3 || route.ClientDisconnects != 2 || route.Timeouts != 0 {
+		t.Errorf("Expected 2 of 3 search requests to be client disconnects but got %+v", route)
+	}
+
+	if route := routes["POST /reports"]; route.Requests != 1 || route.Timeouts != 1 || route.ClientDisconnects != 0 {
+		t.Errorf("Expected the report request to be a timeout but got %+v", route)
+	}
+
+	if snapshot.Errors.ClientDisconnects != 2 || snapshot.Errors.Timeouts != 1 || snapshot.Errors.HandlerErrors != 0 {
+		t.Errorf("Expected 2 client disconnects, 1 timeout, and no handler errors but got %+v", snapshot.Errors)
+	}
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"time"
+)
+
+/*
+JobStats reports runs of a single background job, such as a scheduled
+task or queue worker. Duration describes how long runs took. Error
+messages pass through the ErrorRedactor like handler errors do
+*/
+type JobStats struct {
+	Duration                   LatencyStats `json:"duration"`
+	Failures                   uint64       `json:"failures"`
+	LastDurationInMilliseconds float64      `json:"lastDurationInMilliseconds"`
+	LastError                  string       `json:"lastError,omitempty"`
+	LastErrorAt                *time.Time   `json:"lastErrorAt,omitempty"`
+	LastRunAt                  time.Time    `json:"lastRunAt"`
+	LastSuccessAt              *time.Time   `json:"lastSuccessAt,omitempty"`
+	Runs                       uint64       `json:"runs"`
+	Successes                  uint64       `json:"successes"`
+}
+
+type jobTracker struct {
+	duration      runningStats
+	failures      uint64
+	lastDuration  time.Duration
+	lastError     string
+	lastErrorAt   time.Time
+	lastRunAt     time.Time
+	lastSuccessAt time.Time
+	successes     uint64
+}
+
+/*
+RecordJobRun records a completed run of a background job. A non-nil
+err counts the run as a failure.
+
+	start := time.Now()
+	err := sendReminderEmails()
+	serverStats.RecordJobRun("send reminder emails", time.Since(start), err)
+*/
+func (s *ServerStats) RecordJobRun(name string, duration time.Duration, err error) {
+	now := s.clock.Now().UTC()
+
+	s.Lock()
+	defer s.Unlock()
+
+	tracker, ok := s.jobs[name]
+
+	if !ok {
+		tracker = &jobTracker{}
+		s.jobs[name] = tracker
+	}
+
+	tracker.duration.add(duration)
+	tracker.lastDuration = duration
+	tracker.lastRunAt = now
+
+	if err != nil {
+		tracker.failures++
+		tracker.lastError = s.errorRedactor(err.Error())
+		tracker.lastErrorAt = now
+		return
+	}
+
+	tracker.successes++
+	tracker.lastSuccessAt = now
+}
+
+/*
+StartJob marks the start of a job run. Call the returned function with
+the run's error, or nil, when it finishes.
+
+	done := serverStats.StartJob("import orders")
+	done(importOrders())
+*/
+func (s *ServerStats) StartJob(name string) func(err error) {
+	start := s.clock.Now()
+
+	return func(err error) {
+		s.RecordJobRun(name, s.clock.Since(start), err)
+	}
+}
+
+/*
+GetJobStats returns stats for every job that has run
+*/
+func (s *ServerStats) GetJobStats() map[string]JobStats {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.getJobStats()
+}
+
+/*
+getJobStats returns stats for every job, or nil if no jobs have run.
+The caller must hold a lock
+*/
+func (s *ServerStats) getJobStats() map[string]JobStats {
+	if len(s.jobs) == 0 {
+		return nil
+	}
+
+	result := make(map[string]JobStats, len(s.jobs))
+
+	for name, tracker := range s.jobs {
+		stats := JobStats{
+			Duration:                   tracker.duration.report(),
+			Failures:                   tracker.failures,
+			LastDurationInMilliseconds: toMilliseconds(tracker.lastDuration),
+			LastError:                  tracker.lastError,
+			LastRunAt:                  tracker.lastRunAt,
+			Runs:                       tracker.failures + tracker.successes,
+			Successes:                  tracker.successes,
+		}
+
+		if !tracker.lastErrorAt.IsZero() {
+			lastErrorAt := tracker.lastErrorAt
+			stats.LastErrorAt = &lastErrorAt
+		}
+
+		if !tracker.lastSuccessAt.IsZero() {
+			lastSuccessAt := tracker.lastSuccessAt
+			stats.LastSuccessAt = &lastSuccessAt
+		}
+
+		result[name] = stats
+	}
+
+	return result
+}
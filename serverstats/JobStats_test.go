@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/clock"
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestJobStats(t *testing.T) {
+	start := time.Date(2021, time.June, 1, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	options := serverstats.DefaultServerStatsOptions()
+	options.Clock = fake
+	stats := serverstats.NewServerStatsWithOptions(options, nil)
+
+	if stats.GetJobStats() != nil || stats.GetSnapshot().Jobs != nil {
+		t.Fatalf("Expected no job stats before any job has run")
+	}
+
+	stats.RecordJobRun("import orders", time.Millisecond*100, nil)
+
+	fake.Advance(time.Minute)
+	done := stats.StartJob("import orders")
+	fake.Advance(time.Millisecond * 300)
+	done(errors.New("unable to reach jane@example.com"))
+
+	fake.Advance(time.Minute)
+	done = stats.StartJob("import orders")
+	fake.Advance(time.Millisecond * 200)
+	done(nil)
+
+	fake.Advance(time.Minute)
+	stats.RecordJobRun("send reminders", time.Second, errors.New("smtp down"))
+
+	jobs := stats.GetJobStats()
+
+	if len(jobs) != 2 {
+		t.Fatalf("Expected 2 jobs but got %+v", jobs)
+	}
+
+	lastSuccess := start.Add(time.Minute*2 + time.Millisecond*500)
+	lastImportError := start.Add(time.Minute + time.Millisecond*300)
+	lastReminderRun := start.Add(time.Minute*3 + time.Millisecond*500)
+
+	tests := []struct {
+		name     string
+		expected interface{}
+		actual   interface{}
+	}{
+		{name: "Runs", expected: uint64(3), actual: jobs["import orders"].Runs},
+		{name: "Successes", expected: uint64(2), actual: jobs["import orders"].Successes},
+		{name: "Failures", expected: uint64(1), actual: jobs["import orders"].Failures},
+		{name: "Duration count", expected: uint64(3), actual: jobs["import orders"].Duration.Count},
+		{name: "Mean duration", expected: 200.0, actual: jobs["import orders"].Duration.MeanInMilliseconds},
+		{name: "Min duration", expected: 100.0, actual: jobs["import orders"].Duration.MinInMilliseconds},
+		{name: "Max duration", expected: 300.0, actual: jobs["import orders"].Duration.MaxInMilliseconds},
+		{name: "Last duration", expected: 200.0, actual: jobs["import orders"].LastDurationInMilliseconds},
+		{name: "Last run", expected: lastSuccess, actual: jobs["import orders"].LastRunAt},
+		{name: "Last success", expected: lastSuccess, actual: *jobs["import orders"].LastSuccessAt},
+		{name: "Last error is kept after a success", expected: "unable to reach [email]", actual: jobs["import orders"].LastError},
+		{name: "Last error time", expected: lastImportError, actual: *jobs["import orders"].LastErrorAt},
+		{name: "Failed job runs", expected: uint64(1), actual: jobs["send reminders"].Runs},
+		{name: "Failed job failures", expected: uint64(1), actual: jobs["send reminders"].Failures},
+		{name: "Failed job last run", expected: lastReminderRun, actual: jobs["send reminders"].LastRunAt},
+		{name: "Failed job never succeeded", expected: true, actual: jobs["send reminders"].LastSuccessAt == nil},
+		{name: "Jobs are in the snapshot", expected: uint64(3), actual: stats.GetSnapshot().Jobs["import orders"].Runs},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.actual != test.expected {
+				t.Errorf("Expected %v but got %v", test.expected, test.actual)
+			}
+		})
+	}
+}
+
+func TestConcurrentJobRuns(t *testing.T) {
+	stats := serverstats.NewServerStats(nil)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			var err error
+
+			if i%5 == 0 {
+				err = errors.New("failed")
+			}
+
+			stats.StartJob("worker")(err)
+		}(i)
+	}
+
+	wg.Wait()
+	job := stats.GetJobStats()["worker"]
+
+	if job.Runs != 50 || job.Successes != 40 || job.Failures != 10 || job.Duration.Count != 50 {
+		t.Errorf("Expected 50 runs with 10 failures but got %+v", job)
+	}
+}
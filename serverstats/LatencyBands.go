@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"sort"
+	"time"
+)
+
+/*
+LatencyBand is the number of requests whose response time fell in a
+band. A band covers response times from the previous band's upper bound
+up to (but not including) UpperBound. The last band has no upper bound
+*/
+type LatencyBand struct {
+	Label                    string  `json:"label"`
+	Requests                 uint64  `json:"requests"`
+	UpperBoundInMilliseconds float64 `json:"upperBoundInMilliseconds,omitempty"`
+}
+
+/*
+latencyBands counts requests per band for a set of upper bounds
+*/
+type latencyBands struct {
+	bounds []time.Duration
+	counts []uint64
+}
+
+/*
+normalizeLatencyBands sorts band bounds and drops any that aren't
+positive or are repeated
+*/
+func normalizeLatencyBands(bounds []time.Duration) []time.Duration {
+	result := make([]time.Duration, 0, len(bounds))
+
+	for _, bound := range bounds {
+		if bound > 0 {
+			result = append(result, bound)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i] < result[j]
+	})
+
+	unique := result[:0]
+
+	for index, bound := range result {
+		if index == 0 || bound != result[index-1] {
+			unique = append(unique, bound)
+		}
+	}
+
+	return unique
+}
+
+func newLatencyBands(bounds []time.Duration) *latencyBands {
+	if len(bounds) == 0 {
+		return nil
+	}
+
+	return &latencyBands{
+		bounds: bounds,
+		counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+func (b *latencyBands) add(executionTime time.Duration) {
+	if b == nil {
+		return
+	}
+
+	index := sort.Search(len(b.bounds), func(i int) bool {
+		return executionTime < b.bounds[i]
+	})
+
+	b.counts[index]++
+}
+
+func (b *latencyBands) report() []LatencyBand {
+	if b == nil {
+		return nil
+	}
+
+	result := make([]LatencyBand, 0, len(b.counts))
+
+	for index, count := range b.counts {
+		if index < len(b.bounds) {
+			result = append(result, LatencyBand{
+				Label:                    "<" + b.bounds[index].String(),
+				Requests:                 count,
+				UpperBoundInMilliseconds: toMilliseconds(b.bounds[index]),
+			})
+
+			continue
+		}
+
+		result = append(result, LatencyBand{
+			Label:    ">=" + b.bounds[len(b.bounds)-1].String(),
+			Requests: count,
+		})
+	}
+
+	return result
+}
+
+/*
+mergeLatencyBands adds the counts of two sets of bands. Bands are
+matched by label, so instances configured with different bands still
+merge sensibly
+*/
+func mergeLatencyBands(a, b []LatencyBand) []LatencyBand {
+	if len(a) == 0 {
+		return append([]LatencyBand(nil), b...)
+	}
+
+	result := append([]LatencyBand(nil), a...)
+
+	for _, band := range b {
+		found := false
+
+		for index := range result {
+			if result[index].Label == band.Label {
+				result[index].Requests += band.Requests
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			result = append(result, band)
+		}
+	}
+
+	return result
+}
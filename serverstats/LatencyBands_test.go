@@ -0,0 +1,108 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/clock"
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestLatencyBandBounds(t *testing.T) {
+	tests := []struct {
+		name           string
+		bounds         []time.Duration
+		expectedLabels []string
+	}{
+		{name: "No bands", bounds: nil, expectedLabels: nil},
+		{name: "Sorted", bounds: []time.Duration{time.Millisecond * 100, time.Millisecond * 500}, expectedLabels: []string{"<100ms", "<500ms", ">=500ms"}},
+		{name: "Unsorted", bounds: []time.Duration{time.Second, time.Millisecond * 100, time.Millisecond * 500}, expectedLabels: []string{"<100ms", "<500ms", "<1s", ">=1s"}},
+		{name: "Duplicates", bounds: []time.Duration{time.Millisecond * 500, time.Millisecond * 100, time.Millisecond * 500, time.Millisecond * 100}, expectedLabels: []string{"<100ms", "<500ms", ">=500ms"}},
+		{name: "Non-positive bounds", bounds: []time.Duration{0, time.Millisecond * 250, -time.Second}, expectedLabels: []string{"<250ms", ">=250ms"}},
+		{name: "Only non-positive bounds", bounds: []time.Duration{0, -time.Millisecond}, expectedLabels: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options := serverstats.DefaultServerStatsOptions()
+			options.LatencyBands = test.bounds
+			stats := serverstats.NewServerStatsWithOptions(options, nil)
+
+			serve(stats, http.MethodGet, "/", status(http.StatusOK))
+
+			var labels []string
+
+			for _, band := range stats.GetSnapshot().LatencyBands {
+				labels = append(labels, band.Label)
+			}
+
+			if !reflect.DeepEqual(labels, test.expectedLabels) {
+				t.Errorf("Expected bands %v but got %v", test.expectedLabels, labels)
+			}
+		})
+	}
+}
+
+func TestLatencyBandAssignment(t *testing.T) {
+	tests := []struct {
+		name          string
+		latency       time.Duration
+		expectedLabel string
+	}{
+		{name: "Zero", latency: 0, expectedLabel: "<100ms"},
+		{name: "Just under the first bound", latency: time.Millisecond*100 - 1, expectedLabel: "<100ms"},
+		{name: "On the first bound", latency: time.Millisecond * 100, expectedLabel: "<500ms"},
+		{name: "Just under the last bound", latency: time.Millisecond*500 - 1, expectedLabel: "<500ms"},
+		{name: "On the last bound", latency: time.Millisecond * 500, expectedLabel: ">=500ms"},
+		{name: "Far past the last bound", latency: time.Minute, expectedLabel: ">=500ms"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := clock.NewFake(time.Date(2021, time.June, 1, 9, 0, 0, 0, time.UTC))
+			options := serverstats.DefaultServerStatsOptions()
+			options.Clock = fake
+			options.LatencyBands = []time.Duration{time.Millisecond * 500, time.Millisecond * 100}
+			stats := serverstats.NewServerStatsWithOptions(options, nil)
+
+			serve(stats, http.MethodGet, "/users", func(w http.ResponseWriter, r *http.Request) {
+				fake.Advance(test.latency)
+				w.WriteHeader(http.StatusOK)
+			})
+
+			snapshot := stats.GetSnapshot()
+
+			if len(snapshot.Routes) != 1 {
+				t.Fatalf("Expected one route but got %+v", snapshot.Routes)
+			}
+
+			for scope, bands := range map[string][]serverstats.LatencyBand{"overall": snapshot.LatencyBands, "route": snapshot.Routes[0].LatencyBands} {
+				if len(bands) != 3 {
+					t.Fatalf("%s: expected 3 bands but got %+v", scope, bands)
+				}
+
+				for _, band := range bands {
+					expected := uint64(0)
+
+					if band.Label == test.expectedLabel {
+						expected = 1
+					}
+
+					if band.Requests != expected {
+						t.Errorf("%s: expected %d requests in %s but got %+v", scope, expected, band.Label, bands)
+					}
+				}
+			}
+
+			if bands := snapshot.LatencyBands; bands[0].UpperBoundInMilliseconds != 100 || bands[1].UpperBoundInMilliseconds != 500 || bands[2].UpperBoundInMilliseconds != 0 {
+				t.Errorf("Expected upper bounds of 100ms, 500ms, and none but got %+v", bands)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"math/rand"
+	"time"
+)
+
+// latencyReservoirRetention is how long sampled latencies are kept
+const latencyReservoirRetention = time.Hour
+
+/*
+latencyReservoir keeps a uniform random sample of at most size response
+times for every minute, using reservoir sampling (Algorithm R). Memory
+use stays fixed no matter how busy the server is, and windowed stats
+cover the whole window instead of only the most recent requests
+*/
+type latencyReservoir struct {
+	buckets map[int64]*reservoirBucket
+	random  *rand.Rand
+	size    int
+}
+
+type reservoirBucket struct {
+	samples []ResponseTime
+	seen    uint64
+}
+
+func newLatencyReservoir(size int) *latencyReservoir {
+	if size <= 0 {
+		return nil
+	}
+
+	return &latencyReservoir{
+		buckets: make(map[int64]*reservoirBucket),
+		random:  rand.New(rand.NewSource(time.Now().UnixNano())),
+		size:    size,
+	}
+}
+
+func (r *latencyReservoir) add(responseTime ResponseTime) {
+	if r == nil {
+		return
+	}
+
+	minute := responseTime.Time.Unix() / 60
+	bucket, ok := r.buckets[minute]
+
+	if !ok {
+		bucket = &reservoirBucket{
+			samples: make([]ResponseTime, 0, r.size),
+		}
+
+		r.buckets[minute] = bucket
+		r.prune(responseTime.Time)
+	}
+
+	bucket.seen++
+
+	if len(bucket.samples) < r.size {
+		bucket.samples = append(bucket.samples, responseTime)
+		return
+	}
+
+	if index := r.random.Int63n(int64(bucket.seen)); index < int64(r.size) {
+		bucket.samples[index] = responseTime
+	}
+}
+
+/*
+since returns the sampled response times that started on or after the
+provided time. Each one is weighted by how many requests it stands in for
+*/
+func (r *latencyReservoir) since(since time.Time) []ResponseTime {
+	result := make([]ResponseTime, 0, 100)
+
+	for _, bucket := range r.buckets {
+		if len(bucket.samples) == 0 {
+			continue
+		}
+
+		weight := float64(bucket.seen) / float64(len(bucket.samples))
+
+		for _, sample := range bucket.samples {
+			if !sample.Time.Before(since) {
+				sample.weight = weight
+				result = append(result, sample)
+			}
+		}
+	}
+
+	return result
+}
+
+func (r *latencyReservoir) prune(now time.Time) {
+	oldest := now.Add(-latencyReservoirRetention).Unix() / 60
+
+	for minute := range r.buckets {
+		if minute < oldest {
+			delete(r.buckets, minute)
+		}
+	}
+}
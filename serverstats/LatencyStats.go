@@ -0,0 +1,151 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"math"
+	"time"
+)
+
+/*
+LatencyWindows are the windows minimum, maximum, mean, and standard
+deviation of response times are reported for. Windows are calculated
+from the response time ring, so they can't reach further back than
+NumResponseTimesToKeep requests
+*/
+var LatencyWindows = []time.Duration{
+	time.Minute,
+	time.Minute * 5,
+	time.Minute * 15,
+}
+
+/*
+LatencyStats describes the spread of a set of response times
+*/
+type LatencyStats struct {
+	Count                uint64  `json:"count"`
+	MaxInMilliseconds    float64 `json:"maxInMilliseconds"`
+	MeanInMilliseconds   float64 `json:"meanInMilliseconds"`
+	MinInMilliseconds    float64 `json:"minInMilliseconds"`
+	StdDevInMilliseconds float64 `json:"stdDevInMilliseconds"`
+}
+
+/*
+runningStats calculates min, max, mean, and standard deviation in a
+single pass using Welford's algorithm, so nothing has to be kept
+*/
+type runningStats struct {
+	m2     float64
+	max    float64
+	mean   float64
+	min    float64
+	weight float64
+}
+
+func (r *runningStats) add(executionTime time.Duration) {
+	r.addWeighted(executionTime, 1)
+}
+
+/*
+addWeighted adds a response time that stands in for weight requests,
+which is how sampled latencies are counted
+*/
+func (r *runningStats) addWeighted(executionTime time.Duration, weight float64) {
+	value := toMilliseconds(executionTime)
+
+	if r.weight == 0 || value < r.min {
+		r.min = value
+	}
+
+	if r.weight == 0 || value > r.max {
+		r.max = value
+	}
+
+	r.weight += weight
+
+	delta := value - r.mean
+	r.mean += delta * weight / r.weight
+	r.m2 += weight * delta * (value - r.mean)
+}
+
+func (r *runningStats) report() LatencyStats {
+	result := LatencyStats{
+		Count:              uint64(math.Round(r.weight)),
+		MaxInMilliseconds:  r.max,
+		MeanInMilliseconds: r.mean,
+		MinInMilliseconds:  r.min,
+	}
+
+	if r.weight > 1 {
+		result.StdDevInMilliseconds = math.Sqrt(r.m2 / (r.weight - 1))
+	}
+
+	return result
+}
+
+/*
+getLatencyByWindow calculates latency stats for each of LatencyWindows,
+both overall and per route. The caller must hold a lock
+*/
+func (s *ServerStats) getLatencyByWindow(now time.Time) (map[string]LatencyStats, map[string]map[string]LatencyStats) {
+	overall := make(map[string]LatencyStats, len(LatencyWindows))
+	byRoute := make(map[string]map[string]LatencyStats)
+
+	for _, window := range LatencyWindows {
+		windowName := window.String()
+		windowStats := runningStats{}
+		routeStats := make(map[string]*runningStats)
+
+		for _, responseTime := range s.responseTimesSince(now.Add(-window)) {
+			windowStats.addWeighted(responseTime.ExecutionTime, responseTime.sampleWeight())
+
+			if _, ok := routeStats[responseTime.Route]; !ok {
+				routeStats[responseTime.Route] = &runningStats{}
+			}
+
+			routeStats[responseTime.Route].addWeighted(responseTime.ExecutionTime, responseTime.sampleWeight())
+		}
+
+		overall[windowName] = windowStats.report()
+
+		for route, stats := range routeStats {
+			if _, ok := byRoute[route]; !ok {
+				byRoute[route] = make(map[string]LatencyStats, len(LatencyWindows))
+			}
+
+			byRoute[route][windowName] = stats.report()
+		}
+	}
+
+	return overall, byRoute
+}
+
+/*
+mergeLatencyStats combines latency stats from two sets of requests, using
+the parallel form of Welford's algorithm for the standard deviation
+*/
+func mergeLatencyStats(a, b LatencyStats) LatencyStats {
+	if a.Count == 0 {
+		return b
+	}
+
+	if b.Count == 0 {
+		return a
+	}
+
+	count := float64(a.Count + b.Count)
+	delta := b.MeanInMilliseconds - a.MeanInMilliseconds
+	m2a := a.StdDevInMilliseconds * a.StdDevInMilliseconds * float64(a.Count-1)
+	m2b := b.StdDevInMilliseconds * b.StdDevInMilliseconds * float64(b.Count-1)
+	m2 := m2a + m2b + delta*delta*float64(a.Count)*float64(b.Count)/count
+
+	return LatencyStats{
+		Count:                a.Count + b.Count,
+		MaxInMilliseconds:    math.Max(a.MaxInMilliseconds, b.MaxInMilliseconds),
+		MeanInMilliseconds:   a.MeanInMilliseconds + delta*float64(b.Count)/count,
+		MinInMilliseconds:    math.Min(a.MinInMilliseconds, b.MinInMilliseconds),
+		StdDevInMilliseconds: math.Sqrt(m2 / (count - 1)),
+	}
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/clock"
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestLatencyStats(t *testing.T) {
+	tests := []struct {
+		name      string
+		latencies []time.Duration
+		expected  serverstats.LatencyStats
+	}{
+		{
+			name:     "No requests",
+			expected: serverstats.LatencyStats{},
+		},
+		{
+			name:      "One request",
+			latencies: []time.Duration{time.Millisecond * 7},
+			expected:  serverstats.LatencyStats{Count: 1, MaxInMilliseconds: 7, MeanInMilliseconds: 7, MinInMilliseconds: 7},
+		},
+		{
+			name:      "Identical requests",
+			latencies: []time.Duration{time.Millisecond * 3, time.Millisecond * 3, time.Millisecond * 3},
+			expected:  serverstats.LatencyStats{Count: 3, MaxInMilliseconds: 3, MeanInMilliseconds: 3, MinInMilliseconds: 3},
+		},
+		{
+			/*
+			 * The sum of squared deviations is 32, so the sample standard
+			 * deviation is sqrt(32 / 7)
+			 */
+			name: "Known sample set",
+			latencies: []time.Duration{
+				time.Millisecond * 4, time.Millisecond * 2, time.Millisecond * 9, time.Millisecond * 4,
+				time.Millisecond * 5, time.Millisecond * 4, time.Millisecond * 7, time.Millisecond * 5,
+			},
+			expected: serverstats.LatencyStats{Count: 8, MaxInMilliseconds: 9, MeanInMilliseconds: 5, MinInMilliseconds: 2, StdDevInMilliseconds: math.Sqrt(32.0 / 7.0)},
+		},
+		{
+			name:      "Sub-millisecond requests",
+			latencies: []time.Duration{time.Microsecond * 250, time.Microsecond * 750},
+			expected:  serverstats.LatencyStats{Count: 2, MaxInMilliseconds: 0.75, MeanInMilliseconds: 0.5, MinInMilliseconds: 0.25, StdDevInMilliseconds: math.Sqrt(0.125)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := clock.NewFake(time.Date(2021, time.June, 1, 9, 0, 0, 0, time.UTC))
+			options := serverstats.DefaultServerStatsOptions()
+			options.Clock = fake
+			stats := serverstats.NewServerStatsWithOptions(options, nil)
+
+			for _, latency := range test.latencies {
+				latency := latency
+
+				serve(stats, http.MethodGet, "/reports", func(w http.ResponseWriter, r *http.Request) {
+					fake.Advance(latency)
+					w.WriteHeader(http.StatusOK)
+				})
+			}
+
+			snapshot := stats.GetSnapshot()
+			actual := map[string]serverstats.LatencyStats{}
+
+			for window, latency := range snapshot.LatencyByWindow {
+				actual["overall "+window] = latency
+			}
+
+			for _, route := range snapshot.Routes {
+				actual["route"] = route.Latency
+
+				for window, latency := range route.LatencyByWindow {
+					actual["route "+window] = latency
+				}
+			}
+
+			expectedCount := len(serverstats.LatencyWindows)
+
+			if len(test.latencies) > 0 {
+				expectedCount = len(serverstats.LatencyWindows)*2 + 1
+			}
+
+			if len(actual) != expectedCount {
+				t.Fatalf("Expected %d sets of latency stats but got %d: %+v", expectedCount, len(actual), actual)
+			}
+
+			for name, latency := range actual {
+				if !sameLatencyStats(latency, test.expected) {
+					t.Errorf("%s: expected %+v but got %+v", name, test.expected, latency)
+				}
+
+				if math.IsNaN(latency.StdDevInMilliseconds) {
+					t.Errorf("%s: expected a standard deviation but got NaN", name)
+				}
+			}
+		})
+	}
+}
+
+func sameLatencyStats(a, b serverstats.LatencyStats) bool {
+	const tolerance = 1e-9
+
+	return a.Count == b.Count &&
+		math.Abs(a.MaxInMilliseconds-b.MaxInMilliseconds) < tolerance &&
+		math.Abs(a.MeanInMilliseconds-b.MeanInMilliseconds) < tolerance &&
+		math.Abs(a.MinInMilliseconds-b.MinInMilliseconds) < tolerance &&
+		math.Abs(a.StdDevInMilliseconds-b.StdDevInMilliseconds) < tolerance
+}
@@ -0,0 +1,200 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"time"
+)
+
+const (
+	// EventMemoryLeakSuspected is recorded when heap growth looks like a leak
+	EventMemoryLeakSuspected = "memory-leak-suspected"
+
+	// EventMemoryLeakCleared is recorded when heap growth stops looking like a leak
+	EventMemoryLeakCleared = "memory-leak-cleared"
+)
+
+/*
+LeakDetectionOptions configures the memory leak heuristic. The sampled
+heap series for the last Window is split into Segments, and the smallest
+heap size in each segment is taken as that segment's baseline. Taking
+the smallest value filters out the garbage collector's sawtooth. A leak
+is suspected when every baseline is higher than the one before it, and
+the heap grew by at least MinGrowthPercent across the window.
+
+Defaults are a 30 minute window, 6 segments, and 10% growth. The
+sampler interval must be short enough to put several samples in each
+segment
+*/
+type LeakDetectionOptions struct {
+	MinGrowthPercent float64
+	Segments         int
+	Window           time.Duration
+}
+
+/*
+MemoryLeakReport describes the result of the memory leak heuristic.
+HeapBaselines are the per-segment baselines in bytes, oldest first
+*/
+type MemoryLeakReport struct {
+	GrowthPercent  float64    `json:"growthPercent"`
+	HeapBaselines  []uint64   `json:"heapBaselines"`
+	Suspected      bool       `json:"suspected"`
+	SuspectedSince *time.Time `json:"suspectedSince,omitempty"`
+	Window         string     `json:"window"`
+}
+
+type heapSample struct {
+	heapAlloc uint64
+	time      time.Time
+}
+
+type leakDetector struct {
+	minGrowthPercent float64
+	report           *MemoryLeakReport
+	samples          []heapSample
+	segments         int
+	suspectedSince   time.Time
+	window           time.Duration
+}
+
+func newLeakDetector(options *LeakDetectionOptions) *leakDetector {
+	if options == nil {
+		return nil
+	}
+
+	result := &leakDetector{
+		minGrowthPercent: options.MinGrowthPercent,
+		samples:          make([]heapSample, 0),
+		segments:         options.Segments,
+		window:           options.Window,
+	}
+
+	if result.minGrowthPercent <= 0 {
+		result.minGrowthPercent = 10
+	}
+
+	if result.segments < 2 {
+		result.segments = 6
+	}
+
+	if result.window <= 0 {
+		result.window = time.Minute * 30
+	}
+
+	return result
+}
+
+/*
+add records a heap sample and re-evaluates the heuristic. It returns an
+event name when the leak state changes
+*/
+func (d *leakDetector) add(now time.Time, heapAlloc uint64) string {
+	if d == nil {
+		return ""
+	}
+
+	start := now.Add(-d.window)
+	d.samples = append(d.samples, heapSample{heapAlloc: heapAlloc, time: now})
+
+	for len(d.samples) > 0 && d.samples[0].time.Before(start) {
+		d.samples = d.samples[1:]
+	}
+
+	baselines := d.baselines(start)
+	wasSuspected := d.report != nil && d.report.Suspected
+
+	d.report = &MemoryLeakReport{
+		HeapBaselines: baselines,
+		Window:        d.window.String(),
+	}
+
+	if baselines == nil {
+		return ""
+	}
+
+	d.report.GrowthPercent = float64(int64(baselines[len(baselines)-1])-int64(baselines[0])) / float64(baselines[0]) * 100
+	d.report.Suspected = isIncreasing(baselines) && d.report.GrowthPercent >= d.minGrowthPercent
+
+	switch {
+	case d.report.Suspected && !wasSuspected:
+		d.suspectedSince = now
+		d.report.SuspectedSince = &d.suspectedSince
+		return EventMemoryLeakSuspected
+
+	case d.report.Suspected:
+		d.report.SuspectedSince = &d.suspectedSince
+
+	case wasSuspected:
+		return EventMemoryLeakCleared
+	}
+
+	return ""
+}
+
+/*
+baselines returns the smallest heap size in each segment of the window,
+or nil if the samples don't cover every segment yet
+*/
+func (d *leakDetector) baselines(start time.Time) []uint64 {
+	segmentLength := d.window / time.Duration(d.segments)
+	result := make([]uint64, d.segments)
+	seen := make([]bool, d.segments)
+
+	for _, sample := range d.samples {
+		segment := int(sample.time.Sub(start) / segmentLength)
+
+		if segment >= d.segments {
+			segment = d.segments - 1
+		}
+
+		if !seen[segment] || sample.heapAlloc < result[segment] {
+			result[segment] = sample.heapAlloc
+			seen[segment] = true
+		}
+	}
+
+	for _, ok := range seen {
+		if !ok {
+			return nil
+		}
+	}
+
+	if result[0] == 0 {
+		return nil
+	}
+
+	return result
+}
+
+func isIncreasing(values []uint64) bool {
+	for index := 1; index < len(values); index++ {
+		if values[index] <= values[index-1] {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+getMemoryLeakReport returns a copy of the latest leak report, or nil if
+leak detection is off or hasn't run. The caller must hold a lock
+*/
+func (s *ServerStats) getMemoryLeakReport() *MemoryLeakReport {
+	if s.leakDetector == nil || s.leakDetector.report == nil {
+		return nil
+	}
+
+	result := *s.leakDetector.report
+	result.HeapBaselines = append([]uint64(nil), result.HeapBaselines...)
+
+	if result.SuspectedSince != nil {
+		suspectedSince := *result.SuspectedSince
+		result.SuspectedSince = &suspectedSince
+	}
+
+	return &result
+}
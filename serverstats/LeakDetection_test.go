@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestLeakDetection(t *testing.T) {
+	options := serverstats.DefaultServerStatsOptions()
+	options.LeakDetection = &serverstats.LeakDetectionOptions{
+		MinGrowthPercent: 50,
+		Segments:         3,
+		Window:           time.Millisecond * 300,
+	}
+
+	stats := serverstats.NewServerStatsWithOptions(options, nil)
+	stats.StartSampler(time.Millisecond * 10)
+	defer stats.StopSampler()
+
+	retained := make([][]byte, 0)
+
+	suspected := waitFor(func() bool {
+		chunk := make([]byte, 1<<20)
+
+		for index := range chunk {
+			chunk[index] = byte(index)
+		}
+
+		retained = append(retained, chunk)
+
+		for _, event := range stats.GetEvents() {
+			if event.Name == serverstats.EventMemoryLeakSuspected {
+				return true
+			}
+		}
+
+		return false
+	})
+
+	runtime.KeepAlive(retained)
+
+	if !suspected {
+		t.Fatalf("Expected a %s event while the heap grew", serverstats.EventMemoryLeakSuspected)
+	}
+
+	report := stats.GetSnapshot().MemoryLeak
+
+	if report == nil || len(report.HeapBaselines) != 3 || report.GrowthPercent < 50 {
+		t.Errorf("Expected a report with 3 growing baselines but got %+v", report)
+	}
+}
+
+func TestLeakDetectionSteadyHeap(t *testing.T) {
+	options := serverstats.DefaultServerStatsOptions()
+	options.LeakDetection = &serverstats.LeakDetectionOptions{
+		MinGrowthPercent: 1000,
+		Segments:         3,
+		Window:           time.Millisecond * 300,
+	}
+
+	stats := serverstats.NewServerStatsWithOptions(options, nil)
+	stats.StartSampler(time.Millisecond * 10)
+	defer stats.StopSampler()
+
+	reported := waitFor(func() bool {
+		report := stats.GetSnapshot().MemoryLeak
+		return report != nil && len(report.HeapBaselines) == 3
+	})
+
+	if !reported {
+		t.Fatalf("Expected a leak report once the window was covered")
+	}
+
+	if report := stats.GetSnapshot().MemoryLeak; report.Suspected {
+		t.Errorf("Expected no leak to be suspected but got %+v", report)
+	}
+
+	for _, event := range stats.GetEvents() {
+		if event.Name == serverstats.EventMemoryLeakSuspected {
+			t.Errorf("Expected no %s event", serverstats.EventMemoryLeakSuspected)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+/*
+MetricsHTTPHandler is a net/http handler that returns the build info
+and request counters in the Prometheus text format, for scraping
+*/
+func (s *ServerStats) MetricsHTTPHandler(w http.ResponseWriter, r *http.Request) {
+	if !authorize(s.handlerAuth, w, r) {
+		return
+	}
+
+	buffer := &bytes.Buffer{}
+	_ = s.WriteMetrics(buffer)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buffer.Bytes())
+}
+
+/*
+WriteMetrics writes the build info and request counters in the
+Prometheus text format. Build info is an info metric, a gauge that is
+always 1 with the build in its labels:
+
+	build_info{commit="abc123",go_version="go1.16.5",version="1.4.2"} 1
+*/
+func (s *ServerStats) WriteMetrics(w io.Writer) error {
+	snapshot := s.GetSnapshot()
+
+	metrics := []struct {
+		help   string
+		kind   string
+		name   string
+		labels string
+		value  interface{}
+	}{
+		{
+			help: "Build information about the running server",
+			kind: "gauge",
+			name: "build_info",
+			labels: fmt.Sprintf(`{commit="%s",go_version="%s",version="%s"}`,
+				escapeLabelValue(snapshot.Build.Commit),
+				escapeLabelValue(snapshot.Build.GoVersion),
+				escapeLabelValue(snapshot.Build.Version),
+			),
+			value: 1,
+		},
+		{help: "Seconds since the server started", kind: "gauge", name: "uptime_seconds", value: snapshot.UptimeInSeconds},
+		{help: "Requests handled", kind: "counter", name: "http_requests_total", value: snapshot.RequestCount},
+		{help: "Requests that panicked", kind: "counter", name: "http_panics_total", value: snapshot.Errors.Panics},
+		{help: "Requests whose handler returned an error", kind: "counter", name: "http_handler_errors_total", value: snapshot.Errors.HandlerErrors},
+		{help: "Requests that timed out", kind: "counter", name: "http_timeouts_total", value: snapshot.Errors.Timeouts},
+		{help: "Requests canceled by the client disconnecting", kind: "counter", name: "http_client_disconnects_total", value: snapshot.Errors.ClientDisconnects},
+	}
+
+	for _, metric := range metrics {
+		_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s%s %v\n", metric.name, metric.help, metric.name, metric.kind, metric.name, metric.labels, metric.value)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
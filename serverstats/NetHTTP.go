@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+/*
+HTTPMiddleware captures request and response stats for a plain net/http
+handler. net/http has no notion of route patterns, so routes are named
+by RouteResolver, which defaults to the URL path
+*/
+func (s *ServerStats) HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w}
+		tracked := s.BeginRequest(recorder, r, s.routeResolver(r))
+		defer tracked.EndOnPanic()
+
+		err := tracked.Run(func() error {
+			next.ServeHTTP(recorder, tracked.Request)
+			return nil
+		})
+
+		if err != nil && !recorder.wroteHeader {
+			http.Error(recorder, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+
+		tracked.End(recorder.status(), err)
+	})
+}
+
+/*
+ServeHTTP is a net/http handler that returns stat data. Stats are
+returned as JSON unless the Accept header asks for text/plain or
+text/html, or the "format" query parameter is set to text or html
+*/
+func (s *ServerStats) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !authorize(s.handlerAuth, w, r) {
+		return
+	}
+
+	renderReport(w, r, s.GetSnapshot())
+}
+
+/*
+DefaultRouteResolver names net/http routes by their URL path
+*/
+func DefaultRouteResolver(request *http.Request) string {
+	return request.URL.Path
+}
+
+/*
+statusRecorder captures the status code written by a net/http handler.
+Flush and Hijack are passed through so streaming and WebSocket handlers
+keep working
+*/
+type statusRecorder struct {
+	http.ResponseWriter
+
+	statusCode  int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.statusCode = statusCode
+		r.wroteHeader = true
+	}
+
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		if !r.wroteHeader {
+			r.WriteHeader(http.StatusOK)
+		}
+
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+
+	if !r.wroteHeader {
+		r.statusCode = http.StatusSwitchingProtocols
+		r.wroteHeader = true
+	}
+
+	return hijacker.Hijack()
+}
+
+/*
+Unwrap returns the original response writer
+*/
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *statusRecorder) status() int {
+	if !r.wroteHeader {
+		return http.StatusOK
+	}
+
+	return r.statusCode
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
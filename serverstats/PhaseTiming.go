@@ -0,0 +1,273 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"time"
+)
+
+const (
+	// EventServerReady is recorded when MarkReady is called
+	EventServerReady = "server-ready"
+
+	// EventShutdownStarted is recorded when shutdown begins
+	EventShutdownStarted = "shutdown-started"
+
+	// EventShutdownComplete is recorded when EndShutdown is called
+	EventShutdownComplete = "shutdown-complete"
+)
+
+/*
+Phase is a named step of starting up or shutting down, such as loading
+config or draining connections. A phase that hasn't ended yet is
+InProgress, and its duration is how long it has been running
+*/
+type Phase struct {
+	DurationInMilliseconds float64   `json:"durationInMilliseconds"`
+	InProgress             bool      `json:"inProgress"`
+	Name                   string    `json:"name"`
+	Start                  time.Time `json:"start"`
+}
+
+/*
+LifecycleReport holds startup and shutdown phase timings. Startup is
+measured from when the ServerStats object was created until MarkReady
+is called. Shutdown is measured from the first shutdown phase, or the
+call to BeginShutdown, until EndShutdown is called. While either is
+still running its duration is how long it has taken so far
+*/
+type LifecycleReport struct {
+	ReadyAt                *time.Time `json:"readyAt,omitempty"`
+	Shutdown               []Phase    `json:"shutdown,omitempty"`
+	ShutdownComplete       bool       `json:"shutdownComplete"`
+	ShutdownInMilliseconds float64    `json:"shutdownInMilliseconds,omitempty"`
+	ShutdownStartedAt      *time.Time `json:"shutdownStartedAt,omitempty"`
+	Startup                []Phase    `json:"startup,omitempty"`
+	StartupInMilliseconds  float64    `json:"startupInMilliseconds"`
+}
+
+type lifecycleTracker struct {
+	readyAt           time.Time
+	shutdown          []*phaseTiming
+	shutdownEndedAt   time.Time
+	shutdownStartedAt time.Time
+	startup           []*phaseTiming
+}
+
+type phaseTiming struct {
+	end   time.Time
+	name  string
+	start time.Time
+}
+
+/*
+StartStartupPhase marks the start of a named startup phase. Call the
+returned function when the phase is done.
+
+	endPhase := serverStats.StartStartupPhase("connect to database")
+	db, err := connectToDatabase()
+	endPhase()
+*/
+func (s *ServerStats) StartStartupPhase(name string) func() {
+	s.Lock()
+	defer s.Unlock()
+
+	phase := &phaseTiming{name: name, start: s.clock.Now().UTC()}
+	s.lifecycle.startup = append(s.lifecycle.startup, phase)
+
+	return s.endPhaseFunc(phase)
+}
+
+/*
+MarkReady records that startup is done and the server is ready to take
+requests. Only the first call has any effect
+*/
+func (s *ServerStats) MarkReady() {
+	s.Lock()
+
+	if !s.lifecycle.readyAt.IsZero() {
+		s.Unlock()
+		return
+	}
+
+	s.lifecycle.readyAt = s.clock.Now().UTC()
+	startup := s.lifecycle.readyAt.Sub(s.Uptime)
+	s.Unlock()
+
+	s.RecordEvent(EventServerReady, map[string]interface{}{
+		"startupInMilliseconds": toMilliseconds(startup),
+	})
+}
+
+/*
+BeginShutdown records that the server has started shutting down. It is
+called for you by the first StartShutdownPhase. Only the first call has
+any effect
+*/
+func (s *ServerStats) BeginShutdown() {
+	s.Lock()
+	started := s.beginShutdown()
+	s.Unlock()
+
+	if started {
+		s.RecordEvent(EventShutdownStarted, nil)
+	}
+}
+
+/*
+StartShutdownPhase marks the start of a named shutdown phase, beginning
+shutdown if it hasn't been already. Call the returned function when the
+phase is done. Phases that never end show as in progress, which points
+at where a hung shutdown is stuck
+*/
+func (s *ServerStats) StartShutdownPhase(name string) func() {
+	s.Lock()
+
+	started := s.beginShutdown()
+	phase := &phaseTiming{name: name, start: s.clock.Now().UTC()}
+	s.lifecycle.shutdown = append(s.lifecycle.shutdown, phase)
+
+	s.Unlock()
+
+	if started {
+		s.RecordEvent(EventShutdownStarted, nil)
+	}
+
+	return s.endPhaseFunc(phase)
+}
+
+/*
+EndShutdown records that shutdown is done. Call GetLifecycleReport
+afterwards to log how long each shutdown phase took
+*/
+func (s *ServerStats) EndShutdown() {
+	s.Lock()
+
+	s.beginShutdown()
+
+	if !s.lifecycle.shutdownEndedAt.IsZero() {
+		s.Unlock()
+		return
+	}
+
+	s.lifecycle.shutdownEndedAt = s.clock.Now().UTC()
+	shutdown := s.lifecycle.shutdownEndedAt.Sub(s.lifecycle.shutdownStartedAt)
+	s.Unlock()
+
+	s.RecordEvent(EventShutdownComplete, map[string]interface{}{
+		"shutdownInMilliseconds": toMilliseconds(shutdown),
+	})
+}
+
+/*
+GetLifecycleReport returns startup and shutdown phase timings
+*/
+func (s *ServerStats) GetLifecycleReport() LifecycleReport {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.getLifecycleReport(s.clock.Now().UTC())
+}
+
+/*
+beginShutdown sets the shutdown start time if it isn't set, and reports
+whether it did. The caller must hold a write lock
+*/
+func (s *ServerStats) beginShutdown() bool {
+	if !s.lifecycle.shutdownStartedAt.IsZero() {
+		return false
+	}
+
+	s.lifecycle.shutdownStartedAt = s.clock.Now().UTC()
+	return true
+}
+
+func (s *ServerStats) endPhaseFunc(phase *phaseTiming) func() {
+	return func() {
+		s.Lock()
+		defer s.Unlock()
+
+		if phase.end.IsZero() {
+			phase.end = s.clock.Now().UTC()
+		}
+	}
+}
+
+/*
+getLifecycleSnapshot returns the lifecycle report for the stats output,
+or nil if no lifecycle API has been used. The caller must hold a lock
+*/
+func (s *ServerStats) getLifecycleSnapshot(now time.Time) *LifecycleReport {
+	lifecycle := s.lifecycle
+
+	if lifecycle.readyAt.IsZero() && lifecycle.shutdownStartedAt.IsZero() && len(lifecycle.startup) == 0 {
+		return nil
+	}
+
+	result := s.getLifecycleReport(now)
+	return &result
+}
+
+/*
+getLifecycleReport returns startup and shutdown phase timings as of now.
+The caller must hold a lock
+*/
+func (s *ServerStats) getLifecycleReport(now time.Time) LifecycleReport {
+	shutdownEnd := now
+
+	if !s.lifecycle.shutdownEndedAt.IsZero() {
+		shutdownEnd = s.lifecycle.shutdownEndedAt
+	}
+
+	result := LifecycleReport{
+		Shutdown:         reportPhases(s.lifecycle.shutdown, shutdownEnd),
+		ShutdownComplete: !s.lifecycle.shutdownEndedAt.IsZero(),
+		Startup:          reportPhases(s.lifecycle.startup, now),
+	}
+
+	if readyAt := s.lifecycle.readyAt; !readyAt.IsZero() {
+		result.ReadyAt = &readyAt
+		result.StartupInMilliseconds = toMilliseconds(readyAt.Sub(s.Uptime))
+	} else {
+		result.StartupInMilliseconds = toMilliseconds(now.Sub(s.Uptime))
+	}
+
+	if startedAt := s.lifecycle.shutdownStartedAt; !startedAt.IsZero() {
+		result.ShutdownStartedAt = &startedAt
+		result.ShutdownInMilliseconds = toMilliseconds(shutdownEnd.Sub(startedAt))
+	}
+
+	return result
+}
+
+/*
+reportPhases converts phase timings for the report. Phases that haven't
+ended are measured up to end
+*/
+func reportPhases(phases []*phaseTiming, end time.Time) []Phase {
+	if len(phases) == 0 {
+		return nil
+	}
+
+	result := make([]Phase, 0, len(phases))
+
+	for _, phase := range phases {
+		phaseEnd := phase.end
+		inProgress := phaseEnd.IsZero()
+
+		if inProgress {
+			phaseEnd = end
+		}
+
+		result = append(result, Phase{
+			DurationInMilliseconds: toMilliseconds(phaseEnd.Sub(phase.start)),
+			InProgress:             inProgress,
+			Name:                   phase.name,
+			Start:                  phase.start,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,199 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/clock"
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func newPhaseStats() (*serverstats.ServerStats, *clock.Fake) {
+	fake := clock.NewFake(time.Date(2021, time.June, 1, 9, 0, 0, 0, time.UTC))
+	options := serverstats.DefaultServerStatsOptions()
+	options.Clock = fake
+
+	return serverstats.NewServerStatsWithOptions(options, nil), fake
+}
+
+func TestStartupPhases(t *testing.T) {
+	stats, fake := newPhaseStats()
+
+	if stats.GetSnapshot().Lifecycle != nil {
+		t.Fatalf("Expected no lifecycle stats before any phase is recorded")
+	}
+
+	fake.Advance(time.Millisecond * 50)
+	endConfig := stats.StartStartupPhase("load config")
+	fake.Advance(time.Millisecond * 100)
+	endConfig()
+
+	endDatabase := stats.StartStartupPhase("connect to database")
+	stats.StartStartupPhase("warm cache")
+	fake.Advance(time.Millisecond * 200)
+	endDatabase()
+
+	/*
+	 * Ending a phase again doesn't move its end
+	 */
+	fake.Advance(time.Millisecond * 100)
+	endDatabase()
+
+	report := stats.GetSnapshot().Lifecycle
+
+	if report == nil || report.ReadyAt != nil || report.StartupInMilliseconds != 450 {
+		t.Fatalf("Expected 450ms of startup that isn't ready yet but got %+v", report)
+	}
+
+	stats.MarkReady()
+	fake.Advance(time.Second)
+	stats.MarkReady()
+
+	report = stats.GetSnapshot().Lifecycle
+
+	tests := []struct {
+		name               string
+		phase              serverstats.Phase
+		expectedName       string
+		expectedDuration   float64
+		expectedInProgress bool
+	}{
+		{name: "Ended phase", phase: report.Startup[0], expectedName: "load config", expectedDuration: 100},
+		{name: "Overlapping phase ended once", phase: report.Startup[1], expectedName: "connect to database", expectedDuration: 200},
+		{name: "Unterminated phase runs up to now", phase: report.Startup[2], expectedName: "warm cache", expectedDuration: 1300, expectedInProgress: true},
+	}
+
+	if len(report.Startup) != len(tests) {
+		t.Fatalf("Expected %d startup phases but got %+v", len(tests), report.Startup)
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.phase.Name != test.expectedName || test.phase.DurationInMilliseconds != test.expectedDuration || test.phase.InProgress != test.expectedInProgress {
+				t.Errorf("Expected %s to take %vms (in progress %v) but got %+v", test.expectedName, test.expectedDuration, test.expectedInProgress, test.phase)
+			}
+		})
+	}
+
+	if report.ReadyAt == nil || report.StartupInMilliseconds != 450 {
+		t.Errorf("Expected startup to end at the first MarkReady after 450ms but got %+v", report)
+	}
+
+	if report.ShutdownStartedAt != nil || report.Shutdown != nil || report.ShutdownComplete {
+		t.Errorf("Expected no shutdown yet but got %+v", report)
+	}
+
+	if events := eventNames(stats); len(events) != 1 || events[0] != serverstats.EventServerReady {
+		t.Errorf("Expected a single server-ready event but got %v", events)
+	}
+}
+
+func TestShutdownPhases(t *testing.T) {
+	stats, fake := newPhaseStats()
+
+	fake.Advance(time.Minute)
+	endDrain := stats.StartShutdownPhase("drain HTTP server")
+	fake.Advance(time.Millisecond * 300)
+	endDrain()
+
+	endWorkers := stats.StartShutdownPhase("stop workers")
+	fake.Advance(time.Millisecond * 50)
+
+	/*
+	 * Phases with the same name are kept apart rather than added together
+	 */
+	endClose := stats.StartShutdownPhase("close")
+	fake.Advance(time.Millisecond * 10)
+	endClose()
+	endClose = stats.StartShutdownPhase("close")
+	fake.Advance(time.Millisecond * 20)
+	endClose()
+
+	report := stats.GetLifecycleReport()
+
+	if report.ShutdownComplete || report.ShutdownInMilliseconds != 380 || !report.Shutdown[1].InProgress {
+		t.Errorf("Expected 380ms of shutdown still in progress but got %+v", report)
+	}
+
+	stats.EndShutdown()
+	fake.Advance(time.Second)
+	stats.EndShutdown()
+	endWorkers()
+
+	report = stats.GetLifecycleReport()
+
+	if !report.ShutdownComplete || report.ShutdownInMilliseconds != 380 {
+		t.Errorf("Expected shutdown to end at the first EndShutdown after 380ms but got %+v", report)
+	}
+
+	if report.ShutdownStartedAt == nil || !report.ShutdownStartedAt.Equal(time.Date(2021, time.June, 1, 9, 1, 0, 0, time.UTC)) {
+		t.Errorf("Expected shutdown to start with the first phase but got %v", report.ShutdownStartedAt)
+	}
+
+	expected := []serverstats.Phase{
+		{Name: "drain HTTP server", DurationInMilliseconds: 300},
+		{Name: "stop workers", DurationInMilliseconds: 1080},
+		{Name: "close", DurationInMilliseconds: 10},
+		{Name: "close", DurationInMilliseconds: 20},
+	}
+
+	if len(report.Shutdown) != len(expected) {
+		t.Fatalf("Expected %d shutdown phases but got %+v", len(expected), report.Shutdown)
+	}
+
+	for index, phase := range report.Shutdown {
+		if phase.Name != expected[index].Name || phase.DurationInMilliseconds != expected[index].DurationInMilliseconds || phase.InProgress {
+			t.Errorf("Expected %+v but got %+v", expected[index], phase)
+		}
+	}
+
+	events := eventNames(stats)
+
+	if len(events) != 2 || events[0] != serverstats.EventShutdownStarted || events[1] != serverstats.EventShutdownComplete {
+		t.Errorf("Expected shutdown started and complete events but got %v", events)
+	}
+}
+
+func TestShutdownWithoutPhases(t *testing.T) {
+	stats, fake := newPhaseStats()
+
+	stats.BeginShutdown()
+	fake.Advance(time.Millisecond * 75)
+	stats.BeginShutdown()
+	stats.EndShutdown()
+
+	report := stats.GetSnapshot().Lifecycle
+
+	if report == nil || !report.ShutdownComplete || report.ShutdownInMilliseconds != 75 || report.Shutdown != nil {
+		t.Errorf("Expected 75ms of shutdown without phases but got %+v", report)
+	}
+}
+
+func TestUnterminatedShutdownPhase(t *testing.T) {
+	stats, fake := newPhaseStats()
+
+	stats.StartShutdownPhase("close database")
+	fake.Advance(time.Second * 30)
+	stats.EndShutdown()
+	fake.Advance(time.Second * 30)
+
+	report := stats.GetLifecycleReport()
+
+	if len(report.Shutdown) != 1 || !report.Shutdown[0].InProgress || report.Shutdown[0].DurationInMilliseconds != 30000 {
+		t.Errorf("Expected the hung phase to be in progress for the length of shutdown but got %+v", report.Shutdown)
+	}
+}
+
+func eventNames(stats *serverstats.ServerStats) []string {
+	result := make([]string, 0)
+
+	for _, event := range stats.GetEvents() {
+		result = append(result, event.Name)
+	}
+
+	return result
+}
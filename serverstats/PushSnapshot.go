@@ -0,0 +1,71 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+/*
+PushSnapshot sends this server's current snapshot to an Aggregator's
+PushHandler under the provided instance name
+*/
+func (s *ServerStats) PushSnapshot(pushURL, instance string) error {
+	return s.PushSnapshotWithHeaders(pushURL, instance, nil)
+}
+
+/*
+PushSnapshotWithHeaders is the same as PushSnapshot, but sends additional
+headers with the request, such as credentials for a protected aggregator
+*/
+func (s *ServerStats) PushSnapshotWithHeaders(pushURL, instance string, headers http.Header) error {
+	var (
+		err      error
+		b        []byte
+		u        *url.URL
+		request  *http.Request
+		response *http.Response
+	)
+
+	if u, err = url.Parse(pushURL); err != nil {
+		return fmt.Errorf("invalid push URL: %w", err)
+	}
+
+	query := u.Query()
+	query.Set("instance", instance)
+	u.RawQuery = query.Encode()
+
+	if b, err = json.Marshal(s.GetSnapshot()); err != nil {
+		return fmt.Errorf("error marshaling snapshot: %w", err)
+	}
+
+	if request, err = http.NewRequest(http.MethodPost, u.String(), bytes.NewReader(b)); err != nil {
+		return fmt.Errorf("error creating push request: %w", err)
+	}
+
+	for header, values := range headers {
+		for _, value := range values {
+			request.Header.Add(header, value)
+		}
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	if response, err = s.httpClient.Do(request); err != nil {
+		return fmt.Errorf("error pushing snapshot: %w", err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("aggregator returned status %d", response.StatusCode)
+	}
+
+	return nil
+}
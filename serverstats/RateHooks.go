@@ -0,0 +1,293 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"net/http"
+	"time"
+)
+
+// maxRateWindow is the longest window request rates can be measured over
+const maxRateWindow = time.Minute
+
+/*
+RateSource reports recent request rates, in requests per second, for a
+client or route. Windows longer than a minute are treated as a minute.
+ServerStats implements RateSource, so a rate limiter can make decisions
+from the same measurements the stats are reported from.
+
+	func limit(rates serverstats.RateSource, identify func(*http.Request) string) echo.MiddlewareFunc {
+		return func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(ctx echo.Context) error {
+				if rates.ClientRate(identify(ctx.Request()), time.Second*10) > 5 {
+					return echo.NewHTTPError(http.StatusTooManyRequests)
+				}
+
+				return next(ctx)
+			}
+		}
+	}
+*/
+type RateSource interface {
+	ClientRate(client string, window time.Duration) float64
+	RouteRate(route string, window time.Duration) float64
+}
+
+/*
+ClientRate returns how many requests per second the client made over
+the window. Clients are only tracked when ClientIdentifier is set, and
+requests are counted once they complete
+*/
+func (s *ServerStats) ClientRate(client string, window time.Duration) float64 {
+	s.RLock()
+	defer s.RUnlock()
+
+	element, ok := s.clients.clients[client]
+
+	if !ok {
+		return 0
+	}
+
+	return element.Value.(*clientEntry).rate.perSecond(s.clock.Now(), window)
+}
+
+/*
+RouteRate returns how many requests per second the route served over
+the window. Routes are named by method and route path, as returned by
+RouteName. Requests are counted once they complete
+*/
+func (s *ServerStats) RouteRate(route string, window time.Duration) float64 {
+	s.RLock()
+	defer s.RUnlock()
+
+	tracker, ok := s.routes[route]
+
+	if !ok {
+		return 0
+	}
+
+	return tracker.rate.perSecond(s.clock.Now(), window)
+}
+
+/*
+IdentifyClient returns the identifier the configured ClientIdentifier
+gives the request, or an empty string if none is configured. Use it to
+look up the client's rate with ClientRate
+*/
+func (s *ServerStats) IdentifyClient(request *http.Request) string {
+	if s.clientIdentifier == nil {
+		return ""
+	}
+
+	return s.clientIdentifier(request)
+}
+
+/*
+RouteName returns the name a route is tracked under, such as
+"GET /users/:id". Use it to look up the route's rate with RouteRate
+*/
+func RouteName(method, path string) string {
+	return routeName(method, path)
+}
+
+/*
+RateScope is what a RateHook watches the request rate of
+*/
+type RateScope int
+
+const (
+	// RateScopeClient watches each client's rate. Clients are only tracked when ClientIdentifier is set
+	RateScopeClient RateScope = iota
+
+	// RateScopeRoute watches each route's rate
+	RateScopeRoute
+)
+
+func (r RateScope) String() string {
+	switch r {
+	case RateScopeClient:
+		return "client"
+	case RateScopeRoute:
+		return "route"
+	default:
+		return "unknown"
+	}
+}
+
+/*
+RateHook calls OnExceeded when a client or route reaches Threshold
+requests per second over Window, which defaults to one second. Once the
+hook has fired for a client or route it stays quiet for that client or
+route until Cooldown has passed, however high the rate stays. Rates are
+checked as each request completes, and OnExceeded is called from the
+request's goroutine, so it should return quickly
+*/
+type RateHook struct {
+	Cooldown   time.Duration
+	OnExceeded func(event RateEvent)
+	Scope      RateScope
+	Threshold  float64
+	Window     time.Duration
+}
+
+/*
+RateEvent describes a rate that reached a RateHook's threshold. Key is
+the client identifier or route name
+*/
+type RateEvent struct {
+	Key       string        `json:"key"`
+	Rate      float64       `json:"rate"`
+	Scope     RateScope     `json:"scope"`
+	Threshold float64       `json:"threshold"`
+	Time      time.Time     `json:"time"`
+	Window    time.Duration `json:"window"`
+}
+
+type rateHookState struct {
+	hook      RateHook
+	lastFired map[string]time.Time
+}
+
+type firedRateHook struct {
+	event      RateEvent
+	onExceeded func(event RateEvent)
+}
+
+func newRateHookStates(hooks []RateHook) []*rateHookState {
+	result := make([]*rateHookState, 0, len(hooks))
+
+	for _, hook := range hooks {
+		if hook.OnExceeded == nil || hook.Threshold <= 0 {
+			continue
+		}
+
+		if hook.Window <= 0 {
+			hook.Window = time.Second
+		}
+
+		result = append(result, &rateHookState{
+			hook:      hook,
+			lastFired: make(map[string]time.Time),
+		})
+	}
+
+	return result
+}
+
+/*
+checkRateHooks returns the hooks whose threshold the route or client has
+reached, outside of their cooldown. The caller must hold a write lock,
+and call the returned hooks once it has been released
+*/
+func (s *ServerStats) checkRateHooks(route, client string, now time.Time) []firedRateHook {
+	var result []firedRateHook
+
+	for _, state := range s.rateHooks {
+		var (
+			counter *rateCounter
+			key     string
+		)
+
+		switch state.hook.Scope {
+		case RateScopeClient:
+			if element, ok := s.clients.clients[client]; ok && client != "" {
+				counter, key = &element.Value.(*clientEntry).rate, client
+			}
+
+		case RateScopeRoute:
+			if tracker, ok := s.routes[route]; ok {
+				counter, key = &tracker.rate, route
+			}
+		}
+
+		if counter == nil {
+			continue
+		}
+
+		rate := counter.perSecond(now, state.hook.Window)
+
+		if rate < state.hook.Threshold {
+			continue
+		}
+
+		if lastFired, ok := state.lastFired[key]; ok && now.Sub(lastFired) < state.hook.Cooldown {
+			continue
+		}
+
+		/*
+		 * Keys whose cooldown is over are dropped, so the map only holds
+		 * clients and routes the hook has fired for recently
+		 */
+		for existing, lastFired := range state.lastFired {
+			if now.Sub(lastFired) >= state.hook.Cooldown {
+				delete(state.lastFired, existing)
+			}
+		}
+
+		state.lastFired[key] = now
+
+		result = append(result, firedRateHook{
+			event: RateEvent{
+				Key:       key,
+				Rate:      rate,
+				Scope:     state.hook.Scope,
+				Threshold: state.hook.Threshold,
+				Time:      now.UTC(),
+				Window:    state.hook.Window,
+			},
+			onExceeded: state.hook.OnExceeded,
+		})
+	}
+
+	return result
+}
+
+/*
+rateCounter counts requests per second over the last minute
+*/
+type rateCounter struct {
+	counts  [60]uint64
+	seconds [60]int64
+}
+
+func (c *rateCounter) add(when time.Time) {
+	second := when.Unix()
+	slot := second % 60
+
+	if c.seconds[slot] > second {
+		return
+	}
+
+	if c.seconds[slot] != second {
+		c.seconds[slot] = second
+		c.counts[slot] = 0
+	}
+
+	c.counts[slot]++
+}
+
+func (c *rateCounter) perSecond(now time.Time, window time.Duration) float64 {
+	if window > maxRateWindow {
+		window = maxRateWindow
+	}
+
+	seconds := int64(window / time.Second)
+
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	var total uint64
+
+	current := now.Unix()
+
+	for slot, second := range c.seconds {
+		if second > current-seconds && second <= current {
+			total += c.counts[slot]
+		}
+	}
+
+	return float64(total) / float64(seconds)
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/clock"
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestRateHooks(t *testing.T) {
+	fake := clock.NewFake(time.Date(2021, time.June, 1, 9, 0, 0, 0, time.UTC))
+	events := make([]serverstats.RateEvent, 0)
+
+	options := serverstats.DefaultServerStatsOptions()
+	options.Clock = fake
+	options.RateHooks = []serverstats.RateHook{
+		{
+			Cooldown:   time.Second * 10,
+			OnExceeded: func(event serverstats.RateEvent) { events = append(events, event) },
+			Scope:      serverstats.RateScopeRoute,
+			Threshold:  3,
+		},
+	}
+
+	stats := serverstats.NewServerStatsWithOptions(options, nil)
+
+	burst := func(path string, requests int) {
+		for i := 0; i < requests; i++ {
+			serve(stats, http.MethodGet, path, status(http.StatusOK))
+		}
+	}
+
+	tests := []struct {
+		name           string
+		advance        time.Duration
+		path           string
+		requests       int
+		expectedEvents int
+	}{
+		{name: "Below the threshold", path: "/users", requests: 2, expectedEvents: 0},
+		{name: "Below the threshold in a new second", advance: time.Second, path: "/users", requests: 2, expectedEvents: 0},
+		{name: "At the threshold", advance: time.Second, path: "/users", requests: 3, expectedEvents: 1},
+		{name: "Above the threshold during the cooldown", path: "/users", requests: 5, expectedEvents: 1},
+		{name: "Another route has its own cooldown", path: "/orders", requests: 3, expectedEvents: 2},
+		{name: "Still in the cooldown", advance: time.Second * 9, path: "/users", requests: 3, expectedEvents: 2},
+		{name: "After the cooldown", advance: time.Second, path: "/users", requests: 3, expectedEvents: 3},
+	}
+
+	for _, test := range tests {
+		fake.Advance(test.advance)
+		burst(test.path, test.requests)
+
+		if len(events) != test.expectedEvents {
+			t.Fatalf("%s: expected %d events but got %+v", test.name, test.expectedEvents, events)
+		}
+	}
+
+	expected := []string{"GET /users", "GET /orders", "GET /users"}
+
+	for index, event := range events {
+		if event.Key != expected[index] || event.Scope != serverstats.RateScopeRoute || event.Rate != 3 || event.Threshold != 3 || event.Window != time.Second {
+			t.Errorf("Expected an event for %s at 3 requests per second but got %+v", expected[index], event)
+		}
+	}
+
+	if !events[2].Time.Equal(time.Date(2021, time.June, 1, 9, 0, 12, 0, time.UTC)) {
+		t.Errorf("Expected the last event to fire after the cooldown but got %s", events[2].Time)
+	}
+}
+
+func TestClientRateHooks(t *testing.T) {
+	fake := clock.NewFake(time.Date(2021, time.June, 1, 9, 0, 0, 0, time.UTC))
+	events := make([]serverstats.RateEvent, 0)
+
+	options := serverstats.DefaultServerStatsOptions()
+	options.ClientIdentifier = serverstats.ClientIdentifierHeader("X-Client")
+	options.Clock = fake
+	options.RateHooks = []serverstats.RateHook{
+		{
+			OnExceeded: func(event serverstats.RateEvent) { events = append(events, event) },
+			Scope:      serverstats.RateScopeClient,
+			Threshold:  1,
+			Window:     time.Second * 10,
+		},
+	}
+
+	stats := serverstats.NewServerStatsWithOptions(options, nil)
+
+	request := func(client string) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+		if client != "" {
+			r.Header.Set("X-Client", client)
+		}
+
+		stats.HTTPMiddleware(status(http.StatusOK)).ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	/*
+	 * 10 requests over 10 seconds is 1 per second, so only the tenth
+	 * reaches the threshold
+	 */
+	for i := 0; i < 9; i++ {
+		request("slow")
+		request("")
+		fake.Advance(time.Second)
+	}
+
+	if len(events) != 0 {
+		t.Fatalf("Expected no events below the threshold but got %+v", events)
+	}
+
+	request("slow")
+
+	if len(events) != 1 || events[0].Key != "slow" || events[0].Scope != serverstats.RateScopeClient || events[0].Rate != 1 {
+		t.Fatalf("Expected an event for the slow client but got %+v", events)
+	}
+
+	/*
+	 * Without a cooldown the hook fires every time the rate is reached
+	 */
+	request("slow")
+
+	if len(events) != 2 {
+		t.Errorf("Expected a second event without a cooldown but got %+v", events)
+	}
+}
+
+func TestRateHooksIgnored(t *testing.T) {
+	options := serverstats.DefaultServerStatsOptions()
+	options.RateHooks = []serverstats.RateHook{
+		{Scope: serverstats.RateScopeRoute, Threshold: 1},
+		{OnExceeded: func(event serverstats.RateEvent) { t.Errorf("Expected a hook without a threshold to be ignored") }, Scope: serverstats.RateScopeRoute},
+		{OnExceeded: func(event serverstats.RateEvent) { t.Errorf("Expected client hooks to need a ClientIdentifier") }, Scope: serverstats.RateScopeClient, Threshold: 1},
+	}
+
+	stats := serverstats.NewServerStatsWithOptions(options, nil)
+	serve(stats, http.MethodGet, "/", status(http.StatusOK))
+}
@@ -0,0 +1,291 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+var reportFuncs = map[string]interface{}{
+	"ms": func(value float64) string {
+		return fmt.Sprintf("%.2fms", value)
+	},
+	"score": formatScore,
+	"sortedStatuses": func(statuses map[string]int) []string {
+		result := make([]string, 0, len(statuses))
+
+		for status := range statuses {
+			result = append(result, status)
+		}
+
+		sort.Strings(result)
+		return result
+	},
+}
+
+const textReport = `Server Stats
+============
+
+Uptime:          {{.UptimePretty}} (since {{.ServerStartTime.Format "2006-01-02 15:04:05 MST"}})
+Version:         {{if .Build.Version}}{{.Build.Version}}{{else}}unknown{{end}}{{if .Build.Commit}} ({{.Build.Commit}}){{end}}
+Go:              {{.Build.GoVersion}}
+Requests:        {{.RequestCount}}
+Avg response:    {{.AverageResponseTimeInMilliseconds}}ms
+Response (EWMA): 1m {{ms .DecayingAverages.ResponseTimeInMilliseconds.OneMinute}}, 5m {{ms .DecayingAverages.ResponseTimeInMilliseconds.FiveMinutes}}, 15m {{ms .DecayingAverages.ResponseTimeInMilliseconds.FifteenMinutes}}
+Memory usage:    {{.AverageMemoryUsagePretty}}
+Free memory:     {{.AverageFreeMemoryPretty}}
+Panics:          {{.Errors.Panics}}
+Handler errors:  {{.Errors.HandlerErrors}}
+Timeouts:        {{.Errors.Timeouts}}
+Disconnects:     {{.Errors.ClientDisconnects}}
+
+Statuses
+--------
+{{range $status := sortedStatuses .Statuses}}{{$status}}: {{index $.Statuses $status}}
+{{end}}
+Routes
+------
+{{range .Routes}}{{.Route}}: {{.Requests}} requests, mean {{ms .Latency.MeanInMilliseconds}}, max {{ms .Latency.MaxInMilliseconds}}
+{{end}}{{if .Hosts}}
+Hosts
+-----
+{{range $host, $stats := .Hosts}}{{$host}}: {{$stats.Requests}} requests, mean {{ms $stats.Latency.MeanInMilliseconds}}, max {{ms $stats.Latency.MaxInMilliseconds}}
+{{end}}{{end}}{{with .SLO}}
+Apdex and SLO ({{ms .ApdexThresholdInMilliseconds}} Apdex threshold, {{.AvailabilitySLO}}% availability)
+--------------
+{{range .Windows}}{{.Window}}: {{.Requests}} requests, Apdex {{score .Apdex}}, error rate {{printf "%.2f" .ErrorRate}}%, burn rate {{score .BurnRate}}
+{{end}}{{end}}{{if .Jobs}}
+Jobs
+----
+{{range $name, $job := .Jobs}}{{$name}}: {{$job.Runs}} runs, {{$job.Failures}} failed, mean {{ms $job.Duration.MeanInMilliseconds}}, max {{ms $job.Duration.MaxInMilliseconds}}{{if $job.LastError}}, last error: {{$job.LastError}}{{end}}
+{{end}}{{end}}{{if .Databases}}
+Databases
+---------
+{{range $name, $db := .Databases}}{{$name}}: {{$db.OpenConnections}} open, {{$db.InUse}} in use, {{$db.Idle}} idle, {{$db.WaitCount}} waits ({{$db.WaitCountSinceLastSample}} since last sample), waited {{$db.WaitDurationInMilliseconds}}ms
+{{end}}{{end}}{{with .Lifecycle}}
+Lifecycle
+---------
+Startup: {{ms .StartupInMilliseconds}}{{if not .ReadyAt}} (not ready yet){{end}}
+{{range .Startup}}  {{.Name}}: {{ms .DurationInMilliseconds}}{{if .InProgress}} (in progress){{end}}
+{{end}}{{if .ShutdownStartedAt}}Shutdown: {{ms .ShutdownInMilliseconds}}{{if not .ShutdownComplete}} (in progress){{end}}
+{{range .Shutdown}}  {{.Name}}: {{ms .DurationInMilliseconds}}{{if .InProgress}} (in progress){{end}}
+{{end}}{{end}}{{end}}`
+
+const htmlReport = `<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>Server Stats</title>
+	<style>
+		body { font-family: sans-serif; margin: 2em; }
+		table { border-collapse: collapse; margin-bottom: 2em; }
+		th, td { border: 1px solid #ccc; padding: 0.3em 0.8em; text-align: left; }
+		th { background: #f3f3f3; }
+	</style>
+</head>
+<body>
+	<h1>Server Stats</h1>
+	<table>
+		<tr><th>Uptime</th><td>{{.UptimePretty}} (since {{.ServerStartTime.Format "2006-01-02 15:04:05 MST"}})</td></tr>
+		<tr><th>Version</th><td>{{if .Build.Version}}{{.Build.Version}}{{else}}unknown{{end}}{{if .Build.Commit}} ({{.Build.Commit}}){{end}}</td></tr>
+		<tr><th>Go</th><td>{{.Build.GoVersion}}</td></tr>
+		<tr><th>Requests</th><td>{{.RequestCount}}</td></tr>
+		<tr><th>Average response</th><td>{{.AverageResponseTimeInMilliseconds}}ms</td></tr>
+		<tr><th>Response (1m/5m/15m)</th><td>{{ms .DecayingAverages.ResponseTimeInMilliseconds.OneMinute}} / {{ms .DecayingAverages.ResponseTimeInMilliseconds.FiveMinutes}} / {{ms .DecayingAverages.ResponseTimeInMilliseconds.FifteenMinutes}}</td></tr>
+		<tr><th>Memory usage</th><td>{{.AverageMemoryUsagePretty}}</td></tr>
+		<tr><th>Free memory</th><td>{{.AverageFreeMemoryPretty}}</td></tr>
+		<tr><th>Panics</th><td>{{.Errors.Panics}}</td></tr>
+		<tr><th>Handler errors</th><td>{{.Errors.HandlerErrors}}</td></tr>
+		<tr><th>Timeouts</th><td>{{.Errors.Timeouts}}</td></tr>
+		<tr><th>Client disconnects</th><td>{{.Errors.ClientDisconnects}}</td></tr>
+	</table>
+
+	<h2>Statuses</h2>
+	<table>
+		<tr><th>Status</th><th>Count</th></tr>
+		{{range $status := sortedStatuses .Statuses}}<tr><td>{{$status}}</td><td>{{index $.Statuses $status}}</td></tr>
+		{{end}}
+	</table>
+
+	<h2>Routes</h2>
+	<table>
+		<tr><th>Route</th><th>Requests</th><th>Mean</th><th>Min</th><th>Max</th><th>Std dev</th></tr>
+		{{range .Routes}}<tr><td>{{.Route}}</td><td>{{.Requests}}</td><td>{{ms .Latency.MeanInMilliseconds}}</td><td>{{ms .Latency.MinInMilliseconds}}</td><td>{{ms .Latency.MaxInMilliseconds}}</td><td>{{ms .Latency.StdDevInMilliseconds}}</td></tr>
+		{{end}}
+	</table>
+	{{if .Hosts}}
+	<h2>Hosts</h2>
+	<table>
+		<tr><th>Host</th><th>Requests</th><th>Mean</th><th>Min</th><th>Max</th><th>Std dev</th></tr>
+		{{range $host, $stats := .Hosts}}<tr><td>{{$host}}</td><td>{{$stats.Requests}}</td><td>{{ms $stats.Latency.MeanInMilliseconds}}</td><td>{{ms $stats.Latency.MinInMilliseconds}}</td><td>{{ms $stats.Latency.MaxInMilliseconds}}</td><td>{{ms $stats.Latency.StdDevInMilliseconds}}</td></tr>
+		{{end}}
+	</table>
+	{{end}}
+	{{with .SLO}}
+	<h2>Apdex and SLO</h2>
+	<p>{{ms .ApdexThresholdInMilliseconds}} Apdex threshold, {{.AvailabilitySLO}}% availability</p>
+	<table>
+		<tr><th>Window</th><th>Requests</th><th>Apdex</th><th>Error rate</th><th>Burn rate</th></tr>
+		{{range .Windows}}<tr><td>{{.Window}}</td><td>{{.Requests}}</td><td>{{score .Apdex}}</td><td>{{printf "%.2f" .ErrorRate}}%</td><td>{{score .BurnRate}}</td></tr>
+		{{end}}
+	</table>
+	{{end}}
+	{{if .Jobs}}
+	<h2>Jobs</h2>
+	<table>
+		<tr><th>Job</th><th>Runs</th><th>Failures</th><th>Mean</th><th>Max</th><th>Last error</th></tr>
+		{{range $name, $job := .Jobs}}<tr><td>{{$name}}</td><td>{{$job.Runs}}</td><td>{{$job.Failures}}</td><td>{{ms $job.Duration.MeanInMilliseconds}}</td><td>{{ms $job.Duration.MaxInMilliseconds}}</td><td>{{$job.LastError}}</td></tr>
+		{{end}}
+	</table>
+	{{end}}
+	{{if .Databases}}
+	<h2>Databases</h2>
+	<table>
+		<tr><th>Database</th><th>Open</th><th>In use</th><th>Idle</th><th>Max open</th><th>Waits</th><th>Waits since last sample</th><th>Wait duration</th></tr>
+		{{range $name, $db := .Databases}}<tr><td>{{$name}}</td><td>{{$db.OpenConnections}}</td><td>{{$db.InUse}}</td><td>{{$db.Idle}}</td><td>{{$db.MaxOpenConnections}}</td><td>{{$db.WaitCount}}</td><td>{{$db.WaitCountSinceLastSample}}</td><td>{{$db.WaitDurationInMilliseconds}}ms</td></tr>
+		{{end}}
+	</table>
+	{{end}}
+	{{with .Lifecycle}}
+	<h2>Lifecycle</h2>
+	<table>
+		<tr><th>Phase</th><th>Duration</th></tr>
+		<tr><th>Startup{{if not .ReadyAt}} (not ready yet){{end}}</th><td>{{ms .StartupInMilliseconds}}</td></tr>
+		{{range .Startup}}<tr><td>{{.Name}}{{if .InProgress}} (in progress){{end}}</td><td>{{ms .DurationInMilliseconds}}</td></tr>
+		{{end}}
+		{{if .ShutdownStartedAt}}<tr><th>Shutdown{{if not .ShutdownComplete}} (in progress){{end}}</th><td>{{ms .ShutdownInMilliseconds}}</td></tr>
+		{{range .Shutdown}}<tr><td>{{.Name}}{{if .InProgress}} (in progress){{end}}</td><td>{{ms .DurationInMilliseconds}}</td></tr>
+		{{end}}{{end}}
+	</table>
+	{{end}}
+</body>
+</html>`
+
+var (
+	textReportTemplate = texttemplate.Must(texttemplate.New("report").Funcs(reportFuncs).Parse(textReport))
+	htmlReportTemplate = htmltemplate.Must(htmltemplate.New("report").Funcs(reportFuncs).Parse(htmlReport))
+)
+
+/*
+reportFormat decides how to render the stats. A "format" query parameter
+of json, text, or html wins. Otherwise the Accept header is used, and
+JSON is the default
+*/
+func reportFormat(r *http.Request) string {
+	if format := r.URL.Query().Get("format"); format == "json" || format == "text" || format == "html" {
+		return format
+	}
+
+	accept := r.Header.Get("Accept")
+
+	for _, mediaType := range strings.Split(accept, ",") {
+		mediaType = strings.TrimSpace(strings.Split(mediaType, ";")[0])
+
+		switch mediaType {
+		case "application/json":
+			return "json"
+		case "text/html":
+			return "html"
+		case "text/plain":
+			return "text"
+		}
+	}
+
+	return "json"
+}
+
+func renderReport(w http.ResponseWriter, r *http.Request, snapshot Snapshot) {
+	var err error
+
+	buffer := &bytes.Buffer{}
+
+	switch reportFormat(r) {
+	case "html":
+		if err = htmlReportTemplate.Execute(buffer, snapshot); err != nil {
+			http.Error(w, fmt.Sprintf("error rendering HTML stats report: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+
+	case "text":
+		if err = textReportTemplate.Execute(buffer, snapshot); err != nil {
+			http.Error(w, fmt.Sprintf("error rendering text stats report: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+	default:
+		writeJSON(w, http.StatusOK, snapshot)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buffer.Bytes())
+}
+
+/*
+formatScore formats an Apdex score or burn rate, which are -1 when
+they aren't configured or there were no requests
+*/
+func formatScore(value float64) string {
+	if value < 0 {
+		return "n/a"
+	}
+
+	return strconv.FormatFloat(value, 'f', 3, 64)
+}
+
+/*
+formatUptime returns a duration as a human-readable string, such as
+"3 days, 4 hours, 12 minutes"
+*/
+func formatUptime(uptime time.Duration) string {
+	units := []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"day", time.Hour * 24},
+		{"hour", time.Hour},
+		{"minute", time.Minute},
+		{"second", time.Second},
+	}
+
+	parts := make([]string, 0, len(units))
+
+	for _, unit := range units {
+		count := uptime / unit.duration
+		uptime -= count * unit.duration
+
+		if count == 0 {
+			continue
+		}
+
+		if count == 1 {
+			parts = append(parts, fmt.Sprintf("1 %s", unit.name))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d %ss", count, unit.name))
+		}
+
+		if len(parts) == 3 {
+			break
+		}
+	}
+
+	if len(parts) == 0 {
+		return "0 seconds"
+	}
+
+	return strings.Join(parts, ", ")
+}
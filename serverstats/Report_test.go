@@ -0,0 +1,184 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/clock"
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func newReportStats() (*serverstats.ServerStats, *clock.Fake) {
+	fake := clock.NewFake(time.Date(2021, time.June, 1, 9, 0, 0, 0, time.UTC))
+	options := serverstats.DefaultServerStatsOptions()
+	options.Clock = fake
+	stats := serverstats.NewServerStatsWithOptions(options, nil)
+
+	respond := func(statusCode int, latency time.Duration) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			fake.Advance(latency)
+			w.WriteHeader(statusCode)
+		}
+	}
+
+	serve(stats, http.MethodGet, "/users", respond(http.StatusOK, time.Millisecond*10))
+	serve(stats, http.MethodGet, "/users", respond(http.StatusOK, time.Millisecond*30))
+	serve(stats, http.MethodPost, "/users", respond(http.StatusCreated, time.Millisecond*20))
+	serve(stats, http.MethodGet, "/missing", respond(http.StatusNotFound, time.Millisecond))
+
+	/*
+	 * Route names come from the request, so they must be escaped in HTML
+	 */
+	tracked := stats.BeginRequest(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "/<script>")
+	tracked.End(http.StatusOK, nil)
+
+	fake.Advance(time.Hour*2 + time.Minute*5)
+	return stats, fake
+}
+
+func TestTextReport(t *testing.T) {
+	stats, _ := newReportStats()
+
+	recorder := httptest.NewRecorder()
+	stats.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/serverstats?format=text", nil))
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "text/plain; charset=UTF-8" {
+		t.Errorf("Expected a text content type but got '%s'", contentType)
+	}
+
+	body := recorder.Body.String()
+
+	expected := []string{
+		"Uptime:          2 hours, 5 minutes (since 2021-06-01 09:00:00 UTC)",
+		"Requests:        5",
+		"Handler errors:  0",
+		"200: 3\n201: 1\n404: 1\n",
+		"GET /users: 2 requests, mean 20.00ms, max 30.00ms",
+		"POST /users: 1 requests, mean 20.00ms, max 20.00ms",
+		"GET /<script>: 1 requests",
+	}
+
+	for _, value := range expected {
+		if !strings.Contains(body, value) {
+			t.Errorf("Expected the text report to contain '%s' but got '%s'", value, body)
+		}
+	}
+}
+
+func TestHTMLReport(t *testing.T) {
+	stats, _ := newReportStats()
+
+	recorder := httptest.NewRecorder()
+	stats.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/serverstats?format=html", nil))
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "text/html; charset=UTF-8" {
+		t.Errorf("Expected an HTML content type but got '%s'", contentType)
+	}
+
+	body := recorder.Body.String()
+
+	expected := []string{
+		"<tr><th>Uptime</th><td>2 hours, 5 minutes (since 2021-06-01 09:00:00 UTC)</td></tr>",
+		"<tr><th>Requests</th><td>5</td></tr>",
+		"<tr><td>404</td><td>1</td></tr>",
+		"<tr><td>GET /users</td><td>2</td><td>20.00ms</td><td>10.00ms</td><td>30.00ms</td><td>14.14ms</td></tr>",
+		"<tr><td>GET /&lt;script&gt;</td>",
+	}
+
+	for _, value := range expected {
+		if !strings.Contains(body, value) {
+			t.Errorf("Expected the HTML report to contain '%s' but got '%s'", value, body)
+		}
+	}
+
+	if strings.Contains(body, "<script>") {
+		t.Errorf("Expected route names to be escaped")
+	}
+}
+
+func TestReportFormat(t *testing.T) {
+	stats, _ := newReportStats()
+
+	tests := []struct {
+		name                string
+		query               string
+		accept              string
+		expectedContentType string
+	}{
+		{name: "JSON by default", expectedContentType: "application/json"},
+		{name: "Text by Accept header", accept: "text/plain", expectedContentType: "text/plain; charset=UTF-8"},
+		{name: "HTML by Accept header", accept: "text/html", expectedContentType: "text/html; charset=UTF-8"},
+		{name: "First known media type wins", accept: "application/xml, text/html;q=0.9, text/plain;q=0.8", expectedContentType: "text/html; charset=UTF-8"},
+		{name: "JSON by Accept header", accept: "application/json, text/html", expectedContentType: "application/json"},
+		{name: "Unknown media types fall back to JSON", accept: "application/xml, */*", expectedContentType: "application/json"},
+		{name: "Query parameter wins over Accept", query: "?format=text", accept: "text/html", expectedContentType: "text/plain; charset=UTF-8"},
+		{name: "JSON by query parameter", query: "?format=json", accept: "text/html", expectedContentType: "application/json"},
+		{name: "Unknown query parameter uses Accept", query: "?format=xml", accept: "text/html", expectedContentType: "text/html; charset=UTF-8"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/serverstats"+test.query, nil)
+
+			if test.accept != "" {
+				request.Header.Set("Accept", test.accept)
+			}
+
+			recorder := httptest.NewRecorder()
+			stats.ServeHTTP(recorder, request)
+
+			if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, test.expectedContentType) {
+				t.Errorf("Expected content type '%s' but got '%s'", test.expectedContentType, contentType)
+			}
+
+			if test.expectedContentType == "application/json" {
+				snapshot := serverstats.Snapshot{}
+
+				if err := json.Unmarshal(recorder.Body.Bytes(), &snapshot); err != nil || snapshot.RequestCount != 5 {
+					t.Errorf("Expected a JSON snapshot with 5 requests but got '%s'", recorder.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestUptimePretty(t *testing.T) {
+	tests := []struct {
+		name     string
+		uptime   time.Duration
+		expected string
+	}{
+		{name: "Zero", uptime: 0, expected: "0 seconds"},
+		{name: "Under a second", uptime: time.Millisecond * 999, expected: "0 seconds"},
+		{name: "One second", uptime: time.Second, expected: "1 second"},
+		{name: "Under a minute", uptime: time.Second * 59, expected: "59 seconds"},
+		{name: "One minute", uptime: time.Minute, expected: "1 minute"},
+		{name: "Skips empty units", uptime: time.Hour + time.Second*5, expected: "1 hour, 5 seconds"},
+		{name: "Exactly one day", uptime: time.Hour * 24, expected: "1 day"},
+		{name: "Over a day keeps the three largest units", uptime: time.Hour*24*3 + time.Hour*4 + time.Minute*12 + time.Second*30, expected: "3 days, 4 hours, 12 minutes"},
+		{name: "Over a year", uptime: time.Hour * 24 * 400, expected: "400 days"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := clock.NewFake(time.Date(2021, time.June, 1, 9, 0, 0, 0, time.UTC))
+			options := serverstats.DefaultServerStatsOptions()
+			options.Clock = fake
+			stats := serverstats.NewServerStatsWithOptions(options, nil)
+
+			fake.Advance(test.uptime)
+
+			if actual := stats.GetSnapshot().UptimePretty; actual != test.expected {
+				t.Errorf("Expected '%s' but got '%s'", test.expected, actual)
+			}
+		})
+	}
+}
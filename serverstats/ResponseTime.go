@@ -10,9 +10,26 @@ import (
 
 /*
 ResponseTime is used to track how much time a request took to
-execute, and what time (of day) it happened
+execute, what time (of day) it happened, the route it was for, and the
+resulting HTTP status
 */
 type ResponseTime struct {
 	ExecutionTime time.Duration
+	Route         string
+	Status        int
 	Time          time.Time
+
+	weight float64
+}
+
+/*
+sampleWeight is the number of requests this response time stands in
+for. It is 1 unless latencies are being sampled
+*/
+func (r ResponseTime) sampleWeight() float64 {
+	if r.weight <= 0 {
+		return 1
+	}
+
+	return r.weight
 }
@@ -0,0 +1,123 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// unmatchedRoute is the route name used for requests that didn't match a route
+const unmatchedRoute = "(unmatched)"
+
+/*
+RouteStats reports stats for a single route. Routes are identified by
+method and route path (e.g. "GET /users/:id"), not the raw URL, so all
+requests to the same handler are grouped together
+*/
+type RouteStats struct {
+	ClientDisconnects uint64                  `json:"clientDisconnects"`
+	Latency           LatencyStats            `json:"latency"`
+	LatencyBands      []LatencyBand           `json:"latencyBands,omitempty"`
+	LatencyByWindow   map[string]LatencyStats `json:"latencyByWindow,omitempty"`
+	Method            string                  `json:"method"`
+	Path              string                  `json:"path"`
+	Requests          uint64                  `json:"requests"`
+	Route             string                  `json:"route"`
+	Timeouts          uint64                  `json:"timeouts"`
+}
+
+type routeTracker struct {
+	clientDisconnects uint64
+	latency           runningStats
+	latencyBands      *latencyBands
+	method            string
+	path              string
+	rate              rateCounter
+	requests          uint64
+	statuses          map[string]int
+	timeouts          uint64
+}
+
+/*
+routeName returns the name requests for a route are tracked under
+*/
+func routeName(method, path string) string {
+	return method + " " + routePath(path)
+}
+
+/*
+routePath returns the route path, or "(unmatched)" when the request
+didn't match a route
+*/
+func routePath(path string) string {
+	if path != "" {
+		return path
+	}
+
+	return unmatchedRoute
+}
+
+/*
+recordRoute updates stats for the request's route. The caller must
+hold a write lock
+*/
+func (s *ServerStats) recordRoute(method, path, route string, status int, startTime time.Time, executionTime time.Duration, reason interruption) {
+	tracker, ok := s.routes[route]
+
+	if !ok {
+		tracker = &routeTracker{
+			latencyBands: newLatencyBands(s.latencyBandBounds),
+			method:       method,
+			path:         routePath(path),
+			statuses:     make(map[string]int),
+		}
+
+		s.routes[route] = tracker
+	}
+
+	tracker.requests++
+	tracker.rate.add(startTime)
+	tracker.statuses[guardLabel(tracker.statuses, strconv.Itoa(status), s.maxStatuses, &s.overflow.Statuses)]++
+	tracker.latency.add(executionTime)
+	tracker.latencyBands.add(executionTime)
+
+	switch reason {
+	case interruptionTimeout:
+		tracker.timeouts++
+
+	case interruptionClientDisconnect:
+		tracker.clientDisconnects++
+	}
+}
+
+/*
+getRouteStats returns stats for every route, sorted by route name. The
+caller must hold a lock
+*/
+func (s *ServerStats) getRouteStats(latencyByRoute map[string]map[string]LatencyStats) []RouteStats {
+	result := make([]RouteStats, 0, len(s.routes))
+
+	for route, tracker := range s.routes {
+		result = append(result, RouteStats{
+			ClientDisconnects: tracker.clientDisconnects,
+			Latency:           tracker.latency.report(),
+			LatencyBands:      tracker.latencyBands.report(),
+			LatencyByWindow:   latencyByRoute[route],
+			Method:            tracker.method,
+			Path:              tracker.path,
+			Requests:          tracker.requests,
+			Route:             route,
+			Timeouts:          tracker.timeouts,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Route < result[j].Route
+	})
+
+	return result
+}
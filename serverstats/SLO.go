@@ -0,0 +1,180 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"time"
+)
+
+/*
+SLOWindows are the windows Apdex scores and burn rates are calculated
+over. Pairing a short and long window (5m/1h, 30m/6h) is the common
+approach to multi-window burn rate alerting
+*/
+var SLOWindows = []time.Duration{
+	time.Minute * 5,
+	time.Minute * 30,
+	time.Hour,
+	time.Hour * 6,
+}
+
+/*
+SLOReport contains Apdex scores and error budget burn rates for
+each window in SLOWindows
+*/
+type SLOReport struct {
+	ApdexThresholdInMilliseconds float64           `json:"apdexThresholdInMilliseconds"`
+	AvailabilitySLO              float64           `json:"availabilitySLO"`
+	CalculatedAt                 time.Time         `json:"calculatedAt"`
+	Windows                      []SLOWindowReport `json:"windows"`
+}
+
+/*
+SLOWindowReport contains the Apdex score and burn rate for a single
+window. A burn rate of 1 means the error budget is being spent exactly
+as fast as the SLO allows. Apdex is -1 when no Apdex threshold is
+configured, and BurnRate is -1 when no availability SLO is configured
+*/
+type SLOWindowReport struct {
+	Apdex     float64 `json:"apdex"`
+	BurnRate  float64 `json:"burnRate"`
+	ErrorRate float64 `json:"errorRate"`
+	Requests  uint64  `json:"requests"`
+	Window    string  `json:"window"`
+}
+
+/*
+sloBucket holds request counts for a single minute
+*/
+type sloBucket struct {
+	errors     uint64
+	minute     int64
+	requests   uint64
+	satisfied  uint64
+	tolerating uint64
+}
+
+/*
+sloTracker keeps per-minute request counts for the longest SLO window
+*/
+type sloTracker struct {
+	apdexThreshold  time.Duration
+	availabilitySLO float64
+	buckets         []sloBucket
+}
+
+func newSLOTracker(apdexThreshold time.Duration, availabilitySLO float64) *sloTracker {
+	longestWindow := time.Duration(0)
+
+	for _, window := range SLOWindows {
+		if window > longestWindow {
+			longestWindow = window
+		}
+	}
+
+	return &sloTracker{
+		apdexThreshold:  apdexThreshold,
+		availabilitySLO: availabilitySLO,
+		buckets:         make([]sloBucket, int(longestWindow/time.Minute)+1),
+	}
+}
+
+func (t *sloTracker) enabled() bool {
+	return t.apdexThreshold > 0 || t.availabilitySLO > 0
+}
+
+/*
+record counts a request in the bucket for the minute it started in.
+Errors (5xx) are counted as frustrated for Apdex purposes. Timeouts and
+client disconnects are frustrated too, but stay out of the error rate
+*/
+func (t *sloTracker) record(startTime time.Time, executionTime time.Duration, status int, reason interruption) {
+	if !t.enabled() {
+		return
+	}
+
+	minute := startTime.Unix() / 60
+	bucket := &t.buckets[minute%int64(len(t.buckets))]
+
+	if bucket.minute != minute {
+		*bucket = sloBucket{minute: minute}
+	}
+
+	bucket.requests++
+
+	if reason != interruptionNone {
+		return
+	}
+
+	if status >= 500 {
+		bucket.errors++
+		return
+	}
+
+	if executionTime <= t.apdexThreshold {
+		bucket.satisfied++
+	} else if executionTime <= t.apdexThreshold*4 {
+		bucket.tolerating++
+	}
+}
+
+func (t *sloTracker) windowReport(window time.Duration, now time.Time) SLOWindowReport {
+	var requests, errors, satisfied, tolerating uint64
+
+	currentMinute := now.Unix() / 60
+	oldestMinute := currentMinute - int64(window/time.Minute)
+
+	for _, bucket := range t.buckets {
+		if bucket.minute > oldestMinute && bucket.minute <= currentMinute {
+			requests += bucket.requests
+			errors += bucket.errors
+			satisfied += bucket.satisfied
+			tolerating += bucket.tolerating
+		}
+	}
+
+	result := SLOWindowReport{
+		Apdex:    -1,
+		BurnRate: -1,
+		Requests: requests,
+		Window:   window.String(),
+	}
+
+	if requests == 0 {
+		return result
+	}
+
+	result.ErrorRate = float64(errors) / float64(requests) * 100
+
+	if t.apdexThreshold > 0 {
+		result.Apdex = (float64(satisfied) + float64(tolerating)/2) / float64(requests)
+	}
+
+	if t.availabilitySLO > 0 && t.availabilitySLO < 100 {
+		errorBudget := 100 - t.availabilitySLO
+		result.BurnRate = result.ErrorRate / errorBudget
+	}
+
+	return result
+}
+
+func (t *sloTracker) report(now time.Time) *SLOReport {
+	if !t.enabled() {
+		return nil
+	}
+
+	result := &SLOReport{
+		ApdexThresholdInMilliseconds: toMilliseconds(t.apdexThreshold),
+		AvailabilitySLO:              t.availabilitySLO,
+		CalculatedAt:                 now,
+		Windows:                      make([]SLOWindowReport, 0, len(SLOWindows)),
+	}
+
+	for _, window := range SLOWindows {
+		result.Windows = append(result.Windows, t.windowReport(window, now))
+	}
+
+	return result
+}
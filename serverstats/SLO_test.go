@@ -0,0 +1,151 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestSLOReport(t *testing.T) {
+	options := serverstats.DefaultServerStatsOptions()
+	options.ApdexThreshold = time.Millisecond * 50
+	options.AvailabilitySLO = 99
+
+	stats := serverstats.NewServerStatsWithOptions(options, nil)
+
+	// 2 satisfied, 1 tolerating, and 1 error: Apdex is (2 + 1/2) / 4
+	serve(stats, http.MethodGet, "/", status(http.StatusOK))
+	serve(stats, http.MethodGet, "/", status(http.StatusOK))
+	serve(stats, http.MethodGet, "/", status(http.StatusOK, time.Millisecond*100))
+	serve(stats, http.MethodGet, "/", status(http.StatusServiceUnavailable))
+
+	stats.StartSampler(time.Millisecond * 10)
+	defer stats.StopSampler()
+
+	if !waitFor(func() bool { return stats.GetSnapshot().SLO != nil }) {
+		t.Fatalf("Expected the sampler to calculate an SLO report")
+	}
+
+	report := stats.GetSnapshot().SLO
+
+	if report.ApdexThresholdInMilliseconds != 50 || report.AvailabilitySLO != 99 {
+		t.Errorf("Unexpected report settings %+v", report)
+	}
+
+	if len(report.Windows) != len(serverstats.SLOWindows) {
+		t.Fatalf("Expected %d windows but got %d", len(serverstats.SLOWindows), len(report.Windows))
+	}
+
+	for _, window := range report.Windows {
+		if window.Requests != 4 {
+			t.Errorf("%s: expected 4 requests but got %d", window.Window, window.Requests)
+		}
+
+		if window.Apdex != 0.625 {
+			t.Errorf("%s: expected Apdex 0.625 but got %v", window.Window, window.Apdex)
+		}
+
+		// 25% errors against a 1% error budget
+		if window.ErrorRate != 25 || window.BurnRate != 25 {
+			t.Errorf("%s: expected an error rate and burn rate of 25 but got %v and %v", window.Window, window.ErrorRate, window.BurnRate)
+		}
+	}
+}
+
+func TestSLOReportDisabled(t *testing.T) {
+	stats := serverstats.NewServerStats(nil)
+	serve(stats, http.MethodGet, "/", status(http.StatusOK))
+
+	stats.StartSampler(time.Millisecond * 10)
+	time.Sleep(time.Millisecond * 30)
+	stats.StopSampler()
+
+	if report := stats.GetSnapshot().SLO; report != nil {
+		t.Errorf("Expected no SLO report without a threshold or SLO but got %+v", report)
+	}
+}
+
+func TestSLOInReportsAndExport(t *testing.T) {
+	options := serverstats.DefaultServerStatsOptions()
+	options.ApdexThreshold = time.Millisecond * 50
+	options.AvailabilitySLO = 99
+
+	stats := serverstats.NewServerStatsWithOptions(options, nil)
+
+	serve(stats, http.MethodGet, "/", status(http.StatusOK))
+	serve(stats, http.MethodGet, "/", status(http.StatusOK))
+	serve(stats, http.MethodGet, "/", status(http.StatusOK, time.Millisecond*100))
+	serve(stats, http.MethodGet, "/", status(http.StatusServiceUnavailable))
+
+	stats.StartSampler(time.Millisecond * 10)
+	defer stats.StopSampler()
+
+	if !waitFor(func() bool { return stats.GetSnapshot().SLO != nil }) {
+		t.Fatalf("Expected the sampler to calculate an SLO report")
+	}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{format: "text", expected: "5m0s: 4 requests, Apdex 0.625, error rate 25.00%, burn rate 25.000"},
+		{format: "html", expected: "<tr><td>1h0m0s</td><td>4</td><td>0.625</td><td>25.00%</td><td>25.000</td></tr>"},
+	}
+
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		stats.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/serverstats?format="+test.format, nil))
+
+		if !strings.Contains(recorder.Body.String(), test.expected) {
+			t.Errorf("Expected the %s report to contain '%s' but got '%s'", test.format, test.expected, recorder.Body.String())
+		}
+	}
+
+	buffer := &bytes.Buffer{}
+
+	if err := stats.ExportSLOCSV(buffer); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	records, _ := csv.NewReader(buffer).ReadAll()
+
+	if len(records) != len(serverstats.SLOWindows)+1 || records[0][3] != "apdex" {
+		t.Fatalf("Expected a header and a row per window but got %v", records)
+	}
+
+	if record := records[1]; record[1] != "5m0s" || record[2] != "4" || record[3] != "0.625" || record[4] != "25.000" || record[5] != "25.000" || record[7] != "99" {
+		t.Errorf("Unexpected SLO row %v", record)
+	}
+}
+
+func TestSLOReportsWithoutThreshold(t *testing.T) {
+	options := serverstats.DefaultServerStatsOptions()
+	options.AvailabilitySLO = 99.9
+
+	stats := serverstats.NewServerStatsWithOptions(options, nil)
+	serve(stats, http.MethodGet, "/", status(http.StatusOK))
+
+	stats.StartSampler(time.Millisecond * 10)
+	defer stats.StopSampler()
+
+	if !waitFor(func() bool { return stats.GetSnapshot().SLO != nil }) {
+		t.Fatalf("Expected the sampler to calculate an SLO report")
+	}
+
+	recorder := httptest.NewRecorder()
+	stats.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/serverstats?format=text", nil))
+
+	if expected := "5m0s: 1 requests, Apdex n/a, error rate 0.00%, burn rate 0.000"; !strings.Contains(recorder.Body.String(), expected) {
+		t.Errorf("Expected '%s' but got '%s'", expected, recorder.Body.String())
+	}
+}
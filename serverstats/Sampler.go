@@ -0,0 +1,123 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/shirou/gopsutil/mem"
+)
+
+/*
+StartSampler starts a background goroutine that samples server metrics
+on the provided interval. Each sample updates memory moving averages,
+calculates Apdex scores and burn rates, reads database pool stats, polls
+collectors, checks for memory leaks, and evaluates alert rules.
+
+Call StopSampler to stop sampling. Calling StartSampler while the
+sampler is already running does nothing
+*/
+func (s *ServerStats) StartSampler(interval time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.samplerShutdown != nil {
+		return
+	}
+
+	shutdown := make(chan bool)
+	s.samplerShutdown = shutdown
+	s.samplerInterval = interval
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-shutdown:
+				return
+
+			case <-ticker.C:
+				s.sample()
+			}
+		}
+	}()
+}
+
+/*
+StopSampler stops the background sampler started by StartSampler
+*/
+func (s *ServerStats) StopSampler() {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.samplerShutdown == nil {
+		return
+	}
+
+	close(s.samplerShutdown)
+	s.samplerShutdown = nil
+}
+
+/*
+sample takes a single sample of server metrics, then evaluates alert
+rules. Alerts are dispatched after the lock is released so slow
+callbacks and webhooks don't block requests
+*/
+func (s *ServerStats) sample() {
+	var (
+		err       error
+		vMemStats *mem.VirtualMemoryStat
+	)
+
+	now := s.clock.Now().UTC()
+	freeMemoryPercent := -1.0
+	memStats := &runtime.MemStats{}
+
+	if vMemStats, err = mem.VirtualMemory(); err == nil && vMemStats.Total > 0 {
+		freeMemoryPercent = float64(vMemStats.Available) / float64(vMemStats.Total) * 100
+	}
+
+	runtime.ReadMemStats(memStats)
+
+	s.RLock()
+	interval := s.samplerInterval
+	s.RUnlock()
+
+	collectedStats := s.runCollectors(interval)
+
+	s.Lock()
+	s.collectedStats = collectedStats
+
+	if freeMemoryPercent >= 0 {
+		s.freeMemoryPercent = freeMemoryPercent
+		s.freeMemoryEWMA.update(float64(vMemStats.Available), now)
+	}
+
+	s.memoryUsageEWMA.update(float64(memStats.Sys), now)
+	s.latencyEWMA.flush(s.clock.Now())
+
+	s.sloReport = s.slo.report(now)
+	s.sampleDatabases(now)
+	leakEvent := s.leakDetector.add(now, memStats.HeapAlloc)
+	leakReport := s.getMemoryLeakReport()
+
+	alerts := s.evaluateAlertRules(now)
+	s.Unlock()
+
+	if leakEvent != "" {
+		s.RecordEvent(leakEvent, map[string]interface{}{
+			"growthPercent": leakReport.GrowthPercent,
+			"heapBaselines": leakReport.HeapBaselines,
+			"window":        leakReport.Window,
+		})
+	}
+
+	for _, alert := range alerts {
+		s.dispatchAlert(alert)
+	}
+}
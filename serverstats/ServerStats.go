@@ -13,14 +13,136 @@ import (
 	"sync"
 	"time"
 
-	"github.com/dustin/go-humanize"
+	"github.com/ResurgenceIT/kit/v6/clock"
+	"github.com/ResurgenceIT/kit/v6/restclient"
+	"github.com/ResurgenceIT/kit/v6/sse"
 	"github.com/labstack/echo/v4"
 	"github.com/shirou/gopsutil/mem"
 )
 
+/*
+ServerStatsOptions is used to configure a ServerStats object. HTTPClient
+is used when posting alerts to webhook URLs. If it is nil a client with
+a 10 second timeout is used.
+
+ApdexThreshold is the target response time used to calculate Apdex
+scores, and AvailabilitySLO is the availability objective as a
+percentage (e.g. 99.9) used to calculate error budget burn rates.
+Both are calculated by the background sampler when set.
+
+When RecoverPanics is true the middleware recovers panics, counts them,
+and turns them into errors. The last NumErrorsToKeep panics and handler
+errors are kept, with messages passed through ErrorRedactor first. If
+ErrorRedactor is nil DefaultErrorRedactor is used.
+
+When ClientIdentifier is set, request and error counts are tracked per
+client. At most MaxClients are tracked, with the least recently seen
+client evicted first. The stats output reports the top NumTopClients.
+
+When ClientTypeClassifier is set, requests are also counted by client
+type. Use ClientTypeFromUserAgent to sort requests into browser, mobile,
+bot, and API clients by their User-Agent.
+
+When SlowTraceThreshold is set, every request is assigned an ID (or keeps
+the one in its X-Request-ID header) and a trace. Traces of requests that
+take longer than the threshold are kept, up to NumSlowTracesToKeep.
+
+When HandlerAuth is set, the stats endpoint handlers require requests
+to be authenticated.
+
+When HostAllowlist is set, request counts and latencies are also broken
+down by the request's Host header. Requests to hosts not in the list are
+grouped under "(other)".
+
+RouteResolver names routes for HTTPMiddleware, as net/http has no route
+patterns of its own. If it is nil DefaultRouteResolver is used, which
+uses the URL path. The Echo middleware always uses Echo's route path.
+
+When LatencyBands is set, requests are counted per latency band, overall
+and per route. Each value is a band's upper bound, so 100ms, 300ms, and
+1s give the bands <100ms, <300ms, <1s, and >=1s.
+
+When LeakDetection is set, the background sampler watches the heap for
+sustained growth and records a memory-leak-suspected event when it
+looks like a leak.
+
+The stats output lists up to NumErrorHotSpots routes with the highest
+rate of 5xx responses over the last ErrorHotSpotWindow. Zero uses the
+defaults of 10 routes over 5 minutes.
+
+When LongRequestThreshold is set, requests still in flight after that
+long are listed by LongRunningRequestsHandler, and OnLongRequest, if
+set, is called once for each of them.
+
+RateHooks are called when a client's or route's request rate reaches a
+threshold, such as to tell a rate limiter or raise an alert.
+
+MaxRoutes, MaxStatuses, and MaxCustomStats keep a flood of unique paths,
+odd status codes, or custom stat names from growing memory without bound.
+Once a limit is reached new routes are grouped under "(overflow)", new
+statuses under "other", and new custom stats are dropped. Zero uses the
+defaults of 500 routes, 50 statuses, and 100 custom stats.
+
+When LatencyReservoirSize is set, windowed latency stats and alerts are
+calculated from a random sample of that many response times per minute
+instead of the last NumResponseTimesToKeep responses. This keeps memory
+use fixed on busy servers while still covering the whole window.
+
+Clock is used for timestamps and windows, and defaults to the system
+clock. Tests can pass a clock.Fake to move windows forward without
+waiting
+*/
 type ServerStatsOptions struct {
+	ApdexThreshold         time.Duration
+	AvailabilitySLO        float64
+	ClientIdentifier       ClientIdentifier
+	ClientTypeClassifier   ClientTypeClassifier
+	Clock                  clock.IClock
+	ErrorHotSpotWindow     time.Duration
+	ErrorRedactor          func(message string) string
+	HandlerAuth            *HandlerAuth
+	HostAllowlist          []string
+	HTTPClient             restclient.HTTPClientInterface
+	LatencyBands           []time.Duration
+	LatencyReservoirSize   int
+	LeakDetection          *LeakDetectionOptions
+	LongRequestThreshold   time.Duration
+	MaxClients             int
+	MaxCustomStats         int
+	MaxRoutes              int
+	MaxStatuses            int
+	NumErrorsToKeep        int
+	NumErrorHotSpots       int
+	NumEventsToKeep        int
 	NumMemStatsToKeep      int
 	NumResponseTimesToKeep int
+	NumSlowTracesToKeep    int
+	NumTopClients          int
+	OnLongRequest          func(request InFlightRequest)
+	RateHooks              []RateHook
+	RecoverPanics          bool
+	RouteResolver          func(request *http.Request) string
+	SlowTraceThreshold     time.Duration
+}
+
+/*
+DefaultServerStatsOptions returns the options used by NewServerStats
+*/
+func DefaultServerStatsOptions() ServerStatsOptions {
+	return ServerStatsOptions{
+		ErrorHotSpotWindow:     time.Minute * 5,
+		MaxClients:             1000,
+		MaxCustomStats:         100,
+		MaxRoutes:              500,
+		MaxStatuses:            50,
+		NumErrorHotSpots:       10,
+		NumErrorsToKeep:        20,
+		NumEventsToKeep:        100,
+		NumMemStatsToKeep:      100,
+		NumResponseTimesToKeep: 1000,
+		NumSlowTracesToKeep:    50,
+		NumTopClients:          25,
+	}
 }
 
 /*
@@ -39,6 +161,61 @@ type ServerStats struct {
 	StatsByDayCollection    StatsByDayCollection
 	Statuses                map[string]int `json:"statuses"`
 	customMiddleware        func(ctx echo.Context, serverStats *ServerStats)
+	alertRules              []*alertRuleState
+	buildInfo               BuildInfo
+	clientIdentifier        ClientIdentifier
+	clientDisconnects       uint64
+	clients                 *clientTracker
+	clientTypeClassifier    ClientTypeClassifier
+	clientTypes             map[string]uint64
+	clock                   clock.IClock
+	collectedStats          map[string]map[string]interface{}
+	connections             *connectionTracker
+	collectors              []Collector
+	databases               map[string]DBStatsProvider
+	databaseStats           map[string]DBPoolStats
+	errorHotSpotWindow      time.Duration
+	errorRedactor           func(message string) string
+	eventBroker             *sse.Broker
+	events                  *ring.Ring
+	freeMemoryEWMA          *ewmaSet
+	freeMemoryPercent       float64
+	handlerAuth             *HandlerAuth
+	handlerErrors           uint64
+	hostAllowlist           map[string]struct{}
+	hosts                   map[string]*hostTracker
+	httpClient              restclient.HTTPClientInterface
+	inFlight                map[uint64]*inFlightRequest
+	inFlightSequence        uint64
+	jobs                    map[string]*jobTracker
+	latencyBandBounds       []time.Duration
+	latencyBands            *latencyBands
+	latencyEWMA             *latencyEWMA
+	latencyReservoir        *latencyReservoir
+	leakDetector            *leakDetector
+	lifecycle               lifecycleTracker
+	longRequestThreshold    time.Duration
+	maxCustomStats          int
+	maxRoutes               int
+	maxStatuses             int
+	memoryUsageEWMA         *ewmaSet
+	numErrorHotSpots        int
+	numTopClients           int
+	onLongRequest           func(request InFlightRequest)
+	overflow                OverflowStats
+	panics                  uint64
+	rateHooks               []*rateHookState
+	recentErrors            *ring.Ring
+	recoverPanics           bool
+	routeResolver           func(request *http.Request) string
+	routes                  map[string]*routeTracker
+	samplerInterval         time.Duration
+	samplerShutdown         chan bool
+	slo                     *sloTracker
+	sloReport               *SLOReport
+	slowTraceThreshold      time.Duration
+	slowTraces              *ring.Ring
+	timeouts                uint64
 
 	sync.RWMutex
 }
@@ -47,28 +224,94 @@ type ServerStats struct {
 NewServerStats creates a new ServerStats object
 */
 func NewServerStats(customMiddleware func(ctx echo.Context, serverStats *ServerStats)) *ServerStats {
-	return &ServerStats{
-		AverageFreeSystemMemory: ring.New(100),
-		AverageMemoryUsage:      ring.New(100),
-		customMiddleware:        customMiddleware,
-		CustomStats:             make(map[string]interface{}),
-		Uptime:                  time.Now().UTC(),
-		ResponseTimes:           ring.New(1000),
-		Statuses:                make(map[string]int),
-
-		RWMutex: sync.RWMutex{},
-	}
+	return NewServerStatsWithOptions(DefaultServerStatsOptions(), customMiddleware)
 }
 
+/*
+NewServerStatsWithOptions creates a new ServerStats object configured
+with the provided options
+*/
 func NewServerStatsWithOptions(options ServerStatsOptions, customMiddleware func(ctx echo.Context, serverStats *ServerStats)) *ServerStats {
+	var httpClient restclient.HTTPClientInterface
+	var errorRedactor func(message string) string
+	var routeResolver func(request *http.Request) string
+	var errorHotSpotWindow time.Duration
+	var timeSource clock.IClock
+
+	if httpClient = options.HTTPClient; httpClient == nil {
+		httpClient = &http.Client{
+			Timeout: time.Second * 10,
+		}
+	}
+
+	if errorRedactor = options.ErrorRedactor; errorRedactor == nil {
+		errorRedactor = DefaultErrorRedactor
+	}
+
+	if routeResolver = options.RouteResolver; routeResolver == nil {
+		routeResolver = DefaultRouteResolver
+	}
+
+	if errorHotSpotWindow = options.ErrorHotSpotWindow; errorHotSpotWindow <= 0 {
+		errorHotSpotWindow = time.Minute * 5
+	}
+
+	if timeSource = options.Clock; timeSource == nil {
+		timeSource = clock.Real{}
+	}
+
+	latencyBandBounds := normalizeLatencyBands(options.LatencyBands)
+
 	return &ServerStats{
 		AverageFreeSystemMemory: ring.New(options.NumMemStatsToKeep),
 		AverageMemoryUsage:      ring.New(options.NumMemStatsToKeep),
 		customMiddleware:        customMiddleware,
 		CustomStats:             make(map[string]interface{}),
-		Uptime:                  time.Now().UTC(),
+		Uptime:                  timeSource.Now().UTC(),
 		ResponseTimes:           ring.New(options.NumResponseTimesToKeep),
 		Statuses:                make(map[string]int),
+		alertRules:              make([]*alertRuleState, 0),
+		buildInfo:               readBuildInfo(),
+		clientIdentifier:        options.ClientIdentifier,
+		clients:                 newClientTracker(options.MaxClients),
+		clientTypeClassifier:    options.ClientTypeClassifier,
+		clientTypes:             make(map[string]uint64),
+		clock:                   timeSource,
+		collectors:              make([]Collector, 0),
+		databases:               make(map[string]DBStatsProvider),
+		databaseStats:           make(map[string]DBPoolStats),
+		errorHotSpotWindow:      errorHotSpotWindow,
+		errorRedactor:           errorRedactor,
+		eventBroker:             sse.NewBroker(sse.BrokerConfig{HistorySize: options.NumEventsToKeep}),
+		events:                  newRing(options.NumEventsToKeep, 100),
+		freeMemoryEWMA:          newEWMASet(),
+		handlerAuth:             options.HandlerAuth,
+		hostAllowlist:           newHostAllowlist(options.HostAllowlist),
+		hosts:                   make(map[string]*hostTracker),
+		httpClient:              httpClient,
+		inFlight:                make(map[uint64]*inFlightRequest),
+		jobs:                    make(map[string]*jobTracker),
+		latencyBandBounds:       latencyBandBounds,
+		latencyBands:            newLatencyBands(latencyBandBounds),
+		latencyEWMA:             newLatencyEWMA(),
+		latencyReservoir:        newLatencyReservoir(options.LatencyReservoirSize),
+		leakDetector:            newLeakDetector(options.LeakDetection),
+		longRequestThreshold:    options.LongRequestThreshold,
+		maxCustomStats:          positiveOr(options.MaxCustomStats, 100),
+		maxRoutes:               positiveOr(options.MaxRoutes, 500),
+		maxStatuses:             positiveOr(options.MaxStatuses, 50),
+		memoryUsageEWMA:         newEWMASet(),
+		numErrorHotSpots:        positiveOr(options.NumErrorHotSpots, 10),
+		numTopClients:           options.NumTopClients,
+		onLongRequest:           options.OnLongRequest,
+		rateHooks:               newRateHookStates(options.RateHooks),
+		recentErrors:            newRing(options.NumErrorsToKeep, 20),
+		recoverPanics:           options.RecoverPanics,
+		routeResolver:           routeResolver,
+		routes:                  make(map[string]*routeTracker),
+		slo:                     newSLOTracker(options.ApdexThreshold, options.AvailabilitySLO),
+		slowTraceThreshold:      options.SlowTraceThreshold,
+		slowTraces:              newRing(options.NumSlowTracesToKeep, 50),
 
 		RWMutex: sync.RWMutex{},
 	}
@@ -250,241 +493,79 @@ func (s *ServerStats) breakResponsesIntoMonths() ResponseTimeGraphCollection {
 }
 
 /*
-Middleware is used to capture request and response stats. This is designed
-to be used with the Echo framework
+recordRequest updates request counts, response times, memory usage, and
+statuses for a completed request, and returns any rate hooks it set off.
+The caller must hold a write lock
 */
-func (s *ServerStats) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(ctx echo.Context) error {
-		var err error
-
-		startTime := time.Now()
-
-		if err = next(ctx); err != nil {
-			ctx.Error(err)
-		}
-
-		endTime := time.Since(startTime)
+func (s *ServerStats) recordRequest(request *http.Request, path string, status int, startTime time.Time, executionTime time.Duration, err error) []firedRateHook {
+	method, path := s.guardRoute(request.Method, path)
+	route := routeName(method, path)
+	reason := requestInterruption(request, err)
+
+	s.RequestCount++
+
+	s.ResponseTimes = s.ResponseTimes.Next()
+	responseTime := ResponseTime{
+		ExecutionTime: executionTime,
+		Route:         route,
+		Status:        status,
+		Time:          startTime.UTC(),
+	}
 
-		s.Lock()
-		defer s.Unlock()
+	s.ResponseTimes.Value = responseTime
+	s.latencyReservoir.add(responseTime)
 
-		s.RequestCount++
+	s.AverageFreeSystemMemory = s.AverageFreeSystemMemory.Next()
+	s.AverageMemoryUsage = s.AverageMemoryUsage.Next()
 
-		s.ResponseTimes = s.ResponseTimes.Next()
-		s.ResponseTimes.Value = ResponseTime{
-			ExecutionTime: endTime,
-			Time:          startTime.UTC(),
-		}
+	memStats := &runtime.MemStats{}
+	runtime.ReadMemStats(memStats)
 
-		s.AverageFreeSystemMemory = s.AverageFreeSystemMemory.Next()
-		s.AverageMemoryUsage = s.AverageMemoryUsage.Next()
+	var vMemStats *mem.VirtualMemoryStat
+	vMemStats, _ = mem.VirtualMemory()
 
-		memStats := &runtime.MemStats{}
-		runtime.ReadMemStats(memStats)
+	s.AverageFreeSystemMemory.Value = vMemStats.Available
+	s.AverageMemoryUsage.Value = memStats.Sys
 
-		var vMemStats *mem.VirtualMemoryStat
-		vMemStats, _ = mem.VirtualMemory()
+	now := s.clock.Now()
+	s.freeMemoryEWMA.update(float64(vMemStats.Available), now)
+	s.memoryUsageEWMA.update(float64(memStats.Sys), now)
+	s.latencyEWMA.record(executionTime, now)
+	s.latencyBands.add(executionTime)
 
-		s.AverageFreeSystemMemory.Value = vMemStats.Available
-		s.AverageMemoryUsage.Value = memStats.Sys
+	s.Statuses[guardLabel(s.Statuses, strconv.Itoa(status), s.maxStatuses, &s.overflow.Statuses)]++
+	s.recordInterruption(reason)
+	s.recordRoute(method, path, route, status, startTime, executionTime, reason)
+	s.recordHost(request, status, executionTime)
+	s.slo.record(startTime, executionTime, status, reason)
+	s.recordTrace(request, path, status, executionTime)
 
-		status := strconv.Itoa(ctx.Response().Status)
-		s.Statuses[status]++
+	client := ""
 
-		if s.customMiddleware != nil {
-			s.customMiddleware(ctx, s)
+	if s.clientIdentifier != nil {
+		if client = s.clientIdentifier(request); client != "" {
+			s.clients.record(client, status, startTime.UTC())
 		}
-
-		return nil
 	}
-}
-
-/*
-NewMiddlewareWithTimeTracking returns a middleware that tracks stats by day and hour. You
-provide it a pointer to a StatsByDayCollection and this will update stats grouped by
-day (starting at midnight) and hour.
-*/
-// TODO: Perhaps keep the statsByDayCollection in ServerStats locally. cause otherwise this ain't working
-func (s *ServerStats) NewMiddlewareWithTimeTracking() echo.MiddlewareFunc {
-	return func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(ctx echo.Context) error {
-			var err error
-
-			startTime := time.Now()
-
-			if err = next(ctx); err != nil {
-				ctx.Error(err)
-			}
-
-			endTime := time.Since(startTime)
-
-			s.Lock()
-			defer s.Unlock()
 
-			/*
-			 * Get the date starting at midnight, and the hour integer
-			 */
-			day := time.Date(startTime.Year(), startTime.Month(), startTime.Day(), 0, 0, 0, 0, startTime.Location())
-			hour := startTime.Hour()
-
-			s.RequestCount++
-
-			s.ResponseTimes = s.ResponseTimes.Next()
-			s.ResponseTimes.Value = ResponseTime{
-				ExecutionTime: endTime,
-				Time:          startTime.UTC(),
-			}
+	s.recordClientType(request)
+	s.recordProtocol(request)
 
-			s.AverageFreeSystemMemory = s.AverageFreeSystemMemory.Next()
-			s.AverageMemoryUsage = s.AverageMemoryUsage.Next()
-
-			memStats := &runtime.MemStats{}
-			runtime.ReadMemStats(memStats)
-
-			var vMemStats *mem.VirtualMemoryStat
-			vMemStats, _ = mem.VirtualMemory()
-
-			s.AverageFreeSystemMemory.Value = vMemStats.Available
-			s.AverageMemoryUsage.Value = memStats.Sys
-
-			status := strconv.Itoa(ctx.Response().Status)
-			s.Statuses[status]++
-
-			if s.customMiddleware != nil {
-				s.customMiddleware(ctx, s)
-			}
-
-			/*
-			 * Find the day and hour, then update the hour structure
-			 */
-			var byDay *StatsByDay
-			var byHour *StatsByHour
-			resetStats := false
-
-			for _, d := range s.StatsByDayCollection {
-				if d.Date.Equal(day) {
-					byDay = d
-					break
-				}
-			}
-
-			if byDay != nil {
-				for _, h := range byDay.HourlyStats {
-					if h.Hour == hour {
-						byHour = h
-						break
-					}
-				}
-
-				if byHour == nil {
-					byHour = NewStatsByHour(hour)
-					byDay.HourlyStats = append(byDay.HourlyStats, byHour)
-
-					resetStats = true
-				}
-			} else {
-				byDay = NewStatsByDay(day)
-				byHour = NewStatsByHour(hour)
-				resetStats = true
-
-				byDay.HourlyStats = append(byDay.HourlyStats, byHour)
-				s.StatsByDayCollection = append(s.StatsByDayCollection, byDay)
-			}
-
-			if byHour != nil {
-				if resetStats {
-					s.RequestCount = 0
-					s.Statuses = make(map[string]int)
-				}
-
-				byHour.Calculate(s)
-			}
-
-			return nil
-		}
+	if len(s.rateHooks) == 0 {
+		return nil
 	}
+
+	return s.checkRateHooks(route, client, now)
 }
 
 /*
-Handler is an endpoint handler you can plug into your application
-to return stat data
+newRing creates a ring of the provided size, falling back to defaultSize
+when size isn't a positive number
 */
-func (s *ServerStats) Handler(ctx echo.Context) error {
-	s.RLock()
-	defer s.RUnlock()
-
-	var averageResponseTime int64
-	var numResponses int64
-	var averageFreeMemory uint64
-	var averageMemoryUsage uint64
-
-	averageResponseTime = 0
-	numResponses = 0
-
-	s.ResponseTimes.Do(func(responseTime interface{}) {
-		if responseTimeDuration, ok := responseTime.(ResponseTime); ok {
-			averageResponseTime += int64(responseTimeDuration.ExecutionTime)
-			numResponses++
-		}
-	})
-
-	if numResponses > 0 {
-		averageResponseTime = averageResponseTime / numResponses
-	}
-
-	averageFreeMemory = 0
-	numResponses = 0
-
-	s.AverageFreeSystemMemory.Do(func(iFreeMemory interface{}) {
-		if freeMemory, ok := iFreeMemory.(uint64); ok {
-			averageFreeMemory += freeMemory
-			numResponses++
-		}
-	})
-
-	if numResponses > 0 {
-		averageFreeMemory = averageFreeMemory / uint64(numResponses)
-	}
-
-	averageMemoryUsage = 0
-	numResponses = 0
-
-	s.AverageMemoryUsage.Do(func(iMemUse interface{}) {
-		if memUse, ok := iMemUse.(uint64); ok {
-			averageMemoryUsage += memUse
-			numResponses++
-		}
-	})
-
-	if numResponses > 0 {
-		averageMemoryUsage = averageMemoryUsage / uint64(numResponses)
-	}
-
-	result := struct {
-		AverageFreeMemory                 uint64                 `json:"averageFreeMemory"`
-		AverageFreeMemoryPretty           string                 `json:"averageFreeMemoryPretty"`
-		AverageMemoryUsage                uint64                 `json:"averageMemoryUsage"`
-		AverageMemoryUsagePretty          string                 `json:"averageMemoryUsagePretty"`
-		AverageResponseTimeInNanoseconds  int64                  `json:"averageResponseTimeInNanoseconds"`
-		AverageResponseTimeInMicroseconds int64                  `json:"averageResponseTimeInMicroseconds"`
-		AverageResponseTimeInMilliseconds int64                  `json:"averageResponseTimeInMilliseconds"`
-		CustomStats                       map[string]interface{} `json:"customStats"`
-		ServerStartTime                   time.Time              `json:"serverStartTime"`
-		RequestCount                      uint64                 `json:"requestCount"`
-		Statuses                          map[string]int         `json:"statuses"`
-	}{
-		AverageFreeMemory:                 averageFreeMemory,
-		AverageFreeMemoryPretty:           humanize.Bytes(averageFreeMemory),
-		AverageMemoryUsage:                averageMemoryUsage,
-		AverageMemoryUsagePretty:          humanize.Bytes(averageMemoryUsage),
-		AverageResponseTimeInNanoseconds:  averageResponseTime,
-		AverageResponseTimeInMicroseconds: averageResponseTime / 1000,
-		AverageResponseTimeInMilliseconds: averageResponseTime / 1000 / 1000,
-		CustomStats:                       s.CustomStats,
-		ServerStartTime:                   s.Uptime,
-		RequestCount:                      s.RequestCount,
-		Statuses:                          s.Statuses,
+func newRing(size, defaultSize int) *ring.Ring {
+	if size <= 0 {
+		size = defaultSize
 	}
 
-	return ctx.JSON(http.StatusOK, result)
+	return ring.New(size)
 }
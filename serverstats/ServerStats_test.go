@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/clock"
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	stats := serverstats.NewServerStats(nil)
+
+	serve(stats, http.MethodGet, "/users", status(http.StatusOK))
+	serve(stats, http.MethodGet, "/users", status(http.StatusOK))
+	serve(stats, http.MethodPost, "/users", status(http.StatusCreated))
+	serve(stats, http.MethodGet, "/missing", status(http.StatusNotFound))
+
+	snapshot := stats.GetSnapshot()
+
+	if snapshot.RequestCount != 4 {
+		t.Errorf("Expected 4 requests but got %d", snapshot.RequestCount)
+	}
+
+	expectedStatuses := map[string]int{"200": 2, "201": 1, "404": 1}
+
+	for code, count := range expectedStatuses {
+		if snapshot.Statuses[code] != count {
+			t.Errorf("Expected %d %s responses but got %d", count, code, snapshot.Statuses[code])
+		}
+	}
+
+	if len(snapshot.Routes) != 3 || snapshot.Routes[0].Route != "GET /missing" || snapshot.Routes[1].Requests != 2 {
+		t.Errorf("Unexpected routes %+v", snapshot.Routes)
+	}
+}
+
+func TestClockWindows(t *testing.T) {
+	fake := clock.NewFake(time.Date(2021, time.June, 1, 9, 0, 0, 0, time.UTC))
+	options := serverstats.DefaultServerStatsOptions()
+	options.Clock = fake
+	stats := serverstats.NewServerStatsWithOptions(options, nil)
+
+	serve(stats, http.MethodGet, "/users", func(w http.ResponseWriter, r *http.Request) {
+		fake.Advance(time.Millisecond * 250)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	snapshot := stats.GetSnapshot()
+
+	if latency := snapshot.LatencyByWindow["1m0s"]; latency.Count != 1 || latency.MeanInMilliseconds != 250 {
+		t.Errorf("Expected one 250ms request in the last minute but got %+v", latency)
+	}
+
+	fake.Advance(time.Minute * 2)
+	snapshot = stats.GetSnapshot()
+
+	if snapshot.LatencyByWindow["1m0s"].Count != 0 || snapshot.LatencyByWindow["5m0s"].Count != 1 {
+		t.Errorf("Expected the request to move out of the one minute window but got %+v", snapshot.LatencyByWindow)
+	}
+
+	if snapshot.UptimeInSeconds != 120 {
+		t.Errorf("Expected 120 seconds of uptime but got %d", snapshot.UptimeInSeconds)
+	}
+}
+
+/*
+serve sends a request through the stats middleware to handler
+*/
+func serve(stats *serverstats.ServerStats, method, path string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	stats.HTTPMiddleware(handler).ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+
+	return recorder
+}
+
+/*
+status returns a handler that responds with statusCode after sleeping
+for delay, if one is given
+*/
+func status(statusCode int, delay ...time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(delay) > 0 {
+			time.Sleep(delay[0])
+		}
+
+		w.WriteHeader(statusCode)
+	}
+}
+
+/*
+waitFor polls condition until it is true, giving up after two seconds
+*/
+func waitFor(condition func() bool) bool {
+	deadline := time.Now().Add(time.Second * 2)
+
+	for time.Now().Before(deadline) {
+		if condition() {
+			return true
+		}
+
+		time.Sleep(time.Millisecond * 5)
+	}
+
+	return condition()
+}
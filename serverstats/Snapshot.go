@@ -0,0 +1,190 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+/*
+Snapshot is a point-in-time report of server statistics. This is
+what Handler returns as JSON
+*/
+type Snapshot struct {
+	AverageFreeMemory                 uint64                            `json:"averageFreeMemory"`
+	AverageFreeMemoryPretty           string                            `json:"averageFreeMemoryPretty"`
+	AverageMemoryUsage                uint64                            `json:"averageMemoryUsage"`
+	AverageMemoryUsagePretty          string                            `json:"averageMemoryUsagePretty"`
+	AverageResponseTimeInNanoseconds  int64                             `json:"averageResponseTimeInNanoseconds"`
+	AverageResponseTimeInMicroseconds int64                             `json:"averageResponseTimeInMicroseconds"`
+	AverageResponseTimeInMilliseconds int64                             `json:"averageResponseTimeInMilliseconds"`
+	Build                             BuildInfo                         `json:"build"`
+	Clients                           []ClientStats                     `json:"clients,omitempty"`
+	ClientTypes                       map[string]uint64                 `json:"clientTypes,omitempty"`
+	Collectors                        map[string]map[string]interface{} `json:"collectors,omitempty"`
+	Connections                       *ConnectionStats                  `json:"connections,omitempty"`
+	CustomStats                       map[string]interface{}            `json:"customStats"`
+	Databases                         map[string]DBPoolStats            `json:"databases,omitempty"`
+	DecayingAverages                  DecayingAverages                  `json:"decayingAverages"`
+	ErrorHotSpots                     []ErrorHotSpot                    `json:"errorHotSpots"`
+	Errors                            ErrorStats                        `json:"errors"`
+	Events                            []Event                           `json:"events"`
+	Hosts                             map[string]HostStats              `json:"hosts,omitempty"`
+	Jobs                              map[string]JobStats               `json:"jobs,omitempty"`
+	LatencyBands                      []LatencyBand                     `json:"latencyBands,omitempty"`
+	LatencyByWindow                   map[string]LatencyStats           `json:"latencyByWindow"`
+	Lifecycle                         *LifecycleReport                  `json:"lifecycle,omitempty"`
+	LongRunningRequests               []InFlightRequest                 `json:"longRunningRequests,omitempty"`
+	MemoryLeak                        *MemoryLeakReport                 `json:"memoryLeak,omitempty"`
+	Overflow                          *OverflowStats                    `json:"overflow,omitempty"`
+	ServerStartTime                   time.Time                         `json:"serverStartTime"`
+	RequestCount                      uint64                            `json:"requestCount"`
+	Routes                            []RouteStats                      `json:"routes"`
+	SLO                               *SLOReport                        `json:"slo,omitempty"`
+	Statuses                          map[string]int                    `json:"statuses"`
+	StatusesByRoute                   map[string]map[string]int         `json:"statusesByRoute"`
+	UptimeInSeconds                   int64                             `json:"uptimeInSeconds"`
+	UptimePretty                      string                            `json:"uptimePretty"`
+}
+
+/*
+GetSnapshot calculates and returns a snapshot of the current server
+statistics. Maps in the snapshot are copies, so it can be used after
+requests have moved the stats on
+*/
+func (s *ServerStats) GetSnapshot() Snapshot {
+	s.RLock()
+	defer s.RUnlock()
+
+	var averageResponseTime int64
+	var numResponses int64
+	var averageFreeMemory uint64
+	var averageMemoryUsage uint64
+
+	averageResponseTime = 0
+	numResponses = 0
+
+	s.ResponseTimes.Do(func(responseTime interface{}) {
+		if responseTimeDuration, ok := responseTime.(ResponseTime); ok {
+			averageResponseTime += int64(responseTimeDuration.ExecutionTime)
+			numResponses++
+		}
+	})
+
+	if numResponses > 0 {
+		averageResponseTime = averageResponseTime / numResponses
+	}
+
+	averageFreeMemory = 0
+	numResponses = 0
+
+	s.AverageFreeSystemMemory.Do(func(iFreeMemory interface{}) {
+		if freeMemory, ok := iFreeMemory.(uint64); ok {
+			averageFreeMemory += freeMemory
+			numResponses++
+		}
+	})
+
+	if numResponses > 0 {
+		averageFreeMemory = averageFreeMemory / uint64(numResponses)
+	}
+
+	averageMemoryUsage = 0
+	numResponses = 0
+
+	s.AverageMemoryUsage.Do(func(iMemUse interface{}) {
+		if memUse, ok := iMemUse.(uint64); ok {
+			averageMemoryUsage += memUse
+			numResponses++
+		}
+	})
+
+	if numResponses > 0 {
+		averageMemoryUsage = averageMemoryUsage / uint64(numResponses)
+	}
+
+	var clients []ClientStats
+
+	now := s.clock.Now().UTC()
+	uptime := now.Sub(s.Uptime)
+	latencyByWindow, latencyByRoute := s.getLatencyByWindow(now)
+
+	if s.clientIdentifier != nil {
+		clients = s.clients.top(s.numTopClients)
+	}
+
+	return Snapshot{
+		AverageFreeMemory:                 averageFreeMemory,
+		AverageFreeMemoryPretty:           humanize.Bytes(averageFreeMemory),
+		AverageMemoryUsage:                averageMemoryUsage,
+		AverageMemoryUsagePretty:          humanize.Bytes(averageMemoryUsage),
+		AverageResponseTimeInNanoseconds:  averageResponseTime,
+		AverageResponseTimeInMicroseconds: averageResponseTime / 1000,
+		AverageResponseTimeInMilliseconds: averageResponseTime / 1000 / 1000,
+		Build:                             s.buildInfo,
+		Clients:                           clients,
+		ClientTypes:                       s.getClientTypes(),
+		Collectors:                        copyCollectedStats(s.collectedStats),
+		Connections:                       s.getConnectionStats(),
+		CustomStats:                       copyCustomStats(s.CustomStats),
+		Databases:                         s.getDatabaseStats(),
+		DecayingAverages:                  s.getDecayingAverages(),
+		ErrorHotSpots:                     s.getErrorHotSpots(now),
+		Errors:                            s.getErrorStats(),
+		Events:                            s.getEvents(),
+		Hosts:                             s.getHostStats(),
+		Jobs:                              s.getJobStats(),
+		LatencyBands:                      s.latencyBands.report(),
+		LatencyByWindow:                   latencyByWindow,
+		Lifecycle:                         s.getLifecycleSnapshot(now),
+		LongRunningRequests:               s.getLongRunningRequests(now),
+		MemoryLeak:                        s.getMemoryLeakReport(),
+		Overflow:                          s.getOverflowStats(),
+		ServerStartTime:                   s.Uptime,
+		RequestCount:                      s.RequestCount,
+		Routes:                            s.getRouteStats(latencyByRoute),
+		SLO:                               s.sloReport,
+		Statuses:                          copyStatuses(s.Statuses),
+		StatusesByRoute:                   s.getStatusesByRoute(),
+		UptimeInSeconds:                   int64(uptime / time.Second),
+		UptimePretty:                      formatUptime(uptime),
+	}
+}
+
+func copyStatuses(statuses map[string]int) map[string]int {
+	result := make(map[string]int, len(statuses))
+
+	for status, count := range statuses {
+		result[status] = count
+	}
+
+	return result
+}
+
+func copyCustomStats(customStats map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(customStats))
+
+	for name, value := range customStats {
+		result[name] = value
+	}
+
+	return result
+}
+
+func copyCollectedStats(collectedStats map[string]map[string]interface{}) map[string]map[string]interface{} {
+	if collectedStats == nil {
+		return nil
+	}
+
+	result := make(map[string]map[string]interface{}, len(collectedStats))
+
+	for name, values := range collectedStats {
+		result[name] = copyCustomStats(values)
+	}
+
+	return result
+}
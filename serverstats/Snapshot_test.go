@@ -0,0 +1,47 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestGetSnapshotCopiesMaps(t *testing.T) {
+	stats := serverstats.NewServerStats(nil)
+	serve(stats, http.MethodGet, "/", status(http.StatusOK))
+	stats.SetCustomStat("deploys", 1)
+
+	snapshot := stats.GetSnapshot()
+	wg := &sync.WaitGroup{}
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for index := 0; index < 100; index++ {
+			serve(stats, http.MethodGet, "/", status(http.StatusOK+index%5))
+			stats.SetCustomStat("counter"+strconv.Itoa(index%10), index)
+		}
+	}()
+
+	for index := 0; index < 20; index++ {
+		if _, err := json.Marshal(snapshot); err != nil {
+			t.Fatalf("Expected no error but got '%s'", err)
+		}
+	}
+
+	wg.Wait()
+
+	if snapshot.Statuses["200"] != 1 || len(snapshot.Statuses) != 1 || len(snapshot.CustomStats) != 1 {
+		t.Errorf("Expected the snapshot to be unchanged by later requests but got %v and %v", snapshot.Statuses, snapshot.CustomStats)
+	}
+}
@@ -0,0 +1,217 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/requestid"
+)
+
+const (
+	// RequestIDHeader is the header used to read and write request IDs
+	RequestIDHeader = requestid.Header
+
+	// RequestIDContextKey is the Echo context key the request ID is stored under
+	RequestIDContextKey = "requestID"
+)
+
+type contextKey string
+
+const (
+	traceKey contextKey = "serverstats.trace"
+)
+
+/*
+Trace is a lightweight record of a single request, including any spans
+marked by handlers with StartSpan
+*/
+type Trace struct {
+	Duration  time.Duration `json:"duration"`
+	Method    string        `json:"method"`
+	Path      string        `json:"path"`
+	RequestID string        `json:"requestID"`
+	Route     string        `json:"route"`
+	Spans     []Span        `json:"spans"`
+	Start     time.Time     `json:"start"`
+	Status    int           `json:"status"`
+
+	mutex sync.Mutex
+}
+
+/*
+Span is a named portion of a request. Offset is how long after the
+start of the request the span began
+*/
+type Span struct {
+	Duration time.Duration `json:"duration"`
+	Name     string        `json:"name"`
+	Offset   time.Duration `json:"offset"`
+}
+
+/*
+StartSpanContext marks the start of a named span in the trace of the
+request the context belongs to. Call the returned function when the
+span is done. If tracing is not enabled this does nothing.
+
+	func handler(w http.ResponseWriter, r *http.Request) {
+		endSpan := serverstats.StartSpanContext(r.Context(), "load customer")
+		customer, err := loadCustomer()
+		endSpan()
+		...
+	}
+*/
+func StartSpanContext(ctx context.Context, name string) func() {
+	trace, ok := ctx.Value(traceKey).(*Trace)
+
+	if !ok {
+		return func() {}
+	}
+
+	start := time.Now()
+
+	return func() {
+		trace.mutex.Lock()
+		defer trace.mutex.Unlock()
+
+		trace.Spans = append(trace.Spans, Span{
+			Duration: time.Since(start),
+			Name:     name,
+			Offset:   start.Sub(trace.Start),
+		})
+	}
+}
+
+/*
+RequestIDFromContext returns the ID assigned to the request the context
+belongs to, or an empty string if neither tracing nor the requestid
+middleware is enabled
+*/
+func RequestIDFromContext(ctx context.Context) string {
+	return requestid.FromContext(ctx)
+}
+
+/*
+SlowTracesHTTPHandler is a net/http handler that returns the most recent
+slow request traces, newest first
+*/
+func (s *ServerStats) SlowTracesHTTPHandler(w http.ResponseWriter, r *http.Request) {
+	if !authorize(s.handlerAuth, w, r) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.GetSlowTraces())
+}
+
+/*
+GetSlowTraces returns copies of the most recent slow request traces,
+newest first
+*/
+func (s *ServerStats) GetSlowTraces() []*Trace {
+	s.RLock()
+	defer s.RUnlock()
+
+	result := make([]*Trace, 0, s.slowTraces.Len())
+	r := s.slowTraces
+
+	for index := 0; index < r.Len(); index++ {
+		if trace, ok := r.Value.(*Trace); ok {
+			result = append(result, trace.copy())
+		}
+
+		r = r.Prev()
+	}
+
+	return result
+}
+
+/*
+copy returns a copy of the trace. Spans can still be added after a
+trace is kept, such as by a goroutine the handler started, so the copy
+is taken under the trace's lock
+*/
+func (t *Trace) copy() *Trace {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return &Trace{
+		Duration:  t.Duration,
+		Method:    t.Method,
+		Path:      t.Path,
+		RequestID: t.RequestID,
+		Route:     t.Route,
+		Spans:     append(make([]Span, 0, len(t.Spans)), t.Spans...),
+		Start:     t.Start,
+		Status:    t.Status,
+	}
+}
+
+/*
+startTrace assigns the request an ID, keeping one the requestid
+middleware already assigned or honoring a valid incoming X-Request-ID
+header, and returns the request with a new trace attached to its
+context. This does nothing unless a slow trace threshold is configured
+*/
+func (s *ServerStats) startTrace(w http.ResponseWriter, r *http.Request, startTime time.Time) *http.Request {
+	if s.slowTraceThreshold <= 0 {
+		return r
+	}
+
+	requestID := requestid.FromContext(r.Context())
+
+	if requestID == "" && requestid.Valid(r.Header.Get(RequestIDHeader)) {
+		requestID = r.Header.Get(RequestIDHeader)
+	}
+
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+
+	w.Header().Set(RequestIDHeader, requestID)
+
+	ctx := requestid.NewContext(r.Context(), requestID)
+	ctx = context.WithValue(ctx, traceKey, &Trace{
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		RequestID: requestID,
+		Spans:     make([]Span, 0),
+		Start:     startTime,
+	})
+
+	return r.WithContext(ctx)
+}
+
+/*
+recordTrace keeps the request's trace if it took longer than the slow
+trace threshold. The caller must hold a write lock
+*/
+func (s *ServerStats) recordTrace(request *http.Request, path string, status int, executionTime time.Duration) {
+	trace, ok := request.Context().Value(traceKey).(*Trace)
+
+	if !ok || executionTime < s.slowTraceThreshold {
+		return
+	}
+
+	trace.mutex.Lock()
+	trace.Duration = executionTime
+	trace.Route = path
+	trace.Status = status
+	trace.mutex.Unlock()
+
+	s.slowTraces = s.slowTraces.Next()
+	s.slowTraces.Value = trace
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestSlowTraces(t *testing.T) {
+	options := serverstats.DefaultServerStatsOptions()
+	options.SlowTraceThreshold = time.Millisecond * 20
+
+	stats := serverstats.NewServerStatsWithOptions(options, nil)
+	lateSpan := make(chan func())
+
+	handler := stats.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fast" {
+			return
+		}
+
+		endSpan := serverstats.StartSpanContext(r.Context(), "load customer")
+		time.Sleep(time.Millisecond * 30)
+		endSpan()
+
+		lateSpan <- serverstats.StartSpanContext(r.Context(), "send email")
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "/slow", nil)
+	request.Header.Set(serverstats.RequestIDHeader, "request-1")
+	recorder := httptest.NewRecorder()
+
+	go handler.ServeHTTP(recorder, request)
+	endLateSpan := <-lateSpan
+
+	fast := httptest.NewRecorder()
+	handler.ServeHTTP(fast, httptest.NewRequest(http.MethodGet, "/fast", nil))
+
+	if !waitFor(func() bool { return len(stats.GetSlowTraces()) == 1 }) {
+		t.Fatalf("Expected 1 slow trace but got %d", len(stats.GetSlowTraces()))
+	}
+
+	// Spans ended after the request finished must not race with readers of the trace
+	go endLateSpan()
+
+	if _, err := json.Marshal(stats.GetSlowTraces()); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	trace := stats.GetSlowTraces()[0]
+
+	if trace.RequestID != "request-1" || trace.Method != http.MethodGet || trace.Path != "/slow" || trace.Status != http.StatusOK {
+		t.Errorf("Unexpected trace %+v", trace)
+	}
+
+	if len(trace.Spans) == 0 || trace.Spans[0].Name != "load customer" || trace.Spans[0].Duration < time.Millisecond*30 {
+		t.Errorf("Expected the trace to have the handler's span but got %+v", trace.Spans)
+	}
+
+	if id := fast.Header().Get(serverstats.RequestIDHeader); len(id) != 32 {
+		t.Errorf("Expected a generated request ID but got '%s'", id)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+/*
+TrackedRequest is a request being tracked by ServerStats, and is the
+building block for router adapters. An adapter calls BeginRequest when a
+request arrives and hands Request to the handler, as it carries the
+trace and request ID. It defers EndOnPanic, calls Run to invoke the
+handler, and calls End once the response has been written. If the
+handler replaces the request, set Request to the new one before calling
+End.
+
+The Echo and net/http middlewares in this package are built this way,
+and the same few lines adapt ServerStats to other routers
+*/
+type TrackedRequest struct {
+	Request *http.Request
+
+	ended        bool
+	path         string
+	startTime    time.Time
+	stats        *ServerStats
+	stopWatchdog func()
+}
+
+/*
+BeginRequest starts tracking a request. path is the route pattern the
+request matched, such as "/users/:id". An empty path means the request
+didn't match a route
+*/
+func (s *ServerStats) BeginRequest(w http.ResponseWriter, r *http.Request, path string) *TrackedRequest {
+	startTime := s.clock.Now()
+	r = s.startTrace(w, r, startTime)
+
+	return &TrackedRequest{
+		Request:      r,
+		path:         path,
+		startTime:    startTime,
+		stats:        s,
+		stopWatchdog: s.startWatchdog(r, routeName(r.Method, path), startTime),
+	}
+}
+
+/*
+Run calls the handler and records any error it returns. When panic
+recovery is enabled a panic is recovered and returned as an error, so
+the adapter can respond with a 500
+*/
+func (t *TrackedRequest) Run(handler func() error) error {
+	return t.stats.callNext(t.Request, t.path, handler)
+}
+
+/*
+End records the finished request. status is the status code written to
+the client, and err is the error returned by Run. Only the first call
+has any effect
+*/
+func (t *TrackedRequest) End(status int, err error) {
+	if t.ended {
+		return
+	}
+
+	t.ended = true
+	t.stopWatchdog()
+	executionTime := t.stats.clock.Since(t.startTime)
+
+	t.stats.Lock()
+	fired := t.stats.recordRequest(t.Request, t.path, status, t.startTime, executionTime, err)
+	t.stats.Unlock()
+
+	for _, hook := range fired {
+		hook.onExceeded(hook.event)
+	}
+}
+
+/*
+EndOnPanic ends the request with a 500 when a panic escapes Run, which
+happens when panic recovery is disabled, and then panics again so the
+panic carries on up the stack. Defer it right after BeginRequest, so a
+panic recovered further up doesn't leave the request in flight or let
+OnLongRequest fire for it later:
+
+	tracked := serverStats.BeginRequest(w, r, path)
+	defer tracked.EndOnPanic()
+*/
+func (t *TrackedRequest) EndOnPanic() {
+	r := recover()
+
+	if r == nil {
+		return
+	}
+
+	if !t.ended {
+		err := fmt.Errorf("panic: %v", r)
+
+		t.stats.recordError(t.Request, t.path, err, true, stackDigest(3))
+		t.End(http.StatusInternalServerError, err)
+	}
+
+	panic(r)
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"net/http"
+	"sort"
+	"time"
+)
+
+/*
+InFlightRequest describes a request that is still being handled
+*/
+type InFlightRequest struct {
+	ElapsedInMilliseconds float64   `json:"elapsedInMilliseconds"`
+	Method                string    `json:"method"`
+	RequestID             string    `json:"requestID,omitempty"`
+	Route                 string    `json:"route"`
+	StartTime             time.Time `json:"startTime"`
+}
+
+type inFlightRequest struct {
+	method    string
+	requestID string
+	route     string
+	startTime time.Time
+}
+
+func (r *inFlightRequest) report(now time.Time) InFlightRequest {
+	return InFlightRequest{
+		ElapsedInMilliseconds: toMilliseconds(now.Sub(r.startTime)),
+		Method:                r.method,
+		RequestID:             r.requestID,
+		Route:                 r.route,
+		StartTime:             r.startTime.UTC(),
+	}
+}
+
+/*
+LongRunningRequestsHTTPHandler is a net/http handler that returns the
+requests that have been in flight longer than LongRequestThreshold,
+oldest first
+*/
+func (s *ServerStats) LongRunningRequestsHTTPHandler(w http.ResponseWriter, r *http.Request) {
+	if !authorize(s.handlerAuth, w, r) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.GetLongRunningRequests())
+}
+
+/*
+GetLongRunningRequests returns the requests that have been in flight
+longer than LongRequestThreshold, oldest first
+*/
+func (s *ServerStats) GetLongRunningRequests() []InFlightRequest {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.getLongRunningRequests(s.clock.Now())
+}
+
+/*
+startWatchdog starts tracking a request as in flight and returns a
+function that must be called when the request finishes. If the request
+is still running after LongRequestThreshold, OnLongRequest is called
+once for it. This does nothing unless a threshold is configured
+*/
+func (s *ServerStats) startWatchdog(r *http.Request, route string, startTime time.Time) func() {
+	if s.longRequestThreshold <= 0 {
+		return func() {}
+	}
+
+	requestID := RequestIDFromContext(r.Context())
+
+	if requestID == "" {
+		requestID = r.Header.Get(RequestIDHeader)
+	}
+
+	request := &inFlightRequest{
+		method:    r.Method,
+		requestID: requestID,
+		route:     route,
+		startTime: startTime,
+	}
+
+	s.Lock()
+	s.inFlightSequence++
+	id := s.inFlightSequence
+	s.inFlight[id] = request
+	s.Unlock()
+
+	var timer *time.Timer
+
+	if s.onLongRequest != nil {
+		timer = time.AfterFunc(s.longRequestThreshold, func() {
+			s.onLongRequest(request.report(s.clock.Now()))
+		})
+	}
+
+	return func() {
+		if timer != nil {
+			timer.Stop()
+		}
+
+		s.Lock()
+		delete(s.inFlight, id)
+		s.Unlock()
+	}
+}
+
+/*
+getLongRunningRequests returns in-flight requests older than the
+threshold, oldest first. The caller must hold a lock
+*/
+func (s *ServerStats) getLongRunningRequests(now time.Time) []InFlightRequest {
+	result := make([]InFlightRequest, 0)
+
+	for _, request := range s.inFlight {
+		if now.Sub(request.startTime) >= s.longRequestThreshold {
+			result = append(result, request.report(now))
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StartTime.Before(result[j].StartTime)
+	})
+
+	return result
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats_test
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+)
+
+func TestLongRunningRequests(t *testing.T) {
+	var calls int32
+
+	options := serverstats.DefaultServerStatsOptions()
+	options.LongRequestThreshold = time.Millisecond * 30
+	options.OnLongRequest = func(request serverstats.InFlightRequest) {
+		if request.Route == "GET /reports" {
+			atomic.AddInt32(&calls, 1)
+		}
+	}
+
+	stats := serverstats.NewServerStatsWithOptions(options, nil)
+	done := make(chan struct{})
+
+	go func() {
+		serve(stats, http.MethodGet, "/reports", status(http.StatusOK, time.Millisecond*150))
+		close(done)
+	}()
+
+	listed := waitFor(func() bool {
+		requests := stats.GetLongRunningRequests()
+		return len(requests) == 1 && requests[0].Route == "GET /reports"
+	})
+
+	if !listed {
+		t.Errorf("Expected the long request to be listed")
+	}
+
+	<-done
+
+	if requests := stats.GetLongRunningRequests(); len(requests) != 0 {
+		t.Errorf("Expected no long requests after it finished but got %+v", requests)
+	}
+
+	if calls := atomic.LoadInt32(&calls); calls != 1 {
+		t.Errorf("Expected OnLongRequest to be called once but got %d", calls)
+	}
+}
+
+func TestLongRunningRequestsPanic(t *testing.T) {
+	var calls int32
+
+	options := serverstats.DefaultServerStatsOptions()
+	options.LongRequestThreshold = time.Millisecond * 30
+	options.RecoverPanics = false
+	options.OnLongRequest = func(request serverstats.InFlightRequest) {
+		atomic.AddInt32(&calls, 1)
+	}
+
+	stats := serverstats.NewServerStatsWithOptions(options, nil)
+
+	recovered := func() (r interface{}) {
+		defer func() {
+			r = recover()
+		}()
+
+		serve(stats, http.MethodGet, "/explode", func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})
+
+		return nil
+	}()
+
+	if recovered != "boom" {
+		t.Errorf("Expected the panic to reach the outer recover but got %v", recovered)
+	}
+
+	time.Sleep(time.Millisecond * 60)
+
+	if requests := stats.GetLongRunningRequests(); len(requests) != 0 {
+		t.Errorf("Expected no long requests after a panic but got %+v", requests)
+	}
+
+	if calls := atomic.LoadInt32(&calls); calls != 0 {
+		t.Errorf("Expected OnLongRequest not to be called but got %d calls", calls)
+	}
+
+	snapshot := stats.GetSnapshot()
+
+	if snapshot.Statuses["500"] != 1 || snapshot.Errors.Panics != 1 {
+		t.Errorf("Expected the panic to be recorded as a 500 but got %v and %d panics", snapshot.Statuses, snapshot.Errors.Panics)
+	}
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package serverstats
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+/*
+responseTimesSince returns all tracked response times that started on
+or after the provided time. When latency sampling is on these come from
+the sampled reservoirs instead of the response time ring. The caller
+must hold a lock
+*/
+func (s *ServerStats) responseTimesSince(since time.Time) []ResponseTime {
+	if s.latencyReservoir != nil {
+		return s.latencyReservoir.since(since)
+	}
+
+	result := make([]ResponseTime, 0, 100)
+
+	s.ResponseTimes.Do(func(r interface{}) {
+		if responseTime, ok := r.(ResponseTime); ok && !responseTime.Time.Before(since) {
+			result = append(result, responseTime)
+		}
+	})
+
+	return result
+}
+
+/*
+percentile returns the requested percentile (0-100) of a set of response
+times using the nearest-rank method. Sampled response times count for
+as many requests as they stand in for
+*/
+func percentile(responseTimes []ResponseTime, p float64) time.Duration {
+	if len(responseTimes) == 0 {
+		return 0
+	}
+
+	sorted := make([]ResponseTime, len(responseTimes))
+	copy(sorted, responseTimes)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ExecutionTime < sorted[j].ExecutionTime
+	})
+
+	rank := math.Ceil(p / 100 * totalWeight(sorted))
+	cumulative := 0.0
+
+	for _, responseTime := range sorted {
+		cumulative += responseTime.sampleWeight()
+
+		if cumulative >= rank {
+			return responseTime.ExecutionTime
+		}
+	}
+
+	return sorted[len(sorted)-1].ExecutionTime
+}
+
+/*
+averageResponseTime returns the mean of a set of response times
+*/
+func averageResponseTime(responseTimes []ResponseTime) time.Duration {
+	var total float64
+
+	weight := totalWeight(responseTimes)
+
+	if weight == 0 {
+		return 0
+	}
+
+	for _, responseTime := range responseTimes {
+		total += float64(responseTime.ExecutionTime) * responseTime.sampleWeight()
+	}
+
+	return time.Duration(total / weight)
+}
+
+/*
+errorRate returns the percentage (0-100) of response times with a 5xx status
+*/
+func errorRate(responseTimes []ResponseTime) float64 {
+	if len(responseTimes) == 0 {
+		return 0
+	}
+
+	errors := 0.0
+
+	for _, responseTime := range responseTimes {
+		if responseTime.Status >= 500 {
+			errors += responseTime.sampleWeight()
+		}
+	}
+
+	return errors / totalWeight(responseTimes) * 100
+}
+
+func totalWeight(responseTimes []ResponseTime) float64 {
+	result := 0.0
+
+	for _, responseTime := range responseTimes {
+		result += responseTime.sampleWeight()
+	}
+
+	return result
+}
@@ -0,0 +1,72 @@
+//go:build echov3
+// +build echov3
+
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+/*
+Package echov3 adapts ServerStats to Echo v3 (github.com/labstack/echo),
+for services that haven't moved to Echo v4 yet. It is only built with
+the echov3 build tag, so the kit doesn't depend on Echo v3. Services
+using it build with -tags echov3 and require github.com/labstack/echo
+in their own go.mod
+*/
+package echov3
+
+import (
+	"net/http"
+
+	"github.com/ResurgenceIT/kit/v6/serverstats"
+	"github.com/labstack/echo"
+)
+
+/*
+Middleware captures request and response stats, grouping requests by
+their Echo route pattern. Errors are handed to Echo's error handler so
+the response status is known before the request is recorded. The
+custom middleware passed to NewServerStats takes an Echo v4 context, so
+it isn't run
+*/
+func Middleware(serverStats *serverstats.ServerStats) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			tracked := serverStats.BeginRequest(ctx.Response(), ctx.Request(), ctx.Path())
+			defer tracked.EndOnPanic()
+
+			ctx.SetRequest(tracked.Request)
+
+			if requestID := serverstats.RequestIDFromContext(tracked.Request.Context()); requestID != "" {
+				ctx.Set(serverstats.RequestIDContextKey, requestID)
+			}
+
+			err := tracked.Run(func() error {
+				return next(ctx)
+			})
+
+			if err != nil {
+				ctx.Error(err)
+			}
+
+			tracked.Request = ctx.Request()
+			tracked.End(ctx.Response().Status, err)
+			return nil
+		}
+	}
+}
+
+/*
+Handler returns stat data, negotiating JSON, text, or HTML like
+ServerStats.Handler
+*/
+func Handler(serverStats *serverstats.ServerStats) echo.HandlerFunc {
+	return echo.WrapHandler(serverStats)
+}
+
+/*
+Wrap adapts any of the net/http stats handlers, such as
+ExportHTTPHandler or MetricsHTTPHandler, to an Echo v3 handler
+*/
+func Wrap(handler http.HandlerFunc) echo.HandlerFunc {
+	return echo.WrapHandler(handler)
+}
@@ -0,0 +1,211 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package slug
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	ErrEmptySlug    = errors.New("text has nothing to make a slug from")
+	ErrNoUniqueSlug = errors.New("no unique slug found")
+)
+
+/*
+Exists reports whether a slug is already taken, usually by querying a
+database
+*/
+type Exists func(ctx context.Context, slug string) (bool, error)
+
+// transliterations are letters that don't decompose into a base letter and accents
+var transliterations = map[rune]string{
+	'Æ': "AE", 'æ': "ae", 'Ð': "D", 'ð': "d", 'Đ': "D", 'đ': "d", 'Ħ': "H", 'ħ': "h",
+	'ı': "i", 'Ł': "L", 'ł': "l", 'Ø': "O", 'ø': "o", 'Œ': "OE", 'œ': "oe", 'ß': "ss",
+	'Þ': "TH", 'þ': "th",
+}
+
+/*
+Slugger makes URL slugs from text, such as "crème-brûlée" from "Crème
+Brûlée!". It is safe for concurrent use
+*/
+type Slugger struct {
+	config       SluggerConfig
+	replacements []string
+}
+
+var defaultSlugger = NewSlugger(SluggerConfig{})
+
+/*
+NewSlugger creates a Slugger, filling in defaults for anything not set
+in config
+*/
+func NewSlugger(config SluggerConfig) *Slugger {
+	if config.Separator == "" {
+		config.Separator = "-"
+	}
+
+	if config.MaxLength <= 0 {
+		config.MaxLength = 100
+	}
+
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 100
+	}
+
+	// Replace longer strings first, so "&&" wins over "&"
+	keys := make([]string, 0, len(config.Replacements))
+
+	for key := range config.Replacements {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+
+		return keys[i] < keys[j]
+	})
+
+	replacements := make([]string, 0, len(keys)*2)
+
+	for _, key := range keys {
+		replacements = append(replacements, key, " "+config.Replacements[key]+" ")
+	}
+
+	return &Slugger{
+		config:       config,
+		replacements: replacements,
+	}
+}
+
+/*
+Make returns a slug with the default Slugger
+*/
+func Make(text string) string {
+	return defaultSlugger.Make(text)
+}
+
+/*
+Make returns a lowercase slug of ASCII letters and digits, with words
+separated. Accents are removed and letters such as ß and ø are spelled
+out. Letters with no ASCII form, such as in Chinese or Cyrillic, are
+dropped, so the slug may be empty
+*/
+func (s *Slugger) Make(text string) string {
+	if len(s.replacements) > 0 {
+		text = strings.NewReplacer(s.replacements...).Replace(text)
+	}
+
+	words := []string{}
+	word := strings.Builder{}
+
+	endWord := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range norm.NFKD.String(text) {
+		if spelled, ok := transliterations[r]; ok {
+			word.WriteString(strings.ToLower(spelled))
+			continue
+		}
+
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Accents left by decomposing, such as the one on é
+
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word.WriteRune(unicode.ToLower(r))
+
+		case r == '\'' || r == '’':
+			// Apostrophes join, so "don't" is "dont"
+
+		default:
+			endWord()
+		}
+	}
+
+	endWord()
+	return s.truncate(words, s.config.MaxLength)
+}
+
+/*
+Unique returns a slug that exists says isn't taken, trying the slug,
+then adding "-2", "-3", and so on
+*/
+func (s *Slugger) Unique(ctx context.Context, text string, exists Exists) (string, error) {
+	base := s.Make(text)
+
+	if base == "" {
+		return "", ErrEmptySlug
+	}
+
+	for attempt := 1; attempt <= s.config.MaxAttempts; attempt++ {
+		candidate := base
+
+		if attempt > 1 {
+			suffix := s.config.Separator + strconv.Itoa(attempt)
+			candidate = s.truncate(strings.Split(base, s.config.Separator), s.config.MaxLength-len(suffix)) + suffix
+		}
+
+		taken, err := exists(ctx, candidate)
+
+		if err != nil {
+			return "", fmt.Errorf("error checking slug %s: %w", candidate, err)
+		}
+
+		if !taken {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w for %q after %d attempts", ErrNoUniqueSlug, base, s.config.MaxAttempts)
+}
+
+/*
+Unique returns a unique slug with the default Slugger
+*/
+func Unique(ctx context.Context, text string, exists Exists) (string, error) {
+	return defaultSlugger.Unique(ctx, text, exists)
+}
+
+/*
+truncate joins words up to a length, cutting the first word when it is
+too long on its own
+*/
+func (s *Slugger) truncate(words []string, length int) string {
+	result := ""
+
+	for _, word := range words {
+		next := word
+
+		if result != "" {
+			next = result + s.config.Separator + word
+		}
+
+		if len(next) > length {
+			if result == "" && length > 0 {
+				return word[:length]
+			}
+
+			break
+		}
+
+		result = next
+	}
+
+	return result
+}
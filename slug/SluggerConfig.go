@@ -0,0 +1,20 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package slug
+
+/*
+SluggerConfig configures a Slugger. Separator goes between words, and
+defaults to "-". MaxLength limits slugs, suffix included, to a number of
+characters, cutting at a word where it can. It defaults to 100.
+MaxAttempts is how many numbered suffixes Unique tries, and defaults to
+100. Replacements are applied before transliterating, such as
+{"&": "and"}
+*/
+type SluggerConfig struct {
+	MaxAttempts  int
+	MaxLength    int
+	Replacements map[string]string
+	Separator    string
+}
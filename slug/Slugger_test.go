@@ -0,0 +1,101 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package slug_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/slug"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "Crème Brûlée!", want: "creme-brulee"},
+		{input: "Ærøskøbing Straße", want: "aeroskobing-strasse"},
+		{input: "Don't Stop Me Now", want: "dont-stop-me-now"},
+		{input: "  --Hello__World--  ", want: "hello-world"},
+		{input: "Łódź in 2021", want: "lodz-in-2021"},
+		{input: "ﬁne print", want: "fine-print"},
+		{input: "日本語", want: ""},
+	}
+
+	for _, test := range tests {
+		if got := slug.Make(test.input); got != test.want {
+			t.Errorf("Expected %s for %q but got %s", test.want, test.input, got)
+		}
+	}
+}
+
+func TestSluggerConfig(t *testing.T) {
+	slugger := slug.NewSlugger(slug.SluggerConfig{
+		MaxLength:    16,
+		Replacements: map[string]string{"&": "and", "+": "plus"},
+		Separator:    "_",
+	})
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "Salt & Pepper", want: "salt_and_pepper"},
+		{input: "C++ & Go", want: "c_plus_plus_and"},
+		{input: "Supercalifragilistic", want: "supercalifragili"},
+	}
+
+	for _, test := range tests {
+		if got := slugger.Make(test.input); got != test.want {
+			t.Errorf("Expected %s for %q but got %s", test.want, test.input, got)
+		}
+	}
+}
+
+func TestUnique(t *testing.T) {
+	taken := map[string]bool{"hello-world": true, "hello-world-2": true}
+
+	exists := func(ctx context.Context, s string) (bool, error) {
+		return taken[s], nil
+	}
+
+	got, err := slug.Unique(context.Background(), "Hello, World", exists)
+
+	if err != nil || got != "hello-world-3" {
+		t.Errorf("Expected hello-world-3 but got %s, %v", got, err)
+	}
+
+	long := strings.Repeat("word ", 30)
+	slugger := slug.NewSlugger(slug.SluggerConfig{MaxAttempts: 3, MaxLength: 20})
+
+	got, err = slugger.Unique(context.Background(), long, func(ctx context.Context, s string) (bool, error) {
+		if len(s) > 20 {
+			t.Errorf("Expected at most 20 characters but got %s", s)
+		}
+
+		return s != "word-word-word-2", nil
+	})
+
+	if err != nil || got != "word-word-word-2" {
+		t.Errorf("Expected word-word-word-2 but got %s, %v", got, err)
+	}
+
+	if _, err = slugger.Unique(context.Background(), long, func(ctx context.Context, s string) (bool, error) { return true, nil }); !errors.Is(err, slug.ErrNoUniqueSlug) {
+		t.Errorf("Expected ErrNoUniqueSlug but got %v", err)
+	}
+
+	if _, err = slug.Unique(context.Background(), "!!!", exists); !errors.Is(err, slug.ErrEmptySlug) {
+		t.Errorf("Expected ErrEmptySlug but got %v", err)
+	}
+
+	failure := errors.New("connection refused")
+
+	if _, err = slug.Unique(context.Background(), "Hello", func(ctx context.Context, s string) (bool, error) { return false, failure }); !errors.Is(err, failure) {
+		t.Errorf("Expected the checker's error but got %v", err)
+	}
+}
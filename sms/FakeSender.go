@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package sms
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+/*
+FakeSender is a sender for tests. Instead of sending messages it keeps
+them, so tests can check what was sent, such as to read a login code.
+Set Err to make Send fail
+*/
+type FakeSender struct {
+	sync.Mutex
+
+	Err error
+
+	messages []Message
+}
+
+/*
+NewFakeSender creates a sender that captures messages
+*/
+func NewFakeSender() *FakeSender {
+	return &FakeSender{
+		messages: make([]Message, 0),
+	}
+}
+
+/*
+Send captures a message, or returns Err when it is set
+*/
+func (s *FakeSender) Send(ctx context.Context, message Message) (string, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.Err != nil {
+		return "", s.Err
+	}
+
+	s.messages = append(s.messages, message)
+	return fmt.Sprintf("fake-%d", len(s.messages)), nil
+}
+
+/*
+Messages returns every message sent so far
+*/
+func (s *FakeSender) Messages() []Message {
+	s.Lock()
+	defer s.Unlock()
+
+	result := make([]Message, len(s.messages))
+	copy(result, s.messages)
+	return result
+}
+
+/*
+Last returns the last message sent to a number, and false when none was
+*/
+func (s *FakeSender) Last(to string) (Message, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	for index := len(s.messages) - 1; index >= 0; index-- {
+		if s.messages[index].To == to {
+			return s.messages[index], true
+		}
+	}
+
+	return Message{}, false
+}
+
+/*
+Reset forgets every sent message
+*/
+func (s *FakeSender) Reset() {
+	s.Lock()
+	s.messages = s.messages[:0]
+	s.Unlock()
+}
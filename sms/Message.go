@@ -0,0 +1,75 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrInvalidNumber = errors.New("invalid phone number")
+	ErrNoBody        = errors.New("text message has no body")
+	ErrRateLimited   = errors.New("text message rate limit exceeded")
+)
+
+/*
+Message is a text message. To and From are phone numbers in E.164 form,
+such as +14155552671. From can also be an alphanumeric sender ID where
+the provider and country allow it
+*/
+type Message struct {
+	Body string
+	From string
+	To   string
+}
+
+/*
+ISender sends text messages, returning the provider's ID for the
+message
+*/
+type ISender interface {
+	Send(ctx context.Context, message Message) (string, error)
+}
+
+/*
+ProviderError is an error returned by an SMS provider's API
+*/
+type ProviderError struct {
+	Code       string
+	Message    string
+	Provider   string
+	StatusCode int
+}
+
+func (e *ProviderError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("%s error (status %d): %s", e.Provider, e.StatusCode, e.Message)
+	}
+
+	return fmt.Sprintf("%s error %s (status %d): %s", e.Provider, e.Code, e.StatusCode, e.Message)
+}
+
+type rateLimitedError struct {
+	retryAfter time.Duration
+}
+
+func (e *rateLimitedError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrRateLimited.Error(), e.retryAfter)
+}
+
+func (e *rateLimitedError) Unwrap() error {
+	return ErrRateLimited
+}
+
+/*
+RetryAfter is how long until the message would be allowed, so
+retry.Retrier waits long enough
+*/
+func (e *rateLimitedError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
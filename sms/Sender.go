@@ -0,0 +1,142 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package sms
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/contact"
+	"github.com/ResurgenceIT/kit/v6/ratelimit"
+)
+
+/*
+Sender checks and normalizes text messages, applies rate limits, and
+hands them to a provider. It implements notify.ISMSProvider, so it can
+back a notify.SMSChannel
+*/
+type Sender struct {
+	config SenderConfig
+	now    func() time.Time
+}
+
+/*
+NewSender creates a Sender
+
+	sender := sms.NewSender(sms.SenderConfig{
+		From: "+14155552671",
+		Provider: sms.NewTwilioSender(sms.TwilioConfig{
+			AccountSID: accountSID,
+			AuthToken:  authToken,
+		}),
+		RecipientLimit: ratelimit.Rule{Algorithm: ratelimit.SlidingWindow, Limit: 5, Window: time.Hour},
+	})
+*/
+func NewSender(config SenderConfig) *Sender {
+	if config.DefaultCountry == "" {
+		config.DefaultCountry = "US"
+	}
+
+	if config.Name == "" {
+		config.Name = "sms"
+	}
+
+	if config.Store == nil {
+		config.Store = ratelimit.NewMemoryStore()
+	}
+
+	return &Sender{
+		config: config,
+		now:    time.Now,
+	}
+}
+
+/*
+Send normalizes the message's numbers to E.164, checks the rate limits,
+and sends it. Invalid numbers return ErrInvalidNumber, and messages over
+a limit return ErrRateLimited, with how long to wait available to
+retry.RetryAfter
+*/
+func (s *Sender) Send(ctx context.Context, message Message) (string, error) {
+	var err error
+
+	if strings.TrimSpace(message.Body) == "" {
+		return "", ErrNoBody
+	}
+
+	if message.From == "" {
+		message.From = s.config.From
+	}
+
+	if message.To, err = Normalize(message.To, s.config.DefaultCountry); err != nil {
+		return "", err
+	}
+
+	if strings.HasPrefix(message.From, "+") {
+		if message.From, err = Normalize(message.From, s.config.DefaultCountry); err != nil {
+			return "", err
+		}
+	}
+
+	if err = s.allow(ctx, "all", s.config.Limit); err != nil {
+		return "", err
+	}
+
+	if err = s.allow(ctx, message.To, s.config.RecipientLimit); err != nil {
+		return "", err
+	}
+
+	return s.config.Provider.Send(ctx, message)
+}
+
+/*
+SendSMS sends body to a number from the configured From number
+*/
+func (s *Sender) SendSMS(ctx context.Context, to, body string) (string, error) {
+	return s.Send(ctx, Message{Body: body, To: to})
+}
+
+func (s *Sender) allow(ctx context.Context, key string, rule ratelimit.Rule) error {
+	if rule.Limit <= 0 || rule.Window <= 0 {
+		return nil
+	}
+
+	if rule.Burst <= 0 {
+		rule.Burst = rule.Limit
+	}
+
+	result, err := s.config.Store.Take(ctx, s.config.Name+":"+key, rule, s.now())
+
+	if err != nil {
+		return fmt.Errorf("error checking text message rate limit: %w", err)
+	}
+
+	if !result.Allowed {
+		return &rateLimitedError{retryAfter: result.RetryAfter}
+	}
+
+	return nil
+}
+
+/*
+Normalize parses a phone number as people write it and returns it in
+E.164 form. Numbers without a country code are in defaultCountry.
+Numbers with an extension are rejected, as texts can't be sent to them
+*/
+func Normalize(number, defaultCountry string) (string, error) {
+	phone, err := contact.ParsePhone(number, defaultCountry)
+
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidNumber, err.Error())
+	}
+
+	if phone.Extension != "" {
+		return "", fmt.Errorf("%w: %s has an extension", ErrInvalidNumber, number)
+	}
+
+	return phone.E164(), nil
+}
@@ -0,0 +1,34 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package sms
+
+import (
+	"github.com/ResurgenceIT/kit/v6/ratelimit"
+)
+
+/*
+SenderConfig configures a Sender.
+
+Provider delivers the messages, such as a TwilioSender. From is used
+for messages without one. Numbers without a country code are in
+DefaultCountry, which defaults to "US".
+
+RecipientLimit caps how many messages each number gets, which stops a
+form that sends codes from being used to run up a bill. Limit caps
+messages overall, such as to the provider's throughput. Zero rules
+aren't enforced. Store keeps the counts, and defaults to a
+ratelimit.MemoryStore; use redisstore.RateLimitStore to share limits
+across instances. Name is prepended to keys in the store, and defaults
+to "sms"
+*/
+type SenderConfig struct {
+	DefaultCountry string
+	From           string
+	Limit          ratelimit.Rule
+	Name           string
+	Provider       ISender
+	RecipientLimit ratelimit.Rule
+	Store          ratelimit.IStore
+}
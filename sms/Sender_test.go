@@ -0,0 +1,144 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package sms_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/httpclient"
+	"github.com/ResurgenceIT/kit/v6/notify"
+	"github.com/ResurgenceIT/kit/v6/ratelimit"
+	"github.com/ResurgenceIT/kit/v6/retry"
+	"github.com/ResurgenceIT/kit/v6/sms"
+)
+
+var _ notify.ISMSProvider = &sms.Sender{}
+
+func TestSender(t *testing.T) {
+	fake := sms.NewFakeSender()
+
+	sender := sms.NewSender(sms.SenderConfig{
+		From:           "+1 (415) 555-2671",
+		Provider:       fake,
+		RecipientLimit: ratelimit.Rule{Algorithm: ratelimit.SlidingWindow, Limit: 2, Window: time.Hour},
+	})
+
+	tests := []struct {
+		name     string
+		message  sms.Message
+		expected string
+		err      error
+	}{
+		{name: "Normalizes numbers", message: sms.Message{Body: "Your code is 123456", To: "(212) 555-0100"}, expected: "+12125550100"},
+		{name: "International", message: sms.Message{Body: "Hello", To: "+44 20 7946 0958"}, expected: "+442079460958"},
+		{name: "Invalid number", message: sms.Message{Body: "Hello", To: "555"}, err: sms.ErrInvalidNumber},
+		{name: "Extension", message: sms.Message{Body: "Hello", To: "212 555 0100 x12"}, err: sms.ErrInvalidNumber},
+		{name: "No body", message: sms.Message{Body: " ", To: "212 555 0100"}, err: sms.ErrNoBody},
+		{name: "Recipient limit", message: sms.Message{Body: "Hello", To: "+1 212 555 0100"}, expected: "+12125550100"},
+		{name: "Over recipient limit", message: sms.Message{Body: "Hello", To: "+1 212 555 0100"}, err: sms.ErrRateLimited},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake.Reset()
+
+			_, err := sender.Send(context.Background(), test.message)
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Expected %v but got %v", test.err, err)
+			}
+
+			if test.err != nil {
+				return
+			}
+
+			message, ok := fake.Last(test.expected)
+
+			if !ok || message.From != "+14155552671" {
+				t.Errorf("Expected a message to %s from +14155552671 but got %v", test.expected, fake.Messages())
+			}
+		})
+	}
+
+	_, err := sender.SendSMS(context.Background(), "212-555-0100", "Hello")
+
+	if retry.RetryAfter(err) <= 0 {
+		t.Errorf("Expected a rate limited send to say when to retry but got %v", err)
+	}
+}
+
+func TestProviders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		user, password, _ := r.BasicAuth()
+
+		switch {
+		case r.URL.Path == "/sms/json" && r.PostForm.Get("api_secret") != "secret":
+			w.WriteHeader(http.StatusUnauthorized)
+
+		case r.URL.Path != "/sms/json" && (user != "AC123" || password != "secret"):
+			w.WriteHeader(http.StatusUnauthorized)
+
+		case r.URL.Path == "/2010-04-01/Accounts/AC123/Messages.json" && r.PostForm.Get("To") == "+15005550001":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code": 21211, "message": "The 'To' number is not a valid phone number."}`))
+
+		case r.URL.Path == "/2010-04-01/Accounts/AC123/Messages.json":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"sid": "SM123", "status": "queued"}`))
+
+		case r.URL.Path == "/sms/json" && r.PostForm.Get("to") == "15005550001":
+			_, _ = w.Write([]byte(`{"message-count": "1", "messages": [{"status": "3", "error-text": "Invalid To Number"}]}`))
+
+		case r.URL.Path == "/sms/json":
+			_, _ = w.Write([]byte(`{"message-count": "1", "messages": [{"status": "0", "message-id": "0A0000001"}]}`))
+		}
+	}))
+
+	defer server.Close()
+
+	client := httpclient.NewClient(httpclient.ClientConfig{DisableRetry: true})
+
+	twilio := sms.NewTwilioSender(sms.TwilioConfig{AccountSID: "AC123", AuthToken: "secret", BaseURL: server.URL, Client: client})
+	vonage := sms.NewVonageSender(sms.VonageConfig{APIKey: "key", APISecret: "secret", BaseURL: server.URL, Client: client})
+
+	tests := []struct {
+		name     string
+		sender   sms.ISender
+		to       string
+		expected string
+		code     string
+	}{
+		{name: "Twilio", sender: twilio, to: "+14155552671", expected: "SM123"},
+		{name: "Twilio error", sender: twilio, to: "+15005550001", code: "21211"},
+		{name: "Vonage", sender: vonage, to: "+14155552671", expected: "0A0000001"},
+		{name: "Vonage error", sender: vonage, to: "+15005550001", code: "3"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			id, err := test.sender.Send(context.Background(), sms.Message{Body: "Hello", From: "+12125550100", To: test.to})
+
+			if test.code != "" {
+				var providerErr *sms.ProviderError
+
+				if !errors.As(err, &providerErr) || providerErr.Code != test.code {
+					t.Errorf("Expected provider error %s but got %v", test.code, err)
+				}
+
+				return
+			}
+
+			if err != nil || id != test.expected {
+				t.Errorf("Expected ID %s but got %s (%v)", test.expected, id, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ResurgenceIT/kit/v6/httpclient"
+)
+
+/*
+TwilioConfig configures a TwilioSender. AccountSID and AuthToken are
+from the Twilio console. MessagingServiceSID, when set, sends through a
+messaging service, which picks the From number. BaseURL defaults to
+https://api.twilio.com, and Client to an httpclient.Client named
+"sms-twilio"
+*/
+type TwilioConfig struct {
+	AccountSID          string
+	AuthToken           string
+	BaseURL             string
+	Client              *httpclient.Client
+	MessagingServiceSID string
+}
+
+/*
+TwilioSender sends text messages with Twilio's Messages API
+*/
+type TwilioSender struct {
+	config TwilioConfig
+}
+
+/*
+NewTwilioSender creates a sender for Twilio
+*/
+func NewTwilioSender(config TwilioConfig) *TwilioSender {
+	if config.BaseURL == "" {
+		config.BaseURL = "https://api.twilio.com"
+	}
+
+	if config.Client == nil {
+		config.Client = httpclient.NewClient(httpclient.ClientConfig{Name: "sms-twilio"})
+	}
+
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+
+	return &TwilioSender{
+		config: config,
+	}
+}
+
+type twilioResponse struct {
+	Code    json.Number `json:"code"`
+	Message string      `json:"message"`
+	SID     string      `json:"sid"`
+}
+
+/*
+Send sends a message, returning its Twilio SID
+*/
+func (s *TwilioSender) Send(ctx context.Context, message Message) (string, error) {
+	form := url.Values{
+		"Body": {message.Body},
+		"To":   {message.To},
+	}
+
+	if s.config.MessagingServiceSID != "" {
+		form.Set("MessagingServiceSid", s.config.MessagingServiceSID)
+	} else {
+		form.Set("From", message.From)
+	}
+
+	endpoint := s.config.BaseURL + "/2010-04-01/Accounts/" + url.PathEscape(s.config.AccountSID) + "/Messages.json"
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+
+	if err != nil {
+		return "", fmt.Errorf("error creating Twilio request: %w", err)
+	}
+
+	request.SetBasicAuth(s.config.AccountSID, s.config.AuthToken)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := s.config.Client.Do(request)
+
+	if err != nil {
+		return "", fmt.Errorf("error calling Twilio: %w", err)
+	}
+
+	defer response.Body.Close()
+
+	b, err := ioutil.ReadAll(response.Body)
+
+	if err != nil {
+		return "", fmt.Errorf("error reading Twilio response: %w", err)
+	}
+
+	result := twilioResponse{}
+
+	if err = json.Unmarshal(b, &result); err != nil && response.StatusCode < 300 {
+		return "", fmt.Errorf("error decoding Twilio response: %w", err)
+	}
+
+	if response.StatusCode > 299 {
+		if result.Message == "" {
+			result.Message = http.StatusText(response.StatusCode)
+		}
+
+		return "", &ProviderError{Code: result.Code.String(), Message: result.Message, Provider: "twilio", StatusCode: response.StatusCode}
+	}
+
+	return result.SID, nil
+}
+
+/*
+TwilioStatus maps the MessageStatus Twilio posts to status callbacks to
+whether the message reached the phone. done is false while the message
+is still on its way
+*/
+func TwilioStatus(messageStatus string) (delivered, done bool) {
+	switch messageStatus {
+	case "delivered", "read":
+		return true, true
+
+	case "failed", "undelivered", "canceled":
+		return false, true
+	}
+
+	return false, false
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ResurgenceIT/kit/v6/httpclient"
+)
+
+/*
+VonageConfig configures a VonageSender. APIKey and APISecret are from
+the Vonage dashboard. BaseURL defaults to https://rest.nexmo.com, and
+Client to an httpclient.Client named "sms-vonage"
+*/
+type VonageConfig struct {
+	APIKey    string
+	APISecret string
+	BaseURL   string
+	Client    *httpclient.Client
+}
+
+/*
+VonageSender sends text messages with Vonage's SMS API
+*/
+type VonageSender struct {
+	config VonageConfig
+}
+
+/*
+NewVonageSender creates a sender for Vonage
+*/
+func NewVonageSender(config VonageConfig) *VonageSender {
+	if config.BaseURL == "" {
+		config.BaseURL = "https://rest.nexmo.com"
+	}
+
+	if config.Client == nil {
+		config.Client = httpclient.NewClient(httpclient.ClientConfig{Name: "sms-vonage"})
+	}
+
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+
+	return &VonageSender{
+		config: config,
+	}
+}
+
+type vonageResponse struct {
+	Messages []struct {
+		ErrorText string `json:"error-text"`
+		MessageID string `json:"message-id"`
+		Status    string `json:"status"`
+	} `json:"messages"`
+}
+
+/*
+Send sends a message, returning the Vonage message ID. Long messages
+are split into parts by Vonage, and the first part's ID is returned
+*/
+func (s *VonageSender) Send(ctx context.Context, message Message) (string, error) {
+	form := url.Values{
+		"api_key":    {s.config.APIKey},
+		"api_secret": {s.config.APISecret},
+		"from":       {strings.TrimPrefix(message.From, "+")},
+		"text":       {message.Body},
+		"to":         {strings.TrimPrefix(message.To, "+")},
+	}
+
+	if !isASCII(message.Body) {
+		form.Set("type", "unicode")
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.BaseURL+"/sms/json", strings.NewReader(form.Encode()))
+
+	if err != nil {
+		return "", fmt.Errorf("error creating Vonage request: %w", err)
+	}
+
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := s.config.Client.Do(request)
+
+	if err != nil {
+		return "", fmt.Errorf("error calling Vonage: %w", err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode > 299 {
+		return "", &ProviderError{Message: http.StatusText(response.StatusCode), Provider: "vonage", StatusCode: response.StatusCode}
+	}
+
+	result := vonageResponse{}
+
+	if err = json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("error decoding Vonage response: %w", err)
+	}
+
+	if len(result.Messages) == 0 {
+		return "", &ProviderError{Message: "no messages in response", Provider: "vonage", StatusCode: response.StatusCode}
+	}
+
+	/*
+	 * Vonage answers 200 and reports errors per message part
+	 */
+	for _, part := range result.Messages {
+		if part.Status != "0" {
+			return "", &ProviderError{Code: part.Status, Message: part.ErrorText, Provider: "vonage", StatusCode: response.StatusCode}
+		}
+	}
+
+	return result.Messages[0].MessageID, nil
+}
+
+func isASCII(s string) bool {
+	for _, r := range s {
+		if r > 127 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,228 @@
+package sqldatabase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrBulkColumns = errors.New("bulk insert needs one value per column")
+	ErrBulkStruct  = errors.New("bulk insert struct has no field for column")
+)
+
+/*
+Statement is a query and its arguments
+*/
+type Statement struct {
+	Args  []interface{}
+	Query string
+}
+
+/*
+BulkInsert builds multi-row INSERT statements, optionally as upserts,
+for importing many rows at once. Rows are split into chunks of
+ChunkSize (default 500) rows, fewer if needed to stay under the
+dialect's parameter limit.
+
+	insert := sqldatabase.NewBulkInsert(sqldatabase.Postgres, "products", "sku", "name", "price").
+		OnConflict([]string{"sku"}, "name", "price")
+
+	for _, product := range products {
+		_ = insert.Add(product.SKU, product.Name, product.Price)
+	}
+
+	affected, err := insert.Exec(ctx, db)
+*/
+type BulkInsert struct {
+	ChunkSize int
+
+	columns         []string
+	conflictColumns []string
+	dialect         Dialect
+	rows            [][]interface{}
+	table           string
+	updateColumns   []string
+	upsert          bool
+}
+
+/*
+NewBulkInsert creates a BulkInsert into table's columns
+*/
+func NewBulkInsert(dialect Dialect, table string, columns ...string) *BulkInsert {
+	return &BulkInsert{
+		ChunkSize: 500,
+		columns:   columns,
+		dialect:   dialect,
+		table:     table,
+	}
+}
+
+/*
+OnConflict makes the insert an upsert. When a row conflicts on
+conflictColumns, a unique key, updateColumns are set from the new row.
+With no updateColumns the conflicting row is left as it is. MySQL
+ignores conflictColumns and uses whichever unique key conflicts
+*/
+func (b *BulkInsert) OnConflict(conflictColumns []string, updateColumns ...string) *BulkInsert {
+	b.conflictColumns = conflictColumns
+	b.updateColumns = updateColumns
+	b.upsert = true
+
+	return b
+}
+
+/*
+Add adds a row, with one value for each column in order
+*/
+func (b *BulkInsert) Add(values ...interface{}) error {
+	if len(values) != len(b.columns) {
+		return fmt.Errorf("%w: got %d values for %d columns", ErrBulkColumns, len(values), len(b.columns))
+	}
+
+	b.rows = append(b.rows, values)
+	return nil
+}
+
+/*
+AddStruct adds a row from a struct, or pointer to one, reading each
+column from the field it maps to by the rules ScanStruct uses
+*/
+func (b *BulkInsert) AddStruct(model interface{}) error {
+	value := reflect.Indirect(reflect.ValueOf(model))
+
+	if value.Kind() != reflect.Struct {
+		return ErrScanDestination
+	}
+
+	fields := structFields(value.Type())
+	row := make([]interface{}, len(b.columns))
+
+	for index, column := range b.columns {
+		fieldIndex, ok := fields[strings.ToLower(column)]
+
+		if !ok {
+			return fmt.Errorf("%w: '%s' in %s", ErrBulkStruct, column, value.Type())
+		}
+
+		row[index] = value.FieldByIndex(fieldIndex).Interface()
+	}
+
+	b.rows = append(b.rows, row)
+	return nil
+}
+
+/*
+Len returns how many rows have been added
+*/
+func (b *BulkInsert) Len() int {
+	return len(b.rows)
+}
+
+/*
+Statements returns the statements that insert every row, one per chunk
+*/
+func (b *BulkInsert) Statements() []Statement {
+	chunkSize := b.ChunkSize
+
+	if len(b.columns) > 0 && chunkSize*len(b.columns) > b.dialect.MaxParameters() {
+		chunkSize = b.dialect.MaxParameters() / len(b.columns)
+	}
+
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+
+	result := []Statement{}
+
+	for start := 0; start < len(b.rows); start += chunkSize {
+		end := start + chunkSize
+
+		if end > len(b.rows) {
+			end = len(b.rows)
+		}
+
+		result = append(result, b.statement(b.rows[start:end]))
+	}
+
+	return result
+}
+
+/*
+Exec runs every statement and returns the total rows affected. Run it
+inside WithTransaction if the chunks must succeed or fail together
+*/
+func (b *BulkInsert) Exec(ctx context.Context, q Querier) (int64, error) {
+	var total int64
+
+	for index, statement := range b.Statements() {
+		result, err := q.ExecContext(ctx, statement.Query, statement.Args...)
+
+		if err != nil {
+			return total, fmt.Errorf("error inserting chunk %d into %s: %w", index+1, b.table, err)
+		}
+
+		if affected, err := result.RowsAffected(); err == nil {
+			total += affected
+		}
+	}
+
+	return total, nil
+}
+
+func (b *BulkInsert) statement(rows [][]interface{}) Statement {
+	builder := strings.Builder{}
+	args := make([]interface{}, 0, len(rows)*len(b.columns))
+	row := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(b.columns)), ", ") + ")"
+
+	builder.WriteString(fmt.Sprintf("INSERT INTO %s (%s) VALUES ", b.table, strings.Join(b.columns, ", ")))
+
+	for index, values := range rows {
+		if index > 0 {
+			builder.WriteString(", ")
+		}
+
+		builder.WriteString(row)
+		args = append(args, values...)
+	}
+
+	if b.upsert {
+		builder.WriteString(b.conflictClause())
+	}
+
+	return Statement{Args: args, Query: b.dialect.Rebind(builder.String())}
+}
+
+func (b *BulkInsert) conflictClause() string {
+	assignments := make([]string, 0, len(b.updateColumns))
+
+	if b.dialect == MySQL {
+		for _, column := range b.updateColumns {
+			assignments = append(assignments, fmt.Sprintf("%s = VALUES(%s)", column, column))
+		}
+
+		if len(assignments) == 0 {
+			assignments = append(assignments, fmt.Sprintf("%s = %s", b.columns[0], b.columns[0]))
+		}
+
+		return " ON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", ")
+	}
+
+	target := ""
+
+	if len(b.conflictColumns) > 0 {
+		target = " (" + strings.Join(b.conflictColumns, ", ") + ")"
+	}
+
+	if len(b.updateColumns) == 0 {
+		return " ON CONFLICT" + target + " DO NOTHING"
+	}
+
+	for _, column := range b.updateColumns {
+		assignments = append(assignments, fmt.Sprintf("%s = excluded.%s", column, column))
+	}
+
+	return " ON CONFLICT" + target + " DO UPDATE SET " + strings.Join(assignments, ", ")
+}
@@ -0,0 +1,130 @@
+package sqldatabase_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+)
+
+type product struct {
+	Name  string
+	Price float64
+	SKU   string `db:"sku"`
+}
+
+func TestBulkInsert(t *testing.T) {
+	tests := []struct {
+		name     string
+		insert   *sqldatabase.BulkInsert
+		expected []string
+	}{
+		{
+			name:     "Postgres insert",
+			insert:   sqldatabase.NewBulkInsert(sqldatabase.Postgres, "products", "sku", "name"),
+			expected: []string{"INSERT INTO products (sku, name) VALUES ($1, $2), ($3, $4), ($5, $6)"},
+		},
+		{
+			name:     "Postgres upsert",
+			insert:   sqldatabase.NewBulkInsert(sqldatabase.Postgres, "products", "sku", "name").OnConflict([]string{"sku"}, "name"),
+			expected: []string{"INSERT INTO products (sku, name) VALUES ($1, $2), ($3, $4), ($5, $6) ON CONFLICT (sku) DO UPDATE SET name = excluded.name"},
+		},
+		{
+			name:     "SQLite ignore conflicts",
+			insert:   sqldatabase.NewBulkInsert(sqldatabase.SQLite, "products", "sku", "name").OnConflict([]string{"sku"}),
+			expected: []string{"INSERT INTO products (sku, name) VALUES (?, ?), (?, ?), (?, ?) ON CONFLICT (sku) DO NOTHING"},
+		},
+		{
+			name:     "MySQL upsert",
+			insert:   sqldatabase.NewBulkInsert(sqldatabase.MySQL, "products", "sku", "name").OnConflict(nil, "name"),
+			expected: []string{"INSERT INTO products (sku, name) VALUES (?, ?), (?, ?), (?, ?) ON DUPLICATE KEY UPDATE name = VALUES(name)"},
+		},
+		{
+			name:     "MySQL ignore conflicts",
+			insert:   sqldatabase.NewBulkInsert(sqldatabase.MySQL, "products", "sku", "name").OnConflict(nil),
+			expected: []string{"INSERT INTO products (sku, name) VALUES (?, ?), (?, ?), (?, ?) ON DUPLICATE KEY UPDATE sku = sku"},
+		},
+		{
+			name: "Chunks",
+			insert: func() *sqldatabase.BulkInsert {
+				insert := sqldatabase.NewBulkInsert(sqldatabase.MySQL, "products", "sku", "name")
+				insert.ChunkSize = 2
+				return insert
+			}(),
+			expected: []string{"INSERT INTO products (sku, name) VALUES (?, ?), (?, ?)", "INSERT INTO products (sku, name) VALUES (?, ?)"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, sku := range []string{"a", "b", "c"} {
+				if err := test.insert.Add(sku, "Product "+sku); err != nil {
+					t.Fatalf("Expected no error but got '%s'", err)
+				}
+			}
+
+			statements := test.insert.Statements()
+			queries := []string{}
+			args := 0
+
+			for _, statement := range statements {
+				queries = append(queries, statement.Query)
+				args += len(statement.Args)
+			}
+
+			if !reflect.DeepEqual(queries, test.expected) {
+				t.Errorf("Expected %v but got %v", test.expected, queries)
+			}
+
+			if args != 6 || statements[0].Args[0] != "a" || statements[0].Args[1] != "Product a" {
+				t.Errorf("Expected the args in row order but got %+v", statements)
+			}
+		})
+	}
+}
+
+func TestBulkInsertParameterLimit(t *testing.T) {
+	insert := sqldatabase.NewBulkInsert(sqldatabase.SQLite, "products", "sku", "name", "price")
+
+	for index := 0; index < 1000; index++ {
+		_ = insert.AddStruct(product{Name: "Product", Price: 1, SKU: "sku"})
+	}
+
+	statements := insert.Statements()
+
+	if len(statements) != 4 || len(statements[0].Args) != 999 {
+		t.Errorf("Expected chunks of 333 rows to stay under SQLite's 999 parameters but got %d statements of %d args", len(statements), len(statements[0].Args))
+	}
+
+	if err := insert.Add("too", "few"); !errors.Is(err, sqldatabase.ErrBulkColumns) {
+		t.Errorf("Expected ErrBulkColumns but got '%v'", err)
+	}
+
+	if err := sqldatabase.NewBulkInsert(sqldatabase.SQLite, "products", "color").AddStruct(product{}); !errors.Is(err, sqldatabase.ErrBulkStruct) {
+		t.Errorf("Expected ErrBulkStruct but got '%v'", err)
+	}
+}
+
+func TestBulkInsertExec(t *testing.T) {
+	queries := 0
+	db := &sqldatabase.MockDB{
+		ExecContextFunc: func(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+			queries++
+			return &sqldatabase.MockResult{RowsAffectedFunc: func() (int64, error) { return int64(len(args) / 2), nil }}, nil
+		},
+	}
+
+	insert := sqldatabase.NewBulkInsert(sqldatabase.Postgres, "products", "sku", "name")
+	insert.ChunkSize = 2
+
+	for _, sku := range []string{"a", "b", "c"} {
+		_ = insert.Add(sku, sku)
+	}
+
+	if affected, err := insert.Exec(context.Background(), db); err != nil || affected != 3 || queries != 2 {
+		t.Errorf("Expected 3 rows in 2 queries but got %d in %d, %v", affected, queries, err)
+	}
+}
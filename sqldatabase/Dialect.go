@@ -0,0 +1,154 @@
+package sqldatabase
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+Dialect is the flavor of SQL a database speaks. It covers the parts of
+SQL that differ between the databases the kit supports: placeholders,
+identifier quoting, paging, and RETURNING. The empty Dialect writes
+? placeholders, LIMIT and OFFSET, and leaves identifiers unquoted
+*/
+type Dialect string
+
+const (
+	MySQL     Dialect = "mysql"
+	Postgres  Dialect = "postgres"
+	SQLite    Dialect = "sqlite"
+	SQLServer Dialect = "sqlserver"
+)
+
+/*
+Rebind rewrites ? placeholders in query to the dialect's style. Postgres
+uses $1, $2, and so on, and SQL Server @p1, @p2. Question marks inside
+quoted strings are left alone
+*/
+func (d Dialect) Rebind(query string) string {
+	prefix := ""
+
+	switch d {
+	case Postgres:
+		prefix = "$"
+
+	case SQLServer:
+		prefix = "@p"
+
+	default:
+		return query
+	}
+
+	builder := strings.Builder{}
+	position := 0
+	var quote rune
+
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+
+		case r == '\'' || r == '"':
+			quote = r
+
+		case r == '?':
+			position++
+			builder.WriteString(prefix + strconv.Itoa(position))
+			continue
+		}
+
+		builder.WriteRune(r)
+	}
+
+	return builder.String()
+}
+
+/*
+Quote quotes an identifier, such as a table or column name, so reserved
+words and mixed case are safe to use. Each part of a dotted name, such
+as schema.table, is quoted on its own
+*/
+func (d Dialect) Quote(identifier string) string {
+	if d == "" {
+		return identifier
+	}
+
+	openQuote, closeQuote := `"`, `"`
+
+	switch d {
+	case MySQL:
+		openQuote, closeQuote = "`", "`"
+
+	case SQLServer:
+		openQuote, closeQuote = "[", "]"
+	}
+
+	parts := strings.Split(identifier, ".")
+
+	for index, part := range parts {
+		parts[index] = openQuote + strings.ReplaceAll(part, closeQuote, closeQuote+closeQuote) + closeQuote
+	}
+
+	return strings.Join(parts, ".")
+}
+
+/*
+LimitOffset returns the clause that pages results. SQL Server uses
+OFFSET ... FETCH, which needs an ORDER BY
+*/
+func (d Dialect) LimitOffset(limit, offset int) string {
+	if d == SQLServer {
+		return fmt.Sprintf("OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", offset, limit)
+	}
+
+	return fmt.Sprintf("LIMIT %d OFFSET %d", limit, offset)
+}
+
+/*
+Limit returns the clause that limits results without skipping any
+*/
+func (d Dialect) Limit(limit int) string {
+	if d == SQLServer {
+		return d.LimitOffset(limit, 0)
+	}
+
+	return fmt.Sprintf("LIMIT %d", limit)
+}
+
+/*
+SupportsReturning reports whether INSERT and UPDATE can return columns
+with RETURNING. Postgres and SQLite 3.35 and later can
+*/
+func (d Dialect) SupportsReturning() bool {
+	return d == Postgres || d == SQLite
+}
+
+/*
+Returning returns a RETURNING clause for columns, with a leading space,
+or nothing when the dialect doesn't support it
+*/
+func (d Dialect) Returning(columns ...string) string {
+	if !d.SupportsReturning() || len(columns) == 0 {
+		return ""
+	}
+
+	return " RETURNING " + strings.Join(columns, ", ")
+}
+
+/*
+MaxParameters is how many bind parameters one statement may have
+*/
+func (d Dialect) MaxParameters() int {
+	switch d {
+	case SQLite:
+		return 999
+
+	case SQLServer:
+		return 2100
+	}
+
+	return 65535
+}
@@ -0,0 +1,79 @@
+package sqldatabase_test
+
+import (
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+)
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		dialect  sqldatabase.Dialect
+		query    string
+		expected string
+	}{
+		{dialect: sqldatabase.Postgres, query: "SELECT '?' FROM a WHERE b=? AND c=?", expected: "SELECT '?' FROM a WHERE b=$1 AND c=$2"},
+		{dialect: sqldatabase.SQLServer, query: "SELECT * FROM a WHERE b=? AND c=?", expected: "SELECT * FROM a WHERE b=@p1 AND c=@p2"},
+		{dialect: sqldatabase.MySQL, query: "SELECT * FROM a WHERE b=?", expected: "SELECT * FROM a WHERE b=?"},
+		{dialect: sqldatabase.SQLite, query: "SELECT * FROM a WHERE b=?", expected: "SELECT * FROM a WHERE b=?"},
+	}
+
+	for _, test := range tests {
+		if actual := test.dialect.Rebind(test.query); actual != test.expected {
+			t.Errorf("Expected '%s' for %s but got '%s'", test.expected, test.dialect, actual)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		dialect    sqldatabase.Dialect
+		identifier string
+		expected   string
+	}{
+		{dialect: "", identifier: "users", expected: "users"},
+		{dialect: sqldatabase.Postgres, identifier: "public.users", expected: `"public"."users"`},
+		{dialect: sqldatabase.Postgres, identifier: `odd"name`, expected: `"odd""name"`},
+		{dialect: sqldatabase.SQLite, identifier: "order", expected: `"order"`},
+		{dialect: sqldatabase.MySQL, identifier: "order", expected: "`order`"},
+		{dialect: sqldatabase.SQLServer, identifier: "dbo.order", expected: "[dbo].[order]"},
+	}
+
+	for _, test := range tests {
+		if actual := test.dialect.Quote(test.identifier); actual != test.expected {
+			t.Errorf("Expected %s for %s but got %s", test.expected, test.dialect, actual)
+		}
+	}
+}
+
+func TestPaging(t *testing.T) {
+	tests := []struct {
+		dialect       sqldatabase.Dialect
+		expectedPage  string
+		expectedLimit string
+	}{
+		{dialect: sqldatabase.Postgres, expectedPage: "LIMIT 25 OFFSET 50", expectedLimit: "LIMIT 25"},
+		{dialect: sqldatabase.MySQL, expectedPage: "LIMIT 25 OFFSET 50", expectedLimit: "LIMIT 25"},
+		{dialect: sqldatabase.SQLServer, expectedPage: "OFFSET 50 ROWS FETCH NEXT 25 ROWS ONLY", expectedLimit: "OFFSET 0 ROWS FETCH NEXT 25 ROWS ONLY"},
+	}
+
+	for _, test := range tests {
+		if actual := test.dialect.LimitOffset(25, 50); actual != test.expectedPage {
+			t.Errorf("Expected '%s' for %s but got '%s'", test.expectedPage, test.dialect, actual)
+		}
+
+		if actual := test.dialect.Limit(25); actual != test.expectedLimit {
+			t.Errorf("Expected '%s' for %s but got '%s'", test.expectedLimit, test.dialect, actual)
+		}
+	}
+}
+
+func TestReturning(t *testing.T) {
+	if actual := sqldatabase.Postgres.Returning("id", "created_at"); actual != " RETURNING id, created_at" {
+		t.Errorf("Expected a RETURNING clause but got '%s'", actual)
+	}
+
+	if actual := sqldatabase.MySQL.Returning("id"); actual != "" {
+		t.Errorf("Expected no RETURNING clause for MySQL but got '%s'", actual)
+	}
+}
@@ -0,0 +1,143 @@
+package sqldatabase
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrMissingParameter = errors.New("missing named parameter")
+	ErrNamedArgType     = errors.New("named arguments must be a map[string]interface{} or a struct")
+)
+
+/*
+Named rewrites a query with :name parameters to use ? placeholders, and
+returns the arguments in order. arg is a map[string]interface{}, or a
+struct whose fields are named by their db tags, or their names in
+snake_case. Slices, other than []byte, expand to one placeholder per
+item, which suits IN clauses. Postgres casts (::) and quoted strings are
+left alone.
+
+	query, args, err := sqldatabase.Named(
+		"SELECT id FROM users WHERE account_id = :accountID AND role IN (:roles)",
+		map[string]interface{}{"accountID": 7, "roles": []string{"admin", "owner"}},
+	)
+*/
+func Named(query string, arg interface{}) (string, []interface{}, error) {
+	lookup, err := namedLookup(arg)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	builder := strings.Builder{}
+	args := []interface{}{}
+	runes := []rune(query)
+	var quote rune
+
+	for index := 0; index < len(runes); index++ {
+		r := runes[index]
+
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+
+		case r == ':' && index+1 < len(runes) && runes[index+1] == ':':
+			builder.WriteString("::")
+			index++
+			continue
+
+		case r == ':' && index+1 < len(runes) && isNameRune(runes[index+1]):
+			end := index + 1
+
+			for end < len(runes) && isNameRune(runes[end]) {
+				end++
+			}
+
+			name := string(runes[index+1 : end])
+			value, ok := lookup(name)
+
+			if !ok {
+				return "", nil, fmt.Errorf("%w: '%s'", ErrMissingParameter, name)
+			}
+
+			builder.WriteString(placeholders(value, &args))
+			index = end - 1
+			continue
+		}
+
+		builder.WriteRune(r)
+	}
+
+	return builder.String(), args, nil
+}
+
+func isNameRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func placeholders(value interface{}, args *[]interface{}) string {
+	v := reflect.ValueOf(value)
+
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() == reflect.Uint8 || v.Len() == 0 {
+		*args = append(*args, value)
+		return "?"
+	}
+
+	for index := 0; index < v.Len(); index++ {
+		*args = append(*args, v.Index(index).Interface())
+	}
+
+	return strings.TrimSuffix(strings.Repeat("?, ", v.Len()), ", ")
+}
+
+func namedLookup(arg interface{}) (func(name string) (interface{}, bool), error) {
+	if values, ok := arg.(map[string]interface{}); ok {
+		return func(name string) (interface{}, bool) {
+			value, ok := values[name]
+			return value, ok
+		}, nil
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(arg))
+
+	if value.Kind() != reflect.Struct {
+		return nil, ErrNamedArgType
+	}
+
+	fields := structFields(value.Type())
+
+	return func(name string) (interface{}, bool) {
+		index, ok := fields[strings.ToLower(name)]
+
+		if !ok {
+			if index, ok = fields[snakeCase(name)]; !ok {
+				return nil, false
+			}
+		}
+
+		field := value
+
+		for position, fieldIndex := range index {
+			if position > 0 && field.Kind() == reflect.Ptr {
+				if field.IsNil() {
+					return nil, true
+				}
+
+				field = field.Elem()
+			}
+
+			field = field.Field(fieldIndex)
+		}
+
+		return field.Interface(), true
+	}, nil
+}
@@ -0,0 +1,160 @@
+package sqldatabase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var ErrConflict = errors.New("record was changed by someone else")
+
+/*
+ConflictError is returned when an update expected a version that is no
+longer current, because the row changed since it was read. It matches
+ErrConflict with errors.Is
+*/
+type ConflictError struct {
+	CurrentVersion  int64
+	ExpectedVersion int64
+	ID              interface{}
+	Table           string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s %v is at version %d, not %d: %s", e.Table, e.ID, e.CurrentVersion, e.ExpectedVersion, ErrConflict.Error())
+}
+
+/*
+Is makes errors.Is(err, ErrConflict) true
+*/
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+/*
+VersionedUpdate describes an update guarded by a version column.
+Values are the columns to set. IDColumn defaults to "id" and
+VersionColumn to "version". Queries are written with ? placeholders;
+set Rebind, such as to Postgres.Rebind, for databases that use another
+style
+*/
+type VersionedUpdate struct {
+	ID            interface{}
+	IDColumn      string
+	Rebind        func(query string) string
+	Table         string
+	Values        map[string]interface{}
+	Version       int64
+	VersionColumn string
+}
+
+/*
+UpdateWithVersion sets the update's values and increments the version,
+but only if the row is still at the expected version. It returns the
+new version. When the row has moved on it returns a *ConflictError, and
+when the row doesn't exist, sql.ErrNoRows.
+
+	version, err := sqldatabase.UpdateWithVersion(ctx, db, sqldatabase.VersionedUpdate{
+		ID:      order.ID,
+		Table:   "orders",
+		Values:  map[string]interface{}{"status": "shipped"},
+		Version: order.Version,
+	})
+
+	if errors.Is(err, sqldatabase.ErrConflict) {
+		// reload and try again, or tell the user
+	}
+*/
+func UpdateWithVersion(ctx context.Context, q Querier, update VersionedUpdate) (int64, error) {
+	update = update.withDefaults()
+
+	columns := make([]string, 0, len(update.Values))
+
+	for column := range update.Values {
+		columns = append(columns, column)
+	}
+
+	sort.Strings(columns)
+	assignments := make([]string, 0, len(columns)+1)
+	args := make([]interface{}, 0, len(columns)+2)
+
+	for _, column := range columns {
+		assignments = append(assignments, column+"=?")
+		args = append(args, update.Values[column])
+	}
+
+	assignments = append(assignments, fmt.Sprintf("%s=%s+1", update.VersionColumn, update.VersionColumn))
+	args = append(args, update.ID, update.Version)
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s=? AND %s=?", update.Table, strings.Join(assignments, ", "), update.IDColumn, update.VersionColumn)
+	result, err := q.ExecContext(ctx, update.Rebind(query), args...)
+
+	if err != nil {
+		return 0, fmt.Errorf("error updating %s: %w", update.Table, err)
+	}
+
+	affected, err := result.RowsAffected()
+
+	if err != nil {
+		return 0, fmt.Errorf("error updating %s: %w", update.Table, err)
+	}
+
+	if affected == 0 {
+		return 0, VersionConflict(ctx, q, update)
+	}
+
+	return update.Version + 1, nil
+}
+
+/*
+VersionConflict explains why a versioned update changed no rows. It
+returns a *ConflictError with the current version when the row exists,
+and sql.ErrNoRows when it doesn't
+*/
+func VersionConflict(ctx context.Context, q Querier, update VersionedUpdate) error {
+	update = update.withDefaults()
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s=?", update.VersionColumn, update.Table, update.IDColumn)
+	rows, err := q.QueryContext(ctx, update.Rebind(query), update.ID)
+
+	if err != nil {
+		return fmt.Errorf("error reading %s version: %w", update.Table, err)
+	}
+
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return err
+		}
+
+		return sql.ErrNoRows
+	}
+
+	result := &ConflictError{ExpectedVersion: update.Version, ID: update.ID, Table: update.Table}
+
+	if err = rows.Scan(&result.CurrentVersion); err != nil {
+		return fmt.Errorf("error reading %s version: %w", update.Table, err)
+	}
+
+	return result
+}
+
+func (u VersionedUpdate) withDefaults() VersionedUpdate {
+	if u.IDColumn == "" {
+		u.IDColumn = "id"
+	}
+
+	if u.Rebind == nil {
+		u.Rebind = func(query string) string { return query }
+	}
+
+	if u.VersionColumn == "" {
+		u.VersionColumn = "version"
+	}
+
+	return u
+}
@@ -0,0 +1,68 @@
+package sqldatabase_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+)
+
+func TestUpdateWithVersion(t *testing.T) {
+	tests := []struct {
+		name            string
+		affected        int64
+		current         []interface{}
+		expectedVersion int64
+		expectedErr     error
+	}{
+		{name: "Saved", affected: 1, expectedVersion: 4},
+		{name: "Changed since it was read", current: []interface{}{int64(5)}, expectedErr: sqldatabase.ErrConflict},
+		{name: "Deleted", expectedErr: sql.ErrNoRows},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var query string
+
+			db := &sqldatabase.MockDB{
+				ExecContextFunc: func(ctx context.Context, q string, args ...interface{}) (sql.Result, error) {
+					query = q
+					return &sqldatabase.MockResult{RowsAffectedFunc: func() (int64, error) { return test.affected, nil }}, nil
+				},
+				QueryContextFunc: func(ctx context.Context, q string, args ...interface{}) (sqldatabase.Rows, error) {
+					values := [][]interface{}{}
+
+					if test.current != nil {
+						values = append(values, test.current)
+					}
+
+					return mockRows([]string{"version"}, values...), nil
+				},
+			}
+
+			version, err := sqldatabase.UpdateWithVersion(context.Background(), db, sqldatabase.VersionedUpdate{
+				ID:      7,
+				Rebind:  sqldatabase.Postgres.Rebind,
+				Table:   "orders",
+				Values:  map[string]interface{}{"total": 10, "status": "shipped"},
+				Version: 3,
+			})
+
+			if !errors.Is(err, test.expectedErr) || version != test.expectedVersion {
+				t.Fatalf("Expected version %d and error '%v' but got %d and '%v'", test.expectedVersion, test.expectedErr, version, err)
+			}
+
+			if query != "UPDATE orders SET status=$1, total=$2, version=version+1 WHERE id=$3 AND version=$4" {
+				t.Errorf("Unexpected query '%s'", query)
+			}
+
+			var conflict *sqldatabase.ConflictError
+
+			if errors.As(err, &conflict) && (conflict.CurrentVersion != 5 || conflict.ExpectedVersion != 3) {
+				t.Errorf("Expected current version 5 and expected version 3 but got %+v", conflict)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package sqldatabase
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+/*
+Querier is what DB and Tx have in common, so helpers work inside or
+outside a transaction
+*/
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (Rows, error)
+}
+
+/*
+ContextWithTimeout returns a child of ctx that times out after timeout
+seconds. Unlike GetDBContext, cancelling ctx, such as when a client
+disconnects, cancels the query too. An earlier deadline on ctx is kept
+*/
+func ContextWithTimeout(ctx context.Context, timeout int) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, time.Second*time.Duration(timeout))
+}
+
+/*
+Get runs query and scans the first row into dest, a pointer to a
+struct. It returns sql.ErrNoRows when there are no rows
+*/
+func Get(ctx context.Context, q Querier, dest interface{}, query string, args ...interface{}) error {
+	rows, err := q.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return err
+		}
+
+		return sql.ErrNoRows
+	}
+
+	return ScanStruct(rows, dest)
+}
+
+/*
+Select runs query and scans every row into dest, a pointer to a slice
+of structs
+*/
+func Select(ctx context.Context, q Querier, dest interface{}, query string, args ...interface{}) error {
+	rows, err := q.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return err
+	}
+
+	return ScanAll(rows, dest)
+}
+
+/*
+NamedExec runs a query with :name parameters. See Named
+*/
+func NamedExec(ctx context.Context, q Querier, query string, arg interface{}) (sql.Result, error) {
+	query, args, err := Named(query, arg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return q.ExecContext(ctx, query, args...)
+}
+
+/*
+NamedSelect runs a query with :name parameters and scans every row into
+dest. See Named and Select
+*/
+func NamedSelect(ctx context.Context, q Querier, dest interface{}, query string, arg interface{}) error {
+	query, args, err := Named(query, arg)
+
+	if err != nil {
+		return err
+	}
+
+	return Select(ctx, q, dest, query, args...)
+}
@@ -0,0 +1,184 @@
+package sqldatabase_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+)
+
+type audit struct {
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type user struct {
+	audit
+	AccountID int
+	Email     sql.NullString
+	Name      string `db:"full_name"`
+	Nickname  *string
+	Password  string `db:"-"`
+}
+
+/*
+mockRows returns rows with columns that scan the given values, by
+assigning each to its destination with reflection
+*/
+func mockRows(columns []string, values ...[]interface{}) *sqldatabase.MockRows {
+	index := -1
+
+	return &sqldatabase.MockRows{
+		CloseFunc:   func() error { return nil },
+		ColumnsFunc: func() ([]string, error) { return columns, nil },
+		ErrFunc:     func() error { return nil },
+		NextFunc: func() bool {
+			index++
+			return index < len(values)
+		},
+		ScanFunc: func(dst ...interface{}) error {
+			for column, destination := range dst {
+				target := reflect.ValueOf(destination).Elem()
+
+				if value := values[index][column]; value != nil {
+					if scanner, ok := destination.(sql.Scanner); ok {
+						if err := scanner.Scan(value); err != nil {
+							return err
+						}
+
+						continue
+					}
+
+					if target.Kind() == reflect.Ptr {
+						target.Set(reflect.New(target.Type().Elem()))
+						target = target.Elem()
+					}
+
+					target.Set(reflect.ValueOf(value))
+				}
+			}
+
+			return nil
+		},
+	}
+}
+
+func TestSelect(t *testing.T) {
+	created := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	nickname := "Ace"
+	columns := []string{"account_id", "FULL_NAME", "email", "nickname", "created_at"}
+	db := &sqldatabase.MockDB{
+		QueryContextFunc: func(ctx context.Context, query string, args ...interface{}) (sqldatabase.Rows, error) {
+			return mockRows(columns,
+				[]interface{}{7, "Adam", "adam@example.com", "Ace", created},
+				[]interface{}{7, "Bea", nil, nil, created},
+			), nil
+		},
+	}
+
+	users := []user{}
+
+	if err := sqldatabase.Select(context.Background(), db, &users, "SELECT ..."); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	expected := []user{
+		{audit: audit{CreatedAt: created}, AccountID: 7, Email: sql.NullString{String: "adam@example.com", Valid: true}, Name: "Adam", Nickname: &nickname},
+		{audit: audit{CreatedAt: created}, AccountID: 7, Name: "Bea"},
+	}
+
+	if !reflect.DeepEqual(users, expected) {
+		t.Errorf("Expected %+v but got %+v", expected, users)
+	}
+
+	pointers := []*user{}
+
+	if err := sqldatabase.Select(context.Background(), db, &pointers, "SELECT ..."); err != nil || len(pointers) != 2 || pointers[1].Name != "Bea" {
+		t.Errorf("Expected two users but got %+v, %v", pointers, err)
+	}
+}
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		name     string
+		columns  []string
+		values   [][]interface{}
+		dest     interface{}
+		expected error
+	}{
+		{name: "Found", columns: []string{"full_name"}, values: [][]interface{}{{"Adam"}}, dest: &user{}},
+		{name: "No rows", columns: []string{"full_name"}, dest: &user{}, expected: sql.ErrNoRows},
+		{name: "Unknown column", columns: []string{"password"}, values: [][]interface{}{{"x"}}, dest: &user{}, expected: sqldatabase.ErrUnknownColumn},
+		{name: "Not a struct", columns: []string{"full_name"}, values: [][]interface{}{{"Adam"}}, dest: new(string), expected: sqldatabase.ErrScanDestination},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := &sqldatabase.MockDB{
+				QueryContextFunc: func(ctx context.Context, query string, args ...interface{}) (sqldatabase.Rows, error) {
+					return mockRows(test.columns, test.values...), nil
+				},
+			}
+
+			if err := sqldatabase.Get(context.Background(), db, test.dest, "SELECT ..."); !errors.Is(err, test.expected) {
+				t.Errorf("Expected error '%v' but got '%v'", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestNamed(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		arg           interface{}
+		expectedQuery string
+		expectedArgs  []interface{}
+		expectedErr   error
+	}{
+		{
+			name:          "Map",
+			query:         "SELECT * FROM users WHERE account_id = :accountID AND role IN (:roles)",
+			arg:           map[string]interface{}{"accountID": 7, "roles": []string{"admin", "owner"}},
+			expectedQuery: "SELECT * FROM users WHERE account_id = ? AND role IN (?, ?)",
+			expectedArgs:  []interface{}{7, "admin", "owner"},
+		},
+		{
+			name:          "Struct",
+			query:         "INSERT INTO users (account_id, full_name) VALUES (:accountID, :full_name)",
+			arg:           user{AccountID: 7, Name: "Adam"},
+			expectedQuery: "INSERT INTO users (account_id, full_name) VALUES (?, ?)",
+			expectedArgs:  []interface{}{7, "Adam"},
+		},
+		{
+			name:          "Casts and strings are left alone",
+			query:         "SELECT ':notAParam', created_at::date FROM users WHERE id = :id",
+			arg:           map[string]interface{}{"id": 1},
+			expectedQuery: "SELECT ':notAParam', created_at::date FROM users WHERE id = ?",
+			expectedArgs:  []interface{}{1},
+		},
+		{name: "Missing parameter", query: "SELECT :missing", arg: map[string]interface{}{}, expectedErr: sqldatabase.ErrMissingParameter},
+		{name: "Bad argument", query: "SELECT :id", arg: 7, expectedErr: sqldatabase.ErrNamedArgType},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, args, err := sqldatabase.Named(test.query, test.arg)
+
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("Expected error '%v' but got '%v'", test.expectedErr, err)
+			}
+
+			if test.expectedErr != nil {
+				return
+			}
+
+			if query != test.expectedQuery || !reflect.DeepEqual(args, test.expectedArgs) {
+				t.Errorf("Expected '%s' %v but got '%s' %v", test.expectedQuery, test.expectedArgs, query, args)
+			}
+		})
+	}
+}
@@ -144,3 +144,35 @@ func NullTime(value sql.NullTime) time.Time {
 
 	return time.Time{}
 }
+
+/*
+ToNullFloat returns a SQL float from a float. The SQL value is null
+when value is 0
+*/
+func ToNullFloat(value float64) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: value, Valid: value != 0}
+}
+
+/*
+ToNullInt returns a SQL int64 from an int. The SQL value is null when
+value is 0
+*/
+func ToNullInt(value int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(value), Valid: value != 0}
+}
+
+/*
+ToNullString returns a SQL string from a string. The SQL value is null
+when value is empty
+*/
+func ToNullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+/*
+ToNullTime returns a SQL time from a time.Time. The SQL value is null
+when value is the zero time
+*/
+func ToNullTime(value time.Time) sql.NullTime {
+	return sql.NullTime{Time: value, Valid: !value.IsZero()}
+}
@@ -0,0 +1,264 @@
+package sqldatabase
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+var (
+	ErrScanDestination = errors.New("scan destination must be a pointer to a struct, or to a slice of structs")
+	ErrUnknownColumn   = errors.New("column has no matching struct field")
+)
+
+var (
+	columnCache sync.Map
+	fieldCache  sync.Map
+)
+
+/*
+ScanStruct scans the current row into dest, a pointer to a struct. Each
+column goes to the field whose db tag matches it, or whose name does in
+snake_case, ignoring case. Fields tagged db:"-" are skipped, and
+embedded structs are flattened. Use pointer or sql.Null* fields for
+nullable columns. A column without a field is an error, so a changed
+query can't silently drop data
+*/
+func ScanStruct(rows Rows, dest interface{}) error {
+	value := reflect.ValueOf(dest)
+
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return ErrScanDestination
+	}
+
+	columns, err := rows.Columns()
+
+	if err != nil {
+		return fmt.Errorf("error reading columns: %w", err)
+	}
+
+	targets, err := scanTargets(value.Elem(), columns)
+
+	if err != nil {
+		return err
+	}
+
+	return rows.Scan(targets...)
+}
+
+/*
+ScanAll scans every remaining row into dest, a pointer to a slice of
+structs or of pointers to structs, then closes rows
+*/
+func ScanAll(rows Rows, dest interface{}) error {
+	defer rows.Close()
+
+	slice := reflect.ValueOf(dest)
+
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return ErrScanDestination
+	}
+
+	slice = slice.Elem()
+	elementType := slice.Type().Elem()
+	isPointer := elementType.Kind() == reflect.Ptr
+
+	if isPointer {
+		elementType = elementType.Elem()
+	}
+
+	if elementType.Kind() != reflect.Struct {
+		return ErrScanDestination
+	}
+
+	columns, err := rows.Columns()
+
+	if err != nil {
+		return fmt.Errorf("error reading columns: %w", err)
+	}
+
+	for rows.Next() {
+		element := reflect.New(elementType)
+		targets, err := scanTargets(element.Elem(), columns)
+
+		if err != nil {
+			return err
+		}
+
+		if err = rows.Scan(targets...); err != nil {
+			return err
+		}
+
+		if isPointer {
+			slice.Set(reflect.Append(slice, element))
+		} else {
+			slice.Set(reflect.Append(slice, element.Elem()))
+		}
+	}
+
+	return rows.Err()
+}
+
+func scanTargets(value reflect.Value, columns []string) ([]interface{}, error) {
+	fields := structFields(value.Type())
+	result := make([]interface{}, len(columns))
+
+	for index, column := range columns {
+		fieldIndex, ok := fields[strings.ToLower(column)]
+
+		if !ok {
+			return nil, fmt.Errorf("%w: '%s' in %s", ErrUnknownColumn, column, value.Type())
+		}
+
+		result[index] = fieldByIndex(value, fieldIndex).Addr().Interface()
+	}
+
+	return result, nil
+}
+
+/*
+fieldByIndex is reflect.Value.FieldByIndex, allocating embedded struct
+pointers on the way
+*/
+func fieldByIndex(value reflect.Value, index []int) reflect.Value {
+	for position, fieldIndex := range index {
+		if position > 0 && value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+
+			value = value.Elem()
+		}
+
+		value = value.Field(fieldIndex)
+	}
+
+	return value
+}
+
+/*
+StructColumn is a struct field and the column it maps to. Options are
+whatever follows the name in the db tag, such as "pk" in db:"id,pk"
+*/
+type StructColumn struct {
+	Index   []int
+	Name    string
+	Options []string
+}
+
+/*
+HasOption reports whether the column's db tag has option
+*/
+func (c StructColumn) HasOption(option string) bool {
+	for _, o := range c.Options {
+		if o == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+StructColumns returns the columns of struct type t, in field order, by
+the same rules ScanStruct uses
+*/
+func StructColumns(t reflect.Type) []StructColumn {
+	if cached, ok := columnCache.Load(t); ok {
+		return cached.([]StructColumn)
+	}
+
+	result := []StructColumn{}
+	seen := map[string]bool{}
+	collectColumns(t, nil, seen, &result)
+	columnCache.Store(t, result)
+
+	return result
+}
+
+/*
+structFields maps the lower case column name of each field in t to its
+index
+*/
+func structFields(t reflect.Type) map[string][]int {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.(map[string][]int)
+	}
+
+	result := map[string][]int{}
+
+	for _, column := range StructColumns(t) {
+		result[strings.ToLower(column.Name)] = column.Index
+	}
+
+	fieldCache.Store(t, result)
+	return result
+}
+
+func collectColumns(t reflect.Type, parent []int, seen map[string]bool, result *[]StructColumn) {
+	for index := 0; index < t.NumField(); index++ {
+		field := t.Field(index)
+		tag := strings.Split(field.Tag.Get("db"), ",")
+		fieldIndex := append(append([]int{}, parent...), index)
+
+		if tag[0] == "-" {
+			continue
+		}
+
+		if field.Anonymous && tag[0] == "" {
+			embedded := field.Type
+
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				collectColumns(embedded, fieldIndex, seen, result)
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := tag[0]
+
+		if name == "" {
+			name = snakeCase(field.Name)
+		}
+
+		if seen[strings.ToLower(name)] {
+			continue
+		}
+
+		seen[strings.ToLower(name)] = true
+		*result = append(*result, StructColumn{Index: fieldIndex, Name: name, Options: tag[1:]})
+	}
+}
+
+/*
+snakeCase converts a field name such as UserID to user_id
+*/
+func snakeCase(name string) string {
+	runes := []rune(name)
+	builder := strings.Builder{}
+
+	for index, r := range runes {
+		if index > 0 && unicode.IsUpper(r) {
+			previousLower := unicode.IsLower(runes[index-1])
+			nextLower := index+1 < len(runes) && unicode.IsLower(runes[index+1])
+
+			if previousLower || (nextLower && unicode.IsUpper(runes[index-1])) {
+				builder.WriteRune('_')
+			}
+		}
+
+		builder.WriteRune(unicode.ToLower(r))
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,217 @@
+package sqldatabase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ResurgenceIT/kit/v6/retry"
+)
+
+/*
+TransactionConfig configures WithTransactionConfig. IsRetryable decides
+which errors restart the whole transaction, and defaults to
+IsRetryableError. Retry sets the backoff between attempts; its
+MaxAttempts defaults to 3 here, and its RetryIf is replaced by
+IsRetryable
+*/
+type TransactionConfig struct {
+	IsRetryable func(err error) bool
+	Retry       retry.RetrierConfig
+}
+
+type txContextKey struct{}
+
+/*
+txState is the transaction carried in a context. Each nesting level has
+its own hooks, so hooks registered in a savepoint that rolls back are
+dropped with it
+*/
+type txState struct {
+	hooks      []func()
+	savepoints *int
+	tx         Tx
+}
+
+/*
+WithTransaction runs fn in a transaction, committing if it returns nil
+and rolling back if it returns an error or panics. Transactions that
+fail with a serialization failure or deadlock are retried with backoff,
+so fn may run more than once and must not have side effects outside the
+transaction; register those with AfterCommit.
+
+Calling WithTransaction with a context from inside fn nests, using a
+savepoint, so functions that need a transaction can be composed. A
+nested function that fails rolls back to its savepoint only.
+
+	err := sqldatabase.WithTransaction(ctx, db, func(ctx context.Context, tx sqldatabase.Tx) error {
+		if _, err := tx.ExecContext(ctx, "UPDATE accounts SET balance=balance-? WHERE id=?", amount, from); err != nil {
+			return err
+		}
+
+		sqldatabase.AfterCommit(ctx, func() { bus.Publish(TransferMade{...}) })
+		return nil
+	})
+*/
+func WithTransaction(ctx context.Context, db DB, fn func(ctx context.Context, tx Tx) error) error {
+	return WithTransactionConfig(ctx, db, TransactionConfig{}, fn)
+}
+
+/*
+WithTransactionConfig is WithTransaction with control over retries
+*/
+func WithTransactionConfig(ctx context.Context, db DB, config TransactionConfig, fn func(ctx context.Context, tx Tx) error) error {
+	if parent, ok := ctx.Value(txContextKey{}).(*txState); ok {
+		return inSavepoint(ctx, parent, fn)
+	}
+
+	if config.IsRetryable == nil {
+		config.IsRetryable = IsRetryableError
+	}
+
+	if config.Retry.MaxAttempts == 0 {
+		config.Retry.MaxAttempts = 3
+	}
+
+	config.Retry.RetryIf = config.IsRetryable
+
+	return retry.NewRetrier(config.Retry).Do(ctx, func(ctx context.Context) error {
+		return inTransaction(ctx, db, fn)
+	})
+}
+
+/*
+AfterCommit registers hook to run once the transaction in ctx commits,
+such as publishing events about what it changed. Hooks don't run if the
+transaction rolls back. Outside a transaction hook runs right away
+*/
+func AfterCommit(ctx context.Context, hook func()) {
+	state, ok := ctx.Value(txContextKey{}).(*txState)
+
+	if !ok {
+		hook()
+		return
+	}
+
+	state.hooks = append(state.hooks, hook)
+}
+
+/*
+TxFromContext returns the transaction WithTransaction is running in ctx
+*/
+func TxFromContext(ctx context.Context) (Tx, bool) {
+	state, ok := ctx.Value(txContextKey{}).(*txState)
+
+	if !ok {
+		return nil, false
+	}
+
+	return state.tx, true
+}
+
+/*
+QuerierFromContext returns the transaction in ctx, or db when there
+isn't one, so repository code runs inside a caller's transaction
+without having to be passed it
+*/
+func QuerierFromContext(ctx context.Context, db DB) Querier {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+
+	return db
+}
+
+/*
+IsRetryableError reports whether err is a serialization failure or
+deadlock, which succeed when the transaction is run again. Errors with
+a SQLState() method, as pgx's do, are checked for Postgres codes 40001
+and 40P01. Otherwise the message is checked for those codes, and for
+MySQL errors 1205 and 1213
+*/
+func IsRetryableError(err error) bool {
+	var stateErr interface{ SQLState() string }
+
+	if errors.As(err, &stateErr) {
+		code := stateErr.SQLState()
+		return code == "40001" || code == "40P01"
+	}
+
+	message := err.Error()
+
+	for _, marker := range []string{"40001", "40P01", "Error 1205", "Error 1213", "could not serialize access", "deadlock"} {
+		if strings.Contains(message, marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func inTransaction(ctx context.Context, db DB, fn func(ctx context.Context, tx Tx) error) (err error) {
+	var tx Tx
+
+	if tx, err = db.Begin(); err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+
+	state := &txState{savepoints: new(int), tx: tx}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			_ = tx.Rollback()
+			panic(recovered)
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txContextKey{}, state), tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w (rollback also failed: %s)", err, rollbackErr.Error())
+		}
+
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	for _, hook := range state.hooks {
+		hook()
+	}
+
+	return nil
+}
+
+func inSavepoint(ctx context.Context, parent *txState, fn func(ctx context.Context, tx Tx) error) (err error) {
+	*parent.savepoints++
+	name := fmt.Sprintf("kit_savepoint_%d", *parent.savepoints)
+	state := &txState{savepoints: parent.savepoints, tx: parent.tx}
+
+	if _, err = parent.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("error creating savepoint: %w", err)
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			_, _ = parent.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+			panic(recovered)
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txContextKey{}, state), parent.tx); err != nil {
+		if _, rollbackErr := parent.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rollbackErr != nil {
+			return fmt.Errorf("%w (rollback to savepoint also failed: %s)", err, rollbackErr.Error())
+		}
+
+		return err
+	}
+
+	if _, err = parent.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("error releasing savepoint: %w", err)
+	}
+
+	parent.hooks = append(parent.hooks, state.hooks...)
+	return nil
+}
@@ -0,0 +1,179 @@
+package sqldatabase_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/retry"
+	"github.com/ResurgenceIT/kit/v6/sqldatabase"
+)
+
+type pgError struct {
+	code string
+}
+
+func (e *pgError) Error() string {
+	return "pg error " + e.code
+}
+
+func (e *pgError) SQLState() string {
+	return e.code
+}
+
+/*
+recordingDB returns a DB whose transactions log what happens to them
+*/
+func recordingDB(log *[]string) *sqldatabase.MockDB {
+	return &sqldatabase.MockDB{
+		BeginFunc: func() (sqldatabase.Tx, error) {
+			*log = append(*log, "BEGIN")
+
+			return &sqldatabase.MockTx{
+				CommitFunc: func() error {
+					*log = append(*log, "COMMIT")
+					return nil
+				},
+				ExecContextFunc: func(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+					*log = append(*log, query)
+					return nil, nil
+				},
+				RollbackFunc: func() error {
+					*log = append(*log, "ROLLBACK")
+					return nil
+				},
+			}, nil
+		},
+	}
+}
+
+func TestWithTransaction(t *testing.T) {
+	failure := errors.New("failure")
+
+	tests := []struct {
+		name        string
+		fn          func(ctx context.Context, tx sqldatabase.Tx) error
+		expectedErr error
+		expectedLog string
+	}{
+		{
+			name: "Commits",
+			fn: func(ctx context.Context, tx sqldatabase.Tx) error {
+				_, _ = tx.ExecContext(ctx, "UPDATE a")
+				sqldatabase.AfterCommit(ctx, func() {})
+				return nil
+			},
+			expectedLog: "BEGIN;UPDATE a;COMMIT",
+		},
+		{
+			name:        "Rolls back",
+			fn:          func(ctx context.Context, tx sqldatabase.Tx) error { return failure },
+			expectedErr: failure,
+			expectedLog: "BEGIN;ROLLBACK",
+		},
+		{
+			name: "Retries serialization failures",
+			fn: func() func(ctx context.Context, tx sqldatabase.Tx) error {
+				attempts := 0
+
+				return func(ctx context.Context, tx sqldatabase.Tx) error {
+					if attempts++; attempts < 3 {
+						return &pgError{code: "40001"}
+					}
+
+					return nil
+				}
+			}(),
+			expectedLog: "BEGIN;ROLLBACK;BEGIN;ROLLBACK;BEGIN;COMMIT",
+		},
+		{
+			name: "Nests with savepoints",
+			fn: func(ctx context.Context, tx sqldatabase.Tx) error {
+				_ = sqldatabase.WithTransaction(ctx, nil, func(ctx context.Context, tx sqldatabase.Tx) error {
+					_, _ = tx.ExecContext(ctx, "UPDATE inner")
+					return nil
+				})
+
+				return sqldatabase.WithTransaction(ctx, nil, func(ctx context.Context, tx sqldatabase.Tx) error {
+					return failure
+				})
+			},
+			expectedErr: failure,
+			expectedLog: "BEGIN;SAVEPOINT kit_savepoint_1;UPDATE inner;RELEASE SAVEPOINT kit_savepoint_1;SAVEPOINT kit_savepoint_2;ROLLBACK TO SAVEPOINT kit_savepoint_2;ROLLBACK",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			log := []string{}
+			config := sqldatabase.TransactionConfig{Retry: retry.RetrierConfig{InitialInterval: time.Millisecond}}
+
+			if err := sqldatabase.WithTransactionConfig(context.Background(), recordingDB(&log), config, test.fn); !errors.Is(err, test.expectedErr) {
+				t.Errorf("Expected error '%v' but got '%v'", test.expectedErr, err)
+			}
+
+			if actual := strings.Join(log, ";"); actual != test.expectedLog {
+				t.Errorf("Expected %s but got %s", test.expectedLog, actual)
+			}
+		})
+	}
+}
+
+func TestAfterCommit(t *testing.T) {
+	log := []string{}
+	ran := []string{}
+
+	_ = sqldatabase.WithTransaction(context.Background(), recordingDB(&log), func(ctx context.Context, tx sqldatabase.Tx) error {
+		sqldatabase.AfterCommit(ctx, func() { ran = append(ran, "outer") })
+
+		_ = sqldatabase.WithTransaction(ctx, nil, func(ctx context.Context, tx sqldatabase.Tx) error {
+			sqldatabase.AfterCommit(ctx, func() { ran = append(ran, "released") })
+			return nil
+		})
+
+		_ = sqldatabase.WithTransaction(ctx, nil, func(ctx context.Context, tx sqldatabase.Tx) error {
+			sqldatabase.AfterCommit(ctx, func() { ran = append(ran, "rolled back") })
+			return errors.New("failure")
+		})
+
+		if len(ran) != 0 {
+			t.Errorf("Expected no hooks to run before commit but got %v", ran)
+		}
+
+		return nil
+	})
+
+	if strings.Join(ran, ",") != "outer,released" {
+		t.Errorf("Expected outer and released hooks to run but got %v", ran)
+	}
+
+	_ = sqldatabase.WithTransaction(context.Background(), recordingDB(&log), func(ctx context.Context, tx sqldatabase.Tx) error {
+		sqldatabase.AfterCommit(ctx, func() { ran = append(ran, "failed") })
+		return errors.New("failure")
+	})
+
+	if len(ran) != 2 {
+		t.Errorf("Expected hooks not to run after a rollback but got %v", ran)
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{err: &pgError{code: "40P01"}, expected: true},
+		{err: &pgError{code: "23505"}, expected: false},
+		{err: errors.New("Error 1213: Deadlock found when trying to get lock"), expected: true},
+		{err: errors.New("no such table"), expected: false},
+	}
+
+	for _, test := range tests {
+		if actual := sqldatabase.IsRetryableError(test.err); actual != test.expected {
+			t.Errorf("Expected %v for '%s' but got %v", test.expected, test.err, actual)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package sse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+/*
+Broker sends events published to named channels to the clients
+streaming them. Each channel keeps its recent events, so a browser that
+reconnects with a Last-Event-ID header gets what it missed. Event IDs
+increase across every channel of a broker
+*/
+type Broker struct {
+	channels map[string]*channel
+	closing  bool
+	config   BrokerConfig
+	mutex    sync.Mutex
+	sequence uint64
+	wg       sync.WaitGroup
+}
+
+type channel struct {
+	clients map[*client]struct{}
+	history []Event
+}
+
+type client struct {
+	done   chan struct{}
+	events chan Event
+	once   sync.Once
+}
+
+func (c *client) close() {
+	c.once.Do(func() { close(c.done) })
+}
+
+/*
+NewBroker creates a Broker, filling in defaults for anything not set in
+config
+*/
+func NewBroker(config BrokerConfig) *Broker {
+	if config.ClientBuffer <= 0 {
+		config.ClientBuffer = 32
+	}
+
+	if config.Heartbeat <= 0 {
+		config.Heartbeat = time.Second * 15
+	}
+
+	if config.HistorySize <= 0 {
+		config.HistorySize = 100
+	}
+
+	return &Broker{
+		channels: make(map[string]*channel),
+		config:   config,
+	}
+}
+
+/*
+Publish sends an event to every client streaming a channel and keeps it
+in the channel's history. The event is returned with its ID set
+*/
+func (b *Broker) Publish(channelName string, event Event) Event {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.sequence++
+	event.ID = strconv.FormatUint(b.sequence, 10)
+
+	ch := b.channel(channelName)
+	ch.history = append(ch.history, event)
+
+	if len(ch.history) > b.config.HistorySize {
+		ch.history = ch.history[len(ch.history)-b.config.HistorySize:]
+	}
+
+	for c := range ch.clients {
+		select {
+		case c.events <- event:
+		default:
+			c.close()
+		}
+	}
+
+	return event
+}
+
+/*
+PublishJSON publishes a value, encoded as JSON, as an event with the
+given name
+*/
+func (b *Broker) PublishJSON(channelName, name string, v interface{}) (Event, error) {
+	data, err := json.Marshal(v)
+
+	if err != nil {
+		return Event{}, fmt.Errorf("error encoding event: %w", err)
+	}
+
+	return b.Publish(channelName, Event{Data: string(data), Name: name}), nil
+}
+
+/*
+Clients returns how many clients are streaming a channel
+*/
+func (b *Broker) Clients(channelName string) int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if ch, ok := b.channels[channelName]; ok {
+		return len(ch.clients)
+	}
+
+	return 0
+}
+
+/*
+HTTPHandler returns a net/http handler that streams events from one or
+more channels until the client disconnects
+*/
+func (b *Broker) HTTPHandler(channels ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b.Stream(w, r, channels...)
+	})
+}
+
+/*
+Handler returns an Echo handler that streams events from one or more
+channels until the client disconnects
+
+	e.GET("/orders/events", broker.Handler("orders"))
+*/
+func (b *Broker) Handler(channels ...string) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		b.Stream(ctx.Response(), ctx.Request(), channels...)
+		return nil
+	}
+}
+
+/*
+Stream streams events from one or more channels to a client until it
+disconnects. Use it in handlers that pick channels per request, such as
+one for the signed in user. Events after the request's Last-Event-ID
+header, or lastEventId query parameter, are replayed first
+*/
+func (b *Broker) Stream(w http.ResponseWriter, r *http.Request, channels ...string) {
+	flusher, ok := w.(http.Flusher)
+
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+
+	c, replay, ok := b.subscribe(channels, lastEventID)
+
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	defer b.unsubscribe(channels, c)
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if b.config.Retry > 0 {
+		_, _ = fmt.Fprintf(w, "retry: %d\n\n", b.config.Retry.Milliseconds())
+	}
+
+	for _, event := range replay {
+		if _, err := event.WriteTo(w); err != nil {
+			return
+		}
+	}
+
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(b.config.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-c.done:
+			return
+
+		case event := <-c.events:
+			if _, err := event.WriteTo(w); err != nil {
+				return
+			}
+
+			flusher.Flush()
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+/*
+Shutdown ends every stream and refuses new ones, then waits for the
+handlers to return or ctx to be done. It can be registered with the
+lifecycle package
+*/
+func (b *Broker) Shutdown(ctx context.Context) error {
+	b.mutex.Lock()
+	b.closing = true
+
+	for _, ch := range b.channels {
+		for c := range ch.clients {
+			c.close()
+		}
+	}
+
+	b.mutex.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/*
+subscribe adds a client to channels and returns the events it missed,
+oldest first. Both happen under the lock, so no event is missed or sent
+twice between the replay and the live stream
+*/
+func (b *Broker) subscribe(channels []string, lastEventID string) (*client, []Event, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closing {
+		return nil, nil, false
+	}
+
+	c := &client{
+		done:   make(chan struct{}),
+		events: make(chan Event, b.config.ClientBuffer),
+	}
+
+	replay := []Event{}
+	last, err := strconv.ParseUint(lastEventID, 10, 64)
+	resume := lastEventID != "" && err == nil
+
+	for _, channelName := range channels {
+		ch := b.channel(channelName)
+		ch.clients[c] = struct{}{}
+
+		if !resume {
+			continue
+		}
+
+		for _, event := range ch.history {
+			if id, _ := strconv.ParseUint(event.ID, 10, 64); id > last {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	sort.Slice(replay, func(i, j int) bool {
+		a, _ := strconv.ParseUint(replay[i].ID, 10, 64)
+		b, _ := strconv.ParseUint(replay[j].ID, 10, 64)
+		return a < b
+	})
+
+	b.wg.Add(1)
+	return c, replay, true
+}
+
+func (b *Broker) unsubscribe(channels []string, c *client) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, channelName := range channels {
+		if ch, ok := b.channels[channelName]; ok {
+			delete(ch.clients, c)
+		}
+	}
+
+	c.close()
+	b.wg.Done()
+}
+
+/*
+channel returns a channel, creating it if needed. The caller must hold
+the lock
+*/
+func (b *Broker) channel(name string) *channel {
+	ch, ok := b.channels[name]
+
+	if !ok {
+		ch = &channel{clients: make(map[*client]struct{})}
+		b.channels[name] = ch
+	}
+
+	return ch
+}
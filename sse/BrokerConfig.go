@@ -0,0 +1,29 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package sse
+
+import (
+	"time"
+)
+
+/*
+BrokerConfig configures a Broker.
+
+HistorySize is how many recent events each channel keeps for clients
+that reconnect, and defaults to 100. ClientBuffer is how many events can
+wait for a client, and defaults to 32. A client that falls further
+behind is disconnected, and catches up from the history when its
+browser reconnects.
+
+Heartbeat is how often a comment is sent to idle streams, so proxies
+don't close them, and defaults to 15 seconds. Retry, when set, tells
+browsers how long to wait before reconnecting
+*/
+type BrokerConfig struct {
+	ClientBuffer int
+	Heartbeat    time.Duration
+	HistorySize  int
+	Retry        time.Duration
+}
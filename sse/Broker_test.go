@@ -0,0 +1,265 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package sse_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/sse"
+	"github.com/labstack/echo/v4"
+)
+
+func TestEventWriteTo(t *testing.T) {
+	tests := []struct {
+		name   string
+		event  sse.Event
+		expect string
+	}{
+		{name: "Data only", event: sse.Event{Data: "hello"}, expect: "data: hello\n\n"},
+		{name: "Named", event: sse.Event{Data: "{}", ID: "7", Name: "order"}, expect: "id: 7\nevent: order\ndata: {}\n\n"},
+		{name: "Multiline", event: sse.Event{Data: "one\r\ntwo\nthree"}, expect: "data: one\ndata: two\ndata: three\n\n"},
+		{name: "Injected name", event: sse.Event{Data: "x", Name: "a\ndata: b"}, expect: "event: a data: b\ndata: x\n\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+
+			if _, err := test.event.WriteTo(buffer); err != nil {
+				t.Fatalf("Expected no error but got '%s'", err)
+			}
+
+			if buffer.String() != test.expect {
+				t.Errorf("Expected %q but got %q", test.expect, buffer.String())
+			}
+		})
+	}
+}
+
+func TestBrokerStream(t *testing.T) {
+	broker := sse.NewBroker(sse.BrokerConfig{Retry: time.Second * 3})
+	server := httptest.NewServer(broker.HTTPHandler("orders"))
+	defer server.Close()
+
+	response := get(t, server.URL, "")
+	defer response.Body.Close()
+
+	if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("Expected an event stream but got '%s'", contentType)
+	}
+
+	reader := bufio.NewReader(response.Body)
+
+	if block := readBlock(t, reader); block != "retry: 3000" {
+		t.Errorf("Expected a retry interval but got %q", block)
+	}
+
+	broker.Publish("other", sse.Event{Data: "ignored"})
+	broker.Publish("orders", sse.Event{Data: "line one\nline two", Name: "created"})
+
+	if block := readBlock(t, reader); block != "id: 2\nevent: created\ndata: line one\ndata: line two" {
+		t.Errorf("Expected the orders event but got %q", block)
+	}
+
+	if clients := broker.Clients("orders"); clients != 1 {
+		t.Errorf("Expected 1 client but got %d", clients)
+	}
+}
+
+func TestBrokerReplay(t *testing.T) {
+	tests := []struct {
+		name        string
+		lastEventID string
+		expect      []string
+	}{
+		{name: "Resume", lastEventID: "2", expect: []string{"id: 3\ndata: a", "id: 4\ndata: b", "id: 5\ndata: a"}},
+		{name: "Trimmed history", lastEventID: "0", expect: []string{"id: 2\ndata: b", "id: 3\ndata: a", "id: 4\ndata: b", "id: 5\ndata: a"}},
+		{name: "Up to date", lastEventID: "5", expect: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			broker := sse.NewBroker(sse.BrokerConfig{HistorySize: 2})
+			server := httptest.NewServer(broker.HTTPHandler("a", "b"))
+			defer server.Close()
+
+			for _, channel := range []string{"a", "b", "a", "b", "a"} {
+				broker.Publish(channel, sse.Event{Data: channel})
+			}
+
+			response := get(t, server.URL, test.lastEventID)
+			defer response.Body.Close()
+
+			reader := bufio.NewReader(response.Body)
+
+			for _, expect := range test.expect {
+				if block := readBlock(t, reader); block != expect {
+					t.Errorf("Expected %q but got %q", expect, block)
+				}
+			}
+
+			broker.Publish("a", sse.Event{Data: "live"})
+
+			if block := readBlock(t, reader); !strings.HasSuffix(block, "data: live") {
+				t.Errorf("Expected the live event after the replay but got %q", block)
+			}
+		})
+	}
+}
+
+func TestBrokerHeartbeat(t *testing.T) {
+	broker := sse.NewBroker(sse.BrokerConfig{Heartbeat: time.Millisecond * 20})
+	server := httptest.NewServer(broker.HTTPHandler("orders"))
+	defer server.Close()
+
+	response := get(t, server.URL, "")
+	defer response.Body.Close()
+
+	if block := readBlock(t, bufio.NewReader(response.Body)); block != ": heartbeat" {
+		t.Errorf("Expected a heartbeat but got %q", block)
+	}
+}
+
+func TestBrokerSlowClient(t *testing.T) {
+	broker := sse.NewBroker(sse.BrokerConfig{ClientBuffer: 1})
+	recorder := &blockingRecorder{ResponseRecorder: httptest.NewRecorder(), unblock: make(chan struct{})}
+	done := make(chan struct{})
+
+	go func() {
+		broker.HTTPHandler("orders").ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		close(done)
+	}()
+
+	waitFor(t, func() bool { return broker.Clients("orders") == 1 })
+
+	for i := 0; i < 5; i++ {
+		broker.Publish("orders", sse.Event{Data: "event"})
+	}
+
+	close(recorder.unblock)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second * 2):
+		t.Fatalf("Expected the slow client to be disconnected")
+	}
+
+	if clients := broker.Clients("orders"); clients != 0 {
+		t.Errorf("Expected no clients but got %d", clients)
+	}
+}
+
+func TestBrokerEchoHandlerAndShutdown(t *testing.T) {
+	broker := sse.NewBroker(sse.BrokerConfig{})
+	e := echo.New()
+	e.GET("/events", broker.Handler("orders"))
+
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	response := get(t, server.URL+"/events", "")
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200 but got %d", response.StatusCode)
+	}
+
+	if _, err := broker.PublishJSON("orders", "created", map[string]int{"id": 42}); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	reader := bufio.NewReader(response.Body)
+
+	if block := readBlock(t, reader); block != "id: 1\nevent: created\ndata: {\"id\":42}" {
+		t.Errorf("Expected a JSON event but got %q", block)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+
+	if err := broker.Shutdown(ctx); err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	if _, err := reader.ReadString('\n'); err == nil {
+		t.Errorf("Expected the stream to end")
+	}
+
+	rejected := get(t, server.URL+"/events", "")
+	defer rejected.Body.Close()
+
+	if rejected.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 after shutdown but got %d", rejected.StatusCode)
+	}
+}
+
+type blockingRecorder struct {
+	*httptest.ResponseRecorder
+	unblock chan struct{}
+}
+
+func (r *blockingRecorder) Flush() {
+	<-r.unblock
+	r.ResponseRecorder.Flush()
+}
+
+func get(t *testing.T, url, lastEventID string) *http.Response {
+	t.Helper()
+
+	request, _ := http.NewRequest(http.MethodGet, url, nil)
+
+	if lastEventID != "" {
+		request.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+
+	if err != nil {
+		t.Fatalf("Expected no error but got '%s'", err)
+	}
+
+	return response
+}
+
+func readBlock(t *testing.T, reader *bufio.Reader) string {
+	t.Helper()
+
+	lines := make([]string, 0)
+
+	for {
+		line, err := reader.ReadString('\n')
+
+		if err != nil {
+			t.Fatalf("Expected no error but got '%s'", err)
+		}
+
+		if line == "\n" {
+			return strings.Join(lines, "\n")
+		}
+
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second * 2)
+
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting")
+		}
+
+		time.Sleep(time.Millisecond * 5)
+	}
+}
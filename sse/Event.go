@@ -0,0 +1,49 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package sse
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+var lineReplacer = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+/*
+Event is a Server-Sent Event. Name is the event type clients listen for
+with addEventListener, and is "message" when empty. Data can span
+several lines. ID is assigned when the event is published
+*/
+type Event struct {
+	Data string `json:"data"`
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+/*
+WriteTo writes the event in the event stream format. Line breaks in the
+name and ID are replaced with spaces, as they would end the field early
+*/
+func (e Event) WriteTo(w io.Writer) (int64, error) {
+	buffer := &bytes.Buffer{}
+
+	if e.ID != "" {
+		buffer.WriteString("id: " + lineReplacer.Replace(e.ID) + "\n")
+	}
+
+	if e.Name != "" {
+		buffer.WriteString("event: " + lineReplacer.Replace(e.Name) + "\n")
+	}
+
+	data := strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(e.Data)
+
+	for _, line := range strings.Split(data, "\n") {
+		buffer.WriteString("data: " + line + "\n")
+	}
+
+	buffer.WriteString("\n")
+	return buffer.WriteTo(w)
+}
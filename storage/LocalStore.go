@@ -0,0 +1,378 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	localMetadataDir = ".storage-metadata"
+	localTempPrefix  = ".upload-"
+)
+
+/*
+LocalStore keeps files in a directory on disk. Content types and
+metadata are kept beside them, in a hidden directory under the root.
+Files are written to a temporary file first and renamed into place, so
+readers never see a partial file
+*/
+type LocalStore struct {
+	config LocalStoreConfig
+	now    func() time.Time
+}
+
+type localMetadata struct {
+	ContentType string            `json:"contentType"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+/*
+NewLocalStore creates a store in config.Root, creating the directory if
+it doesn't exist
+*/
+func NewLocalStore(config LocalStoreConfig) (*LocalStore, error) {
+	if config.Root == "" {
+		return nil, ErrMissingRoot
+	}
+
+	if err := os.MkdirAll(config.Root, 0755); err != nil {
+		return nil, fmt.Errorf("error creating storage root %s: %w", config.Root, err)
+	}
+
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+
+	return &LocalStore{
+		config: config,
+		now:    time.Now,
+	}, nil
+}
+
+/*
+Put streams r to the file at key, replacing any file already there
+*/
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader, options PutOptions) (Object, error) {
+	var (
+		err  error
+		file *os.File
+		size int64
+	)
+
+	if err = s.validate(key); err != nil {
+		return Object{}, err
+	}
+
+	r, contentType := detectContentType(r, key, options.ContentType)
+	filename := s.filename(key)
+
+	if err = os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return Object{}, fmt.Errorf("error creating directory for %s: %w", key, err)
+	}
+
+	if file, err = os.CreateTemp(filepath.Dir(filename), localTempPrefix+"*"); err != nil {
+		return Object{}, fmt.Errorf("error creating file for %s: %w", key, err)
+	}
+
+	defer os.Remove(file.Name())
+
+	size, err = io.Copy(file, contextReader{ctx: ctx, r: r})
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return Object{}, fmt.Errorf("error writing %s: %w", key, err)
+	}
+
+	if err = s.writeMetadata(key, localMetadata{ContentType: contentType, Metadata: options.Metadata}); err != nil {
+		return Object{}, err
+	}
+
+	if err = os.Rename(file.Name(), filename); err != nil {
+		return Object{}, fmt.Errorf("error saving %s: %w", key, err)
+	}
+
+	return Object{
+		ContentType:  contentType,
+		Key:          key,
+		LastModified: s.now(),
+		Metadata:     options.Metadata,
+		Size:         size,
+	}, nil
+}
+
+/*
+Get opens the file at key. The caller must close it. The reader is an
+*os.File, so it can also seek
+*/
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	var (
+		err  error
+		file *os.File
+		info os.FileInfo
+	)
+
+	if err = s.validate(key); err != nil {
+		return nil, Object{}, err
+	}
+
+	if file, err = os.Open(s.filename(key)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, Object{}, ErrNotFound
+		}
+
+		return nil, Object{}, fmt.Errorf("error opening %s: %w", key, err)
+	}
+
+	if info, err = file.Stat(); err != nil {
+		_ = file.Close()
+		return nil, Object{}, fmt.Errorf("error reading %s: %w", key, err)
+	}
+
+	return file, s.object(key, info), nil
+}
+
+/*
+Delete removes the file at key. Deleting a file that doesn't exist is
+not an error
+*/
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	if err := s.validate(key); err != nil {
+		return err
+	}
+
+	if err := os.Remove(s.filename(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error deleting %s: %w", key, err)
+	}
+
+	if err := os.Remove(s.metadataFilename(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error deleting metadata for %s: %w", key, err)
+	}
+
+	return nil
+}
+
+/*
+List returns every file whose key starts with prefix, sorted by key
+*/
+func (s *LocalStore) List(ctx context.Context, prefix string) ([]Object, error) {
+	result := []Object{}
+
+	err := filepath.WalkDir(s.config.Root, func(filename string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if entry.Name() == localMetadataDir {
+				return filepath.SkipDir
+			}
+
+			return ctx.Err()
+		}
+
+		if strings.HasPrefix(entry.Name(), localTempPrefix) {
+			return nil
+		}
+
+		relative, _ := filepath.Rel(s.config.Root, filename)
+		key := filepath.ToSlash(relative)
+
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := entry.Info()
+
+		if err != nil {
+			return err
+		}
+
+		result = append(result, s.object(key, info))
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing %s: %w", prefix, err)
+	}
+
+	return result, nil
+}
+
+/*
+SignedURL returns a URL that serves the file at key until it expires.
+The store must be served by ServeHTTP at BaseURL
+*/
+func (s *LocalStore) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	if err := s.validate(key); err != nil {
+		return "", err
+	}
+
+	if s.config.BaseURL == "" || len(s.config.SigningKey) == 0 {
+		return "", ErrNoSigningKey
+	}
+
+	expiresAt := strconv.FormatInt(s.now().Add(expires).Unix(), 10)
+	query := url.Values{
+		"expires":   {expiresAt},
+		"signature": {s.signature(key, expiresAt)},
+	}
+
+	return s.config.BaseURL + "/" + (&url.URL{Path: key}).EscapedPath() + "?" + query.Encode(), nil
+}
+
+/*
+VerifySignedURL checks the expiry and signature of a URL made by
+SignedURL. key is the key the URL is for
+*/
+func (s *LocalStore) VerifySignedURL(key string, query url.Values) error {
+	if len(s.config.SigningKey) == 0 {
+		return ErrNoSigningKey
+	}
+
+	expiresAt := query.Get("expires")
+	expected := s.signature(key, expiresAt)
+
+	if !hmac.Equal([]byte(expected), []byte(query.Get("signature"))) {
+		return ErrInvalidSignature
+	}
+
+	unix, err := strconv.ParseInt(expiresAt, 10, 64)
+
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if s.now().Unix() > unix {
+		return ErrSignedURLExpired
+	}
+
+	return nil
+}
+
+/*
+ServeHTTP serves files through signed URLs. Mount it at the path of
+BaseURL with http.StripPrefix, so the request path is the key
+*/
+func (s *LocalStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/")
+
+	if err := s.VerifySignedURL(key, r.URL.Query()); err != nil {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	reader, object, err := s.Get(r.Context(), key)
+
+	if err != nil {
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrInvalidKey) {
+			http.NotFound(w, r)
+			return
+		}
+
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	defer reader.Close()
+
+	w.Header().Set("Content-Type", object.ContentType)
+	http.ServeContent(w, r, key, object.LastModified, reader.(io.ReadSeeker))
+}
+
+func (s *LocalStore) validate(key string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+
+	if key == localMetadataDir || strings.HasPrefix(key, localMetadataDir+"/") {
+		return ErrInvalidKey
+	}
+
+	return nil
+}
+
+func (s *LocalStore) filename(key string) string {
+	return filepath.Join(s.config.Root, filepath.FromSlash(key))
+}
+
+func (s *LocalStore) metadataFilename(key string) string {
+	return filepath.Join(s.config.Root, localMetadataDir, filepath.FromSlash(key)+".json")
+}
+
+func (s *LocalStore) writeMetadata(key string, metadata localMetadata) error {
+	filename := s.metadataFilename(key)
+	b, _ := json.Marshal(metadata)
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("error creating metadata directory for %s: %w", key, err)
+	}
+
+	if err := os.WriteFile(filename, b, 0644); err != nil {
+		return fmt.Errorf("error writing metadata for %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func (s *LocalStore) object(key string, info os.FileInfo) Object {
+	result := Object{
+		Key:          key,
+		LastModified: info.ModTime(),
+		Size:         info.Size(),
+	}
+
+	metadata := localMetadata{}
+
+	if b, err := os.ReadFile(s.metadataFilename(key)); err == nil && json.Unmarshal(b, &metadata) == nil {
+		result.ContentType = metadata.ContentType
+		result.Metadata = metadata.Metadata
+	}
+
+	if result.ContentType == "" {
+		result.ContentType = mime.TypeByExtension(filepath.Ext(key))
+	}
+
+	return result
+}
+
+func (s *LocalStore) signature(key, expiresAt string) string {
+	mac := hmac.New(sha256.New, s.config.SigningKey)
+	_, _ = mac.Write([]byte(key + "\n" + expiresAt))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+/*
+contextReader stops a copy when its context is canceled
+*/
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return r.r.Read(p)
+}
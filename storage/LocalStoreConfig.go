@@ -0,0 +1,17 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package storage
+
+/*
+LocalStoreConfig configures a LocalStore. Root is the directory files
+are kept in, and is created if needed. BaseURL and SigningKey are only
+needed for SignedURL. BaseURL is where the store is served, such as
+"https://example.com/files"
+*/
+type LocalStoreConfig struct {
+	BaseURL    string
+	Root       string
+	SigningKey []byte
+}
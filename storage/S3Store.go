@@ -0,0 +1,386 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/internal/awsauth"
+)
+
+const maxSignedURLExpiry = time.Hour * 24 * 7
+
+/*
+S3Store keeps files in an Amazon S3 bucket, or a bucket on an S3
+compatible service, using the S3 REST API directly. Metadata is stored
+as x-amz-meta-* headers
+*/
+type S3Store struct {
+	config S3StoreConfig
+	now    func() time.Time
+}
+
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+type s3ListResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+/*
+NewS3Store creates a store for config.Bucket
+*/
+func NewS3Store(config S3StoreConfig) (*S3Store, error) {
+	if config.Bucket == "" {
+		return nil, ErrMissingBucket
+	}
+
+	if config.AccessKeyID == "" {
+		config.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		config.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		config.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+
+	if config.Region == "" {
+		config.Region = os.Getenv("AWS_REGION")
+	}
+
+	if config.Region == "" {
+		config.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+
+	if config.Endpoint == "" {
+		config.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", config.Region)
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: time.Minute * 5}
+	}
+
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+
+	return &S3Store{
+		config: config,
+		now:    time.Now,
+	}, nil
+}
+
+/*
+Put streams r to the object at key. S3 needs to know the size up front,
+so readers of unknown length, anything but a bytes.Reader,
+bytes.Buffer, strings.Reader, or os.File, are spooled to a temporary
+file first
+*/
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, options PutOptions) (Object, error) {
+	var (
+		err      error
+		reader   io.ReadCloser
+		size     int64
+		response *http.Response
+	)
+
+	if err = ValidateKey(key); err != nil {
+		return Object{}, err
+	}
+
+	if reader, size, err = sized(r); err != nil {
+		return Object{}, fmt.Errorf("error buffering %s: %w", key, err)
+	}
+
+	defer reader.Close()
+
+	body, contentType := detectContentType(reader, key, options.ContentType)
+	request, _ := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), ioutil.NopCloser(body))
+	request.ContentLength = size
+	request.Header.Set("Content-Type", contentType)
+
+	for name, value := range options.Metadata {
+		request.Header.Set("X-Amz-Meta-"+name, value)
+	}
+
+	if response, err = s.do(request); err != nil {
+		return Object{}, fmt.Errorf("error uploading %s: %w", key, err)
+	}
+
+	_ = response.Body.Close()
+
+	return Object{
+		ContentType:  contentType,
+		Key:          key,
+		LastModified: s.now(),
+		Metadata:     options.Metadata,
+		Size:         size,
+	}, nil
+}
+
+/*
+Get streams the object at key. The caller must close the reader
+*/
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	var (
+		err      error
+		response *http.Response
+	)
+
+	if err = ValidateKey(key); err != nil {
+		return nil, Object{}, err
+	}
+
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key).String(), nil)
+
+	if response, err = s.do(request); err != nil {
+		return nil, Object{}, fmt.Errorf("error downloading %s: %w", key, err)
+	}
+
+	object := Object{
+		ContentType: response.Header.Get("Content-Type"),
+		Key:         key,
+		Size:        response.ContentLength,
+	}
+
+	object.LastModified, _ = http.ParseTime(response.Header.Get("Last-Modified"))
+
+	for name, values := range response.Header {
+		if strings.HasPrefix(name, "X-Amz-Meta-") && len(values) > 0 {
+			if object.Metadata == nil {
+				object.Metadata = map[string]string{}
+			}
+
+			object.Metadata[strings.ToLower(strings.TrimPrefix(name, "X-Amz-Meta-"))] = values[0]
+		}
+	}
+
+	return response.Body, object, nil
+}
+
+/*
+Delete removes the object at key. Deleting an object that doesn't exist
+is not an error
+*/
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+
+	request, _ := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	response, err := s.do(request)
+
+	if err != nil {
+		return fmt.Errorf("error deleting %s: %w", key, err)
+	}
+
+	_ = response.Body.Close()
+	return nil
+}
+
+/*
+List returns every object whose key starts with prefix, sorted by key.
+S3 doesn't list content types or metadata; use Get for those
+*/
+func (s *S3Store) List(ctx context.Context, prefix string) ([]Object, error) {
+	result := []Object{}
+	token := ""
+
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		u := s.bucketURL()
+		u.RawQuery = awsauth.CanonicalQuery(query)
+
+		request, _ := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		response, err := s.do(request)
+
+		if err != nil {
+			return nil, fmt.Errorf("error listing %s: %w", prefix, err)
+		}
+
+		page := s3ListResult{}
+		err = xml.NewDecoder(response.Body).Decode(&page)
+		_ = response.Body.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("error reading list of %s: %w", prefix, err)
+		}
+
+		for _, content := range page.Contents {
+			result = append(result, Object{
+				Key:          content.Key,
+				LastModified: content.LastModified,
+				Size:         content.Size,
+			})
+		}
+
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return result, nil
+		}
+
+		token = page.NextContinuationToken
+	}
+}
+
+/*
+SignedURL returns a presigned URL that downloads the object at key
+until it expires. S3 allows at most seven days
+*/
+func (s *S3Store) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+
+	if expires > maxSignedURLExpiry {
+		return "", ErrSignedURLTooLong
+	}
+
+	return awsauth.Presign(s.objectURL(key), http.MethodGet, s.credentials(), s.now(), expires), nil
+}
+
+func (s *S3Store) do(request *http.Request) (*http.Response, error) {
+	/*
+	 * S3 accepts an unsigned payload, so bodies can be streamed
+	 * without hashing them first
+	 */
+	request.Header.Set("X-Amz-Content-Sha256", awsauth.UnsignedPayload)
+	awsauth.Sign(request, awsauth.UnsignedPayload, s.credentials(), s.now())
+	response, err := s.config.HTTPClient.Do(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode < 300 {
+		return response, nil
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	b, _ := ioutil.ReadAll(io.LimitReader(response.Body, 64*1024))
+	failure := s3Error{}
+
+	if xml.Unmarshal(b, &failure) == nil && failure.Code != "" {
+		return nil, fmt.Errorf("S3 returned %d %s: %s", response.StatusCode, failure.Code, failure.Message)
+	}
+
+	return nil, fmt.Errorf("S3 returned %d", response.StatusCode)
+}
+
+func (s *S3Store) credentials() awsauth.Credentials {
+	return awsauth.Credentials{
+		AccessKeyID:     s.config.AccessKeyID,
+		Region:          s.config.Region,
+		SecretAccessKey: s.config.SecretAccessKey,
+		Service:         "s3",
+		SessionToken:    s.config.SessionToken,
+	}
+}
+
+func (s *S3Store) bucketURL() *url.URL {
+	u, _ := url.Parse(s.config.Endpoint)
+
+	if s.config.PathStyle {
+		u.Path += "/" + s.config.Bucket
+	} else {
+		u.Host = s.config.Bucket + "." + u.Host
+	}
+
+	u.RawPath = ""
+
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	return u
+}
+
+func (s *S3Store) objectURL(key string) *url.URL {
+	u := s.bucketURL()
+	base := strings.TrimSuffix(u.Path, "/")
+
+	u.Path = base + "/" + key
+	u.RawPath = awsauth.Escape(base, false) + "/" + awsauth.Escape(key, false)
+
+	return u
+}
+
+/*
+sized returns r along with its length, spooling it to a temporary file
+when the length can't be known otherwise. The temporary file is removed
+when the returned reader is closed
+*/
+func sized(r io.Reader) (io.ReadCloser, int64, error) {
+	switch reader := r.(type) {
+	case *bytes.Reader:
+		return ioutil.NopCloser(reader), int64(reader.Len()), nil
+
+	case *bytes.Buffer:
+		return ioutil.NopCloser(reader), int64(reader.Len()), nil
+
+	case *strings.Reader:
+		return ioutil.NopCloser(reader), int64(reader.Len()), nil
+
+	case *os.File:
+		info, err := reader.Stat()
+
+		if err == nil && info.Mode().IsRegular() {
+			offset, _ := reader.Seek(0, io.SeekCurrent)
+			return ioutil.NopCloser(reader), info.Size() - offset, nil
+		}
+	}
+
+	file, err := os.CreateTemp("", "kit-storage-*")
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	size, err := io.Copy(file, r)
+
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return nil, 0, err
+	}
+
+	return tempFile{File: file}, size, nil
+}
+
+type tempFile struct {
+	*os.File
+}
+
+func (f tempFile) Close() error {
+	err := f.File.Close()
+	_ = os.Remove(f.Name())
+	return err
+}
@@ -0,0 +1,28 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package storage
+
+import "net/http"
+
+/*
+S3StoreConfig configures an S3Store. Credentials default to the
+AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN
+environment variables, and Region to AWS_REGION or AWS_DEFAULT_REGION.
+
+Endpoint defaults to the regional S3 endpoint. Set it, along with
+PathStyle, for S3 compatible services such as MinIO, which address
+buckets as part of the path instead of the host name. HTTPClient
+defaults to a client with a five minute timeout
+*/
+type S3StoreConfig struct {
+	AccessKeyID     string
+	Bucket          string
+	Endpoint        string
+	HTTPClient      *http.Client
+	PathStyle       bool
+	Region          string
+	SecretAccessKey string
+	SessionToken    string
+}
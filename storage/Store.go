@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package storage
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidKey       = errors.New("invalid storage key")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrMissingBucket    = errors.New("a bucket is required")
+	ErrMissingRoot      = errors.New("a root directory is required")
+	ErrNoSigningKey     = errors.New("signed URLs need a base URL and signing key")
+	ErrNotFound         = errors.New("object not found")
+	ErrSignedURLExpired = errors.New("signed URL has expired")
+	ErrSignedURLTooLong = errors.New("signed URLs can last at most seven days")
+)
+
+/*
+IStore describes somewhere to keep files, such as a local directory or
+an S3 bucket. Keys are slash separated paths, such as
+"attachments/42/invoice.pdf"
+*/
+type IStore interface {
+	Delete(ctx context.Context, key string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, Object, error)
+	List(ctx context.Context, prefix string) ([]Object, error)
+	Put(ctx context.Context, key string, r io.Reader, options PutOptions) (Object, error)
+	SignedURL(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+/*
+Object describes a stored file
+*/
+type Object struct {
+	ContentType  string
+	Key          string
+	LastModified time.Time
+	Metadata     map[string]string
+	Size         int64
+}
+
+/*
+PutOptions sets the content type and metadata of a file being stored.
+When ContentType is empty it is guessed from the key's extension, or
+failing that, sniffed from the first 512 bytes
+*/
+type PutOptions struct {
+	ContentType string
+	Metadata    map[string]string
+}
+
+/*
+ValidateKey returns ErrInvalidKey unless key is a clean, relative,
+slash separated path that stays inside the store
+*/
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key {
+		return ErrInvalidKey
+	}
+
+	if key == ".." || strings.HasPrefix(key, "../") {
+		return ErrInvalidKey
+	}
+
+	return nil
+}
+
+/*
+detectContentType returns the content type for a file being stored,
+and a reader that still yields every byte of r
+*/
+func detectContentType(r io.Reader, key, contentType string) (io.Reader, string) {
+	if contentType != "" {
+		return r, contentType
+	}
+
+	if byExtension := mime.TypeByExtension(path.Ext(key)); byExtension != "" {
+		return r, byExtension
+	}
+
+	buffered := bufio.NewReaderSize(r, 512)
+	head, _ := buffered.Peek(512)
+
+	return buffered, http.DetectContentType(head)
+}
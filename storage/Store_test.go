@@ -0,0 +1,335 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package storage_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/storage"
+)
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{key: "attachments/42/invoice.pdf", valid: true},
+		{key: "a.txt", valid: true},
+		{key: "", valid: false},
+		{key: "/etc/passwd", valid: false},
+		{key: "../secret", valid: false},
+		{key: "a/../../secret", valid: false},
+		{key: "a//b", valid: false},
+		{key: `a\b`, valid: false},
+	}
+
+	for _, test := range tests {
+		if err := storage.ValidateKey(test.key); (err == nil) != test.valid {
+			t.Errorf("Expected '%s' valid to be %v but got %v", test.key, test.valid, err)
+		}
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocalStore(storage.LocalStoreConfig{Root: t.TempDir()})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	object, err := store.Put(ctx, "mail/1/body.html", strings.NewReader("<p>Hi</p>"), storage.PutOptions{Metadata: map[string]string{"owner": "adam"}})
+
+	if err != nil || object.Size != 9 || object.ContentType != "text/html; charset=utf-8" {
+		t.Fatalf("Expected 9 bytes of HTML but got %+v, %v", object, err)
+	}
+
+	if _, err = store.Put(ctx, "mail/1/attachment", io.MultiReader(strings.NewReader("%PDF-1.4\n")), storage.PutOptions{}); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	if _, err = store.Put(ctx, "other/file.txt", strings.NewReader("x"), storage.PutOptions{}); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	reader, object, err := store.Get(ctx, "mail/1/body.html")
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	b, _ := ioutil.ReadAll(reader)
+	_ = reader.Close()
+
+	if string(b) != "<p>Hi</p>" || object.Metadata["owner"] != "adam" || object.ContentType != "text/html; charset=utf-8" {
+		t.Errorf("Unexpected file '%s' %+v", b, object)
+	}
+
+	objects, err := store.List(ctx, "mail/")
+
+	if err != nil || len(objects) != 2 || objects[0].Key != "mail/1/attachment" || objects[0].ContentType != "application/pdf" {
+		t.Errorf("Expected the two mail files but got %+v, %v", objects, err)
+	}
+
+	if err = store.Delete(ctx, "mail/1/body.html"); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+
+	if _, _, err = store.Get(ctx, "mail/1/body.html"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but got %v", err)
+	}
+
+	if err = store.Delete(ctx, "mail/1/body.html"); err != nil {
+		t.Errorf("Expected deleting a missing file to succeed but got %v", err)
+	}
+
+	if _, err = store.Put(ctx, "../escape", strings.NewReader("x"), storage.PutOptions{}); !errors.Is(err, storage.ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey but got %v", err)
+	}
+}
+
+func TestLocalStoreSignedURL(t *testing.T) {
+	ctx := context.Background()
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	defer server.Close()
+
+	store, _ := storage.NewLocalStore(storage.LocalStoreConfig{
+		BaseURL:    server.URL + "/files",
+		Root:       t.TempDir(),
+		SigningKey: []byte("secret"),
+	})
+
+	mux.Handle("/files/", http.StripPrefix("/files", store))
+	_, _ = store.Put(ctx, "reports/q1 summary.txt", strings.NewReader("revenue up"), storage.PutOptions{})
+
+	signed, err := store.SignedURL(ctx, "reports/q1 summary.txt", time.Minute)
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	tampered, _ := url.Parse(signed)
+	tampered.Path = "/files/reports/other.txt"
+
+	tests := []struct {
+		name   string
+		url    string
+		status int
+	}{
+		{name: "Signed", url: signed, status: http.StatusOK},
+		{name: "Tampered", url: tampered.String(), status: http.StatusForbidden},
+		{name: "Unsigned", url: server.URL + "/files/reports/q1%20summary.txt", status: http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		response, err := http.Get(test.url)
+
+		if err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+
+		b, _ := ioutil.ReadAll(response.Body)
+		_ = response.Body.Close()
+
+		if response.StatusCode != test.status {
+			t.Errorf("%s: expected %d but got %d", test.name, test.status, response.StatusCode)
+		}
+
+		if test.status == http.StatusOK && (string(b) != "revenue up" || response.Header.Get("Content-Type") != "text/plain; charset=utf-8") {
+			t.Errorf("%s: unexpected file '%s' of type %s", test.name, b, response.Header.Get("Content-Type"))
+		}
+	}
+
+	expired, _ := store.SignedURL(ctx, "reports/q1 summary.txt", -time.Minute)
+	u, _ := url.Parse(expired)
+
+	if err = store.VerifySignedURL("reports/q1 summary.txt", u.Query()); !errors.Is(err, storage.ErrSignedURLExpired) {
+		t.Errorf("Expected ErrSignedURLExpired but got %v", err)
+	}
+}
+
+/*
+fakeS3 serves just enough of the S3 API, with path style buckets, to
+exercise S3Store
+*/
+func fakeS3() *httptest.Server {
+	type object struct {
+		body        string
+		contentType string
+		owner       string
+	}
+
+	mutex := sync.Mutex{}
+	objects := map[string]object{}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") || r.Header.Get("X-Amz-Content-Sha256") != "UNSIGNED-PAYLOAD" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`))
+			return
+		}
+
+		if !strings.HasPrefix(r.URL.Path, "/uploads") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/uploads"), "/")
+
+		switch {
+		case r.Method == http.MethodPut:
+			b, _ := ioutil.ReadAll(r.Body)
+
+			if int64(len(b)) != r.ContentLength {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			objects[key] = object{body: string(b), contentType: r.Header.Get("Content-Type"), owner: r.Header.Get("X-Amz-Meta-Owner")}
+
+		case r.Method == http.MethodGet && key == "":
+			type content struct {
+				Key  string `xml:"Key"`
+				Size int    `xml:"Size"`
+			}
+
+			result := struct {
+				XMLName  xml.Name  `xml:"ListBucketResult"`
+				Contents []content `xml:"Contents"`
+			}{}
+
+			for name, o := range objects {
+				if strings.HasPrefix(name, r.URL.Query().Get("prefix")) {
+					result.Contents = append(result.Contents, content{Key: name, Size: len(o.body)})
+				}
+			}
+
+			_ = xml.NewEncoder(w).Encode(result)
+
+		case r.Method == http.MethodGet:
+			o, ok := objects[key]
+
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`<Error><Code>NoSuchKey</Code></Error>`))
+				return
+			}
+
+			w.Header().Set("Content-Type", o.contentType)
+			w.Header().Set("X-Amz-Meta-Owner", o.owner)
+			_, _ = w.Write([]byte(o.body))
+
+		case r.Method == http.MethodDelete:
+			delete(objects, key)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+}
+
+func TestS3Store(t *testing.T) {
+	ctx := context.Background()
+	server := fakeS3()
+
+	defer server.Close()
+
+	store, err := storage.NewS3Store(storage.S3StoreConfig{
+		AccessKeyID:     "AKID",
+		Bucket:          "uploads",
+		Endpoint:        server.URL,
+		PathStyle:       true,
+		Region:          "us-east-1",
+		SecretAccessKey: "secret",
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	reader := io.MultiReader(strings.NewReader("streamed "), strings.NewReader("content"))
+	object, err := store.Put(ctx, "docs/read me.txt", reader, storage.PutOptions{Metadata: map[string]string{"owner": "adam"}})
+
+	if err != nil || object.Size != 16 || object.ContentType != "text/plain; charset=utf-8" {
+		t.Fatalf("Expected 16 bytes of text but got %+v, %v", object, err)
+	}
+
+	body, object, err := store.Get(ctx, "docs/read me.txt")
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	b, _ := ioutil.ReadAll(body)
+	_ = body.Close()
+
+	if string(b) != "streamed content" || object.Metadata["owner"] != "adam" {
+		t.Errorf("Unexpected object '%s' %+v", b, object)
+	}
+
+	objects, err := store.List(ctx, "docs/")
+
+	if err != nil || len(objects) != 1 || objects[0].Key != "docs/read me.txt" || objects[0].Size != 16 {
+		t.Errorf("Expected one object but got %+v, %v", objects, err)
+	}
+
+	if err = store.Delete(ctx, "docs/read me.txt"); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+
+	if _, _, err = store.Get(ctx, "docs/read me.txt"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but got %v", err)
+	}
+
+	denied, _ := storage.NewS3Store(storage.S3StoreConfig{AccessKeyID: "other", Bucket: "uploads", Endpoint: server.URL, PathStyle: true})
+
+	if _, err = denied.Put(ctx, "a.txt", strings.NewReader("x"), storage.PutOptions{}); err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("Expected AccessDenied but got %v", err)
+	}
+}
+
+func TestS3SignedURL(t *testing.T) {
+	store, _ := storage.NewS3Store(storage.S3StoreConfig{
+		AccessKeyID:     "AKID",
+		Bucket:          "examplebucket",
+		Region:          "us-east-1",
+		SecretAccessKey: "secret",
+	})
+
+	signed, err := store.SignedURL(context.Background(), "reports/q1 summary.pdf", time.Hour)
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	u, _ := url.Parse(signed)
+	query := u.Query()
+
+	if u.Host != "examplebucket.s3.us-east-1.amazonaws.com" || u.EscapedPath() != "/reports/q1%20summary.pdf" {
+		t.Errorf("Unexpected URL %s", signed)
+	}
+
+	if query.Get("X-Amz-Expires") != "3600" || query.Get("X-Amz-SignedHeaders") != "host" || len(query.Get("X-Amz-Signature")) != 64 {
+		t.Errorf("Unexpected query %v", query)
+	}
+
+	if _, err = store.SignedURL(context.Background(), "a.txt", time.Hour*24*8); !errors.Is(err, storage.ErrSignedURLTooLong) {
+		t.Errorf("Expected ErrSignedURLTooLong but got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package upload
+
+import (
+	"net/url"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const maxFilenameLength = 200
+
+/*
+File describes an uploaded file after it was stored. ContentType is
+sniffed from the file's contents, while DeclaredType is what the client
+claimed. Width and Height are set for images whose dimensions could be
+read
+*/
+type File struct {
+	ContentType  string `json:"contentType"`
+	DeclaredType string `json:"declaredType,omitempty"`
+	Field        string `json:"field"`
+	Filename     string `json:"filename"`
+	Height       int    `json:"height,omitempty"`
+	Key          string `json:"key"`
+	OriginalName string `json:"originalName"`
+	Size         int64  `json:"size"`
+	Width        int    `json:"width,omitempty"`
+}
+
+/*
+Result is a parsed upload: the stored files, in the order they were
+sent, and the form's other fields
+*/
+type Result struct {
+	Fields url.Values `json:"fields"`
+	Files  []File     `json:"files"`
+}
+
+/*
+File returns the first file uploaded in field
+*/
+func (r Result) File(field string) (File, bool) {
+	for _, file := range r.Files {
+		if file.Field == field {
+			return file, true
+		}
+	}
+
+	return File{}, false
+}
+
+/*
+SanitizeFilename makes a client supplied filename safe to store and
+serve. Directories are dropped, runs of anything but letters, digits,
+dots, dashes, and underscores become a dash, leading dots are removed so
+files can't be hidden, and long names are shortened, keeping the
+extension. A name with nothing left becomes "file"
+*/
+func SanitizeFilename(name string) string {
+	if index := strings.LastIndexAny(name, `/\`); index >= 0 {
+		name = name[index+1:]
+	}
+
+	builder := strings.Builder{}
+	pendingDash := false
+
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.' && r != '_' && r != '-' {
+			pendingDash = true
+			continue
+		}
+
+		if pendingDash && r != '.' && r != '-' && !strings.HasSuffix(builder.String(), "-") {
+			builder.WriteRune('-')
+		}
+
+		builder.WriteRune(r)
+		pendingDash = false
+	}
+
+	result := strings.Trim(builder.String(), ".-_")
+
+	if len(result) > maxFilenameLength {
+		extension := path.Ext(result)
+
+		if len(extension) > 20 {
+			extension = ""
+		}
+
+		cut := maxFilenameLength - len(extension)
+
+		for cut > 0 && !utf8.RuneStart(result[cut]) {
+			cut--
+		}
+
+		result = strings.TrimRight(result[:cut], ".-_") + extension
+	}
+
+	if strings.TrimLeft(result, ".") == "" {
+		return "file"
+	}
+
+	return result
+}
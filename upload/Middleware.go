@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package upload
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/ResurgenceIT/kit/v6/apierror"
+	"github.com/labstack/echo/v4"
+)
+
+type contextKey struct{}
+
+/*
+Middleware parses uploads for the Echo framework. Handlers get the
+result with FromContext. Failed checks are returned as problems, so the
+apierror error handler renders them
+*/
+func (u *Uploader) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		result, err := u.Parse(ctx.Request())
+
+		if err != nil {
+			if problem := Mapper(err); problem != nil {
+				return problem
+			}
+
+			return err
+		}
+
+		ctx.SetRequest(ctx.Request().WithContext(context.WithValue(ctx.Request().Context(), contextKey{}, result)))
+		return next(ctx)
+	}
+}
+
+/*
+HTTPMiddleware parses uploads for a plain net/http handler. Failed
+checks are written as problems
+*/
+func (u *Uploader) HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result, err := u.Parse(r)
+
+		if err != nil {
+			_ = apierror.Write(w, r, apierror.FromError(err, Mapper))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, result)))
+	})
+}
+
+/*
+FromContext returns the upload parsed by the middleware
+*/
+func FromContext(ctx context.Context) (Result, bool) {
+	result, ok := ctx.Value(contextKey{}).(Result)
+	return result, ok
+}
+
+/*
+Mapper turns upload errors into problems: 413 for files that are too
+large or too many, 415 for types that aren't allowed, 422 for images
+that are the wrong size, and 400 for requests that aren't uploads. Add
+it to apierror.EchoErrorHandlerConfig.Mappers when calling Parse
+directly
+*/
+func Mapper(err error) *apierror.Problem {
+	var problem *apierror.Problem
+
+	switch {
+	case errors.Is(err, ErrFileTooLarge):
+		problem = apierror.New(http.StatusRequestEntityTooLarge, "file-too-large", "File Too Large")
+
+	case errors.Is(err, ErrTooManyFiles):
+		problem = apierror.New(http.StatusRequestEntityTooLarge, "too-many-files", "Too Many Files")
+
+	case errors.Is(err, ErrFieldsTooLarge):
+		problem = apierror.New(http.StatusRequestEntityTooLarge, "fields-too-large", "Form Fields Too Large")
+
+	case errors.Is(err, ErrTypeNotAllowed):
+		problem = apierror.New(http.StatusUnsupportedMediaType, "file-type-not-allowed", "File Type Not Allowed")
+
+	case errors.Is(err, ErrImageDimensions):
+		problem = apierror.New(http.StatusUnprocessableEntity, "invalid-image-dimensions", "Invalid Image Dimensions")
+
+	case errors.Is(err, ErrInvalidImage):
+		problem = apierror.New(http.StatusUnprocessableEntity, "invalid-image", "Invalid Image")
+
+	case errors.Is(err, ErrNotMultipart):
+		problem = apierror.New(http.StatusBadRequest, "not-multipart", "Not a Multipart Upload")
+
+	default:
+		return nil
+	}
+
+	return problem.WithDetail(err.Error()).Wrap(err)
+}
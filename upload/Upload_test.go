@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package upload_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/apierror"
+	"github.com/ResurgenceIT/kit/v6/storage"
+	"github.com/ResurgenceIT/kit/v6/upload"
+	"github.com/labstack/echo/v4"
+)
+
+type part struct {
+	field    string
+	filename string
+	content  []byte
+}
+
+func uploadRequest(parts ...part) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	for _, p := range parts {
+		if p.filename == "" {
+			_ = writer.WriteField(p.field, string(p.content))
+			continue
+		}
+
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", `form-data; name="`+p.field+`"; filename="`+p.filename+`"`)
+		header.Set("Content-Type", "application/octet-stream")
+
+		w, _ := writer.CreatePart(header)
+		_, _ = w.Write(p.content)
+	}
+
+	_ = writer.Close()
+
+	request := httptest.NewRequest(http.MethodPost, "/upload", body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return request
+}
+
+func pngImage(width, height int) []byte {
+	buffer := &bytes.Buffer{}
+	_ = png.Encode(buffer, image.NewRGBA(image.Rect(0, 0, width, height)))
+
+	return buffer.Bytes()
+}
+
+func TestParse(t *testing.T) {
+	store, _ := storage.NewLocalStore(storage.LocalStoreConfig{Root: t.TempDir()})
+	uploader, _ := upload.NewUploader(upload.UploaderConfig{
+		AllowedTypes: []string{"image/*", "application/pdf"},
+		KeyPrefix:    "avatars",
+		MaxFileSize:  4096,
+		MaxFiles:     2,
+		MaxWidth:     100,
+		Store:        store,
+	})
+
+	tests := []struct {
+		name        string
+		parts       []part
+		expectedErr error
+	}{
+		{name: "Allowed", parts: []part{{field: "title", content: []byte("Me")}, {field: "avatar", filename: "../My Photo!.png", content: pngImage(64, 32)}}},
+		{name: "Too large", parts: []part{{field: "doc", filename: "a.pdf", content: append([]byte("%PDF-1.4\n"), make([]byte, 5000)...)}}, expectedErr: upload.ErrFileTooLarge},
+		{name: "Wrong type", parts: []part{{field: "doc", filename: "evil.png", content: []byte("<html><script>alert(1)</script></html>")}}, expectedErr: upload.ErrTypeNotAllowed},
+		{name: "Too wide", parts: []part{{field: "avatar", filename: "wide.png", content: pngImage(200, 10)}}, expectedErr: upload.ErrImageDimensions},
+		{name: "Too many files", parts: []part{{field: "a", filename: "1.pdf", content: []byte("%PDF-1.4\n")}, {field: "a", filename: "2.pdf", content: []byte("%PDF-1.4\n")}, {field: "a", filename: "3.pdf", content: []byte("%PDF-1.4\n")}}, expectedErr: upload.ErrTooManyFiles},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := uploader.Parse(uploadRequest(test.parts...))
+
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("Expected %v but got %v", test.expectedErr, err)
+			}
+
+			if test.expectedErr != nil {
+				return
+			}
+
+			file, ok := result.File("avatar")
+
+			if !ok || file.Filename != "My-Photo.png" || file.ContentType != "image/png" || file.Width != 64 || file.Height != 32 || !strings.HasPrefix(file.Key, "avatars/") {
+				t.Errorf("Unexpected file %+v", file)
+			}
+
+			if result.Fields.Get("title") != "Me" {
+				t.Errorf("Expected the title field but got %v", result.Fields)
+			}
+
+			if _, object, err := store.Get(context.Background(), file.Key); err != nil || object.Size != file.Size {
+				t.Errorf("Expected the stored file to be %d bytes but got %+v, %v", file.Size, object, err)
+			}
+		})
+	}
+
+	if objects, _ := store.List(context.Background(), "avatars/"); len(objects) != 1 {
+		t.Errorf("Expected failed uploads to be cleaned up but got %+v", objects)
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "report.pdf", expected: "report.pdf"},
+		{name: `C:\Users\adam\Q1 report (final).xlsx`, expected: "Q1-report-final.xlsx"},
+		{name: "../../etc/passwd", expected: "passwd"},
+		{name: ".htaccess", expected: "htaccess"},
+		{name: "résumé.doc", expected: "résumé.doc"},
+		{name: "a - b.txt", expected: "a-b.txt"},
+		{name: "..", expected: "file"},
+		{name: strings.Repeat("a", 300) + ".txt", expected: strings.Repeat("a", 196) + ".txt"},
+	}
+
+	for _, test := range tests {
+		if actual := upload.SanitizeFilename(test.name); actual != test.expected {
+			t.Errorf("Expected '%s' for '%s' but got '%s'", test.expected, test.name, actual)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	store, _ := storage.NewLocalStore(storage.LocalStoreConfig{Root: t.TempDir()})
+	uploader, _ := upload.NewUploader(upload.UploaderConfig{AllowedTypes: []string{"image/png"}, Store: store})
+
+	e := echo.New()
+	e.HTTPErrorHandler = apierror.NewEchoErrorHandler(apierror.EchoErrorHandlerConfig{})
+	e.POST("/upload", func(ctx echo.Context) error {
+		result, _ := upload.FromContext(ctx.Request().Context())
+		return ctx.JSON(http.StatusCreated, result)
+	}, uploader.Middleware)
+
+	tests := []struct {
+		name    string
+		request *http.Request
+		status  int
+	}{
+		{name: "Upload", request: uploadRequest(part{field: "image", filename: "a.png", content: pngImage(1, 1)}), status: http.StatusCreated},
+		{name: "Wrong type", request: uploadRequest(part{field: "image", filename: "a.png", content: []byte("%PDF-1.4\n")}), status: http.StatusUnsupportedMediaType},
+		{name: "Not multipart", request: httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("{}")), status: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		e.ServeHTTP(recorder, test.request)
+
+		if recorder.Code != test.status {
+			t.Errorf("%s: expected %d but got %d %s", test.name, test.status, recorder.Code, recorder.Body.String())
+		}
+	}
+}
@@ -0,0 +1,288 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package upload
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/ResurgenceIT/kit/v6/storage"
+)
+
+var (
+	ErrFieldsTooLarge  = errors.New("form fields are too large")
+	ErrFileTooLarge    = errors.New("file is too large")
+	ErrImageDimensions = errors.New("image dimensions are out of range")
+	ErrInvalidImage    = errors.New("image could not be read")
+	ErrMissingStore    = errors.New("a store is required")
+	ErrNotMultipart    = errors.New("request is not multipart/form-data")
+	ErrTooManyFiles    = errors.New("too many files")
+	ErrTypeNotAllowed  = errors.New("file type is not allowed")
+)
+
+/*
+Uploader reads multipart/form-data requests, checking each file as it
+streams by and writing it to a store. Files are never held in memory
+or written to temporary files by the uploader itself
+*/
+type Uploader struct {
+	config UploaderConfig
+}
+
+/*
+NewUploader creates an Uploader, filling in defaults for anything not
+set in config
+*/
+func NewUploader(config UploaderConfig) (*Uploader, error) {
+	if config.Store == nil {
+		return nil, ErrMissingStore
+	}
+
+	if config.MaxFieldsSize <= 0 {
+		config.MaxFieldsSize = 1 << 20
+	}
+
+	if config.MaxFileSize <= 0 {
+		config.MaxFileSize = 10 << 20
+	}
+
+	if config.MaxFiles <= 0 {
+		config.MaxFiles = 10
+	}
+
+	if config.KeyFunc == nil {
+		config.KeyFunc = func(file File) string {
+			return path.Join(config.KeyPrefix, randomID(), file.Filename)
+		}
+	}
+
+	return &Uploader{
+		config: config,
+	}, nil
+}
+
+/*
+Parse reads an upload from r, storing its files. If any file fails a
+check, the files already stored are deleted and the error is returned.
+Errors from the checks match the Err values with errors.Is
+*/
+func (u *Uploader) Parse(r *http.Request) (Result, error) {
+	ctx := r.Context()
+	result := Result{Fields: url.Values{}, Files: []File{}}
+	reader, err := r.MultipartReader()
+
+	if err != nil {
+		return result, fmt.Errorf("%w: %s", ErrNotMultipart, err.Error())
+	}
+
+	fieldsRemaining := u.config.MaxFieldsSize
+
+	for {
+		part, err := reader.NextPart()
+
+		if err == io.EOF {
+			return result, nil
+		}
+
+		if err != nil {
+			_ = u.Discard(ctx, result)
+			return Result{}, fmt.Errorf("error reading upload: %w", err)
+		}
+
+		if part.FormName() == "" {
+			_ = part.Close()
+			continue
+		}
+
+		if part.FileName() == "" {
+			err = u.readField(part, &result, &fieldsRemaining)
+		} else if len(result.Files) >= u.config.MaxFiles {
+			err = fmt.Errorf("%w: at most %d may be uploaded", ErrTooManyFiles, u.config.MaxFiles)
+		} else {
+			var file File
+
+			if file, err = u.storeFile(ctx, part); err == nil {
+				result.Files = append(result.Files, file)
+			}
+		}
+
+		_ = part.Close()
+
+		if err != nil {
+			_ = u.Discard(ctx, result)
+			return Result{}, err
+		}
+	}
+}
+
+/*
+Discard deletes the stored files of an upload, such as when a handler
+fails after the upload was parsed
+*/
+func (u *Uploader) Discard(ctx context.Context, result Result) error {
+	var firstErr error
+
+	for _, file := range result.Files {
+		if err := u.config.Store.Delete(ctx, file.Key); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func (u *Uploader) readField(part *multipart.Part, result *Result, remaining *int64) error {
+	b, err := io.ReadAll(io.LimitReader(part, *remaining+1))
+
+	if err != nil {
+		return fmt.Errorf("error reading field %s: %w", part.FormName(), err)
+	}
+
+	*remaining -= int64(len(b))
+
+	if *remaining < 0 {
+		return fmt.Errorf("%w: at most %d bytes", ErrFieldsTooLarge, u.config.MaxFieldsSize)
+	}
+
+	result.Fields.Add(part.FormName(), string(b))
+	return nil
+}
+
+func (u *Uploader) storeFile(ctx context.Context, part *multipart.Part) (File, error) {
+	file := File{
+		DeclaredType: part.Header.Get("Content-Type"),
+		Field:        part.FormName(),
+		Filename:     SanitizeFilename(part.FileName()),
+		OriginalName: part.FileName(),
+	}
+
+	buffered := bufio.NewReaderSize(&limitReader{r: part, remaining: u.config.MaxFileSize}, 512)
+	head, _ := buffered.Peek(512)
+	file.ContentType = http.DetectContentType(head)
+
+	if !u.allowed(file.ContentType) {
+		return file, fmt.Errorf("%w: %s is %s", ErrTypeNotAllowed, file.Filename, file.ContentType)
+	}
+
+	var body io.Reader = buffered
+
+	if strings.HasPrefix(file.ContentType, "image/") {
+		header := &bytes.Buffer{}
+		config, _, err := image.DecodeConfig(io.TeeReader(buffered, header))
+		body = io.MultiReader(header, buffered)
+
+		if errors.Is(err, ErrFileTooLarge) {
+			return file, err
+		}
+
+		if err == nil {
+			file.Width, file.Height = config.Width, config.Height
+		}
+
+		if err = u.checkDimensions(file, err); err != nil {
+			return file, err
+		}
+	}
+
+	file.Key = u.config.KeyFunc(file)
+
+	object, err := u.config.Store.Put(ctx, file.Key, body, storage.PutOptions{
+		ContentType: file.ContentType,
+		Metadata:    map[string]string{"filename": file.Filename},
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrFileTooLarge) {
+			return file, fmt.Errorf("%w: %s is over %d bytes", ErrFileTooLarge, file.Filename, u.config.MaxFileSize)
+		}
+
+		return file, fmt.Errorf("error storing %s: %w", file.Filename, err)
+	}
+
+	file.Size = object.Size
+	return file, nil
+}
+
+func (u *Uploader) allowed(contentType string) bool {
+	if len(u.config.AllowedTypes) == 0 {
+		return true
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	for _, allowed := range u.config.AllowedTypes {
+		if allowed == mediaType || (strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*"))) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (u *Uploader) checkDimensions(file File, decodeErr error) error {
+	if u.config.MaxWidth <= 0 && u.config.MaxHeight <= 0 && u.config.MinWidth <= 0 && u.config.MinHeight <= 0 {
+		return nil
+	}
+
+	if decodeErr != nil {
+		return fmt.Errorf("%w: %s: %s", ErrInvalidImage, file.Filename, decodeErr.Error())
+	}
+
+	tooBig := (u.config.MaxWidth > 0 && file.Width > u.config.MaxWidth) || (u.config.MaxHeight > 0 && file.Height > u.config.MaxHeight)
+	tooSmall := file.Width < u.config.MinWidth || file.Height < u.config.MinHeight
+
+	if tooBig || tooSmall {
+		return fmt.Errorf("%w: %s is %dx%d", ErrImageDimensions, file.Filename, file.Width, file.Height)
+	}
+
+	return nil
+}
+
+/*
+limitReader fails with ErrFileTooLarge once more than remaining bytes
+are read
+*/
+type limitReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+
+	if l.remaining < 0 {
+		return n, ErrFileTooLarge
+	}
+
+	return n, err
+}
+
+func randomID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
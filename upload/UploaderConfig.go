@@ -0,0 +1,40 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package upload
+
+import "github.com/ResurgenceIT/kit/v6/storage"
+
+/*
+UploaderConfig configures an Uploader. Store is where files are
+streamed to, and is required.
+
+AllowedTypes lists the media types files may have, such as "image/png",
+or "image/*" for any image. Types are sniffed from file contents, never
+taken from the client. When empty, any type is allowed.
+
+MaxFileSize defaults to 10 MiB, MaxFiles to 10, and MaxFieldsSize, the
+total size of the form's non-file fields, to 1 MiB.
+
+When any of MaxWidth, MaxHeight, MinWidth, or MinHeight are set, image
+uploads outside those dimensions are rejected, as are images whose
+dimensions can't be read. PNG, JPEG, and GIF are understood.
+
+KeyFunc picks the storage key for a file. It defaults to KeyPrefix, a
+random ID, and the sanitized filename, such as
+"attachments/5f2b9c0e8a1d4e6f/invoice.pdf"
+*/
+type UploaderConfig struct {
+	AllowedTypes  []string
+	KeyFunc       func(file File) string
+	KeyPrefix     string
+	MaxFieldsSize int64
+	MaxFileSize   int64
+	MaxFiles      int
+	MaxHeight     int
+	MaxWidth      int
+	MinHeight     int
+	MinWidth      int
+	Store         storage.IStore
+}
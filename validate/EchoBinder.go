@@ -0,0 +1,51 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package validate
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+/*
+EchoBinder binds requests with Echo's default binder, then validates the
+result, so handlers bind and validate in one step. Binding errors are
+returned as Echo reports them, and validation failures as
+ValidationErrors.
+
+	e.Binder = validate.NewEchoBinder(validator)
+
+	if err := ctx.Bind(&request); err != nil {
+		return err
+	}
+*/
+type EchoBinder struct {
+	binder    echo.DefaultBinder
+	validator *Validator
+}
+
+/*
+NewEchoBinder creates an EchoBinder. A nil validator uses the default
+Validator
+*/
+func NewEchoBinder(validator *Validator) *EchoBinder {
+	if validator == nil {
+		validator = defaultValidator
+	}
+
+	return &EchoBinder{
+		validator: validator,
+	}
+}
+
+/*
+Bind binds the request into i and validates it
+*/
+func (b *EchoBinder) Bind(i interface{}, ctx echo.Context) error {
+	if err := b.binder.Bind(i, ctx); err != nil {
+		return err
+	}
+
+	return b.validator.Struct(i)
+}
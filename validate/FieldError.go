@@ -0,0 +1,58 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package validate
+
+import (
+	"strings"
+)
+
+/*
+FieldError describes one field that failed validation. Field is the
+path to the field using its JSON names, such as "address.city" or
+"items[2].quantity". Rule is the rule that failed, such as "min", and
+Param is the rule's parameter, such as "3". Message is an English
+description of the problem
+*/
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Param   string `json:"param,omitempty"`
+	Rule    string `json:"rule"`
+}
+
+func (e FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+/*
+ValidationErrors is every field that failed validation, in the order the
+fields are declared
+*/
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+
+	for _, fieldError := range e {
+		messages = append(messages, fieldError.Error())
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+/*
+ForField returns the errors for one field
+*/
+func (e ValidationErrors) ForField(field string) []FieldError {
+	result := make([]FieldError, 0)
+
+	for _, fieldError := range e {
+		if fieldError.Field == field {
+			result = append(result, fieldError)
+		}
+	}
+
+	return result
+}
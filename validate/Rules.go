@@ -0,0 +1,142 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+RuleFunc checks a field's value. value is the field with pointers
+dereferenced, and param is the text after "=" in the tag, such as "3"
+for "min=3". It returns false when the value is invalid
+*/
+type RuleFunc func(value interface{}, param string) bool
+
+/*
+Rule is a validation rule. Message describes a failure, and can use %s
+for the rule's parameter
+*/
+type Rule struct {
+	Check   RuleFunc
+	Message string
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func builtInRules() map[string]Rule {
+	return map[string]Rule{
+		"email": {Check: isEmail, Message: "must be a valid email address"},
+		"max":   {Check: isAtMost, Message: "must be at most %s"},
+		"min":   {Check: isAtLeast, Message: "must be at least %s"},
+		"oneof": {Check: isOneOf, Message: "must be one of: %s"},
+		"uuid":  {Check: isUUID, Message: "must be a valid UUID"},
+	}
+}
+
+func isEmail(value interface{}, param string) bool {
+	s, ok := value.(string)
+
+	if !ok {
+		return false
+	}
+
+	address, err := mail.ParseAddress(s)
+	return err == nil && address.Address == s && address.Name == ""
+}
+
+func isUUID(value interface{}, param string) bool {
+	s, ok := value.(string)
+	return ok && uuidPattern.MatchString(s)
+}
+
+func isOneOf(value interface{}, param string) bool {
+	s := fmt.Sprint(value)
+
+	for _, option := range strings.Fields(param) {
+		if s == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isAtLeast(value interface{}, param string) bool {
+	size, limit, ok := measure(value, param)
+	return ok && size >= limit
+}
+
+func isAtMost(value interface{}, param string) bool {
+	size, limit, ok := measure(value, param)
+	return ok && size <= limit
+}
+
+/*
+measure returns what min and max compare: the number of characters in
+a string, the length of a slice or map, or a number's value
+*/
+func measure(value interface{}, param string) (float64, float64, bool) {
+	limit, err := strconv.ParseFloat(param, 64)
+
+	if err != nil {
+		return 0, 0, false
+	}
+
+	v := reflect.ValueOf(value)
+
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), limit, true
+
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), limit, true
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), limit, true
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), limit, true
+
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), limit, true
+	}
+
+	return 0, 0, false
+}
+
+/*
+ruleMessage describes a failed rule. min and max say "characters" or
+"items" when they measure a length
+*/
+func ruleMessage(rule Rule, name, param string, value reflect.Value) string {
+	if !strings.Contains(rule.Message, "%s") {
+		return rule.Message
+	}
+
+	message := fmt.Sprintf(rule.Message, param)
+
+	if name == "min" || name == "max" {
+		switch value.Kind() {
+		case reflect.String:
+			message += " characters"
+
+		case reflect.Slice, reflect.Array, reflect.Map:
+			message += " items"
+		}
+	}
+
+	if name == "oneof" {
+		message = fmt.Sprintf(rule.Message, strings.Join(strings.Fields(param), ", "))
+	}
+
+	return message
+}
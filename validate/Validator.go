@@ -0,0 +1,329 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrNotStruct   = errors.New("validate: value must be a struct or a pointer to one")
+	ErrUnknownRule = errors.New("validate: unknown rule")
+)
+
+/*
+Validator checks structs against the rules in their struct tags. Rules
+are separated by commas:
+
+	type CreateUserRequest struct {
+		Email string   `json:"email" validate:"required,email"`
+		Name  string   `json:"name" validate:"required,min=2,max=100"`
+		Role  string   `json:"role" validate:"oneof=admin member"`
+		Tags  []string `json:"tags" validate:"max=10"`
+	}
+
+Built in rules are required, email, min, max, uuid, and oneof. min and
+max compare the length of strings (in characters), slices, and maps, and
+the value of numbers. Apart from required, rules are skipped when a
+field has its zero value, so optional fields are only checked when set.
+
+Nested structs, pointers to structs, and slices of structs are
+validated too. It is safe for concurrent use
+*/
+type Validator struct {
+	config ValidatorConfig
+	fields sync.Map
+	rules  map[string]Rule
+	mutex  sync.RWMutex
+}
+
+type fieldRule struct {
+	name  string
+	param string
+}
+
+type fieldInfo struct {
+	index    int
+	name     string
+	required bool
+	rules    []fieldRule
+}
+
+var defaultValidator = NewValidator(ValidatorConfig{})
+
+/*
+NewValidator creates a Validator with the built in rules
+*/
+func NewValidator(config ValidatorConfig) *Validator {
+	if config.NameTag == "" {
+		config.NameTag = "json"
+	}
+
+	if config.TagName == "" {
+		config.TagName = "validate"
+	}
+
+	return &Validator{
+		config: config,
+		rules:  builtInRules(),
+	}
+}
+
+/*
+Struct validates value with the default Validator
+*/
+func Struct(value interface{}) error {
+	return defaultValidator.Struct(value)
+}
+
+/*
+Register adds a rule to the default Validator
+*/
+func Register(name string, rule Rule) {
+	defaultValidator.Register(name, rule)
+}
+
+/*
+Register adds a custom rule, or replaces a built in one. Register rules
+before validating structs that use them.
+
+	validator.Register("sku", validate.Rule{
+		Check: func(value interface{}, param string) bool {
+			return skuPattern.MatchString(value.(string))
+		},
+		Message: "must be a valid SKU",
+	})
+*/
+func (v *Validator) Register(name string, rule Rule) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	v.rules[name] = rule
+}
+
+/*
+Validate validates a struct. It lets a Validator be used as Echo's
+validator, so ctx.Validate works:
+
+	e.Validator = validate.NewValidator(validate.ValidatorConfig{})
+*/
+func (v *Validator) Validate(i interface{}) error {
+	return v.Struct(i)
+}
+
+/*
+Struct validates value, which must be a struct or a pointer to one. It
+returns ValidationErrors when any field fails, nil when every field
+passes, or another error when a tag uses a rule that isn't registered
+*/
+func (v *Validator) Struct(value interface{}) error {
+	rv := reflect.ValueOf(value)
+
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return ErrNotStruct
+	}
+
+	result := ValidationErrors{}
+
+	if err := v.validateStruct(rv, "", &result); err != nil {
+		return err
+	}
+
+	if len(result) > 0 {
+		return result
+	}
+
+	return nil
+}
+
+func (v *Validator) validateStruct(rv reflect.Value, prefix string, result *ValidationErrors) error {
+	fields, err := v.fieldsOf(rv.Type())
+
+	if err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		value := rv.Field(field.index)
+		path := field.name
+
+		if prefix != "" {
+			path = prefix + "." + field.name
+		}
+
+		if err = v.validateField(value, path, field, result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (v *Validator) validateField(value reflect.Value, path string, field fieldInfo, result *ValidationErrors) error {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			break
+		}
+
+		value = value.Elem()
+	}
+
+	if isEmpty(value) {
+		if field.required {
+			*result = append(*result, FieldError{Field: path, Message: "is required", Rule: "required"})
+		}
+
+		return nil
+	}
+
+	for _, fieldRule := range field.rules {
+		rule := v.rule(fieldRule.name)
+
+		if !rule.Check(value.Interface(), fieldRule.param) {
+			*result = append(*result, FieldError{
+				Field:   path,
+				Message: ruleMessage(rule, fieldRule.name, fieldRule.param, value),
+				Param:   fieldRule.param,
+				Rule:    fieldRule.name,
+			})
+		}
+	}
+
+	return v.validateNested(value, path, result)
+}
+
+/*
+validateNested validates structs inside a field, including the elements
+of slices and arrays
+*/
+func (v *Validator) validateNested(value reflect.Value, path string, result *ValidationErrors) error {
+	switch value.Kind() {
+	case reflect.Struct:
+		if _, ok := value.Interface().(interface{ IsZero() bool }); ok {
+			return nil
+		}
+
+		return v.validateStruct(value, path, result)
+
+	case reflect.Slice, reflect.Array:
+		for index := 0; index < value.Len(); index++ {
+			element := value.Index(index)
+
+			for element.Kind() == reflect.Ptr && !element.IsNil() {
+				element = element.Elem()
+			}
+
+			if element.Kind() != reflect.Struct {
+				return nil
+			}
+
+			if err := v.validateNested(element, path+"["+strconv.Itoa(index)+"]", result); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (v *Validator) rule(name string) Rule {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	return v.rules[name]
+}
+
+/*
+fieldsOf returns the parsed tags of a struct type's exported fields.
+Results are cached per type
+*/
+func (v *Validator) fieldsOf(t reflect.Type) ([]fieldInfo, error) {
+	if cached, ok := v.fields.Load(t); ok {
+		return cached.([]fieldInfo), nil
+	}
+
+	result := make([]fieldInfo, 0, t.NumField())
+
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	for index := 0; index < t.NumField(); index++ {
+		structField := t.Field(index)
+
+		if structField.PkgPath != "" {
+			continue
+		}
+
+		name := fieldName(structField, v.config.NameTag)
+
+		if name == "-" {
+			continue
+		}
+
+		field := fieldInfo{index: index, name: name, rules: make([]fieldRule, 0)}
+
+		for _, tag := range strings.Split(structField.Tag.Get(v.config.TagName), ",") {
+			tag = strings.TrimSpace(tag)
+
+			if tag == "" {
+				continue
+			}
+
+			if tag == "required" {
+				field.required = true
+				continue
+			}
+
+			ruleName, param := tag, ""
+
+			if equals := strings.Index(tag, "="); equals > -1 {
+				ruleName, param = tag[:equals], tag[equals+1:]
+			}
+
+			if _, ok := v.rules[ruleName]; !ok {
+				return nil, fmt.Errorf("%w %q on %s.%s", ErrUnknownRule, ruleName, t.Name(), structField.Name)
+			}
+
+			field.rules = append(field.rules, fieldRule{name: ruleName, param: param})
+		}
+
+		result = append(result, field)
+	}
+
+	v.fields.Store(t, result)
+	return result, nil
+}
+
+func fieldName(field reflect.StructField, nameTag string) string {
+	if name := strings.Split(field.Tag.Get(nameTag), ",")[0]; name != "" {
+		return name
+	}
+
+	return field.Name
+}
+
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Invalid:
+		return true
+
+	case reflect.Slice, reflect.Map:
+		return value.IsNil() || value.Len() == 0
+
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	}
+
+	return value.IsZero()
+}
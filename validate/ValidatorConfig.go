@@ -0,0 +1,16 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package validate
+
+/*
+ValidatorConfig configures a Validator. TagName is the struct tag rules
+are read from, and defaults to "validate". Field names in errors come
+from the NameTag struct tag, and default to "json", falling back to the
+Go field name
+*/
+type ValidatorConfig struct {
+	NameTag string
+	TagName string
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package validate_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ResurgenceIT/kit/v6/validate"
+	"github.com/labstack/echo/v4"
+)
+
+type address struct {
+	City    string `json:"city" validate:"required"`
+	Country string `json:"country" validate:"required,min=2,max=2"`
+}
+
+type item struct {
+	Quantity int `json:"quantity" validate:"min=1,max=99"`
+}
+
+type createUserRequest struct {
+	Address  *address `json:"address"`
+	Age      int      `json:"age" validate:"min=18"`
+	Email    string   `json:"email" validate:"required,email"`
+	ID       string   `json:"id" validate:"uuid"`
+	Items    []item   `json:"items" validate:"max=3"`
+	Name     string   `json:"name" validate:"required,min=2"`
+	Nickname *string  `json:"nickname" validate:"min=3"`
+	Role     string   `json:"role" validate:"oneof=admin member"`
+	SKU      string   `json:"sku" validate:"sku"`
+}
+
+func newValidator() *validate.Validator {
+	validator := validate.NewValidator(validate.ValidatorConfig{})
+	validator.Register("sku", validate.Rule{
+		Check: func(value interface{}, param string) bool {
+			return strings.HasPrefix(value.(string), "SKU-")
+		},
+		Message: "must be a valid SKU",
+	})
+
+	return validator
+}
+
+func TestStruct(t *testing.T) {
+	short := "al"
+
+	tests := []struct {
+		name     string
+		request  createUserRequest
+		expected []string
+	}{
+		{
+			name:     "Valid",
+			request:  createUserRequest{Email: "bob@example.com", Name: "Bob", Role: "admin", SKU: "SKU-1", Items: []item{{Quantity: 1}}},
+			expected: []string{},
+		},
+		{
+			name:     "Missing required fields",
+			request:  createUserRequest{},
+			expected: []string{"email:required", "name:required"},
+		},
+		{
+			name: "Every rule failing",
+			request: createUserRequest{
+				Age:      12,
+				Email:    "Bob <bob@example.com>",
+				ID:       "not-a-uuid",
+				Name:     "B",
+				Nickname: &short,
+				Role:     "owner",
+				SKU:      "123",
+			},
+			expected: []string{"age:min", "email:email", "id:uuid", "name:min", "nickname:min", "role:oneof", "sku:sku"},
+		},
+		{
+			name: "Nested structs and slices",
+			request: createUserRequest{
+				Address: &address{Country: "USA"},
+				Email:   "bob@example.com",
+				ID:      "0b9e4bd2-8f6c-4c38-9f7a-3d8a3f1b2c4d",
+				Items:   []item{{Quantity: 1}, {Quantity: 100}, {Quantity: 2}, {Quantity: 3}},
+				Name:    "Bob",
+			},
+			expected: []string{"address.city:required", "address.country:max", "items:max", "items[1].quantity:max"},
+		},
+	}
+
+	validator := newValidator()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validator.Struct(&test.request)
+			actual := make([]string, 0)
+
+			if err != nil {
+				var validationErrors validate.ValidationErrors
+
+				if !errors.As(err, &validationErrors) {
+					t.Fatalf("Expected ValidationErrors but got '%s'", err)
+				}
+
+				for _, fieldError := range validationErrors {
+					actual = append(actual, fieldError.Field+":"+fieldError.Rule)
+				}
+			}
+
+			if strings.Join(actual, ",") != strings.Join(test.expected, ",") {
+				t.Errorf("Expected %v but got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestMessages(t *testing.T) {
+	err := newValidator().Struct(createUserRequest{Email: "bob@example.com", Name: "B", Role: "owner", SKU: "SKU-1"})
+	expected := "name must be at least 2 characters; role must be one of: admin, member"
+
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected '%s' but got '%v'", expected, err)
+	}
+}
+
+func TestUnknownRuleAndNotStruct(t *testing.T) {
+	if err := validate.Struct(createUserRequest{}); !errors.Is(err, validate.ErrUnknownRule) {
+		t.Errorf("Expected ErrUnknownRule but got '%v'", err)
+	}
+
+	if err := validate.Struct("hello"); !errors.Is(err, validate.ErrNotStruct) {
+		t.Errorf("Expected ErrNotStruct but got '%v'", err)
+	}
+}
+
+func TestEchoBinder(t *testing.T) {
+	e := echo.New()
+	e.Binder = validate.NewEchoBinder(newValidator())
+
+	tests := []struct {
+		body     string
+		expected string
+	}{
+		{body: `{"email":"bob@example.com","name":"Bob","sku":"SKU-1"}`, expected: ""},
+		{body: `{"email":"bob","name":"Bob"}`, expected: "email must be a valid email address"},
+		{body: `{"email":`, expected: "code=400"},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(test.body))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		ctx := e.NewContext(request, httptest.NewRecorder())
+
+		var body createUserRequest
+		err := ctx.Bind(&body)
+
+		if test.expected == "" && err != nil {
+			t.Errorf("Expected no error for %s but got '%s'", test.body, err)
+		}
+
+		if test.expected != "" && (err == nil || !strings.Contains(err.Error(), test.expected)) {
+			t.Errorf("Expected an error containing '%s' for %s but got '%v'", test.expected, test.body, err)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrDeliveryNotFound = errors.New("webhook delivery not found")
+
+/*
+Delivery is one event on its way to one subscription, with its delivery
+history. Payload is the JSON body that is posted. Dead is set once
+delivery is given up on, after which the delivery is kept for
+inspection and Redeliver, but not tried again
+*/
+type Delivery struct {
+	Attempts       int
+	CreatedAt      time.Time
+	Dead           bool
+	EventID        string
+	EventType      string
+	ID             string
+	LastError      string
+	LastStatus     int
+	NextAttemptAt  time.Time
+	Payload        []byte
+	SubscriptionID string
+}
+
+/*
+IDeliveryStore persists deliveries. Claim must hand each due delivery
+to only one caller, by pushing its NextAttemptAt out by lease, so
+several processes can share a store. Dead deliveries are never claimed.
+Get returns ErrDeliveryNotFound for unknown IDs
+*/
+type IDeliveryStore interface {
+	Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]Delivery, error)
+	DeadLetters(ctx context.Context, subscriptionID string) ([]Delivery, error)
+	Enqueue(ctx context.Context, deliveries ...Delivery) error
+	Get(ctx context.Context, id string) (Delivery, error)
+	Len(ctx context.Context) (int, error)
+	Remove(ctx context.Context, id string) error
+	Update(ctx context.Context, delivery Delivery) error
+}
+
+/*
+MemoryDeliveryStore keeps deliveries in memory. Deliveries are lost
+when the process exits, so use it for tests and development
+*/
+type MemoryDeliveryStore struct {
+	sync.Mutex
+
+	deliveries map[string]Delivery
+}
+
+/*
+NewMemoryDeliveryStore creates an empty in-memory delivery store
+*/
+func NewMemoryDeliveryStore() *MemoryDeliveryStore {
+	return &MemoryDeliveryStore{
+		deliveries: make(map[string]Delivery),
+	}
+}
+
+/*
+Claim returns up to limit deliveries due by now, oldest first
+*/
+func (s *MemoryDeliveryStore) Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]Delivery, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	result := make([]Delivery, 0, limit)
+
+	for _, delivery := range s.deliveries {
+		if !delivery.Dead && !delivery.NextAttemptAt.After(now) {
+			result = append(result, delivery)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].NextAttemptAt.Before(result[j].NextAttemptAt)
+	})
+
+	if len(result) > limit {
+		result = result[:limit]
+	}
+
+	for _, delivery := range result {
+		delivery.NextAttemptAt = now.Add(lease)
+		s.deliveries[delivery.ID] = delivery
+	}
+
+	return result, nil
+}
+
+/*
+DeadLetters returns a subscription's dead deliveries, oldest first. An
+empty subscriptionID returns every dead delivery
+*/
+func (s *MemoryDeliveryStore) DeadLetters(ctx context.Context, subscriptionID string) ([]Delivery, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	result := make([]Delivery, 0)
+
+	for _, delivery := range s.deliveries {
+		if delivery.Dead && (subscriptionID == "" || delivery.SubscriptionID == subscriptionID) {
+			result = append(result, delivery)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+
+	return result, nil
+}
+
+/*
+Enqueue adds deliveries
+*/
+func (s *MemoryDeliveryStore) Enqueue(ctx context.Context, deliveries ...Delivery) error {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, delivery := range deliveries {
+		s.deliveries[delivery.ID] = delivery
+	}
+
+	return nil
+}
+
+/*
+Get returns a delivery
+*/
+func (s *MemoryDeliveryStore) Get(ctx context.Context, id string) (Delivery, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	delivery, ok := s.deliveries[id]
+
+	if !ok {
+		return Delivery{}, ErrDeliveryNotFound
+	}
+
+	return delivery, nil
+}
+
+/*
+Len returns how many deliveries are waiting, not counting dead ones
+*/
+func (s *MemoryDeliveryStore) Len(ctx context.Context) (int, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	result := 0
+
+	for _, delivery := range s.deliveries {
+		if !delivery.Dead {
+			result++
+		}
+	}
+
+	return result, nil
+}
+
+/*
+Remove deletes a delivery
+*/
+func (s *MemoryDeliveryStore) Remove(ctx context.Context, id string) error {
+	s.Lock()
+	delete(s.deliveries, id)
+	s.Unlock()
+
+	return nil
+}
+
+/*
+Update replaces a delivery
+*/
+func (s *MemoryDeliveryStore) Update(ctx context.Context, delivery Delivery) error {
+	s.Lock()
+	s.deliveries[delivery.ID] = delivery
+	s.Unlock()
+
+	return nil
+}
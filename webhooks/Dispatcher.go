@@ -0,0 +1,538 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/httpclient"
+	"github.com/ResurgenceIT/kit/v6/ids"
+	"github.com/ResurgenceIT/kit/v6/randutil"
+	"github.com/ResurgenceIT/kit/v6/retry"
+)
+
+const (
+	/*
+		EventIDHeader carries the event's ID, which is the same on every
+		attempt, so endpoints can drop repeats
+	*/
+	EventIDHeader = "Webhook-ID"
+
+	/*
+		EventTypeHeader carries the event's type
+	*/
+	EventTypeHeader = "Webhook-Event"
+)
+
+/*
+Event is the JSON body posted to subscriptions
+*/
+type Event struct {
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+}
+
+/*
+EndpointStats counts a subscription's deliveries since the dispatcher
+was created
+*/
+type EndpointStats struct {
+	Attempts        uint64    `json:"attempts"`
+	DeadLettered    uint64    `json:"deadLettered"`
+	Delivered       uint64    `json:"delivered"`
+	Failed          uint64    `json:"failed"`
+	LastDeliveredAt time.Time `json:"lastDeliveredAt"`
+	LastError       string    `json:"lastError,omitempty"`
+	LastStatus      int       `json:"lastStatus"`
+	URL             string    `json:"url"`
+}
+
+/*
+Dispatcher manages webhook subscriptions and delivers events to them in
+the background. Each delivery is signed with the subscription's secret
+using an httpclient.HMACSigner, retried with backoff when it fails, and
+dead-lettered when retries run out
+*/
+type Dispatcher struct {
+	sync.Mutex
+
+	config   DispatcherConfig
+	now      func() time.Time
+	retrier  *retry.Retrier
+	shutdown chan bool
+	stats    map[string]*EndpointStats
+}
+
+/*
+NewDispatcher creates a webhook dispatcher. Call Start to deliver in the
+background, or Process to deliver due events once
+*/
+func NewDispatcher(config DispatcherConfig) *Dispatcher {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 10
+	}
+
+	if config.Client == nil {
+		config.Client = httpclient.NewClient(httpclient.ClientConfig{
+			DisableRetry: true,
+			Name:         "webhooks",
+			Timeout:      time.Second * 10,
+		})
+	}
+
+	if config.Deliveries == nil {
+		config.Deliveries = NewMemoryDeliveryStore()
+	}
+
+	if config.Lease <= 0 {
+		config.Lease = time.Minute * 5
+	}
+
+	if config.Name == "" {
+		config.Name = "webhooks"
+	}
+
+	if config.Retry.InitialInterval <= 0 {
+		config.Retry.InitialInterval = time.Second * 30
+	}
+
+	if config.Retry.MaxInterval <= 0 {
+		config.Retry.MaxInterval = time.Hour
+	}
+
+	if config.Retry.MaxAttempts == 0 {
+		config.Retry.MaxAttempts = 10
+	}
+
+	if config.Subscriptions == nil {
+		config.Subscriptions = NewMemorySubscriptionStore()
+	}
+
+	return &Dispatcher{
+		config:  config,
+		now:     time.Now,
+		retrier: retry.NewRetrier(config.Retry),
+		stats:   make(map[string]*EndpointStats),
+	}
+}
+
+/*
+Subscribe adds a subscription, or replaces one with the same ID. The
+URL must be absolute http or https. An ID and a secret are generated
+when empty; return the secret to the subscriber so they can check
+signatures
+*/
+func (d *Dispatcher) Subscribe(ctx context.Context, subscription Subscription) (Subscription, error) {
+	endpoint, err := url.Parse(subscription.URL)
+
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return subscription, fmt.Errorf("%w: URL must be an absolute http or https URL", ErrInvalidSubscription)
+	}
+
+	if subscription.ID == "" {
+		subscription.ID = ids.NewV7().String()
+	}
+
+	if subscription.Secret == "" {
+		if subscription.Secret, err = randutil.Token(32); err != nil {
+			return subscription, fmt.Errorf("error generating webhook secret: %w", err)
+		}
+	}
+
+	if subscription.CreatedAt.IsZero() {
+		subscription.CreatedAt = d.now().UTC()
+	}
+
+	if err = d.config.Subscriptions.Save(ctx, subscription); err != nil {
+		return subscription, fmt.Errorf("error saving webhook subscription: %w", err)
+	}
+
+	return subscription, nil
+}
+
+/*
+Unsubscribe removes a subscription. Deliveries already queued for it
+are dropped when they come due
+*/
+func (d *Dispatcher) Unsubscribe(ctx context.Context, id string) error {
+	if err := d.config.Subscriptions.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	d.Lock()
+	delete(d.stats, id)
+	d.Unlock()
+
+	return nil
+}
+
+/*
+Subscriptions returns every subscription
+*/
+func (d *Dispatcher) Subscriptions(ctx context.Context) ([]Subscription, error) {
+	return d.config.Subscriptions.List(ctx)
+}
+
+/*
+Publish queues an event for every subscription that wants its type, and
+returns the event. data is encoded as JSON
+*/
+func (d *Dispatcher) Publish(ctx context.Context, eventType string, data interface{}) (Event, error) {
+	now := d.now().UTC()
+
+	event := Event{
+		CreatedAt: now,
+		Data:      data,
+		ID:        ids.NewV7().String(),
+		Type:      eventType,
+	}
+
+	payload, err := json.Marshal(event)
+
+	if err != nil {
+		return event, fmt.Errorf("error encoding webhook event: %w", err)
+	}
+
+	subscriptions, err := d.config.Subscriptions.List(ctx)
+
+	if err != nil {
+		return event, fmt.Errorf("error listing webhook subscriptions: %w", err)
+	}
+
+	deliveries := make([]Delivery, 0, len(subscriptions))
+
+	for _, subscription := range subscriptions {
+		if !subscription.Wants(eventType) {
+			continue
+		}
+
+		deliveries = append(deliveries, Delivery{
+			CreatedAt:      now,
+			EventID:        event.ID,
+			EventType:      eventType,
+			ID:             ids.NewV7().String(),
+			NextAttemptAt:  now,
+			Payload:        payload,
+			SubscriptionID: subscription.ID,
+		})
+	}
+
+	if len(deliveries) == 0 {
+		return event, nil
+	}
+
+	if err = d.config.Deliveries.Enqueue(ctx, deliveries...); err != nil {
+		return event, fmt.Errorf("error enqueuing webhook deliveries: %w", err)
+	}
+
+	return event, nil
+}
+
+/*
+DeadLetters returns a subscription's dead deliveries. An empty
+subscriptionID returns every dead delivery
+*/
+func (d *Dispatcher) DeadLetters(ctx context.Context, subscriptionID string) ([]Delivery, error) {
+	return d.config.Deliveries.DeadLetters(ctx, subscriptionID)
+}
+
+/*
+Redeliver queues a dead delivery to be tried again, with a fresh set of
+attempts, such as once the endpoint has been fixed
+*/
+func (d *Dispatcher) Redeliver(ctx context.Context, deliveryID string) error {
+	delivery, err := d.config.Deliveries.Get(ctx, deliveryID)
+
+	if err != nil {
+		return err
+	}
+
+	delivery.Attempts = 0
+	delivery.Dead = false
+	delivery.NextAttemptAt = d.now()
+
+	if err = d.config.Deliveries.Update(ctx, delivery); err != nil {
+		return fmt.Errorf("error updating webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+/*
+Process claims the deliveries that are due and posts them, returning
+how many were claimed. Delivery failures are handled by retrying or
+dead-lettering, so only store errors are returned
+*/
+func (d *Dispatcher) Process(ctx context.Context) (int, error) {
+	now := d.now()
+	deliveries, err := d.config.Deliveries.Claim(ctx, now, d.config.BatchSize, d.config.Lease)
+
+	if err != nil {
+		return 0, fmt.Errorf("error claiming webhook deliveries: %w", err)
+	}
+
+	for _, delivery := range deliveries {
+		if err = d.deliver(ctx, delivery); err != nil {
+			return len(deliveries), err
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+/*
+Start processes deliveries every interval until Stop is called
+*/
+func (d *Dispatcher) Start(interval time.Duration) {
+	d.Lock()
+	defer d.Unlock()
+
+	if d.shutdown != nil {
+		return
+	}
+
+	shutdown := make(chan bool)
+	d.shutdown = shutdown
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-shutdown:
+				return
+
+			case <-ticker.C:
+				if _, err := d.Process(context.Background()); err != nil && d.config.OnError != nil {
+					d.config.OnError(err)
+				}
+			}
+		}
+	}()
+}
+
+/*
+Stop stops the background processing started by Start
+*/
+func (d *Dispatcher) Stop() {
+	d.Lock()
+	defer d.Unlock()
+
+	if d.shutdown == nil {
+		return
+	}
+
+	close(d.shutdown)
+	d.shutdown = nil
+}
+
+/*
+Stats returns delivery counts for each subscription, by ID
+*/
+func (d *Dispatcher) Stats() map[string]EndpointStats {
+	d.Lock()
+	defer d.Unlock()
+
+	result := make(map[string]EndpointStats, len(d.stats))
+
+	for id, stats := range d.stats {
+		result[id] = *stats
+	}
+
+	return result
+}
+
+/*
+Name returns the name the dispatcher's stats are reported under
+*/
+func (d *Dispatcher) Name() string {
+	return d.config.Name
+}
+
+/*
+Collect returns the dispatcher's stats in the form serverstats
+collectors report, so a Dispatcher can be passed to RegisterCollector.
+Endpoints are reported by subscription ID
+*/
+func (d *Dispatcher) Collect(ctx context.Context) map[string]interface{} {
+	endpoints := make(map[string]interface{})
+
+	for id, stats := range d.Stats() {
+		endpoints[id] = stats
+	}
+
+	result := map[string]interface{}{
+		"endpoints": endpoints,
+	}
+
+	if pending, err := d.config.Deliveries.Len(ctx); err == nil {
+		result["pending"] = pending
+	}
+
+	return result
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery Delivery) error {
+	subscription, err := d.config.Subscriptions.Get(ctx, delivery.SubscriptionID)
+
+	if errors.Is(err, ErrSubscriptionNotFound) {
+		return d.remove(ctx, delivery)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error loading webhook subscription: %w", err)
+	}
+
+	status, postErr := d.post(ctx, subscription, delivery)
+
+	delivery.Attempts++
+	delivery.LastStatus = status
+	d.count(subscription, delivery, postErr)
+
+	if postErr == nil {
+		return d.remove(ctx, delivery)
+	}
+
+	delivery.LastError = postErr.Error()
+
+	/*
+	 * 410 Gone means the endpoint doesn't want any more deliveries
+	 */
+	if status == http.StatusGone {
+		subscription.Disabled = true
+
+		if err = d.config.Subscriptions.Save(ctx, subscription); err != nil {
+			return fmt.Errorf("error disabling webhook subscription: %w", err)
+		}
+
+		return d.deadLetter(ctx, subscription, delivery, postErr)
+	}
+
+	if d.config.Retry.MaxAttempts > 0 && delivery.Attempts >= d.config.Retry.MaxAttempts {
+		return d.deadLetter(ctx, subscription, delivery, postErr)
+	}
+
+	delivery.NextAttemptAt = d.now().Add(d.retrier.Backoff(delivery.Attempts, postErr))
+	return d.update(ctx, delivery)
+}
+
+func (d *Dispatcher) post(ctx context.Context, subscription Subscription, delivery Delivery) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+
+	if err != nil {
+		return 0, fmt.Errorf("error creating webhook request: %w", err)
+	}
+
+	/*
+	 * The path is signed, and servers see an empty one as "/"
+	 */
+	if request.URL.Path == "" {
+		request.URL.Path = "/"
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EventIDHeader, delivery.EventID)
+	request.Header.Set(EventTypeHeader, delivery.EventType)
+
+	if err = (httpclient.HMACSigner{Secret: []byte(subscription.Secret)}).Sign(request, delivery.Payload); err != nil {
+		return 0, fmt.Errorf("error signing webhook request: %w", err)
+	}
+
+	response, err := d.config.Client.Do(request)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer response.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(response.Body, 64*1024))
+
+	if response.StatusCode > 299 {
+		return response.StatusCode, &httpclient.ResponseError{StatusCode: response.StatusCode}
+	}
+
+	return response.StatusCode, nil
+}
+
+func (d *Dispatcher) deadLetter(ctx context.Context, subscription Subscription, delivery Delivery, err error) error {
+	delivery.Dead = true
+
+	d.Lock()
+	d.endpoint(subscription).DeadLettered++
+	d.Unlock()
+
+	if updateErr := d.update(ctx, delivery); updateErr != nil {
+		return updateErr
+	}
+
+	if d.config.OnDeadLetter != nil {
+		d.config.OnDeadLetter(delivery, err)
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) count(subscription Subscription, delivery Delivery, err error) {
+	d.Lock()
+	defer d.Unlock()
+
+	stats := d.endpoint(subscription)
+	stats.Attempts++
+	stats.LastStatus = delivery.LastStatus
+
+	if err != nil {
+		stats.Failed++
+		stats.LastError = err.Error()
+		return
+	}
+
+	stats.Delivered++
+	stats.LastDeliveredAt = d.now().UTC()
+	stats.LastError = ""
+}
+
+/*
+endpoint returns a subscription's stats. Callers must hold the lock
+*/
+func (d *Dispatcher) endpoint(subscription Subscription) *EndpointStats {
+	stats, ok := d.stats[subscription.ID]
+
+	if !ok {
+		stats = &EndpointStats{}
+		d.stats[subscription.ID] = stats
+	}
+
+	stats.URL = subscription.URL
+	return stats
+}
+
+func (d *Dispatcher) remove(ctx context.Context, delivery Delivery) error {
+	if err := d.config.Deliveries.Remove(ctx, delivery.ID); err != nil {
+		return fmt.Errorf("error removing webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) update(ctx context.Context, delivery Delivery) error {
+	if err := d.config.Deliveries.Update(ctx, delivery); err != nil {
+		return fmt.Errorf("error updating webhook delivery: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package webhooks
+
+import (
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/httpclient"
+	"github.com/ResurgenceIT/kit/v6/retry"
+)
+
+/*
+DispatcherConfig configures a Dispatcher.
+
+Subscriptions defaults to a MemorySubscriptionStore, and Deliveries to
+a MemoryDeliveryStore. Client posts deliveries, and defaults to an
+httpclient.Client named "webhooks" with a 10 second timeout and its own
+retries turned off, as failed deliveries are retried by the dispatcher.
+
+Retry is the backoff between attempts. It defaults to starting at 30
+seconds, growing to an hour, and giving up after 10 attempts, at which
+point the delivery is dead-lettered and OnDeadLetter is called.
+
+Process claims up to BatchSize deliveries (default 10), which other
+processes sharing the store won't claim for Lease (default 5 minutes).
+OnError is called with store errors from background processing. Name
+identifies the dispatcher's stats in serverstats, and defaults to
+"webhooks"
+*/
+type DispatcherConfig struct {
+	BatchSize     int
+	Client        *httpclient.Client
+	Deliveries    IDeliveryStore
+	Lease         time.Duration
+	Name          string
+	OnDeadLetter  func(delivery Delivery, err error)
+	OnError       func(err error)
+	Retry         retry.RetrierConfig
+	Subscriptions ISubscriptionStore
+}
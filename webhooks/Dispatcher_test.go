@@ -0,0 +1,197 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/httpclient"
+	"github.com/ResurgenceIT/kit/v6/retry"
+	"github.com/ResurgenceIT/kit/v6/webhooks"
+)
+
+type endpoint struct {
+	sync.Mutex
+
+	events   []webhooks.Event
+	statuses []int
+	secret   string
+}
+
+func (e *endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.Lock()
+	defer e.Unlock()
+
+	body, _ := ioutil.ReadAll(r.Body)
+	timestamp := r.Header.Get("X-Signature-Timestamp")
+
+	if r.Header.Get("X-Signature") != httpclient.Signature([]byte(e.secret), timestamp, r.Method, r.URL.EscapedPath(), body) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if len(e.statuses) > 0 {
+		status := e.statuses[0]
+		e.statuses = e.statuses[1:]
+
+		if status > 299 {
+			w.WriteHeader(status)
+			return
+		}
+	}
+
+	event := webhooks.Event{}
+	_ = json.Unmarshal(body, &event)
+
+	if event.ID != r.Header.Get(webhooks.EventIDHeader) || event.Type != r.Header.Get(webhooks.EventTypeHeader) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	e.events = append(e.events, event)
+}
+
+func newDispatcher(deadLetters *[]webhooks.Delivery) *webhooks.Dispatcher {
+	return webhooks.NewDispatcher(webhooks.DispatcherConfig{
+		OnDeadLetter: func(delivery webhooks.Delivery, err error) {
+			*deadLetters = append(*deadLetters, delivery)
+		},
+		Retry: retry.RetrierConfig{InitialInterval: time.Nanosecond, Jitter: -1, MaxAttempts: 3, MaxInterval: time.Nanosecond},
+	})
+}
+
+func processAll(t *testing.T, dispatcher *webhooks.Dispatcher) {
+	for attempt := 0; attempt < 5; attempt++ {
+		time.Sleep(time.Millisecond)
+
+		if _, err := dispatcher.Process(context.Background()); err != nil {
+			t.Fatalf("Expected no error but got %s", err.Error())
+		}
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		eventTypes   []string
+		statuses     []int
+		expected     int
+		deadLettered bool
+		disabled     bool
+	}{
+		{name: "Delivered", eventTypes: []string{"order.created"}, expected: 1},
+		{name: "Every type", eventTypes: []string{"*"}, expected: 1},
+		{name: "Other types", eventTypes: []string{"order.shipped"}, expected: 0},
+		{name: "Retried", statuses: []int{http.StatusInternalServerError, http.StatusBadGateway}, expected: 1},
+		{name: "Dead-lettered", statuses: []int{500, 500, 500}, expected: 0, deadLettered: true},
+		{name: "Gone", statuses: []int{http.StatusGone}, expected: 0, deadLettered: true, disabled: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deadLetters := []webhooks.Delivery{}
+			dispatcher := newDispatcher(&deadLetters)
+
+			receiver := &endpoint{statuses: test.statuses}
+			server := httptest.NewServer(receiver)
+			defer server.Close()
+
+			subscription, err := dispatcher.Subscribe(ctx, webhooks.Subscription{EventTypes: test.eventTypes, URL: server.URL + "/hooks"})
+
+			if err != nil {
+				t.Fatalf("Expected to subscribe but got %s", err.Error())
+			}
+
+			receiver.secret = subscription.Secret
+
+			if _, err = dispatcher.Publish(ctx, "order.created", map[string]string{"id": "1001"}); err != nil {
+				t.Fatalf("Expected to publish but got %s", err.Error())
+			}
+
+			processAll(t, dispatcher)
+
+			if len(receiver.events) != test.expected {
+				t.Errorf("Expected %d events but got %d", test.expected, len(receiver.events))
+			}
+
+			if (len(deadLetters) == 1) != test.deadLettered {
+				t.Errorf("Expected dead-lettered %v but got %v", test.deadLettered, deadLetters)
+			}
+
+			subscriptions, _ := dispatcher.Subscriptions(ctx)
+
+			if subscriptions[0].Disabled != test.disabled {
+				t.Errorf("Expected disabled %v but got %v", test.disabled, subscriptions[0].Disabled)
+			}
+
+			if test.expected > 0 && dispatcher.Stats()[subscription.ID].Attempts != uint64(len(test.statuses)+1) {
+				t.Errorf("Unexpected stats %v", dispatcher.Stats())
+			}
+		})
+	}
+}
+
+func TestDispatcherRedeliver(t *testing.T) {
+	ctx := context.Background()
+	deadLetters := []webhooks.Delivery{}
+	dispatcher := newDispatcher(&deadLetters)
+
+	receiver := &endpoint{statuses: []int{503, 503, 503}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	subscription, _ := dispatcher.Subscribe(ctx, webhooks.Subscription{URL: server.URL})
+	receiver.secret = subscription.Secret
+
+	_, _ = dispatcher.Publish(ctx, "invoice.paid", nil)
+	processAll(t, dispatcher)
+
+	dead, _ := dispatcher.DeadLetters(ctx, subscription.ID)
+
+	if len(dead) != 1 || dead[0].Attempts != 3 || dead[0].LastStatus != 503 {
+		t.Fatalf("Expected a dead letter after 3 attempts but got %v", dead)
+	}
+
+	if err := dispatcher.Redeliver(ctx, dead[0].ID); err != nil {
+		t.Fatalf("Expected to redeliver but got %s", err.Error())
+	}
+
+	processAll(t, dispatcher)
+
+	if dead, _ = dispatcher.DeadLetters(ctx, ""); len(dead) != 0 || len(receiver.events) != 1 {
+		t.Errorf("Expected the redelivered event to arrive but got %d dead letters and %d events", len(dead), len(receiver.events))
+	}
+
+	collected := dispatcher.Collect(ctx)
+	stats := collected["endpoints"].(map[string]interface{})[subscription.ID].(webhooks.EndpointStats)
+
+	if stats.Delivered != 1 || stats.Failed != 3 || stats.DeadLettered != 1 || collected["pending"] != 0 {
+		t.Errorf("Unexpected stats %v", collected)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	dispatcher := webhooks.NewDispatcher(webhooks.DispatcherConfig{})
+
+	for _, url := range []string{"", "/hooks", "ftp://example.com", "https://"} {
+		if _, err := dispatcher.Subscribe(context.Background(), webhooks.Subscription{URL: url}); !errors.Is(err, webhooks.ErrInvalidSubscription) {
+			t.Errorf("Expected ErrInvalidSubscription for '%s' but got %v", url, err)
+		}
+	}
+
+	if err := dispatcher.Unsubscribe(context.Background(), "missing"); !errors.Is(err, webhooks.ErrSubscriptionNotFound) {
+		t.Errorf("Expected ErrSubscriptionNotFound but got %v", err)
+	}
+}
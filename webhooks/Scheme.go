@@ -0,0 +1,216 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/httpclient"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrMissingSignature = errors.New("missing webhook signature")
+	ErrTimestampTooOld  = errors.New("webhook timestamp outside tolerance")
+)
+
+/*
+DefaultTolerance is how far a signed timestamp can be from now before a
+webhook is rejected as a replay
+*/
+const DefaultTolerance = time.Minute * 5
+
+/*
+IScheme checks a received webhook's signature. body is the raw request
+body, and now the time to check signed timestamps against
+*/
+type IScheme interface {
+	Verify(request *http.Request, body []byte, now time.Time) error
+}
+
+/*
+HMACScheme checks signatures made by httpclient.HMACSigner, which is
+how Dispatcher signs deliveries: an HMAC-SHA256 of the timestamp,
+method, path, and body. Header and TimestampHeader default to
+X-Signature and X-Signature-Timestamp, and Tolerance to
+DefaultTolerance
+*/
+type HMACScheme struct {
+	Header          string
+	Secret          []byte
+	TimestampHeader string
+	Tolerance       time.Duration
+}
+
+/*
+Verify checks the signature and that the timestamp is recent
+*/
+func (s HMACScheme) Verify(request *http.Request, body []byte, now time.Time) error {
+	header := s.Header
+	timestampHeader := s.TimestampHeader
+
+	if header == "" {
+		header = "X-Signature"
+	}
+
+	if timestampHeader == "" {
+		timestampHeader = "X-Signature-Timestamp"
+	}
+
+	signature := request.Header.Get(header)
+	timestamp := request.Header.Get(timestampHeader)
+
+	if signature == "" || timestamp == "" {
+		return ErrMissingSignature
+	}
+
+	if err := checkTimestamp(timestamp, now, s.Tolerance); err != nil {
+		return err
+	}
+
+	expected := httpclient.Signature(s.Secret, timestamp, request.Method, request.URL.EscapedPath(), body)
+
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+/*
+StripeScheme checks the Stripe-Signature header Stripe sends, of the
+form "t=<timestamp>,v1=<signature>", where the signature is an
+HMAC-SHA256 of the timestamp, a dot, and the body. During secret
+rotation several v1 signatures are sent, and any can match. Secret is
+the endpoint's signing secret, starting "whsec_", and Tolerance
+defaults to DefaultTolerance
+*/
+type StripeScheme struct {
+	Secret    []byte
+	Tolerance time.Duration
+}
+
+/*
+Verify checks the signature and that the timestamp is recent
+*/
+func (s StripeScheme) Verify(request *http.Request, body []byte, now time.Time) error {
+	header := request.Header.Get("Stripe-Signature")
+
+	if header == "" {
+		return ErrMissingSignature
+	}
+
+	timestamp := ""
+	signatures := make([]string, 0, 2)
+
+	for _, pair := range strings.Split(header, ",") {
+		key, value, ok := cut(strings.TrimSpace(pair), "=")
+
+		switch {
+		case !ok:
+			continue
+
+		case key == "t":
+			timestamp = value
+
+		case key == "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	if timestamp == "" || len(signatures) == 0 {
+		return ErrMissingSignature
+	}
+
+	if err := checkTimestamp(timestamp, now, s.Tolerance); err != nil {
+		return err
+	}
+
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+/*
+GitHubScheme checks the X-Hub-Signature-256 header GitHub sends, of the
+form "sha256=<signature>", an HMAC-SHA256 of the body. GitHub doesn't
+sign a timestamp, so replays can't be detected from the signature;
+check the X-GitHub-Delivery ID if that matters
+*/
+type GitHubScheme struct {
+	Secret []byte
+}
+
+/*
+Verify checks the signature
+*/
+func (s GitHubScheme) Verify(request *http.Request, body []byte, now time.Time) error {
+	header := request.Header.Get("X-Hub-Signature-256")
+
+	if header == "" {
+		return ErrMissingSignature
+	}
+
+	signature := strings.TrimPrefix(header, "sha256=")
+
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+/*
+checkTimestamp checks a Unix timestamp is within tolerance of now,
+either way, to allow for clock skew
+*/
+func checkTimestamp(timestamp string, now time.Time, tolerance time.Duration) error {
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+
+	if err != nil {
+		return fmt.Errorf("%w: bad timestamp %q", ErrInvalidSignature, timestamp)
+	}
+
+	difference := now.Sub(time.Unix(seconds, 0))
+
+	if difference > tolerance || difference < -tolerance {
+		return fmt.Errorf("%w: %s", ErrTimestampTooOld, difference.Round(time.Second))
+	}
+
+	return nil
+}
+
+func cut(s, separator string) (string, string, bool) {
+	if index := strings.Index(s, separator); index >= 0 {
+		return s[:index], s[index+len(separator):], true
+	}
+
+	return s, "", false
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidSubscription  = errors.New("invalid webhook subscription")
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+)
+
+/*
+Subscription is an endpoint that wants events. EventTypes lists the
+event types it gets, and "*" or an empty list means every type. Secret
+signs each delivery, so the endpoint can check it came from you.
+Disabled subscriptions get no new deliveries; a subscription is
+disabled when its endpoint answers 410 Gone
+*/
+type Subscription struct {
+	CreatedAt  time.Time
+	Disabled   bool
+	EventTypes []string
+	ID         string
+	Secret     string
+	URL        string
+}
+
+/*
+Wants reports whether the subscription gets events of eventType
+*/
+func (s Subscription) Wants(eventType string) bool {
+	if s.Disabled {
+		return false
+	}
+
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+
+	for _, wanted := range s.EventTypes {
+		if wanted == "*" || wanted == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+ISubscriptionStore persists subscriptions. Get and Delete return
+ErrSubscriptionNotFound for unknown IDs, and Save inserts or replaces a
+subscription by ID
+*/
+type ISubscriptionStore interface {
+	Delete(ctx context.Context, id string) error
+	Get(ctx context.Context, id string) (Subscription, error)
+	List(ctx context.Context) ([]Subscription, error)
+	Save(ctx context.Context, subscription Subscription) error
+}
+
+/*
+MemorySubscriptionStore keeps subscriptions in memory, for tests and
+development
+*/
+type MemorySubscriptionStore struct {
+	sync.RWMutex
+
+	subscriptions map[string]Subscription
+}
+
+/*
+NewMemorySubscriptionStore creates an empty in-memory subscription store
+*/
+func NewMemorySubscriptionStore() *MemorySubscriptionStore {
+	return &MemorySubscriptionStore{
+		subscriptions: make(map[string]Subscription),
+	}
+}
+
+/*
+Delete removes a subscription
+*/
+func (s *MemorySubscriptionStore) Delete(ctx context.Context, id string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.subscriptions[id]; !ok {
+		return ErrSubscriptionNotFound
+	}
+
+	delete(s.subscriptions, id)
+	return nil
+}
+
+/*
+Get returns a subscription
+*/
+func (s *MemorySubscriptionStore) Get(ctx context.Context, id string) (Subscription, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	subscription, ok := s.subscriptions[id]
+
+	if !ok {
+		return Subscription{}, ErrSubscriptionNotFound
+	}
+
+	return subscription, nil
+}
+
+/*
+List returns every subscription, oldest first
+*/
+func (s *MemorySubscriptionStore) List(ctx context.Context) ([]Subscription, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	result := make([]Subscription, 0, len(s.subscriptions))
+
+	for _, subscription := range s.subscriptions {
+		result = append(result, subscription)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	return result, nil
+}
+
+/*
+Save stores a subscription
+*/
+func (s *MemorySubscriptionStore) Save(ctx context.Context, subscription Subscription) error {
+	s.Lock()
+	s.subscriptions[subscription.ID] = subscription
+	s.Unlock()
+
+	return nil
+}
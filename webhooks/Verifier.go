@@ -0,0 +1,168 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var errBodyTooLarge = errors.New("webhook body too large")
+
+type contextKey struct{}
+
+/*
+Payload is a received webhook whose signature has been checked
+*/
+type Payload struct {
+	Body       []byte
+	ReceivedAt time.Time
+}
+
+/*
+NewPayloadContext returns a copy of ctx holding a verified payload
+*/
+func NewPayloadContext(ctx context.Context, payload Payload) context.Context {
+	return context.WithValue(ctx, contextKey{}, payload)
+}
+
+/*
+PayloadFromContext returns the verified payload of a webhook request,
+and false when the request didn't pass through a Verifier
+*/
+func PayloadFromContext(ctx context.Context) (Payload, bool) {
+	payload, ok := ctx.Value(contextKey{}).(Payload)
+	return payload, ok
+}
+
+/*
+Verifier is middleware that checks the signatures of received webhooks.
+Requests that pass get their verified body in the request context, and
+the body is left readable for the handler
+*/
+type Verifier struct {
+	config VerifierConfig
+	now    func() time.Time
+}
+
+/*
+NewVerifier creates webhook verification middleware
+
+	verifier := webhooks.NewVerifier(webhooks.VerifierConfig{
+		Scheme: webhooks.StripeScheme{Secret: []byte(stripeSecret)},
+	})
+
+	e.POST("/hooks/stripe", stripeHandler, verifier.Middleware)
+*/
+func NewVerifier(config VerifierConfig) *Verifier {
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = 1 << 20
+	}
+
+	return &Verifier{
+		config: config,
+		now:    time.Now,
+	}
+}
+
+/*
+Middleware verifies webhooks for the Echo framework
+*/
+func (v *Verifier) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		request, err := v.verify(ctx.Request())
+
+		if err == nil {
+			ctx.SetRequest(request)
+			return next(ctx)
+		}
+
+		if errors.Is(err, errBodyTooLarge) {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge)
+		}
+
+		if v.config.OnRejected != nil {
+			v.config.OnRejected(ctx.Response(), ctx.Request(), err)
+			return nil
+		}
+
+		return echo.NewHTTPError(http.StatusUnauthorized).SetInternal(err)
+	}
+}
+
+/*
+HTTPMiddleware verifies webhooks for a plain net/http handler
+*/
+func (v *Verifier) HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request, err := v.verify(r)
+
+		if err == nil {
+			next.ServeHTTP(w, request)
+			return
+		}
+
+		if errors.Is(err, errBodyTooLarge) {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if v.config.OnRejected != nil {
+			v.config.OnRejected(w, r, err)
+			return
+		}
+
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	})
+}
+
+/*
+Verify reads a request's body and checks its signature, returning the
+verified payload. Use it from handlers that can't use the middleware
+*/
+func (v *Verifier) Verify(r *http.Request) (Payload, error) {
+	request, err := v.verify(r)
+
+	if err != nil {
+		return Payload{}, err
+	}
+
+	payload, _ := PayloadFromContext(request.Context())
+	return payload, nil
+}
+
+/*
+verify returns a copy of the request with the verified payload in its
+context and its body restored
+*/
+func (v *Verifier) verify(r *http.Request) (*http.Request, error) {
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, v.config.MaxBodySize+1))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(body)) > v.config.MaxBodySize {
+		return nil, errBodyTooLarge
+	}
+
+	now := v.now()
+
+	if err = v.config.Scheme.Verify(r, body, now); err != nil {
+		return nil, err
+	}
+
+	request := r.WithContext(NewPayloadContext(r.Context(), Payload{Body: body, ReceivedAt: now}))
+	request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return request, nil
+}
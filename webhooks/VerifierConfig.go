@@ -0,0 +1,23 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package webhooks
+
+import (
+	"net/http"
+)
+
+/*
+VerifierConfig configures a Verifier.
+
+Scheme checks signatures, such as an HMACScheme, StripeScheme, or
+GitHubScheme. Bodies larger than MaxBodySize, which defaults to 1MB,
+are rejected with a 413. OnRejected writes the response when a webhook
+fails verification, and defaults to a 401
+*/
+type VerifierConfig struct {
+	MaxBodySize int64
+	OnRejected  func(w http.ResponseWriter, r *http.Request, err error)
+	Scheme      IScheme
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package webhooks_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/httpclient"
+	"github.com/ResurgenceIT/kit/v6/webhooks"
+	"github.com/labstack/echo/v4"
+)
+
+var secret = []byte("whsec_test")
+
+func sign(parts ...string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strings.Join(parts, "")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifier(t *testing.T) {
+	body := `{"id": "evt_1"}`
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	hmacRequest := func(timestamp string) *http.Request {
+		request := httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(body))
+		_ = httpclient.HMACSigner{Secret: secret}.Sign(request, []byte(body))
+
+		if timestamp != "" {
+			request.Header.Set("X-Signature", httpclient.Signature(secret, timestamp, http.MethodPost, "/hooks", []byte(body)))
+			request.Header.Set("X-Signature-Timestamp", timestamp)
+		}
+
+		return request
+	}
+
+	tests := []struct {
+		name     string
+		scheme   webhooks.IScheme
+		headers  map[string]string
+		request  *http.Request
+		body     string
+		expected int
+	}{
+		{name: "HMAC", scheme: webhooks.HMACScheme{Secret: secret}, request: hmacRequest(""), expected: http.StatusOK},
+		{name: "HMAC old timestamp", scheme: webhooks.HMACScheme{Secret: secret}, request: hmacRequest(old), expected: http.StatusUnauthorized},
+		{name: "HMAC wrong secret", scheme: webhooks.HMACScheme{Secret: []byte("other")}, request: hmacRequest(""), expected: http.StatusUnauthorized},
+		{name: "Stripe", scheme: webhooks.StripeScheme{Secret: secret}, headers: map[string]string{"Stripe-Signature": "t=" + now + ",v1=" + sign(now, ".", body)}, expected: http.StatusOK},
+		{name: "Stripe rotated secret", scheme: webhooks.StripeScheme{Secret: secret}, headers: map[string]string{"Stripe-Signature": "t=" + now + ",v1=00ff,v1=" + sign(now, ".", body)}, expected: http.StatusOK},
+		{name: "Stripe tampered", scheme: webhooks.StripeScheme{Secret: secret}, headers: map[string]string{"Stripe-Signature": "t=" + now + ",v1=" + sign(now, ".", body)}, body: `{"id": "evt_2"}`, expected: http.StatusUnauthorized},
+		{name: "Stripe old timestamp", scheme: webhooks.StripeScheme{Secret: secret}, headers: map[string]string{"Stripe-Signature": "t=" + old + ",v1=" + sign(old, ".", body)}, expected: http.StatusUnauthorized},
+		{name: "GitHub", scheme: webhooks.GitHubScheme{Secret: secret}, headers: map[string]string{"X-Hub-Signature-256": "sha256=" + sign(body)}, expected: http.StatusOK},
+		{name: "GitHub missing", scheme: webhooks.GitHubScheme{Secret: secret}, expected: http.StatusUnauthorized},
+		{name: "Too large", scheme: webhooks.GitHubScheme{Secret: secret}, body: strings.Repeat("x", 2<<20), expected: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verifier := webhooks.NewVerifier(webhooks.VerifierConfig{Scheme: test.scheme})
+
+			handler := verifier.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				payload, ok := webhooks.PayloadFromContext(r.Context())
+				read, _ := ioutil.ReadAll(r.Body)
+
+				if !ok || string(payload.Body) != body || string(read) != body {
+					t.Errorf("Expected the verified body in the context and request but got '%s' and '%s'", payload.Body, read)
+				}
+			}))
+
+			request := test.request
+
+			if request == nil {
+				requestBody := body
+
+				if test.body != "" {
+					requestBody = test.body
+				}
+
+				request = httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(requestBody))
+			}
+
+			for key, value := range test.headers {
+				request.Header.Set(key, value)
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != test.expected {
+				t.Errorf("Expected status %d but got %d", test.expected, recorder.Code)
+			}
+		})
+	}
+}
+
+func TestVerifierEcho(t *testing.T) {
+	body := `{"action": "opened"}`
+	verifier := webhooks.NewVerifier(webhooks.VerifierConfig{Scheme: webhooks.GitHubScheme{Secret: secret}})
+
+	e := echo.New()
+	e.POST("/hooks/github", func(ctx echo.Context) error {
+		payload, _ := webhooks.PayloadFromContext(ctx.Request().Context())
+		return ctx.Blob(http.StatusOK, "application/json", payload.Body)
+	}, verifier.Middleware)
+
+	for _, signature := range []string{sign(body), "bad"} {
+		request := httptest.NewRequest(http.MethodPost, "/hooks/github", strings.NewReader(body))
+		request.Header.Set("X-Hub-Signature-256", "sha256="+signature)
+
+		recorder := httptest.NewRecorder()
+		e.ServeHTTP(recorder, request)
+
+		if signature == "bad" && recorder.Code != http.StatusUnauthorized {
+			t.Errorf("Expected a bad signature to get a 401 but got %d", recorder.Code)
+		}
+
+		if signature != "bad" && recorder.Body.String() != body {
+			t.Errorf("Expected the payload to be echoed but got %d '%s'", recorder.Code, recorder.Body.String())
+		}
+	}
+}
@@ -70,6 +70,27 @@ func NewPool(config PoolConfig) *Pool {
 	}
 }
 
+/*
+AvailableWorkers returns the number of workers waiting for a job
+*/
+func (p *Pool) AvailableWorkers() int {
+	return len(p.workerQueue)
+}
+
+/*
+MaxWorkers returns the maximum number of workers in the pool
+*/
+func (p *Pool) MaxWorkers() int {
+	return p.config.MaxWorkers
+}
+
+/*
+QueuedJobs returns the number of jobs waiting to be assigned a worker
+*/
+func (p *Pool) QueuedJobs() int {
+	return len(p.jobQueue)
+}
+
 /*
 assignJob attempts to assign a job to a worker, if available. If a
 worker is not available an error is returned
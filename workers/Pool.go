@@ -0,0 +1,314 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	ErrPoolClosed = errors.New("worker pool is shut down")
+	ErrQueueFull  = errors.New("worker pool queue is full")
+)
+
+/*
+Task is a unit of work. The context is canceled if the pool is shut
+down before the task finishes
+*/
+type Task func(ctx context.Context) (interface{}, error)
+
+/*
+Result is the outcome of a task, identified by the ID Submit returned
+*/
+type Result struct {
+	Err   error
+	ID    uint64
+	Value interface{}
+}
+
+/*
+Stats describes a pool's load. Utilization is the fraction of workers
+busy, from 0 to 1
+*/
+type Stats struct {
+	Busy        int     `json:"busy"`
+	Completed   uint64  `json:"completed"`
+	Failed      uint64  `json:"failed"`
+	Queued      int     `json:"queued"`
+	QueueSize   int     `json:"queueSize"`
+	Rejected    uint64  `json:"rejected"`
+	Utilization float64 `json:"utilization"`
+	Workers     int     `json:"workers"`
+}
+
+type queuedTask struct {
+	id   uint64
+	task Task
+}
+
+/*
+Pool runs tasks on a fixed number of workers, queueing tasks that
+arrive while every worker is busy
+*/
+type Pool struct {
+	busy      int64
+	completed uint64
+	failed    uint64
+	nextID    uint64
+	rejected  uint64
+
+	sync.RWMutex
+
+	cancel  context.CancelFunc
+	closed  bool
+	config  PoolConfig
+	ctx     context.Context
+	queue   chan queuedTask
+	results chan Result
+	started bool
+	wg      sync.WaitGroup
+}
+
+/*
+NewPool creates a worker pool. Call Start before submitting tasks
+*/
+func NewPool(config PoolConfig) *Pool {
+	if config.Workers <= 0 {
+		config.Workers = runtime.NumCPU()
+	}
+
+	if config.QueueSize <= 0 {
+		config.QueueSize = 100
+	}
+
+	if config.Name == "" {
+		config.Name = "workers"
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Pool{
+		cancel:  cancel,
+		config:  config,
+		ctx:     ctx,
+		queue:   make(chan queuedTask, config.QueueSize),
+		results: make(chan Result, config.QueueSize),
+	}
+}
+
+/*
+Start starts the workers
+*/
+func (p *Pool) Start() {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.started || p.closed {
+		return
+	}
+
+	p.started = true
+
+	for index := 0; index < p.config.Workers; index++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+}
+
+/*
+Submit queues a task, waiting for room in the queue until ctx is done.
+It returns the ID the task's Result will have
+*/
+func (p *Pool) Submit(ctx context.Context, task Task) (uint64, error) {
+	p.RLock()
+	defer p.RUnlock()
+
+	if p.closed {
+		atomic.AddUint64(&p.rejected, 1)
+		return 0, ErrPoolClosed
+	}
+
+	queued := queuedTask{id: atomic.AddUint64(&p.nextID, 1), task: task}
+
+	select {
+	case p.queue <- queued:
+		return queued.id, nil
+
+	case <-ctx.Done():
+		atomic.AddUint64(&p.rejected, 1)
+		return 0, fmt.Errorf("error submitting task: %w", ctx.Err())
+	}
+}
+
+/*
+TrySubmit queues a task without waiting, returning ErrQueueFull when
+there is no room
+*/
+func (p *Pool) TrySubmit(task Task) (uint64, error) {
+	p.RLock()
+	defer p.RUnlock()
+
+	if p.closed {
+		atomic.AddUint64(&p.rejected, 1)
+		return 0, ErrPoolClosed
+	}
+
+	queued := queuedTask{id: atomic.AddUint64(&p.nextID, 1), task: task}
+
+	select {
+	case p.queue <- queued:
+		return queued.id, nil
+
+	default:
+		atomic.AddUint64(&p.rejected, 1)
+		return 0, ErrQueueFull
+	}
+}
+
+/*
+Results returns the channel task results are sent on when
+CollectResults is set. It is closed once the pool has shut down
+*/
+func (p *Pool) Results() <-chan Result {
+	return p.results
+}
+
+/*
+Shutdown stops accepting tasks and waits for queued and running tasks
+to finish. If ctx is done first, running tasks have their context
+canceled, tasks still queued are dropped with context.Canceled as
+their error, and ctx's error is returned
+*/
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.Lock()
+
+	if p.closed {
+		p.Unlock()
+		return nil
+	}
+
+	p.closed = true
+	close(p.queue)
+
+	if !p.started {
+		p.started = true
+
+		for index := 0; index < p.config.Workers; index++ {
+			p.wg.Add(1)
+			go p.work()
+		}
+	}
+
+	p.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+		p.wg.Wait()
+		close(p.results)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+
+	case <-ctx.Done():
+		p.cancel()
+		return fmt.Errorf("error draining worker pool: %w", ctx.Err())
+	}
+}
+
+/*
+Stats returns the pool's current load and counts
+*/
+func (p *Pool) Stats() Stats {
+	busy := int(atomic.LoadInt64(&p.busy))
+
+	return Stats{
+		Busy:        busy,
+		Completed:   atomic.LoadUint64(&p.completed),
+		Failed:      atomic.LoadUint64(&p.failed),
+		Queued:      len(p.queue),
+		QueueSize:   p.config.QueueSize,
+		Rejected:    atomic.LoadUint64(&p.rejected),
+		Utilization: float64(busy) / float64(p.config.Workers),
+		Workers:     p.config.Workers,
+	}
+}
+
+/*
+Name returns the name the pool's stats are reported under
+*/
+func (p *Pool) Name() string {
+	return p.config.Name
+}
+
+/*
+Collect returns the pool's stats in the form serverstats collectors
+report, so a Pool can be passed to RegisterCollector
+*/
+func (p *Pool) Collect(ctx context.Context) map[string]interface{} {
+	stats := p.Stats()
+
+	return map[string]interface{}{
+		"busy":        stats.Busy,
+		"completed":   stats.Completed,
+		"failed":      stats.Failed,
+		"queueDepth":  stats.Queued,
+		"queueSize":   stats.QueueSize,
+		"rejected":    stats.Rejected,
+		"utilization": stats.Utilization,
+		"workers":     stats.Workers,
+	}
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+
+	for queued := range p.queue {
+		var (
+			err   error
+			value interface{}
+		)
+
+		if err = p.ctx.Err(); err == nil {
+			atomic.AddInt64(&p.busy, 1)
+			value, err = run(p.ctx, queued.task)
+			atomic.AddInt64(&p.busy, -1)
+
+			atomic.AddUint64(&p.completed, 1)
+		}
+
+		if err != nil {
+			atomic.AddUint64(&p.failed, 1)
+		}
+
+		if p.config.CollectResults {
+			p.results <- Result{Err: err, ID: queued.id, Value: value}
+		} else if err != nil && p.config.OnError != nil {
+			p.config.OnError(queued.id, err)
+		}
+	}
+}
+
+/*
+run runs a task, turning a panic into an error
+*/
+func run(ctx context.Context, task Task) (value interface{}, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("task panicked: %v", recovered)
+		}
+	}()
+
+	return task(ctx)
+}
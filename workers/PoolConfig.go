@@ -0,0 +1,22 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package workers
+
+/*
+PoolConfig configures a Pool. Workers is how many tasks run at once, and
+defaults to the number of CPUs. QueueSize is how many tasks can wait
+for a worker, and defaults to 100. When CollectResults is set, every
+task's Result is sent on the Results channel, which must be read.
+Otherwise results are dropped, and OnError, when set, is called with
+each task's error. Name identifies the pool's stats in serverstats, and
+defaults to "workers"
+*/
+type PoolConfig struct {
+	CollectResults bool
+	Name           string
+	OnError        func(id uint64, err error)
+	QueueSize      int
+	Workers        int
+}
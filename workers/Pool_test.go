@@ -0,0 +1,136 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package workers_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/workers"
+)
+
+var errOdd = errors.New("odd")
+
+func TestPoolResults(t *testing.T) {
+	pool := workers.NewPool(workers.PoolConfig{CollectResults: true, QueueSize: 10, Workers: 3})
+	pool.Start()
+
+	ids := make(map[uint64]int)
+
+	for index := 0; index < 10; index++ {
+		n := index
+
+		id, err := pool.Submit(context.Background(), func(ctx context.Context) (interface{}, error) {
+			if n == 7 {
+				panic("seven")
+			}
+
+			if n%2 == 1 {
+				return nil, errOdd
+			}
+
+			return n * n, nil
+		})
+
+		if err != nil {
+			t.Fatalf("Expected no error but got '%v'", err)
+		}
+
+		ids[id] = n
+	}
+
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Expected no error but got '%v'", err)
+	}
+
+	count := 0
+
+	for result := range pool.Results() {
+		count++
+		n := ids[result.ID]
+
+		switch {
+		case n == 7:
+			if result.Err == nil || errors.Is(result.Err, errOdd) {
+				t.Errorf("Expected the panic to be returned as an error but got '%v'", result.Err)
+			}
+
+		case n%2 == 1:
+			if !errors.Is(result.Err, errOdd) {
+				t.Errorf("Expected errOdd for %d but got '%v'", n, result.Err)
+			}
+
+		default:
+			if result.Value != n*n {
+				t.Errorf("Expected %d for %d but got %v", n*n, n, result.Value)
+			}
+		}
+	}
+
+	if count != 10 {
+		t.Errorf("Expected 10 results but got %d", count)
+	}
+
+	stats := pool.Stats()
+
+	if stats.Completed != 10 || stats.Failed != 5 {
+		t.Errorf("Expected 10 completed and 5 failed but got %+v", stats)
+	}
+
+	if _, err := pool.Submit(context.Background(), nil); !errors.Is(err, workers.ErrPoolClosed) {
+		t.Errorf("Expected ErrPoolClosed but got '%v'", err)
+	}
+}
+
+func TestPoolBackpressure(t *testing.T) {
+	release := make(chan bool)
+	pool := workers.NewPool(workers.PoolConfig{QueueSize: 1, Workers: 1})
+	pool.Start()
+
+	block := func(ctx context.Context) (interface{}, error) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+
+		return nil, ctx.Err()
+	}
+
+	_, _ = pool.Submit(context.Background(), block)
+
+	for pool.Stats().Busy != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := pool.TrySubmit(block); err != nil {
+		t.Fatalf("Expected the queue to have room but got '%v'", err)
+	}
+
+	if _, err := pool.TrySubmit(block); !errors.Is(err, workers.ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull but got '%v'", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+
+	if _, err := pool.Submit(ctx, block); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Submit to give up when ctx is done but got '%v'", err)
+	}
+
+	stats := pool.Stats()
+
+	if stats.Utilization != 1 || stats.Queued != 1 || stats.Rejected != 2 {
+		t.Errorf("Expected a busy pool with 1 queued and 2 rejected but got %+v", stats)
+	}
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer shutdownCancel()
+
+	if err := pool.Shutdown(shutdownCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Shutdown to time out but got '%v'", err)
+	}
+}
@@ -0,0 +1,235 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/httpserver"
+	"github.com/gorilla/websocket"
+)
+
+/*
+Conn is a client connected to a Hub. Identity is the user who connected,
+when the request had a valid bearer token. Messages are queued by Send
+and written by the connection's own goroutine, so Send never blocks
+*/
+type Conn struct {
+	ConnectedAt time.Time
+	ID          string
+	Identity    httpserver.Identity
+	RemoteAddr  string
+
+	cancel   context.CancelFunc
+	ctx      context.Context
+	done     chan struct{}
+	hub      *Hub
+	metadata map[string]interface{}
+	mutex    sync.Mutex
+	once     sync.Once
+	rooms    map[string]struct{}
+	send     chan []byte
+	socket   *websocket.Conn
+	closing  closeMessage
+}
+
+type closeMessage struct {
+	code int
+	text string
+}
+
+/*
+Send queues a message to be written to the client. ErrClosed is returned
+once the connection is closed, and ErrQueueFull when the client isn't
+keeping up
+*/
+func (c *Conn) Send(message []byte) error {
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+
+	select {
+	case c.send <- message:
+		c.hub.stats.send()
+		return nil
+
+	default:
+	}
+
+	c.hub.stats.drop()
+
+	if !c.hub.config.DropWhenFull {
+		c.hub.stats.slow()
+		c.closeWith(websocket.ClosePolicyViolation, "too slow")
+	}
+
+	return fmt.Errorf("%w: %s", ErrQueueFull, c.ID)
+}
+
+/*
+SendJSON queues a value, encoded as JSON, to be written to the client
+*/
+func (c *Conn) SendJSON(v interface{}) error {
+	b, err := json.Marshal(v)
+
+	if err != nil {
+		return fmt.Errorf("error encoding message: %w", err)
+	}
+
+	return c.Send(b)
+}
+
+/*
+Join adds the connection to a room, so it gets what's published to it
+*/
+func (c *Conn) Join(room string) {
+	c.hub.join(c, room)
+}
+
+/*
+Leave removes the connection from a room
+*/
+func (c *Conn) Leave(room string) {
+	c.hub.leave(c, room)
+}
+
+/*
+Rooms returns the rooms the connection is in
+*/
+func (c *Conn) Rooms() []string {
+	c.hub.mutex.RLock()
+	defer c.hub.mutex.RUnlock()
+
+	result := make([]string, 0, len(c.rooms))
+
+	for room := range c.rooms {
+		result = append(result, room)
+	}
+
+	return result
+}
+
+/*
+Set stores a value on the connection, such as the tenant it belongs to
+*/
+func (c *Conn) Set(key string, value interface{}) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.metadata[key] = value
+}
+
+/*
+Get returns a value stored with Set, or nil
+*/
+func (c *Conn) Get(key string) interface{} {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.metadata[key]
+}
+
+/*
+Context returns a context carrying the values of the request the client
+connected with, such as its identity and request ID. It's canceled when
+the connection closes
+*/
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+/*
+Close closes the connection normally. Messages already queued are
+dropped
+*/
+func (c *Conn) Close() {
+	c.closeWith(websocket.CloseNormalClosure, "")
+}
+
+func (c *Conn) closeWith(code int, text string) {
+	c.once.Do(func() {
+		c.closing = closeMessage{code: code, text: text}
+		close(c.done)
+		c.cancel()
+	})
+}
+
+/*
+readLoop reads messages until the client goes away, passing each to
+OnMessage. Pongs push the read deadline back
+*/
+func (c *Conn) readLoop() {
+	config := c.hub.config
+
+	c.socket.SetReadLimit(config.MaxMessageSize)
+	_ = c.socket.SetReadDeadline(time.Now().Add(config.PongTimeout))
+
+	c.socket.SetPongHandler(func(string) error {
+		return c.socket.SetReadDeadline(time.Now().Add(config.PongTimeout))
+	})
+
+	for {
+		_, message, err := c.socket.ReadMessage()
+
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived) && config.Logger != nil {
+				config.Logger.WithError(err).WithField("connectionID", c.ID).Warn("websocket read failed")
+			}
+
+			c.Close()
+			return
+		}
+
+		c.hub.stats.receive()
+
+		if config.OnMessage != nil {
+			config.OnMessage(c, message)
+		}
+	}
+}
+
+/*
+writeLoop writes queued messages and pings. It's the only writer, as
+websocket connections support one writer at a time. When the connection
+closes it sends a close message and closes the socket
+*/
+func (c *Conn) writeLoop() {
+	config := c.hub.config
+	ticker := time.NewTicker(config.PingInterval)
+
+	defer func() {
+		ticker.Stop()
+		_ = c.socket.Close()
+	}()
+
+	for {
+		select {
+		case message := <-c.send:
+			_ = c.socket.SetWriteDeadline(time.Now().Add(config.WriteTimeout))
+
+			if err := c.socket.WriteMessage(websocket.TextMessage, message); err != nil {
+				c.Close()
+				return
+			}
+
+		case <-ticker.C:
+			if err := c.socket.WriteControl(websocket.PingMessage, nil, time.Now().Add(config.WriteTimeout)); err != nil {
+				c.Close()
+				return
+			}
+
+		case <-c.done:
+			message := websocket.FormatCloseMessage(c.closing.code, c.closing.text)
+			_ = c.socket.WriteControl(websocket.CloseMessage, message, time.Now().Add(config.WriteTimeout))
+			return
+		}
+	}
+}
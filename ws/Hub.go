@@ -0,0 +1,374 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/httpserver"
+	"github.com/ResurgenceIT/kit/v6/ids"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	ErrClosed            = errors.New("connection closed")
+	ErrQueueFull         = errors.New("send queue full")
+	ErrUnknownConnection = errors.New("unknown connection")
+)
+
+/*
+Hub accepts WebSocket connections and routes messages to them. Messages
+can go to every connection, the connections in a room, the connections
+of one user, or a single connection
+*/
+type Hub struct {
+	closing  bool
+	config   HubConfig
+	conns    map[string]*Conn
+	mutex    sync.RWMutex
+	rooms    map[string]map[*Conn]struct{}
+	stats    *statsTracker
+	upgrader websocket.Upgrader
+	users    map[string]map[*Conn]struct{}
+	wg       sync.WaitGroup
+}
+
+/*
+NewHub creates a Hub, filling in defaults for anything not set in config
+*/
+func NewHub(config HubConfig) *Hub {
+	if config.MaxMessageSize <= 0 {
+		config.MaxMessageSize = 64 * 1024
+	}
+
+	if config.Name == "" {
+		config.Name = "ws"
+	}
+
+	if config.PingInterval <= 0 {
+		config.PingInterval = time.Second * 30
+	}
+
+	if config.PongTimeout <= 0 {
+		config.PongTimeout = time.Second * 60
+	}
+
+	if config.SendQueueSize <= 0 {
+		config.SendQueueSize = 64
+	}
+
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = time.Second * 10
+	}
+
+	result := &Hub{
+		config: config,
+		conns:  make(map[string]*Conn),
+		rooms:  make(map[string]map[*Conn]struct{}),
+		stats:  &statsTracker{},
+		users:  make(map[string]map[*Conn]struct{}),
+	}
+
+	if len(config.AllowedOrigins) > 0 {
+		result.upgrader.CheckOrigin = result.checkOrigin
+	}
+
+	return result
+}
+
+/*
+ServeHTTP upgrades a request to a WebSocket connection and serves it
+until the client goes away
+*/
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, _ := httpserver.IdentityFromContext(r.Context())
+
+	if h.config.RequireIdentity && user.UserID == "" {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	h.mutex.Lock()
+
+	if h.closing {
+		h.mutex.Unlock()
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	h.wg.Add(1)
+	h.mutex.Unlock()
+
+	defer h.wg.Done()
+
+	socket, err := h.upgrader.Upgrade(w, r, nil)
+
+	if err != nil {
+		// The upgrader has already written an error response
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+
+	conn := &Conn{
+		ConnectedAt: time.Now(),
+		ID:          ids.NewV7().String(),
+		Identity:    user,
+		RemoteAddr:  r.RemoteAddr,
+		cancel:      cancel,
+		ctx:         ctx,
+		done:        make(chan struct{}),
+		hub:         h,
+		metadata:    make(map[string]interface{}),
+		rooms:       make(map[string]struct{}),
+		send:        make(chan []byte, h.config.SendQueueSize),
+		socket:      socket,
+	}
+
+	h.add(conn)
+	go conn.writeLoop()
+
+	if h.config.OnConnect != nil {
+		if err = h.config.OnConnect(conn); err != nil {
+			conn.closeWith(websocket.ClosePolicyViolation, "connection refused")
+		}
+	}
+
+	conn.readLoop()
+	h.remove(conn)
+
+	if h.config.OnDisconnect != nil {
+		h.config.OnDisconnect(conn)
+	}
+}
+
+/*
+EchoHandler serves WebSocket connections for the Echo framework
+
+	e.GET("/ws", hub.EchoHandler)
+*/
+func (h *Hub) EchoHandler(ctx echo.Context) error {
+	h.ServeHTTP(ctx.Response(), ctx.Request())
+	return nil
+}
+
+/*
+Broadcast sends a message to every connection, returning how many it was
+queued for
+*/
+func (h *Hub) Broadcast(message []byte) int {
+	h.mutex.RLock()
+	conns := make([]*Conn, 0, len(h.conns))
+
+	for _, conn := range h.conns {
+		conns = append(conns, conn)
+	}
+
+	h.mutex.RUnlock()
+
+	return sendAll(conns, message)
+}
+
+/*
+Publish sends a message to every connection in a room, returning how
+many it was queued for
+*/
+func (h *Hub) Publish(room string, message []byte) int {
+	return sendAll(h.Members(room), message)
+}
+
+/*
+SendToUser sends a message to every connection a user has open, such as
+one per browser tab, returning how many it was queued for
+*/
+func (h *Hub) SendToUser(userID string, message []byte) int {
+	h.mutex.RLock()
+	conns := setToSlice(h.users[userID])
+	h.mutex.RUnlock()
+
+	return sendAll(conns, message)
+}
+
+/*
+SendTo sends a message to a single connection. ErrUnknownConnection is
+returned when the connection isn't connected to this hub
+*/
+func (h *Hub) SendTo(connectionID string, message []byte) error {
+	conn, ok := h.Connection(connectionID)
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownConnection, connectionID)
+	}
+
+	return conn.Send(message)
+}
+
+/*
+Connection returns a connection by its ID
+*/
+func (h *Hub) Connection(connectionID string) (*Conn, bool) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	conn, ok := h.conns[connectionID]
+	return conn, ok
+}
+
+/*
+Members returns the connections in a room
+*/
+func (h *Hub) Members(room string) []*Conn {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	return setToSlice(h.rooms[room])
+}
+
+/*
+Shutdown stops accepting connections, closes every connection with a
+going away status, and waits for them to finish or ctx to be done. It
+can be registered with the lifecycle package
+*/
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.mutex.Lock()
+	h.closing = true
+
+	for _, conn := range h.conns {
+		conn.closeWith(websocket.CloseGoingAway, "server shutting down")
+	}
+
+	h.mutex.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (h *Hub) add(conn *Conn) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.conns[conn.ID] = conn
+
+	if conn.Identity.UserID != "" {
+		addToSet(h.users, conn.Identity.UserID, conn)
+	}
+
+	h.stats.connect()
+}
+
+func (h *Hub) remove(conn *Conn) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	delete(h.conns, conn.ID)
+
+	if conn.Identity.UserID != "" {
+		removeFromSet(h.users, conn.Identity.UserID, conn)
+	}
+
+	for room := range conn.rooms {
+		removeFromSet(h.rooms, room, conn)
+	}
+
+	conn.rooms = map[string]struct{}{}
+}
+
+func (h *Hub) join(conn *Conn, room string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	// A connection that has gone away can't rejoin, or it would stay in the room forever
+	if _, ok := h.conns[conn.ID]; !ok {
+		return
+	}
+
+	conn.rooms[room] = struct{}{}
+	addToSet(h.rooms, room, conn)
+}
+
+func (h *Hub) leave(conn *Conn, room string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	delete(conn.rooms, room)
+	removeFromSet(h.rooms, room, conn)
+}
+
+/*
+checkOrigin allows requests without an Origin header, which don't come
+from browsers, and requests from an allowed origin
+*/
+func (h *Hub) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+
+	if origin == "" {
+		return true
+	}
+
+	for _, allowed := range h.config.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sendAll(conns []*Conn, message []byte) int {
+	result := 0
+
+	for _, conn := range conns {
+		if conn.Send(message) == nil {
+			result++
+		}
+	}
+
+	return result
+}
+
+func addToSet(sets map[string]map[*Conn]struct{}, key string, conn *Conn) {
+	if _, ok := sets[key]; !ok {
+		sets[key] = make(map[*Conn]struct{})
+	}
+
+	sets[key][conn] = struct{}{}
+}
+
+func removeFromSet(sets map[string]map[*Conn]struct{}, key string, conn *Conn) {
+	delete(sets[key], conn)
+
+	if len(sets[key]) == 0 {
+		delete(sets, key)
+	}
+}
+
+func setToSlice(set map[*Conn]struct{}) []*Conn {
+	result := make([]*Conn, 0, len(set))
+
+	for conn := range set {
+		result = append(result, conn)
+	}
+
+	return result
+}
@@ -0,0 +1,54 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ws
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+/*
+HubConfig configures a Hub.
+
+AllowedOrigins are the origins, such as "https://app.example.com", that
+may connect from a browser. When empty, only pages from the same host as
+the request can connect. Use "*" to allow any origin. RequireIdentity
+refuses connections without a valid bearer token, as read by the
+httpserver identity middleware.
+
+Each connection has a queue of SendQueueSize messages, 64 by default,
+waiting to be written. When a client reads too slowly and its queue
+fills, the connection is closed so it can't hold the hub back. Set
+DropWhenFull to drop the message instead.
+
+PingInterval is how often clients are pinged, 30 seconds by default.
+Clients that don't answer within PongTimeout, 60 seconds by default, are
+disconnected. WriteTimeout bounds each write, and defaults to 10
+seconds. Messages larger than MaxMessageSize bytes, 64 KiB by default,
+close the connection.
+
+OnConnect is called when a client connects, such as to join it to rooms.
+Returning an error closes the connection. OnMessage is called with each
+message a client sends, one at a time for each connection, and
+OnDisconnect when it goes away. Logger, when set, logs connection
+errors. Name identifies the hub's stats in serverstats, and defaults to
+"ws"
+*/
+type HubConfig struct {
+	AllowedOrigins  []string
+	DropWhenFull    bool
+	Logger          logrus.FieldLogger
+	MaxMessageSize  int64
+	Name            string
+	OnConnect       func(conn *Conn) error
+	OnDisconnect    func(conn *Conn)
+	OnMessage       func(conn *Conn, message []byte)
+	PingInterval    time.Duration
+	PongTimeout     time.Duration
+	RequireIdentity bool
+	SendQueueSize   int
+	WriteTimeout    time.Duration
+}
@@ -0,0 +1,246 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ws_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/httpserver"
+	"github.com/ResurgenceIT/kit/v6/identity"
+	"github.com/ResurgenceIT/kit/v6/ws"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+)
+
+func newServer(t *testing.T, hub *ws.Hub) (*httptest.Server, map[string]string) {
+	jwtService := identity.NewJWTService(identity.JWTServiceConfig{
+		AuthSalt:         "salt",
+		AuthSecret:       "secret",
+		Issuer:           "issuer",
+		TimeoutInMinutes: 5,
+	})
+
+	tokens := map[string]string{}
+
+	for _, user := range []string{"alice", "bob"} {
+		tokens[user], _ = jwtService.CreateToken(identity.CreateTokenRequest{UserID: user, UserName: user})
+	}
+
+	server, err := httpserver.NewHTTP(hub, httpserver.Options{IdentityOptional: true, JWTService: jwtService})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	return httptest.NewServer(server.HTTPServer.Handler), tokens
+}
+
+func dial(t *testing.T, url, token string, header http.Header) (*websocket.Conn, *http.Response, error) {
+	if header == nil {
+		header = http.Header{}
+	}
+
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+
+	return websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(url, "http"), header)
+}
+
+func read(t *testing.T, conn *websocket.Conn) string {
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second * 2))
+	_, message, err := conn.ReadMessage()
+
+	if err != nil {
+		t.Fatalf("Expected a message but got %v", err)
+	}
+
+	return string(message)
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(time.Second * 5)
+
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting")
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func TestHub(t *testing.T) {
+	hub := ws.NewHub(ws.HubConfig{
+		OnConnect: func(conn *ws.Conn) error {
+			conn.Join("lobby")
+
+			if conn.Identity.UserID == "alice" {
+				conn.Join("admins")
+			}
+
+			return nil
+		},
+		OnMessage: func(conn *ws.Conn, message []byte) {
+			_ = conn.Send([]byte(conn.Identity.UserID + ": " + string(message)))
+		},
+	})
+
+	server, tokens := newServer(t, hub)
+	defer server.Close()
+
+	alice1, _, _ := dial(t, server.URL, tokens["alice"], nil)
+	alice2, _, _ := dial(t, server.URL, tokens["alice"], nil)
+	bob, _, _ := dial(t, server.URL, tokens["bob"], nil)
+	anonymous, _, err := dial(t, server.URL, "", nil)
+
+	if err != nil {
+		t.Fatalf("Expected to connect but got %v", err)
+	}
+
+	waitFor(t, func() bool { return hub.Stats().Connections == 4 })
+
+	if count := hub.Broadcast([]byte("hello")); count != 4 {
+		t.Errorf("Expected a broadcast to 4 but got %d", count)
+	}
+
+	for _, conn := range []*websocket.Conn{alice1, alice2, bob, anonymous} {
+		if message := read(t, conn); message != "hello" {
+			t.Errorf("Expected hello but got %s", message)
+		}
+	}
+
+	if count := hub.Publish("admins", []byte("admins only")); count != 2 || read(t, alice1) != "admins only" || read(t, alice2) != "admins only" {
+		t.Errorf("Expected the room to get the message but got %d", count)
+	}
+
+	if count := hub.SendToUser("bob", []byte("hi bob")); count != 1 || read(t, bob) != "hi bob" {
+		t.Errorf("Expected bob to get the message but got %d", count)
+	}
+
+	_ = bob.WriteMessage(websocket.TextMessage, []byte("ping"))
+
+	if message := read(t, bob); message != "bob: ping" {
+		t.Errorf("Expected an echo but got %s", message)
+	}
+
+	stats := hub.Stats()
+
+	if stats.Rooms != 2 || stats.Users != 2 || stats.Received != 1 || stats.TotalConnections != 4 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	members := hub.Members("admins")
+	rooms := members[0].Rooms()
+	sort.Strings(rooms)
+
+	if len(members) != 2 || strings.Join(rooms, ",") != "admins,lobby" {
+		t.Errorf("Unexpected members %v in %v", members, rooms)
+	}
+
+	if err := hub.SendTo(members[0].ID, []byte("direct")); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+
+	if err := hub.SendTo("missing", []byte("direct")); !errors.Is(err, ws.ErrUnknownConnection) {
+		t.Errorf("Expected ErrUnknownConnection but got %v", err)
+	}
+
+	_ = alice1.Close()
+	_ = alice2.Close()
+
+	waitFor(t, func() bool { return hub.Stats().Connections == 2 })
+
+	if len(hub.Members("admins")) != 0 || hub.Stats().Users != 1 {
+		t.Errorf("Expected alice to be gone but got %+v", hub.Stats())
+	}
+}
+
+func TestHubRejects(t *testing.T) {
+	hub := ws.NewHub(ws.HubConfig{AllowedOrigins: []string{"https://app.example.com"}, RequireIdentity: true})
+	server, tokens := newServer(t, hub)
+	defer server.Close()
+
+	if _, response, err := dial(t, server.URL, "", nil); err == nil || response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected a 401 without a token")
+	}
+
+	if _, response, err := dial(t, server.URL, tokens["bob"], http.Header{"Origin": {"https://evil.example.com"}}); err == nil || response.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected a 403 from another origin")
+	}
+
+	conn, _, err := dial(t, server.URL, tokens["bob"], http.Header{"Origin": {"https://app.example.com"}})
+
+	if err != nil {
+		t.Fatalf("Expected an allowed origin to connect but got %v", err)
+	}
+
+	_ = conn.Close()
+}
+
+func TestHubSlowConsumer(t *testing.T) {
+	hub := ws.NewHub(ws.HubConfig{SendQueueSize: 1, WriteTimeout: time.Millisecond * 100})
+	server, _ := newServer(t, hub)
+	defer server.Close()
+
+	conn, _, _ := dial(t, server.URL, "", nil)
+	defer conn.Close()
+
+	waitFor(t, func() bool { return hub.Stats().Connections == 1 })
+
+	message := []byte(strings.Repeat("x", 64*1024))
+
+	for i := 0; i < 1000 && hub.Stats().SlowDisconnects == 0; i++ {
+		hub.Broadcast(message)
+	}
+
+	waitFor(t, func() bool { return hub.Stats().Connections == 0 })
+
+	if stats := hub.Stats(); stats.SlowDisconnects != 1 || stats.Dropped == 0 {
+		t.Errorf("Expected the slow connection to be closed but got %+v", stats)
+	}
+}
+
+func TestHubShutdown(t *testing.T) {
+	hub := ws.NewHub(ws.HubConfig{})
+
+	e := echo.New()
+	e.GET("/ws", hub.EchoHandler)
+
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	conn, _, err := dial(t, server.URL+"/ws", "", nil)
+
+	if err != nil {
+		t.Fatalf("Expected to connect but got %v", err)
+	}
+
+	waitFor(t, func() bool { return hub.Stats().Connections == 1 })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+
+	if err := hub.Shutdown(ctx); err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+
+	_, _, err = conn.ReadMessage()
+
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("Expected a going away close but got %v", err)
+	}
+
+	if _, response, err := dial(t, server.URL+"/ws", "", nil); err == nil || response.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected new connections to be refused")
+	}
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package ws
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+/*
+Stats describes a Hub's connections and traffic. Connections, Rooms, and
+Users are current counts, and the rest are totals since the hub was
+created. Dropped counts messages that didn't fit in a connection's
+queue, and SlowDisconnects the connections closed because of it
+*/
+type Stats struct {
+	Connections      int    `json:"connections"`
+	Dropped          uint64 `json:"dropped"`
+	Received         uint64 `json:"received"`
+	Rooms            int    `json:"rooms"`
+	Sent             uint64 `json:"sent"`
+	SlowDisconnects  uint64 `json:"slowDisconnects"`
+	TotalConnections uint64 `json:"totalConnections"`
+	Users            int    `json:"users"`
+}
+
+type statsTracker struct {
+	connections     uint64
+	dropped         uint64
+	received        uint64
+	sent            uint64
+	slowDisconnects uint64
+}
+
+func (t *statsTracker) connect() {
+	atomic.AddUint64(&t.connections, 1)
+}
+
+func (t *statsTracker) drop() {
+	atomic.AddUint64(&t.dropped, 1)
+}
+
+func (t *statsTracker) receive() {
+	atomic.AddUint64(&t.received, 1)
+}
+
+func (t *statsTracker) send() {
+	atomic.AddUint64(&t.sent, 1)
+}
+
+func (t *statsTracker) slow() {
+	atomic.AddUint64(&t.slowDisconnects, 1)
+}
+
+/*
+Stats returns the hub's current connection counts and message totals
+*/
+func (h *Hub) Stats() Stats {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	return Stats{
+		Connections:      len(h.conns),
+		Dropped:          atomic.LoadUint64(&h.stats.dropped),
+		Received:         atomic.LoadUint64(&h.stats.received),
+		Rooms:            len(h.rooms),
+		Sent:             atomic.LoadUint64(&h.stats.sent),
+		SlowDisconnects:  atomic.LoadUint64(&h.stats.slowDisconnects),
+		TotalConnections: atomic.LoadUint64(&h.stats.connections),
+		Users:            len(h.users),
+	}
+}
+
+/*
+Name returns the name the hub's stats are reported under
+*/
+func (h *Hub) Name() string {
+	return h.config.Name
+}
+
+/*
+Collect returns the hub's stats in the form serverstats collectors
+report, so a Hub can be passed to RegisterCollector
+*/
+func (h *Hub) Collect(ctx context.Context) map[string]interface{} {
+	stats := h.Stats()
+
+	return map[string]interface{}{
+		"connections":      stats.Connections,
+		"dropped":          stats.Dropped,
+		"received":         stats.Received,
+		"rooms":            stats.Rooms,
+		"sent":             stats.Sent,
+		"slowDisconnects":  stats.SlowDisconnects,
+		"totalConnections": stats.TotalConnections,
+		"users":            stats.Users,
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package xlsx
+
+import (
+	"database/sql/driver"
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/csvkit"
+)
+
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+/*
+cellValue turns a field into what a cell holds: a string, bool,
+float64, or time.Time. It returns false for empty cells, such as nil
+pointers, zero times, and NaN, which Excel can't store
+*/
+func cellValue(value reflect.Value, column csvkit.Column) (interface{}, bool) {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil, false
+		}
+
+		value = value.Elem()
+	}
+
+	if t, ok := value.Interface().(time.Time); ok {
+		return t, !t.IsZero()
+	}
+
+	switch v := value.Interface().(type) {
+	case driver.Valuer:
+		inner, err := v.Value()
+
+		if err != nil || inner == nil {
+			return nil, false
+		}
+
+		return cellValue(reflect.ValueOf(inner), column)
+
+	case encoding.TextMarshaler, fmt.Stringer:
+		return csvkit.FormatValue(value, column), true
+	}
+
+	switch value.Kind() {
+	case reflect.Bool:
+		return value.Bool(), true
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+
+	case reflect.Float32, reflect.Float64:
+		f := value.Float()
+		return f, !math.IsNaN(f) && !math.IsInf(f, 0)
+
+	case reflect.String:
+		return value.String(), value.Len() > 0
+	}
+
+	return csvkit.FormatValue(value, column), true
+}
+
+/*
+excelDate converts a time to Excel's serial date, the days since
+December 30, 1899. Excel has no time zones, so the wall clock time is
+kept as is
+*/
+func excelDate(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return float64(wall.Sub(excelEpoch)) / float64(24*time.Hour)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
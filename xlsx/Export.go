@@ -0,0 +1,59 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package xlsx
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/ResurgenceIT/kit/v6/csvkit"
+	"github.com/labstack/echo/v4"
+)
+
+/*
+ContentType is the media type of .xlsx files
+*/
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+/*
+Export streams a spreadsheet download. It sets the content type and an
+attachment Content-Disposition for filename, calls fn to write the
+rows, and finishes the workbook. Once rows are sent the status can't
+change, so errors from fn are only returned, leaving the client with
+an incomplete file
+*/
+func Export(w http.ResponseWriter, filename string, model interface{}, config WriterConfig, fn func(writer *Writer) error) error {
+	if _, err := csvkit.Columns(model); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.WriteHeader(http.StatusOK)
+
+	writer, err := NewWriter(w, model, config)
+
+	if err == nil {
+		err = fn(writer)
+	}
+
+	if err == nil {
+		err = writer.Close()
+	}
+
+	if err != nil {
+		return fmt.Errorf("error exporting %s: %w", filename, err)
+	}
+
+	return nil
+}
+
+/*
+EchoExport streams a spreadsheet download from an Echo handler
+*/
+func EchoExport(ctx echo.Context, filename string, model interface{}, config WriterConfig, fn func(writer *Writer) error) error {
+	return Export(ctx.Response(), filename, model, config, fn)
+}
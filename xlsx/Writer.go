@@ -0,0 +1,318 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/csvkit"
+)
+
+var (
+	ErrClosed    = errors.New("writer is closed")
+	ErrWrongType = errors.New("record is not the model's type")
+)
+
+var decimalsPattern = regexp.MustCompile(`%[^a-z]*\.(\d+)f`)
+
+/*
+Writer streams structs into a single sheet .xlsx workbook. Columns come
+from the same struct tags as the csvkit package. The header row is
+bold, shaded, and frozen, column widths come from the width tag option
+or the header, and numbers and dates are written as real values with
+number formats, so they sort and sum in Excel. Rows go straight to the
+underlying writer; call Close to finish the file
+*/
+type Writer struct {
+	buffered  *bufio.Writer
+	closed    bool
+	columns   []csvkit.Column
+	modelType reflect.Type
+	row       int
+	styles    []int
+	zip       *zip.Writer
+}
+
+/*
+NewWriter creates a Writer for records of model's type and writes the
+workbook up to the header row
+*/
+func NewWriter(w io.Writer, model interface{}, config WriterConfig) (*Writer, error) {
+	var (
+		err   error
+		sheet io.Writer
+	)
+
+	columns, err := csvkit.Columns(model)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if config.SheetName == "" {
+		config.SheetName = "Sheet1"
+	}
+
+	result := &Writer{
+		columns:   columns,
+		modelType: reflect.Indirect(reflect.ValueOf(model)).Type(),
+		styles:    make([]int, len(columns)),
+		zip:       zip.NewWriter(w),
+	}
+
+	numberFormats := []string{}
+
+	for index, column := range columns {
+		format, ok := config.NumberFormats[column.Header]
+
+		if !ok {
+			format = numberFormat(column)
+		}
+
+		if format == "" {
+			continue
+		}
+
+		position := indexOf(numberFormats, format)
+
+		if position < 0 {
+			numberFormats = append(numberFormats, format)
+			position = len(numberFormats) - 1
+		}
+
+		result.styles[index] = position + 2
+	}
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{name: "[Content_Types].xml", content: contentTypesXML},
+		{name: "_rels/.rels", content: rootRelsXML},
+		{name: "xl/workbook.xml", content: workbookXML(config.SheetName)},
+		{name: "xl/_rels/workbook.xml.rels", content: workbookRelsXML},
+		{name: "xl/styles.xml", content: stylesXML(numberFormats)},
+	}
+
+	for _, part := range parts {
+		if err = result.writePart(part.name, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	if sheet, err = result.zip.Create("xl/worksheets/sheet1.xml"); err != nil {
+		return nil, fmt.Errorf("error creating sheet: %w", err)
+	}
+
+	result.buffered = bufio.NewWriter(sheet)
+
+	if err = result.writeSheetStart(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+/*
+Write writes one record, a struct of the model's type or a pointer to
+one
+*/
+func (w *Writer) Write(record interface{}) error {
+	if w.closed {
+		return ErrClosed
+	}
+
+	value := reflect.ValueOf(record)
+
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	if value.Type() != w.modelType {
+		return fmt.Errorf("%w: %s", ErrWrongType, value.Type())
+	}
+
+	w.row++
+	fmt.Fprintf(w.buffered, `<row r="%d">`, w.row)
+
+	for index, column := range w.columns {
+		w.writeCell(index, value.FieldByIndex(column.Index), column)
+	}
+
+	_, err := w.buffered.WriteString(`</row>`)
+	return err
+}
+
+/*
+WriteAll writes every record in a slice
+*/
+func (w *Writer) WriteAll(records interface{}) error {
+	value := reflect.ValueOf(records)
+
+	if value.Kind() != reflect.Slice {
+		return fmt.Errorf("%w: %s", ErrWrongType, value.Type())
+	}
+
+	for index := 0; index < value.Len(); index++ {
+		if err := w.Write(value.Index(index).Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+Close finishes the sheet and the workbook. It doesn't close the
+underlying writer
+*/
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+
+	w.closed = true
+
+	fmt.Fprintf(w.buffered, `</sheetData><autoFilter ref="A1:%s"/></worksheet>`, cellName(len(w.columns)-1, w.row))
+
+	if err := w.buffered.Flush(); err != nil {
+		return fmt.Errorf("error writing sheet: %w", err)
+	}
+
+	if err := w.zip.Close(); err != nil {
+		return fmt.Errorf("error finishing workbook: %w", err)
+	}
+
+	return nil
+}
+
+func (w *Writer) writePart(name, content string) error {
+	part, err := w.zip.Create(name)
+
+	if err == nil {
+		_, err = io.WriteString(part, content)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error writing %s: %w", name, err)
+	}
+
+	return nil
+}
+
+func (w *Writer) writeSheetStart() error {
+	w.buffered.WriteString(xmlHeader + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	w.buffered.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	w.buffered.WriteString(`<cols>`)
+
+	for index, column := range w.columns {
+		width := column.Width
+
+		if width <= 0 {
+			width = len(column.Header) + 4
+
+			if width < 10 {
+				width = 10
+			}
+		}
+
+		fmt.Fprintf(w.buffered, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, index+1, index+1, width)
+	}
+
+	w.buffered.WriteString(`</cols><sheetData>`)
+
+	w.row++
+	fmt.Fprintf(w.buffered, `<row r="%d">`, w.row)
+
+	for index, column := range w.columns {
+		fmt.Fprintf(w.buffered, `<c r="%s" s="1" t="inlineStr"><is><t>%s</t></is></c>`, cellName(index, w.row), escape(column.Header))
+	}
+
+	_, err := w.buffered.WriteString(`</row>`)
+	return err
+}
+
+func (w *Writer) writeCell(index int, value reflect.Value, column csvkit.Column) {
+	name := cellName(index, w.row)
+	cell, ok := cellValue(value, column)
+
+	if !ok {
+		return
+	}
+
+	switch v := cell.(type) {
+	case string:
+		fmt.Fprintf(w.buffered, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, name, escape(v))
+
+	case bool:
+		b := 0
+
+		if v {
+			b = 1
+		}
+
+		fmt.Fprintf(w.buffered, `<c r="%s" t="b"><v>%d</v></c>`, name, b)
+
+	case time.Time:
+		fmt.Fprintf(w.buffered, `<c r="%s" s="%d"><v>%s</v></c>`, name, w.styles[index], formatFloat(excelDate(v)))
+
+	case float64:
+		fmt.Fprintf(w.buffered, `<c r="%s" s="%d"><v>%s</v></c>`, name, w.styles[index], formatFloat(v))
+	}
+}
+
+/*
+numberFormat picks an Excel number format for a column from its type
+and format tag: dates and times for time.Time, and decimal places for
+floats formatted like %.2f
+*/
+func numberFormat(column csvkit.Column) string {
+	t := column.Type
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == reflect.TypeOf(time.Time{}) {
+		layout := column.Format
+
+		if layout == "" || strings.Contains(layout, "15") || strings.Contains(layout, "03") || strings.Contains(layout, ":04") {
+			return "yyyy-mm-dd hh:mm:ss"
+		}
+
+		return "yyyy-mm-dd"
+	}
+
+	if match := decimalsPattern.FindStringSubmatch(column.Format); match != nil {
+		decimals := 0
+		fmt.Sscanf(match[1], "%d", &decimals)
+
+		if decimals == 0 {
+			return "#,##0"
+		}
+
+		return "#,##0." + strings.Repeat("0", decimals)
+	}
+
+	return ""
+}
+
+func indexOf(list []string, s string) int {
+	for index, item := range list {
+		if item == s {
+			return index
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,16 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package xlsx
+
+/*
+WriterConfig configures a Writer. SheetName defaults to "Sheet1".
+NumberFormats sets Excel number formats by column header, such as
+"$#,##0.00" for a Price column, overriding the format picked from the
+column's type and tag
+*/
+type WriterConfig struct {
+	NumberFormats map[string]string
+	SheetName     string
+}
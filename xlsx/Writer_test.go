@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) 2021. App Nerds LLC. All rights reserved
+ */
+
+package xlsx_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ResurgenceIT/kit/v6/xlsx"
+)
+
+type invoice struct {
+	Number   string     `csv:"Invoice #,width=14"`
+	Customer string     `csv:"Customer"`
+	Total    float64    `csv:"Total,format=%.2f"`
+	Quantity int        `csv:"Quantity"`
+	Paid     bool       `csv:"Paid"`
+	Issued   time.Time  `csv:"Issued,format=2006-01-02"`
+	PaidAt   *time.Time `csv:"Paid At"`
+	Internal string     `csv:"-"`
+}
+
+func readParts(t *testing.T, b []byte) map[string]string {
+	reader, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+
+	if err != nil {
+		t.Fatalf("Expected a zip file but got %v", err)
+	}
+
+	result := map[string]string{}
+
+	for _, file := range reader.File {
+		rc, _ := file.Open()
+		content, _ := ioutil.ReadAll(rc)
+		_ = rc.Close()
+
+		decoder := xml.NewDecoder(bytes.NewReader(content))
+
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("Expected %s to be well formed XML but got %v", file.Name, err)
+			}
+		}
+
+		result[file.Name] = string(content)
+	}
+
+	return result
+}
+
+func TestWriter(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	writer, err := xlsx.NewWriter(buffer, invoice{}, xlsx.WriterConfig{
+		NumberFormats: map[string]string{"Total": "$#,##0.00"},
+		SheetName:     "Invoices & Credits",
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	invoices := []invoice{
+		{Number: "INV-1", Customer: "Smith & <Sons>", Total: 1234.5, Quantity: 3, Paid: true, Issued: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Number: "INV-2", Customer: "Jones", Total: 10},
+	}
+
+	if err = writer.WriteAll(invoices); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	if err = writer.Close(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	parts := readParts(t, buffer.Bytes())
+	sheet := parts["xl/worksheets/sheet1.xml"]
+
+	expected := []struct {
+		part    string
+		content string
+	}{
+		{part: "xl/workbook.xml", content: `name="Invoices &amp; Credits"`},
+		{part: "xl/styles.xml", content: `formatCode="$#,##0.00"`},
+		{part: "xl/styles.xml", content: `formatCode="yyyy-mm-dd"`},
+		{part: "xl/styles.xml", content: `formatCode="yyyy-mm-dd hh:mm:ss"`},
+		{part: "xl/worksheets/sheet1.xml", content: `state="frozen"`},
+		{part: "xl/worksheets/sheet1.xml", content: `<col min="1" max="1" width="14" customWidth="1"/>`},
+		{part: "xl/worksheets/sheet1.xml", content: `<c r="A1" s="1" t="inlineStr"><is><t>Invoice #</t></is></c>`},
+		{part: "xl/worksheets/sheet1.xml", content: `<c r="B2" t="inlineStr"><is><t xml:space="preserve">Smith &amp; &lt;Sons&gt;</t></is></c>`},
+		{part: "xl/worksheets/sheet1.xml", content: `<c r="C2" s="2"><v>1234.5</v></c>`},
+		{part: "xl/worksheets/sheet1.xml", content: `<c r="D2" s="0"><v>3</v></c>`},
+		{part: "xl/worksheets/sheet1.xml", content: `<c r="E2" t="b"><v>1</v></c>`},
+		{part: "xl/worksheets/sheet1.xml", content: `<c r="F2" s="3"><v>44197</v></c>`},
+		{part: "xl/worksheets/sheet1.xml", content: `<autoFilter ref="A1:G3"/>`},
+	}
+
+	for _, test := range expected {
+		if !strings.Contains(parts[test.part], test.content) {
+			t.Errorf("Expected %s to contain %s but got\n%s", test.part, test.content, parts[test.part])
+		}
+	}
+
+	if strings.Contains(sheet, `r="F3"`) || strings.Contains(sheet, `r="G2"`) {
+		t.Errorf("Expected empty cells to be left out but got\n%s", sheet)
+	}
+}
+
+func TestExport(t *testing.T) {
+	recorder := httptest.NewRecorder()
+
+	err := xlsx.Export(recorder, "invoices.xlsx", invoice{}, xlsx.WriterConfig{}, func(writer *xlsx.Writer) error {
+		return writer.Write(&invoice{Number: "INV-1"})
+	})
+
+	if err != nil || recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != xlsx.ContentType {
+		t.Fatalf("Expected a spreadsheet but got %d %v, %v", recorder.Code, recorder.Header(), err)
+	}
+
+	if sheet := readParts(t, recorder.Body.Bytes())["xl/worksheets/sheet1.xml"]; !strings.Contains(sheet, "INV-1") {
+		t.Errorf("Expected the invoice in the sheet but got\n%s", sheet)
+	}
+}